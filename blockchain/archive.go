@@ -0,0 +1,225 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/objectstore"
+)
+
+// Interval at which the finalized blocks are checked for offloading
+const coldArchiveInterval = 10 * time.Second
+
+// Max number of blocks offloaded in one round, so that a node enabling the cold archive catches up
+// gradually
+const coldArchiveBlocksPerRound = 1000
+
+// Key of the height up to which the blocks have been offloaded
+var coldArchiveCursorKey = common.Bytes("coldarchive/cursor")
+
+// ColdArchiveIndexEntry records where the transactions of an offloaded block are stored.
+type ColdArchiveIndexEntry struct {
+	ObjectKey string
+	NumTxs    uint64
+}
+
+func coldArchiveIndexKey(hash common.Hash) common.Bytes {
+	return append(common.Bytes("coldarchive/"), hash[:]...)
+}
+
+// SetColdStore sets the object store where the transactions of old blocks are offloaded. The
+// offloaded transactions are fetched on demand by FindBlock and FindBlocksByHeight.
+func (ch *Chain) SetColdStore(coldStore objectstore.ObjectStore, prefix string) {
+	ch.coldStore = coldStore
+	ch.coldStorePrefix = prefix
+}
+
+// IsOffloaded returns whether the transactions of the block have been offloaded.
+func (ch *Chain) IsOffloaded(hash common.Hash) bool {
+	entry := ColdArchiveIndexEntry{}
+	return ch.store.Get(coldArchiveIndexKey(hash), &entry) == nil
+}
+
+// OffloadBlock uploads the transactions of the block to the cold store, and removes them from the
+// local store. The block header and status stay local.
+func (ch *Chain) OffloadBlock(hash common.Hash) error {
+	if ch.coldStore == nil {
+		return errors.New("Cold store is not set")
+	}
+
+	ch.mu.RLock()
+	block, err := ch.findBlock(hash)
+	ch.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if len(block.Txs) == 0 {
+		return nil
+	}
+
+	// Upload without holding the lock, the transactions of a block never change.
+	data, err := rlp.EncodeToBytes(block.Txs)
+	if err != nil {
+		return err
+	}
+	objectKey := fmt.Sprintf("%v%v/blocks/%v", ch.coldStorePrefix, ch.ChainID, hash.Hex())
+	if err := ch.coldStore.Put(objectKey, data); err != nil {
+		return errors.Wrap(err, "Failed to upload block")
+	}
+
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	block, err = ch.findBlock(hash)
+	if err != nil {
+		return err
+	}
+	entry := ColdArchiveIndexEntry{
+		ObjectKey: objectKey,
+		NumTxs:    uint64(len(block.Txs)),
+	}
+	if err := ch.store.Put(coldArchiveIndexKey(hash), entry); err != nil {
+		return err
+	}
+	block.Txs = nil
+	return ch.saveBlock(block)
+}
+
+// restoreOffloadedTxs fetches the transactions of the block from the cold store if they have been
+// offloaded. The transactions are verified against the tx root in the block header.
+func (ch *Chain) restoreOffloadedTxs(block *core.ExtendedBlock) error {
+	if ch.coldStore == nil || len(block.Txs) > 0 || block.TxHash == core.EmptyRootHash || block.TxHash.IsEmpty() {
+		return nil
+	}
+	entry := ColdArchiveIndexEntry{}
+	if err := ch.store.Get(coldArchiveIndexKey(block.Hash()), &entry); err != nil {
+		return nil
+	}
+
+	data, err := ch.coldStore.Get(entry.ObjectKey)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to fetch the transactions of block %v", block.Hash().Hex())
+	}
+	txs := []common.Bytes{}
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		return errors.Wrapf(err, "Failed to decode the transactions of block %v", block.Hash().Hex())
+	}
+	restored := core.NewBlock()
+	restored.AddTxs(txs)
+	if restored.TxHash != block.TxHash || uint64(len(txs)) != entry.NumTxs {
+		return fmt.Errorf("Transactions of block %v fetched from the cold store do not match the tx root", block.Hash().Hex())
+	}
+	block.Txs = txs
+	return nil
+}
+
+// ColdArchiver offloads the transactions of the finalized blocks older than the retained blocks
+// to the cold store of the chain.
+type ColdArchiver struct {
+	chain          *Chain
+	finality       core.FinalityProvider
+	retainedBlocks uint64
+
+	lastHeight uint64
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewColdArchiver creates a new instance of ColdArchiver.
+func NewColdArchiver(chain *Chain, finality core.FinalityProvider, retainedBlocks uint64) *ColdArchiver {
+	return &ColdArchiver{
+		chain:          chain,
+		finality:       finality,
+		retainedBlocks: retainedBlocks,
+		wg:             &sync.WaitGroup{},
+	}
+}
+
+// Start creates the main goroutine.
+func (ca *ColdArchiver) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	ca.ctx = c
+	ca.cancel = cancel
+
+	if err := ca.chain.store.Get(coldArchiveCursorKey, &ca.lastHeight); err != nil {
+		ca.lastHeight = ca.chain.Root().Height
+	}
+
+	ca.wg.Add(1)
+	go ca.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (ca *ColdArchiver) Stop() {
+	ca.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (ca *ColdArchiver) Wait() {
+	ca.wg.Wait()
+}
+
+func (ca *ColdArchiver) mainLoop() {
+	defer ca.wg.Done()
+
+	ticker := time.NewTicker(coldArchiveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ca.ctx.Done():
+			return
+		case <-ticker.C:
+			ca.offloadFinalizedBlocks()
+		}
+	}
+}
+
+// offloadFinalizedBlocks offloads the finalized blocks which fell out of the retained blocks since
+// the last call. A failed upload is retried in the next round.
+func (ca *ColdArchiver) offloadFinalizedBlocks() {
+	lastFinalizedHeight := ca.finality.GetLastFinalizedBlock().Height
+	if lastFinalizedHeight <= ca.retainedBlocks {
+		return
+	}
+	end := lastFinalizedHeight - ca.retainedBlocks
+	if end > ca.lastHeight+coldArchiveBlocksPerRound {
+		end = ca.lastHeight + coldArchiveBlocksPerRound
+	}
+
+	for height := ca.lastHeight + 1; height <= end; height++ {
+		if ca.ctx.Err() != nil {
+			return
+		}
+		for _, block := range ca.chain.findBlocksByHeightUnrestored(height) {
+			if !block.Status.IsFinalized() {
+				continue
+			}
+			if err := ca.chain.OffloadBlock(block.Hash()); err != nil {
+				logger.WithFields(log.Fields{"height": height, "error": err}).Error("Failed to offload block")
+				return
+			}
+		}
+		ca.lastHeight = height
+		if err := ca.chain.store.Put(coldArchiveCursorKey, ca.lastHeight); err != nil {
+			logger.WithFields(log.Fields{"error": err}).Error("Failed to save cold archive cursor")
+		}
+	}
+}
+
+// findBlocksByHeightUnrestored is FindBlocksByHeight without fetching the offloaded transactions.
+func (ch *Chain) findBlocksByHeightUnrestored(height uint64) []*core.ExtendedBlock {
+	ch.mu.RLock()
+	defer ch.mu.RUnlock()
+	return ch.findBlocksByHeight(height)
+}
@@ -0,0 +1,115 @@
+package blockchain
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/objectstore"
+)
+
+type lastFinalizedEngine struct {
+	core.FinalityProvider
+	lastFinalized *core.ExtendedBlock
+}
+
+func (e *lastFinalizedEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return e.lastFinalized
+}
+
+func TestOffloadBlock(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "coldarchive")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	coldStore, err := objectstore.NewFileStore(dir)
+	require.Nil(err)
+
+	chain := CreateTestChain()
+	chain.SetColdStore(coldStore, "archive/")
+
+	txs := []common.Bytes{common.Bytes("tx1"), common.Bytes("tx2")}
+	block := core.CreateTestBlock("offload_b1", "a0")
+	block.AddTxs(txs)
+	eb, err := chain.AddBlock(block)
+	require.Nil(err)
+	chain.AddTxsToIndex(eb, true)
+
+	require.Nil(chain.OffloadBlock(block.Hash()))
+	assert.True(chain.IsOffloaded(block.Hash()))
+
+	// The transactions are no longer stored locally
+	raw, err := chain.findBlock(block.Hash())
+	require.Nil(err)
+	assert.Equal(0, len(raw.Txs))
+
+	// but are restored transparently
+	found, err := chain.FindBlock(block.Hash())
+	require.Nil(err)
+	assert.Equal(txs, found.Txs)
+	blocks := chain.FindBlocksByHeight(block.Height)
+	require.Equal(1, len(blocks))
+	assert.Equal(txs, blocks[0].Txs)
+	tx, _, ok := chain.FindTxByHash(crypto.Keccak256Hash(txs[1]))
+	assert.True(ok)
+	assert.Equal(txs[1], tx)
+
+	// Status updates keep the transactions offloaded
+	chain.MarkBlockValid(block.Hash())
+	raw, err = chain.findBlock(block.Hash())
+	require.Nil(err)
+	assert.Equal(0, len(raw.Txs))
+	assert.True(raw.Status.IsValid())
+
+	// Tampered transactions are rejected
+	require.Nil(coldStore.Put("archive/testchain/blocks/"+block.Hash().Hex(), []byte{0xc0}))
+	_, err = chain.FindBlock(block.Hash())
+	assert.NotNil(err)
+}
+
+func TestColdArchiver(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "coldarchive")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	coldStore, err := objectstore.NewFileStore(dir)
+	require.Nil(err)
+
+	chain := CreateTestChain()
+	chain.SetColdStore(coldStore, "")
+
+	parent := "a0"
+	hashes := []common.Hash{}
+	for _, name := range []string{"archiver_b1", "archiver_b2", "archiver_b3", "archiver_b4"} {
+		block := core.CreateTestBlock(name, parent)
+		block.AddTxs([]common.Bytes{common.Bytes(name)})
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+		hashes = append(hashes, block.Hash())
+		parent = name
+	}
+	chain.FinalizePreviousBlocks(hashes[3])
+	lastFinalized, err := chain.FindBlock(hashes[3])
+	require.Nil(err)
+
+	archiver := NewColdArchiver(chain, &lastFinalizedEngine{lastFinalized: lastFinalized}, 2)
+	archiver.ctx = context.Background()
+	archiver.lastHeight = chain.Root().Height
+	archiver.offloadFinalizedBlocks()
+
+	assert.True(chain.IsOffloaded(hashes[0]))
+	assert.True(chain.IsOffloaded(hashes[1]))
+	assert.False(chain.IsOffloaded(hashes[2]))
+	assert.False(chain.IsOffloaded(hashes[3]))
+	assert.Equal(lastFinalized.Height-2, archiver.lastHeight)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/store"
+	"github.com/thetatoken/theta/store/objectstore"
 )
 
 const maxDistance = 200
@@ -24,6 +25,9 @@ type Chain struct {
 	ChainID string
 	root    common.Hash
 
+	coldStore       objectstore.ObjectStore
+	coldStorePrefix string
+
 	mu *sync.RWMutex
 }
 
@@ -149,8 +153,18 @@ func (ch *Chain) AddBlockByHeightIndex(height uint64, block common.Hash) {
 // FindBlocksByHeight tries to retrieve blocks by height.
 func (ch *Chain) FindBlocksByHeight(height uint64) []*core.ExtendedBlock {
 	ch.mu.RLock()
-	defer ch.mu.RUnlock()
-	return ch.findBlocksByHeight(height)
+	blocks := ch.findBlocksByHeight(height)
+	ch.mu.RUnlock()
+
+	ret := []*core.ExtendedBlock{}
+	for _, block := range blocks {
+		if err := ch.restoreOffloadedTxs(block); err != nil {
+			logger.WithFields(log.Fields{"error": err}).Error("Failed to restore offloaded transactions")
+			continue
+		}
+		ret = append(ret, block)
+	}
+	return ret
 }
 
 // findBlocksByHeight is the non-locking version of FindBlockByHeight.
@@ -263,14 +277,22 @@ func (ch *Chain) IsOrphan(block *core.Block) bool {
 // saveBlock updates a previously stored block.
 func (ch *Chain) saveBlock(block *core.ExtendedBlock) error {
 	hash := block.Hash()
-	return ch.store.Put(hash[:], *block)
+	return ch.store.Put(hash[:], block)
 }
 
-// FindBlock tries to retrieve a block by hash.
+// FindBlock tries to retrieve a block by hash. The offloaded transactions are fetched from the
+// cold store without holding the lock.
 func (ch *Chain) FindBlock(hash common.Hash) (*core.ExtendedBlock, error) {
 	ch.mu.RLock()
-	defer ch.mu.RUnlock()
-	return ch.findBlock(hash)
+	block, err := ch.findBlock(hash)
+	ch.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.restoreOffloadedTxs(block); err != nil {
+		return nil, err
+	}
+	return block, nil
 }
 
 // findBlock is the non-locking version of FindBlock.
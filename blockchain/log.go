@@ -0,0 +1,141 @@
+package blockchain
+
+import (
+	"encoding/binary"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/store"
+)
+
+// LogIndexBucketSize is the number of heights covered by a log index entry.
+const LogIndexBucketSize = 1000
+
+// logAddressIndexKey constructs the DB key of the blocks with logs emitted by the address, in the
+// given height bucket.
+func logAddressIndexKey(address common.Address, bucket uint64) common.Bytes {
+	return logIndexKey(common.Bytes("logaddr/"), address[:], bucket)
+}
+
+// logTopicIndexKey constructs the DB key of the blocks with logs having the topic at any position,
+// in the given height bucket.
+func logTopicIndexKey(topic common.Hash, bucket uint64) common.Bytes {
+	return logIndexKey(common.Bytes("logtopic/"), topic[:], bucket)
+}
+
+func logIndexKey(prefix common.Bytes, item []byte, bucket uint64) common.Bytes {
+	key := append(prefix, item...)
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], bucket)
+	return append(key, b[:]...)
+}
+
+// blockBloomKey constructs the DB key for the log bloom of the given block.
+func blockBloomKey(blockHash common.Hash) common.Bytes {
+	return append(common.Bytes("bloom/"), blockHash[:]...)
+}
+
+// LogIndexBlock identifies a block in the log index.
+type LogIndexBlock struct {
+	Height uint64
+	Hash   common.Hash
+}
+
+// logIndexEntry is the DB entry holding the blocks of a log index bucket, in the order they were
+// committed.
+type logIndexEntry struct {
+	Blocks []LogIndexBlock
+}
+
+// AddLogsToIndex adds the addresses and topics of the events in the receipts of the given block to
+// the log index, and stores the log bloom of the block.
+func (ch *Chain) AddLogsToIndex(block *core.Block, receipts []*core.TxReceipt) {
+	err := ch.store.Put(blockBloomKey(block.Hash()), core.CreateBloom(receipts))
+	if err != nil {
+		logger.Panic(err)
+	}
+
+	indexed := LogIndexBlock{Height: block.Height, Hash: block.Hash()}
+	bucket := block.Height / LogIndexBucketSize
+	keys := make(map[string]bool)
+	for _, receipt := range receipts {
+		for _, event := range receipt.Events {
+			keys[string(logAddressIndexKey(event.Address, bucket))] = true
+			for _, topic := range event.Topics {
+				keys[string(logTopicIndexKey(topic, bucket))] = true
+			}
+		}
+	}
+	for key := range keys {
+		ch.addToLogIndex(common.Bytes(key), indexed)
+	}
+}
+
+func (ch *Chain) addToLogIndex(key common.Bytes, indexed LogIndexBlock) {
+	entry := &logIndexEntry{}
+	err := ch.store.Get(key, entry)
+	if err != nil && err != store.ErrKeyNotFound {
+		logger.Panic(err)
+	}
+	for _, b := range entry.Blocks {
+		if b.Hash == indexed.Hash {
+			return // the block is re-applied, e.g. on replay
+		}
+	}
+	entry.Blocks = append(entry.Blocks, indexed)
+	err = ch.store.Put(key, entry)
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+// FindBlockBloom looks up the log bloom of the given block.
+func (ch *Chain) FindBlockBloom(blockHash common.Hash) (bloom core.Bloom, founded bool) {
+	err := ch.store.Get(blockBloomKey(blockHash), &bloom)
+	if err != nil {
+		if err != store.ErrKeyNotFound {
+			logger.Error(err)
+		}
+		return core.Bloom{}, false
+	}
+	return bloom, true
+}
+
+// FindLogBlocksByAddress returns the blocks between the given heights, inclusive, with logs emitted
+// by the address. The blocks are not necessarily finalized.
+func (ch *Chain) FindLogBlocksByAddress(address common.Address, fromHeight, toHeight uint64) []LogIndexBlock {
+	return ch.findLogBlocks(func(bucket uint64) common.Bytes {
+		return logAddressIndexKey(address, bucket)
+	}, fromHeight, toHeight)
+}
+
+// FindLogBlocksByTopic returns the blocks between the given heights, inclusive, with logs having
+// the topic at any position. The blocks are not necessarily finalized.
+func (ch *Chain) FindLogBlocksByTopic(topic common.Hash, fromHeight, toHeight uint64) []LogIndexBlock {
+	return ch.findLogBlocks(func(bucket uint64) common.Bytes {
+		return logTopicIndexKey(topic, bucket)
+	}, fromHeight, toHeight)
+}
+
+func (ch *Chain) findLogBlocks(key func(bucket uint64) common.Bytes, fromHeight, toHeight uint64) []LogIndexBlock {
+	blocks := []LogIndexBlock{}
+	if fromHeight > toHeight {
+		return blocks
+	}
+	for bucket := fromHeight / LogIndexBucketSize; bucket <= toHeight/LogIndexBucketSize; bucket++ {
+		entry := &logIndexEntry{}
+		err := ch.store.Get(key(bucket), entry)
+		if err != nil {
+			if err != store.ErrKeyNotFound {
+				logger.Error(err)
+			}
+			continue
+		}
+		for _, b := range entry.Blocks {
+			if b.Height >= fromHeight && b.Height <= toHeight {
+				blocks = append(blocks, b)
+			}
+		}
+	}
+	return blocks
+}
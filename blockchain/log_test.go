@@ -0,0 +1,62 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+func TestLogIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	core.ResetTestBlocks()
+	chain := CreateTestChain()
+
+	contract := common.HexToAddress("0x1234")
+	other := common.HexToAddress("0x5678")
+	transfer := common.HexToHash("0xddf252ad")
+	approval := common.HexToHash("0x8c5be1e5")
+
+	block1 := core.CreateTestBlock("b1", "")
+	block1.Height = LogIndexBucketSize - 1
+	block1.UpdateHash()
+	receipts1 := []*core.TxReceipt{
+		{Events: []core.ReceiptEvent{{Address: contract, Topics: []common.Hash{transfer}}}},
+	}
+	block2 := core.CreateTestBlock("b2", "")
+	block2.Height = LogIndexBucketSize + 1
+	block2.UpdateHash()
+	receipts2 := []*core.TxReceipt{
+		{},
+		{Events: []core.ReceiptEvent{
+			{Address: contract, Topics: []common.Hash{approval}},
+			{Address: other, Topics: []common.Hash{common.HexToHash("0x01"), transfer}},
+		}},
+	}
+
+	chain.AddLogsToIndex(block1, receipts1)
+	chain.AddLogsToIndex(block2, receipts2)
+	chain.AddLogsToIndex(block2, receipts2) // re-applied
+
+	blocks := chain.FindLogBlocksByAddress(contract, 0, 2*LogIndexBucketSize)
+	assert.Equal([]LogIndexBlock{{Height: block1.Height, Hash: block1.Hash()}, {Height: block2.Height, Hash: block2.Hash()}}, blocks)
+	assert.Equal([]LogIndexBlock{{Height: block2.Height, Hash: block2.Hash()}}, chain.FindLogBlocksByAddress(contract, LogIndexBucketSize, 2*LogIndexBucketSize))
+	assert.Equal([]LogIndexBlock{{Height: block1.Height, Hash: block1.Hash()}}, chain.FindLogBlocksByAddress(contract, 0, LogIndexBucketSize))
+	assert.Equal(0, len(chain.FindLogBlocksByAddress(common.HexToAddress("0x9999"), 0, 2*LogIndexBucketSize)))
+	assert.Equal(0, len(chain.FindLogBlocksByAddress(contract, block2.Height, block1.Height)))
+
+	// Topics are indexed regardless of their position
+	assert.Equal(2, len(chain.FindLogBlocksByTopic(transfer, 0, 2*LogIndexBucketSize)))
+	assert.Equal([]LogIndexBlock{{Height: block2.Height, Hash: block2.Hash()}}, chain.FindLogBlocksByTopic(approval, 0, 2*LogIndexBucketSize))
+
+	bloom, found := chain.FindBlockBloom(block2.Hash())
+	assert.True(found)
+	assert.Equal(core.CreateBloom(receipts2), bloom)
+	assert.True(core.BloomLookup(bloom, other))
+	assert.True(core.BloomLookup(bloom, approval))
+
+	_, found = chain.FindBlockBloom(common.HexToHash("0x01"))
+	assert.False(found)
+}
@@ -0,0 +1,61 @@
+package blockchain
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/store"
+)
+
+// receiptsKey constructs the DB key for the receipts of the given block.
+func receiptsKey(blockHash common.Hash) common.Bytes {
+	return append(common.Bytes("rcpt/"), blockHash[:]...)
+}
+
+// blockReceipts is the DB entry holding the receipts of a block.
+type blockReceipts struct {
+	Receipts []*core.TxReceipt
+}
+
+// AddBlockReceipts stores the transaction receipts of the given block.
+func (ch *Chain) AddBlockReceipts(blockHash common.Hash, receipts []*core.TxReceipt) {
+	err := ch.store.Put(receiptsKey(blockHash), blockReceipts{Receipts: receipts})
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+// FindBlockReceipts looks up the transaction receipts of the given block.
+func (ch *Chain) FindBlockReceipts(blockHash common.Hash) (receipts []*core.TxReceipt, founded bool) {
+	entry := &blockReceipts{}
+	err := ch.store.Get(receiptsKey(blockHash), entry)
+	if err != nil {
+		if err != store.ErrKeyNotFound {
+			logger.Error(err)
+		}
+		return nil, false
+	}
+	return entry.Receipts, true
+}
+
+// FindTxReceiptByHash looks up the receipt of the transaction with the given hash, and additionaly
+// returns the containing block, all the receipts of the block and the index of the transaction.
+func (ch *Chain) FindTxReceiptByHash(hash common.Hash) (receipt *core.TxReceipt, block *core.ExtendedBlock, receipts []*core.TxReceipt, index int, founded bool) {
+	txIndexEntry := &TxIndexEntry{}
+	err := ch.store.Get(txIndexKey(hash), txIndexEntry)
+	if err != nil {
+		if err != store.ErrKeyNotFound {
+			logger.Error(err)
+		}
+		return nil, nil, nil, 0, false
+	}
+	receipts, ok := ch.FindBlockReceipts(txIndexEntry.BlockHash)
+	if !ok || txIndexEntry.Index >= uint64(len(receipts)) {
+		return nil, nil, nil, 0, false
+	}
+	block, err = ch.FindBlock(txIndexEntry.BlockHash)
+	if err != nil {
+		return nil, nil, nil, 0, false
+	}
+	index = int(txIndexEntry.Index)
+	return receipts[index], block, receipts, index, true
+}
@@ -60,6 +60,11 @@ func (ch *Chain) FindTxByHash(hash common.Hash) (tx common.Bytes, block *core.Ex
 		if err == store.ErrKeyNotFound {
 			return nil, nil, false
 		}
+		if ch.coldStore != nil {
+			// The transactions may be temporarily unavailable from the cold store
+			logger.Error(err)
+			return nil, nil, false
+		}
 		logger.Panic(err)
 	}
 	return block.Txs[txIndexEntry.Index], block, true
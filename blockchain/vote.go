@@ -1,6 +1,9 @@
 package blockchain
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/core"
 )
@@ -31,3 +34,82 @@ func (ch *Chain) FindVotesByHash(hash common.Hash) *core.VoteSet {
 	ch.store.Get(voteIndexKey(hash), voteSet)
 	return voteSet
 }
+
+// voteTimestampsKey constructs the DB key of the vote timestamps for the given block hash.
+func voteTimestampsKey(hash common.Hash) common.Bytes {
+	return append(common.Bytes("vts/"), hash[:]...)
+}
+
+// VoteTimestamp records when a vote on a block was cast and received.
+type VoteTimestamp struct {
+	Voter      common.Address
+	Epoch      uint64
+	SignedAt   uint64 // Unix time in milliseconds signed by the voter
+	ReceivedAt uint64 // Unix time in milliseconds at which the node processed the vote
+}
+
+type voteTimestamps struct {
+	Timestamps []VoteTimestamp
+}
+
+// AddVoteTimestamp records the timestamp of a vote, which the caller has validated, along with the
+// time it is received. Only the first vote of a voter in an epoch is recorded, so that a vote
+// relayed again does not overwrite the time it was first received.
+func (ch *Chain) AddVoteTimestamp(vote core.Vote, receivedAt time.Time) {
+	if vote.Block.IsEmpty() || vote.Timestamp == nil {
+		return
+	}
+	key := voteTimestampsKey(vote.Block)
+	entry := voteTimestamps{}
+	ch.store.Get(key, &entry)
+	for _, ts := range entry.Timestamps {
+		if ts.Voter == vote.ID && ts.Epoch == vote.Epoch {
+			return
+		}
+	}
+	entry.Timestamps = append(entry.Timestamps, VoteTimestamp{
+		Voter:      vote.ID,
+		Epoch:      vote.Epoch,
+		SignedAt:   vote.Timestamp.Time,
+		ReceivedAt: uint64(receivedAt.UnixNano() / int64(time.Millisecond)),
+	})
+	err := ch.store.Put(key, entry)
+	if err != nil {
+		logger.Panic(err)
+	}
+}
+
+// FindVoteTimestamps returns the timestamps of the votes on the block with the given hash, in the
+// order they were received.
+func (ch *Chain) FindVoteTimestamps(hash common.Hash) []VoteTimestamp {
+	entry := voteTimestamps{}
+	ch.store.Get(voteTimestampsKey(hash), &entry)
+	return entry.Timestamps
+}
+
+// FindFinalizationCertificate returns the votes committing the finalized block with the given hash.
+// They are taken from the HCC of the finalized child, which every finalized block with a finalized
+// child has, and otherwise from the votes received by the node.
+func (ch *Chain) FindFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	block, err := ch.FindBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !block.Status.IsFinalized() {
+		return nil, fmt.Errorf("Block %v is not finalized", hash.Hex())
+	}
+	for _, childHash := range block.Children {
+		child, err := ch.FindBlock(childHash)
+		if err != nil || !child.Status.IsFinalized() {
+			continue
+		}
+		if child.HCC.BlockHash == hash && child.HCC.Votes != nil && !child.HCC.Votes.IsEmpty() {
+			cc := child.HCC.Copy()
+			return &cc, nil
+		}
+	}
+	return &core.CommitCertificate{
+		BlockHash: hash,
+		Votes:     ch.FindVotesByHash(hash),
+	}, nil
+}
@@ -2,6 +2,7 @@ package blockchain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
@@ -48,3 +49,36 @@ func TestVoteIndex(t *testing.T) {
 	assert.Equal(uint64(2), voteSet.Votes()[0].Epoch)
 	assert.Equal(uint64(3), voteSet.Votes()[1].Epoch)
 }
+
+func TestVoteTimestamps(t *testing.T) {
+	assert := assert.New(t)
+
+	chain := CreateTestChain()
+	b1Hash := common.BytesToHash(common.Bytes("b1"))
+	assert.Equal(0, len(chain.FindVoteTimestamps(b1Hash)))
+
+	v1 := core.Vote{
+		Block:     b1Hash,
+		Epoch:     2,
+		ID:        common.HexToAddress("a1"),
+		Timestamp: &core.VoteTimestamp{Time: 1000},
+	}
+	v2 := core.Vote{
+		Block:     b1Hash,
+		Epoch:     2,
+		ID:        common.HexToAddress("a2"),
+		Timestamp: &core.VoteTimestamp{Time: 1500},
+	}
+	// Should not be recorded
+	chain.AddVoteTimestamp(core.Vote{Block: b1Hash, Epoch: 2, ID: common.HexToAddress("a3")}, time.Unix(2, 0))
+
+	chain.AddVoteTimestamp(v2, time.Unix(2, 0))
+	chain.AddVoteTimestamp(v1, time.Unix(3, 0))
+	// The vote relayed again keeps the time it was first received
+	chain.AddVoteTimestamp(v2, time.Unix(4, 0))
+
+	assert.Equal([]VoteTimestamp{
+		{Voter: v2.ID, Epoch: 2, SignedAt: 1500, ReceivedAt: 2000},
+		{Voter: v1.ID, Epoch: 2, SignedAt: 1000, ReceivedAt: 3000},
+	}, chain.FindVoteTimestamps(b1Hash))
+}
@@ -0,0 +1,199 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+)
+
+// fakeNode accepts the transactions in sequence order, and finalizes them after a few status checks
+type fakeNode struct {
+	mu        sync.Mutex
+	sequences map[common.Address]uint64
+	reserves  map[common.Address]int
+	checks    map[common.Hash]int
+	errs      []error
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{
+		sequences: map[common.Address]uint64{},
+		reserves:  map[common.Address]int{},
+		checks:    map[common.Hash]int{},
+	}
+}
+
+func (n *fakeNode) Broadcast(raw common.Bytes) (common.Hash, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var addr common.Address
+	var sequence uint64
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		addr, sequence = tx.Inputs[0].Address, tx.Inputs[0].Sequence
+	case *types.ReserveFundTx:
+		addr, sequence = tx.Source.Address, tx.Source.Sequence
+		n.reserves[addr]++
+	case *types.ServicePaymentTx:
+		addr, sequence = tx.Target.Address, tx.Target.Sequence
+	case *types.SmartContractTx:
+		addr, sequence = tx.From.Address, tx.From.Sequence
+	default:
+		return common.Hash{}, fmt.Errorf("Unexpected transaction %v", tx)
+	}
+	if sequence != n.sequences[addr]+1 {
+		err := fmt.Errorf("Got sequence %v for %v, expected %v", sequence, addr.Hex(), n.sequences[addr]+1)
+		n.errs = append(n.errs, err)
+		return common.Hash{}, err
+	}
+	n.sequences[addr] = sequence
+	return crypto.Keccak256Hash(raw), nil
+}
+
+func (n *fakeNode) TxStatus(hash common.Hash) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.checks[hash]++
+	if n.checks[hash] < 3 {
+		return rpc.TxStatusPending, nil
+	}
+	return rpc.TxStatusFinalized, nil
+}
+
+func (n *fakeNode) Account(addr common.Address) (*types.Account, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return &types.Account{Address: addr, Sequence: n.sequences[addr], ReservedFunds: make([]types.ReservedFund, n.reserves[addr])}, nil
+}
+
+func newAccounts(t *testing.T, num int) []*Account {
+	accounts := []*Account{}
+	for i := 0; i < num; i++ {
+		key, _, err := crypto.GenerateKeyPair()
+		require.Nil(t, err)
+		accounts = append(accounts, NewAccount(key))
+	}
+	return accounts
+}
+
+func TestRunner(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	mix, err := ParseMix("send=4,reserve=2,contract=1")
+	require.Nil(err)
+	node := newFakeNode()
+	runner, err := NewRunner(Config{
+		ChainID:      "bench",
+		Mix:          mix,
+		NumTxs:       70,
+		Concurrency:  4,
+		Timeout:      time.Minute,
+		PollInterval: time.Millisecond,
+		Contract:     common.HexToAddress("0x1"),
+		GasLimit:     100000,
+	}, node, newAccounts(t, 3))
+	require.Nil(err)
+
+	report := runner.Run()
+	assert.Empty(node.errs)
+
+	kinds := map[Kind]KindReport{}
+	for _, kr := range report.Kinds {
+		kinds[kr.Kind] = kr
+	}
+	assert.Equal(70, kinds[KindSend].Submitted+kinds[KindReserve].Submitted+kinds[KindContract].Submitted)
+	assert.True(kinds[KindReserve].Submitted > 0)
+	assert.True(kinds[KindContract].Submitted > 0)
+	assert.Equal(kinds[KindReserve].Finalized, kinds[KindSettle].Submitted)
+	assert.Equal(70+kinds[KindSettle].Submitted, report.Total.Finalized)
+	assert.Equal(0, report.Total.Rejected+report.Total.Abandoned+report.Total.TimedOut)
+	assert.True(report.Total.Latency.P50 > 0)
+
+	// The reserved funds per account stay within the limit, the excess reservations are sent as sends
+	for addr, reserves := range node.reserves {
+		assert.True(reserves <= types.MaximumActiveReservedFundsPerAccount, "%v holds %v reserved funds", addr.Hex(), reserves)
+	}
+}
+
+// rejectingNode rejects every other transaction, leaving gaps the runner needs to recover from
+type rejectingNode struct {
+	*fakeNode
+	count int
+}
+
+func (n *rejectingNode) Broadcast(raw common.Bytes) (common.Hash, error) {
+	n.mu.Lock()
+	n.count++
+	reject := n.count%2 == 0
+	n.mu.Unlock()
+	if reject {
+		return common.Hash{}, errors.New("mempool full")
+	}
+	return n.fakeNode.Broadcast(raw)
+}
+
+func TestRunnerRecoversFromRejections(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	node := &rejectingNode{fakeNode: newFakeNode()}
+	runner, err := NewRunner(Config{
+		ChainID:      "bench",
+		Mix:          Mix{KindSend: 1},
+		NumTxs:       20,
+		Concurrency:  2,
+		Timeout:      time.Minute,
+		PollInterval: time.Millisecond,
+	}, node, newAccounts(t, 2))
+	require.Nil(err)
+
+	report := runner.Run()
+	assert.Empty(node.errs)
+	assert.Equal(10, report.Total.Finalized)
+	assert.Equal(10, report.Total.Rejected)
+	assert.Equal([]string{"mempool full"}, report.Kinds[0].Errors[:1])
+}
+
+func TestComputePercentiles(t *testing.T) {
+	assert := assert.New(t)
+
+	latencies := []time.Duration{}
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	p := ComputePercentiles(latencies)
+	assert.Equal(Percentiles{Min: 1, Mean: 50.5, P50: 50, P90: 90, P99: 99, Max: 100}, p)
+
+	assert.Equal(Percentiles{Min: 7, Mean: 7, P50: 7, P90: 7, P99: 7, Max: 7}, ComputePercentiles([]time.Duration{7 * time.Millisecond}))
+	assert.Equal(Percentiles{}, ComputePercentiles(nil))
+}
+
+func TestParseMix(t *testing.T) {
+	assert := assert.New(t)
+
+	mix, err := ParseMix("send=8, reserve=1,contract=0")
+	assert.Nil(err)
+	assert.Equal(Mix{KindSend: 8, KindReserve: 1, KindContract: 0}, mix)
+	assert.Equal(KindSend, mix.pick(7))
+	assert.Equal(KindReserve, mix.pick(8))
+
+	for _, str := range []string{"send", "settle=1", "send=-1", "send=0", "send=x"} {
+		_, err := ParseMix(str)
+		assert.NotNil(err, str)
+	}
+}
@@ -0,0 +1,79 @@
+package bench
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Node is the node the load is generated against
+type Node interface {
+	// Broadcast submits the raw transaction without waiting for it to be included in a block
+	Broadcast(raw common.Bytes) (common.Hash, error)
+
+	// TxStatus returns the status of the transaction, one of the rpc.TxStatus values
+	TxStatus(hash common.Hash) (string, error)
+
+	// Account returns the account, including the effects of the transactions in the mempool
+	Account(addr common.Address) (*types.Account, error)
+}
+
+// RPCNode is a node reached through its RPC endpoint
+type RPCNode struct {
+	client *jsonrpc.RPCClient
+}
+
+var _ Node = (*RPCNode)(nil)
+
+// NewRPCNode creates a new instance of RPCNode
+func NewRPCNode(endpoint string) *RPCNode {
+	return &RPCNode{client: jsonrpc.NewRPCClient(endpoint)}
+}
+
+// Broadcast implements the Node interface
+func (n *RPCNode) Broadcast(raw common.Bytes) (common.Hash, error) {
+	result := &rpc.BroadcastRawTransactionAsyncResult{}
+	err := n.call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionAsyncArgs{TxBytes: hex.EncodeToString(raw)}, result)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.HexToHash(result.TxHash), nil
+}
+
+// TxStatus implements the Node interface
+func (n *RPCNode) TxStatus(hash common.Hash) (string, error) {
+	// The transaction itself is not decoded, since the types.Tx interface cannot be unmarshaled
+	result := &struct {
+		Status string `json:"status"`
+	}{}
+	err := n.call("theta.GetTransaction", rpc.GetTransactionArgs{Hash: hash.Hex()}, result)
+	if err != nil {
+		return "", err
+	}
+	return result.Status, nil
+}
+
+// Account implements the Node interface
+func (n *RPCNode) Account(addr common.Address) (*types.Account, error) {
+	account := &types.Account{}
+	err := n.call("theta.GetAccount", rpc.GetAccountArgs{Address: addr.Hex(), Preview: true}, account)
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (n *RPCNode) call(method string, args interface{}, result interface{}) error {
+	res, err := n.client.Call(method, args)
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return fmt.Errorf("%v failed: %v", method, res.Error)
+	}
+	return res.GetObject(result)
+}
@@ -0,0 +1,197 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Percentiles of the latencies to finalization, in milliseconds
+type Percentiles struct {
+	Min  float64 `json:"min_ms"`
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// KindReport sums up the transactions of a kind
+type KindReport struct {
+	Kind      Kind        `json:"kind"`
+	Submitted int         `json:"submitted"` // accepted by the node
+	Rejected  int         `json:"rejected"`  // failed to be built or broadcast
+	Finalized int         `json:"finalized"` // included in a finalized block
+	Abandoned int         `json:"abandoned"` // dropped by the mempool
+	TimedOut  int         `json:"timed_out"` // not finalized before the timeout
+	Latency   Percentiles `json:"latency"`   // from submission to finalization
+	Errors    []string    `json:"errors"`    // first few rejection errors
+}
+
+// Report is the outcome of a benchmark run
+type Report struct {
+	Elapsed    float64      `json:"elapsed_s"`
+	Throughput float64      `json:"finalized_tx_per_s"`
+	Total      KindReport   `json:"total"`
+	Kinds      []KindReport `json:"kinds"`
+}
+
+// maxReportedErrors bounds the number of rejection errors kept per kind
+const maxReportedErrors = 5
+
+type kindStats struct {
+	submitted int
+	rejected  int
+	finalized int
+	abandoned int
+	timedOut  int
+	latencies []time.Duration
+	errors    []string
+}
+
+// stats collects the outcomes of the transactions, from any goroutine
+type stats struct {
+	mu    sync.Mutex
+	kinds map[Kind]*kindStats
+}
+
+func newStats() *stats {
+	s := &stats{kinds: map[Kind]*kindStats{}}
+	for _, kind := range Kinds {
+		s.kinds[kind] = &kindStats{}
+	}
+	return s
+}
+
+func (s *stats) submitted(kind Kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind].submitted++
+}
+
+func (s *stats) rejected(kind Kind, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ks := s.kinds[kind]
+	ks.rejected++
+	if len(ks.errors) < maxReportedErrors {
+		ks.errors = append(ks.errors, err.Error())
+	}
+}
+
+func (s *stats) finalized(kind Kind, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind].finalized++
+	s.kinds[kind].latencies = append(s.kinds[kind].latencies, latency)
+}
+
+func (s *stats) abandoned(kind Kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind].abandoned++
+}
+
+func (s *stats) timedOut(kind Kind) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kinds[kind].timedOut++
+}
+
+func (s *stats) report(elapsed time.Duration) *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &Report{Elapsed: elapsed.Seconds()}
+	all := &kindStats{}
+	for _, kind := range Kinds {
+		ks := s.kinds[kind]
+		if ks.submitted+ks.rejected == 0 {
+			continue
+		}
+		report.Kinds = append(report.Kinds, ks.report(kind))
+		all.submitted += ks.submitted
+		all.rejected += ks.rejected
+		all.finalized += ks.finalized
+		all.abandoned += ks.abandoned
+		all.timedOut += ks.timedOut
+		all.latencies = append(all.latencies, ks.latencies...)
+	}
+	report.Total = all.report("total")
+	if elapsed > 0 {
+		report.Throughput = float64(all.finalized) / elapsed.Seconds()
+	}
+	return report
+}
+
+func (ks *kindStats) report(kind Kind) KindReport {
+	return KindReport{
+		Kind:      kind,
+		Submitted: ks.submitted,
+		Rejected:  ks.rejected,
+		Finalized: ks.finalized,
+		Abandoned: ks.abandoned,
+		TimedOut:  ks.timedOut,
+		Latency:   ComputePercentiles(ks.latencies),
+		Errors:    ks.errors,
+	}
+}
+
+// ComputePercentiles computes the percentiles of the latencies with the nearest-rank method.
+func ComputePercentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, latency := range sorted {
+		sum += latency
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		return toMillis(sorted[rank-1])
+	}
+	return Percentiles{
+		Min:  toMillis(sorted[0]),
+		Mean: toMillis(sum / time.Duration(len(sorted))),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  toMillis(sorted[len(sorted)-1]),
+	}
+}
+
+func toMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// WriteText writes the report as a table.
+func (r *Report) WriteText(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "kind\tsubmitted\trejected\tfinalized\tabandoned\ttimed out\tmin ms\tmean ms\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, kr := range append(r.Kinds, r.Total) {
+		l := kr.Latency
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			kr.Kind, kr.Submitted, kr.Rejected, kr.Finalized, kr.Abandoned, kr.TimedOut,
+			l.Min, l.Mean, l.P50, l.P90, l.P99, l.Max)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, kr := range r.Kinds {
+		for _, err := range kr.Errors {
+			fmt.Fprintf(out, "%v rejected: %v\n", kr.Kind, err)
+		}
+	}
+	_, err := fmt.Fprintf(out, "%v transactions finalized in %.1fs, %.2f tx/s\n", r.Total.Finalized, r.Elapsed, r.Throughput)
+	return err
+}
@@ -0,0 +1,322 @@
+// Package bench generates transaction workloads against a node and measures the latency of the
+// transactions from their submission to their finalization.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "bench"})
+
+// errReserveLimit is returned when the account holds as many reserved funds as allowed
+var errReserveLimit = errors.New("The account holds the maximum number of reserved funds")
+
+// Config of a benchmark run
+type Config struct {
+	ChainID      string
+	Mix          Mix
+	NumTxs       int           // transactions of the mix to submit, no limit if 0
+	Duration     time.Duration // time to submit transactions for, no limit if 0
+	Rate         float64       // transactions of the mix submitted per second, as fast as possible if 0
+	Concurrency  int           // transactions submitted concurrently
+	Timeout      time.Duration // time to wait for a transaction to be finalized
+	PollInterval time.Duration // interval between the status checks, which bounds the latency precision
+	Seed         int64         // seed of the choice of kinds and accounts
+
+	Contract     common.Address // contract called by the contract transactions
+	ContractData common.Bytes   // call data of the contract transactions
+	GasLimit     uint64
+}
+
+// Runner submits the transactions of a workload and tracks them until they are finalized
+type Runner struct {
+	config   Config
+	node     Node
+	accounts []*Account
+	stats    *stats
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+
+	pendingMu sync.Mutex
+	pending   map[common.Hash]*pendingTx
+}
+
+type pendingTx struct {
+	kind        Kind
+	account     *Account // account whose sequence the transaction uses
+	submitted   time.Time
+	onFinalized func()
+}
+
+// NewRunner creates a new instance of Runner
+func NewRunner(config Config, node Node, accounts []*Account) (*Runner, error) {
+	if len(accounts) < 2 {
+		return nil, fmt.Errorf("At least 2 accounts are needed, got %v", len(accounts))
+	}
+	if config.Mix.total() == 0 {
+		return nil, errors.New("The mix has no positive weight")
+	}
+	if config.Mix[KindContract] > 0 && config.Contract == (common.Address{}) {
+		return nil, errors.New("The contract address is needed for contract transactions")
+	}
+	if config.NumTxs <= 0 && config.Duration <= 0 {
+		return nil, errors.New("Either the number of transactions or the duration needs to be set")
+	}
+	if config.Concurrency <= 0 || config.Timeout <= 0 || config.PollInterval <= 0 {
+		return nil, errors.New("The concurrency, timeout and poll interval need to be positive")
+	}
+	return &Runner{
+		config:   config,
+		node:     node,
+		accounts: accounts,
+		stats:    newStats(),
+		rng:      rand.New(rand.NewSource(config.Seed)),
+		pending:  make(map[common.Hash]*pendingTx),
+	}, nil
+}
+
+// Run submits the workload, waits for the transactions to be finalized, abandoned or to time out,
+// and reports their latencies.
+func (r *Runner) Run() *Report {
+	start := time.Now()
+
+	jobs := make(chan Kind)
+	go r.produce(jobs, start)
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for kind := range jobs {
+				r.submit(kind)
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	r.poll(done)
+	return r.stats.report(time.Since(start))
+}
+
+// produce paces the transactions of the mix until the number of transactions or the duration is reached.
+func (r *Runner) produce(jobs chan<- Kind, start time.Time) {
+	defer close(jobs)
+
+	var ticker *time.Ticker
+	if r.config.Rate > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / r.config.Rate))
+		defer ticker.Stop()
+	}
+	for i := 0; r.config.NumTxs <= 0 || i < r.config.NumTxs; i++ {
+		if ticker != nil {
+			<-ticker.C
+		}
+		if r.config.Duration > 0 && time.Since(start) >= r.config.Duration {
+			return
+		}
+		jobs <- r.pickKind()
+	}
+}
+
+func (r *Runner) submit(kind Kind) {
+	var err error
+	switch kind {
+	case KindSend:
+		err = r.submitSend()
+	case KindReserve:
+		err = r.submitReserve()
+		if err == errReserveLimit {
+			// Keep the load steady, the reserved funds are released as they expire
+			kind = KindSend
+			err = r.submitSend()
+		}
+	case KindContract:
+		err = r.submitContract()
+	}
+	if err != nil {
+		r.stats.rejected(kind, err)
+	}
+}
+
+func (r *Runner) submitSend() error {
+	from, to := r.pickPair()
+	return r.broadcast(KindSend, from, func(sequence uint64) (types.Tx, func(), error) {
+		tx, err := newSendTx(r.config.ChainID, from, to.Address, sequence)
+		return tx, nil, err
+	})
+}
+
+// submitReserve reserves a fund, and pays it to another account once the reservation is finalized.
+func (r *Runner) submitReserve() error {
+	source, target := r.pickPair()
+	return r.broadcast(KindReserve, source, func(sequence uint64) (types.Tx, func(), error) {
+		if source.reserves >= types.MaximumActiveReservedFundsPerAccount {
+			source.synced = false // reload the reserved funds, some may have been released
+			return nil, nil, errReserveLimit
+		}
+		resourceID := fmt.Sprintf("bench_%v_%v", source.Address.Hex(), sequence)
+		tx, err := newReserveFundTx(r.config.ChainID, source, sequence, resourceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		source.reserves++
+		return tx, func() { r.settle(source, target, sequence, resourceID) }, nil
+	})
+}
+
+func (r *Runner) settle(source, target *Account, reserveSequence uint64, resourceID string) {
+	err := r.broadcast(KindSettle, target, func(sequence uint64) (types.Tx, func(), error) {
+		tx, err := newServicePaymentTx(r.config.ChainID, source, target, sequence, reserveSequence, resourceID)
+		return tx, nil, err
+	})
+	if err != nil {
+		r.stats.rejected(KindSettle, err)
+	}
+}
+
+func (r *Runner) submitContract() error {
+	from := r.pickAccount()
+	return r.broadcast(KindContract, from, func(sequence uint64) (types.Tx, func(), error) {
+		tx, err := newSmartContractTx(r.config.ChainID, from, r.config.Contract, r.config.ContractData, r.config.GasLimit, sequence)
+		return tx, nil, err
+	})
+}
+
+// broadcast builds the transaction with the next sequence of the account and submits it. The
+// account is locked until the node accepts the transaction, so that the transactions of an
+// account reach the node in sequence order.
+func (r *Runner) broadcast(kind Kind, acc *Account, build func(sequence uint64) (types.Tx, func(), error)) error {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	if err := acc.sync(r.node); err != nil {
+		return err
+	}
+	sequence := acc.sequence + 1
+	tx, onFinalized, err := build(sequence)
+	if err != nil {
+		return err
+	}
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return err
+	}
+	hash, err := r.node.Broadcast(raw)
+	if err != nil {
+		acc.synced = false
+		return err
+	}
+	acc.sequence = sequence
+	r.stats.submitted(kind)
+
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+	r.pending[hash] = &pendingTx{kind: kind, account: acc, submitted: time.Now(), onFinalized: onFinalized}
+	return nil
+}
+
+// poll checks the pending transactions until all the transactions have been submitted (done is
+// closed) and none is pending anymore. The settlements are submitted from here, as the
+// reservations get finalized.
+func (r *Runner) poll(done <-chan struct{}) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	submitted := false
+	for {
+		select {
+		case <-done:
+			submitted = true
+			done = nil
+		case <-ticker.C:
+		}
+		r.checkPending()
+		if submitted && r.numPending() == 0 {
+			return
+		}
+	}
+}
+
+func (r *Runner) checkPending() {
+	r.pendingMu.Lock()
+	pending := make(map[common.Hash]*pendingTx, len(r.pending))
+	for hash, tx := range r.pending {
+		pending[hash] = tx
+	}
+	r.pendingMu.Unlock()
+
+	for hash, tx := range pending {
+		status, err := r.node.TxStatus(hash)
+		if err != nil {
+			logger.Warnf("Failed to get the status of transaction %v: %v", hash.Hex(), err)
+		}
+		now := time.Now()
+		switch {
+		case status == rpc.TxStatusFinalized:
+			r.removePending(hash)
+			r.stats.finalized(tx.kind, now.Sub(tx.submitted))
+			if tx.onFinalized != nil {
+				tx.onFinalized()
+			}
+		case status == rpc.TxStatusAbandoned:
+			r.removePending(hash)
+			r.stats.abandoned(tx.kind)
+			tx.account.desync()
+		case now.Sub(tx.submitted) > r.config.Timeout:
+			r.removePending(hash)
+			r.stats.timedOut(tx.kind)
+			tx.account.desync()
+		}
+	}
+}
+
+func (r *Runner) removePending(hash common.Hash) {
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+	delete(r.pending, hash)
+}
+
+func (r *Runner) numPending() int {
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+	return len(r.pending)
+}
+
+func (r *Runner) pickKind() Kind {
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+	return r.config.Mix.pick(r.rng.Intn(r.config.Mix.total()))
+}
+
+func (r *Runner) pickAccount() *Account {
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+	return r.accounts[r.rng.Intn(len(r.accounts))]
+}
+
+// pickPair picks two distinct accounts.
+func (r *Runner) pickPair() (*Account, *Account) {
+	r.rngMu.Lock()
+	defer r.rngMu.Unlock()
+	i := r.rng.Intn(len(r.accounts))
+	j := r.rng.Intn(len(r.accounts) - 1)
+	if j >= i {
+		j++
+	}
+	return r.accounts[i], r.accounts[j]
+}
@@ -0,0 +1,261 @@
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// Kind is the kind of the transactions of a workload
+type Kind string
+
+const (
+	KindSend     Kind = "send"     // TFuel transfer between two accounts
+	KindReserve  Kind = "reserve"  // fund reservation, settled by a service payment once finalized
+	KindSettle   Kind = "settle"   // service payment out of a finalized reservation
+	KindContract Kind = "contract" // smart contract call
+)
+
+// Kinds lists the kinds in report order.
+var Kinds = []Kind{KindSend, KindReserve, KindSettle, KindContract}
+
+const (
+	// TransferAmount is the TFuelWei amount of the sends, reservations and payments
+	TransferAmount int64 = 1000
+
+	// CollateralAmount is the TFuelWei collateral of the reservations, which needs to exceed the fund
+	CollateralAmount int64 = 2 * TransferAmount
+)
+
+// Mix gives the relative weights of the kinds of transactions submitted. The settlements are
+// not part of the mix, every finalized reservation is followed by one.
+type Mix map[Kind]int
+
+// ParseMix parses a mix such as "send=8,reserve=1,contract=1".
+func ParseMix(str string) (Mix, error) {
+	mix := Mix{}
+	total := 0
+	for _, entry := range strings.Split(str, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid mix entry %q, expected kind=weight", entry)
+		}
+		kind := Kind(parts[0])
+		if kind != KindSend && kind != KindReserve && kind != KindContract {
+			return nil, fmt.Errorf("Invalid transaction kind %q, expected send, reserve or contract", parts[0])
+		}
+		weight, err := strconv.Atoi(parts[1])
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("Invalid weight %q for %v", parts[1], kind)
+		}
+		mix[kind] = weight
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("The mix %q has no positive weight", str)
+	}
+	return mix, nil
+}
+
+// pick returns the kind for a number drawn uniformly in [0, total weight).
+func (mix Mix) pick(n int) Kind {
+	for _, kind := range Kinds {
+		if n < mix[kind] {
+			return kind
+		}
+		n -= mix[kind]
+	}
+	panic("The number drawn exceeds the total weight")
+}
+
+func (mix Mix) total() int {
+	total := 0
+	for _, weight := range mix {
+		total += weight
+	}
+	return total
+}
+
+// Account is a funded account the transactions are sent from
+type Account struct {
+	Key     *crypto.PrivateKey
+	Address common.Address
+
+	mu       sync.Mutex
+	synced   bool   // whether sequence and reserves match the node
+	sequence uint64 // sequence of the last transaction submitted
+	reserves int    // reserved funds, which are limited per account
+}
+
+// NewAccount creates a new instance of Account
+func NewAccount(key *crypto.PrivateKey) *Account {
+	return &Account{Key: key, Address: key.PublicKey().Address()}
+}
+
+// LoadAccounts reads the hex encoded private keys of the accounts from a file, one per line.
+// Empty lines and lines starting with # are skipped.
+func LoadAccounts(path string) ([]*Account, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	accounts := []*Account{}
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := crypto.PrivateKeyFromBytes(common.FromHex(line))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid private key on line %v of %v: %v", lineNum, path, err)
+		}
+		accounts = append(accounts, NewAccount(key))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Keep the order deterministic regardless of the file layout
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Address.Hex() < accounts[j].Address.Hex()
+	})
+	return accounts, nil
+}
+
+// sync loads the sequence and the reserved funds of the account from the node, unless the
+// local copies are known to be up to date. It needs to be called with the lock held.
+func (acc *Account) sync(node Node) error {
+	if acc.synced {
+		return nil
+	}
+	account, err := node.Account(acc.Address)
+	if err != nil {
+		return fmt.Errorf("Failed to get account %v: %v", acc.Address.Hex(), err)
+	}
+	acc.sequence = account.Sequence
+	acc.reserves = len(account.ReservedFunds)
+	acc.synced = true
+	return nil
+}
+
+// desync makes the next transaction reload the account from the node, e.g. after one of its
+// transactions has been rejected, which leaves a gap in the sequences.
+func (acc *Account) desync() {
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	acc.synced = false
+}
+
+func (acc *Account) sign(signBytes common.Bytes) (*crypto.Signature, error) {
+	return acc.Key.Sign(signBytes)
+}
+
+func fee() types.Coins {
+	return types.Coins{ThetaWei: big.NewInt(0), TFuelWei: new(big.Int).SetUint64(types.MinimumTransactionFeeTFuelWei)}
+}
+
+func newSendTx(chainID string, from *Account, to common.Address, sequence uint64) (types.Tx, error) {
+	tx := &types.SendTx{
+		Fee: fee(),
+		Inputs: []types.TxInput{{
+			Address:  from.Address,
+			Coins:    types.NewCoins(0, TransferAmount).Plus(fee()),
+			Sequence: sequence,
+		}},
+		Outputs: []types.TxOutput{{
+			Address: to,
+			Coins:   types.NewCoins(0, TransferAmount),
+		}},
+	}
+	sig, err := from.sign(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignature(from.Address, sig)
+	return tx, nil
+}
+
+func newReserveFundTx(chainID string, from *Account, sequence uint64, resourceID string) (types.Tx, error) {
+	tx := &types.ReserveFundTx{
+		Fee: fee(),
+		Source: types.TxInput{
+			Address:  from.Address,
+			Coins:    types.NewCoins(0, TransferAmount),
+			Sequence: sequence,
+		},
+		Collateral:  types.NewCoins(0, CollateralAmount),
+		ResourceIDs: []string{resourceID},
+		Duration:    types.MinimumFundReserveDuration,
+	}
+	sig, err := from.sign(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignature(from.Address, sig)
+	return tx, nil
+}
+
+// newServicePaymentTx pays the whole reserved fund to the target, which submits the payment
+func newServicePaymentTx(chainID string, source, target *Account, targetSequence uint64, reserveSequence uint64, resourceID string) (types.Tx, error) {
+	tx := &types.ServicePaymentTx{
+		Fee: fee(),
+		Source: types.TxInput{
+			Address: source.Address,
+			Coins:   types.NewCoins(0, TransferAmount),
+		},
+		Target: types.TxInput{
+			Address:  target.Address,
+			Sequence: targetSequence,
+		},
+		PaymentSequence: 1,
+		ReserveSequence: reserveSequence,
+		ResourceID:      resourceID,
+	}
+	sig, err := source.sign(tx.SourceSignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSourceSignature(sig)
+	sig, err = target.sign(tx.TargetSignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetTargetSignature(sig)
+	return tx, nil
+}
+
+func newSmartContractTx(chainID string, from *Account, contract common.Address, data common.Bytes, gasLimit uint64, sequence uint64) (types.Tx, error) {
+	gasPrice := new(big.Int).SetUint64(types.MinimumGasPrice)
+	tx := &types.SmartContractTx{
+		From: types.TxInput{
+			Address:  from.Address,
+			Coins:    types.NewCoins(0, 0),
+			Sequence: sequence,
+		},
+		To: types.TxOutput{
+			Address: contract,
+			Coins:   types.NewCoins(0, 0),
+		},
+		GasLimit: gasLimit,
+		GasPrice: gasPrice,
+		Data:     data,
+	}
+	sig, err := from.sign(tx.SignBytes(chainID))
+	if err != nil {
+		return nil, err
+	}
+	tx.SetSignature(from.Address, sig)
+	return tx, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/cmd/theta-bench/bench"
+	"github.com/thetatoken/theta/common"
+)
+
+var (
+	endpointFlag     string
+	chainIDFlag      string
+	keysFlag         string
+	mixFlag          string
+	numTxsFlag       int
+	durationFlag     time.Duration
+	rateFlag         float64
+	concurrencyFlag  int
+	timeoutFlag      time.Duration
+	pollIntervalFlag time.Duration
+	seedFlag         int64
+	contractFlag     string
+	dataFlag         string
+	gasLimitFlag     uint64
+	jsonFlag         bool
+)
+
+// benchCmd represents the theta-bench command
+var benchCmd = &cobra.Command{
+	Use:   "theta-bench",
+	Short: "Generate transaction workloads against a node and report the latency to finalization.",
+	Long: `Generate a workload of send transactions, reserve/settle cycles and smart contract calls against a local or remote node, and report the latency percentiles from the submission of each transaction to its finalization.
+
+The transactions are sent from the accounts whose hex encoded private keys are listed in the key file, one per line. The accounts need to be funded with TFuel. Every finalized fund reservation is followed by a service payment of the fund to another account, reported as the settle kind.`,
+	Example: `theta-bench --chain=privatenet --keys=bench_keys.txt --txs=10000 --rate=200
+theta-bench --chain=privatenet --keys=bench_keys.txt --duration=5m --mix=send=8,reserve=1,contract=1 --contract=0x5E5ee2a8d1bBF1Ee3CA3fD2C5c4D3E1B0aF0a8e1 --data=0x3fb5c1cb
+theta-bench --endpoint=http://10.0.0.2:16888/rpc --chain=privatenet --keys=bench_keys.txt --txs=1000 --json`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&endpointFlag, "endpoint", "http://localhost:16888/rpc", "RPC endpoint of the node")
+	benchCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	benchCmd.Flags().StringVar(&keysFlag, "keys", "", "file with the hex encoded private keys of the funded accounts, one per line")
+	benchCmd.Flags().StringVar(&mixFlag, "mix", "send=1", "relative weights of the transaction kinds (send, reserve, contract)")
+	benchCmd.Flags().IntVar(&numTxsFlag, "txs", 0, "number of transactions of the mix to submit, no limit if 0")
+	benchCmd.Flags().DurationVar(&durationFlag, "duration", 0, "time to submit transactions for, no limit if 0")
+	benchCmd.Flags().Float64Var(&rateFlag, "rate", 0, "transactions of the mix submitted per second, as fast as possible if 0")
+	benchCmd.Flags().IntVar(&concurrencyFlag, "concurrency", 16, "transactions submitted concurrently")
+	benchCmd.Flags().DurationVar(&timeoutFlag, "timeout", time.Minute, "time to wait for a transaction to be finalized")
+	benchCmd.Flags().DurationVar(&pollIntervalFlag, "poll", 200*time.Millisecond, "interval between the status checks of the pending transactions")
+	benchCmd.Flags().Int64Var(&seedFlag, "seed", 1, "seed of the choice of transaction kinds and accounts")
+	benchCmd.Flags().StringVar(&contractFlag, "contract", "", "address of the contract called by the contract transactions")
+	benchCmd.Flags().StringVar(&dataFlag, "data", "", "hex encoded call data of the contract transactions")
+	benchCmd.Flags().Uint64Var(&gasLimitFlag, "gas_limit", 100000, "gas limit of the contract transactions")
+	benchCmd.Flags().BoolVar(&jsonFlag, "json", false, "print the report in JSON")
+
+	benchCmd.MarkFlagRequired("chain")
+	benchCmd.MarkFlagRequired("keys")
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	mix, err := bench.ParseMix(mixFlag)
+	if err != nil {
+		return err
+	}
+	accounts, err := bench.LoadAccounts(keysFlag)
+	if err != nil {
+		return err
+	}
+	config := bench.Config{
+		ChainID:      chainIDFlag,
+		Mix:          mix,
+		NumTxs:       numTxsFlag,
+		Duration:     durationFlag,
+		Rate:         rateFlag,
+		Concurrency:  concurrencyFlag,
+		Timeout:      timeoutFlag,
+		PollInterval: pollIntervalFlag,
+		Seed:         seedFlag,
+		Contract:     common.HexToAddress(contractFlag),
+		ContractData: common.FromHex(dataFlag),
+		GasLimit:     gasLimitFlag,
+	}
+	runner, err := bench.NewRunner(config, bench.NewRPCNode(endpointFlag), accounts)
+	if err != nil {
+		return err
+	}
+
+	report := runner.Run()
+	if jsonFlag {
+		out, err := json.MarshalIndent(report, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	return report.WriteText(os.Stdout)
+}
+
+func main() {
+	if err := benchCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
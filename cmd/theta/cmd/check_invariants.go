@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/ledger/invariants"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var checkInvariantsStartHeight uint64
+var checkInvariantsEndHeight uint64
+
+// checkInvariantsCmd represents the check-invariants command
+var checkInvariantsCmd = &cobra.Command{
+	Use:   "check-invariants",
+	Short: "Check the ledger invariants over a range of finalized blocks.",
+	Long:  `Check the ledger invariants (total supply conservation, no negative balances, reserve collateral, stake accounting) on the finalized blocks in the height range, using the states in the local database. The node should be stopped while checking.`,
+	Example: `theta check-invariants --start=1000 --end=2000
+theta check-invariants --start=1000`,
+	Args: cobra.NoArgs,
+	Run:  runCheckInvariants,
+}
+
+func init() {
+	checkInvariantsCmd.Flags().Uint64Var(&checkInvariantsStartHeight, "start", 0, "first height to check, the block after the root block if 0")
+	checkInvariantsCmd.Flags().Uint64Var(&checkInvariantsEndHeight, "end", 0, "last height to check, the last finalized block if 0")
+	RootCmd.AddCommand(checkInvariantsCmd)
+}
+
+func runCheckInvariants(cmd *cobra.Command, args []string) {
+	lock := lockDataDir()
+	defer lock.Release()
+	db := openDatabase()
+	defer db.Close()
+
+	root := loadRootBlock()
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+
+	start := checkInvariantsStartHeight
+	if start <= root.Height {
+		start = root.Height + 1
+	}
+	end := checkInvariantsEndHeight
+
+	parentBlock := findFinalizedBlock(chain, start-1)
+	if parentBlock == nil {
+		log.Fatalf("No finalized block at height %v", start-1)
+	}
+	parent := state.NewStoreView(parentBlock.Height, parentBlock.StateHash, db)
+	if parent == nil {
+		log.Fatalf("The state at height %v has been pruned", parentBlock.Height)
+	}
+
+	numBlocks, numViolations := 0, 0
+	for height := start; end == 0 || height <= end; height++ {
+		block := findFinalizedBlock(chain, height)
+		if block == nil {
+			if end != 0 {
+				log.Fatalf("No finalized block at height %v", height)
+			}
+			break
+		}
+		view := state.NewStoreView(block.Height, block.StateHash, db)
+		if view == nil {
+			log.Fatalf("The state at height %v has been pruned", block.Height)
+		}
+
+		errs := invariants.CheckAll(&invariants.Transition{
+			ChainID: root.ChainID,
+			Block:   block.Block,
+			Parent:  parent,
+			View:    view,
+		})
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		numBlocks++
+		numViolations += len(errs)
+		parent = view
+	}
+
+	if numViolations > 0 {
+		fmt.Printf("%v violations in %v blocks\n", numViolations, numBlocks)
+		os.Exit(1)
+	}
+	fmt.Printf("Checked %v blocks from height %v, no violation\n", numBlocks, start)
+}
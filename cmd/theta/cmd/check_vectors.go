@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/ledger/vectors"
+)
+
+var updateVectors bool
+
+// checkVectorsCmd represents the check-vectors command
+var checkVectorsCmd = &cobra.Command{
+	Use:   "check-vectors [file]",
+	Short: "Check the binary against the golden vectors.",
+	Long:  `Check that the binary decodes the golden vectors of signed transactions and block headers, and reproduces their encodings, sign bytes and hashes bit for bit. With --update, the vectors of the current versions that are not recorded yet are added to the file.`,
+	Example: `theta check-vectors ledger/vectors/testdata/golden_vectors.json
+theta check-vectors --update ledger/vectors/testdata/golden_vectors.json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runCheckVectors,
+}
+
+func init() {
+	checkVectorsCmd.Flags().BoolVar(&updateVectors, "update", false, "add the vectors of the current versions missing from the file")
+	RootCmd.AddCommand(checkVectorsCmd)
+}
+
+func runCheckVectors(cmd *cobra.Command, args []string) {
+	corpus, err := vectors.LoadCorpus(args[0])
+	if err != nil {
+		log.Fatalf("Failed to load the golden vectors: %v", err)
+	}
+
+	if updateVectors {
+		generated, err := vectors.Generate()
+		if err != nil {
+			log.Fatalf("Failed to generate the golden vectors: %v", err)
+		}
+		if added := corpus.Add(generated); added > 0 {
+			if err := corpus.Save(args[0]); err != nil {
+				log.Fatalf("Failed to save the golden vectors: %v", err)
+			}
+			log.Infof("Added %v vectors to %v", added, args[0])
+		}
+	}
+
+	errs := corpus.Check()
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	if len(errs) > 0 {
+		fmt.Printf("%v mismatches out of %v vectors\n", len(errs), len(corpus.Vectors))
+		os.Exit(1)
+	}
+	fmt.Printf("All %v vectors match\n", len(corpus.Vectors))
+}
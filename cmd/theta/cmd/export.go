@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var exportFromHeight uint64
+var exportToHeight uint64
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:     "export [file]",
+	Short:   "Export finalized blocks and their votes to a chain archive.",
+	Long:    `Export finalized blocks and their votes to a compressed chain archive, which can be imported by another node. The node should be stopped while exporting.`,
+	Example: `theta export --from=1000 --to=2000 theta_chain-1000-2000.archive`,
+	Args:    cobra.ExactArgs(1),
+	Run:     runExport,
+}
+
+func init() {
+	exportCmd.Flags().Uint64Var(&exportFromHeight, "from", 1, "height of the first block to export")
+	exportCmd.Flags().Uint64Var(&exportToHeight, "to", 0, "height of the last block to export, up to the last finalized block if 0")
+	RootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	lock := lockDataDir()
+	defer lock.Release()
+	db := openDatabase()
+	defer db.Close()
+	root := loadRootBlock()
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+
+	header, err := snapshot.ExportChainArchive(chain, exportFromHeight, exportToHeight, args[0])
+	if err != nil {
+		log.Fatalf("Failed to export chain archive, err: %v", err)
+	}
+	log.Infof("Exported blocks %v to %v of chain %v to %v", header.StartHeight, header.EndHeight, header.ChainID, args[0])
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/canonical"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var exportStateHeight uint64
+var verifyStateLocal bool
+
+// exportStateCmd represents the export-state command
+var exportStateCmd = &cobra.Command{
+	Use:     "export-state [file]",
+	Short:   "Export the ledger state in the canonical format.",
+	Long:    `Export the ledger state at a finalized height in the canonical state export format, which other client implementations can compare byte for byte. The node should be stopped while exporting.`,
+	Example: `theta export-state --height=1000 theta_state-1000.export`,
+	Args:    cobra.ExactArgs(1),
+	Run:     runExportState,
+}
+
+// verifyStateCmd represents the verify-state command
+var verifyStateCmd = &cobra.Command{
+	Use:   "verify-state [file] [other file]",
+	Short: "Verify a canonical state export.",
+	Long:  `Verify that a canonical state export is well formed, and optionally compare it with another export or with the local state at the same height.`,
+	Example: `theta verify-state theta_state-1000.export
+theta verify-state theta_state-1000.export other_client_state-1000.export
+theta verify-state --local theta_state-1000.export`,
+	Args: cobra.RangeArgs(1, 2),
+	Run:  runVerifyState,
+}
+
+func init() {
+	exportStateCmd.Flags().Uint64Var(&exportStateHeight, "height", 0, "height of the state to export, the last finalized block if 0")
+	verifyStateCmd.Flags().BoolVar(&verifyStateLocal, "local", false, "compare the export with the local state at the same height")
+	RootCmd.AddCommand(exportStateCmd)
+	RootCmd.AddCommand(verifyStateCmd)
+}
+
+func runExportState(cmd *cobra.Command, args []string) {
+	lock := lockDataDir()
+	defer lock.Release()
+	db := openDatabase()
+	defer db.Close()
+
+	sv, err := loadFinalizedState(db, exportStateHeight)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	footer, err := exportState(sv, args[0])
+	if err != nil {
+		log.Fatalf("Failed to export state, err: %v", err)
+	}
+	log.Infof("Exported the state at height %v to %v, %v records, Merkle root %v", sv.Height(), args[0], footer.NumRecords, footer.Root.Hex())
+}
+
+func runVerifyState(cmd *cobra.Command, args []string) {
+	header, footer := verifyStateFile(args[0])
+	log.Infof("%v is valid, chain %v, height %v, state root %v, %v records, Merkle root %v",
+		args[0], header.ChainID, header.Height, header.StateRoot.Hex(), footer.NumRecords, footer.Root.Hex())
+
+	other := ""
+	if len(args) == 2 {
+		other = args[1]
+		verifyStateFile(other)
+	} else if verifyStateLocal {
+		lock := lockDataDir()
+		defer lock.Release()
+		db := openDatabase()
+		defer db.Close()
+		sv, err := loadFinalizedState(db, header.Height)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		tmpFile, err := ioutil.TempFile("", "theta_state")
+		if err != nil {
+			log.Fatalf("Failed to create temporary file, err: %v", err)
+		}
+		tmpFile.Close()
+		defer os.Remove(tmpFile.Name())
+		if _, err := exportState(sv, tmpFile.Name()); err != nil {
+			log.Fatalf("Failed to export the local state, err: %v", err)
+		}
+		other = tmpFile.Name()
+	} else {
+		return
+	}
+
+	a, err := os.Open(args[0])
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer a.Close()
+	b, err := os.Open(other)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer b.Close()
+	diff, err := canonical.Compare(a, b)
+	if err != nil {
+		log.Fatalf("Failed to compare the exports, err: %v", err)
+	}
+	if diff != nil {
+		log.Fatalf("The exports differ at record %v", diff)
+	}
+	log.Infof("The exports are identical")
+}
+
+func verifyStateFile(filePath string) (*canonical.Header, *canonical.Footer) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer file.Close()
+	header, footer, err := canonical.Verify(file)
+	if err != nil {
+		log.Fatalf("%v is invalid, err: %v", filePath, err)
+	}
+	return header, footer
+}
+
+func exportState(sv *state.StoreView, filePath string) (*canonical.Footer, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return canonical.Export(sv, file)
+}
+
+// loadFinalizedState returns the state after the finalized block at the given height, or the last
+// finalized block if height is 0.
+func loadFinalizedState(db database.Database, height uint64) (*state.StoreView, error) {
+	root := loadRootBlock()
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+
+	var block *core.ExtendedBlock
+	if height == 0 {
+		for h := root.Height; ; h++ {
+			b := findFinalizedBlock(chain, h)
+			if b == nil {
+				break
+			}
+			block = b
+		}
+	} else {
+		block = findFinalizedBlock(chain, height)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("No finalized block at height %v", height)
+	}
+	sv := state.NewStoreView(block.Height, block.StateHash, db)
+	if sv == nil {
+		return nil, fmt.Errorf("The state at height %v has been pruned", block.Height)
+	}
+	return sv, nil
+}
+
+func findFinalizedBlock(chain *blockchain.Chain, height uint64) *core.ExtendedBlock {
+	for _, block := range chain.FindBlocksByHeight(height) {
+		if block.Status.IsFinalized() {
+			return block
+		}
+	}
+	return nil
+}
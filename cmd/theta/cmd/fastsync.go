@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	dp "github.com/thetatoken/theta/dispatcher"
+	"github.com/thetatoken/theta/netsync"
+	"github.com/thetatoken/theta/snapshot"
+)
+
+// fastSyncCmd represents the fastsync command
+var fastSyncCmd = &cobra.Command{
+	Use:     "fastsync",
+	Short:   "Download the snapshot of a trusted checkpoint from the peers.",
+	Long:    `Download the snapshot of a trusted checkpoint from the peers serving it, so that the node only replays the blocks following the checkpoint instead of the whole chain. The checkpoint is the latest one of the network profile, unless set explicitly. An interrupted download is resumed the next time. Run "theta start" once the snapshot is downloaded. With --base, only the state diff from a snapshot already held, e.g. an older backup, is downloaded and applied to it.`,
+	Example: `theta fastsync --network=mainnet`,
+	Run:     runFastSync,
+}
+
+func init() {
+	fastSyncCmd.Flags().String("checkpoint", "", "hash of the trusted finalized block whose snapshot is downloaded")
+	viper.BindPFlag(common.CfgSyncFastSyncCheckpoint, fastSyncCmd.Flags().Lookup("checkpoint"))
+	fastSyncCmd.Flags().String("base", "", "path of a snapshot already held, to download only the state diff from it")
+	RootCmd.AddCommand(fastSyncCmd)
+}
+
+func runFastSync(cmd *cobra.Command, args []string) {
+	checkpoint := getFastSyncCheckpoint()
+	if len(snapshotPath) == 0 {
+		snapshotPath = path.Join(cfgPath, "snapshot")
+	}
+	if _, err := os.Stat(snapshotPath); err == nil {
+		log.Fatalf("The snapshot %v already exists", snapshotPath)
+	}
+
+	lock := lockDataDir()
+	defer lock.Release()
+
+	// The node only needs a P2P identity to download the snapshot
+	nodeKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		log.Fatalf("Failed to generate node key: %v", err)
+	}
+	f := func(c rune) bool {
+		return c == ','
+	}
+	peerSeeds := strings.FieldsFunc(viper.GetString(common.CfgP2PSeeds), f)
+	network := newMessenger(nodeKey, peerSeeds, viper.GetInt(common.CfgP2PPort))
+	dispatcher := dp.NewDispatcher(network)
+	var fetcher *netsync.SnapshotFetcher
+	basePath, _ := cmd.Flags().GetString("base")
+	if len(basePath) != 0 {
+		base, err := snapshot.ReadSnapshotCheckpoint(basePath)
+		if err != nil {
+			log.Fatalf("Failed to read the base snapshot %v: %v", basePath, err)
+		}
+		log.Infof("Downloading the state diff from checkpoint %v of the base snapshot", base.Hex())
+		fetcher = netsync.NewStateDiffFetcher(basePath, base, checkpoint, snapshotPath, dispatcher)
+	} else {
+		fetcher = netsync.NewSnapshotFetcher(checkpoint, snapshotPath, dispatcher)
+	}
+	network.RegisterMessageHandler(fetcher)
+
+	// trap Ctrl+C and call cancel on the context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	go func() {
+		select {
+		case <-c:
+		case <-lock.Lost():
+			log.Error("Another process took over the data directory, shutting down")
+		}
+		signal.Stop(c)
+		cancel()
+	}()
+
+	if err := dispatcher.Start(ctx); err != nil {
+		log.Fatalf("Failed to start the P2P network: %v", err)
+	}
+	defer dispatcher.Stop()
+
+	log.Infof("Downloading the snapshot of checkpoint %v", checkpoint.Hex())
+	header, err := fetcher.Fetch(ctx)
+	if err == context.Canceled {
+		log.Infof("Snapshot download interrupted, it will be resumed the next time")
+		return
+	}
+	if err != nil {
+		log.Fatalf("Failed to download the snapshot, err: %v", err)
+	}
+	log.Infof("Downloaded the snapshot of block %v at height %v to %v, run \"theta start\" to sync the following blocks",
+		header.Hash().Hex(), header.Height, snapshotPath)
+}
+
+// getFastSyncCheckpoint returns the checkpoint set in the config, or else the latest checkpoint
+// of the network profile.
+func getFastSyncCheckpoint() common.Hash {
+	if hash := viper.GetString(common.CfgSyncFastSyncCheckpoint); len(hash) != 0 {
+		return common.HexToHash(hash)
+	}
+	network := viper.GetString(common.CfgNetwork)
+	if len(network) == 0 {
+		log.Fatalf("No checkpoint, set --checkpoint or --network")
+	}
+	profile, err := core.GetNetworkProfile(network)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	checkpoint := profile.LatestCheckpoint()
+	if checkpoint.Height == 0 {
+		log.Fatalf("Network %v has no checkpoint after the genesis, set --checkpoint", network)
+	}
+	return checkpoint.Hash
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var importValidateOnly bool
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:     "import [file]",
+	Short:   "Import blocks from a chain archive.",
+	Long:    `Validate the chain archive and import its blocks and votes. The imported blocks are processed by the consensus engine the next time the node starts. The node should be stopped while importing.`,
+	Example: `theta import theta_chain-1000-2000.archive`,
+	Args:    cobra.ExactArgs(1),
+	Run:     runImport,
+}
+
+func init() {
+	importCmd.Flags().BoolVar(&importValidateOnly, "validate-only", false, "validate the chain archive without importing it")
+	RootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) {
+	lock := lockDataDir()
+	defer lock.Release()
+	db := openDatabase()
+	defer db.Close()
+	root := loadRootBlock()
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+
+	if importValidateOnly {
+		header, err := snapshot.ValidateChainArchive(chain, args[0])
+		if err != nil {
+			log.Fatalf("Chain archive validation failed, err: %v", err)
+		}
+		log.Infof("Chain archive is valid, blocks %v to %v of chain %v", header.StartHeight, header.EndHeight, header.ChainID)
+		return
+	}
+
+	header, numImported, err := snapshot.ImportChainArchive(chain, args[0])
+	if err != nil {
+		log.Fatalf("Failed to import chain archive, err: %v", err)
+	}
+	log.Infof("Imported %v new blocks from blocks %v to %v of chain %v", numImported, header.StartHeight, header.EndHeight, header.ChainID)
+}
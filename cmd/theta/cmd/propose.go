@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+var proposeDryRun bool
+var proposeRPCEndpoint string
+var proposeAPIKey string
+
+// proposeCmd represents the propose command
+var proposeCmd = &cobra.Command{
+	Use:   "propose",
+	Short: "Assemble a block proposal from the mempool of a running node.",
+	Long: `Assemble the block the running node would propose on top of its tip from its mempool, and execute it speculatively. Reports the gas used, the fees collected and the transactions that would be rejected with the reasons. The block is not broadcast, and the mempool is left untouched.
+
+Only --dry-run is supported, the blocks are otherwise proposed by the consensus engine.`,
+	Example: `theta propose --dry-run
+theta propose --dry-run --rpc=http://localhost:16888/rpc --api-key=<admin key>`,
+	Run: runPropose,
+}
+
+func init() {
+	proposeCmd.Flags().BoolVar(&proposeDryRun, "dry-run", false, "assemble and execute the proposal without proposing it")
+	proposeCmd.Flags().StringVar(&proposeRPCEndpoint, "rpc", "", "RPC endpoint of the node, the local node if empty")
+	proposeCmd.Flags().StringVar(&proposeAPIKey, "api-key", "", "admin API key, required once RPC tenants are configured")
+	RootCmd.AddCommand(proposeCmd)
+}
+
+func runPropose(cmd *cobra.Command, args []string) {
+	if !proposeDryRun {
+		log.Fatalf("Only --dry-run is supported, blocks are proposed by the consensus engine")
+	}
+
+	endpoint := proposeRPCEndpoint
+	if len(endpoint) == 0 {
+		endpoint = fmt.Sprintf("http://localhost:%v/rpc", viper.GetString(common.CfgRPCPort))
+	}
+	client := rpcc.NewRPCClient(endpoint)
+	if len(proposeAPIKey) > 0 {
+		client.SetCustomHeader(rpc.APIKeyHeader, proposeAPIKey)
+	}
+
+	res, err := client.Call("theta.DryRunProposal", rpc.DryRunProposalArgs{})
+	if err != nil {
+		log.Fatalf("Failed to call the node at %v, err: %v", endpoint, err)
+	}
+	if res.Error != nil {
+		log.Fatalf("Failed to dry run the proposal: %v", res.Error)
+	}
+	json, err := json.MarshalIndent(res.Result, "", "    ")
+	if err != nil {
+		log.Fatalf("Failed to parse server response: %v", err)
+	}
+	fmt.Println(string(json))
+}
@@ -4,15 +4,19 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
 )
 
 var cfgPath string
 var snapshotPath string
+var forceUnlock bool
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -34,11 +38,19 @@ func init() {
 
 	RootCmd.PersistentFlags().StringVar(&cfgPath, "config", getDefaultConfigPath(), fmt.Sprintf("config path (default is %s)", getDefaultConfigPath()))
 	RootCmd.PersistentFlags().StringVar(&snapshotPath, "snapshot", "", "snapshot path")
+	RootCmd.PersistentFlags().BoolVar(&forceUnlock, "force-unlock", false, "take over the lock of the data directory even if a process on another host seems to hold it")
+	RootCmd.PersistentFlags().String("network", "", fmt.Sprintf("network to join (%s, or a network defined in the config)", strings.Join(core.NetworkNames(), "|")))
+	viper.BindPFlag(common.CfgNetwork, RootCmd.PersistentFlags().Lookup("network"))
 	//RootCmd.PersistentFlags().StringVar(&snapshotPath, "snapshot", getDefaultSnapshotPath(), fmt.Sprintf("snapshot path (default is %s)", getDefaultSnapshotPath()))
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
+	// The nodes of the custom networks keep their config and data apart from the built-in networks
+	network := RootCmd.PersistentFlags().Lookup("network").Value.String()
+	if len(network) != 0 && !core.IsBuiltinNetwork(network) && !RootCmd.PersistentFlags().Changed("config") {
+		cfgPath = getNetworkConfigPath(network)
+	}
 	viper.AddConfigPath(cfgPath)
 
 	// Search config (without extension).
@@ -51,6 +63,81 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	if err := registerNetworks(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := applyNetworkProfile(viper.GetString(common.CfgNetwork)); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if err := common.ValidateConfig(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// registerNetworks registers the custom networks defined in the config
+func registerNetworks() error {
+	configs := []core.NetworkConfig{}
+	if err := viper.UnmarshalKey(common.CfgNetworks, &configs); err != nil {
+		return fmt.Errorf("Failed to parse %v: %v", common.CfgNetworks, err)
+	}
+	return core.RegisterNetworkProfiles(configs)
+}
+
+// applyNetworkProfile configures the node with the genesis hash, the seeds and the default ports of
+// the selected network. Seeds and ports set explicitly take precedence over those of the profile.
+func applyNetworkProfile(network string) error {
+	if len(network) == 0 {
+		return nil
+	}
+	profile, err := core.GetNetworkProfile(network)
+	if err != nil {
+		return err
+	}
+
+	genesisHash := viper.GetString(common.CfgGenesisHash)
+	if len(genesisHash) != 0 && common.HexToHash(genesisHash) != common.HexToHash(profile.GenesisHash) {
+		return fmt.Errorf("The genesis hash %v in the config does not match network %v, expected: %v",
+			genesisHash, network, profile.GenesisHash)
+	}
+	viper.Set(common.CfgGenesisHash, profile.GenesisHash)
+
+	if len(viper.GetString(common.CfgP2PSeeds)) == 0 {
+		viper.Set(common.CfgP2PSeeds, strings.Join(profile.Seeds, ","))
+	}
+	if profile.P2PPort != 0 && !isSetExplicitly(common.CfgP2PPort) {
+		viper.Set(common.CfgP2PPort, profile.P2PPort)
+	}
+	if profile.RPCPort != 0 && !isSetExplicitly(common.CfgRPCPort) {
+		viper.Set(common.CfgRPCPort, strconv.Itoa(profile.RPCPort))
+	}
+
+	fmt.Println("Using network profile:", network)
+	return nil
+}
+
+// isSetExplicitly returns whether the key is set in the config file or the environment, as opposed
+// to having its default value.
+func isSetExplicitly(key string) bool {
+	if _, ok := os.LookupEnv(strings.ToUpper(strings.Replace(key, ".", "_", -1))); ok {
+		return true
+	}
+	segments := strings.SplitN(strings.ToLower(key), ".", 2)
+	if len(segments) == 1 || !viper.InConfig(segments[0]) {
+		return len(segments) == 1 && viper.InConfig(segments[0])
+	}
+	section := viper.Sub(segments[0])
+	return section != nil && section.InConfig(segments[1])
+}
+
+// getNetworkConfigPath returns the default config path of a custom network.
+func getNetworkConfigPath(network string) string {
+	return getDefaultConfigPath() + "-" + network
 }
 
 // getDefaultConfigPath returns the default config path.
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var snapshotExportHeight uint64
+var snapshotExportOut string
+var snapshotImportIn string
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Export and import state snapshots offline.",
+}
+
+// snapshotExportCmd represents the snapshot export command
+var snapshotExportCmd = &cobra.Command{
+	Use:     "export",
+	Short:   "Export the state at a finalized height to a snapshot file.",
+	Long:    `Export the full state at a finalized height, along with the proofs of the validator set changes up to it, to a compressed snapshot file. Its header carries the chain ID, the block hash and the state root, and a checksum of the snapshot. The state of the block and of its parent must not have been pruned. The node should be stopped while exporting.`,
+	Example: `theta snapshot export --height=1000 --out=theta_snapshot-1000.snapshot`,
+	Args:    cobra.NoArgs,
+	Run:     runSnapshotExport,
+}
+
+// snapshotImportCmd represents the snapshot import command
+var snapshotImportCmd = &cobra.Command{
+	Use:     "import",
+	Short:   "Bootstrap the node from a snapshot file.",
+	Long:    `Verify the snapshot file, and install its snapshot as the one the node is bootstrapped from. The node then only needs the blocks following the snapshot, which it downloads from its peers or which can be imported from a chain archive. Run "theta start" once the snapshot is imported.`,
+	Example: `theta snapshot import --in=theta_snapshot-1000.snapshot`,
+	Args:    cobra.NoArgs,
+	Run:     runSnapshotImport,
+}
+
+func init() {
+	snapshotExportCmd.Flags().Uint64Var(&snapshotExportHeight, "height", 0, "height of the state to export, the last finalized block if 0")
+	snapshotExportCmd.Flags().StringVar(&snapshotExportOut, "out", "", "path of the snapshot file")
+	snapshotExportCmd.MarkFlagRequired("out")
+	snapshotImportCmd.Flags().StringVar(&snapshotImportIn, "in", "", "path of the snapshot file")
+	snapshotImportCmd.MarkFlagRequired("in")
+	snapshotCmd.AddCommand(snapshotExportCmd)
+	snapshotCmd.AddCommand(snapshotImportCmd)
+	RootCmd.AddCommand(snapshotCmd)
+}
+
+func runSnapshotExport(cmd *cobra.Command, args []string) {
+	lock := lockDataDir()
+	defer lock.Release()
+	db := openDatabase()
+	defer db.Close()
+	root := loadRootBlock()
+	chain := blockchain.NewChain(root.ChainID, kvstore.NewKVStore(db), root)
+
+	header, err := snapshot.ExportSnapshotFile(db, chain, snapshotExportHeight, snapshotExportOut)
+	if err != nil {
+		log.Fatalf("Failed to export snapshot, err: %v", err)
+	}
+	log.Infof("Exported the state of chain %v at height %v to %v, block %v, state root %v",
+		header.ChainID, header.Height, snapshotExportOut, header.BlockHash.Hex(), header.StateRoot.Hex())
+}
+
+func runSnapshotImport(cmd *cobra.Command, args []string) {
+	if len(snapshotPath) == 0 {
+		snapshotPath = path.Join(cfgPath, "snapshot")
+	}
+	if _, err := os.Stat(snapshotPath); err == nil {
+		log.Fatalf("The snapshot %v already exists", snapshotPath)
+	}
+
+	lock := lockDataDir()
+	defer lock.Release()
+
+	header, err := snapshot.ImportSnapshotFile(snapshotImportIn, snapshotPath)
+	if err != nil {
+		log.Fatalf("Failed to import snapshot, err: %v", err)
+	}
+	log.Infof("Imported the state of chain %v at height %v to %v, block %v, state root %v",
+		header.ChainID, header.Height, snapshotPath, header.BlockHash.Hex(), header.StateRoot.Hex())
+}
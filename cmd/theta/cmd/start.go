@@ -17,9 +17,15 @@ import (
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/node"
+	"github.com/thetatoken/theta/p2p/connection"
 	"github.com/thetatoken/theta/p2p/messenger"
+	"github.com/thetatoken/theta/p2p/netutil"
+	"github.com/thetatoken/theta/p2p/reputation"
 	"github.com/thetatoken/theta/snapshot"
 	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/dirlock"
+	"github.com/thetatoken/theta/store/schema"
+	"github.com/thetatoken/theta/version"
 	ks "github.com/thetatoken/theta/wallet/softwallet/keystore"
 )
 
@@ -31,10 +37,20 @@ var startCmd = &cobra.Command{
 }
 
 func init() {
+	startCmd.Flags().Bool("read-only", false, "run the node without a signing key, it syncs and serves RPC but never votes or proposes")
+	viper.BindPFlag(common.CfgNodeReadOnly, startCmd.Flags().Lookup("read-only"))
 	RootCmd.AddCommand(startCmd)
 }
 
 func runStart(cmd *cobra.Command, args []string) {
+	info := version.GetInfo()
+	log.WithFields(log.Fields{
+		"version":   info.Version,
+		"gitHash":   info.GitHash,
+		"timestamp": info.Timestamp,
+		"features":  info.Features,
+	}).Info("Starting Theta node")
+
 	port := viper.GetInt(common.CfgP2PPort)
 
 	// Parse seeds and filter out empty item.
@@ -42,28 +58,29 @@ func runStart(cmd *cobra.Command, args []string) {
 		return c == ','
 	}
 	peerSeeds := strings.FieldsFunc(viper.GetString(common.CfgP2PSeeds), f)
-	privKey, err := loadOrCreateKey()
-	if err != nil {
-		log.Fatalf("Failed to load or create key: %v", err)
-	}
 
-	network := newMessenger(privKey, peerSeeds, port)
-	mainDBPath := path.Join(cfgPath, "db", "main")
-	refDBPath := path.Join(cfgPath, "db", "ref")
-	db, err := backend.NewLDBDatabase(mainDBPath, refDBPath, 256, 0)
-	if err != nil {
-		log.Fatalf("Failed to connect to the db. main: %v, ref: %v, err: %v",
-			mainDBPath, refDBPath, err)
+	// A read-only node has no signing key, it only uses an ephemeral key as its P2P identity
+	var privKey, nodeKey *crypto.PrivateKey
+	var err error
+	if viper.GetBool(common.CfgNodeReadOnly) {
+		log.Info("Running in read-only mode, the node will not vote or propose blocks")
+		nodeKey, _, err = crypto.GenerateKeyPair()
+		if err != nil {
+			log.Fatalf("Failed to generate node key: %v", err)
+		}
+	} else {
+		privKey, err = loadOrCreateKey()
+		if err != nil {
+			log.Fatalf("Failed to load or create key: %v", err)
+		}
+		nodeKey = privKey
 	}
 
-	if len(snapshotPath) == 0 {
-		snapshotPath = path.Join(cfgPath, "snapshot")
-	}
-	snapshotBlockHeader, err := snapshot.ValidateSnapshot(snapshotPath)
-	if err != nil {
-		log.Fatalf("Snapshot validation failed, err: %v", err)
-	}
-	root := &core.Block{BlockHeader: snapshotBlockHeader}
+	lock := lockDataDir()
+	defer lock.Release()
+	network := newMessenger(nodeKey, peerSeeds, port)
+	db := openDatabase()
+	root := loadRootBlock()
 
 	params := &node.Params{
 		ChainID:      root.ChainID,
@@ -72,6 +89,9 @@ func runStart(cmd *cobra.Command, args []string) {
 		Network:      network,
 		DB:           db,
 		SnapshotPath: snapshotPath,
+		WALPath:      path.Join(cfgPath, "db", "consensus.wal"),
+		ProfilePath:  path.Join(cfgPath, "profiles"),
+		TxIndexPath:  path.Join(cfgPath, "db", "txindex"),
 	}
 	n := node.NewNode(params)
 
@@ -81,7 +101,11 @@ func runStart(cmd *cobra.Command, args []string) {
 	signal.Notify(c, os.Interrupt)
 	done := make(chan struct{})
 	go func() {
-		<-c
+		select {
+		case <-c:
+		case <-lock.Lost():
+			log.Error("Another process took over the data directory, shutting down")
+		}
 		signal.Stop(c)
 		cancel()
 		// Wait at most 5 seconds before forcefully shutting down.
@@ -102,6 +126,81 @@ func runStart(cmd *cobra.Command, args []string) {
 	printExitBanner()
 }
 
+// lockDataDir locks the data directory of the node, so that two processes never open the
+// database at the same time.
+func lockDataDir() *dirlock.Lock {
+	dataDir := path.Join(cfgPath, "db")
+	lock, err := dirlock.Acquire(dataDir, forceUnlock)
+	if err != nil {
+		log.Fatalf("Failed to lock the data directory %v: %v", dataDir, err)
+	}
+	return lock
+}
+
+// openDatabase opens the database of the node under the config path, and migrates it to the
+// current schema version if needed.
+func openDatabase() *backend.LDBDatabase {
+	mainDBPath := path.Join(cfgPath, "db", "main")
+	refDBPath := path.Join(cfgPath, "db", "ref")
+	db, err := backend.NewLDBDatabase(mainDBPath, refDBPath, 256, 0)
+	if err != nil {
+		log.Fatalf("Failed to connect to the db. main: %v, ref: %v, err: %v",
+			mainDBPath, refDBPath, err)
+	}
+
+	needsMigration, err := schema.NeedsMigration(db)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if !needsMigration {
+		return db
+	}
+
+	needsBackup, err := schema.NeedsBackup(db)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if needsBackup && viper.GetBool(common.CfgStorageMigrationBackup) {
+		version, _ := schema.GetVersion(db)
+		backupDir := path.Join(cfgPath, "backup", fmt.Sprintf("db-schema-v%v-%v", version, time.Now().Unix()))
+		db.Close()
+		if err := schema.Backup([]string{mainDBPath, refDBPath}, backupDir); err != nil {
+			log.Fatalf("Failed to back up the db before migrating it, err: %v", err)
+		}
+		log.Infof("Backed up the db to %v", backupDir)
+		db, err = backend.NewLDBDatabase(mainDBPath, refDBPath, 256, 0)
+		if err != nil {
+			log.Fatalf("Failed to connect to the db. main: %v, ref: %v, err: %v",
+				mainDBPath, refDBPath, err)
+		}
+	}
+	if err := schema.Migrate(db); err != nil {
+		log.Fatalf("%v", err)
+	}
+	return db
+}
+
+// loadRootBlock returns the root block of the chain, i.e. the last block of the snapshot.
+func loadRootBlock() *core.Block {
+	if len(snapshotPath) == 0 {
+		snapshotPath = path.Join(cfgPath, "snapshot")
+	}
+	snapshotBlockHeader, err := snapshot.ValidateSnapshot(snapshotPath)
+	if err != nil {
+		log.Fatalf("Snapshot validation failed, err: %v", err)
+	}
+	if network := viper.GetString(common.CfgNetwork); len(network) != 0 {
+		profile, err := core.GetNetworkProfile(network)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := profile.VerifyBlock(snapshotBlockHeader); err != nil {
+			log.Fatalf("The snapshot does not belong to network %v, err: %v", network, err)
+		}
+	}
+	return &core.Block{BlockHeader: snapshotBlockHeader}
+}
+
 func loadOrCreateKey() (*crypto.PrivateKey, error) {
 	keysDir := path.Join(cfgPath, "key")
 	keystore, err := ks.NewKeystoreEncrypted(keysDir, ks.StandardScryptN, ks.StandardScryptP)
@@ -189,8 +288,32 @@ func newMessenger(privKey *crypto.PrivateKey, seedPeerNetAddresses []string, por
 		"pubKey":  fmt.Sprintf("%v", privKey.PublicKey().ToBytes()),
 		"address": fmt.Sprintf("%v", privKey.PublicKey().Address()),
 	}).Info("Using key")
+	channelSendRates, err := connection.ParseChannelRates(viper.GetString(common.CfgP2PChannelSendRates))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Invalid P2P channel send rates")
+	}
+	connection.SetBandwidthLimits(connection.BandwidthLimits{
+		PeerSendRate:     viper.GetInt64(common.CfgP2PPeerSendRate),
+		PeerRecvRate:     viper.GetInt64(common.CfgP2PPeerRecvRate),
+		ChannelSendRates: channelSendRates,
+	})
+
 	msgrConfig := messenger.GetDefaultMessengerConfig()
 	msgrConfig.SetAddressBookFilePath(path.Join(cfgPath, "addrbook.json"))
+	msgrConfig.SetBanListFilePath(path.Join(cfgPath, "banlist.json"))
+	msgrConfig.SetReputationConfig(reputation.Config{
+		ThrottleScore: float64(viper.GetInt(common.CfgP2PPeerThrottleScore)),
+		BanScore:      float64(viper.GetInt(common.CfgP2PPeerBanScore)),
+		BanDuration:   time.Duration(viper.GetInt(common.CfgP2PPeerBanDuration)) * time.Second,
+	})
+	listenAddrs, err := netutil.ParseListenAddresses(viper.GetString(common.CfgP2PListenAddresses))
+	if err != nil {
+		log.WithFields(log.Fields{"err": err}).Fatal("Invalid P2P listen addresses")
+	}
+	msgrConfig.SetListenAddresses(listenAddrs)
+	msgrConfig.SetSkipNAT(!viper.GetBool(common.CfgP2PNATEnabled))
+	msgrConfig.SetNodeKey(privKey)
+	msgrConfig.SetSecureTransport(viper.GetString(common.CfgP2PSecureTransport))
 	messenger, err := messenger.CreateMessenger(privKey.PublicKey(), seedPeerNetAddresses, port, msgrConfig)
 	if err != nil {
 		log.WithFields(log.Fields{"err": err}).Fatal("Failed to create PeerDiscoveryManager instance")
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/snapshot"
+	ks "github.com/thetatoken/theta/wallet/softwallet/keystore"
+)
+
+var (
+	testnetNumValidators int
+	testnetOutputDir     string
+	testnetChainID       string
+	testnetSeed          string
+	testnetPassword      string
+	testnetHost          string
+	testnetP2PPort       int
+	testnetRPCPort       int
+	testnetGenesisTime   int64
+	testnetStateShards   uint64
+	testnetSlashing      string
+	testnetReviewWindow  uint64
+	testnetDockerImage   string
+)
+
+// testnetCmd represents the testnet command
+var testnetCmd = &cobra.Command{
+	Use:   "testnet",
+	Short: "Manage local multi-node test networks.",
+}
+
+// testnetInitCmd represents the testnet init command
+var testnetInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate the validator keys, the genesis and the node configs of a private network.",
+	Long: `Generate the validator keys, the genesis snapshot staking the validators, a config directory per node, a peer list
+and a docker-compose file, so that a multi-node private network can be launched right away. The keys are derived from
+the seed if given, so that the same seed and genesis time always produce the same network.`,
+	Example: `theta testnet init --validators=4 --output=./testnet --seed=mynet --genesis-time=1546300800`,
+	Run:     runTestnetInit,
+}
+
+func init() {
+	testnetInitCmd.Flags().IntVar(&testnetNumValidators, "validators", 4, "number of validators")
+	testnetInitCmd.Flags().StringVar(&testnetOutputDir, "output", "./testnet", "directory to write the network to")
+	testnetInitCmd.Flags().StringVar(&testnetChainID, "chain-id", "local_chain", "chainID of the network")
+	testnetInitCmd.Flags().StringVar(&testnetSeed, "seed", "", "seed to derive the validator keys from, random keys if empty")
+	testnetInitCmd.Flags().StringVar(&testnetPassword, "password", "qwertyuiop", "password encrypting the validator keys")
+	testnetInitCmd.Flags().StringVar(&testnetHost, "host", "127.0.0.1", "host the nodes are reachable at")
+	testnetInitCmd.Flags().IntVar(&testnetP2PPort, "p2p-port", 12000, "P2P port of the first node, incremented for each node")
+	testnetInitCmd.Flags().IntVar(&testnetRPCPort, "rpc-port", 16888, "RPC port of the first node, incremented for each node")
+	testnetInitCmd.Flags().Int64Var(&testnetGenesisTime, "genesis-time", 0, "unix timestamp of the genesis block, the current time if 0")
+	testnetInitCmd.Flags().Uint64Var(&testnetStateShards, "state-shards", 0, "number of shards the accounts are partitioned into, fixed at genesis, 0 to not shard the accounts")
+	testnetInitCmd.Flags().StringVar(&testnetSlashing, "slashing", "", "slashing of the overspent reserved funds: \"immediate\", \"council\" for the validators to review the slashes, or empty to disable it")
+	testnetInitCmd.Flags().Uint64Var(&testnetReviewWindow, "slash-review-window", 1000, "number of blocks the council has to review a slash before the slashed funds are burned")
+	testnetInitCmd.Flags().StringVar(&testnetDockerImage, "docker-image", "theta", "docker image running the nodes in docker-compose.yml")
+	testnetCmd.AddCommand(testnetInitCmd)
+	RootCmd.AddCommand(testnetCmd)
+}
+
+// testnetNode is a validator node of the generated network
+type testnetNode struct {
+	name    string
+	dir     string
+	privKey *crypto.PrivateKey
+	p2pPort int
+	rpcPort int
+}
+
+func (n *testnetNode) address() common.Address {
+	return n.privKey.PublicKey().Address()
+}
+
+func (n *testnetNode) p2pAddress() string {
+	return fmt.Sprintf("%v:%v", testnetHost, n.p2pPort)
+}
+
+func runTestnetInit(cmd *cobra.Command, args []string) {
+	if testnetNumValidators < 1 {
+		log.Fatalf("At least 1 validator is required, got %v", testnetNumValidators)
+	}
+	if testnetP2PPort+testnetNumValidators > 65536 || testnetRPCPort+testnetNumValidators > 65536 {
+		log.Fatalf("The ports of %v nodes exceed the port range", testnetNumValidators)
+	}
+	if _, err := os.Stat(testnetOutputDir); !os.IsNotExist(err) {
+		log.WithFields(log.Fields{"err": err, "path": testnetOutputDir}).Fatal("Folder already exists!")
+	}
+	if err := os.MkdirAll(testnetOutputDir, 0700); err != nil {
+		log.WithFields(log.Fields{"err": err, "path": testnetOutputDir}).Fatal("Failed to create output folder")
+	}
+
+	nodes := []*testnetNode{}
+	for i := 0; i < testnetNumValidators; i++ {
+		privKey, err := deriveValidatorKey(testnetSeed, i)
+		if err != nil {
+			log.Fatalf("Failed to generate the key of validator %v, err: %v", i, err)
+		}
+		name := fmt.Sprintf("node%v", i)
+		nodes = append(nodes, &testnetNode{
+			name:    name,
+			dir:     path.Join(testnetOutputDir, name),
+			privKey: privKey,
+			p2pPort: testnetP2PPort + i,
+			rpcPort: testnetRPCPort + i,
+		})
+	}
+
+	genesisTime := testnetGenesisTime
+	if genesisTime == 0 {
+		genesisTime = time.Now().Unix()
+	}
+	genesisPath := path.Join(testnetOutputDir, "genesis")
+	genesis, err := snapshot.WriteGenesisSnapshot(testnetChainID, genesisTime, testnetStateShards, slashingParams(nodes),
+		genesisAccounts(nodes), genesisPath)
+	if err != nil {
+		log.Fatalf("Failed to write the genesis snapshot, err: %v", err)
+	}
+	genesisHash := genesis.Hash().Hex()
+	genesisBytes, err := ioutil.ReadFile(genesisPath)
+	if err != nil {
+		log.Fatalf("Failed to read the genesis snapshot, err: %v", err)
+	}
+
+	for _, node := range nodes {
+		if err := writeTestnetNode(node, nodes, genesisHash, genesisBytes); err != nil {
+			log.WithFields(log.Fields{"err": err, "path": node.dir}).Fatal("Failed to write node")
+		}
+	}
+	if err := common.WriteFileAtomic(path.Join(testnetOutputDir, "peers.txt"), testnetPeerList(nodes), 0600); err != nil {
+		log.Fatalf("Failed to write the peer list, err: %v", err)
+	}
+	if err := common.WriteFileAtomic(path.Join(testnetOutputDir, "docker-compose.yml"), testnetDockerCompose(nodes), 0600); err != nil {
+		log.Fatalf("Failed to write docker-compose.yml, err: %v", err)
+	}
+
+	fmt.Printf("Generated %v validators of chain %v in %v\n", len(nodes), testnetChainID, testnetOutputDir)
+	fmt.Printf("Genesis block hash: %v\n", genesisHash)
+	fmt.Printf("Launch a node with: theta start --config=%v\n", nodes[0].dir)
+}
+
+// deriveValidatorKey returns the key of the validator with the given index. The keys are derived
+// from the seed if set, and random otherwise.
+func deriveValidatorKey(seed string, index int) (*crypto.PrivateKey, error) {
+	if len(seed) == 0 {
+		privKey, _, err := crypto.GenerateKeyPair()
+		return privKey, err
+	}
+	return crypto.PrivateKeyFromBytes(crypto.Keccak256([]byte(fmt.Sprintf("%v/validator/%v", seed, index))))
+}
+
+// genesisAccounts funds the validators, and stakes the minimum validator deposit for each of
+// them, so that they all have the same voting power.
+func genesisAccounts(nodes []*testnetNode) []snapshot.GenesisAccount {
+	accounts := []snapshot.GenesisAccount{}
+	for _, node := range nodes {
+		theta := new(big.Int).Mul(big.NewInt(10), core.MinValidatorStakeDeposit)
+		accounts = append(accounts, snapshot.GenesisAccount{
+			Address: node.address(),
+			Balance: types.Coins{
+				ThetaWei: theta,
+				TFuelWei: new(big.Int).Mul(big.NewInt(5), theta),
+			},
+			Stake: core.MinValidatorStakeDeposit,
+		})
+	}
+	return accounts
+}
+
+// slashingParams returns the slashing mode selected by the --slashing flag. The slashing council
+// is formed by the validators, a slash review requiring more than two thirds of them.
+func slashingParams(nodes []*testnetNode) *types.SlashingParams {
+	switch testnetSlashing {
+	case "":
+		return nil
+	case "immediate":
+		return &types.SlashingParams{}
+	case "council":
+		council := []common.Address{}
+		for _, node := range nodes {
+			council = append(council, node.address())
+		}
+		return &types.SlashingParams{
+			Council:      council,
+			Threshold:    uint64(len(council)*2/3 + 1),
+			ReviewWindow: testnetReviewWindow,
+		}
+	default:
+		log.Fatalf("Invalid slashing mode: %v, expected immediate, council or empty", testnetSlashing)
+		return nil
+	}
+}
+
+func writeTestnetNode(node *testnetNode, nodes []*testnetNode, genesisHash string, genesis []byte) error {
+	if err := os.MkdirAll(node.dir, 0700); err != nil {
+		return err
+	}
+
+	keystore, err := ks.NewKeystoreEncrypted(path.Join(node.dir, "key"), ks.StandardScryptN, ks.StandardScryptP)
+	if err != nil {
+		return err
+	}
+	if err := keystore.StoreKey(ks.NewKey(node.privKey), testnetPassword); err != nil {
+		return err
+	}
+
+	if err := common.WriteFileAtomic(path.Join(node.dir, "snapshot"), genesis, 0600); err != nil {
+		return err
+	}
+
+	seeds := []string{}
+	for _, peer := range nodes {
+		if peer != node {
+			seeds = append(seeds, peer.p2pAddress())
+		}
+	}
+	config := fmt.Sprintf(`# Theta configuration
+genesis:
+  hash: "%v"
+consensus:
+  maxNumValidators: %v
+p2p:
+  port: %v
+  seeds: %v
+rpc:
+  enabled: true
+  port: %v
+`, genesisHash, len(nodes), node.p2pPort, strings.Join(seeds, ","), node.rpcPort)
+	return common.WriteFileAtomic(path.Join(node.dir, "config.yaml"), []byte(config), 0600)
+}
+
+// testnetPeerList lists the name, the validator address and the P2P address of the nodes
+func testnetPeerList(nodes []*testnetNode) []byte {
+	var buf bytes.Buffer
+	for _, node := range nodes {
+		fmt.Fprintf(&buf, "%v %v %v\n", node.name, node.address().Hex(), node.p2pAddress())
+	}
+	return buf.Bytes()
+}
+
+// testnetDockerCompose runs a container per node. The containers share the network of the host,
+// so that the nodes reach each other at the addresses of the peer list.
+func testnetDockerCompose(nodes []*testnetNode) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "version: \"3\"\nservices:\n")
+	for _, node := range nodes {
+		fmt.Fprintf(&buf, `  %v:
+    image: %v
+    network_mode: host
+    environment:
+      - THETA_PASSWORD=%v
+    volumes:
+      - ./%v:/theta/%v
+    command: sh -c 'echo "$$THETA_PASSWORD" | theta start --config=/theta/%v'
+`, node.name, testnetDockerImage, testnetPassword, node.name, node.name, node.name)
+	}
+	return buf.Bytes()
+}
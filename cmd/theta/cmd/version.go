@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/theta/version"
 )
 
+var versionJSON bool
+
 // versionCmd represents the version command
 var versionCmd = &cobra.Command{
 	Use:   "version",
@@ -15,9 +18,20 @@ var versionCmd = &cobra.Command{
 }
 
 func init() {
+	versionCmd.Flags().BoolVar(&versionJSON, "json", false, "print the build information in JSON")
 	RootCmd.AddCommand(versionCmd)
 }
 
 func runVersion(cmd *cobra.Command, args []string) {
-	fmt.Printf("Version %v %s\nBuilt at %s\n", version.Version, version.GitHash, version.Timestamp)
+	info := version.GetInfo()
+	if !versionJSON {
+		fmt.Println(info)
+		return
+	}
+	json, err := json.MarshalIndent(info, "", "    ")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(json))
 }
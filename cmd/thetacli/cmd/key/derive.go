@@ -0,0 +1,48 @@
+package key
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/wallet"
+	sw "github.com/thetatoken/theta/wallet/softwallet"
+	wtypes "github.com/thetatoken/theta/wallet/types"
+)
+
+// deriveCmd derives the key at the given path from the seed of the mnemonic
+var deriveCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Derive a key from the mnemonic",
+	Long: `Derive the key at the given BIP-44 path from the seed of the mnemonic created or recovered before.
+The password is the one of the mnemonic.`,
+	Example: "thetacli key derive \"m/44'/60'/0'/0/1\"",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 1 {
+			utils.Error("Usage: thetacli key derive <path>\n")
+		}
+		path, err := wtypes.ParseDerivationPath(args[0])
+		if err != nil {
+			utils.Error("Failed to parse derivation path: %v\n", err)
+		}
+
+		cfgPath := cmd.Flag("config").Value.String()
+		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+		if err != nil {
+			utils.Error("Failed to open wallet: %v\n", err)
+		}
+
+		prompt := fmt.Sprintf("Please enter password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.Error("Failed to get password: %v\n", err)
+		}
+
+		address, err := wallet.(*sw.SoftWallet).DeriveKey(path, password)
+		if err != nil {
+			utils.Error("Failed to derive key: %v\n", err)
+		}
+
+		fmt.Printf("Successfully derived key %v: %v\n", path, utils.FormatAddress(address))
+	},
+}
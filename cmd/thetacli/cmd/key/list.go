@@ -28,7 +28,7 @@ var listCmd = &cobra.Command{
 		}
 
 		for _, keyAddress := range keyAddresses {
-			fmt.Printf("%s\n", keyAddress.Hex())
+			fmt.Printf("%s\n", utils.FormatAddress(keyAddress))
 		}
 	},
 }
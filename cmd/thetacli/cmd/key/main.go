@@ -16,4 +16,6 @@ func init() {
 	KeyCmd.AddCommand(listCmd)
 	KeyCmd.AddCommand(deleteCmd)
 	KeyCmd.AddCommand(passwordCmd)
+	KeyCmd.AddCommand(recoverCmd)
+	KeyCmd.AddCommand(deriveCmd)
 }
@@ -6,15 +6,19 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
 	"github.com/thetatoken/theta/wallet"
+	sw "github.com/thetatoken/theta/wallet/softwallet"
 	wtypes "github.com/thetatoken/theta/wallet/types"
 )
 
+var mnemonicFlag bool
+
 // newCmd generates a new key
 var newCmd = &cobra.Command{
-	Use:     "new",
-	Short:   "Generates a new private key",
-	Long:    `Generates a new private key.`,
-	Example: "thetacli key new",
+	Use:   "new",
+	Short: "Generates a new private key",
+	Long: `Generates a new private key. With --mnemonic, generates a mnemonic, the backup phrase of all the keys
+derived from it, and the key of its first account.`,
+	Example: "thetacli key new --mnemonic",
 	Run: func(cmd *cobra.Command, args []string) {
 		cfgPath := cmd.Flag("config").Value.String()
 		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
@@ -28,11 +32,25 @@ var newCmd = &cobra.Command{
 			utils.Error("Failed to get password: %v\n", err)
 		}
 
+		if mnemonicFlag {
+			mnemonic, address, err := wallet.(*sw.SoftWallet).NewMnemonic(password)
+			if err != nil {
+				utils.Error("Failed to generate new mnemonic: %v\n", err)
+			}
+			fmt.Printf("Successfully created key: %v\n", utils.FormatAddress(address))
+			fmt.Printf("Please write down the mnemonic and keep it safe, it recovers all the keys derived from it:\n\n%v\n", mnemonic)
+			return
+		}
+
 		address, err := wallet.NewKey(password)
 		if err != nil {
 			utils.Error("Failed to generate new key: %v\n", err)
 		}
 
-		fmt.Printf("Successfully created key: %v\n", address.Hex())
+		fmt.Printf("Successfully created key: %v\n", utils.FormatAddress(address))
 	},
 }
+
+func init() {
+	newCmd.Flags().BoolVar(&mnemonicFlag, "mnemonic", false, "Generate a mnemonic and derive the key from it")
+}
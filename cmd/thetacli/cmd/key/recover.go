@@ -4,14 +4,51 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/wallet"
+	sw "github.com/thetatoken/theta/wallet/softwallet"
+	wtypes "github.com/thetatoken/theta/wallet/types"
 )
 
 // recoverCmd recovers the key from the given seed phrase
 var recoverCmd = &cobra.Command{
 	Use:   "recover",
 	Short: "Recover a key from seed phrase",
-	Long:  `Recover a key from seed phrase.`,
+	Long: `Recover a key from seed phrase. The key of the first account is recovered, the other accounts
+are recovered with the derive command.`,
+	Example: "thetacli key recover --mnemonic",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("key recover not supported yet")
+		if !mnemonicFlag {
+			utils.Error("Only the recovery from a mnemonic is supported, please specify --mnemonic\n")
+		}
+
+		cfgPath := cmd.Flag("config").Value.String()
+		wallet, err := wallet.OpenWallet(cfgPath, wtypes.WalletTypeSoft, true)
+		if err != nil {
+			utils.Error("Failed to open wallet: %v\n", err)
+		}
+
+		prompt := fmt.Sprintf("Please enter the mnemonic: ")
+		mnemonic, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.Error("Failed to get mnemonic: %v\n", err)
+		}
+
+		prompt = fmt.Sprintf("Please enter password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.Error("Failed to get password: %v\n", err)
+		}
+
+		address, err := wallet.(*sw.SoftWallet).RecoverFromMnemonic(mnemonic, password)
+		if err != nil {
+			utils.Error("Failed to recover key: %v\n", err)
+		}
+
+		fmt.Printf("Successfully recovered key: %v\n", utils.FormatAddress(address))
 	},
 }
+
+func init() {
+	recoverCmd.Flags().BoolVar(&mnemonicFlag, "mnemonic", false, "Recover the key from a mnemonic")
+}
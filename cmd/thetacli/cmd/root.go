@@ -16,6 +16,7 @@ import (
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/key"
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/query"
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/tx"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
 )
 
 var cfgPath string
@@ -40,6 +41,8 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	RootCmd.PersistentFlags().StringVar(&cfgPath, "config", getDefaultConfigPath(), fmt.Sprintf("config path (default is %s)", getDefaultConfigPath()))
+	RootCmd.PersistentFlags().String("network", "", "network of the wallet, which sets the address prefix and the allowed chainID")
+	viper.BindPFlag(utils.CfgNetwork, RootCmd.PersistentFlags().Lookup("network"))
 
 	RootCmd.AddCommand(daemon.DaemonCmd)
 	RootCmd.AddCommand(key.KeyCmd)
@@ -63,6 +66,11 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
+
+	if err := utils.RegisterNetworks(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
 
 func getDefaultConfigPath() string {
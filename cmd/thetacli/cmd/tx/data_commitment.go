@@ -0,0 +1,101 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// dataCommitmentCmd represents the data commitment command, which anchors the Merkle root of off-chain data to the chain.
+// Example:
+//		thetacli tx data_commitment --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --root=0x5d0b1ab8d3b8b8b1c2f3e7c5a2d4e6f8091a2b3c4d5e6f708192a3b4c5d6e7f8 --num_leaves=12 --uri=https://example.com/manifests --seq=8
+var dataCommitmentCmd = &cobra.Command{
+	Use:     "data_commitment",
+	Short:   "Anchor the Merkle root of off-chain data to the chain",
+	Example: `thetacli tx data_commitment --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --root=0x5d0b1ab8d3b8b8b1c2f3e7c5a2d4e6f8091a2b3c4d5e6f708192a3b4c5d6e7f8 --num_leaves=12 --uri=https://example.com/manifests --seq=8`,
+	Run:     doDataCommitmentCmd,
+}
+
+func doDataCommitmentCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
+	if err != nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	root := common.HexToHash(rootFlag)
+	if root.IsEmpty() {
+		utils.Error("Failed to parse root")
+	}
+	if len(uriFlag) > types.MaxDataCommitmentURILength {
+		utils.Error("URI too long, at most %v bytes allowed\n", types.MaxDataCommitmentURILength)
+	}
+
+	dataCommitmentTx := &types.DataCommitmentTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Owner: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Root:      root,
+		NumLeaves: numLeavesFlag,
+		URI:       uriFlag,
+	}
+
+	sig, err := wallet.Sign(fromAddress, dataCommitmentTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	dataCommitmentTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(dataCommitmentTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	fmt.Printf("Successfully broadcasted transaction.\n")
+}
+
+func init() {
+	dataCommitmentCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	dataCommitmentCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the owner of the data")
+	dataCommitmentCmd.Flags().StringVar(&rootFlag, "root", "", "Merkle root of the data")
+	dataCommitmentCmd.Flags().Uint64Var(&numLeavesFlag, "num_leaves", 1, "Number of the pieces of data committed")
+	dataCommitmentCmd.Flags().StringVar(&uriFlag, "uri", "", "Location of the data, optional")
+	dataCommitmentCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
+	dataCommitmentCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	dataCommitmentCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	dataCommitmentCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+
+	dataCommitmentCmd.MarkFlagRequired("chain")
+	dataCommitmentCmd.MarkFlagRequired("from")
+	dataCommitmentCmd.MarkFlagRequired("root")
+	dataCommitmentCmd.MarkFlagRequired("seq")
+}
@@ -58,6 +58,7 @@ func doDepositStakeCmd(cmd *cobra.Command, args []string) {
 	}
 
 	depositStakeTx := &types.DepositStakeTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
 		Fee: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			TFuelWei: fee,
@@ -99,7 +100,8 @@ func init() {
 	depositStakeCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
 	depositStakeCmd.Flags().StringVar(&stakeInThetaFlag, "stake", "5000000", "Theta amount to stake")
 	depositStakeCmd.Flags().Uint8Var(&purposeFlag, "purpose", 0, "Purpose of staking")
-	depositStakeCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	depositStakeCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	depositStakeCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
 
 	depositStakeCmd.MarkFlagRequired("chain")
 	depositStakeCmd.MarkFlagRequired("source")
@@ -27,11 +27,24 @@ var (
 	gasLimitFlag                 uint64
 	dataFlag                     string
 	walletFlag                   string
+	pathFlag                     string
 	stakeInThetaFlag             string
 	purposeFlag                  uint8
 	sourceFlag                   string
 	holderFlag                   string
 	asyncFlag                    bool
+	operatorFlag                 string
+	spendLimitInTFuelFlag        string
+	endpointsFlag                []string
+	expiresAtFlag                uint64
+	recipientsFlag               string
+	memoFlag                     string
+	rootFlag                     string
+	numLeavesFlag                uint64
+	uriFlag                      string
+	thresholdFlag                uint64
+	signerFlag                   string
+	outFlag                      string
 )
 
 // TxCmd represents the Tx command
@@ -43,10 +56,15 @@ var TxCmd = &cobra.Command{
 
 func init() {
 	TxCmd.AddCommand(sendCmd)
+	TxCmd.AddCommand(multiSendCmd)
 	TxCmd.AddCommand(reserveFundCmd)
 	//TxCmd.AddCommand(releaseFundCmd) // No need for releaseFundCmd since auto-release is already implemented
 	TxCmd.AddCommand(splitRuleCmd)
 	TxCmd.AddCommand(smartContractCmd)
 	TxCmd.AddCommand(depositStakeCmd)
 	TxCmd.AddCommand(withdrawStakeCmd)
+	TxCmd.AddCommand(setAccountOperatorCmd)
+	TxCmd.AddCommand(registerNodeAddressCmd)
+	TxCmd.AddCommand(dataCommitmentCmd)
+	TxCmd.AddCommand(multiSigCmd)
 }
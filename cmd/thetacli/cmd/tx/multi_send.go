@@ -0,0 +1,157 @@
+package tx
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+
+	"github.com/ybbus/jsonrpc"
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// multiSendCmd represents the multi-send command. Each line of the recipients file reads
+// "address,theta,tfuel[,memo]", e.g. "9F1233798E905E173560071255140b4A8aBd3Ec6,0,1.5,payout-0001".
+// Example:
+//		thetacli tx multisend --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --recipients=payouts.csv --seq=1
+var multiSendCmd = &cobra.Command{
+	Use:     "multisend",
+	Short:   "Send tokens to many recipients in one transaction",
+	Example: `thetacli tx multisend --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --recipients=payouts.csv --seq=1`,
+	Run:     doMultiSendCmd,
+}
+
+func doMultiSendCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
+	if err != nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	builder := types.NewMultiSendTxBuilder(fromAddress, seqFlag)
+	if err := readMultiSendRecipients(recipientsFlag, builder); err != nil {
+		utils.Error("Failed to read the recipients: %v\n", err)
+	}
+	if len(feeFlag) != 0 {
+		fee, ok := types.ParseCoinAmount(feeFlag)
+		if !ok {
+			utils.Error("Failed to parse fee")
+		}
+		builder.SetFee(fee)
+	}
+	multiSendTx, err := builder.Build()
+	if err != nil {
+		utils.Error("Failed to build transaction: %v\n", err)
+	}
+	multiSendTx.SetExpiryHeight(expiresAtFlag)
+
+	sig, err := wallet.Sign(fromAddress, multiSendTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	multiSendTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(multiSendTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	var res *jsonrpc.RPCResponse
+	if asyncFlag {
+		res, err = client.Call("theta.BroadcastRawTransactionAsync", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	} else {
+		res, err = client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	}
+
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction paying %v recipients with fee %v:\n%s\n",
+		len(multiSendTx.Outputs), multiSendTx.Fee, formatted)
+}
+
+// readMultiSendRecipients adds the recipients listed in the CSV file to the transaction
+func readMultiSendRecipients(path string, builder *types.MultiSendTxBuilder) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(record) < 3 || len(record) > 4 {
+			return fmt.Errorf("line %v: expected address,theta,tfuel[,memo]", line)
+		}
+		address := strings.TrimSpace(record[0])
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("line %v: invalid address %v", line, address)
+		}
+		theta, ok := types.ParseCoinAmount(strings.TrimSpace(record[1]))
+		if !ok {
+			return fmt.Errorf("line %v: failed to parse theta amount", line)
+		}
+		tfuel, ok := types.ParseCoinAmount(strings.TrimSpace(record[2]))
+		if !ok {
+			return fmt.Errorf("line %v: failed to parse tfuel amount", line)
+		}
+		var memo []byte
+		if len(record) == 4 {
+			memo = []byte(record[3])
+		}
+		coins := types.Coins{ThetaWei: theta, TFuelWei: tfuel}
+		if err := builder.AddOutput(common.HexToAddress(address), coins, memo); err != nil {
+			return fmt.Errorf("line %v: %v", line, err)
+		}
+	}
+}
+
+func init() {
+	multiSendCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	multiSendCmd.Flags().StringVar(&fromFlag, "from", "", "Address to send from")
+	multiSendCmd.Flags().StringVar(&recipientsFlag, "recipients", "", "CSV file listing the recipients, one address,theta,tfuel[,memo] per line")
+	multiSendCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	multiSendCmd.Flags().StringVar(&feeFlag, "fee", "", "Fee, defaults to the minimum fee for the number of recipients")
+	multiSendCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	multiSendCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+	multiSendCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
+
+	multiSendCmd.MarkFlagRequired("chain")
+	multiSendCmd.MarkFlagRequired("from")
+	multiSendCmd.MarkFlagRequired("recipients")
+	multiSendCmd.MarkFlagRequired("seq")
+}
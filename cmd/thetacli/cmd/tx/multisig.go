@@ -0,0 +1,301 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// multiSigCmd represents the multisig command. The transactions of a multisig account are created
+// unsigned in a file, which is passed to the signers to sign. The partially signed files are then
+// combined, and the transaction is broadcasted once enough signers have signed.
+// Example:
+//		thetacli tx multisig send --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --tfuel=9 --seq=3 --out=payout.tx
+//		thetacli tx multisig sign payout.tx --chain="mainnet" --signer=A1d1c3E9bA39d5B9ef3e4d87D0DE4A9a4c4A3bc3 --out=payout.alice.tx
+//		thetacli tx multisig combine payout.alice.tx payout.bob.tx --chain="mainnet" --out=payout.signed.tx
+//		thetacli tx multisig broadcast payout.signed.tx
+var multiSigCmd = &cobra.Command{
+	Use:   "multisig",
+	Short: "Create, sign, combine and broadcast the transactions of multisig accounts",
+	Long:  `Create, sign, combine and broadcast the transactions of multisig accounts.`,
+}
+
+// multiSigSendCmd creates an unsigned MultiSigSendTx
+var multiSigSendCmd = &cobra.Command{
+	Use:     "send",
+	Short:   "Create an unsigned transaction sending tokens from a multisig account",
+	Example: `thetacli tx multisig send --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --tfuel=9 --seq=3 --out=payout.tx`,
+	Run:     doMultiSigSendCmd,
+}
+
+// multiSigUpdateSignersCmd creates an unsigned UpdateAccountSignersTx. Omitting the signers removes the signer set.
+var multiSigUpdateSignersCmd = &cobra.Command{
+	Use:     "update_signers",
+	Short:   "Create an unsigned transaction registering the signer set of a multisig account",
+	Example: `thetacli tx multisig update_signers --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --signers=A1d1c3E9bA39d5B9ef3e4d87D0DE4A9a4c4A3bc3,9F1233798E905E173560071255140b4A8aBd3Ec6,70f587259738cB626A1720Af7038B8DcDb6a42a0 --threshold=2 --seq=1 --out=signers.tx`,
+	Run:     doMultiSigUpdateSignersCmd,
+}
+
+// multiSigSignCmd adds the signature of a signer to a transaction file
+var multiSigSignCmd = &cobra.Command{
+	Use:     "sign <file>",
+	Short:   "Sign a multisig transaction",
+	Example: `thetacli tx multisig sign payout.tx --chain="mainnet" --signer=A1d1c3E9bA39d5B9ef3e4d87D0DE4A9a4c4A3bc3 --out=payout.alice.tx`,
+	Args:    cobra.ExactArgs(1),
+	Run:     doMultiSigSignCmd,
+}
+
+// multiSigCombineCmd merges the signatures of several signed copies of the same transaction
+var multiSigCombineCmd = &cobra.Command{
+	Use:     "combine <file> <file>...",
+	Short:   "Combine the signatures of partially signed multisig transactions",
+	Example: `thetacli tx multisig combine payout.alice.tx payout.bob.tx --chain="mainnet" --out=payout.signed.tx`,
+	Args:    cobra.MinimumNArgs(1),
+	Run:     doMultiSigCombineCmd,
+}
+
+// multiSigBroadcastCmd broadcasts a signed multisig transaction
+var multiSigBroadcastCmd = &cobra.Command{
+	Use:     "broadcast <file>",
+	Short:   "Broadcast a signed multisig transaction",
+	Example: `thetacli tx multisig broadcast payout.signed.tx`,
+	Args:    cobra.ExactArgs(1),
+	Run:     doMultiSigBroadcastCmd,
+}
+
+func doMultiSigSendCmd(cmd *cobra.Command, args []string) {
+	theta, ok := types.ParseCoinAmount(thetaAmountFlag)
+	if !ok {
+		utils.Error("Failed to parse theta amount")
+	}
+	tfuel, ok := types.ParseCoinAmount(tfuelAmountFlag)
+	if !ok {
+		utils.Error("Failed to parse tfuel amount")
+	}
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+
+	multiSigSendTx := &types.MultiSigSendTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Input: types.MultiSigInput{
+			Address: utils.ParseAddress(fromFlag),
+			Coins: types.Coins{
+				TFuelWei: new(big.Int).Add(tfuel, fee),
+				ThetaWei: theta,
+			},
+			Sequence: uint64(seqFlag),
+		},
+		Outputs: []types.TxOutput{{
+			Address: utils.ParseAddress(toFlag),
+			Coins: types.Coins{
+				TFuelWei: tfuel,
+				ThetaWei: theta,
+			},
+		}},
+	}
+	writeMultiSigTx(multiSigSendTx, outFlag)
+}
+
+func doMultiSigUpdateSignersCmd(cmd *cobra.Command, args []string) {
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+
+	signers := []common.Address{}
+	for _, signer := range addressesFlag {
+		signers = append(signers, utils.ParseAddress(signer))
+	}
+	if len(signers) != 0 {
+		accountSigners := &types.AccountSigners{Signers: signers, Threshold: thresholdFlag}
+		if err := accountSigners.Validate(); err != nil {
+			utils.Error("Invalid signer set: %v\n", err)
+		}
+	}
+
+	updateAccountSignersTx := &types.UpdateAccountSignersTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Account: types.MultiSigInput{
+			Address:  utils.ParseAddress(fromFlag),
+			Sequence: uint64(seqFlag),
+		},
+		Signers:   signers,
+		Threshold: thresholdFlag,
+	}
+	if len(signers) == 0 {
+		updateAccountSignersTx.Threshold = 0
+	}
+	writeMultiSigTx(updateAccountSignersTx, outFlag)
+}
+
+func doMultiSigSignCmd(cmd *cobra.Command, args []string) {
+	tx := readMultiSigTx(args[0])
+	fmt.Printf("Signing %v\n", tx)
+
+	signer := openSigner(cmd, signerFlag)
+	defer signer.Close()
+
+	sig := signTx(signer, tx.SignBytes(chainIDFlag))
+	tx.GetMultiSigInput().AddSignature(signer.Address(), sig)
+
+	out := outFlag
+	if len(out) == 0 {
+		out = args[0]
+	}
+	writeMultiSigTx(tx, out)
+}
+
+func doMultiSigCombineCmd(cmd *cobra.Command, args []string) {
+	utils.VerifyChainID(chainIDFlag)
+
+	combined := readMultiSigTx(args[0])
+	signBytes := combined.SignBytes(chainIDFlag)
+	for _, file := range args {
+		tx := readMultiSigTx(file)
+		if !bytes.Equal(signBytes, tx.SignBytes(chainIDFlag)) {
+			utils.Error("The transaction in %v is not the same as the one in %v\n", file, args[0])
+		}
+		for _, sig := range tx.GetMultiSigInput().Signatures {
+			if !sig.Signature.Verify(signBytes, sig.Signer) {
+				utils.Error("Invalid signature of %v in %v\n", sig.Signer.Hex(), file)
+			}
+			combined.GetMultiSigInput().AddSignature(sig.Signer, sig.Signature)
+		}
+	}
+	writeMultiSigTx(combined, outFlag)
+}
+
+func doMultiSigBroadcastCmd(cmd *cobra.Command, args []string) {
+	tx := readMultiSigTx(args[0])
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	result := &rpc.BroadcastRawTransactionResult{}
+	err = res.GetObject(result)
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	formatted, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		utils.Error("Failed to parse server response: %v\n", err)
+	}
+	fmt.Printf("Successfully broadcasted transaction:\n%s\n", formatted)
+}
+
+// readMultiSigTx reads a multisig transaction from the file, where it is hex encoded
+func readMultiSigTx(file string) types.MultiSigTx {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		utils.Error("Failed to read transaction: %v\n", err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		utils.Error("Failed to decode transaction in %v: %v\n", file, err)
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		utils.Error("Failed to decode transaction in %v: %v\n", file, err)
+	}
+	multiSigTx, ok := tx.(types.MultiSigTx)
+	if !ok {
+		utils.Error("The transaction in %v is not a multisig transaction\n", file)
+	}
+	return multiSigTx
+}
+
+// writeMultiSigTx writes the hex encoded transaction to the file, or to stdout if no file is given
+func writeMultiSigTx(tx types.MultiSigTx, file string) {
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	encoded := hex.EncodeToString(raw)
+	if len(file) == 0 {
+		fmt.Println(encoded)
+		return
+	}
+	if err := ioutil.WriteFile(file, []byte(encoded+"\n"), 0600); err != nil {
+		utils.Error("Failed to write transaction: %v\n", err)
+	}
+	signers := []string{}
+	for _, sig := range tx.GetMultiSigInput().Signatures {
+		signers = append(signers, sig.Signer.Hex())
+	}
+	fmt.Printf("Transaction written to %v, signed by %v\n", file, signers)
+}
+
+func init() {
+	multiSigSendCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the multisig account")
+	multiSigSendCmd.Flags().StringVar(&toFlag, "to", "", "Address to send to")
+	multiSigSendCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	multiSigSendCmd.Flags().StringVar(&thetaAmountFlag, "theta", "0", "Theta amount")
+	multiSigSendCmd.Flags().StringVar(&tfuelAmountFlag, "tfuel", "0", "TFuel amount")
+	multiSigSendCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
+	multiSigSendCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+	multiSigSendCmd.Flags().StringVar(&outFlag, "out", "", "File to write the unsigned transaction to, stdout if empty")
+	multiSigSendCmd.MarkFlagRequired("from")
+	multiSigSendCmd.MarkFlagRequired("to")
+	multiSigSendCmd.MarkFlagRequired("seq")
+
+	multiSigUpdateSignersCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the multisig account")
+	multiSigUpdateSignersCmd.Flags().StringSliceVar(&addressesFlag, "signers", []string{}, "Addresses of the signers, leave empty to remove the signer set")
+	multiSigUpdateSignersCmd.Flags().Uint64Var(&thresholdFlag, "threshold", 0, "Number of the signers required to sign the transactions")
+	multiSigUpdateSignersCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	multiSigUpdateSignersCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
+	multiSigUpdateSignersCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+	multiSigUpdateSignersCmd.Flags().StringVar(&outFlag, "out", "", "File to write the unsigned transaction to, stdout if empty")
+	multiSigUpdateSignersCmd.MarkFlagRequired("from")
+	multiSigUpdateSignersCmd.MarkFlagRequired("seq")
+
+	multiSigSignCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	multiSigSignCmd.Flags().StringVar(&signerFlag, "signer", "", "Address of the signer")
+	multiSigSignCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	multiSigSignCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the account of the hardware wallet, e.g. m/44'/500'/0'/0/0")
+	multiSigSignCmd.Flags().StringVar(&outFlag, "out", "", "File to write the signed transaction to, the input file if empty")
+	multiSigSignCmd.MarkFlagRequired("chain")
+
+	multiSigCombineCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	multiSigCombineCmd.Flags().StringVar(&outFlag, "out", "", "File to write the combined transaction to, stdout if empty")
+	multiSigCombineCmd.MarkFlagRequired("chain")
+
+	multiSigCmd.AddCommand(multiSigSendCmd)
+	multiSigCmd.AddCommand(multiSigUpdateSignersCmd)
+	multiSigCmd.AddCommand(multiSigSignCmd)
+	multiSigCmd.AddCommand(multiSigCombineCmd)
+	multiSigCmd.AddCommand(multiSigBroadcastCmd)
+}
@@ -0,0 +1,92 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// registerNodeAddressCmd represents the register node address command. Omitting the endpoints removes the registration.
+// Example:
+//		thetacli tx register_node_address --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --endpoints=validator1.example.com:12000,203.0.113.7:12000 --seq=8
+var registerNodeAddressCmd = &cobra.Command{
+	Use:     "register_node_address",
+	Short:   "Publish the network endpoints of a validator",
+	Example: `thetacli tx register_node_address --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --endpoints=validator1.example.com:12000,203.0.113.7:12000 --seq=8`,
+	Run:     doRegisterNodeAddressCmd,
+}
+
+func doRegisterNodeAddressCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
+	if err != nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	if err := core.ValidateNodeEndpoints(endpointsFlag); err != nil {
+		utils.Error("%v\n", err)
+	}
+
+	registerNodeAddressTx := &types.RegisterNodeAddressTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Validator: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Endpoints: endpointsFlag,
+	}
+
+	sig, err := wallet.Sign(fromAddress, registerNodeAddressTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	registerNodeAddressTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(registerNodeAddressTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	fmt.Printf("Successfully broadcasted transaction.\n")
+}
+
+func init() {
+	registerNodeAddressCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	registerNodeAddressCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the validator")
+	registerNodeAddressCmd.Flags().StringSliceVar(&endpointsFlag, "endpoints", []string{}, "Endpoints (host:port) of the validator node, leave empty to remove the registration")
+	registerNodeAddressCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
+	registerNodeAddressCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	registerNodeAddressCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	registerNodeAddressCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+
+	registerNodeAddressCmd.MarkFlagRequired("chain")
+	registerNodeAddressCmd.MarkFlagRequired("from")
+	registerNodeAddressCmd.MarkFlagRequired("seq")
+}
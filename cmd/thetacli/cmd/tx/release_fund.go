@@ -41,6 +41,7 @@ func doReleaseFundCmd(cmd *cobra.Command, args []string) {
 		utils.Error("Failed to parse tfuel amount")
 	}
 	releaseFundTx := &types.ReleaseFundTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
 		Fee: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			TFuelWei: tfuel,
@@ -79,7 +80,8 @@ func init() {
 	releaseFundCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
 	releaseFundCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
 	releaseFundCmd.Flags().Uint64Var(&reserveSeqFlag, "reserve_seq", 1000, "Reserve sequence")
-	releaseFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	releaseFundCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	releaseFundCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
 
 	releaseFundCmd.MarkFlagRequired("chain")
 	releaseFundCmd.MarkFlagRequired("from")
@@ -20,11 +20,13 @@ import (
 // sendCmd represents the send command
 // Example:
 //		thetacli tx send --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --tfuel=9 --seq=1
+//		thetacli tx send --chain="mainnet" --wallet=ledger --path="m/44'/500'/0'/0/0" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --tfuel=9 --seq=1
 var sendCmd = &cobra.Command{
-	Use:     "send",
-	Short:   "Send tokens",
-	Example: `thetacli tx send --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --tfuel=9 --seq=1`,
-	Run:     doSendCmd,
+	Use:   "send",
+	Short: "Send tokens",
+	Example: `thetacli tx send --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --theta=10 --tfuel=9 --seq=1
+	thetacli tx send --chain="mainnet" --wallet=ledger --path="m/44'/500'/0'/0/0" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --to=9F1233798E905E173560071255140b4A8aBd3Ec6 --tfuel=9 --seq=1`,
+	Run: doSendCmd,
 }
 
 func doSendCmd(cmd *cobra.Command, args []string) {
@@ -37,11 +39,9 @@ func doSendCmd(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
-	defer wallet.Lock(fromAddress)
+	signer := openSigner(cmd, fromFlag)
+	defer signer.Close()
+	fromAddress := signer.Address()
 
 	theta, ok := types.ParseCoinAmount(thetaAmountFlag)
 	if !ok {
@@ -55,6 +55,10 @@ func doSendCmd(cmd *cobra.Command, args []string) {
 	if !ok {
 		utils.Error("Failed to parse fee")
 	}
+	if len(memoFlag) != 0 && !cmd.Flags().Changed("fee") {
+		// The memo is charged on top of the minimum fee
+		fee = types.SendTxMinimumFee(len(memoFlag))
+	}
 	inputs := []types.TxInput{{
 		Address: fromAddress,
 		Coins: types.Coins{
@@ -64,25 +68,24 @@ func doSendCmd(cmd *cobra.Command, args []string) {
 		Sequence: uint64(seqFlag),
 	}}
 	outputs := []types.TxOutput{{
-		Address: common.HexToAddress(toFlag),
+		Address: utils.ParseAddress(toFlag),
 		Coins: types.Coins{
 			TFuelWei: tfuel,
 			ThetaWei: theta,
 		},
 	}}
 	sendTx := &types.SendTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
 		Fee: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			TFuelWei: fee,
 		},
 		Inputs:  inputs,
 		Outputs: outputs,
+		Memo:    common.Bytes(memoFlag),
 	}
 
-	sig, err := wallet.Sign(fromAddress, sendTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(signer, sendTx.SignBytes(chainIDFlag))
 	sendTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(sendTx)
@@ -126,7 +129,10 @@ func init() {
 	sendCmd.Flags().StringVar(&thetaAmountFlag, "theta", "0", "Theta amount")
 	sendCmd.Flags().StringVar(&tfuelAmountFlag, "tfuel", "0", "TFuel amount")
 	sendCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
-	sendCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	sendCmd.Flags().StringVar(&memoFlag, "memo", "", fmt.Sprintf("Memo, e.g. to attribute a deposit, at most %v bytes", types.MaxSendTxMemoLength))
+	sendCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	sendCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the account of the hardware wallet, e.g. m/44'/500'/0'/0/0")
+	sendCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
 	sendCmd.Flags().BoolVar(&asyncFlag, "async", false, "block until tx has been included in the blockchain")
 
 	sendCmd.MarkFlagRequired("chain")
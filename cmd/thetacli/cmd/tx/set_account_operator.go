@@ -0,0 +1,103 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc"
+
+	rpcc "github.com/ybbus/jsonrpc"
+)
+
+// setAccountOperatorCmd represents the set account operator command. Omitting the operator revokes the current operator.
+// Example:
+//		thetacli tx set_operator --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --operator=9F1233798E905E173560071255140b4A8aBd3Ec6 --spend_limit=100 --seq=8
+var setAccountOperatorCmd = &cobra.Command{
+	Use:     "set_operator",
+	Short:   "Authorize an operator key to sign service payments for an account",
+	Example: `thetacli tx set_operator --chain="privatenet" --from=2E833968E5bB786Ae419c4d13189fB081Cc43bab --operator=9F1233798E905E173560071255140b4A8aBd3Ec6 --spend_limit=100 --seq=8`,
+	Run:     doSetAccountOperatorCmd,
+}
+
+func doSetAccountOperatorCmd(cmd *cobra.Command, args []string) {
+	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
+	if err != nil {
+		return
+	}
+	defer wallet.Lock(fromAddress)
+
+	fee, ok := types.ParseCoinAmount(feeFlag)
+	if !ok {
+		utils.Error("Failed to parse fee")
+	}
+	spendLimit, ok := types.ParseCoinAmount(spendLimitInTFuelFlag)
+	if !ok {
+		utils.Error("Failed to parse spend limit")
+	}
+
+	operator := common.Address{}
+	if len(operatorFlag) > 0 {
+		operator = common.HexToAddress(operatorFlag)
+	}
+
+	setAccountOperatorTx := &types.SetAccountOperatorTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
+		Fee: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: fee,
+		},
+		Account: types.TxInput{
+			Address:  fromAddress,
+			Sequence: uint64(seqFlag),
+		},
+		Operator: operator,
+		SpendLimit: types.Coins{
+			ThetaWei: new(big.Int).SetUint64(0),
+			TFuelWei: spendLimit,
+		},
+	}
+
+	sig, err := wallet.Sign(fromAddress, setAccountOperatorTx.SignBytes(chainIDFlag))
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	setAccountOperatorTx.SetSignature(fromAddress, sig)
+
+	raw, err := types.TxToBytes(setAccountOperatorTx)
+	if err != nil {
+		utils.Error("Failed to encode transaction: %v\n", err)
+	}
+	signedTx := hex.EncodeToString(raw)
+
+	client := rpcc.NewRPCClient(viper.GetString(utils.CfgRemoteRPCEndpoint))
+
+	res, err := client.Call("theta.BroadcastRawTransaction", rpc.BroadcastRawTransactionArgs{TxBytes: signedTx})
+	if err != nil {
+		utils.Error("Failed to broadcast transaction: %v\n", err)
+	}
+	if res.Error != nil {
+		utils.Error("Server returned error: %v\n", res.Error)
+	}
+	fmt.Printf("Successfully broadcasted transaction.\n")
+}
+
+func init() {
+	setAccountOperatorCmd.Flags().StringVar(&chainIDFlag, "chain", "", "Chain ID")
+	setAccountOperatorCmd.Flags().StringVar(&fromFlag, "from", "", "Address of the account")
+	setAccountOperatorCmd.Flags().StringVar(&operatorFlag, "operator", "", "Address of the operator key, leave empty to revoke the current operator")
+	setAccountOperatorCmd.Flags().StringVar(&spendLimitInTFuelFlag, "spend_limit", "0", "Max amount of TFuel the operator can spend")
+	setAccountOperatorCmd.Flags().StringVar(&feeFlag, "fee", fmt.Sprintf("%dwei", types.MinimumTransactionFeeTFuelWei), "Fee")
+	setAccountOperatorCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
+	setAccountOperatorCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	setAccountOperatorCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
+
+	setAccountOperatorCmd.MarkFlagRequired("chain")
+	setAccountOperatorCmd.MarkFlagRequired("from")
+	setAccountOperatorCmd.MarkFlagRequired("seq")
+}
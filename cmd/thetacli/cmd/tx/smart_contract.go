@@ -39,11 +39,9 @@ var smartContractCmd = &cobra.Command{
 }
 
 func doSmartContractCmd(cmd *cobra.Command, args []string) {
-	wallet, fromAddress, err := walletUnlock(cmd, fromFlag)
-	if err != nil {
-		return
-	}
-	defer wallet.Lock(fromAddress)
+	signer := openSigner(cmd, fromFlag)
+	defer signer.Close()
+	fromAddress := signer.Address()
 
 	value, ok := types.ParseCoinAmount(valueFlag)
 	if !ok {
@@ -51,7 +49,7 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 	}
 
 	from := types.TxInput{
-		Address: common.HexToAddress(fromFlag),
+		Address: fromAddress,
 		Coins: types.Coins{
 			ThetaWei: new(big.Int).SetUint64(0),
 			TFuelWei: value,
@@ -74,6 +72,7 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 	}
 
 	smartContractTx := &types.SmartContractTx{
+		TxExpiry: types.TxExpiry{ExpiresAt: expiresAtFlag},
 		From:     from,
 		To:       to,
 		GasLimit: gasLimitFlag,
@@ -81,10 +80,7 @@ func doSmartContractCmd(cmd *cobra.Command, args []string) {
 		Data:     data,
 	}
 
-	sig, err := wallet.Sign(fromAddress, smartContractTx.SignBytes(chainIDFlag))
-	if err != nil {
-		utils.Error("Failed to sign transaction: %v\n", err)
-	}
+	sig := signTx(signer, smartContractTx.SignBytes(chainIDFlag))
 	smartContractTx.SetSignature(fromAddress, sig)
 
 	raw, err := types.TxToBytes(smartContractTx)
@@ -123,7 +119,9 @@ func init() {
 	smartContractCmd.Flags().Uint64Var(&gasLimitFlag, "gas_limit", 0, "The gas limit")
 	smartContractCmd.Flags().StringVar(&dataFlag, "data", "", "The data for the smart contract")
 	smartContractCmd.Flags().Uint64Var(&seqFlag, "seq", 0, "Sequence number of the transaction")
-	smartContractCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano)")
+	smartContractCmd.Flags().StringVar(&walletFlag, "wallet", "soft", "Wallet type (soft|nano|ledger)")
+	smartContractCmd.Flags().StringVar(&pathFlag, "path", "", "Derivation path of the account of the hardware wallet, e.g. m/44'/500'/0'/0/0")
+	smartContractCmd.Flags().Uint64Var(&expiresAtFlag, "expires_at", 0, "Block height at which the transaction expires if not yet included, 0 for no expiry")
 
 	smartContractCmd.MarkFlagRequired("chain")
 	smartContractCmd.MarkFlagRequired("from")
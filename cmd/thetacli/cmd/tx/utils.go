@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/wallet"
 	wtypes "github.com/thetatoken/theta/wallet/types"
 )
@@ -16,6 +17,7 @@ func walletUnlock(cmd *cobra.Command, addressStr string) (wtypes.Wallet, common.
 	var wallet wtypes.Wallet
 	var address common.Address
 	var err error
+	utils.VerifyChainID(chainIDFlag)
 	walletType := getWalletType(cmd)
 	if walletType == wtypes.WalletTypeSoft {
 		cfgPath := cmd.Flag("config").Value.String()
@@ -71,7 +73,7 @@ func SoftWalletUnlock(cfgPath, addressStr string) (wtypes.Wallet, common.Address
 		return nil, common.Address{}, err
 	}
 
-	address := common.HexToAddress(addressStr)
+	address := utils.ParseAddress(addressStr)
 	err = wallet.Unlock(address, password)
 	if err != nil {
 		fmt.Printf("Failed to unlock address %v: %v\n", address.Hex(), err)
@@ -81,9 +83,60 @@ func SoftWalletUnlock(cfgPath, addressStr string) (wtypes.Wallet, common.Address
 	return wallet, address, nil
 }
 
+// openSigner opens the signer of the address, prompting for the password of the soft wallet. With
+// a hardware wallet, the account is derived at the --path, and must match the address if given.
+func openSigner(cmd *cobra.Command, addressStr string) wtypes.Signer {
+	utils.VerifyChainID(chainIDFlag)
+	if getWalletType(cmd) == wtypes.WalletTypeSoft {
+		prompt := fmt.Sprintf("Please enter password: ")
+		password, err := utils.GetPassword(prompt)
+		if err != nil {
+			utils.Error("Failed to get password: %v\n", err)
+		}
+		cfgPath := cmd.Flag("config").Value.String()
+		address := utils.ParseAddress(addressStr)
+		signer, err := wallet.OpenSoftSigner(cfgPath, address, password)
+		if err != nil {
+			utils.Error("Failed to unlock address %v: %v\n", address.Hex(), err)
+		}
+		return signer
+	}
+
+	var path wtypes.DerivationPath
+	if len(pathFlag) != 0 {
+		var err error
+		if path, err = wtypes.ParseDerivationPath(pathFlag); err != nil {
+			utils.Error("Failed to parse derivation path: %v\n", err)
+		}
+	}
+	signer, err := wallet.OpenLedgerSigner(path)
+	if err != nil {
+		utils.Error("Failed to open hardware wallet: %v\n", err)
+	}
+	if len(addressStr) != 0 && utils.ParseAddress(addressStr) != signer.Address() {
+		signer.Close()
+		utils.Error("The account of the hardware wallet is %v, not %v, please check the derivation path\n",
+			signer.Address().Hex(), addressStr)
+	}
+	log.Infof("Wallet address: %v", signer.Address())
+	return signer
+}
+
+// signTx signs the transaction bytes, asking the user to confirm the transaction on the hardware wallet
+func signTx(signer wtypes.Signer, signBytes common.Bytes) *crypto.Signature {
+	if signer.OnDevice() {
+		fmt.Printf("Please review and confirm the transaction on the device\n")
+	}
+	sig, err := signer.Sign(signBytes)
+	if err != nil {
+		utils.Error("Failed to sign transaction: %v\n", err)
+	}
+	return sig
+}
+
 func getWalletType(cmd *cobra.Command) (walletType wtypes.WalletType) {
 	walletTypeStr := cmd.Flag("wallet").Value.String()
-	if walletTypeStr == "nano" {
+	if walletTypeStr == "nano" || walletTypeStr == "ledger" {
 		walletType = wtypes.WalletTypeCold
 	} else {
 		walletType = wtypes.WalletTypeSoft
@@ -5,9 +5,17 @@ import "github.com/spf13/viper"
 const (
 	CfgRemoteRPCEndpoint = "remoteRPCEndpoint"
 	CfgDebug             = "debug"
+
+	// CfgNetwork selects the network of the wallet, which sets the prefix of the addresses displayed
+	// and the chainID the transactions can be signed for. Any chainID is allowed if not set.
+	CfgNetwork = "network"
+	// CfgNetworks defines custom networks, in the same format as the networks of the node config
+	CfgNetworks = "networks"
 )
 
 func init() {
+	viper.SetDefault(CfgNetwork, "")
+	viper.SetDefault(CfgNetworks, []interface{}{})
 	viper.SetDefault(CfgRemoteRPCEndpoint, "http://localhost:16888/rpc")
 	viper.SetDefault(CfgDebug, false)
 }
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+// RegisterNetworks registers the custom networks defined in the config
+func RegisterNetworks() error {
+	configs := []core.NetworkConfig{}
+	if err := viper.UnmarshalKey(CfgNetworks, &configs); err != nil {
+		return fmt.Errorf("Failed to parse %v: %v", CfgNetworks, err)
+	}
+	return core.RegisterNetworkProfiles(configs)
+}
+
+// GetNetworkProfile returns the profile of the selected network, or nil if no network is selected
+func GetNetworkProfile() *core.NetworkProfile {
+	network := viper.GetString(CfgNetwork)
+	if len(network) == 0 {
+		return nil
+	}
+	profile, err := core.GetNetworkProfile(network)
+	if err != nil {
+		Error("%v\n", err)
+	}
+	return profile
+}
+
+// FormatAddress returns the address as displayed for the selected network
+func FormatAddress(address common.Address) string {
+	if profile := GetNetworkProfile(); profile != nil {
+		return profile.FormatAddress(address)
+	}
+	return address.Hex()
+}
+
+// ParseAddress parses an address displayed for the selected network, and exits if it belongs to
+// another network.
+func ParseAddress(str string) common.Address {
+	profile := GetNetworkProfile()
+	if profile == nil {
+		return common.HexToAddress(str)
+	}
+	address, err := profile.ParseAddress(str)
+	if err != nil {
+		Error("%v\n", err)
+	}
+	return address
+}
+
+// VerifyChainID exits if the chainID is not the one of the selected network, so that transactions
+// are not signed for another network by mistake.
+func VerifyChainID(chainID string) {
+	if profile := GetNetworkProfile(); profile != nil {
+		if err := profile.VerifyChainID(chainID); err != nil {
+			Error("%v\n", err)
+		}
+	}
+}
@@ -5,9 +5,27 @@ import (
 )
 
 const (
+	// CfgNetwork selects a built-in network profile (e.g. mainnet, testnet, privatenet) or one of the
+	// CfgNetworks, which provides the genesis hash, the seeds and the checkpoints of the network.
+	CfgNetwork = "network"
+	// CfgNetworks defines custom networks, e.g. private enterprise ledgers. Each network has a name, a
+	// chainID distinct from the other networks, a genesisHash, and optionally seeds, an addressPrefix
+	// to display the addresses with, and the default p2pPort and rpcPort of its nodes.
+	CfgNetworks = "networks"
+
 	// CfgGenesisHash defines the hash of the genesis block
 	CfgGenesisHash = "genesis.hash"
 
+	// CfgNodeReadOnly runs the node without a signing key. The node syncs, validates and relays
+	// blocks and serves RPC, but never votes or proposes.
+	CfgNodeReadOnly = "node.readOnly"
+
+	// CfgConsensusEngine selects the consensus engine: "bft" for the validator committee, or "solo"
+	// for a single node test chain finalizing the blocks it produces.
+	CfgConsensusEngine = "consensus.engine"
+	// CfgConsensusSoloBlockInterval defines the interval in seconds between the blocks produced by the
+	// solo engine (0 means the blocks are only produced on demand).
+	CfgConsensusSoloBlockInterval = "consensus.soloBlockInterval"
 	// CfgConsensusMaxEpochLength defines the maxium length of an epoch.
 	CfgConsensusMaxEpochLength = "consensus.maxEpochLength"
 	// CfgConsensusMinProposalWait defines the minimal interval between proposals.
@@ -16,6 +34,21 @@ const (
 	CfgConsensusMessageQueueSize = "consensus.messageQueueSize"
 	// CfgConsensusMaxNumValidators defines the max number validators allowed
 	CfgConsensusMaxNumValidators = "consensus.maxNumValidators"
+	// CfgConsensusRecoveryHeightGap defines how far the last finalized block can fall behind the height voted by a
+	// majority of the validators before the node enters the partition recovery mode.
+	CfgConsensusRecoveryHeightGap = "consensus.recoveryHeightGap"
+	// CfgConsensusVoteTimestamps sets whether the votes of the node carry a signed timestamp. The nodes
+	// not supporting the timestamps fail to decode such votes, so it should only be enabled once all the
+	// nodes of the network are upgraded.
+	CfgConsensusVoteTimestamps = "consensus.voteTimestamps"
+	// CfgConsensusAlertWebhookURL sets the URL where the consensus alerts are posted (no webhook if empty).
+	CfgConsensusAlertWebhookURL = "consensus.alertWebhookURL"
+	// CfgConsensusAlertMaxEpochsWithoutFinalization raises an alert when the epoch changes this many times
+	// without any block being finalized (0 disables the alert).
+	CfgConsensusAlertMaxEpochsWithoutFinalization = "consensus.alertMaxEpochsWithoutFinalization"
+	// CfgConsensusAlertMaxFinalizationLatency raises an alert when a block is finalized this many seconds
+	// after its timestamp (0 disables the alert).
+	CfgConsensusAlertMaxFinalizationLatency = "consensus.alertMaxFinalizationLatency"
 
 	// CfgStorageStatePruningEnabled indicates whether state pruning is enabled
 	CfgStorageStatePruningEnabled = "storage.statePruningEnabled"
@@ -23,20 +56,144 @@ const (
 	CfgStorageStatePruningInterval = "storage.statePruningInterval"
 	// CfgStorageStatePruningRetainedBlocks indicates the number of blocks prior to the latest finalized block to be retained
 	CfgStorageStatePruningRetainedBlocks = "storage.statePruningRetainedBlocks"
+	// CfgStorageStatePruningBatchSize indicates the maximum number of heights pruned at once in the background
+	CfgStorageStatePruningBatchSize = "storage.statePruningBatchSize"
+	// CfgStorageColdArchiveEnabled indicates whether the transactions of old blocks are offloaded to an object store
+	CfgStorageColdArchiveEnabled = "storage.coldArchiveEnabled"
+	// CfgStorageColdArchiveRetainedBlocks indicates the number of blocks prior to the latest finalized block whose
+	// transactions are kept on the local disk
+	CfgStorageColdArchiveRetainedBlocks = "storage.coldArchiveRetainedBlocks"
+	// CfgStorageColdArchiveURL sets the object store, either a file:// URL or the http(s):// endpoint of an
+	// S3 compatible service, e.g. https://storage.googleapis.com
+	CfgStorageColdArchiveURL = "storage.coldArchiveURL"
+	// CfgStorageColdArchiveBucket sets the bucket of the S3 compatible service
+	CfgStorageColdArchiveBucket = "storage.coldArchiveBucket"
+	// CfgStorageColdArchiveRegion sets the region used to sign the requests to the S3 compatible service
+	CfgStorageColdArchiveRegion = "storage.coldArchiveRegion"
+	// CfgStorageColdArchiveAccessKey sets the access key of the S3 compatible service
+	CfgStorageColdArchiveAccessKey = "storage.coldArchiveAccessKey"
+	// CfgStorageColdArchiveSecretKey sets the secret key of the S3 compatible service
+	CfgStorageColdArchiveSecretKey = "storage.coldArchiveSecretKey"
+	// CfgStorageColdArchivePrefix sets the prefix of the object keys
+	CfgStorageColdArchivePrefix = "storage.coldArchivePrefix"
+	// CfgStorageMigrationBackup indicates whether the database is backed up before a migration to a
+	// new schema version converting its data. The versions only recorded are not backed up.
+	CfgStorageMigrationBackup = "storage.migrationBackup"
+
+	// CfgLedgerThetaFeeChainIDs lists the chainIDs (comma separated) on which the transaction fees can be paid
+	// in Theta, e.g. for private deployments. On all the other chains, the fees need to be paid in TFuel.
+	CfgLedgerThetaFeeChainIDs = "ledger.thetaFeeChainIDs"
+	// CfgLedgerCheckInvariants indicates whether the ledger invariants are checked after every block, for
+	// debugging. The node panics on the first violation.
+	CfgLedgerCheckInvariants = "ledger.checkInvariants"
+
+	// CfgMempoolReapStrategy sets the strategy used by the proposer to select transactions from the mempool.
+	CfgMempoolReapStrategy = "mempool.reapStrategy"
+	// CfgMempoolReapMaxGas limits the total gas of the transactions reaped for one block (0 means unlimited).
+	CfgMempoolReapMaxGas = "mempool.reapMaxGas"
+	// CfgMempoolReconcileInterval sets the interval (in seconds) at which the mempool is reconciled with one of the
+	// peers, to recover the transactions missed by the gossip (0 disables the reconciliation).
+	CfgMempoolReconcileInterval = "mempool.reconcileInterval"
+	// CfgMempoolReplaceFeeBump sets the percentage by which the effective gas price of a transaction must exceed
+	// the one of the pending transaction with the same sender and sequence to replace it.
+	CfgMempoolReplaceFeeBump = "mempool.replaceFeeBump"
+	// CfgMempoolMaxPendingTxsPerAccount limits the number of pending transactions of an account (0 means unlimited).
+	CfgMempoolMaxPendingTxsPerAccount = "mempool.maxPendingTxsPerAccount"
+	// CfgMempoolMaxNumTxs limits the number of pending transactions. When the mempool is full, a transaction is only
+	// accepted if its effective gas price exceeds the one of the cheapest pending transaction, which is evicted
+	// (0 means unlimited).
+	CfgMempoolMaxNumTxs = "mempool.maxNumTxs"
 
 	// CfgSyncMessageQueueSize defines the capacity of Sync Manager message queue.
 	CfgSyncMessageQueueSize = "sync.messageQueueSize"
+	// CfgSyncDownloadWindowSize defines the number of blocks requested from a peer at once during sync.
+	CfgSyncDownloadWindowSize = "sync.downloadWindowSize"
+	// CfgSyncMaxInflightWindowsPerPeer defines the number of block windows that can be downloaded
+	// concurrently from a single peer.
+	CfgSyncMaxInflightWindowsPerPeer = "sync.maxInflightWindowsPerPeer"
+	// CfgSyncFastSyncCheckpoint is the hash of the trusted finalized block whose snapshot is downloaded
+	// by the fast sync, the latest checkpoint of the network profile if not set.
+	CfgSyncFastSyncCheckpoint = "sync.fastSync.checkpoint"
+	// CfgSyncSnapshotServeDir is the directory of the snapshots served to the fast syncing peers,
+	// e.g. the snapshot backup directory. The snapshots are not served if not set.
+	CfgSyncSnapshotServeDir = "sync.snapshotServeDir"
+	// CfgSyncServeStateDiffs decides whether to generate the state diffs requested by the peers which
+	// already hold a snapshot, from the local states. Only the states not pruned yet can be diffed.
+	CfgSyncServeStateDiffs = "sync.serveStateDiffs"
+
+	// CfgGuardianEnabled decides whether to process the guardian votes.
+	CfgGuardianEnabled = "guardian.enabled"
+	// CfgGuardianMessageQueueSize defines the capacity of the guardian vote queue, the votes received
+	// when it is full are dropped.
+	CfgGuardianMessageQueueSize = "guardian.messageQueueSize"
+	// CfgGuardianMaxVotesPerSecond limits the number of guardian votes accepted from all the peers (0 means unlimited).
+	CfgGuardianMaxVotesPerSecond = "guardian.maxVotesPerSecond"
+	// CfgGuardianMaxPeerVotesPerSecond limits the number of guardian votes accepted from a single peer (0 means unlimited).
+	CfgGuardianMaxPeerVotesPerSecond = "guardian.maxPeerVotesPerSecond"
+	// CfgGuardianAddresses sets the addresses of the guardians (comma separated), whose votes are accepted
+	// and relayed. No vote is accepted if empty.
+	CfgGuardianAddresses = "guardian.addresses"
 
 	// CfgP2PName sets the ID of local node in P2P network.
 	CfgP2PName = "p2p.name"
 	// CfgP2PPort sets the port used by P2P network.
 	CfgP2PPort = "p2p.port"
+	// CfgP2PListenAddresses sets the addresses (comma separated) to accept the peer connections on, each in
+	// the format of "ip:port", with IPv6 addresses in brackets (e.g. "0.0.0.0:50001,[::]:50001"). An address
+	// followed by "/noadvertise" is not announced to the peers. All the interfaces are listened on at
+	// p2p.port if not set.
+	CfgP2PListenAddresses = "p2p.listenAddresses"
 	// CfgP2PSeeds sets the boostrap peers.
 	CfgP2PSeeds = "p2p.seeds"
 	// CfgP2PMessageQueueSize sets the message queue size for network interface.
 	CfgP2PMessageQueueSize = "p2p.messageQueueSize"
 	// CfgP2PSeedPeerOnlyOutbound decides whether only the seed peers can be outbound peers.
 	CfgP2PSeedPeerOnlyOutbound = "p2p.seedPeerOnlyOutbound"
+	// CfgP2PDNSSeeds sets the DNS seed hostnames (host, host:port, or txt:host for the seeds listing the peer addresses in TXT records), which resolve to bootstrap peers.
+	CfgP2PDNSSeeds = "p2p.dnsSeeds"
+	// CfgP2PUseFallbackSeeds decides whether to use the built-in bootstrap peers when none of the seeds is reachable.
+	CfgP2PUseFallbackSeeds = "p2p.useFallbackSeeds"
+	// CfgP2PValidatorMeshEnabled decides whether a validator connects directly to the other validators.
+	CfgP2PValidatorMeshEnabled = "p2p.validatorMeshEnabled"
+	// CfgP2PValidatorMeshAddress sets the public address (host:port) announced to the other validators,
+	// no announcement if empty.
+	CfgP2PValidatorMeshAddress = "p2p.validatorMeshAddress"
+	// CfgP2PValidatorPeers sets the known addresses of validators (comma separated), each in the format
+	// <validator address>@<host:port>.
+	CfgP2PValidatorPeers = "p2p.validatorPeers"
+	// CfgP2PPeerThrottleScore sets the reputation score below which the receive rate of a peer is limited.
+	CfgP2PPeerThrottleScore = "p2p.peerThrottleScore"
+	// CfgP2PPeerBanScore sets the reputation score below which a peer is disconnected and banned.
+	CfgP2PPeerBanScore = "p2p.peerBanScore"
+	// CfgP2PPeerBanDuration sets how long (in seconds) a peer stays banned.
+	CfgP2PPeerBanDuration = "p2p.peerBanDuration"
+	// CfgP2PHandshakeTimeout sets the time (in seconds) a peer has to complete the handshake.
+	CfgP2PHandshakeTimeout = "p2p.handshakeTimeout"
+	// CfgP2PMaxHandshakesPerIP sets the max number of inbound handshakes per minute from an IP, 0 for unlimited.
+	CfgP2PMaxHandshakesPerIP = "p2p.maxHandshakesPerIP"
+	// CfgP2PMaxNewConnectionsPerSecond sets the max number of new inbound connections per second, 0 for unlimited.
+	CfgP2PMaxNewConnectionsPerSecond = "p2p.maxNewConnectionsPerSecond"
+	// CfgP2PMaxPendingHandshakes sets the max number of inbound handshakes in progress, 0 for unlimited.
+	CfgP2PMaxPendingHandshakes = "p2p.maxPendingHandshakes"
+	// CfgP2PMaxHandshakeFailures sets the number of failed handshakes after which an IP is banned, 0 for never.
+	CfgP2PMaxHandshakeFailures = "p2p.maxHandshakeFailures"
+	// CfgP2PHandshakeFailureBanDuration sets how long (in seconds) an IP failing handshakes stays banned.
+	CfgP2PHandshakeFailureBanDuration = "p2p.handshakeFailureBanDuration"
+	// CfgP2PPeerSendRate sets the max rate (bytes/s) data is sent to each peer, 0 for unlimited.
+	CfgP2PPeerSendRate = "p2p.peerSendRate"
+	// CfgP2PPeerRecvRate sets the max rate (bytes/s) data is received from each peer, 0 for unlimited.
+	CfgP2PPeerRecvRate = "p2p.peerRecvRate"
+	// CfgP2PChannelSendRates sets the max rates (bytes/s) data is sent on the channels to all the peers, as a comma
+	// separated list of <channel name>:<bytes per second>, e.g. "block:4194304,vote:524288". The channels not listed
+	// are unlimited.
+	CfgP2PChannelSendRates = "p2p.channelSendRates"
+	// CfgP2PNATEnabled decides whether to map the P2P port through the UPnP or NAT-PMP gateway, so that a node
+	// behind a NAT accepts inbound connections.
+	CfgP2PNATEnabled = "p2p.natEnabled"
+	// CfgP2PSecureTransport sets the mode of the encrypted and authenticated transport: "disabled", "optional"
+	// to encrypt the connections with the peers supporting it, e.g. during a network upgrade, or "required"
+	// to reject the peers not supporting it.
+	CfgP2PSecureTransport = "p2p.secureTransport"
 
 	// CfgRPCEnabled sets whether to run RPC service.
 	CfgRPCEnabled = "rpc.enabled"
@@ -44,9 +201,91 @@ const (
 	CfgRPCPort = "rpc.port"
 	// CfgRPCMaxConnections limits concurrent connections accepted by RPC server.
 	CfgRPCMaxConnections = "rpc.maxConnections"
+	// CfgRPCPrometheusEnabled sets whether to export the metrics in the Prometheus format at /metrics of the RPC server.
+	// Disabled by default, since the endpoint is unauthenticated and the metrics expose the peers of the node.
+	CfgRPCPrometheusEnabled = "rpc.prometheusEnabled"
+	// CfgRPCPaymentSessionExpiryWarningBlocks sets how many blocks before the expiry of a reserve the
+	// registered payment sessions using the reserve get the expiry warning.
+	CfgRPCPaymentSessionExpiryWarningBlocks = "rpc.paymentSessionExpiryWarningBlocks"
+
+	// CfgRPCPprofEnabled sets whether to serve the runtime profiles at /debug/pprof/ of the RPC server.
+	CfgRPCPprofEnabled = "rpc.pprofEnabled"
+	// CfgRPCTenants lists the API tenants served by the RPC server. Each tenant has a name, an API key,
+	// optionally the virtual hosts it is served at, and its quotas: requestsPerSecond, maxSubscriptions
+	// and heavyRequestsPerMinute (0 means unlimited). Admin tenants can query the usage of all tenants and
+	// call the admin methods, which are disabled without them. They are only identified by their API key.
+	CfgRPCTenants = "rpc.tenants"
+	// CfgRPCRequireAPIKey sets whether requests not belonging to any tenant are rejected.
+	CfgRPCRequireAPIKey = "rpc.requireAPIKey"
+	// CfgRPCTLSCertFile sets the PEM certificate the RPC server uses to serve TLS. TLS is disabled if not set.
+	CfgRPCTLSCertFile = "rpc.tlsCertFile"
+	// CfgRPCTLSKeyFile sets the PEM private key of the TLS certificate.
+	CfgRPCTLSKeyFile = "rpc.tlsKeyFile"
+	// CfgRPCTLSClientCAFile sets the PEM certificates of the CAs the RPC clients need to present a certificate
+	// signed by. Client certificates are not required if not set.
+	CfgRPCTLSClientCAFile = "rpc.tlsClientCAFile"
+	// CfgRPCUnixSocket sets the path of a unix domain socket the RPC server also listens on.
+	CfgRPCUnixSocket = "rpc.unixSocket"
+	// CfgRPCUnixSocketMode sets the file mode of the unix domain socket, in octal.
+	CfgRPCUnixSocketMode = "rpc.unixSocketMode"
+	// CfgRPCAuditLog sets the path of the append-only, hash-chained log recording the invocations of the
+	// admin and operator RPC methods, with the identity of their callers. Auditing is disabled if not set.
+	CfgRPCAuditLog = "rpc.auditLog"
+	// CfgRPCLegacyErrors sets whether the RPC errors use the legacy format, where the errors are free-form
+	// messages with the JSON-RPC server error code, instead of carrying the ledger error code and its name.
+	CfgRPCLegacyErrors = "rpc.legacyErrors"
+	// CfgRPCFinalizedOnly sets whether the query RPCs only serve finalized data by default, i.e. the balances,
+	// the sequences and the blocks as of the last finalized block. Requests can override it with finalized_only.
+	CfgRPCFinalizedOnly = "rpc.finalizedOnly"
+	// CfgRPCGRPCEnabled sets whether to run the gRPC server alongside the JSON-RPC server.
+	CfgRPCGRPCEnabled = "rpc.grpcEnabled"
+	// CfgRPCGRPCPort sets the port of the gRPC server. It shares the TLS and tenant settings of the RPC server.
+	CfgRPCGRPCPort = "rpc.grpcPort"
+	// CfgRPCEthEnabled sets whether to serve the Ethereum compatible API (the eth_* methods) at /eth of the RPC server.
+	CfgRPCEthEnabled = "rpc.ethEnabled"
+	// CfgRPCEthChainID sets the EIP-155 chain ID reported by the Ethereum compatible API. If 0, the chain ID
+	// of the known Theta networks is used.
+	CfgRPCEthChainID = "rpc.ethChainID"
+
+	// CfgWebhookHooks lists the webhooks notified of the finalized blocks and transactions. Each webhook
+	// has a url, an optional secret to sign the notifications, and optional filters: events (transaction
+	// and/or block), addresses, txTypes, minTheta and minTFuel.
+	CfgWebhookHooks = "webhook.hooks"
+	// CfgWebhookMaxRetries sets how many times a failed notification is retried before it is skipped.
+	// The skipped notifications are sent again after a restart.
+	CfgWebhookMaxRetries = "webhook.maxRetries"
+	// CfgWebhookRetryInterval sets the seconds before the first retry, doubled after each failed retry.
+	CfgWebhookRetryInterval = "webhook.retryInterval"
+	// CfgWebhookTimeout sets the timeout in seconds of posting a notification.
+	CfgWebhookTimeout = "webhook.timeout"
+
+	// CfgTxIndexEnabled sets whether to index the transactions of the finalized blocks by address, to
+	// serve the GetTransactionsByAddress RPC.
+	CfgTxIndexEnabled = "txindex.enabled"
+
+	// CfgProfilerEnabled sets whether to capture the runtime profiles automatically when the node misbehaves.
+	CfgProfilerEnabled = "profiler.enabled"
+	// CfgProfilerMaxBlockProcessingTime triggers a capture when processing a block takes longer than
+	// this many milliseconds (0 disables the trigger).
+	CfgProfilerMaxBlockProcessingTime = "profiler.maxBlockProcessingTime"
+	// CfgProfilerMaxGCPause triggers a capture when a GC pause is longer than this many milliseconds
+	// (0 disables the trigger).
+	CfgProfilerMaxGCPause = "profiler.maxGCPause"
+	// CfgProfilerMaxHeapAlloc triggers a capture when the allocated heap exceeds this many megabytes
+	// (0 disables the trigger).
+	CfgProfilerMaxHeapAlloc = "profiler.maxHeapAlloc"
+	// CfgProfilerCPUProfileDuration sets how many seconds the CPU profile of a capture covers.
+	CfgProfilerCPUProfileDuration = "profiler.cpuProfileDuration"
+	// CfgProfilerMinCaptureInterval sets the minimal interval (in seconds) between two automatic captures.
+	CfgProfilerMinCaptureInterval = "profiler.minCaptureInterval"
+	// CfgProfilerMaxCaptures sets the number of captures kept on disk, older captures are deleted.
+	CfgProfilerMaxCaptures = "profiler.maxCaptures"
 
-	// CfgLogLevels sets the log level.
+	// CfgLogLevels sets the log level of each module, either as a comma separated list of
+	// <module>:<level>, or as a map from the modules to the levels, see ParseLogLevels.
 	CfgLogLevels = "log.levels"
+	// CfgLogFormat sets the format of the logs, "text" or "json" for machine-parseable logs.
+	CfgLogFormat = "log.format"
 	// CfgLogPrintSelfID determines whether to print node's ID in log (Useful in simulation when
 	// there are more than one node running).
 	CfgLogPrintSelfID = "log.printSelfID"
@@ -60,28 +299,123 @@ p2p:
 `
 
 func init() {
+	viper.SetDefault(CfgNetworks, []interface{}{})
+
+	viper.SetDefault(CfgNodeReadOnly, false)
+
+	viper.SetDefault(CfgConsensusEngine, "bft")
+	viper.SetDefault(CfgConsensusSoloBlockInterval, 6)
 	viper.SetDefault(CfgConsensusMaxEpochLength, 10)
 	viper.SetDefault(CfgConsensusMinProposalWait, 6)
 	viper.SetDefault(CfgConsensusMessageQueueSize, 512)
 	viper.SetDefault(CfgConsensusMaxNumValidators, 7)
+	viper.SetDefault(CfgConsensusRecoveryHeightGap, 20)
+	viper.SetDefault(CfgConsensusVoteTimestamps, false)
+	viper.SetDefault(CfgConsensusAlertWebhookURL, "")
+	viper.SetDefault(CfgConsensusAlertMaxEpochsWithoutFinalization, 5)
+	viper.SetDefault(CfgConsensusAlertMaxFinalizationLatency, 60)
+
+	viper.SetDefault(CfgLedgerThetaFeeChainIDs, "")
+	viper.SetDefault(CfgLedgerCheckInvariants, false)
+
+	viper.SetDefault(CfgMempoolReapStrategy, "greedy_fee")
+	viper.SetDefault(CfgMempoolReapMaxGas, 0)
+	viper.SetDefault(CfgMempoolReconcileInterval, 10)
+	viper.SetDefault(CfgMempoolReplaceFeeBump, 10)
+	viper.SetDefault(CfgMempoolMaxPendingTxsPerAccount, 0)
+	viper.SetDefault(CfgMempoolMaxNumTxs, 0)
 
 	viper.SetDefault(CfgSyncMessageQueueSize, 512)
+	viper.SetDefault(CfgSyncDownloadWindowSize, 16)
+	viper.SetDefault(CfgSyncMaxInflightWindowsPerPeer, 4)
+	viper.SetDefault(CfgSyncFastSyncCheckpoint, "")
+	viper.SetDefault(CfgSyncSnapshotServeDir, "")
+	viper.SetDefault(CfgSyncServeStateDiffs, false)
+
+	viper.SetDefault(CfgGuardianEnabled, true)
+	viper.SetDefault(CfgGuardianMessageQueueSize, 2048)
+	viper.SetDefault(CfgGuardianMaxVotesPerSecond, 2000)
+	viper.SetDefault(CfgGuardianMaxPeerVotesPerSecond, 200)
+	viper.SetDefault(CfgGuardianAddresses, "")
 
 	viper.SetDefault(CfgStorageStatePruningEnabled, true)
 	viper.SetDefault(CfgStorageStatePruningInterval, 16)
 	viper.SetDefault(CfgStorageStatePruningRetainedBlocks, 512)
+	viper.SetDefault(CfgStorageStatePruningBatchSize, 48)
+	viper.SetDefault(CfgStorageColdArchiveEnabled, false)
+	viper.SetDefault(CfgStorageColdArchiveRetainedBlocks, 100000)
+	viper.SetDefault(CfgStorageColdArchiveURL, "")
+	viper.SetDefault(CfgStorageColdArchiveBucket, "")
+	viper.SetDefault(CfgStorageColdArchiveRegion, "us-east-1")
+	viper.SetDefault(CfgStorageColdArchiveAccessKey, "")
+	viper.SetDefault(CfgStorageColdArchiveSecretKey, "")
+	viper.SetDefault(CfgStorageColdArchivePrefix, "")
+	viper.SetDefault(CfgStorageMigrationBackup, true)
 
 	viper.SetDefault(CfgRPCEnabled, false)
 	viper.SetDefault(CfgP2PMessageQueueSize, 512)
 	viper.SetDefault(CfgP2PName, "Anonymous")
 	viper.SetDefault(CfgP2PPort, 50001)
+	viper.SetDefault(CfgP2PListenAddresses, "")
 	viper.SetDefault(CfgP2PSeeds, "")
 	viper.SetDefault(CfgP2PSeedPeerOnlyOutbound, false)
+	viper.SetDefault(CfgP2PDNSSeeds, "")
+	viper.SetDefault(CfgP2PUseFallbackSeeds, true)
+	viper.SetDefault(CfgP2PValidatorMeshEnabled, true)
+	viper.SetDefault(CfgP2PValidatorMeshAddress, "")
+	viper.SetDefault(CfgP2PValidatorPeers, "")
+	viper.SetDefault(CfgP2PPeerThrottleScore, -20)
+	viper.SetDefault(CfgP2PPeerBanScore, -50)
+	viper.SetDefault(CfgP2PPeerBanDuration, 86400)
+	viper.SetDefault(CfgP2PHandshakeTimeout, 10)
+	viper.SetDefault(CfgP2PMaxHandshakesPerIP, 10)
+	viper.SetDefault(CfgP2PMaxNewConnectionsPerSecond, 20)
+	viper.SetDefault(CfgP2PMaxPendingHandshakes, 32)
+	viper.SetDefault(CfgP2PMaxHandshakeFailures, 5)
+	viper.SetDefault(CfgP2PHandshakeFailureBanDuration, 600)
+	viper.SetDefault(CfgP2PPeerSendRate, 512000)
+	viper.SetDefault(CfgP2PPeerRecvRate, 512000)
+	viper.SetDefault(CfgP2PChannelSendRates, "block:4194304,snapshot_response:4194304")
+	viper.SetDefault(CfgP2PNATEnabled, true)
+	viper.SetDefault(CfgP2PSecureTransport, "optional")
 
 	viper.SetDefault(CfgRPCPort, "16888")
 	viper.SetDefault(CfgRPCMaxConnections, 200)
+	viper.SetDefault(CfgRPCPrometheusEnabled, false)
+	viper.SetDefault(CfgRPCPaymentSessionExpiryWarningBlocks, 100)
+	viper.SetDefault(CfgRPCPprofEnabled, false)
+	viper.SetDefault(CfgRPCTenants, []interface{}{})
+	viper.SetDefault(CfgRPCRequireAPIKey, false)
+	viper.SetDefault(CfgRPCTLSCertFile, "")
+	viper.SetDefault(CfgRPCTLSKeyFile, "")
+	viper.SetDefault(CfgRPCTLSClientCAFile, "")
+	viper.SetDefault(CfgRPCUnixSocket, "")
+	viper.SetDefault(CfgRPCUnixSocketMode, "0660")
+	viper.SetDefault(CfgRPCAuditLog, "")
+	viper.SetDefault(CfgRPCLegacyErrors, false)
+	viper.SetDefault(CfgRPCFinalizedOnly, false)
+	viper.SetDefault(CfgRPCGRPCEnabled, false)
+	viper.SetDefault(CfgRPCGRPCPort, "16890")
+	viper.SetDefault(CfgRPCEthEnabled, false)
+	viper.SetDefault(CfgRPCEthChainID, 0)
+
+	viper.SetDefault(CfgWebhookHooks, []interface{}{})
+	viper.SetDefault(CfgWebhookMaxRetries, 8)
+	viper.SetDefault(CfgWebhookRetryInterval, 2)
+	viper.SetDefault(CfgWebhookTimeout, 10)
+
+	viper.SetDefault(CfgTxIndexEnabled, false)
+
+	viper.SetDefault(CfgProfilerEnabled, false)
+	viper.SetDefault(CfgProfilerMaxBlockProcessingTime, 3000)
+	viper.SetDefault(CfgProfilerMaxGCPause, 500)
+	viper.SetDefault(CfgProfilerMaxHeapAlloc, 8192)
+	viper.SetDefault(CfgProfilerCPUProfileDuration, 10)
+	viper.SetDefault(CfgProfilerMinCaptureInterval, 600)
+	viper.SetDefault(CfgProfilerMaxCaptures, 10)
 
 	viper.SetDefault(CfgLogLevels, "*:debug")
+	viper.SetDefault(CfgLogFormat, "text")
 	viper.SetDefault(CfgLogPrintSelfID, false)
 }
 
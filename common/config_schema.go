@@ -0,0 +1,372 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+type configValueType int
+
+const (
+	configBool configValueType = iota
+	configInt
+	configString
+	configList
+	configLogLevels
+)
+
+func (t configValueType) String() string {
+	switch t {
+	case configBool:
+		return "a boolean"
+	case configInt:
+		return "an integer"
+	case configList:
+		return "a list"
+	case configLogLevels:
+		return "module log levels"
+	default:
+		return "a string"
+	}
+}
+
+// configRule describes the expected type, and the valid range or values of a config key
+type configRule struct {
+	valueType configValueType
+	min       int64
+	max       int64
+	values    []string // allowed string values, any string is allowed if empty
+}
+
+func boolRule() configRule {
+	return configRule{valueType: configBool}
+}
+
+func intRule(min, max int64) configRule {
+	return configRule{valueType: configInt, min: min, max: max}
+}
+
+func stringRule(values ...string) configRule {
+	return configRule{valueType: configString, values: values}
+}
+
+func listRule() configRule {
+	return configRule{valueType: configList}
+}
+
+func logLevelsRule() configRule {
+	return configRule{valueType: configLogLevels}
+}
+
+const maxPort = 65535
+
+var logLevelNames = []string{"panic", "fatal", "error", "warn", "info", "debug"}
+
+// ParseLogLevels parses the module log levels set by CfgLogLevels, given either as a comma separated
+// list of <module>:<level>, e.g. "*:info,sync:debug", or as a map from the modules to the levels,
+// e.g. {sync: debug, consensus: warn} in the config file. The "*" module sets the level of the
+// modules not listed.
+func ParseLogLevels(value interface{}) (map[string]string, error) {
+	levels := make(map[string]string)
+	if value == nil {
+		return levels, nil
+	}
+	if _, ok := value.(string); !ok {
+		moduleLevels, err := cast.ToStringMapStringE(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected <module>:<level> pairs, got %#v", value)
+		}
+		for module, level := range moduleLevels {
+			level = strings.ToLower(strings.TrimSpace(level))
+			if !containsString(logLevelNames, level) {
+				return nil, fmt.Errorf("invalid log level \"%v\" of module \"%v\", expected one of: %v",
+					level, module, strings.Join(logLevelNames, "|"))
+			}
+			levels[strings.TrimSpace(module)] = level
+		}
+		return levels, nil
+	}
+
+	for _, moduleAndLevel := range strings.Split(value.(string), ",") {
+		tokens := strings.Split(moduleAndLevel, ":")
+		if len(tokens) != 2 || !containsString(logLevelNames, strings.TrimSpace(tokens[1])) {
+			return nil, fmt.Errorf("invalid module log level \"%v\", expected <module>:<%v>",
+				moduleAndLevel, strings.Join(logLevelNames, "|"))
+		}
+		levels[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+	}
+	return levels, nil
+}
+
+// configSchema lists all the config keys recognized by the node
+var configSchema = map[string]configRule{
+	CfgNetwork:     stringRule(),
+	CfgNetworks:    listRule(),
+	CfgGenesisHash: stringRule(),
+
+	CfgNodeReadOnly: boolRule(),
+
+	// Should be kept in sync with the engines supported by node.NewNode
+	CfgConsensusEngine:                            stringRule("bft", "solo"),
+	CfgConsensusSoloBlockInterval:                 intRule(0, math.MaxInt32),
+	CfgConsensusMaxEpochLength:                    intRule(1, math.MaxInt32),
+	CfgConsensusMinProposalWait:                   intRule(0, math.MaxInt32),
+	CfgConsensusMessageQueueSize:                  intRule(1, math.MaxInt32),
+	CfgConsensusMaxNumValidators:                  intRule(1, math.MaxInt32),
+	CfgConsensusRecoveryHeightGap:                 intRule(1, math.MaxInt32),
+	CfgConsensusVoteTimestamps:                    boolRule(),
+	CfgConsensusAlertWebhookURL:                   stringRule(),
+	CfgConsensusAlertMaxEpochsWithoutFinalization: intRule(0, math.MaxInt32),
+	CfgConsensusAlertMaxFinalizationLatency:       intRule(0, math.MaxInt32),
+
+	CfgStorageStatePruningEnabled:        boolRule(),
+	CfgStorageStatePruningInterval:       intRule(1, math.MaxInt32),
+	CfgStorageStatePruningRetainedBlocks: intRule(1, math.MaxInt32),
+	CfgStorageStatePruningBatchSize:      intRule(1, math.MaxInt32),
+	CfgStorageColdArchiveEnabled:         boolRule(),
+	CfgStorageColdArchiveRetainedBlocks:  intRule(1, math.MaxInt32),
+	CfgStorageColdArchiveURL:             stringRule(),
+	CfgStorageColdArchiveBucket:          stringRule(),
+	CfgStorageColdArchiveRegion:          stringRule(),
+	CfgStorageColdArchiveAccessKey:       stringRule(),
+	CfgStorageColdArchiveSecretKey:       stringRule(),
+	CfgStorageColdArchivePrefix:          stringRule(),
+	CfgStorageMigrationBackup:            boolRule(),
+
+	CfgLedgerThetaFeeChainIDs: stringRule(),
+	CfgLedgerCheckInvariants:  boolRule(),
+
+	// Should be kept in sync with the strategies supported by mempool.NewReapStrategy
+	CfgMempoolReapStrategy:            stringRule("greedy_fee", "knapsack_gas", "round_robin"),
+	CfgMempoolReapMaxGas:              intRule(0, math.MaxInt64),
+	CfgMempoolReconcileInterval:       intRule(0, math.MaxInt32),
+	CfgMempoolReplaceFeeBump:          intRule(0, 1000),
+	CfgMempoolMaxPendingTxsPerAccount: intRule(0, math.MaxInt32),
+	CfgMempoolMaxNumTxs:               intRule(0, math.MaxInt32),
+
+	CfgSyncMessageQueueSize:          intRule(1, math.MaxInt32),
+	CfgSyncDownloadWindowSize:        intRule(1, 1024),
+	CfgSyncMaxInflightWindowsPerPeer: intRule(1, 1024),
+	CfgSyncFastSyncCheckpoint:        stringRule(),
+	CfgSyncSnapshotServeDir:          stringRule(),
+	CfgSyncServeStateDiffs:           boolRule(),
+
+	CfgGuardianEnabled:               boolRule(),
+	CfgGuardianMessageQueueSize:      intRule(1, math.MaxInt32),
+	CfgGuardianMaxVotesPerSecond:     intRule(0, math.MaxInt32),
+	CfgGuardianMaxPeerVotesPerSecond: intRule(0, math.MaxInt32),
+	CfgGuardianAddresses:             stringRule(),
+
+	CfgP2PName:                 stringRule(),
+	CfgP2PPort:                 intRule(1, maxPort),
+	CfgP2PListenAddresses:      stringRule(),
+	CfgP2PSeeds:                stringRule(),
+	CfgP2PMessageQueueSize:     intRule(1, math.MaxInt32),
+	CfgP2PSeedPeerOnlyOutbound: boolRule(),
+	CfgP2PDNSSeeds:             stringRule(),
+	CfgP2PUseFallbackSeeds:     boolRule(),
+	CfgP2PValidatorMeshEnabled: boolRule(),
+	CfgP2PValidatorMeshAddress: stringRule(),
+	CfgP2PValidatorPeers:       stringRule(),
+	CfgP2PPeerThrottleScore:    intRule(-1000, 0),
+	CfgP2PPeerBanScore:         intRule(-1000, 0),
+	CfgP2PPeerBanDuration:      intRule(0, math.MaxInt32),
+
+	CfgP2PHandshakeTimeout:            intRule(1, math.MaxInt32),
+	CfgP2PMaxHandshakesPerIP:          intRule(0, math.MaxInt32),
+	CfgP2PMaxNewConnectionsPerSecond:  intRule(0, math.MaxInt32),
+	CfgP2PMaxPendingHandshakes:        intRule(0, math.MaxInt32),
+	CfgP2PMaxHandshakeFailures:        intRule(0, math.MaxInt32),
+	CfgP2PHandshakeFailureBanDuration: intRule(0, math.MaxInt32),
+
+	CfgP2PPeerSendRate:     intRule(0, math.MaxInt64),
+	CfgP2PPeerRecvRate:     intRule(0, math.MaxInt64),
+	CfgP2PChannelSendRates: stringRule(),
+
+	CfgP2PNATEnabled:      boolRule(),
+	CfgP2PSecureTransport: stringRule("disabled", "optional", "required"),
+
+	CfgRPCEnabled:                           boolRule(),
+	CfgRPCPort:                              intRule(1, maxPort),
+	CfgRPCMaxConnections:                    intRule(1, math.MaxInt32),
+	CfgRPCPrometheusEnabled:                 boolRule(),
+	CfgRPCPaymentSessionExpiryWarningBlocks: intRule(0, math.MaxInt32),
+	CfgRPCPprofEnabled:                      boolRule(),
+	CfgRPCTenants:                           listRule(),
+	CfgRPCRequireAPIKey:                     boolRule(),
+	CfgRPCTLSCertFile:                       stringRule(),
+	CfgRPCTLSKeyFile:                        stringRule(),
+	CfgRPCTLSClientCAFile:                   stringRule(),
+	CfgRPCUnixSocket:                        stringRule(),
+	CfgRPCUnixSocketMode:                    stringRule(),
+	CfgRPCAuditLog:                          stringRule(),
+	CfgRPCLegacyErrors:                      boolRule(),
+	CfgRPCFinalizedOnly:                     boolRule(),
+	CfgRPCGRPCEnabled:                       boolRule(),
+	CfgRPCGRPCPort:                          intRule(1, maxPort),
+	CfgRPCEthEnabled:                        boolRule(),
+	CfgRPCEthChainID:                        intRule(0, math.MaxInt64),
+
+	CfgWebhookHooks:         listRule(),
+	CfgWebhookMaxRetries:    intRule(0, math.MaxInt32),
+	CfgWebhookRetryInterval: intRule(1, math.MaxInt32),
+	CfgWebhookTimeout:       intRule(1, math.MaxInt32),
+
+	CfgTxIndexEnabled: boolRule(),
+
+	CfgProfilerEnabled:                boolRule(),
+	CfgProfilerMaxBlockProcessingTime: intRule(0, math.MaxInt32),
+	CfgProfilerMaxGCPause:             intRule(0, math.MaxInt32),
+	CfgProfilerMaxHeapAlloc:           intRule(0, math.MaxInt32),
+	CfgProfilerCPUProfileDuration:     intRule(1, 300),
+	CfgProfilerMinCaptureInterval:     intRule(0, math.MaxInt32),
+	CfgProfilerMaxCaptures:            intRule(1, 1000),
+
+	CfgLogLevels:      logLevelsRule(),
+	CfgLogFormat:      stringRule("text", "json"),
+	CfgLogPrintSelfID: boolRule(),
+}
+
+// ValidateConfig checks all the config values against the schema, and the options against
+// each other. It reports all the problems found at once, so they can be fixed before the
+// node starts, rather than having the subsystems silently fall back to zero values.
+func ValidateConfig() error {
+	return validateConfig(viper.GetViper())
+}
+
+func validateConfig(v *viper.Viper) error {
+	rules := make(map[string]configRule)
+	for key, rule := range configSchema {
+		rules[strings.ToLower(key)] = rule // viper keys are case insensitive
+	}
+
+	problems := []string{}
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	logLevelsKey := strings.ToLower(CfgLogLevels)
+	for _, key := range keys {
+		if key == logLevelsKey || strings.HasPrefix(key, logLevelsKey+".") {
+			continue // checked below as a whole, since the module log levels can be given as a map
+		}
+		rule, ok := rules[key]
+		if !ok {
+			// Only the sections of the config file can have typos, the other keys are defaults registered by
+			// the packages linked into the binary, e.g. the CLI utilities
+			if v.InConfig(strings.Split(key, ".")[0]) {
+				problems = append(problems, fmt.Sprintf("unknown config key \"%v\"", key))
+			}
+			continue
+		}
+		if err := rule.check(v.Get(key)); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid value for \"%v\": %v", key, err))
+		}
+	}
+	if err := configSchema[CfgLogLevels].check(v.Get(CfgLogLevels)); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid value for \"%v\": %v", logLevelsKey, err))
+	}
+	if len(problems) == 0 {
+		problems = append(problems, checkConfigConflicts(v)...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid configuration:\n  - %v", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func (rule configRule) check(value interface{}) error {
+	switch rule.valueType {
+	case configBool:
+		if _, err := cast.ToBoolE(value); err != nil {
+			return fmt.Errorf("expected %v, got %#v", rule.valueType, value)
+		}
+	case configInt:
+		intValue, err := cast.ToInt64E(value)
+		if err != nil {
+			return fmt.Errorf("expected %v, got %#v", rule.valueType, value)
+		}
+		if intValue < rule.min || intValue > rule.max {
+			return fmt.Errorf("%v is out of range [%v, %v]", intValue, rule.min, rule.max)
+		}
+	case configString:
+		strValue, err := cast.ToStringE(value)
+		if err != nil {
+			return fmt.Errorf("expected %v, got %#v", rule.valueType, value)
+		}
+		if len(rule.values) > 0 && !containsString(rule.values, strValue) {
+			return fmt.Errorf("\"%v\" is not one of: %v", strValue, strings.Join(rule.values, ", "))
+		}
+	case configList:
+		if _, err := cast.ToSliceE(value); err != nil {
+			return fmt.Errorf("expected %v, got %#v", rule.valueType, value)
+		}
+	case configLogLevels:
+		if _, err := ParseLogLevels(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkConfigConflicts checks the options that depend on each other. It assumes
+// the individual values have passed the schema check.
+func checkConfigConflicts(v *viper.Viper) []string {
+	problems := []string{}
+
+	if v.GetInt(CfgConsensusMaxEpochLength) <= v.GetInt(CfgConsensusMinProposalWait) {
+		problems = append(problems, fmt.Sprintf("\"%v\" (%v) must be larger than \"%v\" (%v)",
+			CfgConsensusMaxEpochLength, v.GetInt(CfgConsensusMaxEpochLength),
+			CfgConsensusMinProposalWait, v.GetInt(CfgConsensusMinProposalWait)))
+	}
+
+	if v.GetBool(CfgP2PSeedPeerOnlyOutbound) && len(strings.TrimSpace(v.GetString(CfgP2PSeeds))) == 0 &&
+		len(strings.TrimSpace(v.GetString(CfgP2PDNSSeeds))) == 0 {
+		problems = append(problems, fmt.Sprintf("\"%v\" is enabled, but neither \"%v\" nor \"%v\" is set",
+			CfgP2PSeedPeerOnlyOutbound, CfgP2PSeeds, CfgP2PDNSSeeds))
+	}
+
+	if v.GetInt(CfgP2PPeerBanScore) > v.GetInt(CfgP2PPeerThrottleScore) {
+		problems = append(problems, fmt.Sprintf("\"%v\" (%v) must not be larger than \"%v\" (%v)",
+			CfgP2PPeerBanScore, v.GetInt(CfgP2PPeerBanScore),
+			CfgP2PPeerThrottleScore, v.GetInt(CfgP2PPeerThrottleScore)))
+	}
+
+	if v.GetBool(CfgRPCEnabled) && v.GetInt(CfgRPCPort) == v.GetInt(CfgP2PPort) {
+		problems = append(problems, fmt.Sprintf("\"%v\" and \"%v\" are both set to %v",
+			CfgRPCPort, CfgP2PPort, v.GetInt(CfgRPCPort)))
+	}
+	if v.GetBool(CfgRPCEnabled) && v.GetBool(CfgRPCGRPCEnabled) && v.GetInt(CfgRPCGRPCPort) == v.GetInt(CfgRPCPort) {
+		problems = append(problems, fmt.Sprintf("\"%v\" and \"%v\" are both set to %v",
+			CfgRPCGRPCPort, CfgRPCPort, v.GetInt(CfgRPCGRPCPort)))
+	}
+
+	if (len(v.GetString(CfgRPCTLSCertFile)) == 0) != (len(v.GetString(CfgRPCTLSKeyFile)) == 0) {
+		problems = append(problems, fmt.Sprintf("\"%v\" and \"%v\" need to be set together",
+			CfgRPCTLSCertFile, CfgRPCTLSKeyFile))
+	}
+	if len(v.GetString(CfgRPCTLSClientCAFile)) > 0 && len(v.GetString(CfgRPCTLSCertFile)) == 0 {
+		problems = append(problems, fmt.Sprintf("\"%v\" is set, but \"%v\" is not",
+			CfgRPCTLSClientCAFile, CfgRPCTLSCertFile))
+	}
+	if mode, err := strconv.ParseUint(v.GetString(CfgRPCUnixSocketMode), 8, 32); err != nil || mode > 0777 {
+		problems = append(problems, fmt.Sprintf("invalid file mode \"%v\" in \"%v\", expected an octal number up to 0777",
+			v.GetString(CfgRPCUnixSocketMode), CfgRPCUnixSocketMode))
+	}
+
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
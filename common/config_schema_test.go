@@ -0,0 +1,157 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig returns a config with the default values
+func newTestConfig() *viper.Viper {
+	v := viper.New()
+	for _, key := range viper.AllKeys() {
+		v.SetDefault(key, viper.Get(key))
+	}
+	return v
+}
+
+func TestValidateConfigDefaults(t *testing.T) {
+	assert.Nil(t, validateConfig(newTestConfig()))
+}
+
+func TestValidateShippedConfigs(t *testing.T) {
+	assert := assert.New(t)
+
+	var files []string
+	err := filepath.Walk("../integration", func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Name() == "config.yaml" {
+			files = append(files, path)
+		}
+		return err
+	})
+	assert.Nil(err)
+	assert.NotEmpty(files)
+
+	for _, file := range files {
+		v := newTestConfig()
+		v.SetConfigFile(file)
+		assert.Nil(v.ReadInConfig(), file)
+		assert.Nil(validateConfig(v), file)
+	}
+}
+
+func TestValidateConfigSchema(t *testing.T) {
+	assert := assert.New(t)
+
+	v := newTestConfig()
+	v.Set(CfgP2PPort, "12000")
+	v.Set(CfgRPCPort, 16000)
+	v.Set(CfgMempoolReapStrategy, "round_robin")
+	assert.Nil(validateConfig(v))
+
+	v = newTestConfig()
+	v.SetConfigType("yaml")
+	assert.Nil(v.ReadConfig(strings.NewReader("p2p:\n  prot: 12000\n")))
+	err := validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unknown config key \"p2p.prot\"")
+
+	// Defaults registered by other packages are not part of the schema
+	v = newTestConfig()
+	v.SetDefault("remoteRPCEndpoint", "http://localhost:16888/rpc")
+	assert.Nil(validateConfig(v))
+
+	v = newTestConfig()
+	v.Set(CfgRPCEnabled, "sure")
+	v.Set(CfgP2PPort, 70000)
+	v.Set(CfgStorageStatePruningInterval, 0)
+	v.Set(CfgMempoolReapStrategy, "fifo")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid value for \"rpc.enabled\": expected a boolean")
+	assert.Contains(err.Error(), "invalid value for \"p2p.port\": 70000 is out of range [1, 65535]")
+	assert.Contains(err.Error(), "invalid value for \"storage.statepruninginterval\": 0 is out of range")
+	assert.Contains(err.Error(), "invalid value for \"mempool.reapstrategy\": \"fifo\" is not one of")
+}
+
+func TestValidateConfigConflicts(t *testing.T) {
+	assert := assert.New(t)
+
+	v := newTestConfig()
+	v.Set(CfgConsensusMaxEpochLength, 6)
+	err := validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"consensus.maxEpochLength\" (6) must be larger than \"consensus.minProposalWait\" (6)")
+
+	v = newTestConfig()
+	v.Set(CfgP2PSeedPeerOnlyOutbound, true)
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"p2p.seedPeerOnlyOutbound\" is enabled")
+	v.Set(CfgP2PDNSSeeds, "seed.example.com")
+	assert.Nil(validateConfig(v))
+
+	v = newTestConfig()
+	v.Set(CfgP2PPort, 16888)
+	assert.Nil(validateConfig(v))
+	v.Set(CfgRPCEnabled, true)
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"rpc.port\" and \"p2p.port\" are both set to 16888")
+
+	v = newTestConfig()
+	v.Set(CfgRPCTLSCertFile, "/etc/theta/rpc.crt")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"rpc.tlsCertFile\" and \"rpc.tlsKeyFile\" need to be set together")
+	v.Set(CfgRPCTLSKeyFile, "/etc/theta/rpc.key")
+	assert.Nil(validateConfig(v))
+
+	v = newTestConfig()
+	v.Set(CfgRPCTLSClientCAFile, "/etc/theta/clients.crt")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "\"rpc.tlsClientCAFile\" is set, but \"rpc.tlsCertFile\" is not")
+
+	v = newTestConfig()
+	v.Set(CfgRPCUnixSocketMode, "0689")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid file mode \"0689\" in \"rpc.unixSocketMode\"")
+
+	v = newTestConfig()
+	v.Set(CfgLogLevels, "*:info,consensus:verbose")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid module log level \"consensus:verbose\"")
+}
+
+func TestValidateConfigLogLevels(t *testing.T) {
+	assert := assert.New(t)
+
+	// The module log levels can be given as a map
+	v := newTestConfig()
+	v.SetConfigType("yaml")
+	assert.Nil(v.ReadConfig(strings.NewReader("log:\n  format: json\n  levels:\n    \"*\": info\n    sync: debug\n    consensus: warn\n")))
+	assert.Nil(validateConfig(v))
+	levels, err := ParseLogLevels(v.Get(CfgLogLevels))
+	assert.Nil(err)
+	assert.Equal(map[string]string{"*": "info", "sync": "debug", "consensus": "warn"}, levels)
+
+	v = newTestConfig()
+	v.SetConfigType("yaml")
+	assert.Nil(v.ReadConfig(strings.NewReader("log:\n  levels:\n    sync: verbose\n")))
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid log level \"verbose\" of module \"sync\"")
+
+	v = newTestConfig()
+	v.Set(CfgLogFormat, "xml")
+	err = validateConfig(v)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "invalid value for \"log.format\": \"xml\" is not one of")
+}
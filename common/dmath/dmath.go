@@ -0,0 +1,258 @@
+// Package dmath provides deterministic arithmetic for consensus-critical computations,
+// e.g. percentage splits, reward calculations and inflation. Everything is computed
+// with big.Int and explicit truncation, so the results never depend on the platform
+// floating point behavior.
+package dmath
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Precision is the number of decimal digits after the decimal point of a Dec
+const Precision = 18
+
+// MaxExponent is the largest absolute exponent accepted by ParseDec
+const MaxExponent = 256
+
+var (
+	zero           = big.NewInt(0)
+	ten            = big.NewInt(10)
+	hundred        = big.NewInt(100)
+	precisionScale = new(big.Int).Exp(ten, big.NewInt(Precision), nil)
+)
+
+var (
+	// ErrDivisionByZero is returned when the denominator is zero
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// ErrInvalidDecimal is returned when a string cannot be parsed as a decimal
+	ErrInvalidDecimal = errors.New("invalid decimal")
+)
+
+// ------------------------------ Integer helpers ------------------------------
+
+// MulDiv returns x * num / den, truncated toward zero. It panics if den is zero.
+func MulDiv(x, num, den *big.Int) *big.Int {
+	if den.Sign() == 0 {
+		panic(ErrDivisionByZero)
+	}
+	ret := new(big.Int).Mul(x, num)
+	return ret.Quo(ret, den)
+}
+
+// MulRatio returns x * num / den, truncated toward zero. It panics if den is zero.
+func MulRatio(x *big.Int, num, den int64) *big.Int {
+	return MulDiv(x, big.NewInt(num), big.NewInt(den))
+}
+
+// Percentage returns the given percentage of x, truncated toward zero
+func Percentage(x *big.Int, percentage uint) *big.Int {
+	return MulDiv(x, new(big.Int).SetUint64(uint64(percentage)), hundred)
+}
+
+// Quo returns x / y, truncated toward zero. It panics if y is zero.
+func Quo(x, y *big.Int) *big.Int {
+	if y.Sign() == 0 {
+		panic(ErrDivisionByZero)
+	}
+	return new(big.Int).Quo(x, y)
+}
+
+// QuoUint64 returns x / y, truncated toward zero. It panics if y is zero.
+func QuoUint64(x *big.Int, y uint64) *big.Int {
+	return Quo(x, new(big.Int).SetUint64(y))
+}
+
+// SplitByPercentages splits total according to the given percentages. Each share is
+// truncated toward zero, and the remainder (total minus the sum of the shares) is
+// returned separately so the caller decides who receives the rounding dust.
+func SplitByPercentages(total *big.Int, percentages []uint) (shares []*big.Int, remainder *big.Int) {
+	remainder = new(big.Int).Set(total)
+	shares = make([]*big.Int, len(percentages))
+	for i, p := range percentages {
+		shares[i] = Percentage(total, p)
+		remainder.Sub(remainder, shares[i])
+	}
+	return shares, remainder
+}
+
+// ------------------------------ Fixed point decimal ------------------------------
+
+// Dec is an immutable fixed-point decimal number with Precision digits after the
+// decimal point. The zero value represents 0.
+type Dec struct {
+	i *big.Int // value * 10^Precision
+}
+
+// ZeroDec returns a Dec representing 0
+func ZeroDec() Dec {
+	return Dec{new(big.Int)}
+}
+
+// OneDec returns a Dec representing 1
+func OneDec() Dec {
+	return Dec{new(big.Int).Set(precisionScale)}
+}
+
+// NewDec creates a Dec from an integer
+func NewDec(i int64) Dec {
+	return NewDecFromBigInt(big.NewInt(i))
+}
+
+// NewDecFromBigInt creates a Dec from a big integer
+func NewDecFromBigInt(i *big.Int) Dec {
+	return Dec{new(big.Int).Mul(i, precisionScale)}
+}
+
+// NewDecWithPrec creates a Dec representing i * 10^-prec, e.g. NewDecWithPrec(317, 11)
+// represents 0.00000000317. It panics if prec exceeds Precision.
+func NewDecWithPrec(i int64, prec uint) Dec {
+	if prec > Precision {
+		panic(fmt.Sprintf("precision %v exceeds the maximum of %v", prec, Precision))
+	}
+	scale := new(big.Int).Exp(ten, big.NewInt(int64(Precision-prec)), nil)
+	return Dec{new(big.Int).Mul(big.NewInt(i), scale)}
+}
+
+// NewDecFromRatio creates a Dec representing num / den, truncated toward zero.
+// It panics if den is zero.
+func NewDecFromRatio(num, den int64) Dec {
+	return Dec{MulDiv(big.NewInt(num), precisionScale, big.NewInt(den))}
+}
+
+// NewDecFromPercentage creates a Dec representing percentage / 100
+func NewDecFromPercentage(percentage uint) Dec {
+	return Dec{Percentage(precisionScale, percentage)}
+}
+
+// ParseDec parses a decimal string such as "12", "-0.5" or "1.5e3". Digits beyond
+// Precision are truncated toward zero.
+func ParseDec(s string) (Dec, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return Dec{}, ErrInvalidDecimal
+	}
+
+	exp := int64(0)
+	if idx := strings.IndexAny(s, "eE"); idx >= 0 {
+		e, ok := new(big.Int).SetString(s[idx+1:], 10)
+		if !ok || !e.IsInt64() || e.Int64() > MaxExponent || e.Int64() < -MaxExponent {
+			return Dec{}, ErrInvalidDecimal
+		}
+		exp = e.Int64()
+		s = s[:idx]
+	}
+
+	neg := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if idx := strings.IndexByte(s, '.'); idx >= 0 {
+		intPart, fracPart = s[:idx], s[idx+1:]
+	}
+	if len(intPart) == 0 && len(fracPart) == 0 {
+		return Dec{}, ErrInvalidDecimal
+	}
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return Dec{}, ErrInvalidDecimal
+		}
+	}
+
+	digits, _ := new(big.Int).SetString("0"+intPart+fracPart, 10)
+	shift := int64(Precision) - int64(len(fracPart)) + exp
+	if shift >= 0 {
+		digits.Mul(digits, new(big.Int).Exp(ten, big.NewInt(shift), nil))
+	} else {
+		digits.Quo(digits, new(big.Int).Exp(ten, big.NewInt(-shift), nil))
+	}
+	if neg {
+		digits.Neg(digits)
+	}
+	return Dec{digits}, nil
+}
+
+func (d Dec) bigInt() *big.Int {
+	if d.i == nil {
+		return zero
+	}
+	return d.i
+}
+
+// BigInt returns the underlying integer, i.e. the value multiplied by 10^Precision
+func (d Dec) BigInt() *big.Int {
+	return new(big.Int).Set(d.bigInt())
+}
+
+// Add returns d + d2
+func (d Dec) Add(d2 Dec) Dec {
+	return Dec{new(big.Int).Add(d.bigInt(), d2.bigInt())}
+}
+
+// Sub returns d - d2
+func (d Dec) Sub(d2 Dec) Dec {
+	return Dec{new(big.Int).Sub(d.bigInt(), d2.bigInt())}
+}
+
+// Mul returns d * d2, truncated toward zero
+func (d Dec) Mul(d2 Dec) Dec {
+	return Dec{MulDiv(d.bigInt(), d2.bigInt(), precisionScale)}
+}
+
+// Quo returns d / d2, truncated toward zero. It panics if d2 is zero.
+func (d Dec) Quo(d2 Dec) Dec {
+	return Dec{MulDiv(d.bigInt(), precisionScale, d2.bigInt())}
+}
+
+// MulInt returns d * i
+func (d Dec) MulInt(i *big.Int) Dec {
+	return Dec{new(big.Int).Mul(d.bigInt(), i)}
+}
+
+// MulTruncateInt returns d * i truncated toward zero to an integer. This is the
+// typical way to apply a rate to an amount of coins.
+func (d Dec) MulTruncateInt(i *big.Int) *big.Int {
+	return MulDiv(i, d.bigInt(), precisionScale)
+}
+
+// TruncateInt returns the integer part of d, truncated toward zero
+func (d Dec) TruncateInt() *big.Int {
+	return new(big.Int).Quo(d.bigInt(), precisionScale)
+}
+
+// Cmp compares d and d2, and returns -1, 0 or +1
+func (d Dec) Cmp(d2 Dec) int {
+	return d.bigInt().Cmp(d2.bigInt())
+}
+
+// Equal indicates whether d and d2 represent the same value
+func (d Dec) Equal(d2 Dec) bool {
+	return d.Cmp(d2) == 0
+}
+
+// IsZero indicates whether d is zero
+func (d Dec) IsZero() bool {
+	return d.bigInt().Sign() == 0
+}
+
+// IsNegative indicates whether d is negative
+func (d Dec) IsNegative() bool {
+	return d.bigInt().Sign() < 0
+}
+
+// String returns the decimal representation of d with all Precision digits
+func (d Dec) String() string {
+	abs := new(big.Int).Abs(d.bigInt())
+	intPart, fracPart := new(big.Int).QuoRem(abs, precisionScale, new(big.Int))
+	sign := ""
+	if d.IsNegative() {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%v.%0*v", sign, intPart, Precision, fracPart)
+}
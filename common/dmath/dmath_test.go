@@ -0,0 +1,85 @@
+package dmath
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMulDiv(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("33", MulDiv(big.NewInt(100), big.NewInt(1), big.NewInt(3)).String())
+	assert.Equal("-33", MulDiv(big.NewInt(-100), big.NewInt(1), big.NewInt(3)).String())
+	assert.Equal("66", MulRatio(big.NewInt(100), 2, 3).String())
+	assert.Panics(func() { MulDiv(big.NewInt(1), big.NewInt(1), big.NewInt(0)) })
+}
+
+func TestPercentage(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("33", Percentage(big.NewInt(101), 33).String())
+	assert.Equal("0", Percentage(big.NewInt(99), 1).String())
+	assert.Equal("101", Percentage(big.NewInt(101), 100).String())
+
+	shares, remainder := SplitByPercentages(big.NewInt(1000), []uint{33, 33, 33})
+	for _, share := range shares {
+		assert.Equal("330", share.String())
+	}
+	assert.Equal("10", remainder.String())
+}
+
+func TestQuo(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("3", QuoUint64(big.NewInt(10), 3).String())
+	assert.Equal("-3", Quo(big.NewInt(-10), big.NewInt(3)).String())
+	assert.Panics(func() { QuoUint64(big.NewInt(10), 0) })
+}
+
+func TestDecArithmetic(t *testing.T) {
+	assert := assert.New(t)
+
+	third := NewDecFromRatio(1, 3)
+	assert.Equal("0.333333333333333333", third.String())
+	assert.Equal("0.999999999999999999", third.Add(third).Add(third).String())
+	assert.Equal("0.111111111111111110", third.Mul(third).String())
+	assert.Equal("1.000000000000000000", third.Quo(third).String())
+	assert.Equal("-0.666666666666666667", third.Sub(OneDec()).String())
+	assert.True(NewDecFromPercentage(50).Equal(NewDecWithPrec(5, 1)))
+	assert.True(Dec{}.IsZero())
+	assert.Equal("0.000000000000000000", Dec{}.String())
+
+	rate := NewDecWithPrec(317, 11)
+	amount, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+	assert.Equal("3170000000000000", rate.MulTruncateInt(amount).String())
+	assert.Equal("7", NewDecFromRatio(15, 2).TruncateInt().String())
+	assert.Equal("-7", NewDecFromRatio(-15, 2).TruncateInt().String())
+}
+
+func TestParseDec(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cases := map[string]string{
+		"12":                    "12.000000000000000000",
+		"-0.5":                  "-0.500000000000000000",
+		"+.25":                  "0.250000000000000000",
+		"1.5e3":                 "1500.000000000000000000",
+		"1E-3":                  "0.001000000000000000",
+		"0.0000001e3":           "0.000100000000000000",
+		"0.1234567890123456789": "0.123456789012345678",
+	}
+	for in, expected := range cases {
+		d, err := ParseDec(in)
+		require.Nil(err, in)
+		assert.Equal(expected, d.String(), in)
+	}
+
+	for _, in := range []string{"", ".", "-", "1.2.3", "abc", "1e", "1e1000", "0x10"} {
+		_, err := ParseDec(in)
+		assert.Equal(ErrInvalidDecimal, err, in)
+	}
+}
@@ -0,0 +1,141 @@
+// Package prometheus exports the metrics in the Prometheus text exposition format
+// <https://prometheus.io/docs/instrumenting/exposition_formats/>
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thetatoken/theta/common/metrics"
+)
+
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99}
+
+// Sample is a single value of a metric family, distinguished by its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Family is a group of samples sharing the same metric name, e.g. the traffic
+// counters of all the peers
+type Family struct {
+	Name    string
+	Help    string
+	Type    string // "counter" or "gauge"
+	Samples []Sample
+}
+
+// Collector returns the metric families which cannot be kept in a metrics.Registry,
+// typically the ones with labels
+type Collector func() []Family
+
+// Handler returns an HTTP handler which exports the metrics of the registry followed
+// by the families returned by the collectors. Resetting timers are skipped, since
+// taking their snapshot would clear the values for the other reporters.
+func Handler(reg metrics.Registry, collectors ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(Export(reg, collectors...))
+	})
+}
+
+// Export renders the metrics in the Prometheus text format
+func Export(reg metrics.Registry, collectors ...Collector) []byte {
+	buf := &bytes.Buffer{}
+
+	names := []string{}
+	reg.Each(func(name string, i interface{}) {
+		names = append(names, name)
+	})
+	sort.Strings(names)
+	for _, name := range names {
+		writeMetric(buf, SanitizeName(name), reg.Get(name))
+	}
+
+	for _, collector := range collectors {
+		for _, family := range collector() {
+			writeFamily(buf, family)
+		}
+	}
+	return buf.Bytes()
+}
+
+// SanitizeName converts a metric name such as "mempool/reap/gas" into a valid
+// Prometheus metric name, i.e. "mempool_reap_gas"
+func SanitizeName(name string) string {
+	return strings.Map(func(c rune) rune {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == ':' {
+			return c
+		}
+		return '_'
+	}, name)
+}
+
+func writeMetric(buf *bytes.Buffer, name string, i interface{}) {
+	switch m := i.(type) {
+	case metrics.Counter:
+		writeType(buf, name, "counter")
+		writeValue(buf, name, nil, float64(m.Count()))
+	case metrics.Gauge:
+		writeType(buf, name, "gauge")
+		writeValue(buf, name, nil, float64(m.Value()))
+	case metrics.GaugeFloat64:
+		writeType(buf, name, "gauge")
+		writeValue(buf, name, nil, m.Value())
+	case metrics.Meter:
+		writeType(buf, name, "counter")
+		writeValue(buf, name, nil, float64(m.Snapshot().Count()))
+	case metrics.Histogram:
+		h := m.Snapshot()
+		writeSummary(buf, name, h.Percentiles(quantiles), h.Sum(), h.Count())
+	case metrics.Timer:
+		t := m.Snapshot()
+		writeSummary(buf, name, t.Percentiles(quantiles), t.Sum(), t.Count())
+	}
+}
+
+func writeSummary(buf *bytes.Buffer, name string, ps []float64, sum int64, count int64) {
+	writeType(buf, name, "summary")
+	for i, q := range quantiles {
+		writeValue(buf, name, map[string]string{"quantile": strconv.FormatFloat(q, 'f', -1, 64)}, ps[i])
+	}
+	writeValue(buf, name+"_sum", nil, float64(sum))
+	writeValue(buf, name+"_count", nil, float64(count))
+}
+
+func writeFamily(buf *bytes.Buffer, family Family) {
+	name := SanitizeName(family.Name)
+	if family.Help != "" {
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, family.Help)
+	}
+	writeType(buf, name, family.Type)
+	for _, sample := range family.Samples {
+		writeValue(buf, name, sample.Labels, sample.Value)
+	}
+}
+
+func writeType(buf *bytes.Buffer, name string, typ string) {
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, typ)
+}
+
+func writeValue(buf *bytes.Buffer, name string, labels map[string]string, value float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		keys := []string{}
+		for key := range labels {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pairs := []string{}
+		for _, key := range keys {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", SanitizeName(key), strconv.Quote(labels[key])))
+		}
+		buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	buf.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
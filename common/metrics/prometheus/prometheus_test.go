@@ -0,0 +1,49 @@
+package prometheus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common/metrics"
+)
+
+func init() {
+	metrics.Enabled = true
+}
+
+func TestExport(t *testing.T) {
+	assert := assert.New(t)
+
+	reg := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("trie/cachemiss", reg).Inc(3)
+	metrics.NewRegisteredGauge("mempool/reap/gas", reg).Update(42)
+
+	collector := func() []Family {
+		return []Family{{
+			Name: "p2p_peer_channel_sent_bytes_total",
+			Help: "Bytes sent.",
+			Type: "counter",
+			Samples: []Sample{
+				{Labels: map[string]string{"peer": "0xabc", "channel": "block"}, Value: 1024},
+			},
+		}}
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(Export(reg, collector))), "\n")
+	assert.Equal([]string{
+		"# TYPE mempool_reap_gas gauge",
+		"mempool_reap_gas 42",
+		"# TYPE trie_cachemiss counter",
+		"trie_cachemiss 3",
+		"# HELP p2p_peer_channel_sent_bytes_total Bytes sent.",
+		"# TYPE p2p_peer_channel_sent_bytes_total counter",
+		`p2p_peer_channel_sent_bytes_total{channel="block",peer="0xabc"} 1024`,
+	}, lines)
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("mempool_reap_gas", SanitizeName("mempool/reap/gas"))
+	assert.Equal("db_chain_compact_write_delay", SanitizeName("db.chain/compact-write delay"))
+}
@@ -0,0 +1,322 @@
+package profiler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "profiler"})
+
+// Interval at which the GC pauses and the heap size are checked
+const runtimeCheckInterval = 10 * time.Second
+
+const (
+	cpuProfileFile       = "cpu.pprof"
+	heapProfileFile      = "heap.pprof"
+	goroutineProfileFile = "goroutine.pprof"
+	reasonFile           = "reason.txt"
+)
+
+// ReasonManual is the reason of the captures requested through the RPC
+const ReasonManual = "manual"
+
+// Capture is a set of runtime profiles captured at the same time
+type Capture struct {
+	Name   string   `json:"name"`
+	Reason string   `json:"reason"`
+	Files  []string `json:"files"`
+}
+
+// Profiler captures the CPU, heap and goroutine profiles when block processing is slow, a GC
+// pause is long, or the heap grows too large. It keeps the latest captures on disk, one
+// directory per capture.
+type Profiler struct {
+	mu *sync.Mutex
+
+	dir                    string
+	maxCaptures            int
+	cpuProfileDuration     time.Duration
+	minCaptureInterval     time.Duration
+	maxBlockProcessingTime time.Duration
+	maxGCPause             time.Duration
+	maxHeapAlloc           uint64
+
+	capturing   bool
+	lastCapture time.Time
+	lastNumGC   uint32
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewProfiler creates a new instance of Profiler which stores the captures under dir.
+func NewProfiler(dir string) *Profiler {
+	return &Profiler{
+		mu: &sync.Mutex{},
+		wg: &sync.WaitGroup{},
+
+		dir:                    dir,
+		maxCaptures:            viper.GetInt(common.CfgProfilerMaxCaptures),
+		cpuProfileDuration:     time.Duration(viper.GetInt(common.CfgProfilerCPUProfileDuration)) * time.Second,
+		minCaptureInterval:     time.Duration(viper.GetInt(common.CfgProfilerMinCaptureInterval)) * time.Second,
+		maxBlockProcessingTime: time.Duration(viper.GetInt(common.CfgProfilerMaxBlockProcessingTime)) * time.Millisecond,
+		maxGCPause:             time.Duration(viper.GetInt(common.CfgProfilerMaxGCPause)) * time.Millisecond,
+		maxHeapAlloc:           uint64(viper.GetInt(common.CfgProfilerMaxHeapAlloc)) * 1024 * 1024,
+	}
+}
+
+// Start creates the main goroutine.
+func (p *Profiler) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	p.ctx = c
+	p.cancel = cancel
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	p.lastNumGC = memStats.NumGC
+
+	p.wg.Add(1)
+	go p.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (p *Profiler) Stop() {
+	p.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (p *Profiler) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Profiler) mainLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(runtimeCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			var memStats runtime.MemStats
+			runtime.ReadMemStats(&memStats)
+			if reason := p.checkRuntime(&memStats); len(reason) > 0 {
+				p.trigger(reason)
+			}
+		}
+	}
+}
+
+// checkRuntime returns the reason for a capture, or an empty string if the runtime is healthy.
+func (p *Profiler) checkRuntime(memStats *runtime.MemStats) string {
+	p.mu.Lock()
+	lastNumGC := p.lastNumGC
+	p.lastNumGC = memStats.NumGC
+	p.mu.Unlock()
+
+	if p.maxHeapAlloc > 0 && memStats.HeapAlloc > p.maxHeapAlloc {
+		return fmt.Sprintf("heap alloc %vMB exceeds %vMB", memStats.HeapAlloc/1024/1024, p.maxHeapAlloc/1024/1024)
+	}
+
+	if p.maxGCPause == 0 {
+		return ""
+	}
+	// PauseNs is a circular buffer of the most recent 256 GC pauses
+	numGC := memStats.NumGC - lastNumGC
+	if numGC > uint32(len(memStats.PauseNs)) {
+		numGC = uint32(len(memStats.PauseNs))
+	}
+	for i := uint32(0); i < numGC; i++ {
+		pause := time.Duration(memStats.PauseNs[(memStats.NumGC-i+255)%256])
+		if pause > p.maxGCPause {
+			return fmt.Sprintf("GC pause %v exceeds %v", pause, p.maxGCPause)
+		}
+	}
+	return ""
+}
+
+// ObserveBlockProcessingTime triggers a capture if processing a block took too long.
+func (p *Profiler) ObserveBlockProcessingTime(height uint64, duration time.Duration) {
+	if p.maxBlockProcessingTime == 0 || duration <= p.maxBlockProcessingTime {
+		return
+	}
+	p.trigger(fmt.Sprintf("processing block %v took %v, exceeds %v", height, duration, p.maxBlockProcessingTime))
+}
+
+// trigger starts a capture in the background, unless one is in progress or the last one was
+// taken less than minCaptureInterval ago.
+func (p *Profiler) trigger(reason string) {
+	p.mu.Lock()
+	if p.capturing || (!p.lastCapture.IsZero() && time.Since(p.lastCapture) < p.minCaptureInterval) {
+		p.mu.Unlock()
+		return
+	}
+	p.capturing = true
+	p.mu.Unlock()
+
+	logger.WithFields(log.Fields{"reason": reason}).Warn("Capturing runtime profiles")
+	go func() {
+		if _, err := p.capture(reason); err != nil {
+			logger.WithFields(log.Fields{"error": err}).Error("Failed to capture runtime profiles")
+		}
+	}()
+}
+
+// Capture captures the runtime profiles right away. It blocks for the duration of the CPU profile.
+func (p *Profiler) Capture(reason string) (*Capture, error) {
+	p.mu.Lock()
+	if p.capturing {
+		p.mu.Unlock()
+		return nil, errors.New("Another capture is in progress")
+	}
+	p.capturing = true
+	p.mu.Unlock()
+
+	return p.capture(reason)
+}
+
+// capture expects p.capturing to be set by the caller.
+func (p *Profiler) capture(reason string) (*Capture, error) {
+	defer func() {
+		p.mu.Lock()
+		p.capturing = false
+		p.lastCapture = time.Now()
+		p.mu.Unlock()
+	}()
+
+	name := time.Now().UTC().Format("20060102-150405.000000")
+	captureDir := path.Join(p.dir, name)
+	if err := os.MkdirAll(captureDir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(path.Join(captureDir, reasonFile), []byte(reason), 0600); err != nil {
+		return nil, err
+	}
+
+	// The CPU profile cannot be taken if one is already running, e.g. through /debug/pprof/profile
+	if err := p.writeProfile(captureDir, cpuProfileFile, p.writeCPUProfile); err != nil {
+		logger.WithFields(log.Fields{"error": err}).Warn("Failed to capture CPU profile")
+	}
+	if err := p.writeProfile(captureDir, heapProfileFile, pprof.WriteHeapProfile); err != nil {
+		return nil, err
+	}
+	if err := p.writeProfile(captureDir, goroutineProfileFile, func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 0)
+	}); err != nil {
+		return nil, err
+	}
+
+	p.pruneCaptures()
+
+	return p.loadCapture(name)
+}
+
+func (p *Profiler) writeCPUProfile(w io.Writer) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return err
+	}
+	select {
+	case <-time.After(p.cpuProfileDuration):
+	case <-p.done():
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
+func (p *Profiler) done() <-chan struct{} {
+	if p.ctx == nil {
+		return nil
+	}
+	return p.ctx.Done()
+}
+
+func (p *Profiler) writeProfile(captureDir string, fileName string, write func(w io.Writer) error) error {
+	filePath := path.Join(captureDir, fileName)
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	f.Close()
+	if err != nil {
+		os.Remove(filePath)
+	}
+	return err
+}
+
+// captureNames returns the names of the captures on disk, oldest first.
+func (p *Profiler) captureNames() []string {
+	entries, err := ioutil.ReadDir(p.dir)
+	if err != nil {
+		return []string{}
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Profiler) pruneCaptures() {
+	names := p.captureNames()
+	for i := 0; i < len(names)-p.maxCaptures; i++ {
+		if err := os.RemoveAll(path.Join(p.dir, names[i])); err != nil {
+			logger.WithFields(log.Fields{"error": err, "capture": names[i]}).Warn("Failed to remove capture")
+		}
+	}
+}
+
+func (p *Profiler) loadCapture(name string) (*Capture, error) {
+	captureDir := path.Join(p.dir, name)
+	entries, err := ioutil.ReadDir(captureDir)
+	if err != nil {
+		return nil, err
+	}
+	capture := &Capture{Name: name, Files: []string{}}
+	for _, entry := range entries {
+		if entry.Name() == reasonFile {
+			reason, err := ioutil.ReadFile(path.Join(captureDir, reasonFile))
+			if err != nil {
+				return nil, err
+			}
+			capture.Reason = strings.TrimSpace(string(reason))
+			continue
+		}
+		capture.Files = append(capture.Files, path.Join(captureDir, entry.Name()))
+	}
+	return capture, nil
+}
+
+// Captures returns the captures on disk, oldest first.
+func (p *Profiler) Captures() []Capture {
+	captures := []Capture{}
+	for _, name := range p.captureNames() {
+		capture, err := p.loadCapture(name)
+		if err != nil {
+			continue
+		}
+		captures = append(captures, *capture)
+	}
+	return captures
+}
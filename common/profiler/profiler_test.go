@@ -0,0 +1,97 @@
+package profiler
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProfiler(t *testing.T) (*Profiler, func()) {
+	dir, err := ioutil.TempDir("", "theta-profiler-test")
+	require.Nil(t, err)
+
+	p := NewProfiler(dir)
+	p.cpuProfileDuration = 10 * time.Millisecond
+	p.maxCaptures = 2
+	return p, func() { os.RemoveAll(dir) }
+}
+
+func waitForCapture(p *Profiler) {
+	for i := 0; i < 500; i++ {
+		p.mu.Lock()
+		done := !p.capturing
+		p.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestProfilerCapture(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p, cleanup := newTestProfiler(t)
+	defer cleanup()
+
+	capture, err := p.Capture(ReasonManual)
+	require.Nil(err)
+	assert.Equal(ReasonManual, capture.Reason)
+	assert.Equal(3, len(capture.Files))
+	for _, file := range capture.Files {
+		info, err := os.Stat(file)
+		require.Nil(err)
+		assert.True(info.Size() > 0)
+	}
+
+	// Only the latest captures are kept
+	for i := 0; i < 2; i++ {
+		_, err = p.Capture("test")
+		require.Nil(err)
+	}
+	captures := p.Captures()
+	assert.Equal(2, len(captures))
+	assert.Equal("test", captures[0].Reason)
+	_, err = os.Stat(path.Join(p.dir, capture.Name))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestProfilerTriggers(t *testing.T) {
+	assert := assert.New(t)
+
+	p, cleanup := newTestProfiler(t)
+	defer cleanup()
+	p.maxBlockProcessingTime = time.Second
+	p.maxGCPause = 100 * time.Millisecond
+	p.maxHeapAlloc = 1024 * 1024
+	p.minCaptureInterval = time.Hour
+
+	memStats := &runtime.MemStats{NumGC: 2}
+	memStats.PauseNs[0] = uint64(200 * time.Millisecond)
+	memStats.PauseNs[1] = uint64(50 * time.Millisecond)
+	assert.Contains(p.checkRuntime(memStats), "GC pause")
+	// Pauses already checked are not reported again
+	assert.Equal("", p.checkRuntime(memStats))
+
+	memStats.HeapAlloc = 2 * 1024 * 1024
+	assert.Equal("heap alloc 2MB exceeds 1MB", p.checkRuntime(memStats))
+
+	p.ObserveBlockProcessingTime(10, 500*time.Millisecond)
+	assert.Equal(0, len(p.Captures()))
+
+	p.ObserveBlockProcessingTime(10, 2*time.Second)
+	waitForCapture(p)
+	assert.Equal(1, len(p.Captures()))
+
+	// Automatic captures are rate limited
+	p.ObserveBlockProcessingTime(11, 2*time.Second)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(1, len(p.Captures()))
+}
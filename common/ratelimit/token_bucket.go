@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"time"
+)
+
+// TokenBucket holds up to limit tokens, refilled at limit tokens per period. A limit of 0 means
+// unlimited. It is not safe for concurrent use.
+type TokenBucket struct {
+	limit      float64
+	period     time.Duration
+	tokens     float64
+	lastRefill time.Time
+}
+
+// NewTokenBucket creates a full bucket.
+func NewTokenBucket(limit float64, period time.Duration, now time.Time) *TokenBucket {
+	return &TokenBucket{
+		limit:      limit,
+		period:     period,
+		tokens:     limit,
+		lastRefill: now,
+	}
+}
+
+// Available refills the bucket and returns whether it has n tokens.
+func (b *TokenBucket) Available(n int, now time.Time) bool {
+	if b.limit == 0 || n == 0 {
+		return true
+	}
+	if elapsed := now.Sub(b.lastRefill); elapsed > 0 {
+		b.tokens += b.limit * float64(elapsed) / float64(b.period)
+		if b.tokens > b.limit {
+			b.tokens = b.limit
+		}
+		b.lastRefill = now
+	}
+	return b.tokens >= float64(n)
+}
+
+// Take removes n tokens from the bucket, once Available returned true for them.
+func (b *TokenBucket) Take(n int) {
+	if b.limit == 0 {
+		return
+	}
+	b.tokens -= float64(n)
+}
+
+// Full returns whether the bucket is refilled, i.e. no token was taken for a whole period.
+func (b *TokenBucket) Full(now time.Time) bool {
+	if b.limit == 0 {
+		return true
+	}
+	b.Available(1, now) // refills the bucket
+	return b.tokens >= b.limit
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	b := NewTokenBucket(4, time.Second, now)
+	assert.True(b.Full(now))
+	assert.True(b.Available(4, now))
+	assert.False(b.Available(5, now))
+	b.Take(3)
+	assert.False(b.Available(2, now))
+	assert.False(b.Full(now))
+
+	// Refilled at 4 tokens per second, up to the limit
+	now = now.Add(250 * time.Millisecond)
+	assert.True(b.Available(2, now))
+	now = now.Add(10 * time.Second)
+	assert.True(b.Full(now))
+	assert.False(b.Available(5, now))
+
+	// Unlimited
+	b = NewTokenBucket(0, time.Second, now)
+	b.Take(100)
+	assert.True(b.Available(1000, now))
+	assert.True(b.Full(now))
+}
@@ -1,5 +1,7 @@
 package result
 
+import "fmt"
+
 type ErrorCode int
 
 const (
@@ -13,20 +15,39 @@ const (
 	CodeEmptyPubKeyWithSequence1 ErrorCode = 100004
 	CodeUnauthorizedTx           ErrorCode = 100005
 	CodeInvalidFee               ErrorCode = 100006
+	CodeInvalidFeeDenomination   ErrorCode = 100007
+	CodeZeroFeeLaneLimitExceeded ErrorCode = 100008
+	CodeTxTooLarge               ErrorCode = 100009
+	CodeTooManyTxInputs          ErrorCode = 100010
+	CodeTooManyTxOutputs         ErrorCode = 100011
+	CodeTxExpired                ErrorCode = 100012
+	CodeDuplicateTx              ErrorCode = 100013
+	CodeReplacementFeeTooLow     ErrorCode = 100014
+	CodeInvalidFeePayer          ErrorCode = 100015
+	CodeTooManyPendingTxs        ErrorCode = 100016
+	CodeMempoolFull              ErrorCode = 100017
+	CodeTxReplaced               ErrorCode = 100018
+	CodeTxTimeout                ErrorCode = 100019
+	CodeInvalidResourceID        ErrorCode = 100020
 
 	// ReserveFund Errors
 	CodeReserveFundCheckFailed   ErrorCode = 101001
 	CodeReservedFundNotSpecified ErrorCode = 101002
 	CodeInvalidFundToReserve     ErrorCode = 101003
+	CodeTooManyResourceIDs       ErrorCode = 101004
+	CodeTooManyReservedFunds     ErrorCode = 101005
 
 	// ReleaseFund Errors
 	CodeReleaseFundCheckFailed ErrorCode = 102001
 
 	// ServerPayment Errors
 	CodeCheckTransferReservedFundFailed ErrorCode = 103001
+	CodeStaleServicePayment             ErrorCode = 103002
+	CodeNoPendingSettlement             ErrorCode = 103003
 
 	// SplitRule Errors
 	CodeUnauthorizedToUpdateSplitRule ErrorCode = 104001
+	CodeTooManySplits                 ErrorCode = 104002
 
 	// SmartContract Errors
 	CodeEVMError               ErrorCode = 105001
@@ -39,4 +60,95 @@ const (
 	CodeInvalidStake            ErrorCode = 106002
 	CodeInsufficientStake       ErrorCode = 106003
 	CodeNotEnoughBalanceToStake ErrorCode = 106004
+
+	// AccountOperator Errors
+	CodeInvalidAccountOperator     ErrorCode = 107001
+	CodeOperatorSpendLimitExceeded ErrorCode = 107002
+
+	// NodeAddress Errors
+	CodeInvalidNodeAddress ErrorCode = 108001
+	CodeNotAValidator      ErrorCode = 108002
+
+	// Slashing Errors
+	CodeSlashingDisabled   ErrorCode = 109001
+	CodeNoSlashRecord      ErrorCode = 109002
+	CodeSlashReviewClosed  ErrorCode = 109003
+	CodeNotACouncilMember  ErrorCode = 109004
+	CodeNotEnoughApprovals ErrorCode = 109005
+
+	// DataCommitment Errors
+	CodeInvalidDataCommitment ErrorCode = 110001
+	CodeDataCommitmentExists  ErrorCode = 110002
+
+	// MultiSig Errors
+	CodeInvalidAccountSigners ErrorCode = 111001
+	CodeMultiSigAccount       ErrorCode = 111002
+	CodeNotEnoughSignatures   ErrorCode = 111003
 )
+
+// codeNames are the symbolic names of the error codes, which the clients can match on
+var codeNames = map[ErrorCode]string{
+	CodeOK:                              "OK",
+	CodeGenericError:                    "GenericError",
+	CodeInvalidSignature:                "InvalidSignature",
+	CodeInvalidSequence:                 "InvalidSequence",
+	CodeInsufficientFund:                "InsufficientFund",
+	CodeEmptyPubKeyWithSequence1:        "EmptyPubKeyWithSequence1",
+	CodeUnauthorizedTx:                  "UnauthorizedTx",
+	CodeInvalidFee:                      "InvalidFee",
+	CodeInvalidFeeDenomination:          "InvalidFeeDenomination",
+	CodeZeroFeeLaneLimitExceeded:        "ZeroFeeLaneLimitExceeded",
+	CodeTxTooLarge:                      "TxTooLarge",
+	CodeTooManyTxInputs:                 "TooManyTxInputs",
+	CodeTooManyTxOutputs:                "TooManyTxOutputs",
+	CodeTxExpired:                       "TxExpired",
+	CodeDuplicateTx:                     "DuplicateTx",
+	CodeReplacementFeeTooLow:            "ReplacementFeeTooLow",
+	CodeInvalidFeePayer:                 "InvalidFeePayer",
+	CodeTooManyPendingTxs:               "TooManyPendingTxs",
+	CodeMempoolFull:                     "MempoolFull",
+	CodeTxReplaced:                      "TxReplaced",
+	CodeTxTimeout:                       "TxTimeout",
+	CodeInvalidResourceID:               "InvalidResourceID",
+	CodeReserveFundCheckFailed:          "ReserveFundCheckFailed",
+	CodeReservedFundNotSpecified:        "ReservedFundNotSpecified",
+	CodeInvalidFundToReserve:            "InvalidFundToReserve",
+	CodeTooManyResourceIDs:              "TooManyResourceIDs",
+	CodeTooManyReservedFunds:            "TooManyReservedFunds",
+	CodeReleaseFundCheckFailed:          "ReleaseFundCheckFailed",
+	CodeCheckTransferReservedFundFailed: "CheckTransferReservedFundFailed",
+	CodeStaleServicePayment:             "StaleServicePayment",
+	CodeNoPendingSettlement:             "NoPendingSettlement",
+	CodeUnauthorizedToUpdateSplitRule:   "UnauthorizedToUpdateSplitRule",
+	CodeTooManySplits:                   "TooManySplits",
+	CodeEVMError:                        "EVMError",
+	CodeInvalidValueToTransfer:          "InvalidValueToTransfer",
+	CodeInvalidGasPrice:                 "InvalidGasPrice",
+	CodeFeeLimitTooHigh:                 "FeeLimitTooHigh",
+	CodeInvalidStakePurpose:             "InvalidStakePurpose",
+	CodeInvalidStake:                    "InvalidStake",
+	CodeInsufficientStake:               "InsufficientStake",
+	CodeNotEnoughBalanceToStake:         "NotEnoughBalanceToStake",
+	CodeInvalidAccountOperator:          "InvalidAccountOperator",
+	CodeOperatorSpendLimitExceeded:      "OperatorSpendLimitExceeded",
+	CodeInvalidNodeAddress:              "InvalidNodeAddress",
+	CodeNotAValidator:                   "NotAValidator",
+	CodeSlashingDisabled:                "SlashingDisabled",
+	CodeNoSlashRecord:                   "NoSlashRecord",
+	CodeSlashReviewClosed:               "SlashReviewClosed",
+	CodeNotACouncilMember:               "NotACouncilMember",
+	CodeNotEnoughApprovals:              "NotEnoughApprovals",
+	CodeInvalidDataCommitment:           "InvalidDataCommitment",
+	CodeDataCommitmentExists:            "DataCommitmentExists",
+	CodeInvalidAccountSigners:           "InvalidAccountSigners",
+	CodeMultiSigAccount:                 "MultiSigAccount",
+	CodeNotEnoughSignatures:             "NotEnoughSignatures",
+}
+
+// Name returns the symbolic name of the error code
+func (code ErrorCode) Name() string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(code))
+}
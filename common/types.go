@@ -388,4 +388,23 @@ const (
 
 	// ChannelIDPing indicates the channel for Ping/Pong messages between peers
 	ChannelIDPing
+
+	// ChannelIDValidatorMesh indicates the channel for the direct connections between validators
+	ChannelIDValidatorMesh
+
+	// ChannelIDGuardian indicates the channel for the guardian votes
+	ChannelIDGuardian
+
+	// ChannelIDMempoolSync indicates the channel for the mempool reconciliation between peers
+	ChannelIDMempoolSync
+
+	// ChannelIDSnapshotRequest indicates the channel for the snapshot chunk requests of the fast sync
+	ChannelIDSnapshotRequest
+
+	// ChannelIDSnapshotResponse indicates the channel for the snapshot chunks served to the fast sync
+	ChannelIDSnapshotResponse
+
+	// ChannelIDNodeInfo indicates the packet extending the NodeInfo during the handshake, which the
+	// older nodes ignore as an unknown channel
+	ChannelIDNodeInfo
 )
@@ -2,7 +2,7 @@ package util
 
 import (
 	"fmt"
-	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -21,16 +21,12 @@ const (
 )
 const defaultLevel = warnLevel
 
-func parseLogLevelConfig(config string) map[string]string {
-	levels := make(map[string]string)
-
-	moduleAndLevels := strings.Split(config, ",")
-	for _, moduleAndLevel := range moduleAndLevels {
-		tokens := strings.Split(moduleAndLevel, ":")
-		if len(tokens) != 2 {
-			panic(fmt.Sprintf("Failed to parse module log level: \"%v\"", moduleAndLevel))
-		}
-		levels[strings.TrimSpace(tokens[0])] = strings.TrimSpace(tokens[1])
+// parseLogLevelConfig parses the module log levels, given either as a comma separated list of
+// <module>:<level>, or as a map from the modules to the levels, see common.ParseLogLevels.
+func parseLogLevelConfig(config interface{}) map[string]string {
+	levels, err := common.ParseLogLevels(config)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to parse module log levels: %v", err))
 	}
 
 	if _, ok := levels["*"]; !ok {
@@ -42,17 +38,14 @@ func parseLogLevelConfig(config string) map[string]string {
 // GetLoggerForModule returns the logger for given module.
 func GetLoggerForModule(module string) *log.Entry {
 	if logLevels == nil {
-		logLevels = parseLogLevelConfig(viper.GetString(common.CfgLogLevels))
-		log.Infof("Log settings: %v, %v", logLevels, viper.GetString(common.CfgLogLevels))
+		logLevels = parseLogLevelConfig(viper.Get(common.CfgLogLevels))
+		log.Infof("Log settings: %v, %v", logLevels, viper.Get(common.CfgLogLevels))
 	}
-	customFormatter := new(TextFormatter)
-	customFormatter.TimestampFormat = "2006-01-02 15:04:05"
-	log.SetFormatter(customFormatter)
-	customFormatter.FullTimestamp = true
-	customFormatter.ForceFormatting = true
+	formatter := newLogFormatter(viper.GetString(common.CfgLogFormat))
+	log.SetFormatter(formatter)
 
 	logger := log.New()
-	logger.Formatter = customFormatter
+	logger.Formatter = formatter
 
 	level, ok := logLevels[module]
 	if !ok {
@@ -75,3 +68,16 @@ func GetLoggerForModule(module string) *log.Entry {
 
 	return logger.WithFields(log.Fields{"prefix": module})
 }
+
+// newLogFormatter returns the formatter of the given log format, "json" for structured logs with
+// one JSON object per line, and human readable text otherwise.
+func newLogFormatter(format string) log.Formatter {
+	if format == "json" {
+		return &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}
+	}
+	customFormatter := new(TextFormatter)
+	customFormatter.TimestampFormat = "2006-01-02 15:04:05"
+	customFormatter.FullTimestamp = true
+	customFormatter.ForceFormatting = true
+	return customFormatter
+}
@@ -3,10 +3,15 @@
 package util
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
 )
 
 func TestParseLogLevelConfig(t *testing.T) {
@@ -24,6 +29,13 @@ func TestParseLogLevelConfig(t *testing.T) {
 	assert.Equal("warn", ret2["*"])
 	assert.Equal("debug", ret2["p2p"])
 	assert.Equal("info", ret2["consensus"])
+
+	// Levels given as a map in the config file
+	ret3 := parseLogLevelConfig(map[string]interface{}{"sync": "debug", "consensus": "warn"})
+	assert.Equal(3, len(ret3))
+	assert.Equal("warn", ret3["*"])
+	assert.Equal("debug", ret3["sync"])
+	assert.Equal("warn", ret3["consensus"])
 }
 
 func TestGetLoggerForModule(t *testing.T) {
@@ -35,3 +47,26 @@ func TestGetLoggerForModule(t *testing.T) {
 	assert.Equal(log.InfoLevel, GetLoggerForModule("consensus").Logger.Level)
 	assert.Equal(log.ErrorLevel, GetLoggerForModule("sync").Logger.Level)
 }
+
+func TestGetLoggerForModuleJSON(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	logLevels = parseLogLevelConfig(map[string]interface{}{"*": "error", "sync": "debug"})
+	viper.Set(common.CfgLogFormat, "json")
+	defer viper.Set(common.CfgLogFormat, "text")
+
+	logger := GetLoggerForModule("sync")
+	assert.Equal(log.DebugLevel, logger.Logger.Level)
+
+	buf := &bytes.Buffer{}
+	logger.Logger.Out = buf
+	logger.WithFields(log.Fields{"height": 10}).Debug("Block received")
+
+	entry := map[string]interface{}{}
+	require.Nil(json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal("sync", entry["prefix"])
+	assert.Equal("debug", entry["level"])
+	assert.Equal("Block received", entry["msg"])
+	assert.Equal(float64(10), entry["height"])
+	assert.NotEmpty(entry["time"])
+}
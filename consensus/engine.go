@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/profiler"
 	"github.com/thetatoken/theta/common/util"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
@@ -22,6 +23,7 @@ import (
 var logger = log.WithFields(log.Fields{"prefix": "consensus"})
 
 var _ core.ConsensusEngine = (*ConsensusEngine)(nil)
+var _ core.FinalityProvider = (*ConsensusEngine)(nil)
 
 // ConsensusEngine is the default implementation of the Engine interface.
 type ConsensusEngine struct {
@@ -34,8 +36,9 @@ type ConsensusEngine struct {
 	validatorManager core.ValidatorManager
 	ledger           core.Ledger
 
-	incoming        chan interface{}
-	finalizedBlocks chan *core.Block
+	incoming      chan interface{}
+	finalityFeed  *core.FinalityFeed
+	validatedFeed *core.FinalityFeed
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -47,7 +50,13 @@ type ConsensusEngine struct {
 	epochTimer    *time.Timer
 	proposalTimer *time.Timer
 
-	state *State
+	voteTimestamps bool // whether to timestamp the votes of the node
+
+	state     *State
+	recovery  *partitionRecovery
+	wal       *WAL
+	telemetry *telemetry
+	profiler  *profiler.Profiler
 }
 
 // NewConsensusEngine creates a instance of ConsensusEngine.
@@ -58,13 +67,17 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *bl
 
 		privateKey: privateKey,
 
-		incoming:        make(chan interface{}, viper.GetInt(common.CfgConsensusMessageQueueSize)),
-		finalizedBlocks: make(chan *core.Block, viper.GetInt(common.CfgConsensusMessageQueueSize)),
+		incoming:      make(chan interface{}, viper.GetInt(common.CfgConsensusMessageQueueSize)),
+		finalityFeed:  core.NewFinalityFeed(),
+		validatedFeed: core.NewFinalityFeed(),
 
 		wg: &sync.WaitGroup{},
 
-		mu:    &sync.Mutex{},
-		state: NewState(db, chain),
+		mu:       &sync.Mutex{},
+		state:    NewState(db, chain),
+		recovery: newPartitionRecovery(uint64(viper.GetInt(common.CfgConsensusRecoveryHeightGap))),
+
+		voteTimestamps: viper.GetBool(common.CfgConsensusVoteTimestamps),
 
 		validatorManager: validatorManager,
 	}
@@ -72,6 +85,8 @@ func NewConsensusEngine(privateKey *crypto.PrivateKey, db store.Store, chain *bl
 	logger = util.GetLoggerForModule("consensus")
 	e.logger = logger
 
+	e.telemetry = newTelemetry(e.ID())
+
 	e.logger.WithFields(log.Fields{"state": e.state}).Info("Starting state")
 
 	return e
@@ -81,16 +96,37 @@ func (e *ConsensusEngine) SetLedger(ledger core.Ledger) {
 	e.ledger = ledger
 }
 
+// SetWAL sets the write-ahead log of consensus decisions, which is replayed when the
+// engine starts.
+func (e *ConsensusEngine) SetWAL(wal *WAL) {
+	e.wal = wal
+}
+
+// SetProfiler sets the profiler which captures the runtime profiles when processing a block
+// takes too long.
+func (e *ConsensusEngine) SetProfiler(profiler *profiler.Profiler) {
+	e.profiler = profiler
+}
+
 // GetLedger returns the ledger instance attached to the consensus engine
 func (e *ConsensusEngine) GetLedger() core.Ledger {
 	return e.ledger
 }
 
-// ID returns the identifier of current node.
+// ID returns the identifier of current node, or an empty string if the node is read-only.
 func (e *ConsensusEngine) ID() string {
+	if e.IsReadOnly() {
+		return ""
+	}
 	return e.privateKey.PublicKey().Address().Hex()
 }
 
+// IsReadOnly returns whether the node runs without a signing key, in which case it validates
+// and follows the chain, but never votes or proposes.
+func (e *ConsensusEngine) IsReadOnly() bool {
+	return e.privateKey == nil
+}
+
 // PrivateKey returns the private key
 func (e *ConsensusEngine) PrivateKey() *crypto.PrivateKey {
 	return e.privateKey
@@ -129,8 +165,19 @@ func (e *ConsensusEngine) Start(ctx context.Context) {
 	lastCC := e.state.GetHighestCCBlock()
 	e.ledger.ResetState(lastCC.Height, lastCC.StateHash)
 
+	pendingBlocks := e.replayWAL()
+
 	e.wg.Add(1)
 	go e.mainLoop()
+
+	// Resume processing the blocks that were interrupted by the crash.
+	if len(pendingBlocks) > 0 {
+		go func() {
+			for _, block := range pendingBlocks {
+				e.AddMessage(block)
+			}
+		}()
+	}
 }
 
 // Stop notifies all goroutines to stop without blocking.
@@ -153,6 +200,9 @@ func (e *ConsensusEngine) mainLoop() {
 			select {
 			case <-e.ctx.Done():
 				e.stopped = true
+				if e.wal != nil {
+					e.wal.Close()
+				}
 				return
 			case msg := <-e.incoming:
 				endEpoch := e.processMessage(msg)
@@ -182,6 +232,16 @@ func (e *ConsensusEngine) enterEpoch() {
 		e.proposalTimer.Stop()
 	}
 	e.proposalTimer = time.NewTimer(time.Duration(viper.GetInt(common.CfgConsensusMinProposalWait)) * time.Second)
+
+	epoch := e.GetEpoch()
+	isProposer := !e.IsReadOnly() && e.shouldProposeByID(e.GetTipToExtend().Hash(), epoch, e.ID())
+	e.telemetry.enterEpoch(epoch, isProposer, time.Now())
+
+	if e.wal != nil {
+		if err := e.wal.writeEpochEntered(epoch); err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to write epoch to WAL")
+		}
+	}
 }
 
 // GetChannelIDs implements the p2p.MessageHandler interface.
@@ -207,7 +267,7 @@ func (e *ConsensusEngine) processMessage(msg interface{}) (endEpoch bool) {
 		return endEpoch
 	case *core.Block:
 		e.logger.WithFields(log.Fields{"block": m}).Debug("Received block")
-		e.handleBlock(m)
+		return e.handleBlock(m)
 	default:
 		// Should not happen.
 		log.Errorf("Unknown message type: %v", m)
@@ -317,7 +377,7 @@ func (e *ConsensusEngine) validateBlock(block *core.Block, parent *core.Extended
 	return true
 }
 
-func (e *ConsensusEngine) handleBlock(block *core.Block) {
+func (e *ConsensusEngine) handleBlock(block *core.Block) (endEpoch bool) {
 	eb, err := e.chain.FindBlock(block.Hash())
 	if err != nil {
 		// Should not happen.
@@ -334,6 +394,16 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 		}).Debug("Ignore processed block")
 		return
 	}
+
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start)
+		blockProcessingTimer.Update(duration)
+		if e.profiler != nil {
+			e.profiler.ObserveBlockProcessingTime(block.Height, duration)
+		}
+	}()
+
 	parent, err := e.chain.FindBlock(block.Parent)
 	if err != nil {
 		// Should not happen since netsync layer ensures order of blocks.
@@ -344,6 +414,12 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 		}).Fatal("Failed to find parent block")
 	}
 
+	if e.wal != nil {
+		if err := e.wal.writeProposalSeen(block); err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to write proposal to WAL")
+		}
+	}
+
 	if !e.validateBlock(block, parent) {
 		e.chain.MarkBlockInvalid(block.Hash())
 		e.logger.WithFields(log.Fields{
@@ -356,6 +432,7 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 		e.handleVote(vote)
 	}
 	e.checkCC(block.HCC.BlockHash)
+	endEpoch = e.fastForwardEpoch(block)
 
 	result := e.ledger.ResetState(parent.Height, parent.StateHash)
 	if result.IsError() {
@@ -376,8 +453,6 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 		return
 	}
 
-	e.pruneState(block.Height)
-
 	if hasValidatorUpdate, ok := result.Info["hasValidatorUpdate"]; ok {
 		hasValidatorUpdateBool := hasValidatorUpdate.(bool)
 		if hasValidatorUpdateBool {
@@ -386,6 +461,7 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 	}
 
 	e.chain.MarkBlockValid(block.Hash())
+	e.validatedFeed.Publish(block)
 
 	// Check and process CC.
 	e.checkCC(block.Hash())
@@ -403,9 +479,13 @@ func (e *ConsensusEngine) handleBlock(block *core.Block) {
 	}
 
 	e.vote()
+	return
 }
 
 func (e *ConsensusEngine) shouldVote(block common.Hash) bool {
+	if e.IsReadOnly() {
+		return false
+	}
 	return e.shouldVoteByID(e.privateKey.PublicKey().Address(), block)
 }
 
@@ -453,6 +533,12 @@ func (e *ConsensusEngine) vote() {
 		vote = e.createVote(tip.Block)
 		e.state.SetLastVote(vote)
 	}
+	if e.wal != nil {
+		// Persist the vote before it leaves the node.
+		if err := e.wal.writeVoteCast(vote); err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to write vote to WAL")
+		}
+	}
 	e.logger.WithFields(log.Fields{
 		"vote": vote,
 	}).Debug("Sending vote")
@@ -485,6 +571,9 @@ func (e *ConsensusEngine) createVote(block *core.Block) core.Vote {
 		Epoch:  e.GetEpoch(),
 	}
 	vote.Sign(e.privateKey)
+	if e.voteTimestamps {
+		vote.SignTimestamp(e.privateKey, uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	}
 	return vote
 }
 
@@ -510,6 +599,17 @@ func (e *ConsensusEngine) handleVote(vote core.Vote) (endEpoch bool) {
 		e.logger.WithFields(log.Fields{"err": err}).Panic("Failed to add vote")
 	}
 
+	e.recovery.observeVote(vote)
+	e.updateRecoveryState()
+
+	if vote.Timestamp != nil {
+		if res := vote.ValidateTimestamp(); res.IsError() {
+			e.logger.WithFields(log.Fields{"vote": vote, "err": res.String()}).Debug("Ignoring invalid vote timestamp")
+		} else {
+			e.chain.AddVoteTimestamp(vote, time.Now())
+		}
+	}
+
 	// Update epoch.
 	lfb := e.state.GetLastFinalizedBlock()
 	nextValidators := e.validatorManager.GetNextValidatorSet(lfb.Hash())
@@ -632,16 +732,32 @@ func (e *ConsensusEngine) GetSummary() *StateStub {
 	return e.state.GetSummary()
 }
 
-// FinalizedBlocks returns a channel that will be published with finalized blocks by the engine.
-func (e *ConsensusEngine) FinalizedBlocks() chan *core.Block {
-	return e.finalizedBlocks
-}
-
-// GetLastFinalizedBlock returns the last finalized block.
+// GetLastFinalizedBlock implements the core.FinalityProvider interface.
 func (e *ConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return e.state.GetLastFinalizedBlock()
 }
 
+// IsFinalized implements the core.FinalityProvider interface.
+func (e *ConsensusEngine) IsFinalized(hash common.Hash) bool {
+	block, err := e.chain.FindBlock(hash)
+	return err == nil && block.Status.IsFinalized()
+}
+
+// SubscribeFinalizedBlocks implements the core.FinalityProvider interface.
+func (e *ConsensusEngine) SubscribeFinalizedBlocks(bufferSize int) *core.FinalitySubscription {
+	return e.finalityFeed.Subscribe(bufferSize)
+}
+
+// SubscribeValidatedBlocks implements the core.ConsensusEngine interface.
+func (e *ConsensusEngine) SubscribeValidatedBlocks(bufferSize int) *core.FinalitySubscription {
+	return e.validatedFeed.Subscribe(bufferSize)
+}
+
+// GetFinalizationCertificate implements the core.FinalityProvider interface.
+func (e *ConsensusEngine) GetFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	return e.chain.FindFinalizationCertificate(hash)
+}
+
 func (e *ConsensusEngine) processCCBlock(ccBlock *core.ExtendedBlock) {
 	if ccBlock.Height <= e.state.GetHighestCCBlock().Height {
 		return
@@ -687,13 +803,22 @@ func (e *ConsensusEngine) finalizeBlock(block *core.ExtendedBlock) {
 	// duplicate TX in fork.
 	e.chain.AddTxsToIndex(block, true)
 
-	select {
-	case e.finalizedBlocks <- block.Block:
-	default:
+	e.updateRecoveryState()
+	e.telemetry.blockFinalized(block.Block, time.Now())
+
+	if e.wal != nil && e.wal.shouldCompact() {
+		if err := e.wal.compact(block.Height); err != nil {
+			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to compact WAL")
+		}
 	}
+
+	e.finalityFeed.Publish(block.Block)
 }
 
 func (e *ConsensusEngine) shouldPropose(tip *core.ExtendedBlock, epoch uint64) bool {
+	if e.IsReadOnly() {
+		return false
+	}
 	if epoch <= tip.Epoch {
 		return false
 	}
@@ -747,6 +872,7 @@ func (e *ConsensusEngine) createProposal() (core.Proposal, error) {
 	block.Epoch = e.GetEpoch()
 	block.Parent = tip.Hash()
 	block.Height = tip.Height + 1
+	block.Version = core.BlockHeaderVersion(block.ChainID, block.Height)
 	block.Proposer = e.privateKey.PublicKey().Address()
 	block.Timestamp = big.NewInt(time.Now().Unix())
 	block.HCC.BlockHash = e.state.GetHighestCCBlock().Hash()
@@ -789,6 +915,11 @@ func (e *ConsensusEngine) createProposal() (core.Proposal, error) {
 }
 
 func (e *ConsensusEngine) propose() {
+	if e.IsInRecovery() {
+		e.logger.WithFields(log.Fields{"e.epoch": e.GetEpoch()}).Debug("Skip proposing during partition recovery")
+		return
+	}
+
 	tip := e.GetTipToExtend()
 	if !e.shouldPropose(tip, e.GetEpoch()) {
 		return
@@ -806,6 +937,12 @@ func (e *ConsensusEngine) propose() {
 			e.logger.WithFields(log.Fields{"error": err}).Error("Failed to create proposal")
 			return
 		}
+		if e.wal != nil {
+			// Persist the proposal before it leaves the node.
+			if err := e.wal.writeProposalMade(proposal); err != nil {
+				e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to write proposal to WAL")
+			}
+		}
 		e.state.LastProposal = proposal
 
 		_, err = e.chain.AddBlock(proposal.Block)
@@ -826,27 +963,9 @@ func (e *ConsensusEngine) propose() {
 		Payload:   payload,
 	}
 	e.dispatcher.SendData([]string{}, proposalMsg)
+	e.telemetry.proposalMade(proposal.Block.Epoch)
 
 	go func() {
 		e.AddMessage(proposal.Block)
 	}()
 }
-
-func (e *ConsensusEngine) pruneState(currentBlockHeight uint64) {
-	if !viper.GetBool(common.CfgStorageStatePruningEnabled) {
-		return
-	}
-
-	pruneInterval := uint64(viper.GetInt(common.CfgStorageStatePruningInterval))
-	if currentBlockHeight%pruneInterval != 0 {
-		return
-	}
-
-	minimumNumBlocksToRetain := uint64(viper.GetInt(common.CfgStorageStatePruningRetainedBlocks))
-	if currentBlockHeight <= minimumNumBlocksToRetain+1 {
-		return
-	}
-
-	endHeight := currentBlockHeight - minimumNumBlocksToRetain
-	e.ledger.PruneState(endHeight)
-}
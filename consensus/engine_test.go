@@ -59,6 +59,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
 	b1.Height = chain.Root().Height + 1
+	b1.Version = core.BlockHeaderVersion(b1.ChainID, b1.Height)
 	b1.Epoch = 1
 	b1.Parent = chain.Root().Hash()
 
@@ -97,6 +98,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Parent = chain.Root().Hash()
 
 	vote = core.Vote{Block: invalidBlock.Parent, ID: addr}
@@ -114,6 +116,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 3
 	invalidBlock.Parent = common.Hash{}
 
@@ -132,6 +135,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 4
 	invalidBlock.Parent = chain.Root().Hash()
 
@@ -150,6 +154,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 4
 	invalidBlock.Parent = chain.Root().Hash()
 
@@ -168,6 +173,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 5
 	invalidBlock.Parent = chain.Root().Hash()
 
@@ -186,6 +192,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 6
 	invalidBlock.Parent = chain.Root().Hash()
 
@@ -206,6 +213,7 @@ func TestSingleBlockValidation(t *testing.T) {
 	invalidBlock = core.NewBlock()
 	invalidBlock.ChainID = chain.ChainID
 	invalidBlock.Height = 1
+	invalidBlock.Version = core.BlockHeaderVersion(invalidBlock.ChainID, invalidBlock.Height)
 	invalidBlock.Epoch = 6
 	invalidBlock.Parent = chain.Root().Hash()
 
@@ -239,6 +247,7 @@ func TestValidParent(t *testing.T) {
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
 	b1.Height = chain.Root().Height + 1
+	b1.Version = core.BlockHeaderVersion(b1.ChainID, b1.Height)
 	b1.Epoch = 1
 	b1.Parent = chain.Root().Hash()
 
@@ -257,6 +266,7 @@ func TestValidParent(t *testing.T) {
 	b2 := core.NewBlock()
 	b2.ChainID = chain.ChainID
 	b2.Height = 2
+	b2.Version = core.BlockHeaderVersion(b2.ChainID, b2.Height)
 	b2.Epoch = 2
 	b2.Parent = b1.Hash()
 
@@ -282,6 +292,7 @@ func TestValidParent(t *testing.T) {
 	b3 := core.NewBlock()
 	b3.ChainID = chain.ChainID
 	b3.Height = 3
+	b3.Version = core.BlockHeaderVersion(b3.ChainID, b3.Height)
 	b3.Epoch = 3
 	b3.Parent = b2.Hash()
 
@@ -319,6 +330,7 @@ func TestChildBlockOfValidatorChange(t *testing.T) {
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
 	b1.Height = chain.Root().Height + 1
+	b1.Version = core.BlockHeaderVersion(b1.ChainID, b1.Height)
 	b1.Epoch = 1
 	b1.Parent = chain.Root().Hash()
 
@@ -337,6 +349,7 @@ func TestChildBlockOfValidatorChange(t *testing.T) {
 	b2 := core.NewBlock()
 	b2.ChainID = chain.ChainID
 	b2.Height = 2
+	b2.Version = core.BlockHeaderVersion(b2.ChainID, b2.Height)
 	b2.Epoch = 2
 	b2.Parent = b1.Hash()
 
@@ -359,6 +372,7 @@ func TestChildBlockOfValidatorChange(t *testing.T) {
 	b3 := core.NewBlock()
 	b3.ChainID = chain.ChainID
 	b3.Height = 3
+	b3.Version = core.BlockHeaderVersion(b3.ChainID, b3.Height)
 	b3.Epoch = 3
 	b3.Parent = b2.Hash()
 	// b3's HCC is linked to b1
@@ -383,6 +397,7 @@ func TestChildBlockOfValidatorChange(t *testing.T) {
 	b3 = core.NewBlock()
 	b3.ChainID = chain.ChainID
 	b3.Height = 3
+	b3.Version = core.BlockHeaderVersion(b3.ChainID, b3.Height)
 	b3.Epoch = 4
 	b3.Parent = b2.Hash()
 
@@ -418,6 +433,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
 	b1.Height = chain.Root().Height + 1
+	b1.Version = core.BlockHeaderVersion(b1.ChainID, b1.Height)
 	b1.Epoch = 1
 	b1.Parent = chain.Root().Hash()
 
@@ -437,6 +453,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b2 := core.NewBlock()
 	b2.ChainID = chain.ChainID
 	b2.Height = 2
+	b2.Version = core.BlockHeaderVersion(b2.ChainID, b2.Height)
 	b2.Epoch = 2
 	b2.Parent = b1.Hash()
 
@@ -458,6 +475,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b3 := core.NewBlock()
 	b3.ChainID = chain.ChainID
 	b3.Height = 3
+	b3.Version = core.BlockHeaderVersion(b3.ChainID, b3.Height)
 	b3.Epoch = 3
 	b3.Parent = b2.Hash()
 
@@ -478,6 +496,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b4 := core.NewBlock()
 	b4.ChainID = chain.ChainID
 	b4.Height = 4
+	b4.Version = core.BlockHeaderVersion(b4.ChainID, b4.Height)
 	b4.Epoch = 5
 	b4.Parent = b3.Hash()
 
@@ -497,6 +516,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b4 = core.NewBlock()
 	b4.ChainID = chain.ChainID
 	b4.Height = 4
+	b4.Version = core.BlockHeaderVersion(b4.ChainID, b4.Height)
 	b4.Epoch = 5
 	b4.Parent = b3.Hash()
 
@@ -517,6 +537,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b4 = core.NewBlock()
 	b4.ChainID = chain.ChainID
 	b4.Height = 4
+	b4.Version = core.BlockHeaderVersion(b4.ChainID, b4.Height)
 	b4.Epoch = 6
 	b4.Parent = b3.Hash()
 
@@ -537,6 +558,7 @@ func TestGrandChildBlockOfValidatorChange(t *testing.T) {
 	b4 = core.NewBlock()
 	b4.ChainID = chain.ChainID
 	b4.Height = 4
+	b4.Version = core.BlockHeaderVersion(b4.ChainID, b4.Height)
 	b4.Epoch = 7
 	b4.Parent = b3.Hash()
 
@@ -572,6 +594,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b1 := core.NewBlock()
 	b1.ChainID = chain.ChainID
 	b1.Height = chain.Root().Height + 1
+	b1.Version = core.BlockHeaderVersion(b1.ChainID, b1.Height)
 	b1.Epoch = 1
 	b1.Parent = chain.Root().Hash()
 
@@ -591,6 +614,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b2 := core.NewBlock()
 	b2.ChainID = chain.ChainID
 	b2.Height = 2
+	b2.Version = core.BlockHeaderVersion(b2.ChainID, b2.Height)
 	b2.Epoch = 2
 	b2.Parent = b1.Hash()
 
@@ -612,6 +636,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b3 := core.NewBlock()
 	b3.ChainID = chain.ChainID
 	b3.Height = 3
+	b3.Version = core.BlockHeaderVersion(b3.ChainID, b3.Height)
 	b3.Epoch = 3
 	b3.Parent = b2.Hash()
 
@@ -631,6 +656,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b4 := core.NewBlock()
 	b4.ChainID = chain.ChainID
 	b4.Height = 4
+	b4.Version = core.BlockHeaderVersion(b4.ChainID, b4.Height)
 	b4.Epoch = 5
 	b4.Parent = b3.Hash()
 
@@ -651,6 +677,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b5 := core.NewBlock()
 	b5.ChainID = chain.ChainID
 	b5.Height = 5
+	b5.Version = core.BlockHeaderVersion(b5.ChainID, b5.Height)
 	b5.Epoch = 6
 	b5.Parent = b4.Hash()
 
@@ -671,6 +698,7 @@ func TestGrandGrandChildBlockOfValidatorChange(t *testing.T) {
 	b5 = core.NewBlock()
 	b5.ChainID = chain.ChainID
 	b5.Height = 5
+	b5.Version = core.BlockHeaderVersion(b5.ChainID, b5.Height)
 	b5.Epoch = 7
 	b5.Parent = b4.Hash()
 
@@ -733,3 +761,25 @@ func TestTipSelection(t *testing.T) {
 	tip = ce.GetTipToExtend()
 	assert.Equal(a2.Hash(), tip.Hash(), "should not select blocks with validator update that are higher than local HCC")
 }
+
+func TestReadOnlyEngine(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	validatorManager := MockValidatorManager{PrivKey: privKey}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+
+	ce := NewConsensusEngine(nil, store, chain, nil, validatorManager)
+	assert.True(ce.IsReadOnly())
+	assert.Equal("", ce.ID())
+	assert.False(ce.shouldVote(root.Hash()))
+	assert.False(ce.shouldPropose(ce.GetTipToExtend(), 1))
+
+	ce = NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	assert.False(ce.IsReadOnly())
+	assert.Equal(privKey.PublicKey().Address().Hex(), ce.ID())
+	assert.True(ce.shouldVote(root.Hash()))
+}
@@ -0,0 +1,166 @@
+package consensus
+
+import (
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+// The node leaves the recovery mode once the last finalized block is within
+// recoveryExitHeightGap of the height voted by a majority of the validators. In
+// normal operation the last finalized block lags behind the tip by two blocks.
+const recoveryExitHeightGap = 2
+
+// partitionRecovery detects that the node has fallen far behind a majority of the
+// validators, e.g. after a network partition heals. While in the recovery mode, the
+// node suspends proposing, requests blocks aggressively, and fast-forwards its epoch
+// based on the verified commit certificates.
+type partitionRecovery struct {
+	mu *sync.Mutex
+
+	active      bool
+	heightGap   uint64
+	latestVotes map[common.Address]core.Vote // map: validator |-> its vote with the greatest height
+}
+
+func newPartitionRecovery(heightGap uint64) *partitionRecovery {
+	return &partitionRecovery{
+		mu:          &sync.Mutex{},
+		heightGap:   heightGap,
+		latestVotes: make(map[common.Address]core.Vote),
+	}
+}
+
+// observeVote records the height the voter has reached
+func (pr *partitionRecovery) observeVote(vote core.Vote) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if latest, ok := pr.latestVotes[vote.ID]; ok && latest.Height >= vote.Height {
+		return
+	}
+	pr.latestVotes[vote.ID] = vote
+}
+
+// quorumHeight returns the greatest height reached by a majority of the validators
+func (pr *partitionRecovery) quorumHeight(validators *core.ValidatorSet) (height uint64, ok bool) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	votes := []core.Vote{}
+	for _, vote := range pr.latestVotes {
+		if _, err := validators.GetValidator(vote.ID); err == nil {
+			votes = append(votes, vote)
+		}
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].Height > votes[j].Height
+	})
+	for i := range votes {
+		if validators.HasMajorityVotes(votes[:i+1]) {
+			return votes[i].Height, true
+		}
+	}
+	return 0, false
+}
+
+// update enters or leaves the recovery mode based on the height of the last finalized
+// block. Returns whether the mode has changed.
+func (pr *partitionRecovery) update(lfbHeight uint64, validators *core.ValidatorSet) (changed bool) {
+	if pr.heightGap == 0 {
+		return false // partition recovery disabled
+	}
+	quorumHeight, ok := pr.quorumHeight(validators)
+	if !ok {
+		return false
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if !pr.active && quorumHeight >= lfbHeight+pr.heightGap {
+		pr.active = true
+		return true
+	}
+	if pr.active && quorumHeight <= lfbHeight+recoveryExitHeightGap {
+		pr.active = false
+		return true
+	}
+	return false
+}
+
+func (pr *partitionRecovery) isActive() bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.active
+}
+
+// certifiedEpoch returns the greatest epoch reached by a majority of the validators
+// according to the votes in the commit certificate
+func certifiedEpoch(cc core.CommitCertificate, validators *core.ValidatorSet) (epoch uint64, ok bool) {
+	if cc.Votes == nil {
+		return 0, false
+	}
+	votes := cc.Votes.UniqueVoter().Votes()
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].Epoch > votes[j].Epoch
+	})
+	for i := range votes {
+		if validators.HasMajorityVotes(votes[:i+1]) {
+			return votes[i].Epoch, true
+		}
+	}
+	return 0, false
+}
+
+// IsInRecovery returns whether the node is catching up with the rest of the network
+func (e *ConsensusEngine) IsInRecovery() bool {
+	return e.recovery.isActive()
+}
+
+func (e *ConsensusEngine) updateRecoveryState() {
+	lfb := e.state.GetLastFinalizedBlock()
+	validators := e.validatorManager.GetNextValidatorSet(lfb.Hash())
+	if !e.recovery.update(lfb.Height, validators) {
+		return
+	}
+	if e.recovery.isActive() {
+		e.logger.WithFields(log.Fields{
+			"lfb.Height": lfb.Height,
+			"e.epoch":    e.GetEpoch(),
+		}).Warn("Fallen behind the majority of validators. Entering partition recovery")
+	} else {
+		e.logger.WithFields(log.Fields{
+			"lfb.Height": lfb.Height,
+			"e.epoch":    e.GetEpoch(),
+		}).Info("Caught up with the majority of validators. Leaving partition recovery")
+	}
+}
+
+// fastForwardEpoch moves to the epoch following the one certified by the HCC of a
+// validated block, so that the node does not need to wait through the epochs it
+// missed during the partition. Returns whether the epoch has changed.
+func (e *ConsensusEngine) fastForwardEpoch(block *core.Block) bool {
+	if !e.IsInRecovery() {
+		return false
+	}
+	validators := e.validatorManager.GetValidatorSet(block.HCC.BlockHash)
+	if !block.HCC.IsValid(validators) {
+		return false
+	}
+	epoch, ok := certifiedEpoch(block.HCC, validators)
+	if !ok || epoch+1 <= e.GetEpoch() {
+		return false
+	}
+
+	e.logger.WithFields(log.Fields{
+		"e.epoch":   e.GetEpoch(),
+		"nextEpoch": epoch + 1,
+		"block.HCC": block.HCC.BlockHash.Hex(),
+	}).Info("Fast-forwarding epoch based on commit certificate")
+	e.state.SetEpoch(epoch + 1)
+	return true
+}
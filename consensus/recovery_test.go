@@ -0,0 +1,92 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+func TestPartitionRecovery(t *testing.T) {
+	assert := assert.New(t)
+
+	ids := []string{
+		"0x2e833968e5bb786ae419c4d13189fb081cc43bab",
+		"0x9f1233798e905e173560071255140b4a8abd3ec6",
+		"0x7631a14c3ab9f4cc0c1f9e7ffb19e0c9c4fd8e73",
+		"0xe7bd1ba0a3c2b0b36eb8b5b36ae1e4cd5f0e8aa4",
+	}
+	validators := NewTestValidatorSet(ids)
+	vote := func(id string, height uint64) core.Vote {
+		return core.Vote{ID: common.HexToAddress(id), Height: height}
+	}
+
+	pr := newPartitionRecovery(10)
+	_, ok := pr.quorumHeight(validators)
+	assert.False(ok)
+
+	// Two out of four validators are not a majority
+	pr.observeVote(vote(ids[0], 50))
+	pr.observeVote(vote(ids[1], 40))
+	assert.False(pr.update(5, validators))
+	assert.False(pr.isActive())
+
+	pr.observeVote(vote(ids[2], 30))
+	height, ok := pr.quorumHeight(validators)
+	assert.True(ok)
+	assert.Equal(uint64(30), height)
+
+	// Older votes do not lower the height reached by a validator
+	pr.observeVote(vote(ids[0], 20))
+	height, _ = pr.quorumHeight(validators)
+	assert.Equal(uint64(30), height)
+
+	// Votes from non-validators are ignored
+	pr.observeVote(vote("0x00000000000000000000000000000000000000ff", 100))
+	height, _ = pr.quorumHeight(validators)
+	assert.Equal(uint64(30), height)
+
+	assert.False(pr.update(25, validators)) // within the gap
+	assert.True(pr.update(5, validators))
+	assert.True(pr.isActive())
+	assert.False(pr.update(20, validators)) // still behind
+	assert.True(pr.isActive())
+	assert.True(pr.update(28, validators))
+	assert.False(pr.isActive())
+
+	disabled := newPartitionRecovery(0)
+	disabled.observeVote(vote(ids[0], 50))
+	disabled.observeVote(vote(ids[1], 50))
+	disabled.observeVote(vote(ids[2], 50))
+	assert.False(disabled.update(0, validators))
+	assert.False(disabled.isActive())
+}
+
+func TestCertifiedEpoch(t *testing.T) {
+	assert := assert.New(t)
+
+	ids := []string{
+		"0x2e833968e5bb786ae419c4d13189fb081cc43bab",
+		"0x9f1233798e905e173560071255140b4a8abd3ec6",
+		"0x7631a14c3ab9f4cc0c1f9e7ffb19e0c9c4fd8e73",
+		"0xe7bd1ba0a3c2b0b36eb8b5b36ae1e4cd5f0e8aa4",
+	}
+	validators := NewTestValidatorSet(ids)
+
+	_, ok := certifiedEpoch(core.CommitCertificate{}, validators)
+	assert.False(ok)
+
+	votes := core.NewVoteSet()
+	for i, epoch := range []uint64{12, 9, 15} {
+		votes.AddVote(core.Vote{ID: common.HexToAddress(ids[i]), Epoch: epoch})
+	}
+	epoch, ok := certifiedEpoch(core.CommitCertificate{Votes: votes}, validators)
+	assert.True(ok)
+	assert.Equal(uint64(9), epoch)
+
+	votes.AddVote(core.Vote{ID: common.HexToAddress(ids[3]), Epoch: 14})
+	epoch, ok = certifiedEpoch(core.CommitCertificate{Votes: votes}, validators)
+	assert.True(ok)
+	assert.Equal(uint64(12), epoch)
+}
@@ -0,0 +1,272 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/util"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store"
+)
+
+var _ core.ConsensusEngine = (*SoloEngine)(nil)
+
+// SoloEngine is a deterministic consensus engine for single node chains, e.g. local test
+// networks. It proposes every block itself and finalizes it right away, without any vote.
+// The node key should be the only validator of the genesis block.
+type SoloEngine struct {
+	logger *log.Entry
+
+	privateKey *crypto.PrivateKey
+
+	chain  *blockchain.Chain
+	ledger core.Ledger
+
+	state         *State
+	finalityFeed  *core.FinalityFeed
+	validatedFeed *core.FinalityFeed
+
+	blockInterval time.Duration
+	now           func() time.Time
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu *sync.Mutex
+}
+
+// NewSoloEngine creates a instance of SoloEngine. A new block is produced every blockInterval,
+// or only when ProduceBlock() is called if blockInterval is zero.
+func NewSoloEngine(privateKey *crypto.PrivateKey, db store.Store, chain *blockchain.Chain, blockInterval time.Duration) *SoloEngine {
+	e := &SoloEngine{
+		privateKey: privateKey,
+
+		chain: chain,
+
+		state:         NewState(db, chain),
+		finalityFeed:  core.NewFinalityFeed(),
+		validatedFeed: core.NewFinalityFeed(),
+
+		blockInterval: blockInterval,
+		now:           time.Now,
+
+		wg: &sync.WaitGroup{},
+		mu: &sync.Mutex{},
+	}
+
+	logger = util.GetLoggerForModule("consensus")
+	e.logger = logger
+
+	return e
+}
+
+// SetLedger implements the core.ConsensusEngine interface.
+func (e *SoloEngine) SetLedger(ledger core.Ledger) {
+	e.ledger = ledger
+}
+
+// GetLedger implements the core.ConsensusEngine interface.
+func (e *SoloEngine) GetLedger() core.Ledger {
+	return e.ledger
+}
+
+// SetClock sets the source of the block timestamps, e.g. a fixed clock for deterministic chains.
+func (e *SoloEngine) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.now = now
+}
+
+// ID implements the core.ConsensusEngine interface.
+func (e *SoloEngine) ID() string {
+	return e.privateKey.PublicKey().Address().Hex()
+}
+
+// PrivateKey implements the core.ConsensusEngine interface.
+func (e *SoloEngine) PrivateKey() *crypto.PrivateKey {
+	return e.privateKey
+}
+
+// GetEpoch implements the core.ConsensusEngine interface. The solo engine produces a block
+// per epoch.
+func (e *SoloEngine) GetEpoch() uint64 {
+	return e.state.GetEpoch()
+}
+
+// GetTip implements the core.ConsensusEngine interface. Every block of the solo engine is
+// finalized as soon as it is produced, so the tip is always the last finalized block.
+func (e *SoloEngine) GetTip(includePendingBlockingLeaf bool) *core.ExtendedBlock {
+	return e.state.GetLastFinalizedBlock()
+}
+
+// AddMessage implements the core.ConsensusEngine interface. The solo engine does not take
+// part in any network consensus, so the messages are dropped.
+func (e *SoloEngine) AddMessage(msg interface{}) {
+	e.logger.WithFields(log.Fields{"msg": msg}).Debug("Ignoring consensus message")
+}
+
+// IsInRecovery implements the core.ConsensusEngine interface.
+func (e *SoloEngine) IsInRecovery() bool {
+	return false
+}
+
+// GetLastFinalizedBlock implements the core.FinalityProvider interface.
+func (e *SoloEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return e.state.GetLastFinalizedBlock()
+}
+
+// IsFinalized implements the core.FinalityProvider interface.
+func (e *SoloEngine) IsFinalized(hash common.Hash) bool {
+	block, err := e.chain.FindBlock(hash)
+	return err == nil && block.Status.IsFinalized()
+}
+
+// SubscribeFinalizedBlocks implements the core.FinalityProvider interface.
+func (e *SoloEngine) SubscribeFinalizedBlocks(bufferSize int) *core.FinalitySubscription {
+	return e.finalityFeed.Subscribe(bufferSize)
+}
+
+// SubscribeValidatedBlocks implements the core.ConsensusEngine interface.
+func (e *SoloEngine) SubscribeValidatedBlocks(bufferSize int) *core.FinalitySubscription {
+	return e.validatedFeed.Subscribe(bufferSize)
+}
+
+// GetFinalizationCertificate implements the core.FinalityProvider interface. The blocks of the
+// solo engine are finalized without votes, so there is no certificate to return.
+func (e *SoloEngine) GetFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	return nil, fmt.Errorf("Blocks finalized by the solo engine have no certificate: %v", hash.Hex())
+}
+
+// Start implements the core.ConsensusEngine interface.
+func (e *SoloEngine) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	e.ctx = c
+	e.cancel = cancel
+
+	// Set ledger state pointer to intial state.
+	lfb := e.state.GetLastFinalizedBlock()
+	e.ledger.ResetState(lfb.Height, lfb.StateHash)
+
+	if e.blockInterval > 0 {
+		e.wg.Add(1)
+		go e.mainLoop()
+	}
+}
+
+// Stop implements the core.ConsensusEngine interface.
+func (e *SoloEngine) Stop() {
+	e.cancel()
+}
+
+// Wait implements the core.ConsensusEngine interface.
+func (e *SoloEngine) Wait() {
+	e.wg.Wait()
+}
+
+func (e *SoloEngine) mainLoop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.blockInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := e.ProduceBlock(); err != nil {
+				e.logger.WithFields(log.Fields{"error": err}).Error("Failed to produce block")
+			}
+		}
+	}
+}
+
+// ProduceBlock proposes a block on top of the last finalized block, applies and finalizes it.
+func (e *SoloEngine) ProduceBlock() (*core.ExtendedBlock, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	tip := e.state.GetLastFinalizedBlock()
+	result := e.ledger.ResetState(tip.Height, tip.StateHash)
+	if result.IsError() {
+		return nil, fmt.Errorf("Failed to reset state to tip.StateHash: %v", result.String())
+	}
+
+	block := core.NewBlock()
+	block.ChainID = e.chain.ChainID
+	block.Epoch = tip.Epoch + 1
+	block.Parent = tip.Hash()
+	block.Height = tip.Height + 1
+	block.Version = core.BlockHeaderVersion(block.ChainID, block.Height)
+	block.Proposer = e.privateKey.PublicKey().Address()
+	block.Timestamp = big.NewInt(e.now().Unix())
+	block.HCC.BlockHash = tip.Hash()
+
+	newRoot, txs, result := e.ledger.ProposeBlockTxs(block)
+	if result.IsError() {
+		return nil, fmt.Errorf("Failed to collect Txs for block proposal: %v", result.String())
+	}
+	block.AddTxs(txs)
+	block.StateHash = newRoot
+
+	sig, err := e.privateKey.Sign(block.SignBytes())
+	if err != nil {
+		return nil, fmt.Errorf("Failed to sign block: %v", err)
+	}
+	block.SetSignature(sig)
+
+	if _, err := e.chain.AddBlock(block); err != nil {
+		return nil, fmt.Errorf("Failed to add block to chain: %v", err)
+	}
+
+	result = e.ledger.ResetState(tip.Height, tip.StateHash)
+	if result.IsError() {
+		return nil, fmt.Errorf("Failed to reset state to tip.StateHash: %v", result.String())
+	}
+	result = e.ledger.ApplyBlockTxs(block)
+	if result.IsError() {
+		e.chain.MarkBlockInvalid(block.Hash())
+		return nil, fmt.Errorf("Failed to apply block Txs: %v", result.String())
+	}
+
+	e.chain.MarkBlockValid(block.Hash())
+	e.validatedFeed.Publish(block)
+	e.chain.CommitBlock(block.Hash())
+	e.finalizeBlock(block)
+
+	return e.chain.FindBlock(block.Hash())
+}
+
+func (e *SoloEngine) finalizeBlock(block *core.Block) {
+	e.logger.WithFields(log.Fields{"block.Hash": block.Hash().Hex(), "block.Height": block.Height}).Info("Finalizing block")
+
+	e.chain.FinalizePreviousBlocks(block.Hash())
+	eb, err := e.chain.FindBlock(block.Hash())
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err, "block": block.Hash().Hex()}).Fatal("Failed to find block")
+	}
+
+	if err := e.state.SetHighestCCBlock(eb); err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to save consensus state")
+	}
+	if err := e.state.SetLastFinalizedBlock(eb); err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to save consensus state")
+	}
+	if err := e.state.SetEpoch(eb.Epoch); err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Panic("Failed to save consensus state")
+	}
+	e.ledger.FinalizeState(eb.Height, eb.StateHash)
+	e.chain.AddTxsToIndex(eb, true)
+
+	e.finalityFeed.Publish(block)
+}
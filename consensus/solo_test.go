@@ -0,0 +1,88 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+type soloTestLedger struct {
+	finalizedHeight uint64
+}
+
+func (l *soloTestLedger) GetCurrentBlock() *core.Block                    { return nil }
+func (l *soloTestLedger) ScreenTxUnsafe(rawTx common.Bytes) result.Result { return result.OK }
+func (l *soloTestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return nil, result.OK
+}
+func (l *soloTestLedger) ScreenReplacementTx(rawTx common.Bytes, precedingRawTxs []common.Bytes) (*core.TxInfo, result.Result) {
+	return nil, result.OK
+}
+func (l *soloTestLedger) SimulateScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return nil, result.OK
+}
+func (l *soloTestLedger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return nil, result.OK
+}
+func (l *soloTestLedger) ProposeBlockTxs(block *core.Block) (common.Hash, []common.Bytes, result.Result) {
+	return common.BytesToHash([]byte{byte(block.Height)}), []common.Bytes{}, result.OK
+}
+func (l *soloTestLedger) ApplyBlockTxs(block *core.Block) result.Result { return result.OK }
+func (l *soloTestLedger) ResetState(height uint64, rootHash common.Hash) result.Result {
+	return result.OK
+}
+func (l *soloTestLedger) FinalizeState(height uint64, rootHash common.Hash) result.Result {
+	l.finalizedHeight = height
+	return result.OK
+}
+func (l *soloTestLedger) GetFinalizedValidatorCandidatePool(blockHash common.Hash, isNext bool) (*core.ValidatorCandidatePool, error) {
+	return nil, nil
+}
+func (l *soloTestLedger) GetNodeAddress(blockHash common.Hash, validator common.Address) (*core.NodeAddress, error) {
+	return nil, nil
+}
+
+func TestSoloEngine(t *testing.T) {
+	require := require.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("a0", "")
+	chain := blockchain.NewChain("testchain", db, root)
+
+	ledger := &soloTestLedger{}
+	e := NewSoloEngine(privKey, db, chain, 0)
+	e.SetLedger(ledger)
+
+	sub := e.SubscribeFinalizedBlocks(4)
+	defer sub.Unsubscribe()
+
+	b1, err := e.ProduceBlock()
+	require.Nil(err)
+	b2, err := e.ProduceBlock()
+	require.Nil(err)
+
+	require.Equal(root.Height+2, b2.Height)
+	require.Equal(b1.Hash(), b2.Parent)
+	require.Equal(privKey.PublicKey().Address(), b2.Proposer)
+	require.True(e.IsFinalized(b1.Hash()))
+	require.True(e.IsFinalized(b2.Hash()))
+	require.Equal(b2.Hash(), e.GetLastFinalizedBlock().Hash())
+	require.Equal(b2.Hash(), e.GetTip(true).Hash())
+	require.Equal(b2.Height, ledger.finalizedHeight)
+	require.Equal(b1.Hash(), (<-sub.C).Hash())
+	require.Equal(b2.Hash(), (<-sub.C).Hash())
+
+	// The progress survives a restart.
+	e = NewSoloEngine(privKey, db, chain, 0)
+	e.SetLedger(ledger)
+	require.Equal(b2.Hash(), e.GetLastFinalizedBlock().Hash())
+	require.Equal(b2.Epoch, e.GetEpoch())
+}
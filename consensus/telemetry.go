@@ -0,0 +1,218 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/core"
+)
+
+var (
+	epochGauge                   = metrics.NewRegisteredGauge("consensus/epoch", nil)
+	epochDurationTimer           = metrics.NewRegisteredTimer("consensus/epoch/duration", nil)
+	epochTimeoutCounter          = metrics.NewRegisteredCounter("consensus/epoch/timeouts", nil)
+	epochsSinceFinalizationGauge = metrics.NewRegisteredGauge("consensus/epoch/sincefinalization", nil)
+	proposalsMadeCounter         = metrics.NewRegisteredCounter("consensus/proposals/made", nil)
+	proposalsMissedCounter       = metrics.NewRegisteredCounter("consensus/proposals/missed", nil)
+	finalizedHeightGauge         = metrics.NewRegisteredGauge("consensus/finalization/height", nil)
+	finalizationLatencyTimer     = metrics.NewRegisteredTimer("consensus/finalization/latency", nil)
+	blockProcessingTimer         = metrics.NewRegisteredTimer("consensus/block/processing", nil)
+)
+
+const (
+	// AlertMissedProposal is raised when the local validator does not propose in its epoch
+	AlertMissedProposal = "missed_proposal"
+	// AlertEpochsWithoutFinalization is raised when the epoch keeps changing but no block is finalized
+	AlertEpochsWithoutFinalization = "epochs_without_finalization"
+	// AlertSlowFinalization is raised when a block takes too long to be finalized
+	AlertSlowFinalization = "slow_finalization"
+)
+
+// Alerts of the same type are sent to the webhook at most once per alertMinInterval
+const alertMinInterval = 1 * time.Minute
+
+const alertWebhookTimeout = 5 * time.Second
+
+// Alert is posted as JSON to the webhook configured by CfgConsensusAlertWebhookURL
+type Alert struct {
+	Type      string `json:"type"`
+	Validator string `json:"validator"`
+	Epoch     uint64 `json:"epoch"`
+	Height    uint64 `json:"height"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// alerter logs the alerts and forwards them to the webhook, if one is configured.
+type alerter struct {
+	mu *sync.Mutex
+
+	webhookURL string
+	client     *http.Client
+	lastSent   map[string]time.Time
+
+	send func(alert Alert) // overridden in tests
+}
+
+func newAlerter(webhookURL string) *alerter {
+	a := &alerter{
+		mu:         &sync.Mutex{},
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: alertWebhookTimeout},
+		lastSent:   make(map[string]time.Time),
+	}
+	a.send = a.postToWebhook
+	return a
+}
+
+func (a *alerter) raise(alert Alert) {
+	logger.WithFields(log.Fields{
+		"type":   alert.Type,
+		"epoch":  alert.Epoch,
+		"height": alert.Height,
+	}).Warn(alert.Message)
+
+	if len(a.webhookURL) == 0 {
+		return
+	}
+
+	a.mu.Lock()
+	now := time.Unix(alert.Timestamp, 0)
+	if last, ok := a.lastSent[alert.Type]; ok && now.Sub(last) < alertMinInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.lastSent[alert.Type] = now
+	a.mu.Unlock()
+
+	go a.send(alert)
+}
+
+func (a *alerter) postToWebhook(alert Alert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to encode alert")
+		return
+	}
+	resp, err := a.client.Post(a.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err, "url": a.webhookURL}).Warn("Failed to post alert to webhook")
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logger.WithFields(log.Fields{"status": resp.Status, "url": a.webhookURL}).Warn("Alert webhook returned error status")
+	}
+}
+
+// telemetry tracks the progress of the epochs and the finalization, exports the
+// metrics and raises alerts when consensus degrades.
+type telemetry struct {
+	mu *sync.Mutex
+
+	alerter   *alerter
+	validator string
+
+	maxEpochsWithoutFinalization uint64
+	maxFinalizationLatency       time.Duration
+
+	epoch                   uint64
+	epochStart              time.Time
+	isProposer              bool
+	hasProposed             bool
+	epochsSinceFinalization uint64
+}
+
+func newTelemetry(validator string) *telemetry {
+	return &telemetry{
+		mu:        &sync.Mutex{},
+		alerter:   newAlerter(viper.GetString(common.CfgConsensusAlertWebhookURL)),
+		validator: validator,
+
+		maxEpochsWithoutFinalization: uint64(viper.GetInt(common.CfgConsensusAlertMaxEpochsWithoutFinalization)),
+		maxFinalizationLatency:       time.Duration(viper.GetInt(common.CfgConsensusAlertMaxFinalizationLatency)) * time.Second,
+	}
+}
+
+// enterEpoch is called whenever the engine (re-)enters an epoch. isProposer indicates
+// whether the local validator is expected to propose in the epoch.
+func (t *telemetry) enterEpoch(epoch uint64, isProposer bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if epoch == t.epoch && !t.epochStart.IsZero() {
+		epochTimeoutCounter.Inc(1) // Repeating epoch
+		return
+	}
+
+	if !t.epochStart.IsZero() {
+		epochDurationTimer.Update(now.Sub(t.epochStart))
+		if t.isProposer && !t.hasProposed {
+			proposalsMissedCounter.Inc(1)
+			t.raise(AlertMissedProposal, t.epoch, 0, now,
+				fmt.Sprintf("Validator missed its proposal slot in epoch %v", t.epoch))
+		}
+		t.epochsSinceFinalization++
+		epochsSinceFinalizationGauge.Update(int64(t.epochsSinceFinalization))
+		if t.maxEpochsWithoutFinalization > 0 && t.epochsSinceFinalization == t.maxEpochsWithoutFinalization {
+			t.raise(AlertEpochsWithoutFinalization, epoch, 0, now,
+				fmt.Sprintf("No block finalized in the last %v epochs", t.epochsSinceFinalization))
+		}
+	}
+
+	t.epoch = epoch
+	t.epochStart = now
+	t.isProposer = isProposer
+	t.hasProposed = false
+	epochGauge.Update(int64(epoch))
+}
+
+// proposalMade is called when the local validator proposes a block.
+func (t *telemetry) proposalMade(epoch uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	proposalsMadeCounter.Inc(1)
+	if epoch == t.epoch {
+		t.hasProposed = true
+	}
+}
+
+// blockFinalized is called when a block is finalized.
+func (t *telemetry) blockFinalized(block *core.Block, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.epochsSinceFinalization = 0
+	epochsSinceFinalizationGauge.Update(0)
+	finalizedHeightGauge.Update(int64(block.Height))
+
+	if block.Timestamp == nil {
+		return
+	}
+	latency := now.Sub(time.Unix(block.Timestamp.Int64(), 0))
+	finalizationLatencyTimer.Update(latency)
+	if t.maxFinalizationLatency > 0 && latency > t.maxFinalizationLatency {
+		t.raise(AlertSlowFinalization, block.Epoch, block.Height, now,
+			fmt.Sprintf("Block %v took %v to finalize", block.Hash().Hex(), latency))
+	}
+}
+
+func (t *telemetry) raise(alertType string, epoch uint64, height uint64, now time.Time, message string) {
+	t.alerter.raise(Alert{
+		Type:      alertType,
+		Validator: t.validator,
+		Epoch:     epoch,
+		Height:    height,
+		Message:   message,
+		Timestamp: now.Unix(),
+	})
+}
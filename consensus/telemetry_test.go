@@ -0,0 +1,139 @@
+package consensus
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/core"
+)
+
+type alertRecorder struct {
+	mu     *sync.Mutex
+	alerts []Alert
+}
+
+func (r *alertRecorder) record(alert Alert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+}
+
+func (r *alertRecorder) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ret := []string{}
+	for _, alert := range r.alerts {
+		ret = append(ret, alert.Type)
+	}
+	return ret
+}
+
+func newTestTelemetry() (*telemetry, *alertRecorder) {
+	recorder := &alertRecorder{mu: &sync.Mutex{}}
+	t := newTelemetry("validator1")
+	t.alerter.webhookURL = "http://localhost"
+	t.alerter.send = recorder.record
+	t.maxEpochsWithoutFinalization = 3
+	t.maxFinalizationLatency = 30 * time.Second
+	return t, recorder
+}
+
+func waitForAlerts(recorder *alertRecorder, n int) []string {
+	for i := 0; i < 100 && len(recorder.types()) < n; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return recorder.types()
+}
+
+func TestTelemetryMissedProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	tm, recorder := newTestTelemetry()
+	now := time.Unix(1000, 0)
+
+	tm.enterEpoch(1, true, now)
+	tm.proposalMade(1)
+	tm.enterEpoch(1, true, now.Add(10*time.Second)) // Epoch timeout
+	tm.enterEpoch(2, true, now.Add(20*time.Second))
+	assert.Empty(recorder.types())
+
+	tm.enterEpoch(3, false, now.Add(30*time.Second)) // Missed proposal in epoch 2
+	assert.Equal([]string{AlertMissedProposal}, waitForAlerts(recorder, 1))
+}
+
+func TestTelemetryEpochsWithoutFinalization(t *testing.T) {
+	assert := assert.New(t)
+
+	tm, recorder := newTestTelemetry()
+	now := time.Unix(1000, 0)
+
+	tm.enterEpoch(1, false, now)
+	tm.enterEpoch(2, false, now.Add(10*time.Second))
+	tm.enterEpoch(3, false, now.Add(20*time.Second))
+	assert.Empty(recorder.types())
+
+	tm.enterEpoch(4, false, now.Add(30*time.Second))
+	assert.Equal([]string{AlertEpochsWithoutFinalization}, waitForAlerts(recorder, 1))
+
+	// Finalization resets the counter
+	block := core.NewBlock()
+	block.Timestamp = big.NewInt(now.Add(35 * time.Second).Unix())
+	tm.blockFinalized(block, now.Add(40*time.Second))
+	tm.enterEpoch(5, false, now.Add(50*time.Second))
+	tm.enterEpoch(6, false, now.Add(60*time.Second))
+	assert.Equal(uint64(2), tm.epochsSinceFinalization)
+	assert.Equal(1, len(recorder.types()))
+}
+
+func TestTelemetrySlowFinalization(t *testing.T) {
+	assert := assert.New(t)
+
+	tm, recorder := newTestTelemetry()
+	now := time.Unix(1000, 0)
+
+	block := core.NewBlock()
+	block.Height = 10
+	block.Timestamp = big.NewInt(now.Unix())
+	tm.blockFinalized(block, now.Add(20*time.Second))
+	assert.Empty(recorder.types())
+
+	tm.blockFinalized(block, now.Add(45*time.Second))
+	assert.Equal([]string{AlertSlowFinalization}, waitForAlerts(recorder, 1))
+
+	// Alerts of the same type are rate limited
+	tm.blockFinalized(block, now.Add(50*time.Second))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(1, len(recorder.types()))
+}
+
+func TestAlerterWebhook(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	received := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := Alert{}
+		json.NewDecoder(r.Body).Decode(&alert)
+		received <- alert
+	}))
+	defer server.Close()
+
+	a := newAlerter(server.URL)
+	a.raise(Alert{Type: AlertMissedProposal, Validator: "validator1", Epoch: 7, Timestamp: time.Now().Unix()})
+
+	select {
+	case alert := <-received:
+		assert.Equal(AlertMissedProposal, alert.Type)
+		assert.Equal("validator1", alert.Validator)
+		assert.Equal(uint64(7), alert.Epoch)
+	case <-time.After(3 * time.Second):
+		require.Fail("Alert not received")
+	}
+}
@@ -0,0 +1,466 @@
+package consensus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// Every record in the WAL file is stored as: 4-byte length | 4-byte CRC32 | RLP encoded
+// walRecord, where the length and the checksum are computed over the encoded walRecord
+const walRecordHeaderSize = 8
+
+// The WAL is compacted once it contains more than walCompactThreshold records
+const walCompactThreshold = 1024
+
+var errWALCorrupted = errors.New("WAL record corrupted")
+
+type walRecordType uint8
+
+const (
+	walRecordEpochEntered walRecordType = iota + 1
+	walRecordVoteCast
+	walRecordProposalMade
+	walRecordProposalSeen
+)
+
+type walRecord struct {
+	Type    uint8
+	Payload common.Bytes
+}
+
+// walProposalSeen records a block proposed by the other validators which the engine
+// has started to process
+type walProposalSeen struct {
+	Block  common.Hash
+	Height uint64
+}
+
+// walState is the consensus state reconstructed from the WAL records
+type walState struct {
+	epoch         uint64
+	lastVote      core.Vote
+	lastProposal  core.Proposal
+	proposalsSeen []walProposalSeen
+}
+
+// WAL is the write-ahead log of the consensus decisions. Each decision is flushed to
+// disk before it takes effect (e.g. before a vote is broadcast), and the log is replayed
+// at startup so that a crashed validator resumes where it left off, and never casts a
+// conflicting vote or proposal for the same height or epoch.
+type WAL struct {
+	mu *sync.Mutex
+
+	filePath   string
+	file       *os.File
+	numRecords int
+	epoch      uint64
+}
+
+// OpenWAL opens the WAL file at the given path, creating it if necessary.
+func OpenWAL(filePath string) (*WAL, error) {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &WAL{
+		mu:       &sync.Mutex{},
+		filePath: filePath,
+		file:     file,
+	}, nil
+}
+
+// Close closes the underlying WAL file.
+func (w *WAL) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
+
+func (w *WAL) writeEpochEntered(epoch uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if epoch == w.epoch {
+		return nil // Repeating epoch
+	}
+	if err := w.writeRecord(walRecordEpochEntered, epoch); err != nil {
+		return err
+	}
+	w.epoch = epoch
+	return nil
+}
+
+func (w *WAL) writeVoteCast(vote core.Vote) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writeRecord(walRecordVoteCast, vote)
+}
+
+func (w *WAL) writeProposalMade(proposal core.Proposal) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writeRecord(walRecordProposalMade, proposal)
+}
+
+func (w *WAL) writeProposalSeen(block *core.Block) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.writeRecord(walRecordProposalSeen, walProposalSeen{
+		Block:  block.Hash(),
+		Height: block.Height,
+	})
+}
+
+func (w *WAL) writeRecord(recordType walRecordType, val interface{}) error {
+	record, err := encodeWALRecord(recordType, val)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(record); err != nil {
+		return err
+	}
+	w.numRecords++
+	return w.file.Sync()
+}
+
+func encodeWALRecord(recordType walRecordType, val interface{}) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return nil, err
+	}
+	return frameWALRecord(walRecord{Type: uint8(recordType), Payload: payload})
+}
+
+func frameWALRecord(record walRecord) ([]byte, error) {
+	recordBytes, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, walRecordHeaderSize+len(recordBytes))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(recordBytes)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(recordBytes))
+	copy(buf[walRecordHeaderSize:], recordBytes)
+	return buf, nil
+}
+
+// decodeWALRecords decodes the records in the WAL file content. It also returns the
+// size of the valid prefix, since a crash could leave a partially written record at
+// the end of the file.
+func decodeWALRecords(data []byte) (records []walRecord, validSize int, err error) {
+	for validSize < len(data) {
+		if len(data)-validSize < walRecordHeaderSize {
+			return records, validSize, errWALCorrupted
+		}
+		length := int(binary.BigEndian.Uint32(data[validSize : validSize+4]))
+		checksum := binary.BigEndian.Uint32(data[validSize+4 : validSize+8])
+		start := validSize + walRecordHeaderSize
+		if length > len(data)-start {
+			return records, validSize, errWALCorrupted
+		}
+		recordBytes := data[start : start+length]
+		if crc32.ChecksumIEEE(recordBytes) != checksum {
+			return records, validSize, errWALCorrupted
+		}
+		record := walRecord{}
+		if err := rlp.DecodeBytes(recordBytes, &record); err != nil {
+			return records, validSize, err
+		}
+		records = append(records, record)
+		validSize = start + length
+	}
+	return records, validSize, nil
+}
+
+// readAll returns all the records in the WAL. A corrupted tail is truncated.
+func (w *WAL) readAll() ([]walRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := ioutil.ReadFile(w.filePath)
+	if err != nil {
+		return nil, err
+	}
+	records, validSize, err := decodeWALRecords(data)
+	if err != nil {
+		logger.WithFields(log.Fields{
+			"error":     err,
+			"file":      w.filePath,
+			"fileSize":  len(data),
+			"validSize": validSize,
+		}).Warn("Truncating corrupted WAL tail")
+		if err := w.file.Truncate(int64(validSize)); err != nil {
+			return nil, err
+		}
+	}
+	w.numRecords = len(records)
+	return records, nil
+}
+
+// rewrite atomically replaces the WAL content with the given records.
+func (w *WAL) rewrite(records []walRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := []byte{}
+	for _, record := range records {
+		buf, err := frameWALRecord(record)
+		if err != nil {
+			return err
+		}
+		data = append(data, buf...)
+	}
+
+	// The new content and the rename need to reach the disk before the records dropped by the
+	// compaction are relied upon, otherwise a crash could leave an empty WAL
+	tmpFilePath := w.filePath + ".tmp"
+	if err := writeFileSync(tmpFilePath, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpFilePath, w.filePath); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(w.filePath)); err != nil {
+		return err
+	}
+	w.file.Close()
+	file, err := os.OpenFile(w.filePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.numRecords = len(records)
+	return nil
+}
+
+// writeFileSync writes the data to the file, and flushes it to the disk.
+func writeFileSync(filePath string, data []byte, perm os.FileMode) error {
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir flushes the entries of the directory to the disk, so that a file renamed into it survives
+// a crash. Windows does not support syncing a directory.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (w *WAL) shouldCompact() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.numRecords > walCompactThreshold
+}
+
+// replayWALRecords reconstructs the consensus state from the WAL records.
+func replayWALRecords(records []walRecord) (*walState, error) {
+	ws := &walState{}
+	seen := make(map[common.Hash]bool)
+	for _, record := range records {
+		switch walRecordType(record.Type) {
+		case walRecordEpochEntered:
+			var epoch uint64
+			if err := rlp.DecodeBytes(record.Payload, &epoch); err != nil {
+				return nil, err
+			}
+			if epoch > ws.epoch {
+				ws.epoch = epoch
+			}
+		case walRecordVoteCast:
+			vote := core.Vote{}
+			if err := rlp.DecodeBytes(record.Payload, &vote); err != nil {
+				return nil, err
+			}
+			if vote.Height >= ws.lastVote.Height {
+				ws.lastVote = vote
+			}
+			if vote.Epoch > ws.epoch {
+				ws.epoch = vote.Epoch
+			}
+		case walRecordProposalMade:
+			proposal := core.Proposal{}
+			if err := rlp.DecodeBytes(record.Payload, &proposal); err != nil {
+				return nil, err
+			}
+			if proposal.Block == nil {
+				continue
+			}
+			if ws.lastProposal.Block == nil || proposal.Block.Epoch >= ws.lastProposal.Block.Epoch {
+				ws.lastProposal = proposal
+			}
+		case walRecordProposalSeen:
+			ps := walProposalSeen{}
+			if err := rlp.DecodeBytes(record.Payload, &ps); err != nil {
+				return nil, err
+			}
+			if !seen[ps.Block] {
+				seen[ps.Block] = true
+				ws.proposalsSeen = append(ws.proposalsSeen, ps)
+			}
+		default:
+			return nil, fmt.Errorf("Unknown WAL record type: %v", record.Type)
+		}
+	}
+	return ws, nil
+}
+
+// checkpointRecords returns the minimal records which replay into the given state. The
+// proposals seen at or below minHeight are dropped.
+func checkpointRecords(ws *walState, minHeight uint64) ([]walRecord, error) {
+	records := []walRecord{}
+	add := func(recordType walRecordType, val interface{}) error {
+		payload, err := rlp.EncodeToBytes(val)
+		if err != nil {
+			return err
+		}
+		records = append(records, walRecord{Type: uint8(recordType), Payload: payload})
+		return nil
+	}
+
+	if err := add(walRecordEpochEntered, ws.epoch); err != nil {
+		return nil, err
+	}
+	if ws.lastVote.Height != 0 {
+		if err := add(walRecordVoteCast, ws.lastVote); err != nil {
+			return nil, err
+		}
+	}
+	if ws.lastProposal.Block != nil {
+		if err := add(walRecordProposalMade, ws.lastProposal); err != nil {
+			return nil, err
+		}
+	}
+	for _, ps := range ws.proposalsSeen {
+		if ps.Height <= minHeight {
+			continue
+		}
+		if err := add(walRecordProposalSeen, ps); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// compact rewrites the WAL with the checkpoint of its current content.
+func (w *WAL) compact(minHeight uint64) error {
+	records, err := w.readAll()
+	if err != nil {
+		return err
+	}
+	ws, err := replayWALRecords(records)
+	if err != nil {
+		return err
+	}
+	checkpoint, err := checkpointRecords(ws, minHeight)
+	if err != nil {
+		return err
+	}
+	return w.rewrite(checkpoint)
+}
+
+// replayWAL restores the consensus decisions recorded in the WAL, which might not have
+// been persisted in the consensus state before the crash. Returns the blocks the engine
+// had started but not finished processing.
+func (e *ConsensusEngine) replayWAL() []*core.Block {
+	if e.wal == nil {
+		return nil
+	}
+	records, err := e.wal.readAll()
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Fatal("Failed to read WAL")
+	}
+	ws, err := replayWALRecords(records)
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Fatal("Failed to replay WAL")
+	}
+
+	if ws.epoch > e.state.GetEpoch() {
+		e.state.SetEpoch(ws.epoch)
+	}
+
+	if ws.lastVote.Height > e.state.GetLastVote().Height {
+		if _, err := e.chain.FindBlock(ws.lastVote.Block); err == nil {
+			e.state.SetLastVote(ws.lastVote)
+		} else {
+			e.logger.WithFields(log.Fields{
+				"vote":  ws.lastVote,
+				"error": err,
+			}).Warn("Cannot restore last vote since its block is not found")
+		}
+	}
+
+	if ws.lastProposal.Block != nil {
+		lastProposal := e.state.GetLastProposal()
+		if lastProposal.Block == nil || ws.lastProposal.Block.Epoch > lastProposal.Block.Epoch {
+			if _, err := e.chain.FindBlock(ws.lastProposal.Block.Hash()); err != nil {
+				if _, err := e.chain.AddBlock(ws.lastProposal.Block); err != nil {
+					e.logger.WithFields(log.Fields{"error": err}).Fatal("Failed to add proposed block to chain")
+				}
+			}
+			e.state.SetLastProposal(ws.lastProposal)
+		}
+	}
+
+	pendingBlocks := []*core.Block{}
+	for _, ps := range ws.proposalsSeen {
+		eb, err := e.chain.FindBlock(ps.Block)
+		if err == nil && eb.Status.IsPending() {
+			pendingBlocks = append(pendingBlocks, eb.Block)
+		}
+	}
+
+	lfb := e.state.GetLastFinalizedBlock()
+	checkpoint, err := checkpointRecords(ws, lfb.Height)
+	if err == nil {
+		err = e.wal.rewrite(checkpoint)
+	}
+	if err != nil {
+		e.logger.WithFields(log.Fields{"error": err}).Error("Failed to compact WAL")
+	}
+
+	e.logger.WithFields(log.Fields{
+		"numRecords":       len(records),
+		"e.epoch":          e.GetEpoch(),
+		"lastVote":         e.state.GetLastVote(),
+		"numPendingBlocks": len(pendingBlocks),
+	}).Info("Replayed consensus WAL")
+
+	return pendingBlocks
+}
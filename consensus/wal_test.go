@@ -0,0 +1,165 @@
+package consensus
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func newTestWAL(t *testing.T) (*WAL, func()) {
+	dir, err := ioutil.TempDir("", "theta-wal-test")
+	require.Nil(t, err)
+	wal, err := OpenWAL(filepath.Join(dir, "consensus.wal"))
+	require.Nil(t, err)
+	return wal, func() {
+		wal.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestWALReplay(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	wal, cleanup := newTestWAL(t)
+	defer cleanup()
+
+	block := core.CreateTestBlock("B1", "")
+	block.Epoch = 4
+
+	require.Nil(wal.writeEpochEntered(3))
+	require.Nil(wal.writeEpochEntered(3)) // Repeated epoch is not recorded
+	require.Nil(wal.writeVoteCast(core.Vote{Height: 5, Epoch: 3}))
+	require.Nil(wal.writeVoteCast(core.Vote{Height: 6, Epoch: 3}))
+	require.Nil(wal.writeEpochEntered(4))
+	require.Nil(wal.writeProposalMade(core.Proposal{Block: block}))
+	require.Nil(wal.writeProposalSeen(block))
+	require.Nil(wal.writeProposalSeen(block))
+	require.Nil(wal.Close())
+
+	wal, err := OpenWAL(wal.filePath)
+	require.Nil(err)
+	records, err := wal.readAll()
+	require.Nil(err)
+	assert.Equal(7, len(records))
+
+	ws, err := replayWALRecords(records)
+	require.Nil(err)
+	assert.Equal(uint64(4), ws.epoch)
+	assert.Equal(uint64(6), ws.lastVote.Height)
+	require.NotNil(ws.lastProposal.Block)
+	assert.Equal(block.Hash(), ws.lastProposal.Block.Hash())
+	require.Equal(1, len(ws.proposalsSeen))
+	assert.Equal(block.Hash(), ws.proposalsSeen[0].Block)
+}
+
+func TestWALTruncateCorruptedTail(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	wal, cleanup := newTestWAL(t)
+	defer cleanup()
+
+	require.Nil(wal.writeEpochEntered(1))
+	require.Nil(wal.writeVoteCast(core.Vote{Height: 2, Epoch: 1}))
+
+	// Simulate a crash in the middle of writing a record.
+	record, err := encodeWALRecord(walRecordEpochEntered, uint64(2))
+	require.Nil(err)
+	_, err = wal.file.Write(record[:len(record)-1])
+	require.Nil(err)
+
+	records, err := wal.readAll()
+	require.Nil(err)
+	assert.Equal(2, len(records))
+
+	// Records appended after the truncation can be read back.
+	require.Nil(wal.writeEpochEntered(3))
+	records, err = wal.readAll()
+	require.Nil(err)
+	assert.Equal(3, len(records))
+	ws, err := replayWALRecords(records)
+	require.Nil(err)
+	assert.Equal(uint64(3), ws.epoch)
+}
+
+func TestWALCompact(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	wal, cleanup := newTestWAL(t)
+	defer cleanup()
+
+	b1 := core.CreateTestBlock("B1", "")
+	b1.Height = 1
+	b2 := core.CreateTestBlock("B2", "B1")
+	b2.Height = 2
+	for i := uint64(1); i <= 10; i++ {
+		require.Nil(wal.writeEpochEntered(i))
+		require.Nil(wal.writeVoteCast(core.Vote{Height: i, Epoch: i}))
+	}
+	require.Nil(wal.writeProposalSeen(b1))
+	require.Nil(wal.writeProposalSeen(b2))
+
+	require.Nil(wal.compact(1))
+	records, err := wal.readAll()
+	require.Nil(err)
+	assert.Equal(3, len(records))
+
+	ws, err := replayWALRecords(records)
+	require.Nil(err)
+	assert.Equal(uint64(10), ws.epoch)
+	assert.Equal(uint64(10), ws.lastVote.Height)
+	require.Equal(1, len(ws.proposalsSeen))
+	assert.Equal(b2.Hash(), ws.proposalsSeen[0].Block)
+}
+
+func TestConsensusEngineReplayWAL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	validatorManager := MockValidatorManager{PrivKey: privKey}
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("a0", "")
+	root.ChainID = "testchain"
+	root.Epoch = 0
+	chain := blockchain.NewChain("testchain", store, root)
+
+	b1 := core.NewBlock()
+	b1.ChainID = chain.ChainID
+	b1.Height = root.Height + 1
+	b1.Epoch = 1
+	b1.Parent = root.Hash()
+	chain.AddBlock(b1)
+
+	wal, cleanup := newTestWAL(t)
+	defer cleanup()
+	require.Nil(wal.writeEpochEntered(5))
+	require.Nil(wal.writeVoteCast(core.Vote{Block: b1.Hash(), Height: b1.Height, Epoch: 5}))
+	require.Nil(wal.writeProposalSeen(b1))
+
+	ce := NewConsensusEngine(privKey, store, chain, nil, validatorManager)
+	ce.SetWAL(wal)
+	pendingBlocks := ce.replayWAL()
+
+	assert.Equal(uint64(5), ce.GetEpoch())
+	assert.Equal(b1.Hash(), ce.state.GetLastVote().Block)
+	require.Equal(1, len(pendingBlocks))
+	assert.Equal(b1.Hash(), pendingBlocks[0].Hash())
+
+	// The WAL is compacted to a checkpoint of the replayed state.
+	records, err := wal.readAll()
+	require.Nil(err)
+	assert.Equal(3, len(records))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
@@ -19,6 +20,26 @@ const (
 	MaxNumRegularTxsPerBlock int = 8192
 )
 
+const (
+	// BlockHeaderVersion1 is the original header format, which does not encode the Version and ExtraData fields
+	BlockHeaderVersion1 uint64 = 1
+
+	// BlockHeaderVersion2 appends the Version and the ExtraData fields to the header, and commits
+	// the root of the transaction receipts in ReceiptHash
+	BlockHeaderVersion2 uint64 = 2
+
+	// MaxBlockHeaderExtraDataSize is the max number of bytes of the ExtraData field
+	MaxBlockHeaderExtraDataSize int = 1024
+)
+
+// BlockHeaderVersion returns the header version the block at the given height needs to use
+func BlockHeaderVersion(chainID string, height uint64) uint64 {
+	if IsForkActive(ForkBlockHeaderV2, chainID, height) {
+		return BlockHeaderVersion2
+	}
+	return BlockHeaderVersion1
+}
+
 var (
 	EmptyRootHash = calculateRootHash([]common.Bytes{})
 )
@@ -34,6 +55,36 @@ func NewBlock() *Block {
 	return &Block{BlockHeader: &BlockHeader{}}
 }
 
+// blockRLP is the RLP layout of the block. The methods of the RLP interfaces need to be defined on
+// the block, otherwise the ones of the embedded header would be promoted and encode the header only.
+type blockRLP struct {
+	BlockHeader *BlockHeader
+	Txs         []common.Bytes
+}
+
+var _ rlp.Encoder = (*Block)(nil)
+
+// EncodeRLP implements RLP Encoder interface.
+func (b *Block) EncodeRLP(w io.Writer) error {
+	if b == nil {
+		return rlp.Encode(w, []interface{}{})
+	}
+	return rlp.Encode(w, &blockRLP{BlockHeader: b.BlockHeader, Txs: b.Txs})
+}
+
+var _ rlp.Decoder = (*Block)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (b *Block) DecodeRLP(stream *rlp.Stream) error {
+	raw := &blockRLP{}
+	if err := stream.Decode(raw); err != nil {
+		return err
+	}
+	b.BlockHeader = raw.BlockHeader
+	b.Txs = raw.Txs
+	return nil
+}
+
 func (b *Block) String() string {
 	if b == nil {
 		return "nil"
@@ -51,10 +102,13 @@ func (b *Block) AddTxs(txs []common.Bytes) {
 	b.updateTxHash()
 }
 
-// updateTxHash calculate transaction root hash.
+// updateTxHash calculate transaction root hash. Before BlockHeaderVersion2 the receipt root is
+// not committed and always set to the empty root, afterwards it is set by the ledger, see SetReceipts.
 func (b *Block) updateTxHash() {
 	b.TxHash = calculateRootHash(b.Txs)
-	b.ReceiptHash = EmptyRootHash
+	if !b.CommitsReceipts() {
+		b.ReceiptHash = EmptyRootHash
+	}
 }
 
 func calculateRootHash(items []common.Bytes) common.Hash {
@@ -82,10 +136,115 @@ type BlockHeader struct {
 	Timestamp   *big.Int
 	Proposer    common.Address
 	Signature   *crypto.Signature
+	Version     uint64       // Only encoded from BlockHeaderVersion2, 0 is treated as BlockHeaderVersion1
+	ExtraData   common.Bytes // Commitments added by future protocol upgrades, only in BlockHeaderVersion2
 
 	hash common.Hash // Cache of calculated hash.
 }
 
+// blockHeaderRLP is the RLP layout of the header. The fields of the version 1 format come first, the
+// fields added by a later version are appended to the list, so the version 1 headers keep their encoding
+// and their hashes.
+type blockHeaderRLP struct {
+	ChainID     string
+	Epoch       uint64
+	Height      uint64
+	Parent      common.Hash
+	HCC         CommitCertificate
+	TxHash      common.Hash
+	ReceiptHash common.Hash
+	Bloom       Bloom
+	StateHash   common.Hash
+	Timestamp   *big.Int
+	Proposer    common.Address
+	Signature   *crypto.Signature
+	Extension   []rlp.RawValue `rlp:"tail"` // Version, ExtraData
+}
+
+var _ rlp.Encoder = (*BlockHeader)(nil)
+
+// EncodeRLP implements RLP Encoder interface.
+func (h *BlockHeader) EncodeRLP(w io.Writer) error {
+	if h == nil {
+		return rlp.Encode(w, []interface{}{}) // empty list, same as the nil pointers of the other structs
+	}
+	raw := &blockHeaderRLP{
+		ChainID:     h.ChainID,
+		Epoch:       h.Epoch,
+		Height:      h.Height,
+		Parent:      h.Parent,
+		HCC:         h.HCC,
+		TxHash:      h.TxHash,
+		ReceiptHash: h.ReceiptHash,
+		Bloom:       h.Bloom,
+		StateHash:   h.StateHash,
+		Timestamp:   h.Timestamp,
+		Proposer:    h.Proposer,
+		Signature:   h.Signature,
+	}
+	if h.HeaderVersion() >= BlockHeaderVersion2 {
+		version, err := rlp.EncodeToBytes(h.Version)
+		if err != nil {
+			return err
+		}
+		extraData, err := rlp.EncodeToBytes(h.ExtraData)
+		if err != nil {
+			return err
+		}
+		raw.Extension = []rlp.RawValue{version, extraData}
+	}
+	return rlp.Encode(w, raw)
+}
+
+var _ rlp.Decoder = (*BlockHeader)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (h *BlockHeader) DecodeRLP(stream *rlp.Stream) error {
+	raw := &blockHeaderRLP{}
+	if err := stream.Decode(raw); err != nil {
+		return err
+	}
+	h.ChainID = raw.ChainID
+	h.Epoch = raw.Epoch
+	h.Height = raw.Height
+	h.Parent = raw.Parent
+	h.HCC = raw.HCC
+	h.TxHash = raw.TxHash
+	h.ReceiptHash = raw.ReceiptHash
+	h.Bloom = raw.Bloom
+	h.StateHash = raw.StateHash
+	h.Timestamp = raw.Timestamp
+	h.Proposer = raw.Proposer
+	h.Signature = raw.Signature
+	h.Version = BlockHeaderVersion1
+	h.ExtraData = nil
+
+	switch len(raw.Extension) {
+	case 0:
+	case 2:
+		if err := rlp.DecodeBytes(raw.Extension[0], &h.Version); err != nil {
+			return err
+		}
+		if err := rlp.DecodeBytes(raw.Extension[1], &h.ExtraData); err != nil {
+			return err
+		}
+		if h.Version < BlockHeaderVersion2 {
+			return fmt.Errorf("Block header version %v cannot have extension fields", h.Version)
+		}
+	default:
+		return fmt.Errorf("Invalid number of block header extension fields: %v", len(raw.Extension))
+	}
+	return nil
+}
+
+// HeaderVersion returns the version of the header format
+func (h *BlockHeader) HeaderVersion() uint64 {
+	if h.Version == 0 {
+		return BlockHeaderVersion1
+	}
+	return h.Version
+}
+
 // Hash of header.
 func (h *BlockHeader) Hash() common.Hash {
 	if h == nil {
@@ -134,8 +293,10 @@ func (h *BlockHeader) SignBytes() common.Bytes {
 		StateHash:   h.StateHash,
 		Timestamp:   h.Timestamp,
 		Proposer:    h.Proposer,
+		Version:     h.Version,
+		ExtraData:   h.ExtraData,
 	}
-	raw, _ := rlp.EncodeToBytes(r)
+	raw, _ := rlp.EncodeToBytes(&r)
 	return raw
 }
 
@@ -158,6 +319,16 @@ func (h *BlockHeader) Validate() result.Result {
 	if h.Proposer.IsEmpty() {
 		return result.Error("Proposer is not specified")
 	}
+	if expected := BlockHeaderVersion(h.ChainID, h.Height); h.HeaderVersion() != expected {
+		return result.Error("Invalid header version %v, expected %v", h.HeaderVersion(), expected)
+	}
+	if h.HeaderVersion() < BlockHeaderVersion2 && len(h.ExtraData) > 0 {
+		return result.Error("ExtraData is not supported by header version %v", h.HeaderVersion())
+	}
+	if len(h.ExtraData) > MaxBlockHeaderExtraDataSize {
+		return result.Error("ExtraData too large: %v bytes, at most %v bytes allowed",
+			len(h.ExtraData), MaxBlockHeaderExtraDataSize)
+	}
 	if h.Signature == nil || h.Signature.IsEmpty() {
 		return result.Error("Block is not signed")
 	}
@@ -255,6 +426,44 @@ type ExtendedBlock struct {
 	HasValidatorUpdate bool
 }
 
+// extendedBlockRLP is the RLP layout of the extended block, see blockRLP.
+type extendedBlockRLP struct {
+	Block              *Block
+	Children           []common.Hash
+	Status             BlockStatus
+	HasValidatorUpdate bool
+}
+
+var _ rlp.Encoder = (*ExtendedBlock)(nil)
+
+// EncodeRLP implements RLP Encoder interface.
+func (eb *ExtendedBlock) EncodeRLP(w io.Writer) error {
+	if eb == nil {
+		return rlp.Encode(w, []interface{}{})
+	}
+	return rlp.Encode(w, &extendedBlockRLP{
+		Block:              eb.Block,
+		Children:           eb.Children,
+		Status:             eb.Status,
+		HasValidatorUpdate: eb.HasValidatorUpdate,
+	})
+}
+
+var _ rlp.Decoder = (*ExtendedBlock)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (eb *ExtendedBlock) DecodeRLP(stream *rlp.Stream) error {
+	raw := &extendedBlockRLP{}
+	if err := stream.Decode(raw); err != nil {
+		return err
+	}
+	eb.Block = raw.Block
+	eb.Children = raw.Children
+	eb.Status = raw.Status
+	eb.HasValidatorUpdate = raw.HasValidatorUpdate
+	return nil
+}
+
 // Hash of header.
 func (eb *ExtendedBlock) Hash() common.Hash {
 	if eb.Block == nil {
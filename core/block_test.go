@@ -1,10 +1,17 @@
 package core
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
 )
 
 func TestBlockHash(t *testing.T) {
@@ -28,6 +35,121 @@ func TestBlockHash(t *testing.T) {
 	assert.Equal("0x87a331c1e807476de260f2dc2e4d531dc42500764587605c7574179bc4cbd5bc", eb.Hash().Hex())
 }
 
+func TestBlockHeaderVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	// Version 1 headers keep the original encoding, and hence the hash
+	h1 := &BlockHeader{Epoch: 1}
+	assert.Equal("0x87a331c1e807476de260f2dc2e4d531dc42500764587605c7574179bc4cbd5bc", h1.Hash().Hex())
+	h1.Version = BlockHeaderVersion1
+	assert.Equal("0x87a331c1e807476de260f2dc2e4d531dc42500764587605c7574179bc4cbd5bc", h1.UpdateHash().Hex())
+
+	raw, err := rlp.EncodeToBytes(h1)
+	assert.Nil(err)
+	decoded := &BlockHeader{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(BlockHeaderVersion1, decoded.Version)
+	assert.Equal(h1.Hash(), decoded.Hash())
+
+	// Version 2 headers encode the Version and the ExtraData fields
+	h2 := &BlockHeader{Epoch: 1, Version: BlockHeaderVersion2, ExtraData: common.Bytes("commitments")}
+	assert.NotEqual(h1.Hash(), h2.Hash())
+	raw, err = rlp.EncodeToBytes(h2)
+	assert.Nil(err)
+	decoded = &BlockHeader{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(BlockHeaderVersion2, decoded.Version)
+	assert.Equal(common.Bytes("commitments"), decoded.ExtraData)
+	assert.Equal(h2.Hash(), decoded.Hash())
+
+	// The ExtraData is signed
+	h3 := &BlockHeader{Epoch: 1, Version: BlockHeaderVersion2, ExtraData: common.Bytes("other commitments")}
+	assert.NotEqual(h2.SignBytes(), h3.SignBytes())
+}
+
+func TestBlockRLP(t *testing.T) {
+	assert := assert.New(t)
+
+	block := NewBlock()
+	block.ChainID = "testchain"
+	block.Height = 10
+	block.Version = BlockHeaderVersion2
+	block.ExtraData = common.Bytes("commitments")
+	block.AddTxs([]common.Bytes{common.Bytes("tx1"), common.Bytes("tx2")})
+	eb := &ExtendedBlock{
+		Block:              block,
+		Children:           []common.Hash{common.HexToHash("0x01")},
+		Status:             BlockStatusCommitted,
+		HasValidatorUpdate: true,
+	}
+
+	raw, err := rlp.EncodeToBytes(eb)
+	assert.Nil(err)
+	decoded := &ExtendedBlock{}
+	assert.Nil(rlp.DecodeBytes(raw, decoded))
+	assert.Equal(block.Hash(), decoded.Hash())
+	assert.Equal(block.Txs, decoded.Txs)
+	assert.Equal(block.ExtraData, decoded.ExtraData)
+	assert.Equal(eb.Children, decoded.Children)
+	assert.Equal(eb.Status, decoded.Status)
+	assert.True(decoded.HasValidatorUpdate)
+
+	// A nil block, e.g. of an empty proposal, decodes back to nil
+	raw, err = rlp.EncodeToBytes(&Proposal{})
+	assert.Nil(err)
+	proposal := &Proposal{Block: NewBlock()}
+	assert.Nil(rlp.DecodeBytes(raw, proposal))
+	assert.Nil(proposal.Block)
+
+	// The headers embedded by value, e.g. in the snapshot metadata, are encoded through a pointer
+	metadata := &SnapshotMetadata{TailTrio: SnapshotBlockTrio{Second: SnapshotSecondBlock{Header: *block.BlockHeader}}}
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	assert.Nil(WriteMetadata(writer, metadata))
+	assert.Nil(writer.Flush())
+	decodedMetadata := &SnapshotMetadata{}
+	assert.Nil(rlp.DecodeBytes(buf.Bytes()[8:], decodedMetadata))
+	assert.Equal(block.Hash(), decodedMetadata.TailTrio.Second.Header.Hash())
+}
+
+func TestBlockHeaderVersionValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(BlockHeaderVersion1, BlockHeaderVersion(MainnetChainID, 100))
+	assert.Equal(BlockHeaderVersion2, BlockHeaderVersion("versiontestchain", 0))
+
+	SetForkHeight(ForkBlockHeaderV2, "versiontestchain", 100)
+	defer SetForkHeight(ForkBlockHeaderV2, "versiontestchain", 0)
+
+	assert.Equal(BlockHeaderVersion1, BlockHeaderVersion("versiontestchain", 99))
+	assert.Equal(BlockHeaderVersion2, BlockHeaderVersion("versiontestchain", 100))
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	validate := func(height uint64, version uint64, extraData common.Bytes) result.Result {
+		h := &BlockHeader{
+			ChainID:   "versiontestchain",
+			Height:    height,
+			Parent:    common.HexToHash("0x01"),
+			HCC:       CommitCertificate{BlockHash: common.HexToHash("0x01")},
+			Timestamp: big.NewInt(1),
+			Proposer:  privKey.PublicKey().Address(),
+			Version:   version,
+			ExtraData: extraData,
+		}
+		h.Signature, _ = privKey.Sign(h.SignBytes())
+		return h.Validate()
+	}
+
+	assert.True(validate(99, 0, nil).IsOK())
+	assert.True(validate(99, BlockHeaderVersion1, nil).IsOK())
+	assert.True(validate(99, BlockHeaderVersion2, nil).IsError())
+	assert.True(validate(99, BlockHeaderVersion1, common.Bytes("data")).IsError())
+	assert.True(validate(100, BlockHeaderVersion1, nil).IsError())
+	assert.True(validate(100, BlockHeaderVersion2, common.Bytes("data")).IsOK())
+	assert.True(validate(100, BlockHeaderVersion2, make(common.Bytes, MaxBlockHeaderExtraDataSize+1)).IsError())
+}
+
 func TestCreateTestBlock(t *testing.T) {
 	assert := assert.New(t)
 
@@ -36,3 +158,87 @@ func TestCreateTestBlock(t *testing.T) {
 
 	assert.Equal(b11.Hash(), b12.Hash())
 }
+
+func TestTxProof(t *testing.T) {
+	assert := assert.New(t)
+
+	block := NewBlock()
+	txs := []common.Bytes{}
+	for i := 0; i < 200; i++ {
+		txs = append(txs, common.Bytes(fmt.Sprintf("tx%v", i)))
+	}
+	block.AddTxs(txs)
+
+	for _, index := range []int{0, 1, 127, 128, 199} {
+		proof, err := block.ProveTx(index)
+		assert.Nil(err)
+		tx, err := VerifyTxProof(block.TxHash, proof)
+		assert.Nil(err)
+		assert.Equal(txs[index], tx)
+	}
+
+	_, err := block.ProveTx(200)
+	assert.NotNil(err)
+
+	// The proof does not verify against another root or for another index
+	proof, _ := block.ProveTx(5)
+	_, err = VerifyTxProof(common.HexToHash("0x1234"), proof)
+	assert.NotNil(err)
+	proof.Index = 6
+	tx, err := VerifyTxProof(block.TxHash, proof)
+	assert.True(err != nil || !bytes.Equal(txs[5], tx))
+}
+
+func TestReceiptHash(t *testing.T) {
+	assert := assert.New(t)
+
+	receipts := []*TxReceipt{}
+	for i := 0; i < 20; i++ {
+		receipts = append(receipts, &TxReceipt{
+			TxHash: crypto.Keccak256Hash([]byte(fmt.Sprintf("tx%v", i))),
+			Events: []ReceiptEvent{{
+				Address: common.HexToAddress("0x1234"),
+				Topics:  []common.Hash{common.HexToHash(fmt.Sprintf("0x%x", i))},
+				Data:    common.Bytes(fmt.Sprintf("event%v", i)),
+			}},
+		})
+	}
+
+	// Version 1 headers do not commit the receipts
+	block := NewBlock()
+	block.Version = BlockHeaderVersion1
+	block.SetReceipts(receipts)
+	block.AddTxs([]common.Bytes{common.Bytes("tx0")})
+	assert.Equal(EmptyRootHash, block.ReceiptHash)
+	assert.Equal(Bloom{}, block.Bloom)
+
+	// Version 2 headers do, and adding the txs afterwards keeps the receipt root
+	block = NewBlock()
+	block.Version = BlockHeaderVersion2
+	block.SetReceipts(receipts)
+	block.AddTxs([]common.Bytes{common.Bytes("tx0")})
+	receiptRoot := CalculateReceiptHash(receipts)
+	assert.Equal(receiptRoot, block.ReceiptHash)
+	assert.NotEqual(EmptyRootHash, receiptRoot)
+
+	// The bloom covers the addresses and topics of the events
+	assert.Equal(CreateBloom(receipts), block.Bloom)
+	assert.True(BloomLookup(block.Bloom, common.HexToAddress("0x1234")))
+	assert.True(BloomLookup(block.Bloom, common.HexToHash("0x13")))
+	assert.NotEqual(Bloom{}, block.Bloom)
+
+	for _, index := range []int{0, 7, 19} {
+		proof, err := ProveReceipt(receipts, index)
+		assert.Nil(err)
+		receipt, err := VerifyReceiptProof(block.ReceiptHash, proof)
+		assert.Nil(err)
+		assert.Equal(receipts[index], receipt)
+	}
+
+	_, err := ProveReceipt(receipts, 20)
+	assert.NotNil(err)
+
+	// A tampered receipt changes the root
+	receipts[3].Events[0].Data = common.Bytes("tampered")
+	assert.NotEqual(receiptRoot, CalculateReceiptHash(receipts))
+}
@@ -91,26 +91,28 @@ func (b *Bloom) UnmarshalText(input []byte) error {
 	return hexutil.UnmarshalFixedText("Bloom", input, b[:])
 }
 
-// func CreateBloom(receipts Receipts) Bloom {
-// 	bin := new(big.Int)
-// 	for _, receipt := range receipts {
-// 		bin.Or(bin, LogsBloom(receipt.Logs))
-// 	}
-
-// 	return BytesToBloom(bin.Bytes())
-// }
-
-// func LogsBloom(logs []*Log) *big.Int {
-// 	bin := new(big.Int)
-// 	for _, log := range logs {
-// 		bin.Or(bin, bloom9(log.Address.Bytes()))
-// 		for _, b := range log.Topics {
-// 			bin.Or(bin, bloom9(b[:]))
-// 		}
-// 	}
-
-// 	return bin
-// }
+// CreateBloom creates the bloom filter of the addresses and topics of the events in the receipts.
+func CreateBloom(receipts []*TxReceipt) Bloom {
+	bin := new(big.Int)
+	for _, receipt := range receipts {
+		bin.Or(bin, EventsBloom(receipt.Events))
+	}
+
+	return BytesToBloom(bin.Bytes())
+}
+
+// EventsBloom returns the bloom bits of the addresses and topics of the events.
+func EventsBloom(events []ReceiptEvent) *big.Int {
+	bin := new(big.Int)
+	for _, event := range events {
+		bin.Or(bin, bloom9(event.Address.Bytes()))
+		for _, b := range event.Topics {
+			bin.Or(bin, bloom9(b[:]))
+		}
+	}
+
+	return bin
+}
 
 func bloom9(b []byte) *big.Int {
 	b = crypto.Keccak256(b)
@@ -1,20 +1,41 @@
 package core
 
 import (
+	"context"
+
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
 )
 
-// ConsensusEngine is the interface of a consensus engine.
+// ConsensusEngine is the interface of a consensus engine. The node only interacts with the engine
+// through this interface, so that the engine can be selected with common.CfgConsensusEngine, e.g.
+// to run a single node test chain.
 type ConsensusEngine interface {
+	FinalityProvider
+
+	// ID returns the ID of the node, i.e. the address of its key
 	ID() string
+	// PrivateKey returns the key of the node, used to sign its proposals and votes
 	PrivateKey() *crypto.PrivateKey
+	// GetTip returns the block to extend or to vote on
 	GetTip(includePendingBlockingLeaf bool) *ExtendedBlock
 	GetEpoch() uint64
 	GetLedger() Ledger
+	SetLedger(ledger Ledger)
+	// AddMessage queues a block, vote or proposal received from the network
 	AddMessage(msg interface{})
-	FinalizedBlocks() chan *Block
-	GetLastFinalizedBlock() *ExtendedBlock
+	IsInRecovery() bool
+	// SubscribeValidatedBlocks returns a subscription receiving the blocks once validated and
+	// applied to the state, before they are finalized. The blocks are dropped for the subscribers
+	// whose buffer is full.
+	SubscribeValidatedBlocks(bufferSize int) *FinalitySubscription
+
+	// Start starts the engine goroutines, once the ledger is set
+	Start(ctx context.Context)
+	// Stop notifies the engine goroutines to stop without blocking
+	Stop()
+	// Wait blocks until the engine goroutines stop
+	Wait()
 }
 
 // ValidatorManager is the component for managing validator related logic for consensus engine.
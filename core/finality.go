@@ -0,0 +1,78 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// FinalityProvider gives access to the finalized blocks. It is implemented by the consensus
+// engine, and consumed by the components which only care about finality, e.g. the sync manager,
+// the RPC service and the indexers, so that they do not depend on a specific engine.
+type FinalityProvider interface {
+	// GetLastFinalizedBlock returns the last finalized block.
+	GetLastFinalizedBlock() *ExtendedBlock
+
+	// IsFinalized returns whether the block with the given hash is finalized, directly or as an
+	// ancestor of a finalized block.
+	IsFinalized(hash common.Hash) bool
+
+	// SubscribeFinalizedBlocks returns a subscription receiving the directly finalized blocks. The
+	// blocks are dropped for the subscribers whose buffer is full.
+	SubscribeFinalizedBlocks(bufferSize int) *FinalitySubscription
+
+	// GetFinalizationCertificate returns the votes committing the finalized block with the given hash.
+	GetFinalizationCertificate(hash common.Hash) (*CommitCertificate, error)
+}
+
+// FinalitySubscription receives the finalized blocks on C until it is unsubscribed. It also
+// delivers the validated blocks, see ConsensusEngine.SubscribeValidatedBlocks.
+type FinalitySubscription struct {
+	C    <-chan *Block
+	c    chan *Block
+	feed *FinalityFeed
+}
+
+// Unsubscribe stops the delivery of the finalized blocks to the subscription.
+func (s *FinalitySubscription) Unsubscribe() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	delete(s.feed.subs, s)
+}
+
+// FinalityFeed publishes the finalized blocks to the subscriptions, see FinalityProvider.
+type FinalityFeed struct {
+	mu   *sync.Mutex
+	subs map[*FinalitySubscription]struct{}
+}
+
+// NewFinalityFeed creates a new instance of FinalityFeed.
+func NewFinalityFeed() *FinalityFeed {
+	return &FinalityFeed{
+		mu:   &sync.Mutex{},
+		subs: make(map[*FinalitySubscription]struct{}),
+	}
+}
+
+// Subscribe adds a subscription buffering up to bufferSize blocks.
+func (f *FinalityFeed) Subscribe(bufferSize int) *FinalitySubscription {
+	c := make(chan *Block, bufferSize)
+	sub := &FinalitySubscription{C: c, c: c, feed: f}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish sends the block to all the subscriptions without blocking.
+func (f *FinalityFeed) Publish(block *Block) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		select {
+		case sub.c <- block:
+		default:
+		}
+	}
+}
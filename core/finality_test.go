@@ -0,0 +1,32 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinalityFeed(t *testing.T) {
+	assert := assert.New(t)
+
+	feed := NewFinalityFeed()
+	sub1 := feed.Subscribe(1)
+	sub2 := feed.Subscribe(2)
+
+	b1 := NewBlock()
+	b1.Height = 1
+	b2 := NewBlock()
+	b2.Height = 2
+	feed.Publish(b1)
+	feed.Publish(b2) // dropped for sub1, whose buffer is full
+
+	assert.Equal(b1, <-sub1.C)
+	assert.Equal(0, len(sub1.C))
+	assert.Equal(b1, <-sub2.C)
+	assert.Equal(b2, <-sub2.C)
+
+	sub1.Unsubscribe()
+	feed.Publish(b1)
+	assert.Equal(0, len(sub1.C))
+	assert.Equal(b1, <-sub2.C)
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"math"
+	"sync"
+)
+
+// Fork identifies a change of the consensus rules, which applies from a height set per chain
+type Fork string
+
+const (
+	// ForkServicePaymentDispute holds the service payments in a dispute window before transferring
+	// them, and accepts the ServicePaymentDisputeTx
+	ForkServicePaymentDispute Fork = "servicePaymentDispute"
+
+	// ForkAccountOperator accepts the SetAccountOperatorTx, delegating the service payments of an
+	// account to an operator key
+	ForkAccountOperator Fork = "accountOperator"
+
+	// ForkNodeAddress accepts the RegisterNodeAddressTx, publishing the network endpoints of the
+	// validators on chain
+	ForkNodeAddress Fork = "nodeAddress"
+
+	// ForkMultiSend accepts the MultiSendTx, paying up to types.MaxMultiSendOutputs outputs in a
+	// single transaction
+	ForkMultiSend Fork = "multiSend"
+
+	// ForkSlashReview accepts the SlashReviewTx, confirming or dismissing the slashes locked for
+	// the review of the slashing council
+	ForkSlashReview Fork = "slashReview"
+
+	// ForkDataCommitment accepts the DataCommitmentTx, anchoring the Merkle root of off-chain data
+	// to the chain
+	ForkDataCommitment Fork = "dataCommitment"
+
+	// ForkMultiSig accepts the UpdateAccountSignersTx and the MultiSigSendTx, and rejects the
+	// single-signature inputs of the accounts controlled by a signer set
+	ForkMultiSig Fork = "multiSig"
+
+	// ForkFeePayer accepts the transactions whose fee is paid by a fee payer rather than their
+	// inputs, see types.FeePayer
+	ForkFeePayer Fork = "feePayer"
+
+	// ForkTxExpiry accepts the transactions with an expiry height, see types.ExpiringTx
+	ForkTxExpiry Fork = "txExpiry"
+
+	// ForkReservedFundRemoval removes the fully settled reserved funds right away instead of when
+	// they expire, and indexes the heights at which the reserved funds are removed from the accounts
+	ForkReservedFundRemoval Fork = "reservedFundRemoval"
+
+	// ForkReservedFundLimit caps the number of active reserved funds per account, see
+	// execution.MaxActiveReservedFunds
+	ForkReservedFundLimit Fork = "reservedFundLimit"
+
+	// ForkRewardCohort splits the reward recipients of the coinbase transactions into cohorts of at
+	// most execution.MaxRewardRecipientsPerBlock accounts rewarded in turn, see
+	// execution.RewardCohort
+	ForkRewardCohort Fork = "rewardCohort"
+
+	// ForkZeroFeeLane limits the number of zero-fee protocol transactions per block, see
+	// execution.ZeroFeeLane
+	ForkZeroFeeLane Fork = "zeroFeeLane"
+
+	// ForkResourceIDFormat requires the resource IDs of the reserve fund and split rule transactions
+	// to be content addressed, see types.ParseResourceID
+	ForkResourceIDFormat Fork = "resourceIDFormat"
+
+	// ForkExtensionTx accepts the extension transactions, see execution.RegisterExtensionTxExecutor
+	ForkExtensionTx Fork = "extensionTx"
+
+	// ForkBlockHeaderV2 switches the blocks to the BlockHeaderVersion2 header format
+	ForkBlockHeaderV2 Fork = "blockHeaderV2"
+)
+
+// forkHeights gives the heights from which the forks apply on the chains launched before them.
+// Chains not listed for a fork apply it from their genesis, and chains with the height set to
+// math.MaxUint64 do not apply it yet.
+var forkHeights = map[Fork]map[string]uint64{
+	ForkServicePaymentDispute: notScheduled(),
+	ForkAccountOperator:       notScheduled(),
+	ForkNodeAddress:           notScheduled(),
+	ForkMultiSend:             notScheduled(),
+	ForkSlashReview:           notScheduled(),
+	ForkDataCommitment:        notScheduled(),
+	ForkMultiSig:              notScheduled(),
+	ForkFeePayer:              notScheduled(),
+	ForkTxExpiry:              notScheduled(),
+	ForkReservedFundRemoval:   notScheduled(),
+	ForkReservedFundLimit:     notScheduled(),
+	ForkRewardCohort:          notScheduled(),
+	ForkZeroFeeLane:           notScheduled(),
+	ForkResourceIDFormat:      notScheduled(),
+	ForkExtensionTx:           notScheduled(),
+	ForkBlockHeaderV2:         notScheduled(),
+}
+
+var forkHeightsMutex = &sync.RWMutex{}
+
+// notScheduled returns the heights of a fork not scheduled on the live chains yet
+func notScheduled() map[string]uint64 {
+	return map[string]uint64{
+		MainnetChainID:    math.MaxUint64,
+		TestnetChainID:    math.MaxUint64,
+		PrivatenetChainID: math.MaxUint64,
+	}
+}
+
+// SetForkHeight sets the height from which the fork applies on the chain. It is a hard fork, all
+// the nodes of the chain need the same height.
+func SetForkHeight(fork Fork, chainID string, height uint64) {
+	forkHeightsMutex.Lock()
+	defer forkHeightsMutex.Unlock()
+	if forkHeights[fork] == nil {
+		forkHeights[fork] = make(map[string]uint64)
+	}
+	forkHeights[fork][chainID] = height
+}
+
+// ForkHeight returns the height from which the fork applies on the chain
+func ForkHeight(fork Fork, chainID string) uint64 {
+	forkHeightsMutex.RLock()
+	defer forkHeightsMutex.RUnlock()
+	return forkHeights[fork][chainID]
+}
+
+// IsForkActive returns whether the fork applies to the block at the given height
+func IsForkActive(fork Fork, chainID string, height uint64) bool {
+	forkHeightsMutex.RLock()
+	defer forkHeightsMutex.RUnlock()
+	forkHeight, ok := forkHeights[fork][chainID]
+	return !ok || height >= forkHeight
+}
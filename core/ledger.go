@@ -20,6 +20,7 @@ const (
 //
 type TxInfo struct {
 	EffectiveGasPrice *big.Int
+	Gas               uint64 // estimated gas consumption, used by the proposer to measure block fullness
 	Address           common.Address
 	Sequence          uint64
 }
@@ -31,10 +32,13 @@ type Ledger interface {
 	GetCurrentBlock() *Block
 	ScreenTxUnsafe(rawTx common.Bytes) result.Result
 	ScreenTx(rawTx common.Bytes) (priority *TxInfo, res result.Result)
+	ScreenReplacementTx(rawTx common.Bytes, precedingRawTxs []common.Bytes) (priority *TxInfo, res result.Result)
+	SimulateScreenTx(rawTx common.Bytes) (priority *TxInfo, res result.Result)
+	GetTxInfo(rawTx common.Bytes) (*TxInfo, result.Result)
 	ProposeBlockTxs(block *Block) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result)
 	ApplyBlockTxs(block *Block) result.Result
 	ResetState(height uint64, rootHash common.Hash) result.Result
 	FinalizeState(height uint64, rootHash common.Hash) result.Result
 	GetFinalizedValidatorCandidatePool(blockHash common.Hash, isNext bool) (*ValidatorCandidatePool, error)
-	PruneState(endHeight uint64) error
+	GetNodeAddress(blockHash common.Hash, validator common.Address) (*NodeAddress, error)
 }
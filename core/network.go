@@ -0,0 +1,298 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+)
+
+const (
+	TestnetChainID = "testnet"
+
+	TestnetGenesisBlockHash = "0xa58cb754a23975c872ef06d8b54baf16eec88ad60f966368b950a6c16ae52ed9"
+
+	PrivatenetChainID = "privatenet"
+
+	PrivatenetGenesisBlockHash = "0x45c579eb4d435ffcd37f0f76beaa772072cea146c4ecec2e52066904b80f4e0a"
+
+	// DefaultAddressPrefix is the prefix of the addresses displayed for the built-in networks
+	DefaultAddressPrefix = "0x"
+)
+
+var (
+	networkNameRegexp   = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,31}$`)
+	addressPrefixRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{1,15}$`)
+)
+
+// Checkpoint is a block known to be on the canonical chain of a network
+type Checkpoint struct {
+	Height uint64
+	Hash   common.Hash
+}
+
+// NetworkProfile bundles the parameters identifying a network, so that a node can join the
+// network by its name instead of assembling the configuration manually
+type NetworkProfile struct {
+	Name          string
+	ChainID       string
+	GenesisHash   string
+	Seeds         []string
+	Checkpoints   []Checkpoint
+	AddressPrefix string // displayed in front of the hex of the addresses
+	P2PPort       int    // default P2P port of the network, 0 for the node default
+	RPCPort       int    // default RPC port of the network, 0 for the node default
+}
+
+// NetworkConfig is the definition of a custom network in the config, see common.CfgNetworks.
+type NetworkConfig struct {
+	Name          string   `mapstructure:"name"`
+	ChainID       string   `mapstructure:"chainID"`
+	GenesisHash   string   `mapstructure:"genesisHash"`
+	Seeds         []string `mapstructure:"seeds"`
+	AddressPrefix string   `mapstructure:"addressPrefix"`
+	P2PPort       int      `mapstructure:"p2pPort"`
+	RPCPort       int      `mapstructure:"rpcPort"`
+}
+
+var networkProfiles = map[string]*NetworkProfile{
+	"mainnet": &NetworkProfile{
+		Name:        "mainnet",
+		ChainID:     MainnetChainID,
+		GenesisHash: MainnetGenesisBlockHash,
+		Seeds:       []string{"3.18.35.120:21000", "18.224.234.179:21000", "3.18.96.195:21000"},
+		Checkpoints: []Checkpoint{
+			{Height: GenesisBlockHeight, Hash: common.HexToHash(MainnetGenesisBlockHash)},
+		},
+		AddressPrefix: DefaultAddressPrefix,
+	},
+	"testnet": &NetworkProfile{
+		Name:        "testnet",
+		ChainID:     TestnetChainID,
+		GenesisHash: TestnetGenesisBlockHash,
+		Seeds:       []string{"54.219.137.110:15000"},
+		Checkpoints: []Checkpoint{
+			{Height: GenesisBlockHeight, Hash: common.HexToHash(TestnetGenesisBlockHash)},
+		},
+		AddressPrefix: DefaultAddressPrefix,
+	},
+	"privatenet": &NetworkProfile{
+		Name:        "privatenet",
+		ChainID:     PrivatenetChainID,
+		GenesisHash: PrivatenetGenesisBlockHash,
+		Seeds:       []string{},
+		Checkpoints: []Checkpoint{
+			{Height: GenesisBlockHeight, Hash: common.HexToHash(PrivatenetGenesisBlockHash)},
+		},
+		AddressPrefix: DefaultAddressPrefix,
+	},
+}
+
+// builtinNetworks are the names of the networks shipped with the node
+var builtinNetworks = map[string]bool{"mainnet": true, "testnet": true, "privatenet": true}
+
+// IsBuiltinNetwork returns whether the network with the given name is shipped with the node
+func IsBuiltinNetwork(name string) bool {
+	return builtinNetworks[name]
+}
+
+// GetNetworkProfile returns the profile of the network with the given name
+func GetNetworkProfile(name string) (*NetworkProfile, error) {
+	profile, ok := networkProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown network %v, available networks: %v", name, NetworkNames())
+	}
+	return profile, nil
+}
+
+// RegisterNetworkProfiles adds the custom networks defined in the config. The chainIDs and the
+// address prefixes of the networks must be distinct from those of all the other networks, so that
+// the transactions signed for one network are never valid on another.
+func RegisterNetworkProfiles(configs []NetworkConfig) error {
+	profiles := []*NetworkProfile{}
+	for _, config := range configs {
+		profile, err := newCustomNetworkProfile(config)
+		if err != nil {
+			return err
+		}
+		if err := checkNetworkProfileConflicts(profile, profiles); err != nil {
+			return err
+		}
+		profiles = append(profiles, profile)
+	}
+	for _, profile := range profiles {
+		networkProfiles[profile.Name] = profile
+	}
+	return nil
+}
+
+func newCustomNetworkProfile(config NetworkConfig) (*NetworkProfile, error) {
+	if !networkNameRegexp.MatchString(config.Name) {
+		return nil, fmt.Errorf("Invalid network name %q, expected lower case letters, digits, '_' or '-'", config.Name)
+	}
+	if len(strings.TrimSpace(config.ChainID)) == 0 {
+		return nil, fmt.Errorf("The chainID of network %v is not set", config.Name)
+	}
+	genesisHash := common.HexToHash(config.GenesisHash)
+	if !isHexHash(config.GenesisHash) || (genesisHash == common.Hash{}) {
+		return nil, fmt.Errorf("Invalid genesis hash %q for network %v", config.GenesisHash, config.Name)
+	}
+	addressPrefix := config.AddressPrefix
+	if len(addressPrefix) == 0 {
+		addressPrefix = DefaultAddressPrefix
+	} else if !addressPrefixRegexp.MatchString(addressPrefix) {
+		return nil, fmt.Errorf("Invalid address prefix %q for network %v, expected 2 to 16 lower case letters, digits or '_'",
+			addressPrefix, config.Name)
+	}
+	for _, port := range []int{config.P2PPort, config.RPCPort} {
+		if port < 0 || port > 65535 {
+			return nil, fmt.Errorf("Invalid port %v for network %v", port, config.Name)
+		}
+	}
+	if config.P2PPort != 0 && config.P2PPort == config.RPCPort {
+		return nil, fmt.Errorf("The P2P and RPC ports of network %v must be different", config.Name)
+	}
+
+	seeds := config.Seeds
+	if seeds == nil {
+		seeds = []string{}
+	}
+	return &NetworkProfile{
+		Name:        config.Name,
+		ChainID:     config.ChainID,
+		GenesisHash: config.GenesisHash,
+		Seeds:       seeds,
+		Checkpoints: []Checkpoint{
+			{Height: GenesisBlockHeight, Hash: genesisHash},
+		},
+		AddressPrefix: addressPrefix,
+		P2PPort:       config.P2PPort,
+		RPCPort:       config.RPCPort,
+	}, nil
+}
+
+// checkNetworkProfileConflicts checks the custom profile against the registered profiles, and the
+// custom profiles registered along with it.
+func checkNetworkProfileConflicts(profile *NetworkProfile, pending []*NetworkProfile) error {
+	others := []*NetworkProfile{}
+	for _, other := range networkProfiles {
+		others = append(others, other)
+	}
+	others = append(others, pending...)
+
+	for _, other := range others {
+		if other.Name == profile.Name {
+			if IsBuiltinNetwork(other.Name) {
+				return fmt.Errorf("Network %v is built-in and cannot be redefined", profile.Name)
+			}
+			// Redefining a custom network from the same config is allowed, the chainID excepted
+			if other.ChainID != profile.ChainID {
+				return fmt.Errorf("Network %v is already defined with chainID %v", profile.Name, other.ChainID)
+			}
+			continue
+		}
+		if strings.EqualFold(other.ChainID, profile.ChainID) {
+			return fmt.Errorf("The chainID %v of network %v is already used by network %v",
+				profile.ChainID, profile.Name, other.Name)
+		}
+		if profile.AddressPrefix != DefaultAddressPrefix && other.AddressPrefix == profile.AddressPrefix {
+			return fmt.Errorf("The address prefix %v of network %v is already used by network %v",
+				profile.AddressPrefix, profile.Name, other.Name)
+		}
+	}
+	return nil
+}
+
+// NetworkNames returns the names of the registered network profiles
+func NetworkNames() []string {
+	names := []string{}
+	for name := range networkProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VerifyBlock checks the block against the chainID and the checkpoints of the network, to
+// detect a node starting from the snapshot of another network
+func (p *NetworkProfile) VerifyBlock(block *BlockHeader) error {
+	if block.ChainID != p.ChainID {
+		return fmt.Errorf("ChainID mismatch: block.ChainID(%v) != %v (network %v)", block.ChainID, p.ChainID, p.Name)
+	}
+	for _, checkpoint := range p.Checkpoints {
+		if checkpoint.Height == block.Height && checkpoint.Hash != block.Hash() {
+			return fmt.Errorf("Block %v at height %v does not match the checkpoint %v of network %v",
+				block.Hash().Hex(), block.Height, checkpoint.Hash.Hex(), p.Name)
+		}
+	}
+	return nil
+}
+
+// LatestCheckpoint returns the checkpoint of the network with the largest height
+func (p *NetworkProfile) LatestCheckpoint() Checkpoint {
+	latest := Checkpoint{}
+	for _, checkpoint := range p.Checkpoints {
+		if checkpoint.Height >= latest.Height {
+			latest = checkpoint
+		}
+	}
+	return latest
+}
+
+// VerifyChainID returns an error if the chainID is not the one of the network, to avoid signing or
+// accepting a transaction meant for another network.
+func (p *NetworkProfile) VerifyChainID(chainID string) error {
+	if chainID == p.ChainID {
+		return nil
+	}
+	for _, other := range networkProfiles {
+		if other.ChainID == chainID {
+			return fmt.Errorf("ChainID %v belongs to network %v, not %v (chainID %v)", chainID, other.Name, p.Name, p.ChainID)
+		}
+	}
+	return fmt.Errorf("ChainID mismatch: %v != %v (network %v)", chainID, p.ChainID, p.Name)
+}
+
+// FormatAddress returns the address as displayed for the network: the address prefix followed by
+// the checksummed hex of the address.
+func (p *NetworkProfile) FormatAddress(address common.Address) string {
+	return p.addressPrefix() + address.Hex()[2:]
+}
+
+// ParseAddress parses an address displayed for the network. The plain hex addresses are accepted
+// too, while the addresses with the prefix of another network are rejected.
+func (p *NetworkProfile) ParseAddress(str string) (common.Address, error) {
+	if prefix := p.addressPrefix(); prefix != DefaultAddressPrefix && strings.HasPrefix(str, prefix) &&
+		common.IsHexAddress(str[len(prefix):]) {
+		return common.HexToAddress(str[len(prefix):]), nil
+	}
+	// A bare hex address can start with the prefix of another network, so it is accepted first
+	if common.IsHexAddress(str) {
+		return common.HexToAddress(str), nil
+	}
+	for _, other := range networkProfiles {
+		if other.AddressPrefix != DefaultAddressPrefix && strings.HasPrefix(str, other.AddressPrefix) &&
+			common.IsHexAddress(str[len(other.AddressPrefix):]) {
+			return common.Address{}, fmt.Errorf("Address %v belongs to network %v, not %v", str, other.Name, p.Name)
+		}
+	}
+	return common.Address{}, fmt.Errorf("Invalid address %v for network %v", str, p.Name)
+}
+
+func isHexHash(str string) bool {
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		str = str[2:]
+	}
+	bytes, err := hex.DecodeString(str)
+	return err == nil && len(bytes) == common.HashLength
+}
+
+func (p *NetworkProfile) addressPrefix() string {
+	if len(p.AddressPrefix) == 0 {
+		return DefaultAddressPrefix
+	}
+	return p.AddressPrefix
+}
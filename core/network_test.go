@@ -0,0 +1,142 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestGetNetworkProfile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	profile, err := GetNetworkProfile("mainnet")
+	require.Nil(err)
+	assert.Equal(MainnetChainID, profile.ChainID)
+	assert.Equal(MainnetGenesisBlockHash, profile.GenesisHash)
+
+	_, err = GetNetworkProfile("nosuchnet")
+	assert.NotNil(err)
+
+	assert.Equal([]string{"mainnet", "privatenet", "testnet"}, NetworkNames())
+}
+
+func TestNetworkProfileVerifyBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	block := NewBlock()
+	block.ChainID = "testchain"
+	block.Height = GenesisBlockHeight
+
+	profile := &NetworkProfile{
+		Name:        "testchain",
+		ChainID:     "testchain",
+		Checkpoints: []Checkpoint{{Height: GenesisBlockHeight, Hash: block.Hash()}},
+	}
+	assert.Nil(profile.VerifyBlock(block.BlockHeader))
+
+	// Blocks without a checkpoint at their heights only need to match the chainID
+	other := &BlockHeader{ChainID: "testchain", Height: 100}
+	assert.Nil(profile.VerifyBlock(other))
+
+	other = &BlockHeader{ChainID: "testchain", Height: GenesisBlockHeight, Epoch: 5}
+	assert.NotNil(profile.VerifyBlock(other))
+
+	other = &BlockHeader{ChainID: "mainnet", Height: 100}
+	assert.NotNil(profile.VerifyBlock(other))
+}
+
+func TestRegisterNetworkProfiles(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	genesisHash := "0x1d3f36e2a1c94f9ae6d12ffab7f2e3e8f1c0b6a3e8c2f9db1a0e0b1b2c3d4e5f"
+	defer delete(networkProfiles, "acme")
+
+	err := RegisterNetworkProfiles([]NetworkConfig{{
+		Name:          "acme",
+		ChainID:       "acme-ledger",
+		GenesisHash:   genesisHash,
+		Seeds:         []string{"10.0.0.1:40001"},
+		AddressPrefix: "acme",
+		P2PPort:       40001,
+		RPCPort:       40888,
+	}})
+	require.Nil(err)
+
+	profile, err := GetNetworkProfile("acme")
+	require.Nil(err)
+	assert.Equal("acme-ledger", profile.ChainID)
+	assert.Equal(40001, profile.P2PPort)
+	assert.Equal(40888, profile.RPCPort)
+	assert.Equal([]Checkpoint{{Height: GenesisBlockHeight, Hash: common.HexToHash(genesisHash)}}, profile.Checkpoints)
+	assert.False(IsBuiltinNetwork("acme"))
+	assert.Equal([]string{"acme", "mainnet", "privatenet", "testnet"}, NetworkNames())
+
+	invalid := []NetworkConfig{
+		{Name: "mainnet", ChainID: "mainnet2", GenesisHash: genesisHash},                   // built-in name
+		{Name: "acme2", ChainID: MainnetChainID, GenesisHash: genesisHash},                 // built-in chainID
+		{Name: "acme2", ChainID: "ACME-LEDGER", GenesisHash: genesisHash},                  // chainID of another network
+		{Name: "acme2", ChainID: "acme2", GenesisHash: genesisHash, AddressPrefix: "acme"}, // prefix of another network
+		{Name: "acme2", ChainID: "acme2", GenesisHash: genesisHash, AddressPrefix: "0x"},
+		{Name: "acme2", ChainID: "acme2", GenesisHash: "0x1234"},
+		{Name: "acme2", ChainID: "", GenesisHash: genesisHash},
+		{Name: "Acme 2", ChainID: "acme2", GenesisHash: genesisHash},
+		{Name: "acme2", ChainID: "acme2", GenesisHash: genesisHash, P2PPort: 40001, RPCPort: 40001},
+		{Name: "acme2", ChainID: "acme2", GenesisHash: genesisHash, RPCPort: 70000},
+	}
+	for _, config := range invalid {
+		assert.NotNil(RegisterNetworkProfiles([]NetworkConfig{config}), config.Name)
+	}
+
+	// The networks of a config must be distinct from each other too, and none is registered if any
+	// is invalid
+	err = RegisterNetworkProfiles([]NetworkConfig{
+		{Name: "acme2", ChainID: "acme2", GenesisHash: genesisHash},
+		{Name: "acme3", ChainID: "acme2", GenesisHash: genesisHash},
+	})
+	assert.NotNil(err)
+	_, err = GetNetworkProfile("acme2")
+	assert.NotNil(err)
+}
+
+func TestNetworkProfileAddresses(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	defer delete(networkProfiles, "acme")
+	require.Nil(RegisterNetworkProfiles([]NetworkConfig{{
+		Name:          "acme",
+		ChainID:       "acme-ledger",
+		GenesisHash:   "0x1d3f36e2a1c94f9ae6d12ffab7f2e3e8f1c0b6a3e8c2f9db1a0e0b1b2c3d4e5f",
+		AddressPrefix: "acme",
+	}}))
+	acme, _ := GetNetworkProfile("acme")
+	mainnet, _ := GetNetworkProfile("mainnet")
+
+	address := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	assert.Equal("acme2E833968E5bB786Ae419c4d13189fB081Cc43bab", acme.FormatAddress(address))
+	assert.Equal("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab", mainnet.FormatAddress(address))
+
+	for _, str := range []string{"acme2E833968E5bB786Ae419c4d13189fB081Cc43bab", "0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"} {
+		parsed, err := acme.ParseAddress(str)
+		assert.Nil(err, str)
+		assert.Equal(address, parsed)
+	}
+
+	// The addresses of another network are rejected, the plain hex addresses are not
+	_, err := mainnet.ParseAddress("acme2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	assert.NotNil(err)
+	parsed, err := mainnet.ParseAddress("2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	assert.Nil(err)
+	assert.Equal(address, parsed)
+	_, err = acme.ParseAddress("acme2E833968")
+	assert.NotNil(err)
+
+	assert.Nil(acme.VerifyChainID("acme-ledger"))
+	assert.NotNil(acme.VerifyChainID(MainnetChainID))
+	assert.NotNil(mainnet.VerifyChainID("acme-ledger"))
+	assert.NotNil(mainnet.VerifyChainID("other"))
+}
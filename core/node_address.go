@@ -0,0 +1,130 @@
+package core
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+)
+
+const (
+	// MaxNodeEndpoints is the max number of endpoints a validator can register
+	MaxNodeEndpoints = 4
+
+	// MaxNodeEndpointLength is the max length of a registered endpoint
+	MaxNodeEndpointLength = 255
+
+	// NodeAddressRotationGracePeriod is the number of blocks the previous endpoints of a validator
+	// stay reachable after it registers new ones
+	NodeAddressRotationGracePeriod uint64 = 14400 // roughly 24 hours
+)
+
+//
+// ------- NodeAddress ------- //
+//
+
+// NodeAddress is the network endpoints a validator registers on chain, so that the other
+// validators can connect to it directly.
+type NodeAddress struct {
+	Validator         common.Address
+	Endpoints         []string // host:port, the host being an IP address or a DNS name
+	PreviousEndpoints []string // endpoints replaced by the last rotation
+	RotationHeight    uint64   // height of the last rotation
+}
+
+// ActiveEndpoints returns the endpoints to connect to at the given height. The previous
+// endpoints are included until the grace period of the last rotation is over.
+func (na *NodeAddress) ActiveEndpoints(height uint64) []string {
+	endpoints := append([]string{}, na.Endpoints...)
+	if height < na.RotationHeight+NodeAddressRotationGracePeriod {
+		for _, endpoint := range na.PreviousEndpoints {
+			if !containsEndpoint(endpoints, endpoint) {
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	return endpoints
+}
+
+// Rotate replaces the endpoints at the given height, keeping the current ones as the previous
+// endpoints for the grace period.
+func (na *NodeAddress) Rotate(endpoints []string, height uint64) {
+	na.PreviousEndpoints = na.Endpoints
+	na.Endpoints = endpoints
+	na.RotationHeight = height
+}
+
+func (na *NodeAddress) String() string {
+	if na == nil {
+		return "nil-NodeAddress"
+	}
+	return fmt.Sprintf("NodeAddress{%v, endpoints: %v, previousEndpoints: %v, rotationHeight: %v}",
+		na.Validator, na.Endpoints, na.PreviousEndpoints, na.RotationHeight)
+}
+
+// ValidateNodeEndpoints checks that the endpoints are well formed and distinct.
+func ValidateNodeEndpoints(endpoints []string) error {
+	if len(endpoints) > MaxNodeEndpoints {
+		return fmt.Errorf("At most %v endpoints can be registered", MaxNodeEndpoints)
+	}
+	for i, endpoint := range endpoints {
+		if err := validateNodeEndpoint(endpoint); err != nil {
+			return err
+		}
+		if containsEndpoint(endpoints[:i], endpoint) {
+			return fmt.Errorf("Duplicate endpoint %v", endpoint)
+		}
+	}
+	return nil
+}
+
+func validateNodeEndpoint(endpoint string) error {
+	if len(endpoint) > MaxNodeEndpointLength {
+		return fmt.Errorf("Endpoint longer than %v characters", MaxNodeEndpointLength)
+	}
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return fmt.Errorf("Invalid endpoint %v: %v", endpoint, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return fmt.Errorf("Invalid port in endpoint %v", endpoint)
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if !isDNSName(host) {
+		return fmt.Errorf("Invalid host in endpoint %v", endpoint)
+	}
+	return nil
+}
+
+// isDNSName checks the host name syntax of RFC 1123.
+func isDNSName(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if len(host) == 0 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func containsEndpoint(endpoints []string, endpoint string) bool {
+	for _, e := range endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// ReceiptEvent is an event emitted by a transaction, e.g. a smart contract log.
+type ReceiptEvent struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    common.Bytes   `json:"data"`
+}
+
+// TxReceipt records the outcome of a transaction included in a block. From BlockHeaderVersion2,
+// the root of the receipts of a block is committed in the ReceiptHash of its header, and the
+// bloom filter of their events in the Bloom.
+type TxReceipt struct {
+	TxHash common.Hash    `json:"tx_hash"`
+	Events []ReceiptEvent `json:"events"`
+}
+
+// CommitsReceipts indicates whether the header commits the root of the transaction receipts.
+func (h *BlockHeader) CommitsReceipts() bool {
+	return h.HeaderVersion() >= BlockHeaderVersion2
+}
+
+// SetReceipts sets the receipt root and the log bloom of the block. It is a no-op for the headers
+// that do not commit the receipts.
+func (b *Block) SetReceipts(receipts []*TxReceipt) {
+	if !b.CommitsReceipts() {
+		return
+	}
+	b.ReceiptHash = CalculateReceiptHash(receipts)
+	b.Bloom = CreateBloom(receipts)
+}
+
+// CalculateReceiptHash calculates the root hash of the receipts, the same way as the tx root.
+func CalculateReceiptHash(receipts []*TxReceipt) common.Hash {
+	return calculateRootHash(encodeReceipts(receipts))
+}
+
+// ProveReceipt returns the Merkle proof of the receipt at index against the receipt root.
+func ProveReceipt(receipts []*TxReceipt, index int) (*TxProof, error) {
+	if index < 0 || index >= len(receipts) {
+		return nil, fmt.Errorf("Receipt index %v is out of range, the block has %v receipts", index, len(receipts))
+	}
+	return proveRootItem(encodeReceipts(receipts), index)
+}
+
+// VerifyReceiptProof checks the proof against the receipt root, and returns the receipt it proves.
+func VerifyReceiptProof(receiptRoot common.Hash, proof *TxProof) (*TxReceipt, error) {
+	nodes := &txProofNodes{nodes: proof.Nodes}
+	raw, _, err := trie.VerifyProof(receiptRoot, txProofKey(int(proof.Index)), nodes)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, errors.New("The proof does not contain the receipt")
+	}
+	receipt := &TxReceipt{}
+	if err := rlp.DecodeBytes(raw, receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+func encodeReceipts(receipts []*TxReceipt) []common.Bytes {
+	items := make([]common.Bytes, len(receipts))
+	for i, receipt := range receipts {
+		raw, err := rlp.EncodeToBytes(receipt)
+		if err != nil {
+			panic(err) // receipts only hold RLP encodable fields
+		}
+		items[i] = raw
+	}
+	return items
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// TxProof is the Merkle proof of a transaction against the tx root (TxHash) of its block. The nodes
+// are the encoded trie nodes on the path from the root to the transaction.
+type TxProof struct {
+	Index common.JSONUint64 `json:"index"`
+	Nodes []common.Bytes    `json:"nodes"`
+}
+
+// ProveTx returns the Merkle proof of the transaction at index.
+func (b *Block) ProveTx(index int) (*TxProof, error) {
+	if index < 0 || index >= len(b.Txs) {
+		return nil, fmt.Errorf("Transaction index %v is out of range, the block has %v transactions", index, len(b.Txs))
+	}
+	return proveRootItem(b.Txs, index)
+}
+
+// proveRootItem returns the Merkle proof of the item at index against calculateRootHash(items).
+func proveRootItem(items []common.Bytes, index int) (*TxProof, error) {
+	itemTrie := new(trie.Trie)
+	for i := 0; i < len(items); i++ {
+		itemTrie.Update(txProofKey(i), items[i])
+	}
+	nodes := &txProofNodes{}
+	if err := itemTrie.Prove(txProofKey(index), 0, nodes); err != nil {
+		return nil, err
+	}
+	return &TxProof{
+		Index: common.JSONUint64(index),
+		Nodes: nodes.nodes,
+	}, nil
+}
+
+// VerifyTxProof checks the proof against the tx root, and returns the transaction it proves.
+func VerifyTxProof(txRoot common.Hash, proof *TxProof) (common.Bytes, error) {
+	nodes := &txProofNodes{nodes: proof.Nodes}
+	tx, _, err := trie.VerifyProof(txRoot, txProofKey(int(proof.Index)), nodes)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errors.New("The proof does not contain the transaction")
+	}
+	return tx, nil
+}
+
+// txProofKey is the key of the transaction at index in the tx trie, see calculateRootHash.
+func txProofKey(index int) []byte {
+	key, _ := rlp.EncodeToBytes(uint(index))
+	return key
+}
+
+// txProofNodes stores the proof nodes, keyed by their hash as expected by the trie.
+type txProofNodes struct {
+	nodes []common.Bytes
+}
+
+func (p *txProofNodes) Put(key []byte, value []byte) error {
+	p.nodes = append(p.nodes, value)
+	return nil
+}
+
+func (p *txProofNodes) Get(key []byte) ([]byte, error) {
+	for _, node := range p.nodes {
+		if bytes.Equal(crypto.Keccak256(node), key) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("Proof node %v does not exist", common.Bytes2Hex(key))
+}
+
+func (p *txProofNodes) Has(key []byte) (bool, error) {
+	_, err := p.Get(key)
+	return err == nil, nil
+}
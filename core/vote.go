@@ -76,6 +76,55 @@ type Vote struct {
 	Epoch     uint64         // Voter's current epoch. It doesn't need to equal the epoch in the block above.
 	ID        common.Address // Voter's address.
 	Signature *crypto.Signature
+	Timestamp *VoteTimestamp `json:",omitempty"` // Optional, not covered by the vote signature.
+}
+
+// VoteTimestamp is the time at which a vote was cast, signed by the voter. It is metadata for the
+// latency analytics only: the consensus rules ignore it, and a vote with an invalid timestamp is
+// still a valid vote.
+type VoteTimestamp struct {
+	Time      uint64 // Unix time in milliseconds
+	Signature *crypto.Signature
+}
+
+// rlpVote is the encoding of a Vote. The timestamp trails the other fields only if set, so that
+// the encoding of the votes without timestamp is unchanged.
+type rlpVote struct {
+	Block     common.Hash
+	Height    uint64
+	Epoch     uint64
+	ID        common.Address
+	Signature *crypto.Signature
+	Timestamp []VoteTimestamp `rlp:"tail"` // At most one timestamp
+}
+
+var _ rlp.Encoder = Vote{}
+
+// EncodeRLP implements RLP Encoder interface.
+func (v Vote) EncodeRLP(w io.Writer) error {
+	enc := rlpVote{Block: v.Block, Height: v.Height, Epoch: v.Epoch, ID: v.ID, Signature: v.Signature}
+	if v.Timestamp != nil {
+		enc.Timestamp = []VoteTimestamp{*v.Timestamp}
+	}
+	return rlp.Encode(w, enc)
+}
+
+var _ rlp.Decoder = (*Vote)(nil)
+
+// DecodeRLP implements RLP Decoder interface.
+func (v *Vote) DecodeRLP(stream *rlp.Stream) error {
+	var dec rlpVote
+	if err := stream.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.Timestamp) > 1 {
+		return fmt.Errorf("Vote has %v timestamps", len(dec.Timestamp))
+	}
+	*v = Vote{Block: dec.Block, Height: dec.Height, Epoch: dec.Epoch, ID: dec.ID, Signature: dec.Signature}
+	if len(dec.Timestamp) == 1 {
+		v.Timestamp = &dec.Timestamp[0]
+	}
+	return nil
 }
 
 func (v Vote) String() string {
@@ -108,6 +157,38 @@ func (v *Vote) SetSignature(sig *crypto.Signature) {
 	v.Signature = sig
 }
 
+// TimestampSignBytes returns raw bytes to be signed to timestamp the vote at the given time. They
+// are prefixed so that they can not be mistaken for the sign bytes of a vote.
+func (v Vote) TimestampSignBytes(time uint64) common.Bytes {
+	raw, _ := rlp.EncodeToBytes([]interface{}{"vote_timestamp", v.Block, v.Epoch, v.ID, time})
+	return raw
+}
+
+// SignTimestamp timestamps the vote with the given Unix time in milliseconds, using given private
+// key.
+func (v *Vote) SignTimestamp(priv *crypto.PrivateKey, time uint64) {
+	sig, err := priv.Sign(v.TimestampSignBytes(time))
+	if err != nil {
+		// Should not happen.
+		logger.WithFields(log.Fields{"error": err}).Panic("Failed to sign vote timestamp")
+	}
+	v.Timestamp = &VoteTimestamp{Time: time, Signature: sig}
+}
+
+// ValidateTimestamp checks the vote has a timestamp signed by the voter.
+func (v Vote) ValidateTimestamp() result.Result {
+	if v.Timestamp == nil {
+		return result.Error("Vote is not timestamped")
+	}
+	if v.Timestamp.Signature == nil || v.Timestamp.Signature.IsEmpty() {
+		return result.Error("Vote timestamp is not signed")
+	}
+	if !v.Timestamp.Signature.Verify(v.TimestampSignBytes(v.Timestamp.Time), v.ID) {
+		return result.Error("Vote timestamp signature verification failed")
+	}
+	return result.OK
+}
+
 // Validate checks the vote is legitimate.
 func (v Vote) Validate() result.Result {
 	if v.Block.IsEmpty() {
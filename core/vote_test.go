@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/rlp"
@@ -232,3 +233,58 @@ func TestCommitCertificate(t *testing.T) {
 	cc = CommitCertificate{Votes: invalidVoteSet, BlockHash: blockHash}
 	assert.False(cc.IsValid(vs))
 }
+
+func TestVoteTimestamp(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	privKey, _, _ := crypto.GenerateKeyPair()
+	v1 := Vote{
+		Block:  CreateTestBlock("", "").Hash(),
+		Height: 3,
+		ID:     privKey.PublicKey().Address(),
+		Epoch:  5,
+	}
+	v1.Sign(privKey)
+
+	// The encoding of a vote without timestamp is unchanged
+	type legacyVote struct {
+		Block     common.Hash
+		Height    uint64
+		Epoch     uint64
+		ID        common.Address
+		Signature *crypto.Signature
+	}
+	legacy, err := rlp.EncodeToBytes(legacyVote{v1.Block, v1.Height, v1.Epoch, v1.ID, v1.Signature})
+	require.Nil(err)
+	b, err := rlp.EncodeToBytes(v1)
+	require.Nil(err)
+	assert.Equal(legacy, b)
+	assert.True(v1.ValidateTimestamp().IsError())
+
+	// The timestamp is carried along, and not covered by the vote signature
+	v1.SignTimestamp(privKey, 1600000000123)
+	b, err = rlp.EncodeToBytes(v1)
+	require.Nil(err)
+	v2 := Vote{}
+	require.Nil(rlp.DecodeBytes(b, &v2))
+	require.NotNil(v2.Timestamp)
+	assert.Equal(uint64(1600000000123), v2.Timestamp.Time)
+	assert.True(v2.Validate().IsOK())
+	assert.True(v2.ValidateTimestamp().IsOK())
+
+	// A tampered timestamp does not invalidate the vote
+	v2.Timestamp.Time++
+	assert.True(v2.Validate().IsOK())
+	assert.True(v2.ValidateTimestamp().IsError())
+
+	// The timestamps are kept in the vote sets
+	vs := NewVoteSet()
+	vs.AddVote(v1)
+	b, err = rlp.EncodeToBytes(vs)
+	require.Nil(err)
+	vs2 := NewVoteSet()
+	require.Nil(rlp.DecodeBytes(b, vs2))
+	require.Equal(1, vs2.Size())
+	assert.True(vs2.Votes()[0].ValidateTimestamp().IsOK())
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"io"
 	"math/big"
 
@@ -71,6 +72,23 @@ func (sk *PrivateKey) Sign(msg common.Bytes) (*Signature, error) {
 	return sig, err
 }
 
+// SharedSecret returns the ECDH shared secret of the private key and the public key of the other
+// party, i.e. the X coordinate of the product of the public key by the private key.
+func (sk *PrivateKey) SharedSecret(pk *PublicKey) (common.Bytes, error) {
+	curve := sk.privKey.Curve
+	if pk == nil || pk.pubKey == nil || !curve.IsOnCurve(pk.pubKey.X, pk.pubKey.Y) {
+		return nil, errors.New("Invalid public key for the shared secret")
+	}
+	x, _ := curve.ScalarMult(pk.pubKey.X, pk.pubKey.Y, sk.privKey.D.Bytes())
+	if x == nil || x.Sign() == 0 {
+		return nil, errors.New("Invalid shared secret")
+	}
+	secret := make([]byte, (curve.Params().BitSize+7)/8)
+	xBytes := x.Bytes()
+	copy(secret[len(secret)-len(xBytes):], xBytes)
+	return secret, nil
+}
+
 //
 // PublicKey represents the public key
 //
@@ -245,6 +263,10 @@ func (sig *Signature) Verify(msg common.Bytes, addr common.Address) bool {
 	if sig == nil || sig.IsEmpty() {
 		return false
 	}
+	cacheKey := signatureCacheKey(msg, sig, addr)
+	if sigCache.contains(cacheKey) {
+		return true
+	}
 	recoveredAddress, err := sig.RecoverSignerAddress(msg)
 	if err != nil {
 		return false
@@ -252,6 +274,7 @@ func (sig *Signature) Verify(msg common.Bytes, addr common.Address) bool {
 	if recoveredAddress != addr {
 		return false
 	}
+	sigCache.add(cacheKey)
 	return true
 }
 
@@ -76,6 +76,28 @@ func TestKeyBasics(t *testing.T) {
 	assert.Equal(65, len(seededPubKey2.ToBytes()))
 }
 
+func TestSharedSecret(t *testing.T) {
+	assert := assert.New(t)
+
+	privKeyA, pubKeyA, err := GenerateKeyPair()
+	assert.Nil(err)
+	privKeyB, pubKeyB, err := GenerateKeyPair()
+	assert.Nil(err)
+	_, pubKeyC, err := GenerateKeyPair()
+	assert.Nil(err)
+
+	secretAB, err := privKeyA.SharedSecret(pubKeyB)
+	assert.Nil(err)
+	secretBA, err := privKeyB.SharedSecret(pubKeyA)
+	assert.Nil(err)
+	assert.Equal(32, len(secretAB))
+	assert.Equal(secretAB, secretBA)
+
+	secretAC, err := privKeyA.SharedSecret(pubKeyC)
+	assert.Nil(err)
+	assert.NotEqual(secretAB, secretAC)
+}
+
 func TestToAndFromBytes(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+)
+
+// SignatureCacheSize is the max number of verified signatures kept in the cache
+const SignatureCacheSize = 16384
+
+var (
+	sigCacheHitCounter   = metrics.NewRegisteredCounter("crypto/sigcache/hits", nil)
+	sigCacheMissCounter  = metrics.NewRegisteredCounter("crypto/sigcache/misses", nil)
+	sigCacheHitRateGauge = metrics.NewRegisteredGaugeFloat64("crypto/sigcache/hitrate", nil)
+)
+
+// sigCache is shared by all the signature verifications, so that a signature seen in different
+// places, e.g. a transaction received through gossip and later included in a block, or a vote
+// relayed by several peers, is only verified once.
+var sigCache = newSignatureCache(SignatureCacheSize)
+
+// SignatureCacheStats returns the number of cache hits and misses of the signature verifications
+func SignatureCacheStats() (hits uint64, misses uint64) {
+	return sigCache.stats()
+}
+
+//
+// signatureCache is an LRU set of signatures that have been verified successfully. An entry
+// is keyed by the hash of (signer address, message hash, signature), only valid signatures
+// are added, so a hit means the signature is known to be valid.
+//
+type signatureCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[common.Hash]*list.Element
+	lru      *list.List // front is the most recently used
+
+	hits   uint64
+	misses uint64
+}
+
+func newSignatureCache(capacity int) *signatureCache {
+	return &signatureCache{
+		capacity: capacity,
+		entries:  make(map[common.Hash]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func signatureCacheKey(msg common.Bytes, sig *Signature, addr common.Address) common.Hash {
+	return keccak256Hash(addr[:], keccak256(msg), sig.ToBytes())
+}
+
+// contains returns whether the signature has been verified before, and updates the hit rate
+func (c *signatureCache) contains(key common.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(elem)
+		c.hits++
+		sigCacheHitCounter.Inc(1)
+	} else {
+		c.misses++
+		sigCacheMissCounter.Inc(1)
+	}
+	sigCacheHitRateGauge.Update(float64(c.hits) / float64(c.hits+c.misses))
+	return ok
+}
+
+// add records a successfully verified signature, evicting the least recently used one if full
+func (c *signatureCache) add(key common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(key)
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(common.Hash))
+	}
+}
+
+func (c *signatureCache) stats() (uint64, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
@@ -0,0 +1,54 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestSignatureCacheVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	privKey, pubKey, err := GenerateKeyPair()
+	assert.Nil(err)
+	msg := common.Bytes("Hello world!")
+	sig, err := privKey.Sign(msg)
+	assert.Nil(err)
+
+	hits, misses := SignatureCacheStats()
+	assert.True(sig.Verify(msg, pubKey.Address()))
+	assert.True(sig.Verify(msg, pubKey.Address()))
+	newHits, newMisses := SignatureCacheStats()
+	assert.Equal(hits+1, newHits)
+	assert.Equal(misses+1, newMisses)
+
+	// Invalid signatures are never cached
+	_, otherPubKey, err := GenerateKeyPair()
+	assert.Nil(err)
+	assert.False(sig.Verify(msg, otherPubKey.Address()))
+	assert.False(sig.Verify(msg, otherPubKey.Address()))
+	assert.False(sig.Verify(common.Bytes("Hello world?"), pubKey.Address()))
+}
+
+func TestSignatureCacheEviction(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := newSignatureCache(2)
+	k1 := common.BytesToHash([]byte{1})
+	k2 := common.BytesToHash([]byte{2})
+	k3 := common.BytesToHash([]byte{3})
+
+	cache.add(k1)
+	cache.add(k2)
+	assert.True(cache.contains(k1)) // k2 becomes the least recently used
+	cache.add(k3)
+
+	assert.True(cache.contains(k1))
+	assert.False(cache.contains(k2))
+	assert.True(cache.contains(k3))
+
+	hits, misses := cache.stats()
+	assert.Equal(uint64(3), hits)
+	assert.Equal(uint64(1), misses)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"sync"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/util"
 	"github.com/thetatoken/theta/p2p"
 	p2ptypes "github.com/thetatoken/theta/p2p/types"
 )
@@ -14,6 +16,7 @@ import (
 //
 type Dispatcher struct {
 	p2pnet p2p.Network
+	logger *log.Entry
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -27,6 +30,7 @@ type Dispatcher struct {
 func NewDispatcher(p2pnet p2p.Network) *Dispatcher {
 	return &Dispatcher{
 		p2pnet: p2pnet,
+		logger: util.GetLoggerForModule("dispatcher"),
 		wg:     &sync.WaitGroup{},
 	}
 }
@@ -38,6 +42,9 @@ func (dp *Dispatcher) Start(ctx context.Context) error {
 	dp.cancel = cancel
 
 	err := dp.p2pnet.Start(c)
+	if err != nil {
+		dp.logger.WithFields(log.Fields{"error": err}).Error("Failed to start the p2p network")
+	}
 	return err
 }
 
@@ -72,6 +79,29 @@ func (dp *Dispatcher) SendData(peerIDs []string, datarsp DataResponse) {
 	dp.send(peerIDs, datarsp.ChannelID, datarsp)
 }
 
+// GetSnapshotChunk sends out the SnapshotChunkRequest
+func (dp *Dispatcher) GetSnapshotChunk(peerIDs []string, req SnapshotChunkRequest) {
+	dp.send(peerIDs, common.ChannelIDSnapshotRequest, req)
+}
+
+// SendSnapshotChunk sends out the SnapshotChunkResponse
+func (dp *Dispatcher) SendSnapshotChunk(peerIDs []string, resp SnapshotChunkResponse) {
+	dp.send(peerIDs, common.ChannelIDSnapshotResponse, resp)
+}
+
+// PeerStats returns the per-channel traffic stats of the connected peers
+func (dp *Dispatcher) PeerStats() []p2ptypes.PeerStats {
+	return dp.p2pnet.PeerStats()
+}
+
+// ReportPeer reports a behavior of the peer to the network, if the network keeps track of the
+// reputation of the peers
+func (dp *Dispatcher) ReportPeer(peerID string, event p2ptypes.PeerEvent) {
+	if reporter, ok := dp.p2pnet.(p2p.PeerReporter); ok {
+		reporter.ReportPeer(peerID, event)
+	}
+}
+
 func (dp *Dispatcher) send(peerIDs []string, channelID common.ChannelIDEnum, content interface{}) {
 	message := p2ptypes.Message{
 		ChannelID: channelID,
@@ -31,3 +31,23 @@ type DataResponse struct {
 	ChannelID common.ChannelIDEnum
 	Payload   common.Bytes
 }
+
+// SnapshotChunkRequest defines the structure of the request for a chunk of the snapshot whose
+// last finalized block is the checkpoint. If Base is set, the request is for a chunk of the state
+// diff from the snapshot whose last finalized block is Base[0] to the checkpoint, instead of the
+// full snapshot.
+type SnapshotChunkRequest struct {
+	Checkpoint common.Hash
+	Index      uint64
+	Base       []common.Hash `rlp:"tail"` // At most one
+}
+
+// SnapshotChunkResponse defines the structure of the response to a SnapshotChunkRequest. The
+// size is the total size of the snapshot, 0 if the snapshot is not available.
+type SnapshotChunkResponse struct {
+	Checkpoint common.Hash
+	Index      uint64
+	Size       uint64
+	Data       common.Bytes
+	Base       []common.Hash `rlp:"tail"` // At most one
+}
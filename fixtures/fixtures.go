@@ -0,0 +1,305 @@
+// Package fixtures generates small deterministic chains and state snapshots for tests. The same
+// Config always produces the same blocks, transactions and state hashes, since the keys, the
+// transaction mix and the block timestamps are all derived from it.
+//
+// The package builds on the ledger and the consensus engines, so the tests of those packages
+// can only use it from an external test package, e.g. package ledger_test.
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"time"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/consensus"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger"
+	"github.com/thetatoken/theta/ledger/canonical"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	mp "github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+// TxKind is a kind of transaction the generator can put in the blocks
+type TxKind int
+
+const (
+	// TxSend transfers TFuel between two accounts
+	TxSend TxKind = iota
+
+	// TxReserveFund reserves a TFuel fund for off-chain micropayments
+	TxReserveFund
+)
+
+// txKinds lists the kinds in a fixed order, so that the weighted choice is deterministic
+var txKinds = []TxKind{TxSend, TxReserveFund}
+
+// Config specifies the chain to generate
+type Config struct {
+	ChainID       string
+	NumBlocks     int
+	NumAccounts   int
+	TxsPerBlock   int
+	TxMix         map[TxKind]int // relative weights of the transaction kinds
+	Seed          int64          // seed of the choice of the senders, recipients and transaction kinds
+	StartTime     time.Time      // timestamp of the genesis block
+	BlockInterval time.Duration
+}
+
+// DefaultConfig returns the config of a short chain with a mix of send and reserve fund
+// transactions.
+func DefaultConfig() Config {
+	return Config{
+		ChainID:       "fixture_chain",
+		NumBlocks:     8,
+		NumAccounts:   4,
+		TxsPerBlock:   4,
+		TxMix:         map[TxKind]int{TxSend: 3, TxReserveFund: 1},
+		Seed:          1,
+		StartTime:     time.Unix(1546300800, 0),
+		BlockInterval: 6 * time.Second,
+	}
+}
+
+// Fixture is a generated chain along with the ledger holding its states.
+type Fixture struct {
+	Config Config
+
+	DB     database.Database
+	Chain  *blockchain.Chain
+	Ledger *ledger.Ledger
+
+	// Validator is the only validator, proposing all the blocks
+	Validator types.PrivAccount
+
+	// Accounts are the funded accounts sending the transactions
+	Accounts []types.PrivAccount
+
+	// Blocks are the finalized blocks indexed by height, starting from the genesis block
+	Blocks []*core.ExtendedBlock
+
+	engine    *consensus.SoloEngine
+	mempool   *mp.Mempool
+	rnd       *rand.Rand
+	sequences []uint64
+	reserved  []int
+	numTxs    int
+}
+
+// Generate generates the chain specified by the config.
+func Generate(config Config) (*Fixture, error) {
+	if config.NumAccounts < 2 {
+		return nil, fmt.Errorf("At least 2 accounts are required, got %v", config.NumAccounts)
+	}
+	if config.TxsPerBlock > core.MaxNumRegularTxsPerBlock {
+		return nil, fmt.Errorf("At most %v transactions fit in a block, got %v", core.MaxNumRegularTxsPerBlock, config.TxsPerBlock)
+	}
+
+	f := &Fixture{
+		Config:    config,
+		DB:        backend.NewMemDatabase(),
+		Validator: types.MakeAcc("fixture_validator"),
+		rnd:       rand.New(rand.NewSource(config.Seed)),
+		sequences: make([]uint64, config.NumAccounts),
+		reserved:  make([]int, config.NumAccounts),
+	}
+	for i := 0; i < config.NumAccounts; i++ {
+		f.Accounts = append(f.Accounts, types.MakeAccWithInitBalance(fmt.Sprintf("fixture_account%v", i), initBalance()))
+	}
+
+	genesis := f.genesisBlock()
+	store := kvstore.NewKVStore(f.DB)
+	f.Chain = blockchain.NewChain(config.ChainID, store, genesis)
+
+	valMgr := consensus.NewFixedValidatorManager()
+	f.engine = consensus.NewSoloEngine(f.Validator.PrivKey, store, f.Chain, 0)
+	valMgr.SetConsensusEngine(f.engine)
+	f.mempool = mp.CreateMempool(nil) // never started, the transactions are not broadcast
+	f.Ledger = ledger.NewLedger(config.ChainID, f.DB, f.Chain, f.engine, valMgr, f.mempool)
+	f.engine.SetLedger(f.Ledger)
+	f.mempool.SetLedger(f.Ledger)
+
+	now := config.StartTime
+	f.engine.SetClock(func() time.Time { return now })
+	f.engine.Start(context.Background())
+	defer f.engine.Stop()
+
+	f.Blocks = []*core.ExtendedBlock{f.Chain.Root()}
+	for i := 0; i < config.NumBlocks; i++ {
+		now = now.Add(config.BlockInterval)
+		if err := f.insertTxs(); err != nil {
+			return nil, err
+		}
+		block, err := f.engine.ProduceBlock()
+		if err != nil {
+			return nil, err
+		}
+		if len(block.Txs) != f.numTxs+1 { // plus the coinbase transaction
+			return nil, fmt.Errorf("Block %v has %v transactions out of %v generated", block.Height, len(block.Txs)-1, f.numTxs)
+		}
+		f.Blocks = append(f.Blocks, block)
+	}
+
+	return f, nil
+}
+
+func initBalance() types.Coins {
+	return types.Coins{
+		ThetaWei: new(big.Int).Mul(new(big.Int).SetUint64(10), core.MinValidatorStakeDeposit),
+		TFuelWei: new(big.Int).Mul(new(big.Int).SetUint64(10), core.MinValidatorStakeDeposit),
+	}
+}
+
+// genesisBlock saves the genesis state, with the validator staked and the accounts funded, and
+// returns the root block of the chain.
+func (f *Fixture) genesisBlock() *core.Block {
+	vcp := &core.ValidatorCandidatePool{}
+	vcp.DepositStake(f.Validator.Address, f.Validator.Address, core.MinValidatorStakeDeposit)
+
+	sv := state.NewStoreView(0, common.Hash{}, f.DB)
+	sv.UpdateValidatorCandidatePool(vcp)
+	sv.SetAccount(f.Validator.Address, &f.Validator.Account)
+	for i := range f.Accounts {
+		sv.SetAccount(f.Accounts[i].Address, &f.Accounts[i].Account)
+	}
+
+	block := core.NewBlock()
+	block.ChainID = f.Config.ChainID
+	block.Timestamp = big.NewInt(f.Config.StartTime.Unix())
+	block.StateHash = sv.Save()
+	return block
+}
+
+// insertTxs inserts the transactions of the next block into the mempool.
+func (f *Fixture) insertTxs() error {
+	f.numTxs = 0
+	for i := 0; i < f.Config.TxsPerBlock; i++ {
+		from := f.rnd.Intn(len(f.Accounts))
+		to := (from + 1 + f.rnd.Intn(len(f.Accounts)-1)) % len(f.Accounts)
+
+		kind := f.pickKind()
+		if kind == TxReserveFund && f.reserved[from] >= types.MaximumActiveReservedFundsPerAccount {
+			kind = TxSend
+		}
+
+		var tx types.Tx
+		switch kind {
+		case TxReserveFund:
+			tx = f.newReserveFundTx(from)
+		default:
+			tx = f.newSendTx(from, to)
+		}
+
+		rawTx, err := types.TxToBytes(tx)
+		if err != nil {
+			return err
+		}
+		if err := f.mempool.InsertTransaction(rawTx); err != nil {
+			return fmt.Errorf("Failed to insert generated transaction %v: %v", tx, err)
+		}
+		f.numTxs++
+	}
+	return nil
+}
+
+func (f *Fixture) pickKind() TxKind {
+	total := 0
+	for _, kind := range txKinds {
+		total += f.Config.TxMix[kind]
+	}
+	if total == 0 {
+		return TxSend
+	}
+	r := f.rnd.Intn(total)
+	for _, kind := range txKinds {
+		if r < f.Config.TxMix[kind] {
+			return kind
+		}
+		r -= f.Config.TxMix[kind]
+	}
+	return TxSend
+}
+
+func (f *Fixture) nextSequence(idx int) uint64 {
+	f.sequences[idx]++
+	return f.sequences[idx]
+}
+
+func (f *Fixture) newSendTx(from, to int) types.Tx {
+	fee := int64(types.MinimumTransactionFeeTFuelWei)
+	tx := &types.SendTx{
+		Fee: types.NewCoins(0, fee),
+		Inputs: []types.TxInput{
+			{
+				Address:  f.Accounts[from].Address,
+				Coins:    types.NewCoins(10, 1000+fee),
+				Sequence: f.nextSequence(from),
+			},
+		},
+		Outputs: []types.TxOutput{
+			{
+				Address: f.Accounts[to].Address,
+				Coins:   types.NewCoins(10, 1000),
+			},
+		},
+	}
+	sig := f.Accounts[from].Sign(tx.SignBytes(f.Config.ChainID))
+	tx.SetSignature(f.Accounts[from].Address, sig)
+	return tx
+}
+
+func (f *Fixture) newReserveFundTx(from int) types.Tx {
+	sequence := f.nextSequence(from)
+	tx := &types.ReserveFundTx{
+		Fee: types.NewCoins(0, int64(types.MinimumTransactionFeeTFuelWei)),
+		Source: types.TxInput{
+			Address:  f.Accounts[from].Address,
+			Coins:    types.NewCoins(0, 1000),
+			Sequence: sequence,
+		},
+		Collateral:  types.NewCoins(0, 2000),
+		ResourceIDs: []string{types.NewResourceID([]byte(fmt.Sprintf("fixture_resource_%v_%v", from, sequence)))},
+		Duration:    types.MinimumFundReserveDuration,
+	}
+	sig := f.Accounts[from].Sign(tx.SignBytes(f.Config.ChainID))
+	tx.SetSignature(f.Accounts[from].Address, sig)
+	f.reserved[from]++
+	return tx
+}
+
+// Tip returns the last block of the chain.
+func (f *Fixture) Tip() *core.ExtendedBlock {
+	return f.Blocks[len(f.Blocks)-1]
+}
+
+// StateAt returns a view of the state after the block at the given height.
+func (f *Fixture) StateAt(height uint64) (*state.StoreView, error) {
+	if height >= uint64(len(f.Blocks)) {
+		return nil, fmt.Errorf("Height %v is beyond the tip %v", height, f.Tip().Height)
+	}
+	return state.NewStoreView(height, f.Blocks[height].StateHash, f.DB), nil
+}
+
+// ExportState writes the canonical export of the state after the block at the given height.
+func (f *Fixture) ExportState(height uint64, w io.Writer) (*canonical.Footer, error) {
+	sv, err := f.StateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return canonical.Export(sv, w)
+}
+
+// ExportChain writes the blocks in the given height range to a chain archive, which can be
+// imported into another chain with the same genesis block.
+func (f *Fixture) ExportChain(startHeight, endHeight uint64, filePath string) (*snapshot.ChainArchiveHeader, error) {
+	return snapshot.ExportChainArchive(f.Chain, startHeight, endHeight, filePath)
+}
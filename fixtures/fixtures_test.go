@@ -0,0 +1,94 @@
+package fixtures
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	require := require.New(t)
+
+	config := DefaultConfig()
+	f1, err := Generate(config)
+	require.Nil(err)
+	f2, err := Generate(config)
+	require.Nil(err)
+
+	require.Equal(config.NumBlocks+1, len(f1.Blocks))
+	for height := range f1.Blocks {
+		require.Equal(f1.Blocks[height].Hash(), f2.Blocks[height].Hash())
+		require.Equal(f1.Blocks[height].StateHash, f2.Blocks[height].StateHash)
+	}
+	require.Equal(config.TxsPerBlock+1, len(f1.Tip().Txs))
+
+	var buf1, buf2 bytes.Buffer
+	footer1, err := f1.ExportState(f1.Tip().Height, &buf1)
+	require.Nil(err)
+	footer2, err := f2.ExportState(f2.Tip().Height, &buf2)
+	require.Nil(err)
+	require.Equal(footer1, footer2)
+	require.Equal(buf1.Bytes(), buf2.Bytes())
+
+	config.Seed++
+	f3, err := Generate(config)
+	require.Nil(err)
+	require.NotEqual(f1.Tip().StateHash, f3.Tip().StateHash)
+}
+
+func TestGenerateState(t *testing.T) {
+	require := require.New(t)
+
+	config := DefaultConfig()
+	config.NumBlocks = 4 // stays within the limit of active reserved funds per account
+	config.TxMix = map[TxKind]int{TxReserveFund: 1}
+	f, err := Generate(config)
+	require.Nil(err)
+
+	genesis, err := f.StateAt(0)
+	require.Nil(err)
+	tip, err := f.StateAt(f.Tip().Height)
+	require.Nil(err)
+
+	numReserved := 0
+	for _, acc := range f.Accounts {
+		require.Equal(0, len(genesis.GetAccount(acc.Address).ReservedFunds))
+		numReserved += len(tip.GetAccount(acc.Address).ReservedFunds)
+	}
+	require.Equal(config.NumBlocks*config.TxsPerBlock, numReserved)
+
+	_, err = f.StateAt(f.Tip().Height + 1)
+	require.NotNil(err)
+}
+
+func TestExportChain(t *testing.T) {
+	require := require.New(t)
+
+	f, err := Generate(DefaultConfig())
+	require.Nil(err)
+
+	dir, err := ioutil.TempDir("", "fixtures")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	file := path.Join(dir, "chain.archive")
+	_, err = f.ExportChain(1, f.Tip().Height, file)
+	require.Nil(err)
+
+	chain := blockchain.NewChain(f.Config.ChainID, kvstore.NewKVStore(backend.NewMemDatabase()), f.Blocks[0].Block)
+	_, numBlocks, err := snapshot.ImportChainArchive(chain, file)
+	require.Nil(err)
+	require.Equal(f.Config.NumBlocks, numBlocks)
+
+	block, err := chain.FindBlock(f.Tip().Hash())
+	require.Nil(err)
+	require.Equal(f.Tip().StateHash, block.StateHash)
+}
@@ -0,0 +1,132 @@
+package fixtures
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// The transactions below have fixed field values, so that their sign bytes and encodings can be
+// compared against golden values. Only the addresses of the signers vary, since the tests either
+// sign them with their own keys or use the fixed addresses returned by Address.
+
+// PrivatenetSenderKey is the key of the sender of PrivatenetSendTx, which the wallets use to
+// check their own encoding.
+const PrivatenetSenderKey = "93a90ea508331dfdf27fb79757d4250b4e84954927ba0073cd67454ac432c737"
+
+// Address returns the address spelling the given name in its leading bytes, e.g. "input1".
+func Address(name string) common.Address {
+	var address common.Address
+	copy(address[:], name)
+	return address
+}
+
+// CoinbaseTx returns the coinbase transaction of the block at height 10, paying 333 and 444
+// ThetaWei to the outputs.
+func CoinbaseTx(proposer, output1, output2 common.Address) *types.CoinbaseTx {
+	return &types.CoinbaseTx{
+		Proposer: types.NewTxInput(proposer, types.NewCoins(0, 0), 1),
+		Outputs: []types.TxOutput{
+			{Address: output1, Coins: types.NewCoins(333, 0)},
+			{Address: output2, Coins: types.NewCoins(444, 0)},
+		},
+		BlockHeight: 10,
+	}
+}
+
+// SlashTx returns the transaction slashing the given address for overspending its reserved fund.
+func SlashTx(proposer, slashed common.Address) *types.SlashTx {
+	return &types.SlashTx{
+		Proposer:        types.NewTxInput(proposer, types.NewCoins(0, 0), 1),
+		SlashedAddress:  slashed,
+		ReserveSequence: 1,
+		SlashProof:      []byte("2345ABC"),
+	}
+}
+
+// SendTx returns a send transaction of ThetaWei from two inputs to two outputs.
+func SendTx(input1, input2, output1, output2 common.Address) *types.SendTx {
+	return &types.SendTx{
+		Fee: types.NewCoins(111, 0),
+		Inputs: []types.TxInput{
+			types.NewTxInput(input1, types.NewCoins(12345, 0), 67890),
+			types.NewTxInput(input2, types.NewCoins(111, 0), 222),
+		},
+		Outputs: []types.TxOutput{
+			{Address: output1, Coins: types.NewCoins(333, 0)},
+			{Address: output2, Coins: types.NewCoins(444, 0)},
+		},
+	}
+}
+
+// PrivatenetSendTx returns the send transaction of 10 Theta and 20 TFuel on the privatenet,
+// signed by the wallets with PrivatenetSenderKey.
+func PrivatenetSendTx() *types.SendTx {
+	ten18 := new(big.Int).SetUint64(1000000000000000000) // 10^18
+	thetaWei := new(big.Int).Mul(new(big.Int).SetUint64(10), ten18)
+	tfuelWei := new(big.Int).Mul(new(big.Int).SetUint64(20), ten18)
+	feeInTFuelWei := new(big.Int).SetUint64(1000000000000) // 10^12
+
+	return &types.SendTx{
+		Fee: types.Coins{ThetaWei: big.NewInt(0), TFuelWei: feeInTFuelWei},
+		Inputs: []types.TxInput{
+			{
+				Address:  common.HexToAddress("2E833968E5bB786Ae419c4d13189fB081Cc43bab"),
+				Coins:    types.Coins{ThetaWei: thetaWei, TFuelWei: new(big.Int).Add(tfuelWei, feeInTFuelWei)},
+				Sequence: 2,
+			},
+		},
+		Outputs: []types.TxOutput{
+			{
+				Address: common.HexToAddress("9F1233798E905E173560071255140b4A8aBd3Ec6"),
+				Coins:   types.Coins{ThetaWei: thetaWei, TFuelWei: tfuelWei},
+			},
+		},
+	}
+}
+
+// ReserveFundTx returns the transaction reserving a fund of the source for resource rid00123.
+func ReserveFundTx(source common.Address) *types.ReserveFundTx {
+	return &types.ReserveFundTx{
+		Fee:         types.NewCoins(0, 111),
+		Source:      types.NewTxInput(source, types.NewCoins(0, 12345), 67890),
+		Collateral:  types.NewCoins(0, 22897),
+		ResourceIDs: []string{"rid00123"},
+		Duration:    uint64(999),
+	}
+}
+
+// ReleaseFundTx returns the transaction releasing the fund the source reserved at sequence 12.
+func ReleaseFundTx(source common.Address) *types.ReleaseFundTx {
+	return &types.ReleaseFundTx{
+		Fee:             types.NewCoins(0, 111),
+		Source:          types.NewTxInput(source, types.NewCoins(0, 12345), 67890),
+		ReserveSequence: 12,
+	}
+}
+
+// ServicePaymentTx returns the third payment from the fund the source reserved at sequence 12
+// to the target.
+func ServicePaymentTx(source, target common.Address) *types.ServicePaymentTx {
+	return &types.ServicePaymentTx{
+		Fee:             types.NewCoins(0, 111),
+		Source:          types.NewTxInput(source, types.NewCoins(0, 12345), 67890),
+		Target:          types.NewTxInput(target, types.NewCoins(0, 0), 22341),
+		PaymentSequence: 3,
+		ReserveSequence: 12,
+		ResourceID:      "rid00123",
+	}
+}
+
+// SplitRuleTx returns the transaction splitting 30% of the payments for resource rid00123 to
+// the given address.
+func SplitRuleTx(initiator, split common.Address) *types.SplitRuleTx {
+	return &types.SplitRuleTx{
+		Fee:        types.NewCoins(0, 111),
+		ResourceID: "rid00123",
+		Initiator:  types.NewTxInput(initiator, types.NewCoins(0, 12345), 67890),
+		Splits:     []types.Split{{Address: split, Percentage: 30}},
+		Duration:   99,
+	}
+}
@@ -0,0 +1,301 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/ratelimit"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "guardian"})
+
+const (
+	// Votes are accepted for the blocks up to this many heights above the last finalized block
+	maxHeightsAhead = 128
+	// Votes are kept for the blocks up to this many heights below the last finalized block
+	retainedHeights = 256
+	// Max number of guardian votes kept for a block
+	maxVotesPerBlock = 4096
+	// Max number of guardian votes kept for all the blocks
+	maxVotesInPool = 65536
+	// Interval at which the height window of the pool follows the finalized block
+	windowUpdateInterval = 2 * time.Second
+)
+
+// Relayer gossips the accepted votes to the peers, see dispatcher.Dispatcher.
+type Relayer interface {
+	SendData(peerIDs []string, datarsp dispatcher.DataResponse)
+}
+
+// Membership tells whether an address is a guardian allowed to vote for a block.
+type Membership interface {
+	IsGuardian(guardian common.Address, block common.Hash) bool
+}
+
+// Config defines the limits of the guardian vote processing.
+type Config struct {
+	MessageQueueSize      int // max number of votes waiting to be processed
+	MaxVotesPerSecond     int // max number of votes accepted from all the peers, 0 means unlimited
+	MaxPeerVotesPerSecond int // max number of votes accepted from a single peer, 0 means unlimited
+}
+
+type incomingVote struct {
+	peerID string
+	vote   Vote
+}
+
+// Engine processes the guardian votes. The votes are received on their own channel, and are
+// queued and verified by the engine goroutine, so that however heavy the guardian traffic is, it
+// never delays the processing of the validator votes by the consensus engine. When the queue is
+// full, or a peer sends votes faster than allowed, the votes are dropped.
+type Engine struct {
+	finality   core.FinalityProvider
+	relayer    Relayer
+	membership Membership // nil rejects all the votes
+	config     Config
+	pool       *VotePool
+	incoming   chan incomingVote
+
+	mu           *sync.Mutex
+	limiter      *ratelimit.TokenBucket
+	peerLimiters map[string]*ratelimit.TokenBucket
+
+	now func() time.Time
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewEngine creates a new instance of Engine.
+func NewEngine(finality core.FinalityProvider, relayer Relayer, membership Membership, config Config) *Engine {
+	e := &Engine{
+		finality:     finality,
+		relayer:      relayer,
+		membership:   membership,
+		config:       config,
+		pool:         NewVotePool(maxVotesPerBlock, maxVotesInPool),
+		incoming:     make(chan incomingVote, config.MessageQueueSize),
+		mu:           &sync.Mutex{},
+		peerLimiters: make(map[string]*ratelimit.TokenBucket),
+		now:          time.Now,
+		wg:           &sync.WaitGroup{},
+	}
+	e.limiter = ratelimit.NewTokenBucket(float64(config.MaxVotesPerSecond), time.Second, e.now())
+	queueLengthGauge.Update(0)
+	return e
+}
+
+// Start creates the main goroutine.
+func (e *Engine) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	e.ctx = c
+	e.cancel = cancel
+
+	e.wg.Add(1)
+	go e.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (e *Engine) Stop() {
+	e.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (e *Engine) Wait() {
+	e.wg.Wait()
+}
+
+func (e *Engine) mainLoop() {
+	defer e.wg.Done()
+
+	e.updateHeightWindow()
+	ticker := time.NewTicker(windowUpdateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case iv := <-e.incoming:
+			queueLengthGauge.Update(int64(len(e.incoming)))
+			e.processVote(iv.peerID, iv.vote)
+		case <-ticker.C:
+			e.updateHeightWindow()
+			e.prunePeerLimiters()
+		}
+	}
+}
+
+// GetAggregatedVotes returns the guardian votes received for the given block.
+func (e *Engine) GetAggregatedVotes(block common.Hash) *AggregatedVotes {
+	return e.pool.Get(block)
+}
+
+// AddVote queues a vote created locally, e.g. by the guardian running the node. It is processed
+// and relayed as the votes received from the peers.
+func (e *Engine) AddVote(vote Vote) error {
+	if !e.enqueue("", vote) {
+		return errors.New("Guardian vote queue is full")
+	}
+	return nil
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface.
+func (e *Engine) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		common.ChannelIDGuardian,
+	}
+}
+
+// ParseMessage implements the p2p.MessageHandler interface.
+func (e *Engine) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	data := dispatcher.DataResponse{}
+	err := rlp.DecodeBytes(rawMessageBytes, &data)
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   data,
+	}, err
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface.
+func (e *Engine) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// HandleMessage implements the p2p.MessageHandler interface. It is called by the goroutine reading
+// the peer connection, so it only decodes the vote and queues it, without blocking.
+func (e *Engine) HandleMessage(msg p2ptypes.Message) error {
+	data, ok := msg.Content.(dispatcher.DataResponse)
+	if !ok || data.ChannelID != common.ChannelIDGuardian {
+		return errors.New("Invalid guardian vote message")
+	}
+	vote := Vote{}
+	if err := rlp.DecodeBytes(data.Payload, &vote); err != nil {
+		votesInvalidCounter.Inc(1)
+		return err
+	}
+	votesReceivedCounter.Inc(1)
+
+	if !e.allow(msg.PeerID) {
+		votesRateLimitedCounter.Inc(1)
+		return nil
+	}
+	if !e.enqueue(msg.PeerID, vote) {
+		logger.WithFields(log.Fields{"peer": msg.PeerID, "vote": vote}).Debug("Guardian vote queue is full, dropping vote")
+	}
+	return nil
+}
+
+func (e *Engine) enqueue(peerID string, vote Vote) bool {
+	select {
+	case e.incoming <- incomingVote{peerID: peerID, vote: vote}:
+		queueLengthGauge.Update(int64(len(e.incoming)))
+		return true
+	default:
+		votesQueueFullCounter.Inc(1)
+		return false
+	}
+}
+
+// allow applies the global and the per peer rate limits.
+func (e *Engine) allow(peerID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	peerLimiter, ok := e.peerLimiters[peerID]
+	if !ok {
+		peerLimiter = ratelimit.NewTokenBucket(float64(e.config.MaxPeerVotesPerSecond), time.Second, now)
+		e.peerLimiters[peerID] = peerLimiter
+	}
+	if !peerLimiter.Available(1, now) || !e.limiter.Available(1, now) {
+		return false
+	}
+	peerLimiter.Take(1)
+	e.limiter.Take(1)
+	return true
+}
+
+// prunePeerLimiters drops the limiters of the idle peers, they are recreated full anyway.
+func (e *Engine) prunePeerLimiters() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	for peerID, limiter := range e.peerLimiters {
+		if limiter.Full(now) {
+			delete(e.peerLimiters, peerID)
+		}
+	}
+}
+
+func (e *Engine) updateHeightWindow() {
+	lfb := e.finality.GetLastFinalizedBlock()
+	if lfb == nil {
+		return
+	}
+	minHeight := uint64(0)
+	if lfb.Height > retainedHeights {
+		minHeight = lfb.Height - retainedHeights
+	}
+	e.pool.SetHeightWindow(minHeight, lfb.Height+maxHeightsAhead)
+	blocksInPoolGauge.Update(int64(e.pool.NumBlocks()))
+}
+
+func (e *Engine) processVote(peerID string, vote Vote) {
+	start := e.now()
+	defer func() { voteProcessingTimer.UpdateSince(start) }()
+
+	if !e.pool.InWindow(vote.Height) {
+		votesOutOfWindowCounter.Inc(1)
+		return
+	}
+	if res := vote.Validate(); res.IsError() {
+		votesInvalidCounter.Inc(1)
+		logger.WithFields(log.Fields{"peer": peerID, "vote": vote, "error": res.Message}).Debug("Invalid guardian vote")
+		return
+	}
+	// Only the votes of the guardians are kept and relayed, so that the peers cannot flood the
+	// pool and the network with the votes of arbitrary keys
+	if e.membership == nil || !e.membership.IsGuardian(vote.Guardian, vote.Block) {
+		votesInvalidCounter.Inc(1)
+		logger.WithFields(log.Fields{"peer": peerID, "vote": vote}).Debug("Vote from a non-guardian")
+		return
+	}
+	if !e.pool.Add(vote) {
+		return // already known
+	}
+	votesAcceptedCounter.Inc(1)
+	blocksInPoolGauge.Update(int64(e.pool.NumBlocks()))
+
+	if err := e.relay(vote); err != nil {
+		logger.WithFields(log.Fields{"vote": vote, "error": err}).Warn("Failed to relay guardian vote")
+	}
+}
+
+func (e *Engine) relay(vote Vote) error {
+	if e.relayer == nil {
+		return nil
+	}
+	payload, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		return fmt.Errorf("Failed to encode vote: %v", err)
+	}
+	e.relayer.SendData([]string{}, dispatcher.DataResponse{
+		ChannelID: common.ChannelIDGuardian,
+		Payload:   payload,
+	})
+	return nil
+}
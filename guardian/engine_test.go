@@ -0,0 +1,254 @@
+package guardian
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/ratelimit"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+type testFinality struct {
+	core.FinalityProvider
+	lfb *core.ExtendedBlock
+}
+
+func (c *testFinality) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return c.lfb
+}
+
+type testRelayer struct {
+	relayed []Vote
+}
+
+func (r *testRelayer) SendData(peerIDs []string, datarsp dispatcher.DataResponse) {
+	vote := Vote{}
+	if err := rlp.DecodeBytes(datarsp.Payload, &vote); err == nil {
+		r.relayed = append(r.relayed, vote)
+	}
+}
+
+type testMembership struct {
+	guardians map[common.Address]bool
+}
+
+func (m *testMembership) IsGuardian(guardian common.Address, block common.Hash) bool {
+	return m.guardians[guardian]
+}
+
+// newTestGuardians returns the membership of the guardians of the given seeds, see newTestVote.
+func newTestGuardians(t *testing.T, seeds ...string) *testMembership {
+	membership := &testMembership{guardians: make(map[common.Address]bool)}
+	for _, seed := range seeds {
+		privKey, _, err := crypto.TEST_GenerateKeyPairWithSeed(seed)
+		require.Nil(t, err)
+		membership.guardians[privKey.PublicKey().Address()] = true
+	}
+	return membership
+}
+
+func newTestEngine(config Config, membership Membership) (*Engine, *testRelayer) {
+	lfb := &core.ExtendedBlock{Block: core.NewBlock()}
+	lfb.Height = 1000
+	relayer := &testRelayer{}
+	e := NewEngine(&testFinality{lfb: lfb}, relayer, membership, config)
+	now := time.Unix(1000000, 0)
+	e.now = func() time.Time { return now }
+	e.limiter = ratelimit.NewTokenBucket(float64(config.MaxVotesPerSecond), time.Second, now)
+	e.updateHeightWindow()
+	return e, relayer
+}
+
+func newTestVote(t *testing.T, seed string, block common.Hash, height uint64) Vote {
+	privKey, _, err := crypto.TEST_GenerateKeyPairWithSeed(seed)
+	require.Nil(t, err)
+	vote := Vote{Block: block, Height: height, Guardian: privKey.PublicKey().Address()}
+	require.Nil(t, vote.Sign(privKey))
+	return vote
+}
+
+func newTestMessage(t *testing.T, peerID string, vote Vote) p2ptypes.Message {
+	payload, err := rlp.EncodeToBytes(vote)
+	require.Nil(t, err)
+	raw, err := rlp.EncodeToBytes(dispatcher.DataResponse{ChannelID: common.ChannelIDGuardian, Payload: payload})
+	require.Nil(t, err)
+	e := &Engine{}
+	msg, err := e.ParseMessage(peerID, common.ChannelIDGuardian, raw)
+	require.Nil(t, err)
+	return msg
+}
+
+// processQueued processes the queued votes, as done by the engine goroutine.
+func processQueued(e *Engine) int {
+	count := 0
+	for {
+		select {
+		case iv := <-e.incoming:
+			e.processVote(iv.peerID, iv.vote)
+			count++
+		default:
+			return count
+		}
+	}
+}
+
+func TestGuardianVoteProcessing(t *testing.T) {
+	assert := assert.New(t)
+
+	e, relayer := newTestEngine(Config{MessageQueueSize: 16}, newTestGuardians(t, "guardian1", "guardian2", "guardian3"))
+	block := common.HexToHash("0x1234")
+
+	vote := newTestVote(t, "guardian1", block, 1001)
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", vote)))
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer2", vote))) // duplicate
+	assert.Equal(2, processQueued(e))
+	assert.Equal(1, len(relayer.relayed))
+
+	// Votes with an invalid signature, or for blocks outside the window, are dropped
+	forged := newTestVote(t, "guardian2", block, 1001)
+	forged.Guardian = newTestVote(t, "guardian3", block, 1001).Guardian
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", forged)))
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", newTestVote(t, "guardian2", block, 1000+maxHeightsAhead+1))))
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", newTestVote(t, "guardian2", block, 1000-retainedHeights-1))))
+	assert.Equal(3, processQueued(e))
+	assert.Equal(1, len(relayer.relayed))
+
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", newTestVote(t, "guardian2", block, 1001))))
+	processQueued(e)
+	assert.Equal(2, len(relayer.relayed))
+
+	aggregated := e.GetAggregatedVotes(block)
+	require.NotNil(t, aggregated)
+	assert.Equal(uint64(1001), aggregated.Height)
+	assert.Equal(2, len(aggregated.Votes))
+	assert.Nil(e.GetAggregatedVotes(common.HexToHash("0x5678")))
+
+	// Votes from the other channels are rejected
+	assert.NotNil(e.HandleMessage(p2ptypes.Message{PeerID: "peer1", ChannelID: common.ChannelIDVote,
+		Content: dispatcher.DataResponse{ChannelID: common.ChannelIDVote}}))
+}
+
+func TestGuardianMembership(t *testing.T) {
+	assert := assert.New(t)
+
+	block := common.HexToHash("0x1234")
+	member := newTestVote(t, "guardian1", block, 1001)
+	membership := &testMembership{guardians: map[common.Address]bool{member.Guardian: true}}
+	e, relayer := newTestEngine(Config{MessageQueueSize: 16}, membership)
+
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", member)))
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", newTestVote(t, "guardian2", block, 1001))))
+	processQueued(e)
+	assert.Equal(1, len(relayer.relayed))
+	assert.Equal(member.Guardian, relayer.relayed[0].Guardian)
+
+	// Without a guardian set, no vote is accepted nor relayed
+	e, relayer = newTestEngine(Config{MessageQueueSize: 16}, nil)
+	assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", member)))
+	processQueued(e)
+	assert.Equal(0, len(relayer.relayed))
+	assert.Nil(e.GetAggregatedVotes(block))
+
+	// The guardian set configured for the node
+	static, err := NewStaticMembership([]string{member.Guardian.Hex(), " " + newTestVote(t, "guardian2", block, 1001).Guardian.Hex()})
+	assert.Nil(err)
+	assert.True(static.IsGuardian(member.Guardian, block))
+	assert.True(static.IsGuardian(newTestVote(t, "guardian2", block, 1001).Guardian, block))
+	assert.False(static.IsGuardian(newTestVote(t, "guardian3", block, 1001).Guardian, block))
+	_, err = NewStaticMembership([]string{"0x1234"})
+	assert.NotNil(err)
+}
+
+func TestGuardianRateLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	e, _ := newTestEngine(Config{MessageQueueSize: 16, MaxVotesPerSecond: 3, MaxPeerVotesPerSecond: 2}, newTestGuardians(t, "guardian1"))
+	block := common.HexToHash("0x1234")
+	vote := newTestVote(t, "guardian1", block, 1001)
+
+	// The peer limit applies first, then the global limit
+	for i := 0; i < 3; i++ {
+		e.HandleMessage(newTestMessage(t, "peer1", vote))
+	}
+	assert.Equal(2, len(e.incoming))
+	for i := 0; i < 2; i++ {
+		e.HandleMessage(newTestMessage(t, "peer2", vote))
+	}
+	assert.Equal(3, len(e.incoming))
+
+	// The buckets are refilled over time
+	now := e.now().Add(time.Second)
+	e.now = func() time.Time { return now }
+	e.HandleMessage(newTestMessage(t, "peer1", vote))
+	assert.Equal(4, len(e.incoming))
+
+	// The limiters of the idle peers are dropped
+	processQueued(e)
+	now = now.Add(time.Second)
+	e.prunePeerLimiters()
+	assert.Equal(0, len(e.peerLimiters))
+}
+
+func TestGuardianQueueFull(t *testing.T) {
+	assert := assert.New(t)
+
+	e, relayer := newTestEngine(Config{MessageQueueSize: 2}, newTestGuardians(t, "guardian1", "guardian2", "guardian3"))
+	block := common.HexToHash("0x1234")
+	for _, seed := range []string{"guardian1", "guardian2", "guardian3"} {
+		assert.Nil(e.HandleMessage(newTestMessage(t, "peer1", newTestVote(t, seed, block, 1001))))
+	}
+	assert.Equal(2, processQueued(e))
+	assert.Equal(2, len(relayer.relayed))
+
+	assert.Nil(e.AddVote(newTestVote(t, "guardian3", block, 1001)))
+	assert.Equal(1, processQueued(e))
+	assert.Equal(3, len(relayer.relayed))
+}
+
+func TestVotePoolHeightWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	pool := NewVotePool(2, 0)
+	pool.SetHeightWindow(10, 20)
+	block1 := common.HexToHash("0x01")
+	block2 := common.HexToHash("0x02")
+
+	assert.True(pool.Add(newTestVote(t, "guardian1", block1, 10)))
+	assert.True(pool.Add(newTestVote(t, "guardian2", block1, 10)))
+	assert.False(pool.Add(newTestVote(t, "guardian3", block1, 10))) // max votes per block
+	assert.False(pool.Add(newTestVote(t, "guardian1", block2, 9)))
+	assert.True(pool.Add(newTestVote(t, "guardian1", block2, 15)))
+	assert.Equal(2, pool.NumBlocks())
+
+	pool.SetHeightWindow(11, 21)
+	assert.Equal(1, pool.NumBlocks())
+	assert.Nil(pool.Get(block1))
+	assert.Equal(1, len(pool.Get(block2).Votes))
+}
+
+func TestVotePoolMaxVotes(t *testing.T) {
+	assert := assert.New(t)
+
+	pool := NewVotePool(2, 3)
+	pool.SetHeightWindow(10, 20)
+	block1 := common.HexToHash("0x01")
+	block2 := common.HexToHash("0x02")
+
+	assert.True(pool.Add(newTestVote(t, "guardian1", block1, 10)))
+	assert.True(pool.Add(newTestVote(t, "guardian2", block1, 10)))
+	assert.True(pool.Add(newTestVote(t, "guardian1", block2, 15)))
+	assert.False(pool.Add(newTestVote(t, "guardian2", block2, 15))) // max votes overall
+
+	// The votes dropped with their block free room in the pool
+	pool.SetHeightWindow(11, 21)
+	assert.True(pool.Add(newTestVote(t, "guardian2", block2, 15)))
+	assert.Equal(2, len(pool.Get(block2).Votes))
+}
@@ -0,0 +1,32 @@
+package guardian
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// StaticMembership is the guardian set configured for the node, which votes for every block,
+// until the guardian stakes are supported.
+type StaticMembership struct {
+	guardians map[common.Address]bool
+}
+
+// NewStaticMembership creates the guardian set of the given hex encoded addresses.
+func NewStaticMembership(addresses []string) (*StaticMembership, error) {
+	m := &StaticMembership{guardians: make(map[common.Address]bool)}
+	for _, address := range addresses {
+		address = strings.TrimSpace(address)
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("Invalid guardian address: %v", address)
+		}
+		m.guardians[common.HexToAddress(address)] = true
+	}
+	return m, nil
+}
+
+// IsGuardian implements the Membership interface.
+func (m *StaticMembership) IsGuardian(guardian common.Address, block common.Hash) bool {
+	return m.guardians[guardian]
+}
@@ -0,0 +1,140 @@
+package guardian
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// AggregatedVotes holds the votes of the guardians for a block.
+type AggregatedVotes struct {
+	Block  common.Hash
+	Height uint64
+	Votes  []Vote // sorted by guardian address
+}
+
+// Guardians returns the addresses of the guardians which voted for the block.
+func (a *AggregatedVotes) Guardians() []common.Address {
+	guardians := make([]common.Address, len(a.Votes))
+	for i, vote := range a.Votes {
+		guardians[i] = vote.Guardian
+	}
+	return guardians
+}
+
+// VotePool aggregates the guardian votes by block. It only keeps the votes for the blocks within
+// a window of heights, up to a number of votes per block and overall, so that the memory used is
+// bounded whatever the guardian traffic.
+type VotePool struct {
+	mu               *sync.Mutex
+	maxVotesPerBlock int
+	maxVotes         int
+	numVotes         int
+	minHeight        uint64
+	maxHeight        uint64
+	blocks           map[common.Hash]*blockVotes
+}
+
+type blockVotes struct {
+	height uint64
+	votes  map[common.Address]Vote
+}
+
+// NewVotePool creates a new instance of VotePool. A limit of 0 means unlimited.
+func NewVotePool(maxVotesPerBlock, maxVotes int) *VotePool {
+	return &VotePool{
+		mu:               &sync.Mutex{},
+		maxVotesPerBlock: maxVotesPerBlock,
+		maxVotes:         maxVotes,
+		maxHeight:        ^uint64(0),
+		blocks:           make(map[common.Hash]*blockVotes),
+	}
+}
+
+// SetHeightWindow sets the heights of the blocks the pool accepts votes for, and drops the votes
+// outside the window.
+func (p *VotePool) SetHeightWindow(minHeight, maxHeight uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.minHeight = minHeight
+	p.maxHeight = maxHeight
+	for hash, bv := range p.blocks {
+		if bv.height < minHeight || bv.height > maxHeight {
+			p.numVotes -= len(bv.votes)
+			delete(p.blocks, hash)
+		}
+	}
+}
+
+// InWindow returns whether the pool accepts votes for blocks at the given height.
+func (p *VotePool) InWindow(height uint64) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return height >= p.minHeight && height <= p.maxHeight
+}
+
+// Add adds the vote to the pool, and returns whether it was not known yet. The vote needs to be
+// validated by the caller.
+func (p *VotePool) Add(vote Vote) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if vote.Height < p.minHeight || vote.Height > p.maxHeight {
+		return false
+	}
+	if p.maxVotes > 0 && p.numVotes >= p.maxVotes {
+		return false
+	}
+	bv, ok := p.blocks[vote.Block]
+	if !ok {
+		bv = &blockVotes{
+			height: vote.Height,
+			votes:  make(map[common.Address]Vote),
+		}
+		p.blocks[vote.Block] = bv
+	}
+	if bv.height != vote.Height {
+		return false
+	}
+	if _, ok := bv.votes[vote.Guardian]; ok {
+		return false
+	}
+	if p.maxVotesPerBlock > 0 && len(bv.votes) >= p.maxVotesPerBlock {
+		return false
+	}
+	bv.votes[vote.Guardian] = vote
+	p.numVotes++
+	return true
+}
+
+// Get returns the votes for the given block, nil if there is none.
+func (p *VotePool) Get(block common.Hash) *AggregatedVotes {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	bv, ok := p.blocks[block]
+	if !ok {
+		return nil
+	}
+	aggregated := &AggregatedVotes{
+		Block:  block,
+		Height: bv.height,
+		Votes:  make([]Vote, 0, len(bv.votes)),
+	}
+	for _, vote := range bv.votes {
+		aggregated.Votes = append(aggregated.Votes, vote)
+	}
+	sort.Slice(aggregated.Votes, func(i, j int) bool {
+		return aggregated.Votes[i].Guardian.Hex() < aggregated.Votes[j].Guardian.Hex()
+	})
+	return aggregated
+}
+
+// NumBlocks returns the number of blocks with votes in the pool.
+func (p *VotePool) NumBlocks() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.blocks)
+}
@@ -0,0 +1,17 @@
+package guardian
+
+import (
+	"github.com/thetatoken/theta/common/metrics"
+)
+
+var (
+	votesReceivedCounter    = metrics.NewRegisteredCounter("guardian/votes/received", nil)
+	votesAcceptedCounter    = metrics.NewRegisteredCounter("guardian/votes/accepted", nil)
+	votesInvalidCounter     = metrics.NewRegisteredCounter("guardian/votes/invalid", nil)
+	votesOutOfWindowCounter = metrics.NewRegisteredCounter("guardian/votes/outofwindow", nil)
+	votesRateLimitedCounter = metrics.NewRegisteredCounter("guardian/votes/ratelimited", nil)
+	votesQueueFullCounter   = metrics.NewRegisteredCounter("guardian/votes/queuefull", nil)
+	queueLengthGauge        = metrics.NewRegisteredGauge("guardian/queue/length", nil)
+	blocksInPoolGauge       = metrics.NewRegisteredGauge("guardian/pool/blocks", nil)
+	voteProcessingTimer     = metrics.NewRegisteredTimer("guardian/votes/processing", nil)
+)
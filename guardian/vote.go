@@ -0,0 +1,56 @@
+package guardian
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// Vote is the attestation of a guardian for a block. Guardian votes are gossiped on their own
+// channel and processed separately from the validator votes.
+type Vote struct {
+	Block     common.Hash
+	Height    uint64
+	Guardian  common.Address
+	Signature *crypto.Signature
+}
+
+func (v Vote) String() string {
+	return fmt.Sprintf("GuardianVote{Block: %s, Height: %v, Guardian: %s}", v.Block.Hex(), v.Height, v.Guardian.Hex())
+}
+
+// SignBytes returns the bytes signed by the guardian.
+func (v Vote) SignBytes() common.Bytes {
+	raw, _ := rlp.EncodeToBytes([]interface{}{"theta-guardian-vote", v.Block, v.Height, v.Guardian})
+	return raw
+}
+
+// Sign signs the vote with the guardian key.
+func (v *Vote) Sign(privateKey *crypto.PrivateKey) error {
+	sig, err := privateKey.Sign(v.SignBytes())
+	if err != nil {
+		return err
+	}
+	v.Signature = sig
+	return nil
+}
+
+// Validate checks the vote is well formed and signed by the guardian.
+func (v Vote) Validate() result.Result {
+	if v.Block.IsEmpty() {
+		return result.Error("Block is not specified")
+	}
+	if v.Guardian.IsEmpty() {
+		return result.Error("Guardian is not specified")
+	}
+	if v.Signature == nil || v.Signature.IsEmpty() {
+		return result.Error("Vote is not signed")
+	}
+	if !v.Signature.Verify(v.SignBytes(), v.Guardian) {
+		return result.Error("Signature verification failed")
+	}
+	return result.OK
+}
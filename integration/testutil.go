@@ -26,24 +26,26 @@ func testConsensus(assert *assert.Assertions, simnet *p2psim.Simnet, nodes []*no
 	}
 	simnet.Start(ctx)
 	for _, node := range nodes {
+		finalized := node.Consensus.SubscribeFinalizedBlocks(512)
 		node.Start(ctx)
 		wg.Add(1)
-		go func(n core.ConsensusEngine) {
+		go func(id string, finalized *core.FinalitySubscription) {
 			defer func() {
+				finalized.Unsubscribe()
 				wg.Done()
 			}()
 			for {
 				select {
 				case <-ctx.Done():
 					return
-				case block := <-n.FinalizedBlocks():
+				case block := <-finalized.C:
 					l.Lock()
-					finalizedBlocksByNode[n.ID()] = append(finalizedBlocksByNode[n.ID()], block.Hash())
+					finalizedBlocksByNode[id] = append(finalizedBlocksByNode[id], block.Hash())
 					l.Unlock()
 				}
 
 			}
-		}(node.Consensus)
+		}(node.Consensus.ID(), finalized)
 	}
 
 	time.Sleep(duration)
@@ -0,0 +1,291 @@
+// Package canonical implements the canonical state export, a deterministic encoding of the ledger
+// state at a given height which alternative client implementations can produce and compare byte
+// for byte. See docs/canonical-state-export.md for the specification.
+package canonical
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+)
+
+const (
+	// Magic identifies a canonical state export
+	Magic = "theta-canonical-state"
+	// Version is the version of the format produced by Export
+	Version uint64 = 1
+)
+
+// Kinds of the records, which appear in the export in this order.
+const (
+	KindHeader                  uint64 = 0x00
+	KindAccount                 uint64 = 0x01
+	KindStorageSlot             uint64 = 0x02
+	KindCode                    uint64 = 0x03
+	KindAccountOperator         uint64 = 0x04
+	KindPendingSettlement       uint64 = 0x05
+	KindSplitRule               uint64 = 0x06
+	KindValidatorCandidatePool  uint64 = 0x07
+	KindStakeTransactionHeights uint64 = 0x08
+	KindRawEntry                uint64 = 0x09
+	KindNodeAddress             uint64 = 0x0a
+	KindFooter                  uint64 = 0xff
+)
+
+// Domain separators of the Merkle tree
+const (
+	leafPrefix  byte = 0x00
+	innerPrefix byte = 0x01
+)
+
+// Header is the first record of an export.
+type Header struct {
+	Magic     string
+	Version   uint64
+	ChainID   string
+	Height    uint64
+	StateRoot common.Hash
+}
+
+// Account is an account, see types.Account. The coin amounts are in wei.
+type Account struct {
+	Address                common.Address
+	Sequence               uint64
+	ThetaWei               *big.Int
+	TFuelWei               *big.Int
+	LastUpdatedBlockHeight uint64
+	StorageRoot            common.Hash
+	CodeHash               common.Hash
+	ReservedFunds          []ReservedFund
+}
+
+// ReservedFund is a reserved fund of an account, in the order of the account.
+type ReservedFund struct {
+	CollateralThetaWei  *big.Int
+	CollateralTFuelWei  *big.Int
+	InitialFundThetaWei *big.Int
+	InitialFundTFuelWei *big.Int
+	UsedFundThetaWei    *big.Int
+	UsedFundTFuelWei    *big.Int
+	ResourceIDs         []string
+	EndBlockHeight      uint64
+	ReserveSequence     uint64
+	TransferRecords     []common.Bytes // Wire encoding of the service payment transactions
+}
+
+// StorageSlot is a non-empty slot of the storage of a smart contract.
+type StorageSlot struct {
+	Address common.Address
+	Key     common.Hash
+	Value   common.Bytes
+}
+
+// Code is the code of a smart contract.
+type Code struct {
+	CodeHash common.Hash
+	Code     common.Bytes
+}
+
+// AccountOperator is the operator of an account, see types.AccountOperator.
+type AccountOperator struct {
+	Account            common.Address
+	Operator           common.Address
+	SpendLimitThetaWei *big.Int
+	SpendLimitTFuelWei *big.Int
+	SpentThetaWei      *big.Int
+	SpentTFuelWei      *big.Int
+}
+
+// PendingSettlement is a service payment waiting for its dispute window to end.
+type PendingSettlement struct {
+	Source          common.Address
+	Target          common.Address
+	ReserveSequence uint64
+	ServicePayment  common.Bytes // Wire encoding of the service payment transaction
+	SettleHeight    uint64
+}
+
+// SplitRule is a payment split rule, see types.SplitRule.
+type SplitRule struct {
+	ResourceID     string
+	Initiator      common.Address
+	Splits         []Split
+	EndBlockHeight uint64
+}
+
+// Split is a share of a split rule, in the order of the rule.
+type Split struct {
+	Address    common.Address
+	Percentage uint64
+}
+
+// ValidatorCandidatePool lists the stake holders in the order of the pool, which is significant
+// for the validator selection.
+type ValidatorCandidatePool struct {
+	Candidates []StakeHolder
+}
+
+// StakeHolder is a candidate of the validator candidate pool.
+type StakeHolder struct {
+	Holder common.Address
+	Stakes []Stake
+}
+
+// Stake is a stake of a stake holder, in the order of the stake holder.
+type Stake struct {
+	Source       common.Address
+	AmountWei    *big.Int
+	Withdrawn    bool
+	ReturnHeight uint64
+}
+
+// StakeTransactionHeights lists the heights of the blocks containing stake transactions.
+type StakeTransactionHeights struct {
+	Heights []uint64
+}
+
+// RawEntry is a state entry which none of the other kinds covers. Its value is in the native
+// encoding of the implementation, so a raw entry in an export points to a gap in this format.
+type RawEntry struct {
+	Key   common.Bytes
+	Value common.Bytes
+}
+
+// NodeAddress is the network endpoints registered by a validator, see core.NodeAddress.
+type NodeAddress struct {
+	Validator         common.Address
+	Endpoints         []string
+	PreviousEndpoints []string
+	RotationHeight    uint64
+}
+
+// Footer is the last record of an export, it is not part of the Merkle tree.
+type Footer struct {
+	NumRecords uint64
+	Root       common.Hash
+}
+
+// envelope is the encoding of a record.
+type envelope struct {
+	Kind    uint64
+	Payload rlp.RawValue
+}
+
+// encodeRecord returns the canonical encoding of the record.
+func encodeRecord(kind uint64, payload interface{}) ([]byte, error) {
+	raw, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(envelope{Kind: kind, Payload: raw})
+}
+
+// decodeRecord returns the kind and the decoded payload of the record.
+func decodeRecord(record []byte) (uint64, interface{}, error) {
+	env := envelope{}
+	if err := rlp.DecodeBytes(record, &env); err != nil {
+		return 0, nil, err
+	}
+	var payload interface{}
+	switch env.Kind {
+	case KindHeader:
+		payload = &Header{}
+	case KindAccount:
+		payload = &Account{}
+	case KindStorageSlot:
+		payload = &StorageSlot{}
+	case KindCode:
+		payload = &Code{}
+	case KindAccountOperator:
+		payload = &AccountOperator{}
+	case KindPendingSettlement:
+		payload = &PendingSettlement{}
+	case KindSplitRule:
+		payload = &SplitRule{}
+	case KindValidatorCandidatePool:
+		payload = &ValidatorCandidatePool{}
+	case KindStakeTransactionHeights:
+		payload = &StakeTransactionHeights{}
+	case KindRawEntry:
+		payload = &RawEntry{}
+	case KindNodeAddress:
+		payload = &NodeAddress{}
+	case KindFooter:
+		payload = &Footer{}
+	default:
+		return 0, nil, fmt.Errorf("Unknown record kind %v", env.Kind)
+	}
+	if err := rlp.DecodeBytes(env.Payload, payload); err != nil {
+		return 0, nil, fmt.Errorf("Failed to decode record of kind %v: %v", env.Kind, err)
+	}
+	// Re-encoding must give back the record, which rejects non-minimal encodings
+	encoded, err := encodeRecord(env.Kind, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(encoded, record) {
+		return 0, nil, fmt.Errorf("Record of kind %v is not canonically encoded", env.Kind)
+	}
+	return env.Kind, payload, nil
+}
+
+// sortKey returns the key which orders the records of the same kind.
+func sortKey(payload interface{}) []byte {
+	switch p := payload.(type) {
+	case *Account:
+		return p.Address[:]
+	case *StorageSlot:
+		return append(append([]byte{}, p.Address[:]...), p.Key[:]...)
+	case *Code:
+		return p.CodeHash[:]
+	case *AccountOperator:
+		return p.Account[:]
+	case *PendingSettlement:
+		return settlementSortKey(p.Source, p.Target, p.ReserveSequence)
+	case *SplitRule:
+		return []byte(p.ResourceID)
+	case *RawEntry:
+		return p.Key
+	case *NodeAddress:
+		return p.Validator[:]
+	default:
+		return nil
+	}
+}
+
+func settlementSortKey(source, target common.Address, reserveSequence uint64) []byte {
+	key := append(append([]byte{}, source[:]...), target[:]...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], reserveSequence)
+	return append(key, seq[:]...)
+}
+
+func leafHash(record []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{leafPrefix}, record)
+}
+
+// MerkleRoot returns the root of the binary Merkle tree over the hashes of the records. The last
+// node of a level with an odd number of nodes is promoted to the next level unchanged.
+func MerkleRoot(leaves []common.Hash) common.Hash {
+	if len(leaves) == 0 {
+		return common.Hash{}
+	}
+	level := append([]common.Hash{}, leaves...)
+	for len(level) > 1 {
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, crypto.Keccak256Hash([]byte{innerPrefix}, level[i][:], level[i+1][:]))
+		}
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,189 @@
+package canonical
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func createTestState() *state.StoreView {
+	sv := state.NewStoreView(10, common.Hash{}, backend.NewMemDatabase())
+	sv.Set(state.ChainIDKey(), common.Bytes("testchain"))
+
+	// Accounts are set out of order, the export sorts them
+	for _, hex := range []string{"0x3000000000000000000000000000000000000003", "0x1000000000000000000000000000000000000001"} {
+		addr := common.HexToAddress(hex)
+		acc := types.NewAccount(addr)
+		acc.Balance = types.NewCoins(100, 2000)
+		acc.Sequence = 3
+		sv.SetAccount(addr, acc)
+	}
+
+	contract := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	sv.SetCode(contract, []byte{0x60, 0x00})
+	sv.SetState(contract, common.BytesToHash([]byte{2}), common.BytesToHash([]byte{0xaa}))
+	sv.SetState(contract, common.BytesToHash([]byte{1}), common.BytesToHash([]byte{0xbb}))
+
+	sv.SetAccountOperator(&types.AccountOperator{
+		AccountAddress:  common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		OperatorAddress: common.HexToAddress("0x4000000000000000000000000000000000000004"),
+		SpendLimit:      types.NewCoins(0, 500),
+	})
+
+	for _, seq := range []uint64{10, 9} {
+		sv.SetPendingSettlement(&types.PendingSettlement{
+			ServicePayment: types.ServicePaymentTx{
+				Fee:             types.NewCoins(0, 1),
+				Source:          types.TxInput{Address: common.HexToAddress("0x1000000000000000000000000000000000000001"), Coins: types.NewCoins(0, 10)},
+				Target:          types.TxInput{Address: common.HexToAddress("0x3000000000000000000000000000000000000003")},
+				ReserveSequence: seq,
+				ResourceID:      "rid",
+			},
+			SettleHeight: 20,
+		})
+	}
+
+	sv.SetSplitRule("rid", &types.SplitRule{
+		InitiatorAddress: common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		ResourceID:       "rid",
+		Splits:           []types.Split{{Address: common.HexToAddress("0x3000000000000000000000000000000000000003"), Percentage: 30}},
+		EndBlockHeight:   100,
+	})
+
+	vcp := &core.ValidatorCandidatePool{}
+	vcp.DepositStake(common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		common.HexToAddress("0x5000000000000000000000000000000000000005"), new(big.Int).Mul(big.NewInt(1e18), big.NewInt(2000000)))
+	sv.UpdateValidatorCandidatePool(vcp)
+	sv.UpdateStakeTransactionHeightList(&types.HeightList{Heights: []uint64{1, 5}})
+
+	sv.Set(common.Bytes("custom/key"), common.Bytes("value"))
+	sv.Save()
+	return sv
+}
+
+func TestExportIsDeterministic(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sv := createTestState()
+	buf1, buf2 := &bytes.Buffer{}, &bytes.Buffer{}
+	footer, err := Export(sv, buf1)
+	require.Nil(err)
+	_, err = Export(sv, buf2)
+	require.Nil(err)
+	assert.Equal(buf1.Bytes(), buf2.Bytes())
+
+	header, verified, err := Verify(bytes.NewReader(buf1.Bytes()))
+	require.Nil(err)
+	assert.Equal(footer, verified)
+	assert.Equal("testchain", header.ChainID)
+	assert.Equal(uint64(10), header.Height)
+	assert.Equal(sv.Hash(), header.StateRoot)
+
+	kinds := []uint64{}
+	rd := newReader(bytes.NewReader(buf1.Bytes()))
+	for {
+		record, err := rd.next()
+		if err != nil {
+			break
+		}
+		kind, payload, err := decodeRecord(record)
+		require.Nil(err)
+		kinds = append(kinds, kind)
+		if raw, ok := payload.(*RawEntry); ok {
+			assert.Equal(common.Bytes("custom/key"), raw.Key)
+		}
+	}
+	assert.Equal([]uint64{
+		KindHeader,
+		KindAccount, KindAccount, KindAccount,
+		KindStorageSlot, KindStorageSlot,
+		KindCode,
+		KindAccountOperator,
+		KindPendingSettlement, KindPendingSettlement,
+		KindSplitRule,
+		KindValidatorCandidatePool,
+		KindStakeTransactionHeights,
+		KindRawEntry,
+		KindFooter,
+	}, kinds)
+	assert.Equal(uint64(len(kinds)-1), footer.NumRecords)
+}
+
+func TestVerifyAndCompare(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sv := createTestState()
+	buf := &bytes.Buffer{}
+	_, err := Export(sv, buf)
+	require.Nil(err)
+	export := buf.Bytes()
+
+	// Truncated
+	_, _, err = Verify(bytes.NewReader(export[:len(export)-40]))
+	assert.NotNil(err)
+
+	// Tampered record
+	tampered := append([]byte{}, export...)
+	index := bytes.Index(tampered, []byte("rid"))
+	require.True(index > 0)
+	tampered[index] = 'x'
+	_, _, err = Verify(bytes.NewReader(tampered))
+	assert.NotNil(err)
+
+	// Out of order records
+	header, err := encodeRecord(KindHeader, &Header{Magic: Magic, Version: Version})
+	require.Nil(err)
+	acc1, err := encodeRecord(KindAccount, &Account{Address: common.HexToAddress("0x02"), ThetaWei: big.NewInt(0), TFuelWei: big.NewInt(0)})
+	require.Nil(err)
+	acc2, err := encodeRecord(KindAccount, &Account{Address: common.HexToAddress("0x01"), ThetaWei: big.NewInt(0), TFuelWei: big.NewInt(0)})
+	require.Nil(err)
+	leaves := []common.Hash{leafHash(header), leafHash(acc1), leafHash(acc2)}
+	footer, err := encodeRecord(KindFooter, &Footer{NumRecords: 3, Root: MerkleRoot(leaves)})
+	require.Nil(err)
+	unordered := bytes.Join([][]byte{header, acc1, acc2, footer}, nil)
+	_, _, err = Verify(bytes.NewReader(unordered))
+	assert.NotNil(err)
+
+	// Exports of the same state compare equal, a balance change already shows in the state root
+	diff, err := Compare(bytes.NewReader(export), bytes.NewReader(export))
+	require.Nil(err)
+	assert.Nil(diff)
+
+	addr := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	acc := sv.GetAccount(addr)
+	acc.Balance = types.NewCoins(100, 2001)
+	sv.SetAccount(addr, acc)
+	sv.Save()
+	buf2 := &bytes.Buffer{}
+	_, err = Export(sv, buf2)
+	require.Nil(err)
+	diff, err = Compare(bytes.NewReader(export), bytes.NewReader(buf2.Bytes()))
+	require.Nil(err)
+	require.NotNil(diff)
+	assert.Equal(0, diff.Index)
+	_, headerA, err := decodeRecord(diff.RecordA)
+	require.Nil(err)
+	_, headerB, err := decodeRecord(diff.RecordB)
+	require.Nil(err)
+	assert.NotEqual(headerA.(*Header).StateRoot, headerB.(*Header).StateRoot)
+}
+
+func TestMerkleRoot(t *testing.T) {
+	assert := assert.New(t)
+
+	a, b, c := common.BytesToHash([]byte{1}), common.BytesToHash([]byte{2}), common.BytesToHash([]byte{3})
+	assert.Equal(a, MerkleRoot([]common.Hash{a}))
+	ab := MerkleRoot([]common.Hash{a, b})
+	assert.NotEqual(ab, MerkleRoot([]common.Hash{b, a}))
+	assert.Equal(MerkleRoot([]common.Hash{ab, c}), MerkleRoot([]common.Hash{a, b, c}))
+}
@@ -0,0 +1,339 @@
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/treestore"
+)
+
+// exporter writes the records and accumulates the leaves of the Merkle tree.
+type exporter struct {
+	w      *bufio.Writer
+	leaves []common.Hash
+}
+
+func (e *exporter) write(kind uint64, payload interface{}) error {
+	record, err := encodeRecord(kind, payload)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(record); err != nil {
+		return err
+	}
+	if kind != KindFooter {
+		e.leaves = append(e.leaves, leafHash(record))
+	}
+	return nil
+}
+
+// Export writes the canonical export of the state to w, and returns its footer. The state is read
+// in a few passes over the state trie, so that the export does not need to fit in memory.
+func Export(sv *state.StoreView, w io.Writer) (*Footer, error) {
+	e := &exporter{w: bufio.NewWriter(w)}
+
+	header := &Header{
+		Magic:     Magic,
+		Version:   Version,
+		ChainID:   string(sv.Get(state.ChainIDKey())),
+		Height:    sv.Height(),
+		StateRoot: sv.Hash(),
+	}
+	if err := e.write(KindHeader, header); err != nil {
+		return nil, err
+	}
+
+	// The trie iterates the keys in byte order, which is the order of the records for the kinds
+	// whose sort key is the suffix of the state key.
+	contracts := []*types.Account{}
+	err := traverseAccounts(sv, func(key, value common.Bytes) error {
+		acc := &types.Account{}
+		if err := types.FromBytes(value, acc); err != nil {
+			return errors.Wrapf(err, "Failed to decode account %x", key)
+		}
+		if !acc.Root.IsEmpty() {
+			contracts = append(contracts, acc)
+		}
+		account, err := newAccount(acc)
+		if err != nil {
+			return err
+		}
+		return e.write(KindAccount, account)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, acc := range contracts {
+		storage := treestore.NewTreeStore(acc.Root, sv.GetDB())
+		if storage == nil {
+			return nil, fmt.Errorf("Storage of %v is missing", acc.Address.Hex())
+		}
+		err := traverseStore(storage, nil, func(key, value common.Bytes) error {
+			_, content, _, err := rlp.Split(value)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to decode storage slot %x of %v", key, acc.Address.Hex())
+			}
+			return e.write(KindStorageSlot, &StorageSlot{
+				Address: acc.Address,
+				Key:     common.BytesToHash(key),
+				Value:   content,
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = traverse(sv, state.CodeKeyPrefix(), func(key, value common.Bytes) error {
+		return e.write(KindCode, &Code{
+			CodeHash: common.BytesToHash(key[len(state.CodeKeyPrefix()):]),
+			Code:     value,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = traverse(sv, state.AccountOperatorKeyPrefix(), func(key, value common.Bytes) error {
+		operator := &types.AccountOperator{}
+		if err := types.FromBytes(value, operator); err != nil {
+			return errors.Wrapf(err, "Failed to decode account operator %x", key)
+		}
+		spendLimit, spent := operator.SpendLimit.NoNil(), operator.Spent.NoNil()
+		return e.write(KindAccountOperator, &AccountOperator{
+			Account:            operator.AccountAddress,
+			Operator:           operator.OperatorAddress,
+			SpendLimitThetaWei: spendLimit.ThetaWei,
+			SpendLimitTFuelWei: spendLimit.TFuelWei,
+			SpentThetaWei:      spent.ThetaWei,
+			SpentTFuelWei:      spent.TFuelWei,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The reserve sequence is a decimal string in the state key, so the settlements are sorted here.
+	settlements := []*PendingSettlement{}
+	err = traverse(sv, state.PendingSettlementKeyPrefix(), func(key, value common.Bytes) error {
+		settlement := &types.PendingSettlement{}
+		if err := types.FromBytes(value, settlement); err != nil {
+			return errors.Wrapf(err, "Failed to decode pending settlement %x", key)
+		}
+		payment := settlement.ServicePayment
+		raw, err := types.TxToBytes(&payment)
+		if err != nil {
+			return err
+		}
+		settlements = append(settlements, &PendingSettlement{
+			Source:          payment.Source.Address,
+			Target:          payment.Target.Address,
+			ReserveSequence: payment.ReserveSequence,
+			ServicePayment:  raw,
+			SettleHeight:    settlement.SettleHeight,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(settlements, func(i, j int) bool {
+		return bytes.Compare(sortKey(settlements[i]), sortKey(settlements[j])) < 0
+	})
+	for _, settlement := range settlements {
+		if err := e.write(KindPendingSettlement, settlement); err != nil {
+			return nil, err
+		}
+	}
+
+	err = traverse(sv, state.SplitRuleKeyPrefix(), func(key, value common.Bytes) error {
+		splitRule := &types.SplitRule{}
+		if err := types.FromBytes(value, splitRule); err != nil {
+			return errors.Wrapf(err, "Failed to decode split rule %x", key)
+		}
+		splits := []Split{}
+		for _, split := range splitRule.Splits {
+			splits = append(splits, Split{Address: split.Address, Percentage: uint64(split.Percentage)})
+		}
+		return e.write(KindSplitRule, &SplitRule{
+			ResourceID:     splitRule.ResourceID,
+			Initiator:      splitRule.InitiatorAddress,
+			Splits:         splits,
+			EndBlockHeight: splitRule.EndBlockHeight,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if vcp := sv.GetValidatorCandidatePool(); vcp != nil {
+		if err := e.write(KindValidatorCandidatePool, newValidatorCandidatePool(vcp)); err != nil {
+			return nil, err
+		}
+	}
+
+	if hl := sv.GetStakeTransactionHeightList(); hl != nil {
+		heights := append([]uint64{}, hl.Heights...)
+		if err := e.write(KindStakeTransactionHeights, &StakeTransactionHeights{Heights: heights}); err != nil {
+			return nil, err
+		}
+	}
+
+	err = traverse(sv, nil, func(key, value common.Bytes) error {
+		if isCoveredKey(key) {
+			return nil
+		}
+		return e.write(KindRawEntry, &RawEntry{Key: key, Value: value})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = traverse(sv, state.NodeAddressKeyPrefix(), func(key, value common.Bytes) error {
+		nodeAddress := &core.NodeAddress{}
+		if err := types.FromBytes(value, nodeAddress); err != nil {
+			return errors.Wrapf(err, "Failed to decode node address %x", key)
+		}
+		return e.write(KindNodeAddress, &NodeAddress{
+			Validator:         nodeAddress.Validator,
+			Endpoints:         append([]string{}, nodeAddress.Endpoints...),
+			PreviousEndpoints: append([]string{}, nodeAddress.PreviousEndpoints...),
+			RotationHeight:    nodeAddress.RotationHeight,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	footer := &Footer{
+		NumRecords: uint64(len(e.leaves)),
+		Root:       MerkleRoot(e.leaves),
+	}
+	if err := e.write(KindFooter, footer); err != nil {
+		return nil, err
+	}
+	return footer, e.w.Flush()
+}
+
+func newAccount(acc *types.Account) (*Account, error) {
+	balance := acc.Balance.NoNil()
+	ret := &Account{
+		Address:                acc.Address,
+		Sequence:               acc.Sequence,
+		ThetaWei:               balance.ThetaWei,
+		TFuelWei:               balance.TFuelWei,
+		LastUpdatedBlockHeight: acc.LastUpdatedBlockHeight,
+		StorageRoot:            acc.Root,
+		CodeHash:               acc.CodeHash,
+		ReservedFunds:          []ReservedFund{},
+	}
+	for _, fund := range acc.ReservedFunds {
+		collateral, initialFund, usedFund := fund.Collateral.NoNil(), fund.InitialFund.NoNil(), fund.UsedFund.NoNil()
+		transferRecords := []common.Bytes{}
+		for _, record := range fund.TransferRecords {
+			payment := record.ServicePayment
+			raw, err := types.TxToBytes(&payment)
+			if err != nil {
+				return nil, err
+			}
+			transferRecords = append(transferRecords, raw)
+		}
+		ret.ReservedFunds = append(ret.ReservedFunds, ReservedFund{
+			CollateralThetaWei:  collateral.ThetaWei,
+			CollateralTFuelWei:  collateral.TFuelWei,
+			InitialFundThetaWei: initialFund.ThetaWei,
+			InitialFundTFuelWei: initialFund.TFuelWei,
+			UsedFundThetaWei:    usedFund.ThetaWei,
+			UsedFundTFuelWei:    usedFund.TFuelWei,
+			ResourceIDs:         append([]string{}, fund.ResourceIDs...),
+			EndBlockHeight:      fund.EndBlockHeight,
+			ReserveSequence:     fund.ReserveSequence,
+			TransferRecords:     transferRecords,
+		})
+	}
+	return ret, nil
+}
+
+func newValidatorCandidatePool(vcp *core.ValidatorCandidatePool) *ValidatorCandidatePool {
+	ret := &ValidatorCandidatePool{Candidates: []StakeHolder{}}
+	for _, candidate := range vcp.SortedCandidates {
+		stakes := []Stake{}
+		for _, stake := range candidate.Stakes {
+			amount := stake.Amount
+			if amount == nil {
+				amount = big.NewInt(0)
+			}
+			stakes = append(stakes, Stake{
+				Source:       stake.Source,
+				AmountWei:    amount,
+				Withdrawn:    stake.Withdrawn,
+				ReturnHeight: stake.ReturnHeight,
+			})
+		}
+		ret.Candidates = append(ret.Candidates, StakeHolder{Holder: candidate.Holder, Stakes: stakes})
+	}
+	return ret
+}
+
+// isCoveredKey returns whether the state entry is exported by a kind other than the raw entries.
+func isCoveredKey(key common.Bytes) bool {
+	if state.IsShardKey(key) {
+		return true // the shard roots depend on the layout of the state, not on its content
+	}
+	if bytes.Equal(key, state.ChainIDKey()) ||
+		bytes.Equal(key, state.ValidatorCandidatePoolKey()) ||
+		bytes.Equal(key, state.StakeTransactionHeightListKey()) {
+		return true
+	}
+	for _, prefix := range []common.Bytes{
+		state.AccountKeyPrefix(),
+		state.AccountOperatorKeyPrefix(),
+		state.CodeKeyPrefix(),
+		state.PendingSettlementKeyPrefix(),
+		state.SplitRuleKeyPrefix(),
+		state.NodeAddressKeyPrefix(),
+	} {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func traverse(sv *state.StoreView, prefix common.Bytes, cb func(key, value common.Bytes) error) error {
+	return traverseStore(sv.GetStore(), prefix, cb)
+}
+
+// traverseAccounts calls cb on the account entries in key order, until cb returns an error.
+func traverseAccounts(sv *state.StoreView, cb func(key, value common.Bytes) error) error {
+	var err error
+	sv.TraverseAccounts(func(key, value common.Bytes) bool {
+		err = cb(key, value)
+		return err == nil
+	})
+	return err
+}
+
+// traverseStore calls cb on the entries under the prefix in key order, until cb returns an error.
+func traverseStore(store *treestore.TreeStore, prefix common.Bytes, cb func(key, value common.Bytes) error) error {
+	var err error
+	store.Traverse(prefix, func(key, value common.Bytes) bool {
+		if err == nil {
+			err = cb(key, value)
+		}
+		return err == nil
+	})
+	return err
+}
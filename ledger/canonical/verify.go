@@ -0,0 +1,143 @@
+package canonical
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// reader reads the records of an export one by one.
+type reader struct {
+	stream *rlp.Stream
+}
+
+func newReader(r io.Reader) *reader {
+	return &reader{stream: rlp.NewStream(bufio.NewReader(r), 0)}
+}
+
+// next returns the next record, or io.EOF at the end of the export.
+func (r *reader) next() ([]byte, error) {
+	record, err := r.stream.Raw()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read record: %v", err)
+	}
+	return record, nil
+}
+
+// Verify reads the export from r, and checks that it is well formed: the records are canonically
+// encoded and ordered, and their Merkle root and count match the footer. It does not check the
+// records against a state, see Compare for that.
+func Verify(r io.Reader) (*Header, *Footer, error) {
+	rd := newReader(r)
+	var header *Header
+	var footer *Footer
+	var prevKind uint64
+	var prevKey []byte
+	leaves := []common.Hash{}
+	for index := 0; ; index++ {
+		record, err := rd.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if footer != nil {
+			return nil, nil, fmt.Errorf("Record %v is after the footer", index)
+		}
+		kind, payload, err := decodeRecord(record)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Invalid record %v: %v", index, err)
+		}
+
+		if index == 0 {
+			if kind != KindHeader {
+				return nil, nil, errors.New("The export does not start with a header")
+			}
+			header = payload.(*Header)
+			if header.Magic != Magic {
+				return nil, nil, errors.New("Not a canonical state export")
+			}
+			if header.Version != Version {
+				return nil, nil, fmt.Errorf("Unsupported version %v, expected %v", header.Version, Version)
+			}
+		} else if kind == KindFooter {
+			footer = payload.(*Footer)
+			continue
+		} else {
+			key := sortKey(payload)
+			if kind < prevKind || (kind == prevKind && bytes.Compare(key, prevKey) <= 0) {
+				return nil, nil, fmt.Errorf("Record %v of kind %v is out of order", index, kind)
+			}
+			prevKind, prevKey = kind, key
+		}
+		leaves = append(leaves, leafHash(record))
+	}
+
+	if header == nil {
+		return nil, nil, errors.New("The export is empty")
+	}
+	if footer == nil {
+		return nil, nil, errors.New("The export is truncated, the footer is missing")
+	}
+	if footer.NumRecords != uint64(len(leaves)) {
+		return nil, nil, fmt.Errorf("The footer counts %v records, but the export has %v", footer.NumRecords, len(leaves))
+	}
+	if root := MerkleRoot(leaves); footer.Root != root {
+		return nil, nil, fmt.Errorf("The footer has Merkle root %v, but the records have %v", footer.Root.Hex(), root.Hex())
+	}
+	return header, footer, nil
+}
+
+// Difference is the first record where two exports differ. A nil record means that the export
+// has fewer records.
+type Difference struct {
+	Index   int
+	RecordA []byte
+	RecordB []byte
+}
+
+func (d *Difference) String() string {
+	return fmt.Sprintf("Difference{index: %v, a: %v, b: %v}", d.Index, describeRecord(d.RecordA), describeRecord(d.RecordB))
+}
+
+// Compare returns the first record where the two exports differ, or nil if they are identical
+// byte for byte.
+func Compare(a, b io.Reader) (*Difference, error) {
+	ra, rb := newReader(a), newReader(b)
+	for index := 0; ; index++ {
+		recordA, errA := ra.next()
+		if errA != nil && errA != io.EOF {
+			return nil, errA
+		}
+		recordB, errB := rb.next()
+		if errB != nil && errB != io.EOF {
+			return nil, errB
+		}
+		if errA == io.EOF && errB == io.EOF {
+			return nil, nil
+		}
+		if !bytes.Equal(recordA, recordB) {
+			return &Difference{Index: index, RecordA: recordA, RecordB: recordB}, nil
+		}
+	}
+}
+
+func describeRecord(record []byte) string {
+	if record == nil {
+		return "none"
+	}
+	kind, payload, err := decodeRecord(record)
+	if err != nil {
+		return fmt.Sprintf("invalid record %x", record)
+	}
+	return fmt.Sprintf("kind %v %+v", kind, payload)
+}
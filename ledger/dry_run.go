@@ -0,0 +1,101 @@
+package ledger
+
+import (
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	exec "github.com/thetatoken/theta/ledger/execution"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ProposalDryRun is the outcome of assembling a block proposal without proposing it
+type ProposalDryRun struct {
+	StateHash   common.Hash
+	Txs         []common.Bytes
+	GasUsed     uint64
+	Fees        types.Coins // fees paid by the included transactions
+	RejectedTxs []*RejectedTx
+}
+
+// RejectedTx is a transaction that would be left out of the block proposal
+type RejectedTx struct {
+	Hash   common.Hash
+	RawTx  common.Bytes
+	Code   result.ErrorCode
+	Reason string
+}
+
+func newRejectedTx(rawTx common.Bytes, res result.Result) *RejectedTx {
+	return &RejectedTx{
+		Hash:   crypto.Keccak256Hash(rawTx),
+		RawTx:  rawTx,
+		Code:   res.Code,
+		Reason: res.Message,
+	}
+}
+
+// DryRunProposal assembles a block proposal from the mempool the same way ProposeBlockTxs does, and
+// executes it on a copy of the delivered state. Neither the mempool nor the ledger state is modified,
+// and the block is left untouched. The locks of the mempool and the ledger are only held to take the
+// transactions and copy the state, so that the dry run does not delay the block processing.
+func (ledger *Ledger) DryRunProposal(block *core.Block) (*ProposalDryRun, result.Result) {
+	// Must always acquire locks in following order to avoid deadlock: mempool, ledger.
+	ledger.mempool.Lock()
+	ledger.mu.Lock()
+	regularRawTxs := ledger.mempool.PeekUnsafe(core.MaxNumRegularTxsPerBlock)
+	state, err := ledger.state.Copy()
+	ledger.mu.Unlock()
+	ledger.mempool.Unlock()
+	if err != nil {
+		return nil, result.Error("Failed to copy the ledger state: %v", err)
+	}
+
+	// The dry run ledger executes the transactions on the copied state only
+	dryRunLedger := &Ledger{
+		chain:        ledger.chain,
+		consensus:    ledger.consensus,
+		valMgr:       ledger.valMgr,
+		currentBlock: block,
+		mu:           &sync.RWMutex{},
+		state:        state,
+		executor:     exec.NewExecutor(state, ledger.consensus, ledger.valMgr),
+	}
+	view := state.Checked()
+	proposedTxs, rejectedTxs := dryRunLedger.checkProposalTxs(block, view, regularRawTxs)
+
+	dryRun := &ProposalDryRun{
+		Txs:         []common.Bytes{},
+		Fees:        types.NewCoins(0, 0),
+		RejectedTxs: rejectedTxs,
+	}
+	for _, ptx := range proposedTxs {
+		gasUsed := dryRunLedger.txGasUsed(ptx)
+		dryRun.Txs = append(dryRun.Txs, ptx.rawTx)
+		dryRun.GasUsed += gasUsed
+		dryRun.Fees = dryRun.Fees.Plus(types.TxFee(ptx.tx, gasUsed))
+	}
+	if dryRun.RejectedTxs == nil {
+		dryRun.RejectedTxs = []*RejectedTx{}
+	}
+
+	dryRunLedger.handleDelayedStateUpdates(view)
+	dryRun.StateHash = view.Hash()
+
+	return dryRun, result.OK
+}
+
+// txGasUsed returns the gas used by a checked transaction. Only smart contract transactions report
+// the gas actually used, the gas of the other transactions is fixed by their type.
+func (ledger *Ledger) txGasUsed(ptx *proposedTx) uint64 {
+	if gasUsed, ok := ptx.res.Info["gasUsed"].(uint64); ok {
+		return gasUsed
+	}
+	txInfo, res := ledger.executor.GetTxInfo(ptx.tx)
+	if res.IsError() {
+		return 0
+	}
+	return txInfo.Gas
+}
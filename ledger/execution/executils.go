@@ -3,7 +3,9 @@ package execution
 import (
 	"encoding/hex"
 	"math/big"
+	"strings"
 
+	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
@@ -65,10 +67,9 @@ func isAValidator(address common.Address, validatorAddresses []common.Address) r
 	return result.OK
 }
 
-// The accounts from the TxInputs must either already have
-// crypto.PubKey.(type) != nil, (it must be known),
-// or it must be specified in the TxInput.
-func getInputs(view *state.StoreView, ins []types.TxInput) (map[string]*types.Account, result.Result) {
+// The TxInputs do not carry the public keys. The signer public key is recovered from the
+// signature (using the recovery id) and checked against the input address, see validateInputAdvanced().
+func getInputs(chainID string, view *state.StoreView, ins []types.TxInput) (map[string]*types.Account, result.Result) {
 	accounts := map[string]*types.Account{}
 	for _, in := range ins {
 		// Account shouldn't be duplicated
@@ -80,30 +81,64 @@ func getInputs(view *state.StoreView, ins []types.TxInput) (map[string]*types.Ac
 		if success.IsError() {
 			return nil, result.Error("getInputs - Unknown address: %v", in.Address)
 		}
+		if res := checkNotMultiSig(chainID, view, in.Address); res.IsError() {
+			return nil, res
+		}
 
 		accounts[string(in.Address[:])] = acc
 	}
 	return accounts, result.OK
 }
 
-func getInput(view *state.StoreView, in types.TxInput) (*types.Account, result.Result) {
-	return getOrMakeInputImpl(view, in, false)
+func getInput(chainID string, view *state.StoreView, in types.TxInput) (*types.Account, result.Result) {
+	return getOrMakeInputImpl(chainID, view, in, false)
 }
 
-func getOrMakeInput(view *state.StoreView, in types.TxInput) (*types.Account, result.Result) {
-	return getOrMakeInputImpl(view, in, true)
+func getOrMakeInput(chainID string, view *state.StoreView, in types.TxInput) (*types.Account, result.Result) {
+	return getOrMakeInputImpl(chainID, view, in, true)
 }
 
-// This function guarantees the public key of the retrieved account is not empty
-func getOrMakeInputImpl(view *state.StoreView, in types.TxInput, makeNewAccount bool) (*types.Account, result.Result) {
+func getOrMakeInputImpl(chainID string, view *state.StoreView, in types.TxInput, makeNewAccount bool) (*types.Account, result.Result) {
 	acc, success := getOrMakeAccountImpl(view, in.Address, makeNewAccount)
 	if success.IsError() {
 		return nil, result.Error("getOrMakeInputImpl - Unknown address: %v", in.Address)
 	}
+	if res := checkNotMultiSig(chainID, view, in.Address); res.IsError() {
+		return nil, res
+	}
 
 	return acc, result.OK
 }
 
+// checkNotMultiSig rejects the single-signature inputs of the multisig accounts, which can only
+// sign with the MultiSigInputs, see getMultiSigInput(). There are no multisig accounts before the
+// fork, so the signer sets are not looked up.
+func checkNotMultiSig(chainID string, view *state.StoreView, address common.Address) result.Result {
+	if !core.IsForkActive(core.ForkMultiSig, chainID, view.Height()+1) {
+		return result.OK
+	}
+	if view.GetAccountSigners(address) != nil {
+		return result.Error("Account %v is a multisig account, it needs the signatures of its signers",
+			address).WithErrorCode(result.CodeMultiSigAccount)
+	}
+	return result.OK
+}
+
+// getMultiSigInput returns the account of the multisig input, and the signer set controlling it.
+// An account without a registered signer set is controlled by its own key.
+func getMultiSigInput(view *state.StoreView, in types.MultiSigInput) (*types.Account, *types.AccountSigners, result.Result) {
+	acc, success := getAccount(view, in.Address)
+	if success.IsError() {
+		return nil, nil, result.Error("getMultiSigInput - Unknown address: %v", in.Address)
+	}
+
+	signers := view.GetAccountSigners(in.Address)
+	if signers == nil {
+		signers = types.NewAccountSigners(in.Address)
+	}
+	return acc, signers, result.OK
+}
+
 func getAccount(view *state.StoreView, address common.Address) (*types.Account, result.Result) {
 	return getOrMakeAccountImpl(view, address, false)
 }
@@ -197,6 +232,81 @@ func validateInputAdvanced(acc *types.Account, signBytes []byte, in types.TxInpu
 	return result.OK
 }
 
+// validateMultiSigInput checks the sequence and the coins of the multisig input like
+// validateInputAdvanced(), and that at least the threshold number of distinct signers of the
+// account have signed the sign bytes.
+func validateMultiSigInput(acc *types.Account, signers *types.AccountSigners, signBytes []byte, in types.MultiSigInput) result.Result {
+	// Check sequence/coins
+	seq, balance := acc.Sequence, acc.Balance
+	if seq+1 != in.Sequence {
+		return result.Error("ValidateMultiSigInput: Got %v, expected %v. (acc.seq=%v)",
+			in.Sequence, seq+1, acc.Sequence).WithErrorCode(result.CodeInvalidSequence)
+	}
+
+	// Check amount
+	if !balance.IsGTE(in.Coins) {
+		return result.Error("Insufficient fund: balance is %v, tried to send %v",
+			balance, in.Coins).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	// Check signatures
+	signed := make(map[common.Address]bool)
+	for _, sig := range in.Signatures {
+		if !signers.IsSigner(sig.Signer) {
+			return result.Error("%v is not a signer of account %v", sig.Signer.Hex(),
+				in.Address.Hex()).WithErrorCode(result.CodeInvalidSignature)
+		}
+		if signed[sig.Signer] {
+			return result.Error("Duplicated signature of signer %v", sig.Signer.Hex()).
+				WithErrorCode(result.CodeInvalidSignature)
+		}
+		if !sig.Signature.Verify(signBytes, sig.Signer) {
+			return result.Error("Signature verification failed for signer %v, SignBytes: %v",
+				sig.Signer.Hex(), hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
+		}
+		signed[sig.Signer] = true
+	}
+	if uint64(len(signed)) < signers.Threshold {
+		return result.Error("Not enough signatures: %v signatures, %v of the %v signers need to sign",
+			len(signed), signers.Threshold, len(signers.Signers)).WithErrorCode(result.CodeNotEnoughSignatures)
+	}
+
+	return result.OK
+}
+
+// validateFeePayer checks the signature of the fee payer of the transaction, and that its
+// balance covers the given fee. The fee payer cannot be one of the senders of the transaction.
+func validateFeePayer(chainID string, view *state.StoreView, tx types.SponsoredTx, fee types.Coins, senders ...common.Address) (*types.Account, result.Result) {
+	feePayer := tx.GetFeePayer()
+	for _, sender := range senders {
+		if sender == feePayer.Address {
+			return nil, result.Error("The fee payer %v cannot be a sender of the transaction",
+				feePayer.Address.Hex()).WithErrorCode(result.CodeInvalidFeePayer)
+		}
+	}
+
+	acc, res := getAccount(view, feePayer.Address)
+	if res.IsError() {
+		return nil, result.Error("Unknown fee payer: %v", feePayer.Address.Hex()).WithErrorCode(result.CodeInvalidFeePayer)
+	}
+	if res := checkNotMultiSig(chainID, view, feePayer.Address); res.IsError() {
+		return nil, res
+	}
+
+	signBytes := tx.FeePayerSignBytes(chainID)
+	if !feePayer.Signature.Verify(signBytes, feePayer.Address) {
+		return nil, result.Error("Fee payer signature verification failed, SignBytes: %v",
+			hex.EncodeToString(signBytes)).WithErrorCode(result.CodeInvalidSignature)
+	}
+
+	if !acc.Balance.IsGTE(fee) {
+		return nil, result.Error("Insufficient fund: fee payer balance is %v, fee is %v",
+			acc.Balance, fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	return acc, result.OK
+}
+
 func validateOutputsBasic(outs []types.TxOutput) result.Result {
 	for _, out := range outs {
 		// Check TxOutput basic
@@ -256,10 +366,37 @@ func sanityCheckForGasPrice(gasPrice *big.Int) bool {
 	return true
 }
 
-func sanityCheckForFee(fee types.Coins) bool {
+// sanityCheckForFee checks the fee against the fee denomination policy of the chain. The fee
+// needs to be paid in TFuel, unless the chain is configured to accept fees in Theta (e.g. a
+// private deployment). The minimum fee always applies to the TFuel part.
+func sanityCheckForFee(chainID string, fee types.Coins) result.Result {
 	fee = fee.NoNil()
+	if fee.ThetaWei.Cmp(types.Zero) != 0 && !IsThetaFeeAllowed(chainID) {
+		return result.Error("Invalid fee denomination. Transaction fee needs to be paid in TFuel, got %v ThetaWei",
+			fee.ThetaWei).WithErrorCode(result.CodeInvalidFeeDenomination)
+	}
+	if fee.ThetaWei.Sign() < 0 {
+		return result.Error("Invalid fee. Transaction fee cannot be negative").WithErrorCode(result.CodeInvalidFee)
+	}
 	minimumFee := new(big.Int).SetUint64(types.MinimumTransactionFeeTFuelWei)
-	return fee.ThetaWei.Cmp(types.Zero) == 0 && fee.TFuelWei.Cmp(minimumFee) >= 0
+	if fee.TFuelWei.Cmp(minimumFee) < 0 {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
+			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	}
+	return result.OK
+}
+
+// IsThetaFeeAllowed returns whether the chain accepts the transaction fees paid in Theta
+func IsThetaFeeAllowed(chainID string) bool {
+	f := func(c rune) bool {
+		return c == ','
+	}
+	for _, id := range strings.FieldsFunc(viper.GetString(common.CfgLedgerThetaFeeChainIDs), f) {
+		if strings.TrimSpace(id) == chainID {
+			return true
+		}
+	}
+	return false
 }
 
 func chargeFee(account *types.Account, fee types.Coins) bool {
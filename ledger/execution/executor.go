@@ -29,16 +29,27 @@ type Executor struct {
 	consensus core.ConsensusEngine
 	valMgr    core.ValidatorManager
 
-	coinbaseTxExec *CoinbaseTxExecutor
-	// slashTxExec          *SlashTxExecutor
+	coinbaseTxExec       *CoinbaseTxExecutor
+	slashTxExec          *SlashTxExecutor
 	sendTxExec           *SendTxExecutor
+	multiSendTxExec      *MultiSendTxExecutor
 	reserveFundTxExec    *ReserveFundTxExecutor
 	releaseFundTxExec    *ReleaseFundTxExecutor
 	servicePaymentTxExec *ServicePaymentTxExecutor
 	splitRuleTxExec      *SplitRuleTxExecutor
 	//smartContractTxExec  *SmartContractTxExecutor
-	depositStakeTxExec  *DepositStakeExecutor
-	withdrawStakeTxExec *WithdrawStakeExecutor
+	depositStakeTxExec          *DepositStakeExecutor
+	withdrawStakeTxExec         *WithdrawStakeExecutor
+	setAccountOperatorTxExec    *SetAccountOperatorTxExecutor
+	servicePaymentDisputeTxExec *ServicePaymentDisputeTxExecutor
+	registerNodeAddressTxExec   *RegisterNodeAddressTxExecutor
+	slashReviewTxExec           *SlashReviewTxExecutor
+	dataCommitmentTxExec        *DataCommitmentTxExecutor
+	updateAccountSignersTxExec  *UpdateAccountSignersTxExecutor
+	multiSigSendTxExec          *MultiSigSendTxExecutor
+
+	// txExecutors maps the tx types to their executors, including the registered extension types
+	txExecutors map[types.TxType]TxExecutor
 
 	skipSanityCheck bool
 }
@@ -46,20 +57,51 @@ type Executor struct {
 // NewExecutor creates a new instance of Executor
 func NewExecutor(state *st.LedgerState, consensus core.ConsensusEngine, valMgr core.ValidatorManager) *Executor {
 	executor := &Executor{
-		state:          state,
-		consensus:      consensus,
-		valMgr:         valMgr,
-		coinbaseTxExec: NewCoinbaseTxExecutor(state, consensus, valMgr),
-		// slashTxExec:          NewSlashTxExecutor(consensus, valMgr),
+		state:                state,
+		consensus:            consensus,
+		valMgr:               valMgr,
+		coinbaseTxExec:       NewCoinbaseTxExecutor(state, consensus, valMgr),
+		slashTxExec:          NewSlashTxExecutor(consensus, valMgr),
 		sendTxExec:           NewSendTxExecutor(),
+		multiSendTxExec:      NewMultiSendTxExecutor(),
 		reserveFundTxExec:    NewReserveFundTxExecutor(state),
 		releaseFundTxExec:    NewReleaseFundTxExecutor(state),
 		servicePaymentTxExec: NewServicePaymentTxExecutor(state),
 		splitRuleTxExec:      NewSplitRuleTxExecutor(state),
 		//smartContractTxExec:  NewSmartContractTxExecutor(state),
-		depositStakeTxExec:  NewDepositStakeExecutor(),
-		withdrawStakeTxExec: NewWithdrawStakeExecutor(state),
-		skipSanityCheck:     false,
+		depositStakeTxExec:          NewDepositStakeExecutor(),
+		withdrawStakeTxExec:         NewWithdrawStakeExecutor(state),
+		setAccountOperatorTxExec:    NewSetAccountOperatorTxExecutor(state),
+		servicePaymentDisputeTxExec: NewServicePaymentDisputeTxExecutor(state),
+		registerNodeAddressTxExec:   NewRegisterNodeAddressTxExecutor(state),
+		slashReviewTxExec:           NewSlashReviewTxExecutor(state),
+		dataCommitmentTxExec:        NewDataCommitmentTxExecutor(state),
+		updateAccountSignersTxExec:  NewUpdateAccountSignersTxExecutor(state),
+		multiSigSendTxExec:          NewMultiSigSendTxExecutor(),
+		skipSanityCheck:             false,
+	}
+
+	executor.txExecutors = newExtensionTxExecutors(state)
+	for txType, txExecutor := range map[types.TxType]TxExecutor{
+		types.TxCoinbase:              executor.coinbaseTxExec,
+		types.TxSlash:                 executor.slashTxExec,
+		types.TxSend:                  executor.sendTxExec,
+		types.TxMultiSend:             executor.multiSendTxExec,
+		types.TxReserveFund:           executor.reserveFundTxExec,
+		types.TxReleaseFund:           executor.releaseFundTxExec,
+		types.TxServicePayment:        executor.servicePaymentTxExec,
+		types.TxSplitRule:             executor.splitRuleTxExec,
+		types.TxDepositStake:          executor.depositStakeTxExec,
+		types.TxWithdrawStake:         executor.withdrawStakeTxExec,
+		types.TxSetAccountOperator:    executor.setAccountOperatorTxExec,
+		types.TxServicePaymentDispute: executor.servicePaymentDisputeTxExec,
+		types.TxRegisterNodeAddress:   executor.registerNodeAddressTxExec,
+		types.TxSlashReview:           executor.slashReviewTxExec,
+		types.TxDataCommitment:        executor.dataCommitmentTxExec,
+		types.TxUpdateAccountSigners:  executor.updateAccountSignersTxExec,
+		types.TxMultiSigSend:          executor.multiSigSendTxExec,
+	} {
+		executor.txExecutors[txType] = txExecutor
 	}
 
 	return executor
@@ -97,6 +139,16 @@ func (exec *Executor) GetTxInfo(tx types.Tx) (*core.TxInfo, result.Result) {
 	return txInfo, result.OK
 }
 
+// SettleServicePayments transfers the service payments whose dispute window is over
+func (exec *Executor) SettleServicePayments(view *st.StoreView) {
+	exec.servicePaymentTxExec.settleDuePayments(view)
+}
+
+// BurnExpiredSlashes burns the slashed funds the council did not review in time
+func (exec *Executor) BurnExpiredSlashes(view *st.StoreView) {
+	exec.slashReviewTxExec.burnExpiredSlashes(view)
+}
+
 // processTx contains the main logic to process the transaction. If the tx is invalid, a TMSP error will be returned.
 func (exec *Executor) processTx(tx types.Tx, viewSel core.ViewSelector) (common.Hash, result.Result) {
 	chainID := exec.state.GetChainID()
@@ -110,12 +162,37 @@ func (exec *Executor) processTx(tx types.Tx, viewSel core.ViewSelector) (common.
 		view = exec.state.Screened()
 	}
 
+	if viewSel != core.DeliveredView {
+		if res := checkTxComplexity(tx); res.IsError() {
+			return common.Hash{}, res
+		}
+	}
+
+	blockHeight := view.Height() + 1 // the view points to the parent of the current block
+	if res := checkTxTypeActive(chainID, blockHeight, tx); res.IsError() {
+		return common.Hash{}, res
+	}
+	if types.GetTxFeePayer(tx) != nil && !core.IsForkActive(core.ForkFeePayer, chainID, blockHeight) {
+		return common.Hash{}, result.Error("Fee payers are not activated at height %v", blockHeight).
+			WithErrorCode(result.CodeInvalidFeePayer)
+	}
+	if etx, ok := tx.(types.ExpiringTx); ok && etx.ExpiryHeight() != 0 && !core.IsForkActive(core.ForkTxExpiry, chainID, blockHeight) {
+		return common.Hash{}, result.Error("Tx expiry heights are not activated at height %v", blockHeight)
+	}
+	if types.IsTxExpired(tx, blockHeight) {
+		return common.Hash{}, result.Error("Transaction expired at height %v, current block height: %v",
+			tx.(types.ExpiringTx).ExpiryHeight(), blockHeight).WithErrorCode(result.CodeTxExpired)
+	}
+
 	sanityCheckResult := exec.sanityCheck(chainID, view, tx)
 	if sanityCheckResult.IsError() {
 		return common.Hash{}, sanityCheckResult
 	}
 
 	txHash, processResult := exec.process(chainID, view, tx)
+	if viewSel == core.ScreenedView {
+		view.PopLogs() // receipts are only built from the checked and the delivered views
+	}
 	return txHash, processResult
 }
 
@@ -151,31 +228,11 @@ func (exec *Executor) process(chainID string, view *st.StoreView, tx types.Tx) (
 	return txHash, processResult
 }
 
+// getTxExecutor returns the executor of the tx type, or nil if the type is unknown
 func (exec *Executor) getTxExecutor(tx types.Tx) TxExecutor {
-	var txExecutor TxExecutor
-	switch tx.(type) {
-	case *types.CoinbaseTx:
-		txExecutor = exec.coinbaseTxExec
-	// case *types.SlashTx:
-	// 	txExecutor = exec.slashTxExec
-	case *types.SendTx:
-		txExecutor = exec.sendTxExec
-	case *types.ReserveFundTx:
-		txExecutor = exec.reserveFundTxExec
-	case *types.ReleaseFundTx:
-		txExecutor = exec.releaseFundTxExec
-	case *types.ServicePaymentTx:
-		txExecutor = exec.servicePaymentTxExec
-	case *types.SplitRuleTx:
-		txExecutor = exec.splitRuleTxExec
-	// case *types.SmartContractTx:
-	// 	txExecutor = exec.smartContractTxExec
-	case *types.DepositStakeTx:
-		txExecutor = exec.depositStakeTxExec
-	case *types.WithdrawStakeTx:
-		txExecutor = exec.withdrawStakeTxExec
-	default:
-		txExecutor = nil
+	txType, err := types.GetTxType(tx)
+	if err != nil {
+		return nil
 	}
-	return txExecutor
+	return exec.txExecutors[txType]
 }
@@ -0,0 +1,26 @@
+package execution
+
+import (
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// coreTxForks gives the forks activating the core transaction types added after the launch of the
+// chains, see checkTxTypeActive
+var coreTxForks = map[types.TxType]core.Fork{
+	types.TxServicePaymentDispute: core.ForkServicePaymentDispute,
+	types.TxSetAccountOperator:    core.ForkAccountOperator,
+	types.TxRegisterNodeAddress:   core.ForkNodeAddress,
+	types.TxMultiSend:             core.ForkMultiSend,
+	types.TxSlashReview:           core.ForkSlashReview,
+	types.TxDataCommitment:        core.ForkDataCommitment,
+	types.TxUpdateAccountSigners:  core.ForkMultiSig,
+	types.TxMultiSigSend:          core.ForkMultiSig,
+}
+
+func init() {
+	st.SetReservedFundIndexActivation(func(chainID string, height uint64) bool {
+		return core.IsForkActive(core.ForkReservedFundRemoval, chainID, height)
+	})
+}
@@ -0,0 +1,49 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestCoreTxForks(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txs := []types.Tx{
+		&types.ServicePaymentDisputeTx{},
+		&types.SetAccountOperatorTx{},
+		&types.RegisterNodeAddressTx{},
+		&types.MultiSendTx{},
+		&types.SlashReviewTx{},
+		&types.DataCommitmentTx{},
+		&types.UpdateAccountSignersTx{},
+		&types.MultiSigSendTx{},
+	}
+	assert.Equal(len(coreTxForks), len(txs))
+
+	height := et.state().Height() + 1
+	for _, tx := range txs {
+		txType, err := types.GetTxType(tx)
+		assert.Nil(err)
+		fork, ok := coreTxForks[txType]
+		assert.True(ok, "tx type %v is not gated", txType)
+
+		// The live chains do not accept the transactions before the fork is scheduled
+		assert.False(core.IsForkActive(fork, core.MainnetChainID, height))
+		assert.True(checkTxTypeActive(core.MainnetChainID, height, tx).IsError())
+
+		restore := et.setForkHeight(fork, height+1)
+		res := checkTxTypeActive(et.chainID, height, tx)
+		assert.True(res.IsError(), res.String())
+		res = checkTxTypeActive(et.chainID, height+1, tx)
+		assert.True(res.IsOK(), res.String())
+		restore()
+
+		// Chains launched after the fork accept them from their genesis
+		res = checkTxTypeActive(et.chainID, height, tx)
+		assert.True(res.IsOK(), res.String())
+	}
+}
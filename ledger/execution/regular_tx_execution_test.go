@@ -1,12 +1,18 @@
 package execution
 
 import (
+	"fmt"
+	"math"
 	"math/big"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/ledger/types"
 )
 
@@ -15,25 +21,25 @@ func TestGetInputs(t *testing.T) {
 	et := NewExecTest()
 
 	// nil submissions
-	acc, res := getInputs(nil, nil)
+	acc, res := getInputs(et.chainID, nil, nil)
 	assert.True(res.IsOK(), "getInputs: error on nil submission")
 	assert.Zero(len(acc), "getInputs: accounts returned on nil submission")
 
 	// test getInputs for registered, non-registered account
 	et.reset()
 	inputs := types.Accs2TxInputs(1, et.accIn)
-	acc, res = getInputs(et.state().Delivered(), inputs)
+	acc, res = getInputs(et.chainID, et.state().Delivered(), inputs)
 	assert.True(res.IsError(), "getInputs: expected error when using getInput with non-registered Input")
 
 	et.acc2State(et.accIn)
-	acc, res = getInputs(et.state().Delivered(), inputs)
+	acc, res = getInputs(et.chainID, et.state().Delivered(), inputs)
 	assert.True(res.IsOK(), "getInputs: expected to getInput from registered Input")
 
 	// test sending duplicate accounts
 	et.reset()
 	et.acc2State(et.accIn, et.accIn, et.accIn)
 	inputs = types.Accs2TxInputs(1, et.accIn, et.accIn, et.accIn)
-	acc, res = getInputs(et.state().Delivered(), inputs)
+	acc, res = getInputs(et.chainID, et.state().Delivered(), inputs)
 	assert.True(res.IsError(), "getInputs: expected error when sending duplicate accounts")
 
 	// test calculating reward
@@ -43,7 +49,7 @@ func TestGetInputs(t *testing.T) {
 	et.fastforwardBy(1000) // fastforward to reach a sufficient height for TFuel generation
 
 	inputs = types.Accs2TxInputs(1, et.accIn)
-	acc, res = getInputs(et.state().Delivered(), inputs)
+	acc, res = getInputs(et.chainID, et.state().Delivered(), inputs)
 	assert.True(res.IsOK(), "getInputs: expected to get input from a few block heights ago")
 	assert.True(acc[string(inputs[0].Address[:])].Balance.TFuelWei.Cmp(et.accIn.Balance.TFuelWei) == 0,
 		"getInputs: tfuel amount should not change")
@@ -127,7 +133,7 @@ func TestValidateInputsAdvanced(t *testing.T) {
 	tx := types.MakeSendTx(1, et.accOut, accIn1, accIn2, accIn3)
 
 	et.acc2State(accIn1, accIn2, accIn3, et.accOut)
-	accMap, res := getInputs(et.state().Delivered(), tx.Inputs)
+	accMap, res := getInputs(et.chainID, et.state().Delivered(), tx.Inputs)
 	assert.True(res.IsOK(), "validateInputsAdvanced: error retrieving accMap. Error: %v", res.Message)
 	signBytes := tx.SignBytes(et.chainID)
 
@@ -218,7 +224,7 @@ func TestAdjustBy(t *testing.T) {
 
 	txIn := types.Accs2TxInputs(1, et.accIn)
 	txOut := types.Accs2TxOutputs(et.accOut)
-	accMap, _ := getInputs(et.state().Delivered(), txIn)
+	accMap, _ := getInputs(et.chainID, et.state().Delivered(), txIn)
 	accMap, _ = getOrMakeOutputs(et.state().Delivered(), accMap, txOut)
 
 	adjustByInputs(et.state().Delivered(), accMap, txIn)
@@ -279,6 +285,211 @@ func TestSendTx(t *testing.T) {
 		"ExecTx/good DeliverTx: unexpected change in output balance, got: %v, expected: %v", balOut, balOutExp)
 }
 
+func TestSendTxExpiry(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	et.acc2State(et.accIn)
+	et.acc2State(et.accOut)
+	blockHeight := et.state().Screened().Height() + 1
+
+	tx := types.MakeSendTx(1, et.accOut, et.accIn)
+	tx.SetExpiryHeight(blockHeight)
+	et.signSendTx(tx, et.accIn)
+	res, _, _, _, _ := et.execSendTx(tx, true)
+	assert.Equal(result.CodeTxExpired, res.Code, res.String())
+	_, res = et.executor.ExecuteTx(tx)
+	assert.Equal(result.CodeTxExpired, res.Code, res.String())
+
+	tx.SetExpiryHeight(blockHeight + 1)
+	et.signSendTx(tx, et.accIn)
+	res, _, _, _, _ = et.execSendTx(tx, true)
+	assert.True(res.IsOK(), res.String())
+
+	// The expiry heights are rejected before the fork
+	restore := et.setForkHeight(core.ForkTxExpiry, math.MaxUint64)
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsError(), res.String())
+	restore()
+
+	res, balIn, balInExp, _, _ := et.execSendTx(tx, false)
+	assert.True(res.IsOK(), res.String())
+	assert.True(balIn.IsEqual(balInExp))
+}
+
+func TestSendTxFeePayer(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	accPayer := types.MakeAcc("payer")
+	et.acc2State(et.accIn, et.accOut, accPayer)
+
+	tx := types.MakeSendTx(1, et.accOut, et.accIn)
+	tx.Inputs[0].Coins = tx.Outputs[0].Coins
+	tx.SetFeePayer(&types.FeePayer{Address: accPayer.Address})
+	et.signSendTx(tx, et.accIn)
+
+	// The fee payer needs to sign the transaction
+	_, res := et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+	tx.SetSignature(accPayer.Address, accPayer.Sign(tx.FeePayerSignBytes(et.chainID)))
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsOK(), res.String())
+
+	// The fee payers are rejected before the fork
+	restore := et.setForkHeight(core.ForkFeePayer, math.MaxUint64)
+	_, res = et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidFeePayer, res.Code, res.String())
+	restore()
+
+	initBalPayer := et.state().Delivered().GetAccount(accPayer.Address).Balance
+	res, balIn, _, balOut, balOutExp := et.execSendTx(tx, false)
+	assert.True(res.IsOK(), res.String())
+	assert.True(balIn.IsEqual(et.accIn.Balance.Minus(tx.Outputs[0].Coins)), "got %v", balIn)
+	assert.True(balOut.IsEqual(balOutExp), "got %v, expected: %v", balOut, balOutExp)
+	balPayer := et.state().Delivered().GetAccount(accPayer.Address).Balance
+	assert.True(balPayer.IsEqual(initBalPayer.Minus(tx.Fee)), "got %v", balPayer)
+
+	// The inputs do not pay the fee when there is a fee payer
+	tx = types.MakeSendTx(2, et.accOut, et.accIn)
+	tx.SetFeePayer(&types.FeePayer{Address: accPayer.Address})
+	et.signSendTx(tx, et.accIn)
+	tx.SetSignature(accPayer.Address, accPayer.Sign(tx.FeePayerSignBytes(et.chainID)))
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsError())
+
+	// The fee payer cannot be one of the inputs
+	tx = types.MakeSendTx(2, et.accOut, et.accIn)
+	tx.Inputs[0].Coins = tx.Outputs[0].Coins
+	tx.SetFeePayer(&types.FeePayer{Address: et.accIn.Address})
+	et.signSendTx(tx, et.accIn)
+	tx.FeePayer.Signature = et.accIn.Sign(tx.FeePayerSignBytes(et.chainID))
+	_, res = et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidFeePayer, res.Code, res.String())
+}
+
+func TestSendTxFeeDenomination(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	tx := types.MakeSendTx(1, et.accOut, et.accIn)
+	tx.Fee = types.NewCoins(5, getMinimumTxFee()) // Theta-denominated fee
+	tx.Inputs[0].Coins = tx.Inputs[0].Coins.Plus(types.NewCoins(5, 0))
+	et.signSendTx(tx, et.accIn)
+
+	// Rejected with the dedicated error code at both CheckTx and DeliverTx
+	et.acc2State(et.accIn)
+	et.acc2State(et.accOut)
+	_, res := et.executor.CheckTx(tx)
+	assert.Equal(result.CodeInvalidFeeDenomination, res.Code, res.String())
+	res, balIn, _, balOut, _ := et.execSendTx(tx, false)
+	assert.Equal(result.CodeInvalidFeeDenomination, res.Code, res.String())
+	assert.True(balIn.IsEqual(et.accIn.Balance), "Rejected tx should not change the input balance")
+	assert.True(balOut.IsEqual(et.accOut.Balance), "Rejected tx should not change the output balance")
+
+	// Accepted on the chains configured to allow fees in Theta
+	viper.Set(common.CfgLedgerThetaFeeChainIDs, "privatenet1, "+et.chainID)
+	defer viper.Set(common.CfgLedgerThetaFeeChainIDs, "")
+
+	_, res = et.executor.CheckTx(tx)
+	assert.True(res.IsOK(), res.String())
+	res, balIn, balInExp, balOut, balOutExp := et.execSendTx(tx, false)
+	assert.True(res.IsOK(), res.String())
+	assert.True(balIn.IsEqual(balInExp), "got %v, expected: %v", balIn, balInExp)
+	assert.True(balOut.IsEqual(balOutExp), "got %v, expected: %v", balOut, balOutExp)
+
+	// The minimum fee still needs to be paid in TFuel
+	tx = types.MakeSendTx(2, et.accOut, et.accIn)
+	tx.Fee = types.NewCoins(5, 0)
+	tx.Inputs[0].Coins = types.NewCoins(5, 0).Plus(tx.Outputs[0].Coins)
+	et.signSendTx(tx, et.accIn)
+	_, res = et.executor.CheckTx(tx)
+	assert.Equal(result.CodeInvalidFee, res.Code, res.String())
+}
+
+func TestSendTxMemo(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+	et.acc2State(et.accIn, et.accOut)
+
+	tx := types.MakeSendTx(1, et.accOut, et.accIn)
+	gasWithoutMemo := et.executor.sendTxExec.calculateGas(tx)
+
+	// The memo is bounded
+	tx.Memo = make(common.Bytes, types.MaxSendTxMemoLength+1)
+	et.signSendTx(tx, et.accIn)
+	_, res := et.executor.ScreenTx(tx)
+	assert.True(res.IsError())
+
+	// The memo is charged gas
+	tx.Memo = common.Bytes("deposit 12345")
+	assert.Equal(gasWithoutMemo+13*types.GasSendTxMemoPerByte, et.executor.sendTxExec.calculateGas(tx))
+	et.signSendTx(tx, et.accIn)
+	_, res = et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidFee, res.Code, res.String())
+
+	fee := types.NewCoins(0, 0)
+	fee.TFuelWei = types.SendTxMinimumFee(len(tx.Memo))
+	tx.Inputs[0].Coins = tx.Inputs[0].Coins.Minus(tx.Fee).Plus(fee)
+	tx.Fee = fee
+	et.signSendTx(tx, et.accIn)
+	res, balIn, balInExp, balOut, balOutExp := et.execSendTx(tx, false)
+	assert.True(res.IsOK(), res.String())
+	assert.True(balIn.IsEqual(balInExp))
+	assert.True(balOut.IsEqual(balOutExp))
+}
+
+func TestMultiSendTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	et := NewExecTest()
+	et.acc2State(et.accIn)
+
+	// More outputs than a send transaction allows
+	numOutputs := MaxOutputsPerSendTx + 44
+	builder := types.NewMultiSendTxBuilder(et.accIn.Address, 1)
+	for i := 0; i < numOutputs; i++ {
+		to := common.BytesToAddress([]byte(fmt.Sprintf("payee%v", i)))
+		require.Nil(builder.AddOutput(to, types.NewCoins(1, int64(i)), []byte(fmt.Sprintf("payout %v", i))))
+	}
+	tx, err := builder.Build()
+	require.Nil(err)
+	sign := func() {
+		tx.Inputs[0].Signature = et.accIn.Sign(tx.SignBytes(et.chainID))
+	}
+	sign()
+
+	// The fee needs to cover the outputs
+	minimumFee := tx.Fee
+	tx.Fee = types.NewCoins(0, getMinimumTxFee())
+	tx.Inputs[0].Coins = tx.Inputs[0].Coins.Minus(minimumFee).Plus(tx.Fee)
+	sign()
+	_, res := et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeInvalidFee, res.Code, res.String())
+	tx.Inputs[0].Coins = tx.Inputs[0].Coins.Minus(tx.Fee).Plus(minimumFee)
+	tx.Fee = minimumFee
+
+	// The memos are bounded
+	tx.Outputs[0].Memo = make([]byte, types.MaxMultiSendMemoLength+1)
+	sign()
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsError())
+	tx.Outputs[0].Memo = nil
+	sign()
+
+	_, res = et.executor.ScreenTx(tx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.ExecuteTx(tx)
+	assert.True(res.IsOK(), res.String())
+
+	view := et.state().Delivered()
+	expected := et.accIn.Balance.Minus(tx.Inputs[0].Coins)
+	assert.True(expected.IsEqual(view.GetAccount(et.accIn.Address).Balance))
+	for i, out := range tx.Outputs {
+		assert.True(types.NewCoins(1, int64(i)).IsEqual(view.GetAccount(out.Address).Balance))
+	}
+}
+
 func TestSendDuplicatedInputOutput(t *testing.T) {
 	assert := assert.New(t)
 	et := NewExecTest()
@@ -538,7 +749,7 @@ func TestReserveFundTx(t *testing.T) {
 			Sequence: 1,
 		},
 		Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
-		ResourceIDs: []string{"rid001"},
+		ResourceIDs: []string{testResourceID},
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
@@ -555,7 +766,7 @@ func TestReserveFundTx(t *testing.T) {
 			Sequence: 1,
 		},
 		Collateral:  types.Coins{TFuelWei: big.NewInt(50001 * txFee), ThetaWei: big.NewInt(0)},
-		ResourceIDs: []string{"rid001"},
+		ResourceIDs: []string{testResourceID},
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
@@ -572,7 +783,7 @@ func TestReserveFundTx(t *testing.T) {
 			Sequence: 1,
 		},
 		Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
-		ResourceIDs: []string{"rid001"},
+		ResourceIDs: []string{testResourceID},
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
@@ -589,7 +800,7 @@ func TestReserveFundTx(t *testing.T) {
 			Sequence: 1,
 		},
 		Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
-		ResourceIDs: []string{"rid001"},
+		ResourceIDs: []string{testResourceID},
 		Duration:    1000,
 	}
 	tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
@@ -600,11 +811,125 @@ func TestReserveFundTx(t *testing.T) {
 
 	retrievedUserAcc := et.state().Delivered().GetAccount(user1.Address)
 	assert.Equal(1, len(retrievedUserAcc.ReservedFunds))
-	assert.Equal([]string{"rid001"}, retrievedUserAcc.ReservedFunds[0].ResourceIDs)
+	assert.Equal([]string{testResourceID}, retrievedUserAcc.ReservedFunds[0].ResourceIDs)
 	assert.Equal(types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)}, retrievedUserAcc.ReservedFunds[0].Collateral)
 	assert.Equal(uint64(1), retrievedUserAcc.ReservedFunds[0].ReserveSequence)
 }
 
+func TestReserveFundTxResourceIDFormat(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+
+	user1 := types.MakeAcc("user 1")
+	user1.Balance = types.Coins{
+		TFuelWei: big.NewInt(6200 * txFee),
+		ThetaWei: big.NewInt(10000 * 1e6),
+	}
+	et.acc2State(user1)
+
+	et.fastforwardTo(1e7)
+
+	createTx := func(resourceID string) *types.ReserveFundTx {
+		tx := &types.ReserveFundTx{
+			Fee: types.NewCoins(0, txFee),
+			Source: types.TxInput{
+				Address:  user1.Address,
+				Coins:    types.Coins{TFuelWei: big.NewInt(1000 * txFee), ThetaWei: big.NewInt(0)},
+				Sequence: 1,
+			},
+			Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
+			ResourceIDs: []string{resourceID},
+			Duration:    1000,
+		}
+		tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+	opaqueTx := createTx("rid001")
+	contentAddressedTx := createTx(types.NewResourceID([]byte("video manifest")))
+
+	// Opaque resource IDs are accepted before the fork
+	core.SetForkHeight(core.ForkResourceIDFormat, et.chainID, et.state().Height()+2)
+	defer core.SetForkHeight(core.ForkResourceIDFormat, et.chainID, 0)
+
+	res := et.executor.getTxExecutor(opaqueTx).sanityCheck(et.chainID, et.state().Delivered(), opaqueTx)
+	assert.True(res.IsOK(), res.String())
+
+	// Only content-addressed resource IDs are accepted after the fork
+	core.SetForkHeight(core.ForkResourceIDFormat, et.chainID, et.state().Height()+1)
+
+	res = et.executor.getTxExecutor(opaqueTx).sanityCheck(et.chainID, et.state().Delivered(), opaqueTx)
+	assert.False(res.IsOK(), res.String())
+	assert.Equal(result.CodeInvalidResourceID, res.Code)
+
+	res = et.executor.getTxExecutor(contentAddressedTx).sanityCheck(et.chainID, et.state().Delivered(), contentAddressedTx)
+	assert.True(res.IsOK(), res.String())
+}
+
+func TestReserveFundTxActiveReservedFundsLimit(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	maxReservedFunds := 3
+	SetMaxActiveReservedFunds(et.chainID, maxReservedFunds)
+	defer func() {
+		maxActiveReservedFundsMutex.Lock()
+		defer maxActiveReservedFundsMutex.Unlock()
+		delete(maxActiveReservedFunds, et.chainID)
+	}()
+
+	txFee := getMinimumTxFee()
+
+	user1 := types.MakeAcc("user 1")
+	user1.Balance = types.Coins{
+		TFuelWei: big.NewInt(int64(3000*txFee) * int64(maxReservedFunds+1)),
+		ThetaWei: big.NewInt(0),
+	}
+	et.acc2State(user1)
+
+	et.fastforwardTo(1e7)
+
+	makeTx := func(sequence uint64) *types.ReserveFundTx {
+		tx := &types.ReserveFundTx{
+			Fee: types.NewCoins(0, txFee),
+			Source: types.TxInput{
+				Address:  user1.Address,
+				Coins:    types.Coins{TFuelWei: big.NewInt(1000 * txFee), ThetaWei: big.NewInt(0)},
+				Sequence: sequence,
+			},
+			Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * txFee), ThetaWei: big.NewInt(0)},
+			ResourceIDs: []string{testResourceID},
+			Duration:    1000,
+		}
+		tx.Source.Signature = user1.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	for i := 1; i <= maxReservedFunds; i++ {
+		tx := makeTx(uint64(i))
+		res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+		assert.True(res.IsOK(), res.String())
+		_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+		assert.True(res.IsOK(), res.String())
+	}
+
+	tx := makeTx(uint64(maxReservedFunds + 1))
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeTooManyReservedFunds, res.Code, res.String())
+
+	// The limit is not enforced before the fork
+	restore := et.setForkHeight(core.ForkReservedFundLimit, et.state().Height()+2)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	restore()
+
+	// The expired reserved funds no longer count towards the limit
+	et.fastforwardTo(1e7 + 1000 + types.ReservedFundFreezePeriodDuration + 1)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+}
+
 func TestReleaseFundTx(t *testing.T) {
 	assert := assert.New(t)
 	et := NewExecTest()
@@ -630,7 +955,7 @@ func TestReleaseFundTx(t *testing.T) {
 			Sequence: 1,
 		},
 		Collateral:  types.Coins{TFuelWei: big.NewInt(1001 * 1e6), ThetaWei: big.NewInt(0)},
-		ResourceIDs: []string{"rid001"},
+		ResourceIDs: []string{testResourceID},
 		Duration:    1000,
 	}
 	reserveFundTx.Source.Signature = user1.Sign(reserveFundTx.SignBytes(et.chainID))
@@ -666,7 +991,7 @@ func TestReleaseFundTx(t *testing.T) {
 	releaseFundTx.Source.Signature = user1.Sign(releaseFundTx.SignBytes(et.chainID))
 	res = et.executor.getTxExecutor(releaseFundTx).sanityCheck(et.chainID, et.state().Delivered(), releaseFundTx)
 	assert.False(res.IsOK(), res.String())
-	assert.Equal(res.Code, result.CodeInvalidFee, res.String())
+	assert.Equal(res.Code, result.CodeInvalidFeeDenomination, res.String())
 
 	// Not expire yet
 	releaseFundTx = &types.ReleaseFundTx{
@@ -741,6 +1066,7 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	assert.True(res.IsOK(), res.Message)
 	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
 	et.state().Commit()
@@ -760,6 +1086,7 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	assert.True(res.IsOK(), res.Message)
 	_, res = et.executor.getTxExecutor(servicePaymentTx2).process(et.chainID, et.state().Delivered(), servicePaymentTx2)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
 	et.state().Commit()
@@ -778,6 +1105,7 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	assert.True(res.IsOK(), res.Message)
 	_, res = et.executor.getTxExecutor(servicePaymentTx3).process(et.chainID, et.state().Delivered(), servicePaymentTx3)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 
 	et.state().Commit()
@@ -798,6 +1126,7 @@ func TestServicePaymentTxNormalExecutionAndSlash(t *testing.T) {
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 	_, res = et.executor.getTxExecutor(servicePaymentTx4).process(et.chainID, et.state().Delivered(), servicePaymentTx4)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 	//assert.Equal(1, len(et.state().Delivered().GetSlashIntents()))
 }
 
@@ -824,6 +1153,7 @@ func TestServicePaymentTxExpiration(t *testing.T) {
 	assert.True(res.IsOK(), res.Message)
 	_, res = et.executor.getTxExecutor(servicePaymentTx1).process(et.chainID, et.state().Delivered(), servicePaymentTx1)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 
 	et.state().Commit()
 
@@ -958,6 +1288,7 @@ func TestSplitRuleTxNormalExecution(t *testing.T) {
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 
 	et.state().Commit()
 
@@ -1022,6 +1353,7 @@ func TestSplitRuleTxExpiration(t *testing.T) {
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 
 	et.state().Commit()
 
@@ -1182,6 +1514,10 @@ func TestSplitPaymentInternalMethod(t *testing.T) {
 func TestSplitRuleTxTargetAddressAlsoSplits(t *testing.T) {
 	assert := assert.New(t)
 	et, resourceID, alice, bob, carol, aliceInitBalance, bobInitBalance, carolInitBalance := setupForServicePayment(assert)
+
+	// The payment uses up Alice's reserved fund, which is kept until it expires before the fork
+	core.SetForkHeight(core.ForkReservedFundRemoval, et.chainID, math.MaxUint64)
+	defer core.SetForkHeight(core.ForkReservedFundRemoval, et.chainID, 0)
 	log.Infof("Bob's initial balance:   %v", bobInitBalance)
 	log.Infof("Carol's initial balance: %v", carolInitBalance)
 
@@ -1235,6 +1571,7 @@ func TestSplitRuleTxTargetAddressAlsoSplits(t *testing.T) {
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 
 	et.state().Commit()
 
@@ -1266,6 +1603,10 @@ func TestSplitRuleTxTargetAddressAlsoSplits(t *testing.T) {
 func TestSplitRuleHundredPercSplits(t *testing.T) {
 	assert := assert.New(t)
 	et, resourceID, alice, bob, carol, aliceInitBalance, bobInitBalance, carolInitBalance := setupForServicePayment(assert)
+
+	// The payment uses up Alice's reserved fund, which is kept until it expires before the fork
+	core.SetForkHeight(core.ForkReservedFundRemoval, et.chainID, math.MaxUint64)
+	defer core.SetForkHeight(core.ForkReservedFundRemoval, et.chainID, 0)
 	log.Infof("Bob's initial balance:   %v", bobInitBalance)
 	log.Infof("Carol's initial balance: %v", carolInitBalance)
 
@@ -1316,6 +1657,7 @@ func TestSplitRuleHundredPercSplits(t *testing.T) {
 	assert.Equal(0, len(et.state().Delivered().GetSlashIntents()))
 	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
 	assert.True(res.IsOK(), res.Message)
+	et.settleServicePayments()
 
 	et.state().Commit()
 
@@ -1343,3 +1685,322 @@ func TestSplitRuleHundredPercSplits(t *testing.T) {
 	assert.Equal(1, len(et.state().Delivered().GetAccount(alice.Address).ReservedFunds))
 	assert.True(et.state().Delivered().GetAccount(alice.Address).ReservedFunds[0].UsedFund.IsPositive())
 }
+
+func TestSetAccountOperatorTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, _, _ := setupForServicePayment(assert)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+	operator := types.MakeAcc("Alice Operator")
+
+	setOperatorTx := &types.SetAccountOperatorTx{
+		Fee: types.NewCoins(0, txFee),
+		Account: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		Operator:   operator.Address,
+		SpendLimit: types.NewCoins(0, 100*txFee),
+	}
+
+	// The operator cannot be set with the operator key
+	setOperatorTx.Account.Signature = operator.Sign(setOperatorTx.SignBytes(et.chainID))
+	res := et.executor.getTxExecutor(setOperatorTx).sanityCheck(et.chainID, et.state().Delivered(), setOperatorTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	// The spend limit can only be in TFuel
+	setOperatorTx.SpendLimit = types.NewCoins(1, 100*txFee)
+	setOperatorTx.Account.Signature = alice.Sign(setOperatorTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(setOperatorTx).sanityCheck(et.chainID, et.state().Delivered(), setOperatorTx)
+	assert.Equal(result.CodeInvalidAccountOperator, res.Code, res.String())
+
+	setOperatorTx.SpendLimit = types.NewCoins(0, 100*txFee)
+	setOperatorTx.Account.Signature = alice.Sign(setOperatorTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(setOperatorTx).sanityCheck(et.chainID, et.state().Delivered(), setOperatorTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(setOperatorTx).process(et.chainID, et.state().Delivered(), setOperatorTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	ao := et.state().Delivered().GetAccountOperator(alice.Address)
+	assert.NotNil(ao)
+	assert.Equal(operator.Address, ao.OperatorAddress)
+
+	// The operator can sign service payments up to the spend limit
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 60*txFee, 1, 1, 1, 1, resourceID)
+	servicePaymentTx.Source.Signature = operator.Sign(servicePaymentTx.SourceSignBytes(et.chainID))
+	servicePaymentTx.Target.Signature = bob.Sign(servicePaymentTx.TargetSignBytes(et.chainID))
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	et.settleServicePayments()
+	et.state().Commit()
+
+	ao = et.state().Delivered().GetAccountOperator(alice.Address)
+	assert.Equal(types.NewCoins(0, 60*txFee), ao.Spent)
+
+	servicePaymentTx = createServicePaymentTx(et.chainID, &alice, &bob, 110*txFee, 1, 2, 2, 1, resourceID)
+	servicePaymentTx.Source.Signature = operator.Sign(servicePaymentTx.SourceSignBytes(et.chainID))
+	servicePaymentTx.Target.Signature = bob.Sign(servicePaymentTx.TargetSignBytes(et.chainID))
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.Equal(result.CodeOperatorSpendLimitExceeded, res.Code, res.String())
+
+	// The operator cannot sign send transactions
+	sendTx := &types.SendTx{
+		Fee: types.NewCoins(0, txFee),
+		Inputs: []types.TxInput{
+			{
+				Address:  alice.Address,
+				Coins:    types.NewCoins(0, 10*txFee),
+				Sequence: 3,
+			},
+		},
+		Outputs: []types.TxOutput{
+			{
+				Address: operator.Address,
+				Coins:   types.NewCoins(0, 9*txFee),
+			},
+		},
+	}
+	sendTx.Inputs[0].Signature = operator.Sign(sendTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(sendTx).sanityCheck(et.chainID, et.state().Delivered(), sendTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	// Revoke the operator
+	revokeTx := &types.SetAccountOperatorTx{
+		Fee: types.NewCoins(0, txFee),
+		Account: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 3,
+		},
+	}
+	revokeTx.Account.Signature = alice.Sign(revokeTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(revokeTx).sanityCheck(et.chainID, et.state().Delivered(), revokeTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(revokeTx).process(et.chainID, et.state().Delivered(), revokeTx)
+	assert.True(res.IsOK(), res.String())
+	assert.Nil(et.state().Delivered().GetAccountOperator(alice.Address))
+
+	servicePaymentTx = createServicePaymentTx(et.chainID, &alice, &bob, 70*txFee, 1, 2, 2, 1, resourceID)
+	servicePaymentTx.Source.Signature = operator.Sign(servicePaymentTx.SourceSignBytes(et.chainID))
+	servicePaymentTx.Target.Signature = bob.Sign(servicePaymentTx.TargetSignBytes(et.chainID))
+	res = et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsError(), res.String())
+}
+
+func TestServicePaymentDisputeTx(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, bobInitBalance, _ := setupForServicePayment(assert)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+
+	// Bob settles an older payment
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 80*txFee, 1, 1, 1, 1, resourceID)
+	res := et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	// The payment is not transferred during the dispute window, only the fee is charged
+	assert.Equal(bobInitBalance.Minus(types.NewCoins(0, txFee)), et.state().Delivered().GetAccount(bob.Address).Balance)
+	settlement := et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1)
+	assert.NotNil(settlement)
+	assert.Equal(uint64(1), settlement.ServicePayment.PaymentSequence)
+
+	// The settlement cannot be replaced with the same payment sequence
+	staleTx := createServicePaymentTx(et.chainID, &alice, &bob, 90*txFee, 1, 2, 1, 1, resourceID)
+	res = et.executor.getTxExecutor(staleTx).sanityCheck(et.chainID, et.state().Delivered(), staleTx)
+	assert.Equal(result.CodeStaleServicePayment, res.Code, res.String())
+
+	disputeTx := &types.ServicePaymentDisputeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		Proof: *staleTx,
+	}
+	disputeTx.Source.Signature = alice.Sign(disputeTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(disputeTx).sanityCheck(et.chainID, et.state().Delivered(), disputeTx)
+	assert.Equal(result.CodeStaleServicePayment, res.Code, res.String())
+
+	// The proof must be countersigned by the target
+	proof := createServicePaymentTx(et.chainID, &alice, &bob, 50*txFee, 1, 2, 2, 1, resourceID)
+	proof.Target.Signature = alice.Sign(proof.TargetSignBytes(et.chainID))
+	disputeTx.Proof = *proof
+	disputeTx.Source.Signature = alice.Sign(disputeTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(disputeTx).sanityCheck(et.chainID, et.state().Delivered(), disputeTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	// Alice disputes with the newer payment
+	proof = createServicePaymentTx(et.chainID, &alice, &bob, 50*txFee, 1, 2, 2, 1, resourceID)
+	disputeTx.Proof = *proof
+	disputeTx.Source.Signature = alice.Sign(disputeTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(disputeTx).sanityCheck(et.chainID, et.state().Delivered(), disputeTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(disputeTx).process(et.chainID, et.state().Delivered(), disputeTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	settlement = et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1)
+	assert.Equal(uint64(2), settlement.ServicePayment.PaymentSequence)
+
+	// The settlement resolves to the payment with the highest payment sequence
+	et.settleServicePayments()
+	assert.Nil(et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1))
+	assert.Equal(bobInitBalance.Plus(types.NewCoins(0, 50*txFee)).Minus(types.NewCoins(0, txFee)),
+		et.state().Delivered().GetAccount(bob.Address).Balance)
+	aliceAcc := et.state().Delivered().GetAccount(alice.Address)
+	assert.Equal(types.NewCoins(0, 50*txFee), aliceAcc.ReservedFunds[0].UsedFund)
+
+	// The settlement can no longer be disputed
+	disputeTx.Source.Sequence = 3
+	disputeTx.Proof = *createServicePaymentTx(et.chainID, &alice, &bob, 60*txFee, 1, 2, 3, 1, resourceID)
+	disputeTx.Source.Signature = alice.Sign(disputeTx.SignBytes(et.chainID))
+	res = et.executor.getTxExecutor(disputeTx).sanityCheck(et.chainID, et.state().Delivered(), disputeTx)
+	assert.Equal(result.CodeNoPendingSettlement, res.Code, res.String())
+}
+
+func TestServicePaymentBeforeDisputeFork(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, bobInitBalance, _ := setupForServicePayment(assert)
+	et.state().Commit()
+	defer et.setForkHeight(core.ForkServicePaymentDispute, math.MaxUint64)()
+
+	txFee := getMinimumTxFee()
+
+	// The payment is transferred right away
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 80*txFee, 1, 1, 1, 1, resourceID)
+	res := et.executor.getTxExecutor(servicePaymentTx).sanityCheck(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	assert.Nil(et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1))
+	assert.Equal(bobInitBalance.Plus(types.NewCoins(0, 79*txFee)), et.state().Delivered().GetAccount(bob.Address).Balance)
+
+	// The dispute transactions are not accepted
+	disputeTx := &types.ServicePaymentDisputeTx{
+		Fee: types.NewCoins(0, txFee),
+		Source: types.TxInput{
+			Address:  alice.Address,
+			Sequence: 2,
+		},
+		Proof: *createServicePaymentTx(et.chainID, &alice, &bob, 90*txFee, 1, 2, 2, 1, resourceID),
+	}
+	disputeTx.Source.Signature = alice.Sign(disputeTx.SignBytes(et.chainID))
+	_, res = et.executor.CheckTx(disputeTx)
+	assert.True(res.IsError(), res.String())
+}
+
+func TestServicePaymentSettlementRetry(t *testing.T) {
+	assert := assert.New(t)
+	et, resourceID, alice, bob, _, _, bobInitBalance, _ := setupForServicePayment(assert)
+	et.state().Commit()
+
+	txFee := getMinimumTxFee()
+
+	servicePaymentTx := createServicePaymentTx(et.chainID, &alice, &bob, 80*txFee, 1, 1, 1, 1, resourceID)
+	_, res := et.executor.getTxExecutor(servicePaymentTx).process(et.chainID, et.state().Delivered(), servicePaymentTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	// The reserved fund does not cover the payment, so the settlement is kept and retried
+	view := et.state().Delivered()
+	aliceAcc := view.GetAccount(alice.Address)
+	aliceAcc.ReservedFunds[0].UsedFund = aliceAcc.ReservedFunds[0].InitialFund.Minus(types.NewCoins(0, 10*txFee))
+	view.SetAccount(alice.Address, aliceAcc)
+	et.settleServicePayments()
+
+	settlement := et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1)
+	assert.NotNil(settlement)
+	assert.Equal(et.state().Delivered().Height(), settlement.SettleHeight)
+	assert.Equal(bobInitBalance.Minus(types.NewCoins(0, txFee)), et.state().Delivered().GetAccount(bob.Address).Balance)
+
+	// The settlement is dropped once the reserved fund is released
+	view = et.state().Delivered()
+	aliceAcc = view.GetAccount(alice.Address)
+	aliceAcc.ReservedFunds = nil
+	view.SetAccount(alice.Address, aliceAcc)
+	et.settleServicePayments()
+	assert.Nil(et.state().Delivered().GetPendingSettlement(alice.Address, bob.Address, 1))
+}
+
+func TestRegisterNodeAddressTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	validator := types.MakeAccWithInitBalance("validator", types.NewCoins(0, 100*txFee))
+	other := types.MakeAccWithInitBalance("other", types.NewCoins(0, 100*txFee))
+	et.acc2State(validator, other)
+	et.state().Delivered().UpdateValidatorCandidatePool(&core.ValidatorCandidatePool{
+		SortedCandidates: []*core.StakeHolder{{Holder: validator.Address, Stakes: []*core.Stake{}}},
+	})
+	et.state().Commit()
+
+	newTx := func(acc types.PrivAccount, sequence uint64, endpoints ...string) *types.RegisterNodeAddressTx {
+		tx := &types.RegisterNodeAddressTx{
+			Fee: types.NewCoins(0, txFee),
+			Validator: types.TxInput{
+				Address:  acc.Address,
+				Sequence: sequence,
+			},
+			Endpoints: endpoints,
+		}
+		tx.Validator.Signature = acc.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	// Only the validator candidates can register
+	tx := newTx(other, 1, "203.0.113.7:12000")
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeNotAValidator, res.Code, res.String())
+
+	// The endpoints must be well formed
+	for _, endpoint := range []string{"203.0.113.7", "203.0.113.7:0", "-invalid.example.com:12000", "validator one:12000"} {
+		tx = newTx(validator, 1, endpoint)
+		res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+		assert.Equal(result.CodeInvalidNodeAddress, res.Code, endpoint)
+	}
+	tx = newTx(validator, 1, "203.0.113.7:12000", "203.0.113.7:12000")
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeInvalidNodeAddress, res.Code, res.String())
+
+	tx = newTx(validator, 1, "203.0.113.7:12000", "validator1.example.com:12000")
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	nodeAddress := et.state().Delivered().GetNodeAddress(validator.Address)
+	assert.NotNil(nodeAddress)
+	assert.Equal([]string{"203.0.113.7:12000", "validator1.example.com:12000"}, nodeAddress.Endpoints)
+
+	// The previous endpoints stay active for the grace period after a rotation
+	tx = newTx(validator, 2, "198.51.100.9:12000")
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	nodeAddress = et.state().Delivered().GetNodeAddress(validator.Address)
+	height := nodeAddress.RotationHeight
+	assert.Equal([]string{"198.51.100.9:12000", "203.0.113.7:12000", "validator1.example.com:12000"}, nodeAddress.ActiveEndpoints(height))
+	assert.Equal([]string{"198.51.100.9:12000"}, nodeAddress.ActiveEndpoints(height+core.NodeAddressRotationGracePeriod))
+
+	// Registering no endpoint removes the registration
+	tx = newTx(validator, 3)
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	assert.Nil(et.state().Delivered().GetNodeAddress(validator.Address))
+}
@@ -0,0 +1,20 @@
+package execution
+
+import (
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// checkResourceIDs checks the resource IDs are content addressed, once enforced on the chain.
+func checkResourceIDs(chainID string, height uint64, resourceIDs ...string) result.Result {
+	if !core.IsForkActive(core.ForkResourceIDFormat, chainID, height) {
+		return result.OK
+	}
+	for _, resourceID := range resourceIDs {
+		if err := types.ValidateResourceID(resourceID); err != nil {
+			return result.Error("Invalid resource ID: %v", err).WithErrorCode(result.CodeInvalidResourceID)
+		}
+	}
+	return result.OK
+}
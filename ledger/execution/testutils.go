@@ -1,6 +1,7 @@
 package execution
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -26,16 +27,30 @@ type TestConsensusEngine struct {
 	privKey *crypto.PrivateKey
 }
 
-func (tce *TestConsensusEngine) ID() string                        { return tce.privKey.PublicKey().Address().Hex() }
-func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey    { return tce.privKey }
-func (tce *TestConsensusEngine) GetTip(bool) *core.ExtendedBlock   { return nil }
-func (tce *TestConsensusEngine) GetEpoch() uint64                  { return 100 }
-func (tce *TestConsensusEngine) AddMessage(msg interface{})        {}
-func (tce *TestConsensusEngine) FinalizedBlocks() chan *core.Block { return nil }
-func (tce *TestConsensusEngine) GetLedger() core.Ledger            { return nil }
+func (tce *TestConsensusEngine) ID() string                      { return tce.privKey.PublicKey().Address().Hex() }
+func (tce *TestConsensusEngine) PrivateKey() *crypto.PrivateKey  { return tce.privKey }
+func (tce *TestConsensusEngine) GetTip(bool) *core.ExtendedBlock { return nil }
+func (tce *TestConsensusEngine) GetEpoch() uint64                { return 100 }
+func (tce *TestConsensusEngine) AddMessage(msg interface{})      {}
+func (tce *TestConsensusEngine) GetLedger() core.Ledger          { return nil }
+func (tce *TestConsensusEngine) SetLedger(ledger core.Ledger)    {}
+func (tce *TestConsensusEngine) IsInRecovery() bool              { return false }
+func (tce *TestConsensusEngine) Start(ctx context.Context)       {}
+func (tce *TestConsensusEngine) Stop()                           {}
+func (tce *TestConsensusEngine) Wait()                           {}
+func (tce *TestConsensusEngine) IsFinalized(common.Hash) bool    { return false }
 func (tce *TestConsensusEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return &core.ExtendedBlock{}
 }
+func (tce *TestConsensusEngine) SubscribeFinalizedBlocks(bufferSize int) *core.FinalitySubscription {
+	return core.NewFinalityFeed().Subscribe(bufferSize)
+}
+func (tce *TestConsensusEngine) SubscribeValidatedBlocks(bufferSize int) *core.FinalitySubscription {
+	return core.NewFinalityFeed().Subscribe(bufferSize)
+}
+func (tce *TestConsensusEngine) GetFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	return nil, fmt.Errorf("Block %v is not finalized", hash.Hex())
+}
 
 func NewTestConsensusEngine(seed string) *TestConsensusEngine {
 	privKey, _, _ := crypto.TEST_GenerateKeyPairWithSeed(seed)
@@ -90,7 +105,7 @@ func NewExecTest() *execTest {
 	return et
 }
 
-//reset everything. state is empty
+// reset everything. state is empty
 func (et *execTest) reset() {
 	et.accIn = types.MakeAccWithInitBalance("foo", types.NewCoins(700000, 50*getMinimumTxFee()))
 	et.accOut = types.MakeAccWithInitBalance("bar", types.NewCoins(700000, 50*getMinimumTxFee()))
@@ -136,6 +151,19 @@ func (et *execTest) fastforwardTo(targetHeight uint64) bool {
 	return true
 }
 
+// setForkHeight sets the height of the fork on the test chain, and returns a function restoring it
+func (et *execTest) setForkHeight(fork core.Fork, height uint64) (restore func()) {
+	core.SetForkHeight(fork, et.chainID, height)
+	return func() { core.SetForkHeight(fork, et.chainID, 0) }
+}
+
+// settleServicePayments fast forwards over the dispute window, and transfers the pending service payments
+func (et *execTest) settleServicePayments() {
+	et.fastforwardBy(types.ServicePaymentDisputeWindow + 1)
+	et.executor.SettleServicePayments(et.state().Delivered())
+	et.state().Commit()
+}
+
 func (et *execTest) signSendTx(tx *types.SendTx, accsIn ...types.PrivAccount) {
 	types.SignSendTx(et.chainID, tx, accsIn...)
 }
@@ -220,6 +248,9 @@ func createServicePaymentTx(chainID string, source, target *types.PrivAccount, a
 	return servicePaymentTx
 }
 
+// testResourceID is the content-addressed resource ID of the reserved funds of the tests
+var testResourceID = types.NewResourceID([]byte("rid001"))
+
 func setupForServicePayment(ast *assert.Assertions) (et *execTest, resourceID string,
 	alice, bob, carol types.PrivAccount, aliceInitBalance, bobInitBalance, carolInitBalance types.Coins) {
 	et = NewExecTest()
@@ -244,7 +275,7 @@ func setupForServicePayment(ast *assert.Assertions) (et *execTest, resourceID st
 
 	et.fastforwardTo(1e2)
 
-	resourceID = "rid001"
+	resourceID = testResourceID
 	reserveFundTx := &types.ReserveFundTx{
 		Fee: types.NewCoins(0, getMinimumTxFee()),
 		Source: types.TxInput{
@@ -1,7 +1,9 @@
 package execution
 
 import (
+	"bytes"
 	"math/big"
+	"sort"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
@@ -51,7 +53,7 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 		return res
 	}
 
-	proposerAccount, res := getOrMakeInput(view, tx.Proposer)
+	proposerAccount, res := getOrMakeInput(chainID, view, tx.Proposer)
 	if res.IsError() {
 		return res
 	}
@@ -73,14 +75,18 @@ func (exec *CoinbaseTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 			tx.BlockHeight, exec.state.Height())
 	}
 
-	// check the reward amount
-	expectedRewards := CalculateReward(view, validatorAddresses)
+	// check the reward cohort and the reward amount
+	expectedRewards := CalculateReward(chainID, view, validatorAddresses, tx.BlockHeight)
 	if len(expectedRewards) != len(tx.Outputs) {
 		return result.Error("Number of rewarded account is incorrect")
 	}
 	for _, output := range tx.Outputs {
 		exp, ok := expectedRewards[string(output.Address[:])]
-		if !ok || !exp.IsEqual(output.Coins) {
+		if !ok {
+			return result.Error("Invalid rewards, address %v is not in the reward cohort of block %v",
+				output.Address, tx.BlockHeight)
+		}
+		if !exp.IsEqual(output.Coins) {
 			return result.Error("Invalid rewards, address %v expecting %v, but is %v",
 				output.Address, exp, output.Coins)
 		}
@@ -115,19 +121,65 @@ func (exec *CoinbaseTxExecutor) process(chainID string, view *st.StoreView, tran
 	return txHash, result.OK
 }
 
-// CalculateReward calculates the block reward for each account
-func CalculateReward(view *st.StoreView, validatorAddresses []common.Address) map[string]types.Coins {
+// MaxRewardRecipientsPerBlock is the max number of accounts rewarded by the coinbase transaction
+// of a block. The reward recipients beyond it are split into cohorts rewarded in turn, which
+// bounds the size of the blocks and the time to commit them.
+const MaxRewardRecipientsPerBlock = 256
+
+// CalculateReward calculates the block reward for each account of the reward cohort of the block
+// at the given height. Each account is rewarded once per rotation of the cohorts, for all the
+// blocks of the rotation.
+func CalculateReward(chainID string, view *st.StoreView, validatorAddresses []common.Address, blockHeight uint64) map[string]types.Coins {
 	accountReward := map[string]types.Coins{}
 
-	for _, validatorAddress := range validatorAddresses {
+	cohort, numCohorts := RewardCohort(chainID, validatorAddresses, blockHeight)
+	for _, address := range cohort {
 		// Initial Mainnet release should not reward the validators until the guardians ready to deploy
 		zeroReward := types.Coins{}.NoNil()
-		accountReward[string(validatorAddress[:])] = zeroReward
+		accountReward[string(address[:])] = rewardForCohort(zeroReward, numCohorts)
 	}
 
 	return accountReward
 }
 
+// RewardCohort returns the recipients rewarded at the given height, and the number of cohorts.
+// The recipients are sorted by address and split into cohorts of at most MaxRewardRecipientsPerBlock
+// accounts, which the blocks reward in a round-robin, so that every proposer selects the same cohort.
+// Before the core.ForkRewardCohort height, all the recipients are rewarded by every block.
+func RewardCohort(chainID string, recipients []common.Address, blockHeight uint64) ([]common.Address, uint64) {
+	sorted := make([]common.Address, 0, len(recipients))
+	seen := make(map[common.Address]bool)
+	for _, address := range recipients {
+		if !seen[address] {
+			seen[address] = true
+			sorted = append(sorted, address)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+	if len(sorted) <= MaxRewardRecipientsPerBlock || !core.IsForkActive(core.ForkRewardCohort, chainID, blockHeight) {
+		return sorted, 1
+	}
+
+	numCohorts := uint64((len(sorted) + MaxRewardRecipientsPerBlock - 1) / MaxRewardRecipientsPerBlock)
+	start := int(blockHeight%numCohorts) * MaxRewardRecipientsPerBlock
+	end := start + MaxRewardRecipientsPerBlock
+	if end > len(sorted) {
+		end = len(sorted)
+	}
+	return sorted[start:end], numCohorts
+}
+
+// rewardForCohort scales the reward of a block to the number of blocks in a rotation of the cohorts
+func rewardForCohort(blockReward types.Coins, numCohorts uint64) types.Coins {
+	scale := new(big.Int).SetUint64(numCohorts)
+	return types.Coins{
+		ThetaWei: new(big.Int).Mul(blockReward.ThetaWei, scale),
+		TFuelWei: new(big.Int).Mul(blockReward.TFuelWei, scale),
+	}
+}
+
 func (exec *CoinbaseTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 	return &core.TxInfo{
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
@@ -0,0 +1,87 @@
+package execution
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const testChainID = "test_chain_id"
+
+func createRewardRecipients(num int) []common.Address {
+	recipients := []common.Address{}
+	for i := 0; i < num; i++ {
+		recipients = append(recipients, common.BytesToAddress([]byte(fmt.Sprintf("recipient%v", i))))
+	}
+	return recipients
+}
+
+func TestRewardCohort(t *testing.T) {
+	assert := assert.New(t)
+
+	// All the recipients are rewarded by every block, unless there are too many of them
+	recipients := createRewardRecipients(MaxRewardRecipientsPerBlock)
+	cohort, numCohorts := RewardCohort(testChainID, recipients, 10)
+	assert.Equal(uint64(1), numCohorts)
+	assert.Equal(len(recipients), len(cohort))
+
+	// Each recipient is rewarded once per rotation of the cohorts
+	recipients = createRewardRecipients(3*MaxRewardRecipientsPerBlock + 1)
+	rewarded := map[common.Address]int{}
+	for height := uint64(100); height < 104; height++ {
+		cohort, numCohorts := RewardCohort(testChainID, recipients, height)
+		assert.Equal(uint64(4), numCohorts)
+		assert.True(len(cohort) <= MaxRewardRecipientsPerBlock)
+		for _, address := range cohort {
+			rewarded[address]++
+		}
+	}
+	assert.Equal(len(recipients), len(rewarded))
+	for _, count := range rewarded {
+		assert.Equal(1, count)
+	}
+
+	// The cohorts depend on neither the order of the recipients nor the duplicates
+	reversed := []common.Address{}
+	for i := len(recipients) - 1; i >= 0; i-- {
+		reversed = append(reversed, recipients[i], recipients[i])
+	}
+	for height := uint64(100); height < 104; height++ {
+		expected, _ := RewardCohort(testChainID, recipients, height)
+		actual, _ := RewardCohort(testChainID, reversed, height)
+		assert.Equal(expected, actual)
+	}
+	next, _ := RewardCohort(testChainID, recipients, 104)
+	first, _ := RewardCohort(testChainID, recipients, 100)
+	assert.Equal(first, next)
+
+	// All the recipients are rewarded by every block before the fork
+	core.SetForkHeight(core.ForkRewardCohort, testChainID, 200)
+	defer core.SetForkHeight(core.ForkRewardCohort, testChainID, 0)
+	cohort, numCohorts = RewardCohort(testChainID, recipients, 199)
+	assert.Equal(uint64(1), numCohorts)
+	assert.Equal(len(recipients), len(cohort))
+	cohort, numCohorts = RewardCohort(testChainID, recipients, 200)
+	assert.Equal(uint64(4), numCohorts)
+	assert.Equal(MaxRewardRecipientsPerBlock, len(cohort))
+}
+
+func TestCalculateRewardCohort(t *testing.T) {
+	assert := assert.New(t)
+
+	recipients := createRewardRecipients(2*MaxRewardRecipientsPerBlock + 1)
+	rewards := CalculateReward(testChainID, nil, recipients, 7)
+	cohort, _ := RewardCohort(testChainID, recipients, 7)
+	assert.Equal(len(cohort), len(rewards))
+	for _, address := range cohort {
+		reward, ok := rewards[string(address[:])]
+		assert.True(ok)
+		assert.True(reward.IsEqual(types.NewCoins(0, 0)))
+	}
+
+	assert.True(rewardForCohort(types.NewCoins(2, 3), 4).IsEqual(types.NewCoins(8, 12)))
+}
@@ -0,0 +1,121 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*DataCommitmentTxExecutor)(nil)
+
+// ------------------------------- DataCommitment Transaction -----------------------------------
+
+// DataCommitmentTxExecutor implements the TxExecutor interface
+type DataCommitmentTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewDataCommitmentTxExecutor creates a new instance of DataCommitmentTxExecutor
+func NewDataCommitmentTxExecutor(state *st.LedgerState) *DataCommitmentTxExecutor {
+	return &DataCommitmentTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *DataCommitmentTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.DataCommitmentTx)
+
+	res := tx.Owner.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get inputs
+	account, res := getInput(chainID, view, tx.Owner)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(account, signBytes, tx.Owner)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	minimalBalance := tx.Fee
+	if !account.Balance.IsGTE(minimalBalance) {
+		logger.Infof("the account did not have enough to cover the fee %X", tx.Owner.Address)
+		return result.Error("the account balance is %v, but required minimal balance is %v", account.Balance, minimalBalance)
+	}
+
+	if tx.Root.IsEmpty() {
+		return result.Error("Merkle root must be specified").WithErrorCode(result.CodeInvalidDataCommitment)
+	}
+	if tx.NumLeaves == 0 {
+		return result.Error("At least one leaf must be committed").WithErrorCode(result.CodeInvalidDataCommitment)
+	}
+	if len(tx.URI) > types.MaxDataCommitmentURILength {
+		return result.Error("URI too long: %v bytes, at most %v bytes allowed",
+			len(tx.URI), types.MaxDataCommitmentURILength).WithErrorCode(result.CodeInvalidDataCommitment)
+	}
+
+	if commitment := view.GetDataCommitment(tx.Root); commitment != nil {
+		return result.Error("Root %v is already committed at height %v", tx.Root.Hex(), commitment.Height).
+			WithErrorCode(result.CodeDataCommitmentExists)
+	}
+
+	return result.OK
+}
+
+func (exec *DataCommitmentTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.DataCommitmentTx)
+
+	account, res := getInput(chainID, view, tx.Owner)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	if !chargeFee(account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	view.SetDataCommitment(&types.DataCommitment{
+		Root:      tx.Root,
+		Owner:     tx.Owner.Address,
+		NumLeaves: tx.NumLeaves,
+		URI:       tx.URI,
+		Height:    view.Height() + 1,
+	})
+
+	account.Sequence++
+	view.SetAccount(tx.Owner.Address, account)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *DataCommitmentTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.DataCommitmentTx)
+	return &core.TxInfo{
+		Address:           tx.Owner.Address,
+		Sequence:          tx.Owner.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasDataCommitmentTx,
+	}
+}
+
+func (exec *DataCommitmentTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.DataCommitmentTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasDataCommitmentTx)
+	return effectiveGasPrice
+}
@@ -0,0 +1,73 @@
+package execution
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestDataCommitmentTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	owner := types.MakeAccWithInitBalance("owner", types.NewCoins(0, 100*txFee))
+	other := types.MakeAccWithInitBalance("other", types.NewCoins(0, 100*txFee))
+	et.acc2State(owner, other)
+	et.state().Commit()
+
+	leaves := [][]byte{[]byte("manifest 1"), []byte("manifest 2"), []byte("license")}
+	root := types.DataCommitmentRoot(leaves)
+
+	newTx := func(acc types.PrivAccount, sequence uint64, root common.Hash, numLeaves uint64, uri string) *types.DataCommitmentTx {
+		tx := &types.DataCommitmentTx{
+			Fee: types.NewCoins(0, txFee),
+			Owner: types.TxInput{
+				Address:  acc.Address,
+				Sequence: sequence,
+			},
+			Root:      root,
+			NumLeaves: numLeaves,
+			URI:       uri,
+		}
+		tx.Owner.Signature = acc.Sign(tx.SignBytes(et.chainID))
+		return tx
+	}
+
+	// The commitment must be well formed
+	for _, tx := range []*types.DataCommitmentTx{
+		newTx(owner, 1, common.Hash{}, 3, ""),
+		newTx(owner, 1, root, 0, ""),
+		newTx(owner, 1, root, 3, strings.Repeat("a", types.MaxDataCommitmentURILength+1)),
+	} {
+		res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+		assert.Equal(result.CodeInvalidDataCommitment, res.Code, res.String())
+	}
+
+	tx := newTx(owner, 1, root, 3, "https://example.com/manifests")
+	res := et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(tx).process(et.chainID, et.state().Delivered(), tx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	commitment := et.state().Delivered().GetDataCommitment(root)
+	assert.NotNil(commitment)
+	assert.Equal(owner.Address, commitment.Owner)
+	assert.Equal(uint64(3), commitment.NumLeaves)
+	assert.Equal("https://example.com/manifests", commitment.URI)
+	assert.Equal(et.state().Height(), commitment.Height)
+
+	proof, err := types.ProveDataCommitment(leaves, 2)
+	assert.Nil(err)
+	assert.Nil(types.VerifyDataCommitmentProof(commitment.Root, leaves[2], proof))
+
+	// A root can only be committed once
+	tx = newTx(other, 1, root, 3, "")
+	res = et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	assert.Equal(result.CodeDataCommitmentExists, res.Code, res.String())
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -32,7 +33,7 @@ func (exec *DepositStakeExecutor) sanityCheck(chainID string, view *st.StoreView
 		return res
 	}
 
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return result.Error("Failed to get the source account: %v", tx.Source.Address)
 	}
@@ -44,9 +45,8 @@ func (exec *DepositStakeExecutor) sanityCheck(chainID string, view *st.StoreView
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
 	}
 
 	if !(tx.Purpose == core.StakeForValidator || tx.Purpose == core.StakeForGuardian) {
@@ -84,7 +84,7 @@ func (exec *DepositStakeExecutor) sanityCheck(chainID string, view *st.StoreView
 func (exec *DepositStakeExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.DepositStakeTx)
 
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return common.Hash{}, result.Error("Failed to get the source account")
 	}
@@ -137,13 +137,13 @@ func (exec *DepositStakeExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasDepositStakeTx,
 	}
 }
 
 func (exec *DepositStakeExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.DepositStakeTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasDepositStakeTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasDepositStakeTx)
 	return effectiveGasPrice
 }
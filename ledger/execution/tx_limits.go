@@ -0,0 +1,92 @@
+package execution
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const (
+	// MaxTxSizeInBytes is the max size of a raw transaction accepted into the mempool
+	MaxTxSizeInBytes = 64 * 1024
+
+	// MaxMultiSendTxSizeInBytes is the max size of a raw multi-send transaction, which batches
+	// up to types.MaxMultiSendOutputs outputs
+	MaxMultiSendTxSizeInBytes = 1024 * 1024
+
+	// MaxInputsPerSendTx is the max number of inputs of a send transaction
+	MaxInputsPerSendTx = 256
+
+	// MaxOutputsPerSendTx is the max number of outputs of a send transaction
+	MaxOutputsPerSendTx = 256
+
+	// MaxInputsPerMultiSendTx is the max number of inputs of a multi-send transaction
+	MaxInputsPerMultiSendTx = 16
+
+	// MaxSplitsPerSplitRuleTx is the max number of splits of a split rule transaction
+	MaxSplitsPerSplitRuleTx = 64
+
+	// MaxResourceIDsPerReserveFundTx is the max number of resource IDs of a reserve fund transaction
+	MaxResourceIDsPerReserveFundTx = 128
+)
+
+//
+// CheckTxSize checks the size of the raw transaction. It should be called before decoding
+// the transaction so that oversized transactions are rejected cheaply.
+//
+func CheckTxSize(rawTx common.Bytes) result.Result {
+	maxSize := MaxTxSizeInBytes
+	if txType, err := types.PeekTxType(rawTx); err == nil && txType == types.TxMultiSend {
+		maxSize = MaxMultiSendTxSizeInBytes
+	}
+	if len(rawTx) > maxSize {
+		return result.Error("Transaction too large: %v bytes, at most %v bytes allowed",
+			len(rawTx), maxSize).WithErrorCode(result.CodeTxTooLarge)
+	}
+	return result.OK
+}
+
+//
+// checkTxComplexity checks the number of the items in the transaction that the ledger needs
+// to iterate over, so that resource-exhaustion transactions never enter the mempool. The limits
+// are only enforced at CheckTx, transactions in the proposed blocks are not affected.
+//
+func checkTxComplexity(tx types.Tx) result.Result {
+	switch tx := tx.(type) {
+	case *types.SendTx:
+		if len(tx.Inputs) > MaxInputsPerSendTx {
+			return result.Error("Too many inputs: %v, at most %v inputs are allowed per send transaction",
+				len(tx.Inputs), MaxInputsPerSendTx).WithErrorCode(result.CodeTooManyTxInputs)
+		}
+		if len(tx.Outputs) > MaxOutputsPerSendTx {
+			return result.Error("Too many outputs: %v, at most %v outputs are allowed per send transaction",
+				len(tx.Outputs), MaxOutputsPerSendTx).WithErrorCode(result.CodeTooManyTxOutputs)
+		}
+	case *types.MultiSendTx:
+		if len(tx.Inputs) > MaxInputsPerMultiSendTx {
+			return result.Error("Too many inputs: %v, at most %v inputs are allowed per multi-send transaction",
+				len(tx.Inputs), MaxInputsPerMultiSendTx).WithErrorCode(result.CodeTooManyTxInputs)
+		}
+	case *types.MultiSigSendTx:
+		if len(tx.Outputs) > MaxOutputsPerSendTx {
+			return result.Error("Too many outputs: %v, at most %v outputs are allowed per multisig send transaction",
+				len(tx.Outputs), MaxOutputsPerSendTx).WithErrorCode(result.CodeTooManyTxOutputs)
+		}
+	case *types.SlashReviewTx:
+		if len(tx.Inputs) > types.MaxSlashingCouncilSize {
+			return result.Error("Too many inputs: %v, at most %v inputs are allowed per slash review transaction",
+				len(tx.Inputs), types.MaxSlashingCouncilSize).WithErrorCode(result.CodeTooManyTxInputs)
+		}
+	case *types.SplitRuleTx:
+		if len(tx.Splits) > MaxSplitsPerSplitRuleTx {
+			return result.Error("Too many splits: %v, at most %v splits are allowed per split rule transaction",
+				len(tx.Splits), MaxSplitsPerSplitRuleTx).WithErrorCode(result.CodeTooManySplits)
+		}
+	case *types.ReserveFundTx:
+		if len(tx.ResourceIDs) > MaxResourceIDsPerReserveFundTx {
+			return result.Error("Too many resource IDs: %v, at most %v resource IDs are allowed per reserve fund transaction",
+				len(tx.ResourceIDs), MaxResourceIDsPerReserveFundTx).WithErrorCode(result.CodeTooManyResourceIDs)
+		}
+	}
+	return result.OK
+}
@@ -0,0 +1,77 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestCheckTxSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(CheckTxSize(make(common.Bytes, MaxTxSizeInBytes)).IsOK())
+	res := CheckTxSize(make(common.Bytes, MaxTxSizeInBytes+1))
+	assert.Equal(result.CodeTxTooLarge, res.Code, res.String())
+
+	// The multi-send transactions are allowed to be larger
+	multiSendTx, err := types.TxToBytes(&types.MultiSendTx{})
+	assert.Nil(err)
+	multiSendTx = append(multiSendTx, make(common.Bytes, MaxMultiSendTxSizeInBytes-len(multiSendTx))...)
+	assert.True(CheckTxSize(multiSendTx).IsOK())
+	res = CheckTxSize(append(multiSendTx, 0x00))
+	assert.Equal(result.CodeTxTooLarge, res.Code, res.String())
+}
+
+func TestCheckTxComplexity(t *testing.T) {
+	assert := assert.New(t)
+
+	sendTx := &types.SendTx{
+		Inputs:  make([]types.TxInput, MaxInputsPerSendTx),
+		Outputs: make([]types.TxOutput, MaxOutputsPerSendTx),
+	}
+	assert.True(checkTxComplexity(sendTx).IsOK())
+	sendTx.Inputs = append(sendTx.Inputs, types.TxInput{})
+	res := checkTxComplexity(sendTx)
+	assert.Equal(result.CodeTooManyTxInputs, res.Code, res.String())
+	sendTx.Inputs = sendTx.Inputs[:1]
+	sendTx.Outputs = append(sendTx.Outputs, types.TxOutput{})
+	res = checkTxComplexity(sendTx)
+	assert.Equal(result.CodeTooManyTxOutputs, res.Code, res.String())
+
+	splitRuleTx := &types.SplitRuleTx{Splits: make([]types.Split, MaxSplitsPerSplitRuleTx)}
+	assert.True(checkTxComplexity(splitRuleTx).IsOK())
+	splitRuleTx.Splits = append(splitRuleTx.Splits, types.Split{})
+	res = checkTxComplexity(splitRuleTx)
+	assert.Equal(result.CodeTooManySplits, res.Code, res.String())
+
+	reserveFundTx := &types.ReserveFundTx{ResourceIDs: make([]string, MaxResourceIDsPerReserveFundTx)}
+	assert.True(checkTxComplexity(reserveFundTx).IsOK())
+	reserveFundTx.ResourceIDs = append(reserveFundTx.ResourceIDs, "rid")
+	res = checkTxComplexity(reserveFundTx)
+	assert.Equal(result.CodeTooManyResourceIDs, res.Code, res.String())
+
+	multiSendTx := &types.MultiSendTx{Inputs: make([]types.TxInput, MaxInputsPerMultiSendTx)}
+	assert.True(checkTxComplexity(multiSendTx).IsOK())
+	multiSendTx.Inputs = append(multiSendTx.Inputs, types.TxInput{})
+	res = checkTxComplexity(multiSendTx)
+	assert.Equal(result.CodeTooManyTxInputs, res.Code, res.String())
+
+	// Other transaction types are not limited
+	assert.True(checkTxComplexity(&types.DepositStakeTx{}).IsOK())
+}
+
+func TestCheckTxComplexityOnlyAtCheckTx(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	tx := &types.SendTx{
+		Inputs: make([]types.TxInput, MaxInputsPerSendTx+1),
+	}
+	_, res := et.executor.CheckTx(tx)
+	assert.Equal(result.CodeTooManyTxInputs, res.Code, res.String())
+	_, res = et.executor.ScreenTx(tx)
+	assert.Equal(result.CodeTooManyTxInputs, res.Code, res.String())
+}
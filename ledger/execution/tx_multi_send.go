@@ -0,0 +1,137 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*MultiSendTxExecutor)(nil)
+
+// ------------------------------- MultiSend Transaction -----------------------------------
+
+// MultiSendTxExecutor implements the TxExecutor interface
+type MultiSendTxExecutor struct {
+}
+
+// NewMultiSendTxExecutor creates a new instance of MultiSendTxExecutor
+func NewMultiSendTxExecutor() *MultiSendTxExecutor {
+	return &MultiSendTxExecutor{}
+}
+
+func (exec *MultiSendTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.MultiSendTx)
+
+	if len(tx.Inputs) == 0 || len(tx.Outputs) == 0 {
+		return result.Error("Invalid multiSendTx, Inputs and/or Outputs are empty")
+	}
+	if len(tx.Outputs) > types.MaxMultiSendOutputs {
+		return result.Error("Too many outputs: %v, at most %v outputs are allowed per multi-send transaction",
+			len(tx.Outputs), types.MaxMultiSendOutputs).WithErrorCode(result.CodeTooManyTxOutputs)
+	}
+	for _, out := range tx.Outputs {
+		if len(out.Memo) > types.MaxMultiSendMemoLength {
+			return result.Error("Memo too long: %v bytes, at most %v bytes allowed",
+				len(out.Memo), types.MaxMultiSendMemoLength)
+		}
+	}
+
+	// Validate inputs and outputs, basic
+	outputs := tx.TxOutputs()
+	res := validateInputsBasic(tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+	res = validateOutputsBasic(outputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Get inputs
+	accounts, res := getInputs(chainID, view, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Get or make outputs.
+	accounts, res = getOrMakeOutputs(view, accounts, outputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Validate inputs and outputs, advanced
+	signBytes := tx.SignBytes(chainID)
+	inTotal, res := validateInputsAdvanced(accounts, signBytes, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+	minimumFee := types.MultiSendMinimumFee(len(tx.Outputs))
+	if tx.Fee.NoNil().TFuelWei.Cmp(minimumFee) < 0 {
+		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei for %v outputs",
+			minimumFee, len(tx.Outputs)).WithErrorCode(result.CodeInvalidFee)
+	}
+
+	outPlusFees := sumOutputs(outputs).Plus(tx.Fee)
+	if !inTotal.IsEqual(outPlusFees) {
+		return result.Error("Input total (%v) != output total + fees (%v)", inTotal, outPlusFees)
+	}
+
+	return result.OK
+}
+
+func (exec *MultiSendTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.MultiSendTx)
+
+	accounts, res := getInputs(chainID, view, tx.Inputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	outputs := tx.TxOutputs()
+	accounts, res = getOrMakeOutputs(view, accounts, outputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	adjustByInputs(view, accounts, tx.Inputs)
+	adjustByOutputs(view, accounts, outputs)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *MultiSendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.MultiSendTx)
+	return &core.TxInfo{
+		Address:           tx.Inputs[0].Address,
+		Sequence:          tx.Inputs[0].Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               exec.calculateGas(transaction),
+	}
+}
+
+func (exec *MultiSendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.MultiSendTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, exec.calculateGas(transaction))
+	return effectiveGasPrice
+}
+
+func (exec *MultiSendTxExecutor) calculateGas(transaction types.Tx) uint64 {
+	tx := transaction.(*types.MultiSendTx)
+	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	gasUint64 := types.GasSendTxPerAccount * numAccountsAffected
+	if gasUint64 < 2*types.GasSendTxPerAccount {
+		gasUint64 = 2 * types.GasSendTxPerAccount // to prevent spamming with invalid transactions, e.g. empty inputs/outputs
+	}
+	return gasUint64
+}
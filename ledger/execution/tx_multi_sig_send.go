@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*MultiSigSendTxExecutor)(nil)
+
+// ------------------------------- MultiSigSend Transaction -----------------------------------
+
+// MultiSigSendTxExecutor implements the TxExecutor interface
+type MultiSigSendTxExecutor struct {
+}
+
+// NewMultiSigSendTxExecutor creates a new instance of MultiSigSendTxExecutor
+func NewMultiSigSendTxExecutor() *MultiSigSendTxExecutor {
+	return &MultiSigSendTxExecutor{}
+}
+
+func (exec *MultiSigSendTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.MultiSigSendTx)
+
+	// Validate input and outputs, basic
+	res := tx.Input.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+	res = validateOutputsBasic(tx.Outputs)
+	if res.IsError() {
+		return res
+	}
+
+	if len(tx.Outputs) == 0 {
+		return result.Error("Invalid multiSigSendTx, Outputs are empty")
+	}
+
+	numAccountsAffected := uint64(1 + len(tx.Outputs))
+	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
+		return result.Error("Trasaction modifying too many accounts. At most %v accounts are allowed per transaction",
+			types.MaxAccountsAffectedPerTx)
+	}
+
+	// Get input
+	account, signers, res := getMultiSigInput(view, tx.Input)
+	if res.IsError() {
+		return res
+	}
+
+	// Get or make outputs. The input account is included so that the outputs cannot duplicate it.
+	accounts := map[string]*types.Account{string(tx.Input.Address[:]): account}
+	accounts, res = getOrMakeOutputs(view, accounts, tx.Outputs)
+	if res.IsError() {
+		return res
+	}
+
+	// Validate input, advanced
+	signBytes := tx.SignBytes(chainID)
+	res = validateMultiSigInput(account, signers, signBytes, tx.Input)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	outPlusFees := sumOutputs(tx.Outputs).Plus(tx.Fee)
+	if !tx.Input.Coins.NoNil().IsEqual(outPlusFees) {
+		return result.Error("Input total (%v) != output total + fees (%v)", tx.Input.Coins, outPlusFees)
+	}
+
+	return result.OK
+}
+
+func (exec *MultiSigSendTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.MultiSigSendTx)
+
+	account, _, res := getMultiSigInput(view, tx.Input)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	accounts := map[string]*types.Account{string(tx.Input.Address[:]): account}
+	accounts, res = getOrMakeOutputs(view, accounts, tx.Outputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	// Since the input coins cover the outputs and the fee, the fee is charged implicitly
+	if !account.Balance.IsGTE(tx.Input.Coins) {
+		return common.Hash{}, result.Error("Insufficient fund: balance is %v, tried to send %v",
+			account.Balance, tx.Input.Coins).WithErrorCode(result.CodeInsufficientFund)
+	}
+	account.Balance = account.Balance.Minus(tx.Input.Coins)
+	account.Sequence++
+	view.SetAccount(tx.Input.Address, account)
+
+	adjustByOutputs(view, accounts, tx.Outputs)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *MultiSigSendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.MultiSigSendTx)
+	return &core.TxInfo{
+		Address:           tx.Input.Address,
+		Sequence:          tx.Input.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               exec.calculateGas(transaction),
+	}
+}
+
+func (exec *MultiSigSendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.MultiSigSendTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, exec.calculateGas(transaction))
+	return effectiveGasPrice
+}
+
+func (exec *MultiSigSendTxExecutor) calculateGas(transaction types.Tx) uint64 {
+	tx := transaction.(*types.MultiSigSendTx)
+	gasUint64 := types.GasSendTxPerAccount * uint64(1+len(tx.Outputs))
+	if gasUint64 < 2*types.GasSendTxPerAccount {
+		gasUint64 = 2 * types.GasSendTxPerAccount // to prevent spamming with invalid transactions, e.g. empty outputs
+	}
+	gasUint64 += types.GasMultiSigSignature * uint64(len(tx.Input.Signatures))
+	return gasUint64
+}
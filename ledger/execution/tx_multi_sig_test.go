@@ -0,0 +1,163 @@
+package execution
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestMultiSigTxs(t *testing.T) {
+	assert := assert.New(t)
+	et := NewExecTest()
+
+	txFee := getMinimumTxFee()
+	treasury := types.MakeAccWithInitBalance("treasury", types.NewCoins(1000, 100*txFee))
+	alice := types.MakeAcc("alice")
+	bob := types.MakeAcc("bob")
+	carol := types.MakeAcc("carol")
+	recipient := types.MakeAccWithInitBalance("recipient", types.NewCoins(0, 0))
+	et.acc2State(treasury, recipient)
+	et.state().Commit()
+
+	sign := func(tx types.MultiSigTx, signers ...types.PrivAccount) {
+		tx.GetMultiSigInput().Signatures = nil
+		for _, signer := range signers {
+			tx.GetMultiSigInput().AddSignature(signer.Address, signer.Sign(tx.SignBytes(et.chainID)))
+		}
+	}
+	newUpdateTx := func(sequence uint64, threshold uint64, signers ...common.Address) *types.UpdateAccountSignersTx {
+		return &types.UpdateAccountSignersTx{
+			Fee: types.NewCoins(0, txFee),
+			Account: types.MultiSigInput{
+				Address:  treasury.Address,
+				Sequence: sequence,
+			},
+			Signers:   signers,
+			Threshold: threshold,
+		}
+	}
+	check := func(tx types.Tx) result.Result {
+		return et.executor.getTxExecutor(tx).sanityCheck(et.chainID, et.state().Delivered(), tx)
+	}
+
+	// Without a signer set, the account is controlled by its own key
+	updateTx := newUpdateTx(1, 2, alice.Address, bob.Address, carol.Address)
+	sign(updateTx, alice)
+	assert.Equal(result.CodeInvalidSignature, check(updateTx).Code)
+
+	// The signer set must be valid
+	for _, tx := range []*types.UpdateAccountSignersTx{
+		newUpdateTx(1, 4, alice.Address, bob.Address, carol.Address),
+		newUpdateTx(1, 0, alice.Address, bob.Address, carol.Address),
+		newUpdateTx(1, 2, alice.Address, bob.Address, alice.Address),
+		newUpdateTx(1, 1),
+	} {
+		sign(tx, treasury)
+		res := check(tx)
+		assert.Equal(result.CodeInvalidAccountSigners, res.Code, res.String())
+	}
+
+	sign(updateTx, treasury)
+	res := check(updateTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(updateTx).process(et.chainID, et.state().Delivered(), updateTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	signers := et.state().Delivered().GetAccountSigners(treasury.Address)
+	assert.NotNil(signers)
+	assert.Equal([]common.Address{alice.Address, bob.Address, carol.Address}, signers.Signers)
+	assert.Equal(uint64(2), signers.Threshold)
+
+	// The key of the account can no longer sign for it alone
+	sendTx := &types.SendTx{
+		Fee: types.NewCoins(0, txFee),
+		Inputs: []types.TxInput{
+			{
+				Address:  treasury.Address,
+				Coins:    types.NewCoins(0, 11*txFee),
+				Sequence: 2,
+			},
+		},
+		Outputs: []types.TxOutput{
+			{
+				Address: recipient.Address,
+				Coins:   types.NewCoins(0, 10*txFee),
+			},
+		},
+	}
+	sendTx.Inputs[0].Signature = treasury.Sign(sendTx.SignBytes(et.chainID))
+	res = check(sendTx)
+	assert.Equal(result.CodeMultiSigAccount, res.Code, res.String())
+
+	// The signer sets are only looked up once the fork is active
+	restore := et.setForkHeight(core.ForkMultiSig, math.MaxUint64)
+	assert.True(checkNotMultiSig(et.chainID, et.state().Delivered(), treasury.Address).IsOK())
+	restore()
+
+	multiSigSendTx := &types.MultiSigSendTx{
+		Fee: types.NewCoins(0, txFee),
+		Input: types.MultiSigInput{
+			Address:  treasury.Address,
+			Coins:    types.NewCoins(100, 11*txFee),
+			Sequence: 2,
+		},
+		Outputs: []types.TxOutput{
+			{
+				Address: recipient.Address,
+				Coins:   types.NewCoins(100, 10*txFee),
+			},
+		},
+	}
+
+	sign(multiSigSendTx, alice)
+	res = check(multiSigSendTx)
+	assert.Equal(result.CodeNotEnoughSignatures, res.Code, res.String())
+
+	sign(multiSigSendTx, alice, treasury)
+	res = check(multiSigSendTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	// The same signer only counts once
+	sign(multiSigSendTx, alice)
+	multiSigSendTx.Input.Signatures = append(multiSigSendTx.Input.Signatures, multiSigSendTx.Input.Signatures[0])
+	res = check(multiSigSendTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	// The signatures are combined in any order
+	sign(multiSigSendTx, carol, alice)
+	res = check(multiSigSendTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(multiSigSendTx).process(et.chainID, et.state().Delivered(), multiSigSendTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+
+	treasuryAcc := et.state().Delivered().GetAccount(treasury.Address)
+	assert.Equal(uint64(2), treasuryAcc.Sequence)
+	assert.Equal(types.NewCoins(900, 88*txFee), treasuryAcc.Balance)
+	assert.Equal(types.NewCoins(100, 10*txFee), et.state().Delivered().GetAccount(recipient.Address).Balance)
+
+	// The signer set is updated by its signers, and removing it returns the account to its key
+	removeTx := newUpdateTx(3, 0)
+	sign(removeTx, treasury)
+	res = check(removeTx)
+	assert.Equal(result.CodeInvalidSignature, res.Code, res.String())
+
+	sign(removeTx, bob, carol)
+	res = check(removeTx)
+	assert.True(res.IsOK(), res.String())
+	_, res = et.executor.getTxExecutor(removeTx).process(et.chainID, et.state().Delivered(), removeTx)
+	assert.True(res.IsOK(), res.String())
+	et.state().Commit()
+	assert.Nil(et.state().Delivered().GetAccountSigners(treasury.Address))
+
+	sendTx.Inputs[0].Sequence = 4
+	sendTx.Inputs[0].Signature = treasury.Sign(sendTx.SignBytes(et.chainID))
+	res = check(sendTx)
+	assert.True(res.IsOK(), res.String())
+}
@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*RegisterNodeAddressTxExecutor)(nil)
+
+// ------------------------------- RegisterNodeAddress Transaction -----------------------------------
+
+// RegisterNodeAddressTxExecutor implements the TxExecutor interface
+type RegisterNodeAddressTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewRegisterNodeAddressTxExecutor creates a new instance of RegisterNodeAddressTxExecutor
+func NewRegisterNodeAddressTxExecutor(state *st.LedgerState) *RegisterNodeAddressTxExecutor {
+	return &RegisterNodeAddressTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *RegisterNodeAddressTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.RegisterNodeAddressTx)
+
+	res := tx.Validator.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get inputs
+	account, res := getInput(chainID, view, tx.Validator)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(account, signBytes, tx.Validator)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	minimalBalance := tx.Fee
+	if !account.Balance.IsGTE(minimalBalance) {
+		logger.Infof("the account did not have enough to cover the fee %X", tx.Validator.Address)
+		return result.Error("the account balance is %v, but required minimal balance is %v", account.Balance, minimalBalance)
+	}
+
+	if err := core.ValidateNodeEndpoints(tx.Endpoints); err != nil {
+		return result.Error("%v", err).WithErrorCode(result.CodeInvalidNodeAddress)
+	}
+
+	// Only the validator candidates can register, a removal is always allowed so that a former
+	// validator can clean up its registration
+	if len(tx.Endpoints) > 0 && !isValidatorCandidate(view, tx.Validator.Address) {
+		return result.Error("%v is not a validator candidate", tx.Validator.Address.Hex()).
+			WithErrorCode(result.CodeNotAValidator)
+	}
+
+	return result.OK
+}
+
+func (exec *RegisterNodeAddressTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.RegisterNodeAddressTx)
+
+	account, res := getInput(chainID, view, tx.Validator)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	if !chargeFee(account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	if len(tx.Endpoints) == 0 {
+		view.DeleteNodeAddress(tx.Validator.Address)
+	} else {
+		nodeAddress := view.GetNodeAddress(tx.Validator.Address)
+		if nodeAddress == nil {
+			nodeAddress = &core.NodeAddress{
+				Validator: tx.Validator.Address,
+				Endpoints: tx.Endpoints,
+			}
+		} else {
+			nodeAddress.Rotate(tx.Endpoints, view.Height())
+		}
+		view.SetNodeAddress(nodeAddress)
+	}
+
+	account.Sequence++
+	view.SetAccount(tx.Validator.Address, account)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *RegisterNodeAddressTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.RegisterNodeAddressTx)
+	return &core.TxInfo{
+		Address:           tx.Validator.Address,
+		Sequence:          tx.Validator.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasRegisterNodeAddress,
+	}
+}
+
+func (exec *RegisterNodeAddressTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.RegisterNodeAddressTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasRegisterNodeAddress)
+	return effectiveGasPrice
+}
+
+func isValidatorCandidate(view *st.StoreView, addr common.Address) bool {
+	vcp := view.GetValidatorCandidatePool()
+	if vcp == nil {
+		return false
+	}
+	for _, candidate := range vcp.SortedCandidates {
+		if candidate.Holder == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package execution
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ExtensionTxExecutor defines the interface of the executors of the extension transactions, see
+// RegisterExtensionTxExecutor. CheckTx checks the validity of the transaction against the view
+// without modifying it, and DeliverTx applies the transaction to the view.
+type ExtensionTxExecutor interface {
+	CheckTx(chainID string, view *st.StoreView, tx types.Tx) result.Result
+	DeliverTx(chainID string, view *st.StoreView, tx types.Tx) (common.Hash, result.Result)
+	GetTxInfo(tx types.Tx) *core.TxInfo
+}
+
+// ExtensionTxExecutorFactory creates the executor of an extension transaction type for the ledger
+// state, once for each Executor.
+type ExtensionTxExecutorFactory func(state *st.LedgerState) ExtensionTxExecutor
+
+var (
+	extensionTxExecutorsMutex = &sync.RWMutex{}
+	extensionTxExecutors      = make(map[types.TxType]ExtensionTxExecutorFactory)
+)
+
+// RegisterExtensionTxExecutor registers a transaction type of the extension range together with
+// its executor, so that new transaction types are added without modifying the Executor. newTx
+// returns an empty transaction of the type, see types.RegisterExtensionTx. The executors need to
+// be registered before the Executor is created, e.g. in an init function.
+func RegisterExtensionTxExecutor(txType types.TxType, newTx func() types.Tx, factory ExtensionTxExecutorFactory) error {
+	if factory == nil {
+		return fmt.Errorf("The executor of tx type %v is not specified", txType)
+	}
+	if err := types.RegisterExtensionTx(txType, newTx); err != nil {
+		return err
+	}
+
+	extensionTxExecutorsMutex.Lock()
+	defer extensionTxExecutorsMutex.Unlock()
+	extensionTxExecutors[txType] = factory
+	return nil
+}
+
+// checkTxTypeActive rejects the extension transactions, and the core transactions added by a fork,
+// before they are activated on the chain
+func checkTxTypeActive(chainID string, height uint64, tx types.Tx) result.Result {
+	txType, err := types.GetTxType(tx)
+	if err != nil {
+		return result.Error("Unknown tx type")
+	}
+	if types.IsExtensionTxType(txType) && !core.IsForkActive(core.ForkExtensionTx, chainID, height) {
+		return result.Error("Tx type %v is not activated at height %v", txType, height)
+	}
+	if fork, ok := coreTxForks[txType]; ok && !core.IsForkActive(fork, chainID, height) {
+		return result.Error("Tx type %v is not activated at height %v", txType, height)
+	}
+	return result.OK
+}
+
+// newExtensionTxExecutors creates the executors of the registered extension transaction types
+func newExtensionTxExecutors(state *st.LedgerState) map[types.TxType]TxExecutor {
+	extensionTxExecutorsMutex.RLock()
+	defer extensionTxExecutorsMutex.RUnlock()
+
+	executors := make(map[types.TxType]TxExecutor)
+	for txType, factory := range extensionTxExecutors {
+		executors[txType] = &extensionTxExecutor{executor: factory(state)}
+	}
+	return executors
+}
+
+// extensionTxExecutor adapts an ExtensionTxExecutor to the TxExecutor interface
+type extensionTxExecutor struct {
+	executor ExtensionTxExecutor
+}
+
+func (exec *extensionTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	return exec.executor.CheckTx(chainID, view, transaction)
+}
+
+func (exec *extensionTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	return exec.executor.DeliverTx(chainID, view, transaction)
+}
+
+func (exec *extensionTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	return exec.executor.GetTxInfo(transaction)
+}
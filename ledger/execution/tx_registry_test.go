@@ -0,0 +1,107 @@
+package execution
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const testNoteTxType = types.TxExtensionBase + 1
+
+// testNoteTx is an extension transaction storing a note in the state
+type testNoteTx struct {
+	Key  string
+	Note string
+	Fee  types.Coins
+}
+
+func (tx *testNoteTx) AssertIsTx() {}
+
+func (tx *testNoteTx) SignBytes(chainID string) []byte {
+	return []byte(chainID + tx.Key + tx.Note)
+}
+
+func (tx *testNoteTx) GetFee() types.Coins {
+	return tx.Fee
+}
+
+type testNoteTxExecutor struct {
+	state *st.LedgerState
+}
+
+func (exec *testNoteTxExecutor) CheckTx(chainID string, view *st.StoreView, tx types.Tx) result.Result {
+	if len(tx.(*testNoteTx).Key) == 0 {
+		return result.Error("Empty note key")
+	}
+	return result.OK
+}
+
+func (exec *testNoteTxExecutor) DeliverTx(chainID string, view *st.StoreView, tx types.Tx) (common.Hash, result.Result) {
+	noteTx := tx.(*testNoteTx)
+	view.Set(common.Bytes("note/"+noteTx.Key), common.Bytes(noteTx.Note))
+	return types.TxID(chainID, tx), result.OK
+}
+
+func (exec *testNoteTxExecutor) GetTxInfo(tx types.Tx) *core.TxInfo {
+	return &core.TxInfo{EffectiveGasPrice: big.NewInt(0)}
+}
+
+func init() {
+	err := RegisterExtensionTxExecutor(testNoteTxType, func() types.Tx { return &testNoteTx{} },
+		func(state *st.LedgerState) ExtensionTxExecutor { return &testNoteTxExecutor{state: state} })
+	if err != nil {
+		panic(err)
+	}
+}
+
+func TestExtensionTxRegistry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// The extension transactions are encoded and decoded with the registered type
+	tx := &testNoteTx{Key: "greeting", Note: "hello", Fee: types.NewCoins(0, 1)}
+	txType, err := types.GetTxType(tx)
+	require.Nil(err)
+	assert.Equal(testNoteTxType, txType)
+	raw, err := types.TxToBytes(tx)
+	require.Nil(err)
+	decoded, err := types.TxFromBytes(raw)
+	require.Nil(err)
+	require.IsType(&testNoteTx{}, decoded)
+	assert.Equal(tx.Key, decoded.(*testNoteTx).Key)
+	assert.Equal(tx.Note, decoded.(*testNoteTx).Note)
+	assert.True(tx.Fee.IsEqual(decoded.(*testNoteTx).Fee))
+	assert.True(types.TxFee(tx, 0).IsEqual(tx.Fee))
+
+	// Only the unused types of the extension range can be registered
+	newTx := func() types.Tx { return &testNoteTx{} }
+	factory := func(state *st.LedgerState) ExtensionTxExecutor { return &testNoteTxExecutor{state: state} }
+	assert.NotNil(RegisterExtensionTxExecutor(types.TxSend, newTx, factory))
+	assert.NotNil(RegisterExtensionTxExecutor(testNoteTxType, newTx, factory))
+	assert.NotNil(RegisterExtensionTxExecutor(testNoteTxType+1, newTx, factory))
+	assert.NotNil(RegisterExtensionTxExecutor(testNoteTxType+1, newTx, nil))
+
+	et := NewExecTest()
+	assert.NotNil(et.executor.getTxExecutor(tx))
+	assert.NotNil(et.executor.getTxExecutor(&types.SendTx{}))
+
+	// The extension transactions are rejected before the fork
+	restore := et.setForkHeight(core.ForkExtensionTx, math.MaxUint64)
+	_, res := et.executor.CheckTx(tx)
+	assert.True(res.IsError(), res.String())
+	restore()
+
+	_, res = et.executor.CheckTx(&testNoteTx{Note: "no key"})
+	assert.True(res.IsError(), res.String())
+	_, res = et.executor.ExecuteTx(tx)
+	assert.True(res.IsOK(), res.String())
+	assert.Equal(common.Bytes("hello"), et.state().Delivered().Get(common.Bytes("note/greeting")))
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -37,7 +38,7 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 	}
 
 	// Get input account
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return result.Error("Unknown address: %v", tx.Source.Address)
 	}
@@ -50,9 +51,8 @@ func (exec *ReleaseFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
 	}
 
 	minimalBalance := tx.Fee
@@ -76,7 +76,7 @@ func (exec *ReleaseFundTxExecutor) process(chainID string, view *st.StoreView, t
 	tx := transaction.(*types.ReleaseFundTx)
 
 	sourceInputs := []types.TxInput{tx.Source}
-	accounts, success := getInputs(view, sourceInputs)
+	accounts, success := getInputs(chainID, view, sourceInputs)
 	if success.IsError() {
 		// TODO: revisit whether we should panic or just log the error.
 		return common.Hash{}, result.Error("Failed to get the source account")
@@ -105,13 +105,13 @@ func (exec *ReleaseFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasReleaseFundTx,
 	}
 }
 
 func (exec *ReleaseFundTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ReleaseFundTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasReleaseFundTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasReleaseFundTx)
 	return effectiveGasPrice
 }
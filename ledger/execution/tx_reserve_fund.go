@@ -3,8 +3,10 @@ package execution
 import (
 	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -37,7 +39,7 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 	}
 
 	// Get input account
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return result.Error("Failed to get the source account: %v", tx.Source.Address)
 	}
@@ -62,9 +64,12 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 			WithErrorCode(result.CodeInvalidFundToReserve)
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	if res := checkResourceIDs(chainID, view.Height()+1, tx.ResourceIDs...); res.IsError() {
+		return res
 	}
 
 	fund := tx.Source.Coins
@@ -79,6 +84,15 @@ func (exec *ReserveFundTxExecutor) sanityCheck(chainID string, view *st.StoreVie
 			sourceAccount.Balance, minimalBalance).WithErrorCode(result.CodeInsufficientFund)
 	}
 
+	// Expired funds have been released by getInput(), the remaining ones are still active
+	if core.IsForkActive(core.ForkReservedFundLimit, chainID, view.Height()+1) {
+		maxReservedFunds := MaxActiveReservedFunds(chainID)
+		if len(sourceAccount.ReservedFunds) >= maxReservedFunds {
+			return result.Error("Too many active reserved funds, at most %v reserved funds are allowed per account",
+				maxReservedFunds).WithErrorCode(result.CodeTooManyReservedFunds)
+		}
+	}
+
 	err := sourceAccount.CheckReserveFund(collateral, fund, duration, reserveSequence)
 	if err != nil {
 		return result.Error(err.Error()).WithErrorCode(result.CodeReserveFundCheckFailed)
@@ -91,7 +105,7 @@ func (exec *ReserveFundTxExecutor) process(chainID string, view *st.StoreView, t
 	tx := transaction.(*types.ReserveFundTx)
 
 	sourceAddress := tx.Source.Address
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return common.Hash{}, result.Error("Failed to get the source account")
 	}
@@ -121,13 +135,39 @@ func (exec *ReserveFundTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasReserveFundTx,
 	}
 }
 
 func (exec *ReserveFundTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ReserveFundTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasReserveFundTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasReserveFundTx)
 	return effectiveGasPrice
 }
+
+// maxActiveReservedFunds gives the maximum number of active reserved funds per account of the chains
+// setting their own limit. The other chains allow types.MaximumActiveReservedFundsPerAccount.
+var (
+	maxActiveReservedFunds      = map[string]int{}
+	maxActiveReservedFundsMutex = &sync.RWMutex{}
+)
+
+// SetMaxActiveReservedFunds sets the maximum number of active reserved funds per account of the chain.
+// It is a consensus parameter, all the nodes of the chain need the same value.
+func SetMaxActiveReservedFunds(chainID string, max int) {
+	maxActiveReservedFundsMutex.Lock()
+	defer maxActiveReservedFundsMutex.Unlock()
+	maxActiveReservedFunds[chainID] = max
+}
+
+// MaxActiveReservedFunds returns the maximum number of active reserved funds per account of the chain,
+// enforced from the core.ForkReservedFundLimit height
+func MaxActiveReservedFunds(chainID string) int {
+	maxActiveReservedFundsMutex.RLock()
+	defer maxActiveReservedFundsMutex.RUnlock()
+	if max, ok := maxActiveReservedFunds[chainID]; ok {
+		return max
+	}
+	return types.MaximumActiveReservedFundsPerAccount
+}
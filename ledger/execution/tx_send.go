@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -40,14 +41,19 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *st.StoreView, tran
 		return result.Error("Invalid sendTx, Inputs and/or Outputs are empty")
 	}
 
-	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	if len(tx.Memo) > types.MaxSendTxMemoLength {
+		return result.Error("Memo too long: %v bytes, at most %v bytes allowed",
+			len(tx.Memo), types.MaxSendTxMemoLength)
+	}
+
+	numAccountsAffected := exec.numAccountsAffected(tx)
 	if numAccountsAffected > types.MaxAccountsAffectedPerTx {
 		return result.Error("Trasaction modifying too many accounts. At most %v accounts are allowed per transaction",
 			types.MaxAccountsAffectedPerTx)
 	}
 
 	// Get inputs
-	accounts, res := getInputs(view, tx.Inputs)
+	accounts, res := getInputs(chainID, view, tx.Inputs)
 	if res.IsError() {
 		return res
 	}
@@ -65,12 +71,33 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *st.StoreView, tran
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+	if len(tx.Memo) != 0 {
+		minimumFee := types.SendTxMinimumFee(len(tx.Memo))
+		if tx.Fee.NoNil().TFuelWei.Cmp(minimumFee) < 0 {
+			return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei for a %v-byte memo",
+				minimumFee, len(tx.Memo)).WithErrorCode(result.CodeInvalidFee)
+		}
 	}
 
 	outTotal := sumOutputs(tx.Outputs)
+	if tx.FeePayer != nil {
+		senders := make([]common.Address, len(tx.Inputs))
+		for i, input := range tx.Inputs {
+			senders[i] = input.Address
+		}
+		if _, res := validateFeePayer(chainID, view, tx, tx.Fee, senders...); res.IsError() {
+			return res
+		}
+		// The fee is paid by the fee payer rather than the inputs
+		if !inTotal.IsEqual(outTotal) {
+			return result.Error("Input total (%v) != output total (%v)", inTotal, outTotal)
+		}
+		return result.OK
+	}
+
 	outPlusFees := outTotal
 	outPlusFees = outTotal.Plus(tx.Fee)
 	if !inTotal.IsEqual(outPlusFees) {
@@ -83,7 +110,18 @@ func (exec *SendTxExecutor) sanityCheck(chainID string, view *st.StoreView, tran
 func (exec *SendTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.SendTx)
 
-	accounts, res := getInputs(view, tx.Inputs)
+	if tx.FeePayer != nil {
+		feePayerAccount, res := getAccount(view, tx.FeePayer.Address)
+		if res.IsError() {
+			return common.Hash{}, res
+		}
+		if !chargeFee(feePayerAccount, tx.Fee) {
+			return common.Hash{}, result.Error("failed to charge transaction fee")
+		}
+		view.SetAccount(tx.FeePayer.Address, feePayerAccount)
+	}
+
+	accounts, res := getInputs(chainID, view, tx.Inputs)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
@@ -106,18 +144,33 @@ func (exec *SendTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 		Address:           tx.Inputs[0].Address,
 		Sequence:          tx.Inputs[0].Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               exec.calculateGas(transaction),
 	}
 }
 
 func (exec *SendTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SendTx)
 	fee := tx.Fee
-	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, exec.calculateGas(transaction))
+	return effectiveGasPrice
+}
+
+func (exec *SendTxExecutor) calculateGas(transaction types.Tx) uint64 {
+	tx := transaction.(*types.SendTx)
+	numAccountsAffected := exec.numAccountsAffected(tx)
 	gasUint64 := types.GasSendTxPerAccount * numAccountsAffected
 	if gasUint64 < 2*types.GasSendTxPerAccount {
 		gasUint64 = 2 * types.GasSendTxPerAccount // to prevent spamming with invalid transactions, e.g. empty inputs/outputs
 	}
-	gas := new(big.Int).SetUint64(gasUint64)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
-	return effectiveGasPrice
+	gasUint64 += types.GasSendTxMemoPerByte * uint64(len(tx.Memo))
+	return gasUint64
+}
+
+// numAccountsAffected returns the number of accounts modified by the transaction, including its fee payer
+func (exec *SendTxExecutor) numAccountsAffected(tx *types.SendTx) uint64 {
+	numAccountsAffected := uint64(len(tx.Inputs) + len(tx.Outputs))
+	if tx.FeePayer != nil {
+		numAccountsAffected++
+	}
+	return numAccountsAffected
 }
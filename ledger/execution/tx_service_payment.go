@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -43,13 +44,13 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 	sourceAddress := tx.Source.Address
 	targetAddress := tx.Target.Address
 
-	sourceAccount, res := getInput(view, tx.Source)
+	sourceAccount, res := getInput(chainID, view, tx.Source)
 	if res.IsError() {
 		return res
 	}
 
 	// Get the target account (that signed and broadcasted this transaction)
-	targetAccount, res := getOrMakeInput(view, tx.Target)
+	targetAccount, res := getOrMakeInput(chainID, view, tx.Target)
 	if res.IsError() {
 		return res
 	}
@@ -59,11 +60,9 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 	}
 
 	// Verify source
-	sourceSignBytes := tx.SourceSignBytes(chainID)
-	if !tx.Source.Signature.Verify(sourceSignBytes, sourceAccount.Address) {
-		errMsg := fmt.Sprintf("sanityCheckForServicePaymentTx failed on source signature, addr: %v", sourceAddress.Hex())
-		logger.Infof(errMsg)
-		return result.Error(errMsg)
+	res = verifyServicePaymentSource(chainID, view, sourceAccount, tx)
+	if res.IsError() {
+		return res
 	}
 
 	// Verify target
@@ -79,9 +78,14 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 		return result.Error(errMsg)
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	// Once the payment is held in the dispute window, the target pays the fee from its balance
+	if core.IsForkActive(core.ForkServicePaymentDispute, chainID, view.Height()+1) && !targetAccount.Balance.IsGTE(tx.Fee) {
+		return result.Error("the target account balance is %v, but required minimal balance is %v",
+			targetAccount.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
 	}
 
 	transferAmount := tx.Source.Coins
@@ -97,6 +101,13 @@ func (exec *ServicePaymentTxExecutor) sanityCheck(chainID string, view *st.Store
 		return result.Error(err.Error()).WithErrorCode(result.CodeCheckTransferReservedFundFailed)
 	}
 
+	// A settlement in the dispute window can only be replaced by a newer payment
+	settlement := view.GetPendingSettlement(sourceAddress, targetAddress, reserveSequence)
+	if settlement != nil && settlement.ServicePayment.PaymentSequence >= paymentSequence {
+		return result.Error("Payment sequence %v is not higher than the pending settlement's payment sequence %v",
+			paymentSequence, settlement.ServicePayment.PaymentSequence).WithErrorCode(result.CodeStaleServicePayment)
+	}
+
 	return result.OK
 }
 
@@ -106,23 +117,150 @@ func (exec *ServicePaymentTxExecutor) process(chainID string, view *st.StoreView
 	sourceAddress := tx.Source.Address
 	targetAddress := tx.Target.Address
 
-	sourceAccount, res := getInput(view, tx.Source)
+	sourceAccount, res := getInput(chainID, view, tx.Source)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
 
-	targetAccount, res := getOrMakeInput(view, tx.Target)
+	targetAccount, res := getOrMakeInput(chainID, view, tx.Target)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
 
+	// Count the payment against the spend limit if it was signed by the operator
+	if !tx.Source.Signature.Verify(tx.SourceSignBytes(chainID), sourceAddress) {
+		if operator := view.GetAccountOperator(sourceAddress); operator != nil {
+			operator.Spent = operator.Spent.NoNil().Plus(tx.Source.Coins)
+			view.SetAccountOperator(operator)
+		}
+	}
+
+	// Before the fork, the payment is transferred right away
+	if !core.IsForkActive(core.ForkServicePaymentDispute, chainID, view.Height()+1) {
+		accounts, _, res := exec.transferPayment(view, tx, sourceAccount, targetAccount)
+		if res.IsError() {
+			return common.Hash{}, res
+		}
+		if !chargeFee(targetAccount, tx.Fee) {
+			// should charge after transfer the fund, so an empty address has some fund to pay the tx fee
+			return common.Hash{}, result.Error("failed to charge transaction fee")
+		}
+		targetAccount.Sequence++ // targetAccount broadcasted the transaction
+
+		view.SetAccount(sourceAddress, sourceAccount)
+		view.SetAccount(targetAddress, targetAccount)
+		for account := range accounts {
+			view.SetAccount(account.Address, account)
+		}
+
+		txHash := types.TxID(chainID, tx)
+		return txHash, result.OK
+	}
+
+	// The payment is transferred when the dispute window is over
+	if !chargeFee(targetAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	settlement := view.GetPendingSettlement(sourceAddress, targetAddress, tx.ReserveSequence)
+	if settlement == nil {
+		settlement = &types.PendingSettlement{
+			SettleHeight: exec.settleHeight(view.Height(), sourceAccount, tx.ReserveSequence),
+		}
+	}
+	settlement.ServicePayment = *tx
+	view.SetPendingSettlement(settlement)
+
+	targetAccount.Sequence++ // targetAccount broadcasted the transaction
+	view.SetAccount(targetAddress, targetAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+// settleHeight returns the height at which a new settlement is transferred. The dispute window
+// is cut short if the reserved fund expires earlier, so the payment is never lost to the expiry.
+func (exec *ServicePaymentTxExecutor) settleHeight(currentBlockHeight uint64, sourceAccount *types.Account, reserveSequence uint64) uint64 {
+	settleHeight := currentBlockHeight + types.ServicePaymentDisputeWindow
+	for _, reservedFund := range sourceAccount.ReservedFunds {
+		if reservedFund.ReserveSequence == reserveSequence && reservedFund.EndBlockHeight < settleHeight {
+			settleHeight = reservedFund.EndBlockHeight
+		}
+	}
+	return settleHeight
+}
+
+// settleDuePayments transfers the pending settlements whose dispute window is over. A settlement
+// that fails is kept, and retried at the next block as long as the source account holds the
+// reserved fund it is paid from.
+func (exec *ServicePaymentTxExecutor) settleDuePayments(view *st.StoreView) {
+	for _, settlement := range view.GetDuePendingSettlements(view.Height()) {
+		payment := settlement.ServicePayment
+		res := exec.settle(view, settlement)
+		if res.IsOK() {
+			view.DeletePendingSettlement(payment.Source.Address, payment.Target.Address, payment.ReserveSequence)
+			continue
+		}
+
+		if !hasReservedFund(view, payment.Source.Address, payment.ReserveSequence) {
+			logger.Errorf("Failed to settle service payment %v, dropped as the reserved fund is released: %v",
+				settlement, res.Message)
+			view.DeletePendingSettlement(payment.Source.Address, payment.Target.Address, payment.ReserveSequence)
+			continue
+		}
+		logger.Warnf("Failed to settle service payment %v, retrying at the next block: %v", settlement, res.Message)
+		settlement.SettleHeight = view.Height() + 1
+		view.SetPendingSettlement(settlement)
+	}
+}
+
+// settle transfers the payment of the settlement. The accounts are only saved if the whole payment
+// is transferred.
+func (exec *ServicePaymentTxExecutor) settle(view *st.StoreView, settlement *types.PendingSettlement) result.Result {
+	tx := &settlement.ServicePayment
+
+	sourceAddress := tx.Source.Address
+	targetAddress := tx.Target.Address
+
+	sourceAccount, res := getAccount(view, sourceAddress)
+	if res.IsError() {
+		return res
+	}
+	targetAccount := getOrMakeAccount(view, targetAddress)
+
+	accounts, transferred, res := exec.transferPayment(view, tx, sourceAccount, targetAccount)
+	if res.IsError() {
+		return res
+	}
+	if !transferred {
+		return result.Error("Reserved fund %v of %v does not cover the payment",
+			tx.ReserveSequence, sourceAddress.Hex()).WithErrorCode(result.CodeCheckTransferReservedFundFailed)
+	}
+
+	view.SetAccount(sourceAddress, sourceAccount)
+	view.SetAccount(targetAddress, targetAccount)
+	for account := range accounts {
+		view.SetAccount(account.Address, account)
+	}
+
+	return result.OK
+}
+
+// transferPayment transfers the payment from the reserved fund of the source account to the target
+// account and the participants of the split rule of the resource, and returns whether the reserved
+// fund covered the payment. The accounts are not saved.
+func (exec *ServicePaymentTxExecutor) transferPayment(view *st.StoreView, tx *types.ServicePaymentTx,
+	sourceAccount, targetAccount *types.Account) (map[*types.Account]types.Coins, bool, result.Result) {
+	sourceAddress := tx.Source.Address
+	targetAddress := tx.Target.Address
+
 	resourceID := tx.ResourceID
 	splitRule := view.GetSplitRule(resourceID)
 
 	fullTransferAmount := tx.Source.Coins
 	splitSuccess, addrCoinsMap := exec.splitPayment(view, splitRule, resourceID, targetAddress, fullTransferAmount)
 	if !splitSuccess {
-		return common.Hash{}, result.Error("Failed to split payment")
+		return nil, false, result.Error("Failed to split payment")
 	}
 
 	accCoinsMap := map[*types.Account]types.Coins{}
@@ -140,24 +278,34 @@ func (exec *ServicePaymentTxExecutor) process(chainID string, view *st.StoreView
 
 	currentBlockHeight := view.Height()
 	reserveSequence := tx.ReserveSequence
-	shouldSlash, _ := sourceAccount.TransferReservedFund(accCoinsMap, currentBlockHeight, reserveSequence, tx)
+	transferred := false
+	for _, reservedFund := range sourceAccount.ReservedFunds {
+		if reservedFund.ReserveSequence == reserveSequence && reservedFund.HasResourceID(resourceID) {
+			transferred = true
+		}
+	}
+	shouldSlash, _ := sourceAccount.TransferReservedFund(accCoinsMap, currentBlockHeight, reserveSequence, tx,
+		core.IsForkActive(core.ForkReservedFundRemoval, exec.state.GetChainID(), currentBlockHeight+1))
 	if shouldSlash {
+		transferred = false
 		//view.AddSlashIntent(slashIntent)
 	}
-	if !chargeFee(targetAccount, tx.Fee) {
-		// should charge after transfer the fund, so an empty address has some fund to pay the tx fee
-		return common.Hash{}, result.Error("failed to charge transaction fee")
-	}
-	targetAccount.Sequence++ // targetAccount broadcasted the transaction
 
-	view.SetAccount(sourceAddress, sourceAccount)
-	view.SetAccount(targetAddress, targetAccount)
-	for account := range accCoinsMap {
-		view.SetAccount(account.Address, account)
-	}
+	return accCoinsMap, transferred, result.OK
+}
 
-	txHash := types.TxID(chainID, tx)
-	return txHash, result.OK
+// hasReservedFund returns whether the account holds the reserved fund of the reserve sequence
+func hasReservedFund(view *st.StoreView, address common.Address, reserveSequence uint64) bool {
+	account := view.GetAccount(address)
+	if account == nil {
+		return false
+	}
+	for _, reservedFund := range account.ReservedFunds {
+		if reservedFund.ReserveSequence == reserveSequence {
+			return true
+		}
+	}
+	return false
 }
 
 func (exec *ServicePaymentTxExecutor) splitPayment(view *st.StoreView, splitRule *types.SplitRule, resourceID string,
@@ -214,13 +362,36 @@ func (exec *ServicePaymentTxExecutor) getTxInfo(transaction types.Tx) *core.TxIn
 		Address:           tx.Target.Address,
 		Sequence:          tx.Target.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasServicePaymentTx,
 	}
 }
 
 func (exec *ServicePaymentTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.ServicePaymentTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasServicePaymentTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasServicePaymentTx)
 	return effectiveGasPrice
 }
+
+// verifyServicePaymentSource verifies the source signature of the payment. The payment can be signed
+// either by the source account, or by the operator of the source account within its spend limit.
+func verifyServicePaymentSource(chainID string, view *st.StoreView, sourceAccount *types.Account, tx *types.ServicePaymentTx) result.Result {
+	sourceAddress := tx.Source.Address
+	sourceSignBytes := tx.SourceSignBytes(chainID)
+	if tx.Source.Signature.Verify(sourceSignBytes, sourceAccount.Address) {
+		return result.OK
+	}
+
+	operator := view.GetAccountOperator(sourceAddress)
+	if operator == nil || !tx.Source.Signature.Verify(sourceSignBytes, operator.OperatorAddress) {
+		errMsg := fmt.Sprintf("sanityCheckForServicePaymentTx failed on source signature, addr: %v", sourceAddress.Hex())
+		logger.Infof(errMsg)
+		return result.Error(errMsg)
+	}
+	if !operator.CanSpend(tx.Source.Coins) {
+		return result.Error("Operator %v spend limit exceeded: limit is %v, spent %v, tried to spend %v",
+			operator.OperatorAddress.Hex(), operator.SpendLimit, operator.Spent, tx.Source.Coins).
+			WithErrorCode(result.CodeOperatorSpendLimitExceeded)
+	}
+	return result.OK
+}
@@ -0,0 +1,140 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*ServicePaymentDisputeTxExecutor)(nil)
+
+// ------------------------------- ServicePaymentDispute Transaction -----------------------------------
+
+// ServicePaymentDisputeTxExecutor implements the TxExecutor interface
+type ServicePaymentDisputeTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewServicePaymentDisputeTxExecutor creates a new instance of ServicePaymentDisputeTxExecutor
+func NewServicePaymentDisputeTxExecutor(state *st.LedgerState) *ServicePaymentDisputeTxExecutor {
+	return &ServicePaymentDisputeTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *ServicePaymentDisputeTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.ServicePaymentDisputeTx)
+	proof := &tx.Proof
+
+	res := tx.Source.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	sourceAccount, res := getInput(chainID, view, tx.Source)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(sourceAccount, signBytes, tx.Source)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	minimalBalance := tx.Fee
+	if !sourceAccount.Balance.IsGTE(minimalBalance) {
+		logger.Infof("the source did not have enough to cover the fee %X", tx.Source.Address)
+		return result.Error("the source account balance is %v, but required minimal balance is %v", sourceAccount.Balance, minimalBalance)
+	}
+
+	if proof.Source.Address != tx.Source.Address {
+		return result.Error("Only the source of the settlement can dispute it")
+	}
+
+	settlement := view.GetPendingSettlement(proof.Source.Address, proof.Target.Address, proof.ReserveSequence)
+	if settlement == nil || settlement.SettleHeight < view.Height() {
+		return result.Error("No pending settlement from %v to %v for reserve sequence %v",
+			proof.Source.Address.Hex(), proof.Target.Address.Hex(), proof.ReserveSequence).
+			WithErrorCode(result.CodeNoPendingSettlement)
+	}
+	if proof.ResourceID != settlement.ServicePayment.ResourceID {
+		return result.Error("Proof resource ID %v does not match the settlement resource ID %v",
+			proof.ResourceID, settlement.ServicePayment.ResourceID)
+	}
+	if proof.PaymentSequence <= settlement.ServicePayment.PaymentSequence {
+		return result.Error("Proof payment sequence %v is not higher than the pending settlement's payment sequence %v",
+			proof.PaymentSequence, settlement.ServicePayment.PaymentSequence).WithErrorCode(result.CodeStaleServicePayment)
+	}
+
+	// The proof must be countersigned by both the source and the target
+	if proof.Source.Coins.ThetaWei.Cmp(types.Zero) != 0 {
+		return result.Error("Cannot send ThetaWei as service payment!")
+	}
+	res = verifyServicePaymentSource(chainID, view, sourceAccount, proof)
+	if res.IsError() {
+		return res
+	}
+	if !proof.Target.Signature.Verify(proof.TargetSignBytes(chainID), proof.Target.Address) {
+		return result.Error("Proof verification failed on target signature, addr: %v", proof.Target.Address.Hex()).
+			WithErrorCode(result.CodeInvalidSignature)
+	}
+
+	return result.OK
+}
+
+func (exec *ServicePaymentDisputeTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.ServicePaymentDisputeTx)
+	proof := tx.Proof
+
+	sourceAccount, res := getInput(chainID, view, tx.Source)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	settlement := view.GetPendingSettlement(proof.Source.Address, proof.Target.Address, proof.ReserveSequence)
+	if settlement == nil {
+		return common.Hash{}, result.Error("pending settlement not found").WithErrorCode(result.CodeNoPendingSettlement)
+	}
+
+	if !chargeFee(sourceAccount, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	// The settlement resolves to the payment with the highest payment sequence, the dispute
+	// window is not extended
+	settlement.ServicePayment = proof
+	view.SetPendingSettlement(settlement)
+
+	sourceAccount.Sequence++
+	view.SetAccount(tx.Source.Address, sourceAccount)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *ServicePaymentDisputeTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.ServicePaymentDisputeTx)
+	return &core.TxInfo{
+		Address:           tx.Source.Address,
+		Sequence:          tx.Source.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasServicePaymentDispute,
+	}
+}
+
+func (exec *ServicePaymentDisputeTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.ServicePaymentDisputeTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasServicePaymentDispute)
+	return effectiveGasPrice
+}
@@ -0,0 +1,127 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*SetAccountOperatorTxExecutor)(nil)
+
+// ------------------------------- SetAccountOperator Transaction -----------------------------------
+
+// SetAccountOperatorTxExecutor implements the TxExecutor interface
+type SetAccountOperatorTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewSetAccountOperatorTxExecutor creates a new instance of SetAccountOperatorTxExecutor
+func NewSetAccountOperatorTxExecutor(state *st.LedgerState) *SetAccountOperatorTxExecutor {
+	return &SetAccountOperatorTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SetAccountOperatorTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.SetAccountOperatorTx)
+
+	res := tx.Account.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// Get inputs
+	account, res := getInput(chainID, view, tx.Account)
+	if res.IsError() {
+		return res
+	}
+
+	// The operator can only be set with the main key of the account
+	signBytes := tx.SignBytes(chainID)
+	res = validateInputAdvanced(account, signBytes, tx.Account)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	minimalBalance := tx.Fee
+	if !account.Balance.IsGTE(minimalBalance) {
+		logger.Infof("the account did not have enough to cover the fee %X", tx.Account.Address)
+		return result.Error("the account balance is %v, but required minimal balance is %v", account.Balance, minimalBalance)
+	}
+
+	if tx.Operator == tx.Account.Address {
+		return result.Error("Cannot set the account itself as its operator").
+			WithErrorCode(result.CodeInvalidAccountOperator)
+	}
+
+	// Service payments can only be made in TFuel
+	spendLimit := tx.SpendLimit.NoNil()
+	if spendLimit.ThetaWei.Cmp(types.Zero) != 0 {
+		return result.Error("The operator spend limit cannot include ThetaWei").
+			WithErrorCode(result.CodeInvalidAccountOperator)
+	}
+	if !spendLimit.IsNonnegative() {
+		return result.Error("The operator spend limit cannot be negative").
+			WithErrorCode(result.CodeInvalidAccountOperator)
+	}
+
+	return result.OK
+}
+
+func (exec *SetAccountOperatorTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.SetAccountOperatorTx)
+
+	account, res := getInput(chainID, view, tx.Account)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	if !chargeFee(account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	// An empty operator address revokes the current operator. Otherwise the operator
+	// is replaced, and the amount it has spent is reset.
+	if tx.Operator == (common.Address{}) {
+		view.DeleteAccountOperator(tx.Account.Address)
+	} else {
+		view.SetAccountOperator(&types.AccountOperator{
+			AccountAddress:  tx.Account.Address,
+			OperatorAddress: tx.Operator,
+			SpendLimit:      tx.SpendLimit.NoNil(),
+			Spent:           types.NewCoins(0, 0),
+		})
+	}
+
+	account.Sequence++
+	view.SetAccount(tx.Account.Address, account)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *SetAccountOperatorTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.SetAccountOperatorTx)
+	return &core.TxInfo{
+		Address:           tx.Account.Address,
+		Sequence:          tx.Account.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasSetAccountOperator,
+	}
+}
+
+func (exec *SetAccountOperatorTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.SetAccountOperatorTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasSetAccountOperator)
+	return effectiveGasPrice
+}
@@ -44,7 +44,7 @@ func (exec *SlashTxExecutor) sanityCheck(chainID string, view *st.StoreView, tra
 		return res
 	}
 
-	proposerAccount, res := getInput(view, tx.Proposer)
+	proposerAccount, res := getInput(chainID, view, tx.Proposer)
 	if res.IsError() {
 		return res
 	}
@@ -114,25 +114,43 @@ func (exec *SlashTxExecutor) process(chainID string, view *st.StoreView, transac
 		return common.Hash{}, result.Error("Proposer %v does not exist!", proposerAddress)
 	}
 
-	// TODO: We should transfer the collateral to a special address, e.g. 0x0 instead of
-	//       transfering to the proposer, so the proposer gain no extra benefit if it colludes with
-	//       the address that overspent
-
-	// Slash: transfer the collateral and remainding deposit to the validator that identified the overspending
+	// Slash: the collateral and remainding deposit of the overspent reserved fund
 	remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
 	if !remainingFund.IsNonnegative() {
 		remainingFund = types.NewCoins(0, 0) // Should NOT happen, just to be on the safe side
 	}
 	slashedAmount := reservedFund.Collateral.Plus(remainingFund)
 
-	proposerAccount.Balance = proposerAccount.Balance.Plus(slashedAmount)
 	slashedAccount.ReservedFunds = append(slashedAccount.ReservedFunds[:reservedFundIdx],
 		slashedAccount.ReservedFunds[reservedFundIdx+1:]...)
-
-	view.SetAccount(proposerAddress, proposerAccount)
 	view.SetAccount(slashedAddress, slashedAccount)
 
 	txHash := types.TxID(chainID, tx)
+
+	// Without slashing params, the network keeps the original immediate slashing
+	if params := view.GetSlashingParams(); params != nil && params.IsTwoPhase() {
+		// Lock the slashed amount until the council reviews the slash. The proposer gains nothing,
+		// so that it has no incentive to collude with the address that overspent
+		view.SetSlashRecord(&types.SlashRecord{
+			ID:              txHash,
+			SlashedAddress:  slashedAddress,
+			ReserveSequence: tx.ReserveSequence,
+			Proposer:        proposerAddress,
+			SlashProof:      tx.SlashProof,
+			Amount:          slashedAmount,
+			ReviewDeadline:  view.Height() + params.ReviewWindow,
+		})
+		return txHash, result.OK
+	}
+
+	// TODO: We should transfer the collateral to a special address, e.g. 0x0 instead of
+	//       transfering to the proposer, so the proposer gain no extra benefit if it colludes with
+	//       the address that overspent
+
+	// Transfer the slashed amount to the validator that identified the overspending
+	proposerAccount.Balance = proposerAccount.Balance.Plus(slashedAmount)
+	view.SetAccount(proposerAddress, proposerAccount)
+
 	return txHash, result.OK
 }
 
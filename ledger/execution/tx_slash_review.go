@@ -0,0 +1,158 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*SlashReviewTxExecutor)(nil)
+
+// ------------------------------- SlashReview Transaction -----------------------------------
+
+// SlashReviewTxExecutor implements the TxExecutor interface
+type SlashReviewTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewSlashReviewTxExecutor creates a new instance of SlashReviewTxExecutor
+func NewSlashReviewTxExecutor(state *st.LedgerState) *SlashReviewTxExecutor {
+	return &SlashReviewTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *SlashReviewTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.SlashReviewTx)
+
+	params := view.GetSlashingParams()
+	if params == nil || !params.IsTwoPhase() {
+		return result.Error("The slashes are not reviewed on this network").WithErrorCode(result.CodeSlashingDisabled)
+	}
+
+	if len(tx.Inputs) == 0 {
+		return result.Error("Tx needs at least one input")
+	}
+	res := validateInputsBasic(tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+	for _, in := range tx.Inputs {
+		if !in.Coins.NoNil().IsZero() {
+			return result.Error("The council members cannot send coins, got %v from %v", in.Coins, in.Address.Hex())
+		}
+		if !params.IsCouncilMember(in.Address) {
+			return result.Error("%v is not a member of the slashing council", in.Address.Hex()).
+				WithErrorCode(result.CodeNotACouncilMember)
+		}
+	}
+	if uint64(len(tx.Inputs)) < params.Threshold {
+		return result.Error("The slash review is approved by %v council members, %v required",
+			len(tx.Inputs), params.Threshold).WithErrorCode(result.CodeNotEnoughApprovals)
+	}
+
+	// Get inputs, which also rejects the duplicated council members
+	accounts, res := getInputs(chainID, view, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	_, res = validateInputsAdvanced(accounts, signBytes, tx.Inputs)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	feePayer := accounts[string(tx.Inputs[0].Address[:])]
+	if !feePayer.Balance.IsGTE(tx.Fee) {
+		return result.Error("the account balance is %v, but required minimal balance is %v",
+			feePayer.Balance, tx.Fee).WithErrorCode(result.CodeInsufficientFund)
+	}
+
+	record := view.GetSlashRecord(tx.RecordID)
+	if record == nil {
+		return result.Error("No slash pending review with ID %v", tx.RecordID.Hex()).
+			WithErrorCode(result.CodeNoSlashRecord)
+	}
+	if record.ReviewDeadline < view.Height() {
+		return result.Error("The review of slash %v closed at height %v", tx.RecordID.Hex(), record.ReviewDeadline).
+			WithErrorCode(result.CodeSlashReviewClosed)
+	}
+
+	return result.OK
+}
+
+func (exec *SlashReviewTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.SlashReviewTx)
+
+	record := view.GetSlashRecord(tx.RecordID)
+	if record == nil {
+		return common.Hash{}, result.Error("No slash pending review with ID %v", tx.RecordID.Hex()).
+			WithErrorCode(result.CodeNoSlashRecord)
+	}
+
+	accounts, res := getInputs(chainID, view, tx.Inputs)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+	if !chargeFee(accounts[string(tx.Inputs[0].Address[:])], tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+	for _, in := range tx.Inputs {
+		account := accounts[string(in.Address[:])]
+		account.Sequence++
+		view.SetAccount(in.Address, account)
+	}
+
+	if tx.Confirm {
+		view.BurnSlashedFunds(record.Amount)
+		logger.Infof("Slash %v confirmed, burned %v", record.ID.Hex(), record.Amount)
+	} else {
+		slashedAccount := getOrMakeAccount(view, record.SlashedAddress)
+		slashedAccount.Balance = slashedAccount.Balance.Plus(record.Amount)
+		view.SetAccount(record.SlashedAddress, slashedAccount)
+		logger.Infof("Slash %v dismissed, returned %v to %v", record.ID.Hex(), record.Amount, record.SlashedAddress.Hex())
+	}
+	view.DeleteSlashRecord(record.ID)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+// burnExpiredSlashes burns the slashed funds of the slashes whose review window is over
+func (exec *SlashReviewTxExecutor) burnExpiredSlashes(view *st.StoreView) {
+	for _, record := range view.GetSlashRecords() {
+		if record.ReviewDeadline >= view.Height() {
+			continue
+		}
+		view.BurnSlashedFunds(record.Amount)
+		view.DeleteSlashRecord(record.ID)
+		logger.Infof("Slash %v not reviewed by height %v, burned %v", record.ID.Hex(), record.ReviewDeadline, record.Amount)
+	}
+}
+
+func (exec *SlashReviewTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.SlashReviewTx)
+	return &core.TxInfo{
+		Address:           tx.Inputs[0].Address,
+		Sequence:          tx.Inputs[0].Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasSlashReviewTx,
+	}
+}
+
+func (exec *SlashReviewTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.SlashReviewTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasSlashReviewTx)
+	return effectiveGasPrice
+}
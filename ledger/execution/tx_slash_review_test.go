@@ -0,0 +1,157 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// lockSlash slashes the reserved fund of the offender, skipping the verification of the proof
+func lockSlash(require *require.Assertions, et *execTest, offender types.PrivAccount, reserveSequence uint64) *types.SlashRecord {
+	view := et.state().Delivered()
+	account := view.GetAccount(offender.Address)
+	account.ReservedFunds = append(account.ReservedFunds, types.ReservedFund{
+		Collateral:      types.NewCoins(0, 1001),
+		InitialFund:     types.NewCoins(0, 1000),
+		UsedFund:        types.NewCoins(0, 400),
+		ReserveSequence: reserveSequence,
+	})
+	view.SetAccount(offender.Address, account)
+
+	slashTx := &types.SlashTx{
+		Proposer:        types.TxInput{Address: et.accProposer.Address},
+		SlashedAddress:  offender.Address,
+		ReserveSequence: reserveSequence,
+		SlashProof:      common.Bytes("overspending proof"),
+	}
+	txHash, res := et.executor.slashTxExec.process(et.chainID, view, slashTx)
+	require.True(res.IsOK(), res.Message)
+	record := view.GetSlashRecord(txHash)
+	require.NotNil(record)
+	return record
+}
+
+func newSlashReviewTx(et *execTest, record *types.SlashRecord, confirm bool, members ...types.PrivAccount) *types.SlashReviewTx {
+	tx := &types.SlashReviewTx{
+		Fee:      types.NewCoins(0, getMinimumTxFee()),
+		RecordID: record.ID,
+		Confirm:  confirm,
+	}
+	for _, member := range members {
+		seq := et.state().Delivered().GetAccount(member.Address).Sequence + 1
+		tx.Inputs = append(tx.Inputs, types.TxInput{Address: member.Address, Sequence: seq})
+	}
+	signBytes := tx.SignBytes(et.chainID)
+	for _, member := range members {
+		tx.SetSignature(member.Address, member.Sign(signBytes))
+	}
+	return tx
+}
+
+func TestSlashTxWithoutSlashingParams(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	et := NewExecTest()
+	offender := et.accIn
+	et.acc2State(offender, et.accProposer)
+	et.state().Commit()
+
+	// Without slashing params, the slashed funds are transferred to the proposer right away
+	view := et.state().Delivered()
+	proposerBalance := view.GetAccount(et.accProposer.Address).Balance
+	account := view.GetAccount(offender.Address)
+	account.ReservedFunds = append(account.ReservedFunds, types.ReservedFund{
+		Collateral:      types.NewCoins(0, 1001),
+		InitialFund:     types.NewCoins(0, 1000),
+		UsedFund:        types.NewCoins(0, 400),
+		ReserveSequence: 1,
+	})
+	view.SetAccount(offender.Address, account)
+
+	slashTx := &types.SlashTx{
+		Proposer:        types.TxInput{Address: et.accProposer.Address},
+		SlashedAddress:  offender.Address,
+		ReserveSequence: 1,
+		SlashProof:      common.Bytes("overspending proof"),
+	}
+	txHash, res := et.executor.slashTxExec.process(et.chainID, view, slashTx)
+	require.True(res.IsOK(), res.Message)
+	assert.Nil(view.GetSlashRecord(txHash))
+	assert.Equal(proposerBalance.Plus(types.NewCoins(0, 1601)), view.GetAccount(et.accProposer.Address).Balance)
+	assert.Equal(0, len(view.GetAccount(offender.Address).ReservedFunds))
+}
+
+func TestSlashReviewTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	et := NewExecTest()
+	alice := types.MakeAcc("alice")
+	bob := types.MakeAcc("bob")
+	carol := types.MakeAcc("carol")
+	offender := et.accIn
+	et.acc2State(alice, bob, carol, offender, et.accProposer)
+
+	// Slashing is disabled by default
+	record := &types.SlashRecord{ID: common.BytesToHash([]byte("slash"))}
+	_, res := et.executor.ExecuteTx(newSlashReviewTx(et, record, true, alice, bob))
+	assert.Equal(result.CodeSlashingDisabled, res.Code, res.String())
+
+	require.Nil(et.state().Delivered().InitSlashing(&types.SlashingParams{
+		Council:      []common.Address{alice.Address, bob.Address, carol.Address},
+		Threshold:    2,
+		ReviewWindow: 10,
+	}))
+	et.state().Commit()
+
+	// The slashed funds are locked, not transferred to the proposer
+	proposerBalance := et.state().Delivered().GetAccount(et.accProposer.Address).Balance
+	offenderBalance := et.state().Delivered().GetAccount(offender.Address).Balance
+	record = lockSlash(require, et, offender, 1)
+	assert.Equal(types.NewCoins(0, 1601), record.Amount)
+	assert.Equal(et.state().Delivered().Height()+10, record.ReviewDeadline)
+	assert.Equal(proposerBalance, et.state().Delivered().GetAccount(et.accProposer.Address).Balance)
+	assert.Equal(0, len(et.state().Delivered().GetAccount(offender.Address).ReservedFunds))
+	et.state().Commit()
+
+	// The review requires the threshold of the council members
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, record, false, alice))
+	assert.Equal(result.CodeNotEnoughApprovals, res.Code, res.String())
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, record, false, alice, et.accProposer))
+	assert.Equal(result.CodeNotACouncilMember, res.Code, res.String())
+
+	// A dismissed slash returns the funds to the offender
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, record, false, alice, bob))
+	require.True(res.IsOK(), res.Message)
+	assert.Nil(et.state().Delivered().GetSlashRecord(record.ID))
+	assert.Equal(offenderBalance.Plus(record.Amount), et.state().Delivered().GetAccount(offender.Address).Balance)
+	assert.Equal(uint64(1), et.state().Delivered().GetAccount(bob.Address).Sequence)
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, record, true, alice, bob))
+	assert.Equal(result.CodeNoSlashRecord, res.Code, res.String())
+	et.state().Commit()
+
+	// A confirmed slash burns the funds
+	confirmed := lockSlash(require, et, offender, 2)
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, confirmed, true, bob, carol))
+	require.True(res.IsOK(), res.Message)
+	assert.Nil(et.state().Delivered().GetSlashRecord(confirmed.ID))
+	assert.Equal(confirmed.Amount, et.state().Delivered().GetBurnedSlashes())
+	et.state().Commit()
+
+	// The slashes not reviewed in time are burned
+	expired := lockSlash(require, et, offender, 3)
+	et.fastforwardTo(expired.ReviewDeadline)
+	et.executor.BurnExpiredSlashes(et.state().Delivered())
+	assert.NotNil(et.state().Delivered().GetSlashRecord(expired.ID))
+	et.fastforwardTo(expired.ReviewDeadline + 1)
+	_, res = et.executor.ExecuteTx(newSlashReviewTx(et, expired, false, alice, carol))
+	assert.Equal(result.CodeSlashReviewClosed, res.Code, res.String())
+	et.executor.BurnExpiredSlashes(et.state().Delivered())
+	assert.Nil(et.state().Delivered().GetSlashRecord(expired.ID))
+	assert.Equal(confirmed.Amount.Plus(expired.Amount), et.state().Delivered().GetBurnedSlashes())
+}
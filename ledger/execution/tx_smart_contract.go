@@ -38,7 +38,7 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 	}
 
 	// Get input account
-	fromAccount, success := getInput(view, tx.From)
+	fromAccount, success := getInput(chainID, view, tx.From)
 	if success.IsError() {
 		return result.Error("Failed to get the from account")
 	}
@@ -72,6 +72,17 @@ func (exec *SmartContractTxExecutor) sanityCheck(chainID string, view *st.StoreV
 	}
 
 	value := coins.TFuelWei // NoNil() already guarantees value is NOT nil
+	if tx.FeePayer != nil {
+		// The fee payer covers the fee limit, the from account only the value to transfer
+		maxFee := types.Coins{
+			ThetaWei: zero,
+			TFuelWei: feeLimit,
+		}
+		if _, res := validateFeePayer(chainID, view, tx, maxFee, tx.From.Address); res.IsError() {
+			return res
+		}
+		feeLimit = big.NewInt(0)
+	}
 	minimalBalance := types.Coins{
 		ThetaWei: zero,
 		TFuelWei: feeLimit.Add(feeLimit, value),
@@ -94,7 +105,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 	_, _, gasUsed, _ := vm.Execute(tx, view)
 
 	fromAddress := tx.From.Address
-	fromAccount, success := getInput(view, tx.From)
+	fromAccount, success := getInput(chainID, view, tx.From)
 	if success.IsError() {
 		return common.Hash{}, result.Error("Failed to get the from account")
 	}
@@ -104,7 +115,16 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 		ThetaWei: big.NewInt(int64(0)),
 		TFuelWei: feeAmount,
 	}
-	if !chargeFee(fromAccount, fee) {
+	if tx.FeePayer != nil {
+		feePayerAccount, res := getAccount(view, tx.FeePayer.Address)
+		if res.IsError() {
+			return common.Hash{}, res
+		}
+		if !chargeFee(feePayerAccount, fee) {
+			return common.Hash{}, result.Error("failed to charge transaction fee")
+		}
+		view.SetAccount(tx.FeePayer.Address, feePayerAccount)
+	} else if !chargeFee(fromAccount, fee) {
 		return common.Hash{}, result.Error("failed to charge transaction fee")
 	}
 
@@ -115,7 +135,7 @@ func (exec *SmartContractTxExecutor) process(chainID string, view *st.StoreView,
 	view.SetAccount(fromAddress, fromAccount)
 
 	txHash := types.TxID(chainID, tx)
-	return txHash, result.OK
+	return txHash, result.OKWith(result.Info{"gasUsed": gasUsed})
 }
 
 func (exec *SmartContractTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
@@ -124,6 +144,7 @@ func (exec *SmartContractTxExecutor) getTxInfo(transaction types.Tx) *core.TxInf
 		Address:           tx.From.Address,
 		Sequence:          tx.From.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               tx.GasLimit,
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -36,7 +37,7 @@ func (exec *SplitRuleTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 	}
 
 	// Get inputs
-	initiatorAccount, res := getInput(view, tx.Initiator)
+	initiatorAccount, res := getInput(chainID, view, tx.Initiator)
 	if res.IsError() {
 		return res
 	}
@@ -48,9 +49,12 @@ func (exec *SplitRuleTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	if res := checkResourceIDs(chainID, view.Height()+1, tx.ResourceID); res.IsError() {
+		return res
 	}
 
 	minimalBalance := tx.Fee
@@ -96,7 +100,7 @@ func (exec *SplitRuleTxExecutor) sanityCheck(chainID string, view *st.StoreView,
 func (exec *SplitRuleTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.SplitRuleTx)
 
-	initiatorAccount, res := getInput(view, tx.Initiator)
+	initiatorAccount, res := getInput(chainID, view, tx.Initiator)
 	if res.IsError() {
 		return common.Hash{}, res
 	}
@@ -148,13 +152,13 @@ func (exec *SplitRuleTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
 		Address:           tx.Initiator.Address,
 		Sequence:          tx.Initiator.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasSplitRuleTx,
 	}
 }
 
 func (exec *SplitRuleTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.SplitRuleTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasSplitRuleTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasSplitRuleTx)
 	return effectiveGasPrice
 }
@@ -0,0 +1,130 @@
+package execution
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+var _ TxExecutor = (*UpdateAccountSignersTxExecutor)(nil)
+
+// ------------------------------- UpdateAccountSigners Transaction -----------------------------------
+
+// UpdateAccountSignersTxExecutor implements the TxExecutor interface
+type UpdateAccountSignersTxExecutor struct {
+	state *st.LedgerState
+}
+
+// NewUpdateAccountSignersTxExecutor creates a new instance of UpdateAccountSignersTxExecutor
+func NewUpdateAccountSignersTxExecutor(state *st.LedgerState) *UpdateAccountSignersTxExecutor {
+	return &UpdateAccountSignersTxExecutor{
+		state: state,
+	}
+}
+
+func (exec *UpdateAccountSignersTxExecutor) sanityCheck(chainID string, view *st.StoreView, transaction types.Tx) result.Result {
+	tx := transaction.(*types.UpdateAccountSignersTx)
+
+	res := tx.Account.ValidateBasic()
+	if res.IsError() {
+		return res
+	}
+
+	// The signer set can only be updated with the signatures of the current signers
+	account, signers, res := getMultiSigInput(view, tx.Account)
+	if res.IsError() {
+		return res
+	}
+
+	signBytes := tx.SignBytes(chainID)
+	res = validateMultiSigInput(account, signers, signBytes, tx.Account)
+	if res.IsError() {
+		return res
+	}
+
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
+	}
+
+	minimalBalance := tx.Fee
+	if !account.Balance.IsGTE(minimalBalance) {
+		logger.Infof("the account did not have enough to cover the fee %X", tx.Account.Address)
+		return result.Error("the account balance is %v, but required minimal balance is %v", account.Balance, minimalBalance)
+	}
+
+	if len(tx.Signers) == 0 && tx.Threshold == 0 {
+		if view.GetAccountSigners(tx.Account.Address) == nil {
+			return result.Error("Account %v has no signer set to remove", tx.Account.Address.Hex()).
+				WithErrorCode(result.CodeInvalidAccountSigners)
+		}
+		return result.OK
+	}
+
+	newSigners := &types.AccountSigners{
+		AccountAddress: tx.Account.Address,
+		Signers:        tx.Signers,
+		Threshold:      tx.Threshold,
+	}
+	if err := newSigners.Validate(); err != nil {
+		return result.Error("Invalid signer set: %v", err).WithErrorCode(result.CodeInvalidAccountSigners)
+	}
+
+	return result.OK
+}
+
+func (exec *UpdateAccountSignersTxExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
+	tx := transaction.(*types.UpdateAccountSignersTx)
+
+	account, _, res := getMultiSigInput(view, tx.Account)
+	if res.IsError() {
+		return common.Hash{}, res
+	}
+
+	if !chargeFee(account, tx.Fee) {
+		return common.Hash{}, result.Error("failed to charge transaction fee")
+	}
+
+	// An empty signer set returns the account to the control of its key
+	if len(tx.Signers) == 0 {
+		view.DeleteAccountSigners(tx.Account.Address)
+	} else {
+		view.SetAccountSigners(&types.AccountSigners{
+			AccountAddress: tx.Account.Address,
+			Signers:        tx.Signers,
+			Threshold:      tx.Threshold,
+		})
+	}
+
+	account.Sequence++
+	view.SetAccount(tx.Account.Address, account)
+
+	txHash := types.TxID(chainID, tx)
+	return txHash, result.OK
+}
+
+func (exec *UpdateAccountSignersTxExecutor) getTxInfo(transaction types.Tx) *core.TxInfo {
+	tx := transaction.(*types.UpdateAccountSignersTx)
+	return &core.TxInfo{
+		Address:           tx.Account.Address,
+		Sequence:          tx.Account.Sequence,
+		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               exec.calculateGas(transaction),
+	}
+}
+
+func (exec *UpdateAccountSignersTxExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
+	tx := transaction.(*types.UpdateAccountSignersTx)
+	fee := tx.Fee
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, exec.calculateGas(transaction))
+	return effectiveGasPrice
+}
+
+func (exec *UpdateAccountSignersTxExecutor) calculateGas(transaction types.Tx) uint64 {
+	tx := transaction.(*types.UpdateAccountSignersTx)
+	return types.GasUpdateAccountSigners + types.GasMultiSigSignature*uint64(len(tx.Account.Signatures))
+}
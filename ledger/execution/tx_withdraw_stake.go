@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	st "github.com/thetatoken/theta/ledger/state"
@@ -35,7 +36,7 @@ func (exec *WithdrawStakeExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return res
 	}
 
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return result.Error("Failed to get the source account: %v", tx.Source.Address)
 	}
@@ -47,9 +48,8 @@ func (exec *WithdrawStakeExecutor) sanityCheck(chainID string, view *st.StoreVie
 		return res
 	}
 
-	if !sanityCheckForFee(tx.Fee) {
-		return result.Error("Insufficient fee. Transaction fee needs to be at least %v TFuelWei",
-			types.MinimumTransactionFeeTFuelWei).WithErrorCode(result.CodeInvalidFee)
+	if res := sanityCheckForFee(chainID, tx.Fee); res.IsError() {
+		return res
 	}
 
 	if !(tx.Purpose == core.StakeForValidator || tx.Purpose == core.StakeForGuardian) {
@@ -73,7 +73,7 @@ func (exec *WithdrawStakeExecutor) sanityCheck(chainID string, view *st.StoreVie
 func (exec *WithdrawStakeExecutor) process(chainID string, view *st.StoreView, transaction types.Tx) (common.Hash, result.Result) {
 	tx := transaction.(*types.WithdrawStakeTx)
 
-	sourceAccount, success := getInput(view, tx.Source)
+	sourceAccount, success := getInput(chainID, view, tx.Source)
 	if success.IsError() {
 		return common.Hash{}, result.Error("Failed to get the source account")
 	}
@@ -120,13 +120,13 @@ func (exec *WithdrawStakeExecutor) getTxInfo(transaction types.Tx) *core.TxInfo
 		Address:           tx.Source.Address,
 		Sequence:          tx.Source.Sequence,
 		EffectiveGasPrice: exec.calculateEffectiveGasPrice(transaction),
+		Gas:               types.GasWidthdrawStakeTx,
 	}
 }
 
 func (exec *WithdrawStakeExecutor) calculateEffectiveGasPrice(transaction types.Tx) *big.Int {
 	tx := transaction.(*types.WithdrawStakeTx)
 	fee := tx.Fee
-	gas := new(big.Int).SetUint64(types.GasWidthdrawStakeTx)
-	effectiveGasPrice := new(big.Int).Div(fee.TFuelWei, gas)
+	effectiveGasPrice := dmath.QuoUint64(fee.TFuelWei, types.GasWidthdrawStakeTx)
 	return effectiveGasPrice
 }
@@ -0,0 +1,76 @@
+package execution
+
+import (
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const (
+	// MaxCoinbaseTxsPerBlock is the max number of coinbase transactions in a block
+	MaxCoinbaseTxsPerBlock = 1
+
+	// MaxSlashTxsPerBlock is the max number of slash transactions in a block
+	MaxSlashTxsPerBlock = 16
+)
+
+//
+// zeroFeeLaneRules lists the protocol transaction types which are exempt from the fee
+// requirements, and how many of them a block can contain. These transactions are created
+// by the block proposer instead of the clients, so they never go through the mempool.
+// Protocol transaction types added in the future (e.g. governance votes from the
+// validators) should be registered here.
+//
+var zeroFeeLaneRules = map[types.TxType]int{
+	types.TxCoinbase: MaxCoinbaseTxsPerBlock,
+	types.TxSlash:    MaxSlashTxsPerBlock,
+}
+
+//
+// IsZeroFeeLaneTx returns whether the transaction is validated in the zero-fee lane
+//
+func IsZeroFeeLaneTx(tx types.Tx) bool {
+	txType, err := types.GetTxType(tx)
+	if err != nil {
+		return false
+	}
+	_, ok := zeroFeeLaneRules[txType]
+	return ok
+}
+
+//
+// ZeroFeeLane enforces the per-block limits of the zero-fee transactions. A new instance
+// should be created for each block. The blocks before core.ForkZeroFeeLane are not limited.
+//
+type ZeroFeeLane struct {
+	active bool
+	counts map[types.TxType]int
+}
+
+// NewZeroFeeLane creates a new instance of ZeroFeeLane for the block at the given height
+func NewZeroFeeLane(chainID string, blockHeight uint64) *ZeroFeeLane {
+	return &ZeroFeeLane{
+		active: core.IsForkActive(core.ForkZeroFeeLane, chainID, blockHeight),
+		counts: make(map[types.TxType]int),
+	}
+}
+
+// Admit counts the transaction against the limits of the block. Returns error if the
+// limit for the transaction type is exceeded. Regular transactions, and all the transactions of
+// the blocks before core.ForkZeroFeeLane, are always admitted.
+func (lane *ZeroFeeLane) Admit(tx types.Tx) result.Result {
+	txType, err := types.GetTxType(tx)
+	if err != nil {
+		return result.Error("Unknown tx type: %v", err)
+	}
+	maxPerBlock, ok := zeroFeeLaneRules[txType]
+	if !ok || !lane.active {
+		return result.OK
+	}
+	if lane.counts[txType] >= maxPerBlock {
+		return result.Error("Too many zero-fee transactions of type %v in block, at most %v allowed",
+			txType, maxPerBlock).WithErrorCode(result.CodeZeroFeeLaneLimitExceeded)
+	}
+	lane.counts[txType]++
+	return result.OK
+}
@@ -0,0 +1,57 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestIsZeroFeeLaneTx(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsZeroFeeLaneTx(&types.CoinbaseTx{}))
+	assert.True(IsZeroFeeLaneTx(&types.SlashTx{}))
+	assert.False(IsZeroFeeLaneTx(&types.SendTx{}))
+	assert.False(IsZeroFeeLaneTx(&types.DepositStakeTx{}))
+}
+
+func TestZeroFeeLaneLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	lane := NewZeroFeeLane(testChainID, 1)
+
+	// At most one coinbase transaction per block
+	assert.True(lane.Admit(&types.CoinbaseTx{}).IsOK())
+	res := lane.Admit(&types.CoinbaseTx{})
+	assert.Equal(result.CodeZeroFeeLaneLimitExceeded, res.Code, res.String())
+
+	// Slash transactions have their own limit
+	for i := 0; i < MaxSlashTxsPerBlock; i++ {
+		assert.True(lane.Admit(&types.SlashTx{}).IsOK())
+	}
+	res = lane.Admit(&types.SlashTx{})
+	assert.Equal(result.CodeZeroFeeLaneLimitExceeded, res.Code, res.String())
+
+	// Regular transactions are not limited by the lane
+	for i := 0; i < 2*MaxSlashTxsPerBlock; i++ {
+		assert.True(lane.Admit(&types.SendTx{}).IsOK())
+	}
+
+	// Limits are per block
+	lane = NewZeroFeeLane(testChainID, 1)
+	assert.True(lane.Admit(&types.CoinbaseTx{}).IsOK())
+
+	// Blocks before the fork are not limited
+	core.SetForkHeight(core.ForkZeroFeeLane, testChainID, 100)
+	defer core.SetForkHeight(core.ForkZeroFeeLane, testChainID, 0)
+	lane = NewZeroFeeLane(testChainID, 99)
+	for i := 0; i < 2; i++ {
+		assert.True(lane.Admit(&types.CoinbaseTx{}).IsOK())
+	}
+	lane = NewZeroFeeLane(testChainID, 100)
+	assert.True(lane.Admit(&types.CoinbaseTx{}).IsOK())
+	assert.False(lane.Admit(&types.CoinbaseTx{}).IsOK())
+}
@@ -0,0 +1,256 @@
+// Package invariants checks the properties the ledger state needs to keep across blocks, e.g. that
+// no coins are created out of thin air. The checks traverse the whole state, so they are meant for
+// debugging and for offline verification, not for the block processing of production nodes.
+package invariants
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	exec "github.com/thetatoken/theta/ledger/execution"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// Transition is a block along with the states before and after it.
+type Transition struct {
+	ChainID string
+	Block   *core.Block
+	Parent  *state.StoreView // state before the block
+	View    *state.StoreView // state after the block
+}
+
+// Invariant is a property every block needs to preserve
+type Invariant struct {
+	Name  string
+	Check func(t *Transition) error
+}
+
+var (
+	mu         sync.RWMutex
+	invariants = []Invariant{}
+)
+
+func init() {
+	Register(Invariant{Name: "supply_conservation", Check: checkSupplyConservation})
+	Register(Invariant{Name: "non_negative_balances", Check: checkNonNegativeBalances})
+	Register(Invariant{Name: "reserve_collateral", Check: checkReserveCollateral})
+	Register(Invariant{Name: "stake_accounting", Check: checkStakeAccounting})
+}
+
+// Register adds an invariant to the ones checked by CheckAll.
+func Register(inv Invariant) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, registered := range invariants {
+		if registered.Name == inv.Name {
+			panic(fmt.Sprintf("Invariant %v is already registered", inv.Name))
+		}
+	}
+	invariants = append(invariants, inv)
+}
+
+// All returns the registered invariants, in registration order.
+func All() []Invariant {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return append([]Invariant{}, invariants...)
+}
+
+// CheckAll checks all the registered invariants on the transition, and returns the violations.
+func CheckAll(t *Transition) []error {
+	errs := []error{}
+	for _, inv := range All() {
+		if err := inv.Check(t); err != nil {
+			errs = append(errs, fmt.Errorf("Invariant %v violated by block %v at height %v: %v",
+				inv.Name, t.Block.Hash().Hex(), t.Block.Height, err))
+		}
+	}
+	return errs
+}
+
+// Supply is the total amount of coins held in the state
+type Supply struct {
+	Balances      types.Coins // balances of the accounts, including the contracts
+	ReservedFunds types.Coins // collateral and remaining funds of the reserved funds
+	Stakes        *big.Int    // ThetaWei staked, including the withdrawn stakes not returned yet
+	SlashRecords  types.Coins // funds locked by the slashes pending the council review
+	BurnedSlashes types.Coins // slashed funds burned so far, not part of the total supply
+}
+
+// Total returns the total supply.
+func (s *Supply) Total() types.Coins {
+	return s.Balances.Plus(s.ReservedFunds).Plus(s.SlashRecords).Plus(types.Coins{ThetaWei: s.Stakes, TFuelWei: big.NewInt(0)})
+}
+
+// GetSupply sums the coins held in the state.
+func GetSupply(sv *state.StoreView) (*Supply, error) {
+	supply := &Supply{
+		Balances:      types.NewCoins(0, 0),
+		ReservedFunds: types.NewCoins(0, 0),
+		Stakes:        big.NewInt(0),
+		SlashRecords:  types.NewCoins(0, 0),
+		BurnedSlashes: sv.GetBurnedSlashes().NoNil(),
+	}
+	err := traverseAccounts(sv, func(acc *types.Account) error {
+		supply.Balances = supply.Balances.Plus(acc.Balance.NoNil())
+		for _, fund := range acc.ReservedFunds {
+			remaining := fund.InitialFund.NoNil().Minus(fund.UsedFund.NoNil())
+			supply.ReservedFunds = supply.ReservedFunds.Plus(fund.Collateral.NoNil()).Plus(remaining)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range sv.GetSlashRecords() {
+		supply.SlashRecords = supply.SlashRecords.Plus(record.Amount.NoNil())
+	}
+	if vcp := sv.GetValidatorCandidatePool(); vcp != nil {
+		for _, holder := range vcp.SortedCandidates {
+			for _, stake := range holder.Stakes {
+				supply.Stakes.Add(supply.Stakes, stake.Amount)
+			}
+		}
+	}
+	return supply, nil
+}
+
+// traverseAccounts calls cb on the accounts in key order, until cb returns an error.
+func traverseAccounts(sv *state.StoreView, cb func(acc *types.Account) error) error {
+	var err error
+	sv.TraverseAccounts(func(key, value common.Bytes) bool {
+		acc := &types.Account{}
+		if decodeErr := types.FromBytes(value, acc); decodeErr != nil {
+			err = fmt.Errorf("Failed to decode account %x: %v", key, decodeErr)
+			return false
+		}
+		err = cb(acc)
+		return err == nil
+	})
+	return err
+}
+
+// checkSupplyConservation checks that Theta is never created, and only burned by the fees on
+// the chains accepting fees in Theta, and by the slashing, and that TFuel is only created by the
+// coinbase transaction.
+func checkSupplyConservation(t *Transition) error {
+	if t.Parent == nil {
+		return nil
+	}
+	before, err := GetSupply(t.Parent)
+	if err != nil {
+		return err
+	}
+	after, err := GetSupply(t.View)
+	if err != nil {
+		return err
+	}
+
+	minted := types.NewCoins(0, 0)
+	for _, rawTx := range t.Block.Txs {
+		tx, err := types.TxFromBytes(rawTx)
+		if err != nil {
+			return err
+		}
+		if coinbaseTx, ok := tx.(*types.CoinbaseTx); ok {
+			for _, output := range coinbaseTx.Outputs {
+				minted = minted.Plus(output.Coins.NoNil())
+			}
+		}
+	}
+
+	// The slashed funds burned in the block are accounted for as if they were still held
+	beforeTotal := before.Total()
+	afterTotal := after.Total().Plus(after.BurnedSlashes.Minus(before.BurnedSlashes))
+	maxTheta := new(big.Int).Add(beforeTotal.ThetaWei, minted.ThetaWei)
+	if afterTotal.ThetaWei.Cmp(maxTheta) > 0 {
+		return fmt.Errorf("ThetaWei supply increased from %v to %v, with %v minted", beforeTotal.ThetaWei, afterTotal.ThetaWei, minted.ThetaWei)
+	}
+	if afterTotal.ThetaWei.Cmp(maxTheta) < 0 && !exec.IsThetaFeeAllowed(t.ChainID) {
+		return fmt.Errorf("ThetaWei supply decreased from %v to %v, but the fees cannot be paid in Theta", beforeTotal.ThetaWei, afterTotal.ThetaWei)
+	}
+	maxTFuel := new(big.Int).Add(beforeTotal.TFuelWei, minted.TFuelWei)
+	if afterTotal.TFuelWei.Cmp(maxTFuel) > 0 {
+		return fmt.Errorf("TFuelWei supply increased from %v to %v, with %v minted", beforeTotal.TFuelWei, afterTotal.TFuelWei, minted.TFuelWei)
+	}
+	return nil
+}
+
+// checkNonNegativeBalances checks the balances, and the coins held by the reserved funds.
+func checkNonNegativeBalances(t *Transition) error {
+	return traverseAccounts(t.View, func(acc *types.Account) error {
+		if !acc.Balance.NoNil().IsNonnegative() {
+			return fmt.Errorf("Account %v has a negative balance %v", acc.Address.Hex(), acc.Balance)
+		}
+		for _, fund := range acc.ReservedFunds {
+			if !fund.Collateral.NoNil().IsNonnegative() || !fund.InitialFund.NoNil().IsNonnegative() || !fund.UsedFund.NoNil().IsNonnegative() {
+				return fmt.Errorf("Reserved fund %v of %v holds negative coins: collateral %v, fund %v, used %v",
+					fund.ReserveSequence, acc.Address.Hex(), fund.Collateral, fund.InitialFund, fund.UsedFund)
+			}
+		}
+		return nil
+	})
+}
+
+// checkReserveCollateral checks that the payments out of a reserved fund never exceed it, and
+// that the collateral covers the fund, so overspending can always be slashed.
+func checkReserveCollateral(t *Transition) error {
+	return traverseAccounts(t.View, func(acc *types.Account) error {
+		for _, fund := range acc.ReservedFunds {
+			if !fund.InitialFund.NoNil().IsGTE(fund.UsedFund.NoNil()) {
+				return fmt.Errorf("Reserved fund %v of %v paid out %v, more than the fund %v",
+					fund.ReserveSequence, acc.Address.Hex(), fund.UsedFund, fund.InitialFund)
+			}
+			if !fund.Collateral.NoNil().Minus(fund.InitialFund.NoNil()).IsPositive() {
+				return fmt.Errorf("Collateral %v of reserved fund %v of %v does not cover the fund %v",
+					fund.Collateral, fund.ReserveSequence, acc.Address.Hex(), fund.InitialFund)
+			}
+		}
+		return nil
+	})
+}
+
+// checkStakeAccounting checks that the validator candidate pool is sorted, that every stake
+// holder and stake source is listed once, and that the stakes are consistent.
+func checkStakeAccounting(t *Transition) error {
+	vcp := t.View.GetValidatorCandidatePool()
+	if vcp == nil {
+		return nil
+	}
+
+	holders := map[common.Address]bool{}
+	for i, holder := range vcp.SortedCandidates {
+		if holders[holder.Holder] {
+			return fmt.Errorf("Stake holder %v is listed more than once", holder.Holder.Hex())
+		}
+		holders[holder.Holder] = true
+		if len(holder.Stakes) == 0 {
+			return fmt.Errorf("Stake holder %v has no stake", holder.Holder.Hex())
+		}
+		if i > 0 && vcp.SortedCandidates[i-1].TotalStake().Cmp(holder.TotalStake()) < 0 {
+			return fmt.Errorf("Stake holder %v is sorted after %v, which has less stake", holder.Holder.Hex(), vcp.SortedCandidates[i-1].Holder.Hex())
+		}
+
+		sources := map[common.Address]bool{}
+		for _, stake := range holder.Stakes {
+			if sources[stake.Source] {
+				return fmt.Errorf("Source %v has more than one stake with holder %v", stake.Source.Hex(), holder.Holder.Hex())
+			}
+			sources[stake.Source] = true
+			if stake.Amount == nil || stake.Amount.Cmp(core.MinValidatorStakeDeposit) < 0 {
+				return fmt.Errorf("Stake of %v with holder %v is below the minimum deposit: %v", stake.Source.Hex(), holder.Holder.Hex(), stake.Amount)
+			}
+			if stake.Withdrawn == (stake.ReturnHeight == core.InvalidReturnHeight) {
+				return fmt.Errorf("Stake of %v with holder %v has withdrawn = %v but return height %v",
+					stake.Source.Hex(), holder.Holder.Hex(), stake.Withdrawn, stake.ReturnHeight)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package invariants_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/fixtures"
+	"github.com/thetatoken/theta/ledger/invariants"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func generate(t *testing.T) *fixtures.Fixture {
+	// The ledger also checks the invariants while generating the chain
+	viper.Set(common.CfgLedgerCheckInvariants, true)
+	defer viper.Set(common.CfgLedgerCheckInvariants, false)
+
+	f, err := fixtures.Generate(fixtures.DefaultConfig())
+	require.Nil(t, err)
+	return f
+}
+
+// tipTransition returns the transition of the last block, with a copy of its state to tamper with
+func tipTransition(t *testing.T, f *fixtures.Fixture) *invariants.Transition {
+	parent, err := f.StateAt(f.Tip().Height - 1)
+	require.Nil(t, err)
+	view, err := f.StateAt(f.Tip().Height)
+	require.Nil(t, err)
+	view, err = view.Copy()
+	require.Nil(t, err)
+	return &invariants.Transition{ChainID: f.Config.ChainID, Block: f.Tip().Block, Parent: parent, View: view}
+}
+
+func violated(errs []error) []string {
+	names := []string{}
+	for _, inv := range invariants.All() {
+		for _, err := range errs {
+			if strings.Contains(err.Error(), "Invariant "+inv.Name+" ") {
+				names = append(names, inv.Name)
+			}
+		}
+	}
+	return names
+}
+
+func TestInvariantsHold(t *testing.T) {
+	f := generate(t)
+	for height := uint64(1); height <= f.Tip().Height; height++ {
+		parent, err := f.StateAt(height - 1)
+		require.Nil(t, err)
+		view, err := f.StateAt(height)
+		require.Nil(t, err)
+		errs := invariants.CheckAll(&invariants.Transition{ChainID: f.Config.ChainID, Block: f.Blocks[height].Block, Parent: parent, View: view})
+		assert.Empty(t, errs)
+	}
+}
+
+func TestInvariantViolations(t *testing.T) {
+	assert := assert.New(t)
+	f := generate(t)
+	addr := f.Accounts[0].Address
+
+	// Coins created out of thin air
+	tr := tipTransition(t, f)
+	acc := tr.View.GetAccount(addr)
+	acc.Balance = acc.Balance.Plus(types.NewCoins(0, 1e18)) // more than the fees burned by the block
+	tr.View.SetAccount(addr, acc)
+	assert.Equal([]string{"supply_conservation"}, violated(invariants.CheckAll(tr)))
+
+	// Theta burned on a chain where the fees are paid in TFuel only
+	tr = tipTransition(t, f)
+	acc = tr.View.GetAccount(addr)
+	acc.Balance = acc.Balance.Minus(types.NewCoins(1, 0))
+	tr.View.SetAccount(addr, acc)
+	assert.Equal([]string{"supply_conservation"}, violated(invariants.CheckAll(tr)))
+
+	// Reserved fund overspent
+	tr = tipTransition(t, f)
+	var fundAddr common.Address
+	for _, account := range f.Accounts {
+		if acc = tr.View.GetAccount(account.Address); len(acc.ReservedFunds) > 0 {
+			fundAddr = account.Address
+			break
+		}
+	}
+	require.NotEmpty(t, acc.ReservedFunds)
+	fund := &acc.ReservedFunds[0]
+	fund.UsedFund = fund.InitialFund.Plus(types.NewCoins(0, 1))
+	tr.View.SetAccount(fundAddr, acc)
+	assert.Equal([]string{"reserve_collateral"}, violated(invariants.CheckAll(tr)))
+
+	// Stake withdrawn without a return height
+	tr = tipTransition(t, f)
+	vcp := tr.View.GetValidatorCandidatePool()
+	vcp.SortedCandidates[0].Stakes[0].Withdrawn = true
+	tr.View.UpdateValidatorCandidatePool(vcp)
+	assert.Equal([]string{"stake_accounting"}, violated(invariants.CheckAll(tr)))
+}
+
+func TestSlashedFundsAccounting(t *testing.T) {
+	assert := assert.New(t)
+	f := generate(t)
+	addr := f.Accounts[0].Address
+	slashed := types.NewCoins(1, 1000)
+
+	// Funds locked by a slash pending review remain in the supply
+	tr := tipTransition(t, f)
+	acc := tr.View.GetAccount(addr)
+	acc.Balance = acc.Balance.Minus(slashed)
+	tr.View.SetAccount(addr, acc)
+	record := &types.SlashRecord{ID: common.BytesToHash([]byte("slash")), SlashedAddress: addr, Amount: slashed}
+	tr.View.SetSlashRecord(record)
+	assert.Empty(invariants.CheckAll(tr))
+
+	// Burning the slashed funds, even Theta, is accounted for
+	tr.View.DeleteSlashRecord(record.ID)
+	tr.View.BurnSlashedFunds(slashed)
+	assert.Empty(invariants.CheckAll(tr))
+
+	// Returning more than the locked funds creates coins
+	acc.Balance = acc.Balance.Plus(slashed).Plus(slashed)
+	tr.View.SetAccount(addr, acc)
+	assert.Equal([]string{"supply_conservation"}, violated(invariants.CheckAll(tr)))
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	num := len(invariants.All())
+	invariants.Register(invariants.Invariant{Name: "test_invariant", Check: func(tr *invariants.Transition) error { return nil }})
+	assert.Equal(num+1, len(invariants.All()))
+	assert.Panics(func() {
+		invariants.Register(invariants.Invariant{Name: "test_invariant", Check: func(tr *invariants.Transition) error { return nil }})
+	})
+}
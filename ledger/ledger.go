@@ -18,6 +18,7 @@ import (
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
 	exec "github.com/thetatoken/theta/ledger/execution"
+	"github.com/thetatoken/theta/ledger/invariants"
 	"github.com/thetatoken/theta/ledger/state"
 	st "github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
@@ -127,6 +128,21 @@ func (ledger *Ledger) GetFinalizedValidatorCandidatePool(blockHash common.Hash,
 	return nil, fmt.Errorf("Failed to find a directly finalized ancestor block for %v", blockHash)
 }
 
+// GetNodeAddress returns the node address registered by the validator in the state of the given
+// block, or nil if none
+func (ledger *Ledger) GetNodeAddress(blockHash common.Hash, validator common.Address) (*core.NodeAddress, error) {
+	db := ledger.state.DB()
+	block, err := findBlock(kvstore.NewKVStore(db), blockHash)
+	if err != nil {
+		return nil, err
+	}
+	storeView := st.NewStoreView(block.Height, block.BlockHeader.StateHash, db)
+	if storeView == nil {
+		return nil, fmt.Errorf("The state of block %v has been pruned", blockHash.Hex())
+	}
+	return storeView.GetNodeAddress(validator), nil
+}
+
 func findBlock(store store.Store, blockHash common.Hash) (*core.ExtendedBlock, error) {
 	var block core.ExtendedBlock
 	err := store.Get(blockHash[:], &block)
@@ -150,15 +166,9 @@ func (ledger *Ledger) ScreenTxUnsafe(rawTx common.Bytes) (res result.Result) {
 
 // ScreenTx screens the given transaction
 func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res result.Result) {
-	var tx types.Tx
-	tx, err := types.TxFromBytes(rawTx)
-	if err != nil {
-		return nil, result.Error("Error decoding tx: %v", err)
-	}
-
-	if ledger.shouldSkipCheckTx(tx) {
-		return nil, result.Error("Unauthorized transaction, should skip").
-			WithErrorCode(result.CodeUnauthorizedTx)
+	tx, res := decodeTxToScreen(rawTx)
+	if res.IsError() {
+		return nil, res
 	}
 
 	ledger.mu.RLock()
@@ -177,6 +187,103 @@ func (ledger *Ledger) ScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res res
 	return txInfo, res
 }
 
+// ScreenReplacementTx screens the transaction replacing a pending transaction of the mempool. The
+// screened view already holds the replaced transaction, so the replacement is screened against the
+// delivered state instead, on which the given pending transactions of the same sender preceding it
+// are applied first.
+func (ledger *Ledger) ScreenReplacementTx(rawTx common.Bytes, precedingRawTxs []common.Bytes) (txInfo *core.TxInfo, res result.Result) {
+	tx, res := decodeTxToScreen(rawTx)
+	if res.IsError() {
+		return nil, res
+	}
+
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	view, err := ledger.state.Delivered().Copy()
+	if err != nil {
+		return nil, result.Error("Failed to copy the delivered view: %v", err)
+	}
+	screened := ledger.state.SetScreened(view)
+	defer ledger.state.SetScreened(screened)
+
+	for _, precedingRawTx := range precedingRawTxs {
+		precedingTx, err := types.TxFromBytes(precedingRawTx)
+		if err != nil {
+			return nil, result.Error("Error decoding tx: %v", err)
+		}
+		if _, res = ledger.executor.ScreenTx(precedingTx); res.IsError() {
+			return nil, res
+		}
+	}
+
+	_, res = ledger.executor.ScreenTx(tx)
+	if res.IsError() {
+		return nil, res
+	}
+
+	return ledger.executor.GetTxInfo(tx)
+}
+
+// SimulateScreenTx screens the given transaction the same way ScreenTx does, but on a copy of the
+// screened view, so that the screened state is left untouched.
+func (ledger *Ledger) SimulateScreenTx(rawTx common.Bytes) (txInfo *core.TxInfo, res result.Result) {
+	tx, res := decodeTxToScreen(rawTx)
+	if res.IsError() {
+		return nil, res
+	}
+
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	view, err := ledger.state.Screened().Copy()
+	if err != nil {
+		return nil, result.Error("Failed to copy the screened view: %v", err)
+	}
+	screened := ledger.state.SetScreened(view)
+	defer ledger.state.SetScreened(screened)
+
+	_, res = ledger.executor.ScreenTx(tx)
+	if res.IsError() {
+		return nil, res
+	}
+
+	return ledger.executor.GetTxInfo(tx)
+}
+
+// GetTxInfo returns the information used by the mempool to sort the given transaction, without
+// screening it.
+func (ledger *Ledger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil, result.Error("Error decoding tx: %v", err)
+	}
+	return ledger.executor.GetTxInfo(tx)
+}
+
+// decodeTxToScreen decodes the given transaction, and rejects the transactions which can never
+// enter the mempool.
+func decodeTxToScreen(rawTx common.Bytes) (types.Tx, result.Result) {
+	if res := exec.CheckTxSize(rawTx); res.IsError() {
+		return nil, res
+	}
+
+	var tx types.Tx
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil, result.Error("Error decoding tx: %v", err)
+	}
+
+	// Zero-fee transactions can only be initiated by the validators, i.e. if a regular user
+	// submits a coinbaseTx or slashTx, it should be skipped so it will not get into the mempool
+	if exec.IsZeroFeeLaneTx(tx) {
+		return nil, result.Error("Unauthorized transaction, should skip").
+			WithErrorCode(result.CodeUnauthorizedTx)
+	}
+
+	return tx, result.OK
+}
+
 // ProposeBlockTxs collects and executes a list of transactions, which will be used to assemble the next blockl
 // It also clears these transactions from the mempool.
 func (ledger *Ledger) ProposeBlockTxs(block *core.Block) (stateRootHash common.Hash, blockRawTxs []common.Bytes, res result.Result) {
@@ -192,36 +299,76 @@ func (ledger *Ledger) ProposeBlockTxs(block *core.Block) (stateRootHash common.H
 	defer func() { ledger.currentBlock = nil }()
 
 	view := ledger.state.Checked()
+	regularRawTxs := ledger.mempool.ReapUnsafe(core.MaxNumRegularTxsPerBlock)
+	proposedTxs, _ := ledger.checkProposalTxs(block, view, regularRawTxs)
+
+	blockRawTxs = []common.Bytes{}
+	receipts := []*core.TxReceipt{}
+	for _, ptx := range proposedTxs {
+		blockRawTxs = append(blockRawTxs, ptx.rawTx)
+		receipts = append(receipts, ptx.receipt)
+	}
+
+	ledger.handleDelayedStateUpdates(view)
+
+	if block != nil {
+		block.SetReceipts(receipts)
+	}
+
+	stateRootHash = view.Hash()
 
+	return stateRootHash, blockRawTxs, result.OK
+}
+
+// proposedTx is a transaction that passed the check for a block proposal
+type proposedTx struct {
+	rawTx   common.Bytes
+	tx      types.Tx
+	res     result.Result
+	receipt *core.TxReceipt
+}
+
+// checkProposalTxs adds the special transactions, and checks them and the given regular transactions
+// on the view in order. It returns the transactions to include in the block, and the rejected ones.
+// The caller must hold the mempool and the ledger locks.
+func (ledger *Ledger) checkProposalTxs(block *core.Block, view *st.StoreView, regularRawTxs []common.Bytes) (proposedTxs []*proposedTx, rejectedTxs []*RejectedTx) {
 	// Add special transactions
 	rawTxCandidates := []common.Bytes{}
 	ledger.addSpecialTransactions(block, view, &rawTxCandidates)
 
 	// Add regular transactions submitted by the clients
-	regularRawTxs := ledger.mempool.ReapUnsafe(core.MaxNumRegularTxsPerBlock)
 	for _, regularRawTx := range regularRawTxs {
 		rawTxCandidates = append(rawTxCandidates, regularRawTx)
 	}
 
-	blockRawTxs = []common.Bytes{}
+	blockHeight := view.Height() + 1 // the view points to the parent of the proposed block, which may be nil
+	zeroFeeLane := exec.NewZeroFeeLane(ledger.state.GetChainID(), blockHeight)
 	for _, rawTxCandidate := range rawTxCandidates {
 		tx, err := types.TxFromBytes(rawTxCandidate)
 		if err != nil {
+			rejectedTxs = append(rejectedTxs, newRejectedTx(rawTxCandidate, result.Error("Failed to parse transaction: %v", err)))
 			continue
 		}
+		if res := zeroFeeLane.Admit(tx); res.IsError() {
+			logger.Errorf("Transaction skipped: errMsg = %v, tx = %v", res.Message, tx)
+			rejectedTxs = append(rejectedTxs, newRejectedTx(rawTxCandidate, res))
+			continue
+		}
+		view.PopLogs() // discard the logs left by the previous failed transaction, if any
 		_, res := ledger.executor.CheckTx(tx)
 		if res.IsError() {
 			logger.Errorf("Transaction check failed: errMsg = %v, tx = %v", res.Message, tx)
+			rejectedTxs = append(rejectedTxs, newRejectedTx(rawTxCandidate, res))
 			continue
 		}
-		blockRawTxs = append(blockRawTxs, rawTxCandidate)
+		proposedTxs = append(proposedTxs, &proposedTx{
+			rawTx:   rawTxCandidate,
+			tx:      tx,
+			res:     res,
+			receipt: newTxReceipt(rawTxCandidate, view.PopLogs()),
+		})
 	}
-
-	ledger.handleDelayedStateUpdates(view)
-
-	stateRootHash = view.Hash()
-
-	return stateRootHash, blockRawTxs, result.OK
+	return proposedTxs, rejectedTxs
 }
 
 // ApplyBlockTxs applies the given block transactions. If any of the transactions failed, it returns
@@ -248,12 +395,19 @@ func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
 	currStateRoot := view.Hash()
 
 	hasValidatorUpdate := false
+	receipts := []*core.TxReceipt{}
+	zeroFeeLane := exec.NewZeroFeeLane(ledger.state.GetChainID(), block.Height)
+	view.PopLogs()
 	for _, rawTx := range blockRawTxs {
 		tx, err := types.TxFromBytes(rawTx)
 		if err != nil {
 			ledger.resetState(currHeight, currStateRoot)
 			return result.Error("Failed to parse transaction: %v", hex.EncodeToString(rawTx))
 		}
+		if res := zeroFeeLane.Admit(tx); res.IsError() {
+			ledger.resetState(currHeight, currStateRoot)
+			return res
+		}
 		if _, ok := tx.(*types.DepositStakeTx); ok {
 			hasValidatorUpdate = true
 		} else if _, ok := tx.(*types.WithdrawStakeTx); ok {
@@ -264,6 +418,7 @@ func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
 			ledger.resetState(currHeight, currStateRoot)
 			return res
 		}
+		receipts = append(receipts, newTxReceipt(rawTx, view.PopLogs()))
 	}
 
 	ledger.handleDelayedStateUpdates(view)
@@ -276,59 +431,116 @@ func (ledger *Ledger) ApplyBlockTxs(block *core.Block) result.Result {
 			hex.EncodeToString(expectedStateRoot[:]))
 	}
 
+	if block.CommitsReceipts() {
+		receiptRoot := core.CalculateReceiptHash(receipts)
+		if receiptRoot != block.ReceiptHash {
+			ledger.resetState(currHeight, currStateRoot)
+			return result.Error("Receipt root mismatch! root: %v, exptected: %v",
+				hex.EncodeToString(receiptRoot[:]),
+				hex.EncodeToString(block.ReceiptHash[:]))
+		}
+		if bloom := core.CreateBloom(receipts); bloom != block.Bloom {
+			ledger.resetState(currHeight, currStateRoot)
+			return result.Error("Log bloom mismatch! bloom: %v, expected: %v",
+				hex.EncodeToString(bloom[:]),
+				hex.EncodeToString(block.Bloom[:]))
+		}
+	}
+
+	if viper.GetBool(common.CfgLedgerCheckInvariants) {
+		ledger.checkInvariants(block, currHeight, currStateRoot, view)
+	}
+
 	ledger.state.Commit() // commit to persistent storage
 
+	if block.CommitsReceipts() && ledger.chain != nil {
+		ledger.chain.AddBlockReceipts(block.Hash(), receipts)
+		ledger.chain.AddLogsToIndex(block, receipts)
+	}
+
 	ledger.mempool.UpdateUnsafe(blockRawTxs) // clear txs from the mempool
 
 	return result.OKWith(result.Info{"hasValidatorUpdate": hasValidatorUpdate})
 }
 
-// PruneState attempts to prune the state up to the targetEndHeight
-func (ledger *Ledger) PruneState(targetEndHeight uint64) error {
+// checkInvariants checks the ledger invariants on the state transition of the block. A violation
+// means the transactions were applied incorrectly, so the node stops right away.
+func (ledger *Ledger) checkInvariants(block *core.Block, parentHeight uint64, parentStateRoot common.Hash, view *st.StoreView) {
+	transition := &invariants.Transition{
+		ChainID: ledger.state.GetChainID(),
+		Block:   block,
+		Parent:  st.NewStoreView(parentHeight, parentStateRoot, view.GetDB()),
+		View:    view,
+	}
+	errs := invariants.CheckAll(transition)
+	for _, err := range errs {
+		logger.Error(err)
+	}
+	if len(errs) > 0 {
+		log.Panicf("%v ledger invariants violated by block %v", len(errs), block.Hash().Hex())
+	}
+}
+
+// newTxReceipt creates the receipt of the given transaction from the logs it emitted
+func newTxReceipt(rawTx common.Bytes, logs []*types.Log) *core.TxReceipt {
+	events := []core.ReceiptEvent{}
+	for _, l := range logs {
+		events = append(events, core.ReceiptEvent{
+			Address: l.Address,
+			Topics:  l.Topics,
+			Data:    l.Data,
+		})
+	}
+	return &core.TxReceipt{
+		TxHash: crypto.Keccak256Hash(rawTx),
+		Events: events,
+	}
+}
+
+// StatePruningProgress returns the height up to which the states have been pruned
+func (ledger *Ledger) StatePruningProgress() uint64 {
 	var processedHeight uint64
-	db := ledger.State().DB()
-	kvStore := kvstore.NewKVStore(db)
+	kvStore := kvstore.NewKVStore(ledger.State().DB())
 	err := kvStore.Get(state.StatePruningProgressKey(), &processedHeight)
 	if err != nil {
 		processedHeight = ledger.chain.Root().Height
 	}
+	return processedHeight
+}
 
-	pruneInterval := uint64(viper.GetInt(common.CfgStorageStatePruningInterval))
-	maxHeightsToPrune := 3 * pruneInterval // prune too many heights at once could cause hang, should catchup gradually
-	endHeight := processedHeight + maxHeightsToPrune
+// PruneState prunes the states after the pruning progress up to the targetEndHeight, at most
+// maxHeights heights at once since pruning too many heights at once could cause hang. It returns
+// the height up to which the states have been pruned, and the number of pruned states.
+func (ledger *Ledger) PruneState(targetEndHeight, maxHeights uint64) (uint64, int, error) {
+	processedHeight := ledger.StatePruningProgress()
+	endHeight := processedHeight + maxHeights
 	if endHeight > targetEndHeight {
 		endHeight = targetEndHeight
 	}
 
 	startHeight := processedHeight + 1
 	if endHeight < startHeight {
-		errMsg := fmt.Sprintf("endHeight (%v) < startHeight (%v)", endHeight, startHeight)
-		logger.Warnf(errMsg)
-		return fmt.Errorf(errMsg)
+		return processedHeight, 0, nil
 	}
 
 	lastFinalizedBlock := ledger.consensus.GetLastFinalizedBlock()
 	if endHeight >= lastFinalizedBlock.Height {
-		errMsg := fmt.Sprintf("Can't prune at height >= %v yet", lastFinalizedBlock.Height)
-		logger.Warnf(errMsg)
-		return fmt.Errorf(errMsg)
+		return processedHeight, 0, fmt.Errorf("Can't prune at height >= %v yet", lastFinalizedBlock.Height)
 	}
 
 	// Need to save the progress before pruning -- in case the program exits during pruning (e.g. Ctrl+C),
 	// the states that are already pruned do not get pruned again
+	kvStore := kvstore.NewKVStore(ledger.State().DB())
 	kvStore.Put(state.StatePruningProgressKey(), endHeight)
 
-	err = ledger.pruneStateForRange(startHeight, endHeight)
-	if err != nil {
-		logger.Warnf("Unable to pruning state: %v", err)
-		return err
-	}
-
-	return nil
+	numPruned, err := ledger.pruneStateForRange(startHeight, endHeight)
+	return endHeight, numPruned, err
 }
 
-// pruneStateForRange prunes states from startHeight to endHeight (inclusive for both end)
-func (ledger *Ledger) pruneStateForRange(startHeight, endHeight uint64) error {
+// pruneStateForRange prunes states from startHeight to endHeight (inclusive for both end), and returns
+// the number of pruned states. The ledger is locked while each state is pruned, so that the reference
+// counts of the trie nodes are not updated concurrently by the blocks being applied.
+func (ledger *Ledger) pruneStateForRange(startHeight, endHeight uint64) (int, error) {
 	logger.Infof("Prune state from height %v to %v", startHeight, endHeight)
 
 	db := ledger.State().DB()
@@ -338,6 +550,7 @@ func (ledger *Ledger) pruneStateForRange(startHeight, endHeight uint64) error {
 
 	sv := state.NewStoreView(lastFinalizedBlock.Height, lastFinalizedBlock.BlockHeader.StateHash, db)
 
+	numPruned := 0
 	stateHashMap := make(map[string]bool)
 	kvStore := kvstore.NewKVStore(db)
 	hl := sv.GetStakeTransactionHeightList().Heights
@@ -387,16 +600,19 @@ func (ledger *Ledger) pruneStateForRange(startHeight, endHeight uint64) error {
 					continue
 				}
 
+				ledger.mu.Lock()
 				sv := state.NewStoreView(height, block.StateHash, db)
 				err = sv.Prune()
+				ledger.mu.Unlock()
 				if err != nil {
-					return fmt.Errorf("Failed to prune storeview at height %v, %v", height, err)
+					return numPruned, fmt.Errorf("Failed to prune storeview at height %v, %v", height, err)
 				}
+				numPruned++
 			}
 		}
 	}
 
-	return nil
+	return numPruned, nil
 }
 
 // ResetState sets the ledger state with the designated root
@@ -430,24 +646,12 @@ func (ledger *Ledger) resetState(height uint64, rootHash common.Hash) result.Res
 	return result.OK
 }
 
-// CheckTx() should skip all the transactions that can only be initiated by the validators
-// i.e., if a regular user submits a coinbaseTx or slashTx, it should be skipped so it will not
-// get into the mempool
-func (ledger *Ledger) shouldSkipCheckTx(tx types.Tx) bool {
-	switch tx.(type) {
-	case *types.CoinbaseTx:
-		return true
-	case *types.SlashTx:
-		return true
-	default:
-		return false
-	}
-}
-
 // handleDelayedStateUpdates handles delayed state updates, e.g. stake return, where the stake
 // is returned only after X blocks of its corresponding StakeWithdraw transaction
 func (ledger *Ledger) handleDelayedStateUpdates(view *st.StoreView) {
 	ledger.handleStakeReturn(view)
+	ledger.executor.SettleServicePayments(view)
+	ledger.executor.BurnExpiredSlashes(view)
 }
 
 func (ledger *Ledger) handleStakeReturn(view *st.StoreView) {
@@ -509,15 +713,16 @@ func (ledger *Ledger) addCoinbaseTx(view *st.StoreView, proposer *core.Validator
 		validatorAddress := validator.Address
 		validatorAddresses[idx] = validatorAddress
 	}
-	accountRewardMap := exec.CalculateReward(view, validatorAddresses)
+	chainID := ledger.state.GetChainID()
+	accountRewardMap := exec.CalculateReward(chainID, view, validatorAddresses, ledger.state.Height())
 
+	// List the rewarded accounts in the order of the reward cohort
+	cohort, _ := exec.RewardCohort(chainID, validatorAddresses, ledger.state.Height())
 	coinbaseTxOutputs := []types.TxOutput{}
-	for accountAddressStr, accountReward := range accountRewardMap {
-		var accountAddress common.Address
-		copy(accountAddress[:], accountAddressStr)
+	for _, accountAddress := range cohort {
 		coinbaseTxOutputs = append(coinbaseTxOutputs, types.TxOutput{
 			Address: accountAddress,
-			Coins:   accountReward,
+			Coins:   accountRewardMap[string(accountAddress[:])],
 		})
 	}
 
@@ -579,9 +784,13 @@ func (ledger *Ledger) addSlashTxs(view *st.StoreView, proposer *core.Validator,
 
 // signTransaction signs the given transaction
 func (ledger *Ledger) signTransaction(tx types.Tx) (*crypto.Signature, error) {
+	privateKey := ledger.consensus.PrivateKey()
+	if privateKey == nil {
+		return nil, fmt.Errorf("Read-only node cannot sign transactions")
+	}
 	chainID := ledger.state.GetChainID()
 	signBytes := tx.SignBytes(chainID)
-	signature, err := ledger.consensus.PrivateKey().Sign(signBytes)
+	signature, err := privateKey.Sign(signBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +102,41 @@ func TestLedgerProposerBlockTxs(t *testing.T) {
 	}
 }
 
+func TestLedgerDryRunProposal(t *testing.T) {
+	assert := assert.New(t)
+
+	chainID, ledger, mempool := newTestLedger()
+	numInAccs := 3
+	accOut, accIns := prepareInitLedgerState(ledger, numInAccs)
+
+	expectedFees := types.NewCoins(0, 0)
+	for idx := 0; idx < numInAccs; idx++ {
+		sendTxBytes := newRawSendTx(chainID, 1, true, accOut, accIns[idx], true)
+		err := mempool.InsertTransaction(sendTxBytes)
+		assert.Nil(err, fmt.Sprintf("Mempool insertion error: %v", err))
+		tx, err := types.TxFromBytes(sendTxBytes)
+		assert.Nil(err)
+		expectedFees = expectedFees.Plus(tx.(*types.SendTx).Fee)
+	}
+	checkedStateHash := ledger.state.Checked().Hash()
+
+	dryRun, res := ledger.DryRunProposal(nil)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(numInAccs, len(dryRun.Txs))
+	assert.Equal(0, len(dryRun.RejectedTxs))
+	assert.Equal(uint64(numInAccs)*2*types.GasSendTxPerAccount, dryRun.GasUsed)
+	assert.Equal(0, expectedFees.TFuelWei.Cmp(dryRun.Fees.TFuelWei))
+
+	// The dry run leaves the mempool and the checked view untouched
+	assert.Equal(numInAccs, mempool.Size())
+	assert.Equal(checkedStateHash, ledger.state.Checked().Hash())
+
+	stateHash, blockTxs, res := ledger.ProposeBlockTxs(nil)
+	assert.True(res.IsOK(), res.Message)
+	assert.Equal(dryRun.Txs, blockTxs)
+	assert.Equal(dryRun.StateHash, stateHash)
+}
+
 func TestLedgerApplyBlockTxs(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
@@ -0,0 +1,148 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/core"
+)
+
+// statePruningCheckInterval is how often the pruner checks whether states fell out of the retained blocks
+const statePruningCheckInterval = 2 * time.Second
+
+// StatePruningStatus reports the progress of the state pruning.
+type StatePruningStatus struct {
+	RetainedBlocks      uint64
+	BatchSize           uint64
+	LastFinalizedHeight uint64
+	TargetHeight        uint64 // height up to which the states are to be pruned
+	PrunedHeight        uint64 // height up to which the states have been pruned
+	PrunedStates        uint64 // number of states pruned since the node started
+	LastBatchTime       time.Time
+	LastBatchDuration   time.Duration
+	LastError           string
+}
+
+// StatePruner prunes in the background the states of the finalized blocks which fell out of the
+// retained blocks, in batches of at most batchSize heights. The trie nodes still referenced by the
+// retained states are kept, as well as the states of the stake transaction heights and of the
+// blocks with validator updates.
+type StatePruner struct {
+	ledger         *Ledger
+	finality       core.FinalityProvider
+	retainedBlocks uint64
+	interval       uint64
+	batchSize      uint64
+
+	mu     *sync.Mutex
+	status StatePruningStatus
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewStatePruner creates a new instance of StatePruner. A batch is pruned once the last finalized
+// block advanced by interval blocks past the pruned height, so batches follow each other while catching up.
+func NewStatePruner(ledger *Ledger, finality core.FinalityProvider, retainedBlocks, interval, batchSize uint64) *StatePruner {
+	return &StatePruner{
+		ledger:         ledger,
+		finality:       finality,
+		retainedBlocks: retainedBlocks,
+		interval:       interval,
+		batchSize:      batchSize,
+		mu:             &sync.Mutex{},
+		status: StatePruningStatus{
+			RetainedBlocks: retainedBlocks,
+			BatchSize:      batchSize,
+		},
+		wg: &sync.WaitGroup{},
+	}
+}
+
+// Start creates the main goroutine.
+func (sp *StatePruner) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	sp.ctx = c
+	sp.cancel = cancel
+
+	sp.mu.Lock()
+	sp.status.PrunedHeight = sp.ledger.StatePruningProgress()
+	sp.mu.Unlock()
+
+	sp.wg.Add(1)
+	go sp.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (sp *StatePruner) Stop() {
+	sp.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (sp *StatePruner) Wait() {
+	sp.wg.Wait()
+}
+
+// Status returns the progress of the state pruning.
+func (sp *StatePruner) Status() StatePruningStatus {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.status
+}
+
+func (sp *StatePruner) mainLoop() {
+	defer sp.wg.Done()
+
+	ticker := time.NewTicker(statePruningCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sp.ctx.Done():
+			return
+		case <-ticker.C:
+			sp.pruneBatch()
+		}
+	}
+}
+
+// pruneBatch prunes the next batch of heights, if any. A failed batch is not retried, the
+// progress is saved before pruning.
+func (sp *StatePruner) pruneBatch() {
+	lastFinalizedHeight := sp.finality.GetLastFinalizedBlock().Height
+
+	sp.mu.Lock()
+	sp.status.LastFinalizedHeight = lastFinalizedHeight
+	prunedHeight := sp.status.PrunedHeight
+	sp.mu.Unlock()
+
+	if lastFinalizedHeight <= sp.retainedBlocks+1 {
+		return
+	}
+	targetHeight := lastFinalizedHeight - sp.retainedBlocks
+	if targetHeight < prunedHeight+sp.interval {
+		return
+	}
+
+	start := time.Now()
+	prunedHeight, numPruned, err := sp.ledger.PruneState(targetHeight, sp.batchSize)
+	if err != nil {
+		logger.WithFields(log.Fields{"targetHeight": targetHeight, "error": err}).Warn("Failed to prune state")
+	}
+
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.status.TargetHeight = targetHeight
+	sp.status.PrunedHeight = prunedHeight
+	sp.status.PrunedStates += uint64(numPruned)
+	sp.status.LastBatchTime = start
+	sp.status.LastBatchDuration = time.Since(start)
+	if err != nil {
+		sp.status.LastError = err.Error()
+	} else {
+		sp.status.LastError = ""
+	}
+}
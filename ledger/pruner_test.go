@@ -0,0 +1,57 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+type lastFinalizedEngine struct {
+	core.FinalityProvider
+	lastFinalized *core.ExtendedBlock
+}
+
+func (e *lastFinalizedEngine) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return e.lastFinalized
+}
+
+func TestStatePrunerRetainedBlocks(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ledger, _ := newTestLedger()
+	kvStore := kvstore.NewKVStore(ledger.State().DB())
+	kvStore.Put(state.StatePruningProgressKey(), uint64(100))
+
+	finality := &lastFinalizedEngine{lastFinalized: &core.ExtendedBlock{Block: core.NewBlock()}}
+	pruner := NewStatePruner(ledger, finality, 512, 16, 48)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pruner.Start(ctx)
+	pruner.Wait()
+
+	status := pruner.Status()
+	assert.Equal(uint64(100), status.PrunedHeight)
+	assert.Equal(uint64(512), status.RetainedBlocks)
+	assert.Equal(uint64(48), status.BatchSize)
+
+	// Within the retained blocks
+	finality.lastFinalized.Height = 400
+	pruner.pruneBatch()
+	status = pruner.Status()
+	assert.Equal(uint64(400), status.LastFinalizedHeight)
+	assert.Equal(uint64(100), status.PrunedHeight)
+	assert.True(status.LastBatchTime.IsZero())
+
+	// Less than the pruning interval past the pruned height
+	finality.lastFinalized.Height = 100 + 512 + 15
+	pruner.pruneBatch()
+	status = pruner.Status()
+	assert.Equal(uint64(100), status.PrunedHeight)
+	assert.True(status.LastBatchTime.IsZero())
+	assert.Equal(uint64(100), ledger.StatePruningProgress())
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/treestore"
+)
+
+// DiffStoreViews calls cb on the entries of the state `to` which differ from the state `from`, with
+// an empty value for the entries deleted in `to`, until cb returns false. The sharded accounts are
+// reported like the other entries, while the shard roots, derived from the accounts, are skipped.
+// The account storage tries are not compared. Both states must have the same number of shards.
+func DiffStoreViews(from, to *StoreView, cb func(key, value common.Bytes) bool) error {
+	if from.numShards != to.numShards {
+		return fmt.Errorf("Number of shards mismatch: %v vs %v", from.numShards, to.numShards)
+	}
+	from.syncShards()
+	to.syncShards()
+
+	stopped := false
+	diffCb := func(key, value common.Bytes) bool {
+		if from.numShards != 0 && (IsShardKey(key) || bytes.Equal(key, ShardCountKey())) {
+			return true
+		}
+		stopped = !cb(key, value)
+		return !stopped
+	}
+	if err := treestore.Diff(from.store, to.store, diffCb); err != nil || stopped {
+		return err
+	}
+	for index := uint64(0); index < from.numShards; index++ {
+		if from.shardRoot(index) == to.shardRoot(index) {
+			continue
+		}
+		if err := treestore.Diff(from.getShard(index), to.getShard(index), diffCb); err != nil {
+			return fmt.Errorf("Failed to diff shard %v: %v", index, err)
+		}
+		if stopped {
+			return nil
+		}
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package state
 
-import "github.com/thetatoken/theta/common"
+import (
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
 
 //
 // ------------------------- Ledger State Keys -------------------------
@@ -11,9 +15,59 @@ func ChainIDKey() common.Bytes {
 	return common.Bytes("chainid")
 }
 
+// AccountKeyPrefix returns the prefix for the account key
+func AccountKeyPrefix() common.Bytes {
+	return common.Bytes("ls/a/")
+}
+
 // AccountKey constructs the state key for the given address
 func AccountKey(addr common.Address) common.Bytes {
-	return append(common.Bytes("ls/a/"), addr[:]...)
+	return append(AccountKeyPrefix(), addr[:]...)
+}
+
+// AccountOperatorKeyPrefix returns the prefix for the account operator key
+func AccountOperatorKeyPrefix() common.Bytes {
+	return common.Bytes("ls/ao/")
+}
+
+// AccountOperatorKey constructs the state key for the operator of the given account
+func AccountOperatorKey(addr common.Address) common.Bytes {
+	return append(AccountOperatorKeyPrefix(), addr[:]...)
+}
+
+// AccountSignersKeyPrefix returns the prefix for the account signers key
+func AccountSignersKeyPrefix() common.Bytes {
+	return common.Bytes("ls/as/")
+}
+
+// AccountSignersKey constructs the state key for the signer set of the given multisig account
+func AccountSignersKey(addr common.Address) common.Bytes {
+	return append(AccountSignersKeyPrefix(), addr[:]...)
+}
+
+// PendingSettlementKeyPrefix returns the prefix for the pending service payment settlement key
+func PendingSettlementKeyPrefix() common.Bytes {
+	return common.Bytes("ls/psp/")
+}
+
+// PendingSettlementKey constructs the state key for the pending settlement between the source and
+// the target for the reserved fund with the given reserve sequence
+func PendingSettlementKey(source common.Address, target common.Address, reserveSequence uint64) common.Bytes {
+	key := append(PendingSettlementKeyPrefix(), source[:]...)
+	key = append(key, target[:]...)
+	return append(key, common.Bytes(fmt.Sprintf("/%d", reserveSequence))...)
+}
+
+// ReservedFundLastHeightKeyPrefix returns the prefix for the key of the reserved fund removal index
+func ReservedFundLastHeightKeyPrefix() common.Bytes {
+	return common.Bytes("ls/rflh/")
+}
+
+// ReservedFundLastHeightKey constructs the state key for the last height at which the account state
+// holds the reserved fund with the given reserve sequence
+func ReservedFundLastHeightKey(addr common.Address, reserveSequence uint64) common.Bytes {
+	key := append(ReservedFundLastHeightKeyPrefix(), addr[:]...)
+	return append(key, common.Bytes(fmt.Sprintf("/%d", reserveSequence))...)
 }
 
 // SplitRuleKeyPrefix returns the prefix for the split rule key
@@ -27,9 +81,34 @@ func SplitRuleKey(resourceID string) common.Bytes {
 	return append(SplitRuleKeyPrefix(), resourceIDBytes[:]...)
 }
 
+// CodeKeyPrefix returns the prefix for the code key
+func CodeKeyPrefix() common.Bytes {
+	return common.Bytes("ls/ch/")
+}
+
 // CodeKey constructs the state key for the given code hash
 func CodeKey(codeHash common.Bytes) common.Bytes {
-	return append(common.Bytes("ls/ch/"), codeHash...)
+	return append(CodeKeyPrefix(), codeHash...)
+}
+
+// NodeAddressKeyPrefix returns the prefix for the validator node address key
+func NodeAddressKeyPrefix() common.Bytes {
+	return common.Bytes("ls/na/")
+}
+
+// NodeAddressKey constructs the state key for the node address registered by the given validator
+func NodeAddressKey(addr common.Address) common.Bytes {
+	return append(NodeAddressKeyPrefix(), addr[:]...)
+}
+
+// DataCommitmentKeyPrefix returns the prefix for the data commitment key
+func DataCommitmentKeyPrefix() common.Bytes {
+	return common.Bytes("ls/dc/")
+}
+
+// DataCommitmentKey constructs the state key for the data commitment with the given Merkle root
+func DataCommitmentKey(root common.Hash) common.Bytes {
+	return append(DataCommitmentKeyPrefix(), root[:]...)
 }
 
 // ValidatorCandidatePoolKey returns the state key for the stake holder set
@@ -47,3 +126,38 @@ func StakeTransactionHeightListKey() common.Bytes {
 func StatePruningProgressKey() common.Bytes {
 	return common.Bytes("ls/spp")
 }
+
+// SlashingParamsKey returns the state key for the slashing parameters, fixed at genesis
+func SlashingParamsKey() common.Bytes {
+	return common.Bytes("ls/slashp")
+}
+
+// SlashRecordKeyPrefix returns the prefix for the key of the slashes pending the council review
+func SlashRecordKeyPrefix() common.Bytes {
+	return common.Bytes("ls/slash/")
+}
+
+// SlashRecordKey constructs the state key for the slash record with the given ID
+func SlashRecordKey(id common.Hash) common.Bytes {
+	return append(SlashRecordKeyPrefix(), id[:]...)
+}
+
+// BurnedSlashesKey returns the state key for the total amount of the slashed funds burned
+func BurnedSlashesKey() common.Bytes {
+	return common.Bytes("ls/slashburn")
+}
+
+// ShardCountKey returns the state key for the number of account shards, fixed at genesis
+func ShardCountKey() common.Bytes {
+	return common.Bytes("ls/shards")
+}
+
+// ShardRootKeyPrefix returns the prefix for the shard root key
+func ShardRootKeyPrefix() common.Bytes {
+	return common.Bytes("ls/shard/")
+}
+
+// ShardRootKey constructs the state key for the root of the trie of the shard with the given index
+func ShardRootKey(index uint64) common.Bytes {
+	return append(ShardRootKeyPrefix(), common.Bytes(fmt.Sprintf("%d", index))...)
+}
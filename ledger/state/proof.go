@@ -0,0 +1,192 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// ProveAccount returns the Merkle proof of the account with the given address, or of its absence,
+// against the root of the state.
+func (sv *StoreView) ProveAccount(addr common.Address) (*types.AccountProof, error) {
+	sv.syncShards()
+	proof := &types.AccountProof{Address: addr}
+	if err := sv.store.Trie.Prove(ShardCountKey(), 0, &proof.ShardCountProof); err != nil {
+		return nil, err
+	}
+	if sv.numShards == 0 {
+		if err := sv.store.Trie.Prove(AccountKey(addr), 0, &proof.AccountProof); err != nil {
+			return nil, err
+		}
+		return proof, nil
+	}
+
+	proof.NumShards = common.JSONUint64(sv.numShards)
+	index := ShardIndex(addr, sv.numShards)
+	if err := sv.store.Trie.Prove(ShardRootKey(index), 0, &proof.ShardRootProof); err != nil {
+		return nil, err
+	}
+	if err := sv.getShard(index).Trie.Prove(AccountKey(addr), 0, &proof.AccountProof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// VerifyAccountProof checks the account proof against the state root, and returns the account it
+// proves, or nil if it proves the account does not exist.
+func VerifyAccountProof(stateRoot common.Hash, proof *types.AccountProof) (*types.Account, error) {
+	rawShardCount, err := verifyProofValue(stateRoot, ShardCountKey(), proof.ShardCountProof)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid shard count proof: %v", err)
+	}
+	numShards := uint64(0)
+	if len(rawShardCount) > 0 {
+		if err := rlp.DecodeBytes(rawShardCount, &numShards); err != nil {
+			return nil, fmt.Errorf("Failed to decode the shard count: %v", err)
+		}
+	}
+	if numShards != uint64(proof.NumShards) {
+		return nil, fmt.Errorf("Shard count mismatch: %v vs %v", numShards, proof.NumShards)
+	}
+
+	accountRoot := stateRoot
+	if numShards != 0 {
+		index := ShardIndex(proof.Address, numShards)
+		rawShardRoot, err := verifyProofValue(stateRoot, ShardRootKey(index), proof.ShardRootProof)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid shard root proof: %v", err)
+		}
+		accountRoot = common.BytesToHash(rawShardRoot)
+	}
+
+	rawAccount, err := verifyProofValue(accountRoot, AccountKey(proof.Address), proof.AccountProof)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid account proof: %v", err)
+	}
+	if len(rawAccount) == 0 {
+		return nil, nil
+	}
+	account := &types.Account{}
+	if err := types.FromBytes(rawAccount, account); err != nil {
+		return nil, fmt.Errorf("Failed to decode the account: %v", err)
+	}
+	return account, nil
+}
+
+// ProveAccounts returns the Merkle proof of the accounts with the given addresses, or of their
+// absence, against the root of the state, in a single bundle.
+func (sv *StoreView) ProveAccounts(addrs []common.Address) (*types.BatchAccountProof, error) {
+	sv.syncShards()
+	proof := &types.BatchAccountProof{
+		Addresses: addrs,
+		NumShards: common.JSONUint64(sv.numShards),
+	}
+	nodes := types.NewProofNodeSet()
+	stateKeys := []common.Bytes{ShardCountKey()}
+	shardKeys := make(map[uint64][]common.Bytes)
+	for _, addr := range addrs {
+		if sv.numShards == 0 {
+			stateKeys = append(stateKeys, AccountKey(addr))
+			continue
+		}
+		index := ShardIndex(addr, sv.numShards)
+		if _, ok := shardKeys[index]; !ok {
+			stateKeys = append(stateKeys, ShardRootKey(index))
+		}
+		shardKeys[index] = append(shardKeys[index], AccountKey(addr))
+	}
+
+	if err := sv.store.Trie.ProveBatch(toByteSlices(stateKeys), nodes); err != nil {
+		return nil, err
+	}
+	for index, keys := range shardKeys {
+		if err := sv.getShard(index).Trie.ProveBatch(toByteSlices(keys), nodes); err != nil {
+			return nil, err
+		}
+	}
+	proof.Nodes = nodes.Nodes()
+	return proof, nil
+}
+
+// VerifyBatchAccountProof checks the batch account proof against the state root, and returns the
+// accounts it proves in the order of the addresses of the proof, nil for the accounts it proves
+// do not exist.
+func VerifyBatchAccountProof(stateRoot common.Hash, proof *types.BatchAccountProof) ([]*types.Account, error) {
+	nodes := proof.Nodes.ToSet()
+	rawShardCount, err := verifyProofSetValue(stateRoot, ShardCountKey(), nodes)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid shard count proof: %v", err)
+	}
+	numShards := uint64(0)
+	if len(rawShardCount) > 0 {
+		if err := rlp.DecodeBytes(rawShardCount, &numShards); err != nil {
+			return nil, fmt.Errorf("Failed to decode the shard count: %v", err)
+		}
+	}
+	if numShards != uint64(proof.NumShards) {
+		return nil, fmt.Errorf("Shard count mismatch: %v vs %v", numShards, proof.NumShards)
+	}
+
+	shardRoots := make(map[uint64]common.Hash)
+	accounts := make([]*types.Account, len(proof.Addresses))
+	for i, addr := range proof.Addresses {
+		accountRoot := stateRoot
+		if numShards != 0 {
+			index := ShardIndex(addr, numShards)
+			shardRoot, ok := shardRoots[index]
+			if !ok {
+				rawShardRoot, err := verifyProofSetValue(stateRoot, ShardRootKey(index), nodes)
+				if err != nil {
+					return nil, fmt.Errorf("Invalid shard root proof of shard %v: %v", index, err)
+				}
+				shardRoot = common.BytesToHash(rawShardRoot)
+				shardRoots[index] = shardRoot
+			}
+			accountRoot = shardRoot
+		}
+
+		rawAccount, err := verifyProofSetValue(accountRoot, AccountKey(addr), nodes)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid account proof of %v: %v", addr.Hex(), err)
+		}
+		if len(rawAccount) == 0 {
+			continue
+		}
+		account := &types.Account{}
+		if err := types.FromBytes(rawAccount, account); err != nil {
+			return nil, fmt.Errorf("Failed to decode the account %v: %v", addr.Hex(), err)
+		}
+		accounts[i] = account
+	}
+	return accounts, nil
+}
+
+func toByteSlices(keys []common.Bytes) [][]byte {
+	slices := make([][]byte, len(keys))
+	for i, key := range keys {
+		slices[i] = key
+	}
+	return slices
+}
+
+// verifyProofValue returns the value of the key proven against the root, nil if the proof shows
+// the key is absent. Nothing needs to be proven against the root of an empty trie.
+func verifyProofValue(root common.Hash, key common.Bytes, nodes types.ProofNodes) (common.Bytes, error) {
+	if isEmptyRoot(root) {
+		return nil, nil
+	}
+	value, _, err := trie.VerifyProof(root, key, nodes)
+	return value, err
+}
+
+// verifyProofSetValue is verifyProofValue against a set of proof nodes.
+func verifyProofSetValue(root common.Hash, key common.Bytes, nodes *types.ProofNodeSet) (common.Bytes, error) {
+	if isEmptyRoot(root) {
+		return nil, nil
+	}
+	value, _, err := trie.VerifyProof(root, key, nodes)
+	return value, err
+}
@@ -0,0 +1,101 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func TestAccountProof(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sharded := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	require.Nil(sharded.InitShards(4))
+	unsharded := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	addrs := createShardTestAccounts(21)
+	for i, addr := range addrs[:20] {
+		setShardTestAccount(sharded, addr, int64(i+1))
+		setShardTestAccount(unsharded, addr, int64(i+1))
+	}
+	absent := addrs[20]
+
+	for _, sv := range []*StoreView{sharded, unsharded} {
+		root := sv.Save()
+
+		proof, err := sv.ProveAccount(addrs[3])
+		require.Nil(err)
+		assert.Equal(sv.NumShards(), uint64(proof.NumShards))
+		account, err := VerifyAccountProof(root, proof)
+		require.Nil(err)
+		require.NotNil(account)
+		assert.Equal(addrs[3], account.Address)
+		assert.Equal(int64(4), account.Balance.TFuelWei.Int64())
+
+		// The proof is only valid for the proven account and the state root
+		proof.Address = addrs[4]
+		_, err = VerifyAccountProof(root, proof)
+		assert.NotNil(err)
+		proof.Address = addrs[3]
+		_, err = VerifyAccountProof(common.BytesToHash([]byte("other root")), proof)
+		assert.NotNil(err)
+
+		// The shard count can't be misreported
+		proof.NumShards = 2
+		_, err = VerifyAccountProof(root, proof)
+		assert.NotNil(err)
+
+		proof, err = sv.ProveAccount(absent)
+		require.Nil(err)
+		account, err = VerifyAccountProof(root, proof)
+		require.Nil(err)
+		assert.Nil(account)
+	}
+}
+
+func TestBatchAccountProof(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	sharded := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	require.Nil(sharded.InitShards(4))
+	unsharded := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	addrs := createShardTestAccounts(21)
+	for i, addr := range addrs[:20] {
+		setShardTestAccount(sharded, addr, int64(i+1))
+		setShardTestAccount(unsharded, addr, int64(i+1))
+	}
+	absent := addrs[20]
+
+	for _, sv := range []*StoreView{sharded, unsharded} {
+		root := sv.Save()
+
+		proven := []common.Address{addrs[3], absent, addrs[7], addrs[12]}
+		proof, err := sv.ProveAccounts(proven)
+		require.Nil(err)
+		accounts, err := VerifyBatchAccountProof(root, proof)
+		require.Nil(err)
+		require.Equal(4, len(accounts))
+		assert.Equal(addrs[3], accounts[0].Address)
+		assert.Equal(int64(4), accounts[0].Balance.TFuelWei.Int64())
+		assert.Nil(accounts[1])
+		assert.Equal(addrs[7], accounts[2].Address)
+		assert.Equal(addrs[12], accounts[3].Address)
+
+		// The shared nodes are included once
+		size := 0
+		for _, addr := range proven {
+			single, err := sv.ProveAccount(addr)
+			require.Nil(err)
+			size += len(single.ShardCountProof) + len(single.ShardRootProof) + len(single.AccountProof)
+		}
+		assert.True(len(proof.Nodes) < size)
+
+		_, err = VerifyBatchAccountProof(common.BytesToHash([]byte("other root")), proof)
+		assert.NotNil(err)
+		proof.NumShards = 2
+		_, err = VerifyBatchAccountProof(root, proof)
+		assert.NotNil(err)
+	}
+}
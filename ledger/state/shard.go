@@ -0,0 +1,268 @@
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/treestore"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+//
+// ------------------------- Account Shards -------------------------
+//
+// The accounts can be partitioned into a number of shards fixed at genesis. The accounts of each
+// shard are stored in a separate trie, whose root is stored in the top-level trie along with the
+// other state entries, so that the shards can later be committed and synced independently. The
+// states without a shard count, e.g. those created before the sharding, keep the accounts in the
+// top-level trie.
+//
+
+// MaxNumShards is the maximum number of account shards
+const MaxNumShards = 1024
+
+// ShardIndex returns the shard of the account with the given address, derived from the hash of
+// the address so that the accounts are evenly spread across the shards.
+func ShardIndex(addr common.Address, numShards uint64) uint64 {
+	hash := crypto.Keccak256(addr[:])
+	return binary.BigEndian.Uint64(hash[:8]) % numShards
+}
+
+// IsShardKey returns whether the state key is managed by the shard layer, as opposed to an entry
+// of the logical state. The shard roots are derived from the accounts.
+func IsShardKey(key common.Bytes) bool {
+	return bytes.HasPrefix(key, ShardRootKeyPrefix())
+}
+
+// isAccountKey returns whether the state key is the key of an account.
+func isAccountKey(key common.Bytes) bool {
+	prefix := AccountKeyPrefix()
+	return len(key) == len(prefix)+common.AddressLength && bytes.HasPrefix(key, prefix)
+}
+
+// NumShards returns the number of account shards, 0 if the accounts are not sharded
+func (sv *StoreView) NumShards() uint64 {
+	return sv.numShards
+}
+
+// InitShards partitions the accounts into the given number of shards. It can only be called on a
+// state without accounts, i.e. when generating the genesis state.
+func (sv *StoreView) InitShards(numShards uint64) error {
+	if sv.numShards != 0 {
+		return fmt.Errorf("The accounts are already partitioned into %v shards", sv.numShards)
+	}
+	if numShards == 0 || numShards > MaxNumShards {
+		return fmt.Errorf("Invalid number of shards: %v, expected 1 to %v", numShards, MaxNumShards)
+	}
+	hasAccounts := false
+	sv.store.Traverse(AccountKeyPrefix(), func(key, value common.Bytes) bool {
+		hasAccounts = true
+		return false
+	})
+	if hasAccounts {
+		return errors.New("The accounts can only be partitioned before any account is created")
+	}
+
+	raw, err := rlp.EncodeToBytes(numShards)
+	if err != nil {
+		return err
+	}
+	sv.store.Set(ShardCountKey(), raw)
+	sv.numShards = numShards
+	return nil
+}
+
+// loadShards reads the number of shards from the top-level trie, and drops the open shard tries.
+func (sv *StoreView) loadShards() {
+	sv.numShards = 0
+	sv.shards = make(map[uint64]*treestore.TreeStore)
+	sv.dirtyShards = make(map[uint64]bool)
+
+	raw := sv.store.Get(ShardCountKey())
+	if len(raw) == 0 {
+		return
+	}
+	if err := rlp.DecodeBytes(raw, &sv.numShards); err != nil {
+		log.Panicf("Failed to decode the number of shards %X: %v", raw, err)
+	}
+}
+
+// shardRoot returns the root of the trie of the shard, empty if the shard has no account.
+func (sv *StoreView) shardRoot(index uint64) common.Hash {
+	return common.BytesToHash(sv.store.Get(ShardRootKey(index)))
+}
+
+// getShard returns the trie of the shard. The shard tries share the in-memory trie DB of the
+// top-level trie, so that reverting the top-level trie also reverts the shards.
+func (sv *StoreView) getShard(index uint64) *treestore.TreeStore {
+	if shard, ok := sv.shards[index]; ok {
+		return shard
+	}
+	shard, err := sv.store.Open(sv.shardRoot(index))
+	if err != nil {
+		log.Panicf("Failed to open shard %v: %v", index, err)
+	}
+	sv.shards[index] = shard
+	return shard
+}
+
+// accountShard returns the shard trie storing the key, if it is the key of a sharded account.
+func (sv *StoreView) accountShard(key common.Bytes) (*treestore.TreeStore, uint64, bool) {
+	if sv.numShards == 0 || !isAccountKey(key) {
+		return nil, 0, false
+	}
+	index := ShardIndex(common.BytesToAddress(key[len(AccountKeyPrefix()):]), sv.numShards)
+	return sv.getShard(index), index, true
+}
+
+// syncShards commits the modified shard tries to the in-memory trie DB, and records their roots
+// in the top-level trie. The shard tries are hashed in parallel, which dominates the commit time
+// of the blocks updating many accounts, e.g. airdrops or reward distributions. Their roots are
+// then merged into the top-level trie in the order of the shards.
+func (sv *StoreView) syncShards() {
+	if len(sv.dirtyShards) == 0 {
+		return
+	}
+	indices := make([]uint64, 0, len(sv.dirtyShards))
+	for index := range sv.dirtyShards {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	shards := make([]*treestore.TreeStore, len(indices))
+	for i, index := range indices {
+		shards[i] = sv.shards[index]
+	}
+
+	roots, errs := commitShardTries(shards)
+	for i, index := range indices {
+		if errs[i] != nil {
+			log.Panicf("Failed to commit shard %v: %v", index, errs[i])
+		}
+		if isEmptyRoot(roots[i]) {
+			sv.store.Delete(ShardRootKey(index))
+		} else {
+			sv.store.Set(ShardRootKey(index), roots[i][:])
+		}
+	}
+	sv.dirtyShards = make(map[uint64]bool)
+}
+
+// commitShardTries commits the shard tries to the in-memory trie DB concurrently, using up to one
+// worker per CPU. The trie DB serializes the insertions of the hashed nodes.
+func commitShardTries(shards []*treestore.TreeStore) ([]common.Hash, []error) {
+	roots := make([]common.Hash, len(shards))
+	errs := make([]error, len(shards))
+	if len(shards) == 1 {
+		roots[0], errs[0] = shards[0].Trie.Commit(nil)
+		return roots, errs
+	}
+
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(shards) {
+		numWorkers = len(shards)
+	}
+	next := make(chan int, len(shards))
+	for i := range shards {
+		next <- i
+	}
+	close(next)
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				roots[i], errs[i] = shards[i].Trie.Commit(nil)
+			}
+		}()
+	}
+	wg.Wait()
+	return roots, errs
+}
+
+// commitShards persists the shard tries. Each version of the state references all its non-empty
+// shard roots, which pruning the version dereferences. Unlike the hashing, the shard tries are
+// persisted one after the other, since the reference counts of the nodes are updated in place.
+func (sv *StoreView) commitShards() error {
+	for index := uint64(0); index < sv.numShards; index++ {
+		root := sv.shardRoot(index)
+		if isEmptyRoot(root) {
+			continue
+		}
+		if err := sv.store.CommitTrie(root); err != nil {
+			return fmt.Errorf("Failed to commit shard %v: %v", index, err)
+		}
+	}
+	return nil
+}
+
+// pruneShards prunes the shard tries of the state.
+func (sv *StoreView) pruneShards(cb func(node []byte) bool) error {
+	for index := uint64(0); index < sv.numShards; index++ {
+		root := sv.shardRoot(index)
+		if isEmptyRoot(root) {
+			continue
+		}
+		shard := treestore.NewTreeStore(root, sv.GetDB())
+		if shard == nil {
+			return fmt.Errorf("Shard %v is missing", index)
+		}
+		if err := shard.Prune(cb); err != nil {
+			return fmt.Errorf("Failed to prune shard %v: %v", index, err)
+		}
+	}
+	return nil
+}
+
+// TraverseAccounts calls cb on the account entries in key order, whether the accounts are sharded
+// or not, until cb returns false.
+func (sv *StoreView) TraverseAccounts(cb func(key, value common.Bytes) bool) {
+	stores := []*treestore.TreeStore{sv.store}
+	if sv.numShards != 0 {
+		stores = []*treestore.TreeStore{}
+		for index := uint64(0); index < sv.numShards; index++ {
+			stores = append(stores, sv.getShard(index))
+		}
+	}
+
+	// Merge the entries of the tries, the number of shards is small
+	prefix := AccountKeyPrefix()
+	its := []*trie.Iterator{}
+	for _, store := range stores {
+		it := trie.NewIterator(store.NodeIterator(prefix))
+		if it.Next() && bytes.HasPrefix(it.Key, prefix) {
+			its = append(its, it)
+		}
+	}
+	for len(its) > 0 {
+		next := 0
+		for i := 1; i < len(its); i++ {
+			if bytes.Compare(its[i].Key, its[next].Key) < 0 {
+				next = i
+			}
+		}
+		it := its[next]
+		if !cb(it.Key, it.Value) {
+			return
+		}
+		if !it.Next() || !bytes.HasPrefix(it.Key, prefix) {
+			its = append(its[:next], its[next+1:]...)
+		}
+	}
+}
+
+func isEmptyRoot(root common.Hash) bool {
+	return root == common.Hash{} || root == emptyTrieRoot
+}
+
+// emptyTrieRoot is the root hash of an empty trie
+var emptyTrieRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
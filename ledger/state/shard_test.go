@@ -0,0 +1,176 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func createShardTestAccounts(num int) []common.Address {
+	addrs := []common.Address{}
+	for i := 0; i < num; i++ {
+		addrs = append(addrs, common.BytesToAddress([]byte(fmt.Sprintf("account%v", i))))
+	}
+	return addrs
+}
+
+func setShardTestAccount(sv *StoreView, addr common.Address, balance int64) {
+	acc := types.NewAccount(addr)
+	acc.Balance = types.NewCoins(0, balance)
+	sv.SetAccount(addr, acc)
+}
+
+func TestShardIndex(t *testing.T) {
+	assert := assert.New(t)
+
+	counts := make([]int, 4)
+	for _, addr := range createShardTestAccounts(400) {
+		index := ShardIndex(addr, 4)
+		assert.Equal(index, ShardIndex(addr, 4))
+		counts[index]++
+	}
+	for _, count := range counts {
+		assert.True(count > 50)
+	}
+	assert.Equal(uint64(0), ShardIndex(createShardTestAccounts(1)[0], 1))
+}
+
+func TestStoreViewShards(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(1, common.Hash{}, db)
+	assert.Equal(uint64(0), sv.NumShards())
+	assert.NotNil(sv.InitShards(0))
+	assert.NotNil(sv.InitShards(MaxNumShards + 1))
+	require.Nil(sv.InitShards(4))
+	assert.NotNil(sv.InitShards(4))
+
+	unsharded := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	addrs := createShardTestAccounts(20)
+	for i, addr := range addrs {
+		setShardTestAccount(sv, addr, int64(i+1))
+		setShardTestAccount(unsharded, addr, int64(i+1))
+	}
+	sv.Set(common.Bytes("key"), common.Bytes("value"))
+
+	// The accounts are not in the top-level trie, but in the shard tries
+	root := sv.Save()
+	sv.GetStore().Traverse(AccountKeyPrefix(), func(key, value common.Bytes) bool {
+		assert.Fail("account in the top-level trie", "%x", key)
+		return true
+	})
+	assert.NotEqual(unsharded.Hash(), root)
+
+	// The accounts are traversed in key order, whether sharded or not
+	expected, actual := []string{}, []string{}
+	unsharded.TraverseAccounts(func(key, value common.Bytes) bool {
+		expected = append(expected, string(key))
+		return true
+	})
+	sv.TraverseAccounts(func(key, value common.Bytes) bool {
+		actual = append(actual, string(key))
+		return true
+	})
+	assert.Equal(len(addrs), len(actual))
+	assert.Equal(expected, actual)
+
+	// The shards are reloaded from the saved state
+	sv = NewStoreView(2, root, db)
+	require.NotNil(sv)
+	assert.Equal(uint64(4), sv.NumShards())
+	for i, addr := range addrs {
+		assert.Equal(big.NewInt(int64(i+1)), sv.GetBalance(addr))
+	}
+	assert.Equal(common.Bytes("value"), sv.Get(common.Bytes("key")))
+	assert.NotNil(sv.InitShards(8))
+
+	// Reverting to a snapshot reverts the shards
+	snapshot := sv.Snapshot()
+	sv.AddBalance(addrs[0], big.NewInt(100))
+	sv.DeleteAccount(addrs[1])
+	assert.NotEqual(snapshot, sv.Hash())
+	sv.RevertToSnapshot(snapshot)
+	assert.Equal(snapshot, sv.Hash())
+	assert.Equal(big.NewInt(1), sv.GetBalance(addrs[0]))
+	assert.NotNil(sv.GetAccount(addrs[1]))
+
+	// The copies do not share the modifications
+	sv.AddBalance(addrs[0], big.NewInt(100))
+	copied, err := sv.Copy()
+	require.Nil(err)
+	assert.Equal(sv.Hash(), copied.Hash())
+	copied.AddBalance(addrs[0], big.NewInt(100))
+	assert.Equal(big.NewInt(101), sv.GetBalance(addrs[0]))
+	assert.Equal(big.NewInt(201), copied.GetBalance(addrs[0]))
+	assert.NotEqual(sv.Hash(), copied.Hash())
+}
+
+func TestStoreViewInitShardsWithAccounts(t *testing.T) {
+	assert := assert.New(t)
+
+	sv := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+	setShardTestAccount(sv, createShardTestAccounts(1)[0], 1)
+	assert.NotNil(sv.InitShards(4))
+	assert.Equal(uint64(0), sv.NumShards())
+}
+
+func TestStoreViewShardsParallelCommit(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	// The root does not depend on the order in which the shards are updated and hashed
+	addrs := createShardTestAccounts(200)
+	roots := []common.Hash{}
+	for _, order := range [][]common.Address{addrs, reverseShardTestAccounts(addrs)} {
+		sv := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+		require.Nil(sv.InitShards(16))
+		for _, addr := range order {
+			setShardTestAccount(sv, addr, 1)
+		}
+		assert.Equal(16, len(sv.dirtyShards))
+		roots = append(roots, sv.Save())
+		assert.Empty(sv.dirtyShards)
+	}
+	assert.Equal(roots[0], roots[1])
+}
+
+func reverseShardTestAccounts(addrs []common.Address) []common.Address {
+	reversed := make([]common.Address, len(addrs))
+	for i, addr := range addrs {
+		reversed[len(addrs)-1-i] = addr
+	}
+	return reversed
+}
+
+// BenchmarkStoreViewSave measures the commit latency of the blocks updating many accounts
+func BenchmarkStoreViewSave(b *testing.B) {
+	addrs := createShardTestAccounts(5000)
+	for _, numShards := range []uint64{0, 4, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%v", numShards), func(b *testing.B) {
+			sv := NewStoreView(1, common.Hash{}, backend.NewMemDatabase())
+			if numShards != 0 {
+				require.Nil(b, sv.InitShards(numShards))
+			}
+			for _, addr := range addrs {
+				setShardTestAccount(sv, addr, 1)
+			}
+			sv.Save()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for _, addr := range addrs {
+					sv.AddBalance(addr, big.NewInt(1))
+				}
+				b.StartTimer()
+				sv.Save()
+			}
+		})
+	}
+}
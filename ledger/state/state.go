@@ -67,6 +67,29 @@ func (s *LedgerState) Finalize(height uint64, stateRootHash common.Hash) result.
 	return result.OK
 }
 
+// Copy returns a LedgerState whose views are copies of the delivered view of this one, so that
+// transactions can be executed on it without affecting this state, e.g. speculatively.
+func (s *LedgerState) Copy() (*LedgerState, error) {
+	copied := &LedgerState{
+		chainID: s.chainID,
+		db:      s.db,
+	}
+	var err error
+	if copied.finalized, err = s.finalized.Copy(); err != nil {
+		return nil, err
+	}
+	if copied.delivered, err = s.delivered.Copy(); err != nil {
+		return nil, err
+	}
+	if copied.checked, err = s.delivered.Copy(); err != nil {
+		return nil, err
+	}
+	if copied.screened, err = s.delivered.Copy(); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
 // GetChainID gets chain ID.
 func (s *LedgerState) GetChainID() string {
 	if s.chainID != "" {
@@ -97,11 +120,27 @@ func (s *LedgerState) Checked() *StoreView {
 	return s.checked
 }
 
+// SetChecked replaces the checked view, e.g. to check transactions speculatively, and returns
+// the previous one so that it can be restored.
+func (s *LedgerState) SetChecked(view *StoreView) *StoreView {
+	prev := s.checked
+	s.checked = view
+	return prev
+}
+
 // Screened creates a fresh clone of delivered view to be used for checking transcations.
 func (s *LedgerState) Screened() *StoreView {
 	return s.screened
 }
 
+// SetScreened replaces the screened view, e.g. to screen a transaction against another state, and
+// returns the previous one so that it can be restored.
+func (s *LedgerState) SetScreened(view *StoreView) *StoreView {
+	prev := s.screened
+	s.screened = view
+	return prev
+}
+
 // Finalized creates a fresh clone of delivered view to be used for checking transcations.
 func (s *LedgerState) Finalized() *StoreView {
 	return s.finalized
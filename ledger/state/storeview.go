@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"math/big"
+	"sort"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/thetatoken/theta/common"
@@ -25,9 +26,26 @@ type StoreView struct {
 	height uint64 // block height
 	store  *treestore.TreeStore
 
+	numShards   uint64                          // Number of account shards, 0 if not sharded
+	shards      map[uint64]*treestore.TreeStore // Open shard tries
+	dirtyShards map[uint64]bool                 // Shards modified since their roots were recorded
+
 	coinbaseTransactinProcessed bool
 	slashIntents                []types.SlashIntent
-	refund                      uint64 // Gas refund during smart contract execution
+	refund                      uint64       // Gas refund during smart contract execution
+	logs                        []*types.Log // Logs emitted by the transaction being executed
+	revisions                   []revision   // Snapshots taken during the execution of the transaction
+	nextRevisionID              int
+}
+
+// revision records a snapshot taken during the execution of a transaction. Reverting to it reverts
+// the state to its root, and drops the logs emitted afterwards. The revisions are identified by an
+// increasing ID rather than the root, since nested snapshots have the same root when the state is
+// not modified in between.
+type revision struct {
+	id      int
+	root    common.Hash
+	numLogs int
 }
 
 // NewStoreView creates an instance of the StoreView
@@ -43,11 +61,13 @@ func NewStoreView(height uint64, root common.Hash, db database.Database) *StoreV
 		slashIntents: []types.SlashIntent{},
 		refund:       0,
 	}
+	sv.loadShards()
 	return sv
 }
 
 // Copy returns a copy of the StoreView
 func (sv *StoreView) Copy() (*StoreView, error) {
+	sv.syncShards()
 	copiedStore, err := sv.store.Copy()
 	if err != nil {
 		return nil, err
@@ -58,6 +78,7 @@ func (sv *StoreView) Copy() (*StoreView, error) {
 		slashIntents: []types.SlashIntent{},
 		refund:       0,
 	}
+	copiedStoreView.loadShards()
 	return copiedStoreView, nil
 }
 
@@ -68,6 +89,7 @@ func (sv *StoreView) GetDB() database.Database {
 
 // Hash returns the root hash of the tree store
 func (sv *StoreView) Hash() common.Hash {
+	sv.syncShards()
 	return sv.store.Hash()
 }
 
@@ -83,6 +105,10 @@ func (sv *StoreView) IncrementHeight() {
 
 // Save saves the StoreView to the persistent storage, and return the root hash
 func (sv *StoreView) Save() common.Hash {
+	sv.syncShards()
+	if err := sv.commitShards(); err != nil {
+		log.Panicf("Failed to save the StoreView: %v", err)
+	}
 	rootHash, err := sv.store.Commit()
 
 	logger.Infof("Commit to data store, height: %v, rootHash: %v", sv.height+1, rootHash.Hex())
@@ -95,6 +121,9 @@ func (sv *StoreView) Save() common.Hash {
 
 // Get returns the value corresponding to the key
 func (sv *StoreView) Get(key common.Bytes) common.Bytes {
+	if shard, _, ok := sv.accountShard(key); ok {
+		return shard.Get(key)
+	}
 	value := sv.store.Get(key)
 	return value
 }
@@ -105,11 +134,21 @@ func (sv *StoreView) ProveVCP(vcpKey []byte, vp *core.VCPProof) error {
 
 // Delete removes the value corresponding to the key
 func (sv *StoreView) Delete(key common.Bytes) {
+	if shard, index, ok := sv.accountShard(key); ok {
+		shard.Delete(key)
+		sv.dirtyShards[index] = true
+		return
+	}
 	sv.store.Delete(key)
 }
 
 // Set returns the value corresponding to the key
 func (sv *StoreView) Set(key common.Bytes, value common.Bytes) {
+	if shard, index, ok := sv.accountShard(key); ok {
+		shard.Set(key, value)
+		sv.dirtyShards[index] = true
+		return
+	}
 	sv.store.Set(key, value)
 }
 
@@ -155,6 +194,8 @@ func (sv *StoreView) GetAccount(addr common.Address) *types.Account {
 
 // SetAccount sets an account.
 func (sv *StoreView) SetAccount(addr common.Address, acc *types.Account) {
+	sv.indexRemovedReservedFunds(addr, acc)
+
 	accBytes, err := types.ToBytes(acc)
 	if err != nil {
 		log.Panicf("Error writing account %v error: %v",
@@ -163,11 +204,332 @@ func (sv *StoreView) SetAccount(addr common.Address, acc *types.Account) {
 	sv.Set(AccountKey(addr), accBytes)
 }
 
+// reservedFundIndexActive returns whether the reserved fund removal index is maintained for the block
+// at the given height. The index is part of the state, hence it is activated by a fork set by the
+// execution package, which the state package can not import.
+var reservedFundIndexActive = func(chainID string, height uint64) bool { return true }
+
+// SetReservedFundIndexActivation sets the function deciding whether the reserved fund removal index is
+// maintained for the block at the given height.
+func SetReservedFundIndexActivation(active func(chainID string, height uint64) bool) {
+	reservedFundIndexActive = active
+}
+
+// indexRemovedReservedFunds records the reserved funds that are about to be removed from the account
+// state, i.e. released, expired or fully settled. The removed entries remain in the state of the current
+// height, which archive nodes keep.
+func (sv *StoreView) indexRemovedReservedFunds(addr common.Address, acc *types.Account) {
+	prevAcc := sv.GetAccount(addr)
+	if prevAcc == nil || len(prevAcc.ReservedFunds) == 0 {
+		return
+	}
+	// The view holds the state of the parent block
+	if !reservedFundIndexActive(string(sv.Get(ChainIDKey())), sv.Height()+1) {
+		return
+	}
+	remaining := make(map[uint64]bool)
+	for _, reservedFund := range acc.ReservedFunds {
+		remaining[reservedFund.ReserveSequence] = true
+	}
+	for _, reservedFund := range prevAcc.ReservedFunds {
+		if remaining[reservedFund.ReserveSequence] {
+			continue
+		}
+		heightBytes, err := types.ToBytes(sv.Height())
+		if err != nil {
+			log.Panicf("Error writing reserved fund last height %v error: %v",
+				sv.Height(), err.Error())
+		}
+		sv.Set(ReservedFundLastHeightKey(addr, reservedFund.ReserveSequence), heightBytes)
+	}
+}
+
+// GetReservedFundLastHeight returns the last height at which the account state holds the reserved fund
+// with the given reserve sequence. Returns false if the reserved fund has not been removed.
+func (sv *StoreView) GetReservedFundLastHeight(addr common.Address, reserveSequence uint64) (uint64, bool) {
+	data := sv.Get(ReservedFundLastHeightKey(addr, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return 0, false
+	}
+	var height uint64
+	err := types.FromBytes(data, &height)
+	if err != nil {
+		log.Panicf("Error reading reserved fund last height %X error: %v",
+			data, err.Error())
+	}
+	return height, true
+}
+
 // DeleteAccount deletes an account.
 func (sv *StoreView) DeleteAccount(addr common.Address) {
 	sv.Delete(AccountKey(addr))
 }
 
+// GetAccountOperator returns the operator authorized by the given account, or nil if none
+func (sv *StoreView) GetAccountOperator(addr common.Address) *types.AccountOperator {
+	data := sv.Get(AccountOperatorKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	operator := &types.AccountOperator{}
+	err := types.FromBytes(data, operator)
+	if err != nil {
+		log.Panicf("Error reading account operator %X error: %v",
+			data, err.Error())
+	}
+	return operator
+}
+
+// SetAccountOperator sets the operator of an account
+func (sv *StoreView) SetAccountOperator(operator *types.AccountOperator) {
+	operatorBytes, err := types.ToBytes(operator)
+	if err != nil {
+		log.Panicf("Error writing account operator %v error: %v",
+			operator, err.Error())
+	}
+	sv.Set(AccountOperatorKey(operator.AccountAddress), operatorBytes)
+}
+
+// DeleteAccountOperator revokes the operator of an account
+func (sv *StoreView) DeleteAccountOperator(addr common.Address) bool {
+	return sv.store.Delete(AccountOperatorKey(addr))
+}
+
+// GetAccountSigners returns the signer set registered on the given account, or nil if none
+func (sv *StoreView) GetAccountSigners(addr common.Address) *types.AccountSigners {
+	data := sv.Get(AccountSignersKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	signers := &types.AccountSigners{}
+	err := types.FromBytes(data, signers)
+	if err != nil {
+		log.Panicf("Error reading account signers %X error: %v",
+			data, err.Error())
+	}
+	return signers
+}
+
+// SetAccountSigners sets the signer set of an account
+func (sv *StoreView) SetAccountSigners(signers *types.AccountSigners) {
+	signersBytes, err := types.ToBytes(signers)
+	if err != nil {
+		log.Panicf("Error writing account signers %v error: %v",
+			signers, err.Error())
+	}
+	sv.Set(AccountSignersKey(signers.AccountAddress), signersBytes)
+}
+
+// DeleteAccountSigners removes the signer set of an account, returning it to the control of its key
+func (sv *StoreView) DeleteAccountSigners(addr common.Address) bool {
+	return sv.store.Delete(AccountSignersKey(addr))
+}
+
+// GetNodeAddress returns the node address registered by the given validator, or nil if none
+func (sv *StoreView) GetNodeAddress(addr common.Address) *core.NodeAddress {
+	data := sv.Get(NodeAddressKey(addr))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	nodeAddress := &core.NodeAddress{}
+	err := types.FromBytes(data, nodeAddress)
+	if err != nil {
+		log.Panicf("Error reading node address %X error: %v",
+			data, err.Error())
+	}
+	return nodeAddress
+}
+
+// SetNodeAddress sets the node address of a validator
+func (sv *StoreView) SetNodeAddress(nodeAddress *core.NodeAddress) {
+	nodeAddressBytes, err := types.ToBytes(nodeAddress)
+	if err != nil {
+		log.Panicf("Error writing node address %v error: %v",
+			nodeAddress, err.Error())
+	}
+	sv.Set(NodeAddressKey(nodeAddress.Validator), nodeAddressBytes)
+}
+
+// DeleteNodeAddress removes the node address of a validator
+func (sv *StoreView) DeleteNodeAddress(addr common.Address) bool {
+	return sv.store.Delete(NodeAddressKey(addr))
+}
+
+// GetDataCommitment returns the data commitment with the given Merkle root, or nil if none
+func (sv *StoreView) GetDataCommitment(root common.Hash) *types.DataCommitment {
+	data := sv.Get(DataCommitmentKey(root))
+	if len(data) == 0 {
+		return nil
+	}
+	commitment := &types.DataCommitment{}
+	err := types.FromBytes(data, commitment)
+	if err != nil {
+		log.Panicf("Error reading data commitment %X error: %v",
+			data, err.Error())
+	}
+	return commitment
+}
+
+// SetDataCommitment sets the data commitment
+func (sv *StoreView) SetDataCommitment(commitment *types.DataCommitment) {
+	commitmentBytes, err := types.ToBytes(commitment)
+	if err != nil {
+		log.Panicf("Error writing data commitment %v error: %v",
+			commitment, err.Error())
+	}
+	sv.Set(DataCommitmentKey(commitment.Root), commitmentBytes)
+}
+
+// GetPendingSettlement returns the pending settlement between the source and the target for the
+// reserved fund, or nil if none
+func (sv *StoreView) GetPendingSettlement(source common.Address, target common.Address, reserveSequence uint64) *types.PendingSettlement {
+	data := sv.Get(PendingSettlementKey(source, target, reserveSequence))
+	if data == nil || len(data) == 0 {
+		return nil
+	}
+	settlement := &types.PendingSettlement{}
+	err := types.FromBytes(data, settlement)
+	if err != nil {
+		log.Panicf("Error reading pending settlement %X error: %v",
+			data, err.Error())
+	}
+	return settlement
+}
+
+// SetPendingSettlement adds or updates a pending settlement
+func (sv *StoreView) SetPendingSettlement(settlement *types.PendingSettlement) {
+	settlementBytes, err := types.ToBytes(settlement)
+	if err != nil {
+		log.Panicf("Error writing pending settlement %v error: %v",
+			settlement, err.Error())
+	}
+	payment := settlement.ServicePayment
+	sv.Set(PendingSettlementKey(payment.Source.Address, payment.Target.Address, payment.ReserveSequence), settlementBytes)
+}
+
+// DeletePendingSettlement deletes a pending settlement
+func (sv *StoreView) DeletePendingSettlement(source common.Address, target common.Address, reserveSequence uint64) bool {
+	return sv.store.Delete(PendingSettlementKey(source, target, reserveSequence))
+}
+
+// GetDuePendingSettlements returns the pending settlements whose dispute window is over at the given height
+func (sv *StoreView) GetDuePendingSettlements(currentBlockHeight uint64) []*types.PendingSettlement {
+	settlements := []*types.PendingSettlement{}
+	sv.store.Traverse(PendingSettlementKeyPrefix(), func(key, value common.Bytes) bool {
+		settlement := &types.PendingSettlement{}
+		err := types.FromBytes(value, settlement)
+		if err != nil {
+			log.Panicf("Error reading pending settlement %X error: %v", value, err.Error())
+		}
+		if settlement.SettleHeight <= currentBlockHeight {
+			settlements = append(settlements, settlement)
+		}
+		return true
+	})
+	return settlements
+}
+
+// GetSlashingParams returns the slashing parameters of the network, or nil if slashing is disabled
+func (sv *StoreView) GetSlashingParams() *types.SlashingParams {
+	data := sv.Get(SlashingParamsKey())
+	if len(data) == 0 {
+		return nil
+	}
+	params := &types.SlashingParams{}
+	err := types.FromBytes(data, params)
+	if err != nil {
+		log.Panicf("Error reading slashing params %X error: %v",
+			data, err.Error())
+	}
+	return params
+}
+
+// InitSlashing enables slashing with the given parameters. It can only be called when generating
+// the genesis state.
+func (sv *StoreView) InitSlashing(params *types.SlashingParams) error {
+	if sv.GetSlashingParams() != nil {
+		return fmt.Errorf("Slashing is already enabled")
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	paramsBytes, err := types.ToBytes(params)
+	if err != nil {
+		return err
+	}
+	sv.Set(SlashingParamsKey(), paramsBytes)
+	return nil
+}
+
+// GetSlashRecord returns the slash record with the given ID, or nil if none
+func (sv *StoreView) GetSlashRecord(id common.Hash) *types.SlashRecord {
+	data := sv.Get(SlashRecordKey(id))
+	if len(data) == 0 {
+		return nil
+	}
+	record := &types.SlashRecord{}
+	err := types.FromBytes(data, record)
+	if err != nil {
+		log.Panicf("Error reading slash record %X error: %v",
+			data, err.Error())
+	}
+	return record
+}
+
+// SetSlashRecord adds or updates a slash record
+func (sv *StoreView) SetSlashRecord(record *types.SlashRecord) {
+	recordBytes, err := types.ToBytes(record)
+	if err != nil {
+		log.Panicf("Error writing slash record %v error: %v",
+			record, err.Error())
+	}
+	sv.Set(SlashRecordKey(record.ID), recordBytes)
+}
+
+// DeleteSlashRecord deletes a slash record
+func (sv *StoreView) DeleteSlashRecord(id common.Hash) bool {
+	return sv.store.Delete(SlashRecordKey(id))
+}
+
+// GetSlashRecords returns the slashes pending the review of the council
+func (sv *StoreView) GetSlashRecords() []*types.SlashRecord {
+	records := []*types.SlashRecord{}
+	sv.store.Traverse(SlashRecordKeyPrefix(), func(key, value common.Bytes) bool {
+		record := &types.SlashRecord{}
+		err := types.FromBytes(value, record)
+		if err != nil {
+			log.Panicf("Error reading slash record %X error: %v", value, err.Error())
+		}
+		records = append(records, record)
+		return true
+	})
+	return records
+}
+
+// GetBurnedSlashes returns the total amount of the slashed funds burned
+func (sv *StoreView) GetBurnedSlashes() types.Coins {
+	data := sv.Get(BurnedSlashesKey())
+	if len(data) == 0 {
+		return types.NewCoins(0, 0)
+	}
+	burned := types.Coins{}
+	err := types.FromBytes(data, &burned)
+	if err != nil {
+		log.Panicf("Error reading burned slashes %X error: %v",
+			data, err.Error())
+	}
+	return burned
+}
+
+// BurnSlashedFunds adds the given coins to the total amount of the slashed funds burned
+func (sv *StoreView) BurnSlashedFunds(coins types.Coins) {
+	burnedBytes, err := types.ToBytes(sv.GetBurnedSlashes().Plus(coins))
+	if err != nil {
+		log.Panicf("Error writing burned slashes error: %v", err.Error())
+	}
+	sv.Set(BurnedSlashesKey(), burnedBytes)
+}
+
 // SplitRuleExists checks if a split rule associated with the given resourceID already exists
 func (sv *StoreView) SplitRuleExists(resourceID string) bool {
 	return sv.GetSplitRule(resourceID) != nil
@@ -306,6 +668,8 @@ func (sv *StoreView) UpdateStakeTransactionHeightList(hl *types.HeightList) {
 	sv.Set(StakeTransactionHeightListKey(), hlBytes)
 }
 
+// GetStore returns the top-level tree store, which does not hold the accounts if they are sharded,
+// see TraverseAccounts.
 func (sv *StoreView) GetStore() *treestore.TreeStore {
 	return sv.store
 }
@@ -503,37 +867,77 @@ func (sv *StoreView) RevertToSnapshot(root common.Hash) {
 	if err != nil {
 		log.Panic(err)
 	}
+	sv.loadShards() // the shard tries are reopened from the reverted roots
 }
 
 func (sv *StoreView) Snapshot() common.Hash {
+	sv.syncShards()
 	sv.store.Trie.Commit(nil) // Needs to commit to the in-memory trie DB
 	return sv.store.Hash()
 }
 
-func (sv *StoreView) Prune() error {
-	err := sv.store.Prune(func(node []byte) bool {
-		account := &types.Account{}
-		err := types.FromBytes(node, account)
-		if err != nil {
-			return false
-		}
-		if account.Root == (common.Hash{}) {
-			return false
-		}
-		storage := sv.getAccountStorage(account)
-		err = storage.Prune(nil)
-		if err != nil {
-			logger.Errorf("Failed to prune storage for account %v", account)
-			return false
-		}
-		return true
+// SnapshotRevision takes a snapshot of the state and the logs, and returns its revision ID
+func (sv *StoreView) SnapshotRevision() int {
+	id := sv.nextRevisionID
+	sv.nextRevisionID++
+	sv.revisions = append(sv.revisions, revision{id: id, root: sv.Snapshot(), numLogs: len(sv.logs)})
+	return id
+}
+
+// RevertToRevision reverts the state and the logs to the given revision, and discards the revisions
+// taken afterwards
+func (sv *StoreView) RevertToRevision(id int) {
+	idx := sort.Search(len(sv.revisions), func(i int) bool {
+		return sv.revisions[i].id >= id
 	})
+	if idx == len(sv.revisions) || sv.revisions[idx].id != id {
+		log.Panicf("Revision %v cannot be reverted", id)
+	}
+	rev := sv.revisions[idx]
+	sv.RevertToSnapshot(rev.root)
+	sv.logs = sv.logs[:rev.numLogs]
+	sv.revisions = sv.revisions[:idx]
+}
+
+func (sv *StoreView) Prune() error {
+	if err := sv.pruneShards(sv.pruneAccountStorage); err != nil {
+		return fmt.Errorf("Failed to prune store view, %v", err)
+	}
+	err := sv.store.Prune(sv.pruneAccountStorage)
 	if err != nil {
 		return fmt.Errorf("Failed to prune store view, %v", err)
 	}
 	return nil
 }
 
-func (sv *StoreView) AddLog(*types.Log) {
-	// TODO
+// pruneAccountStorage prunes the storage of the account encoded in the trie node, if any
+func (sv *StoreView) pruneAccountStorage(node []byte) bool {
+	account := &types.Account{}
+	err := types.FromBytes(node, account)
+	if err != nil {
+		return false
+	}
+	if account.Root == (common.Hash{}) {
+		return false
+	}
+	storage := sv.getAccountStorage(account)
+	err = storage.Prune(nil)
+	if err != nil {
+		logger.Errorf("Failed to prune storage for account %v", account)
+		return false
+	}
+	return true
+}
+
+// AddLog records a log emitted by the transaction being executed
+func (sv *StoreView) AddLog(l *types.Log) {
+	sv.logs = append(sv.logs, l)
+}
+
+// PopLogs returns the logs recorded since the last call, and clears them
+func (sv *StoreView) PopLogs() []*types.Log {
+	logs := sv.logs
+	sv.logs = nil
+	sv.revisions = nil
+	return logs
 }
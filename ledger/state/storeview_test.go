@@ -109,6 +109,118 @@ func TestStoreViewAccountAccess(t *testing.T) {
 	log.Infof("Balance: %v\n", accRetrieved.Balance)
 }
 
+func TestStoreViewReservedFundLastHeight(t *testing.T) {
+	assert := assert.New(t)
+
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("account1")
+	assert.Nil(err)
+
+	acc := &types.Account{
+		Address: pubKey.Address(),
+		Balance: types.NewCoins(0, 20000),
+	}
+	acc.ReserveFund(types.NewCoins(0, 101), types.NewCoins(0, 100), []string{"rid001"}, 300, 1)
+	acc.ReserveFund(types.NewCoins(0, 101), types.NewCoins(0, 100), []string{"rid001"}, 400, 2)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	sv.SetAccount(acc.Address, acc)
+
+	_, removed := sv.GetReservedFundLastHeight(acc.Address, 1)
+	assert.False(removed)
+
+	sv.IncrementHeight()
+	acc.ReleaseFund(sv.Height(), 1)
+	sv.SetAccount(acc.Address, acc)
+
+	height, removed := sv.GetReservedFundLastHeight(acc.Address, 1)
+	assert.True(removed)
+	assert.Equal(uint64(11), height)
+	_, removed = sv.GetReservedFundLastHeight(acc.Address, 2)
+	assert.False(removed)
+	assert.Equal(1, len(sv.GetAccount(acc.Address).ReservedFunds))
+}
+
+func TestStoreViewReservedFundLastHeightInactive(t *testing.T) {
+	assert := assert.New(t)
+
+	defer SetReservedFundIndexActivation(reservedFundIndexActive)
+	SetReservedFundIndexActivation(func(chainID string, height uint64) bool {
+		return height > 20
+	})
+
+	_, pubKey, err := crypto.TEST_GenerateKeyPairWithSeed("account1")
+	assert.Nil(err)
+
+	acc := &types.Account{
+		Address: pubKey.Address(),
+		Balance: types.NewCoins(0, 20000),
+	}
+	acc.ReserveFund(types.NewCoins(0, 101), types.NewCoins(0, 100), []string{"rid001"}, 300, 1)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	sv.SetAccount(acc.Address, acc)
+
+	acc.ReleaseFund(sv.Height(), 1)
+	sv.SetAccount(acc.Address, acc)
+
+	_, removed := sv.GetReservedFundLastHeight(acc.Address, 1)
+	assert.False(removed)
+}
+
+func TestStoreViewLogs(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+
+	sv.AddLog(&types.Log{Data: []byte("log1")})
+	revision := sv.SnapshotRevision()
+	sv.Set(common.Bytes("key"), common.Bytes("value"))
+	sv.AddLog(&types.Log{Data: []byte("log2")})
+
+	// Reverting to the snapshot drops the logs emitted afterwards
+	sv.RevertToRevision(revision)
+	sv.AddLog(&types.Log{Data: []byte("log3")})
+
+	logs := sv.PopLogs()
+	assert.Equal(2, len(logs))
+	assert.Equal([]byte("log1"), logs[0].Data)
+	assert.Equal([]byte("log3"), logs[1].Data)
+	assert.Equal(0, len(sv.PopLogs()))
+}
+
+func TestStoreViewNestedRevisions(t *testing.T) {
+	assert := assert.New(t)
+
+	db := backend.NewMemDatabase()
+	sv := NewStoreView(uint64(10), common.Hash{}, db)
+	sv.Set(common.Bytes("key"), common.Bytes("value1"))
+
+	// The log does not modify the state, so the nested snapshots have the same root
+	outer := sv.SnapshotRevision()
+	sv.AddLog(&types.Log{Data: []byte("log1")})
+	inner := sv.SnapshotRevision()
+	assert.NotEqual(outer, inner)
+	sv.Set(common.Bytes("key"), common.Bytes("value2"))
+	sv.AddLog(&types.Log{Data: []byte("log2")})
+
+	sv.RevertToRevision(inner)
+	assert.Equal(common.Bytes("value1"), sv.Get(common.Bytes("key")))
+	assert.Equal(1, len(sv.logs))
+
+	// The inner call succeeds, then the outer call reverts
+	innerOK := sv.SnapshotRevision()
+	sv.AddLog(&types.Log{Data: []byte("log3")})
+	sv.RevertToRevision(outer)
+	assert.Equal(common.Bytes("value1"), sv.Get(common.Bytes("key")))
+	assert.Equal(0, len(sv.PopLogs()))
+
+	// The revisions taken after the reverted one are discarded
+	assert.Panics(func() { sv.RevertToRevision(innerOK) })
+}
+
 func TestStoreViewSplitRuleAccess(t *testing.T) {
 	assert := assert.New(t)
 
@@ -0,0 +1,70 @@
+package ledger
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	exec "github.com/thetatoken/theta/ledger/execution"
+	st "github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/ledger/vm"
+)
+
+// TracedTx is the outcome of the traced execution of a smart contract transaction
+type TracedTx struct {
+	Tx              *types.SmartContractTx
+	ReturnValue     common.Bytes
+	ContractAddress common.Address
+	GasUsed         uint64
+	VMError         error
+}
+
+// TraceTx re-executes the smart contract transaction at the given index of the block with the
+// tracer. The transaction runs on the state of the parent block, updated by the preceding
+// transactions of the block. The transactions are replayed on a separate ledger state, so neither
+// the ledger state nor the mempool is modified, and the ledger is not locked.
+func (ledger *Ledger) TraceTx(block *core.Block, txIndex int, tracer vm.Tracer) (*TracedTx, result.Result) {
+	if txIndex < 0 || txIndex >= len(block.Txs) {
+		return nil, result.Error("Transaction index %v is out of range, the block has %v transactions", txIndex, len(block.Txs))
+	}
+	tx, err := types.TxFromBytes(block.Txs[txIndex])
+	if err != nil {
+		return nil, result.Error("Failed to parse transaction: %v", err)
+	}
+	sctx, ok := tx.(*types.SmartContractTx)
+	if !ok {
+		return nil, result.Error("Transaction %v of the block is not a smart contract transaction", txIndex)
+	}
+	if ledger.chain == nil {
+		return nil, result.Error("The chain is not available")
+	}
+	parent, err := ledger.chain.FindBlock(block.Parent)
+	if err != nil {
+		return nil, result.Error("Failed to find the parent block %v: %v", block.Parent.Hex(), err)
+	}
+
+	state := st.NewLedgerState(ledger.state.GetChainID(), ledger.state.DB())
+	if res := state.ResetState(parent.Height, parent.StateHash); res.IsError() {
+		return nil, result.Error("The state at height %v is not available, it might have been pruned", parent.Height)
+	}
+	executor := exec.NewExecutor(state, ledger.consensus, ledger.valMgr)
+	executor.SetSkipSanityCheck(true) // the transactions of the block have been checked already
+	for i, rawTx := range block.Txs[:txIndex] {
+		precedingTx, err := types.TxFromBytes(rawTx)
+		if err != nil {
+			return nil, result.Error("Failed to parse transaction %v of the block: %v", i, err)
+		}
+		if _, res := executor.ExecuteTx(precedingTx); res.IsError() {
+			return nil, result.Error("Failed to replay transaction %v of the block: %v", i, res.Message)
+		}
+	}
+
+	ret, contractAddr, gasUsed, vmErr := vm.Trace(sctx, state.Delivered(), tracer)
+	return &TracedTx{
+		Tx:              sctx,
+		ReturnValue:     ret,
+		ContractAddress: contractAddr,
+		GasUsed:         gasUsed,
+		VMError:         vmErr,
+	}, result.OK
+}
@@ -187,14 +187,22 @@ func (acc *Account) ReleaseFund(currentBlockHeight uint64, reserveSequence uint6
 		if reservedFund.ReserveSequence != reserveSequence {
 			continue
 		}
+		acc.removeReservedFund(idx)
+		return // at most one matching reserveSequence
+	}
+}
 
-		remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
-		if !remainingFund.IsNonnegative() {
-			remainingFund = NewCoins(0, 0) // Should NOT happen, just to be on the safe side
-		}
-		acc.Balance = acc.Balance.Plus(remainingFund).Plus(reservedFund.Collateral)
-		acc.ReservedFunds = append(acc.ReservedFunds[:idx], acc.ReservedFunds[idx+1:]...)
+// removeReservedFund returns the remaining fund and the collateral of the reserved fund to the
+// balance, and removes the entry from the account. The removed entries are not kept in the account
+// state, see StoreView.GetReservedFundLastHeight() for how to query them on archive nodes
+func (acc *Account) removeReservedFund(idx int) {
+	reservedFund := acc.ReservedFunds[idx]
+	remainingFund := reservedFund.InitialFund.Minus(reservedFund.UsedFund)
+	if !remainingFund.IsNonnegative() {
+		remainingFund = NewCoins(0, 0) // Should NOT happen, just to be on the safe side
 	}
+	acc.Balance = acc.Balance.Plus(remainingFund).Plus(reservedFund.Collateral)
+	acc.ReservedFunds = append(acc.ReservedFunds[:idx], acc.ReservedFunds[idx+1:]...)
 }
 
 // CheckTransferReservedFund verifies inputs for SplitReservedFund
@@ -219,9 +227,10 @@ func (acc *Account) CheckTransferReservedFund(tgtAcc *Account, transferAmount Co
 	return errors.Errorf("No matching ReservedFund with reserveSequence %d", reserveSequence)
 }
 
-// TransferReservedFund transfers the specified amount of reserved fund to the accounts participated in the payment split, and send remainder back to the source account (i.e. the acount itself)
+// TransferReservedFund transfers the specified amount of reserved fund to the accounts participated in the payment split, and send remainder back to the source account (i.e. the acount itself).
+// With removeSettled set, a fully settled fund is removed right away instead of when it expires.
 func (acc *Account) TransferReservedFund(splittedCoinsMap map[*Account]Coins, currentBlockHeight uint64,
-	reserveSequence uint64, servicePaymentTx *ServicePaymentTx) (shouldSlash bool, slashIntent SlashIntent) {
+	reserveSequence uint64, servicePaymentTx *ServicePaymentTx, removeSettled bool) (shouldSlash bool, slashIntent SlashIntent) {
 	for idx := range acc.ReservedFunds {
 		reservedFund := &acc.ReservedFunds[idx]
 		if reservedFund.ReserveSequence != reserveSequence {
@@ -251,6 +260,12 @@ func (acc *Account) TransferReservedFund(splittedCoinsMap map[*Account]Coins, cu
 
 		reservedFund.RecordTransfer(servicePaymentTx)
 
+		// A fully settled fund has nothing left to pay, release the collateral right away
+		// instead of keeping the entry until it expires
+		if removeSettled && reservedFund.IsFullySettled() {
+			acc.removeReservedFund(idx)
+		}
+
 		return false, SlashIntent{} // at most one matching reserveSequence
 	}
 
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// ** Account Operator: A secondary key authorized to sign service payments on behalf of an account **
+//
+
+// AccountOperator allows the operator key to sign the ServicePaymentTxs from the account,
+// up to the spend limit, so that the main key of the account can be kept cold
+type AccountOperator struct {
+	AccountAddress  common.Address `json:"account_address"`  // Address of the account
+	OperatorAddress common.Address `json:"operator_address"` // Address of the operator key
+	SpendLimit      Coins          `json:"spend_limit"`      // Max total amount the operator can spend
+	Spent           Coins          `json:"spent"`            // Total amount the operator has spent
+}
+
+// CanSpend checks whether the operator can spend the given amount within the spend limit
+func (ao *AccountOperator) CanSpend(amount Coins) bool {
+	return ao.SpendLimit.IsGTE(ao.Spent.NoNil().Plus(amount))
+}
+
+func (ao *AccountOperator) String() string {
+	if ao == nil {
+		return "nil-AccountOperator"
+	}
+	return fmt.Sprintf("AccountOperator{%v, operator: %v, spendLimit: %v, spent: %v}",
+		ao.AccountAddress, ao.OperatorAddress, ao.SpendLimit, ao.Spent)
+}
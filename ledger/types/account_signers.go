@@ -0,0 +1,76 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// ** Account Signers: The M-of-N signer set controlling a multisig account **
+//
+
+// MaxAccountSigners is the max number of signers of a multisig account
+const MaxAccountSigners = 16
+
+// AccountSigners is the signer set registered on an account with an UpdateAccountSignersTx. The
+// transactions from the account then need the signatures of at least the threshold number of the
+// signers, see MultiSigInput, and the key of the account can no longer sign for it alone.
+type AccountSigners struct {
+	AccountAddress common.Address   `json:"account_address"` // Address of the account
+	Signers        []common.Address `json:"signers"`         // Addresses of the signer keys
+	Threshold      uint64           `json:"threshold"`       // Min number of signatures required
+}
+
+// NewAccountSigners returns the signer set of an account without a registered signer set, i.e.
+// the key of the account itself.
+func NewAccountSigners(address common.Address) *AccountSigners {
+	return &AccountSigners{
+		AccountAddress: address,
+		Signers:        []common.Address{address},
+		Threshold:      1,
+	}
+}
+
+// Validate checks that the signer set is not empty, has no duplicates, and that the threshold
+// can be reached.
+func (as *AccountSigners) Validate() error {
+	if len(as.Signers) == 0 {
+		return errors.New("The signer set is empty")
+	}
+	if len(as.Signers) > MaxAccountSigners {
+		return fmt.Errorf("Too many signers: %v, at most %v signers are allowed", len(as.Signers), MaxAccountSigners)
+	}
+	seen := make(map[common.Address]bool)
+	for _, signer := range as.Signers {
+		if signer == (common.Address{}) {
+			return errors.New("The signer address cannot be empty")
+		}
+		if seen[signer] {
+			return fmt.Errorf("Duplicated signer: %v", signer.Hex())
+		}
+		seen[signer] = true
+	}
+	if as.Threshold == 0 || as.Threshold > uint64(len(as.Signers)) {
+		return fmt.Errorf("Invalid threshold: %v for %v signers", as.Threshold, len(as.Signers))
+	}
+	return nil
+}
+
+// IsSigner checks whether the address is one of the signers
+func (as *AccountSigners) IsSigner(address common.Address) bool {
+	for _, signer := range as.Signers {
+		if signer == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (as *AccountSigners) String() string {
+	if as == nil {
+		return "nil-AccountSigners"
+	}
+	return fmt.Sprintf("AccountSigners{%v, signers: %v, threshold: %v}",
+		as.AccountAddress, as.Signers, as.Threshold)
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestAccountSignersValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	alice, bob := MakeAcc("alice").Address, MakeAcc("bob").Address
+	assert.Nil((&AccountSigners{Signers: []common.Address{alice, bob}, Threshold: 2}).Validate())
+	assert.Nil(NewAccountSigners(alice).Validate())
+
+	assert.NotNil((&AccountSigners{Threshold: 1}).Validate())
+	assert.NotNil((&AccountSigners{Signers: []common.Address{alice, bob}, Threshold: 0}).Validate())
+	assert.NotNil((&AccountSigners{Signers: []common.Address{alice, bob}, Threshold: 3}).Validate())
+	assert.NotNil((&AccountSigners{Signers: []common.Address{alice, alice}, Threshold: 1}).Validate())
+	assert.NotNil((&AccountSigners{Signers: []common.Address{alice, {}}, Threshold: 1}).Validate())
+
+	signers := make([]common.Address, MaxAccountSigners+1)
+	for i := range signers {
+		signers[i] = common.BytesToAddress([]byte{byte(i + 1)})
+	}
+	assert.NotNil((&AccountSigners{Signers: signers, Threshold: 1}).Validate())
+}
+
+func TestMultiSigSendTxSignatures(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice, bob := MakeAcc("alice"), MakeAcc("bob")
+	tx := &MultiSigSendTx{
+		Fee: NewCoins(0, 1000000000000),
+		Input: MultiSigInput{
+			Address:  MakeAcc("treasury").Address,
+			Coins:    NewCoins(10, 1000000000000),
+			Sequence: 1,
+		},
+		Outputs: []TxOutput{{Address: MakeAcc("recipient").Address, Coins: NewCoins(10, 0)}},
+	}
+
+	// The sign bytes do not cover the signatures, so that the signers can sign independently
+	signBytes := tx.SignBytes("test_chain_id")
+	assert.True(tx.SetSignature(alice.Address, alice.Sign(signBytes)))
+	assert.Equal(signBytes, tx.SignBytes("test_chain_id"))
+	assert.True(tx.SetSignature(bob.Address, bob.Sign(signBytes)))
+	assert.True(tx.SetSignature(alice.Address, alice.Sign(signBytes)))
+	assert.Equal(2, len(tx.Input.Signatures))
+
+	raw, err := TxToBytes(tx)
+	require.Nil(err)
+	decoded, err := TxFromBytes(raw)
+	require.Nil(err)
+	multiSigTx, ok := decoded.(MultiSigTx)
+	require.True(ok)
+	assert.Equal(signBytes, multiSigTx.SignBytes("test_chain_id"))
+	for _, sig := range multiSigTx.GetMultiSigInput().Signatures {
+		assert.True(sig.Signature.Verify(signBytes, sig.Signer))
+	}
+}
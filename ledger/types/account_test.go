@@ -151,7 +151,7 @@ func TestTransferReservedFund1(t *testing.T) {
 	currentBlockHeight := uint64(900)
 	err := srcAcc.CheckTransferReservedFund(&tgtAcc, totalTransferAmount, paymentSequence, currentBlockHeight, reserveSequence)
 	if err != nil {
-		srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx)
+		srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, true)
 	}
 	assert.NotEqual(t, nil, err) // should error out since the currentBlockHeight > endBlockHeight
 }
@@ -172,7 +172,7 @@ func TestTransferReservedFund2(t *testing.T) {
 	currentBlockHeight := uint64(100)
 	err := srcAcc.CheckTransferReservedFund(&tgtAcc, totalTransferAmount, paymentSequence, currentBlockHeight, reserveSequence2)
 	if err != nil {
-		srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx)
+		srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, true)
 	}
 	assert.NotEqual(t, nil, err) // should error out since no matching reserve sequence is found
 }
@@ -194,7 +194,7 @@ func TestTransferReservedFund3(t *testing.T) {
 	err := srcAcc.CheckTransferReservedFund(&tgtAcc, totalTransferAmount, paymentSequence, currentBlockHeight, reserveSequence)
 	shouldSlash := false
 	if err == nil {
-		shouldSlash, _ = srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx)
+		shouldSlash, _ = srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, true)
 	}
 	assert.Equal(t, nil, err)   // should be able to pass the check
 	assert.True(t, shouldSlash) // overspend, should slash
@@ -217,7 +217,7 @@ func TestTransferReservedFund4(t *testing.T) {
 	err := srcAcc.CheckTransferReservedFund(&tgtAcc, totalTransferAmount, paymentSequence, currentBlockHeight, reserveSequence)
 	shouldSlash := false
 	if err == nil {
-		shouldSlash, _ = srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx)
+		shouldSlash, _ = srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, true)
 	}
 
 	assert.Equal(t, nil, err)
@@ -230,6 +230,44 @@ func TestTransferReservedFund4(t *testing.T) {
 	assert.Equal(t, totalTransferAmount, srcAcc.ReservedFunds[0].UsedFund)
 }
 
+// Test 5: the fully settled fund is removed and the collateral is returned
+func TestTransferReservedFund5(t *testing.T) {
+	srcAcc, tgtAcc, splitAcc1, _, servicePaymentTx, reserveSequence := prepareForTransferReservedFund()
+	balanceBeforeSettlement := srcAcc.Balance
+
+	coinsMap := make(map[*Account]Coins)
+	coinsMap[&splitAcc1] = NewCoins(0, 600)
+	coinsMap[&tgtAcc] = NewCoins(0, 400)
+	paymentSequence := uint64(1)
+	currentBlockHeight := uint64(100)
+	err := srcAcc.CheckTransferReservedFund(&tgtAcc, NewCoins(0, 1000), paymentSequence, currentBlockHeight, reserveSequence)
+	assert.Equal(t, nil, err)
+
+	shouldSlash, _ := srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, true)
+	assert.False(t, shouldSlash)
+	assert.Equal(t, 0, len(srcAcc.ReservedFunds))
+	assert.Equal(t, balanceBeforeSettlement.Plus(NewCoins(0, 1001)), srcAcc.Balance)
+	assert.Equal(t, NewCoins(0, 600), splitAcc1.Balance)
+	assert.Equal(t, NewCoins(0, 400), tgtAcc.Balance)
+}
+
+// Test 6: the fully settled fund is kept until it expires before the removal applies
+func TestTransferReservedFund6(t *testing.T) {
+	srcAcc, tgtAcc, splitAcc1, _, servicePaymentTx, reserveSequence := prepareForTransferReservedFund()
+	balanceBeforeSettlement := srcAcc.Balance
+
+	coinsMap := make(map[*Account]Coins)
+	coinsMap[&splitAcc1] = NewCoins(0, 600)
+	coinsMap[&tgtAcc] = NewCoins(0, 400)
+	currentBlockHeight := uint64(100)
+
+	shouldSlash, _ := srcAcc.TransferReservedFund(coinsMap, currentBlockHeight, reserveSequence, &servicePaymentTx, false)
+	assert.False(t, shouldSlash)
+	assert.Equal(t, 1, len(srcAcc.ReservedFunds))
+	assert.Equal(t, NewCoins(0, 1000), srcAcc.ReservedFunds[0].UsedFund)
+	assert.Equal(t, balanceBeforeSettlement, srcAcc.Balance)
+}
+
 // For the initial Mainnet release, TFuel should not inflate
 // func TestUpdateAccountTFuelReward(t *testing.T) {
 // 	assert := assert.New(t)
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/dmath"
 )
 
 var (
@@ -92,19 +93,9 @@ func (coins Coins) NoNil() Coins {
 func (coins Coins) CalculatePercentage(percentage uint) Coins {
 	c := coins.NoNil()
 
-	p := big.NewInt(int64(percentage))
-
-	theta := new(big.Int)
-	theta.Mul(c.ThetaWei, p)
-	theta.Div(theta, Hundred)
-
-	tfuel := new(big.Int)
-	tfuel.Mul(c.TFuelWei, p)
-	tfuel.Div(tfuel, Hundred)
-
 	return Coins{
-		ThetaWei: theta,
-		TFuelWei: tfuel,
+		ThetaWei: dmath.Percentage(c.ThetaWei, percentage),
+		TFuelWei: dmath.Percentage(c.TFuelWei, percentage),
 	}
 }
 
@@ -179,16 +170,15 @@ func ParseCoinAmount(in string) (*big.Int, bool) {
 		in = in[:len(in)-3]
 	}
 
-	f, ok := new(big.Float).SetPrec(1024).SetString(in)
-	if !ok || f.Sign() < 0 {
+	d, err := dmath.ParseDec(in)
+	if err != nil || d.IsNegative() {
 		return nil, false
 	}
 
-	if !inWei {
-		f = f.Mul(f, new(big.Float).SetPrec(1024).SetUint64(1e18))
+	if inWei {
+		return d.TruncateInt(), true
 	}
 
-	ret, _ := f.Int(nil)
-
-	return ret, true
+	// 1 Theta/TFuel = 10^18 Wei, which coincides with the precision of dmath.Dec
+	return d.BigInt(), true
 }
@@ -15,6 +15,19 @@ const (
 
 	// MaxAccountsAffectedPerTx specifies the max number of accounts one transaction is allowed to modify to avoid spamming
 	MaxAccountsAffectedPerTx = 512
+
+	// MaxSendTxMemoLength specifies the max length in bytes of the memo of a send transaction
+	MaxSendTxMemoLength = 128
+
+	// MinimumMultiSendFeePerOutputTFuelWei specifies the minimum fee per output of a multi-send transaction,
+	// on top of the minimum fee of a regular transaction
+	MinimumMultiSendFeePerOutputTFuelWei uint64 = 1e11
+
+	// MaxMultiSendOutputs specifies the max number of outputs of a multi-send transaction
+	MaxMultiSendOutputs = 4096
+
+	// MaxMultiSendMemoLength specifies the max length in bytes of the memo of a multi-send output
+	MaxMultiSendMemoLength = 128
 )
 
 const (
@@ -67,4 +80,8 @@ const (
 
 	// ReservedFundFreezePeriodDuration indicates the freeze duration (in terms of number of blocks) of the reserved fund
 	ReservedFundFreezePeriodDuration uint64 = 5
+
+	// MaximumActiveReservedFundsPerAccount indicates the maximum number of reserved funds an account can hold
+	// simultaneously. It bounds the account state size and the cost of scanning the reserved funds
+	MaximumActiveReservedFundsPerAccount int = 16
 )
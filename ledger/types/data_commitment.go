@@ -0,0 +1,146 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+//
+// ** Data Commitment: The Merkle root of off-chain data, anchored to the chain by its owner **
+//
+
+// MaxDataCommitmentURILength is the maximum length of the URI a data commitment points to
+const MaxDataCommitmentURILength = 256
+
+// Domain separators of the data commitment Merkle tree, so that a leaf can't be passed off as an
+// inner node
+const (
+	dataCommitmentLeafPrefix  byte = 0x00
+	dataCommitmentInnerPrefix byte = 0x01
+)
+
+// DataCommitment anchors the Merkle root of off-chain data, e.g. stream manifests or licensing
+// documents, to the chain, so that its owner can later prove that each piece of the data existed,
+// unchanged, at the height of the commitment.
+type DataCommitment struct {
+	Root      common.Hash    `json:"root"`       // Merkle root of the data, see DataCommitmentRoot
+	Owner     common.Address `json:"owner"`      // The account which signed the commitment
+	NumLeaves uint64         `json:"num_leaves"` // Number of the pieces of data committed
+	URI       string         `json:"uri"`        // Optional location of the data
+	Height    uint64         `json:"height"`     // Height of the block the commitment is included in
+}
+
+func (dc *DataCommitment) String() string {
+	if dc == nil {
+		return "nil-DataCommitment"
+	}
+	return fmt.Sprintf("DataCommitment{root: %v, owner: %v, num_leaves: %v, uri: %v, height: %v}",
+		dc.Root.Hex(), dc.Owner.Hex(), dc.NumLeaves, dc.URI, dc.Height)
+}
+
+// DataCommitmentProof is the Merkle path from a leaf of the data to the root of a data commitment.
+type DataCommitmentProof struct {
+	Index     common.JSONUint64 `json:"index"`      // Index of the leaf
+	NumLeaves common.JSONUint64 `json:"num_leaves"` // Number of the leaves of the tree
+	Siblings  []common.Hash     `json:"siblings"`   // The sibling of the path at each level, bottom up
+}
+
+// DataCommitmentLeafHash returns the hash of the leaf in the data commitment Merkle tree.
+func DataCommitmentLeafHash(leaf []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{dataCommitmentLeafPrefix}, leaf)
+}
+
+func dataCommitmentInnerHash(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte{dataCommitmentInnerPrefix}, left[:], right[:])
+}
+
+// DataCommitmentRoot returns the root of the binary Merkle tree over the leaves. The last node of a
+// level with an odd number of nodes is promoted to the next level unchanged.
+func DataCommitmentRoot(leaves [][]byte) common.Hash {
+	if len(leaves) == 0 {
+		return common.Hash{}
+	}
+	level := make([]common.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = DataCommitmentLeafHash(leaf)
+	}
+	for len(level) > 1 {
+		level = nextDataCommitmentLevel(level)
+	}
+	return level[0]
+}
+
+func nextDataCommitmentLevel(level []common.Hash) []common.Hash {
+	next := make([]common.Hash, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 == len(level) {
+			next = append(next, level[i])
+			continue
+		}
+		next = append(next, dataCommitmentInnerHash(level[i], level[i+1]))
+	}
+	return next
+}
+
+// ProveDataCommitment returns the Merkle proof of the leaf at the given index against the root of
+// the leaves, see DataCommitmentRoot.
+func ProveDataCommitment(leaves [][]byte, index int) (*DataCommitmentProof, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("Leaf index %v out of range [0, %v)", index, len(leaves))
+	}
+	proof := &DataCommitmentProof{
+		Index:     common.JSONUint64(index),
+		NumLeaves: common.JSONUint64(len(leaves)),
+		Siblings:  []common.Hash{},
+	}
+	level := make([]common.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = DataCommitmentLeafHash(leaf)
+	}
+	for len(level) > 1 {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof.Siblings = append(proof.Siblings, level[sibling])
+		}
+		level = nextDataCommitmentLevel(level)
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyDataCommitmentProof checks that the leaf is committed by the root, at the index of the proof.
+func VerifyDataCommitmentProof(root common.Hash, leaf []byte, proof *DataCommitmentProof) error {
+	if proof == nil {
+		return errors.New("Proof must be specified")
+	}
+	index, size := uint64(proof.Index), uint64(proof.NumLeaves)
+	if index >= size {
+		return fmt.Errorf("Leaf index %v out of range [0, %v)", index, size)
+	}
+	hash := DataCommitmentLeafHash(leaf)
+	siblings := proof.Siblings
+	for ; size > 1; size = (size + 1) / 2 {
+		sibling := index ^ 1
+		if sibling < size {
+			if len(siblings) == 0 {
+				return errors.New("Proof is too short")
+			}
+			if index%2 == 0 {
+				hash = dataCommitmentInnerHash(hash, siblings[0])
+			} else {
+				hash = dataCommitmentInnerHash(siblings[0], hash)
+			}
+			siblings = siblings[1:]
+		}
+		index /= 2
+	}
+	if len(siblings) != 0 {
+		return errors.New("Proof is too long")
+	}
+	if hash != root {
+		return fmt.Errorf("Leaf is not committed by root %v", root.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func createDataCommitmentLeaves(n int) [][]byte {
+	leaves := [][]byte{}
+	for i := 0; i < n; i++ {
+		leaves = append(leaves, []byte(fmt.Sprintf("manifest %v", i)))
+	}
+	return leaves
+}
+
+func TestDataCommitmentProof(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	assert.Equal(common.Hash{}, DataCommitmentRoot(nil))
+
+	for n := 1; n <= 9; n++ {
+		leaves := createDataCommitmentLeaves(n)
+		root := DataCommitmentRoot(leaves)
+		for i := range leaves {
+			proof, err := ProveDataCommitment(leaves, i)
+			require.Nil(err)
+			assert.Nil(VerifyDataCommitmentProof(root, leaves[i], proof), "leaf %v of %v", i, n)
+
+			// The proof only holds for the leaf, at its index
+			assert.NotNil(VerifyDataCommitmentProof(root, []byte("forged"), proof))
+			if n > 1 {
+				proof.Index = common.JSONUint64((i + 1) % n)
+				assert.NotNil(VerifyDataCommitmentProof(root, leaves[i], proof))
+				proof.Index = common.JSONUint64(i)
+			}
+
+			// The siblings can't be dropped or padded
+			if len(proof.Siblings) > 0 {
+				siblings := proof.Siblings
+				proof.Siblings = siblings[:len(siblings)-1]
+				assert.NotNil(VerifyDataCommitmentProof(root, leaves[i], proof))
+				proof.Siblings = siblings
+			}
+			proof.Siblings = append(proof.Siblings, common.Hash{})
+			assert.NotNil(VerifyDataCommitmentProof(root, leaves[i], proof))
+		}
+		_, err := ProveDataCommitment(leaves, n)
+		assert.NotNil(err)
+	}
+
+	// A single leaf is its own root
+	leaves := createDataCommitmentLeaves(1)
+	assert.Equal(DataCommitmentLeafHash(leaves[0]), DataCommitmentRoot(leaves))
+
+	// An inner node can't be passed off as a leaf
+	leaves = createDataCommitmentLeaves(2)
+	root := DataCommitmentRoot(leaves)
+	h0, h1 := DataCommitmentLeafHash(leaves[0]), DataCommitmentLeafHash(leaves[1])
+	inner := append(append([]byte{}, h0[:]...), h1[:]...)
+	assert.NotNil(VerifyDataCommitmentProof(root, inner, &DataCommitmentProof{Index: 0, NumLeaves: 1}))
+}
+
+func TestDataCommitmentTxSerialization(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	tx := &DataCommitmentTx{
+		Fee:       NewCoins(0, 1000000000000),
+		Owner:     TxInput{Address: common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab"), Sequence: 3},
+		Root:      DataCommitmentRoot(createDataCommitmentLeaves(3)),
+		NumLeaves: 3,
+		URI:       "https://example.com/manifests",
+	}
+	raw, err := TxToBytes(tx)
+	require.Nil(err)
+	decoded, err := TxFromBytes(raw)
+	require.Nil(err)
+	decodedTx, ok := decoded.(*DataCommitmentTx)
+	require.True(ok)
+	assert.Equal(tx.Owner.Address, decodedTx.Owner.Address)
+	assert.Equal(tx.Root, decodedTx.Root)
+	assert.Equal(tx.NumLeaves, decodedTx.NumLeaves)
+	assert.Equal(tx.URI, decodedTx.URI)
+	assert.Equal(tx.SignBytes("testchain"), decodedTx.SignBytes("testchain"))
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"fmt"
+)
+
+// ServicePaymentDisputeWindow is the number of blocks an on-chain service payment settlement
+// stays pending before the payment is transferred. During the window the source can dispute
+// the settlement with a countersigned payment of a higher payment sequence.
+const ServicePaymentDisputeWindow uint64 = 100
+
+// ** Pending Settlement: A service payment settlement in the dispute window **
+//
+
+// PendingSettlement is the settlement between a source and a target for a reserved fund,
+// which is transferred once the dispute window is over
+type PendingSettlement struct {
+	ServicePayment ServicePaymentTx `json:"service_payment"` // The payment with the highest payment sequence submitted so far
+	SettleHeight   uint64           `json:"settle_height"`   // Height at which the payment is transferred
+}
+
+func (ps *PendingSettlement) String() string {
+	if ps == nil {
+		return "nil-PendingSettlement"
+	}
+	return fmt.Sprintf("PendingSettlement{source: %v, target: %v, reserve_sequence: %v, payment_sequence: %v, settle_height: %v}",
+		ps.ServicePayment.Source.Address, ps.ServicePayment.Target.Address, ps.ServicePayment.ReserveSequence,
+		ps.ServicePayment.PaymentSequence, ps.SettleHeight)
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// ProofNodes are the encoded trie nodes on the path from the root of a trie to a key, as collected
+// by the Prove method of the trie. They are looked up by their hash when verifying the proof.
+type ProofNodes []common.Bytes
+
+// Put implements the database.Putter interface, to collect the nodes of a proof.
+func (p *ProofNodes) Put(key []byte, value []byte) error {
+	*p = append(*p, common.CopyBytes(value))
+	return nil
+}
+
+// Get implements the trie.DatabaseReader interface, to verify a proof.
+func (p ProofNodes) Get(key []byte) ([]byte, error) {
+	for _, node := range p {
+		if bytes.Equal(crypto.Keccak256(node), key) {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("Proof node %v does not exist", common.Bytes2Hex(key))
+}
+
+// Has implements the trie.DatabaseReader interface.
+func (p ProofNodes) Has(key []byte) (bool, error) {
+	_, err := p.Get(key)
+	return err == nil, nil
+}
+
+// AccountProof is the Merkle proof of an account, or of its absence, against the state root of a
+// block. If the accounts are sharded, the account is proven against the root of its shard, which is
+// itself proven against the state root. The shard count is always proven, so that the verifier can
+// derive the shard of the account, see state.VerifyAccountProof.
+type AccountProof struct {
+	Address         common.Address    `json:"address"`
+	NumShards       common.JSONUint64 `json:"num_shards"`        // 0 if the accounts are not sharded
+	ShardCountProof ProofNodes        `json:"shard_count_proof"` // proof of the shard count in the state trie
+	ShardRootProof  ProofNodes        `json:"shard_root_proof"`  // proof of the shard root in the state trie, empty if not sharded
+	AccountProof    ProofNodes        `json:"account_proof"`     // proof of the account in the shard trie, or the state trie if not sharded
+}
+
+// ProofNodeSet collects the nodes of many proofs, each node once, and looks them up by hash. It is
+// used to construct and verify the batch proofs, which share the nodes of their paths.
+type ProofNodeSet struct {
+	nodes map[common.Hash]common.Bytes
+	order []common.Hash
+}
+
+// NewProofNodeSet creates an empty ProofNodeSet
+func NewProofNodeSet() *ProofNodeSet {
+	return &ProofNodeSet{
+		nodes: make(map[common.Hash]common.Bytes),
+	}
+}
+
+// ToSet indexes the proof nodes by hash.
+func (p ProofNodes) ToSet() *ProofNodeSet {
+	set := NewProofNodeSet()
+	for _, node := range p {
+		set.Put(crypto.Keccak256(node), node)
+	}
+	return set
+}
+
+// Put implements the database.Putter interface, to collect the nodes of proofs. The key is the
+// hash of the node.
+func (s *ProofNodeSet) Put(key []byte, value []byte) error {
+	hash := common.BytesToHash(key)
+	if _, ok := s.nodes[hash]; ok {
+		return nil
+	}
+	s.nodes[hash] = common.CopyBytes(value)
+	s.order = append(s.order, hash)
+	return nil
+}
+
+// Get implements the trie.DatabaseReader interface, to verify proofs.
+func (s *ProofNodeSet) Get(key []byte) ([]byte, error) {
+	if node, ok := s.nodes[common.BytesToHash(key)]; ok {
+		return node, nil
+	}
+	return nil, fmt.Errorf("Proof node %v does not exist", common.Bytes2Hex(key))
+}
+
+// Has implements the trie.DatabaseReader interface.
+func (s *ProofNodeSet) Has(key []byte) (bool, error) {
+	_, ok := s.nodes[common.BytesToHash(key)]
+	return ok, nil
+}
+
+// Nodes returns the nodes in the order they are collected.
+func (s *ProofNodeSet) Nodes() ProofNodes {
+	nodes := make(ProofNodes, len(s.order))
+	for i, hash := range s.order {
+		nodes[i] = s.nodes[hash]
+	}
+	return nodes
+}
+
+// BatchAccountProof is the Merkle proof of many accounts, or of their absence, against the state
+// root of a block, in a single bundle. The nodes shared by the paths to the accounts, e.g. the
+// root and the upper levels of the tries, are included once, so that the bundle is much smaller
+// than the separate proofs of the accounts. See state.VerifyBatchAccountProof.
+type BatchAccountProof struct {
+	Addresses []common.Address  `json:"addresses"`
+	NumShards common.JSONUint64 `json:"num_shards"` // 0 if the accounts are not sharded
+	Nodes     ProofNodes        `json:"nodes"`      // nodes of the paths to the shard count, the shard roots and the accounts
+}
@@ -95,6 +95,11 @@ func (reservedFund *ReservedFund) RecordTransfer(serverPaymentTx *ServicePayment
 	reservedFund.TransferRecords = append(reservedFund.TransferRecords, transferRecord)
 }
 
+// IsFullySettled returns whether the whole initial fund has been transferred
+func (reservedFund *ReservedFund) IsFullySettled() bool {
+	return reservedFund.UsedFund.IsGTE(reservedFund.InitialFund)
+}
+
 func (reservedFund *ReservedFund) HasResourceID(resourceID string) bool {
 	for _, rid := range reservedFund.ResourceIDs {
 		if strings.Compare(rid, resourceID) == 0 {
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/thetatoken/theta/crypto"
+)
+
+//
+// ------------------------- Resource IDs -------------------------
+//
+// A content-addressed resource ID is the multihash of the content descriptor of the resource,
+// e.g. the manifest of a video stream, hex encoded after the "rid:" prefix. The multihash is the
+// varint code of the hash function, the varint length of the digest, and then the digest, so that
+// the resource IDs remain interoperable if other hash functions are adopted later.
+//
+
+// ResourceIDPrefix prefixes the content-addressed resource IDs
+const ResourceIDPrefix = "rid:"
+
+// The multihash codes of the hash functions supported in the resource IDs
+const (
+	MultihashSHA2_256  uint64 = 0x12
+	MultihashKeccak256 uint64 = 0x1b
+)
+
+// multihashDigestSizes are the digest sizes of the supported hash functions
+var multihashDigestSizes = map[uint64]int{
+	MultihashSHA2_256:  32,
+	MultihashKeccak256: 32,
+}
+
+// ResourceIDHash is the multihash a resource ID carries.
+type ResourceIDHash struct {
+	Code   uint64 // multihash code of the hash function
+	Digest []byte
+}
+
+// NewResourceID returns the resource ID of the resource with the given content descriptor, using
+// the Keccak-256 hash.
+func NewResourceID(descriptor []byte) string {
+	return FormatResourceID(MultihashKeccak256, crypto.Keccak256(descriptor))
+}
+
+// FormatResourceID returns the resource ID carrying the digest of the hash function with the given
+// multihash code.
+func FormatResourceID(code uint64, digest []byte) string {
+	buf := make([]byte, 2*binary.MaxVarintLen64+len(digest))
+	n := binary.PutUvarint(buf, code)
+	n += binary.PutUvarint(buf[n:], uint64(len(digest)))
+	n += copy(buf[n:], digest)
+	return ResourceIDPrefix + hex.EncodeToString(buf[:n])
+}
+
+// ParseResourceID parses the content-addressed resource ID, and returns the multihash it carries.
+func ParseResourceID(resourceID string) (*ResourceIDHash, error) {
+	if !strings.HasPrefix(resourceID, ResourceIDPrefix) {
+		return nil, fmt.Errorf("Resource ID %v does not start with %v", resourceID, ResourceIDPrefix)
+	}
+	encoded := resourceID[len(ResourceIDPrefix):]
+	if strings.ToLower(encoded) != encoded {
+		return nil, fmt.Errorf("Resource ID %v is not lowercase hex encoded", resourceID)
+	}
+	raw, err := hex.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("Resource ID %v is not hex encoded: %v", resourceID, err)
+	}
+
+	code, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, fmt.Errorf("Resource ID %v has an invalid hash function code", resourceID)
+	}
+	raw = raw[n:]
+	size, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, fmt.Errorf("Resource ID %v has an invalid digest size", resourceID)
+	}
+	raw = raw[n:]
+	expectedSize, ok := multihashDigestSizes[code]
+	if !ok {
+		return nil, fmt.Errorf("Resource ID %v uses the unsupported hash function 0x%x", resourceID, code)
+	}
+	if size != uint64(expectedSize) || len(raw) != expectedSize {
+		return nil, fmt.Errorf("Resource ID %v has a digest of %v bytes, %v bytes expected", resourceID, len(raw), expectedSize)
+	}
+	return &ResourceIDHash{Code: code, Digest: raw}, nil
+}
+
+// ValidateResourceID checks the resource ID is a well-formed content-addressed resource ID.
+func ValidateResourceID(resourceID string) error {
+	_, err := ParseResourceID(resourceID)
+	return err
+}
+
+// VerifyResourceID checks the resource ID is the one of the resource with the given content descriptor.
+func VerifyResourceID(resourceID string, descriptor []byte) error {
+	hash, err := ParseResourceID(resourceID)
+	if err != nil {
+		return err
+	}
+	var digest []byte
+	switch hash.Code {
+	case MultihashKeccak256:
+		digest = crypto.Keccak256(descriptor)
+	case MultihashSHA2_256:
+		sum := sha256.Sum256(descriptor)
+		digest = sum[:]
+	}
+	if FormatResourceID(hash.Code, digest) != resourceID {
+		return fmt.Errorf("Resource ID %v does not match the content descriptor", resourceID)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/crypto"
+)
+
+func TestResourceID(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	descriptor := []byte("video manifest")
+	resourceID := NewResourceID(descriptor)
+	assert.True(strings.HasPrefix(resourceID, ResourceIDPrefix))
+	// 0x1b keccak-256, 0x20 bytes of digest
+	assert.True(strings.HasPrefix(resourceID, ResourceIDPrefix+"1b20"))
+
+	hash, err := ParseResourceID(resourceID)
+	require.Nil(err)
+	assert.Equal(MultihashKeccak256, hash.Code)
+	assert.Equal(crypto.Keccak256(descriptor), hash.Digest)
+	assert.Nil(ValidateResourceID(resourceID))
+	assert.Nil(VerifyResourceID(resourceID, descriptor))
+	assert.NotNil(VerifyResourceID(resourceID, []byte("other manifest")))
+
+	sum := sha256.Sum256(descriptor)
+	sha256ID := FormatResourceID(MultihashSHA2_256, sum[:])
+	assert.Nil(ValidateResourceID(sha256ID))
+	assert.Nil(VerifyResourceID(sha256ID, descriptor))
+	assert.NotEqual(resourceID, sha256ID)
+}
+
+func TestInvalidResourceID(t *testing.T) {
+	assert := assert.New(t)
+
+	resourceID := NewResourceID([]byte("video manifest"))
+	encoded := resourceID[len(ResourceIDPrefix):]
+
+	invalidIDs := []string{
+		"",
+		"rid_vid001",
+		encoded,          // no prefix
+		ResourceIDPrefix, // no multihash
+		ResourceIDPrefix + strings.ToUpper(encoded),   // not canonical
+		ResourceIDPrefix + "zz" + encoded[2:],         // not hex
+		ResourceIDPrefix + encoded[:len(encoded)-2],   // truncated digest
+		resourceID + "00",                             // trailing bytes
+		FormatResourceID(0x13, make([]byte, 64)),      // unsupported hash function
+		FormatResourceID(MultihashSHA2_256, []byte{}), // empty digest
+	}
+	for _, resourceID := range invalidIDs {
+		assert.NotNil(ValidateResourceID(resourceID), resourceID)
+	}
+}
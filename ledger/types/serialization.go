@@ -33,6 +33,14 @@ const (
 	TxSmartContract
 	TxDepositStake
 	TxWithdrawStake
+	TxSetAccountOperator
+	TxServicePaymentDispute
+	TxRegisterNodeAddress
+	TxMultiSend
+	TxSlashReview
+	TxDataCommitment
+	TxUpdateAccountSigners
+	TxMultiSigSend
 )
 
 func TxFromBytes(raw []byte) (Tx, error) {
@@ -42,6 +50,31 @@ func TxFromBytes(raw []byte) (Tx, error) {
 	if err != nil {
 		return nil, err
 	}
+	tx, err := txBodyFromBytes(txType, buff)
+	if err != nil {
+		return nil, err
+	}
+	if err := decodeTxExpiry(buff, tx); err != nil {
+		return nil, err
+	}
+	if err := decodeTxFeePayer(buff, tx); err != nil {
+		return nil, err
+	}
+	if buff.Len() != 0 {
+		return nil, fmt.Errorf("Unexpected data after the tx body")
+	}
+	return tx, nil
+}
+
+// PeekTxType returns the type of the raw transaction without decoding its body
+func PeekTxType(raw []byte) (TxType, error) {
+	var txType TxType
+	err := rlp.Decode(bytes.NewBuffer(raw), &txType)
+	return txType, err
+}
+
+func txBodyFromBytes(txType TxType, buff *bytes.Buffer) (Tx, error) {
+	var err error
 	if txType == TxCoinbase {
 		data := &CoinbaseTx{}
 		err = rlp.Decode(buff, data)
@@ -82,13 +115,90 @@ func TxFromBytes(raw []byte) (Tx, error) {
 		data := &WithdrawStakeTx{}
 		err = rlp.Decode(buff, data)
 		return data, err
+	} else if txType == TxSetAccountOperator {
+		data := &SetAccountOperatorTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxServicePaymentDispute {
+		data := &ServicePaymentDisputeTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxRegisterNodeAddress {
+		data := &RegisterNodeAddressTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxMultiSend {
+		data := &MultiSendTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxSlashReview {
+		data := &SlashReviewTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxDataCommitment {
+		data := &DataCommitmentTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxUpdateAccountSigners {
+		data := &UpdateAccountSignersTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if txType == TxMultiSigSend {
+		data := &MultiSigSendTx{}
+		err = rlp.Decode(buff, data)
+		return data, err
+	} else if data, ok := newExtensionTx(txType); ok {
+		err = rlp.Decode(buff, data)
+		return data, err
 	} else {
 		return nil, fmt.Errorf("Unknown TX type: %v", txType)
 	}
 }
 
-func TxToBytes(t Tx) ([]byte, error) {
-	var buf bytes.Buffer
+// decodeTxExpiry decodes the optional expiry height trailing the tx body
+func decodeTxExpiry(buff *bytes.Buffer, tx Tx) error {
+	if buff.Len() == 0 || isRLPList(buff.Bytes()) {
+		return nil
+	}
+	etx, ok := tx.(ExpiringTx)
+	if !ok {
+		return fmt.Errorf("Unexpected data after the tx body")
+	}
+	var expiresAt uint64
+	if err := rlp.Decode(buff, &expiresAt); err != nil {
+		return err
+	}
+	if expiresAt == 0 {
+		return fmt.Errorf("Invalid tx expiry")
+	}
+	etx.SetExpiryHeight(expiresAt)
+	return nil
+}
+
+// decodeTxFeePayer decodes the optional fee payer trailing the tx body and the expiry height
+func decodeTxFeePayer(buff *bytes.Buffer, tx Tx) error {
+	if buff.Len() == 0 {
+		return nil
+	}
+	stx, ok := tx.(SponsoredTx)
+	if !ok || !isRLPList(buff.Bytes()) {
+		return fmt.Errorf("Unexpected data after the tx body")
+	}
+	feePayer := &FeePayer{}
+	if err := rlp.Decode(buff, feePayer); err != nil {
+		return err
+	}
+	stx.SetFeePayer(feePayer)
+	return nil
+}
+
+// isRLPList returns whether the RLP encoded data starts with a list
+func isRLPList(data []byte) bool {
+	return len(data) > 0 && data[0] >= 0xc0
+}
+
+// GetTxType returns the type of the given transaction
+func GetTxType(t Tx) (TxType, error) {
 	var txType TxType
 	switch t.(type) {
 	case *CoinbaseTx:
@@ -111,10 +221,39 @@ func TxToBytes(t Tx) ([]byte, error) {
 		txType = TxDepositStake
 	case *WithdrawStakeTx:
 		txType = TxWithdrawStake
+	case *SetAccountOperatorTx:
+		txType = TxSetAccountOperator
+	case *ServicePaymentDisputeTx:
+		txType = TxServicePaymentDispute
+	case *RegisterNodeAddressTx:
+		txType = TxRegisterNodeAddress
+	case *MultiSendTx:
+		txType = TxMultiSend
+	case *SlashReviewTx:
+		txType = TxSlashReview
+	case *DataCommitmentTx:
+		txType = TxDataCommitment
+	case *UpdateAccountSignersTx:
+		txType = TxUpdateAccountSigners
+	case *MultiSigSendTx:
+		txType = TxMultiSigSend
 	default:
-		return nil, errors.New("Unsupported message type")
+		extensionTxType, ok := getExtensionTxType(t)
+		if !ok {
+			return txType, errors.New("Unsupported message type")
+		}
+		txType = extensionTxType
 	}
-	err := rlp.Encode(&buf, txType)
+	return txType, nil
+}
+
+func TxToBytes(t Tx) ([]byte, error) {
+	var buf bytes.Buffer
+	txType, err := GetTxType(t)
+	if err != nil {
+		return nil, err
+	}
+	err = rlp.Encode(&buf, txType)
 	if err != nil {
 		return nil, err
 	}
@@ -122,5 +261,19 @@ func TxToBytes(t Tx) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if etx, ok := t.(ExpiringTx); ok && etx.ExpiryHeight() != 0 {
+		// The expiry height trails the tx body, so that the encoding of the txs without
+		// an expiry is unchanged
+		err = rlp.Encode(&buf, etx.ExpiryHeight())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if feePayer := GetTxFeePayer(t); feePayer != nil {
+		err = rlp.Encode(&buf, feePayer)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return buf.Bytes(), nil
 }
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -157,6 +158,191 @@ func TestTx(t *testing.T) {
 	assert.Equal(tx1.(*SplitRuleTx).Duration, tx2.(*SplitRuleTx).Duration)
 }
 
+func TestTxExpiry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tx1 := &SendTx{
+		Fee:     NewCoins(123, 0),
+		Inputs:  []TxInput{{Address: getTestAddress("123"), Sequence: 1}},
+		Outputs: []TxOutput{{Address: getTestAddress("456")}},
+	}
+	noExpiry, err := TxToBytes(tx1)
+	require.Nil(err)
+	noExpirySignBytes := tx1.SignBytes("test_chain")
+
+	// The expiry height trails the tx body, and is covered by the signature
+	tx1.SetExpiryHeight(1000)
+	b, err := TxToBytes(tx1)
+	require.Nil(err)
+	assert.Equal(noExpiry, b[:len(noExpiry)])
+	assert.NotEqual(noExpirySignBytes, tx1.SignBytes("test_chain"))
+	tx2, err := TxFromBytes(b)
+	require.Nil(err)
+	assert.Equal(uint64(1000), tx2.(*SendTx).ExpiryHeight())
+	assert.False(IsTxExpired(tx2, 999))
+	assert.True(IsTxExpired(tx2, 1000))
+
+	tx2, err = TxFromBytes(noExpiry)
+	require.Nil(err)
+	assert.Equal(uint64(0), tx2.(*SendTx).ExpiryHeight())
+	assert.False(IsTxExpired(tx2, 1000000))
+
+	// Malformed trailers are rejected
+	zero, _ := rlp.EncodeToBytes(uint64(0))
+	_, err = TxFromBytes(append(append([]byte{}, noExpiry...), zero...))
+	assert.NotNil(err)
+	_, err = TxFromBytes(append(append([]byte{}, b...), 0x01))
+	assert.NotNil(err)
+
+	// The protocol transactions cannot expire
+	coinbase, err := TxToBytes(&CoinbaseTx{BlockHeight: 999})
+	require.Nil(err)
+	expiry, _ := rlp.EncodeToBytes(uint64(1000))
+	_, err = TxFromBytes(append(coinbase, expiry...))
+	assert.NotNil(err)
+}
+
+func TestTxFeePayer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender := MakeAcc("sender")
+	payer := MakeAcc("payer")
+	tx1 := &SendTx{
+		Fee:     NewCoins(0, 123),
+		Inputs:  []TxInput{{Address: sender.Address, Sequence: 1}},
+		Outputs: []TxOutput{{Address: getTestAddress("456")}},
+	}
+	noFeePayerSignBytes := tx1.SignBytes("test_chain")
+
+	// The signature of the sender covers the address of the fee payer, and the fee payer
+	// signs over the signature of the sender
+	tx1.SetExpiryHeight(1000)
+	tx1.SetFeePayer(&FeePayer{Address: payer.Address})
+	signBytes := tx1.SignBytes("test_chain")
+	assert.NotEqual(noFeePayerSignBytes, signBytes)
+	assert.True(tx1.SetSignature(sender.Address, sender.Sign(signBytes)))
+	feePayerSignBytes := tx1.FeePayerSignBytes("test_chain")
+	assert.NotEqual(signBytes, feePayerSignBytes)
+	assert.True(tx1.SetSignature(payer.Address, payer.Sign(feePayerSignBytes)))
+	assert.Equal(signBytes, tx1.SignBytes("test_chain"))
+	assert.Equal(feePayerSignBytes, tx1.FeePayerSignBytes("test_chain"))
+
+	b, err := TxToBytes(tx1)
+	require.Nil(err)
+	tx2, err := TxFromBytes(b)
+	require.Nil(err)
+	assert.Equal(uint64(1000), tx2.(*SendTx).ExpiryHeight())
+	feePayer := GetTxFeePayer(tx2)
+	require.NotNil(feePayer)
+	assert.Equal(payer.Address, feePayer.Address)
+	assert.True(feePayer.Signature.Verify(feePayerSignBytes, payer.Address))
+
+	// The fee payer can be set without an expiry height
+	tx1.SetExpiryHeight(0)
+	b, err = TxToBytes(tx1)
+	require.Nil(err)
+	tx2, err = TxFromBytes(b)
+	require.Nil(err)
+	assert.Equal(uint64(0), tx2.(*SendTx).ExpiryHeight())
+	assert.Equal(payer.Address, GetTxFeePayer(tx2).Address)
+
+	// Only the sponsored transactions can have a fee payer
+	feePayerBytes, _ := rlp.EncodeToBytes(&FeePayer{Address: payer.Address})
+	_, err = TxFromBytes(append(append([]byte{}, b...), feePayerBytes...))
+	assert.NotNil(err)
+	withdraw, err := TxToBytes(&WithdrawStakeTx{Fee: NewCoins(0, 123)})
+	require.Nil(err)
+	_, err = TxFromBytes(append(withdraw, feePayerBytes...))
+	assert.NotNil(err)
+}
+
+func TestSendTxMemo(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	tx1 := &SendTx{
+		Fee:     NewCoins(0, 123),
+		Inputs:  []TxInput{{Address: getTestAddress("123"), Sequence: 1}},
+		Outputs: []TxOutput{{Address: getTestAddress("456")}},
+	}
+	noMemo, err := TxToBytes(tx1)
+	require.Nil(err)
+	noMemoSignBytes := tx1.SignBytes("test_chain")
+
+	// The memo is covered by the signature, and survives the expiry and fee payer trailers
+	tx1.Memo = common.Bytes("deposit 12345")
+	assert.NotEqual(noMemoSignBytes, tx1.SignBytes("test_chain"))
+	tx1.SetExpiryHeight(1000)
+	tx1.SetFeePayer(&FeePayer{Address: getTestAddress("789")})
+	b, err := TxToBytes(tx1)
+	require.Nil(err)
+	tx2, err := TxFromBytes(b)
+	require.Nil(err)
+	assert.Equal(common.Bytes("deposit 12345"), tx2.(*SendTx).Memo)
+	assert.Equal(uint64(1000), tx2.(*SendTx).ExpiryHeight())
+	assert.Equal(getTestAddress("789"), GetTxFeePayer(tx2).Address)
+
+	// The transactions without memo are encoded as before
+	tx2, err = TxFromBytes(noMemo)
+	require.Nil(err)
+	assert.Nil(tx2.(*SendTx).Memo)
+	b, err = TxToBytes(tx2)
+	require.Nil(err)
+	assert.Equal(noMemo, b)
+
+	// An empty memo has a single encoding
+	txType, _ := rlp.EncodeToBytes(TxSend)
+	body, _ := rlp.EncodeToBytes([]interface{}{tx1.Fee, tx1.Inputs, tx1.Outputs, common.Bytes{}})
+	_, err = TxFromBytes(append(txType, body...))
+	assert.NotNil(err)
+}
+
+func TestMultiSendTx(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	sender := MakeAcc("sender")
+	builder := NewMultiSendTxBuilder(sender.Address, 3)
+	require.Nil(builder.AddOutput(getTestAddress("123"), NewCoins(10, 0), []byte("payout 0001")))
+	require.Nil(builder.AddOutput(getTestAddress("456"), NewCoins(0, 20), nil))
+	assert.NotNil(builder.AddOutput(getTestAddress("123"), NewCoins(1, 0), nil))
+	assert.NotNil(builder.AddOutput(getTestAddress("789"), NewCoins(1, 0), make([]byte, MaxMultiSendMemoLength+1)))
+
+	// The input covers the outputs and the minimum fee, which grows with the number of outputs
+	tx1, err := builder.Build()
+	require.Nil(err)
+	assert.Equal(MultiSendMinimumFee(2), tx1.Fee.TFuelWei)
+	assert.True(MultiSendMinimumFee(3).Cmp(MultiSendMinimumFee(2)) > 0)
+	assert.True(tx1.Inputs[0].Coins.IsEqual(NewCoins(10, 20).Plus(tx1.Fee)))
+	builder.SetFee(big.NewInt(1))
+	_, err = builder.Build()
+	assert.NotNil(err)
+
+	tx1.SetExpiryHeight(1000)
+	signBytes := tx1.SignBytes("test_chain")
+	assert.True(tx1.SetSignature(sender.Address, sender.Sign(signBytes)))
+	assert.Equal(signBytes, tx1.SignBytes("test_chain"))
+
+	b, err := TxToBytes(tx1)
+	require.Nil(err)
+	txType, err := PeekTxType(b)
+	require.Nil(err)
+	assert.Equal(TxMultiSend, txType)
+	tx2, err := TxFromBytes(b)
+	require.Nil(err)
+	multiSendTx := tx2.(*MultiSendTx)
+	assert.Equal(uint64(1000), multiSendTx.ExpiryHeight())
+	assert.Equal(common.Bytes("payout 0001"), multiSendTx.Outputs[0].Memo)
+	assert.Equal(signBytes, multiSendTx.SignBytes("test_chain"))
+	assert.True(multiSendTx.Inputs[0].Signature.Verify(signBytes, sender.Address))
+
+	// The memos are covered by the signature
+	multiSendTx.Outputs[1].Memo = common.Bytes("payout 0002")
+	assert.NotEqual(signBytes, multiSendTx.SignBytes("test_chain"))
+}
+
 func getTestAddress(addr string) common.Address {
 	var address common.Address
 	copy(address[:], addr)
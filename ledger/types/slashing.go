@@ -0,0 +1,98 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// MaxSlashingCouncilSize is the max number of members of the slashing council
+const MaxSlashingCouncilSize = 64
+
+// ** Slashing Parameters: The slashing mode of a network, fixed at genesis **
+//
+
+// SlashingParams sets the slashing mode of the overspent reserved funds on a network. Without
+// params, or without a council, a slash transaction transfers the slashed funds to its proposer
+// right away. With a council, the slashing takes two phases: the slash transaction locks the slashed funds into a
+// SlashRecord, which the council confirms or dismisses within the review window. The funds of
+// a confirmed slash, or of a slash not reviewed in time, are burned, and the funds of a dismissed
+// slash are returned to the slashed account.
+type SlashingParams struct {
+	Council      []common.Address `json:"council"`       // Accounts reviewing the slashes, none for the immediate slashing
+	Threshold    uint64           `json:"threshold"`     // Number of council members required to review a slash
+	ReviewWindow uint64           `json:"review_window"` // Number of blocks the council has to review a slash
+}
+
+// IsTwoPhase returns whether the slashes are reviewed by a council
+func (sp *SlashingParams) IsTwoPhase() bool {
+	return len(sp.Council) > 0
+}
+
+// IsCouncilMember returns whether the address is a member of the council
+func (sp *SlashingParams) IsCouncilMember(addr common.Address) bool {
+	for _, member := range sp.Council {
+		if member == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the council can reach the threshold, and has time to review the slashes
+func (sp *SlashingParams) Validate() error {
+	if !sp.IsTwoPhase() {
+		if sp.Threshold != 0 || sp.ReviewWindow != 0 {
+			return errors.New("The threshold and the review window require a council")
+		}
+		return nil
+	}
+	if len(sp.Council) > MaxSlashingCouncilSize {
+		return fmt.Errorf("The council has %v members, at most %v allowed", len(sp.Council), MaxSlashingCouncilSize)
+	}
+	seen := make(map[common.Address]bool)
+	for _, member := range sp.Council {
+		if seen[member] {
+			return fmt.Errorf("Duplicated council member: %v", member.Hex())
+		}
+		seen[member] = true
+	}
+	if sp.Threshold == 0 || sp.Threshold > uint64(len(sp.Council)) {
+		return fmt.Errorf("Invalid threshold: %v, expected 1 to %v", sp.Threshold, len(sp.Council))
+	}
+	if sp.ReviewWindow == 0 {
+		return errors.New("The review window cannot be empty")
+	}
+	return nil
+}
+
+func (sp *SlashingParams) String() string {
+	if sp == nil {
+		return "nil-SlashingParams"
+	}
+	return fmt.Sprintf("SlashingParams{council: %v, threshold: %v, review_window: %v}",
+		sp.Council, sp.Threshold, sp.ReviewWindow)
+}
+
+// ** Slash Record: A slash pending the review of the council **
+//
+
+// SlashRecord is the evidence of an offense, and the funds it locked until the council reviews it
+type SlashRecord struct {
+	ID              common.Hash    `json:"id"`               // Hash of the slash transaction
+	SlashedAddress  common.Address `json:"slashed_address"`  // The offender
+	ReserveSequence uint64         `json:"reserve_sequence"` // The overspent reserved fund
+	Proposer        common.Address `json:"proposer"`         // The validator which submitted the evidence
+	SlashProof      common.Bytes   `json:"slash_proof"`      // The evidence
+	Amount          Coins          `json:"amount"`           // The collateral and the remaining fund, locked
+	ReviewDeadline  uint64         `json:"review_deadline"`  // Last height at which the council can review the slash
+}
+
+func (sr *SlashRecord) String() string {
+	if sr == nil {
+		return "nil-SlashRecord"
+	}
+	return fmt.Sprintf("SlashRecord{id: %v, slashed_address: %v, reserve_sequence: %v, proposer: %v, amount: %v, review_deadline: %v}",
+		sr.ID.Hex(), sr.SlashedAddress.Hex(), sr.ReserveSequence, sr.Proposer.Hex(), sr.Amount, sr.ReviewDeadline)
+}
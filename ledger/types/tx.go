@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 
@@ -20,25 +21,41 @@ Transaction Types:
  - CoinbaseTx           Coinbase transaction for block rewards
  - SlashTx     			Transaction for slashing dishonest user
  - SendTx               Send coins to address
+ - MultiSendTx          Send coins to many addresses, e.g. for payouts, with per-output memos
  - ReserveFundTx        Reserve fund for subsequence service payments
  - ReleaseFundTx        Release fund reserved for service payments
  - ServicePaymentTx     Payments for service
  - SplitRuleTx          Payment split rule
  - DepositStakeTx       Deposit stake to a target address (e.g. a validator)
  - WithdrawStakeTx      Withdraw stake from a target address (e.g. a validator)
+ - SetAccountOperatorTx Authorize a secondary key to sign service payments for an account
+ - ServicePaymentDisputeTx Dispute a pending service payment settlement with a newer payment
+ - RegisterNodeAddressTx Publish the network endpoints of a validator
+ - SlashReviewTx        Confirm or dismiss a slash pending the review of the slashing council
+ - DataCommitmentTx     Anchor the Merkle root of off-chain data, e.g. media metadata, to the chain
+ - UpdateAccountSignersTx Register the M-of-N signer set of a multisig account
+ - MultiSigSendTx       Send coins from a multisig account
  - SmartContractTx      Execute smart contract
 */
 
 // Gas of regular transactions
 const (
-	GasSendTxPerAccount   uint64 = 5000
-	GasReserveFundTx      uint64 = 10000
-	GasReleaseFundTx      uint64 = 10000
-	GasServicePaymentTx   uint64 = 10000
-	GasSplitRuleTx        uint64 = 10000
-	GasUpdateValidatorsTx uint64 = 10000
-	GasDepositStakeTx     uint64 = 10000
-	GasWidthdrawStakeTx   uint64 = 10000
+	GasSendTxPerAccount      uint64 = 5000
+	GasSendTxMemoPerByte     uint64 = 68
+	GasReserveFundTx         uint64 = 10000
+	GasReleaseFundTx         uint64 = 10000
+	GasServicePaymentTx      uint64 = 10000
+	GasSplitRuleTx           uint64 = 10000
+	GasUpdateValidatorsTx    uint64 = 10000
+	GasDepositStakeTx        uint64 = 10000
+	GasWidthdrawStakeTx      uint64 = 10000
+	GasSetAccountOperator    uint64 = 10000
+	GasServicePaymentDispute uint64 = 10000
+	GasRegisterNodeAddress   uint64 = 10000
+	GasSlashReviewTx         uint64 = 10000
+	GasDataCommitmentTx      uint64 = 10000
+	GasUpdateAccountSigners  uint64 = 10000
+	GasMultiSigSignature     uint64 = 3000 // per signature of a multisig input
 )
 
 type Tx interface {
@@ -60,6 +77,161 @@ func TxID(chainID string, tx Tx) common.Hash {
 	return crypto.Keccak256Hash(signBytes)
 }
 
+// TxFee returns the fee charged for a transaction, given the gas used by a smart contract transaction
+func TxFee(tx Tx, gasUsed uint64) Coins {
+	switch tx := tx.(type) {
+	case *SmartContractTx:
+		return Coins{
+			ThetaWei: big.NewInt(0),
+			TFuelWei: new(big.Int).Mul(tx.GasPrice, new(big.Int).SetUint64(gasUsed)),
+		}
+	case *SendTx:
+		return tx.Fee
+	case *MultiSendTx:
+		return tx.Fee
+	case *ReserveFundTx:
+		return tx.Fee
+	case *ReleaseFundTx:
+		return tx.Fee
+	case *ServicePaymentTx:
+		return tx.Fee
+	case *SplitRuleTx:
+		return tx.Fee
+	case *DepositStakeTx:
+		return tx.Fee
+	case *WithdrawStakeTx:
+		return tx.Fee
+	case *SetAccountOperatorTx:
+		return tx.Fee
+	case *ServicePaymentDisputeTx:
+		return tx.Fee
+	case *RegisterNodeAddressTx:
+		return tx.Fee
+	case *SlashReviewTx:
+		return tx.Fee
+	case *DataCommitmentTx:
+		return tx.Fee
+	case *UpdateAccountSignersTx:
+		return tx.Fee
+	case *MultiSigSendTx:
+		return tx.Fee
+	case FeeTx:
+		return tx.GetFee()
+	default:
+		return NewCoins(0, 0)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// TxExpiry is the optional expiry of a transaction. A transaction with an expiry height can only
+// be included in the blocks below that height, so that its sender can safely re-sign it, e.g. with
+// a higher fee, once it has expired. The expiry height trails the tx body in the encoding of the
+// transaction (see TxToBytes), and is thus covered by its signatures.
+type TxExpiry struct {
+	ExpiresAt uint64 `json:"expires_at,omitempty"` // 0 if the transaction never expires
+}
+
+// ExpiryHeight returns the height at which the transaction expires, 0 if it never expires
+func (e *TxExpiry) ExpiryHeight() uint64 {
+	return e.ExpiresAt
+}
+
+// SetExpiryHeight sets the height at which the transaction expires, 0 for no expiry
+func (e *TxExpiry) SetExpiryHeight(height uint64) {
+	e.ExpiresAt = height
+}
+
+// ExpiringTx is a transaction which can carry an expiry height. The transactions generated by the
+// protocol, and the service payments, whose source signature is collected off-chain, cannot expire.
+type ExpiringTx interface {
+	Tx
+	ExpiryHeight() uint64
+	SetExpiryHeight(height uint64)
+}
+
+// IsTxExpired returns whether the transaction can no longer be included in the block at the given height
+func IsTxExpired(tx Tx, height uint64) bool {
+	etx, ok := tx.(ExpiringTx)
+	if !ok {
+		return false
+	}
+	expiresAt := etx.ExpiryHeight()
+	return expiresAt != 0 && height >= expiresAt
+}
+
+//-----------------------------------------------------------------------------
+
+// FeePayer is a third party account paying the fee of a transaction on behalf of its sender,
+// e.g. to onboard users holding no TFuel. The fee payer signs the transaction including the
+// signatures of the sender(s), see FeePayerSignBytes.
+type FeePayer struct {
+	Address   common.Address    `json:"address"`
+	Signature *crypto.Signature `json:"signature"`
+}
+
+// TxFeePayer is the optional fee payer of a transaction. Like the expiry height, the fee payer
+// trails the tx body in the encoding of the transaction (see TxToBytes).
+type TxFeePayer struct {
+	FeePayer *FeePayer `json:"fee_payer,omitempty"` // nil if the sender pays the fee
+}
+
+// GetFeePayer returns the fee payer of the transaction, nil if the sender pays the fee
+func (p *TxFeePayer) GetFeePayer() *FeePayer {
+	return p.FeePayer
+}
+
+// SetFeePayer sets the fee payer of the transaction, nil if the sender pays the fee
+func (p *TxFeePayer) SetFeePayer(feePayer *FeePayer) {
+	p.FeePayer = feePayer
+}
+
+// SponsoredTx is a transaction whose fee can be paid by a fee payer.
+type SponsoredTx interface {
+	Tx
+	GetFeePayer() *FeePayer
+	SetFeePayer(feePayer *FeePayer)
+	FeePayerSignBytes(chainID string) []byte
+}
+
+// GetTxFeePayer returns the fee payer of the transaction, nil if the sender pays the fee
+func GetTxFeePayer(tx Tx) *FeePayer {
+	stx, ok := tx.(SponsoredTx)
+	if !ok {
+		return nil
+	}
+	return stx.GetFeePayer()
+}
+
+// feePayerSignBytes returns the bytes signed by the fee payer, i.e. the transaction with all
+// the signatures except the one of the fee payer.
+func feePayerSignBytes(chainID string, tx SponsoredTx) []byte {
+	signBytes := encodeToBytes(chainID)
+	feePayer := tx.GetFeePayer()
+	if feePayer == nil {
+		return nil
+	}
+	sig := feePayer.Signature
+	feePayer.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	feePayer.Signature = sig
+	return signBytes
+}
+
+// clearFeePayerSignature clears the signature of the fee payer, if any, and returns a function
+// restoring it. The signatures of the senders cover the address but not the signature of the fee payer.
+func clearFeePayerSignature(p *TxFeePayer) func() {
+	if p.FeePayer == nil {
+		return func() {}
+	}
+	sig := p.FeePayer.Signature
+	p.FeePayer.Signature = nil
+	return func() { p.FeePayer.Signature = sig }
+}
+
 //--------------------------------------------------------------------------------
 
 // Contract: This function is deterministic and completely reversible.
@@ -85,14 +257,14 @@ type TxInput struct {
 	Address   common.Address // Hash of the PubKey
 	Coins     Coins
 	Sequence  uint64            // Must be 1 greater than the last committed TxInput
-	Signature *crypto.Signature // Depends on the PubKey type and the whole Tx
+	Signature *crypto.Signature // Recoverable secp256k1 signature [R || S || V] over the whole Tx, the PubKey is recovered from it
 }
 
 type TxInputJSON struct {
 	Address   common.Address    `json:"address"`   // Hash of the PubKey
 	Coins     Coins             `json:"coins"`     //
 	Sequence  common.JSONUint64 `json:"sequence"`  // Must be 1 greater than the last committed TxInput
-	Signature *crypto.Signature `json:"signature"` // Depends on the PubKey type and the whole Tx
+	Signature *crypto.Signature `json:"signature"` // Recoverable secp256k1 signature [R || S || V] over the whole Tx, the PubKey is recovered from it
 }
 
 func NewTxInputJSON(a TxInput) TxInputJSON {
@@ -162,6 +334,102 @@ func NewTxInput(address common.Address, coins Coins, sequence int) TxInput {
 
 //-----------------------------------------------------------------------------
 
+// SignerSignature is the signature of one of the signers of a multisig account
+type SignerSignature struct {
+	Signer    common.Address    `json:"signer"`    // Address of the signer key
+	Signature *crypto.Signature `json:"signature"` // Signature over the SignBytes of the transaction
+}
+
+// MultiSigInput is the input of a multisig account, see AccountSigners. Its signers all sign the
+// same SignBytes, which do not cover the signatures, so that they can sign independently and the
+// signatures can be combined in any order.
+type MultiSigInput struct {
+	Address    common.Address
+	Coins      Coins
+	Sequence   uint64            // Must be 1 greater than the last committed sequence of the account
+	Signatures []SignerSignature // At least the threshold number of signatures of distinct signers
+}
+
+type MultiSigInputJSON struct {
+	Address    common.Address    `json:"address"`
+	Coins      Coins             `json:"coins"`
+	Sequence   common.JSONUint64 `json:"sequence"`
+	Signatures []SignerSignature `json:"signatures"`
+}
+
+func NewMultiSigInputJSON(a MultiSigInput) MultiSigInputJSON {
+	return MultiSigInputJSON{
+		Address:    a.Address,
+		Coins:      a.Coins,
+		Sequence:   common.JSONUint64(a.Sequence),
+		Signatures: a.Signatures,
+	}
+}
+
+func (a MultiSigInputJSON) MultiSigInput() MultiSigInput {
+	return MultiSigInput{
+		Address:    a.Address,
+		Coins:      a.Coins,
+		Sequence:   uint64(a.Sequence),
+		Signatures: a.Signatures,
+	}
+}
+
+func (a MultiSigInput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewMultiSigInputJSON(a))
+}
+
+func (a *MultiSigInput) UnmarshalJSON(data []byte) error {
+	var b MultiSigInputJSON
+	if err := json.Unmarshal(data, &b); err != nil {
+		return err
+	}
+	*a = b.MultiSigInput()
+	return nil
+}
+
+func (txIn MultiSigInput) ValidateBasic() result.Result {
+	if len(txIn.Address) != 20 {
+		return result.Error("Invalid address length")
+	}
+	if !txIn.Coins.IsValid() {
+		return result.Error("Invalid coins: %v", txIn.Coins)
+	}
+	if len(txIn.Signatures) > MaxAccountSigners {
+		return result.Error("Too many signatures: %v, at most %v signatures are allowed",
+			len(txIn.Signatures), MaxAccountSigners)
+	}
+	return result.OK
+}
+
+// AddSignature adds the signature of the signer, replacing its previous signature if any
+func (txIn *MultiSigInput) AddSignature(signer common.Address, sig *crypto.Signature) {
+	for i := range txIn.Signatures {
+		if txIn.Signatures[i].Signer == signer {
+			txIn.Signatures[i].Signature = sig
+			return
+		}
+	}
+	txIn.Signatures = append(txIn.Signatures, SignerSignature{Signer: signer, Signature: sig})
+}
+
+func (txIn MultiSigInput) String() string {
+	signers := make([]string, len(txIn.Signatures))
+	for i, sig := range txIn.Signatures {
+		signers[i] = sig.Signer.Hex()
+	}
+	return fmt.Sprintf("MultiSigInput{%v,%v,%v,signed by %v}", txIn.Address.Hex(), txIn.Coins, txIn.Sequence, signers)
+}
+
+// MultiSigTx is implemented by the transactions from a multisig account. The CLI uses it to add
+// the signatures of the signers to a transaction, and to combine partially signed transactions.
+type MultiSigTx interface {
+	Tx
+	GetMultiSigInput() *MultiSigInput
+}
+
+//-----------------------------------------------------------------------------
+
 type TxOutput struct {
 	Address common.Address `json:"address"` // Hash of the PubKey
 	Coins   Coins          `json:"coins"`   // Amount of coins
@@ -332,9 +600,55 @@ func (tx *SlashTx) String() string {
 //-----------------------------------------------------------------------------
 
 type SendTx struct {
-	Fee     Coins      `json:"fee"` // Fee
-	Inputs  []TxInput  `json:"inputs"`
-	Outputs []TxOutput `json:"outputs"`
+	TxExpiry   `rlp:"-"` // Encoded after the tx body, see TxToBytes
+	TxFeePayer `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee     Coins        `json:"fee"` // Fee
+	Inputs  []TxInput    `json:"inputs"`
+	Outputs []TxOutput   `json:"outputs"`
+	Memo    common.Bytes `json:"memo,omitempty"` // Optional, e.g. for the exchanges to attribute the deposits
+}
+
+// rlpSendTx is the encoding of a SendTx. The memo trails the other fields only if set, so that
+// the encoding of the transactions without memo is unchanged.
+type rlpSendTx struct {
+	Fee     Coins
+	Inputs  []TxInput
+	Outputs []TxOutput
+	Memo    []common.Bytes `rlp:"tail"` // At most one memo
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (tx *SendTx) EncodeRLP(w io.Writer) error {
+	enc := rlpSendTx{Fee: tx.Fee, Inputs: tx.Inputs, Outputs: tx.Outputs}
+	if len(tx.Memo) != 0 {
+		enc.Memo = []common.Bytes{tx.Memo}
+	}
+	return rlp.Encode(w, enc)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (tx *SendTx) DecodeRLP(s *rlp.Stream) error {
+	var dec rlpSendTx
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.Memo) > 1 || (len(dec.Memo) == 1 && len(dec.Memo[0]) == 0) {
+		return fmt.Errorf("Invalid memo of SendTx")
+	}
+	tx.Fee, tx.Inputs, tx.Outputs, tx.Memo = dec.Fee, dec.Inputs, dec.Outputs, nil
+	if len(dec.Memo) == 1 {
+		tx.Memo = dec.Memo[0]
+	}
+	return nil
+}
+
+// SendTxMinimumFee returns the minimum fee of a SendTx with a memo of the given length, which is
+// charged the gas of the memo at the minimum gas price on top of the minimum transaction fee.
+func SendTxMinimumFee(memoLength int) *big.Int {
+	fee := new(big.Int).SetUint64(GasSendTxMemoPerByte * MinimumGasPrice)
+	fee.Mul(fee, big.NewInt(int64(memoLength)))
+	return fee.Add(fee, new(big.Int).SetUint64(MinimumTransactionFeeTFuelWei))
 }
 
 func (_ *SendTx) AssertIsTx() {}
@@ -346,16 +660,23 @@ func (tx *SendTx) SignBytes(chainID string) []byte {
 		sigz[i] = tx.Inputs[i].Signature
 		tx.Inputs[i].Signature = nil
 	}
+	restoreFeePayerSignature := clearFeePayerSignature(&tx.TxFeePayer)
 	txBytes, _ := TxToBytes(tx)
 	signBytes = append(signBytes, txBytes...)
 	signBytes = addPrefixForSignBytes(signBytes)
 
+	restoreFeePayerSignature()
 	for i := range tx.Inputs {
 		tx.Inputs[i].Signature = sigz[i]
 	}
 	return signBytes
 }
 
+// FeePayerSignBytes returns the bytes to be signed by the fee payer
+func (tx *SendTx) FeePayerSignBytes(chainID string) []byte {
+	return feePayerSignBytes(chainID, tx)
+}
+
 func (tx *SendTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
 	for i, input := range tx.Inputs {
 		if input.Address == addr {
@@ -363,16 +684,173 @@ func (tx *SendTx) SetSignature(addr common.Address, sig *crypto.Signature) bool
 			return true
 		}
 	}
+	if tx.FeePayer != nil && tx.FeePayer.Address == addr {
+		tx.FeePayer.Signature = sig
+		return true
+	}
 	return false
 }
 
 func (tx *SendTx) String() string {
+	if len(tx.Memo) != 0 {
+		return fmt.Sprintf("SendTx{fee: %v, %v->%v, memo: %v}", tx.Fee, tx.Inputs, tx.Outputs, hex.EncodeToString(tx.Memo))
+	}
 	return fmt.Sprintf("SendTx{fee: %v, %v->%v}", tx.Fee, tx.Inputs, tx.Outputs)
 }
 
 //-----------------------------------------------------------------------------
 
+// MultiSendOutput is an output of a MultiSendTx, carrying an optional memo for the recipient,
+// e.g. the ID of the payout it settles.
+type MultiSendOutput struct {
+	Address common.Address `json:"address"`
+	Coins   Coins          `json:"coins"`
+	Memo    common.Bytes   `json:"memo"`
+}
+
+func (out MultiSendOutput) String() string {
+	return fmt.Sprintf("MultiSendOutput{%v, %v, memo: %v}", out.Address.Hex(), out.Coins, hex.EncodeToString(out.Memo))
+}
+
+// MultiSendTx sends coins from a few inputs to up to MaxMultiSendOutputs outputs, so that the
+// payout services can pay thousands of recipients in a single transaction. Its minimum fee grows
+// with the number of outputs, see MultiSendMinimumFee.
+type MultiSendTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee     Coins             `json:"fee"` // Fee
+	Inputs  []TxInput         `json:"inputs"`
+	Outputs []MultiSendOutput `json:"outputs"`
+}
+
+func (_ *MultiSendTx) AssertIsTx() {}
+
+func (tx *MultiSendTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sigz := make([]*crypto.Signature, len(tx.Inputs))
+	for i := range tx.Inputs {
+		sigz[i] = tx.Inputs[i].Signature
+		tx.Inputs[i].Signature = nil
+	}
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	for i := range tx.Inputs {
+		tx.Inputs[i].Signature = sigz[i]
+	}
+	return signBytes
+}
+
+func (tx *MultiSendTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	for i, input := range tx.Inputs {
+		if input.Address == addr {
+			tx.Inputs[i].Signature = sig
+			return true
+		}
+	}
+	return false
+}
+
+func (tx *MultiSendTx) String() string {
+	return fmt.Sprintf("MultiSendTx{fee: %v, %v->%v}", tx.Fee, tx.Inputs, tx.Outputs)
+}
+
+// TxOutputs returns the outputs without their memos
+func (tx *MultiSendTx) TxOutputs() []TxOutput {
+	outputs := make([]TxOutput, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		outputs[i] = TxOutput{Address: out.Address, Coins: out.Coins}
+	}
+	return outputs
+}
+
+// MultiSendMinimumFee returns the minimum fee of a MultiSendTx with the given number of outputs
+func MultiSendMinimumFee(numOutputs int) *big.Int {
+	fee := new(big.Int).SetUint64(MinimumMultiSendFeePerOutputTFuelWei)
+	fee.Mul(fee, big.NewInt(int64(numOutputs)))
+	return fee.Add(fee, new(big.Int).SetUint64(MinimumTransactionFeeTFuelWei))
+}
+
+// MultiSendTxBuilder builds the MultiSendTx paying the recipients from a single account
+type MultiSendTxBuilder struct {
+	from      common.Address
+	sequence  uint64
+	fee       *big.Int
+	outputs   []MultiSendOutput
+	recipient map[common.Address]bool
+}
+
+// NewMultiSendTxBuilder creates a builder of the MultiSendTx sent from the given account
+func NewMultiSendTxBuilder(from common.Address, sequence uint64) *MultiSendTxBuilder {
+	return &MultiSendTxBuilder{
+		from:      from,
+		sequence:  sequence,
+		recipient: make(map[common.Address]bool),
+	}
+}
+
+// AddOutput adds a recipient. Each recipient can only be paid once per transaction.
+func (b *MultiSendTxBuilder) AddOutput(to common.Address, coins Coins, memo []byte) error {
+	if len(b.outputs) >= MaxMultiSendOutputs {
+		return fmt.Errorf("At most %v outputs are allowed per multi-send transaction", MaxMultiSendOutputs)
+	}
+	if b.recipient[to] {
+		return fmt.Errorf("Duplicated recipient %v", to.Hex())
+	}
+	if len(memo) > MaxMultiSendMemoLength {
+		return fmt.Errorf("Memo of %v too long: %v bytes, at most %v bytes allowed", to.Hex(), len(memo), MaxMultiSendMemoLength)
+	}
+	coins = coins.NoNil()
+	if !coins.IsNonnegative() {
+		return fmt.Errorf("Invalid amount for %v: %v", to.Hex(), coins)
+	}
+	b.recipient[to] = true
+	b.outputs = append(b.outputs, MultiSendOutput{Address: to, Coins: coins, Memo: memo})
+	return nil
+}
+
+// SetFee sets the fee in TFuelWei, which defaults to the minimum fee for the number of outputs
+func (b *MultiSendTxBuilder) SetFee(fee *big.Int) {
+	b.fee = fee
+}
+
+// Build returns the unsigned transaction, whose input covers the outputs and the fee
+func (b *MultiSendTxBuilder) Build() (*MultiSendTx, error) {
+	if len(b.outputs) == 0 {
+		return nil, fmt.Errorf("No output")
+	}
+	fee := NewCoins(0, 0)
+	fee.TFuelWei = MultiSendMinimumFee(len(b.outputs))
+	if b.fee != nil {
+		if b.fee.Cmp(fee.TFuelWei) < 0 {
+			return nil, fmt.Errorf("Insufficient fee %v TFuelWei, at least %v TFuelWei needed for %v outputs",
+				b.fee, fee.TFuelWei, len(b.outputs))
+		}
+		fee.TFuelWei = new(big.Int).Set(b.fee)
+	}
+	total := fee
+	for _, out := range b.outputs {
+		total = total.Plus(out.Coins)
+	}
+	outputs := make([]MultiSendOutput, len(b.outputs))
+	copy(outputs, b.outputs)
+	return &MultiSendTx{
+		Fee: fee,
+		Inputs: []TxInput{{
+			Address:  b.from,
+			Coins:    total,
+			Sequence: b.sequence,
+		}},
+		Outputs: outputs,
+	}, nil
+}
+
+//-----------------------------------------------------------------------------
+
 type ReserveFundTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	Fee         Coins    // Fee
 	Source      TxInput  // Source account
 	Collateral  Coins    // Collateral for the micropayment pool
@@ -381,6 +859,8 @@ type ReserveFundTx struct {
 }
 
 type ReserveFundTxJSON struct {
+	TxExpiry
+
 	Fee         Coins             `json:"fee"`          // Fee
 	Source      TxInput           `json:"source"`       // Source account
 	Collateral  Coins             `json:"collateral"`   // Collateral for the micropayment pool
@@ -390,6 +870,7 @@ type ReserveFundTxJSON struct {
 
 func NewReserveFundTxJSON(a ReserveFundTx) ReserveFundTxJSON {
 	return ReserveFundTxJSON{
+		TxExpiry:    a.TxExpiry,
 		Fee:         a.Fee,
 		Source:      a.Source,
 		Collateral:  a.Collateral,
@@ -400,6 +881,7 @@ func NewReserveFundTxJSON(a ReserveFundTx) ReserveFundTxJSON {
 
 func (a ReserveFundTxJSON) ReserveFundTx() ReserveFundTx {
 	return ReserveFundTx{
+		TxExpiry:    a.TxExpiry,
 		Fee:         a.Fee,
 		Source:      a.Source,
 		Collateral:  a.Collateral,
@@ -451,12 +933,16 @@ func (tx *ReserveFundTx) String() string {
 //-----------------------------------------------------------------------------
 
 type ReleaseFundTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	Fee             Coins   // Fee
 	Source          TxInput // source account
 	ReserveSequence uint64
 }
 
 type ReleaseFundTxJSON struct {
+	TxExpiry
+
 	Fee             Coins             `json:"fee"`    // Fee
 	Source          TxInput           `json:"source"` // source account
 	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
@@ -464,6 +950,7 @@ type ReleaseFundTxJSON struct {
 
 func NewReleaseFundTxJSON(a ReleaseFundTx) ReleaseFundTxJSON {
 	return ReleaseFundTxJSON{
+		TxExpiry:        a.TxExpiry,
 		Fee:             a.Fee,
 		Source:          a.Source,
 		ReserveSequence: common.JSONUint64(a.ReserveSequence),
@@ -472,6 +959,7 @@ func NewReleaseFundTxJSON(a ReleaseFundTx) ReleaseFundTxJSON {
 
 func (a ReleaseFundTxJSON) ReleaseFundTx() ReleaseFundTx {
 	return ReleaseFundTx{
+		TxExpiry:        a.TxExpiry,
 		Fee:             a.Fee,
 		Source:          a.Source,
 		ReserveSequence: uint64(a.ReserveSequence),
@@ -642,6 +1130,8 @@ func (tx *ServicePaymentTx) TxBytes() ([]byte, error) {
 //-----------------------------------------------------------------------------
 
 type SplitRuleTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	Fee        Coins   // Fee
 	ResourceID string  // ResourceID of the payment to be split
 	Initiator  TxInput // Initiator of the split rule
@@ -650,6 +1140,8 @@ type SplitRuleTx struct {
 }
 
 type SplitRuleTxJSON struct {
+	TxExpiry
+
 	Fee        Coins             `json:"fee"`         // Fee
 	ResourceID string            `json:"resource_id"` // ResourceID of the payment to be split
 	Initiator  TxInput           `json:"initiator"`   // Initiator of the split rule
@@ -659,6 +1151,7 @@ type SplitRuleTxJSON struct {
 
 func NewSplitRuleTxJSON(a SplitRuleTx) SplitRuleTxJSON {
 	return SplitRuleTxJSON{
+		TxExpiry:   a.TxExpiry,
 		Fee:        a.Fee,
 		ResourceID: a.ResourceID,
 		Initiator:  a.Initiator,
@@ -669,6 +1162,7 @@ func NewSplitRuleTxJSON(a SplitRuleTx) SplitRuleTxJSON {
 
 func (a SplitRuleTxJSON) SplitRuleTx() SplitRuleTx {
 	return SplitRuleTx{
+		TxExpiry:   a.TxExpiry,
 		Fee:        a.Fee,
 		ResourceID: a.ResourceID,
 		Initiator:  a.Initiator,
@@ -720,6 +1214,9 @@ func (tx *SplitRuleTx) String() string {
 //-----------------------------------------------------------------------------
 
 type SmartContractTx struct {
+	TxExpiry   `rlp:"-"` // Encoded after the tx body, see TxToBytes
+	TxFeePayer `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	From     TxInput
 	To       TxOutput
 	GasLimit uint64
@@ -728,6 +1225,9 @@ type SmartContractTx struct {
 }
 
 type SmartContractTxJSON struct {
+	TxExpiry
+	TxFeePayer
+
 	From     TxInput           `json:"from"`
 	To       TxOutput          `json:"to"`
 	GasLimit common.JSONUint64 `json:"gas_limit"`
@@ -737,21 +1237,25 @@ type SmartContractTxJSON struct {
 
 func NewSmartContractTxJSON(a SmartContractTx) SmartContractTxJSON {
 	return SmartContractTxJSON{
-		From:     a.From,
-		To:       a.To,
-		GasLimit: common.JSONUint64(a.GasLimit),
-		GasPrice: (*common.JSONBig)(a.GasPrice),
-		Data:     a.Data,
+		TxExpiry:   a.TxExpiry,
+		TxFeePayer: a.TxFeePayer,
+		From:       a.From,
+		To:         a.To,
+		GasLimit:   common.JSONUint64(a.GasLimit),
+		GasPrice:   (*common.JSONBig)(a.GasPrice),
+		Data:       a.Data,
 	}
 }
 
 func (a SmartContractTxJSON) SmartContractTx() SmartContractTx {
 	return SmartContractTx{
-		From:     a.From,
-		To:       a.To,
-		GasLimit: uint64(a.GasLimit),
-		GasPrice: (*big.Int)(a.GasPrice),
-		Data:     a.Data,
+		TxExpiry:   a.TxExpiry,
+		TxFeePayer: a.TxFeePayer,
+		From:       a.From,
+		To:         a.To,
+		GasLimit:   uint64(a.GasLimit),
+		GasPrice:   (*big.Int)(a.GasPrice),
+		Data:       a.Data,
 	}
 }
 
@@ -774,19 +1278,30 @@ func (tx *SmartContractTx) SignBytes(chainID string) []byte {
 	signBytes := encodeToBytes(chainID)
 	sig := tx.From.Signature
 	tx.From.Signature = nil
+	restoreFeePayerSignature := clearFeePayerSignature(&tx.TxFeePayer)
 	txBytes, _ := TxToBytes(tx)
 	signBytes = append(signBytes, txBytes...)
 	signBytes = addPrefixForSignBytes(signBytes)
 
+	restoreFeePayerSignature()
 	tx.From.Signature = sig
 	return signBytes
 }
 
+// FeePayerSignBytes returns the bytes to be signed by the fee payer
+func (tx *SmartContractTx) FeePayerSignBytes(chainID string) []byte {
+	return feePayerSignBytes(chainID, tx)
+}
+
 func (tx *SmartContractTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
 	if tx.From.Address == addr {
 		tx.From.Signature = sig
 		return true
 	}
+	if tx.FeePayer != nil && tx.FeePayer.Address == addr {
+		tx.FeePayer.Signature = sig
+		return true
+	}
 	return false
 }
 
@@ -798,6 +1313,8 @@ func (tx *SmartContractTx) String() string {
 //-----------------------------------------------------------------------------
 
 type DepositStakeTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	Fee     Coins    `json:"fee"`     // Fee
 	Source  TxInput  `json:"source"`  // source staker account
 	Holder  TxOutput `json:"holder"`  // stake holder account
@@ -834,6 +1351,8 @@ func (tx *DepositStakeTx) String() string {
 //-----------------------------------------------------------------------------
 
 type WithdrawStakeTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
 	Fee     Coins    `json:"fee"`     // Fee
 	Source  TxInput  `json:"source"`  // source staker account
 	Holder  TxOutput `json:"holder"`  // stake holder account
@@ -867,6 +1386,296 @@ func (tx *WithdrawStakeTx) String() string {
 		tx.Source.Address, tx.Holder.Address, tx.Source.Coins.ThetaWei, tx.Purpose)
 }
 
+//-----------------------------------------------------------------------------
+
+// SetAccountOperatorTx authorizes the operator key to sign ServicePaymentTxs on behalf of the
+// account up to the spend limit. Setting an empty operator address revokes the authorization.
+type SetAccountOperatorTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee        Coins          `json:"fee"`         // Fee
+	Account    TxInput        `json:"account"`     // The account granting the authorization, signed by its main key
+	Operator   common.Address `json:"operator"`    // Address of the operator key
+	SpendLimit Coins          `json:"spend_limit"` // Max total amount the operator can spend
+}
+
+func (_ *SetAccountOperatorTx) AssertIsTx() {}
+
+func (tx *SetAccountOperatorTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Account.Signature
+	tx.Account.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Account.Signature = sig
+	return signBytes
+}
+
+func (tx *SetAccountOperatorTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Account.Address == addr {
+		tx.Account.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *SetAccountOperatorTx) String() string {
+	return fmt.Sprintf("SetAccountOperatorTx{fee: %v, account: %v, operator: %v, spend_limit: %v}",
+		tx.Fee, tx.Account, tx.Operator, tx.SpendLimit)
+}
+
+//-----------------------------------------------------------------------------
+
+// ServicePaymentDisputeTx replaces a pending service payment settlement with a payment of a
+// higher payment sequence, countersigned by the source and the target of the settlement.
+type ServicePaymentDisputeTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee    Coins            `json:"fee"`    // Fee
+	Source TxInput          `json:"source"` // The source account of the settlement
+	Proof  ServicePaymentTx `json:"proof"`  // The newer payment signed by both the source and the target
+}
+
+func (_ *ServicePaymentDisputeTx) AssertIsTx() {}
+
+func (tx *ServicePaymentDisputeTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Source.Signature
+	tx.Source.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Source.Signature = sig
+	return signBytes
+}
+
+func (tx *ServicePaymentDisputeTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Source.Address == addr {
+		tx.Source.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *ServicePaymentDisputeTx) String() string {
+	return fmt.Sprintf("ServicePaymentDisputeTx{fee: %v, source: %v, proof: %v}",
+		tx.Fee, tx.Source, tx.Proof.String())
+}
+
+//-----------------------------------------------------------------------------
+
+// RegisterNodeAddressTx publishes the network endpoints of a validator, so that the other
+// validators can connect to it directly. Registering new endpoints rotates the current ones out
+// after a grace period, and registering no endpoint removes the registration.
+type RegisterNodeAddressTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee       Coins    `json:"fee"`       // Fee
+	Validator TxInput  `json:"validator"` // The validator, signed by its key
+	Endpoints []string `json:"endpoints"` // host:port, the host being an IP address or a DNS name
+}
+
+func (_ *RegisterNodeAddressTx) AssertIsTx() {}
+
+func (tx *RegisterNodeAddressTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Validator.Signature
+	tx.Validator.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Validator.Signature = sig
+	return signBytes
+}
+
+func (tx *RegisterNodeAddressTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Validator.Address == addr {
+		tx.Validator.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *RegisterNodeAddressTx) String() string {
+	return fmt.Sprintf("RegisterNodeAddressTx{fee: %v, validator: %v, endpoints: %v}",
+		tx.Fee, tx.Validator, tx.Endpoints)
+}
+
+//-----------------------------------------------------------------------------
+
+// SlashReviewTx confirms or dismisses a slash pending the review of the slashing council. The
+// inputs are the approving council members, the first one paying the fee.
+type SlashReviewTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee      Coins       `json:"fee"`       // Fee
+	Inputs   []TxInput   `json:"inputs"`    // The council members, each signing the tx
+	RecordID common.Hash `json:"record_id"` // ID of the slash record
+	Confirm  bool        `json:"confirm"`   // Burn the slashed funds if true, return them otherwise
+}
+
+func (_ *SlashReviewTx) AssertIsTx() {}
+
+func (tx *SlashReviewTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sigz := make([]*crypto.Signature, len(tx.Inputs))
+	for i := range tx.Inputs {
+		sigz[i] = tx.Inputs[i].Signature
+		tx.Inputs[i].Signature = nil
+	}
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	for i := range tx.Inputs {
+		tx.Inputs[i].Signature = sigz[i]
+	}
+	return signBytes
+}
+
+func (tx *SlashReviewTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	for i, input := range tx.Inputs {
+		if input.Address == addr {
+			tx.Inputs[i].Signature = sig
+			return true
+		}
+	}
+	return false
+}
+
+func (tx *SlashReviewTx) String() string {
+	return fmt.Sprintf("SlashReviewTx{fee: %v, inputs: %v, record_id: %v, confirm: %v}",
+		tx.Fee, tx.Inputs, tx.RecordID.Hex(), tx.Confirm)
+}
+
+//-----------------------------------------------------------------------------
+
+// DataCommitmentTx anchors the Merkle root of off-chain data, e.g. stream manifests or licensing
+// documents, to the chain with the signature of its owner. Each root can only be committed once.
+type DataCommitmentTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee       Coins       `json:"fee"`        // Fee
+	Owner     TxInput     `json:"owner"`      // The owner of the data, signing the commitment
+	Root      common.Hash `json:"root"`       // Merkle root of the data, see DataCommitmentRoot
+	NumLeaves uint64      `json:"num_leaves"` // Number of the pieces of data committed
+	URI       string      `json:"uri"`        // Optional location of the data
+}
+
+func (_ *DataCommitmentTx) AssertIsTx() {}
+
+func (tx *DataCommitmentTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sig := tx.Owner.Signature
+	tx.Owner.Signature = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Owner.Signature = sig
+	return signBytes
+}
+
+func (tx *DataCommitmentTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	if tx.Owner.Address == addr {
+		tx.Owner.Signature = sig
+		return true
+	}
+	return false
+}
+
+func (tx *DataCommitmentTx) String() string {
+	return fmt.Sprintf("DataCommitmentTx{fee: %v, owner: %v, root: %v, num_leaves: %v, uri: %v}",
+		tx.Fee, tx.Owner, tx.Root.Hex(), tx.NumLeaves, tx.URI)
+}
+
+//-----------------------------------------------------------------------------
+
+// UpdateAccountSignersTx registers the M-of-N signer set of a multisig account, or replaces it.
+// It needs the signatures of the current signer set, i.e. the key of the account itself when the
+// account has none yet. An empty signer set with a zero threshold removes the signer set, returning
+// the account to the control of its key.
+type UpdateAccountSignersTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee       Coins            `json:"fee"`       // Fee
+	Account   MultiSigInput    `json:"account"`   // The account, signed by its current signers
+	Signers   []common.Address `json:"signers"`   // Addresses of the new signer keys
+	Threshold uint64           `json:"threshold"` // Min number of signatures of the new signers
+}
+
+func (_ *UpdateAccountSignersTx) AssertIsTx() {}
+
+func (tx *UpdateAccountSignersTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sigs := tx.Account.Signatures
+	tx.Account.Signatures = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Account.Signatures = sigs
+	return signBytes
+}
+
+func (tx *UpdateAccountSignersTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	tx.Account.AddSignature(addr, sig)
+	return true
+}
+
+func (tx *UpdateAccountSignersTx) GetMultiSigInput() *MultiSigInput {
+	return &tx.Account
+}
+
+func (tx *UpdateAccountSignersTx) String() string {
+	return fmt.Sprintf("UpdateAccountSignersTx{fee: %v, account: %v, signers: %v, threshold: %v}",
+		tx.Fee, tx.Account, tx.Signers, tx.Threshold)
+}
+
+//-----------------------------------------------------------------------------
+
+// MultiSigSendTx sends coins from a multisig account, signed by at least the threshold number of
+// its signers. The coins of the input cover the outputs and the fee.
+type MultiSigSendTx struct {
+	TxExpiry `rlp:"-"` // Encoded after the tx body, see TxToBytes
+
+	Fee     Coins         `json:"fee"`     // Fee
+	Input   MultiSigInput `json:"input"`   // The multisig account
+	Outputs []TxOutput    `json:"outputs"` // Recipients
+}
+
+func (_ *MultiSigSendTx) AssertIsTx() {}
+
+func (tx *MultiSigSendTx) SignBytes(chainID string) []byte {
+	signBytes := encodeToBytes(chainID)
+	sigs := tx.Input.Signatures
+	tx.Input.Signatures = nil
+	txBytes, _ := TxToBytes(tx)
+	signBytes = append(signBytes, txBytes...)
+	signBytes = addPrefixForSignBytes(signBytes)
+
+	tx.Input.Signatures = sigs
+	return signBytes
+}
+
+func (tx *MultiSigSendTx) SetSignature(addr common.Address, sig *crypto.Signature) bool {
+	tx.Input.AddSignature(addr, sig)
+	return true
+}
+
+func (tx *MultiSigSendTx) GetMultiSigInput() *MultiSigInput {
+	return &tx.Input
+}
+
+func (tx *MultiSigSendTx) String() string {
+	return fmt.Sprintf("MultiSigSendTx{fee: %v, input: %v, outputs: %v}",
+		tx.Fee, tx.Input, tx.Outputs)
+}
+
 // --------------- Utils --------------- //
 
 // Need to add the following prefix to the tx signbytes to be compatible with
@@ -0,0 +1,76 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TxExtensionBase is the first type ID of the extension range. The types from TxExtensionBase up
+// are reserved for the transaction types registered with RegisterExtensionTx, e.g. by private
+// deployments, so that they never collide with the built-in types.
+const TxExtensionBase TxType = 0x8000
+
+// FeeTx is implemented by the extension transactions charging a fee, see TxFee.
+type FeeTx interface {
+	Tx
+	GetFee() Coins
+}
+
+var (
+	extensionTxsMutex = &sync.RWMutex{}
+	extensionTxs      = make(map[TxType]func() Tx)    // type ID -> constructor of an empty transaction
+	extensionTxTypes  = make(map[reflect.Type]TxType) // Go type -> type ID
+)
+
+// IsExtensionTxType returns whether the type ID is in the extension range
+func IsExtensionTxType(txType TxType) bool {
+	return txType >= TxExtensionBase
+}
+
+// RegisterExtensionTx registers a transaction type of the extension range, so that the transactions
+// of the type can be encoded and decoded. newTx returns an empty transaction of the type, which
+// needs to be a pointer, to decode the transactions into.
+func RegisterExtensionTx(txType TxType, newTx func() Tx) error {
+	if !IsExtensionTxType(txType) {
+		return fmt.Errorf("Tx type %v is not in the extension range, which starts at %v", txType, TxExtensionBase)
+	}
+	goType := reflect.TypeOf(newTx())
+	if goType == nil || goType.Kind() != reflect.Ptr {
+		return fmt.Errorf("The transactions of type %v need to be pointers", txType)
+	}
+
+	extensionTxsMutex.Lock()
+	defer extensionTxsMutex.Unlock()
+
+	if _, ok := extensionTxs[txType]; ok {
+		return fmt.Errorf("Tx type %v is already registered", txType)
+	}
+	if registered, ok := extensionTxTypes[goType]; ok {
+		return fmt.Errorf("%v is already registered as tx type %v", goType, registered)
+	}
+	extensionTxs[txType] = newTx
+	extensionTxTypes[goType] = txType
+	return nil
+}
+
+// newExtensionTx returns an empty transaction of the registered extension type
+func newExtensionTx(txType TxType) (Tx, bool) {
+	extensionTxsMutex.RLock()
+	defer extensionTxsMutex.RUnlock()
+
+	newTx, ok := extensionTxs[txType]
+	if !ok {
+		return nil, false
+	}
+	return newTx(), true
+}
+
+// getExtensionTxType returns the type ID of the registered extension transaction
+func getExtensionTxType(tx Tx) (TxType, bool) {
+	extensionTxsMutex.RLock()
+	defer extensionTxsMutex.RUnlock()
+
+	txType, ok := extensionTxTypes[reflect.TypeOf(tx)]
+	return txType, ok
+}
@@ -1,4 +1,4 @@
-package types
+package types_test
 
 import (
 	"encoding/hex"
@@ -11,8 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/thetatoken/theta/cmd/thetacli/cmd/utils"
-	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/fixtures"
+	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/rlp"
 )
 
@@ -20,22 +21,9 @@ var chainID string = "test_chain"
 
 func TestCoinbaseTxSignable(t *testing.T) {
 	chainID := "test_chain_id"
-	va1PrivAcc := PrivAccountFromSecret("validator1")
-
-	coinbaseTx := &CoinbaseTx{
-		Proposer: NewTxInput(va1PrivAcc.Address, NewCoins(0, 0), 1),
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: getTestAddress("validator1"),
-				Coins:   Coins{ThetaWei: big.NewInt(333), TFuelWei: big.NewInt(0)},
-			},
-			TxOutput{
-				Address: getTestAddress("validator1"),
-				Coins:   Coins{ThetaWei: big.NewInt(444), TFuelWei: big.NewInt(0)},
-			},
-		},
-		BlockHeight: 10,
-	}
+	va1PrivAcc := types.PrivAccountFromSecret("validator1")
+
+	coinbaseTx := fixtures.CoinbaseTx(va1PrivAcc.Address, fixtures.Address("validator1"), fixtures.Address("validator1"))
 	signBytes := coinbaseTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
 	expected := "F87F80808094000000000000000000000000000000000000000080B8648D746573745F636861696E5F696480F853DA94B23369B1225E72332462A75C1B7F509A805E3D6EC280800180F6DA9476616C696461746F723100000000000000000000C482014D80DA9476616C696461746F723100000000000000000000C48201BC800A"
@@ -48,27 +36,18 @@ func TestCoinbaseTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	va1PrivAcc := PrivAccountFromSecret("validator1")
-	va2PrivAcc := PrivAccountFromSecret("validator2")
+	va1PrivAcc := types.PrivAccountFromSecret("validator1")
+	va2PrivAcc := types.PrivAccountFromSecret("validator2")
 
 	// Construct a CoinbaseTx signature
-	tx := &CoinbaseTx{
-		Proposer: NewTxInput(va1PrivAcc.Address, NewCoins(0, 0), 1),
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: va2PrivAcc.PrivKey.PublicKey().Address(),
-				Coins:   Coins{ThetaWei: big.NewInt(8), TFuelWei: big.NewInt(0)},
-			},
-		},
-		BlockHeight: 10,
-	}
+	tx := fixtures.CoinbaseTx(va1PrivAcc.Address, va2PrivAcc.Address, va2PrivAcc.Address)
 	tx.Proposer.Signature = va1PrivAcc.Sign(tx.SignBytes(chainID))
 
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*CoinbaseTx)
+	tx2 := txs.(*types.CoinbaseTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -90,87 +69,20 @@ func TestCoinbaseTxProto(t *testing.T) {
 	tx2.SetSignature(va1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	assert.Equal(tx, tx2)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*CoinbaseTx)
+	tx2 = txs.(*types.CoinbaseTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx, tx2)
 	assert.False(tx2.Proposer.Signature.IsEmpty())
 }
 
-/*
-func TestCoinbaseTxRLP(t *testing.T) {
-	assert, require := assert.New(t), require.New(t)
-
-	chainID := "test_chain_id"
-	va1PrivAcc := PrivAccountFromSecret("validator1")
-	va2PrivAcc := PrivAccountFromSecret("validator2")
-
-	// Construct a CoinbaseTx signature
-	tx := &CoinbaseTx{
-		Proposer: NewTxInput(va1PrivAcc.PrivKey.PublicKey(), Coins{{"", 0}}, 1),
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: va2PrivAcc.PrivKey.PublicKey().Address(),
-				Coins:   Coins{{"foo", 8}},
-			},
-		},
-		BlockHeight: 10,
-	}
-	tx.Proposer.Signature = va1PrivAcc.Sign(tx.SignBytes(chainID))
-
-	b, err := rlp.EncodeToBytes(tx)
-	require.Nil(err)
-
-	var txs Tx
-	err = rlp.DecodeBytes(b, &txs)
-	require.Nil(err, &txs)
-	tx2 := txs.(*CoinbaseTx)
-
-	// make sure they are the same!
-	signBytes := tx.SignBytes(chainID)
-	signBytes2 := tx2.SignBytes(chainID)
-
-	fmt.Printf(">>>>> tx : %v\n", tx)
-	fmt.Printf(">>>>> tx2: %v\n", tx2)
-
-	fmt.Printf(">>>>> signBytes : %v\n", hex.EncodeToString(signBytes))
-	fmt.Printf(">>>>> signBytes2: %v\n", hex.EncodeToString(signBytes2))
-
-	//assert.Equal(signBytes, signBytes2)
-	assert.Equal(tx, tx2)
-
-	// // sign this thing
-	// sig := va1PrivAcc.Sign(signBytes)
-	// // we handle both raw sig and wrapped sig the same
-	// tx.SetSignature(va1PrivAcc.PrivKey.PublicKey().Address(), sig)
-	// tx2.SetSignature(va1PrivAcc.PrivKey.PublicKey().Address(), sig)
-	// assert.Equal(tx, &tx2)
-
-	// // let's marshal / unmarshal this with signature
-	// js, err = json.Marshal(tx)
-	// require.Nil(err)
-	// // fmt.Println(string(js))
-	// err = json.Unmarshal(js, &tx2)
-	// require.Nil(err)
-
-	// // and make sure the sig is preserved
-	// assert.Equal(tx, &tx2)
-	// assert.False(tx2.Proposer.Signature.IsEmpty())
-}
-*/
-
 func TestSlashTxSignable(t *testing.T) {
-	va1PrivAcc := PrivAccountFromSecret("validator1")
-	slashTx := &SlashTx{
-		Proposer:        NewTxInput(va1PrivAcc.Address, NewCoins(0, 0), 1),
-		SlashedAddress:  getTestAddress("014FAB"),
-		ReserveSequence: 1,
-		SlashProof:      []byte("2345ABC"),
-	}
+	va1PrivAcc := types.PrivAccountFromSecret("validator1")
+	slashTx := fixtures.SlashTx(va1PrivAcc.Address, fixtures.Address("014FAB"))
 	signBytes := slashTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
 	expected := "F86280808094000000000000000000000000000000000000000080B8478A746573745F636861696E01F839DA94B23369B1225E72332462A75C1B7F509A805E3D6EC280800180943031344641420000000000000000000000000000018732333435414243"
@@ -183,22 +95,17 @@ func TestSlashTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	va1PrivAcc := PrivAccountFromSecret("validator1")
+	va1PrivAcc := types.PrivAccountFromSecret("validator1")
 
 	// Construct a SlashTx signature
-	tx := &SlashTx{
-		Proposer:        NewTxInput(va1PrivAcc.Address, Coins{}, 1),
-		SlashedAddress:  getTestAddress("014FAB"),
-		ReserveSequence: 1,
-		SlashProof:      []byte("2345ABC"),
-	}
+	tx := fixtures.SlashTx(va1PrivAcc.Address, fixtures.Address("014FAB"))
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*SlashTx)
+	tx2 := txs.(*types.SlashTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -211,11 +118,11 @@ func TestSlashTxProto(t *testing.T) {
 	tx.SetSignature(va1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	tx2.SetSignature(va1PrivAcc.PrivKey.PublicKey().Address(), sig)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*SlashTx)
+	tx2 = txs.(*types.SlashTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx.Proposer.Signature, tx2.Proposer.Signature)
@@ -223,31 +130,8 @@ func TestSlashTxProto(t *testing.T) {
 }
 
 func TestSendTxSignable(t *testing.T) {
-	sendTx := &SendTx{
-		Fee: Coins{ThetaWei: big.NewInt(111), TFuelWei: big.NewInt(0)},
-		Inputs: []TxInput{
-			TxInput{
-				Address:  getTestAddress("input1"),
-				Coins:    Coins{ThetaWei: big.NewInt(12345)},
-				Sequence: 67890,
-			},
-			TxInput{
-				Address:  getTestAddress("input2"),
-				Coins:    Coins{ThetaWei: big.NewInt(111), TFuelWei: big.NewInt(0)},
-				Sequence: 222,
-			},
-		},
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: getTestAddress("output1"),
-				Coins:   Coins{ThetaWei: big.NewInt(333), TFuelWei: big.NewInt(0)},
-			},
-			TxOutput{
-				Address: getTestAddress("output2"),
-				Coins:   Coins{ThetaWei: big.NewInt(444), TFuelWei: big.NewInt(0)},
-			},
-		},
-	}
+	sendTx := fixtures.SendTx(fixtures.Address("input1"), fixtures.Address("input2"),
+		fixtures.Address("output1"), fixtures.Address("output2"))
 	signBytes := sendTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
 	expected := "F8A180808094000000000000000000000000000000000000000080B8868A746573745F636861696E02F878C26F80F83CDF94696E707574310000000000000000000000000000C4823039808301093280DB94696E707574320000000000000000000000000000C26F8081DE80F6DA946F75747075743100000000000000000000000000C482014D80DA946F75747075743200000000000000000000000000C48201BC80"
@@ -258,29 +142,9 @@ func TestSendTxSignable(t *testing.T) {
 
 func TestSendTxSignable2(t *testing.T) {
 	chainID := "privatenet"
-	ten18 := new(big.Int).SetUint64(1000000000000000000) // 10^18
-	thetaWei := new(big.Int).Mul(new(big.Int).SetUint64(10), ten18)
-	tfuelWei := new(big.Int).Mul(new(big.Int).SetUint64(20), ten18)
-	feeInTFuelWei := new(big.Int).SetUint64(1000000000000) // 10^12
-
-	senderAddr := common.HexToAddress("2E833968E5bB786Ae419c4d13189fB081Cc43bab")
-	receiverAddr := common.HexToAddress("9F1233798E905E173560071255140b4A8aBd3Ec6")
-	sendTx := &SendTx{
-		Fee: Coins{ThetaWei: big.NewInt(0), TFuelWei: feeInTFuelWei},
-		Inputs: []TxInput{
-			TxInput{
-				Address:  senderAddr,
-				Coins:    Coins{ThetaWei: thetaWei, TFuelWei: new(big.Int).Add(tfuelWei, feeInTFuelWei)},
-				Sequence: 2,
-			},
-		},
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: receiverAddr,
-				Coins:   Coins{ThetaWei: thetaWei, TFuelWei: tfuelWei},
-			},
-		},
-	}
+	sendTx := fixtures.PrivatenetSendTx()
+	senderAddr := sendTx.Inputs[0].Address
+
 	signBytes := sendTx.SignBytes(chainID)
 	signBytesHex := hex.EncodeToString(signBytes)
 	expected := "f88980808094000000000000000000000000000000000000000080b86e8a707269766174656e657402f860c78085e8d4a51000eceb942e833968e5bb786ae419c4d13189fb081cc43babd3888ac7230489e800008901158e46f1e87510000280eae9949f1233798e905e173560071255140b4a8abd3ec6d3888ac7230489e800008901158e460913d00000"
@@ -314,7 +178,7 @@ func TestSendTxSignable2(t *testing.T) {
 	outputs0CoinsEncoded, _ := rlp.EncodeToBytes(sendTx.Outputs[0].Coins)
 	t.Logf("sendTx.Outputs[0].Coins : %v", hex.EncodeToString(outputs0CoinsEncoded))
 
-	senderSkBytes, _ := hex.DecodeString("93a90ea508331dfdf27fb79757d4250b4e84954927ba0073cd67454ac432c737")
+	senderSkBytes, _ := hex.DecodeString(fixtures.PrivatenetSenderKey)
 	senderPrivKey, _ := crypto.PrivateKeyFromBytes(senderSkBytes)
 	senderSignature, _ := senderPrivKey.Sign(signBytes)
 
@@ -323,7 +187,7 @@ func TestSendTxSignable2(t *testing.T) {
 
 	sendTx.SetSignature(senderAddr, senderSignature)
 
-	raw, err := TxToBytes(sendTx)
+	raw, err := types.TxToBytes(sendTx)
 	if err != nil {
 		utils.Error("Failed to encode transaction: %v\n", err)
 	}
@@ -342,29 +206,18 @@ func TestSendTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	test1PrivAcc := PrivAccountFromSecret("sendtx1")
-	test2PrivAcc := PrivAccountFromSecret("sendtx2")
+	test1PrivAcc := types.PrivAccountFromSecret("sendtx1")
+	test2PrivAcc := types.PrivAccountFromSecret("sendtx2")
 
 	// Construct a SendTx signature
-	tx := &SendTx{
-		Fee: Coins{TFuelWei: big.NewInt(2)},
-		Inputs: []TxInput{
-			NewTxInput(test1PrivAcc.Address, Coins{ThetaWei: big.NewInt(0), TFuelWei: big.NewInt(10)}, 1),
-		},
-		Outputs: []TxOutput{
-			TxOutput{
-				Address: test2PrivAcc.Address,
-				Coins:   Coins{ThetaWei: big.NewInt(0), TFuelWei: big.NewInt(8)},
-			},
-		},
-	}
+	tx := fixtures.SendTx(test1PrivAcc.Address, test2PrivAcc.Address, test2PrivAcc.Address, test1PrivAcc.Address)
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*SendTx)
+	tx2 := txs.(*types.SendTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -377,11 +230,11 @@ func TestSendTxProto(t *testing.T) {
 	tx.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	tx2.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*SendTx)
+	tx2 = txs.(*types.SendTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx.Inputs[0].Signature, tx2.Inputs[0].Signature)
@@ -389,17 +242,7 @@ func TestSendTxProto(t *testing.T) {
 }
 
 func TestReserveFundTxSignable(t *testing.T) {
-	reserveFundTx := &ReserveFundTx{
-		Fee: Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source: TxInput{
-			Address:  getTestAddress("input1"),
-			Coins:    Coins{ThetaWei: Zero, TFuelWei: big.NewInt(12345)},
-			Sequence: 67890,
-		},
-		Collateral:  Coins{ThetaWei: Zero, TFuelWei: big.NewInt(22897)},
-		ResourceIDs: []string{"rid00123"},
-		Duration:    uint64(999),
-	}
+	reserveFundTx := fixtures.ReserveFundTx(fixtures.Address("input1"))
 
 	signBytes := reserveFundTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
@@ -413,23 +256,17 @@ func TestReserveFundTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	test1PrivAcc := PrivAccountFromSecret("reservefundtx")
+	test1PrivAcc := types.PrivAccountFromSecret("reservefundtx")
 
 	// Construct a ReserveFundTx transaction
-	tx := &ReserveFundTx{
-		Fee:         Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source:      NewTxInput(test1PrivAcc.Address, Coins{ThetaWei: Zero, TFuelWei: big.NewInt(10)}, 1),
-		Collateral:  Coins{ThetaWei: Zero, TFuelWei: big.NewInt(22897)},
-		ResourceIDs: []string{"rid00123"},
-		Duration:    uint64(999),
-	}
+	tx := fixtures.ReserveFundTx(test1PrivAcc.Address)
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*ReserveFundTx)
+	tx2 := txs.(*types.ReserveFundTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -442,11 +279,11 @@ func TestReserveFundTxProto(t *testing.T) {
 	tx.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	tx2.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*ReserveFundTx)
+	tx2 = txs.(*types.ReserveFundTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx.Source.Signature, tx2.Source.Signature)
@@ -454,15 +291,7 @@ func TestReserveFundTxProto(t *testing.T) {
 }
 
 func TestReleaseFundTxSignable(t *testing.T) {
-	releaseFundTx := &ReleaseFundTx{
-		Fee: Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source: TxInput{
-			Address:  getTestAddress("input1"),
-			Coins:    Coins{ThetaWei: Zero, TFuelWei: big.NewInt(12345)},
-			Sequence: 67890,
-		},
-		ReserveSequence: 12,
-	}
+	releaseFundTx := fixtures.ReleaseFundTx(fixtures.Address("input1"))
 
 	signBytes := releaseFundTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
@@ -476,21 +305,17 @@ func TestReleaseFundTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	test1PrivAcc := PrivAccountFromSecret("releasefundtx")
+	test1PrivAcc := types.PrivAccountFromSecret("releasefundtx")
 
-	// Construct a ReserveFundTx transaction
-	tx := &ReleaseFundTx{
-		Fee:             Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source:          NewTxInput(test1PrivAcc.Address, Coins{ThetaWei: Zero, TFuelWei: big.NewInt(10)}, 1),
-		ReserveSequence: 1,
-	}
+	// Construct a ReleaseFundTx transaction
+	tx := fixtures.ReleaseFundTx(test1PrivAcc.Address)
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*ReleaseFundTx)
+	tx2 := txs.(*types.ReleaseFundTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -503,11 +328,11 @@ func TestReleaseFundTxProto(t *testing.T) {
 	tx.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	tx2.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*ReleaseFundTx)
+	tx2 = txs.(*types.ReleaseFundTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx.Source.Signature, tx2.Source.Signature)
@@ -515,22 +340,7 @@ func TestReleaseFundTxProto(t *testing.T) {
 }
 
 func TestServicePaymentTxSourceSignable(t *testing.T) {
-	servicePaymentTx := &ServicePaymentTx{
-		Fee: Coins{TFuelWei: big.NewInt(111)},
-		Source: TxInput{
-			Address:  getTestAddress("source"),
-			Coins:    Coins{ThetaWei: Zero, TFuelWei: big.NewInt(12345)},
-			Sequence: 67890,
-		},
-		Target: TxInput{
-			Address:  getTestAddress("target"),
-			Coins:    NewCoins(0, 0),
-			Sequence: 22341,
-		},
-		PaymentSequence: 3,
-		ReserveSequence: 12,
-		ResourceID:      "rid00123",
-	}
+	servicePaymentTx := fixtures.ServicePaymentTx(fixtures.Address("source"), fixtures.Address("target"))
 
 	signBytes := servicePaymentTx.SourceSignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
@@ -541,22 +351,7 @@ func TestServicePaymentTxSourceSignable(t *testing.T) {
 }
 
 func TestServicePaymentTxTargetSignable(t *testing.T) {
-	servicePaymentTx := &ServicePaymentTx{
-		Fee: Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source: TxInput{
-			Address:  getTestAddress("source"),
-			Coins:    Coins{ThetaWei: Zero, TFuelWei: big.NewInt(12345)},
-			Sequence: 67890,
-		},
-		Target: TxInput{
-			Address:  getTestAddress("target"),
-			Coins:    NewCoins(0, 0),
-			Sequence: 22341,
-		},
-		PaymentSequence: 3,
-		ReserveSequence: 12,
-		ResourceID:      "rid00123",
-	}
+	servicePaymentTx := fixtures.ServicePaymentTx(fixtures.Address("source"), fixtures.Address("target"))
 
 	signBytes := servicePaymentTx.TargetSignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
@@ -570,25 +365,18 @@ func TestServicePaymentTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	sourcePrivAcc := PrivAccountFromSecret("servicepaymenttxsource")
-	targetPrivAcc := PrivAccountFromSecret("servicepaymenttxtarget")
-
-	// Construct a ReserveFundTx signature
-	tx := &ServicePaymentTx{
-		Fee:             Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		Source:          NewTxInput(sourcePrivAcc.Address, Coins{ThetaWei: Zero, TFuelWei: big.NewInt(10000)}, 1),
-		Target:          NewTxInput(targetPrivAcc.Address, NewCoins(0, 0), 1),
-		PaymentSequence: 3,
-		ReserveSequence: 12,
-		ResourceID:      "rid00123",
-	}
+	sourcePrivAcc := types.PrivAccountFromSecret("servicepaymenttxsource")
+	targetPrivAcc := types.PrivAccountFromSecret("servicepaymenttxtarget")
+
+	// Construct a ServicePaymentTx transaction
+	tx := fixtures.ServicePaymentTx(sourcePrivAcc.Address, targetPrivAcc.Address)
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*ServicePaymentTx)
+	tx2 := txs.(*types.ServicePaymentTx)
 
 	// make sure they are the same!
 	sourceSignBytes := tx.SourceSignBytes(chainID)
@@ -601,21 +389,7 @@ func TestServicePaymentTxProto(t *testing.T) {
 }
 
 func TestSplitRuleTxSignable(t *testing.T) {
-	split := Split{
-		Address:    getTestAddress("splitaddr1"),
-		Percentage: 30,
-	}
-	splitRuleTx := &SplitRuleTx{
-		Fee:        Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		ResourceID: "rid00123",
-		Initiator: TxInput{
-			Address:  getTestAddress("source"),
-			Coins:    Coins{ThetaWei: Zero, TFuelWei: big.NewInt(12345)},
-			Sequence: 67890,
-		},
-		Splits:   []Split{split},
-		Duration: 99,
-	}
+	splitRuleTx := fixtures.SplitRuleTx(fixtures.Address("source"), fixtures.Address("splitaddr1"))
 
 	signBytes := splitRuleTx.SignBytes(chainID)
 	signBytesHex := fmt.Sprintf("%X", signBytes)
@@ -629,27 +403,17 @@ func TestSplitRuleTxProto(t *testing.T) {
 	assert, require := assert.New(t), require.New(t)
 
 	chainID := "test_chain_id"
-	test1PrivAcc := PrivAccountFromSecret("splitruletx")
+	test1PrivAcc := types.PrivAccountFromSecret("splitruletx")
 
 	// Construct a SplitRuleTx signature
-	split := Split{
-		Address:    getTestAddress("splitaddr1"),
-		Percentage: 30,
-	}
-	tx := &SplitRuleTx{
-		Fee:        Coins{ThetaWei: Zero, TFuelWei: big.NewInt(111)},
-		ResourceID: "rid00123",
-		Initiator:  NewTxInput(test1PrivAcc.Address, Coins{ThetaWei: Zero, TFuelWei: big.NewInt(10)}, 1),
-		Splits:     []Split{split},
-		Duration:   99,
-	}
+	tx := fixtures.SplitRuleTx(test1PrivAcc.Address, fixtures.Address("splitaddr1"))
 
 	// serialize this and back
-	b, err := TxToBytes(tx)
+	b, err := types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err := TxFromBytes(b)
+	txs, err := types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 := txs.(*SplitRuleTx)
+	tx2 := txs.(*types.SplitRuleTx)
 
 	// make sure they are the same!
 	signBytes := tx.SignBytes(chainID)
@@ -662,11 +426,11 @@ func TestSplitRuleTxProto(t *testing.T) {
 	tx.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 	tx2.SetSignature(test1PrivAcc.PrivKey.PublicKey().Address(), sig)
 
-	b, err = TxToBytes(tx)
+	b, err = types.TxToBytes(tx)
 	require.Nil(err)
-	txs, err = TxFromBytes(b)
+	txs, err = types.TxFromBytes(b)
 	require.Nil(err)
-	tx2 = txs.(*SplitRuleTx)
+	tx2 = txs.(*types.SplitRuleTx)
 
 	// and make sure the sig is preserved
 	assert.Equal(tx.Initiator.Signature, tx2.Initiator.Signature)
@@ -677,13 +441,13 @@ func TestCoinbaseTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := CoinbaseTx{
+	a := types.CoinbaseTx{
 		BlockHeight: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d CoinbaseTx
+	var d types.CoinbaseTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.BlockHeight)
@@ -693,13 +457,13 @@ func TestSlashTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := SlashTx{
+	a := types.SlashTx{
 		ReserveSequence: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d SlashTx
+	var d types.SlashTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
@@ -709,13 +473,13 @@ func TestReserveFundTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := ReserveFundTx{
+	a := types.ReserveFundTx{
 		Duration: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d ReserveFundTx
+	var d types.ReserveFundTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.Duration)
@@ -725,13 +489,13 @@ func TestReleaseFundTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := ReleaseFundTx{
+	a := types.ReleaseFundTx{
 		ReserveSequence: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d ReleaseFundTx
+	var d types.ReleaseFundTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
@@ -741,13 +505,13 @@ func TestServicePaymentTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := ServicePaymentTx{
+	a := types.ServicePaymentTx{
 		ReserveSequence: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d ServicePaymentTx
+	var d types.ServicePaymentTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.ReserveSequence)
@@ -757,13 +521,13 @@ func TestSplitRuleTxJSON(t *testing.T) {
 	assert := assert.New(t)
 	require := require.New(t)
 
-	a := SplitRuleTx{
+	a := types.SplitRuleTx{
 		Duration: math.MaxUint64,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d SplitRuleTx
+	var d types.SplitRuleTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.Duration)
@@ -774,14 +538,14 @@ func TestSmartContractTxJSON(t *testing.T) {
 	require := require.New(t)
 
 	gasPrice, _ := new(big.Int).SetString("12312312312312312312331231231231212312312312312313213", 10)
-	a := SmartContractTx{
+	a := types.SmartContractTx{
 		GasLimit: math.MaxUint64,
 		GasPrice: gasPrice,
 	}
 	s, err := json.Marshal(a)
 	require.Nil(err)
 
-	var d SmartContractTx
+	var d types.SmartContractTx
 	err = json.Unmarshal(s, &d)
 	require.Nil(err)
 	assert.Equal(uint64(math.MaxUint64), d.GasLimit)
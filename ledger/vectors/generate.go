@@ -0,0 +1,413 @@
+package vectors
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// privatenetSenderKey is the key signing the send transaction of the privatenet vector, which
+// the wallets use to check their own encoding.
+const privatenetSenderKey = "93a90ea508331dfdf27fb79757d4250b4e84954927ba0073cd67454ac432c737"
+
+// signers holds the fixed keys of the generated vectors
+type signers map[common.Address]*crypto.PrivateKey
+
+func (s signers) key(name string) common.Address {
+	privKey, err := crypto.PrivateKeyFromBytes(crypto.Keccak256([]byte("vectors/" + name)))
+	if err != nil {
+		panic(err)
+	}
+	return s.add(privKey)
+}
+
+func (s signers) add(privKey *crypto.PrivateKey) common.Address {
+	address := privKey.PublicKey().Address()
+	s[address] = privKey
+	return address
+}
+
+func (s signers) sign(address common.Address, signBytes common.Bytes) (*crypto.Signature, error) {
+	privKey, ok := s[address]
+	if !ok {
+		return nil, fmt.Errorf("No key for signer %v", address.Hex())
+	}
+	return privKey.Sign(signBytes)
+}
+
+// txVector is a transaction of the corpus, built unsigned
+type txVector struct {
+	name    string
+	chainID string
+	build   func(s signers) types.Tx
+}
+
+// Generate returns the vectors of the current versions, as encoded by this release.
+func Generate() ([]Vector, error) {
+	s := signers{}
+	vectors := []Vector{}
+	for _, tv := range txVectors() {
+		v, err := generateTx(s, tv)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to generate vector %v: %v", tv.name, err)
+		}
+		vectors = append(vectors, v)
+	}
+	for _, version := range []uint64{core.BlockHeaderVersion1, core.BlockHeaderVersion2} {
+		v, err := generateBlockHeader(s, version)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to generate block header vector v%v: %v", version, err)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+func generateTx(s signers, tv txVector) (Vector, error) {
+	tx := tv.build(s)
+
+	// Sign in order, since a later signature may cover the earlier ones, e.g. the target of a
+	// service payment signs over the signature of the source, and the fee payer over all of them.
+	inputs, err := txSigners(tv.chainID, tx)
+	if err != nil {
+		return Vector{}, err
+	}
+	for i := range inputs {
+		inputs, _ = txSigners(tv.chainID, tx)
+		sig, err := s.sign(inputs[i].address, inputs[i].signBytes)
+		if err != nil {
+			return Vector{}, err
+		}
+		inputs[i].sign(sig)
+	}
+
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return Vector{}, err
+	}
+	inputs, _ = txSigners(tv.chainID, tx)
+	v := Vector{
+		Name:    tv.name,
+		Kind:    KindTx,
+		Version: TxEncodingVersion,
+		ChainID: tv.chainID,
+		Raw:     raw,
+		Hash:    crypto.Keccak256Hash(raw),
+	}
+	for _, input := range inputs {
+		v.Signers = append(v.Signers, Signer{Address: input.address, SignBytes: hexutil.Bytes(input.signBytes)})
+	}
+	return v, nil
+}
+
+func generateBlockHeader(s signers, version uint64) (Vector, error) {
+	header := &core.BlockHeader{
+		ChainID:   core.MainnetChainID,
+		Epoch:     12,
+		Height:    10,
+		Parent:    common.HexToHash("0x8f4b7e7e8c4e2f2f3c3d3e3f404142434445464748494a4b4c4d4e4f50515253"),
+		HCC:       core.CommitCertificate{BlockHash: common.HexToHash("0x8f4b7e7e8c4e2f2f3c3d3e3f404142434445464748494a4b4c4d4e4f50515253")},
+		TxHash:    core.EmptyRootHash,
+		StateHash: common.HexToHash("0x1b7d8a2c3e4f5061728394a5b6c7d8e9f0a1b2c3d4e5f60718293a4b5c6d7e8f"),
+		Timestamp: big.NewInt(1546300800),
+		Proposer:  s.key("proposer"),
+	}
+	if version >= core.BlockHeaderVersion2 {
+		header.Version = version
+		header.ExtraData = common.Bytes("golden vector extra data")
+	}
+	sig, err := s.sign(header.Proposer, header.SignBytes())
+	if err != nil {
+		return Vector{}, err
+	}
+	header.SetSignature(sig)
+
+	raw, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return Vector{}, err
+	}
+	return Vector{
+		Name:    "block_header",
+		Kind:    KindBlockHeader,
+		Version: version,
+		ChainID: header.ChainID,
+		Raw:     raw,
+		Hash:    header.Hash(),
+		Signers: []Signer{{Address: header.Proposer, SignBytes: hexutil.Bytes(header.SignBytes())}},
+	}, nil
+}
+
+func coins(theta, tfuel int64) types.Coins {
+	return types.NewCoins(theta, tfuel)
+}
+
+func fee() types.Coins {
+	return coins(0, int64(types.MinimumTransactionFeeTFuelWei))
+}
+
+func txVectors() []txVector {
+	chainID := core.MainnetChainID
+	return []txVector{
+		{"coinbase_tx", chainID, func(s signers) types.Tx {
+			return &types.CoinbaseTx{
+				Proposer: types.TxInput{Address: s.key("proposer")},
+				Outputs: []types.TxOutput{
+					{Address: s.key("validator1"), Coins: coins(0, 48000000000000000)},
+					{Address: s.key("validator2"), Coins: coins(0, 48000000000000000)},
+				},
+				BlockHeight: 10,
+			}
+		}},
+		{"slash_tx", chainID, func(s signers) types.Tx {
+			return &types.SlashTx{
+				Proposer:        types.TxInput{Address: s.key("proposer")},
+				SlashedAddress:  s.key("source"),
+				ReserveSequence: 3,
+				SlashProof:      common.Bytes("overspending proof"),
+			}
+		}},
+		{"send_tx", chainID, func(s signers) types.Tx {
+			return &types.SendTx{
+				Fee: fee(),
+				Inputs: []types.TxInput{
+					{Address: s.key("source"), Coins: coins(10, 1000+int64(types.MinimumTransactionFeeTFuelWei)), Sequence: 1},
+					{Address: s.key("source2"), Coins: coins(5, 0), Sequence: 7},
+				},
+				Outputs: []types.TxOutput{
+					{Address: s.key("target"), Coins: coins(15, 1000)},
+				},
+			}
+		}},
+		{"send_tx_memo", chainID, func(s signers) types.Tx {
+			memoFee := coins(0, 0)
+			memoFee.TFuelWei = types.SendTxMinimumFee(len("deposit 12345"))
+			return &types.SendTx{
+				Fee: memoFee,
+				Inputs: []types.TxInput{
+					{Address: s.key("source"), Coins: coins(10, 0).Plus(memoFee), Sequence: 2},
+				},
+				Outputs: []types.TxOutput{
+					{Address: s.key("target"), Coins: coins(10, 0)},
+				},
+				Memo: common.Bytes("deposit 12345"),
+			}
+		}},
+		{"send_tx_privatenet", core.PrivatenetChainID, func(s signers) types.Tx {
+			skBytes, _ := hex.DecodeString(privatenetSenderKey)
+			privKey, _ := crypto.PrivateKeyFromBytes(skBytes)
+			ten18 := new(big.Int).SetUint64(1000000000000000000)
+			thetaWei := new(big.Int).Mul(new(big.Int).SetUint64(10), ten18)
+			tfuelWei := new(big.Int).Mul(new(big.Int).SetUint64(20), ten18)
+			feeInTFuelWei := new(big.Int).SetUint64(1000000000000)
+			return &types.SendTx{
+				Fee: types.Coins{ThetaWei: big.NewInt(0), TFuelWei: feeInTFuelWei},
+				Inputs: []types.TxInput{
+					{
+						Address:  s.add(privKey),
+						Coins:    types.Coins{ThetaWei: thetaWei, TFuelWei: new(big.Int).Add(tfuelWei, feeInTFuelWei)},
+						Sequence: 2,
+					},
+				},
+				Outputs: []types.TxOutput{
+					{
+						Address: common.HexToAddress("9F1233798E905E173560071255140b4A8aBd3Ec6"),
+						Coins:   types.Coins{ThetaWei: thetaWei, TFuelWei: tfuelWei},
+					},
+				},
+			}
+		}},
+		{"reserve_fund_tx", chainID, func(s signers) types.Tx {
+			return &types.ReserveFundTx{
+				Fee:         fee(),
+				Source:      types.TxInput{Address: s.key("source"), Coins: coins(0, 1000), Sequence: 2},
+				Collateral:  coins(0, 1001),
+				ResourceIDs: []string{"rid001", "rid002"},
+				Duration:    types.MinimumFundReserveDuration,
+			}
+		}},
+		{"release_fund_tx", chainID, func(s signers) types.Tx {
+			return &types.ReleaseFundTx{
+				Fee:             fee(),
+				Source:          types.TxInput{Address: s.key("source"), Sequence: 3},
+				ReserveSequence: 2,
+			}
+		}},
+		{"service_payment_tx", chainID, func(s signers) types.Tx {
+			return &types.ServicePaymentTx{
+				Fee:             fee(),
+				Source:          types.TxInput{Address: s.key("source"), Coins: coins(0, 100)},
+				Target:          types.TxInput{Address: s.key("target"), Sequence: 1},
+				PaymentSequence: 1,
+				ReserveSequence: 2,
+				ResourceID:      "rid001",
+			}
+		}},
+		{"split_rule_tx", chainID, func(s signers) types.Tx {
+			return &types.SplitRuleTx{
+				Fee:        fee(),
+				ResourceID: "rid001",
+				Initiator:  types.TxInput{Address: s.key("source"), Sequence: 4},
+				Splits: []types.Split{
+					{Address: s.key("target"), Percentage: 30},
+					{Address: s.key("source2"), Percentage: 20},
+				},
+				Duration: 1000,
+			}
+		}},
+		{"smart_contract_tx", chainID, func(s signers) types.Tx {
+			return &types.SmartContractTx{
+				From:     types.TxInput{Address: s.key("source"), Coins: coins(0, 0), Sequence: 5},
+				To:       types.TxOutput{Address: s.key("contract")},
+				GasLimit: 100000,
+				GasPrice: big.NewInt(1000000000000),
+				Data:     common.Hex2Bytes("a9059cbb"),
+			}
+		}},
+		{"deposit_stake_tx", chainID, func(s signers) types.Tx {
+			return &types.DepositStakeTx{
+				Fee:     fee(),
+				Source:  types.TxInput{Address: s.key("source"), Coins: types.Coins{ThetaWei: core.MinValidatorStakeDeposit, TFuelWei: big.NewInt(0)}, Sequence: 6},
+				Holder:  types.TxOutput{Address: s.key("validator1")},
+				Purpose: core.StakeForValidator,
+			}
+		}},
+		{"withdraw_stake_tx", chainID, func(s signers) types.Tx {
+			return &types.WithdrawStakeTx{
+				Fee:     fee(),
+				Source:  types.TxInput{Address: s.key("source"), Sequence: 7},
+				Holder:  types.TxOutput{Address: s.key("validator1")},
+				Purpose: core.StakeForValidator,
+			}
+		}},
+		{"set_account_operator_tx", chainID, func(s signers) types.Tx {
+			return &types.SetAccountOperatorTx{
+				Fee:        fee(),
+				Account:    types.TxInput{Address: s.key("source"), Sequence: 8},
+				Operator:   s.key("operator"),
+				SpendLimit: coins(0, 5000),
+			}
+		}},
+		{"service_payment_dispute_tx", chainID, func(s signers) types.Tx {
+			return &types.ServicePaymentDisputeTx{
+				Fee:    fee(),
+				Source: types.TxInput{Address: s.key("source"), Sequence: 9},
+				Proof: types.ServicePaymentTx{
+					Fee:             fee(),
+					Source:          types.TxInput{Address: s.key("source"), Coins: coins(0, 200)},
+					Target:          types.TxInput{Address: s.key("target"), Sequence: 2},
+					PaymentSequence: 2,
+					ReserveSequence: 2,
+					ResourceID:      "rid001",
+				},
+			}
+		}},
+		{"register_node_address_tx", chainID, func(s signers) types.Tx {
+			return &types.RegisterNodeAddressTx{
+				Fee:       fee(),
+				Validator: types.TxInput{Address: s.key("validator1"), Sequence: 1},
+				Endpoints: []string{"10.0.0.1:30001", "validator1.thetatoken.org:30001"},
+			}
+		}},
+		{"slash_review_tx", chainID, func(s signers) types.Tx {
+			return &types.SlashReviewTx{
+				Fee: fee(),
+				Inputs: []types.TxInput{
+					{Address: s.key("validator1"), Sequence: 2},
+					{Address: s.key("validator2"), Sequence: 5},
+				},
+				RecordID: common.BytesToHash([]byte("slash record")),
+				Confirm:  true,
+			}
+		}},
+		{"multi_send_tx", chainID, func(s signers) types.Tx {
+			multiSendFee := coins(0, 0)
+			multiSendFee.TFuelWei = types.MultiSendMinimumFee(2)
+			return &types.MultiSendTx{
+				Fee: multiSendFee,
+				Inputs: []types.TxInput{
+					{Address: s.key("source"), Coins: coins(10, 1000).Plus(multiSendFee), Sequence: 3},
+				},
+				Outputs: []types.MultiSendOutput{
+					{Address: s.key("target"), Coins: coins(10, 0), Memo: common.Bytes("payout 0001")},
+					{Address: s.key("target2"), Coins: coins(0, 1000)},
+				},
+			}
+		}},
+		{"send_tx_expiry", chainID, func(s signers) types.Tx {
+			tx := &types.SendTx{
+				Fee: fee(),
+				Inputs: []types.TxInput{
+					{Address: s.key("source"), Coins: coins(10, int64(types.MinimumTransactionFeeTFuelWei)), Sequence: 4},
+				},
+				Outputs: []types.TxOutput{
+					{Address: s.key("target"), Coins: coins(10, 0)},
+				},
+			}
+			tx.SetExpiryHeight(1000)
+			return tx
+		}},
+		{"send_tx_fee_payer", chainID, func(s signers) types.Tx {
+			tx := &types.SendTx{
+				Fee: fee(),
+				Inputs: []types.TxInput{
+					{Address: s.key("source"), Coins: coins(10, 0), Sequence: 5},
+				},
+				Outputs: []types.TxOutput{
+					{Address: s.key("target"), Coins: coins(10, 0)},
+				},
+			}
+			tx.SetFeePayer(&types.FeePayer{Address: s.key("fee_payer")})
+			return tx
+		}},
+		{"smart_contract_tx_expiry_fee_payer", chainID, func(s signers) types.Tx {
+			tx := &types.SmartContractTx{
+				From:     types.TxInput{Address: s.key("source"), Coins: coins(0, 0), Sequence: 6},
+				To:       types.TxOutput{Address: s.key("contract")},
+				GasLimit: 100000,
+				GasPrice: big.NewInt(1000000000000),
+				Data:     common.Hex2Bytes("a9059cbb"),
+			}
+			tx.SetExpiryHeight(1000)
+			tx.SetFeePayer(&types.FeePayer{Address: s.key("fee_payer")})
+			return tx
+		}},
+		{"update_account_signers_tx", chainID, func(s signers) types.Tx {
+			// The account has no signer set yet, so that it is still controlled by its own key
+			return &types.UpdateAccountSignersTx{
+				Fee: fee(),
+				Account: types.MultiSigInput{
+					Address:    s.key("multisig"),
+					Coins:      fee(),
+					Sequence:   1,
+					Signatures: []types.SignerSignature{{Signer: s.key("multisig")}},
+				},
+				Signers:   []common.Address{s.key("signer1"), s.key("signer2"), s.key("signer3")},
+				Threshold: 2,
+			}
+		}},
+		{"multi_sig_send_tx", chainID, func(s signers) types.Tx {
+			return &types.MultiSigSendTx{
+				Fee: fee(),
+				Input: types.MultiSigInput{
+					Address:  s.key("multisig"),
+					Coins:    coins(10, 1000).Plus(fee()),
+					Sequence: 2,
+					Signatures: []types.SignerSignature{
+						{Signer: s.key("signer1")},
+						{Signer: s.key("signer3")},
+					},
+				},
+				Outputs: []types.TxOutput{
+					{Address: s.key("target"), Coins: coins(10, 1000)},
+				},
+			}
+		}},
+	}
+}
@@ -0,0 +1,311 @@
+// Package vectors maintains the golden vectors of the signed objects, i.e. the exact encodings,
+// sign bytes and hashes of sample transactions and block headers per protocol version. Every
+// release must decode the vectors of all the versions it supports, and reproduce their sign
+// bytes and hashes bit for bit, otherwise it would fork from the nodes running older releases.
+package vectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+const (
+	// KindTx is the kind of the transaction vectors
+	KindTx = "tx"
+
+	// KindBlockHeader is the kind of the block header vectors
+	KindBlockHeader = "block_header"
+)
+
+// TxEncodingVersion is the version of the encoding and of the sign bytes of the transactions.
+// A release changing either has to bump it, and keep supporting the vectors of the older versions.
+const TxEncodingVersion uint64 = 1
+
+// Signer is a signature carried by a vector, along with the bytes it signs.
+type Signer struct {
+	Address   common.Address `json:"address"`
+	SignBytes hexutil.Bytes  `json:"sign_bytes"`
+}
+
+// Vector is a signed object in its encoded form, along with the sign bytes and the hash the
+// release must derive from it.
+type Vector struct {
+	Name    string        `json:"name"`
+	Kind    string        `json:"kind"`
+	Version uint64        `json:"version"`
+	ChainID string        `json:"chain_id"`
+	Raw     hexutil.Bytes `json:"raw"`
+	Hash    common.Hash   `json:"hash"`
+	Signers []Signer      `json:"signers"`
+}
+
+func (v *Vector) key() string {
+	return fmt.Sprintf("%v/%v/v%v", v.Kind, v.Name, v.Version)
+}
+
+// Check verifies the release decodes the vector, re-encodes it to the same bytes, and derives
+// the same hash and sign bytes, and that the signatures are valid.
+func (v *Vector) Check() error {
+	var hash common.Hash
+	var signers []signedInput
+	var err error
+	switch v.Kind {
+	case KindTx:
+		hash, signers, err = v.decodeTx()
+	case KindBlockHeader:
+		hash, signers, err = v.decodeBlockHeader()
+	default:
+		err = fmt.Errorf("unknown kind %v", v.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("Vector %v: %v", v.key(), err)
+	}
+
+	if hash != v.Hash {
+		return fmt.Errorf("Vector %v: hash mismatch, expected %v, got %v", v.key(), v.Hash.Hex(), hash.Hex())
+	}
+	if len(signers) != len(v.Signers) {
+		return fmt.Errorf("Vector %v: expected %v signatures, got %v", v.key(), len(v.Signers), len(signers))
+	}
+	for i, signer := range signers {
+		expected := v.Signers[i]
+		if signer.address != expected.Address {
+			return fmt.Errorf("Vector %v: signer #%v mismatch, expected %v, got %v", v.key(), i, expected.Address.Hex(), signer.address.Hex())
+		}
+		if !bytes.Equal(signer.signBytes, expected.SignBytes) {
+			return fmt.Errorf("Vector %v: sign bytes of %v mismatch, expected %v, got %v",
+				v.key(), signer.address.Hex(), expected.SignBytes, hexutil.Bytes(signer.signBytes))
+		}
+		if signer.signature == nil || !signer.signature.Verify(signer.signBytes, signer.address) {
+			return fmt.Errorf("Vector %v: invalid signature of %v", v.key(), signer.address.Hex())
+		}
+	}
+	return nil
+}
+
+func (v *Vector) decodeTx() (common.Hash, []signedInput, error) {
+	if v.Version > TxEncodingVersion {
+		return common.Hash{}, nil, fmt.Errorf("version %v is newer than the version %v supported by this release", v.Version, TxEncodingVersion)
+	}
+	tx, err := types.TxFromBytes(v.Raw)
+	if err != nil {
+		return common.Hash{}, nil, fmt.Errorf("failed to decode: %v", err)
+	}
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return common.Hash{}, nil, fmt.Errorf("failed to encode: %v", err)
+	}
+	if !bytes.Equal(raw, v.Raw) {
+		return common.Hash{}, nil, fmt.Errorf("re-encoding mismatch, got %v", hexutil.Bytes(raw))
+	}
+	signers, err := txSigners(v.ChainID, tx)
+	return crypto.Keccak256Hash(raw), signers, err
+}
+
+func (v *Vector) decodeBlockHeader() (common.Hash, []signedInput, error) {
+	header := &core.BlockHeader{}
+	if err := rlp.DecodeBytes(v.Raw, header); err != nil {
+		return common.Hash{}, nil, fmt.Errorf("failed to decode: %v", err)
+	}
+	if header.HeaderVersion() != v.Version {
+		return common.Hash{}, nil, fmt.Errorf("decoded as header version %v", header.HeaderVersion())
+	}
+	raw, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return common.Hash{}, nil, fmt.Errorf("failed to encode: %v", err)
+	}
+	if !bytes.Equal(raw, v.Raw) {
+		return common.Hash{}, nil, fmt.Errorf("re-encoding mismatch, got %v", hexutil.Bytes(raw))
+	}
+	signers := []signedInput{{header.Proposer, header.SignBytes(), header.Signature, nil}}
+	return header.Hash(), signers, nil
+}
+
+// signedInput is a signature of a decoded object
+type signedInput struct {
+	address   common.Address
+	signBytes common.Bytes
+	signature *crypto.Signature
+	sign      func(sig *crypto.Signature) // sets the signature in the transaction, nil for the block headers
+}
+
+func newSignedInput(input *types.TxInput, signBytes common.Bytes) signedInput {
+	return signedInput{input.Address, signBytes, input.Signature, func(sig *crypto.Signature) { input.Signature = sig }}
+}
+
+func newSignedMultiSigInput(input *types.MultiSigInput, signBytes common.Bytes) []signedInput {
+	signers := []signedInput{}
+	for i := range input.Signatures {
+		ss := &input.Signatures[i]
+		signers = append(signers, signedInput{ss.Signer, signBytes, ss.Signature, func(sig *crypto.Signature) { ss.Signature = sig }})
+	}
+	return signers
+}
+
+func newSignedFeePayer(feePayer *types.FeePayer, signBytes common.Bytes) signedInput {
+	return signedInput{feePayer.Address, signBytes, feePayer.Signature, func(sig *crypto.Signature) { feePayer.Signature = sig }}
+}
+
+// txSigners returns the signatures carried by the transaction, in the order they appear in it.
+// The fee payer, if any, trails the tx body and signs last.
+func txSigners(chainID string, tx types.Tx) ([]signedInput, error) {
+	signers, err := senderSigners(chainID, tx)
+	if err != nil {
+		return nil, err
+	}
+	if stx, ok := tx.(types.SponsoredTx); ok && stx.GetFeePayer() != nil {
+		signers = append(signers, newSignedFeePayer(stx.GetFeePayer(), stx.FeePayerSignBytes(chainID)))
+	}
+	return signers, nil
+}
+
+// senderSigners returns the signatures of the senders of the transaction, i.e. all but the one
+// of the fee payer.
+func senderSigners(chainID string, tx types.Tx) ([]signedInput, error) {
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		return []signedInput{newSignedInput(&tx.Proposer, tx.SignBytes(chainID))}, nil
+	case *types.SlashTx:
+		return []signedInput{newSignedInput(&tx.Proposer, tx.SignBytes(chainID))}, nil
+	case *types.SendTx:
+		signBytes := tx.SignBytes(chainID)
+		signers := []signedInput{}
+		for i := range tx.Inputs {
+			signers = append(signers, newSignedInput(&tx.Inputs[i], signBytes))
+		}
+		return signers, nil
+	case *types.MultiSendTx:
+		signBytes := tx.SignBytes(chainID)
+		signers := []signedInput{}
+		for i := range tx.Inputs {
+			signers = append(signers, newSignedInput(&tx.Inputs[i], signBytes))
+		}
+		return signers, nil
+	case *types.ReserveFundTx:
+		return []signedInput{newSignedInput(&tx.Source, tx.SignBytes(chainID))}, nil
+	case *types.ReleaseFundTx:
+		return []signedInput{newSignedInput(&tx.Source, tx.SignBytes(chainID))}, nil
+	case *types.ServicePaymentTx:
+		return []signedInput{
+			newSignedInput(&tx.Source, tx.SourceSignBytes(chainID)),
+			newSignedInput(&tx.Target, tx.TargetSignBytes(chainID)),
+		}, nil
+	case *types.SplitRuleTx:
+		return []signedInput{newSignedInput(&tx.Initiator, tx.SignBytes(chainID))}, nil
+	case *types.SmartContractTx:
+		return []signedInput{newSignedInput(&tx.From, tx.SignBytes(chainID))}, nil
+	case *types.DepositStakeTx:
+		return []signedInput{newSignedInput(&tx.Source, tx.SignBytes(chainID))}, nil
+	case *types.WithdrawStakeTx:
+		return []signedInput{newSignedInput(&tx.Source, tx.SignBytes(chainID))}, nil
+	case *types.SetAccountOperatorTx:
+		return []signedInput{newSignedInput(&tx.Account, tx.SignBytes(chainID))}, nil
+	case *types.ServicePaymentDisputeTx:
+		return []signedInput{newSignedInput(&tx.Source, tx.SignBytes(chainID))}, nil
+	case *types.RegisterNodeAddressTx:
+		return []signedInput{newSignedInput(&tx.Validator, tx.SignBytes(chainID))}, nil
+	case *types.DataCommitmentTx:
+		return []signedInput{newSignedInput(&tx.Owner, tx.SignBytes(chainID))}, nil
+	case *types.SlashReviewTx:
+		signBytes := tx.SignBytes(chainID)
+		signers := []signedInput{}
+		for i := range tx.Inputs {
+			signers = append(signers, newSignedInput(&tx.Inputs[i], signBytes))
+		}
+		return signers, nil
+	case *types.UpdateAccountSignersTx:
+		return newSignedMultiSigInput(&tx.Account, tx.SignBytes(chainID)), nil
+	case *types.MultiSigSendTx:
+		return newSignedMultiSigInput(&tx.Input, tx.SignBytes(chainID)), nil
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %T", tx)
+	}
+}
+
+// Corpus is the collection of the golden vectors, kept in the repository as JSON.
+type Corpus struct {
+	Vectors []Vector `json:"vectors"`
+}
+
+// LoadCorpus reads the corpus at the given path.
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	corpus := &Corpus{}
+	if err := json.Unmarshal(data, corpus); err != nil {
+		return nil, fmt.Errorf("Malformed corpus %v: %v", path, err)
+	}
+	return corpus, nil
+}
+
+// Save writes the corpus to the given path.
+func (c *Corpus) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Check verifies every vector of the corpus, and that the vectors this release generates for
+// the current versions are identical to the recorded ones. It returns all the mismatches found.
+func (c *Corpus) Check() []error {
+	errs := []error{}
+	recorded := map[string]*Vector{}
+	for i := range c.Vectors {
+		v := &c.Vectors[i]
+		if _, ok := recorded[v.key()]; ok {
+			errs = append(errs, fmt.Errorf("Vector %v is recorded more than once", v.key()))
+			continue
+		}
+		recorded[v.key()] = v
+		if err := v.Check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	generated, err := Generate()
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, v := range generated {
+		r, ok := recorded[v.key()]
+		if !ok {
+			errs = append(errs, fmt.Errorf("Vector %v is not recorded yet", v.key()))
+			continue
+		}
+		if !bytes.Equal(r.Raw, v.Raw) {
+			errs = append(errs, fmt.Errorf("Vector %v: this release encodes it as %v", v.key(), v.Raw))
+		}
+	}
+	return errs
+}
+
+// Add appends the given vectors that are not recorded yet, and returns how many were added. The
+// recorded vectors are never updated, since they pin the behavior of the past releases.
+func (c *Corpus) Add(vectors []Vector) int {
+	recorded := map[string]bool{}
+	for i := range c.Vectors {
+		recorded[c.Vectors[i].key()] = true
+	}
+	added := 0
+	for _, v := range vectors {
+		if !recorded[v.key()] {
+			c.Vectors = append(c.Vectors, v)
+			recorded[v.key()] = true
+			added++
+		}
+	}
+	return added
+}
@@ -0,0 +1,82 @@
+package vectors
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const corpusPath = "testdata/golden_vectors.json"
+
+func TestGoldenVectors(t *testing.T) {
+	require := require.New(t)
+
+	corpus, err := LoadCorpus(corpusPath)
+	require.Nil(err)
+	require.NotEmpty(corpus.Vectors)
+
+	for _, err := range corpus.Check() {
+		t.Error(err)
+	}
+}
+
+func TestPrivatenetSendTxVector(t *testing.T) {
+	require := require.New(t)
+
+	// The signed transaction the wallets check their encoding against
+	vectors, err := Generate()
+	require.Nil(err)
+	for _, v := range vectors {
+		if v.Name == "send_tx_privatenet" {
+			require.Equal("02f8a4c78085e8d4a51000f86ff86d942e833968e5bb786ae419c4d13189fb081cc43babd3888ac7230489e800008901158e46f1e875100002b8415a6e9a2e93487c786f07175998493161e61a5d9613745aa0e2fe51e5db1eaf626f72bfae41d971e88ff3b2c217cf611c2addb266e7d7ebda29cb0e9e5a2f482800eae9949f1233798e905e173560071255140b4a8abd3ec6d3888ac7230489e800008901158e460913d00000",
+				hex.EncodeToString(v.Raw))
+			return
+		}
+	}
+	t.Fatal("Vector send_tx_privatenet not generated")
+}
+
+func TestCorpusDetectsMismatches(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	recorded, err := Generate()
+	require.Nil(err)
+	corpus := &Corpus{}
+	require.Equal(len(recorded), corpus.Add(recorded))
+	require.Equal(0, corpus.Add(recorded))
+	assert.Empty(corpus.Check())
+
+	// Unmodified copies to restore the corpus
+	vectors, err := Generate()
+	require.Nil(err)
+
+	// Sign bytes changed by a release
+	corpus.Vectors[0].Signers[0].SignBytes = append(corpus.Vectors[0].Signers[0].SignBytes, 0x00)
+	assert.Equal(1, len(corpus.Check()))
+	corpus.Vectors[0].Signers[0].SignBytes = vectors[0].Signers[0].SignBytes
+
+	// Hash changed by a release
+	corpus.Vectors[1].Hash[0] ^= 0xff
+	assert.Equal(1, len(corpus.Check()))
+	corpus.Vectors[1].Hash = vectors[1].Hash
+
+	// Tampered encoding, which invalidates the signature and no longer matches the generated one
+	raw := append([]byte{}, vectors[2].Raw...)
+	raw[len(raw)-1] ^= 0x01
+	corpus.Vectors[2].Raw = raw
+	assert.Equal(2, len(corpus.Check()))
+	corpus.Vectors[2].Raw = vectors[2].Raw
+
+	// Vectors of a version not supported by this release
+	corpus.Vectors[3].Version = TxEncodingVersion + 1
+	assert.Equal(2, len(corpus.Check())) // the current version is no longer recorded either
+	corpus.Vectors[3].Version = TxEncodingVersion
+
+	// Missing vectors
+	corpus.Vectors = corpus.Vectors[1:]
+	assert.Equal(1, len(corpus.Check()))
+	assert.Equal(1, corpus.Add(vectors))
+	assert.Empty(corpus.Check())
+}
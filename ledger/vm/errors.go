@@ -27,4 +27,7 @@ var (
 	ErrInsufficientBalance      = errors.New("insufficient balance for transfer")
 	ErrContractAddressCollision = errors.New("contract address collision")
 	ErrNoCompatibleInterpreter  = errors.New("no compatible interpreter")
+
+	// ErrExecutionReverted is returned when the contract reverts, the return value being the revert reason
+	ErrExecutionReverted = errExecutionReverted
 )
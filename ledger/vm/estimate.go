@@ -0,0 +1,81 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// revertReasonSelector is the selector of Error(string), the ABI encoding of the revert reasons
+var revertReasonSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// EstimateGas returns the minimal gas limit, no lower than the gas it uses, with which the smart
+// contract transaction executes successfully, searching up to the gas limit of the transaction. Each execution runs on a copy of
+// the view, which is left unmodified. If the execution fails even with the gas limit of the
+// transaction, the return value, e.g. the revert reason, and the error of that execution are
+// returned.
+func EstimateGas(tx *types.SmartContractTx, storeView *state.StoreView) (gasLimit uint64, evmRet common.Bytes, evmErr error) {
+	execute := func(gasLimit uint64) (common.Bytes, uint64, error) {
+		view, err := storeView.Copy()
+		if err != nil {
+			return nil, 0, err
+		}
+		candidate := *tx
+		candidate.GasLimit = gasLimit
+		ret, _, gasUsed, err := Execute(&candidate, view)
+		return ret, gasUsed, err
+	}
+
+	ret, gasUsed, err := execute(tx.GasLimit)
+	if err != nil {
+		return 0, ret, err
+	}
+
+	// With less gas than it used the execution would differ, e.g. an internal call could run out of
+	// gas, so the search starts from the gas used. It may need more than it used, since a contract
+	// can only pass 63/64 of its remaining gas to the contracts it calls.
+	lo, hi := uint64(0), tx.GasLimit
+	if gasUsed > 0 {
+		lo = gasUsed - 1
+	}
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		if _, _, err := execute(mid); err != nil {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi, ret, nil
+}
+
+// RevertReason decodes the reason of a revert from the return value, if the contract reverted
+// with an Error(string), e.g. with the require and revert statements of Solidity.
+func RevertReason(ret []byte) (string, bool) {
+	if len(ret) < 4+64 || !bytes.Equal(ret[:4], revertReasonSelector) {
+		return "", false
+	}
+	data := ret[4:]
+	offset, ok := abiUint(data[:32])
+	if !ok || offset+32 > uint64(len(data)) {
+		return "", false
+	}
+	length, ok := abiUint(data[offset : offset+32])
+	if !ok || offset+32+length > uint64(len(data)) {
+		return "", false
+	}
+	return string(data[offset+32 : offset+32+length]), true
+}
+
+// abiUint decodes an ABI encoded uint256 word which fits in 32 bits.
+func abiUint(word []byte) (uint64, bool) {
+	for _, b := range word[:28] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return uint64(binary.BigEndian.Uint32(word[28:])), true
+}
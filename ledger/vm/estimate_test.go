@@ -0,0 +1,90 @@
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func TestEstimateGas(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	privAccounts := prepareInitState(storeView, 1)
+	senderAddr := privAccounts[0].Account.Address
+
+	// ASM: push 0x3, push 0x0, mstore8, push 0x1, push 0x0, return
+	callee := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	calleeCode, _ := hex.DecodeString("600360005360016000f3")
+	storeView.SetCode(callee, calleeCode)
+	// ASM: push 0x0, push 0x0, revert
+	reverting := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	revertingCode, _ := hex.DecodeString("60006000fd")
+	storeView.SetCode(reverting, revertingCode)
+	caller := common.HexToAddress("0x2000000000000000000000000000000000000001")
+	storeView.SetCode(caller, callerCode(callee))
+
+	callTx := func(to common.Address, gasLimit uint64) *types.SmartContractTx {
+		return &types.SmartContractTx{
+			From:     types.TxInput{Address: senderAddr, Coins: types.NewCoins(0, 0)},
+			To:       types.TxOutput{Address: to},
+			GasLimit: gasLimit,
+			GasPrice: big.NewInt(5000),
+		}
+	}
+	copyView := func() *state.StoreView {
+		view, err := storeView.Copy()
+		require.Nil(err)
+		return view
+	}
+	rootHash := storeView.Hash()
+
+	_, _, gasUsed, vmErr := Execute(callTx(caller, 100000), copyView())
+	require.Nil(vmErr)
+
+	gasLimit, _, vmErr := EstimateGas(callTx(caller, 100000), storeView)
+	require.Nil(vmErr)
+	assert.True(gasLimit >= gasUsed)
+	assert.Equal(rootHash, storeView.Hash(), "the view is not modified")
+
+	// The internal call still succeeds with the estimated gas limit
+	tracer := NewExecutionTracer(nil)
+	_, _, _, vmErr = Trace(callTx(caller, gasLimit), copyView(), tracer)
+	require.Nil(vmErr)
+	require.Equal(1, len(tracer.Calls()))
+	assert.False(tracer.Calls()[0].Failed)
+
+	// The transaction fails even with its gas limit
+	_, _, vmErr = EstimateGas(callTx(caller, 20000), storeView) // below the intrinsic gas
+	assert.Equal(ErrOutOfGas, vmErr)
+	_, _, vmErr = EstimateGas(callTx(reverting, 100000), storeView)
+	assert.Equal(ErrExecutionReverted, vmErr)
+}
+
+func TestRevertReason(t *testing.T) {
+	assert := assert.New(t)
+
+	// Error("not enough funds")
+	ret, _ := hex.DecodeString("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000010" +
+		"6e6f7420656e6f7567682066756e647300000000000000000000000000000000")
+	reason, ok := RevertReason(ret)
+	assert.True(ok)
+	assert.Equal("not enough funds", reason)
+
+	_, ok = RevertReason(nil)
+	assert.False(ok)
+	_, ok = RevertReason(ret[:len(ret)-32]) // the reason is truncated
+	assert.False(ok)
+	_, ok = RevertReason(append([]byte{0, 0, 0, 0}, ret[4:]...)) // not an Error(string)
+	assert.False(ok)
+}
@@ -13,6 +13,18 @@ import (
 
 // Execute executes the given smart contract
 func Execute(tx *types.SmartContractTx, storeView *state.StoreView) (evmRet common.Bytes,
+	contractAddr common.Address, gasUsed uint64, evmErr error) {
+	return execute(tx, storeView, Config{})
+}
+
+// Trace executes the given smart contract the same way as Execute, reporting each step of the
+// execution to the tracer
+func Trace(tx *types.SmartContractTx, storeView *state.StoreView, tracer Tracer) (evmRet common.Bytes,
+	contractAddr common.Address, gasUsed uint64, evmErr error) {
+	return execute(tx, storeView, Config{Debug: true, Tracer: tracer})
+}
+
+func execute(tx *types.SmartContractTx, storeView *state.StoreView, config Config) (evmRet common.Bytes,
 	contractAddr common.Address, gasUsed uint64, evmErr error) {
 	context := Context{
 		GasPrice:    tx.GasPrice,
@@ -22,7 +34,6 @@ func Execute(tx *types.SmartContractTx, storeView *state.StoreView) (evmRet comm
 		Difficulty:  new(big.Int).SetInt64(0),
 	}
 	chainConfig := &params.ChainConfig{}
-	config := Config{}
 	evm := NewEVM(context, storeView, chainConfig, config)
 
 	value := tx.From.Coins.TFuelWei
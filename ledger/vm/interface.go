@@ -57,8 +57,8 @@ type StateDB interface {
 	// is defined according to EIP161 (balance = nonce = code = 0).
 	Empty(common.Address) bool
 
-	RevertToSnapshot(common.Hash)
-	Snapshot() common.Hash
+	RevertToRevision(int)
+	SnapshotRevision() int
 
 	AddLog(*types.Log)
 }
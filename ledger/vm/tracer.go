@@ -0,0 +1,121 @@
+package vm
+
+import (
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// InternalCall is a message call or a contract creation made by a contract during the execution
+type InternalCall struct {
+	Type   OpCode // CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE or CREATE2
+	From   common.Address
+	To     common.Address // the created contract for the creations, empty if failed
+	Value  *big.Int
+	Gas    uint64
+	Input  common.Bytes
+	Depth  int // depth of the calling contract
+	Failed bool
+}
+
+// ExecutionTracer records the steps of the execution like the StructLogger, and additionally the
+// internal calls, in the order they were made
+type ExecutionTracer struct {
+	*StructLogger
+
+	calls   []*InternalCall
+	pending []*InternalCall // calls that have not returned yet, innermost last
+}
+
+// NewExecutionTracer returns a new execution tracer
+func NewExecutionTracer(cfg *LogConfig) *ExecutionTracer {
+	return &ExecutionTracer{StructLogger: NewStructLogger(cfg)}
+}
+
+// CaptureState implements the Tracer interface. The operands of the call and create ops are read
+// before the op executes, and their outcome once the execution is back to the calling contract.
+func (t *ExecutionTracer) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	t.returnCalls(depth, stack)
+	if err == nil {
+		if call := newInternalCall(env, op, memory, stack, contract, depth); call != nil {
+			t.calls = append(t.calls, call)
+			t.pending = append(t.pending, call)
+		}
+	}
+	return t.StructLogger.CaptureState(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// CaptureFault implements the Tracer interface
+func (t *ExecutionTracer) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	t.returnCalls(depth, stack)
+	return t.StructLogger.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+}
+
+// returnCalls completes the pending calls made at the given depth or deeper, the result of the
+// last one being on top of the stack.
+func (t *ExecutionTracer) returnCalls(depth int, stack *Stack) {
+	for len(t.pending) > 0 {
+		call := t.pending[len(t.pending)-1]
+		if call.Depth < depth {
+			return
+		}
+		t.pending = t.pending[:len(t.pending)-1]
+		if call.Depth > depth || stack.len() == 0 {
+			call.Failed = true // the calling contract failed in turn
+			continue
+		}
+		result := stack.peek()
+		call.Failed = result.Sign() == 0
+		if !call.Failed && (call.Type == CREATE || call.Type == CREATE2) {
+			call.To = common.BigToAddress(result)
+		}
+	}
+}
+
+// Calls returns the internal calls made during the execution. The calls which did not return,
+// e.g. because the execution ran out of gas, are failed.
+func (t *ExecutionTracer) Calls() []*InternalCall {
+	for _, call := range t.pending {
+		call.Failed = true
+	}
+	t.pending = nil
+	if t.calls == nil {
+		return []*InternalCall{}
+	}
+	return t.calls
+}
+
+func newInternalCall(env *EVM, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int) *InternalCall {
+	call := &InternalCall{
+		Type:  op,
+		From:  contract.Address(),
+		Value: big.NewInt(0),
+		Depth: depth,
+	}
+	var inOffset, inSize *big.Int
+	switch op {
+	case CALL, CALLCODE:
+		call.Gas = env.callGasTemp // the gas forwarded, as computed by the gas function of the op
+		call.To = common.BigToAddress(stack.Back(1))
+		call.Value = new(big.Int).Set(stack.Back(2))
+		inOffset, inSize = stack.Back(3), stack.Back(4)
+	case DELEGATECALL:
+		call.Gas = env.callGasTemp
+		call.To = common.BigToAddress(stack.Back(1))
+		call.Value = new(big.Int).Set(contract.Value()) // the value of the calling contract is kept
+		inOffset, inSize = stack.Back(2), stack.Back(3)
+	case STATICCALL:
+		call.Gas = env.callGasTemp
+		call.To = common.BigToAddress(stack.Back(1))
+		inOffset, inSize = stack.Back(2), stack.Back(3)
+	case CREATE, CREATE2:
+		call.Gas = contract.Gas - contract.Gas/64
+		call.Value = new(big.Int).Set(stack.Back(0))
+		inOffset, inSize = stack.Back(1), stack.Back(2)
+	default:
+		return nil
+	}
+	// The memory was expanded to cover the input before the op executes
+	call.Input = common.Bytes(memory.Get(inOffset.Int64(), inSize.Int64()))
+	return call
+}
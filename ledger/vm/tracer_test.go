@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+// callerCode calls the callee without value nor input, with 0xffff gas, and stops.
+func callerCode(callee common.Address) []byte {
+	code, _ := hex.DecodeString("6000600060006000600073" + hex.EncodeToString(callee[:]) + "61fffff100")
+	return code
+}
+
+func TestExecutionTracer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	storeView := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	privAccounts := prepareInitState(storeView, 1)
+	senderAddr := privAccounts[0].Account.Address
+
+	// ASM: push 0x3, push 0x0, mstore8, push 0x1, push 0x0, return
+	callee := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	calleeCode, _ := hex.DecodeString("600360005360016000f3")
+	storeView.SetCode(callee, calleeCode)
+	// ASM: push 0x0, push 0x0, revert
+	reverting := common.HexToAddress("0x1000000000000000000000000000000000000002")
+	revertingCode, _ := hex.DecodeString("60006000fd")
+	storeView.SetCode(reverting, revertingCode)
+
+	caller := common.HexToAddress("0x2000000000000000000000000000000000000001")
+	storeView.SetCode(caller, callerCode(callee))
+	revertingCaller := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	storeView.SetCode(revertingCaller, callerCode(reverting))
+
+	callTx := func(to common.Address) *types.SmartContractTx {
+		return &types.SmartContractTx{
+			From:     types.TxInput{Address: senderAddr, Coins: types.NewCoins(0, 0)},
+			To:       types.TxOutput{Address: to},
+			GasLimit: 100000,
+			GasPrice: big.NewInt(5000),
+		}
+	}
+
+	tracer := NewExecutionTracer(nil)
+	_, _, gasUsed, vmErr := Trace(callTx(caller), storeView, tracer)
+	require.Nil(vmErr)
+	assert.True(gasUsed > 0)
+
+	logs := tracer.StructLogs()
+	require.Equal(15, len(logs)) // 8 steps of the caller, 6 of the callee, and the final STOP
+	assert.Equal(PUSH1, logs[0].Op)
+	assert.Equal(1, logs[0].Depth)
+	assert.Equal(CALL, logs[7].Op)
+	assert.Equal(7, len(logs[7].Stack))
+	assert.Equal(2, logs[8].Depth)
+	assert.Equal(RETURN, logs[13].Op)
+	assert.Equal(STOP, logs[14].Op)
+	assert.Equal(1, logs[14].Depth)
+	assert.Equal(int64(1), logs[14].Stack[0].Int64(), "the call succeeded")
+
+	calls := tracer.Calls()
+	require.Equal(1, len(calls))
+	assert.Equal(CALL, calls[0].Type)
+	assert.Equal(caller, calls[0].From)
+	assert.Equal(callee, calls[0].To)
+	assert.Equal(0, calls[0].Value.Sign())
+	assert.Equal(uint64(0xffff), calls[0].Gas)
+	assert.Equal(1, calls[0].Depth)
+	assert.False(calls[0].Failed)
+
+	// The reverted internal call is failed, while the transaction succeeds
+	tracer = NewExecutionTracer(nil)
+	_, _, _, vmErr = Trace(callTx(revertingCaller), storeView, tracer)
+	require.Nil(vmErr)
+	calls = tracer.Calls()
+	require.Equal(1, len(calls))
+	assert.Equal(reverting, calls[0].To)
+	assert.True(calls[0].Failed)
+
+	// The steps beyond the limit are not recorded, but the calls still are
+	tracer = NewExecutionTracer(&LogConfig{Limit: 3, DisableMemory: true, DisableStack: true})
+	_, _, _, vmErr = Trace(callTx(caller), storeView, tracer)
+	require.Nil(vmErr)
+	assert.Equal(3, len(tracer.StructLogs()))
+	assert.Nil(tracer.StructLogs()[0].Stack)
+	assert.Equal(1, len(tracer.Calls()))
+
+	// Tracing does not change the outcome of the execution
+	ret, _, tracedGasUsed, _ := Execute(callTx(caller), storeView)
+	assert.Equal(gasUsed, tracedGasUsed)
+	assert.Equal(0, len(ret))
+}
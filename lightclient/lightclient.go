@@ -0,0 +1,192 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/consensus"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// LightClient tracks the finalized block headers of a chain without executing the transactions.
+// Starting from a trusted header and the validator set committing the blocks following it, it
+// verifies that each new header is committed by the votes of the validators holding more than 2/3
+// of the stake, and follows the changes of the validator set through the Merkle proofs of the
+// validator candidate pool against the state root of the verified headers. The transactions of
+// the verified blocks are then checked against their tx root, see VerifyTxInclusion, and the
+// accounts against their state root, see VerifyAccount.
+type LightClient struct {
+	mu *sync.Mutex
+
+	chainID          string
+	validators       *core.ValidatorSet
+	validatorsHeight uint64 // height of the block the validator set is taken from
+	headers          map[common.Hash]*core.BlockHeader
+	latest           *core.BlockHeader
+}
+
+// NewLightClient creates a light client trusting the given header, e.g. the genesis block or a
+// checkpoint, and the validator set committing the blocks following it.
+func NewLightClient(trusted *core.BlockHeader, validators *core.ValidatorSet) *LightClient {
+	return &LightClient{
+		mu:               &sync.Mutex{},
+		chainID:          trusted.ChainID,
+		validators:       validators.Copy(),
+		validatorsHeight: trusted.Height,
+		headers:          map[common.Hash]*core.BlockHeader{trusted.Hash(): trusted},
+		latest:           trusted,
+	}
+}
+
+// ChainID returns the ID of the chain tracked by the light client.
+func (lc *LightClient) ChainID() string {
+	return lc.chainID
+}
+
+// LatestHeader returns the verified header with the greatest height.
+func (lc *LightClient) LatestHeader() *core.BlockHeader {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.latest
+}
+
+// GetHeader returns the verified header with the given hash.
+func (lc *LightClient) GetHeader(hash common.Hash) (*core.BlockHeader, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	header, ok := lc.headers[hash]
+	return header, ok
+}
+
+// Validators returns the validator set the new headers are verified against.
+func (lc *LightClient) Validators() *core.ValidatorSet {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.validators.Copy()
+}
+
+// VerifyHeader verifies that the header is committed by the certificate, i.e. that the votes of the
+// certificate are for the header, are signed by the validators, and add up to more than 2/3 of the
+// stake of the validator set. The header is tracked once verified. The certificate is the one
+// returned along with the raw header by the GetTransactionProof RPC, or the HCC of the child block.
+func (lc *LightClient) VerifyHeader(header *core.BlockHeader, cc *core.CommitCertificate) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	hash := header.Hash()
+	if _, ok := lc.headers[hash]; ok {
+		return nil
+	}
+	if header.ChainID != lc.chainID {
+		return fmt.Errorf("Chain ID mismatch: %v vs %v", header.ChainID, lc.chainID)
+	}
+	if header.Height <= lc.validatorsHeight {
+		return fmt.Errorf("Header at height %v precedes the validator set, taken at height %v",
+			header.Height, lc.validatorsHeight)
+	}
+	if cc == nil || cc.BlockHash != hash {
+		return fmt.Errorf("The certificate does not commit block %v", hash.Hex())
+	}
+	if !cc.IsValid(lc.validators) {
+		return fmt.Errorf("Block %v is not committed by the validators with more than 2/3 of the stake", hash.Hex())
+	}
+
+	lc.headers[hash] = header
+	if header.Height > lc.latest.Height {
+		lc.latest = header
+	}
+	return nil
+}
+
+// VerifyRawHeader decodes the RLP encoded header and verifies it, see VerifyHeader.
+func (lc *LightClient) VerifyRawHeader(rawHeader common.Bytes, cc *core.CommitCertificate) (*core.BlockHeader, error) {
+	header := &core.BlockHeader{}
+	if err := rlp.DecodeBytes(rawHeader, header); err != nil {
+		return nil, fmt.Errorf("Failed to decode block header: %v", err)
+	}
+	if err := lc.VerifyHeader(header, cc); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// UpdateValidatorSet switches to the validator set selected from the validator candidate pool in the
+// state of the verified block with the given hash. The pool is proven against the state root of the
+// block, so that the validator set is as trusted as the block. The following headers are verified
+// against the new validator set.
+func (lc *LightClient) UpdateValidatorSet(blockHash common.Hash, proof *core.VCPProof) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	header, ok := lc.headers[blockHash]
+	if !ok {
+		return fmt.Errorf("Block %v is not verified", blockHash.Hex())
+	}
+	if header.Height <= lc.validatorsHeight {
+		return fmt.Errorf("Block %v at height %v precedes the validator set, taken at height %v",
+			blockHash.Hex(), header.Height, lc.validatorsHeight)
+	}
+
+	serializedVCP, _, err := trie.VerifyProof(header.StateHash, state.ValidatorCandidatePoolKey(), proof)
+	if err != nil {
+		return fmt.Errorf("Failed to verify the VCP proof: %v", err)
+	}
+	vcp := &core.ValidatorCandidatePool{}
+	if err = rlp.DecodeBytes(serializedVCP, vcp); err != nil {
+		return fmt.Errorf("Failed to decode the validator candidate pool: %v", err)
+	}
+	validators := consensus.SelectTopStakeHoldersAsValidators(vcp)
+	if validators.Size() == 0 {
+		return errors.New("The validator candidate pool has no validator")
+	}
+
+	lc.validators = validators
+	lc.validatorsHeight = header.Height
+	return nil
+}
+
+// VerifyTxInclusion verifies the Merkle proof of a transaction against the tx root of the verified
+// block with the given hash, and returns the transaction it proves.
+func (lc *LightClient) VerifyTxInclusion(blockHash common.Hash, proof *core.TxProof) (common.Bytes, error) {
+	header, ok := lc.GetHeader(blockHash)
+	if !ok {
+		return nil, fmt.Errorf("Block %v is not verified", blockHash.Hex())
+	}
+	if proof == nil {
+		return nil, errors.New("Proof must be specified")
+	}
+	return core.VerifyTxProof(header.TxHash, proof)
+}
+
+// VerifyAccount verifies the Merkle proof of an account against the state root of the verified block
+// with the given hash, and returns the account it proves, or nil if it proves the account does not
+// exist. The proof is the one returned by the GetAccountProof RPC.
+func (lc *LightClient) VerifyAccount(blockHash common.Hash, proof *types.AccountProof) (*types.Account, error) {
+	header, ok := lc.GetHeader(blockHash)
+	if !ok {
+		return nil, fmt.Errorf("Block %v is not verified", blockHash.Hex())
+	}
+	if proof == nil {
+		return nil, errors.New("Proof must be specified")
+	}
+	return state.VerifyAccountProof(header.StateHash, proof)
+}
+
+// VerifyAccounts is VerifyAccount for the batch proof returned by the GetBatchAccountProof RPC. The
+// accounts are returned in the order of the addresses of the proof.
+func (lc *LightClient) VerifyAccounts(blockHash common.Hash, proof *types.BatchAccountProof) ([]*types.Account, error) {
+	header, ok := lc.GetHeader(blockHash)
+	if !ok {
+		return nil, fmt.Errorf("Block %v is not verified", blockHash.Hex())
+	}
+	if proof == nil {
+		return nil, errors.New("Proof must be specified")
+	}
+	return state.VerifyBatchAccountProof(header.StateHash, proof)
+}
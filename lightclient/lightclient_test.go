@@ -0,0 +1,108 @@
+package lightclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func createTestHeader(parent *core.BlockHeader, stateHash common.Hash, txs []common.Bytes) *core.Block {
+	block := core.NewBlock()
+	block.ChainID = parent.ChainID
+	block.Height = parent.Height + 1
+	block.Epoch = parent.Epoch + 1
+	block.Parent = parent.Hash()
+	block.HCC.BlockHash = parent.Hash()
+	block.StateHash = stateHash
+	block.Timestamp = big.NewInt(int64(1546300800 + block.Height))
+	block.AddTxs(txs)
+	return block
+}
+
+func commit(header *core.BlockHeader, signers ...*crypto.PrivateKey) *core.CommitCertificate {
+	votes := core.NewVoteSet()
+	for _, signer := range signers {
+		vote := core.Vote{Block: header.Hash(), Height: header.Height, Epoch: header.Epoch, ID: signer.PublicKey().Address()}
+		vote.Sign(signer)
+		votes.AddVote(vote)
+	}
+	return &core.CommitCertificate{BlockHash: header.Hash(), Votes: votes}
+}
+
+func TestLightClient(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signers := []*crypto.PrivateKey{}
+	validators := core.NewValidatorSet()
+	for i := 0; i < 4; i++ {
+		signer, _, _ := crypto.GenerateKeyPair()
+		signers = append(signers, signer)
+		validators.AddValidator(core.NewValidator(signer.PublicKey().Address().Hex(), core.MinValidatorStakeDeposit))
+	}
+
+	genesis := core.NewBlock()
+	genesis.ChainID = "lightclient_test"
+	genesis.Timestamp = big.NewInt(1546300800)
+	lc := NewLightClient(genesis.BlockHeader, validators)
+
+	// The transactions are larger than a hash, so that the trie nodes are not inlined into the
+	// proof of another transaction
+	txs := []common.Bytes{common.Bytes("tx0 with a payload larger than 32 bytes"), common.Bytes("tx1 with a payload larger than 32 bytes")}
+	b1 := createTestHeader(genesis.BlockHeader, common.Hash{}, txs)
+
+	// 2 out of 4 validators is not more than 2/3 of the stake
+	assert.NotNil(lc.VerifyHeader(b1.BlockHeader, commit(b1.BlockHeader, signers[0], signers[1])))
+	assert.NotNil(lc.VerifyHeader(b1.BlockHeader, commit(genesis.BlockHeader, signers[0], signers[1], signers[2])))
+	other, _, _ := crypto.GenerateKeyPair()
+	assert.NotNil(lc.VerifyHeader(b1.BlockHeader, commit(b1.BlockHeader, signers[0], signers[1], other)))
+	_, ok := lc.GetHeader(b1.Hash())
+	assert.False(ok)
+
+	require.Nil(lc.VerifyHeader(b1.BlockHeader, commit(b1.BlockHeader, signers[0], signers[1], signers[2])))
+	assert.Equal(b1.Hash(), lc.LatestHeader().Hash())
+
+	proof, err := b1.ProveTx(1)
+	require.Nil(err)
+	tx, err := lc.VerifyTxInclusion(b1.Hash(), proof)
+	require.Nil(err)
+	assert.Equal(txs[1], tx)
+	proof.Index = 0
+	_, err = lc.VerifyTxInclusion(b1.Hash(), proof)
+	assert.NotNil(err)
+	_, err = lc.VerifyTxInclusion(genesis.Hash(), proof)
+	assert.NotNil(err)
+
+	// Hand over to a new validator set, proven against the state root of a verified block
+	newSigner, _, _ := crypto.GenerateKeyPair()
+	vcp := &core.ValidatorCandidatePool{}
+	require.Nil(vcp.DepositStake(newSigner.PublicKey().Address(), newSigner.PublicKey().Address(), core.MinValidatorStakeDeposit))
+	sv := state.NewStoreView(0, common.Hash{}, backend.NewMemDatabase())
+	sv.UpdateValidatorCandidatePool(vcp)
+	stateHash := sv.Save()
+	vcpProof := &core.VCPProof{}
+	require.Nil(sv.ProveVCP(state.ValidatorCandidatePoolKey(), vcpProof))
+
+	b2 := createTestHeader(b1.BlockHeader, stateHash, nil)
+	assert.NotNil(lc.UpdateValidatorSet(b2.Hash(), vcpProof), "not verified")
+	require.Nil(lc.VerifyHeader(b2.BlockHeader, commit(b2.BlockHeader, signers[1], signers[2], signers[3])))
+	assert.NotNil(lc.UpdateValidatorSet(b1.Hash(), vcpProof), "state root mismatch")
+	require.Nil(lc.UpdateValidatorSet(b2.Hash(), vcpProof))
+	assert.Equal(1, lc.Validators().Size())
+
+	b3 := createTestHeader(b2.BlockHeader, stateHash, nil)
+	assert.NotNil(lc.VerifyHeader(b3.BlockHeader, commit(b3.BlockHeader, signers[0], signers[1], signers[2])))
+	require.Nil(lc.VerifyHeader(b3.BlockHeader, commit(b3.BlockHeader, newSigner)))
+	assert.Equal(b3.Hash(), lc.LatestHeader().Hash())
+
+	// The headers preceding the validator set can no longer be verified
+	fork := createTestHeader(b1.BlockHeader, common.BytesToHash([]byte("fork")), nil)
+	assert.NotNil(lc.VerifyHeader(fork.BlockHeader, commit(fork.BlockHeader, newSigner)))
+}
@@ -0,0 +1,109 @@
+package mempool
+
+import (
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// TxEventType is the kind of change of a transaction in the mempool
+type TxEventType int
+
+const (
+	// TxEventInserted is published when a transaction is inserted into the mempool
+	TxEventInserted TxEventType = iota
+	// TxEventCommitted is published when a transaction is removed after being included in a block
+	TxEventCommitted
+	// TxEventDropped is published when a transaction is removed because it became invalid or expired
+	TxEventDropped
+	// TxEventReplaced is published when a transaction is replaced by a transaction paying a higher fee
+	TxEventReplaced
+)
+
+func (t TxEventType) String() string {
+	switch t {
+	case TxEventInserted:
+		return "inserted"
+	case TxEventCommitted:
+		return "committed"
+	case TxEventDropped:
+		return "dropped"
+	case TxEventReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+// TxEvent is published when a transaction enters or leaves the mempool.
+type TxEvent struct {
+	Type    TxEventType
+	Address common.Address // Address of the sender
+	TxHash  common.Hash
+	RawTx   common.Bytes
+}
+
+// TxEventSubscription receives the transaction events on C until it is unsubscribed.
+type TxEventSubscription struct {
+	C    <-chan *TxEvent
+	c    chan *TxEvent
+	feed *txEventFeed
+}
+
+// Unsubscribe stops the delivery of the transaction events to the subscription.
+func (s *TxEventSubscription) Unsubscribe() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	delete(s.feed.subs, s)
+}
+
+// txEventFeed publishes the transaction events to the subscriptions. The events are dropped for
+// the subscribers whose buffer is full.
+type txEventFeed struct {
+	mu   *sync.Mutex
+	subs map[*TxEventSubscription]struct{}
+}
+
+func newTxEventFeed() *txEventFeed {
+	return &txEventFeed{
+		mu:   &sync.Mutex{},
+		subs: make(map[*TxEventSubscription]struct{}),
+	}
+}
+
+func (f *txEventFeed) subscribe(bufferSize int) *TxEventSubscription {
+	c := make(chan *TxEvent, bufferSize)
+	sub := &TxEventSubscription{C: c, c: c, feed: f}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+func (f *txEventFeed) publish(eventType TxEventType, address common.Address, rawTx common.Bytes) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.subs) == 0 {
+		return
+	}
+	event := &TxEvent{
+		Type:    eventType,
+		Address: address,
+		TxHash:  crypto.Keccak256Hash(rawTx),
+		RawTx:   rawTx,
+	}
+	for sub := range f.subs {
+		select {
+		case sub.c <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeTxEvents returns a subscription receiving the insertions and removals of the
+// transactions, buffering up to bufferSize events.
+func (mp *Mempool) SubscribeTxEvents(bufferSize int) *TxEventSubscription {
+	return mp.txEvents.subscribe(bufferSize)
+}
@@ -0,0 +1,79 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/crypto"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2psim "github.com/thetatoken/theta/p2p/simulation"
+)
+
+func TestMempoolTxEvents(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool := CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer0")))
+	ledger := &txEventTestLedger{replacementTestLedger: newReplacementTestLedger(), invalid: make(map[string]bool)}
+	mempool.SetLedger(ledger)
+	mempool.replaceFeeBump = 10
+	events := mempool.SubscribeTxEvents(10)
+	defer events.Unsubscribe()
+
+	txA1 := createReplacementTestTx("A1", 1, 100)
+	txA2 := createReplacementTestTx("A1", 2, 100)
+	txB1 := createReplacementTestTx("B1", 1, 100)
+	txA2Replacement := createReplacementTestTx("A1", 2, 111)
+	require.Nil(mempool.InsertTransaction(txA1))
+	require.Nil(mempool.InsertTransaction(txA2))
+	require.Nil(mempool.InsertTransaction(txB1))
+	require.Nil(mempool.InsertTransaction(txA2Replacement))
+
+	// The rejected transactions are not published
+	assert.NotNil(mempool.InsertTransaction(txB1))
+
+	ledger.invalid[string(txB1)] = true
+	mempool.Update([]common.Bytes{txA1})
+
+	expected := []struct {
+		eventType TxEventType
+		address   string
+		rawTx     common.Bytes
+	}{
+		{TxEventInserted, "A1", txA1},
+		{TxEventInserted, "A1", txA2},
+		{TxEventInserted, "B1", txB1},
+		{TxEventReplaced, "A1", txA2},
+		{TxEventInserted, "A1", txA2Replacement},
+		{TxEventCommitted, "A1", txA1},
+		{TxEventDropped, "B1", txB1},
+	}
+	require.Equal(len(expected), len(events.C))
+	for _, e := range expected {
+		event := <-events.C
+		assert.Equal(e.eventType, event.Type, "%v", e.eventType)
+		assert.Equal(common.HexToAddress(e.address), event.Address)
+		assert.Equal(e.rawTx, event.RawTx)
+		assert.Equal(crypto.Keccak256Hash(e.rawTx), event.TxHash)
+	}
+	assert.Equal(1, mempool.Size())
+}
+
+// --------------- Test Utilities --------------- //
+
+// txEventTestLedger invalidates the given transactions when the mempool is updated.
+type txEventTestLedger struct {
+	*replacementTestLedger
+	invalid map[string]bool
+}
+
+func (tl *txEventTestLedger) ScreenTxUnsafe(rawTx common.Bytes) result.Result {
+	if tl.invalid[string(rawTx)] {
+		return result.Error("Transaction expired").WithErrorCode(result.CodeTxExpired)
+	}
+	return result.OK
+}
@@ -0,0 +1,91 @@
+package mempool
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+)
+
+// checkLimits checks the transaction fits within the pending transaction limits: its sender has
+// fewer pending transactions than allowed, and either the mempool is not full or the transaction
+// pays a higher effective gas price than the transaction it would evict. It is called before the
+// screening, which advances the screened state of the sender. Caller must hold the mempool lock.
+func (mp *Mempool) checkLimits(txInfo *core.TxInfo) error {
+	if mp.maxPendingTxsPerAccount > 0 {
+		if txGroup, ok := mp.addressToTxGroup[txInfo.Address]; ok && txGroup.txs.NumElements() >= mp.maxPendingTxsPerAccount {
+			return ScreeningError{Result: result.Error("Account %v already has %v pending transactions",
+				txInfo.Address.Hex(), mp.maxPendingTxsPerAccount).WithErrorCode(result.CodeTooManyPendingTxs)}
+		}
+	}
+
+	if !mp.isFull() {
+		return nil
+	}
+	_, evicted := mp.findEvictedTx(txInfo.Address)
+	if evicted == nil {
+		return ScreeningError{Result: result.Error("Mempool is full").WithErrorCode(result.CodeMempoolFull)}
+	}
+	if txInfo.EffectiveGasPrice == nil || txInfo.EffectiveGasPrice.Cmp(evicted.txInfo.EffectiveGasPrice) <= 0 {
+		return ScreeningError{Result: result.Error("Mempool is full, the effective gas price %v must exceed %v",
+			txInfo.EffectiveGasPrice, evicted.txInfo.EffectiveGasPrice).WithErrorCode(result.CodeMempoolFull)}
+	}
+	return nil
+}
+
+func (mp *Mempool) isFull() bool {
+	return mp.maxNumTxs > 0 && mp.size >= mp.maxNumTxs
+}
+
+// evictForTx evicts a pending transaction to make room for the screened transaction if the mempool
+// is full. Caller must hold the mempool lock.
+func (mp *Mempool) evictForTx(txInfo *core.TxInfo) {
+	if !mp.isFull() {
+		return
+	}
+	txGroup, evicted := mp.findEvictedTx(txInfo.Address)
+	if evicted == nil {
+		return
+	}
+
+	logger.Infof("Evict tx, tx.hash: 0x%v, txInfo: %v", getTransactionHash(evicted.rawTransaction), evicted.txInfo)
+
+	txGroup.txs.Remove(evicted.GetIndex())
+	mp.candidateTxs.Remove(txGroup.GetIndex())
+	if txGroup.IsEmpty() {
+		delete(mp.addressToTxGroup, txGroup.address)
+	} else {
+		mp.candidateTxs.Push(txGroup)
+	}
+	mp.size--
+	mp.txBookeepper.markAbandoned(evicted.rawTransaction)
+	mp.txEvents.publish(TxEventDropped, txGroup.address, evicted.rawTransaction)
+}
+
+// findEvictedTx returns the transaction evicted first when the mempool is full: the transaction
+// with the highest sequence of the group whose first transaction pays the lowest effective gas
+// price, so that no sequence gap is left in the group. The group of the sender of the incoming
+// transaction is skipped, since the incoming transaction may follow its last transaction.
+func (mp *Mempool) findEvictedTx(sender common.Address) (*mempoolTransactionGroup, *mempoolTransaction) {
+	var cheapest *mempoolTransactionGroup
+	for _, elem := range *mp.candidateTxs.ElementList() {
+		txGroup := elem.(*mempoolTransactionGroup)
+		if txGroup.IsEmpty() || txGroup.address == sender {
+			continue
+		}
+		if cheapest == nil || txGroup.Priority().Cmp(cheapest.Priority()) < 0 {
+			cheapest = txGroup
+		}
+	}
+	if cheapest == nil {
+		return nil, nil
+	}
+
+	var last *mempoolTransaction
+	for _, elem := range *cheapest.txs.ElementList() {
+		mptx := elem.(*mempoolTransaction)
+		if last == nil || mptx.txInfo.Sequence > last.txInfo.Sequence {
+			last = mptx
+		}
+	}
+	return cheapest, last
+}
@@ -0,0 +1,90 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2psim "github.com/thetatoken/theta/p2p/simulation"
+)
+
+func TestMempoolMaxPendingTxsPerAccount(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool := CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer0")))
+	mempool.SetLedger(&txEventTestLedger{replacementTestLedger: newReplacementTestLedger(), invalid: make(map[string]bool)})
+	mempool.replaceFeeBump = 10
+	mempool.maxPendingTxsPerAccount = 2
+
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("A1", 1, 100)))
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("A1", 2, 100)))
+	err := mempool.InsertTransaction(createReplacementTestTx("A1", 3, 100))
+	require.NotNil(err)
+	assert.Equal(result.CodeTooManyPendingTxs, err.(ScreeningError).Result.Code)
+
+	// The replacements and the other accounts are not limited
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("A1", 2, 200)))
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("B1", 1, 100)))
+	assert.Equal(3, mempool.Size())
+
+	// The rejected transaction is accepted once a pending transaction is committed, since it was
+	// rejected before being screened
+	mempool.Update([]common.Bytes{createReplacementTestTx("A1", 1, 100)})
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("A1", 3, 100)))
+}
+
+func TestMempoolEviction(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool := CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer0")))
+	mempool.SetLedger(newReplacementTestLedger())
+	mempool.maxNumTxs = 3
+	events := mempool.SubscribeTxEvents(10)
+	defer events.Unsubscribe()
+
+	txA1 := createReplacementTestTx("A1", 1, 100)
+	txA2 := createReplacementTestTx("A1", 2, 500)
+	txB1 := createReplacementTestTx("B1", 1, 300)
+	require.Nil(mempool.InsertTransaction(txA1))
+	require.Nil(mempool.InsertTransaction(txA2))
+	require.Nil(mempool.InsertTransaction(txB1))
+
+	// The last transaction of the group paying the lowest fee must be outbid
+	err := mempool.InsertTransaction(createReplacementTestTx("C1", 1, 500))
+	require.NotNil(err)
+	assert.Equal(result.CodeMempoolFull, err.(ScreeningError).Result.Code)
+
+	txC1 := createReplacementTestTx("C1", 1, 501)
+	require.Nil(mempool.InsertTransaction(txC1))
+	assert.Equal(3, mempool.Size())
+	status, ok := mempool.GetTransactionStatus(getTransactionHash(txA2))
+	assert.True(ok)
+	assert.Equal(TxStatusAbandoned, status)
+
+	for i := 0; i < 3; i++ {
+		<-events.C
+	}
+	event := <-events.C
+	assert.Equal(TxEventDropped, event.Type)
+	assert.Equal(txA2, event.RawTx)
+
+	// The group of the sender is not evicted to make room for its own transaction, which would
+	// leave a sequence gap
+	mempool = CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer1")))
+	mempool.SetLedger(newReplacementTestLedger())
+	mempool.maxNumTxs = 2
+	require.Nil(mempool.InsertTransaction(txA1))
+	require.Nil(mempool.InsertTransaction(txB1))
+	err = mempool.InsertTransaction(createReplacementTestTx("A1", 2, 200))
+	require.NotNil(err)
+	assert.Equal(result.CodeMempoolFull, err.(ScreeningError).Result.Code)
+	require.Nil(mempool.InsertTransaction(createReplacementTestTx("A1", 2, 301)))
+	assert.Equal([]common.Bytes{txA1, createReplacementTestTx("A1", 2, 301)}, mempool.Reap(10))
+}
@@ -3,16 +3,18 @@ package mempool
 import (
 	"context"
 	"encoding/hex"
-	"errors"
 	"math/big"
+	"sort"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/clist"
 	"github.com/thetatoken/theta/common/math"
 	"github.com/thetatoken/theta/common/pqueue"
+	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	dp "github.com/thetatoken/theta/dispatcher"
 )
@@ -27,6 +29,16 @@ func (m MempoolError) Error() string {
 
 const DuplicateTxError = MempoolError("Transaction already seen")
 
+// ScreeningError is returned when a transaction is rejected by the ledger screening. It carries
+// the result of the screening, so that the submitters can tell the rejection reasons apart.
+type ScreeningError struct {
+	Result result.Result
+}
+
+func (e ScreeningError) Error() string {
+	return e.Result.Message
+}
+
 //
 // mempoolTransaction implements the pqueue.Element interface
 //
@@ -99,8 +111,8 @@ func (mtg *mempoolTransactionGroup) IsEmpty() bool {
 	return mtg.txs.IsEmpty()
 }
 
-// RemoveTxs removes matching Txs from transaction group. Returns number of Txs removed.
-func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool) (numRemoved int) {
+// RemoveTxs removes matching Txs from transaction group. Returns the Txs removed.
+func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool) (removed []*mempoolTransaction) {
 	elementList := mtg.txs.ElementList()
 	elemsTobeRemoved := []pqueue.Element{}
 	for _, elem := range *elementList {
@@ -113,7 +125,7 @@ func (mtg *mempoolTransactionGroup) RemoveTxs(committedRawTxMap map[string]bool)
 	}
 	for _, elem := range elemsTobeRemoved {
 		mtg.txs.Remove(elem.GetIndex())
-		numRemoved++
+		removed = append(removed, elem.(*mempoolTransaction))
 	}
 	return
 }
@@ -143,6 +155,22 @@ type Mempool struct {
 	addressToTxGroup map[common.Address]*mempoolTransactionGroup
 	size             int
 
+	// Block assembly
+	reapStrategy  ReapStrategy
+	reapMaxGas    uint64
+	lastReapStats ReapStats
+
+	// Fee replacement
+	replaceFeeBump int // percent
+	replacements   *replacementFeed
+
+	// Pending transaction limits, 0 means unlimited
+	maxPendingTxsPerAccount int
+	maxNumTxs               int
+
+	// Insertions and removals of the transactions
+	txEvents *txEventFeed
+
 	// Life cycle
 	wg      *sync.WaitGroup
 	quit    chan struct{}
@@ -153,14 +181,28 @@ type Mempool struct {
 
 // CreateMempool creates an instance of Mempool
 func CreateMempool(dispatcher *dp.Dispatcher) *Mempool {
+	reapStrategyName := viper.GetString(common.CfgMempoolReapStrategy)
+	reapStrategy, err := NewReapStrategy(reapStrategyName)
+	if err != nil {
+		logger.Warnf("Invalid reap strategy %v, fall back to %v", reapStrategyName, ReapStrategyGreedyFee)
+		reapStrategy = &GreedyFeeReapStrategy{}
+	}
+
 	return &Mempool{
-		mutex:            &sync.Mutex{},
-		dispatcher:       dispatcher,
-		newTxs:           clist.New(),
-		candidateTxs:     pqueue.CreatePriorityQueue(),
-		addressToTxGroup: make(map[common.Address]*mempoolTransactionGroup),
-		txBookeepper:     createTransactionBookkeeper(defaultMaxNumTxs),
-		wg:               &sync.WaitGroup{},
+		mutex:                   &sync.Mutex{},
+		dispatcher:              dispatcher,
+		newTxs:                  clist.New(),
+		candidateTxs:            pqueue.CreatePriorityQueue(),
+		addressToTxGroup:        make(map[common.Address]*mempoolTransactionGroup),
+		txBookeepper:            createTransactionBookkeeper(defaultMaxNumTxs),
+		reapStrategy:            reapStrategy,
+		reapMaxGas:              uint64(viper.GetInt64(common.CfgMempoolReapMaxGas)),
+		replaceFeeBump:          viper.GetInt(common.CfgMempoolReplaceFeeBump),
+		replacements:            newReplacementFeed(),
+		maxPendingTxsPerAccount: viper.GetInt(common.CfgMempoolMaxPendingTxsPerAccount),
+		maxNumTxs:               viper.GetInt(common.CfgMempoolMaxNumTxs),
+		txEvents:                newTxEventFeed(),
+		wg:                      &sync.WaitGroup{},
 	}
 }
 
@@ -169,6 +211,24 @@ func (mp *Mempool) SetLedger(ledger core.Ledger) {
 	mp.ledger = ledger
 }
 
+// SetReapStrategy sets the strategy used to select the transactions for block assembly.
+// maxGas caps the total gas of the reaped transactions, 0 means uncapped.
+func (mp *Mempool) SetReapStrategy(strategy ReapStrategy, maxGas uint64) {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	mp.reapStrategy = strategy
+	mp.reapMaxGas = maxGas
+}
+
+// LastReapStats returns the statistics of the most recent reap
+func (mp *Mempool) LastReapStats() ReapStats {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	return mp.lastReapStats
+}
+
 // InsertTransaction inserts the incoming transaction to mempool (submitted by the clients or relayed from peers)
 func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
 	mp.mutex.Lock()
@@ -180,11 +240,25 @@ func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
 		return DuplicateTxError
 	}
 
+	if txGroup, replaced, txInfo := mp.findReplacedTx(rawTx); replaced != nil {
+		return mp.replaceTransaction(rawTx, txInfo, txGroup, replaced)
+	}
+
+	// The limits are checked before the screening, since the screening advances the screened
+	// state of the sender. The transactions that can not be decoded are rejected by the screening.
+	if txInfo, res := mp.ledger.GetTxInfo(rawTx); res.IsOK() && txInfo != nil {
+		if err := mp.checkLimits(txInfo); err != nil {
+			logger.Debugf("Transaction exceeds the mempool limits, tx: %v, error: %v", hex.EncodeToString(rawTx), err)
+			return err
+		}
+	}
+
 	txInfo, checkTxRes := mp.ledger.ScreenTx(rawTx)
 	if !checkTxRes.IsOK() {
 		logger.Debugf("Transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
-		return errors.New(checkTxRes.Message)
+		return ScreeningError{Result: checkTxRes}
 	}
+	mp.evictForTx(txInfo)
 
 	logger.Infof("Insert tx, tx.hash: 0x%v", getTransactionHash(rawTx))
 	logger.Debugf("rawTx: %v, txInfo: %v", hex.EncodeToString(rawTx), txInfo)
@@ -208,6 +282,35 @@ func (mp *Mempool) InsertTransaction(rawTx common.Bytes) error {
 
 	mp.newTxs.PushBack(rawTx)
 	mp.size++
+	mp.txEvents.publish(TxEventInserted, txInfo.Address, rawTx)
+	return nil
+}
+
+// WouldAccept runs the admission checks of InsertTransaction on the given transaction without
+// inserting it: it returns the error InsertTransaction would return, and modifies neither the
+// mempool nor the screened ledger state.
+func (mp *Mempool) WouldAccept(rawTx common.Bytes) error {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	if mp.txBookeepper.hasSeen(rawTx) {
+		return DuplicateTxError
+	}
+
+	if txGroup, replaced, txInfo := mp.findReplacedTx(rawTx); replaced != nil {
+		_, err := mp.checkReplacement(rawTx, txInfo, txGroup, replaced)
+		return err
+	}
+
+	if txInfo, res := mp.ledger.GetTxInfo(rawTx); res.IsOK() && txInfo != nil {
+		if err := mp.checkLimits(txInfo); err != nil {
+			return err
+		}
+	}
+
+	if _, checkTxRes := mp.ledger.SimulateScreenTx(rawTx); !checkTxRes.IsOK() {
+		return ScreeningError{Result: checkTxRes}
+	}
 	return nil
 }
 
@@ -252,9 +355,10 @@ func (mp *Mempool) Size() int {
 // transactions from the candidate pool. maxNumTxs == 0 means
 // none, maxNumTxs < 0 means uncapped. Note that Reap does NOT remove
 // the transactions from the candidateTxs list. Instead, the consensus engine needs
-// to call the Mempool.Update() function to remove the committed transactions
-// RUNTIME COMPLEXITY: k*log(n), where k is the number transactions to reap,
-// and n is the number of transactions in the candidate pool.
+// to call the Mempool.Update() function to remove the committed transactions.
+// The transactions are selected by the configured ReapStrategy.
+// RUNTIME COMPLEXITY: n*log(n), where n is the number of transactions in the
+// candidate pool.
 func (mp *Mempool) Reap(maxNumTxs int) []common.Bytes {
 	mp.mutex.Lock()
 	defer mp.mutex.Unlock()
@@ -266,36 +370,97 @@ func (mp *Mempool) Reap(maxNumTxs int) []common.Bytes {
 func (mp *Mempool) ReapUnsafe(maxNumTxs int) []common.Bytes {
 	if maxNumTxs == 0 {
 		return []common.Bytes{}
-	} else if maxNumTxs < 0 {
-		maxNumTxs = mp.Size()
-	} else {
-		maxNumTxs = math.MinInt(mp.Size(), maxNumTxs)
 	}
+	maxNumTxs = mp.reapLimit(maxNumTxs)
 
-	txs := make([]common.Bytes, 0, maxNumTxs)
-	for i := 0; i < maxNumTxs; i++ {
-		if mp.candidateTxs.IsEmpty() {
-			break
-		}
-		txGroup := mp.candidateTxs.Pop().(*mempoolTransactionGroup)
-		rawTx, txInfo := txGroup.PopTx()
-		txs = append(txs, rawTx)
+	reaped := mp.reapStrategy.Reap(mp.reapCandidateGroups(), maxNumTxs, mp.reapMaxGas)
 
+	txs := make([]common.Bytes, 0, len(reaped))
+	affectedGroups := make(map[common.Address]*mempoolTransactionGroup)
+	for _, rc := range reaped {
+		txGroup := mp.addressToTxGroup[rc.TxInfo.Address]
+		txGroup.txs.Remove(rc.mptx.GetIndex())
+		affectedGroups[txGroup.address] = txGroup
+		txs = append(txs, rc.RawTx)
+
+		logger.Debugf("Reap tx: %v, txInfo: %v",
+			hex.EncodeToString(rc.RawTx), rc.TxInfo)
+	}
+
+	// The priority of a group changes once its head transaction is reaped
+	for address, txGroup := range affectedGroups {
+		mp.candidateTxs.Remove(txGroup.GetIndex())
 		if txGroup.IsEmpty() {
-			delete(mp.addressToTxGroup, txGroup.address)
+			delete(mp.addressToTxGroup, address)
 		} else {
 			mp.candidateTxs.Push(txGroup)
 		}
-
-		logger.Debugf("Reap tx: %v, txInfo: %v",
-			hex.EncodeToString(rawTx), txInfo)
 	}
 
 	mp.size -= len(txs)
 
+	mp.lastReapStats = newReapStats(mp.reapStrategy.Name(), reaped, maxNumTxs, mp.reapMaxGas)
+	mp.lastReapStats.updateMetrics()
+	logger.Debugf("Reaped transactions: %v", mp.lastReapStats)
+
+	return txs
+}
+
+// PeekUnsafe returns the transactions ReapUnsafe would return, without removing them from
+// the candidate pool. Caller must call Mempool.Lock() before calling this method.
+func (mp *Mempool) PeekUnsafe(maxNumTxs int) []common.Bytes {
+	if maxNumTxs == 0 {
+		return []common.Bytes{}
+	}
+	maxNumTxs = mp.reapLimit(maxNumTxs)
+
+	reaped := mp.reapStrategy.Reap(mp.reapCandidateGroups(), maxNumTxs, mp.reapMaxGas)
+	txs := make([]common.Bytes, 0, len(reaped))
+	for _, rc := range reaped {
+		txs = append(txs, rc.RawTx)
+	}
 	return txs
 }
 
+// reapLimit returns the number of transactions to reap, maxNumTxs < 0 means uncapped.
+func (mp *Mempool) reapLimit(maxNumTxs int) int {
+	if maxNumTxs < 0 {
+		return mp.Size()
+	}
+	return math.MinInt(mp.Size(), maxNumTxs)
+}
+
+// reapCandidateGroups returns a snapshot of the candidate transactions. The groups are
+// ordered by the effective gas price of their first transaction (high to low), and the
+// transactions within a group are ordered by sequence.
+func (mp *Mempool) reapCandidateGroups() []*ReapCandidateGroup {
+	txGroups := make([]*mempoolTransactionGroup, 0, mp.candidateTxs.NumElements())
+	for _, elem := range *mp.candidateTxs.ElementList() {
+		txGroups = append(txGroups, elem.(*mempoolTransactionGroup))
+	}
+	sort.SliceStable(txGroups, func(i, j int) bool {
+		return txGroups[i].Priority().Cmp(txGroups[j].Priority()) > 0
+	})
+
+	groups := make([]*ReapCandidateGroup, 0, len(txGroups))
+	for _, txGroup := range txGroups {
+		group := &ReapCandidateGroup{Address: txGroup.address}
+		for _, elem := range *txGroup.txs.ElementList() {
+			mptx := elem.(*mempoolTransaction)
+			group.Txs = append(group.Txs, &ReapCandidate{
+				RawTx:  mptx.rawTransaction,
+				TxInfo: mptx.txInfo,
+				mptx:   mptx,
+			})
+		}
+		sort.SliceStable(group.Txs, func(i, j int) bool {
+			return group.Txs[i].TxInfo.Sequence < group.Txs[j].TxInfo.Sequence
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
 // Update removes the committed transactions from the transaction candidate list
 // RUNTIME COMPLEXITY: O(k + n), where k is the number committed raw transactions,
 // and n is the number of transactions in the candidate pool.
@@ -308,7 +473,7 @@ func (mp *Mempool) Update(committedRawTxs []common.Bytes) {
 // UpdateUnsafe is the non-locking version of Update. Caller must call Mempool.Lock() before
 // calling this method.
 func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) {
-	mp.removeTxs(committedRawTxs)
+	mp.removeTxs(committedRawTxs, TxEventCommitted)
 
 	// Remove Txs that have become obsolete.
 	invalidTxs := []common.Bytes{}
@@ -321,15 +486,19 @@ func (mp *Mempool) UpdateUnsafe(committedRawTxs []common.Bytes) {
 			checkTxRes := mp.ledger.ScreenTxUnsafe(mempoolTx.rawTransaction)
 			if !checkTxRes.IsOK() {
 				invalidTxs = append(invalidTxs, mempoolTx.rawTransaction)
-				mp.txBookeepper.markAbandoned(mempoolTx.rawTransaction)
+				if checkTxRes.Code == result.CodeTxExpired {
+					mp.txBookeepper.markExpired(mempoolTx.rawTransaction)
+				} else {
+					mp.txBookeepper.markAbandoned(mempoolTx.rawTransaction)
+				}
 			}
 		}
 	}
 	logger.Debugf("Removing %d obsolete Txs: %v", len(invalidTxs), invalidTxs)
-	mp.removeTxs(invalidTxs)
+	mp.removeTxs(invalidTxs, TxEventDropped)
 }
 
-func (mp *Mempool) removeTxs(committedRawTxs []common.Bytes) {
+func (mp *Mempool) removeTxs(committedRawTxs []common.Bytes, eventType TxEventType) {
 	committedRawTxMap := make(map[string]bool)
 	for _, rawtx := range committedRawTxs {
 		committedRawTxMap[string(rawtx)] = true
@@ -339,8 +508,11 @@ func (mp *Mempool) removeTxs(committedRawTxs []common.Bytes) {
 	elemsTobeRemoved := []pqueue.Element{}
 	for _, elem := range *elementList {
 		txGroup := elem.(*mempoolTransactionGroup)
-		numRemoved := txGroup.RemoveTxs(committedRawTxMap)
-		mp.size -= numRemoved
+		removed := txGroup.RemoveTxs(committedRawTxMap)
+		mp.size -= len(removed)
+		for _, mptx := range removed {
+			mp.txEvents.publish(eventType, txGroup.address, mptx.rawTransaction)
+		}
 		if txGroup.IsEmpty() {
 			delete(mp.addressToTxGroup, txGroup.address)
 			elemsTobeRemoved = append(elemsTobeRemoved, txGroup)
@@ -379,6 +551,21 @@ func (mp *Mempool) GetCandidateTransactionHashes() []string {
 	return txHashes
 }
 
+// getCandidateRawTxs returns the raw transactions in the candidate pool.
+func (mp *Mempool) getCandidateRawTxs() []common.Bytes {
+	mp.mutex.Lock()
+	defer mp.mutex.Unlock()
+
+	rawTxs := []common.Bytes{}
+	for _, txgElem := range *mp.candidateTxs.ElementList() {
+		txg := txgElem.(*mempoolTransactionGroup)
+		for _, txElem := range *txg.txs.ElementList() {
+			rawTxs = append(rawTxs, txElem.(*mempoolTransaction).rawTransaction)
+		}
+	}
+	return rawTxs
+}
+
 // Flush removes all transactions from the Mempool and the transactionBookkeeper
 func (mp *Mempool) Flush() {
 	mp.mutex.Lock()
@@ -10,6 +10,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
@@ -391,6 +392,47 @@ func TestMempoolTransactionGossip(t *testing.T) {
 	}
 }
 
+func TestMempoolWouldAccept(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool := CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer0")))
+	ledger := newReplacementTestLedger()
+	mempool.SetLedger(ledger)
+	mempool.replaceFeeBump = 10
+
+	tx1 := createReplacementTestTx("A1", 1, 100)
+	require.Nil(mempool.InsertTransaction(tx1))
+
+	assert.Equal(DuplicateTxError, mempool.WouldAccept(tx1))
+
+	// The screened state is left untouched
+	tx2 := createReplacementTestTx("A1", 2, 100)
+	assert.Nil(mempool.WouldAccept(tx2))
+	assert.Nil(mempool.WouldAccept(tx2))
+	assert.Equal(uint64(1), ledger.sequences[common.HexToAddress("A1")])
+	assert.Equal(1, mempool.Size())
+
+	err := mempool.WouldAccept(createReplacementTestTx("A1", 3, 100))
+	require.NotNil(err)
+	assert.Equal(result.CodeInvalidSequence, err.(ScreeningError).Result.Code)
+
+	err = mempool.WouldAccept(createReplacementTestTx("A1", 1, 105))
+	require.NotNil(err)
+	assert.Equal(result.CodeReplacementFeeTooLow, err.(ScreeningError).Result.Code)
+	assert.Nil(mempool.WouldAccept(createReplacementTestTx("A1", 1, 200)))
+
+	mempool.maxPendingTxsPerAccount = 1
+	err = mempool.WouldAccept(tx2)
+	require.NotNil(err)
+	assert.Equal(result.CodeTooManyPendingTxs, err.(ScreeningError).Result.Code)
+
+	mempool.maxPendingTxsPerAccount = 0
+	require.Nil(mempool.InsertTransaction(tx2))
+	assert.Equal(2, mempool.Size())
+}
+
 // --------------- Test Utilities --------------- //
 
 func newTestMempool(peerID string, simnet *p2psim.Simnet) (*Mempool, context.Context) {
@@ -470,6 +512,18 @@ func (tl *TestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result)
 	return txInfo, result.OK
 }
 
+func (tl *TestLedger) ScreenReplacementTx(rawTx common.Bytes, precedingRawTxs []common.Bytes) (*core.TxInfo, result.Result) {
+	return tl.ScreenTx(rawTx)
+}
+
+func (tl *TestLedger) SimulateScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return tl.ScreenTx(rawTx)
+}
+
+func (tl *TestLedger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	return nil, result.Error("The test ledger does not decode transactions")
+}
+
 func (tl *TestLedger) GetCurrentBlock() *core.Block {
 	return nil
 }
@@ -494,8 +548,8 @@ func (tl *TestLedger) GetFinalizedValidatorCandidatePool(blockHash common.Hash,
 	return nil, nil
 }
 
-func (tl *TestLedger) PruneState(endHeight uint64) error {
-	return nil
+func (tl *TestLedger) GetNodeAddress(blockHash common.Hash, validator common.Address) (*core.NodeAddress, error) {
+	return nil, nil
 }
 
 type TestNetworkMessageInterceptor struct {
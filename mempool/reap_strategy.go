@@ -0,0 +1,405 @@
+package mempool
+
+import (
+	"container/heap"
+	"fmt"
+	"math/big"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/core"
+)
+
+const (
+	// ReapStrategyGreedyFee selects transactions strictly by effective gas price (high to low)
+	ReapStrategyGreedyFee = "greedy_fee"
+
+	// ReapStrategyKnapsackGas maximizes the total fee under the gas limit, taking chains of
+	// transactions from the same sender into account
+	ReapStrategyKnapsackGas = "knapsack_gas"
+
+	// ReapStrategyRoundRobin selects one transaction per sender in each round, so that a
+	// single sender cannot monopolize a block
+	ReapStrategyRoundRobin = "round_robin"
+)
+
+var (
+	reapNumTxsGauge   = metrics.NewRegisteredGauge("mempool/reap/numtxs", nil)
+	reapGasGauge      = metrics.NewRegisteredGauge("mempool/reap/gas", nil)
+	reapFullnessGauge = metrics.NewRegisteredGauge("mempool/reap/fullness", nil)
+)
+
+//
+// ReapCandidate is a pending transaction that can be selected by a ReapStrategy
+//
+type ReapCandidate struct {
+	RawTx  common.Bytes
+	TxInfo *core.TxInfo
+
+	mptx *mempoolTransaction
+}
+
+// Fee returns the fee paid by the transaction, i.e. effective gas price * gas
+func (rc *ReapCandidate) Fee() *big.Int {
+	if rc.TxInfo.EffectiveGasPrice == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(rc.TxInfo.EffectiveGasPrice, new(big.Int).SetUint64(rc.TxInfo.Gas))
+}
+
+//
+// ReapCandidateGroup holds the pending transactions of one sender ordered by sequence.
+// A ReapStrategy may only select a prefix of Txs.
+//
+type ReapCandidateGroup struct {
+	Address common.Address
+	Txs     []*ReapCandidate
+}
+
+//
+// ReapStrategy determines how the proposer selects transactions from the mempool for the next block
+//
+type ReapStrategy interface {
+	// Name returns the name of the strategy used in the configuration
+	Name() string
+
+	// Reap selects at most maxNumTxs transactions whose total gas does not exceed maxGas
+	// (0 means unlimited). The groups are ordered by the effective gas price of their first
+	// transaction (high to low).
+	Reap(groups []*ReapCandidateGroup, maxNumTxs int, maxGas uint64) []*ReapCandidate
+}
+
+// NewReapStrategy creates the ReapStrategy with the given name
+func NewReapStrategy(name string) (ReapStrategy, error) {
+	switch name {
+	case ReapStrategyGreedyFee:
+		return &GreedyFeeReapStrategy{}, nil
+	case ReapStrategyKnapsackGas:
+		return &KnapsackGasReapStrategy{}, nil
+	case ReapStrategyRoundRobin:
+		return &RoundRobinReapStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("Unknown reap strategy: %v", name)
+	}
+}
+
+//
+// ReapStats records the outcome of a reap, for tuning the reap strategy
+//
+type ReapStats struct {
+	Strategy  string
+	NumTxs    int
+	Gas       uint64
+	Fees      *big.Int
+	MaxNumTxs int
+	MaxGas    uint64
+}
+
+func newReapStats(strategy string, reaped []*ReapCandidate, maxNumTxs int, maxGas uint64) ReapStats {
+	stats := ReapStats{
+		Strategy:  strategy,
+		NumTxs:    len(reaped),
+		Fees:      new(big.Int),
+		MaxNumTxs: maxNumTxs,
+		MaxGas:    maxGas,
+	}
+	for _, rc := range reaped {
+		stats.Gas += rc.TxInfo.Gas
+		stats.Fees.Add(stats.Fees, rc.Fee())
+	}
+	return stats
+}
+
+// Fullness returns how full the block is in percentage, measured by gas if the
+// gas is capped, or by the number of transactions otherwise.
+func (rs ReapStats) Fullness() int64 {
+	if rs.MaxGas > 0 {
+		return int64(rs.Gas * 100 / rs.MaxGas)
+	}
+	if rs.MaxNumTxs > 0 {
+		return int64(rs.NumTxs * 100 / rs.MaxNumTxs)
+	}
+	return 0
+}
+
+func (rs ReapStats) String() string {
+	return fmt.Sprintf("ReapStats{strategy: %v, numTxs: %v, gas: %v, fees: %v, fullness: %v%%}",
+		rs.Strategy, rs.NumTxs, rs.Gas, rs.Fees, rs.Fullness())
+}
+
+func (rs ReapStats) updateMetrics() {
+	reapNumTxsGauge.Update(int64(rs.NumTxs))
+	reapGasGauge.Update(int64(rs.Gas))
+	reapFullnessGauge.Update(rs.Fullness())
+}
+
+// reapBudget keeps track of the remaining capacity of the block being assembled
+type reapBudget struct {
+	numTxs int
+	gas    uint64 // only meaningful if capped
+	capped bool
+}
+
+func newReapBudget(maxNumTxs int, maxGas uint64) *reapBudget {
+	return &reapBudget{
+		numTxs: maxNumTxs,
+		gas:    maxGas,
+		capped: maxGas > 0,
+	}
+}
+
+func (b *reapBudget) exhausted() bool {
+	return b.numTxs <= 0
+}
+
+func (b *reapBudget) fits(rc *ReapCandidate) bool {
+	return b.numTxs > 0 && (!b.capped || rc.TxInfo.Gas <= b.gas)
+}
+
+func (b *reapBudget) consume(rc *ReapCandidate) {
+	b.numTxs--
+	if b.capped {
+		b.gas -= rc.TxInfo.Gas
+	}
+}
+
+//
+// GreedyFeeReapStrategy always picks the pending transaction with the highest effective gas
+// price. A sender whose next transaction does not fit into the remaining gas is skipped.
+//
+type GreedyFeeReapStrategy struct{}
+
+var _ ReapStrategy = (*GreedyFeeReapStrategy)(nil)
+
+// Name implements the ReapStrategy interface
+func (s *GreedyFeeReapStrategy) Name() string {
+	return ReapStrategyGreedyFee
+}
+
+// Reap implements the ReapStrategy interface
+func (s *GreedyFeeReapStrategy) Reap(groups []*ReapCandidateGroup, maxNumTxs int, maxGas uint64) []*ReapCandidate {
+	budget := newReapBudget(maxNumTxs, maxGas)
+	cursors := newGroupCursorHeap(groups, func(c *groupCursor) (*big.Int, *big.Int) {
+		return c.head().TxInfo.EffectiveGasPrice, common.Big1
+	})
+
+	reaped := []*ReapCandidate{}
+	for cursors.Len() > 0 && !budget.exhausted() {
+		cursor := heap.Pop(cursors).(*groupCursor)
+		rc := cursor.head()
+		if !budget.fits(rc) {
+			continue
+		}
+		budget.consume(rc)
+		reaped = append(reaped, rc)
+		cursor.pos++
+		if !cursor.done() {
+			heap.Push(cursors, cursor)
+		}
+	}
+	return reaped
+}
+
+//
+// KnapsackGasReapStrategy approximates the 0/1 knapsack problem with the gas limit as the
+// capacity and the fees as the values. Since the transactions of a sender have to be
+// included in sequence order, it ranks senders by the best average gas price over a prefix
+// of their pending transactions, so that a high-fee transaction can pull in its low-fee
+// predecessors.
+//
+type KnapsackGasReapStrategy struct{}
+
+var _ ReapStrategy = (*KnapsackGasReapStrategy)(nil)
+
+// Name implements the ReapStrategy interface
+func (s *KnapsackGasReapStrategy) Name() string {
+	return ReapStrategyKnapsackGas
+}
+
+// Reap implements the ReapStrategy interface
+func (s *KnapsackGasReapStrategy) Reap(groups []*ReapCandidateGroup, maxNumTxs int, maxGas uint64) []*ReapCandidate {
+	budget := newReapBudget(maxNumTxs, maxGas)
+	cursors := newGroupCursorHeap(groups, func(c *groupCursor) (*big.Int, *big.Int) {
+		c.packageLen, c.packageFee, c.packageGas = bestPackage(c.group.Txs[c.pos:])
+		return c.packageFee, c.packageGas
+	})
+
+	reaped := []*ReapCandidate{}
+	for cursors.Len() > 0 && !budget.exhausted() {
+		cursor := heap.Pop(cursors).(*groupCursor)
+		numTaken := 0
+		for i := 0; i < cursor.packageLen; i++ {
+			rc := cursor.head()
+			if !budget.fits(rc) {
+				break
+			}
+			budget.consume(rc)
+			reaped = append(reaped, rc)
+			cursor.pos++
+			numTaken++
+		}
+		if numTaken == 0 || cursor.done() {
+			continue // the rest of the sender's transactions cannot be included
+		}
+		cursors.push(cursor)
+	}
+	return reaped
+}
+
+// bestPackage returns the length, total fee and total gas of the prefix of txs with the highest
+// average gas price.
+func bestPackage(txs []*ReapCandidate) (length int, fee *big.Int, gas *big.Int) {
+	fee, gas = new(big.Int), new(big.Int)
+	currFee, currGas := new(big.Int), new(big.Int)
+	for i, rc := range txs {
+		currFee.Add(currFee, rc.Fee())
+		currGas.Add(currGas, new(big.Int).SetUint64(rc.TxInfo.Gas))
+		if length == 0 || compareRatio(currFee, currGas, fee, gas) > 0 {
+			length = i + 1
+			fee.Set(currFee)
+			gas.Set(currGas)
+		}
+	}
+	return length, fee, gas
+}
+
+//
+// RoundRobinReapStrategy takes one transaction from each sender per round, with the senders
+// ordered by the effective gas price of their first transaction.
+//
+type RoundRobinReapStrategy struct{}
+
+var _ ReapStrategy = (*RoundRobinReapStrategy)(nil)
+
+// Name implements the ReapStrategy interface
+func (s *RoundRobinReapStrategy) Name() string {
+	return ReapStrategyRoundRobin
+}
+
+// Reap implements the ReapStrategy interface
+func (s *RoundRobinReapStrategy) Reap(groups []*ReapCandidateGroup, maxNumTxs int, maxGas uint64) []*ReapCandidate {
+	budget := newReapBudget(maxNumTxs, maxGas)
+	cursors := []*groupCursor{}
+	for _, group := range groups {
+		if len(group.Txs) > 0 {
+			cursors = append(cursors, &groupCursor{group: group})
+		}
+	}
+
+	reaped := []*ReapCandidate{}
+	for len(cursors) > 0 && !budget.exhausted() {
+		remaining := cursors[:0]
+		for _, cursor := range cursors {
+			rc := cursor.head()
+			if !budget.fits(rc) {
+				continue
+			}
+			budget.consume(rc)
+			reaped = append(reaped, rc)
+			cursor.pos++
+			if !cursor.done() {
+				remaining = append(remaining, cursor)
+			}
+		}
+		cursors = remaining
+	}
+	return reaped
+}
+
+//
+// groupCursor tracks the next transaction to be selected from a ReapCandidateGroup
+//
+type groupCursor struct {
+	group *ReapCandidateGroup
+	pos   int
+	rank  int // position of the group in the input, used to break ties deterministically
+
+	// priority of the cursor represented as a ratio, i.e. num / den
+	num *big.Int
+	den *big.Int
+
+	// used by KnapsackGasReapStrategy
+	packageLen int
+	packageFee *big.Int
+	packageGas *big.Int
+}
+
+func (c *groupCursor) head() *ReapCandidate {
+	return c.group.Txs[c.pos]
+}
+
+func (c *groupCursor) done() bool {
+	return c.pos >= len(c.group.Txs)
+}
+
+// groupCursorHeap implements heap.Interface, ordering the cursors by priority (high to low)
+type groupCursorHeap struct {
+	cursors  []*groupCursor
+	priority func(c *groupCursor) (num *big.Int, den *big.Int)
+}
+
+func newGroupCursorHeap(groups []*ReapCandidateGroup, priority func(c *groupCursor) (*big.Int, *big.Int)) *groupCursorHeap {
+	h := &groupCursorHeap{priority: priority}
+	for rank, group := range groups {
+		if len(group.Txs) == 0 {
+			continue
+		}
+		cursor := &groupCursor{group: group, rank: rank}
+		cursor.num, cursor.den = priority(cursor)
+		h.cursors = append(h.cursors, cursor)
+	}
+	heap.Init(h)
+	return h
+}
+
+// push re-evaluates the priority of the cursor and inserts it into the heap
+func (h *groupCursorHeap) push(c *groupCursor) {
+	heap.Push(h, c)
+}
+
+func (h groupCursorHeap) Len() int { return len(h.cursors) }
+
+func (h groupCursorHeap) Less(i, j int) bool {
+	ci, cj := h.cursors[i], h.cursors[j]
+	cmp := compareRatio(ci.num, ci.den, cj.num, cj.den)
+	if cmp != 0 {
+		return cmp > 0
+	}
+	return ci.rank < cj.rank
+}
+
+func (h groupCursorHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+
+func (h *groupCursorHeap) Push(x interface{}) {
+	c := x.(*groupCursor)
+	c.num, c.den = h.priority(c)
+	h.cursors = append(h.cursors, c)
+}
+
+func (h *groupCursorHeap) Pop() interface{} {
+	n := len(h.cursors)
+	c := h.cursors[n-1]
+	h.cursors = h.cursors[:n-1]
+	return c
+}
+
+// compareRatio compares num1/den1 with num2/den2. Non-positive denominators are treated as 1.
+func compareRatio(num1, den1, num2, den2 *big.Int) int {
+	if num1 == nil {
+		num1 = common.Big0
+	}
+	if num2 == nil {
+		num2 = common.Big0
+	}
+	if den1 == nil || den1.Sign() <= 0 {
+		den1 = common.Big1
+	}
+	if den2 == nil || den2.Sign() <= 0 {
+		den2 = common.Big1
+	}
+	lhs := new(big.Int).Mul(num1, den2)
+	rhs := new(big.Int).Mul(num2, den1)
+	return lhs.Cmp(rhs)
+}
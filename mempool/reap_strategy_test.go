@@ -0,0 +1,157 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+func TestNewReapStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{ReapStrategyGreedyFee, ReapStrategyKnapsackGas, ReapStrategyRoundRobin} {
+		strategy, err := NewReapStrategy(name)
+		assert.Nil(err)
+		assert.Equal(name, strategy.Name())
+	}
+
+	_, err := NewReapStrategy("unknown")
+	assert.NotNil(err)
+}
+
+func TestGreedyFeeReapStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	groups := []*ReapCandidateGroup{
+		newTestReapCandidateGroup("A", []int64{30, 10}, []uint64{100, 100}),
+		newTestReapCandidateGroup("B", []int64{20, 40}, []uint64{100, 100}),
+	}
+	strategy := &GreedyFeeReapStrategy{}
+
+	assert.Equal([]string{"A1", "B1", "B2", "A2"}, reapedTxNames(strategy.Reap(groups, 10, 0)))
+	assert.Equal([]string{"A1", "B1"}, reapedTxNames(strategy.Reap(groups, 2, 0)))
+	assert.Equal([]string{"A1", "B1"}, reapedTxNames(strategy.Reap(groups, 10, 250)))
+}
+
+func TestKnapsackGasReapStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	// B1 has a low gas price, but it unlocks B2 with a high gas price
+	groups := []*ReapCandidateGroup{
+		newTestReapCandidateGroup("A", []int64{30, 10}, []uint64{100, 100}),
+		newTestReapCandidateGroup("B", []int64{20, 60}, []uint64{100, 100}),
+		newTestReapCandidateGroup("C", []int64{5}, []uint64{50}),
+	}
+	strategy := &KnapsackGasReapStrategy{}
+
+	assert.Equal([]string{"B1", "B2", "A1", "A2", "C1"}, reapedTxNames(strategy.Reap(groups, 10, 0)))
+	assert.Equal([]string{"B1", "B2", "C1"}, reapedTxNames(strategy.Reap(groups, 10, 250)))
+	assert.Equal([]string{"B1"}, reapedTxNames(strategy.Reap(groups, 1, 0)))
+
+	greedyStats := newReapStats(ReapStrategyGreedyFee, (&GreedyFeeReapStrategy{}).Reap(groups, 10, 250), 10, 250)
+	knapsackStats := newReapStats(ReapStrategyKnapsackGas, strategy.Reap(groups, 10, 250), 10, 250)
+	assert.True(knapsackStats.Fees.Cmp(greedyStats.Fees) > 0)
+}
+
+func TestRoundRobinReapStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	groups := []*ReapCandidateGroup{
+		newTestReapCandidateGroup("A", []int64{30, 30, 30}, []uint64{100, 100, 100}),
+		newTestReapCandidateGroup("B", []int64{20}, []uint64{100}),
+		newTestReapCandidateGroup("C", []int64{10, 10}, []uint64{100, 300}),
+	}
+	strategy := &RoundRobinReapStrategy{}
+
+	assert.Equal([]string{"A1", "B1", "C1", "A2", "C2", "A3"}, reapedTxNames(strategy.Reap(groups, 10, 0)))
+	assert.Equal([]string{"A1", "B1", "C1", "A2"}, reapedTxNames(strategy.Reap(groups, 4, 0)))
+	assert.Equal([]string{"A1", "B1", "C1", "A2", "A3"}, reapedTxNames(strategy.Reap(groups, 10, 500)))
+}
+
+func TestReapStats(t *testing.T) {
+	assert := assert.New(t)
+
+	group := newTestReapCandidateGroup("A", []int64{10, 20}, []uint64{100, 300})
+	stats := newReapStats(ReapStrategyGreedyFee, group.Txs, 10, 800)
+	assert.Equal(2, stats.NumTxs)
+	assert.Equal(uint64(400), stats.Gas)
+	assert.Equal(big.NewInt(7000), stats.Fees)
+	assert.Equal(int64(50), stats.Fullness())
+
+	stats = newReapStats(ReapStrategyGreedyFee, group.Txs, 8, 0)
+	assert.Equal(int64(25), stats.Fullness())
+}
+
+func BenchmarkReapGreedyFee(b *testing.B) {
+	benchmarkReapStrategy(b, &GreedyFeeReapStrategy{})
+}
+
+func BenchmarkReapKnapsackGas(b *testing.B) {
+	benchmarkReapStrategy(b, &KnapsackGasReapStrategy{})
+}
+
+func BenchmarkReapRoundRobin(b *testing.B) {
+	benchmarkReapStrategy(b, &RoundRobinReapStrategy{})
+}
+
+func benchmarkReapStrategy(b *testing.B, strategy ReapStrategy) {
+	maxNumTxs := core.MaxNumRegularTxsPerBlock
+	maxGas := uint64(20000000)
+	groups := newRandomReapCandidateGroups(2000, 10)
+
+	var stats ReapStats
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reaped := strategy.Reap(groups, maxNumTxs, maxGas)
+		stats = newReapStats(strategy.Name(), reaped, maxNumTxs, maxGas)
+	}
+	b.StopTimer()
+
+	b.Logf("%v", stats)
+}
+
+func newTestReapCandidateGroup(name string, gasPrices []int64, gas []uint64) *ReapCandidateGroup {
+	address := common.BytesToAddress([]byte(name))
+	group := &ReapCandidateGroup{Address: address}
+	for i := range gasPrices {
+		group.Txs = append(group.Txs, &ReapCandidate{
+			RawTx: common.Bytes(fmt.Sprintf("%v%v", name, i+1)),
+			TxInfo: &core.TxInfo{
+				Address:           address,
+				Sequence:          uint64(i + 1),
+				EffectiveGasPrice: big.NewInt(gasPrices[i]),
+				Gas:               gas[i],
+			},
+		})
+	}
+	return group
+}
+
+func newRandomReapCandidateGroups(numSenders, maxTxsPerSender int) []*ReapCandidateGroup {
+	rng := rand.New(rand.NewSource(1))
+	groups := []*ReapCandidateGroup{}
+	for i := 0; i < numSenders; i++ {
+		numTxs := rng.Intn(maxTxsPerSender) + 1
+		gasPrices := make([]int64, numTxs)
+		gas := make([]uint64, numTxs)
+		for j := 0; j < numTxs; j++ {
+			gasPrices[j] = rng.Int63n(1000000) + 1
+			gas[j] = uint64(rng.Intn(100000) + 10000)
+		}
+		groups = append(groups, newTestReapCandidateGroup(fmt.Sprintf("sender%v", i), gasPrices, gas))
+	}
+	return groups
+}
+
+func reapedTxNames(reaped []*ReapCandidate) []string {
+	names := []string{}
+	for _, rc := range reaped {
+		names = append(names, string(rc.RawTx))
+	}
+	return names
+}
@@ -0,0 +1,495 @@
+package mempool
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/common/ratelimit"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+const (
+	// Number of cells of the first sketch sent to a peer, when few differences are expected
+	minSketchCells = 30
+	// Number of cells beyond which the reconciliation with a peer is given up
+	maxSketchCells = 3 * 1024
+	// Maximum number of transactions sent or requested in one message
+	maxReconcileTxs = 1024
+	// Maximum number of sketches, retries and transaction requests handled from a peer per
+	// reconciliation interval. A reconciliation takes up to 7 sketches until maxSketchCells.
+	maxPeerRequests = 16
+)
+
+var (
+	reconcileRoundsCounter    = metrics.NewRegisteredCounter("mempool/reconcile/rounds", nil)
+	reconcileRetriesCounter   = metrics.NewRegisteredCounter("mempool/reconcile/retries", nil)
+	reconcileFailuresCounter  = metrics.NewRegisteredCounter("mempool/reconcile/failures", nil)
+	reconcileRecoveredCounter = metrics.NewRegisteredCounter("mempool/reconcile/recovered", nil)
+	reconcileThrottledCounter = metrics.NewRegisteredCounter("mempool/reconcile/throttled", nil)
+)
+
+// ReconcileMessageType defines the types of the reconciliation messages
+type ReconcileMessageType byte
+
+const (
+	reconcileSketchType    ReconcileMessageType = 0x01
+	reconcileRetryType     ReconcileMessageType = 0x02
+	reconcileTxRequestType ReconcileMessageType = 0x03
+	reconcileTxBatchType   ReconcileMessageType = 0x04
+)
+
+// ReconcileMessage is the envelope of the reconciliation messages, the payload is the RLP
+// encoding of a SketchMessage, a RetryMessage, a TxRequestMessage or a TxBatchMessage.
+type ReconcileMessage struct {
+	Type    ReconcileMessageType
+	Payload common.Bytes
+}
+
+// SketchMessage starts a reconciliation with the sketch of the short IDs of the candidate
+// transactions of the sender.
+type SketchMessage struct {
+	Salt   uint64
+	Sketch Sketch
+}
+
+// RetryMessage asks for a larger sketch, when the difference could not be decoded.
+type RetryMessage struct {
+	NumCells uint64
+}
+
+// TxRequestMessage asks for the transactions with the given short IDs, salted as in the sketch.
+type TxRequestMessage struct {
+	Salt     uint64
+	ShortIDs []uint64
+}
+
+// TxBatchMessage holds the raw transactions missing from the receiver's mempool.
+type TxBatchMessage struct {
+	Txs []common.Bytes
+}
+
+// Transport sends the reconciliation messages to the peers, see dispatcher.Dispatcher.
+type Transport interface {
+	SendData(peerIDs []string, datarsp dp.DataResponse)
+	PeerStats() []p2ptypes.PeerStats
+}
+
+// Reconciler recovers the transactions missed by the gossip. Periodically, it sends a sketch of
+// the transactions of the mempool to one of the peers, in turn. The peer subtracts the sketch of
+// its own transactions, decodes the difference, and sends the transactions it has which are
+// missing from the sketch, and requests the ones it misses. The size of the sketch only depends
+// on the size of the difference, estimated from the previous reconciliation with the peer, and
+// doubled whenever the difference cannot be decoded.
+type Reconciler struct {
+	mempool   *Mempool
+	transport Transport
+	interval  time.Duration
+
+	mu       *sync.Mutex
+	nextPeer int
+	diffs    map[string]int                    // peer ID -> number of transactions exchanged since the last sketch sent
+	salts    map[string]uint64                 // peer ID -> salt of the last reconciliation started with the peer
+	requests map[string]*ratelimit.TokenBucket // peer ID -> requests handled from the peer
+	cache    map[uint64]*saltedTxs             // salt -> local transactions by short ID
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewReconciler creates a new instance of Reconciler, which reconciles the mempool with a peer
+// at every interval.
+func NewReconciler(mempool *Mempool, transport Transport, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		mempool:   mempool,
+		transport: transport,
+		interval:  interval,
+		mu:        &sync.Mutex{},
+		diffs:     make(map[string]int),
+		salts:     make(map[string]uint64),
+		requests:  make(map[string]*ratelimit.TokenBucket),
+		cache:     make(map[uint64]*saltedTxs),
+		wg:        &sync.WaitGroup{},
+	}
+}
+
+// saltedTxs are the candidate transactions by short ID, for a salt.
+type saltedTxs struct {
+	rawTxs map[uint64]common.Bytes
+	expiry time.Time
+}
+
+// Start creates the main goroutine.
+func (r *Reconciler) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	r.ctx = c
+	r.cancel = cancel
+
+	r.wg.Add(1)
+	go r.mainLoop()
+}
+
+// Stop notifies the main goroutine to stop without blocking.
+func (r *Reconciler) Stop() {
+	r.cancel()
+}
+
+// Wait blocks until the main goroutine stops.
+func (r *Reconciler) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Reconciler) mainLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reconcile()
+		}
+	}
+}
+
+// Reconcile starts a reconciliation with the next peer.
+func (r *Reconciler) Reconcile() {
+	peerID := r.pickPeer()
+	if len(peerID) == 0 {
+		return
+	}
+	salt, err := newSalt()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to generate the reconciliation salt")
+		return
+	}
+	r.mu.Lock()
+	r.salts[peerID] = salt
+	r.mu.Unlock()
+
+	reconcileRoundsCounter.Inc(1)
+	r.sendSketch(peerID, salt, r.sketchCells(peerID))
+}
+
+// pickPeer returns the connected peers in turn, and forgets the disconnected ones.
+func (r *Reconciler) pickPeer() string {
+	peerIDs := []string{}
+	connected := make(map[string]bool)
+	for _, stats := range r.transport.PeerStats() {
+		peerIDs = append(peerIDs, stats.PeerID)
+		connected[stats.PeerID] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for peerID := range r.requests {
+		if !connected[peerID] {
+			delete(r.requests, peerID)
+		}
+	}
+	for peerID := range r.salts {
+		if !connected[peerID] {
+			delete(r.salts, peerID)
+		}
+	}
+
+	if len(peerIDs) == 0 {
+		return ""
+	}
+	sort.Strings(peerIDs)
+	r.nextPeer = (r.nextPeer + 1) % len(peerIDs)
+	return peerIDs[r.nextPeer]
+}
+
+// sketchCells sizes the sketch sent to the peer after the difference found by the last
+// reconciliation with the peer, with some margin for the decoding to succeed.
+func (r *Reconciler) sketchCells(peerID string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	numCells := 2 * r.diffs[peerID]
+	delete(r.diffs, peerID)
+	if numCells < minSketchCells {
+		return minSketchCells
+	}
+	if numCells > maxSketchCells {
+		return maxSketchCells
+	}
+	return numCells
+}
+
+func (r *Reconciler) recordDiff(peerID string, numTxs int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.diffs[peerID] += numTxs
+}
+
+// allowRequest returns whether a request of the peer, which takes a local sketch, is within
+// the rate limit of the peer.
+func (r *Reconciler) allowRequest(peerID string) bool {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.requests[peerID]
+	if !ok {
+		bucket = ratelimit.NewTokenBucket(maxPeerRequests, r.interval, now)
+		r.requests[peerID] = bucket
+	}
+	if !bucket.Available(1, now) {
+		return false
+	}
+	bucket.Take(1)
+	return true
+}
+
+// localSketch returns the sketch of the candidate transactions, and the transactions by short ID.
+func (r *Reconciler) localSketch(salt uint64, numCells int) (*Sketch, map[uint64]common.Bytes) {
+	sketch := NewSketch(numCells)
+	rawTxs := r.saltedTxs(salt)
+	for id := range rawTxs {
+		sketch.Add(id)
+	}
+	return sketch, rawTxs
+}
+
+// saltedTxs returns the candidate transactions by short ID. They are cached for a reconciliation
+// interval, since all the sketches of a reconciliation, and the request of the missing
+// transactions, share the salt.
+func (r *Reconciler) saltedTxs(salt uint64) map[uint64]common.Bytes {
+	now := time.Now()
+
+	r.mu.Lock()
+	for cachedSalt, cached := range r.cache {
+		if now.After(cached.expiry) {
+			delete(r.cache, cachedSalt)
+		}
+	}
+	cached, ok := r.cache[salt]
+	r.mu.Unlock()
+	if ok {
+		return cached.rawTxs
+	}
+
+	rawTxs := make(map[uint64]common.Bytes)
+	for _, rawTx := range r.mempool.getCandidateRawTxs() {
+		id := shortTxID(salt, rawTx)
+		if _, ok := rawTxs[id]; ok {
+			continue // the salt makes such collisions unlikely, and different in the next reconciliation
+		}
+		rawTxs[id] = rawTx
+	}
+
+	r.mu.Lock()
+	r.cache[salt] = &saltedTxs{rawTxs: rawTxs, expiry: now.Add(r.interval)}
+	r.mu.Unlock()
+	return rawTxs
+}
+
+func (r *Reconciler) sendSketch(peerID string, salt uint64, numCells int) {
+	sketch, _ := r.localSketch(salt, numCells)
+	r.send(peerID, reconcileSketchType, &SketchMessage{Salt: salt, Sketch: *sketch})
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface.
+func (r *Reconciler) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		common.ChannelIDMempoolSync,
+	}
+}
+
+// ParseMessage implements the p2p.MessageHandler interface.
+func (r *Reconciler) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	data := dp.DataResponse{}
+	err := rlp.DecodeBytes(rawMessageBytes, &data)
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   data,
+	}, err
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface.
+func (r *Reconciler) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// HandleMessage implements the p2p.MessageHandler interface.
+func (r *Reconciler) HandleMessage(msg p2ptypes.Message) error {
+	data, ok := msg.Content.(dp.DataResponse)
+	if !ok || data.ChannelID != common.ChannelIDMempoolSync {
+		return errors.New("Invalid mempool reconciliation message")
+	}
+	message := &ReconcileMessage{}
+	if err := rlp.DecodeBytes(data.Payload, message); err != nil {
+		return err
+	}
+	if message.Type != reconcileTxBatchType && !r.allowRequest(msg.PeerID) {
+		reconcileThrottledCounter.Inc(1)
+		logger.WithFields(log.Fields{"peer": msg.PeerID, "type": message.Type}).Debug("Dropped mempool reconciliation request over the rate limit")
+		return nil
+	}
+
+	switch message.Type {
+	case reconcileSketchType:
+		sketchMsg := &SketchMessage{}
+		if err := rlp.DecodeBytes(message.Payload, sketchMsg); err != nil {
+			return err
+		}
+		return r.handleSketch(msg.PeerID, sketchMsg)
+	case reconcileRetryType:
+		retryMsg := &RetryMessage{}
+		if err := rlp.DecodeBytes(message.Payload, retryMsg); err != nil {
+			return err
+		}
+		return r.handleRetry(msg.PeerID, retryMsg)
+	case reconcileTxRequestType:
+		requestMsg := &TxRequestMessage{}
+		if err := rlp.DecodeBytes(message.Payload, requestMsg); err != nil {
+			return err
+		}
+		return r.handleTxRequest(msg.PeerID, requestMsg)
+	case reconcileTxBatchType:
+		batchMsg := &TxBatchMessage{}
+		if err := rlp.DecodeBytes(message.Payload, batchMsg); err != nil {
+			return err
+		}
+		return r.handleTxBatch(msg.PeerID, batchMsg)
+	default:
+		return fmt.Errorf("Unknown mempool reconciliation message type: %v", message.Type)
+	}
+}
+
+// handleSketch decodes the difference between the sketch of the peer and the local one, then
+// sends the transactions the peer misses, and requests the ones missing locally.
+func (r *Reconciler) handleSketch(peerID string, msg *SketchMessage) error {
+	numCells := len(msg.Sketch.Cells)
+	if !msg.Sketch.IsValid() || numCells > maxSketchCells {
+		return fmt.Errorf("Invalid sketch with %v cells", numCells)
+	}
+
+	sketch, rawTxs := r.localSketch(msg.Salt, numCells)
+	sketch.Subtract(&msg.Sketch)
+	localOnly, peerOnly, ok := sketch.Decode()
+	if !ok {
+		if 2*numCells > maxSketchCells {
+			reconcileFailuresCounter.Inc(1)
+			logger.WithFields(log.Fields{"peer": peerID, "cells": numCells}).Debug("Mempool difference too large to reconcile")
+			return nil
+		}
+		reconcileRetriesCounter.Inc(1)
+		r.send(peerID, reconcileRetryType, &RetryMessage{NumCells: uint64(2 * numCells)})
+		return nil
+	}
+	r.recordDiff(peerID, len(localOnly)+len(peerOnly))
+
+	logger.WithFields(log.Fields{
+		"peer":      peerID,
+		"localOnly": len(localOnly),
+		"peerOnly":  len(peerOnly),
+	}).Debug("Reconciled mempool with peer")
+
+	r.sendTxs(peerID, rawTxs, localOnly)
+	if len(peerOnly) > 0 {
+		if len(peerOnly) > maxReconcileTxs {
+			peerOnly = peerOnly[:maxReconcileTxs]
+		}
+		r.send(peerID, reconcileTxRequestType, &TxRequestMessage{Salt: msg.Salt, ShortIDs: peerOnly})
+	}
+	return nil
+}
+
+func (r *Reconciler) handleRetry(peerID string, msg *RetryMessage) error {
+	if msg.NumCells > maxSketchCells {
+		return fmt.Errorf("Invalid sketch size requested: %v", msg.NumCells)
+	}
+
+	// The larger sketch keeps the salt of the reconciliation
+	r.mu.Lock()
+	salt, ok := r.salts[peerID]
+	r.mu.Unlock()
+	if !ok {
+		return errors.New("Sketch retry requested without a reconciliation in progress")
+	}
+	r.sendSketch(peerID, salt, int(msg.NumCells))
+	return nil
+}
+
+func (r *Reconciler) handleTxRequest(peerID string, msg *TxRequestMessage) error {
+	if len(msg.ShortIDs) > maxReconcileTxs {
+		return fmt.Errorf("Too many transactions requested: %v", len(msg.ShortIDs))
+	}
+	r.sendTxs(peerID, r.saltedTxs(msg.Salt), msg.ShortIDs)
+	r.recordDiff(peerID, len(msg.ShortIDs))
+	return nil
+}
+
+func (r *Reconciler) handleTxBatch(peerID string, msg *TxBatchMessage) error {
+	if len(msg.Txs) > maxReconcileTxs {
+		return fmt.Errorf("Too many transactions in batch: %v", len(msg.Txs))
+	}
+	r.recordDiff(peerID, len(msg.Txs))
+
+	recovered := 0
+	for _, rawTx := range msg.Txs {
+		if err := r.mempool.InsertTransaction(rawTx); err == nil {
+			recovered++
+		}
+	}
+	reconcileRecoveredCounter.Inc(int64(recovered))
+	if recovered > 0 {
+		logger.WithFields(log.Fields{"peer": peerID, "recovered": recovered}).Info("Recovered transactions missed by the gossip")
+	}
+	return nil
+}
+
+func (r *Reconciler) sendTxs(peerID string, rawTxs map[uint64]common.Bytes, ids []uint64) {
+	batch := &TxBatchMessage{}
+	for _, id := range ids {
+		if rawTx, ok := rawTxs[id]; ok && len(batch.Txs) < maxReconcileTxs {
+			batch.Txs = append(batch.Txs, rawTx)
+		}
+	}
+	if len(batch.Txs) > 0 {
+		r.send(peerID, reconcileTxBatchType, batch)
+	}
+}
+
+func (r *Reconciler) send(peerID string, msgType ReconcileMessageType, payload interface{}) {
+	raw, err := rlp.EncodeToBytes(payload)
+	if err == nil {
+		raw, err = rlp.EncodeToBytes(&ReconcileMessage{Type: msgType, Payload: raw})
+	}
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to encode mempool reconciliation message")
+		return
+	}
+	r.transport.SendData([]string{peerID}, dp.DataResponse{
+		ChannelID: common.ChannelIDMempoolSync,
+		Payload:   raw,
+	})
+}
+
+func newSalt() (uint64, error) {
+	var salt [8]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(salt[:]), nil
+}
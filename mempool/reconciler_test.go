@@ -0,0 +1,142 @@
+package mempool
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// testTransport delivers the messages synchronously to the reconcilers of the peers
+type testTransport struct {
+	id    string
+	peers map[string]*Reconciler
+	sent  map[ReconcileMessageType]int
+}
+
+func (tt *testTransport) SendData(peerIDs []string, data dp.DataResponse) {
+	raw, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		panic(err)
+	}
+	for _, peerID := range peerIDs {
+		message := &ReconcileMessage{}
+		rlp.DecodeBytes(data.Payload, message)
+		tt.sent[message.Type]++
+
+		peer := tt.peers[peerID]
+		msg, err := peer.ParseMessage(tt.id, common.ChannelIDMempoolSync, raw)
+		if err != nil {
+			panic(err)
+		}
+		if err := peer.HandleMessage(msg); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (tt *testTransport) PeerStats() []p2ptypes.PeerStats {
+	stats := []p2ptypes.PeerStats{}
+	for peerID := range tt.peers {
+		stats = append(stats, p2ptypes.PeerStats{PeerID: peerID})
+	}
+	return stats
+}
+
+func newTestReconcilers() (*Reconciler, *testTransport, *Reconciler, *testTransport) {
+	transportA := &testTransport{id: "peerA", peers: map[string]*Reconciler{}, sent: map[ReconcileMessageType]int{}}
+	transportB := &testTransport{id: "peerB", peers: map[string]*Reconciler{}, sent: map[ReconcileMessageType]int{}}
+	mempoolA, mempoolB := CreateMempool(nil), CreateMempool(nil)
+	mempoolA.SetLedger(newTestLedger())
+	mempoolB.SetLedger(newTestLedger())
+	reconcilerA := NewReconciler(mempoolA, transportA, time.Second)
+	reconcilerB := NewReconciler(mempoolB, transportB, time.Second)
+	transportA.peers["peerB"] = reconcilerB
+	transportB.peers["peerA"] = reconcilerA
+	return reconcilerA, transportA, reconcilerB, transportB
+}
+
+func TestReconcile(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	a, transportA, b, transportB := newTestReconcilers()
+	for i := 1; i <= 10; i++ {
+		rawTx := createTestRawTx("tx" + strconv.Itoa(i))
+		if i <= 6 {
+			require.Nil(a.mempool.InsertTransaction(rawTx))
+		}
+		if i >= 4 {
+			require.Nil(b.mempool.InsertTransaction(rawTx))
+		}
+	}
+
+	a.Reconcile()
+	assert.Equal(10, a.mempool.Size())
+	assert.Equal(10, b.mempool.Size())
+	assert.Equal(map[ReconcileMessageType]int{reconcileSketchType: 1, reconcileTxBatchType: 1}, transportA.sent)
+	assert.Equal(map[ReconcileMessageType]int{reconcileTxRequestType: 1, reconcileTxBatchType: 1}, transportB.sent)
+
+	// Nothing left to exchange
+	a.Reconcile()
+	assert.Equal(2, transportA.sent[reconcileSketchType])
+	assert.Equal(1, transportA.sent[reconcileTxBatchType])
+	assert.Equal(1, transportB.sent[reconcileTxBatchType])
+}
+
+func TestReconcileLargeDifference(t *testing.T) {
+	assert := assert.New(t)
+
+	a, transportA, b, transportB := newTestReconcilers()
+	for i := 0; i < 200; i++ {
+		a.mempool.InsertTransaction(createTestRawTx("tx" + strconv.Itoa(i)))
+	}
+
+	// The first sketches are too small, and get doubled until the difference can be decoded
+	a.Reconcile()
+	assert.Equal(200, b.mempool.Size())
+	assert.True(transportB.sent[reconcileRetryType] > 0)
+	assert.Equal(transportB.sent[reconcileRetryType]+1, transportA.sent[reconcileSketchType])
+
+	// The next sketch sent to the peer is sized after the difference found
+	assert.Equal(2*200, a.sketchCells("peerB"))
+	assert.Equal(minSketchCells, a.sketchCells("peerB"))
+}
+
+func TestReconcileInvalidMessages(t *testing.T) {
+	assert := assert.New(t)
+
+	a, _, _, _ := newTestReconcilers()
+	assert.NotNil(a.handleSketch("peerB", &SketchMessage{Sketch: Sketch{Cells: make([]SketchCell, 31)}}))
+	assert.NotNil(a.handleSketch("peerB", &SketchMessage{Sketch: *NewSketch(2 * maxSketchCells)}))
+	assert.NotNil(a.handleRetry("peerB", &RetryMessage{NumCells: 2 * maxSketchCells}))
+	assert.NotNil(a.handleTxRequest("peerB", &TxRequestMessage{ShortIDs: make([]uint64, maxReconcileTxs+1)}))
+	assert.NotNil(a.HandleMessage(p2ptypes.Message{Content: dp.DataResponse{ChannelID: common.ChannelIDTransaction}}))
+}
+
+func TestReconcileRateLimit(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	a, transportA, b, _ := newTestReconcilers()
+	require.Nil(a.mempool.InsertTransaction(createTestRawTx("tx1")))
+
+	// Every sketch of the peer takes a local sketch, until the rate limit of the peer is reached
+	for i := 0; i < 2*maxPeerRequests; i++ {
+		b.send("peerA", reconcileSketchType, &SketchMessage{Salt: 1, Sketch: *NewSketch(minSketchCells)})
+	}
+	assert.Equal(maxPeerRequests, transportA.sent[reconcileTxBatchType])
+
+	// A retry keeps the salt of the reconciliation, and reuses its cached transactions
+	b.Reconcile()
+	_, ok := b.cache[b.salts["peerA"]]
+	assert.True(ok)
+	numCached := len(b.cache)
+	require.Nil(b.handleRetry("peerA", &RetryMessage{NumCells: 2 * minSketchCells}))
+	assert.Equal(numCached, len(b.cache))
+	assert.NotNil(b.handleRetry("peerC", &RetryMessage{NumCells: 2 * minSketchCells}))
+}
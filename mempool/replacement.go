@@ -0,0 +1,188 @@
+package mempool
+
+import (
+	"encoding/hex"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// TxReplacement is published when a pending transaction is replaced by a transaction of the same
+// sender and sequence paying a higher fee.
+type TxReplacement struct {
+	Address           common.Address
+	Sequence          uint64
+	ReplacedTxHash    common.Hash
+	ReplacementTxHash common.Hash
+}
+
+// ReplacementSubscription receives the replacements of the pending transactions on C until it
+// is unsubscribed.
+type ReplacementSubscription struct {
+	C    <-chan *TxReplacement
+	c    chan *TxReplacement
+	feed *replacementFeed
+}
+
+// Unsubscribe stops the delivery of the replacements to the subscription.
+func (s *ReplacementSubscription) Unsubscribe() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	delete(s.feed.subs, s)
+}
+
+// replacementFeed publishes the replacements to the subscriptions. The replacements are dropped
+// for the subscribers whose buffer is full.
+type replacementFeed struct {
+	mu   *sync.Mutex
+	subs map[*ReplacementSubscription]struct{}
+}
+
+func newReplacementFeed() *replacementFeed {
+	return &replacementFeed{
+		mu:   &sync.Mutex{},
+		subs: make(map[*ReplacementSubscription]struct{}),
+	}
+}
+
+func (f *replacementFeed) subscribe(bufferSize int) *ReplacementSubscription {
+	c := make(chan *TxReplacement, bufferSize)
+	sub := &ReplacementSubscription{C: c, c: c, feed: f}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+func (f *replacementFeed) publish(replacement *TxReplacement) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		select {
+		case sub.c <- replacement:
+		default:
+		}
+	}
+}
+
+// SubscribeReplacedTxs returns a subscription receiving the replacements of the pending transactions,
+// buffering up to bufferSize replacements.
+func (mp *Mempool) SubscribeReplacedTxs(bufferSize int) *ReplacementSubscription {
+	return mp.replacements.subscribe(bufferSize)
+}
+
+// findReplacedTx returns the pending transaction with the same sender and sequence as the given
+// transaction, if any.
+func (mp *Mempool) findReplacedTx(rawTx common.Bytes) (*mempoolTransactionGroup, *mempoolTransaction, *core.TxInfo) {
+	txInfo, res := mp.ledger.GetTxInfo(rawTx)
+	if res.IsError() || txInfo == nil {
+		return nil, nil, nil
+	}
+	txGroup, ok := mp.addressToTxGroup[txInfo.Address]
+	if !ok {
+		return nil, nil, nil
+	}
+	replaced := txGroup.getTx(txInfo.Sequence)
+	if replaced == nil {
+		return nil, nil, nil
+	}
+	return txGroup, replaced, txInfo
+}
+
+// replaceTransaction replaces the pending transaction with the given transaction of the same sender
+// and sequence, provided that it passes checkReplacement.
+func (mp *Mempool) replaceTransaction(rawTx common.Bytes, txInfo *core.TxInfo,
+	txGroup *mempoolTransactionGroup, replaced *mempoolTransaction) error {
+	txInfo, err := mp.checkReplacement(rawTx, txInfo, txGroup, replaced)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Replace tx, tx.hash: 0x%v, replaced tx.hash: 0x%v",
+		getTransactionHash(rawTx), getTransactionHash(replaced.rawTransaction))
+
+	txGroup.txs.Remove(replaced.GetIndex())
+	txGroup.AddTx(rawTx, txInfo)
+	mp.candidateTxs.Remove(txGroup.index) // Need to re-insert txGroup into queue since its priority could change.
+	mp.candidateTxs.Push(txGroup)
+
+	mp.txBookeepper.markReplaced(replaced.rawTransaction)
+	mp.txBookeepper.record(rawTx)
+	mp.newTxs.PushBack(rawTx)
+
+	mp.txEvents.publish(TxEventReplaced, txInfo.Address, replaced.rawTransaction)
+	mp.txEvents.publish(TxEventInserted, txInfo.Address, rawTx)
+	mp.replacements.publish(&TxReplacement{
+		Address:           txInfo.Address,
+		Sequence:          txInfo.Sequence,
+		ReplacedTxHash:    crypto.Keccak256Hash(replaced.rawTransaction),
+		ReplacementTxHash: crypto.Keccak256Hash(rawTx),
+	})
+	return nil
+}
+
+// checkReplacement checks the given transaction can replace the pending transaction of the same
+// sender and sequence: its effective gas price must exceed the one of the replaced transaction by
+// the configured percentage, see common.CfgMempoolReplaceFeeBump, and it must pass the screening.
+// It returns the screened transaction info, neither the mempool nor the ledger state is modified.
+func (mp *Mempool) checkReplacement(rawTx common.Bytes, txInfo *core.TxInfo,
+	txGroup *mempoolTransactionGroup, replaced *mempoolTransaction) (*core.TxInfo, error) {
+	replacedPrice := replaced.txInfo.EffectiveGasPrice
+	if !isFeeBumped(replacedPrice, txInfo.EffectiveGasPrice, mp.replaceFeeBump) {
+		return nil, ScreeningError{Result: result.Error("Replacement fee too low, the effective gas price %v must exceed %v by more than %v%%",
+			txInfo.EffectiveGasPrice, replacedPrice, mp.replaceFeeBump).WithErrorCode(result.CodeReplacementFeeTooLow)}
+	}
+
+	txInfo, checkTxRes := mp.ledger.ScreenReplacementTx(rawTx, txGroup.rawTxsBefore(txInfo.Sequence))
+	if !checkTxRes.IsOK() {
+		logger.Debugf("Replacement transaction screening failed, tx: %v, error: %v", hex.EncodeToString(rawTx), checkTxRes.Message)
+		return nil, ScreeningError{Result: checkTxRes}
+	}
+	return txInfo, nil
+}
+
+// isFeeBumped returns whether the price exceeds the replaced price by more than bumpPercent percent.
+func isFeeBumped(replacedPrice, price *big.Int, bumpPercent int) bool {
+	if replacedPrice == nil || price == nil {
+		return false
+	}
+	threshold := new(big.Int).Mul(replacedPrice, big.NewInt(int64(100+bumpPercent)))
+	return new(big.Int).Mul(price, big.NewInt(100)).Cmp(threshold) > 0
+}
+
+// getTx returns the transaction of the group with the given sequence, nil if there is none.
+func (mtg *mempoolTransactionGroup) getTx(sequence uint64) *mempoolTransaction {
+	for _, elem := range *mtg.txs.ElementList() {
+		mptx := elem.(*mempoolTransaction)
+		if mptx.txInfo.Sequence == sequence {
+			return mptx
+		}
+	}
+	return nil
+}
+
+// rawTxsBefore returns the transactions of the group preceding the given sequence, in sequence order.
+func (mtg *mempoolTransactionGroup) rawTxsBefore(sequence uint64) []common.Bytes {
+	preceding := []*mempoolTransaction{}
+	for _, elem := range *mtg.txs.ElementList() {
+		mptx := elem.(*mempoolTransaction)
+		if mptx.txInfo.Sequence < sequence {
+			preceding = append(preceding, mptx)
+		}
+	}
+	sort.Slice(preceding, func(i, j int) bool {
+		return preceding[i].txInfo.Sequence < preceding[j].txInfo.Sequence
+	})
+
+	rawTxs := make([]common.Bytes, len(preceding))
+	for i, mptx := range preceding {
+		rawTxs[i] = mptx.rawTransaction
+	}
+	return rawTxs
+}
@@ -0,0 +1,126 @@
+package mempool
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2psim "github.com/thetatoken/theta/p2p/simulation"
+)
+
+func TestMempoolReplaceByFee(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	p2psimnet := p2psim.NewSimnetWithHandler(nil)
+	mempool := CreateMempool(dp.NewDispatcher(p2psimnet.AddEndpoint("peer0")))
+	mempool.SetLedger(newReplacementTestLedger())
+	mempool.replaceFeeBump = 10
+	replacements := mempool.SubscribeReplacedTxs(10)
+	defer replacements.Unsubscribe()
+
+	tx1 := createReplacementTestTx("A1", 1, 100)
+	tx2 := createReplacementTestTx("A1", 2, 100)
+	require.Nil(mempool.InsertTransaction(tx1))
+	require.Nil(mempool.InsertTransaction(tx2))
+	assert.Equal(2, mempool.Size())
+
+	// The fee must be bumped by more than 10%
+	err := mempool.InsertTransaction(createReplacementTestTx("A1", 2, 110))
+	require.NotNil(err)
+	assert.Equal(result.CodeReplacementFeeTooLow, err.(ScreeningError).Result.Code)
+	assert.Equal(0, len(replacements.C))
+
+	tx2Replacement := createReplacementTestTx("A1", 2, 111)
+	require.Nil(mempool.InsertTransaction(tx2Replacement))
+	assert.Equal(2, mempool.Size())
+
+	status, ok := mempool.GetTransactionStatus(getTransactionHash(tx2))
+	assert.True(ok)
+	assert.Equal(TxStatusReplaced, status)
+	status, ok = mempool.GetTransactionStatus(getTransactionHash(tx2Replacement))
+	assert.True(ok)
+	assert.Equal(TxStatusPending, status)
+
+	require.Equal(1, len(replacements.C))
+	replacement := <-replacements.C
+	assert.Equal(common.HexToAddress("A1"), replacement.Address)
+	assert.Equal(uint64(2), replacement.Sequence)
+	assert.Equal(crypto.Keccak256Hash(tx2), replacement.ReplacedTxHash)
+	assert.Equal(crypto.Keccak256Hash(tx2Replacement), replacement.ReplacementTxHash)
+
+	reaped := mempool.Reap(10)
+	assert.Equal([]common.Bytes{tx1, tx2Replacement}, reaped)
+}
+
+func TestIsFeeBumped(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(isFeeBumped(big.NewInt(100), big.NewInt(100), 0))
+	assert.True(isFeeBumped(big.NewInt(100), big.NewInt(101), 0))
+	assert.False(isFeeBumped(big.NewInt(100), big.NewInt(125), 25))
+	assert.True(isFeeBumped(big.NewInt(100), big.NewInt(126), 25))
+	assert.False(isFeeBumped(nil, big.NewInt(126), 25))
+}
+
+// --------------- Test Utilities --------------- //
+
+// replacementTestLedger screens the transactions encoded as "address/sequence/price", which must
+// follow the sequence of their sender.
+type replacementTestLedger struct {
+	TestLedger
+	sequences map[common.Address]uint64
+}
+
+func newReplacementTestLedger() *replacementTestLedger {
+	return &replacementTestLedger{sequences: make(map[common.Address]uint64)}
+}
+
+func createReplacementTestTx(address string, sequence uint64, price int64) common.Bytes {
+	return common.Bytes(fmt.Sprintf("%v/%v/%v", address, sequence, price))
+}
+
+func (tl *replacementTestLedger) GetTxInfo(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	fields := strings.Split(string(rawTx), "/")
+	sequence, _ := strconv.ParseUint(fields[1], 10, 64)
+	price, _ := new(big.Int).SetString(fields[2], 10)
+	return &core.TxInfo{
+		Address:           common.HexToAddress(fields[0]),
+		Sequence:          sequence,
+		EffectiveGasPrice: price,
+	}, result.OK
+}
+
+func (tl *replacementTestLedger) ScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	txInfo, _ := tl.GetTxInfo(rawTx)
+	if txInfo.Sequence != tl.sequences[txInfo.Address]+1 {
+		return nil, result.Error("Invalid sequence").WithErrorCode(result.CodeInvalidSequence)
+	}
+	tl.sequences[txInfo.Address] = txInfo.Sequence
+	return txInfo, result.OK
+}
+
+func (tl *replacementTestLedger) SimulateScreenTx(rawTx common.Bytes) (*core.TxInfo, result.Result) {
+	txInfo, _ := tl.GetTxInfo(rawTx)
+	if txInfo.Sequence != tl.sequences[txInfo.Address]+1 {
+		return nil, result.Error("Invalid sequence").WithErrorCode(result.CodeInvalidSequence)
+	}
+	return txInfo, result.OK
+}
+
+func (tl *replacementTestLedger) ScreenReplacementTx(rawTx common.Bytes, precedingRawTxs []common.Bytes) (*core.TxInfo, result.Result) {
+	txInfo, _ := tl.GetTxInfo(rawTx)
+	if txInfo.Sequence != uint64(len(precedingRawTxs))+1 {
+		return nil, result.Error("Invalid sequence").WithErrorCode(result.CodeInvalidSequence)
+	}
+	return txInfo, result.OK
+}
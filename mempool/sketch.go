@@ -0,0 +1,150 @@
+package mempool
+
+import (
+	"encoding/binary"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// sketchHashCount is the number of cells each ID is added to. The cells are split in as many
+// subtables, so that the cells of an ID are always distinct.
+const sketchHashCount = 3
+
+// SketchCell is a cell of a Sketch. The count wraps around, so that the cells can be subtracted.
+type SketchCell struct {
+	Count   uint64 // number of IDs added, minus the number removed
+	IDSum   uint64 // XOR of the IDs
+	HashSum uint64 // XOR of the check hashes of the IDs
+}
+
+// Sketch summarizes a set of 64-bit IDs in a number of cells proportional to the expected size
+// of the difference with another set, rather than to the size of the set. It is an invertible
+// Bloom lookup table: subtracting the sketch of another set cancels out the common IDs, and as
+// long as the difference is small enough compared to the number of cells, the IDs held by only
+// one of the sets can be decoded from the remaining cells.
+type Sketch struct {
+	Cells []SketchCell
+}
+
+// NewSketch creates a sketch with the given number of cells, rounded up to a multiple of the
+// number of subtables.
+func NewSketch(numCells int) *Sketch {
+	if numCells < sketchHashCount {
+		numCells = sketchHashCount
+	}
+	numCells = (numCells + sketchHashCount - 1) / sketchHashCount * sketchHashCount
+	return &Sketch{Cells: make([]SketchCell, numCells)}
+}
+
+// IsValid checks that the sketch could have been created by NewSketch.
+func (s *Sketch) IsValid() bool {
+	return len(s.Cells) > 0 && len(s.Cells)%sketchHashCount == 0
+}
+
+// Add adds an ID to the set.
+func (s *Sketch) Add(id uint64) {
+	s.update(id, 1)
+}
+
+// Subtract removes the IDs of the other sketch, which needs to have the same number of cells.
+// The cells then only hold the IDs in one of the two sets.
+func (s *Sketch) Subtract(other *Sketch) bool {
+	if len(s.Cells) != len(other.Cells) {
+		return false
+	}
+	for i := range s.Cells {
+		s.Cells[i].Count -= other.Cells[i].Count
+		s.Cells[i].IDSum ^= other.Cells[i].IDSum
+		s.Cells[i].HashSum ^= other.Cells[i].HashSum
+	}
+	return true
+}
+
+// Decode recovers the IDs of a subtracted sketch: the IDs only in the set of the sketch, and the
+// IDs only in the set subtracted. It fails if the difference is too large for the number of
+// cells. The sketch is emptied in the process.
+func (s *Sketch) Decode() (added []uint64, removed []uint64, ok bool) {
+	pure := []int{}
+	for i := range s.Cells {
+		if s.isPure(i) {
+			pure = append(pure, i)
+		}
+	}
+	for len(pure) > 0 {
+		i := pure[len(pure)-1]
+		pure = pure[:len(pure)-1]
+		if !s.isPure(i) {
+			continue // emptied by a previous ID
+		}
+		id, count := s.Cells[i].IDSum, s.Cells[i].Count
+		if count == 1 {
+			added = append(added, id)
+		} else {
+			removed = append(removed, id)
+		}
+		for _, j := range s.indices(id) {
+			s.Cells[j].Count -= count
+			s.Cells[j].IDSum ^= id
+			s.Cells[j].HashSum ^= sketchCheckHash(id)
+			if s.isPure(j) {
+				pure = append(pure, j)
+			}
+		}
+	}
+	for _, cell := range s.Cells {
+		if cell != (SketchCell{}) {
+			return nil, nil, false
+		}
+	}
+	return added, removed, true
+}
+
+// isPure returns whether the cell holds a single ID, added or removed.
+func (s *Sketch) isPure(i int) bool {
+	cell := s.Cells[i]
+	return (cell.Count == 1 || cell.Count == ^uint64(0)) && cell.HashSum == sketchCheckHash(cell.IDSum)
+}
+
+func (s *Sketch) update(id uint64, count uint64) {
+	for _, i := range s.indices(id) {
+		s.Cells[i].Count += count
+		s.Cells[i].IDSum ^= id
+		s.Cells[i].HashSum ^= sketchCheckHash(id)
+	}
+}
+
+// indices returns the cell of the ID in each subtable.
+func (s *Sketch) indices(id uint64) [sketchHashCount]int {
+	var indices [sketchHashCount]int
+	subtableSize := uint64(len(s.Cells) / sketchHashCount)
+	for k := 0; k < sketchHashCount; k++ {
+		indices[k] = k*int(subtableSize) + int(mix64(id+uint64(k)*0x9e3779b97f4a7c15)%subtableSize)
+	}
+	return indices
+}
+
+// sketchCheckHash tells the cells holding a single ID apart from the cells holding several.
+func sketchCheckHash(id uint64) uint64 {
+	return mix64(id ^ 0x5851f42d4c957f2d)
+}
+
+// mix64 is the finalizer of SplitMix64.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// shortTxID maps a transaction to a 64-bit ID. The salt is picked for each reconciliation, so
+// that the collisions between the IDs of the transactions cannot be precomputed.
+func shortTxID(salt uint64, rawTx common.Bytes) uint64 {
+	var saltBytes [8]byte
+	binary.BigEndian.PutUint64(saltBytes[:], salt)
+	txHash := crypto.Keccak256Hash(rawTx)
+	hash := crypto.Keccak256Hash(saltBytes[:], txHash[:])
+	return binary.BigEndian.Uint64(hash[:8])
+}
@@ -0,0 +1,60 @@
+package mempool
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sortIDs(ids []uint64) []uint64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestSketchDecode(t *testing.T) {
+	assert := assert.New(t)
+	rng := rand.New(rand.NewSource(1))
+
+	for _, numDiffs := range []int{0, 1, 5, 20, 100} {
+		local, remote := NewSketch(3*numDiffs+30), NewSketch(3*numDiffs+30)
+		for i := 0; i < 1000; i++ {
+			id := rng.Uint64()
+			local.Add(id)
+			remote.Add(id)
+		}
+		localOnly, remoteOnly := []uint64{}, []uint64{}
+		for i := 0; i < numDiffs; i++ {
+			localID, remoteID := rng.Uint64(), rng.Uint64()
+			local.Add(localID)
+			remote.Add(remoteID)
+			localOnly = append(localOnly, localID)
+			remoteOnly = append(remoteOnly, remoteID)
+		}
+
+		assert.True(local.Subtract(remote))
+		added, removed, ok := local.Decode()
+		assert.True(ok, "%v differences", numDiffs)
+		assert.Equal(sortIDs(localOnly), sortIDs(append([]uint64{}, added...)))
+		assert.Equal(sortIDs(remoteOnly), sortIDs(append([]uint64{}, removed...)))
+	}
+}
+
+func TestSketchDecodeFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	local, remote := NewSketch(30), NewSketch(30)
+	for id := uint64(1); id <= 100; id++ {
+		local.Add(id)
+	}
+	remote.Add(1000)
+	assert.True(local.Subtract(remote))
+	_, _, ok := local.Decode()
+	assert.False(ok)
+
+	// Sketches of different sizes cannot be subtracted
+	assert.False(NewSketch(30).Subtract(NewSketch(60)))
+	assert.Equal(33, len(NewSketch(31).Cells))
+	assert.False((&Sketch{Cells: make([]SketchCell, 31)}).IsValid())
+}
@@ -28,6 +28,8 @@ type TxStatus int
 const (
 	TxStatusPending TxStatus = iota
 	TxStatusAbandoned
+	TxStatusExpired
+	TxStatusReplaced
 )
 
 func createTransactionBookkeeper(maxNumTxs uint) transactionBookkeeper {
@@ -84,6 +86,18 @@ func (tb *transactionBookkeeper) record(rawTx common.Bytes) bool {
 }
 
 func (tb *transactionBookkeeper) markAbandoned(rawTx common.Bytes) {
+	tb.markStatus(rawTx, TxStatusAbandoned)
+}
+
+func (tb *transactionBookkeeper) markExpired(rawTx common.Bytes) {
+	tb.markStatus(rawTx, TxStatusExpired)
+}
+
+func (tb *transactionBookkeeper) markReplaced(rawTx common.Bytes) {
+	tb.markStatus(rawTx, TxStatusReplaced)
+}
+
+func (tb *transactionBookkeeper) markStatus(rawTx common.Bytes, status TxStatus) {
 	tb.mutex.Lock()
 	defer tb.mutex.Unlock()
 
@@ -91,7 +105,7 @@ func (tb *transactionBookkeeper) markAbandoned(rawTx common.Bytes) {
 	if _, exists := tb.txMap[txhash]; !exists {
 		return
 	}
-	tb.txMap[txhash] = TxStatusAbandoned
+	tb.txMap[txhash] = status
 }
 
 func (tb *transactionBookkeeper) remove(rawTx common.Bytes) {
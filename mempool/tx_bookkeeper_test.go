@@ -47,6 +47,15 @@ func TestTxBookkeeper(t *testing.T) {
 	assert.True(txb.hasSeen(tx5))
 	assert.False(txb.hasSeen(tx2)) // tx2 should have been purged
 
+	txb.markExpired(tx3)
+	status, ok := txb.getStatus(getTransactionHash(tx3))
+	assert.True(ok)
+	assert.Equal(TxStatusExpired, status)
+	txb.markAbandoned(tx4)
+	status, ok = txb.getStatus(getTransactionHash(tx4))
+	assert.True(ok)
+	assert.Equal(TxStatusAbandoned, status)
+
 	txb.remove(tx4)
 	assert.False(txb.hasSeen(tx4))
 
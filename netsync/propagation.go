@@ -0,0 +1,233 @@
+package netsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/core"
+)
+
+var (
+	blockReceiptsCounter             = metrics.NewRegisteredCounter("sync/propagation/block/receipts", nil)
+	blockDuplicatesCounter           = metrics.NewRegisteredCounter("sync/propagation/block/duplicates", nil)
+	voteReceiptsCounter              = metrics.NewRegisteredCounter("sync/propagation/vote/receipts", nil)
+	voteDuplicatesCounter            = metrics.NewRegisteredCounter("sync/propagation/vote/duplicates", nil)
+	voteLatencyTimer                 = metrics.NewRegisteredTimer("sync/propagation/vote/latency", nil)
+	blockSeenToFinalizedTimer        = metrics.NewRegisteredTimer("sync/propagation/block/finalization", nil)
+	blockDuplicatesPerBlockHistogram = metrics.NewRegisteredHistogram("sync/propagation/block/duplicatesperblock", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// MaxTrackedBlocks is the number of the most recently seen blocks the propagation is tracked for
+const MaxTrackedBlocks = 1024
+
+// VotePropagation records when and from which peer a vote on a block was first received.
+type VotePropagation struct {
+	Voter     common.Address
+	Epoch     uint64
+	Source    string // ID of the peer the vote was first received from
+	FirstSeen time.Time
+	Receipts  uint64 // number of times the vote was received, duplicates included
+}
+
+// BlockPropagation records when and from which peer a block was first received, how many times it
+// was received, and when it was finalized. The block might only be known from the votes on it, in
+// which case Source is empty and FirstSeen is zero.
+type BlockPropagation struct {
+	Hash        common.Hash
+	Height      uint64
+	Source      string // ID of the peer the block was first received from
+	FirstSeen   time.Time
+	Receipts    uint64    // number of times the block was received, duplicates included
+	FinalizedAt time.Time // zero if not finalized yet
+	Votes       []VotePropagation
+}
+
+// Duplicates returns the number of times the block was received after the first time.
+func (p *BlockPropagation) Duplicates() uint64 {
+	if p.Receipts == 0 {
+		return 0
+	}
+	return p.Receipts - 1
+}
+
+// FinalizationLatency returns the time from the first receipt of the block to its finalization,
+// or 0 if the block has not been received or finalized.
+func (p *BlockPropagation) FinalizationLatency() time.Duration {
+	if p.FirstSeen.IsZero() || p.FinalizedAt.IsZero() || p.FinalizedAt.Before(p.FirstSeen) {
+		return 0
+	}
+	return p.FinalizedAt.Sub(p.FirstSeen)
+}
+
+type voteKey struct {
+	voter common.Address
+	epoch uint64
+}
+
+type trackedBlock struct {
+	BlockPropagation
+	parent common.Hash
+	votes  map[voteKey]*VotePropagation
+}
+
+// PropagationTracker records the first-seen time and the source peer of the blocks and the votes
+// received from the network, for the MaxTrackedBlocks most recently seen blocks, and reports how
+// long the blocks took to be finalized once seen.
+type PropagationTracker struct {
+	mu *sync.Mutex
+
+	chain  *blockchain.Chain
+	blocks map[common.Hash]*trackedBlock
+	order  []common.Hash // in the order the blocks were first seen
+}
+
+// NewPropagationTracker creates a new instance of PropagationTracker.
+func NewPropagationTracker(chain *blockchain.Chain) *PropagationTracker {
+	return &PropagationTracker{
+		mu:     &sync.Mutex{},
+		chain:  chain,
+		blocks: make(map[common.Hash]*trackedBlock),
+	}
+}
+
+func (t *PropagationTracker) getOrAdd(hash common.Hash) *trackedBlock {
+	tb, ok := t.blocks[hash]
+	if ok {
+		return tb
+	}
+	tb = &trackedBlock{
+		BlockPropagation: BlockPropagation{Hash: hash},
+		votes:            make(map[voteKey]*VotePropagation),
+	}
+	t.blocks[hash] = tb
+	t.order = append(t.order, hash)
+	if len(t.order) > MaxTrackedBlocks {
+		delete(t.blocks, t.order[0])
+		t.order = t.order[1:]
+	}
+	return tb
+}
+
+// RecordBlock records the receipt of the block from the peer.
+func (t *PropagationTracker) RecordBlock(block *core.Block, peerID string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tb := t.getOrAdd(block.Hash())
+	blockReceiptsCounter.Inc(1)
+	tb.Receipts++
+	if tb.Receipts > 1 {
+		blockDuplicatesCounter.Inc(1)
+		return
+	}
+	tb.Height = block.Height
+	tb.parent = block.Parent
+	tb.Source = peerID
+	tb.FirstSeen = now
+}
+
+// RecordVote records the receipt of the vote from the peer.
+func (t *PropagationTracker) RecordVote(vote core.Vote, peerID string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tb := t.getOrAdd(vote.Block)
+	voteReceiptsCounter.Inc(1)
+	key := voteKey{voter: vote.ID, epoch: vote.Epoch}
+	if vp, ok := tb.votes[key]; ok {
+		vp.Receipts++
+		voteDuplicatesCounter.Inc(1)
+		return
+	}
+	if !tb.FirstSeen.IsZero() && now.After(tb.FirstSeen) {
+		voteLatencyTimer.Update(now.Sub(tb.FirstSeen))
+	}
+	tb.votes[key] = &VotePropagation{
+		Voter:     vote.ID,
+		Epoch:     vote.Epoch,
+		Source:    peerID,
+		FirstSeen: now,
+		Receipts:  1,
+	}
+}
+
+// BlockFinalized records the finalization of the block, and of its ancestors finalized along with it.
+func (t *PropagationTracker) BlockFinalized(block *core.Block, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hash := block.Hash()
+	parent := block.Parent
+	for i := 0; i <= len(t.order); i++ {
+		if tb, ok := t.blocks[hash]; ok {
+			if !tb.FinalizedAt.IsZero() {
+				return
+			}
+			tb.FinalizedAt = now
+			if tb.Receipts > 0 {
+				blockSeenToFinalizedTimer.Update(tb.FinalizationLatency())
+				blockDuplicatesPerBlockHistogram.Update(int64(tb.Duplicates()))
+			}
+		}
+		if parent.IsEmpty() {
+			return
+		}
+		hash = parent
+		parent = t.parentOf(hash)
+	}
+}
+
+// parentOf returns the hash of the parent of the block, or an empty hash if the block is unknown.
+func (t *PropagationTracker) parentOf(hash common.Hash) common.Hash {
+	if tb, ok := t.blocks[hash]; ok && !tb.parent.IsEmpty() {
+		return tb.parent
+	}
+	eb, err := t.chain.FindBlock(hash)
+	if err != nil {
+		return common.Hash{}
+	}
+	return eb.Parent
+}
+
+// GetBlockPropagation returns the propagation report of the block with the given hash.
+func (t *PropagationTracker) GetBlockPropagation(hash common.Hash) (*BlockPropagation, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tb, ok := t.blocks[hash]
+	if !ok {
+		return nil, false
+	}
+	return tb.report(), true
+}
+
+// GetRecentBlockPropagations returns the propagation reports of up to count blocks, the most
+// recently seen first.
+func (t *PropagationTracker) GetRecentBlockPropagations(count int) []*BlockPropagation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reports := []*BlockPropagation{}
+	for i := len(t.order) - 1; i >= 0 && len(reports) < count; i-- {
+		reports = append(reports, t.blocks[t.order[i]].report())
+	}
+	return reports
+}
+
+// report returns a copy of the propagation of the block, with the votes in the order they were
+// first seen.
+func (tb *trackedBlock) report() *BlockPropagation {
+	report := tb.BlockPropagation
+	report.Votes = make([]VotePropagation, 0, len(tb.votes))
+	for _, vp := range tb.votes {
+		report.Votes = append(report.Votes, *vp)
+	}
+	sort.Slice(report.Votes, func(i, j int) bool {
+		return report.Votes[i].FirstSeen.Before(report.Votes[j].FirstSeen)
+	})
+	return &report
+}
@@ -0,0 +1,79 @@
+package netsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func TestPropagationTracker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	root := core.CreateTestBlock("prop_a0", "")
+	chain := blockchain.NewChain("testchain", kvstore.NewKVStore(backend.NewMemDatabase()), root)
+	a1 := core.CreateTestBlock("prop_a1", "prop_a0")
+	a2 := core.CreateTestBlock("prop_a2", "prop_a1")
+	a3 := core.CreateTestBlock("prop_a3", "prop_a2")
+	for _, block := range []*core.Block{a1, a2, a3} {
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+	}
+
+	tracker := NewPropagationTracker(chain)
+	start := time.Unix(1546300800, 0)
+	tracker.RecordBlock(a1, "peer1", start)
+	tracker.RecordBlock(a1, "peer2", start.Add(100*time.Millisecond))
+	tracker.RecordBlock(a1, "peer3", start.Add(200*time.Millisecond))
+
+	signer, _, _ := crypto.GenerateKeyPair()
+	vote := core.Vote{Block: a1.Hash(), Height: a1.Height, Epoch: 1, ID: signer.PublicKey().Address()}
+	tracker.RecordVote(vote, "peer2", start.Add(300*time.Millisecond))
+	tracker.RecordVote(vote, "peer1", start.Add(400*time.Millisecond))
+
+	// A block only known from the votes on it
+	tracker.RecordVote(core.Vote{Block: a2.Hash(), Height: a2.Height, Epoch: 2, ID: signer.PublicKey().Address()}, "peer1", start)
+
+	p, ok := tracker.GetBlockPropagation(a1.Hash())
+	require.True(ok)
+	assert.Equal("peer1", p.Source)
+	assert.Equal(start, p.FirstSeen)
+	assert.Equal(uint64(3), p.Receipts)
+	assert.Equal(uint64(2), p.Duplicates())
+	require.Equal(1, len(p.Votes))
+	assert.Equal("peer2", p.Votes[0].Source)
+	assert.Equal(uint64(2), p.Votes[0].Receipts)
+	assert.Equal(time.Duration(0), p.FinalizationLatency())
+
+	// Finalizing a3 finalizes its ancestors
+	tracker.BlockFinalized(a3, start.Add(5*time.Second))
+	p, _ = tracker.GetBlockPropagation(a1.Hash())
+	assert.Equal(5*time.Second, p.FinalizationLatency())
+	p, _ = tracker.GetBlockPropagation(a2.Hash())
+	assert.False(p.FinalizedAt.IsZero())
+	assert.True(p.FirstSeen.IsZero())
+	assert.Equal(time.Duration(0), p.FinalizationLatency())
+	_, ok = tracker.GetBlockPropagation(a3.Hash())
+	assert.False(ok)
+
+	reports := tracker.GetRecentBlockPropagations(10)
+	require.Equal(2, len(reports))
+	assert.Equal(a2.Hash(), reports[0].Hash)
+	assert.Equal(a1.Hash(), reports[1].Hash)
+
+	// Only the most recently seen blocks are tracked
+	for i := 0; i < MaxTrackedBlocks; i++ {
+		block := core.NewBlock()
+		block.Height = uint64(100 + i)
+		tracker.RecordBlock(block, "peer1", start)
+	}
+	_, ok = tracker.GetBlockPropagation(a1.Hash())
+	assert.False(ok)
+}
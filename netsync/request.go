@@ -3,23 +3,30 @@ package netsync
 import (
 	"container/list"
 	"context"
-	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
 	"github.com/thetatoken/theta/common/util"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
 
 	log "github.com/sirupsen/logrus"
 )
 
+var (
+	pendingBlocksGauge = metrics.NewRegisteredGauge("sync/requests/pending", nil)
+	orphanBlocksGauge  = metrics.NewRegisteredGauge("sync/requests/orphans", nil)
+)
+
 const RequestTimeout = 10 * time.Second
 const MinInventoryRequestInterval = 3 * time.Second
 const MaxInventoryRequestInterval = 30 * time.Second
+const RecoveryInventoryRequestInterval = 1 * time.Second
 const RequestQuotaPerSecond = 1000
 
 type RequestState uint8
@@ -75,6 +82,7 @@ type RequestManager struct {
 	pendingBlocks         *list.List
 	pendingBlocksByHash   map[string]*list.Element
 	pendingBlocksByParent map[string][]*core.Block
+	scheduler             *downloadScheduler
 
 	endHashCache      []common.Bytes
 	blockRequestCache []common.Bytes
@@ -97,6 +105,8 @@ func NewRequestManager(syncMgr *SyncManager) *RequestManager {
 		pendingBlocks:         list.New(),
 		pendingBlocksByHash:   make(map[string]*list.Element),
 		pendingBlocksByParent: make(map[string][]*core.Block),
+		scheduler: newDownloadScheduler(viper.GetInt(common.CfgSyncDownloadWindowSize),
+			viper.GetInt(common.CfgSyncMaxInflightWindowsPerPeer)),
 	}
 
 	logger := util.GetLoggerForModule("request")
@@ -104,6 +114,9 @@ func NewRequestManager(syncMgr *SyncManager) *RequestManager {
 		logger = logger.WithFields(log.Fields{"id": rm.syncMgr.consensus.ID()})
 	}
 	rm.logger = logger
+	rm.scheduler.onTimeout = func(peerID string) {
+		rm.dispatcher.ReportPeer(peerID, p2ptypes.PeerEventTimeout)
+	}
 
 	return rm
 }
@@ -145,7 +158,7 @@ func (rm *RequestManager) Wait() {
 
 func (rm *RequestManager) buildInventoryRequest() dispatcher.InventoryRequest {
 	tip := rm.syncMgr.consensus.GetTip(true)
-	lfb := rm.syncMgr.consensus.GetLastFinalizedBlock()
+	lfb := rm.syncMgr.finality.GetLastFinalizedBlock()
 
 	// Build expontially backoff starting hashes:
 	// https://en.bitcoin.it/wiki/Protocol_documentation#getblocks
@@ -179,14 +192,22 @@ func (rm *RequestManager) buildInventoryRequest() dispatcher.InventoryRequest {
 }
 
 func (rm *RequestManager) tryToDownload() {
-	rm.mu.RLock()
-	defer rm.mu.RUnlock()
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	pendingBlocksGauge.Update(int64(rm.pendingBlocks.Len()))
+	orphanBlocksGauge.Update(int64(len(rm.pendingBlocksByParent)))
 
 	hasUndownloadedBlocks := rm.pendingBlocks.Len() > 0 || len(rm.pendingBlocksByHash) > 0 || len(rm.pendingBlocksByParent) > 0
 	minIntervalPassed := time.Since(rm.lastInventoryRequest) >= MinInventoryRequestInterval
 	maxIntervalPassed := time.Since(rm.lastInventoryRequest) >= MaxInventoryRequestInterval
 
-	if maxIntervalPassed || (hasUndownloadedBlocks && minIntervalPassed) {
+	// Keep requesting inventories while the consensus engine is catching up with
+	// the rest of the network, e.g. after a network partition heals.
+	recoveryIntervalPassed := time.Since(rm.lastInventoryRequest) >= RecoveryInventoryRequestInterval
+	inRecovery := rm.syncMgr.consensus.IsInRecovery()
+
+	if maxIntervalPassed || (hasUndownloadedBlocks && minIntervalPassed) || (inRecovery && recoveryIntervalPassed) {
 		if hasUndownloadedBlocks && rm.pendingBlocks.Len() > 1 {
 			rm.logger.WithFields(log.Fields{
 				"pendingBlocks":     rm.pendingBlocks.Len(),
@@ -207,7 +228,15 @@ func (rm *RequestManager) tryToDownload() {
 		rm.syncMgr.dispatcher.GetInventory([]string{}, req)
 	}
 
-	for curr := rm.pendingBlocks.Front(); rm.quota != 0 && curr != nil; curr = curr.Next() {
+	now := time.Now()
+	for _, hash := range rm.scheduler.expire(now) {
+		if pendingBlockEl, ok := rm.pendingBlocksByHash[hash.String()]; ok {
+			pendingBlockEl.Value.(*PendingBlock).status = RequestToSendDataReq
+		}
+	}
+
+	hashes := []common.Hash{}
+	for curr := rm.pendingBlocks.Front(); curr != nil; curr = curr.Next() {
 		pendingBlock := curr.Value.(*PendingBlock)
 		if pendingBlock.block != nil {
 			continue
@@ -215,25 +244,62 @@ func (rm *RequestManager) tryToDownload() {
 		if len(pendingBlock.peers) == 0 {
 			continue
 		}
-		if pendingBlock.status == RequestToSendDataReq ||
-			(pendingBlock.status == RequestWaitingDataResp && pendingBlock.HasTimedOut()) {
-			randomPeerID := pendingBlock.peers[rand.Intn(len(pendingBlock.peers))]
-			request := dispatcher.DataRequest{
-				ChannelID: common.ChannelIDBlock,
-				Entries:   []string{pendingBlock.hash.String()},
-			}
-			rm.logger.WithFields(log.Fields{
-				"channelID":       request.ChannelID,
-				"request.Entries": request.Entries,
-				"peer":            randomPeerID,
-			}).Debug("Sending data request")
-			rm.syncMgr.dispatcher.GetData([]string{randomPeerID}, request)
+		if rm.scheduler.isAssigned(pendingBlock.hash) {
+			continue
+		}
+		hashes = append(hashes, pendingBlock.hash)
+	}
+
+	// The blocks are downloaded in windows from multiple peers concurrently, and passed down
+	// to the consensus engine in order once their parents are available, see dumpReadyBlocks.
+	windows := rm.scheduler.schedule(hashes, rm.getPeers, rm.quota, now)
+	windows = append(windows, rm.scheduler.steal(rm.getPeers, now)...)
+	for _, window := range windows {
+		rm.requestWindow(window)
+	}
+}
+
+func (rm *RequestManager) getPeers(hash common.Hash) []string {
+	pendingBlockEl, ok := rm.pendingBlocksByHash[hash.String()]
+	if !ok {
+		return []string{}
+	}
+	return pendingBlockEl.Value.(*PendingBlock).peers
+}
+
+func (rm *RequestManager) requestWindow(window *downloadWindow) {
+	entries := []string{}
+	for _, hash := range window.hashes {
+		if _, ok := window.remaining[hash]; !ok {
+			continue
+		}
+		entries = append(entries, hash.String())
+		if pendingBlockEl, ok := rm.pendingBlocksByHash[hash.String()]; ok {
+			pendingBlock := pendingBlockEl.Value.(*PendingBlock)
 			pendingBlock.UpdateTimestamp()
 			pendingBlock.status = RequestWaitingDataResp
-			rm.quota--
-			continue
 		}
 	}
+	request := dispatcher.DataRequest{
+		ChannelID: common.ChannelIDBlock,
+		Entries:   entries,
+	}
+	rm.logger.WithFields(log.Fields{
+		"channelID":       request.ChannelID,
+		"request.Entries": request.Entries,
+		"peer":            window.peerID,
+	}).Debug("Sending data request")
+	rm.syncMgr.dispatcher.GetData([]string{window.peerID}, request)
+	rm.quota -= len(entries)
+}
+
+// RecordDownload records the block downloaded from the given peer, which is used to estimate the
+// latency and the throughput of the peer.
+func (rm *RequestManager) RecordDownload(peerID string, hash common.Hash) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.scheduler.onBlock(peerID, hash, time.Now())
 }
 
 func (rm *RequestManager) AddHash(x common.Hash, peerIDs []string) {
@@ -275,6 +341,8 @@ func (rm *RequestManager) AddBlock(block *core.Block) {
 	rm.mu.Lock()
 	defer rm.mu.Unlock()
 
+	rm.scheduler.release(block.Hash())
+
 	if pendingBlockEl, ok := rm.pendingBlocksByHash[block.Hash().String()]; ok {
 		pendingBlock := pendingBlockEl.Value.(*PendingBlock)
 		pendingBlock.block = block
@@ -324,7 +392,7 @@ func (rm *RequestManager) dumpAllReadyBlocks() {
 // resumePendingBlocks is called during process start to resume blocks that are already downloaded
 // but are not yet processed by consensus engine.
 func (rm *RequestManager) resumePendingBlocks() {
-	lfb := rm.syncMgr.consensus.GetLastFinalizedBlock()
+	lfb := rm.syncMgr.finality.GetLastFinalizedBlock()
 	queue := []*core.ExtendedBlock{lfb}
 	for len(queue) > 0 {
 		block := queue[0]
@@ -0,0 +1,333 @@
+package netsync
+
+import (
+	"sort"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+)
+
+// ewmaWeight is the weight of the latest sample in the latency and throughput estimates of the peers
+const ewmaWeight = 0.3
+
+// downloadWindow is a batch of consecutive pending blocks requested from a single peer.
+type downloadWindow struct {
+	peerID       string
+	hashes       []common.Hash
+	remaining    map[common.Hash]struct{}
+	requestedAt  time.Time
+	lastActivity time.Time
+	received     int
+}
+
+func newDownloadWindow(peerID string) *downloadWindow {
+	return &downloadWindow{
+		peerID:    peerID,
+		hashes:    []common.Hash{},
+		remaining: make(map[common.Hash]struct{}),
+	}
+}
+
+func (w *downloadWindow) add(hash common.Hash) {
+	w.hashes = append(w.hashes, hash)
+	w.remaining[hash] = struct{}{}
+}
+
+func (w *downloadWindow) hasTimedOut(now time.Time) bool {
+	return now.Sub(w.lastActivity) > RequestTimeout
+}
+
+// peerStats tracks the download performance of a peer.
+type peerStats struct {
+	latency    time.Duration // Time to the first block of a window
+	throughput float64       // Blocks per second, 0 if unknown
+	inflight   int           // Number of windows being downloaded from the peer
+}
+
+func (ps *peerStats) recordLatency(latency time.Duration) {
+	if ps.latency == 0 {
+		ps.latency = latency
+		return
+	}
+	ps.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(ps.latency))
+}
+
+func (ps *peerStats) recordThroughput(throughput float64) {
+	if ps.throughput == 0 {
+		ps.throughput = throughput
+		return
+	}
+	ps.throughput = ewmaWeight*throughput + (1-ewmaWeight)*ps.throughput
+}
+
+// downloadScheduler splits the pending blocks into windows downloaded concurrently from multiple
+// peers. The windows are preferably assigned to the peers with the highest throughput, and the idle
+// peers steal the tail of the windows of the slower peers. The scheduler is not thread safe, it is
+// guarded by the lock of the RequestManager.
+type downloadScheduler struct {
+	windowSize  int
+	maxInflight int
+
+	peers    map[string]*peerStats
+	assigned map[common.Hash]*downloadWindow
+
+	onTimeout func(peerID string) // called for each window expired, if set
+}
+
+func newDownloadScheduler(windowSize, maxInflight int) *downloadScheduler {
+	if windowSize < 1 {
+		windowSize = 1
+	}
+	if maxInflight < 1 {
+		maxInflight = 1
+	}
+	return &downloadScheduler{
+		windowSize:  windowSize,
+		maxInflight: maxInflight,
+		peers:       make(map[string]*peerStats),
+		assigned:    make(map[common.Hash]*downloadWindow),
+	}
+}
+
+func (s *downloadScheduler) getPeer(peerID string) *peerStats {
+	ps, ok := s.peers[peerID]
+	if !ok {
+		ps = &peerStats{}
+		s.peers[peerID] = ps
+	}
+	return ps
+}
+
+// isAssigned returns whether the block is being downloaded.
+func (s *downloadScheduler) isAssigned(hash common.Hash) bool {
+	_, ok := s.assigned[hash]
+	return ok
+}
+
+// score estimates the download rate a new window would get from the peer. The peers without
+// samples yet are assumed to be as fast as the fastest known peer, so that they get tried.
+func (s *downloadScheduler) score(peerID string, bestThroughput float64) float64 {
+	ps := s.getPeer(peerID)
+	throughput := ps.throughput
+	if throughput == 0 {
+		throughput = bestThroughput
+	}
+	return throughput / float64(ps.inflight+1)
+}
+
+func (s *downloadScheduler) bestThroughput() float64 {
+	best := 1.0
+	for _, ps := range s.peers {
+		if ps.throughput > best {
+			best = ps.throughput
+		}
+	}
+	return best
+}
+
+// schedule assigns up to quota of the given unassigned hashes, in order, to windows of the peers
+// having the blocks. It returns the windows to request.
+func (s *downloadScheduler) schedule(hashes []common.Hash, peersOf func(common.Hash) []string, quota int, now time.Time) []*downloadWindow {
+	bestThroughput := s.bestThroughput()
+	building := make(map[string]*downloadWindow)
+	windows := []*downloadWindow{}
+
+	for _, hash := range hashes {
+		if quota <= 0 {
+			break
+		}
+		if s.isAssigned(hash) {
+			continue
+		}
+
+		var bestPeer string
+		bestScore := -1.0
+		for _, peerID := range peersOf(hash) {
+			if w, ok := building[peerID]; ok && len(w.hashes) < s.windowSize {
+				// Filling up the current window of the peer does not add to its load
+				bestPeer = peerID
+				break
+			}
+			if s.getPeer(peerID).inflight >= s.maxInflight {
+				continue
+			}
+			if score := s.score(peerID, bestThroughput); score > bestScore {
+				bestPeer, bestScore = peerID, score
+			}
+		}
+		if bestPeer == "" {
+			continue
+		}
+
+		w, ok := building[bestPeer]
+		if !ok || len(w.hashes) >= s.windowSize {
+			w = s.open(bestPeer, now)
+			building[bestPeer] = w
+			windows = append(windows, w)
+		}
+		w.add(hash)
+		s.assigned[hash] = w
+		quota--
+	}
+	return windows
+}
+
+// steal lets each idle peer take over the tail half of the remaining blocks of the window of
+// another peer, which made no progress for longer than its usual latency. It returns the windows
+// to request.
+func (s *downloadScheduler) steal(peersOf func(common.Hash) []string, now time.Time) []*downloadWindow {
+	victims := s.windows()
+	sort.SliceStable(victims, func(i, j int) bool {
+		return len(victims[i].remaining) > len(victims[j].remaining)
+	})
+
+	windows := []*downloadWindow{}
+	for _, victim := range victims {
+		if len(victim.remaining) < 2 {
+			break
+		}
+		if now.Sub(victim.lastActivity) < s.getPeer(victim.peerID).latency {
+			continue
+		}
+		tail := []common.Hash{}
+		for _, hash := range victim.hashes {
+			if _, ok := victim.remaining[hash]; ok {
+				tail = append(tail, hash)
+			}
+		}
+		tail = tail[len(tail)/2:]
+
+		thief := s.findIdlePeer(tail, peersOf, victim.peerID)
+		if thief == "" {
+			continue
+		}
+		w := s.open(thief, now)
+		for _, hash := range tail {
+			delete(victim.remaining, hash)
+			w.add(hash)
+			s.assigned[hash] = w
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+// findIdlePeer returns a peer other than the given one which has all the blocks and is not
+// downloading any window.
+func (s *downloadScheduler) findIdlePeer(hashes []common.Hash, peersOf func(common.Hash) []string, exclude string) string {
+	counts := make(map[string]int)
+	for _, hash := range hashes {
+		for _, peerID := range peersOf(hash) {
+			counts[peerID]++
+		}
+	}
+	idle := []string{}
+	for peerID, count := range counts {
+		if peerID != exclude && count == len(hashes) && s.getPeer(peerID).inflight == 0 {
+			idle = append(idle, peerID)
+		}
+	}
+	if len(idle) == 0 {
+		return ""
+	}
+	sort.Strings(idle)
+	bestThroughput := s.bestThroughput()
+	thief := idle[0]
+	for _, peerID := range idle[1:] {
+		if s.score(peerID, bestThroughput) > s.score(thief, bestThroughput) {
+			thief = peerID
+		}
+	}
+	return thief
+}
+
+func (s *downloadScheduler) open(peerID string, now time.Time) *downloadWindow {
+	w := newDownloadWindow(peerID)
+	w.requestedAt = now
+	w.lastActivity = now
+	s.getPeer(peerID).inflight++
+	return w
+}
+
+// windows returns the windows being downloaded.
+func (s *downloadScheduler) windows() []*downloadWindow {
+	seen := make(map[*downloadWindow]struct{})
+	windows := []*downloadWindow{}
+	for _, w := range s.assigned {
+		if _, ok := seen[w]; !ok {
+			seen[w] = struct{}{}
+			windows = append(windows, w)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].requestedAt.Before(windows[j].requestedAt)
+	})
+	return windows
+}
+
+// onBlock records the block downloaded from the given peer.
+func (s *downloadScheduler) onBlock(peerID string, hash common.Hash, now time.Time) {
+	w, ok := s.assigned[hash]
+	if !ok || w.peerID != peerID {
+		return
+	}
+	ps := s.getPeer(peerID)
+	if w.received == 0 {
+		ps.recordLatency(now.Sub(w.requestedAt))
+	}
+	w.received++
+	w.lastActivity = now
+	s.remove(w, hash)
+
+	if len(w.remaining) == 0 {
+		if elapsed := now.Sub(w.requestedAt).Seconds(); elapsed > 0 {
+			ps.recordThroughput(float64(w.received) / elapsed)
+		}
+	}
+}
+
+// release stops tracking the block, e.g. once it has been received by any means.
+func (s *downloadScheduler) release(hash common.Hash) {
+	if w, ok := s.assigned[hash]; ok {
+		s.remove(w, hash)
+	}
+}
+
+func (s *downloadScheduler) remove(w *downloadWindow, hash common.Hash) {
+	delete(s.assigned, hash)
+	if _, ok := w.remaining[hash]; !ok {
+		return
+	}
+	delete(w.remaining, hash)
+	if len(w.remaining) == 0 {
+		s.getPeer(w.peerID).inflight--
+	}
+}
+
+// expire drops the windows without any progress within RequestTimeout, and penalizes their peers.
+// It returns the hashes to reschedule.
+func (s *downloadScheduler) expire(now time.Time) []common.Hash {
+	expired := []common.Hash{}
+	for _, w := range s.windows() {
+		if !w.hasTimedOut(now) {
+			continue
+		}
+		if s.onTimeout != nil {
+			s.onTimeout(w.peerID)
+		}
+		ps := s.getPeer(w.peerID)
+		ps.recordLatency(RequestTimeout)
+		if ps.throughput == 0 {
+			ps.throughput = 1 / RequestTimeout.Seconds()
+		} else {
+			ps.throughput /= 2
+		}
+		for _, hash := range w.hashes {
+			if _, ok := w.remaining[hash]; ok {
+				expired = append(expired, hash)
+				s.remove(w, hash)
+			}
+		}
+	}
+	return expired
+}
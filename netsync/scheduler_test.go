@@ -0,0 +1,130 @@
+package netsync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+func createTestHashes(num int) []common.Hash {
+	hashes := []common.Hash{}
+	for i := 0; i < num; i++ {
+		hashes = append(hashes, common.BytesToHash([]byte(fmt.Sprintf("block%v", i))))
+	}
+	return hashes
+}
+
+func TestDownloadSchedulerSchedule(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newDownloadScheduler(4, 2)
+	hashes := createTestHashes(20)
+	peersOf := func(common.Hash) []string { return []string{"peer1", "peer2"} }
+	now := time.Now()
+
+	// At most 2 windows of 4 blocks per peer
+	windows := s.schedule(hashes, peersOf, 1000, now)
+	assert.Equal(4, len(windows))
+	peerWindows := make(map[string]int)
+	for i, w := range windows {
+		assert.Equal(hashes[4*i:4*i+4], w.hashes)
+		peerWindows[w.peerID]++
+	}
+	assert.Equal(2, peerWindows["peer1"])
+	assert.Equal(2, peerWindows["peer2"])
+	assert.True(s.isAssigned(hashes[15]))
+	assert.False(s.isAssigned(hashes[16]))
+
+	// The quota caps the number of blocks requested
+	assert.Equal(0, len(s.schedule(hashes, peersOf, 1000, now)))
+	s = newDownloadScheduler(4, 2)
+	windows = s.schedule(hashes, peersOf, 6, now)
+	assert.Equal(2, len(windows))
+	assert.Equal(6, len(windows[0].hashes)+len(windows[1].hashes))
+}
+
+func TestDownloadSchedulerPrefersFastPeers(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newDownloadScheduler(4, 4)
+	hashes := createTestHashes(8)
+	peersOf := func(common.Hash) []string { return []string{"slow", "fast"} }
+	now := time.Now()
+
+	windows := s.schedule(hashes[:4], func(common.Hash) []string { return []string{"slow"} }, 1000, now)
+	assert.Equal(1, len(windows))
+	for _, hash := range hashes[:4] {
+		s.onBlock("slow", hash, now.Add(4*time.Second))
+	}
+	assert.Equal(4*time.Second, s.getPeer("slow").latency)
+	assert.Equal(1.0, s.getPeer("slow").throughput)
+	assert.Equal(0, s.getPeer("slow").inflight)
+
+	windows = s.schedule(hashes[4:], func(common.Hash) []string { return []string{"fast"} }, 1000, now)
+	for _, hash := range hashes[4:] {
+		s.onBlock("fast", hash, now.Add(100*time.Millisecond))
+	}
+	assert.True(s.getPeer("fast").throughput > s.getPeer("slow").throughput)
+
+	// A new window goes to the fastest peer
+	hashes = createTestHashes(12)[8:]
+	windows = s.schedule(hashes, peersOf, 1000, now)
+	assert.Equal(1, len(windows))
+	assert.Equal("fast", windows[0].peerID)
+}
+
+func TestDownloadSchedulerSteal(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newDownloadScheduler(8, 1)
+	hashes := createTestHashes(8)
+	now := time.Now()
+
+	windows := s.schedule(hashes, func(common.Hash) []string { return []string{"peer1"} }, 1000, now)
+	assert.Equal(1, len(windows))
+	s.onBlock("peer1", hashes[0], now.Add(time.Second))
+
+	// The idle peer takes over the tail half of the remaining blocks
+	peersOf := func(common.Hash) []string { return []string{"peer1", "peer2"} }
+	assert.Equal(0, len(s.steal(peersOf, now.Add(time.Second))))
+	stolen := s.steal(peersOf, now.Add(3*time.Second))
+	assert.Equal(1, len(stolen))
+	assert.Equal("peer2", stolen[0].peerID)
+	assert.Equal(hashes[4:], stolen[0].hashes)
+	assert.Equal(3, len(windows[0].remaining))
+
+	// Busy peers do not steal
+	assert.Equal(0, len(s.steal(peersOf, now.Add(5*time.Second))))
+
+	// A block delivered by the victim still completes the stolen window
+	for _, hash := range hashes[4:] {
+		s.release(hash)
+	}
+	assert.Equal(0, s.getPeer("peer2").inflight)
+	assert.Equal(1, s.getPeer("peer1").inflight)
+}
+
+func TestDownloadSchedulerExpire(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newDownloadScheduler(4, 1)
+	timedOut := []string{}
+	s.onTimeout = func(peerID string) { timedOut = append(timedOut, peerID) }
+	hashes := createTestHashes(4)
+	now := time.Now()
+
+	s.schedule(hashes, func(common.Hash) []string { return []string{"peer1"} }, 1000, now)
+	s.onBlock("peer1", hashes[0], now.Add(time.Second))
+	assert.Equal(0, len(s.expire(now.Add(RequestTimeout))))
+	assert.Equal(0, len(timedOut))
+
+	expired := s.expire(now.Add(time.Second + RequestTimeout + time.Millisecond))
+	assert.Equal(hashes[1:], expired)
+	assert.Equal([]string{"peer1"}, timedOut)
+	assert.Equal(0, s.getPeer("peer1").inflight)
+	assert.False(s.isAssigned(hashes[1]))
+	assert.Equal(1/RequestTimeout.Seconds(), s.getPeer("peer1").throughput)
+}
@@ -0,0 +1,414 @@
+package netsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/util"
+	"github.com/thetatoken/theta/core"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/snapshot"
+)
+
+const (
+	// SnapshotChunkTimeout is the time after which a snapshot chunk is requested from another peer
+	SnapshotChunkTimeout = 30 * time.Second
+	// MaxSnapshotSize is the maximum size of the snapshots downloaded by the fast sync
+	MaxSnapshotSize = 1 << 40
+
+	maxInflightSnapshotChunks    = 16
+	snapshotFetchInterval        = 500 * time.Millisecond
+	snapshotProgressSaveInterval = 1 * time.Second
+	// interval after which the peers not serving the snapshot are asked again, e.g. in case they
+	// have generated the requested state diff since
+	snapshotPeerRetryInterval = 30 * time.Second
+)
+
+// snapshotProgress records the chunks already downloaded, so that an interrupted download resumes
+// where it stopped.
+type snapshotProgress struct {
+	Checkpoint common.Hash `json:"checkpoint"`
+	Base       common.Hash `json:"base,omitempty"` // Base checkpoint of a state diff
+	Size       uint64      `json:"size"`
+	Done       []byte      `json:"done"` // Bitmap of the downloaded chunks
+}
+
+func (p *snapshotProgress) setSize(size uint64) {
+	p.Size = size
+	p.Done = make([]byte, (numSnapshotChunks(size)+7)/8)
+}
+
+func (p *snapshotProgress) isDone(index uint64) bool {
+	return p.Done[index/8]&(1<<(index%8)) != 0
+}
+
+func (p *snapshotProgress) setDone(index uint64) {
+	p.Done[index/8] |= 1 << (index % 8)
+}
+
+func (p *snapshotProgress) isComplete() bool {
+	if p.Size == 0 {
+		return false
+	}
+	for i := uint64(0); i < numSnapshotChunks(p.Size); i++ {
+		if !p.isDone(i) {
+			return false
+		}
+	}
+	return true
+}
+
+type snapshotChunkRequest struct {
+	peerID string
+	sentAt time.Time
+}
+
+// SnapshotFetcher downloads the snapshot of a trusted checkpoint from the peers for the fast sync,
+// so that a new node only replays the blocks following the checkpoint. The chunks of the snapshot
+// are requested from multiple peers concurrently, and written to a partial file along with the
+// progress of the download, which is resumed if interrupted. Once complete, the snapshot is
+// validated, and its last finalized block must be the checkpoint.
+type SnapshotFetcher struct {
+	checkpoint common.Hash
+	base       []common.Hash // Checkpoint of the base snapshot when fetching a state diff
+	filePath   string
+	transport  SnapshotTransport
+	validate   func(filePath string) (*core.BlockHeader, error)
+
+	mu          *sync.Mutex
+	progress    *snapshotProgress
+	lastSave    time.Time
+	file        *os.File
+	inflight    map[uint64]*snapshotChunkRequest
+	unavailable map[string]bool // Peers not serving the snapshot
+	lastRetry   time.Time
+	nextPeer    int
+	received    chan struct{}
+
+	logger *log.Entry
+}
+
+// NewSnapshotFetcher creates a new instance of SnapshotFetcher, which downloads the snapshot
+// of the checkpoint to the given path.
+func NewSnapshotFetcher(checkpoint common.Hash, filePath string, transport SnapshotTransport) *SnapshotFetcher {
+	return &SnapshotFetcher{
+		checkpoint:  checkpoint,
+		filePath:    filePath,
+		transport:   transport,
+		validate:    snapshot.ValidateSnapshot,
+		mu:          &sync.Mutex{},
+		inflight:    make(map[uint64]*snapshotChunkRequest),
+		unavailable: make(map[string]bool),
+		received:    make(chan struct{}, 1),
+		logger:      util.GetLoggerForModule("snapshot"),
+	}
+}
+
+// NewStateDiffFetcher creates a new instance of SnapshotFetcher, which downloads the state diff
+// from the snapshot of the base checkpoint at baseSnapshotPath to the snapshot of the checkpoint,
+// and then applies it to produce the snapshot of the checkpoint at snapshotPath.
+func NewStateDiffFetcher(baseSnapshotPath string, base, checkpoint common.Hash, snapshotPath string, transport SnapshotTransport) *SnapshotFetcher {
+	sf := NewSnapshotFetcher(checkpoint, snapshotPath+".diff", transport)
+	sf.base = []common.Hash{base}
+	sf.validate = func(filePath string) (*core.BlockHeader, error) {
+		return snapshot.ApplyStateDiffFile(baseSnapshotPath, filePath, snapshotPath)
+	}
+	return sf
+}
+
+func (sf *SnapshotFetcher) baseCheckpoint() common.Hash {
+	if len(sf.base) == 0 {
+		return common.Hash{}
+	}
+	return sf.base[0]
+}
+
+func (sf *SnapshotFetcher) partPath() string {
+	return sf.filePath + ".part"
+}
+
+func (sf *SnapshotFetcher) progressPath() string {
+	return sf.filePath + ".progress"
+}
+
+// Fetch downloads the snapshot, and returns the header of its last finalized block, i.e. the checkpoint.
+func (sf *SnapshotFetcher) Fetch(ctx context.Context) (*core.BlockHeader, error) {
+	if err := sf.open(); err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(snapshotFetchInterval)
+	defer ticker.Stop()
+	for !sf.requestChunks(time.Now()) {
+		select {
+		case <-ctx.Done():
+			sf.close()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		case <-sf.received:
+		}
+	}
+	return sf.finish()
+}
+
+// open resumes the download recorded in the progress file, if it is for the same checkpoint.
+func (sf *SnapshotFetcher) open() error {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	progress := &snapshotProgress{}
+	raw, err := ioutil.ReadFile(sf.progressPath())
+	if err == nil {
+		err = json.Unmarshal(raw, progress)
+	}
+	if err == nil && progress.Checkpoint == sf.checkpoint && progress.Base == sf.baseCheckpoint() &&
+		uint64(len(progress.Done)) == (numSnapshotChunks(progress.Size)+7)/8 {
+		sf.logger.WithFields(log.Fields{
+			"checkpoint": sf.checkpoint.Hex(),
+			"size":       progress.Size,
+		}).Info("Resuming the snapshot download")
+	} else {
+		progress = &snapshotProgress{Checkpoint: sf.checkpoint, Base: sf.baseCheckpoint()}
+		os.Remove(sf.partPath())
+	}
+	sf.progress = progress
+	sf.lastRetry = time.Now()
+
+	file, err := os.OpenFile(sf.partPath(), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	sf.file = file
+	return nil
+}
+
+func (sf *SnapshotFetcher) close() {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	sf.saveProgress()
+	sf.file.Close()
+}
+
+// requestChunks requests the missing chunks which are not being downloaded, and returns whether
+// the download is complete. The first chunk is requested alone, to learn the size of the snapshot.
+func (sf *SnapshotFetcher) requestChunks(now time.Time) bool {
+	complete, requests := sf.scheduleChunks(now)
+	for index, peerID := range requests {
+		sf.transport.GetSnapshotChunk([]string{peerID}, dp.SnapshotChunkRequest{
+			Checkpoint: sf.checkpoint,
+			Index:      index,
+			Base:       sf.base,
+		})
+	}
+	return complete
+}
+
+// scheduleChunks assigns the chunks to request to the peers, the requests are sent without
+// holding the lock since the responses may be handled synchronously.
+func (sf *SnapshotFetcher) scheduleChunks(now time.Time) (bool, map[uint64]string) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	requests := make(map[uint64]string)
+	if sf.progress.isComplete() {
+		return true, requests
+	}
+
+	numChunks := uint64(1)
+	if sf.progress.Size != 0 {
+		numChunks = numSnapshotChunks(sf.progress.Size)
+	}
+	numInflight := 0
+	for index, req := range sf.inflight {
+		if now.Sub(req.sentAt) > SnapshotChunkTimeout {
+			delete(sf.inflight, index)
+		} else {
+			numInflight++
+		}
+	}
+
+	for index := uint64(0); index < numChunks && numInflight < maxInflightSnapshotChunks; index++ {
+		if sf.progress.Size != 0 && sf.progress.isDone(index) {
+			continue
+		}
+		if _, ok := sf.inflight[index]; ok {
+			continue
+		}
+		peerID := sf.pickPeer(now)
+		if peerID == "" {
+			break
+		}
+		sf.inflight[index] = &snapshotChunkRequest{peerID: peerID, sentAt: now}
+		requests[index] = peerID
+		numInflight++
+	}
+	return false, requests
+}
+
+// pickPeer returns the next peer in turn, skipping the peers not serving the snapshot. Once no
+// peer is left, the peers are asked again after snapshotPeerRetryInterval.
+func (sf *SnapshotFetcher) pickPeer(now time.Time) string {
+	peerIDs := []string{}
+	allStats := sf.transport.PeerStats()
+	for _, stats := range allStats {
+		if !sf.unavailable[stats.PeerID] {
+			peerIDs = append(peerIDs, stats.PeerID)
+		}
+	}
+	if len(peerIDs) == 0 && len(allStats) != 0 && now.Sub(sf.lastRetry) >= snapshotPeerRetryInterval {
+		sf.lastRetry = now
+		sf.unavailable = make(map[string]bool)
+		for _, stats := range allStats {
+			peerIDs = append(peerIDs, stats.PeerID)
+		}
+	}
+	if len(peerIDs) == 0 {
+		return ""
+	}
+	sort.Strings(peerIDs)
+	sf.nextPeer = (sf.nextPeer + 1) % len(peerIDs)
+	return peerIDs[sf.nextPeer]
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface.
+func (sf *SnapshotFetcher) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		common.ChannelIDSnapshotResponse,
+	}
+}
+
+// ParseMessage implements the p2p.MessageHandler interface.
+func (sf *SnapshotFetcher) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	data := dp.SnapshotChunkResponse{}
+	err := rlp.DecodeBytes(rawMessageBytes, &data)
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   data,
+	}, err
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface.
+func (sf *SnapshotFetcher) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// HandleMessage implements the p2p.MessageHandler interface.
+func (sf *SnapshotFetcher) HandleMessage(msg p2ptypes.Message) error {
+	resp, ok := msg.Content.(dp.SnapshotChunkResponse)
+	if !ok {
+		return errors.New("Invalid snapshot chunk response")
+	}
+	if resp.Checkpoint != sf.checkpoint || len(resp.Base) != len(sf.base) ||
+		(len(sf.base) != 0 && resp.Base[0] != sf.base[0]) {
+		return nil
+	}
+
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	if req, ok := sf.inflight[resp.Index]; ok && req.peerID == msg.PeerID {
+		delete(sf.inflight, resp.Index)
+	}
+	if err := sf.writeChunk(&resp); err != nil {
+		sf.logger.WithFields(log.Fields{
+			"index": resp.Index,
+			"peer":  msg.PeerID,
+			"error": err,
+		}).Debug("Discarded snapshot chunk")
+		sf.unavailable[msg.PeerID] = true
+	}
+
+	// Request the next chunks, or the same chunk from another peer
+	select {
+	case sf.received <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (sf *SnapshotFetcher) writeChunk(resp *dp.SnapshotChunkResponse) error {
+	if resp.Size == 0 {
+		return errors.New("Snapshot not available")
+	}
+	if sf.progress.Size == 0 {
+		if resp.Size > MaxSnapshotSize {
+			return fmt.Errorf("Snapshot too large: %v bytes", resp.Size)
+		}
+		sf.progress.setSize(resp.Size)
+	}
+	if resp.Size != sf.progress.Size {
+		return fmt.Errorf("Snapshot size mismatch: %v vs %v", resp.Size, sf.progress.Size)
+	}
+	if resp.Index >= numSnapshotChunks(sf.progress.Size) ||
+		uint64(len(resp.Data)) != snapshotChunkLength(sf.progress.Size, resp.Index) {
+		return fmt.Errorf("Invalid snapshot chunk %v", resp.Index)
+	}
+	if sf.progress.isDone(resp.Index) {
+		return nil
+	}
+
+	if _, err := sf.file.WriteAt(resp.Data, int64(resp.Index*SnapshotChunkSize)); err != nil {
+		return err
+	}
+	sf.progress.setDone(resp.Index)
+	if time.Since(sf.lastSave) >= snapshotProgressSaveInterval {
+		sf.saveProgress()
+	}
+	return nil
+}
+
+func (sf *SnapshotFetcher) saveProgress() {
+	sf.lastSave = time.Now()
+	if err := sf.file.Sync(); err != nil {
+		sf.logger.WithFields(log.Fields{"error": err}).Warn("Failed to sync the snapshot")
+		return
+	}
+	raw, err := json.Marshal(sf.progress)
+	if err == nil {
+		err = ioutil.WriteFile(sf.progressPath(), raw, 0600)
+	}
+	if err != nil {
+		sf.logger.WithFields(log.Fields{"error": err}).Warn("Failed to save the snapshot download progress")
+	}
+}
+
+// finish validates the downloaded snapshot against the checkpoint. The download starts over
+// if the snapshot is invalid.
+func (sf *SnapshotFetcher) finish() (*core.BlockHeader, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	sf.file.Close()
+	os.Remove(sf.progressPath())
+	if err := os.Rename(sf.partPath(), sf.filePath); err != nil {
+		return nil, err
+	}
+
+	header, err := sf.validate(sf.filePath)
+	if err == nil && header.Hash() != sf.checkpoint {
+		err = fmt.Errorf("The last finalized block %v of the snapshot is not the checkpoint %v",
+			header.Hash().Hex(), sf.checkpoint.Hex())
+	}
+	if err != nil {
+		os.Remove(sf.filePath)
+		return nil, fmt.Errorf("Invalid snapshot: %v", err)
+	}
+	if len(sf.base) != 0 {
+		os.Remove(sf.filePath) // the state diff has been applied
+	}
+	return header, nil
+}
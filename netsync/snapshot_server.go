@@ -0,0 +1,297 @@
+package netsync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/util"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/snapshot"
+	"github.com/thetatoken/theta/store/database"
+)
+
+// SnapshotChunkSize is the size of the snapshot chunks exchanged by the fast sync
+const SnapshotChunkSize = 256 * 1024
+
+// snapshotRescanInterval is the minimum interval between two scans of the snapshot directory,
+// when a peer requests an unknown snapshot
+const snapshotRescanInterval = 1 * time.Minute
+
+// SnapshotTransport sends the snapshot chunk requests and responses to the peers, see dispatcher.Dispatcher.
+type SnapshotTransport interface {
+	GetSnapshotChunk(peerIDs []string, req dp.SnapshotChunkRequest)
+	SendSnapshotChunk(peerIDs []string, resp dp.SnapshotChunkResponse)
+	PeerStats() []p2ptypes.PeerStats
+}
+
+var _ SnapshotTransport = (*dp.Dispatcher)(nil)
+
+// stateDiffKey identifies a state diff by the checkpoints of its base and resulting snapshots
+type stateDiffKey struct {
+	base       common.Hash
+	checkpoint common.Hash
+}
+
+// SnapshotServer serves the chunks of the snapshots of a directory to the fast syncing peers. The
+// snapshots are identified by their checkpoint, i.e. the hash of their last finalized block. It
+// also serves the state diff files of the directory to the peers which already hold a snapshot,
+// and generates the missing ones from the local states if enabled, see EnableStateDiffs.
+type SnapshotServer struct {
+	dir       string
+	transport SnapshotTransport
+
+	mu       *sync.Mutex
+	files    map[common.Hash]string
+	diffs    map[stateDiffKey]string
+	lastScan time.Time
+
+	db         database.Database
+	chain      *blockchain.Chain
+	generating bool // Whether a state diff is being generated
+
+	logger *log.Entry
+}
+
+// NewSnapshotServer creates a new instance of SnapshotServer
+func NewSnapshotServer(dir string, transport SnapshotTransport) *SnapshotServer {
+	return &SnapshotServer{
+		dir:       dir,
+		transport: transport,
+		mu:        &sync.Mutex{},
+		files:     make(map[common.Hash]string),
+		diffs:     make(map[stateDiffKey]string),
+		logger:    util.GetLoggerForModule("snapshot"),
+	}
+}
+
+// EnableStateDiffs makes the server generate the state diffs requested by the peers from the local
+// states, one at a time. The peers are told the diff is not available until it is generated, and
+// the diffs whose states have been pruned are never available.
+func (ss *SnapshotServer) EnableStateDiffs(db database.Database, chain *blockchain.Chain) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.db = db
+	ss.chain = chain
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface.
+func (ss *SnapshotServer) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		common.ChannelIDSnapshotRequest,
+	}
+}
+
+// ParseMessage implements the p2p.MessageHandler interface.
+func (ss *SnapshotServer) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	data := dp.SnapshotChunkRequest{}
+	err := rlp.DecodeBytes(rawMessageBytes, &data)
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   data,
+	}, err
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface.
+func (ss *SnapshotServer) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// HandleMessage implements the p2p.MessageHandler interface.
+func (ss *SnapshotServer) HandleMessage(msg p2ptypes.Message) error {
+	req, ok := msg.Content.(dp.SnapshotChunkRequest)
+	if !ok {
+		return errors.New("Invalid snapshot chunk request")
+	}
+
+	resp := dp.SnapshotChunkResponse{
+		Checkpoint: req.Checkpoint,
+		Index:      req.Index,
+		Base:       req.Base,
+	}
+	var filePath string
+	var err error
+	switch len(req.Base) {
+	case 0:
+		filePath, err = ss.findSnapshot(req.Checkpoint)
+	case 1:
+		filePath, err = ss.findStateDiff(stateDiffKey{base: req.Base[0], checkpoint: req.Checkpoint})
+	default:
+		err = errors.New("Invalid state diff base")
+	}
+	var size uint64
+	var data common.Bytes
+	if err == nil {
+		size, data, err = readSnapshotChunk(filePath, req.Index)
+	}
+	if err != nil {
+		ss.logger.WithFields(log.Fields{
+			"checkpoint": req.Checkpoint.Hex(),
+			"index":      req.Index,
+			"peer":       msg.PeerID,
+			"error":      err,
+		}).Debug("Snapshot chunk not available")
+	} else {
+		resp.Size = size
+		resp.Data = data
+	}
+	ss.transport.SendSnapshotChunk([]string{msg.PeerID}, resp)
+	return nil
+}
+
+// readSnapshotChunk returns the size of the file, and the chunk at the given index.
+func readSnapshotChunk(filePath string, index uint64) (uint64, common.Bytes, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, nil, err
+	}
+	size := uint64(info.Size())
+	if size == 0 || index >= numSnapshotChunks(size) {
+		return 0, nil, errors.New("Invalid snapshot chunk index")
+	}
+	data := make([]byte, snapshotChunkLength(size, index))
+	if _, err := file.ReadAt(data, int64(index*SnapshotChunkSize)); err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+	return size, data, nil
+}
+
+func (ss *SnapshotServer) findSnapshot(checkpoint common.Hash) (string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if filePath, ok := ss.files[checkpoint]; ok {
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath, nil
+		}
+		delete(ss.files, checkpoint)
+	}
+	if time.Since(ss.lastScan) < snapshotRescanInterval {
+		return "", errors.New("Unknown snapshot")
+	}
+	ss.scan()
+	if filePath, ok := ss.files[checkpoint]; ok {
+		return filePath, nil
+	}
+	return "", errors.New("Unknown snapshot")
+}
+
+func (ss *SnapshotServer) findStateDiff(key stateDiffKey) (string, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if filePath, ok := ss.diffs[key]; ok {
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath, nil
+		}
+		delete(ss.diffs, key)
+	}
+	if time.Since(ss.lastScan) >= snapshotRescanInterval {
+		ss.scan()
+		if filePath, ok := ss.diffs[key]; ok {
+			return filePath, nil
+		}
+	}
+	if ss.chain != nil && !ss.generating {
+		ss.generating = true
+		go ss.generateStateDiff(key)
+	}
+	return "", errors.New("Unknown state diff")
+}
+
+// generateStateDiff exports the state diff to the directory, and indexes it.
+func (ss *SnapshotServer) generateStateDiff(key stateDiffKey) {
+	filename := fmt.Sprintf("theta_statediff-%v-%v", key.base.Hex(), key.checkpoint.Hex())
+	tmpPath := path.Join(ss.dir, "tmp_"+filename)
+	filePath := path.Join(ss.dir, filename)
+	_, err := snapshot.ExportStateDiffFile(ss.db, ss.chain, key.base, key.checkpoint, tmpPath)
+	if err == nil {
+		err = os.Rename(tmpPath, filePath)
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.generating = false
+	if err != nil {
+		os.Remove(tmpPath)
+		ss.logger.WithFields(log.Fields{
+			"base":       key.base.Hex(),
+			"checkpoint": key.checkpoint.Hex(),
+			"error":      err,
+		}).Debug("Failed to generate the state diff")
+		return
+	}
+	ss.diffs[key] = filePath
+	ss.logger.WithFields(log.Fields{"file": filePath}).Info("Generated a state diff")
+}
+
+// scan indexes the snapshots of the directory by checkpoint.
+func (ss *SnapshotServer) scan() {
+	ss.lastScan = time.Now()
+	infos, err := ioutil.ReadDir(ss.dir)
+	if err != nil {
+		ss.logger.WithFields(log.Fields{"dir": ss.dir, "error": err}).Warn("Failed to read the snapshot directory")
+		return
+	}
+	indexed := make(map[string]bool)
+	for _, filePath := range ss.files {
+		indexed[filePath] = true
+	}
+	for _, filePath := range ss.diffs {
+		indexed[filePath] = true
+	}
+	for _, info := range infos {
+		filePath := path.Join(ss.dir, info.Name())
+		if info.IsDir() || indexed[filePath] {
+			continue
+		}
+		if strings.HasPrefix(info.Name(), "theta_statediff-") {
+			header, err := snapshot.ReadStateDiffFileHeader(filePath)
+			if err != nil {
+				ss.logger.WithFields(log.Fields{"file": filePath, "error": err}).Warn("Failed to read the state diff header")
+				continue
+			}
+			ss.diffs[stateDiffKey{base: header.FromBlockHash, checkpoint: header.ToBlockHash}] = filePath
+			continue
+		}
+		if !strings.HasPrefix(info.Name(), "theta_snapshot-") {
+			continue
+		}
+		checkpoint, err := snapshot.ReadSnapshotCheckpoint(filePath)
+		if err != nil {
+			ss.logger.WithFields(log.Fields{"file": filePath, "error": err}).Warn("Failed to read the snapshot metadata")
+			continue
+		}
+		ss.files[checkpoint] = filePath
+	}
+}
+
+func numSnapshotChunks(size uint64) uint64 {
+	return (size + SnapshotChunkSize - 1) / SnapshotChunkSize
+}
+
+func snapshotChunkLength(size uint64, index uint64) uint64 {
+	if (index+1)*SnapshotChunkSize > size {
+		return size - index*SnapshotChunkSize
+	}
+	return SnapshotChunkSize
+}
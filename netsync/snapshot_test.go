@@ -0,0 +1,256 @@
+package netsync
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	dp "github.com/thetatoken/theta/dispatcher"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// snapshotTestTransport delivers the messages synchronously between a fetcher and the servers
+type snapshotTestTransport struct {
+	fetcher   *SnapshotFetcher
+	servers   map[string]*SnapshotServer
+	requested map[uint64]int
+	numSent   int
+	maxServed int // Number of chunk requests served, unlimited if negative
+}
+
+func (tt *snapshotTestTransport) GetSnapshotChunk(peerIDs []string, req dp.SnapshotChunkRequest) {
+	raw, err := rlp.EncodeToBytes(req)
+	if err != nil {
+		panic(err)
+	}
+	for _, peerID := range peerIDs {
+		tt.requested[req.Index]++
+		tt.numSent++
+		if tt.maxServed >= 0 && tt.numSent > tt.maxServed {
+			continue
+		}
+		server := tt.servers[peerID]
+		msg, err := server.ParseMessage("fetcher", common.ChannelIDSnapshotRequest, raw)
+		if err != nil {
+			panic(err)
+		}
+		server.transport = &snapshotServerTestTransport{id: peerID, fetcher: tt.fetcher}
+		if err := server.HandleMessage(msg); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (tt *snapshotTestTransport) SendSnapshotChunk(peerIDs []string, resp dp.SnapshotChunkResponse) {
+	panic("unexpected snapshot chunk response from the fetcher")
+}
+
+func (tt *snapshotTestTransport) PeerStats() []p2ptypes.PeerStats {
+	stats := []p2ptypes.PeerStats{}
+	for peerID := range tt.servers {
+		stats = append(stats, p2ptypes.PeerStats{PeerID: peerID})
+	}
+	return stats
+}
+
+type snapshotServerTestTransport struct {
+	id      string
+	fetcher *SnapshotFetcher
+}
+
+func (st *snapshotServerTestTransport) GetSnapshotChunk(peerIDs []string, req dp.SnapshotChunkRequest) {
+	panic("unexpected snapshot chunk request from a server")
+}
+
+func (st *snapshotServerTestTransport) SendSnapshotChunk(peerIDs []string, resp dp.SnapshotChunkResponse) {
+	raw, err := rlp.EncodeToBytes(resp)
+	if err != nil {
+		panic(err)
+	}
+	msg, err := st.fetcher.ParseMessage(st.id, common.ChannelIDSnapshotResponse, raw)
+	if err != nil {
+		panic(err)
+	}
+	if err := st.fetcher.HandleMessage(msg); err != nil {
+		panic(err)
+	}
+}
+
+func (st *snapshotServerTestTransport) PeerStats() []p2ptypes.PeerStats {
+	return []p2ptypes.PeerStats{}
+}
+
+type snapshotTestEnv struct {
+	dir        string
+	header     *core.BlockHeader
+	checkpoint common.Hash
+	content    []byte
+	servers    map[string]*SnapshotServer
+}
+
+// newSnapshotTestEnv creates a snapshot of a few chunks, served by "peer1", while "peer2"
+// does not have it.
+func newSnapshotTestEnv(t *testing.T) *snapshotTestEnv {
+	dir, err := ioutil.TempDir("", "snapshot_test")
+	require.Nil(t, err)
+
+	header := &core.BlockHeader{ChainID: "testchain", Height: 100}
+	content := make([]byte, 3*SnapshotChunkSize+1000)
+	rand.Read(content)
+	filePath := path.Join(dir, "theta_snapshot-100")
+	require.Nil(t, ioutil.WriteFile(filePath, content, 0600))
+
+	now := time.Now()
+	server1 := NewSnapshotServer(dir, nil)
+	server1.files[header.Hash()] = filePath
+	server1.lastScan = now
+	server2 := NewSnapshotServer(path.Join(dir, "none"), nil)
+	server2.lastScan = now
+
+	return &snapshotTestEnv{
+		dir:        dir,
+		header:     header,
+		checkpoint: header.Hash(),
+		content:    content,
+		servers:    map[string]*SnapshotServer{"peer1": server1, "peer2": server2},
+	}
+}
+
+func (env *snapshotTestEnv) newFetcher(maxServed int) (*SnapshotFetcher, *snapshotTestTransport) {
+	transport := &snapshotTestTransport{
+		servers:   env.servers,
+		requested: make(map[uint64]int),
+		maxServed: maxServed,
+	}
+	fetcher := NewSnapshotFetcher(env.checkpoint, path.Join(env.dir, "download"), transport)
+	fetcher.validate = func(string) (*core.BlockHeader, error) {
+		return env.header, nil
+	}
+	transport.fetcher = fetcher
+	return fetcher, transport
+}
+
+func TestSnapshotFetch(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	env := newSnapshotTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	fetcher, _ := env.newFetcher(-1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	header, err := fetcher.Fetch(ctx)
+	require.Nil(err)
+	assert.Equal(env.checkpoint, header.Hash())
+
+	content, err := ioutil.ReadFile(fetcher.filePath)
+	require.Nil(err)
+	assert.Equal(env.content, content)
+	_, err = os.Stat(fetcher.partPath())
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(fetcher.progressPath())
+	assert.True(os.IsNotExist(err))
+
+	// Only peer1 serves the snapshot
+	assert.True(fetcher.unavailable["peer2"])
+	assert.False(fetcher.unavailable["peer1"])
+}
+
+func TestSnapshotFetchResume(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	env := newSnapshotTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	// The download is interrupted after the first two requests, including the one to peer2
+	fetcher, _ := env.newFetcher(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := fetcher.Fetch(ctx)
+	assert.Equal(context.DeadlineExceeded, err)
+	_, err = os.Stat(fetcher.progressPath())
+	require.Nil(err)
+
+	// The chunk already downloaded is not requested again
+	fetcher, transport := env.newFetcher(-1)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	header, err := fetcher.Fetch(ctx)
+	require.Nil(err)
+	assert.Equal(env.checkpoint, header.Hash())
+	assert.Equal(0, transport.requested[0])
+	assert.True(transport.requested[1] > 0)
+
+	content, err := ioutil.ReadFile(fetcher.filePath)
+	require.Nil(err)
+	assert.Equal(env.content, content)
+}
+
+func TestSnapshotFetchInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	env := newSnapshotTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	fetcher, _ := env.newFetcher(-1)
+	fetcher.validate = func(string) (*core.BlockHeader, error) {
+		return &core.BlockHeader{ChainID: "testchain", Height: 99}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := fetcher.Fetch(ctx)
+	assert.NotNil(err)
+	_, err = os.Stat(fetcher.filePath)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestStateDiffFetch(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	env := newSnapshotTestEnv(t)
+	defer os.RemoveAll(env.dir)
+
+	// peer2 serves the state diff from the base checkpoint, peer1 only the full snapshot
+	base := common.BytesToHash([]byte("base"))
+	diff := env.content[:SnapshotChunkSize+100]
+	diffPath := path.Join(env.dir, "theta_statediff-100")
+	require.Nil(ioutil.WriteFile(diffPath, diff, 0600))
+	env.servers["peer2"].diffs[stateDiffKey{base: base, checkpoint: env.checkpoint}] = diffPath
+
+	transport := &snapshotTestTransport{
+		servers:   env.servers,
+		requested: make(map[uint64]int),
+		maxServed: -1,
+	}
+	snapshotPath := path.Join(env.dir, "snapshot")
+	fetcher := NewStateDiffFetcher(path.Join(env.dir, "base"), base, env.checkpoint, snapshotPath, transport)
+	applied := []byte{}
+	fetcher.validate = func(filePath string) (*core.BlockHeader, error) {
+		var err error
+		applied, err = ioutil.ReadFile(filePath)
+		return env.header, err
+	}
+	transport.fetcher = fetcher
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	header, err := fetcher.Fetch(ctx)
+	require.Nil(err)
+	assert.Equal(env.checkpoint, header.Hash())
+	assert.Equal(diff, applied)
+	assert.True(fetcher.unavailable["peer1"])
+	assert.False(fetcher.unavailable["peer2"])
+
+	// The applied diff is removed
+	_, err = os.Stat(fetcher.filePath)
+	assert.True(os.IsNotExist(err))
+}
@@ -3,6 +3,7 @@ package netsync
 import (
 	"context"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -18,6 +19,9 @@ import (
 
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "netsync"})
 
+// finalizedBlocksBufferSize is the number of finalized blocks buffered for the propagation tracker
+const finalizedBlocksBufferSize = 64
+
 type MessageConsumer interface {
 	AddMessage(interface{})
 }
@@ -29,10 +33,13 @@ var _ p2p.MessageHandler = (*SyncManager)(nil)
 type SyncManager struct {
 	chain      *blockchain.Chain
 	consensus  core.ConsensusEngine
+	finality   core.FinalityProvider
 	consumer   MessageConsumer
 	dispatcher *dispatcher.Dispatcher
 	requestMgr *RequestManager
 
+	propagation *PropagationTracker
+
 	wg      *sync.WaitGroup
 	ctx     context.Context
 	cancel  context.CancelFunc
@@ -43,10 +50,11 @@ type SyncManager struct {
 	logger *log.Entry
 }
 
-func NewSyncManager(chain *blockchain.Chain, cons core.ConsensusEngine, network p2p.Network, disp *dispatcher.Dispatcher, consumer MessageConsumer) *SyncManager {
+func NewSyncManager(chain *blockchain.Chain, cons core.ConsensusEngine, finality core.FinalityProvider, network p2p.Network, disp *dispatcher.Dispatcher, consumer MessageConsumer) *SyncManager {
 	sm := &SyncManager{
 		chain:      chain,
 		consensus:  cons,
+		finality:   finality,
 		consumer:   consumer,
 		dispatcher: disp,
 
@@ -54,9 +62,11 @@ func NewSyncManager(chain *blockchain.Chain, cons core.ConsensusEngine, network
 		incoming: make(chan p2ptypes.Message, viper.GetInt(common.CfgSyncMessageQueueSize)),
 	}
 	sm.requestMgr = NewRequestManager(sm)
+	sm.propagation = NewPropagationTracker(chain)
 	network.RegisterMessageHandler(sm)
 
-	logger := util.GetLoggerForModule("sync")
+	// The package logger honors the module log level and the log format as well
+	logger = util.GetLoggerForModule("sync")
 	if viper.GetBool(common.CfgLogPrintSelfID) {
 		logger = logger.WithFields(log.Fields{"id": sm.consensus.ID()})
 	}
@@ -88,6 +98,9 @@ func (sm *SyncManager) Wait() {
 func (sm *SyncManager) mainLoop() {
 	defer sm.wg.Done()
 
+	finalized := sm.finality.SubscribeFinalizedBlocks(finalizedBlocksBufferSize)
+	defer finalized.Unsubscribe()
+
 	for {
 		select {
 		case <-sm.ctx.Done():
@@ -95,10 +108,17 @@ func (sm *SyncManager) mainLoop() {
 			return
 		case msg := <-sm.incoming:
 			sm.processMessage(msg)
+		case block := <-finalized.C:
+			sm.propagation.BlockFinalized(block, time.Now())
 		}
 	}
 }
 
+// PropagationTracker returns the tracker of the propagation of the blocks and the votes received.
+func (sm *SyncManager) PropagationTracker() *PropagationTracker {
+	return sm.propagation
+}
+
 // GetChannelIDs implements the p2p.MessageHandler interface.
 func (sm *SyncManager) GetChannelIDs() []common.ChannelIDEnum {
 	return []common.ChannelIDEnum{
@@ -172,7 +192,7 @@ func (m *SyncManager) locateStart(starts []string) common.Hash {
 func (m *SyncManager) collectBlocks(start common.Hash, end common.Hash) []string {
 	ret := []string{}
 
-	lfbHeight := m.consensus.GetLastFinalizedBlock().Height
+	lfbHeight := m.finality.GetLastFinalizedBlock().Height
 	q := []common.Hash{start}
 	for len(q) > 0 && len(ret) < dispatcher.MaxInventorySize-1 {
 		curr := q[0]
@@ -217,7 +237,7 @@ func (m *SyncManager) collectBlocks(start common.Hash, end common.Hash) []string
 	}
 
 	// Add last finalized block in the end so that receiver is aware of latest network state.
-	ret = append(ret, m.consensus.GetLastFinalizedBlock().Hash().Hex())
+	ret = append(ret, m.finality.GetLastFinalizedBlock().Hash().Hex())
 
 	return ret
 }
@@ -293,7 +313,7 @@ func (m *SyncManager) handleDataRequest(peerID string, data *dispatcher.DataRequ
 					"err":       err,
 					"peerID":    peerID,
 				}).Debug("Failed to find hash string locally")
-				return
+				continue
 			}
 
 			payload, err := rlp.EncodeToBytes(block.Block)
@@ -334,8 +354,12 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 				"error":     err,
 				"peerID":    peerID,
 			}).Warn("Failed to decode DataResponse payload")
+			m.dispatcher.ReportPeer(peerID, p2ptypes.PeerEventInvalidMessage)
 			return
 		}
+		m.dispatcher.ReportPeer(peerID, p2ptypes.PeerEventUsefulData)
+		m.requestMgr.RecordDownload(peerID, block.Hash())
+		m.propagation.RecordBlock(block, peerID, time.Now())
 		m.handleBlock(block)
 	case common.ChannelIDVote:
 		vote := core.Vote{}
@@ -347,8 +371,10 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 				"error":     err,
 				"peerID":    peerID,
 			}).Warn("Failed to decode DataResponse payload")
+			m.dispatcher.ReportPeer(peerID, p2ptypes.PeerEventInvalidMessage)
 			return
 		}
+		m.propagation.RecordVote(vote, peerID, time.Now())
 		m.handleVote(vote)
 	case common.ChannelIDProposal:
 		proposal := &core.Proposal{}
@@ -360,8 +386,18 @@ func (m *SyncManager) handleDataResponse(peerID string, data *dispatcher.DataRes
 				"error":     err,
 				"peerID":    peerID,
 			}).Warn("Failed to decode DataResponse payload")
+			m.dispatcher.ReportPeer(peerID, p2ptypes.PeerEventInvalidMessage)
 			return
 		}
+		now := time.Now()
+		if proposal.Votes != nil {
+			for _, vote := range proposal.Votes.Votes() {
+				m.propagation.RecordVote(vote, peerID, now)
+			}
+		}
+		if proposal.Block != nil {
+			m.propagation.RecordBlock(proposal.Block, peerID, now)
+		}
 		m.handleProposal(proposal)
 	default:
 		m.logger.WithFields(log.Fields{
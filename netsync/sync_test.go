@@ -87,7 +87,7 @@ func TestSyncManager(t *testing.T) {
 	consensus := consensus.NewConsensusEngine(nil, db, initChain, dispatch, valMgr)
 	mockMsgConsumer := NewMockMessageConsumer()
 
-	sm := NewSyncManager(initChain, consensus, net1, dispatch, mockMsgConsumer)
+	sm := NewSyncManager(initChain, consensus, consensus, net1, dispatch, mockMsgConsumer)
 	sm.Start(context.Background())
 
 	// Send block A4 to node1
@@ -183,8 +183,8 @@ func NewMockConsensus(chain *blockchain.Chain, lfb *core.ExtendedBlock) *MockCon
 // GetEpoch() uint64
 // GetLedger() Ledger
 // AddMessage(msg interface{})
-// FinalizedBlocks() chan *Block
 // GetLastFinalizedBlock() *ExtendedBlock
+// IsInRecovery() bool
 
 func (c *MockConsensus) ID() string {
 	return ""
@@ -205,15 +205,48 @@ func (c *MockConsensus) GetEpoch() uint64 {
 func (c *MockConsensus) GetLedger() core.Ledger {
 	return (*ledger.Ledger)(nil)
 }
+func (c *MockConsensus) SetLedger(ledger core.Ledger) {
+}
 func (c *MockConsensus) AddMessage(msg interface{}) {
 
 }
-func (c *MockConsensus) FinalizedBlocks() chan *core.Block {
-	return make(chan *core.Block)
+func (c *MockConsensus) Start(ctx context.Context) {
+}
+func (c *MockConsensus) Stop() {
+}
+func (c *MockConsensus) Wait() {
 }
 func (c *MockConsensus) GetLastFinalizedBlock() *core.ExtendedBlock {
 	return c.lfb
 }
+func (c *MockConsensus) IsFinalized(hash common.Hash) bool {
+	block, err := c.chain.FindBlock(hash)
+	return err == nil && block.Status.IsFinalized()
+}
+func (c *MockConsensus) SubscribeFinalizedBlocks(bufferSize int) *core.FinalitySubscription {
+	return core.NewFinalityFeed().Subscribe(bufferSize)
+}
+func (c *MockConsensus) SubscribeValidatedBlocks(bufferSize int) *core.FinalitySubscription {
+	return core.NewFinalityFeed().Subscribe(bufferSize)
+}
+func (c *MockConsensus) GetFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	return c.chain.FindFinalizationCertificate(hash)
+}
+func (c *MockConsensus) IsInRecovery() bool {
+	return false
+}
+
+// MockRecoveringConsensus is catching up with the network, its tip is the last finalized block
+type MockRecoveringConsensus struct {
+	*MockConsensus
+}
+
+func (c *MockRecoveringConsensus) GetTip(includePendingBlockingLeaf bool) *core.ExtendedBlock {
+	return c.lfb
+}
+func (c *MockRecoveringConsensus) IsInRecovery() bool {
+	return true
+}
 
 func TestCollectBlocks(t *testing.T) {
 	assert := assert.New(t)
@@ -243,7 +276,7 @@ func TestCollectBlocks(t *testing.T) {
 	consensus := NewMockConsensus(initChain, a3)
 	mockMsgConsumer := NewMockMessageConsumer()
 
-	sm := NewSyncManager(initChain, consensus, net1, dispatch, mockMsgConsumer)
+	sm := NewSyncManager(initChain, consensus, consensus, net1, dispatch, mockMsgConsumer)
 
 	blocks := sm.collectBlocks(core.GetTestBlock("A1").Hash(), core.GetTestBlock("A5").Hash())
 	// Expected blocks: [A1, A2, A3, A4, D4, A5, A3]
@@ -256,3 +289,71 @@ func TestCollectBlocks(t *testing.T) {
 	assert.Equal(core.GetTestBlock("A5").Hash().Hex(), blocks[5])
 	assert.Equal(core.GetTestBlock("A3").Hash().Hex(), blocks[6])
 }
+
+func TestSyncManagerPartitionRecovery(t *testing.T) {
+	assert := assert.New(t)
+	core.ResetTestBlocks()
+
+	// node1 has been partitioned from the network, and has finalized up to A3
+	initChain := blockchain.CreateTestChainByBlocks([]string{
+		"A1", "A0",
+		"A2", "A1",
+		"A3", "A2",
+	})
+	initChain.FinalizePreviousBlocks(core.GetTestBlock("A3").Hash())
+
+	simnet := simulation.NewSimnet()
+	net1 := simnet.AddEndpoint("node1")
+	net2 := simnet.AddEndpoint("node2")
+	mockMsgHandler := &MockMsgHandler{C: make(chan interface{}, 128)}
+	net2.RegisterMessageHandler(mockMsgHandler)
+	simnet.Partition([]string{"node1"}, []string{"node2"})
+	simnet.Start(context.Background())
+
+	dispatch := dispatcher.NewDispatcher(net1)
+	a3, _ := initChain.FindBlock(core.GetTestBlock("A3").Hash())
+	consensus := &MockRecoveringConsensus{NewMockConsensus(initChain, a3)}
+
+	sm := NewSyncManager(initChain, consensus, consensus, net1, dispatch, NewMockMessageConsumer())
+	sm.Start(context.Background())
+	defer sm.Stop()
+
+	// The inventory requests are dropped by the partition
+	time.Sleep(2500 * time.Millisecond)
+	assert.Equal(0, len(mockMsgHandler.C))
+
+	// Once the partition heals, node1 keeps requesting inventories until it catches up
+	simnet.Heal()
+	var res interface{}
+	select {
+	case res = <-mockMsgHandler.C:
+	case <-time.After(3 * time.Second):
+		assert.FailNow("No inventory request received after the partition healed")
+	}
+	invReq, ok := res.(dispatcher.InventoryRequest)
+	assert.True(ok)
+	assert.Equal(common.ChannelIDBlock, invReq.ChannelID)
+	assert.Equal(core.GetTestBlock("A3").Hash().Hex(), invReq.Starts[len(invReq.Starts)-1])
+
+	// node2 replies with the blocks node1 has missed
+	net2.Broadcast(types.Message{
+		ChannelID: common.ChannelIDBlock,
+		Content: dispatcher.InventoryResponse{
+			ChannelID: common.ChannelIDBlock,
+			Entries:   []string{core.CreateTestBlock("A4", "A3").Hash().Hex()},
+		},
+	})
+
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case res = <-mockMsgHandler.C:
+		case <-timeout:
+			assert.FailNow("No data request received for the missing block")
+		}
+		if dataReq, ok := res.(dispatcher.DataRequest); ok {
+			assert.Equal([]string{core.GetTestBlock("A4").Hash().String()}, dataReq.Entries)
+			return
+		}
+	}
+}
@@ -3,36 +3,52 @@ package node
 import (
 	"context"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/profiler"
 	"github.com/thetatoken/theta/consensus"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
 	dp "github.com/thetatoken/theta/dispatcher"
+	"github.com/thetatoken/theta/guardian"
 	ld "github.com/thetatoken/theta/ledger"
 	mp "github.com/thetatoken/theta/mempool"
 	"github.com/thetatoken/theta/netsync"
 	"github.com/thetatoken/theta/p2p"
+	"github.com/thetatoken/theta/p2p/validatormesh"
 	"github.com/thetatoken/theta/rpc"
 	"github.com/thetatoken/theta/snapshot"
 	"github.com/thetatoken/theta/store"
 	"github.com/thetatoken/theta/store/database"
 	"github.com/thetatoken/theta/store/kvstore"
+	"github.com/thetatoken/theta/store/objectstore"
+	"github.com/thetatoken/theta/txindex"
+	"github.com/thetatoken/theta/webhook"
 )
 
 type Node struct {
 	Store            store.Store
 	Chain            *blockchain.Chain
-	Consensus        *consensus.ConsensusEngine
+	Consensus        core.ConsensusEngine
 	ValidatorManager core.ValidatorManager
 	SyncManager      *netsync.SyncManager
 	Dispatcher       *dp.Dispatcher
 	Ledger           core.Ledger
 	Mempool          *mp.Mempool
 	RPC              *rpc.ThetaRPCServer
+	Profiler         *profiler.Profiler
+	Webhooks         *webhook.Manager
+	TxIndexer        *txindex.Indexer
+	ColdArchiver     *blockchain.ColdArchiver
+	StatePruner      *ld.StatePruner
+	ValidatorMesh    *validatormesh.Mesh
+	Guardian         *guardian.Engine
+	Reconciler       *mp.Reconciler
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -49,6 +65,9 @@ type Params struct {
 	Network      p2p.Network
 	DB           database.Database
 	SnapshotPath string
+	WALPath      string // path of the consensus write-ahead log, no WAL if empty
+	ProfilePath  string // directory of the runtime profile captures
+	TxIndexPath  string // path of the transaction index, no index if empty
 }
 
 func NewNode(params *Params) *Node {
@@ -56,7 +75,18 @@ func NewNode(params *Params) *Node {
 	chain := blockchain.NewChain(params.ChainID, store, params.Root)
 	validatorManager := consensus.NewRotatingValidatorManager()
 	dispatcher := dp.NewDispatcher(params.Network)
-	consensus := consensus.NewConsensusEngine(params.PrivateKey, store, chain, dispatcher, validatorManager)
+	var bftEngine *consensus.ConsensusEngine
+	var consensus core.ConsensusEngine
+	switch engine := viper.GetString(common.CfgConsensusEngine); engine {
+	case "bft":
+		bftEngine = newBFTEngine(params, store, chain, dispatcher, validatorManager)
+		consensus = bftEngine
+	case "solo":
+		blockInterval := time.Duration(viper.GetInt(common.CfgConsensusSoloBlockInterval)) * time.Second
+		consensus = newSoloEngine(params, store, chain, blockInterval)
+	default:
+		log.Fatalf("Unsupported consensus engine: %v", engine)
+	}
 
 	currentHeight := consensus.GetLastFinalizedBlock().Height
 	if currentHeight <= params.Root.Height {
@@ -66,7 +96,7 @@ func NewNode(params *Params) *Node {
 		}
 	}
 
-	syncMgr := netsync.NewSyncManager(chain, consensus, params.Network, dispatcher, consensus)
+	syncMgr := netsync.NewSyncManager(chain, consensus, consensus, params.Network, dispatcher, consensus)
 	mempool := mp.CreateMempool(dispatcher)
 	ledger := ld.NewLedger(params.ChainID, params.DB, chain, consensus, validatorManager, mempool)
 	validatorManager.SetConsensusEngine(consensus)
@@ -86,13 +116,125 @@ func NewNode(params *Params) *Node {
 		Mempool:          mempool,
 	}
 
+	if viper.GetBool(common.CfgProfilerEnabled) && len(params.ProfilePath) > 0 {
+		node.Profiler = profiler.NewProfiler(params.ProfilePath)
+		if bftEngine != nil {
+			bftEngine.SetProfiler(node.Profiler)
+		}
+	}
+
+	webhooks, err := webhook.NewManager(chain, consensus, store)
+	if err != nil {
+		log.Fatalf("Failed to load webhooks: %v", err)
+	}
+	if webhooks.NumHooks() > 0 {
+		node.Webhooks = webhooks
+	}
+
+	if viper.GetBool(common.CfgTxIndexEnabled) && len(params.TxIndexPath) > 0 {
+		node.TxIndexer, err = txindex.OpenIndexer(chain, consensus, params.TxIndexPath)
+		if err != nil {
+			log.Fatalf("Failed to open the transaction index: %v", err)
+		}
+	}
+
+	if viper.GetBool(common.CfgStorageColdArchiveEnabled) {
+		coldStore, err := objectstore.NewObjectStore(
+			viper.GetString(common.CfgStorageColdArchiveURL),
+			viper.GetString(common.CfgStorageColdArchiveBucket),
+			viper.GetString(common.CfgStorageColdArchiveRegion),
+			viper.GetString(common.CfgStorageColdArchiveAccessKey),
+			viper.GetString(common.CfgStorageColdArchiveSecretKey))
+		if err != nil {
+			log.Fatalf("Failed to open the cold archive: %v", err)
+		}
+		chain.SetColdStore(coldStore, viper.GetString(common.CfgStorageColdArchivePrefix))
+		node.ColdArchiver = blockchain.NewColdArchiver(chain, consensus,
+			uint64(viper.GetInt(common.CfgStorageColdArchiveRetainedBlocks)))
+	}
+
+	if viper.GetBool(common.CfgStorageStatePruningEnabled) {
+		node.StatePruner = ld.NewStatePruner(ledger, consensus,
+			uint64(viper.GetInt(common.CfgStorageStatePruningRetainedBlocks)),
+			uint64(viper.GetInt(common.CfgStorageStatePruningInterval)),
+			uint64(viper.GetInt(common.CfgStorageStatePruningBatchSize)))
+	}
+
+	if viper.GetBool(common.CfgP2PValidatorMeshEnabled) {
+		f := func(c rune) bool {
+			return c == ','
+		}
+		validatorPeers := strings.FieldsFunc(viper.GetString(common.CfgP2PValidatorPeers), f)
+		mesh, err := validatormesh.NewMesh(params.PrivateKey, params.Network, consensus, validatorManager,
+			viper.GetString(common.CfgP2PValidatorMeshAddress), validatorPeers)
+		if err != nil {
+			log.Fatalf("Failed to create the validator mesh: %v", err)
+		}
+		params.Network.RegisterMessageHandler(mesh)
+		node.ValidatorMesh = mesh
+	}
+
+	if viper.GetBool(common.CfgGuardianEnabled) {
+		membership, err := guardian.NewStaticMembership(strings.FieldsFunc(viper.GetString(common.CfgGuardianAddresses),
+			func(c rune) bool { return c == ',' }))
+		if err != nil {
+			log.Fatalf("Failed to create the guardian set: %v", err)
+		}
+		node.Guardian = guardian.NewEngine(consensus, dispatcher, membership, guardian.Config{
+			MessageQueueSize:      viper.GetInt(common.CfgGuardianMessageQueueSize),
+			MaxVotesPerSecond:     viper.GetInt(common.CfgGuardianMaxVotesPerSecond),
+			MaxPeerVotesPerSecond: viper.GetInt(common.CfgGuardianMaxPeerVotesPerSecond),
+		})
+		params.Network.RegisterMessageHandler(node.Guardian)
+	}
+
+	if interval := viper.GetInt(common.CfgMempoolReconcileInterval); interval > 0 {
+		node.Reconciler = mp.NewReconciler(mempool, dispatcher, time.Duration(interval)*time.Second)
+		params.Network.RegisterMessageHandler(node.Reconciler)
+	}
+
+	if dir := viper.GetString(common.CfgSyncSnapshotServeDir); len(dir) != 0 {
+		snapshotServer := netsync.NewSnapshotServer(dir, dispatcher)
+		if viper.GetBool(common.CfgSyncServeStateDiffs) {
+			snapshotServer.EnableStateDiffs(params.DB, chain)
+		}
+		params.Network.RegisterMessageHandler(snapshotServer)
+	}
+
 	if viper.GetBool(common.CfgRPCEnabled) {
-		node.RPC = rpc.NewThetaRPCServer(mempool, ledger, chain, consensus)
+		node.RPC = rpc.NewThetaRPCServer(mempool, ledger, chain, consensus, dispatcher)
+		node.RPC.SetProfiler(node.Profiler)
+		node.RPC.SetTxIndexer(node.TxIndexer)
+		node.RPC.SetStatePruner(node.StatePruner)
+		node.RPC.SetPropagationTracker(syncMgr.PropagationTracker())
 	}
 
 	return node
 }
 
+func newBFTEngine(params *Params, store store.Store, chain *blockchain.Chain, dispatcher *dp.Dispatcher,
+	validatorManager core.ValidatorManager) *consensus.ConsensusEngine {
+	var wal *consensus.WAL
+	if len(params.WALPath) > 0 {
+		var err error
+		if wal, err = consensus.OpenWAL(params.WALPath); err != nil {
+			log.Fatalf("Failed to open consensus WAL: %v, err: %v", params.WALPath, err)
+		}
+	}
+	engine := consensus.NewConsensusEngine(params.PrivateKey, store, chain, dispatcher, validatorManager)
+	if wal != nil {
+		engine.SetWAL(wal)
+	}
+	return engine
+}
+
+func newSoloEngine(params *Params, store store.Store, chain *blockchain.Chain, blockInterval time.Duration) *consensus.SoloEngine {
+	if params.PrivateKey == nil {
+		log.Fatalf("The solo consensus engine requires the key of the node")
+	}
+	return consensus.NewSoloEngine(params.PrivateKey, store, chain, blockInterval)
+}
+
 // Start starts sub components and kick off the main loop.
 func (n *Node) Start(ctx context.Context) {
 	c, cancel := context.WithCancel(ctx)
@@ -104,6 +246,38 @@ func (n *Node) Start(ctx context.Context) {
 	n.Dispatcher.Start(n.ctx)
 	n.Mempool.Start(n.ctx)
 
+	if n.Profiler != nil {
+		n.Profiler.Start(n.ctx)
+	}
+
+	if n.Webhooks != nil {
+		n.Webhooks.Start(n.ctx)
+	}
+
+	if n.TxIndexer != nil {
+		n.TxIndexer.Start(n.ctx)
+	}
+
+	if n.ColdArchiver != nil {
+		n.ColdArchiver.Start(n.ctx)
+	}
+
+	if n.StatePruner != nil {
+		n.StatePruner.Start(n.ctx)
+	}
+
+	if n.ValidatorMesh != nil {
+		n.ValidatorMesh.Start(n.ctx)
+	}
+
+	if n.Guardian != nil {
+		n.Guardian.Start(n.ctx)
+	}
+
+	if n.Reconciler != nil {
+		n.Reconciler.Start(n.ctx)
+	}
+
 	if viper.GetBool(common.CfgRPCEnabled) {
 		n.RPC.Start(n.ctx)
 	}
@@ -121,4 +295,28 @@ func (n *Node) Wait() {
 	if n.RPC != nil {
 		n.RPC.Wait()
 	}
+	if n.Profiler != nil {
+		n.Profiler.Wait()
+	}
+	if n.Webhooks != nil {
+		n.Webhooks.Wait()
+	}
+	if n.TxIndexer != nil {
+		n.TxIndexer.Wait()
+	}
+	if n.ColdArchiver != nil {
+		n.ColdArchiver.Wait()
+	}
+	if n.StatePruner != nil {
+		n.StatePruner.Wait()
+	}
+	if n.ValidatorMesh != nil {
+		n.ValidatorMesh.Wait()
+	}
+	if n.Guardian != nil {
+		n.Guardian.Wait()
+	}
+	if n.Reconciler != nil {
+		n.Reconciler.Wait()
+	}
 }
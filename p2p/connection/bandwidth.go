@@ -0,0 +1,172 @@
+package connection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+// sendThrottleRetry is how long the send routine waits before retrying to send the packets
+// held back by the channel send rates
+const sendThrottleRetry = 20 * time.Millisecond
+
+var sendThrottledCounter = metrics.NewRegisteredCounter("p2p/bandwidth/throttled", nil)
+
+// allChannelIDs lists the channels of a connection
+var allChannelIDs = []common.ChannelIDEnum{
+	common.ChannelIDCheckpoint,
+	common.ChannelIDHeader,
+	common.ChannelIDBlock,
+	common.ChannelIDProposal,
+	common.ChannelIDVote,
+	common.ChannelIDTransaction,
+	common.ChannelIDPeerDiscovery,
+	common.ChannelIDPing,
+	common.ChannelIDValidatorMesh,
+	common.ChannelIDGuardian,
+	common.ChannelIDMempoolSync,
+	common.ChannelIDSnapshotRequest,
+	common.ChannelIDSnapshotResponse,
+}
+
+//
+// BandwidthLimits specifies the send and receive rates (bytes/s) of the connections. A rate of 0
+// means unlimited.
+//
+type BandwidthLimits struct {
+	PeerSendRate     int64                          // send rate of each peer
+	PeerRecvRate     int64                          // receive rate of each peer
+	ChannelSendRates map[common.ChannelIDEnum]int64 // send rate of each channel, shared by all the peers
+}
+
+var (
+	peerSendRate = int64(512000) // 500KB/s
+	peerRecvRate = int64(512000) // 500KB/s
+
+	channelLimitersMutex = &sync.Mutex{}
+	channelLimiters      = make(map[common.ChannelIDEnum]*bandwidthLimiter)
+)
+
+// SetBandwidthLimits sets the send and receive rates of the connections. The peer rates apply to
+// the connections created afterwards, the channel rates apply to all the connections immediately.
+func SetBandwidthLimits(limits BandwidthLimits) {
+	atomic.StoreInt64(&peerSendRate, limits.PeerSendRate)
+	atomic.StoreInt64(&peerRecvRate, limits.PeerRecvRate)
+	for _, channelID := range allChannelIDs {
+		getChannelLimiter(channelID).setRate(limits.ChannelSendRates[channelID])
+	}
+}
+
+// ParseChannelRates parses the comma separated list of channel send rates, each in the format
+// <channel name>:<bytes per second>, e.g. "block:4194304,vote:524288". See p2ptypes.ChannelName
+// for the channel names.
+func ParseChannelRates(s string) (map[common.ChannelIDEnum]int64, error) {
+	rates := make(map[common.ChannelIDEnum]int64)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid channel rate %v, expected <channel name>:<bytes per second>", entry)
+		}
+		channelID, ok := channelIDByName(strings.TrimSpace(parts[0]))
+		if !ok {
+			return nil, fmt.Errorf("Unknown channel %v", parts[0])
+		}
+		rate, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("Invalid rate %v of channel %v", parts[1], parts[0])
+		}
+		rates[channelID] = rate
+	}
+	return rates, nil
+}
+
+func channelIDByName(name string) (common.ChannelIDEnum, bool) {
+	for _, channelID := range allChannelIDs {
+		if p2ptypes.ChannelName(channelID) == name {
+			return channelID, true
+		}
+	}
+	return 0, false
+}
+
+// getChannelLimiter returns the send rate limiter of the channel shared by all the connections
+func getChannelLimiter(channelID common.ChannelIDEnum) *bandwidthLimiter {
+	channelLimitersMutex.Lock()
+	defer channelLimitersMutex.Unlock()
+
+	limiter, ok := channelLimiters[channelID]
+	if !ok {
+		limiter = newBandwidthLimiter(0)
+		channelLimiters[channelID] = limiter
+	}
+	return limiter
+}
+
+//
+// bandwidthLimiter is a token bucket of bytes, holding up to a second worth of bytes. A packet can
+// be sent as long as the bucket is not empty, which may overdraw it. A rate of 0 means unlimited.
+//
+type bandwidthLimiter struct {
+	mutex *sync.Mutex
+
+	rate       int64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	return &bandwidthLimiter{
+		mutex:      &sync.Mutex{},
+		rate:       rate,
+		tokens:     float64(rate),
+		lastRefill: time.Now(),
+	}
+}
+
+func (bl *bandwidthLimiter) setRate(rate int64) {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+
+	bl.rate = rate
+	bl.tokens = float64(rate)
+}
+
+// allow refills the bucket and returns whether a packet can be sent
+func (bl *bandwidthLimiter) allow(now time.Time) bool {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+
+	if bl.rate == 0 {
+		return true
+	}
+	if elapsed := now.Sub(bl.lastRefill); elapsed > 0 {
+		bl.tokens += float64(bl.rate) * elapsed.Seconds()
+		if bl.tokens > float64(bl.rate) {
+			bl.tokens = float64(bl.rate)
+		}
+		bl.lastRefill = now
+	}
+	return bl.tokens > 0
+}
+
+// consume takes the bytes sent from the bucket
+func (bl *bandwidthLimiter) consume(numBytes int) {
+	bl.mutex.Lock()
+	defer bl.mutex.Unlock()
+
+	if bl.rate == 0 {
+		return
+	}
+	bl.tokens -= float64(numBytes)
+}
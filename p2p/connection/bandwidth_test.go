@@ -0,0 +1,110 @@
+package connection
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestParseChannelRates(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	rates, err := ParseChannelRates(" block:4194304, vote:524288,")
+	require.Nil(err)
+	assert.Equal(map[common.ChannelIDEnum]int64{
+		common.ChannelIDBlock: 4194304,
+		common.ChannelIDVote:  524288,
+	}, rates)
+
+	rates, err = ParseChannelRates("")
+	require.Nil(err)
+	assert.Equal(0, len(rates))
+
+	_, err = ParseChannelRates("block")
+	assert.NotNil(err)
+	_, err = ParseChannelRates("blocks:100")
+	assert.NotNil(err)
+	_, err = ParseChannelRates("block:-1")
+	assert.NotNil(err)
+	_, err = ParseChannelRates("block:fast")
+	assert.NotNil(err)
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	bl := newBandwidthLimiter(1000)
+	bl.lastRefill = now
+
+	assert.True(bl.allow(now))
+	bl.consume(1500) // a packet can overdraw the bucket
+	assert.False(bl.allow(now))
+	assert.False(bl.allow(now.Add(400 * time.Millisecond)))
+	assert.True(bl.allow(now.Add(600 * time.Millisecond)))
+
+	// The bucket holds up to a second worth of bytes
+	assert.True(bl.allow(now.Add(time.Hour)))
+	bl.consume(1000)
+	assert.False(bl.allow(now.Add(time.Hour)))
+
+	unlimited := newBandwidthLimiter(0)
+	unlimited.consume(1 << 30)
+	assert.True(unlimited.allow(now))
+}
+
+func TestChannelSendRates(t *testing.T) {
+	assert := assert.New(t)
+
+	SetBandwidthLimits(BandwidthLimits{
+		PeerSendRate:     1000,
+		PeerRecvRate:     2000,
+		ChannelSendRates: map[common.ChannelIDEnum]int64{common.ChannelIDBlock: 1},
+	})
+	defer SetBandwidthLimits(BandwidthLimits{PeerSendRate: 512000, PeerRecvRate: 512000})
+
+	config := GetDefaultConnectionConfig()
+	assert.Equal(int64(1000), config.SendRate)
+	assert.Equal(int64(2000), config.RecvRate)
+
+	cg := newTestEmptyChannelGroup()
+	// Room for two messages, the default send buffer holds only one
+	sbCfg := getDefaultSendBufferConfig()
+	sbCfg.queueCapacity = 2
+	chBlock := createChannel(common.ChannelIDBlock, getDefaultChannelConfig(), sbCfg, getDefaultRecvBufferConfig())
+	chVote := createDefaultChannel(common.ChannelIDVote)
+	assert.True(cg.addChannel(&chBlock))
+	assert.True(cg.addChannel(&chVote))
+
+	assert.True(chBlock.enqueueMessage([]byte("block1")))
+	assert.True(chBlock.enqueueMessage([]byte("block2")))
+	assert.True(chVote.enqueueMessage([]byte("vote1")))
+
+	success, ch := cg.nextChannelToSendPacket()
+	assert.True(success)
+	assert.Equal(&chBlock, ch)
+	_, numBytes, err := ch.sendPacketTo(&bytes.Buffer{})
+	assert.Nil(err)
+	ch.sendLimiter.consume(numBytes)
+
+	// The block channel is out of budget, the vote channel is not limited
+	success, ch = cg.nextChannelToSendPacket()
+	assert.True(success)
+	assert.Equal(&chVote, ch)
+	_, _, err = ch.sendPacketTo(&bytes.Buffer{})
+	assert.Nil(err)
+
+	success, ch = cg.nextChannelToSendPacket()
+	assert.True(success)
+	assert.Nil(ch)
+	assert.True(cg.hasPacketToSend())
+
+	// The budget is shared by the channels of all the connections
+	other := createDefaultChannel(common.ChannelIDBlock)
+	assert.Equal(chBlock.sendLimiter, other.sendLimiter)
+}
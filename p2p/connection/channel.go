@@ -2,6 +2,7 @@ package connection
 
 import (
 	"io"
+	"time"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/rlp"
@@ -16,6 +17,9 @@ type Channel struct {
 	sendBuf SendBuffer
 	recvBuf RecvBuffer
 
+	stats       *channelStats
+	sendLimiter *bandwidthLimiter // shared by the channels with the same ID of all the connections
+
 	config ChannelConfig
 }
 
@@ -41,10 +45,12 @@ func createChannel(channelID common.ChannelIDEnum, channelConf ChannelConfig, sb
 	sendBuf := createSendBuffer(sbConf)
 	recvBuf := createRecvBuffer(rbConf)
 	return Channel{
-		id:      channelID,
-		sendBuf: sendBuf,
-		recvBuf: recvBuf,
-		config:  channelConf,
+		id:          channelID,
+		sendBuf:     sendBuf,
+		recvBuf:     recvBuf,
+		stats:       &channelStats{},
+		sendLimiter: getChannelLimiter(channelID),
+		config:      channelConf,
 	}
 }
 
@@ -101,6 +107,11 @@ func (ch *Channel) canEnqueueMessage() bool {
 }
 
 // hasPacketToSend returns whether there are pending data in the sendBuffer
+// canSendPacket returns whether the send rate of the channel allows sending a packet
+func (ch *Channel) canSendPacket(now time.Time) bool {
+	return ch.sendLimiter.allow(now)
+}
+
 func (ch *Channel) hasPacketToSend() bool {
 	hasPacket := !ch.sendBuf.isEmpty()
 	return hasPacket
@@ -2,6 +2,7 @@ package connection
 
 import (
 	"sync"
+	"time"
 
 	"github.com/thetatoken/theta/common"
 )
@@ -132,7 +133,10 @@ func (cg *ChannelGroup) getTotalNumChannels() uint {
 	return uint(len(cg.channels))
 }
 
+// nextChannelToSendPacket selects the next channel with a packet to send, skipping the channels
+// held back by their send rates
 func (cg *ChannelGroup) nextChannelToSendPacket() (sucess bool, channel *Channel) {
+	now := time.Now()
 	channels := cg.getAllChannels()
 	totalNumberOfChannels := cg.getTotalNumChannels()
 	for i := uint(0); i < totalNumberOfChannels; i++ {
@@ -144,7 +148,7 @@ func (cg *ChannelGroup) nextChannelToSendPacket() (sucess bool, channel *Channel
 			return false, nil
 		}
 		selectedChannel := (*channels)[selectedChannelIndex]
-		if !selectedChannel.hasPacketToSend() {
+		if !selectedChannel.hasPacketToSend() || !selectedChannel.canSendPacket(now) {
 			continue
 		}
 		return true, selectedChannel
@@ -152,6 +156,16 @@ func (cg *ChannelGroup) nextChannelToSendPacket() (sucess bool, channel *Channel
 	return true, nil
 }
 
+// hasPacketToSend returns whether any of the channels has a packet to send
+func (cg *ChannelGroup) hasPacketToSend() bool {
+	for _, channel := range *(cg.getAllChannels()) {
+		if channel.hasPacketToSend() {
+			return true
+		}
+	}
+	return false
+}
+
 //
 // RoundRobinChannelSelector implments the ChannelSelector interface
 // with the round robin strategy
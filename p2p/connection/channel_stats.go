@@ -0,0 +1,66 @@
+package connection
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+// channelStats keeps the traffic counters of a channel. The counters are updated
+// by the send and receive goroutines of the connection concurrently, hence atomics
+type channelStats struct {
+	msgsReceived    uint64
+	bytesReceived   uint64
+	msgsSent        uint64
+	bytesSent       uint64
+	decodeFailures  uint64
+	replaysRejected uint64
+
+	msgsHandled        uint64
+	handlingNanosTotal uint64
+}
+
+func (cs *channelStats) recordReceived(numBytes int) {
+	atomic.AddUint64(&cs.msgsReceived, 1)
+	atomic.AddUint64(&cs.bytesReceived, uint64(numBytes))
+}
+
+func (cs *channelStats) recordSent(numBytes int) {
+	atomic.AddUint64(&cs.msgsSent, 1)
+	atomic.AddUint64(&cs.bytesSent, uint64(numBytes))
+}
+
+func (cs *channelStats) recordDecodeFailure() {
+	atomic.AddUint64(&cs.decodeFailures, 1)
+}
+
+func (cs *channelStats) recordReplayRejected() {
+	atomic.AddUint64(&cs.replaysRejected, 1)
+}
+
+func (cs *channelStats) recordHandled(elapsed time.Duration) {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	atomic.AddUint64(&cs.msgsHandled, 1)
+	atomic.AddUint64(&cs.handlingNanosTotal, uint64(elapsed.Nanoseconds()))
+}
+
+func (cs *channelStats) snapshot(channelID common.ChannelIDEnum) p2ptypes.ChannelStats {
+	stats := p2ptypes.ChannelStats{
+		ChannelID:       channelID,
+		MsgsReceived:    atomic.LoadUint64(&cs.msgsReceived),
+		BytesReceived:   atomic.LoadUint64(&cs.bytesReceived),
+		MsgsSent:        atomic.LoadUint64(&cs.msgsSent),
+		BytesSent:       atomic.LoadUint64(&cs.bytesSent),
+		DecodeFailures:  atomic.LoadUint64(&cs.decodeFailures),
+		ReplaysRejected: atomic.LoadUint64(&cs.replaysRejected),
+	}
+	msgsHandled := atomic.LoadUint64(&cs.msgsHandled)
+	if msgsHandled > 0 {
+		stats.AvgHandlingMicros = atomic.LoadUint64(&cs.handlingNanosTotal) / msgsHandled / 1000
+	}
+	return stats
+}
@@ -0,0 +1,56 @@
+package connection
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+func TestConnectionChannelStats(t *testing.T) {
+	assert := assert.New(t)
+
+	netconn, remote := net.Pipe()
+	defer netconn.Close()
+	defer remote.Close()
+
+	conn := CreateConnection(netconn, GetDefaultConnectionConfig())
+	conn.SetMessageParser(func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+		if string(rawMessageBytes) == "garbage" {
+			return p2ptypes.Message{}, errors.New("invalid message")
+		}
+		return p2ptypes.Message{ChannelID: channelID, Content: rawMessageBytes}, nil
+	})
+	conn.SetReceiveHandler(func(message p2ptypes.Message) error {
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+
+	assert.True(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDBlock, Bytes: []byte("block"), IsEOF: byte(0x01)}))
+	assert.True(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDBlock, Bytes: []byte("block2"), IsEOF: byte(0x01)}))
+	assert.False(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDVote, Bytes: []byte("garbage"), IsEOF: byte(0x01)}))
+	assert.True(conn.EnqueueMessage(common.ChannelIDVote, "vote"))
+
+	stats := make(map[common.ChannelIDEnum]p2ptypes.ChannelStats)
+	for _, cs := range conn.GetChannelStats() {
+		stats[cs.ChannelID] = cs
+	}
+	assert.Equal(13, len(stats))
+
+	blockStats := stats[common.ChannelIDBlock]
+	assert.Equal(uint64(2), blockStats.MsgsReceived)
+	assert.Equal(uint64(11), blockStats.BytesReceived)
+	assert.Equal(uint64(0), blockStats.DecodeFailures)
+	assert.True(blockStats.AvgHandlingMicros >= 2000)
+
+	voteStats := stats[common.ChannelIDVote]
+	assert.Equal(uint64(1), voteStats.MsgsReceived)
+	assert.Equal(uint64(1), voteStats.DecodeFailures)
+	assert.Equal(uint64(0), voteStats.AvgHandlingMicros)
+	assert.Equal(uint64(1), voteStats.MsgsSent)
+	assert.True(voteStats.BytesSent > 0)
+}
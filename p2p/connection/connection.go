@@ -2,8 +2,10 @@ package connection
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"runtime/debug"
 	"sync"
@@ -41,12 +43,17 @@ type Connection struct {
 	onError      ErrorHandler
 	errored      uint32
 
+	sealer      *envelopeSealer // nil unless the messages are wrapped in envelopes
+	openKey     common.Bytes    // key authenticating the envelopes received
+	replayGuard *ReplayGuard
+
 	sendPulse chan bool
 	pongPulse chan bool
 	quitPulse chan bool
 
-	flushTimer *timer.ThrottleTimer // flush writes as necessary but throttled
-	pingTimer  *timer.RepeatTimer   // send pings periodically
+	flushTimer    *timer.ThrottleTimer // flush writes as necessary but throttled
+	pingTimer     *timer.RepeatTimer   // send pings periodically
+	throttleTimer *timer.ThrottleTimer // retry sending the packets held back by the channel send rates
 
 	pendingPings uint32
 
@@ -100,6 +107,11 @@ func CreateConnection(netconn net.Conn, config ConnectionConfig) *Connection {
 	channelTransaction := createDefaultChannel(common.ChannelIDTransaction)
 	channelPeerDiscover := createDefaultChannel(common.ChannelIDPeerDiscovery)
 	channelPing := createDefaultChannel(common.ChannelIDPing)
+	channelValidatorMesh := createDefaultChannel(common.ChannelIDValidatorMesh)
+	channelGuardian := createDefaultChannel(common.ChannelIDGuardian)
+	channelMempoolSync := createDefaultChannel(common.ChannelIDMempoolSync)
+	channelSnapshotRequest := createDefaultChannel(common.ChannelIDSnapshotRequest)
+	channelSnapshotResponse := createDefaultChannel(common.ChannelIDSnapshotResponse)
 	channels := []*Channel{
 		&channelCheckpoint,
 		&channelHeader,
@@ -109,6 +121,11 @@ func CreateConnection(netconn net.Conn, config ConnectionConfig) *Connection {
 		&channelTransaction,
 		&channelPeerDiscover,
 		&channelPing,
+		&channelValidatorMesh,
+		&channelGuardian,
+		&channelMempoolSync,
+		&channelSnapshotRequest,
+		&channelSnapshotResponse,
 	}
 
 	success, channelGroup := createChannelGroup(getDefaultChannelGroupConfig(), channels)
@@ -131,15 +148,17 @@ func CreateConnection(netconn net.Conn, config ConnectionConfig) *Connection {
 		config:       config,
 		wg:           &sync.WaitGroup{},
 
-		onEncode: defaultMessageEncoder,
+		onEncode:      defaultMessageEncoder,
+		throttleTimer: timer.NewThrottleTimer("throttle", sendThrottleRetry),
 	}
 }
 
-// GetDefaultConnectionConfig returns the default ConnectionConfig
+// GetDefaultConnectionConfig returns the default ConnectionConfig, with the peer rates set by
+// SetBandwidthLimits
 func GetDefaultConnectionConfig() ConnectionConfig {
 	return ConnectionConfig{
-		SendRate:        int64(512000), // 500KB/s
-		RecvRate:        int64(512000), // 500KB/s
+		SendRate:        atomic.LoadInt64(&peerSendRate),
+		RecvRate:        atomic.LoadInt64(&peerRecvRate),
 		PacketBatchSize: int64(10),
 		FlushThrottle:   100 * time.Millisecond,
 		PingTimeout:     40 * time.Second,
@@ -204,6 +223,35 @@ func (conn *Connection) SetErrorHandler(errorHandler ErrorHandler) {
 	conn.onError = errorHandler
 }
 
+// SetRecvRate sets the max rate (bytes/s) data is received at, e.g. to throttle a misbehaving peer
+func (conn *Connection) SetRecvRate(rate int64) {
+	atomic.StoreInt64(&conn.config.RecvRate, rate)
+}
+
+// EnableMessageEnvelopes wraps the messages exchanged in envelopes, and rejects the messages
+// received which are stale, replayed or not authenticated by the peer. The envelopes sent are
+// authenticated with sendKey and the ones received with recvKey, which are the session keys the
+// peer holds in reverse. Both ends of the connection need to enable it before the connection starts.
+func (conn *Connection) EnableMessageEnvelopes(sendKey, recvKey common.Bytes) {
+	conn.sealer = newEnvelopeSealer(sendKey)
+	conn.openKey = recvKey
+	conn.replayGuard = defaultReplayGuard
+}
+
+// UseSecureConn replaces the net.Conn of the connection with the SecureConn wrapping it, so that the
+// messages are encrypted on the wire. It needs to be called before the connection starts.
+func (conn *Connection) UseSecureConn(secureConn *SecureConn) {
+	conn.netconn = secureConn
+	conn.bufWriter = bufio.NewWriterSize(secureConn, conn.config.MinWriteBufferSize)
+	conn.bufReader = bufio.NewReaderSize(secureConn, conn.config.MinReadBufferSize)
+}
+
+// PrependReceived makes the connection handle the given bytes before the ones it receives, e.g.
+// the packets read ahead by the handshake. It needs to be called before the connection starts.
+func (conn *Connection) PrependReceived(data []byte) {
+	conn.bufReader = bufio.NewReaderSize(io.MultiReader(bytes.NewReader(data), conn.netconn), conn.config.MinReadBufferSize)
+}
+
 // EnqueueMessage enqueues the given message to the target channel.
 // The message will be sent out later
 func (conn *Connection) EnqueueMessage(channelID common.ChannelIDEnum, message interface{}) bool {
@@ -213,13 +261,14 @@ func (conn *Connection) EnqueueMessage(channelID common.ChannelIDEnum, message i
 		return false
 	}
 
-	msgBytes, err := conn.onEncode(channelID, message)
+	msgBytes, err := conn.encodeMessage(channelID, message)
 	if err != nil {
 		logger.Errorf("Failed to encode message to bytes: %v, err: %v", message, err)
 		return false
 	}
 	success := channel.enqueueMessage(msgBytes)
 	if success {
+		channel.stats.recordSent(len(msgBytes))
 		conn.scheduleSendPulse()
 	}
 
@@ -235,19 +284,28 @@ func (conn *Connection) AttemptToEnqueueMessage(channelID common.ChannelIDEnum,
 		return false
 	}
 
-	msgBytes, err := conn.onEncode(channelID, message)
+	msgBytes, err := conn.encodeMessage(channelID, message)
 	if err != nil {
 		logger.Errorf("Failed to encode message to bytes: %v, error: %v", message, err)
 		return false
 	}
 	success := channel.attemptToEnqueueMessage(msgBytes)
 	if success {
+		channel.stats.recordSent(len(msgBytes))
 		conn.scheduleSendPulse()
 	}
 
 	return success
 }
 
+func (conn *Connection) encodeMessage(channelID common.ChannelIDEnum, message interface{}) (common.Bytes, error) {
+	msgBytes, err := conn.onEncode(channelID, message)
+	if err != nil || conn.sealer == nil {
+		return msgBytes, err
+	}
+	return conn.sealer.seal(msgBytes)
+}
+
 // CanEnqueueMessage returns whether more messages can still be enqueued
 // into the connection at the moment
 func (conn *Connection) CanEnqueueMessage(channelID common.ChannelIDEnum) bool {
@@ -279,6 +337,8 @@ func (conn *Connection) sendRoutine() {
 			err = conn.sendPongSignal()
 		case <-conn.sendPulse:
 			conn.sendPacketBatchAndScheduleSendPulse()
+		case <-conn.throttleTimer.Ch:
+			conn.sendPacketBatchAndScheduleSendPulse()
 		case <-conn.quitPulse:
 			return
 		}
@@ -409,13 +469,32 @@ func (conn *Connection) handleReceivedPacket(packet *Packet) (success bool) {
 		return true
 	}
 
+	channel.stats.recordReceived(len(aggregatedBytes))
+	if conn.sealer != nil {
+		envelope, err := openEnvelope(conn.openKey, aggregatedBytes)
+		if err != nil {
+			channel.stats.recordDecodeFailure()
+			logger.Errorf("Error opening message envelope: %v, err: %v", packet, err)
+			return false
+		}
+		if err := conn.replayGuard.Check(envelope, aggregatedBytes); err != nil {
+			channel.stats.recordReplayRejected()
+			replayRejectedCounter.Inc(1)
+			logger.Debugf("Rejected message from %v, nonce: %v, err: %v", conn.netconn.RemoteAddr(), envelope.Nonce, err)
+			return false
+		}
+		aggregatedBytes = envelope.Payload
+	}
 	message, err := conn.onParse(packet.ChannelID, aggregatedBytes)
 	if err != nil {
+		channel.stats.recordDecodeFailure()
 		logger.Errorf("Error parsing packet: %v, err: %v", packet, err)
 		return false
 	}
 
+	start := time.Now()
 	err = conn.onReceive(message)
+	channel.stats.recordHandled(time.Since(start))
 	if err != nil {
 		logger.Debugf("Error handling message: %v, err: %v", message, err)
 		return false
@@ -458,6 +537,11 @@ func (conn *Connection) sendPacket() (success bool, exhausted bool) {
 		return false, false // TODO: error handling
 	}
 	if channel == nil {
+		if conn.channelGroup.hasPacketToSend() {
+			// Held back by the channel send rates, retry later
+			sendThrottledCounter.Inc(1)
+			conn.throttleTimer.Set()
+		}
 		return true, true // Nothing to be sent
 	}
 
@@ -470,6 +554,7 @@ func (conn *Connection) sendPacket() (success bool, exhausted bool) {
 	}
 
 	conn.sendMonitor.Update(numBytes)
+	channel.sendLimiter.consume(numBytes)
 	conn.flushTimer.Set()
 
 	return true, false
@@ -477,6 +562,16 @@ func (conn *Connection) sendPacket() (success bool, exhausted bool) {
 
 // --------------------- Utils --------------------- //
 
+// GetChannelStats returns the traffic stats of all the channels of the connection
+func (conn *Connection) GetChannelStats() []p2ptypes.ChannelStats {
+	channels := conn.channelGroup.getAllChannels()
+	stats := []p2ptypes.ChannelStats{}
+	for _, channel := range *channels {
+		stats = append(stats, channel.stats.snapshot(channel.getID()))
+	}
+	return stats
+}
+
 // GetNetconn returns the attached network connection
 func (conn *Connection) GetNetconn() net.Conn {
 	return conn.netconn
@@ -0,0 +1,177 @@
+package connection
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+)
+
+const (
+	// maxMessageAge is how long after being sealed an envelope is accepted. Past that, the
+	// envelope is rejected, so that the envelopes only need to be remembered for that long.
+	maxMessageAge = 2 * time.Minute
+
+	// maxClockDrift is how far in the future the timestamp of an envelope can be, to tolerate
+	// the clocks of the peers being ahead of ours
+	maxClockDrift = 30 * time.Second
+
+	// maxSeenEnvelopes bounds the memory used to remember the envelopes received. The oldest
+	// envelopes are forgotten first when exceeded.
+	maxSeenEnvelopes = 1 << 20
+)
+
+var (
+	errStaleMessage    = errors.New("message is too old")
+	errFutureMessage   = errors.New("message is too far in the future")
+	errReplayedMessage = errors.New("message already received")
+	errInvalidMAC      = errors.New("message authentication failed")
+
+	replayRejectedCounter = metrics.NewRegisteredCounter("p2p/replay/rejected", nil)
+)
+
+// MessageEnvelope wraps the encoded messages exchanged with the peers supporting it. The nonces
+// of a connection are unique, so that no two envelopes sent are the same, and a captured
+// envelope replayed to a node is detected. The MAC binds the envelope to the session key of the
+// connection, so that a captured payload cannot be wrapped again with a fresh nonce and
+// timestamp by anyone but the peer it was received from.
+type MessageEnvelope struct {
+	Timestamp uint64 // unix time in milliseconds when the message was sealed
+	Nonce     uint64
+	Payload   common.Bytes
+	MAC       common.Bytes
+}
+
+// envelopeMAC returns the MAC of the envelope fields other than the MAC itself
+func envelopeMAC(key common.Bytes, envelope *MessageEnvelope) common.Bytes {
+	var header [16]byte
+	binary.BigEndian.PutUint64(header[:8], envelope.Timestamp)
+	binary.BigEndian.PutUint64(header[8:], envelope.Nonce)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(header[:])
+	mac.Write(envelope.Payload)
+	return mac.Sum(nil)
+}
+
+// seenEnvelope is an envelope remembered by the ReplayGuard until it expires
+type seenEnvelope struct {
+	hash   common.Hash
+	expiry time.Time
+}
+
+// ReplayGuard rejects the envelopes which are too old, or which were already received from any
+// peer, so that a message cannot be replayed to the node later. It is shared by the connections
+// and is goroutine safe.
+type ReplayGuard struct {
+	mutex *sync.Mutex
+
+	seen  map[common.Hash]time.Time
+	queue []seenEnvelope // in the order received
+	now   func() time.Time
+}
+
+// NewReplayGuard creates an instance of ReplayGuard
+func NewReplayGuard() *ReplayGuard {
+	return &ReplayGuard{
+		mutex: &sync.Mutex{},
+		seen:  make(map[common.Hash]time.Time),
+		now:   time.Now,
+	}
+}
+
+// defaultReplayGuard is shared by all the connections of the node, since a captured message can
+// be replayed through any of them.
+var defaultReplayGuard = NewReplayGuard()
+
+// Check records the envelope, and returns an error if it is outside of the acceptance window or
+// was already recorded.
+func (g *ReplayGuard) Check(envelope *MessageEnvelope, rawEnvelope common.Bytes) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	now := g.now()
+	timestamp := time.Unix(0, int64(envelope.Timestamp)*int64(time.Millisecond))
+	if timestamp.Before(now.Add(-maxMessageAge)) {
+		return errStaleMessage
+	}
+	if timestamp.After(now.Add(maxClockDrift)) {
+		return errFutureMessage
+	}
+
+	g.prune(now)
+
+	hash := crypto.Keccak256Hash(rawEnvelope)
+	if _, ok := g.seen[hash]; ok {
+		return errReplayedMessage
+	}
+	// Past the expiry, the envelope is rejected as stale
+	expiry := timestamp.Add(maxMessageAge)
+	g.seen[hash] = expiry
+	g.queue = append(g.queue, seenEnvelope{hash: hash, expiry: expiry})
+	return nil
+}
+
+// prune forgets the envelopes expired, and the oldest ones if there are too many.
+func (g *ReplayGuard) prune(now time.Time) {
+	i := 0
+	for ; i < len(g.queue); i++ {
+		if len(g.queue)-i < maxSeenEnvelopes && g.queue[i].expiry.After(now) {
+			break
+		}
+		delete(g.seen, g.queue[i].hash)
+	}
+	g.queue = g.queue[i:]
+}
+
+// envelopeSealer wraps the messages sent through a connection
+type envelopeSealer struct {
+	mutex *sync.Mutex
+	key   common.Bytes
+	nonce uint64
+	now   func() time.Time
+}
+
+func newEnvelopeSealer(key common.Bytes) *envelopeSealer {
+	// The nonces start at a random value, so that they are not reused by a later connection
+	var nonceBytes [8]byte
+	rand.Read(nonceBytes[:])
+	return &envelopeSealer{
+		mutex: &sync.Mutex{},
+		key:   key,
+		nonce: binary.BigEndian.Uint64(nonceBytes[:]),
+		now:   time.Now,
+	}
+}
+
+func (s *envelopeSealer) seal(payload common.Bytes) (common.Bytes, error) {
+	s.mutex.Lock()
+	s.nonce++
+	envelope := MessageEnvelope{
+		Timestamp: uint64(s.now().UnixNano() / int64(time.Millisecond)),
+		Nonce:     s.nonce,
+		Payload:   payload,
+	}
+	s.mutex.Unlock()
+	envelope.MAC = envelopeMAC(s.key, &envelope)
+	return rlp.EncodeToBytes(envelope)
+}
+
+// openEnvelope decodes the envelope, and returns an error unless it was sealed with the key
+func openEnvelope(key common.Bytes, rawEnvelope common.Bytes) (*MessageEnvelope, error) {
+	envelope := &MessageEnvelope{}
+	if err := rlp.DecodeBytes(rawEnvelope, envelope); err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(envelope.MAC, envelopeMAC(key, envelope)) {
+		return nil, errInvalidMAC
+	}
+	return envelope, nil
+}
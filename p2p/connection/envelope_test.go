@@ -0,0 +1,119 @@
+package connection
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+func TestReplayGuard(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Unix(1600000000, 0)
+	guard := NewReplayGuard()
+	guard.now = func() time.Time { return now }
+	key := common.Bytes("session key")
+	sealer := newEnvelopeSealer(key)
+	sealer.now = func() time.Time { return now }
+
+	check := func(raw common.Bytes) error {
+		envelope, err := openEnvelope(key, raw)
+		assert.Nil(err)
+		return guard.Check(envelope, raw)
+	}
+
+	raw1, err := sealer.seal(common.Bytes("vote"))
+	assert.Nil(err)
+	raw2, err := sealer.seal(common.Bytes("vote"))
+	assert.Nil(err)
+	assert.NotEqual(raw1, raw2) // same message, different nonces
+
+	assert.Nil(check(raw1))
+	assert.Nil(check(raw2))
+	assert.Equal(errReplayedMessage, check(raw1))
+	assert.Equal(errReplayedMessage, check(raw2))
+
+	// Envelopes outside of the acceptance window
+	sealer.now = func() time.Time { return now.Add(-maxMessageAge - time.Second) }
+	stale, _ := sealer.seal(common.Bytes("vote"))
+	assert.Equal(errStaleMessage, check(stale))
+	sealer.now = func() time.Time { return now.Add(maxClockDrift + time.Second) }
+	future, _ := sealer.seal(common.Bytes("vote"))
+	assert.Equal(errFutureMessage, check(future))
+
+	// The envelopes are forgotten once stale
+	now = now.Add(maxMessageAge + time.Second)
+	sealer.now = func() time.Time { return now }
+	fresh, _ := sealer.seal(common.Bytes("vote"))
+	assert.Nil(check(fresh))
+	assert.Equal(1, len(guard.seen))
+	assert.Equal(1, len(guard.queue))
+	assert.Equal(errStaleMessage, check(raw1))
+}
+
+func TestConnectionMessageEnvelopes(t *testing.T) {
+	assert := assert.New(t)
+
+	netconn, remote := net.Pipe()
+	defer netconn.Close()
+	defer remote.Close()
+
+	conn := CreateConnection(netconn, GetDefaultConnectionConfig())
+	sendKey, recvKey := common.Bytes("send key"), common.Bytes("recv key")
+	conn.EnableMessageEnvelopes(sendKey, recvKey)
+	conn.replayGuard = NewReplayGuard()
+	conn.SetMessageParser(func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+		var content string
+		err := rlp.DecodeBytes(rawMessageBytes, &content)
+		return p2ptypes.Message{ChannelID: channelID, Content: content}, err
+	})
+	received := []string{}
+	conn.SetReceiveHandler(func(message p2ptypes.Message) error {
+		received = append(received, message.Content.(string))
+		return nil
+	})
+
+	// Sealed by the peer
+	sealed, err := newEnvelopeSealer(recvKey).seal(encodeString("vote"))
+	assert.Nil(err)
+	assert.True(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDVote, Bytes: sealed, IsEOF: byte(0x01)}))
+	assert.Equal([]string{"vote"}, received)
+
+	// Replayed, or not sealed
+	assert.False(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDVote, Bytes: sealed, IsEOF: byte(0x01)}))
+	assert.False(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDVote, Bytes: encodeString("vote"), IsEOF: byte(0x01)}))
+	assert.Equal([]string{"vote"}, received)
+
+	// Sealed with another key, e.g. a payload captured elsewhere and wrapped again
+	resealed, err := newEnvelopeSealer(common.Bytes("other key")).seal(encodeString("vote"))
+	assert.Nil(err)
+	assert.False(conn.handleReceivedPacket(&Packet{ChannelID: common.ChannelIDVote, Bytes: resealed, IsEOF: byte(0x01)}))
+	assert.Equal([]string{"vote"}, received)
+
+	for _, cs := range conn.GetChannelStats() {
+		if cs.ChannelID == common.ChannelIDVote {
+			assert.Equal(uint64(4), cs.MsgsReceived)
+			assert.Equal(uint64(1), cs.ReplaysRejected)
+			assert.Equal(uint64(2), cs.DecodeFailures)
+		}
+	}
+
+	// The messages sent are sealed too
+	msgBytes, err := conn.encodeMessage(common.ChannelIDVote, "vote")
+	assert.Nil(err)
+	_, err = openEnvelope(recvKey, msgBytes)
+	assert.Equal(errInvalidMAC, err)
+	envelope, err := openEnvelope(sendKey, msgBytes)
+	assert.Nil(err)
+	assert.Equal(encodeString("vote"), envelope.Payload)
+}
+
+func encodeString(s string) common.Bytes {
+	bytes, _ := rlp.EncodeToBytes(s)
+	return bytes
+}
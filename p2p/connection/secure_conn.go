@@ -0,0 +1,137 @@
+package connection
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// maxSecureFramePayload is the maximum size of the plaintext of a frame of a SecureConn
+const maxSecureFramePayload = 16 * 1024
+
+// SecureConn encrypts and authenticates the bytes exchanged over a net.Conn. The bytes written are
+// split into frames, each sealed with AES-256-GCM and prefixed by the length of the sealed frame.
+// The frames are numbered in each direction, the number of the frame being the nonce, so that
+// the frames cannot be replayed, reordered or dropped without the other end noticing. Each
+// direction uses its own key, derived by both ends from a shared secret during the handshake.
+type SecureConn struct {
+	net.Conn
+
+	wmu       sync.Mutex
+	sendAEAD  cipher.AEAD
+	sendNonce uint64
+
+	rmu       sync.Mutex
+	recvAEAD  cipher.AEAD
+	recvNonce uint64
+	readBuf   []byte // Decrypted bytes not read yet
+}
+
+var _ net.Conn = (*SecureConn)(nil)
+
+// NewSecureConn wraps the netconn in a SecureConn encrypting the bytes sent with sendKey and
+// decrypting the bytes received with recvKey. The keys are 32 bytes long.
+func NewSecureConn(netconn net.Conn, sendKey, recvKey []byte) (*SecureConn, error) {
+	sendAEAD, err := newFrameAEAD(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recvAEAD, err := newFrameAEAD(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SecureConn{
+		Conn:     netconn,
+		sendAEAD: sendAEAD,
+		recvAEAD: recvAEAD,
+	}, nil
+}
+
+func newFrameAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("Invalid secure transport key length: %v", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Write implements the net.Conn interface.
+func (sc *SecureConn) Write(b []byte) (int, error) {
+	sc.wmu.Lock()
+	defer sc.wmu.Unlock()
+
+	written := 0
+	for written < len(b) {
+		end := written + maxSecureFramePayload
+		if end > len(b) {
+			end = len(b)
+		}
+		if err := sc.writeFrame(b[written:end]); err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+func (sc *SecureConn) writeFrame(plaintext []byte) error {
+	frame := make([]byte, 4, 4+len(plaintext)+sc.sendAEAD.Overhead())
+	frame = sc.sendAEAD.Seal(frame, frameNonce(sc.sendNonce, sc.sendAEAD.NonceSize()), plaintext, nil)
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(frame)-4))
+	sc.sendNonce++
+	_, err := sc.Conn.Write(frame)
+	return err
+}
+
+// Read implements the net.Conn interface.
+func (sc *SecureConn) Read(b []byte) (int, error) {
+	sc.rmu.Lock()
+	defer sc.rmu.Unlock()
+
+	for len(sc.readBuf) == 0 {
+		plaintext, err := sc.readFrame()
+		if err != nil {
+			return 0, err
+		}
+		sc.readBuf = plaintext
+	}
+	n := copy(b, sc.readBuf)
+	sc.readBuf = sc.readBuf[n:]
+	return n, nil
+}
+
+func (sc *SecureConn) readFrame() ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(sc.Conn, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size < uint32(sc.recvAEAD.Overhead()) || size > uint32(maxSecureFramePayload+sc.recvAEAD.Overhead()) {
+		return nil, fmt.Errorf("Invalid secure frame size: %v", size)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(sc.Conn, frame); err != nil {
+		return nil, err
+	}
+	plaintext, err := sc.recvAEAD.Open(frame[:0], frameNonce(sc.recvNonce, sc.recvAEAD.NonceSize()), frame, nil)
+	if err != nil {
+		return nil, errors.New("Failed to authenticate secure frame")
+	}
+	sc.recvNonce++
+	return plaintext, nil
+}
+
+// frameNonce returns the AEAD nonce of the frame with the given number
+func frameNonce(number uint64, size int) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce[size-8:], number)
+	return nonce
+}
@@ -0,0 +1,65 @@
+package connection
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+)
+
+func TestSecureConn(t *testing.T) {
+	assert := assert.New(t)
+
+	keyAB := bytes.Repeat([]byte{1}, 32)
+	keyBA := bytes.Repeat([]byte{2}, 32)
+
+	netconnA, netconnB := net.Pipe()
+	defer netconnA.Close()
+	defer netconnB.Close()
+	connA, err := NewSecureConn(netconnA, keyAB, keyBA)
+	assert.Nil(err)
+	connB, err := NewSecureConn(netconnB, keyBA, keyAB)
+	assert.Nil(err)
+
+	// Messages longer than a frame are split and reassembled
+	msg := bytes.Repeat([]byte("The Theta blockchain"), 2*maxSecureFramePayload/20+7)
+	var writeErr error
+	received := make([]byte, len(msg))
+	common.Parallel(
+		func() { _, writeErr = connA.Write(msg) },
+		func() { _, err = io.ReadFull(connB, received) },
+	)
+	assert.Nil(writeErr)
+	assert.Nil(err)
+	assert.Equal(msg, received)
+
+	// The other direction uses its own key and frame numbers
+	reply := []byte("ack")
+	received = make([]byte, len(reply))
+	common.Parallel(
+		func() { _, writeErr = connB.Write(reply) },
+		func() { _, err = io.ReadFull(connA, received) },
+	)
+	assert.Nil(writeErr)
+	assert.Nil(err)
+	assert.Equal(reply, received)
+
+	// The keys must be 32 bytes long
+	_, err = NewSecureConn(netconnA, keyAB[:16], keyBA)
+	assert.NotNil(err)
+
+	// A frame sealed with another key is rejected
+	netconnC, netconnD := net.Pipe()
+	defer netconnC.Close()
+	defer netconnD.Close()
+	connC, err := NewSecureConn(netconnC, keyAB, keyBA)
+	assert.Nil(err)
+	connD, err := NewSecureConn(netconnD, keyAB, keyBA)
+	assert.Nil(err)
+	go connC.Write([]byte("hello"))
+	_, err = connD.Read(make([]byte, 5))
+	assert.NotNil(err)
+}
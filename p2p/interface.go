@@ -50,4 +50,17 @@ type Network interface {
 
 	// ID returns the ID of the network peer
 	ID() string
+
+	// PeerStats returns the per-channel traffic stats of the connected peers
+	PeerStats() []types.PeerStats
+}
+
+//
+// PeerReporter is implemented by the networks keeping track of the reputation of the peers
+//
+type PeerReporter interface {
+
+	// ReportPeer reports a behavior of the peer, the network throttles or disconnects the peers
+	// misbehaving persistently
+	ReportPeer(peerID string, event types.PeerEvent)
 }
@@ -16,6 +16,8 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -75,6 +77,11 @@ const (
 	// max addresses returned by GetSelection
 	// NOTE: this must match "maxPexMessageSize"
 	maxGetSelection = 250
+
+	// min and max delay before a known good address is redialed after a failed attempt,
+	// doubled on each consecutive failure.
+	minRedialBackoff = time.Minute * 2
+	maxRedialBackoff = time.Hour * 2
 )
 
 const (
@@ -311,6 +318,91 @@ func (a *AddrBook) GetSelection() []*nu.NetAddress {
 	return allAddr[:numAddresses]
 }
 
+// GetPexSelection randomly selects some addresses we have successfully connected to
+// recently, along with their freshness metadata. Suitable for the PEX protocol.
+func (a *AddrBook) GetPexSelection() []PexAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	goodAddrs := []*knownAddress{}
+	for _, ka := range a.addrLookup {
+		if isFreshPexTime(ka.LastSuccess) {
+			goodAddrs = append(goodAddrs, ka)
+		}
+	}
+
+	numAddresses := mm.MaxInt(
+		mm.MinInt(minGetSelection, len(goodAddrs)),
+		len(goodAddrs)*getSelectionPercent/100)
+	numAddresses = mm.MinInt(maxGetSelection, numAddresses)
+
+	for i := 0; i < numAddresses; i++ {
+		j := a.rand.Intn(len(goodAddrs)-i) + i
+		goodAddrs[i], goodAddrs[j] = goodAddrs[j], goodAddrs[i]
+	}
+
+	pexAddrs := make([]PexAddress, numAddresses)
+	for i, ka := range goodAddrs[:numAddresses] {
+		pexAddrs[i] = newPexAddress(ka)
+	}
+	return pexAddrs
+}
+
+// AddPexAddresses adds the addresses received from the src peer through PEX. Stale
+// addresses and addresses claiming to be from the future are dropped. Since the new
+// bucket is determined by the source group, a single source can only populate a small
+// fraction of the book, which mitigates eclipse attacks. Returns the number of
+// addresses added.
+func (a *AddrBook) AddPexAddresses(pexAddrs []PexAddress, src *nu.NetAddress) (numAdded int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if len(pexAddrs) > maxGetSelection {
+		pexAddrs = pexAddrs[:maxGetSelection]
+	}
+	for _, pexAddr := range pexAddrs {
+		if pexAddr.Addr == nil || !pexAddr.Addr.Valid() {
+			continue
+		}
+		if !isFreshPexTime(time.Unix(int64(pexAddr.LastSuccess), 0)) {
+			continue
+		}
+		_, existed := a.addrLookup[pexAddr.Addr.String()]
+		a.addAddress(pexAddr.Addr, src)
+		if _, exists := a.addrLookup[pexAddr.Addr.String()]; exists && !existed {
+			numAdded++
+		}
+	}
+	return numAdded
+}
+
+// GetKnownGoodAddresses returns up to max addresses we have successfully connected to before,
+// which are due for a redial, ordered by their freshness score (see knownAddress.freshnessScore).
+func (a *AddrBook) GetKnownGoodAddresses(max int) []*nu.NetAddress {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := time.Now()
+	goodAddrs := []*knownAddress{}
+	for _, ka := range a.addrLookup {
+		if ka.isOld() && !ka.LastSuccess.IsZero() && ka.isRedialDue(now) {
+			goodAddrs = append(goodAddrs, ka)
+		}
+	}
+	sort.SliceStable(goodAddrs, func(i, j int) bool {
+		return goodAddrs[i].freshnessScore(now) > goodAddrs[j].freshnessScore(now)
+	})
+
+	if len(goodAddrs) > max {
+		goodAddrs = goodAddrs[:max]
+	}
+	addrs := make([]*nu.NetAddress, len(goodAddrs))
+	for i, ka := range goodAddrs {
+		addrs[i] = ka.Addr
+	}
+	return addrs
+}
+
 /* Loading & Saving */
 
 type addrBookJSON struct {
@@ -339,14 +431,19 @@ func (a *AddrBook) saveToFile(filePath string) {
 		logger.Errorf("Failed to save AddrBook to file: %v", err)
 		return
 	}
+	err = os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		logger.Errorf("Failed to create the AddrBook folder for file: %v, error: %v", filePath, err)
+		return
+	}
 	err = common.WriteFileAtomic(filePath, jsonBytes, 0644)
 	if err != nil {
 		logger.Errorf("Failed to save AddrBook to file: %v, error: %v", filePath, err)
 	}
 }
 
-// Returns false if file does not exist.
-// panic if file is corrupt.
+// Returns false if file does not exist or is corrupt. In the latter case
+// the node starts with an empty book and relies on the seeds and anchors.
 func (a *AddrBook) loadFromFile(filePath string) bool {
 	// If doesn't exist, do nothing.
 	_, err := os.Stat(filePath)
@@ -357,21 +454,30 @@ func (a *AddrBook) loadFromFile(filePath string) bool {
 	// Load addrBookJSON{}
 	r, err := os.Open(filePath)
 	if err != nil {
-		log.Panicf("Error opening file %s: %v", filePath, err)
+		logger.Errorf("Error opening file %s: %v", filePath, err)
+		return false
 	}
 	defer r.Close()
 	aJSON := &addrBookJSON{}
 	dec := json.NewDecoder(r)
 	err = dec.Decode(aJSON)
 	if err != nil {
-		log.Panicf("Error reading file %s: %v", filePath, err)
+		logger.Errorf("Error reading file %s, discard the saved addresses: %v", filePath, err)
+		return false
 	}
 
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
 	// Restore all the fields...
 	// Restore the key
 	a.key = aJSON.Key
 	// Restore .addrNew & .addrOld
 	for _, ka := range aJSON.Addrs {
+		if !a.isValidSavedAddress(ka) {
+			logger.Warnf("Discard invalid saved address: %v", ka)
+			continue
+		}
 		for _, bucketIndex := range ka.Buckets {
 			bucket := a.getBucket(ka.BucketType, bucketIndex)
 			bucket[ka.Addr.String()] = ka
@@ -386,6 +492,34 @@ func (a *AddrBook) loadFromFile(filePath string) bool {
 	return true
 }
 
+// isValidSavedAddress checks that a saved address references existing buckets,
+// so that a tampered file cannot corrupt the bucket bookkeeping
+func (a *AddrBook) isValidSavedAddress(ka *knownAddress) bool {
+	if ka == nil || ka.Addr == nil || len(ka.Buckets) == 0 {
+		return false
+	}
+	numBuckets := len(a.addrNew)
+	switch ka.BucketType {
+	case bucketTypeNew:
+		if len(ka.Buckets) > maxNewBucketsPerAddress {
+			return false
+		}
+	case bucketTypeOld:
+		numBuckets = len(a.addrOld)
+		if len(ka.Buckets) != 1 {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, bucketIndex := range ka.Buckets {
+		if bucketIndex < 0 || bucketIndex >= numBuckets {
+			return false
+		}
+	}
+	return true
+}
+
 // Save saves the book.
 func (a *AddrBook) Save() {
 	logger.Infof("Saving AddrBook to file, size: %v", a.Size())
@@ -471,7 +605,7 @@ func (a *AddrBook) addToOldBucket(ka *knownAddress, bucketIdx int) bool {
 	}
 
 	addrStr := ka.Addr.String()
-	bucket := a.getBucket(bucketTypeNew, bucketIdx)
+	bucket := a.getBucket(bucketTypeOld, bucketIdx)
 
 	// Already exists?
 	if _, ok := bucket[addrStr]; ok {
@@ -786,6 +920,32 @@ func (ka *knownAddress) markGood() {
 	ka.LastSuccess = now
 }
 
+// freshnessScore rates how likely the address is to be reachable, from 0 (never connected) to 1
+// (connected just now). The score halves for each day since the last success, and for each
+// failed attempt since then.
+func (ka *knownAddress) freshnessScore(now time.Time) float64 {
+	if ka.LastSuccess.IsZero() {
+		return 0
+	}
+	daysSinceSuccess := now.Sub(ka.LastSuccess).Hours() / 24
+	if daysSinceSuccess < 0 {
+		daysSinceSuccess = 0
+	}
+	return math.Pow(0.5, daysSinceSuccess+float64(ka.Attempts))
+}
+
+// isRedialDue returns whether the backoff after the failed attempts to connect to the address is over
+func (ka *knownAddress) isRedialDue(now time.Time) bool {
+	if ka.Attempts <= 0 {
+		return true
+	}
+	backoff := maxRedialBackoff
+	if ka.Attempts < 12 && minRedialBackoff<<uint(ka.Attempts-1) < maxRedialBackoff {
+		backoff = minRedialBackoff << uint(ka.Attempts-1)
+	}
+	return now.After(ka.LastAttempt.Add(backoff))
+}
+
 func (ka *knownAddress) addBucketRef(bucketIdx int) int {
 	for _, bucket := range ka.Buckets {
 		if bucket == bucketIdx {
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/p2p/netutil"
@@ -167,3 +168,129 @@ func TestAddrBookRemoveAddress(t *testing.T) {
 	book.RemoveAddress(nonExistingAddr)
 	assert.Equal(t, 0, book.Size())
 }
+
+func TestAddrBookPexSelection(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+	book := NewAddrBook(fname, true)
+
+	randAddrs := randNetAddressPairs(t, 100)
+	for i, addrSrc := range randAddrs {
+		book.AddAddress(addrSrc.addr, addrSrc.src)
+		if i%2 == 0 {
+			book.MarkGood(addrSrc.addr)
+		}
+	}
+
+	// Only the addresses we have successfully connected to are exchanged
+	selection := book.GetPexSelection()
+	assert.Equal(t, 32, len(selection))
+	for _, pexAddr := range selection {
+		ka := book.addrLookup[pexAddr.Addr.String()]
+		assert.True(t, ka.isOld())
+		assert.Equal(t, uint64(ka.LastSuccess.Unix()), pexAddr.LastSuccess)
+	}
+}
+
+func TestAddrBookAddPexAddresses(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+	book := NewAddrBook(fname, true)
+
+	now := time.Now()
+	src := randIPv4Address(t)
+	pexAddrs := []PexAddress{
+		{Addr: randIPv4Address(t), LastSuccess: uint64(now.Unix())},
+		{Addr: randIPv4Address(t), LastSuccess: uint64(now.Add(-time.Hour).Unix())},
+		{Addr: randIPv4Address(t), LastSuccess: uint64(now.Add(-2 * pexMaxAddressAge).Unix())}, // stale
+		{Addr: randIPv4Address(t), LastSuccess: uint64(now.Add(2 * pexMaxClockDrift).Unix())},  // from the future
+		{Addr: randIPv4Address(t)}, // never connected
+		{Addr: nil, LastSuccess: uint64(now.Unix())},
+	}
+
+	assert.Equal(t, 2, book.AddPexAddresses(pexAddrs, src))
+	assert.Equal(t, 2, book.Size())
+	assert.NotNil(t, book.addrLookup[pexAddrs[0].Addr.String()])
+	assert.NotNil(t, book.addrLookup[pexAddrs[1].Addr.String()])
+
+	// Duplicates are not counted
+	assert.Equal(t, 0, book.AddPexAddresses(pexAddrs[:1], src))
+	assert.Equal(t, 2, book.Size())
+}
+
+func TestAddrBookPickAddress(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+	book := NewAddrBook(fname, true)
+	assert.Nil(t, book.PickAddress(50))
+
+	randAddrs := randNetAddressPairs(t, 10)
+	for _, addrSrc := range randAddrs {
+		book.AddAddress(addrSrc.addr, addrSrc.src)
+		book.MarkGood(addrSrc.addr)
+	}
+
+	// All the addresses have been promoted to the old buckets
+	addr := book.PickAddress(0)
+	assert.NotNil(t, addr)
+	assert.True(t, book.addrLookup[addr.String()].isOld())
+}
+
+func TestAddrBookLoadCorruptFile(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+	assert.Nil(t, ioutil.WriteFile(fname, []byte("{corrupted"), 0644))
+
+	book := NewAddrBook(fname, true)
+	assert.False(t, book.loadFromFile(fname))
+	assert.Zero(t, book.Size())
+}
+
+func TestAddrBookLoadDiscardsInvalidAddresses(t *testing.T) {
+	fname := createTempFileName("addrbook_test")
+
+	book := NewAddrBook(fname, true)
+	for _, addrSrc := range randNetAddressPairs(t, 10) {
+		book.AddAddress(addrSrc.addr, addrSrc.src)
+	}
+	for _, ka := range book.addrLookup {
+		ka.Buckets = []int{newBucketCount + 1} // out of range
+		break
+	}
+	book.saveToFile(fname)
+
+	book = NewAddrBook(fname, true)
+	assert.True(t, book.loadFromFile(fname))
+	assert.Equal(t, 9, book.Size())
+}
+
+func TestAddrBookGetKnownGoodAddresses(t *testing.T) {
+	assert := assert.New(t)
+	fname := createTempFileName("addrbook_test")
+
+	randAddrs := randNetAddressPairs(t, 4)
+	book := NewAddrBook(fname, true)
+	for _, addrSrc := range randAddrs {
+		book.AddAddress(addrSrc.addr, addrSrc.src)
+	}
+
+	// Only the addresses connected before are known good
+	assert.Equal(0, len(book.GetKnownGoodAddresses(10)))
+	for _, addrSrc := range randAddrs[:3] {
+		book.MarkGood(addrSrc.addr)
+	}
+
+	// The older successes score lower
+	book.addrLookup[randAddrs[0].addr.String()].LastSuccess = time.Now().Add(-48 * time.Hour)
+	addrs := book.GetKnownGoodAddresses(10)
+	assert.Equal(3, len(addrs))
+	assert.True(addrs[2].Equals(randAddrs[0].addr))
+	assert.Equal(2, len(book.GetKnownGoodAddresses(2)))
+
+	// The failed addresses are backed off
+	book.MarkAttempt(randAddrs[1].addr)
+	addrs = book.GetKnownGoodAddresses(10)
+	assert.Equal(2, len(addrs))
+	ka := book.addrLookup[randAddrs[1].addr.String()]
+	ka.LastAttempt = time.Now().Add(-minRedialBackoff - time.Second)
+	assert.Equal(3, len(book.GetKnownGoodAddresses(10)))
+	ka.Attempts = 2
+	assert.Equal(2, len(book.GetKnownGoodAddresses(10)))
+	assert.True(ka.freshnessScore(time.Now()) < book.addrLookup[randAddrs[2].addr.String()].freshnessScore(time.Now()))
+}
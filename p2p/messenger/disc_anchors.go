@@ -0,0 +1,158 @@
+package messenger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/p2p/netutil"
+	pr "github.com/thetatoken/theta/p2p/peer"
+)
+
+const (
+	// max number of outbound peers saved as anchors, which the node reconnects to
+	// first upon restart
+	maxNumAnchors = 2
+
+	// anchors saved longer ago are stale, and not reconnected to
+	maxAnchorsAge = 24 * time.Hour
+
+	// max number of outbound peers in the same address group (/16 for IPv4, /32 for
+	// IPv6) among the peers obtained through discovery
+	maxOutboundPeersPerGroup = 2
+)
+
+type anchorsJSON struct {
+	Owner   string // address of the node which saved the anchors
+	SavedAt time.Time
+	Anchors []string
+}
+
+// anchorsFilePath returns the path of the anchors file, which is kept next to the address
+// book, e.g. addrbook_anchors.json for addrbook.json
+func anchorsFilePath(addrBookFilePath string) string {
+	return strings.TrimSuffix(addrBookFilePath, filepath.Ext(addrBookFilePath)) + "_anchors.json"
+}
+
+func writeAnchors(filePath string, owner string, savedAt time.Time, anchors []*netutil.NetAddress) error {
+	aJSON := anchorsJSON{Owner: owner, SavedAt: savedAt}
+	for _, anchor := range anchors {
+		aJSON.Anchors = append(aJSON.Anchors, anchor.String())
+	}
+	jsonBytes, err := json.MarshalIndent(aJSON, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filePath), 0700)
+	if err != nil {
+		return err
+	}
+	return common.WriteFileAtomic(filePath, jsonBytes, 0644)
+}
+
+// readAnchors returns the anchors saved by the given node. The anchors saved by another node, e.g.
+// before the node key was replaced, or more than maxAnchorsAge ago are ignored, until they get
+// replaced once the node has outbound peers.
+func readAnchors(filePath string, owner string, now time.Time) ([]*netutil.NetAddress, error) {
+	jsonBytes, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	aJSON := anchorsJSON{}
+	err = json.Unmarshal(jsonBytes, &aJSON)
+	if err != nil {
+		return nil, err
+	}
+	if aJSON.Owner != owner {
+		logger.Infof("Ignoring the anchors saved by another node: %v", aJSON.Owner)
+		return nil, nil
+	}
+	if now.Sub(aJSON.SavedAt) > maxAnchorsAge {
+		logger.Infof("Ignoring the anchors saved at %v", aJSON.SavedAt)
+		return nil, nil
+	}
+	if len(aJSON.Anchors) > maxNumAnchors {
+		aJSON.Anchors = aJSON.Anchors[:maxNumAnchors]
+	}
+	return netutil.NewNetAddressStrings(aJSON.Anchors)
+}
+
+// saveAnchors saves the longest lived outbound peers as anchors. Unlike the peers
+// learned from the network, the anchors were chosen by the node itself, so reconnecting
+// to them upon restart prevents an attacker from filling all the outbound slots.
+func (discMgr *PeerDiscoveryManager) saveAnchors() {
+	anchors := []*netutil.NetAddress{}
+	for _, peer := range *(discMgr.peerTable.GetAllPeers()) {
+		if len(anchors) >= maxNumAnchors {
+			break
+		}
+		if peer.IsOutbound() && !discMgr.seedPeerConnector.isASeedPeer(peer.NetAddress()) {
+			anchors = append(anchors, peer.NetAddress())
+		}
+	}
+	if len(anchors) == 0 {
+		return
+	}
+
+	filePath := anchorsFilePath(discMgr.addrBook.filePath)
+	if err := writeAnchors(filePath, discMgr.anchorsOwner(), time.Now(), anchors); err != nil {
+		logger.Errorf("Failed to save anchors to file: %v, error: %v", filePath, err)
+	}
+}
+
+// connectToAnchors reconnects to the anchors saved before the last shutdown. The anchors file
+// is kept, and replaced as the outbound peers connect.
+func (discMgr *PeerDiscoveryManager) connectToAnchors() {
+	filePath := anchorsFilePath(discMgr.addrBook.filePath)
+	anchors, err := readAnchors(filePath, discMgr.anchorsOwner(), time.Now())
+	if err != nil {
+		logger.Warnf("Failed to load anchors from file: %v, error: %v", filePath, err)
+		return
+	}
+	for _, anchor := range anchors {
+		discMgr.wg.Add(1)
+		go func(anchor *netutil.NetAddress) {
+			defer discMgr.wg.Done()
+			_, err := discMgr.connectToOutboundPeer(anchor, true)
+			if err != nil {
+				logger.Warnf("Failed to connect to anchor peer %v: %v", anchor.String(), err)
+			} else {
+				logger.Infof("Successfully connected to anchor peer %v", anchor.String())
+			}
+		}(anchor)
+	}
+}
+
+// anchorsOwner identifies the node in the anchors file
+func (discMgr *PeerDiscoveryManager) anchorsOwner() string {
+	return discMgr.nodeInfo.PubKey.Address().Hex()
+}
+
+// isOutboundGroupAllowed checks whether connecting to the given address keeps the outbound
+// peers diverse enough, so that an attacker controlling a few IP ranges cannot take over
+// all the outbound connections. Non-routable addresses (e.g. local networks) are exempted.
+func (discMgr *PeerDiscoveryManager) isOutboundGroupAllowed(addr *netutil.NetAddress) bool {
+	if !addr.Routable() {
+		return true
+	}
+	group := discMgr.addrBook.groupKey(addr)
+	numPeersInGroup := 0
+	for _, peer := range *(discMgr.peerTable.GetAllPeers()) {
+		if peer.IsOutbound() && discMgr.addrBook.groupKey(peer.NetAddress()) == group {
+			numPeersInGroup++
+		}
+	}
+	return numPeersInGroup < maxOutboundPeersPerGroup
+}
+
+func (discMgr *PeerDiscoveryManager) handleOutboundPeerAdded(peer *pr.Peer) {
+	discMgr.addrBook.MarkGood(peer.NetAddress())
+	discMgr.saveAnchors()
+}
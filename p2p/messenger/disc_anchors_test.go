@@ -0,0 +1,75 @@
+package messenger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/p2p/netutil"
+)
+
+func TestAnchorsFilePath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("./.addrbook/addrbook_anchors.json", anchorsFilePath("./.addrbook/addrbook.json"))
+	assert.Equal("addrbook_127.0.0.1:24531_anchors.json", anchorsFilePath("addrbook_127.0.0.1:24531.json"))
+}
+
+func TestAnchorsSaveLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := createTempFileName("anchors_test")
+	os.Remove(dir)
+	filePath := filepath.Join(dir, "addrbook_anchors.json")
+
+	// Missing file means no anchors
+	anchors, err := readAnchors(filePath, "node", time.Now())
+	assert.Nil(err)
+	assert.Empty(anchors)
+
+	addrs, err := netutil.NewNetAddressStrings([]string{"104.25.10.1:50001", "35.12.1.3:50001", "52.1.2.3:50001"})
+	assert.Nil(err)
+	assert.Nil(writeAnchors(filePath, "node", time.Now(), addrs))
+
+	// At most maxNumAnchors are loaded
+	anchors, err = readAnchors(filePath, "node", time.Now())
+	assert.Nil(err)
+	assert.Equal(maxNumAnchors, len(anchors))
+	for i := 0; i < maxNumAnchors; i++ {
+		assert.True(addrs[i].Equals(anchors[i]))
+	}
+
+	assert.Nil(ioutil.WriteFile(filePath, []byte("corrupted"), 0644))
+	_, err = readAnchors(filePath, "node", time.Now())
+	assert.NotNil(err)
+}
+
+func TestAnchorsOwnerAndAge(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := createTempFileName("anchors_test")
+	os.Remove(dir)
+	filePath := filepath.Join(dir, "addrbook_anchors.json")
+
+	addrs, err := netutil.NewNetAddressStrings([]string{"104.25.10.1:50001"})
+	assert.Nil(err)
+	now := time.Now()
+	assert.Nil(writeAnchors(filePath, "node", now, addrs))
+
+	anchors, err := readAnchors(filePath, "node", now.Add(maxAnchorsAge))
+	assert.Nil(err)
+	assert.Equal(1, len(anchors))
+
+	// The anchors of another node, e.g. before the node key was replaced, are ignored
+	anchors, err = readAnchors(filePath, "other node", now)
+	assert.Nil(err)
+	assert.Empty(anchors)
+
+	// Stale anchors are ignored
+	anchors, err = readAnchors(filePath, "node", now.Add(maxAnchorsAge+time.Second))
+	assert.Nil(err)
+	assert.Empty(anchors)
+}
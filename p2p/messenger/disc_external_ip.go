@@ -0,0 +1,70 @@
+package messenger
+
+import (
+	"net"
+	"sync"
+
+	"github.com/thetatoken/theta/p2p/netutil"
+)
+
+const (
+	// the number of peers at different IPs which need to agree on the external IP of the node
+	minExternalIPVotes = 3
+
+	// the IPs observed by at most this many peers are tracked
+	maxExternalIPVotes = 256
+)
+
+// externalIPDetector learns the external IP of the node from the IPs the peers see it at, which
+// differ from the interface addresses behind a NAT. An IP is only taken once enough peers at
+// different IPs agree on it, and they are the majority, so that a few peers can't mislead the node.
+type externalIPDetector struct {
+	mu *sync.Mutex
+
+	votes   map[string]string // IP of the peer -> IP the peer sees the node at
+	current string
+}
+
+func newExternalIPDetector() *externalIPDetector {
+	return &externalIPDetector{
+		mu:    &sync.Mutex{},
+		votes: make(map[string]string),
+	}
+}
+
+// record records the IP the peer at peerIP sees the node at, and returns the external IP of the
+// node when it changes.
+func (d *externalIPDetector) record(peerIP string, observedIP string) (string, bool) {
+	ip := net.ParseIP(observedIP)
+	if ip == nil || !netutil.NewNetAddressIPPort(ip, 0).Routable() {
+		return "", false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.votes[peerIP]; !ok && len(d.votes) >= maxExternalIPVotes {
+		return "", false
+	}
+	d.votes[peerIP] = ip.String()
+
+	counts := make(map[string]int)
+	for _, vote := range d.votes {
+		counts[vote]++
+	}
+	for candidate, count := range counts {
+		if count >= minExternalIPVotes && count*2 > len(d.votes) && candidate != d.current {
+			d.current = candidate
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// forget drops the vote of the peer at peerIP, e.g. once it disconnects
+func (d *externalIPDetector) forget(peerIP string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.votes, peerIP)
+}
@@ -0,0 +1,44 @@
+package messenger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalIPDetector(t *testing.T) {
+	assert := assert.New(t)
+
+	d := newExternalIPDetector()
+
+	// The private and invalid IPs are ignored
+	_, changed := d.record("10.0.0.1", "192.168.1.23")
+	assert.False(changed)
+	_, changed = d.record("10.0.0.1", "not an ip")
+	assert.False(changed)
+	assert.Equal(0, len(d.votes))
+
+	_, changed = d.record("10.0.0.1", "203.0.113.7")
+	assert.False(changed)
+	_, changed = d.record("10.0.0.2", "203.0.113.7")
+	assert.False(changed)
+	// The same peer IP votes once
+	_, changed = d.record("10.0.0.2", "203.0.113.7")
+	assert.False(changed)
+	ip, changed := d.record("10.0.0.3", "203.0.113.7")
+	assert.True(changed)
+	assert.Equal("203.0.113.7", ip)
+	_, changed = d.record("10.0.0.4", "203.0.113.7")
+	assert.False(changed)
+
+	// A new IP needs to be observed by the majority of the peers
+	for _, peerIP := range []string{"10.0.0.5", "10.0.0.6", "10.0.0.7"} {
+		_, changed = d.record(peerIP, "198.51.100.9")
+		assert.False(changed)
+	}
+	d.forget("10.0.0.1")
+	d.forget("10.0.0.2")
+	ip, changed = d.record("10.0.0.8", "198.51.100.9")
+	assert.True(changed)
+	assert.Equal("198.51.100.9", ip)
+}
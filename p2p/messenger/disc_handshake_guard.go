@@ -0,0 +1,224 @@
+package messenger
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/common/ratelimit"
+)
+
+const (
+	// the handshake failures of an IP older than this are forgotten
+	handshakeFailureWindow = 10 * time.Minute
+
+	// the records of the IPs are pruned once there are more than this many
+	maxTrackedIPs = 1 << 16
+)
+
+var (
+	errIPBanned                 = errors.New("IP is banned for failing handshakes")
+	errTooManyPendingHandshakes = errors.New("too many pending handshakes")
+	errConnectionThrottled      = errors.New("too many new connections")
+	errHandshakeRateLimited     = errors.New("too many handshakes from the IP")
+
+	handshakeRateLimitedCounter = metrics.NewRegisteredCounter("p2p/handshake/ratelimited", nil)
+	handshakeBannedCounter      = metrics.NewRegisteredCounter("p2p/handshake/banned", nil)
+	handshakeFailedCounter      = metrics.NewRegisteredCounter("p2p/handshake/failed", nil)
+	connectionThrottledCounter  = metrics.NewRegisteredCounter("p2p/connection/throttled", nil)
+	pendingHandshakesGauge      = metrics.NewRegisteredGauge("p2p/handshake/pending", nil)
+	bannedIPsGauge              = metrics.NewRegisteredGauge("p2p/handshake/bannedips", nil)
+
+	// The configured thresholds, so that the rejections can be read against them
+	maxHandshakesPerIPGauge   = metrics.NewRegisteredGauge("p2p/handshake/limit/perip", nil)
+	maxNewConnectionsGauge    = metrics.NewRegisteredGauge("p2p/connection/limit/persecond", nil)
+	maxPendingHandshakesGauge = metrics.NewRegisteredGauge("p2p/handshake/limit/pending", nil)
+	maxHandshakeFailuresGauge = metrics.NewRegisteredGauge("p2p/handshake/limit/failures", nil)
+	handshakeTimeoutGauge     = metrics.NewRegisteredGauge("p2p/handshake/limit/timeout", nil)
+	handshakeBanDurationGauge = metrics.NewRegisteredGauge("p2p/handshake/limit/banduration", nil)
+)
+
+// handshakeGuardConfig specifies the limits on the inbound connections. A limit of 0 means unlimited.
+type handshakeGuardConfig struct {
+	maxHandshakesPerIP          int           // handshakes per minute from an IP
+	maxNewConnections           int           // new connections per second from all the IPs
+	maxPendingHandshakes        int           // handshakes in progress
+	maxHandshakeFailures        int           // failed handshakes before an IP is banned
+	handshakeFailureBanDuration time.Duration // how long an IP failing handshakes is banned
+}
+
+// getHandshakeTimeout returns the time the peers have to complete the handshake
+func getHandshakeTimeout() time.Duration {
+	return time.Duration(viper.GetInt(common.CfgP2PHandshakeTimeout)) * time.Second
+}
+
+func getHandshakeGuardConfig() handshakeGuardConfig {
+	return handshakeGuardConfig{
+		maxHandshakesPerIP:          viper.GetInt(common.CfgP2PMaxHandshakesPerIP),
+		maxNewConnections:           viper.GetInt(common.CfgP2PMaxNewConnectionsPerSecond),
+		maxPendingHandshakes:        viper.GetInt(common.CfgP2PMaxPendingHandshakes),
+		maxHandshakeFailures:        viper.GetInt(common.CfgP2PMaxHandshakeFailures),
+		handshakeFailureBanDuration: time.Duration(viper.GetInt(common.CfgP2PHandshakeFailureBanDuration)) * time.Second,
+	}
+}
+
+type ipHandshakes struct {
+	attempts    *ratelimit.TokenBucket
+	failures    int
+	lastFailure time.Time
+	bannedUntil time.Time
+}
+
+// handshakeGuard admits the inbound connections to the handshake, throttling the new connections,
+// rate limiting the handshakes of each IP, and banning the IPs failing the handshake repeatedly.
+// The loopback IPs are exempted from the per IP limits, so that local nodes can connect freely.
+type handshakeGuard struct {
+	mu *sync.Mutex
+
+	config   handshakeGuardConfig
+	newConns *ratelimit.TokenBucket
+	ips      map[string]*ipHandshakes
+	pending  int
+}
+
+func newHandshakeGuard(config handshakeGuardConfig, now time.Time) *handshakeGuard {
+	handshakeTimeoutGauge.Update(int64(getHandshakeTimeout().Seconds()))
+	maxHandshakesPerIPGauge.Update(int64(config.maxHandshakesPerIP))
+	maxNewConnectionsGauge.Update(int64(config.maxNewConnections))
+	maxPendingHandshakesGauge.Update(int64(config.maxPendingHandshakes))
+	maxHandshakeFailuresGauge.Update(int64(config.maxHandshakeFailures))
+	handshakeBanDurationGauge.Update(int64(config.handshakeFailureBanDuration.Seconds()))
+
+	return &handshakeGuard{
+		mu:       &sync.Mutex{},
+		config:   config,
+		newConns: ratelimit.NewTokenBucket(float64(config.maxNewConnections), time.Second, now),
+		ips:      make(map[string]*ipHandshakes),
+	}
+}
+
+// admit checks whether a new connection from the IP can proceed to the handshake. Each admitted
+// connection needs to be released once the handshake completes.
+func (hg *handshakeGuard) admit(ip string, now time.Time) error {
+	hg.mu.Lock()
+	defer hg.mu.Unlock()
+
+	var record *ipHandshakes
+	if !isLoopbackIP(ip) {
+		record = hg.getRecord(ip, now)
+		if now.Before(record.bannedUntil) {
+			handshakeBannedCounter.Inc(1)
+			return errIPBanned
+		}
+	}
+	if hg.config.maxPendingHandshakes > 0 && hg.pending >= hg.config.maxPendingHandshakes {
+		connectionThrottledCounter.Inc(1)
+		return errTooManyPendingHandshakes
+	}
+	if !hg.newConns.Available(1, now) {
+		connectionThrottledCounter.Inc(1)
+		return errConnectionThrottled
+	}
+	if record != nil && !record.attempts.Available(1, now) {
+		handshakeRateLimitedCounter.Inc(1)
+		return errHandshakeRateLimited
+	}
+
+	hg.newConns.Take(1)
+	if record != nil {
+		record.attempts.Take(1)
+	}
+	hg.pending++
+	pendingHandshakesGauge.Update(int64(hg.pending))
+	return nil
+}
+
+// release records the result of the handshake of a connection admitted, and bans the IP once it
+// fails too many handshakes.
+func (hg *handshakeGuard) release(ip string, err error, now time.Time) {
+	hg.mu.Lock()
+	defer hg.mu.Unlock()
+
+	hg.pending--
+	pendingHandshakesGauge.Update(int64(hg.pending))
+
+	if err != nil {
+		handshakeFailedCounter.Inc(1)
+	}
+	if isLoopbackIP(ip) {
+		return
+	}
+	record := hg.getRecord(ip, now)
+	if err == nil {
+		record.failures = 0
+		return
+	}
+	if now.Sub(record.lastFailure) > handshakeFailureWindow {
+		record.failures = 0
+	}
+	record.failures++
+	record.lastFailure = now
+	if hg.config.maxHandshakeFailures > 0 && record.failures >= hg.config.maxHandshakeFailures {
+		logger.Warnf("Banning IP %v for %v after %v failed handshakes", ip, hg.config.handshakeFailureBanDuration, record.failures)
+		record.bannedUntil = now.Add(hg.config.handshakeFailureBanDuration)
+		record.failures = 0
+		bannedIPsGauge.Update(int64(hg.numBannedIPs(now)))
+	}
+}
+
+func (hg *handshakeGuard) getRecord(ip string, now time.Time) *ipHandshakes {
+	record, ok := hg.ips[ip]
+	if !ok {
+		if len(hg.ips) >= maxTrackedIPs {
+			hg.prune(now)
+		}
+		record = &ipHandshakes{
+			attempts: ratelimit.NewTokenBucket(float64(hg.config.maxHandshakesPerIP), time.Minute, now),
+		}
+		hg.ips[ip] = record
+	}
+	return record
+}
+
+// prune forgets the IPs which are not banned, have no recent failure, and have not handshaked
+// for a minute.
+func (hg *handshakeGuard) prune(now time.Time) {
+	for ip, record := range hg.ips {
+		if now.Before(record.bannedUntil) || now.Sub(record.lastFailure) <= handshakeFailureWindow {
+			continue
+		}
+		if record.attempts.Full(now) {
+			delete(hg.ips, ip)
+		}
+	}
+	bannedIPsGauge.Update(int64(hg.numBannedIPs(now)))
+}
+
+func (hg *handshakeGuard) numBannedIPs(now time.Time) int {
+	count := 0
+	for _, record := range hg.ips {
+		if now.Before(record.bannedUntil) {
+			count++
+		}
+	}
+	return count
+}
+
+// remoteIP returns the IP of the remote end of the connection
+func remoteIP(netconn net.Conn) string {
+	host, _, err := net.SplitHostPort(netconn.RemoteAddr().String())
+	if err != nil {
+		return netconn.RemoteAddr().String()
+	}
+	return host
+}
+
+func isLoopbackIP(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.IsLoopback()
+}
@@ -0,0 +1,101 @@
+package messenger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandshakeGuard(now time.Time) *handshakeGuard {
+	return newHandshakeGuard(handshakeGuardConfig{
+		maxHandshakesPerIP:          2,
+		maxNewConnections:           4,
+		maxPendingHandshakes:        3,
+		maxHandshakeFailures:        2,
+		handshakeFailureBanDuration: time.Minute,
+	}, now)
+}
+
+func TestHandshakeGuardRateLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	hg := newTestHandshakeGuard(now)
+
+	// Per IP limit
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", nil, now)
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", nil, now)
+	assert.Equal(errHandshakeRateLimited, hg.admit("10.0.0.1", now))
+
+	// Global new connection limit
+	assert.Nil(hg.admit("10.0.0.2", now))
+	hg.release("10.0.0.2", nil, now)
+	assert.Nil(hg.admit("10.0.0.3", now))
+	hg.release("10.0.0.3", nil, now)
+	assert.Equal(errConnectionThrottled, hg.admit("10.0.0.4", now))
+
+	// The limits refill over time
+	now = now.Add(time.Minute)
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", nil, now)
+
+	// Pending handshakes limit
+	now = now.Add(time.Minute)
+	assert.Nil(hg.admit("10.0.0.5", now))
+	assert.Nil(hg.admit("10.0.0.6", now))
+	assert.Nil(hg.admit("10.0.0.7", now))
+	assert.Equal(errTooManyPendingHandshakes, hg.admit("10.0.0.8", now))
+	hg.release("10.0.0.5", nil, now)
+	assert.Nil(hg.admit("10.0.0.8", now))
+}
+
+func TestHandshakeGuardBansFailingIPs(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	hg := newTestHandshakeGuard(now)
+	errHandshake := errors.New("handshake failed")
+
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", errHandshake, now)
+	now = now.Add(time.Minute)
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", errHandshake, now)
+	assert.Equal(errIPBanned, hg.admit("10.0.0.1", now))
+	assert.Equal(1, hg.numBannedIPs(now))
+
+	// The ban expires
+	now = now.Add(time.Minute + time.Second)
+	assert.Nil(hg.admit("10.0.0.1", now))
+	hg.release("10.0.0.1", nil, now)
+	assert.Equal(0, hg.numBannedIPs(now))
+
+	// The failures spread out in time are forgotten
+	assert.Nil(hg.admit("10.0.0.2", now))
+	hg.release("10.0.0.2", errHandshake, now)
+	now = now.Add(handshakeFailureWindow + time.Second)
+	assert.Nil(hg.admit("10.0.0.2", now))
+	hg.release("10.0.0.2", errHandshake, now)
+	assert.Nil(hg.admit("10.0.0.2", now))
+	hg.release("10.0.0.2", nil, now)
+}
+
+func TestHandshakeGuardExemptsLoopback(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+	hg := newTestHandshakeGuard(now)
+	errHandshake := errors.New("handshake failed")
+
+	for i := 0; i < 4; i++ {
+		assert.Nil(hg.admit("127.0.0.1", now))
+		hg.release("127.0.0.1", errHandshake, now)
+	}
+	// Still subject to the global limit
+	assert.Equal(errConnectionThrottled, hg.admit("127.0.0.1", now))
+	assert.Equal(0, len(hg.ips))
+}
@@ -2,6 +2,7 @@ package messenger
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
@@ -15,6 +16,10 @@ import (
 const (
 	defaultExternalPort = 7650
 	tryListenSeconds    = 5
+
+	// the port mappings of the NAT gateway are renewed this often, well before the mappings
+	// requested from the NAT-PMP gateways expire
+	natMappingRenewInterval = 30 * time.Minute
 )
 
 //
@@ -23,13 +28,16 @@ const (
 type InboundPeerListener struct {
 	discMgr *PeerDiscoveryManager
 
-	netListener  net.Listener
+	netListeners []net.Listener
 	internalAddr *netutil.NetAddress
 	externalAddr *netutil.NetAddress
+	natMapping   *natMapping
+	addrMutex    *sync.Mutex
 
 	inboundCallback InboundCallback
 
 	config InboundPeerListenerConfig
+	guard  *handshakeGuard
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -44,28 +52,56 @@ type InboundPeerListener struct {
 //
 type InboundPeerListenerConfig struct {
 	numBufferedConnections int
+	guard                  handshakeGuardConfig
 }
 
 // InboundCallback is called when an inbound peer is created
 type InboundCallback func(peer *pr.Peer, err error)
 
-// createInboundPeerListener creates a new inbound peer listener instance
-func createInboundPeerListener(discMgr *PeerDiscoveryManager, protocol string, localAddr string,
-	skipUPNP bool, config InboundPeerListenerConfig) (InboundPeerListener, error) {
-	localAddrIP, localAddrPort := splitHostPort(localAddr)
-	netListener := initiateNetListener(protocol, localAddr)
-	netListenerIP, netListenerPort := splitHostPort(netListener.Addr().String())
-	logger.Infof("Local network listener, ip: %v, port: %v", netListenerIP, netListenerPort)
+// natMapping is a port mapped through the NAT gateway
+type natMapping struct {
+	nat          netutil.NAT
+	externalPort int
+	internalPort int
+}
+
+// createInboundPeerListener creates a new inbound peer listener instance, listening on all the
+// given addresses. The internal and external addresses are those of the first advertised address.
+func createInboundPeerListener(discMgr *PeerDiscoveryManager, protocol string, listenAddrs []netutil.ListenAddress,
+	skipNAT bool, config InboundPeerListenerConfig) (InboundPeerListener, error) {
+	var advertisedAddr *netutil.ListenAddress
+	var advertisedListener net.Listener
+	netListeners := []net.Listener{}
+	for i, la := range listenAddrs {
+		netListener := initiateNetListener(la.Network(protocol), la.Addr())
+		netListenerIP, netListenerPort := splitHostPort(netListener.Addr().String())
+		logger.Infof("Local network listener, ip: %v, port: %v, advertised: %v", netListenerIP, netListenerPort, la.Advertise)
+		netListeners = append(netListeners, netListener)
+		if la.Advertise && advertisedAddr == nil {
+			advertisedAddr = &listenAddrs[i]
+			advertisedListener = netListener
+		}
+	}
+	if advertisedAddr == nil {
+		for _, l := range netListeners {
+			l.Close()
+		}
+		return InboundPeerListener{}, errors.New("None of the listen addresses is advertised")
+	}
 
-	internalNetAddr := getInternalNetAddress(localAddr)
-	externalNetAddr := getExternalNetAddress(localAddrIP, localAddrPort, netListenerPort, skipUPNP)
+	_, netListenerPort := splitHostPort(advertisedListener.Addr().String())
+	internalNetAddr := getInternalNetAddress(advertisedAddr.Addr())
+	externalNetAddr, mapping := getExternalNetAddress(*advertisedAddr, netListenerPort, skipNAT)
 
 	inboundPeerListener := InboundPeerListener{
 		discMgr:      discMgr,
-		netListener:  netListener,
+		netListeners: netListeners,
 		internalAddr: internalNetAddr,
 		externalAddr: externalNetAddr,
+		natMapping:   mapping,
+		addrMutex:    &sync.Mutex{},
 		config:       config,
+		guard:        newHandshakeGuard(config.guard, time.Now()),
 		wg:           &sync.WaitGroup{},
 	}
 
@@ -76,6 +112,7 @@ func createInboundPeerListener(discMgr *PeerDiscoveryManager, protocol string, l
 func GetDefaultInboundPeerListenerConfig() InboundPeerListenerConfig {
 	return InboundPeerListenerConfig{
 		numBufferedConnections: 10,
+		guard:                  getHandshakeGuardConfig(),
 	}
 }
 
@@ -85,15 +122,23 @@ func (ipl *InboundPeerListener) Start(ctx context.Context) error {
 	ipl.ctx = c
 	ipl.cancel = cancel
 
-	ipl.wg.Add(1)
-	go ipl.listenRoutine()
+	for _, netListener := range ipl.netListeners {
+		ipl.wg.Add(1)
+		go ipl.listenRoutine(netListener)
+	}
+	if ipl.natMapping != nil {
+		ipl.wg.Add(1)
+		go ipl.natMappingRoutine()
+	}
 
 	return nil
 }
 
 // Stop is called when the InboundPeerListener instance stops
 func (ipl *InboundPeerListener) Stop() {
-	ipl.netListener.Close()
+	for _, netListener := range ipl.netListeners {
+		netListener.Close()
+	}
 	ipl.cancel()
 }
 
@@ -107,18 +152,53 @@ func (ipl *InboundPeerListener) SetInboundCallback(incb InboundCallback) {
 	ipl.inboundCallback = incb
 }
 
-func (ipl *InboundPeerListener) listenRoutine() {
+func (ipl *InboundPeerListener) listenRoutine(netListener net.Listener) {
 	defer ipl.wg.Done()
 
 	for {
-		netconn, err := ipl.netListener.Accept()
+		netconn, err := netListener.Accept()
 		if err != nil {
 			logger.Fatalf("net listener error: %v", err)
 		}
 
-		peer, err := ipl.discMgr.connectWithInboundPeer(netconn, true)
-		if ipl.inboundCallback != nil {
-			ipl.inboundCallback(peer, err)
+		ip := remoteIP(netconn)
+		if err := ipl.guard.admit(ip, time.Now()); err != nil {
+			logger.Debugf("Rejected inbound connection from %v: %v", netconn.RemoteAddr(), err)
+			netconn.Close()
+			continue
+		}
+
+		// Handshake in the background, so that a slow peer does not hold up the other connections
+		ipl.wg.Add(1)
+		go func(netconn net.Conn) {
+			defer ipl.wg.Done()
+			peer, err := ipl.discMgr.connectWithInboundPeer(netconn, true)
+			ipl.guard.release(ip, err, time.Now())
+			if ipl.inboundCallback != nil {
+				ipl.inboundCallback(peer, err)
+			}
+		}(netconn)
+	}
+}
+
+// natMappingRoutine renews the port mapping of the NAT gateway, and deletes it once the listener stops
+func (ipl *InboundPeerListener) natMappingRoutine() {
+	defer ipl.wg.Done()
+
+	mapping := ipl.natMapping
+	ticker := time.NewTicker(natMappingRenewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ipl.ctx.Done():
+			if err := mapping.nat.DeletePortMapping("tcp", mapping.externalPort, mapping.internalPort); err != nil {
+				logger.Infof("Could not delete the NAT port mapping: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if _, err := mapping.nat.AddPortMapping("tcp", mapping.externalPort, mapping.internalPort, "theta", 0); err != nil {
+				logger.Warnf("Could not renew the NAT port mapping: %v", err)
+			}
 		}
 	}
 }
@@ -130,16 +210,39 @@ func (ipl *InboundPeerListener) InternalAddress() *netutil.NetAddress {
 
 // ExternalAddress returns the external address of the current node
 func (ipl *InboundPeerListener) ExternalAddress() *netutil.NetAddress {
+	ipl.addrMutex.Lock()
+	defer ipl.addrMutex.Unlock()
 	return ipl.externalAddr
 }
 
-// NetListener returns the attached network listener
+// MappedPort returns the external port mapped through the NAT gateway, if any
+func (ipl *InboundPeerListener) MappedPort() (uint16, bool) {
+	if ipl.natMapping == nil {
+		return 0, false
+	}
+	return uint16(ipl.natMapping.externalPort), true
+}
+
+// setExternalIP replaces the IP of the external address, e.g. with the IP the peers see the node at
+func (ipl *InboundPeerListener) setExternalIP(ip net.IP) *netutil.NetAddress {
+	ipl.addrMutex.Lock()
+	defer ipl.addrMutex.Unlock()
+	ipl.externalAddr = netutil.NewNetAddressIPPort(ip, ipl.externalAddr.Port)
+	return ipl.externalAddr
+}
+
+// NetListener returns the network listener of the first listen address
 func (ipl *InboundPeerListener) NetListener() net.Listener {
-	return ipl.netListener
+	return ipl.netListeners[0]
+}
+
+// NetListeners returns the network listeners of all the listen addresses
+func (ipl *InboundPeerListener) NetListeners() []net.Listener {
+	return ipl.netListeners
 }
 
 func (ipl *InboundPeerListener) String() string {
-	return fmt.Sprintf("InboundPeerListener(@%v)", ipl.externalAddr)
+	return fmt.Sprintf("InboundPeerListener(@%v)", ipl.ExternalAddress())
 }
 
 func splitHostPort(addr string) (host string, port int) {
@@ -179,69 +282,83 @@ func getInternalNetAddress(localAddr string) *netutil.NetAddress {
 	return internalAddr
 }
 
-func getExternalNetAddress(localAddrIP string, localAddrPort int, listenerPort int, skipUPNP bool) *netutil.NetAddress {
+func getExternalNetAddress(listenAddr netutil.ListenAddress, listenerPort int, skipNAT bool) (*netutil.NetAddress, *natMapping) {
 	var externalAddr *netutil.NetAddress
-	if !skipUPNP {
-		// If the lAddrIP is INADDR_ANY, try UPNP
-		if localAddrIP == "" || localAddrIP == "0.0.0.0" {
-			externalAddr = getUPNPExternalAddress(localAddrPort, listenerPort)
+	var mapping *natMapping
+	if !skipNAT {
+		// If the listen address is INADDR_ANY, try to map the port through the NAT gateway. The
+		// UPnP and NAT-PMP gateways only map IPv4 ports.
+		if listenAddr.IsUnspecified() && !listenAddr.IsIPv6() {
+			externalAddr, mapping = getNATExternalAddress(int(listenAddr.Port), listenerPort)
 		}
 	}
 	// Otherwise just use the local address
 	if externalAddr == nil {
-		externalAddr = getNaiveExternalAddress(listenerPort)
+		externalAddr = getNaiveExternalAddress(listenerPort, listenAddr.IsIPv6())
 	}
 	if externalAddr == nil {
 		logger.Fatalf("Could not determine external address!")
 	}
 
-	return externalAddr
+	return externalAddr, mapping
 }
 
-func getUPNPExternalAddress(externalPort, internalPort int) *netutil.NetAddress {
-	logger.Infof("Getting UPNP external address")
-	nat, err := netutil.Discover()
+// getNATExternalAddress maps the port through the UPnP or NAT-PMP gateway, and returns the
+// external address of the mapped port
+func getNATExternalAddress(externalPort, internalPort int) (*netutil.NetAddress, *natMapping) {
+	logger.Infof("Getting NAT external address")
+	nat, err := netutil.DiscoverNAT()
 	if err != nil {
-		logger.Infof("Could not perform UPNP discover: %v", err)
-		return nil
+		logger.Infof("Could not discover the NAT gateway: %v", err)
+		return nil, nil
 	}
 
 	ext, err := nat.GetExternalAddress()
 	if err != nil {
-		logger.Infof("Could not get UPNP external address: %v", err)
-		return nil
+		logger.Infof("Could not get NAT external address: %v", err)
+		return nil, nil
 	}
 
-	if externalPort == 0 { // Cannot get external port from UPNP, use the default port
+	if externalPort == 0 { // Cannot get external port from the gateway, use the default port
 		externalPort = defaultExternalPort
 	}
 
 	externalPort, err = nat.AddPortMapping("tcp", externalPort, internalPort, "theta", 0)
 	if err != nil {
-		logger.Infof("Could not add UPNP port mapping: %v", err)
-		return nil
+		logger.Infof("Could not add NAT port mapping: %v", err)
+		return nil, nil
 	}
 
-	logger.Infof("Got UPNP external address: %v", ext)
-	return netutil.NewNetAddressIPPort(ext, uint16(externalPort))
+	logger.Infof("Got NAT external address: %v:%v", ext, externalPort)
+	mapping := &natMapping{nat: nat, externalPort: externalPort, internalPort: internalPort}
+	return netutil.NewNetAddressIPPort(ext, uint16(externalPort)), mapping
 }
 
-func getNaiveExternalAddress(port int) *netutil.NetAddress {
+// getNaiveExternalAddress returns the first non-loopback IPv4 address of the interfaces, or the
+// first global IPv6 address on the hosts without IPv4. The IPv6 addresses are preferred if the
+// node only listens on IPv6.
+func getNaiveExternalAddress(port int, preferIPv6 bool) *netutil.NetAddress {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
 		logger.Fatalf("Could not fetch interface addresses: %v", err)
 	}
 
+	var v4Addr, v6Addr *netutil.NetAddress
 	for _, a := range addrs {
 		ipnet, ok := a.(*net.IPNet)
 		if !ok {
 			continue
 		}
-		v4 := ipnet.IP.To4()
-		if v4 == nil || v4[0] == 127 {
-			continue
-		} // loopback
-		return netutil.NewNetAddressIPPort(ipnet.IP, uint16(port))
+		if v4 := ipnet.IP.To4(); v4 != nil {
+			if v4[0] != 127 && v4Addr == nil { // skip loopback
+				v4Addr = netutil.NewNetAddressIPPort(ipnet.IP, uint16(port))
+			}
+		} else if ipnet.IP.IsGlobalUnicast() && v6Addr == nil {
+			v6Addr = netutil.NewNetAddressIPPort(ipnet.IP, uint16(port))
+		}
 	}
-	return nil
+	if v4Addr == nil || (preferIPv6 && v6Addr != nil) {
+		return v6Addr
+	}
+	return v4Addr
 }
@@ -24,6 +24,8 @@ type PeerDiscoveryMessageType byte
 const (
 	peerAddressesRequestType PeerDiscoveryMessageType = 0x01
 	peerAddressesReplyType   PeerDiscoveryMessageType = 0x02
+	peerExchangeRequestType  PeerDiscoveryMessageType = 0x03
+	peerExchangeReplyType    PeerDiscoveryMessageType = 0x04
 )
 
 const (
@@ -40,6 +42,7 @@ type PeerDiscoveryMessage struct {
 	Type         PeerDiscoveryMessageType
 	SourcePeerID string
 	Addresses    []pr.PeerIDAddress
+	PexAddresses []PexAddress `rlp:"tail"` // only for the PEX messages, omitted on the wire otherwise
 }
 
 //
@@ -51,6 +54,9 @@ type PeerDiscoveryMessageHandler struct {
 	peerDiscoveryPulse         *time.Ticker
 	peerDiscoveryPulseInterval time.Duration
 	discoveryCallback          InboundCallback
+	pexInterval                time.Duration
+	pexRequests                pexRequestTracker
+	redialInterval             time.Duration
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -65,7 +71,10 @@ func createPeerDiscoveryMessageHandler(discMgr *PeerDiscoveryManager, selfNetAdd
 	pdmh := PeerDiscoveryMessageHandler{
 		discMgr:                    discMgr,
 		peerDiscoveryPulseInterval: defaultPeerDiscoveryPulseInterval,
-		wg: &sync.WaitGroup{},
+		pexInterval:                defaultPexInterval,
+		pexRequests:                newPexRequestTracker(),
+		redialInterval:             defaultRedialInterval,
+		wg:                         &sync.WaitGroup{},
 	}
 	selfNetAddress, err := netutil.NewNetAddressString(selfNetAddressStr)
 	if err != nil {
@@ -85,6 +94,12 @@ func (pdmh *PeerDiscoveryMessageHandler) Start(ctx context.Context) error {
 	pdmh.wg.Add(1)
 	go pdmh.maintainSufficientConnectivityRoutine()
 
+	pdmh.wg.Add(1)
+	go pdmh.pexRoutine()
+
+	pdmh.wg.Add(1)
+	go pdmh.redialKnownGoodPeersRoutine()
+
 	return nil
 }
 
@@ -152,6 +167,10 @@ func (pdmh *PeerDiscoveryMessageHandler) HandleMessage(msg types.Message) error
 		pdmh.handlePeerAddressRequest(peer, discMsg)
 	case peerAddressesReplyType:
 		pdmh.handlePeerAddressReply(peer, discMsg)
+	case peerExchangeRequestType:
+		pdmh.handlePexRequest(peer, discMsg)
+	case peerExchangeReplyType:
+		pdmh.handlePexReply(peer, discMsg)
 	default:
 		errMsg := "Invalid PeerDiscoveryMessageType"
 		logger.Errorf(errMsg)
@@ -177,7 +196,8 @@ func (pdmh *PeerDiscoveryMessageHandler) handlePeerAddressReply(peer *pr.Peer, m
 			continue
 		}
 
-		if idAddr.Addr.Valid() && pdmh.discMgr.messenger.ID() != idAddr.ID && !pdmh.discMgr.peerTable.PeerExists(idAddr.ID) {
+		if idAddr.Addr.Valid() && pdmh.discMgr.messenger.ID() != idAddr.ID && !pdmh.discMgr.peerTable.PeerExists(idAddr.ID) &&
+			pdmh.discMgr.isOutboundGroupAllowed(idAddr.Addr) {
 			validAddressMap[idAddr.Addr] = true
 		}
 	}
@@ -255,9 +275,13 @@ func (pdmh *PeerDiscoveryMessageHandler) maintainSufficientConnectivity() {
 				peer := peers[perm[i]]
 				pdmh.requestAddresses(peer)
 			}
+			pdmh.connectToAddrBookPeers(int(GetDefaultPeerDiscoveryManagerConfig().SufficientNumPeers - numPeers))
+		}
+	} else { // no peer left in the peer table, try the address book first, then the seed peers
+		numDialed := pdmh.connectToAddrBookPeers(int(GetDefaultPeerDiscoveryManagerConfig().SufficientNumPeers))
+		if numDialed == 0 {
+			pdmh.discMgr.seedPeerConnector.connectToSeedPeers()
 		}
-	} else { // no peer left in the peer table, try to reconnect to seed peers
-		pdmh.discMgr.seedPeerConnector.connectToSeedPeers()
 	}
 }
 
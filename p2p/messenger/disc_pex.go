@@ -0,0 +1,188 @@
+package messenger
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/p2p/netutil"
+	pr "github.com/thetatoken/theta/p2p/peer"
+)
+
+const (
+	defaultPexInterval = 60 * time.Second
+
+	// addresses not successfully connected to within pexMaxAddressAge are not exchanged
+	pexMaxAddressAge = 3 * 24 * time.Hour
+
+	// tolerance for the clock drift between peers
+	pexMaxClockDrift = 10 * time.Minute
+
+	// replies to requests older than pexRequestTimeout are discarded
+	pexRequestTimeout = 30 * time.Second
+
+	// max number of address book entries dialed per connectivity check
+	pexMaxDialsPerRound = 8
+
+	// bias toward the addresses we have not yet connected to, in percentage
+	pexNewAddressBias = 30
+)
+
+//
+// PexAddress is a peer address exchanged through the PEX protocol
+//
+type PexAddress struct {
+	Addr        *netutil.NetAddress
+	LastSuccess uint64 // unix time (seconds) of the last successful connection
+	LastAttempt uint64 // unix time (seconds) of the last connection attempt
+}
+
+func newPexAddress(ka *knownAddress) PexAddress {
+	return PexAddress{
+		Addr:        ka.Addr,
+		LastSuccess: uint64(ka.LastSuccess.Unix()),
+		LastAttempt: uint64(ka.LastAttempt.Unix()),
+	}
+}
+
+func isFreshPexTime(t time.Time) bool {
+	now := time.Now()
+	return t.After(now.Add(-pexMaxAddressAge)) && t.Before(now.Add(pexMaxClockDrift))
+}
+
+//
+// pexRequestTracker keeps track of the outstanding PEX requests, so that unsolicited
+// replies can be rejected
+//
+type pexRequestTracker struct {
+	mutex    *sync.Mutex
+	requests map[string]time.Time // map: peerID |-> time of the request
+}
+
+func newPexRequestTracker() pexRequestTracker {
+	return pexRequestTracker{
+		mutex:    &sync.Mutex{},
+		requests: make(map[string]time.Time),
+	}
+}
+
+func (prt pexRequestTracker) add(peerID string) {
+	prt.mutex.Lock()
+	defer prt.mutex.Unlock()
+	prt.requests[peerID] = time.Now()
+}
+
+// remove returns whether there was an outstanding request to the peer
+func (prt pexRequestTracker) remove(peerID string) bool {
+	prt.mutex.Lock()
+	defer prt.mutex.Unlock()
+	requestTime, ok := prt.requests[peerID]
+	if !ok {
+		return false
+	}
+	delete(prt.requests, peerID)
+	return time.Since(requestTime) <= pexRequestTimeout
+}
+
+func (pdmh *PeerDiscoveryMessageHandler) pexRoutine() {
+	defer pdmh.wg.Done()
+
+	pexTicker := time.NewTicker(pdmh.pexInterval)
+	defer pexTicker.Stop()
+	for {
+		select {
+		case <-pdmh.ctx.Done():
+			return
+		case <-pexTicker.C:
+			pdmh.requestPexAddressesFromRandomPeer()
+		}
+	}
+}
+
+func (pdmh *PeerDiscoveryMessageHandler) requestPexAddressesFromRandomPeer() {
+	peers := *(pdmh.discMgr.peerTable.GetAllPeers())
+	if len(peers) == 0 {
+		return
+	}
+	pdmh.requestPexAddresses(peers[rand.Intn(len(peers))])
+}
+
+func (pdmh *PeerDiscoveryMessageHandler) requestPexAddresses(peer *pr.Peer) {
+	message := PeerDiscoveryMessage{
+		Type: peerExchangeRequestType,
+	}
+	pdmh.pexRequests.add(peer.ID())
+	peer.Send(common.ChannelIDPeerDiscovery, message)
+}
+
+func (pdmh *PeerDiscoveryMessageHandler) handlePexRequest(peer *pr.Peer, message PeerDiscoveryMessage) {
+	reply := PeerDiscoveryMessage{
+		Type:         peerExchangeReplyType,
+		PexAddresses: pdmh.discMgr.addrBook.GetPexSelection(),
+	}
+	peer.Send(common.ChannelIDPeerDiscovery, reply)
+}
+
+func (pdmh *PeerDiscoveryMessageHandler) handlePexReply(peer *pr.Peer, message PeerDiscoveryMessage) {
+	if !pdmh.pexRequests.remove(peer.ID()) {
+		logger.Warnf("Discard unsolicited PEX reply from peer %v", peer.ID())
+		return
+	}
+	numAdded := pdmh.discMgr.addrBook.AddPexAddresses(message.PexAddresses, peer.NetAddress())
+	logger.Debugf("Received %v PEX addresses from peer %v, %v added to the address book",
+		len(message.PexAddresses), peer.ID(), numAdded)
+}
+
+// connectToAddrBookPeers dials up to numNeeded addresses picked from the address book.
+// Returns the number of addresses dialed.
+func (pdmh *PeerDiscoveryMessageHandler) connectToAddrBookPeers(numNeeded int) int {
+	if seedPeerOnlyOutbound() || numNeeded <= 0 {
+		return 0
+	}
+	if numNeeded > pexMaxDialsPerRound {
+		numNeeded = pexMaxDialsPerRound
+	}
+
+	addrBook := pdmh.discMgr.addrBook
+	connected := make(map[string]bool)
+	for _, peer := range *(pdmh.discMgr.peerTable.GetAllPeers()) {
+		connected[peer.NetAddress().String()] = true
+	}
+
+	picked := make(map[string]*netutil.NetAddress)
+	pickedGroups := make(map[string]bool) // dial at most one address per group in each round
+	for i := 0; i < 3*numNeeded && len(picked) < numNeeded; i++ {
+		addr := addrBook.PickAddress(pexNewAddressBias)
+		if addr == nil {
+			break
+		}
+		if connected[addr.String()] || addr.Equals(&pdmh.selfNetAddress) ||
+			pdmh.discMgr.seedPeerConnector.isASeedPeer(addr) || !pdmh.discMgr.isOutboundGroupAllowed(addr) {
+			continue
+		}
+		group := addrBook.groupKey(addr)
+		if addr.Routable() && pickedGroups[group] {
+			continue
+		}
+		pickedGroups[group] = true
+		picked[addr.String()] = addr
+	}
+
+	for _, addr := range picked {
+		go func(addr *netutil.NetAddress) {
+			time.Sleep(time.Duration(rand.Int63n(discoverInterval)) * time.Millisecond)
+			addrBook.MarkAttempt(addr)
+			peer, err := pdmh.discMgr.connectToOutboundPeer(addr, true)
+			if err != nil {
+				logger.Warnf("Failed to connect to address book peer %v: %v", addr.String(), err)
+			} else {
+				logger.Infof("Successfully connected to address book peer %v", addr.String())
+			}
+			if pdmh.discoveryCallback != nil {
+				pdmh.discoveryCallback(peer, err)
+			}
+		}(addr)
+	}
+	return len(picked)
+}
@@ -0,0 +1,67 @@
+package messenger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/p2p/netutil"
+	pr "github.com/thetatoken/theta/p2p/peer"
+	"github.com/thetatoken/theta/rlp"
+)
+
+func TestPexMessageEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	addr, err := netutil.NewNetAddressString("104.25.10.1:50001")
+	assert.Nil(err)
+
+	// The PEX addresses are omitted on the wire for the other message types, so
+	// the encoding of the address reply remains unchanged
+	type legacyPeerDiscoveryMessage struct {
+		Type         PeerDiscoveryMessageType
+		SourcePeerID string
+		Addresses    []pr.PeerIDAddress
+	}
+	legacyBytes, err := rlp.EncodeToBytes(legacyPeerDiscoveryMessage{
+		Type:      peerAddressesReplyType,
+		Addresses: []pr.PeerIDAddress{{ID: "peer1", Addr: addr}},
+	})
+	assert.Nil(err)
+	msg, err := decodePeerDiscoveryMessage(legacyBytes)
+	assert.Nil(err)
+	assert.Equal(peerAddressesReplyType, msg.Type)
+	assert.Equal(1, len(msg.Addresses))
+	assert.Equal(0, len(msg.PexAddresses))
+
+	pexReply := PeerDiscoveryMessage{
+		Type: peerExchangeReplyType,
+		PexAddresses: []PexAddress{
+			{Addr: addr, LastSuccess: 1000, LastAttempt: 2000},
+		},
+	}
+	pexBytes, err := rlp.EncodeToBytes(pexReply)
+	assert.Nil(err)
+	msg, err = decodePeerDiscoveryMessage(pexBytes)
+	assert.Nil(err)
+	assert.Equal(peerExchangeReplyType, msg.Type)
+	assert.Equal(1, len(msg.PexAddresses))
+	assert.True(addr.Equals(msg.PexAddresses[0].Addr))
+	assert.Equal(uint64(1000), msg.PexAddresses[0].LastSuccess)
+	assert.Equal(uint64(2000), msg.PexAddresses[0].LastAttempt)
+}
+
+func TestPexRequestTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	tracker := newPexRequestTracker()
+	assert.False(tracker.remove("peer1")) // unsolicited
+
+	tracker.add("peer1")
+	assert.True(tracker.remove("peer1"))
+	assert.False(tracker.remove("peer1")) // replied already
+
+	tracker.add("peer2")
+	tracker.requests["peer2"] = time.Now().Add(-2 * pexRequestTimeout)
+	assert.False(tracker.remove("peer2")) // expired
+}
@@ -0,0 +1,88 @@
+package messenger
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/thetatoken/theta/p2p/netutil"
+)
+
+const (
+	// interval of checking whether the known good peers need to be redialed
+	defaultRedialInterval = 2 * time.Minute
+
+	// max number of known good peers redialed in each round
+	maxRedialsPerRound = 4
+)
+
+// redialKnownGoodPeersRoutine periodically redials the peers the node has successfully connected to
+// before, so that the node recovers its outbound connectivity after e.g. a network outage, without
+// relying on the seeds or on the addresses learned from the current peers.
+func (pdmh *PeerDiscoveryMessageHandler) redialKnownGoodPeersRoutine() {
+	defer pdmh.wg.Done()
+
+	redialTicker := time.NewTicker(pdmh.redialInterval)
+	defer redialTicker.Stop()
+	for {
+		select {
+		case <-pdmh.ctx.Done():
+			return
+		case <-redialTicker.C:
+			pdmh.redialKnownGoodPeers()
+		}
+	}
+}
+
+// redialKnownGoodPeers dials the freshest known good addresses of the address book when the node
+// has fewer than minNumOutboundPeers outbound peers. Returns the number of addresses dialed.
+func (pdmh *PeerDiscoveryMessageHandler) redialKnownGoodPeers() int {
+	if seedPeerOnlyOutbound() {
+		return 0
+	}
+
+	connected := make(map[string]bool)
+	numOutbound := 0
+	for _, peer := range *(pdmh.discMgr.peerTable.GetAllPeers()) {
+		connected[peer.NetAddress().String()] = true
+		if peer.IsOutbound() {
+			numOutbound++
+		}
+	}
+	numNeeded := minNumOutboundPeers - numOutbound
+	if numNeeded <= 0 {
+		return 0
+	}
+	if numNeeded > maxRedialsPerRound {
+		numNeeded = maxRedialsPerRound
+	}
+
+	addrBook := pdmh.discMgr.addrBook
+	picked := []*netutil.NetAddress{}
+	for _, addr := range addrBook.GetKnownGoodAddresses(3 * numNeeded) {
+		if len(picked) >= numNeeded {
+			break
+		}
+		if connected[addr.String()] || addr.Equals(&pdmh.selfNetAddress) ||
+			pdmh.discMgr.seedPeerConnector.isASeedPeer(addr) || !pdmh.discMgr.isOutboundGroupAllowed(addr) {
+			continue
+		}
+		picked = append(picked, addr)
+	}
+
+	for _, addr := range picked {
+		go func(addr *netutil.NetAddress) {
+			time.Sleep(time.Duration(rand.Int63n(discoverInterval)) * time.Millisecond)
+			addrBook.MarkAttempt(addr)
+			peer, err := pdmh.discMgr.connectToOutboundPeer(addr, true)
+			if err != nil {
+				logger.Warnf("Failed to redial known good peer %v: %v", addr.String(), err)
+			} else {
+				logger.Infof("Successfully redialed known good peer %v", addr.String())
+			}
+			if pdmh.discoveryCallback != nil {
+				pdmh.discoveryCallback(peer, err)
+			}
+		}(addr)
+	}
+	return len(picked)
+}
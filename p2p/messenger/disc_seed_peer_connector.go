@@ -3,9 +3,14 @@ package messenger
 import (
 	"context"
 	"math/rand"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/spf13/viper"
+
+	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/p2p/netutil"
 )
 
@@ -15,8 +20,11 @@ import (
 type SeedPeerConnector struct {
 	discMgr *PeerDiscoveryManager
 
-	selfNetAddress       netutil.NetAddress
-	seedPeerNetAddresses []netutil.NetAddress
+	selfNetAddress               netutil.NetAddress
+	seedPeerNetAddresses         []netutil.NetAddress
+	dnsSeeds                     *netutil.DNSSeedResolver
+	fallbackSeedPeerNetAddresses []netutil.NetAddress
+	useFallbackSeeds             bool
 
 	Connected chan bool
 
@@ -57,6 +65,24 @@ func createSeedPeerConnector(discMgr *PeerDiscoveryManager,
 		spc.seedPeerNetAddresses = append(spc.seedPeerNetAddresses, *seedNetAddress)
 	}
 
+	dnsSeedStrs := strings.FieldsFunc(viper.GetString(common.CfgP2PDNSSeeds), func(c rune) bool {
+		return c == ','
+	})
+	spc.dnsSeeds = netutil.NewDNSSeedResolver(dnsSeedStrs, selfNetAddress.Port)
+
+	spc.useFallbackSeeds = viper.GetBool(common.CfgP2PUseFallbackSeeds)
+	for _, fallbackSeedStr := range fallbackSeeds {
+		fallbackNetAddress, err := netutil.NewNetAddressString(fallbackSeedStr)
+		if err != nil {
+			logger.Warnf("Failed to parse the fallback seed network address: %v", fallbackSeedStr)
+			continue
+		}
+		if fallbackNetAddress.Equals(selfNetAddress) {
+			continue
+		}
+		spc.fallbackSeedPeerNetAddresses = append(spc.fallbackSeedPeerNetAddresses, *fallbackNetAddress)
+	}
+
 	return spc, nil
 }
 
@@ -86,28 +112,114 @@ func (spc *SeedPeerConnector) isASeedPeer(netAddr *netutil.NetAddress) bool {
 			return true
 		}
 	}
-	return false
+	for _, seedAddr := range spc.fallbackSeedPeerNetAddresses {
+		if netAddr.Equals(&seedAddr) {
+			return true
+		}
+	}
+	return spc.dnsSeeds.Contains(netAddr)
 }
 
 func (spc *SeedPeerConnector) connectToSeedPeers() {
 	logger.Infof("Connecting to seed peers...")
+	numConnected := int32(0)
+	attempts := &sync.WaitGroup{}
+
 	perm := rand.Perm(len(spc.seedPeerNetAddresses))
 	for i := 0; i < len(perm); i++ { // create outbound peers in a random order
 		spc.wg.Add(1)
+		attempts.Add(1)
 		go func(i int) {
 			defer spc.wg.Done()
+			defer attempts.Done()
 
 			time.Sleep(time.Duration(rand.Int63n(3000)) * time.Millisecond)
 			j := perm[i]
 			peerNetAddress := spc.seedPeerNetAddresses[j]
 			_, err := spc.discMgr.connectToOutboundPeer(&peerNetAddress, true)
 			if err != nil {
-				spc.Connected <- false
+				spc.notifyConnected(false)
 				logger.Warnf("Failed to connect to seed peer %v: %v", peerNetAddress.String(), err)
 			} else {
-				spc.Connected <- true
+				atomic.AddInt32(&numConnected, 1)
+				spc.notifyConnected(true)
 				logger.Infof("Successfully connected to seed peer %v", peerNetAddress.String())
 			}
 		}(i)
 	}
+
+	for _, dnsSeedNetAddress := range spc.dnsSeedNetAddresses() {
+		spc.wg.Add(1)
+		attempts.Add(1)
+		go func(peerNetAddress *netutil.NetAddress) {
+			defer spc.wg.Done()
+			defer attempts.Done()
+
+			time.Sleep(time.Duration(rand.Int63n(3000)) * time.Millisecond)
+			_, err := spc.discMgr.connectToOutboundPeer(peerNetAddress, true)
+			if err != nil {
+				logger.Warnf("Failed to connect to DNS seed peer %v: %v", peerNetAddress.String(), err)
+			} else {
+				atomic.AddInt32(&numConnected, 1)
+				logger.Infof("Successfully connected to DNS seed peer %v", peerNetAddress.String())
+			}
+		}(dnsSeedNetAddress)
+	}
+
+	if !spc.useFallbackSeeds || len(spc.fallbackSeedPeerNetAddresses) == 0 {
+		return
+	}
+	spc.wg.Add(1)
+	go func() {
+		defer spc.wg.Done()
+		attempts.Wait()
+		if atomic.LoadInt32(&numConnected) == 0 {
+			spc.connectToFallbackSeedPeers()
+		}
+	}()
+}
+
+// notifyConnected reports the result of a seed peer connection attempt. The result is dropped
+// if no one consumes the Connected channel, so that the reconnection attempts never block.
+func (spc *SeedPeerConnector) notifyConnected(connected bool) {
+	select {
+	case spc.Connected <- connected:
+	default:
+	}
+}
+
+// dnsSeedNetAddresses returns the addresses resolved from the DNS seeds, excluding the
+// ones already configured as seeds
+func (spc *SeedPeerConnector) dnsSeedNetAddresses() []*netutil.NetAddress {
+	addrs := []*netutil.NetAddress{}
+	for _, addr := range spc.dnsSeeds.Addresses() {
+		duplicated := addr.Equals(&spc.selfNetAddress)
+		for _, seedAddr := range spc.seedPeerNetAddresses {
+			duplicated = duplicated || addr.Equals(&seedAddr)
+		}
+		if !duplicated {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// connectToFallbackSeedPeers connects to the built-in bootstrap peers, so that a fresh node
+// can still join the network when none of the configured seeds is reachable
+func (spc *SeedPeerConnector) connectToFallbackSeedPeers() {
+	logger.Infof("None of the seed peers is reachable, connecting to the fallback seed peers...")
+	for _, fallbackSeedNetAddress := range spc.fallbackSeedPeerNetAddresses {
+		spc.wg.Add(1)
+		go func(peerNetAddress netutil.NetAddress) {
+			defer spc.wg.Done()
+
+			time.Sleep(time.Duration(rand.Int63n(3000)) * time.Millisecond)
+			_, err := spc.discMgr.connectToOutboundPeer(&peerNetAddress, true)
+			if err != nil {
+				logger.Warnf("Failed to connect to fallback seed peer %v: %v", peerNetAddress.String(), err)
+			} else {
+				logger.Infof("Successfully connected to fallback seed peer %v", peerNetAddress.String())
+			}
+		}(fallbackSeedNetAddress)
+	}
 }
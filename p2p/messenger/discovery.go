@@ -3,10 +3,12 @@ package messenger
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/thetatoken/theta/crypto"
 	cn "github.com/thetatoken/theta/p2p/connection"
 	"github.com/thetatoken/theta/p2p/netutil"
 	pr "github.com/thetatoken/theta/p2p/peer"
@@ -28,6 +30,11 @@ type PeerDiscoveryManager struct {
 	peerDiscMsgHandler  PeerDiscoveryMessageHandler // pro-actively connect to peer candidates obtained from connected peers
 	inboundPeerListener InboundPeerListener         // listen to incoming peering requests
 
+	externalIPs *externalIPDetector // learns the external IP of the node from the peers
+
+	nodeKey         *crypto.PrivateKey
+	secureTransport string
+
 	// Life cycle
 	wg      *sync.WaitGroup
 	quit    chan struct{}
@@ -42,19 +49,32 @@ type PeerDiscoveryManager struct {
 type PeerDiscoveryManagerConfig struct {
 	MaxNumPeers        uint
 	SufficientNumPeers uint
+
+	// ListenAddresses are the addresses to accept the inbound peers on. The local network
+	// address is listened on if empty.
+	ListenAddresses []netutil.ListenAddress
+
+	// NodeKey is the key of the node, which authenticates the secure transport with the peers
+	NodeKey *crypto.PrivateKey
+	// SecureTransport is the mode of the secure transport, see peer.SecureTransportOptional
+	SecureTransport string
 }
 
 // CreatePeerDiscoveryManager creates an instance of the PeerDiscoveryManager
 func CreatePeerDiscoveryManager(msgr *Messenger, nodeInfo *p2ptypes.NodeInfo, addrBookFilePath string,
 	routabilityRestrict bool, seedPeerNetAddresses []string,
-	networkProtocol string, localNetworkAddr string, skipUPNP bool, peerTable *pr.PeerTable,
+	networkProtocol string, localNetworkAddr string, skipNAT bool, peerTable *pr.PeerTable,
 	config PeerDiscoveryManagerConfig) (*PeerDiscoveryManager, error) {
 
 	discMgr := &PeerDiscoveryManager{
-		messenger: msgr,
-		nodeInfo:  nodeInfo,
-		peerTable: peerTable,
-		wg:        &sync.WaitGroup{},
+		messenger:   msgr,
+		nodeInfo:    nodeInfo,
+		peerTable:   peerTable,
+		externalIPs: newExternalIPDetector(),
+		wg:          &sync.WaitGroup{},
+
+		nodeKey:         config.NodeKey,
+		secureTransport: config.SecureTransport,
 	}
 
 	discMgr.addrBook = NewAddrBook(addrBookFilePath, routabilityRestrict)
@@ -70,11 +90,25 @@ func CreatePeerDiscoveryManager(msgr *Messenger, nodeInfo *p2ptypes.NodeInfo, ad
 		return discMgr, err
 	}
 
+	listenAddrs := config.ListenAddresses
+	if len(listenAddrs) == 0 {
+		localListenAddr, err := netutil.ParseListenAddress(localNetworkAddr)
+		if err != nil {
+			return discMgr, err
+		}
+		listenAddrs = []netutil.ListenAddress{localListenAddr}
+	}
 	inlConfig := GetDefaultInboundPeerListenerConfig()
-	discMgr.inboundPeerListener, err = createInboundPeerListener(discMgr, networkProtocol, localNetworkAddr, skipUPNP, inlConfig)
+	discMgr.inboundPeerListener, err = createInboundPeerListener(discMgr, networkProtocol, listenAddrs, skipNAT, inlConfig)
 	if err != nil {
 		return discMgr, err
 	}
+	// Advertise the port mapped through the NAT gateway in the handshakes, since the peers can only
+	// reach the listener through it
+	if mappedPort, ok := discMgr.inboundPeerListener.MappedPort(); ok {
+		nodeInfo.Port = mappedPort
+	}
+	discMgr.addrBook.AddOurAddress(discMgr.inboundPeerListener.ExternalAddress())
 	discMgr.inboundPeerListener.SetInboundCallback(func(peer *pr.Peer, err error) {
 		if err == nil {
 			logger.Infof("Inbound peer connected, ID: %v, from: %v", peer.ID(), peer.GetConnection().GetNetconn().RemoteAddr())
@@ -91,6 +125,7 @@ func GetDefaultPeerDiscoveryManagerConfig() PeerDiscoveryManagerConfig {
 	return PeerDiscoveryManagerConfig{
 		MaxNumPeers:        128,
 		SufficientNumPeers: 32,
+		SecureTransport:    pr.SecureTransportOptional,
 	}
 }
 
@@ -105,6 +140,9 @@ func (discMgr *PeerDiscoveryManager) Start(ctx context.Context) error {
 	discMgr.ctx = c
 	discMgr.cancel = cancel
 
+	discMgr.addrBook.OnStart()
+	discMgr.connectToAnchors()
+
 	var err error
 	err = discMgr.seedPeerConnector.Start(c)
 	if err != nil {
@@ -126,6 +164,8 @@ func (discMgr *PeerDiscoveryManager) Start(ctx context.Context) error {
 
 // Stop is called when the PeerDiscoveryManager stops
 func (discMgr *PeerDiscoveryManager) Stop() {
+	discMgr.saveAnchors()
+	discMgr.addrBook.Save()
 	discMgr.cancel()
 }
 
@@ -160,6 +200,10 @@ func (discMgr *PeerDiscoveryManager) HandlePeerWithErrors(peer *pr.Peer) {
 	}
 
 	discMgr.peerTable.DeletePeer(peer.ID())
+	if discMgr.messenger != nil {
+		discMgr.messenger.reputation.Forget(peer.ID())
+	}
+	discMgr.externalIPs.forget(remoteIP(peer.GetConnection().GetNetconn()))
 	peer.Stop() // TODO: may need to stop peer regardless of the remote address comparison
 
 	if peer.IsPersistent() {
@@ -182,9 +226,28 @@ func (discMgr *PeerDiscoveryManager) HandlePeerWithErrors(peer *pr.Peer) {
 	}
 }
 
+// DisconnectPeer disconnects the peer without reconnecting, e.g. when it is banned
+func (discMgr *PeerDiscoveryManager) DisconnectPeer(peer *pr.Peer) {
+	if lookedUpPeer := discMgr.peerTable.GetPeer(peer.ID()); lookedUpPeer == peer {
+		discMgr.peerTable.DeletePeer(peer.ID())
+	}
+	peer.Stop()
+}
+
+// getPeerConfig returns the config of the peers the node connects with
+func (discMgr *PeerDiscoveryManager) getPeerConfig() pr.PeerConfig {
+	peerConfig := pr.GetDefaultPeerConfig()
+	peerConfig.HandshakeTimeout = getHandshakeTimeout()
+	peerConfig.NodeKey = discMgr.nodeKey
+	if len(discMgr.secureTransport) != 0 {
+		peerConfig.SecureTransport = discMgr.secureTransport
+	}
+	return peerConfig
+}
+
 func (discMgr *PeerDiscoveryManager) connectToOutboundPeer(peerNetAddress *netutil.NetAddress, persistent bool) (*pr.Peer, error) {
 	logger.Infof("Connecting to outbound peer: %v...", peerNetAddress)
-	peerConfig := pr.GetDefaultPeerConfig()
+	peerConfig := discMgr.getPeerConfig()
 	connConfig := cn.GetDefaultConnectionConfig()
 	peer, err := pr.CreateOutboundPeer(peerNetAddress, peerConfig, connConfig)
 	if err != nil {
@@ -198,7 +261,7 @@ func (discMgr *PeerDiscoveryManager) connectToOutboundPeer(peerNetAddress *netut
 
 func (discMgr *PeerDiscoveryManager) connectWithInboundPeer(netconn net.Conn, persistent bool) (*pr.Peer, error) {
 	logger.Infof("Connecting with inbound peer: %v...", netconn.RemoteAddr())
-	peerConfig := pr.GetDefaultPeerConfig()
+	peerConfig := discMgr.getPeerConfig()
 	connConfig := cn.GetDefaultConnectionConfig()
 	peer, err := pr.CreateInboundPeer(netconn, peerConfig, connConfig)
 	if err != nil {
@@ -218,6 +281,14 @@ func (discMgr *PeerDiscoveryManager) handshakeAndAddPeer(peer *pr.Peer) error {
 		return err
 	}
 
+	if discMgr.messenger != nil && discMgr.messenger.IsBanned(peer.ID()) {
+		peer.GetConnection().GetNetconn().Close()
+		logger.Infof("Rejected banned peer %v", peer.ID())
+		return fmt.Errorf("Peer %v is banned", peer.ID())
+	}
+
+	discMgr.updateExternalIP(peer)
+
 	if discMgr.messenger != nil {
 		discMgr.messenger.AttachMessageHandlersToPeer(peer)
 	} else {
@@ -237,7 +308,22 @@ func (discMgr *PeerDiscoveryManager) handshakeAndAddPeer(peer *pr.Peer) error {
 	}
 
 	discMgr.addrBook.AddAddress(peer.NetAddress(), peer.NetAddress())
+	if peer.IsOutbound() {
+		discMgr.handleOutboundPeerAdded(peer)
+	}
 	discMgr.addrBook.Save()
 
 	return nil
 }
+
+// updateExternalIP records the IP the peer sees the node at, and advertises the external IP of the
+// node once enough peers agree on it
+func (discMgr *PeerDiscoveryManager) updateExternalIP(peer *pr.Peer) {
+	ip, changed := discMgr.externalIPs.record(remoteIP(peer.GetConnection().GetNetconn()), peer.ObservedIP())
+	if !changed {
+		return
+	}
+	externalAddr := discMgr.inboundPeerListener.setExternalIP(net.ParseIP(ip))
+	discMgr.addrBook.AddOurAddress(externalAddr)
+	logger.Infof("Detected the external address of the node from the peers: %v", externalAddr)
+}
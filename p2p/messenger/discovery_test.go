@@ -359,12 +359,12 @@ func newTestPeerDiscoveryManager(seedPeerNetAddressStrs []string, localNetworkAd
 	addrbookPath := "./.addrbooks/addrbook_" + localNetworkAddress + ".json"
 	routabilityRestrict := false
 	networkProtocol := "tcp"
-	skipUPNP := true
+	skipNAT := true
 	peerTable := pr.CreatePeerTable()
 	config := GetDefaultPeerDiscoveryManagerConfig()
 	discMgr, err := CreatePeerDiscoveryManager(messenger, &peerNodeInfo, addrbookPath, routabilityRestrict,
 		seedPeerNetAddressStrs, networkProtocol, localNetworkAddress,
-		skipUPNP, &peerTable, config)
+		skipNAT, &peerTable, config)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create PeerDiscoveryManager instance: %v", err))
 	}
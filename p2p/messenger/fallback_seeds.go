@@ -0,0 +1,6 @@
+package messenger
+
+// fallbackSeeds lists the bootstrap peers ("IP:Port") shipped with the release. They are
+// dialed only when none of the configured seeds or DNS seeds is reachable. The list should
+// be refreshed for each release.
+var fallbackSeeds = []string{}
@@ -10,23 +10,31 @@ import (
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/p2p"
+	cn "github.com/thetatoken/theta/p2p/connection"
+	"github.com/thetatoken/theta/p2p/netutil"
 	pr "github.com/thetatoken/theta/p2p/peer"
+	"github.com/thetatoken/theta/p2p/reputation"
 	p2ptypes "github.com/thetatoken/theta/p2p/types"
 )
 
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "p2p"})
 
+// throttledRecvRate is the receive rate (bytes/s) of the throttled peers
+const throttledRecvRate = int64(32 * 1024)
+
 //
 // Messenger implements the Network interface
 //
 var _ p2p.Network = (*Messenger)(nil)
+var _ p2p.PeerReporter = (*Messenger)(nil)
 
 type Messenger struct {
 	discMgr       *PeerDiscoveryManager
 	msgHandlerMap map[common.ChannelIDEnum](p2p.MessageHandler)
 
-	peerTable pr.PeerTable
-	nodeInfo  p2ptypes.NodeInfo // information of our blockchain node
+	peerTable  pr.PeerTable
+	nodeInfo   p2ptypes.NodeInfo // information of our blockchain node
+	reputation *reputation.Tracker
 
 	config MessengerConfig
 
@@ -44,28 +52,57 @@ type Messenger struct {
 type MessengerConfig struct {
 	addrBookFilePath    string
 	routabilityRestrict bool
-	skipUPNP            bool
+	skipNAT             bool
 	networkProtocol     string
+	listenAddresses     []netutil.ListenAddress
+	nodeKey             *crypto.PrivateKey
+	secureTransport     string
+	banListFilePath     string
+	reputationConfig    reputation.Config
 }
 
-// CreateMessenger creates an instance of Messenger
+// CreateMessenger creates an instance of Messenger. It listens on the given port of all the
+// interfaces, unless the listen addresses are set in the config, in which case the port is
+// replaced by the advertised port of the listen addresses.
 func CreateMessenger(pubKey *crypto.PublicKey, seedPeerNetAddresses []string,
 	port int, msgrConfig MessengerConfig) (*Messenger, error) {
 
+	listenAddrs := msgrConfig.listenAddresses
+	if len(listenAddrs) == 0 {
+		listenAddrs = []netutil.ListenAddress{{Port: uint16(port), Advertise: true}}
+	} else {
+		advertisedPort, err := netutil.AdvertisedPort(listenAddrs)
+		if err != nil {
+			return nil, err
+		}
+		port = int(advertisedPort)
+	}
+
+	reputationConfig := msgrConfig.reputationConfig
+	if reputationConfig == (reputation.Config{}) {
+		reputationConfig = reputation.GetDefaultConfig()
+	}
+
 	messenger := &Messenger{
 		msgHandlerMap: make(map[common.ChannelIDEnum](p2p.MessageHandler)),
 		peerTable:     pr.CreatePeerTable(),
 		nodeInfo:      p2ptypes.CreateNodeInfo(pubKey, uint16(port)),
+		reputation:    reputation.NewTracker(msgrConfig.banListFilePath, reputationConfig),
 		config:        msgrConfig,
 		wg:            &sync.WaitGroup{},
 	}
 
 	localNetAddress := "0.0.0.0:" + strconv.Itoa(port)
 	discMgrConfig := GetDefaultPeerDiscoveryManagerConfig()
+	discMgrConfig.ListenAddresses = listenAddrs
+	discMgrConfig.NodeKey = msgrConfig.nodeKey
+	if len(msgrConfig.secureTransport) != 0 {
+		discMgrConfig.SecureTransport = msgrConfig.secureTransport
+	}
 	discMgr, err := CreatePeerDiscoveryManager(messenger, &(messenger.nodeInfo),
 		msgrConfig.addrBookFilePath, msgrConfig.routabilityRestrict,
 		seedPeerNetAddresses, msgrConfig.networkProtocol,
-		localNetAddress, msgrConfig.skipUPNP, &messenger.peerTable, discMgrConfig)
+		localNetAddress, msgrConfig.skipNAT, &messenger.peerTable, discMgrConfig)
 	if err != nil {
 		logger.Errorf("Failed to create CreatePeerDiscoveryManager")
 		return messenger, err
@@ -83,8 +120,10 @@ func GetDefaultMessengerConfig() MessengerConfig {
 	return MessengerConfig{
 		addrBookFilePath:    "./.addrbook/addrbook.json",
 		routabilityRestrict: false,
-		skipUPNP:            false,
+		skipNAT:             false,
 		networkProtocol:     "tcp",
+		banListFilePath:     "./.addrbook/banlist.json",
+		reputationConfig:    reputation.GetDefaultConfig(),
 	}
 }
 
@@ -141,6 +180,22 @@ func (msgr *Messenger) Send(peerID string, message p2ptypes.Message) bool {
 	return success
 }
 
+// ConnectToPeer connects to the peer at the given address, e.g. a validator learned outside the
+// peer discovery. The connection is not retried if it fails.
+func (msgr *Messenger) ConnectToPeer(netAddress string) error {
+	addr, err := netutil.NewNetAddressString(netAddress)
+	if err != nil {
+		return err
+	}
+	for _, peer := range *(msgr.peerTable.GetAllPeers()) {
+		if peer.NetAddress().Equals(addr) {
+			return nil
+		}
+	}
+	_, err = msgr.discMgr.connectToOutboundPeer(addr, false)
+	return err
+}
+
 // RegisterMessageHandler registers the message handler
 func (msgr *Messenger) RegisterMessageHandler(msgHandler p2p.MessageHandler) {
 	channelIDs := msgHandler.GetChannelIDs()
@@ -158,6 +213,44 @@ func (msgr *Messenger) ID() string {
 	return msgr.nodeInfo.PubKey.Address().Hex()
 }
 
+// PeerStats returns the per-channel traffic stats of the connected peers
+func (msgr *Messenger) PeerStats() []p2ptypes.PeerStats {
+	stats := []p2ptypes.PeerStats{}
+	for _, peer := range *(msgr.peerTable.GetAllPeers()) {
+		peerStats := peer.GetStats()
+		peerStats.Score = msgr.reputation.GetScore(peer.ID()).Score
+		stats = append(stats, peerStats)
+	}
+	return stats
+}
+
+// ReportPeer reports a behavior of the peer. The peer is throttled when its score falls below
+// the throttle score, and disconnected and banned when it falls below the ban score.
+func (msgr *Messenger) ReportPeer(peerID string, event p2ptypes.PeerEvent) {
+	action := msgr.reputation.Report(peerID, event)
+	if action == reputation.ActionNone {
+		return
+	}
+	peer := msgr.peerTable.GetPeer(peerID)
+	if peer == nil {
+		return
+	}
+	switch action {
+	case reputation.ActionThrottle:
+		peer.GetConnection().SetRecvRate(throttledRecvRate)
+	case reputation.ActionUnthrottle:
+		peer.GetConnection().SetRecvRate(cn.GetDefaultConnectionConfig().RecvRate)
+	case reputation.ActionDisconnect:
+		logger.Warnf("Disconnecting banned peer %v", peerID)
+		msgr.discMgr.DisconnectPeer(peer)
+	}
+}
+
+// IsBanned returns whether the peer is banned
+func (msgr *Messenger) IsBanned(peerID string) bool {
+	return msgr.reputation.IsBanned(peerID)
+}
+
 // AttachMessageHandlersToPeer attaches the registerred message handlers to the given peer
 func (msgr *Messenger) AttachMessageHandlersToPeer(peer *pr.Peer) {
 	messageParser := func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
@@ -167,6 +260,9 @@ func (msgr *Messenger) AttachMessageHandlersToPeer(peer *pr.Peer) {
 			logger.Errorf("Failed to setup message parser for channelID %v", channelID)
 		}
 		message, err := msgHandler.ParseMessage(peerID, channelID, rawMessageBytes)
+		if err != nil {
+			msgr.ReportPeer(peerID, p2ptypes.PeerEventInvalidMessage)
+		}
 		return message, err
 	}
 	peer.GetConnection().SetMessageParser(messageParser)
@@ -198,3 +294,34 @@ func (msgr *Messenger) AttachMessageHandlersToPeer(peer *pr.Peer) {
 func (msgrConfig *MessengerConfig) SetAddressBookFilePath(filePath string) {
 	msgrConfig.addrBookFilePath = filePath
 }
+
+// SetBanListFilePath sets the file path the banned peers are saved to
+func (msgrConfig *MessengerConfig) SetBanListFilePath(filePath string) {
+	msgrConfig.banListFilePath = filePath
+}
+
+// SetReputationConfig sets the scores to throttle and ban the peers at, and the ban duration
+func (msgrConfig *MessengerConfig) SetReputationConfig(config reputation.Config) {
+	msgrConfig.reputationConfig = config
+}
+
+// SetSkipNAT sets whether to skip mapping the listen port through the UPnP or NAT-PMP gateway
+func (msgrConfig *MessengerConfig) SetSkipNAT(skip bool) {
+	msgrConfig.skipNAT = skip
+}
+
+// SetListenAddresses sets the addresses to accept the inbound peers on
+func (msgrConfig *MessengerConfig) SetListenAddresses(listenAddrs []netutil.ListenAddress) {
+	msgrConfig.listenAddresses = listenAddrs
+}
+
+// SetNodeKey sets the key of the node, which authenticates the secure transport with the peers.
+// The messages are exchanged in plaintext if not set.
+func (msgrConfig *MessengerConfig) SetNodeKey(nodeKey *crypto.PrivateKey) {
+	msgrConfig.nodeKey = nodeKey
+}
+
+// SetSecureTransport sets the mode of the secure transport, see peer.SecureTransportOptional
+func (msgrConfig *MessengerConfig) SetSecureTransport(mode string) {
+	msgrConfig.secureTransport = mode
+}
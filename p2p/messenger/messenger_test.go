@@ -3,6 +3,7 @@ package messenger
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
 	"testing"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/p2p"
+	"github.com/thetatoken/theta/p2p/netutil"
+	pr "github.com/thetatoken/theta/p2p/peer"
 	p2ptypes "github.com/thetatoken/theta/p2p/types"
 	"github.com/thetatoken/theta/rlp"
 )
@@ -119,6 +122,61 @@ type TestMessageHandler struct {
 	recvMsgChan chan string
 }
 
+func TestMessengerListenAddresses(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	if l, err := net.Listen("tcp6", "[::1]:0"); err != nil {
+		t.Skip("IPv6 is not available")
+	} else {
+		l.Close()
+	}
+
+	peerANetAddr := "127.0.0.1:24561"
+	peerBNetAddr := "127.0.0.1:24562"
+	peerCNetAddr := "127.0.0.1:24563"
+	peerCIPv6NetAddr := "[::1]:24564"
+
+	// Simulate PeerC (i.e. us), listening on both IPv4 and IPv6
+	listenAddrs, err := netutil.ParseListenAddresses(peerCNetAddr + "," + peerCIPv6NetAddr + "/noadvertise")
+	assert.Nil(err)
+	testMsgrConfig := MessengerConfig{
+		addrBookFilePath:    "./.addrbooks/addrbook_" + peerCNetAddr + ".json",
+		routabilityRestrict: false,
+		skipNAT:             true,
+		networkProtocol:     "tcp",
+	}
+	testMsgrConfig.SetListenAddresses(listenAddrs)
+	messenger, err := CreateMessenger(p2ptypes.GetTestRandPubKey(), []string{}, 50001, testMsgrConfig)
+	assert.Nil(err)
+	assert.Equal(uint16(24563), messenger.nodeInfo.Port) // the port of the advertised address
+	discMgr := messenger.discMgr
+	assert.Equal(2, len(discMgr.inboundPeerListener.NetListeners()))
+	assert.Equal(uint16(24563), discMgr.inboundPeerListener.ExternalAddress().Port)
+
+	inboundPeerChan := make(chan *pr.Peer, 2)
+	discMgr.inboundPeerListener.SetInboundCallback(func(peer *pr.Peer, err error) {
+		assert.Nil(err)
+		inboundPeerChan <- peer
+	})
+	messenger.Start(ctx)
+
+	// PeerA connects over IPv4, and PeerB over IPv6
+	peerADiscMgr := newTestPeerDiscoveryManager([]string{peerCNetAddr}, peerANetAddr)
+	peerADiscMgr.Start(ctx)
+	peerBDiscMgr := newTestPeerDiscoveryManager([]string{peerCIPv6NetAddr}, peerBNetAddr)
+	peerBDiscMgr.Start(ctx)
+
+	remoteIPs := map[string]string{}
+	for i := 0; i < 2; i++ {
+		peer := <-inboundPeerChan
+		assert.NotNil(peer)
+		remoteIPs[peer.ID()] = peer.NetAddress().IP.String()
+	}
+	assert.Equal("127.0.0.1", remoteIPs[peerADiscMgr.nodeInfo.PubKey.Address().Hex()])
+	assert.Equal("::1", remoteIPs[peerBDiscMgr.nodeInfo.PubKey.Address().Hex()])
+}
+
 func newTestMessageHandler(selfPeerID string, t *testing.T, assert *assert.Assertions) p2p.MessageHandler {
 	return &TestMessageHandler{
 		selfPeerID:  selfPeerID,
@@ -166,7 +224,7 @@ func newTestMessenger(seedPeerNetAddressStrs []string, port int) *Messenger {
 	testMsgrConfig := MessengerConfig{
 		addrBookFilePath:    "./.addrbooks/addrbook_" + localNetworkAddress + ".json",
 		routabilityRestrict: false,
-		skipUPNP:            true,
+		skipNAT:             true,
 		networkProtocol:     "tcp",
 	}
 	messenger, err := CreateMessenger(peerPubKey, seedPeerNetAddressStrs, port, testMsgrConfig)
@@ -0,0 +1,240 @@
+package netutil
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "p2p"})
+
+const (
+	// DefaultDNSSeedTTL is used when the lookup function cannot report the TTL of
+	// the DNS records, e.g. the lookup via the system resolver
+	DefaultDNSSeedTTL = 30 * time.Minute
+
+	minDNSSeedTTL = 1 * time.Minute
+	maxDNSSeedTTL = 24 * time.Hour
+
+	// max number of addresses taken from a single DNS seed
+	maxAddrsPerDNSSeed = 16
+
+	// txtDNSSeedPrefix marks the DNS seeds whose TXT records list the peer addresses
+	txtDNSSeedPrefix = "txt:"
+)
+
+// DNSLookupFunc resolves a hostname into IP addresses, and reports how long the result
+// can be cached (i.e. the TTL of the DNS records)
+type DNSLookupFunc func(host string) (ips []net.IP, ttl time.Duration, err error)
+
+// SystemDNSLookup resolves the hostname using the system resolver. Since the system resolver
+// does not expose the TTL of the records, DefaultDNSSeedTTL is reported.
+func SystemDNSLookup(host string) ([]net.IP, time.Duration, error) {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ips, DefaultDNSSeedTTL, nil
+}
+
+// DNSTXTLookupFunc looks up the TXT records of a hostname, and reports how long the result
+// can be cached (i.e. the TTL of the DNS records)
+type DNSTXTLookupFunc func(host string) (txts []string, ttl time.Duration, err error)
+
+// SystemDNSTXTLookup looks up the TXT records using the system resolver. Since the system
+// resolver does not expose the TTL of the records, DefaultDNSSeedTTL is reported.
+func SystemDNSTXTLookup(host string) ([]string, time.Duration, error) {
+	txts, err := net.LookupTXT(host)
+	if err != nil {
+		return nil, 0, err
+	}
+	return txts, DefaultDNSSeedTTL, nil
+}
+
+type dnsSeedEntry struct {
+	addrs  []*NetAddress
+	expiry time.Time
+}
+
+//
+// DNSSeedResolver resolves DNS seed hostnames into peer addresses. A seed is either
+// "host[:port]", whose A/AAAA records give the IPs of the peers listening on the port, or
+// "txt:host", whose TXT records list the peer addresses, separated by spaces or commas,
+// e.g. "104.25.10.1:30001 104.25.10.2:30001". The results are cached for the TTL of the
+// DNS records, and refreshed lazily upon expiry.
+//
+type DNSSeedResolver struct {
+	mutex *sync.Mutex
+
+	seeds       []string // in the form of "host", "host:port" or "txt:host"
+	defaultPort uint16
+	lookup      DNSLookupFunc
+	txtLookup   DNSTXTLookupFunc
+	cache       map[string]*dnsSeedEntry
+	now         func() time.Time
+}
+
+// NewDNSSeedResolver creates an instance of DNSSeedResolver. The defaultPort is used for
+// the seeds that do not specify a port.
+func NewDNSSeedResolver(seeds []string, defaultPort uint16) *DNSSeedResolver {
+	return &DNSSeedResolver{
+		mutex:       &sync.Mutex{},
+		seeds:       seeds,
+		defaultPort: defaultPort,
+		lookup:      SystemDNSLookup,
+		txtLookup:   SystemDNSTXTLookup,
+		cache:       make(map[string]*dnsSeedEntry),
+		now:         time.Now,
+	}
+}
+
+// SetLookupFunc sets the function used to resolve the hostnames
+func (r *DNSSeedResolver) SetLookupFunc(lookup DNSLookupFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lookup = lookup
+}
+
+// SetTXTLookupFunc sets the function used to look up the TXT records
+func (r *DNSSeedResolver) SetTXTLookupFunc(txtLookup DNSTXTLookupFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.txtLookup = txtLookup
+}
+
+// NumSeeds returns the number of the DNS seeds
+func (r *DNSSeedResolver) NumSeeds() int {
+	return len(r.seeds)
+}
+
+// Addresses returns the peer addresses of all the DNS seeds. The expired entries are
+// resolved again. If a lookup fails, the previously resolved addresses are kept and
+// the lookup is retried after a short while.
+func (r *DNSSeedResolver) Addresses() []*NetAddress {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	addrs := []*NetAddress{}
+	now := r.now()
+	for _, seed := range r.seeds {
+		entry, ok := r.cache[seed]
+		if !ok || now.After(entry.expiry) {
+			entry = r.resolve(seed, entry)
+			r.cache[seed] = entry
+		}
+		addrs = append(addrs, entry.addrs...)
+	}
+	return addrs
+}
+
+// Contains returns whether the address was resolved from one of the DNS seeds
+func (r *DNSSeedResolver) Contains(addr *NetAddress) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, entry := range r.cache {
+		for _, seedAddr := range entry.addrs {
+			if seedAddr.Equals(addr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *DNSSeedResolver) resolve(seed string, prev *dnsSeedEntry) *dnsSeedEntry {
+	retry := &dnsSeedEntry{expiry: r.now().Add(minDNSSeedTTL)}
+	if prev != nil {
+		retry.addrs = prev.addrs
+	}
+
+	var addrs []*NetAddress
+	var ttl time.Duration
+	var err error
+	if strings.HasPrefix(seed, txtDNSSeedPrefix) {
+		addrs, ttl, err = r.resolveTXT(strings.TrimPrefix(seed, txtDNSSeedPrefix))
+	} else {
+		addrs, ttl, err = r.resolveIPs(seed)
+	}
+	if err != nil {
+		logger.Warnf("Invalid DNS seed %v: %v", seed, err)
+		retry.expiry = r.now().Add(maxDNSSeedTTL)
+		return retry
+	}
+	if len(addrs) == 0 {
+		logger.Warnf("Failed to resolve DNS seed %v", seed)
+		return retry
+	}
+
+	if ttl < minDNSSeedTTL {
+		ttl = minDNSSeedTTL
+	} else if ttl > maxDNSSeedTTL {
+		ttl = maxDNSSeedTTL
+	}
+	if len(addrs) > maxAddrsPerDNSSeed {
+		addrs = addrs[:maxAddrsPerDNSSeed]
+	}
+	return &dnsSeedEntry{addrs: addrs, expiry: r.now().Add(ttl)}
+}
+
+// resolveIPs resolves the A/AAAA records of the seed. The error is only returned for the
+// malformed seeds, the failed lookups return no address.
+func (r *DNSSeedResolver) resolveIPs(seed string) ([]*NetAddress, time.Duration, error) {
+	host, port, err := r.splitHostPort(seed)
+	if err != nil {
+		return nil, 0, err
+	}
+	ips, ttl, err := r.lookup(host)
+	if err != nil {
+		logger.Warnf("Failed to look up the IPs of DNS seed %v: %v", seed, err)
+		return nil, 0, nil
+	}
+	addrs := []*NetAddress{}
+	for _, ip := range ips {
+		addrs = append(addrs, NewNetAddressIPPort(ip, port))
+	}
+	return addrs, ttl, nil
+}
+
+// resolveTXT parses the peer addresses listed in the TXT records of the host. The entries
+// without a port use the default port, and the malformed entries are skipped.
+func (r *DNSSeedResolver) resolveTXT(host string) ([]*NetAddress, time.Duration, error) {
+	txts, ttl, err := r.txtLookup(host)
+	if err != nil {
+		logger.Warnf("Failed to look up the TXT records of DNS seed %v: %v", host, err)
+		return nil, 0, nil
+	}
+	addrs := []*NetAddress{}
+	for _, txt := range txts {
+		entries := strings.FieldsFunc(txt, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+		for _, entry := range entries {
+			ipStr, port, err := r.splitHostPort(entry)
+			ip := net.ParseIP(ipStr)
+			if err != nil || ip == nil {
+				logger.Debugf("Skipped the malformed address %v listed by DNS seed %v", entry, host)
+				continue
+			}
+			addrs = append(addrs, NewNetAddressIPPort(ip, port))
+		}
+	}
+	return addrs, ttl, nil
+}
+
+func (r *DNSSeedResolver) splitHostPort(seed string) (string, uint16, error) {
+	host, portStr, err := net.SplitHostPort(seed)
+	if err != nil {
+		// no port specified
+		return seed, r.defaultPort, nil
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, uint16(port), nil
+}
@@ -0,0 +1,97 @@
+package netutil
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSSeedResolver(t *testing.T) {
+	assert := assert.New(t)
+
+	numLookups := 0
+	lookupErr := error(nil)
+	lookup := func(host string) ([]net.IP, time.Duration, error) {
+		numLookups++
+		if lookupErr != nil {
+			return nil, 0, lookupErr
+		}
+		switch host {
+		case "seed1.example.com":
+			return []net.IP{net.ParseIP("104.25.10.1"), net.ParseIP("104.25.10.2")}, 10 * time.Minute, nil
+		case "seed2.example.com":
+			return []net.IP{net.ParseIP("35.12.1.3")}, time.Second, nil // below the min TTL
+		}
+		return nil, 0, errors.New("no such host")
+	}
+
+	now := time.Now()
+	resolver := NewDNSSeedResolver([]string{"seed1.example.com", "seed2.example.com:30001", "unknown.example.com"}, 50001)
+	resolver.SetLookupFunc(lookup)
+	resolver.now = func() time.Time { return now }
+
+	addrs := resolver.Addresses()
+	assert.Equal(3, len(addrs))
+	assert.Equal("104.25.10.1:50001", addrs[0].String())
+	assert.Equal("104.25.10.2:50001", addrs[1].String())
+	assert.Equal("35.12.1.3:30001", addrs[2].String())
+	assert.Equal(3, numLookups)
+	assert.True(resolver.Contains(addrs[2]))
+
+	// Cached within the TTL
+	now = now.Add(30 * time.Second)
+	resolver.Addresses()
+	assert.Equal(3, numLookups)
+
+	// seed2 and the failed lookup expire after the min TTL
+	now = now.Add(minDNSSeedTTL)
+	resolver.Addresses()
+	assert.Equal(5, numLookups)
+
+	// The previously resolved addresses are kept if the lookup fails
+	lookupErr = errors.New("network unreachable")
+	now = now.Add(10 * time.Minute)
+	addrs = resolver.Addresses()
+	assert.Equal(3, len(addrs))
+	assert.Equal(8, numLookups)
+}
+
+func TestDNSSeedResolverTXT(t *testing.T) {
+	assert := assert.New(t)
+
+	numIPLookups := 0
+	lookup := func(host string) ([]net.IP, time.Duration, error) {
+		numIPLookups++
+		return nil, 0, errors.New("no such host")
+	}
+	txtLookup := func(host string) ([]string, time.Duration, error) {
+		if host != "seeds.example.com" {
+			return nil, 0, errors.New("no such host")
+		}
+		return []string{
+			"104.25.10.1:30001, 104.25.10.2",
+			"not-an-ip:30001 [2001:db8::1]:30002 35.12.1.3:badport",
+		}, time.Hour, nil
+	}
+
+	now := time.Now()
+	resolver := NewDNSSeedResolver([]string{"txt:seeds.example.com", "txt:unknown.example.com"}, 50001)
+	resolver.SetLookupFunc(lookup)
+	resolver.SetTXTLookupFunc(txtLookup)
+	resolver.now = func() time.Time { return now }
+
+	addrs := resolver.Addresses()
+	assert.Equal(3, len(addrs))
+	assert.Equal("104.25.10.1:30001", addrs[0].String())
+	assert.Equal("104.25.10.2:50001", addrs[1].String())
+	assert.Equal("[2001:db8::1]:30002", addrs[2].String())
+	assert.Equal(0, numIPLookups)
+
+	// The TTL of the TXT records applies
+	now = now.Add(30 * time.Minute)
+	assert.Equal(3, len(resolver.Addresses()))
+	assert.True(resolver.Contains(addrs[1]))
+}
@@ -0,0 +1,115 @@
+package netutil
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const noAdvertiseSuffix = "/noadvertise"
+
+// ListenAddress is a local address the node accepts peer connections on
+type ListenAddress struct {
+	Host      string // IP address, or empty for all the interfaces of both the IPv4 and IPv6 stacks
+	Port      uint16
+	Advertise bool // whether the port is announced to the peers and mapped through UPnP
+}
+
+// ParseListenAddress parses a listen address in the form of "host:port", where an IPv6 host is
+// enclosed in brackets (e.g. "[::]:50001"). The address can be followed by "/noadvertise" to
+// accept connections on it without announcing it to the peers.
+func ParseListenAddress(str string) (ListenAddress, error) {
+	str = strings.TrimSpace(str)
+	la := ListenAddress{Advertise: true}
+	if strings.HasSuffix(str, noAdvertiseSuffix) {
+		la.Advertise = false
+		str = strings.TrimSuffix(str, noAdvertiseSuffix)
+	}
+
+	host, portStr, err := net.SplitHostPort(str)
+	if err != nil {
+		return ListenAddress{}, err
+	}
+	if len(host) > 0 && net.ParseIP(host) == nil {
+		return ListenAddress{}, fmt.Errorf("Invalid listen address %v, the host needs to be an IP address", str)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return ListenAddress{}, fmt.Errorf("Invalid port in listen address %v: %v", str, err)
+	}
+	la.Host = host
+	la.Port = uint16(port)
+	return la, nil
+}
+
+// ParseListenAddresses parses a comma separated list of listen addresses.
+func ParseListenAddresses(str string) ([]ListenAddress, error) {
+	addrs := []ListenAddress{}
+	for _, addrStr := range strings.Split(str, ",") {
+		if len(strings.TrimSpace(addrStr)) == 0 {
+			continue
+		}
+		la, err := ParseListenAddress(addrStr)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, la)
+	}
+	return addrs, nil
+}
+
+// AdvertisedPort returns the port announced to the peers. The peers only learn our IP addresses
+// from the connections, so all the advertised addresses need to share the same port.
+func AdvertisedPort(addrs []ListenAddress) (uint16, error) {
+	advertised := false
+	var port uint16
+	for _, la := range addrs {
+		if !la.Advertise {
+			continue
+		}
+		if advertised && la.Port != port {
+			return 0, fmt.Errorf("The advertised listen addresses need to share the same port, got %v and %v", port, la.Port)
+		}
+		advertised = true
+		port = la.Port
+	}
+	if !advertised {
+		return 0, fmt.Errorf("None of the listen addresses is advertised")
+	}
+	return port, nil
+}
+
+// Network returns the network to listen on for the given protocol. An IP address listens on its
+// own stack only, so that the IPv4 and IPv6 wildcard addresses can be listened on side by side.
+func (la ListenAddress) Network(protocol string) string {
+	if protocol != "tcp" || len(la.Host) == 0 {
+		return protocol
+	}
+	if net.ParseIP(la.Host).To4() != nil {
+		return "tcp4"
+	}
+	return "tcp6"
+}
+
+// IsIPv6 returns whether the address only accepts IPv6 connections.
+func (la ListenAddress) IsIPv6() bool {
+	return len(la.Host) > 0 && net.ParseIP(la.Host).To4() == nil
+}
+
+// IsUnspecified returns whether the address listens on all the interfaces.
+func (la ListenAddress) IsUnspecified() bool {
+	return len(la.Host) == 0 || net.ParseIP(la.Host).IsUnspecified()
+}
+
+// Addr returns the address in the form of "host:port".
+func (la ListenAddress) Addr() string {
+	return net.JoinHostPort(la.Host, strconv.FormatUint(uint64(la.Port), 10))
+}
+
+func (la ListenAddress) String() string {
+	if la.Advertise {
+		return la.Addr()
+	}
+	return la.Addr() + noAdvertiseSuffix
+}
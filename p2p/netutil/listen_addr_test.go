@@ -0,0 +1,67 @@
+package netutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseListenAddresses(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	addrs, err := ParseListenAddresses("0.0.0.0:50001, [::]:50001,[::1]:50002/noadvertise,:50003")
+	require.Nil(err)
+	require.Equal(4, len(addrs))
+
+	assert.Equal(ListenAddress{Host: "0.0.0.0", Port: 50001, Advertise: true}, addrs[0])
+	assert.Equal("tcp4", addrs[0].Network("tcp"))
+	assert.True(addrs[0].IsUnspecified())
+	assert.False(addrs[0].IsIPv6())
+
+	assert.Equal(ListenAddress{Host: "::", Port: 50001, Advertise: true}, addrs[1])
+	assert.Equal("tcp6", addrs[1].Network("tcp"))
+	assert.Equal("[::]:50001", addrs[1].Addr())
+	assert.True(addrs[1].IsUnspecified())
+	assert.True(addrs[1].IsIPv6())
+
+	assert.Equal(ListenAddress{Host: "::1", Port: 50002, Advertise: false}, addrs[2])
+	assert.Equal("[::1]:50002/noadvertise", addrs[2].String())
+	assert.False(addrs[2].IsUnspecified())
+
+	// An empty host listens on both stacks
+	assert.Equal(ListenAddress{Host: "", Port: 50003, Advertise: true}, addrs[3])
+	assert.Equal("tcp", addrs[3].Network("tcp"))
+	assert.True(addrs[3].IsUnspecified())
+	assert.False(addrs[3].IsIPv6())
+
+	addrs, err = ParseListenAddresses("")
+	require.Nil(err)
+	assert.Equal(0, len(addrs))
+
+	for _, invalid := range []string{"50001", "::1:50001", "localhost:50001", "127.0.0.1:65536", "127.0.0.1:50001/private"} {
+		_, err = ParseListenAddresses(invalid)
+		assert.NotNil(err, invalid)
+	}
+}
+
+func TestAdvertisedPort(t *testing.T) {
+	assert := assert.New(t)
+
+	port, err := AdvertisedPort([]ListenAddress{
+		{Host: "127.0.0.1", Port: 50002, Advertise: false},
+		{Host: "0.0.0.0", Port: 50001, Advertise: true},
+		{Host: "::", Port: 50001, Advertise: true},
+	})
+	assert.Nil(err)
+	assert.Equal(uint16(50001), port)
+
+	_, err = AdvertisedPort([]ListenAddress{
+		{Host: "0.0.0.0", Port: 50001, Advertise: true},
+		{Host: "::", Port: 50002, Advertise: true},
+	})
+	assert.NotNil(err)
+
+	_, err = AdvertisedPort([]ListenAddress{{Host: "127.0.0.1", Port: 50002, Advertise: false}})
+	assert.NotNil(err)
+}
@@ -0,0 +1,170 @@
+package netutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	natpmpPort = 5351
+
+	// natpmpDefaultLifetime is the lifetime (in seconds) of the port mappings requested without a
+	// timeout, as recommended by RFC 6886. The mappings need to be renewed before they expire.
+	natpmpDefaultLifetime = 7200
+
+	natpmpOpExternalAddress = 0
+	natpmpOpMapUDP          = 1
+	natpmpOpMapTCP          = 2
+
+	natpmpMaxAttempts    = 3
+	natpmpInitialTimeout = 250 * time.Millisecond
+)
+
+// DiscoverNAT looks for a NAT gateway the ports can be mapped through, trying UPnP first, and
+// NAT-PMP if no UPnP gateway responds.
+func DiscoverNAT() (NAT, error) {
+	nat, upnpErr := Discover()
+	if upnpErr == nil {
+		return nat, nil
+	}
+	nat, err := DiscoverNATPMP()
+	if err == nil {
+		return nat, nil
+	}
+	return nil, fmt.Errorf("%v %v", upnpErr, err)
+}
+
+// natpmpNAT is a NAT gateway speaking NAT-PMP (RFC 6886), e.g. the routers which do not enable
+// UPnP, but support the simpler NAT-PMP.
+type natpmpNAT struct {
+	gateway *net.UDPAddr
+}
+
+// DiscoverNATPMP looks for a NAT-PMP gateway. Since the default route is not available without
+// syscalls, the first host address of the subnet of each private IPv4 interface address is tried
+// as the gateway, e.g. 192.168.1.1 for 192.168.1.23/24, which is the address of most home routers.
+func DiscoverNATPMP() (NAT, error) {
+	for _, gateway := range guessGateways() {
+		nat := &natpmpNAT{gateway: &net.UDPAddr{IP: gateway, Port: natpmpPort}}
+		if _, err := nat.GetExternalAddress(); err == nil {
+			return nat, nil
+		}
+	}
+	return nil, errors.New("NAT-PMP gateway discovery failed.")
+}
+
+func (n *natpmpNAT) GetExternalAddress() (addr net.IP, err error) {
+	resp, err := n.call([]byte{0, natpmpOpExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// AddPortMapping maps the port for the timeout (in seconds), or for natpmpDefaultLifetime if the
+// timeout is 0, since NAT-PMP does not support permanent mappings.
+func (n *natpmpNAT) AddPortMapping(protocol string, externalPort, internalPort int, description string, timeout int) (mappedExternalPort int, err error) {
+	if timeout <= 0 {
+		timeout = natpmpDefaultLifetime
+	}
+	resp, err := n.mapPort(protocol, externalPort, internalPort, timeout)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(resp[10:12])), nil
+}
+
+// DeletePortMapping deletes the mapping of the internal port, by requesting it with a lifetime of
+// 0. NAT-PMP identifies the mappings by the internal port only.
+func (n *natpmpNAT) DeletePortMapping(protocol string, externalPort, internalPort int) (err error) {
+	_, err = n.mapPort(protocol, 0, internalPort, 0)
+	return err
+}
+
+func (n *natpmpNAT) mapPort(protocol string, externalPort, internalPort, lifetime int) ([]byte, error) {
+	var op byte
+	switch protocol {
+	case "udp":
+		op = natpmpOpMapUDP
+	case "tcp":
+		op = natpmpOpMapTCP
+	default:
+		return nil, fmt.Errorf("Unsupported protocol: %v", protocol)
+	}
+
+	req := make([]byte, 12)
+	req[1] = op
+	binary.BigEndian.PutUint16(req[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(req[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime))
+	return n.call(req, 16)
+}
+
+// call sends the request to the gateway, resending it with a doubling timeout until the gateway
+// responds, and returns the response of the given size.
+func (n *natpmpNAT) call(req []byte, respSize int) ([]byte, error) {
+	conn, err := net.DialUDP("udp4", nil, n.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	resp := make([]byte, 16)
+	timeout := natpmpInitialTimeout
+	for i := 0; i < natpmpMaxAttempts; i++ {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		timeout *= 2
+		numBytes, err := conn.Read(resp)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				continue
+			}
+			return nil, err
+		}
+		if numBytes < 4 || resp[0] != 0 || resp[1] != req[1]|0x80 {
+			continue // not the response to the request
+		}
+		if code := binary.BigEndian.Uint16(resp[2:4]); code != 0 {
+			return nil, fmt.Errorf("NAT-PMP request failed with result code %v", code)
+		}
+		if numBytes < respSize {
+			return nil, fmt.Errorf("Truncated NAT-PMP response of %v bytes", numBytes)
+		}
+		return resp[:respSize], nil
+	}
+	return nil, fmt.Errorf("NAT-PMP gateway %v did not respond", n.gateway)
+}
+
+// guessGateways returns the first host address of the subnet of each private IPv4 interface address
+func guessGateways() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	gateways := []net.IP{}
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipnet.IP.To4()
+		if ip == nil || !(&NetAddress{IP: ip}).RFC1918() {
+			continue
+		}
+		gateway := ip.Mask(ipnet.Mask)
+		if gateway == nil {
+			continue
+		}
+		gateway[3]++
+		if !gateway.Equal(ip) {
+			gateways = append(gateways, gateway)
+		}
+	}
+	return gateways
+}
@@ -0,0 +1,77 @@
+package netutil
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestNATPMPGateway serves the NAT-PMP requests, mapping the internal ports to the internal
+// port + 1000, and records the lifetimes requested.
+func startTestNATPMPGateway(t *testing.T, lifetimes chan uint32) *net.UDPConn {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.Nil(t, err)
+
+	go func() {
+		req := make([]byte, 16)
+		for {
+			numBytes, addr, err := conn.ReadFromUDP(req)
+			if err != nil {
+				return
+			}
+			var resp []byte
+			switch {
+			case numBytes == 2 && req[1] == natpmpOpExternalAddress:
+				resp = make([]byte, 12)
+				copy(resp[8:], net.IPv4(203, 0, 113, 7).To4())
+			case numBytes == 12 && req[1] == natpmpOpMapTCP:
+				resp = make([]byte, 16)
+				internalPort := binary.BigEndian.Uint16(req[4:6])
+				copy(resp[8:10], req[4:6])
+				binary.BigEndian.PutUint16(resp[10:12], internalPort+1000)
+				copy(resp[12:16], req[8:12])
+				lifetimes <- binary.BigEndian.Uint32(req[8:12])
+			default:
+				resp = make([]byte, 4)
+				binary.BigEndian.PutUint16(resp[2:4], 5) // unsupported opcode
+			}
+			resp[1] = req[1] | 0x80
+			conn.WriteToUDP(resp, addr)
+		}
+	}()
+	return conn
+}
+
+func TestNATPMP(t *testing.T) {
+	assert, require := assert.New(t), require.New(t)
+
+	lifetimes := make(chan uint32, 3)
+	gateway := startTestNATPMPGateway(t, lifetimes)
+	defer gateway.Close()
+	nat := &natpmpNAT{gateway: gateway.LocalAddr().(*net.UDPAddr)}
+
+	ip, err := nat.GetExternalAddress()
+	require.Nil(err)
+	assert.Equal("203.0.113.7", ip.String())
+
+	port, err := nat.AddPortMapping("tcp", 50001, 50001, "theta", 0)
+	require.Nil(err)
+	assert.Equal(51001, port)
+	assert.Equal(uint32(natpmpDefaultLifetime), <-lifetimes)
+
+	_, err = nat.AddPortMapping("tcp", 50001, 50001, "theta", 600)
+	require.Nil(err)
+	assert.Equal(uint32(600), <-lifetimes)
+
+	require.Nil(nat.DeletePortMapping("tcp", 51001, 50001))
+	assert.Equal(uint32(0), <-lifetimes)
+
+	// The gateway rejects the UDP mappings
+	_, err = nat.AddPortMapping("udp", 50001, 50001, "theta", 0)
+	assert.NotNil(err)
+	_, err = nat.AddPortMapping("sctp", 50001, 50001, "theta", 0)
+	assert.NotNil(err)
+}
@@ -0,0 +1,78 @@
+package peer
+
+import (
+	"errors"
+	"io"
+
+	cmn "github.com/thetatoken/theta/common"
+	cn "github.com/thetatoken/theta/p2p/connection"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// maxReadAheadSize bounds the bytes an older node can send before answering the ping probe of the
+// handshake, which are read ahead of the connection
+const maxReadAheadSize = 1 << 20
+
+// writeNodeInfo sends the NodeInfo encoded as by the older nodes, followed by a packet carrying the
+// Extension and a ping probing whether the peer reads the Extension. The older nodes decode the
+// NodeInfo, and once they start the connection, ignore the Extension as sent on an unknown channel
+// and answer the ping.
+func writeNodeInfo(w io.Writer, nodeInfo *p2ptypes.NodeInfo) error {
+	if err := rlp.Encode(w, nodeInfo); err != nil {
+		return err
+	}
+	extensionBytes, err := rlp.EncodeToBytes(&nodeInfo.Extension)
+	if err != nil {
+		return err
+	}
+	if err := rlp.Encode(w, &cn.Packet{ChannelID: cmn.ChannelIDNodeInfo, Bytes: extensionBytes, IsEOF: byte(0x01)}); err != nil {
+		return err
+	}
+	return rlp.Encode(w, &cn.Packet{ChannelID: cmn.ChannelIDPing, Bytes: []byte{p2ptypes.PingSignal}, IsEOF: byte(0x01)})
+}
+
+// readNodeInfo reads the NodeInfo sent by writeNodeInfo. The Extension is followed by the ping
+// probe of the peer, which is consumed. An older node sends no Extension, and the packets it sends
+// before answering our ping probe are returned, for the connection to handle them.
+func readNodeInfo(r io.Reader, nodeInfo *p2ptypes.NodeInfo) (readAhead []byte, err error) {
+	// Read one byte at a time, so that the bytes following the handshake, e.g. the first frame
+	// of the secure transport, are not consumed
+	stream := rlp.NewStream(unbufferedByteReader{r}, 0)
+	if err := stream.Decode(nodeInfo); err != nil {
+		return nil, err
+	}
+	hasExtension := false
+	for {
+		rawPacket, err := stream.Raw()
+		if err != nil {
+			return nil, err
+		}
+		var packet cn.Packet
+		if err := rlp.DecodeBytes(rawPacket, &packet); err != nil {
+			return nil, err
+		}
+		switch {
+		case packet.ChannelID == cmn.ChannelIDNodeInfo && !hasExtension:
+			if err := rlp.DecodeBytes(packet.Bytes, &nodeInfo.Extension); err != nil {
+				return nil, err
+			}
+			hasExtension = true
+		case packet.ChannelID == cmn.ChannelIDPing && hasExtension && isSignal(packet, p2ptypes.PingSignal):
+			return nil, nil
+		case packet.ChannelID == cmn.ChannelIDPing && !hasExtension && isSignal(packet, p2ptypes.PongSignal):
+			return readAhead, nil
+		case hasExtension:
+			return nil, errors.New("Unexpected packet after the NodeInfo extension")
+		default:
+			if len(readAhead)+len(rawPacket) > maxReadAheadSize {
+				return nil, errors.New("Too much data sent during the handshake")
+			}
+			readAhead = append(readAhead, rawPacket...)
+		}
+	}
+}
+
+func isSignal(packet cn.Packet, signal byte) bool {
+	return len(packet.Bytes) == 1 && packet.Bytes[0] == signal
+}
@@ -14,7 +14,6 @@ import (
 	cn "github.com/thetatoken/theta/p2p/connection"
 	nu "github.com/thetatoken/theta/p2p/netutil"
 	p2ptypes "github.com/thetatoken/theta/p2p/types"
-	"github.com/thetatoken/theta/rlp"
 )
 
 var logger *log.Entry = log.WithFields(log.Fields{"prefix": "p2p"})
@@ -30,6 +29,11 @@ type Peer struct {
 	netAddress   *nu.NetAddress
 
 	nodeInfo p2ptypes.NodeInfo // information of the blockchain node of the peer
+	secure   bool              // whether the messages are encrypted
+
+	// session keys authenticating the message envelopes, set by the secure transport
+	envelopeSendKey cmn.Bytes
+	envelopeRecvKey cmn.Bytes
 
 	config PeerConfig
 
@@ -47,6 +51,8 @@ type Peer struct {
 type PeerConfig struct {
 	HandshakeTimeout time.Duration
 	DialTimeout      time.Duration
+	NodeKey          *crypto.PrivateKey // key of the node, needed by the secure transport
+	SecureTransport  string             // mode of the secure transport, see SecureTransportOptional
 }
 
 // CreateOutboundPeer creates an instance of an outbound peer
@@ -77,6 +83,7 @@ func GetDefaultPeerConfig() PeerConfig {
 	return PeerConfig{
 		HandshakeTimeout: 10 * time.Second,
 		DialTimeout:      10 * time.Second,
+		SecureTransport:  SecureTransportOptional,
 	}
 }
 
@@ -117,9 +124,21 @@ func (peer *Peer) Handshake(sourceNodeInfo *p2ptypes.NodeInfo) error {
 	var sendError error
 	var recvError error
 	targetPeerNodeInfo := p2ptypes.NodeInfo{}
+	// Tell the remote end the IP it is seen at, so that a node behind a NAT learns its external IP
+	remoteHost, _, _ := net.SplitHostPort(remoteAddr.String())
+	nodeInfo := sourceNodeInfo.WithObservedIP(remoteHost)
+	var ephemeralKey *crypto.PrivateKey
+	if peer.config.secureTransportEnabled() {
+		var err error
+		if ephemeralKey, _, err = crypto.GenerateKeyPair(); err != nil {
+			return err
+		}
+		nodeInfo = nodeInfo.WithEphemeralKey(ephemeralKey.PublicKey())
+	}
+	var readAhead []byte
 	cmn.Parallel(
-		func() { sendError = rlp.Encode(peer.connection.GetNetconn(), sourceNodeInfo) },
-		func() { recvError = rlp.Decode(peer.connection.GetNetconn(), &targetPeerNodeInfo) },
+		func() { sendError = writeNodeInfo(peer.connection.GetNetconn(), &nodeInfo) },
+		func() { readAhead, recvError = readNodeInfo(peer.connection.GetNetconn(), &targetPeerNodeInfo) },
 	)
 	if sendError != nil {
 		logger.Errorf("Error during handshake/send: %v", sendError)
@@ -130,7 +149,6 @@ func (peer *Peer) Handshake(sourceNodeInfo *p2ptypes.NodeInfo) error {
 		return recvError
 	}
 	netconn := peer.connection.GetNetconn()
-	netconn.SetDeadline(time.Time{})
 	targetNodePubKey, err := crypto.PublicKeyFromBytes(targetPeerNodeInfo.PubKeyBytes)
 	if err != nil {
 		logger.Errorf("Error during handshake/recv: %v", err)
@@ -138,17 +156,42 @@ func (peer *Peer) Handshake(sourceNodeInfo *p2ptypes.NodeInfo) error {
 	}
 	targetPeerNodeInfo.PubKey = targetNodePubKey
 	peer.nodeInfo = targetPeerNodeInfo
+	if len(readAhead) != 0 {
+		peer.connection.PrependReceived(readAhead)
+	}
+
+	if ephemeralKey != nil && len(targetPeerNodeInfo.EphemeralKey()) != 0 {
+		if err := peer.upgradeToSecureTransport(ephemeralKey, targetPeerNodeInfo.EphemeralKey()); err != nil {
+			logger.Errorf("Error during handshake/secure transport: %v", err)
+			return err
+		}
+	} else if peer.config.SecureTransport == SecureTransportRequired {
+		logger.Errorf("Peer %v does not support the secure transport", remoteAddr)
+		return errors.New("The peer does not support the secure transport")
+	}
+	netconn.SetDeadline(time.Time{})
+
+	// The envelopes are authenticated with the session keys, so they need the secure transport
+	if peer.secure && sourceNodeInfo.HasCapability(p2ptypes.CapabilityMessageEnvelope) &&
+		targetPeerNodeInfo.HasCapability(p2ptypes.CapabilityMessageEnvelope) {
+		peer.connection.EnableMessageEnvelopes(peer.envelopeSendKey, peer.envelopeRecvKey)
+	}
 
 	if !peer.isOutbound {
 		peer.SetNetAddress(nu.NewNetAddressWithEnforcedPort(netconn.RemoteAddr(), int(peer.nodeInfo.Port)))
 	}
 
-	logger.Infof("Handshake completed, target address: %v, target public key: %v",
-		remoteAddr, hex.EncodeToString(targetNodePubKey.ToBytes()))
+	logger.Infof("Handshake completed, target address: %v, target public key: %v, target version: %v %v, secure: %v",
+		remoteAddr, hex.EncodeToString(targetNodePubKey.ToBytes()), peer.nodeInfo.Version(), peer.nodeInfo.GitHash(), peer.secure)
 
 	return nil
 }
 
+// IsSecure returns whether the messages exchanged with the peer are encrypted
+func (peer *Peer) IsSecure() bool {
+	return peer.secure
+}
+
 // Send sends the given message through the specified channel to the target peer
 func (peer *Peer) Send(channelID cmn.ChannelIDEnum, message interface{}) bool {
 	success := peer.connection.EnqueueMessage(channelID, message)
@@ -172,6 +215,21 @@ func (peer *Peer) GetConnection() *cn.Connection {
 	return peer.connection
 }
 
+// GetStats returns the per-channel traffic stats of the peer
+func (peer *Peer) GetStats() p2ptypes.PeerStats {
+	stats := p2ptypes.PeerStats{
+		PeerID:     peer.ID(),
+		IsOutbound: peer.isOutbound,
+		Version:    peer.nodeInfo.Version(),
+		GitHash:    peer.nodeInfo.GitHash(),
+		Channels:   peer.connection.GetChannelStats(),
+	}
+	if peer.netAddress != nil {
+		stats.NetAddress = peer.netAddress.String()
+	}
+	return stats
+}
+
 // GetRemoteAddress returns the remote address of the peer
 func (peer *Peer) GetRemoteAddress() net.Addr {
 	return peer.connection.GetNetconn().RemoteAddr()
@@ -202,6 +260,11 @@ func (peer *Peer) NetAddress() *nu.NetAddress {
 	return peer.netAddress
 }
 
+// ObservedIP returns the IP the peer sees the node at, or an empty string if the peer does not report it
+func (peer *Peer) ObservedIP() string {
+	return peer.nodeInfo.ObservedIP()
+}
+
 // ID returns the unique idenitifier of the peer in the P2P network
 func (peer *Peer) ID() string {
 	peerID := peer.nodeInfo.PubKey.Address() // use the blockchain address as the peer ID
@@ -1,6 +1,7 @@
 package peer
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
 	cn "github.com/thetatoken/theta/p2p/connection"
 	nu "github.com/thetatoken/theta/p2p/netutil"
 	p2ptypes "github.com/thetatoken/theta/p2p/types"
@@ -147,6 +149,140 @@ func TestPeerHandshakeAndCommunication(t *testing.T) {
 	}
 }
 
+func TestPeerSecureHandshake(t *testing.T) {
+	assert := assert.New(t)
+
+	newConfig := func(mode string, withKey bool) PeerConfig {
+		config := GetDefaultPeerConfig()
+		config.SecureTransport = mode
+		if withKey {
+			config.NodeKey, _, _ = crypto.GenerateKeyPair()
+		}
+		return config
+	}
+
+	closePeers := func(peers ...*Peer) {
+		for _, peer := range peers {
+			peer.GetConnection().GetNetconn().Close()
+		}
+	}
+
+	// Both ends support the secure transport
+	outboundPeer, inboundPeer, outboundErr, inboundErr := handshakeWithConfigs(
+		newConfig(SecureTransportOptional, true), newConfig(SecureTransportOptional, true), true)
+	assert.Nil(outboundErr)
+	assert.Nil(inboundErr)
+	assert.True(outboundPeer.IsSecure())
+	assert.True(inboundPeer.IsSecure())
+
+	// The encrypted connection carries the messages
+	receivedChan := make(chan string, 1)
+	inboundPeer.GetConnection().SetMessageParser(func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+		return p2ptypes.Message{ChannelID: channelID, Content: rawMessageBytes}, nil
+	})
+	inboundPeer.GetConnection().SetReceiveHandler(func(message p2ptypes.Message) error {
+		var msg string
+		err := rlp.DecodeBytes(message.Content.(common.Bytes), &msg)
+		receivedChan <- msg
+		return err
+	})
+	ctx := context.Background()
+	outboundPeer.Start(ctx)
+	inboundPeer.Start(ctx)
+	assert.True(outboundPeer.Send(common.ChannelIDTransaction, "encrypted hello"))
+	select {
+	case msg := <-receivedChan:
+		assert.Equal("encrypted hello", msg)
+	case <-time.After(5 * time.Second):
+		assert.Fail("Timed out waiting for the message")
+	}
+	outboundPeer.Stop()
+	inboundPeer.Stop()
+
+	// Mixed mode: a peer without the secure transport falls back to plaintext
+	outboundPeer, inboundPeer, outboundErr, inboundErr = handshakeWithConfigs(
+		newConfig(SecureTransportOptional, true), newConfig(SecureTransportDisabled, true), true)
+	assert.Nil(outboundErr)
+	assert.Nil(inboundErr)
+	assert.False(outboundPeer.IsSecure())
+	assert.False(inboundPeer.IsSecure())
+	closePeers(outboundPeer, inboundPeer)
+
+	// A node requiring the secure transport rejects the older peers
+	outboundPeer, inboundPeer, outboundErr, _ = handshakeWithConfigs(
+		newConfig(SecureTransportRequired, true), newConfig(SecureTransportOptional, false), true)
+	assert.NotNil(outboundErr)
+	closePeers(outboundPeer, inboundPeer)
+
+	// A peer must hold the node key of its NodeInfo
+	outboundPeer, inboundPeer, outboundErr, _ = handshakeWithConfigs(
+		newConfig(SecureTransportOptional, true), newConfig(SecureTransportOptional, true), false)
+	assert.NotNil(outboundErr)
+	closePeers(outboundPeer, inboundPeer)
+}
+
+func TestPeerHandshakeWithOlderNode(t *testing.T) {
+	assert := assert.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(err)
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	// The NodeInfo of the nodes not sending the extension
+	type olderNodeInfo struct {
+		PubKeyBytes common.Bytes
+		Port        uint16
+	}
+
+	// Simulate an older node, which sends a message before answering the ping probe
+	go func() {
+		netconn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		reader := bufio.NewReader(netconn)
+		assert.Nil(rlp.Encode(netconn, &olderNodeInfo{p2ptypes.GetTestRandPubKey().ToBytes(), uint16(port)}))
+		var nodeInfo olderNodeInfo
+		assert.Nil(rlp.Decode(reader, &nodeInfo))
+		msgBytes, _ := rlp.EncodeToBytes("hello")
+		assert.Nil(rlp.Encode(netconn, &cn.Packet{ChannelID: common.ChannelIDTransaction, Bytes: msgBytes, IsEOF: byte(0x01)}))
+
+		var packet cn.Packet
+		assert.Nil(rlp.Decode(reader, &packet))
+		assert.Equal(common.ChannelIDNodeInfo, packet.ChannelID) // ignored as an unknown channel
+		assert.Nil(rlp.Decode(reader, &packet))
+		assert.Equal(common.ChannelIDPing, packet.ChannelID)
+		assert.Nil(rlp.Encode(netconn, &cn.Packet{ChannelID: common.ChannelIDPing, Bytes: []byte{p2ptypes.PongSignal}, IsEOF: byte(0x01)}))
+	}()
+
+	outboundPeer := newOutboundPeer(listener.Addr().String())
+	nodeInfo := p2ptypes.CreateNodeInfo(p2ptypes.GetTestRandPubKey(), uint16(port))
+	assert.Nil(outboundPeer.Handshake(&nodeInfo))
+	assert.Equal("", outboundPeer.nodeInfo.Version())
+	assert.False(outboundPeer.nodeInfo.HasCapability(p2ptypes.CapabilityMessageEnvelope))
+
+	// The message read ahead by the handshake is handled by the connection
+	receivedChan := make(chan string, 1)
+	outboundPeer.GetConnection().SetMessageParser(func(channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+		return p2ptypes.Message{ChannelID: channelID, Content: rawMessageBytes}, nil
+	})
+	outboundPeer.GetConnection().SetReceiveHandler(func(message p2ptypes.Message) error {
+		var msg string
+		err := rlp.DecodeBytes(message.Content.(common.Bytes), &msg)
+		receivedChan <- msg
+		return err
+	})
+	outboundPeer.Start(context.Background())
+	defer outboundPeer.Stop()
+	select {
+	case msg := <-receivedChan:
+		assert.Equal("hello", msg)
+	case <-time.After(5 * time.Second):
+		assert.Fail("Timed out waiting for the message")
+	}
+}
+
 // --------------- Test Utilities --------------- //
 
 func newOutboundPeer(ipAddr string) *Peer {
@@ -173,3 +309,51 @@ func newInboundPeer(netconn net.Conn) *Peer {
 	}
 	return inboundPeer
 }
+
+// handshakeWithConfigs connects two peers over a loopback connection and completes their handshake.
+// If matchNodeKeys is false, the inbound peer advertises a public key other than its node key.
+func handshakeWithConfigs(outboundConfig, inboundConfig PeerConfig, matchNodeKeys bool) (*Peer, *Peer, error, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to listen: %v", err))
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	nodeInfo := func(config PeerConfig, matchNodeKey bool) p2ptypes.NodeInfo {
+		if config.NodeKey != nil && matchNodeKey {
+			return p2ptypes.CreateNodeInfo(config.NodeKey.PublicKey(), uint16(port))
+		}
+		return p2ptypes.CreateNodeInfo(p2ptypes.GetTestRandPubKey(), uint16(port))
+	}
+
+	var outboundPeer, inboundPeer *Peer
+	var outboundErr, inboundErr error
+	common.Parallel(
+		func() {
+			netaddr, err := nu.NewNetAddressString(listener.Addr().String())
+			if err != nil {
+				panic(fmt.Sprintf("Failed to create net address: %v", err))
+			}
+			outboundPeer, err = CreateOutboundPeer(netaddr, outboundConfig, cn.GetDefaultConnectionConfig())
+			if err != nil {
+				panic(fmt.Sprintf("Failed to create outbound peer: %v", err))
+			}
+			sourceNodeInfo := nodeInfo(outboundConfig, true)
+			outboundErr = outboundPeer.Handshake(&sourceNodeInfo)
+		},
+		func() {
+			netconn, err := listener.Accept()
+			if err != nil {
+				panic(fmt.Sprintf("Failed to accept the netconn: %v", err))
+			}
+			inboundPeer, err = CreateInboundPeer(netconn, inboundConfig, cn.GetDefaultConnectionConfig())
+			if err != nil {
+				panic(fmt.Sprintf("Failed to create inbound peer: %v", err))
+			}
+			sourceNodeInfo := nodeInfo(inboundConfig, matchNodeKeys)
+			inboundErr = inboundPeer.Handshake(&sourceNodeInfo)
+		},
+	)
+	return outboundPeer, inboundPeer, outboundErr, inboundErr
+}
@@ -0,0 +1,139 @@
+package peer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	cmn "github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	cn "github.com/thetatoken/theta/p2p/connection"
+)
+
+// The modes of the secure transport
+const (
+	// SecureTransportDisabled exchanges the messages in plaintext
+	SecureTransportDisabled = "disabled"
+	// SecureTransportOptional encrypts the messages if the peer supports the secure transport,
+	// which lets the upgraded nodes interoperate with the older ones during a network upgrade
+	SecureTransportOptional = "optional"
+	// SecureTransportRequired rejects the peers not supporting the secure transport
+	SecureTransportRequired = "required"
+)
+
+// secureAuthDomain separates the signatures of the secure handshake from the other signatures
+// made with the node key
+const secureAuthDomain = "theta/p2p/secure-transport/v1"
+
+// envelopeKeyDomain separates the keys authenticating the message envelopes from the keys
+// encrypting the connection
+const envelopeKeyDomain = "theta/p2p/message-envelope/v1"
+
+// maxSecureAuthSize is the maximum size of the signature exchanged by the secure handshake
+const maxSecureAuthSize = 1024
+
+// secureTransportEnabled returns whether the node offers the secure transport to its peers
+func (config PeerConfig) secureTransportEnabled() bool {
+	return config.NodeKey != nil && config.SecureTransport != SecureTransportDisabled &&
+		len(config.SecureTransport) != 0
+}
+
+// upgradeToSecureTransport completes the secure handshake, once both ends have exchanged their
+// ephemeral public keys in their NodeInfo. The ephemeral keys give both ends a shared secret, from
+// which the keys encrypting each direction of the connection are derived. Each end then proves it
+// holds the node key of its NodeInfo by signing both ephemeral keys, so that the peer ID is bound
+// to the encrypted session and a man in the middle cannot relay the handshake.
+func (peer *Peer) upgradeToSecureTransport(ephemeralKey *crypto.PrivateKey, remoteEphemeralKeyBytes cmn.Bytes) error {
+	remoteEphemeralKey, err := crypto.PublicKeyFromBytes(remoteEphemeralKeyBytes)
+	if err != nil {
+		return fmt.Errorf("Invalid ephemeral key: %v", err)
+	}
+	secret, err := ephemeralKey.SharedSecret(remoteEphemeralKey)
+	if err != nil {
+		return err
+	}
+
+	// The directions are told apart by the order of the ephemeral keys
+	localEphemeralKeyBytes := ephemeralKey.PublicKey().ToBytes()
+	sendKey := crypto.Keccak256(secret, localEphemeralKeyBytes, remoteEphemeralKeyBytes)
+	recvKey := crypto.Keccak256(secret, remoteEphemeralKeyBytes, localEphemeralKeyBytes)
+	secureConn, err := cn.NewSecureConn(peer.connection.GetNetconn(), sendKey, recvKey)
+	if err != nil {
+		return err
+	}
+
+	sig, err := peer.config.NodeKey.Sign(secureAuthMessage(localEphemeralKeyBytes, remoteEphemeralKeyBytes))
+	if err != nil {
+		return err
+	}
+	var sendError, recvError error
+	var remoteSigBytes []byte
+	cmn.Parallel(
+		func() { sendError = writeSecureAuth(secureConn, sig.ToBytes()) },
+		func() { remoteSigBytes, recvError = readSecureAuth(secureConn) },
+	)
+	if sendError != nil {
+		return sendError
+	}
+	if recvError != nil {
+		return recvError
+	}
+	remoteSig, err := crypto.SignatureFromBytes(remoteSigBytes)
+	if err != nil {
+		return err
+	}
+	if !peer.nodeInfo.PubKey.VerifySignature(secureAuthMessage(remoteEphemeralKeyBytes, localEphemeralKeyBytes), remoteSig) {
+		return errors.New("The peer failed to prove the ownership of its node key")
+	}
+
+	peer.connection.UseSecureConn(secureConn)
+	peer.secure = true
+	peer.envelopeSendKey = crypto.Keccak256([]byte(envelopeKeyDomain), sendKey)
+	peer.envelopeRecvKey = crypto.Keccak256([]byte(envelopeKeyDomain), recvKey)
+	return nil
+}
+
+// secureAuthMessage returns the message the node signs to authenticate the secure transport
+func secureAuthMessage(signerEphemeralKey, otherEphemeralKey []byte) []byte {
+	msg := append([]byte(secureAuthDomain), signerEphemeralKey...)
+	return append(msg, otherEphemeralKey...)
+}
+
+func writeSecureAuth(w io.Writer, sig []byte) error {
+	buf := make([]byte, 2+len(sig))
+	binary.BigEndian.PutUint16(buf, uint16(len(sig)))
+	copy(buf[2:], sig)
+	_, err := w.Write(buf)
+	return err
+}
+
+func readSecureAuth(r io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint16(header)
+	if size > maxSecureAuthSize {
+		return nil, fmt.Errorf("Invalid secure handshake signature size: %v", size)
+	}
+	sig := make([]byte, size)
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// unbufferedByteReader reads one byte at a time, so that decoding the NodeInfo does not consume
+// the bytes following it, e.g. the first frame of the secure transport.
+type unbufferedByteReader struct {
+	io.Reader
+}
+
+func (r unbufferedByteReader) ReadByte() (byte, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r.Reader, b); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
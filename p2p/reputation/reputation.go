@@ -0,0 +1,340 @@
+package reputation
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "p2p"})
+
+const (
+	// maxScore caps the score a peer builds up with useful data, so that a long connected peer
+	// still gets banned after a burst of invalid messages
+	maxScore = 50.0
+
+	// the scores of the peers decay towards zero with this half life, so that the old events
+	// weigh less than the recent ones
+	scoreHalfLife = 10 * time.Minute
+)
+
+// eventWeights are the score changes of the peer events
+var eventWeights = map[p2ptypes.PeerEvent]float64{
+	p2ptypes.PeerEventUsefulData:     1,
+	p2ptypes.PeerEventTimeout:        -5,
+	p2ptypes.PeerEventInvalidMessage: -20,
+}
+
+//
+// Action is what the network should do with a peer after an event is reported
+//
+type Action byte
+
+const (
+	// ActionNone leaves the peer as is
+	ActionNone Action = iota
+
+	// ActionThrottle limits the receive rate of the peer, its score fell below the throttle score
+	ActionThrottle
+
+	// ActionUnthrottle restores the receive rate of the peer, its score recovered
+	ActionUnthrottle
+
+	// ActionDisconnect disconnects the peer, which is banned
+	ActionDisconnect
+)
+
+//
+// Config specifies the configuration of the Tracker
+//
+type Config struct {
+	ThrottleScore float64       // peers with a score below are throttled
+	BanScore      float64       // peers with a score below are disconnected and banned
+	BanDuration   time.Duration // how long a ban lasts
+}
+
+// GetDefaultConfig returns the default config of the Tracker
+func GetDefaultConfig() Config {
+	return Config{
+		ThrottleScore: -20,
+		BanScore:      -50,
+		BanDuration:   24 * time.Hour,
+	}
+}
+
+//
+// PeerScore summarizes the behavior of a peer
+//
+type PeerScore struct {
+	Score           float64
+	UsefulData      uint64
+	Timeouts        uint64
+	InvalidMessages uint64
+	Throttled       bool
+}
+
+// UsefulRatio returns the share of the reported events of the peer which are useful data
+func (ps PeerScore) UsefulRatio() float64 {
+	total := ps.UsefulData + ps.Timeouts + ps.InvalidMessages
+	if total == 0 {
+		return 1
+	}
+	return float64(ps.UsefulData) / float64(total)
+}
+
+type peerRecord struct {
+	PeerScore
+	updatedAt time.Time
+}
+
+// decay applies the decay of the score since the last update
+func (pr *peerRecord) decay(now time.Time) {
+	elapsed := now.Sub(pr.updatedAt)
+	if elapsed > 0 {
+		pr.Score *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	pr.updatedAt = now
+}
+
+//
+// BanEntry records a banned peer
+//
+type BanEntry struct {
+	PeerID string    `json:"peer_id"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+//
+// Tracker scores the peers by the events reported on them, i.e. invalid messages, timeouts
+// and useful data, and decides when to throttle or ban a peer. The bans are saved to file,
+// so that they survive restarts.
+//
+type Tracker struct {
+	mu *sync.Mutex
+
+	config   Config
+	filePath string
+
+	peers map[string]*peerRecord // map: peerID |-> record
+	bans  map[string]*BanEntry   // map: peerID |-> ban
+
+	now func() time.Time
+}
+
+// NewTracker creates a Tracker, loading the bans from the given file if it exists
+func NewTracker(filePath string, config Config) *Tracker {
+	t := &Tracker{
+		mu:       &sync.Mutex{},
+		config:   config,
+		filePath: filePath,
+		peers:    make(map[string]*peerRecord),
+		bans:     make(map[string]*BanEntry),
+		now:      time.Now,
+	}
+	t.loadFromFile()
+	return t
+}
+
+// Report records the event on the peer, and returns what to do with the peer
+func (t *Tracker) Report(peerID string, event p2ptypes.PeerEvent) Action {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if entry, banned := t.bans[peerID]; banned && !now.After(entry.Until) {
+		return ActionDisconnect
+	}
+
+	pr, ok := t.peers[peerID]
+	if !ok {
+		pr = &peerRecord{updatedAt: now}
+		t.peers[peerID] = pr
+	}
+	pr.decay(now)
+	pr.Score = math.Min(pr.Score+eventWeights[event], maxScore)
+	switch event {
+	case p2ptypes.PeerEventUsefulData:
+		pr.UsefulData++
+	case p2ptypes.PeerEventTimeout:
+		pr.Timeouts++
+	case p2ptypes.PeerEventInvalidMessage:
+		pr.InvalidMessages++
+	}
+
+	if pr.Score < t.config.BanScore {
+		logger.Warnf("Banning peer %v with score %.1f, useful data ratio: %.2f, timeouts: %v, invalid messages: %v",
+			peerID, pr.Score, pr.UsefulRatio(), pr.Timeouts, pr.InvalidMessages)
+		t.ban(peerID, "low score after "+event.String(), now)
+		delete(t.peers, peerID)
+		return ActionDisconnect
+	}
+	if !pr.Throttled && pr.Score < t.config.ThrottleScore {
+		logger.Infof("Throttling peer %v with score %.1f", peerID, pr.Score)
+		pr.Throttled = true
+		return ActionThrottle
+	}
+	if pr.Throttled && pr.Score >= t.config.ThrottleScore {
+		logger.Infof("Unthrottling peer %v with score %.1f", peerID, pr.Score)
+		pr.Throttled = false
+		return ActionUnthrottle
+	}
+	return ActionNone
+}
+
+// GetScore returns the current score of the peer
+func (t *Tracker) GetScore(peerID string) PeerScore {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pr, ok := t.peers[peerID]
+	if !ok {
+		return PeerScore{}
+	}
+	pr.decay(t.now())
+	return pr.PeerScore
+}
+
+// Forget drops the score of a disconnected peer, unless the score is negative, so that a
+// misbehaving peer can't reset its score by reconnecting
+func (t *Tracker) Forget(peerID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pr, ok := t.peers[peerID]
+	if !ok {
+		return
+	}
+	pr.decay(t.now())
+	if pr.Score >= 0 {
+		delete(t.peers, peerID)
+	}
+}
+
+// Ban bans the peer for the configured ban duration
+func (t *Tracker) Ban(peerID string, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ban(peerID, reason, t.now())
+}
+
+func (t *Tracker) ban(peerID string, reason string, now time.Time) {
+	t.bans[peerID] = &BanEntry{
+		PeerID: peerID,
+		Until:  now.Add(t.config.BanDuration),
+		Reason: reason,
+	}
+	t.saveToFile()
+}
+
+// Unban lifts the ban of the peer
+func (t *Tracker) Unban(peerID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.bans[peerID]; !ok {
+		return
+	}
+	delete(t.bans, peerID)
+	t.saveToFile()
+}
+
+// IsBanned returns whether the peer is banned
+func (t *Tracker) IsBanned(peerID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.bans[peerID]
+	if !ok {
+		return false
+	}
+	if t.now().After(entry.Until) {
+		delete(t.bans, peerID)
+		t.saveToFile()
+		return false
+	}
+	return true
+}
+
+// GetBans returns the bans in effect
+func (t *Tracker) GetBans() []BanEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	bans := []BanEntry{}
+	for _, entry := range t.bans {
+		if now.After(entry.Until) {
+			continue
+		}
+		bans = append(bans, *entry)
+	}
+	return bans
+}
+
+// saveToFile saves the bans in effect. It assumes the lock is held.
+func (t *Tracker) saveToFile() {
+	if len(t.filePath) == 0 {
+		return
+	}
+	now := t.now()
+	bans := []*BanEntry{}
+	for _, entry := range t.bans {
+		if now.After(entry.Until) {
+			continue
+		}
+		bans = append(bans, entry)
+	}
+	jsonBytes, err := json.MarshalIndent(bans, "", "\t")
+	if err != nil {
+		logger.Errorf("Failed to save the ban list: %v", err)
+		return
+	}
+	err = os.MkdirAll(filepath.Dir(t.filePath), 0700)
+	if err != nil {
+		logger.Errorf("Failed to create the ban list folder for file: %v, error: %v", t.filePath, err)
+		return
+	}
+	err = common.WriteFileAtomic(t.filePath, jsonBytes, 0644)
+	if err != nil {
+		logger.Errorf("Failed to save the ban list to file: %v, error: %v", t.filePath, err)
+	}
+}
+
+// loadFromFile loads the bans which have not expired. A corrupt file is discarded.
+func (t *Tracker) loadFromFile() {
+	if len(t.filePath) == 0 {
+		return
+	}
+	r, err := os.Open(t.filePath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		logger.Errorf("Error opening file %s: %v", t.filePath, err)
+		return
+	}
+	defer r.Close()
+
+	bans := []*BanEntry{}
+	if err := json.NewDecoder(r).Decode(&bans); err != nil {
+		logger.Errorf("Error reading file %s, discard the saved bans: %v", t.filePath, err)
+		return
+	}
+	now := t.now()
+	for _, entry := range bans {
+		if entry == nil || now.After(entry.Until) {
+			continue
+		}
+		t.bans[entry.PeerID] = entry
+	}
+}
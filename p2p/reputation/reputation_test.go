@@ -0,0 +1,122 @@
+package reputation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+func newTestTracker(filePath string, now *time.Time) *Tracker {
+	t := NewTracker(filePath, GetDefaultConfig())
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestTrackerThrottleAndBan(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Unix(1600000000, 0)
+	tracker := newTestTracker("", &now)
+
+	assert.Equal(ActionNone, tracker.Report("peer1", p2ptypes.PeerEventUsefulData))
+	assert.Equal(ActionNone, tracker.Report("peer1", p2ptypes.PeerEventInvalidMessage))
+	assert.Equal(ActionThrottle, tracker.Report("peer1", p2ptypes.PeerEventInvalidMessage))
+	assert.True(tracker.GetScore("peer1").Throttled)
+	assert.Equal(ActionNone, tracker.Report("peer1", p2ptypes.PeerEventTimeout))
+	assert.False(tracker.IsBanned("peer1"))
+
+	assert.Equal(ActionDisconnect, tracker.Report("peer1", p2ptypes.PeerEventInvalidMessage))
+	assert.True(tracker.IsBanned("peer1"))
+	assert.Equal(ActionDisconnect, tracker.Report("peer1", p2ptypes.PeerEventUsefulData))
+
+	// The other peers are not affected
+	assert.Equal(ActionNone, tracker.Report("peer2", p2ptypes.PeerEventUsefulData))
+	assert.False(tracker.IsBanned("peer2"))
+
+	// The ban expires
+	now = now.Add(GetDefaultConfig().BanDuration + time.Second)
+	assert.False(tracker.IsBanned("peer1"))
+	assert.Equal(ActionNone, tracker.Report("peer1", p2ptypes.PeerEventUsefulData))
+}
+
+func TestTrackerScoreDecay(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Unix(1600000000, 0)
+	tracker := newTestTracker("", &now)
+
+	tracker.Report("peer1", p2ptypes.PeerEventInvalidMessage)
+	assert.Equal(ActionThrottle, tracker.Report("peer1", p2ptypes.PeerEventTimeout))
+	assert.Equal(-25.0, tracker.GetScore("peer1").Score)
+
+	now = now.Add(scoreHalfLife)
+	assert.Equal(ActionUnthrottle, tracker.Report("peer1", p2ptypes.PeerEventUsefulData))
+	score := tracker.GetScore("peer1")
+	assert.Equal(-11.5, score.Score)
+	assert.Equal(uint64(1), score.UsefulData)
+	assert.Equal(uint64(1), score.Timeouts)
+	assert.Equal(uint64(1), score.InvalidMessages)
+	assert.InDelta(1.0/3, score.UsefulRatio(), 1e-9)
+
+	// The negative scores are kept after the peer disconnects
+	tracker.Forget("peer1")
+	assert.Equal(-11.5, tracker.GetScore("peer1").Score)
+
+	tracker.Report("peer2", p2ptypes.PeerEventUsefulData)
+	tracker.Forget("peer2")
+	assert.Equal(PeerScore{}, tracker.GetScore("peer2"))
+}
+
+func TestTrackerUsefulDataCapped(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Unix(1600000000, 0)
+	tracker := newTestTracker("", &now)
+
+	for i := 0; i < 1000; i++ {
+		tracker.Report("peer1", p2ptypes.PeerEventUsefulData)
+	}
+	assert.Equal(maxScore, tracker.GetScore("peer1").Score)
+
+	action := ActionNone
+	for i := 0; i < 6 && action != ActionDisconnect; i++ {
+		action = tracker.Report("peer1", p2ptypes.PeerEventInvalidMessage)
+	}
+	assert.Equal(ActionDisconnect, action)
+}
+
+func TestTrackerBansPersisted(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "reputation")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "banlist.json")
+
+	now := time.Now() // the bans are loaded against the wall clock
+	tracker := newTestTracker(filePath, &now)
+	tracker.Ban("peer1", "test")
+	tracker.Ban("peer2", "test")
+	tracker.Unban("peer2")
+
+	reloaded := newTestTracker(filePath, &now)
+	assert.True(reloaded.IsBanned("peer1"))
+	assert.False(reloaded.IsBanned("peer2"))
+	bans := reloaded.GetBans()
+	require.Equal(1, len(bans))
+	assert.Equal("peer1", bans[0].PeerID)
+	assert.Equal("test", bans[0].Reason)
+
+	// A corrupt ban list is discarded
+	require.Nil(ioutil.WriteFile(filePath, []byte("not json"), 0644))
+	reloaded = newTestTracker(filePath, &now)
+	assert.False(reloaded.IsBanned("peer1"))
+}
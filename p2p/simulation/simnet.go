@@ -24,6 +24,8 @@ type Simnet struct {
 	msgHandler p2p.MessageHandler
 	messages   chan Envelope
 	MsgLogs    []Envelope
+	partitions map[string]int // map: endpoint ID |-> partition index
+	pmu        *sync.RWMutex  // guards partitions
 
 	// Life cycle.
 	wg      *sync.WaitGroup
@@ -40,6 +42,7 @@ func NewSimnet() *Simnet {
 		MsgLogs:  []Envelope{},
 		wg:       &sync.WaitGroup{},
 		mu:       &sync.Mutex{},
+		pmu:      &sync.RWMutex{},
 	}
 }
 
@@ -50,6 +53,7 @@ func NewSimnetWithHandler(msgHandler p2p.MessageHandler) *Simnet {
 		messages:   make(chan Envelope, viper.GetInt(common.CfgP2PMessageQueueSize)),
 		wg:         &sync.WaitGroup{},
 		mu:         &sync.Mutex{},
+		pmu:        &sync.RWMutex{},
 	}
 }
 
@@ -102,6 +106,9 @@ func (sn *Simnet) mainLoop() {
 		case envelope := <-sn.messages:
 			time.Sleep(1 * time.Microsecond)
 			for _, endpoint := range sn.Endpoints {
+				if !sn.isReachable(envelope.From, endpoint.ID()) {
+					continue
+				}
 				if (envelope.To == "" && envelope.From != endpoint.ID()) || envelope.To == endpoint.ID() {
 					go func(endpoint *SimnetEndpoint, envelope Envelope) {
 						// Simulate network delay except for messages to self.
@@ -117,6 +124,47 @@ func (sn *Simnet) mainLoop() {
 	}
 }
 
+// Partition splits the network into the given groups of endpoints. Messages between
+// endpoints in different groups are dropped. Endpoints not listed in any group are
+// isolated from all the others.
+func (sn *Simnet) Partition(groups ...[]string) {
+	sn.pmu.Lock()
+	defer sn.pmu.Unlock()
+
+	sn.partitions = make(map[string]int)
+	for i, group := range groups {
+		for _, id := range group {
+			sn.partitions[id] = i
+		}
+	}
+}
+
+// Heal removes the partition so that all endpoints can reach each other again.
+func (sn *Simnet) Heal() {
+	sn.pmu.Lock()
+	defer sn.pmu.Unlock()
+
+	sn.partitions = nil
+}
+
+func (sn *Simnet) isReachable(from string, to string) bool {
+	sn.pmu.RLock()
+	defer sn.pmu.RUnlock()
+
+	if sn.partitions == nil || from == to {
+		return true
+	}
+	fromPartition, ok := sn.partitions[from]
+	if !ok {
+		return false
+	}
+	toPartition, ok := sn.partitions[to]
+	if !ok {
+		return false
+	}
+	return fromPartition == toPartition
+}
+
 // AddMessage send a message through the network.
 func (sn *Simnet) AddMessage(msg Envelope) {
 	sn.mu.Lock()
@@ -204,6 +252,11 @@ func (se *SimnetEndpoint) ID() string {
 	return se.id
 }
 
+// PeerStats implements the Network interface. The simulated network does not collect traffic stats.
+func (se *SimnetEndpoint) PeerStats() []p2ptypes.PeerStats {
+	return []p2ptypes.PeerStats{}
+}
+
 // HandleMessage implements the MessageHandler interface.
 func (se *SimnetEndpoint) HandleMessage(message p2ptypes.Message) error {
 	for _, handler := range se.handlers {
@@ -106,3 +106,30 @@ func TestSimnetSend(t *testing.T) {
 	msgHandler.lock.Unlock()
 	assert.EqualValues([]string{"e1 -> world!"}, msgHandler.ReceivedMessages)
 }
+
+func TestSimnetPartition(t *testing.T) {
+	assert := assert.New(t)
+	msgHandler := &SimMessageHandler{lock: &sync.Mutex{}}
+	simnet := NewSimnetWithHandler(msgHandler)
+	e1 := simnet.AddEndpoint("e1")
+	e2 := simnet.AddEndpoint("e2")
+	simnet.AddEndpoint("e3")
+	simnet.Partition([]string{"e1", "e2"}, []string{"e3"})
+	simnet.Start(context.Background())
+
+	e1.Broadcast(createBlockMessage("hello!"))
+	e1.Send("e3", createBlockMessage("dropped!"))
+	time.Sleep(1 * time.Second)
+	msgHandler.lock.Lock()
+	assert.EqualValues([]string{"e1 -> hello!"}, msgHandler.ReceivedMessages)
+	msgHandler.ReceivedMessages = make([]string, 0)
+	msgHandler.lock.Unlock()
+
+	simnet.Heal()
+	e2.Broadcast(createBlockMessage("world!"))
+	time.Sleep(1 * time.Second)
+	msgHandler.lock.Lock()
+	sort.Strings(msgHandler.ReceivedMessages)
+	msgHandler.lock.Unlock()
+	assert.EqualValues([]string{"e2 -> world!", "e2 -> world!"}, msgHandler.ReceivedMessages)
+}
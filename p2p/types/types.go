@@ -5,6 +5,8 @@ import (
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/version"
 )
 
 //
@@ -17,24 +19,108 @@ type Message struct {
 }
 
 //
-// NodeInfo provides the information of the corresponding blockchain node of the peer
+// NodeInfo provides the information of the corresponding blockchain node of the peer. It is
+// encoded as by the older nodes, the Extension being sent separately to the peers reading it,
+// see Peer.Handshake.
 //
 type NodeInfo struct {
 	PubKey      *crypto.PublicKey `rlp:"-"`
 	PubKeyBytes common.Bytes      // needed for RLP serialization
 	Port        uint16
+	Extension   NodeInfoExtension `rlp:"-"` // empty for older nodes
 }
 
+// NodeInfoExtensionVersion is the version of the NodeInfoExtension encoding
+const NodeInfoExtensionVersion uint = 1
+
+//
+// NodeInfoExtension is the information of the node the older nodes do not exchange. Later versions
+// only append fields, which the nodes of the earlier versions keep in Rest.
+//
+type NodeInfoExtension struct {
+	Version      uint // see NodeInfoExtensionVersion
+	BuildVersion string
+	GitHash      string
+	Features     []string
+	Capabilities []string
+	ObservedIP   string // IP the node sees the remote end of the connection at
+	EphemeralKey common.Bytes // public key the node generated for the secure transport, empty unless it uses it
+
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// CapabilityMessageEnvelope is the capability of wrapping the messages in a MessageEnvelope
+const CapabilityMessageEnvelope = "envelope"
+
+// capabilities lists the optional protocol features the node supports. Both ends of a connection
+// need to support a capability for it to be used.
+var capabilities = []string{CapabilityMessageEnvelope}
+
 // CreateNodeInfo creates an instance of NodeInfo
 func CreateNodeInfo(pubKey *crypto.PublicKey, port uint16) NodeInfo {
+	info := version.GetInfo()
 	nodeInfo := NodeInfo{
 		PubKey:      pubKey,
 		PubKeyBytes: pubKey.ToBytes(),
 		Port:        port,
+		Extension: NodeInfoExtension{
+			Version:      NodeInfoExtensionVersion,
+			BuildVersion: info.Version,
+			GitHash:      info.GitHash,
+			Features:     info.Features,
+			Capabilities: capabilities,
+		},
 	}
 	return nodeInfo
 }
 
+// Version returns the version of the node, or an empty string if the node does not report it
+func (info NodeInfo) Version() string {
+	return info.Extension.BuildVersion
+}
+
+// GitHash returns the commit the node is built from, or an empty string if the node does not report it
+func (info NodeInfo) GitHash() string {
+	return info.Extension.GitHash
+}
+
+// HasCapability returns whether the node supports the given capability. Older nodes do not report
+// any capability.
+func (info NodeInfo) HasCapability(capability string) bool {
+	for _, c := range info.Extension.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// ObservedIP returns the IP the node sees the remote end of the connection at, which tells a node
+// behind a NAT its external IP, or an empty string if the node does not report it
+func (info NodeInfo) ObservedIP() string {
+	return info.Extension.ObservedIP
+}
+
+// WithObservedIP returns a copy of the NodeInfo reporting the IP the remote end of the connection
+// is seen at
+func (info NodeInfo) WithObservedIP(ip string) NodeInfo {
+	info.Extension.ObservedIP = ip
+	return info
+}
+
+// EphemeralKey returns the public key the node generated for the connection to establish the
+// secure transport, or nil if the node does not use it
+func (info NodeInfo) EphemeralKey() common.Bytes {
+	return info.Extension.EphemeralKey
+}
+
+// WithEphemeralKey returns a copy of the NodeInfo carrying the ephemeral public key of the secure
+// transport
+func (info NodeInfo) WithEphemeralKey(key *crypto.PublicKey) NodeInfo {
+	info.Extension.EphemeralKey = key.ToBytes()
+	return info
+}
+
 const (
 	// PingSignal represents a ping signal to a peer
 	PingSignal = byte(0x0)
@@ -55,3 +141,98 @@ func (se StackError) String() string {
 func (se StackError) Error() string {
 	return se.String()
 }
+
+//
+// ChannelStats summarizes the traffic of a peer on one of the channels
+//
+type ChannelStats struct {
+	ChannelID         common.ChannelIDEnum
+	MsgsReceived      uint64
+	BytesReceived     uint64
+	MsgsSent          uint64
+	BytesSent         uint64
+	DecodeFailures    uint64
+	ReplaysRejected   uint64 // stale or replayed messages from the peer
+	AvgHandlingMicros uint64 // average time spent handling a received message, in microseconds
+}
+
+//
+// PeerStats summarizes the traffic of a peer on all the channels
+//
+type PeerStats struct {
+	PeerID     string
+	NetAddress string
+	IsOutbound bool
+	Version    string
+	GitHash    string
+	Score      float64 // reputation score of the peer, see PeerEvent
+	Channels   []ChannelStats
+}
+
+//
+// PeerEvent is a behavior of a peer which affects its reputation
+//
+type PeerEvent byte
+
+const (
+	// PeerEventUsefulData is reported when the peer delivers data the node uses, e.g. a new block
+	PeerEventUsefulData PeerEvent = iota
+
+	// PeerEventTimeout is reported when the peer fails to answer a request in time
+	PeerEventTimeout
+
+	// PeerEventInvalidMessage is reported when the peer sends a message that can't be decoded
+	// or is invalid
+	PeerEventInvalidMessage
+)
+
+func (e PeerEvent) String() string {
+	switch e {
+	case PeerEventUsefulData:
+		return "useful_data"
+	case PeerEventTimeout:
+		return "timeout"
+	case PeerEventInvalidMessage:
+		return "invalid_message"
+	default:
+		return fmt.Sprintf("event_%d", e)
+	}
+}
+
+// ChannelName returns a human readable name of the channel, e.g. for labeling the metrics
+func ChannelName(channelID common.ChannelIDEnum) string {
+	switch channelID {
+	case common.ChannelIDCheckpoint:
+		return "checkpoint"
+	case common.ChannelIDHeader:
+		return "header"
+	case common.ChannelIDBlock:
+		return "block"
+	case common.ChannelIDProposal:
+		return "proposal"
+	case common.ChannelIDCC:
+		return "cc"
+	case common.ChannelIDVote:
+		return "vote"
+	case common.ChannelIDTransaction:
+		return "transaction"
+	case common.ChannelIDPeerDiscovery:
+		return "peer_discovery"
+	case common.ChannelIDPing:
+		return "ping"
+	case common.ChannelIDValidatorMesh:
+		return "validator_mesh"
+	case common.ChannelIDGuardian:
+		return "guardian"
+	case common.ChannelIDMempoolSync:
+		return "mempool_sync"
+	case common.ChannelIDSnapshotRequest:
+		return "snapshot_request"
+	case common.ChannelIDSnapshotResponse:
+		return "snapshot_response"
+	case common.ChannelIDNodeInfo:
+		return "node_info"
+	default:
+		return fmt.Sprintf("channel_%d", channelID)
+	}
+}
@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
 	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/version"
 )
 
 func TestNodeInfoRLPEncoding1(t *testing.T) {
@@ -55,3 +57,98 @@ func TestNodeInfoRLPEncoding2(t *testing.T) {
 
 	assert.Equal(nodeInfo.PubKey.Address(), decodedNodeInfo.PubKey.Address())
 }
+
+func TestNodeInfoExtension(t *testing.T) {
+	assert := assert.New(t)
+
+	_, randPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	nodeInfo := CreateNodeInfo(randPubKey, 1234)
+
+	assert.Equal(version.Version, nodeInfo.Version())
+	assert.Equal(version.GitHash, nodeInfo.GitHash())
+	assert.True(nodeInfo.HasCapability(CapabilityMessageEnvelope))
+	assert.False(nodeInfo.HasCapability("unknown"))
+
+	// The NodeInfo is encoded as by the older nodes, which decode it
+	legacyNodeInfo := struct {
+		PubKeyBytes common.Bytes
+		Port        uint16
+	}{}
+	encodedNodeInfoBytes, err := rlp.EncodeToBytes(nodeInfo)
+	assert.Nil(err)
+	assert.Nil(rlp.DecodeBytes(encodedNodeInfoBytes, &legacyNodeInfo))
+	assert.Equal(uint16(1234), legacyNodeInfo.Port)
+
+	// Older nodes do not send the build info
+	var decodedNodeInfo NodeInfo
+	assert.Nil(rlp.DecodeBytes(encodedNodeInfoBytes, &decodedNodeInfo))
+	assert.Equal(uint16(1234), decodedNodeInfo.Port)
+	assert.Equal("", decodedNodeInfo.Version())
+	assert.Equal("", decodedNodeInfo.GitHash())
+	assert.False(decodedNodeInfo.HasCapability(CapabilityMessageEnvelope))
+
+	// The fields appended by later versions of the extension are kept aside
+	ext := nodeInfo.Extension
+	newerExtension := struct {
+		Version      uint
+		BuildVersion string
+		GitHash      string
+		Features     []string
+		Capabilities []string
+		ObservedIP   string
+		EphemeralKey common.Bytes
+		NewField     string
+	}{NodeInfoExtensionVersion + 1, ext.BuildVersion, ext.GitHash, ext.Features, ext.Capabilities, "", nil, "new"}
+	encodedExtensionBytes, err := rlp.EncodeToBytes(newerExtension)
+	assert.Nil(err)
+	decodedNodeInfo = NodeInfo{}
+	assert.Nil(rlp.DecodeBytes(encodedExtensionBytes, &decodedNodeInfo.Extension))
+	assert.Equal(NodeInfoExtensionVersion+1, decodedNodeInfo.Extension.Version)
+	assert.Equal(version.GitHash, decodedNodeInfo.GitHash())
+	assert.True(decodedNodeInfo.HasCapability(CapabilityMessageEnvelope))
+	assert.Equal(1, len(decodedNodeInfo.Extension.Rest))
+}
+
+func TestNodeInfoObservedIP(t *testing.T) {
+	assert := assert.New(t)
+
+	_, randPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	nodeInfo := CreateNodeInfo(randPubKey, 1234)
+	assert.Equal("", nodeInfo.ObservedIP())
+
+	observed := nodeInfo.WithObservedIP("203.0.113.7")
+	assert.Equal("", nodeInfo.ObservedIP()) // the original is not modified
+
+	// The extension is sent separately from the NodeInfo
+	encodedExtensionBytes, err := rlp.EncodeToBytes(observed.Extension)
+	assert.Nil(err)
+	var decodedNodeInfo NodeInfo
+	assert.Nil(rlp.DecodeBytes(encodedExtensionBytes, &decodedNodeInfo.Extension))
+	assert.Equal("203.0.113.7", decodedNodeInfo.ObservedIP())
+	assert.Equal(version.Version, decodedNodeInfo.Version())
+	assert.True(decodedNodeInfo.HasCapability(CapabilityMessageEnvelope))
+}
+
+func TestNodeInfoEphemeralKey(t *testing.T) {
+	assert := assert.New(t)
+
+	_, randPubKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	nodeInfo := CreateNodeInfo(randPubKey, 1234)
+	assert.Equal(0, len(nodeInfo.EphemeralKey()))
+
+	_, ephemeralKey, err := crypto.GenerateKeyPair()
+	assert.Nil(err)
+	withKey := nodeInfo.WithObservedIP("203.0.113.7").WithEphemeralKey(ephemeralKey)
+	assert.Equal(0, len(nodeInfo.EphemeralKey())) // the original is not modified
+
+	// The extension is sent separately from the NodeInfo
+	encodedExtensionBytes, err := rlp.EncodeToBytes(withKey.Extension)
+	assert.Nil(err)
+	var decodedNodeInfo NodeInfo
+	assert.Nil(rlp.DecodeBytes(encodedExtensionBytes, &decodedNodeInfo.Extension))
+	assert.Equal(ephemeralKey.ToBytes(), decodedNodeInfo.EphemeralKey())
+	assert.Equal("203.0.113.7", decodedNodeInfo.ObservedIP())
+}
@@ -0,0 +1,444 @@
+package validatormesh
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/p2p"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+	"github.com/thetatoken/theta/rlp"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "mesh"})
+
+const (
+	// Interval at which the connections to the validators are checked
+	maintainInterval = 10 * time.Second
+	// Interval at which a validator announces its address
+	announceInterval = 5 * time.Minute
+	// Announcements older than this are ignored
+	maxAnnouncementAge = 24 * time.Hour
+	// Announcements timestamped further in the future than this are ignored
+	maxClockDrift = 10 * time.Minute
+	nonceSize     = 32
+)
+
+// Connector connects to a peer at the given network address, see messenger.Messenger.
+type Connector interface {
+	ConnectToPeer(netAddress string) error
+}
+
+// Mesh maintains direct connections between the validators of the current validator set. The
+// validators learn each other's address from the endpoints registered on chain with a
+// RegisterNodeAddressTx, and otherwise from the signed announcements relayed by all the nodes
+// running the mesh, or the configured validator peers. A connected validator is authenticated
+// with a challenge signed by its validator key.
+//
+// The votes and proposals are broadcast to all the connected peers, so once the validators are
+// connected to each other they reach the other validators directly instead of hopping through
+// the gossip network, which stays the fallback.
+type Mesh struct {
+	privateKey *crypto.PrivateKey // nil for a node which is not a validator
+	network    p2p.Network
+	connector  Connector // nil if the network cannot dial addresses
+	consensus  core.ConsensusEngine
+	valMgr     core.ValidatorManager
+	netAddress string // announced address, no announcement if empty
+
+	mu             *sync.Mutex
+	configuredAddr map[common.Address]string
+	announcements  map[common.Address]*Announcement
+	challenges     map[string]common.Bytes   // peer ID -> nonce of the pending challenge
+	authenticated  map[string]common.Address // peer ID -> validator
+	dialing        map[common.Address]bool
+	attempts       map[common.Address]int // number of dials, to rotate through the registered endpoints
+	lastAnnounced  time.Time
+
+	now func() time.Time
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewMesh creates a new instance of Mesh. validatorPeers lists known validator addresses, each in
+// the format <validator address>@<host:port>.
+func NewMesh(privateKey *crypto.PrivateKey, network p2p.Network, consensus core.ConsensusEngine,
+	valMgr core.ValidatorManager, netAddress string, validatorPeers []string) (*Mesh, error) {
+	m := &Mesh{
+		privateKey:     privateKey,
+		network:        network,
+		consensus:      consensus,
+		valMgr:         valMgr,
+		netAddress:     netAddress,
+		mu:             &sync.Mutex{},
+		configuredAddr: make(map[common.Address]string),
+		announcements:  make(map[common.Address]*Announcement),
+		challenges:     make(map[string]common.Bytes),
+		authenticated:  make(map[string]common.Address),
+		dialing:        make(map[common.Address]bool),
+		attempts:       make(map[common.Address]int),
+		now:            time.Now,
+		wg:             &sync.WaitGroup{},
+	}
+	if connector, ok := network.(Connector); ok {
+		m.connector = connector
+	}
+	for _, validatorPeer := range validatorPeers {
+		validatorPeer = strings.TrimSpace(validatorPeer)
+		if len(validatorPeer) == 0 {
+			continue
+		}
+		tokens := strings.Split(validatorPeer, "@")
+		if len(tokens) != 2 || !common.IsHexAddress(tokens[0]) || len(tokens[1]) == 0 {
+			return nil, fmt.Errorf("Invalid validator peer %v, expected <validator address>@<host:port>", validatorPeer)
+		}
+		m.configuredAddr[common.HexToAddress(tokens[0])] = tokens[1]
+	}
+	return m, nil
+}
+
+// Start creates the main goroutine.
+func (m *Mesh) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	m.ctx = c
+	m.cancel = cancel
+
+	m.wg.Add(1)
+	go m.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (m *Mesh) Stop() {
+	m.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (m *Mesh) Wait() {
+	m.wg.Wait()
+}
+
+// AuthenticatedValidators returns the validators connected directly and authenticated.
+func (m *Mesh) AuthenticatedValidators() []common.Address {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ret := []common.Address{}
+	for _, validator := range m.authenticated {
+		ret = append(ret, validator)
+	}
+	return ret
+}
+
+func (m *Mesh) mainLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(maintainInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.maintain()
+		}
+	}
+}
+
+// maintain announces the address of this validator, challenges the connected validators which
+// are not authenticated yet, and dials the validators which are not connected.
+func (m *Mesh) maintain() {
+	validators := m.currentValidators()
+	isValidator := m.privateKey != nil && validators[m.privateKey.PublicKey().Address()]
+
+	connected := make(map[string]bool)
+	for _, stats := range m.network.PeerStats() {
+		connected[stats.PeerID] = true
+	}
+
+	m.mu.Lock()
+	for peerID := range m.authenticated {
+		if !connected[peerID] {
+			delete(m.authenticated, peerID)
+		}
+	}
+	for peerID := range m.challenges {
+		if !connected[peerID] {
+			delete(m.challenges, peerID)
+		}
+	}
+	connectedValidators := make(map[common.Address]bool)
+	challenges := make(map[string]common.Bytes)
+	for peerID := range connected {
+		addr := common.HexToAddress(peerID)
+		if !validators[addr] {
+			continue
+		}
+		connectedValidators[addr] = true
+		if _, ok := m.authenticated[peerID]; ok {
+			continue
+		}
+		if _, ok := m.challenges[peerID]; ok {
+			continue
+		}
+		nonce := make([]byte, nonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			continue
+		}
+		m.challenges[peerID] = nonce
+		challenges[peerID] = nonce
+	}
+	m.mu.Unlock()
+
+	for peerID, nonce := range challenges {
+		m.send(peerID, challengeType, &Challenge{Nonce: nonce})
+	}
+
+	if !isValidator {
+		return
+	}
+
+	if len(m.netAddress) > 0 && m.now().Sub(m.lastAnnounced) >= announceInterval {
+		m.announce()
+	}
+
+	if m.connector == nil {
+		return
+	}
+	for validator := range validators {
+		if validator == m.privateKey.PublicKey().Address() || connectedValidators[validator] {
+			continue
+		}
+		m.dial(validator, m.registeredEndpoints(validator))
+	}
+}
+
+// registeredEndpoints returns the endpoints the validator registered on chain, as of the tip.
+func (m *Mesh) registeredEndpoints(validator common.Address) []string {
+	ledger := m.consensus.GetLedger()
+	tip := m.consensus.GetTip(true)
+	if ledger == nil || tip == nil {
+		return nil
+	}
+	nodeAddress, err := ledger.GetNodeAddress(tip.Hash(), validator)
+	if err != nil || nodeAddress == nil {
+		return nil
+	}
+	return nodeAddress.ActiveEndpoints(tip.Height)
+}
+
+// dial connects to the validator. The endpoints registered on chain are tried in turn, the
+// announced and the configured addresses are the fallback for the validators which have not
+// registered any.
+func (m *Mesh) dial(validator common.Address, registered []string) {
+	m.mu.Lock()
+	netAddress := m.configuredAddr[validator]
+	if announcement, ok := m.announcements[validator]; ok {
+		netAddress = announcement.NetAddress
+	}
+	if len(registered) > 0 {
+		netAddress = registered[m.attempts[validator]%len(registered)]
+	}
+	if len(netAddress) == 0 || m.dialing[validator] {
+		m.mu.Unlock()
+		return
+	}
+	m.attempts[validator]++
+	m.dialing[validator] = true
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := m.connector.ConnectToPeer(netAddress); err != nil {
+			logger.WithFields(log.Fields{"validator": validator.Hex(), "address": netAddress, "error": err}).Debug("Failed to connect to validator")
+		}
+		m.mu.Lock()
+		delete(m.dialing, validator)
+		m.mu.Unlock()
+	}()
+}
+
+func (m *Mesh) announce() {
+	announcement := &Announcement{
+		Validator:  m.privateKey.PublicKey().Address(),
+		NetAddress: m.netAddress,
+		Timestamp:  uint64(m.now().Unix()),
+	}
+	sig, err := m.privateKey.Sign(announcement.SignBytes())
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to sign announcement")
+		return
+	}
+	announcement.Signature = sig
+	m.lastAnnounced = m.now()
+	m.broadcast(announcementType, announcement)
+}
+
+// currentValidators returns the validators of the tip, and the next validator set.
+func (m *Mesh) currentValidators() map[common.Address]bool {
+	ret := make(map[common.Address]bool)
+	tip := m.consensus.GetTip(true)
+	if tip == nil {
+		return ret
+	}
+	for _, vs := range []*core.ValidatorSet{m.valMgr.GetValidatorSet(tip.Hash()), m.valMgr.GetNextValidatorSet(tip.Hash())} {
+		if vs == nil {
+			continue
+		}
+		for _, v := range vs.Validators() {
+			ret[v.ID()] = true
+		}
+	}
+	return ret
+}
+
+// GetChannelIDs implements the p2p.MessageHandler interface.
+func (m *Mesh) GetChannelIDs() []common.ChannelIDEnum {
+	return []common.ChannelIDEnum{
+		common.ChannelIDValidatorMesh,
+	}
+}
+
+// ParseMessage implements the p2p.MessageHandler interface.
+func (m *Mesh) ParseMessage(peerID string, channelID common.ChannelIDEnum, rawMessageBytes common.Bytes) (p2ptypes.Message, error) {
+	message := &Message{}
+	err := rlp.DecodeBytes(rawMessageBytes, message)
+	return p2ptypes.Message{
+		PeerID:    peerID,
+		ChannelID: channelID,
+		Content:   message,
+	}, err
+}
+
+// EncodeMessage implements the p2p.MessageHandler interface.
+func (m *Mesh) EncodeMessage(message interface{}) (common.Bytes, error) {
+	return rlp.EncodeToBytes(message)
+}
+
+// HandleMessage implements the p2p.MessageHandler interface.
+func (m *Mesh) HandleMessage(msg p2ptypes.Message) error {
+	message, ok := msg.Content.(*Message)
+	if !ok {
+		return errors.New("Invalid validator mesh message")
+	}
+	switch message.Type {
+	case announcementType:
+		announcement := &Announcement{}
+		if err := rlp.DecodeBytes(message.Payload, announcement); err != nil {
+			return err
+		}
+		return m.handleAnnouncement(announcement)
+	case challengeType:
+		challenge := &Challenge{}
+		if err := rlp.DecodeBytes(message.Payload, challenge); err != nil {
+			return err
+		}
+		return m.handleChallenge(msg.PeerID, challenge)
+	case responseType:
+		response := &Response{}
+		if err := rlp.DecodeBytes(message.Payload, response); err != nil {
+			return err
+		}
+		return m.handleResponse(msg.PeerID, response)
+	default:
+		return fmt.Errorf("Unknown validator mesh message type %v", message.Type)
+	}
+}
+
+// handleAnnouncement records the announcement of a validator of the current validator sets, and
+// relays it if it is newer than the one known so far.
+func (m *Mesh) handleAnnouncement(announcement *Announcement) error {
+	if !m.currentValidators()[announcement.Validator] {
+		return fmt.Errorf("Announcement from %v, which is not a validator", announcement.Validator.Hex())
+	}
+	now := m.now()
+	timestamp := time.Unix(int64(announcement.Timestamp), 0)
+	if timestamp.After(now.Add(maxClockDrift)) || timestamp.Before(now.Add(-maxAnnouncementAge)) {
+		return fmt.Errorf("Announcement of %v is outdated", announcement.Validator.Hex())
+	}
+	if announcement.Signature == nil || !announcement.Signature.Verify(announcement.SignBytes(), announcement.Validator) {
+		return fmt.Errorf("Invalid signature of the announcement of %v", announcement.Validator.Hex())
+	}
+
+	m.mu.Lock()
+	if known, ok := m.announcements[announcement.Validator]; ok && known.Timestamp >= announcement.Timestamp {
+		m.mu.Unlock()
+		return nil
+	}
+	m.announcements[announcement.Validator] = announcement
+	m.mu.Unlock()
+
+	logger.WithFields(log.Fields{"validator": announcement.Validator.Hex(), "address": announcement.NetAddress}).Debug("Received validator announcement")
+	m.broadcast(announcementType, announcement)
+	return nil
+}
+
+func (m *Mesh) handleChallenge(peerID string, challenge *Challenge) error {
+	if m.privateKey == nil {
+		return nil
+	}
+	if len(challenge.Nonce) != nonceSize {
+		return errors.New("Invalid challenge")
+	}
+	sig, err := m.privateKey.Sign(responseSignBytes(challenge.Nonce, common.HexToAddress(peerID)))
+	if err != nil {
+		return err
+	}
+	m.send(peerID, responseType, &Response{Nonce: challenge.Nonce, Signature: sig})
+	return nil
+}
+
+func (m *Mesh) handleResponse(peerID string, response *Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nonce, ok := m.challenges[peerID]
+	if !ok || !bytes.Equal(nonce, response.Nonce) {
+		return fmt.Errorf("Unexpected response from %v", peerID)
+	}
+	delete(m.challenges, peerID)
+
+	validator := common.HexToAddress(peerID)
+	if response.Signature == nil || !response.Signature.Verify(responseSignBytes(nonce, common.HexToAddress(m.network.ID())), validator) {
+		return fmt.Errorf("Peer %v failed to authenticate as validator", peerID)
+	}
+	m.authenticated[peerID] = validator
+	logger.WithFields(log.Fields{"validator": validator.Hex()}).Info("Validator connected directly")
+	return nil
+}
+
+func (m *Mesh) send(peerID string, msgType MessageType, payload interface{}) {
+	message, err := encodeMessage(msgType, payload)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to encode validator mesh message")
+		return
+	}
+	m.network.Send(peerID, p2ptypes.Message{
+		ChannelID: common.ChannelIDValidatorMesh,
+		Content:   message,
+	})
+}
+
+func (m *Mesh) broadcast(msgType MessageType, payload interface{}) {
+	message, err := encodeMessage(msgType, payload)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to encode validator mesh message")
+		return
+	}
+	m.network.Broadcast(p2ptypes.Message{
+		ChannelID: common.ChannelIDValidatorMesh,
+		Content:   message,
+	})
+}
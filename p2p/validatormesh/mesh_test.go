@@ -0,0 +1,286 @@
+package validatormesh
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/p2p"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+// testNetwork delivers the messages synchronously between the meshes of a test.
+type testNetwork struct {
+	id       string
+	peers    map[string]*testNetwork
+	handler  p2p.MessageHandler
+	mu       *sync.Mutex
+	dialed   []string
+	numSends int
+}
+
+func newTestNetwork(privKey *crypto.PrivateKey) *testNetwork {
+	return &testNetwork{
+		id:    privKey.PublicKey().Address().Hex(),
+		peers: make(map[string]*testNetwork),
+		mu:    &sync.Mutex{},
+	}
+}
+
+func connect(a, b *testNetwork) {
+	a.peers[b.id] = b
+	b.peers[a.id] = a
+}
+
+func (n *testNetwork) Start(ctx context.Context) error { return nil }
+func (n *testNetwork) Wait()                           {}
+func (n *testNetwork) Stop()                           {}
+func (n *testNetwork) ID() string                      { return n.id }
+
+func (n *testNetwork) RegisterMessageHandler(handler p2p.MessageHandler) {
+	n.handler = handler
+}
+
+func (n *testNetwork) Broadcast(message p2ptypes.Message) chan bool {
+	for peerID := range n.peers {
+		n.Send(peerID, message)
+	}
+	return nil
+}
+
+func (n *testNetwork) Send(peerID string, message p2ptypes.Message) bool {
+	n.numSends++
+	peer, ok := n.peers[peerID]
+	if !ok {
+		return false
+	}
+	raw, err := n.handler.EncodeMessage(message.Content)
+	if err != nil {
+		return false
+	}
+	msg, err := peer.handler.ParseMessage(n.id, message.ChannelID, raw)
+	if err != nil {
+		return false
+	}
+	peer.handler.HandleMessage(msg)
+	return true
+}
+
+func (n *testNetwork) PeerStats() []p2ptypes.PeerStats {
+	ret := []p2ptypes.PeerStats{}
+	for peerID := range n.peers {
+		ret = append(ret, p2ptypes.PeerStats{PeerID: peerID})
+	}
+	return ret
+}
+
+func (n *testNetwork) ConnectToPeer(netAddress string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dialed = append(n.dialed, netAddress)
+	return nil
+}
+
+func (n *testNetwork) getDialed() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string{}, n.dialed...)
+}
+
+type testConsensus struct {
+	core.ConsensusEngine
+	tip    *core.ExtendedBlock
+	ledger core.Ledger
+}
+
+func (c *testConsensus) GetTip(includePendingBlockingLeaf bool) *core.ExtendedBlock {
+	return c.tip
+}
+
+func (c *testConsensus) GetLedger() core.Ledger {
+	return c.ledger
+}
+
+type testLedger struct {
+	core.Ledger
+	nodeAddresses map[common.Address]*core.NodeAddress
+}
+
+func (l *testLedger) GetNodeAddress(blockHash common.Hash, validator common.Address) (*core.NodeAddress, error) {
+	return l.nodeAddresses[validator], nil
+}
+
+type testValidatorManager struct {
+	core.ValidatorManager
+	vs *core.ValidatorSet
+}
+
+func (m *testValidatorManager) GetValidatorSet(blockHash common.Hash) *core.ValidatorSet {
+	return m.vs
+}
+
+func (m *testValidatorManager) GetNextValidatorSet(blockHash common.Hash) *core.ValidatorSet {
+	return m.vs
+}
+
+func newTestMesh(t *testing.T, name string, privKey *crypto.PrivateKey, validators []*crypto.PrivateKey, netAddress string, validatorPeers []string) (*Mesh, *testNetwork) {
+	vs := core.NewValidatorSet()
+	for _, v := range validators {
+		vs.AddValidator(core.NewValidator(v.PublicKey().Address().Hex(), big.NewInt(1)))
+	}
+	consensus := &testConsensus{tip: &core.ExtendedBlock{Block: core.CreateTestBlock(name, "")}}
+	network := newTestNetwork(privKey)
+	m, err := NewMesh(privKey, network, consensus, &testValidatorManager{vs: vs}, netAddress, validatorPeers)
+	require.Nil(t, err)
+	network.RegisterMessageHandler(m)
+	return m, network
+}
+
+func generateKeys(t *testing.T, n int) []*crypto.PrivateKey {
+	ret := []*crypto.PrivateKey{}
+	for i := 0; i < n; i++ {
+		privKey, _, err := crypto.GenerateKeyPair()
+		require.Nil(t, err)
+		ret = append(ret, privKey)
+	}
+	return ret
+}
+
+func TestMeshAuthentication(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateKeys(t, 3)
+	validators := keys[:2]
+
+	a, na := newTestMesh(t, "mesh_auth_a", keys[0], validators, "", nil)
+	b, nb := newTestMesh(t, "mesh_auth_b", keys[1], validators, "", nil)
+	c, nc := newTestMesh(t, "mesh_auth_c", keys[2], validators, "", nil)
+	connect(na, nb)
+	connect(na, nc)
+
+	a.maintain()
+	b.maintain()
+	c.maintain()
+
+	assert.Equal([]common.Address{keys[1].PublicKey().Address()}, a.AuthenticatedValidators())
+	assert.Equal([]common.Address{keys[0].PublicKey().Address()}, b.AuthenticatedValidators())
+	assert.Equal([]common.Address{keys[0].PublicKey().Address()}, c.AuthenticatedValidators())
+
+	// A peer claiming the ID of a validator without its key fails the challenge
+	d, nd := newTestMesh(t, "mesh_auth_d", keys[0], validators, "", nil)
+	impostor, ni := newTestMesh(t, "mesh_auth_impostor", keys[2], validators, "", nil)
+	ni.id = keys[1].PublicKey().Address().Hex()
+	connect(nd, ni)
+	d.maintain()
+	assert.Empty(d.AuthenticatedValidators())
+	assert.Empty(impostor.AuthenticatedValidators())
+
+	// The state of the disconnected peers is dropped
+	delete(na.peers, nb.id)
+	a.maintain()
+	assert.Empty(a.AuthenticatedValidators())
+}
+
+func TestMeshAnnouncement(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateKeys(t, 3)
+	validators := keys[:2]
+
+	a, na := newTestMesh(t, "mesh_announce_a", keys[0], validators, "10.0.0.1:12000", nil)
+	b, nb := newTestMesh(t, "mesh_announce_b", keys[1], validators, "", nil)
+	_, nc := newTestMesh(t, "mesh_announce_c", keys[2], validators, "", nil)
+	connect(na, nc)
+
+	// The announcement of a is relayed by c to b, which dials a
+	a.maintain()
+	connect(nb, nc)
+	c := nc.handler.(*Mesh)
+	for _, announcement := range c.announcements {
+		c.broadcast(announcementType, announcement)
+	}
+	b.maintain()
+	b.Wait()
+	assert.Equal([]string{"10.0.0.1:12000"}, nb.getDialed())
+
+	// A forged announcement is rejected
+	forged := &Announcement{
+		Validator:  keys[0].PublicKey().Address(),
+		NetAddress: "10.6.6.6:12000",
+		Timestamp:  uint64(time.Now().Unix() + 1),
+	}
+	forged.Signature, _ = keys[2].Sign(forged.SignBytes())
+	assert.NotNil(b.handleAnnouncement(forged))
+	assert.Equal("10.0.0.1:12000", b.announcements[keys[0].PublicKey().Address()].NetAddress)
+
+	// So is the announcement of a node which is not a validator
+	other := &Announcement{
+		Validator:  keys[2].PublicKey().Address(),
+		NetAddress: "10.0.0.3:12000",
+		Timestamp:  uint64(time.Now().Unix()),
+	}
+	other.Signature, _ = keys[2].Sign(other.SignBytes())
+	assert.NotNil(b.handleAnnouncement(other))
+
+	// And an outdated one
+	old := &Announcement{
+		Validator:  keys[0].PublicKey().Address(),
+		NetAddress: "10.0.0.1:12000",
+		Timestamp:  uint64(time.Now().Add(-2 * maxAnnouncementAge).Unix()),
+	}
+	old.Signature, _ = keys[0].Sign(old.SignBytes())
+	assert.NotNil(b.handleAnnouncement(old))
+
+	// A replayed announcement is not relayed again
+	numSends := nb.numSends
+	assert.Nil(b.handleAnnouncement(b.announcements[keys[0].PublicKey().Address()]))
+	assert.Equal(numSends, nb.numSends)
+}
+
+func TestMeshValidatorPeers(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateKeys(t, 2)
+
+	_, err := NewMesh(keys[0], newTestNetwork(keys[0]), nil, nil, "", []string{"10.0.0.1:12000"})
+	assert.NotNil(err)
+
+	validatorPeers := []string{keys[1].PublicKey().Address().Hex() + "@10.0.0.2:12000"}
+	a, na := newTestMesh(t, "mesh_peers_a", keys[0], keys, "", validatorPeers)
+	a.maintain()
+	a.Wait()
+	assert.Equal([]string{"10.0.0.2:12000"}, na.getDialed())
+
+	// A node which is not a validator does not dial
+	b, nb := newTestMesh(t, "mesh_peers_b", keys[0], keys[1:], "", validatorPeers)
+	b.maintain()
+	b.Wait()
+	assert.Empty(nb.getDialed())
+}
+
+func TestMeshRegisteredEndpoints(t *testing.T) {
+	assert := assert.New(t)
+	keys := generateKeys(t, 2)
+
+	// The endpoints registered on chain take precedence, and are tried in turn
+	validatorPeers := []string{keys[1].PublicKey().Address().Hex() + "@10.0.0.2:12000"}
+	a, na := newTestMesh(t, "mesh_registered_a", keys[0], keys, "", validatorPeers)
+	a.consensus.(*testConsensus).ledger = &testLedger{
+		nodeAddresses: map[common.Address]*core.NodeAddress{
+			keys[1].PublicKey().Address(): {
+				Validator: keys[1].PublicKey().Address(),
+				Endpoints: []string{"validator1.example.com:12000", "203.0.113.7:12000"},
+			},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		a.maintain()
+		a.Wait()
+	}
+	assert.Equal([]string{"validator1.example.com:12000", "203.0.113.7:12000", "validator1.example.com:12000"}, na.getDialed())
+}
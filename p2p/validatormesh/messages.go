@@ -0,0 +1,64 @@
+package validatormesh
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// MessageType defines the types of the validator mesh messages
+type MessageType byte
+
+const (
+	announcementType MessageType = 0x01
+	challengeType    MessageType = 0x02
+	responseType     MessageType = 0x03
+)
+
+// Message is the envelope of the validator mesh messages, the payload is the RLP encoding of an
+// Announcement, a Challenge or a Response.
+type Message struct {
+	Type    MessageType
+	Payload common.Bytes
+}
+
+// Announcement is the network address a validator announces to the other validators. It is
+// signed with the validator key, and relayed by all the nodes running the mesh.
+type Announcement struct {
+	Validator  common.Address
+	NetAddress string
+	Timestamp  uint64 // Unix time in seconds, a newer announcement replaces the older ones
+	Signature  *crypto.Signature
+}
+
+// SignBytes returns the bytes signed by the validator.
+func (a *Announcement) SignBytes() common.Bytes {
+	raw, _ := rlp.EncodeToBytes([]interface{}{"theta-validator-mesh-announcement", a.Validator, a.NetAddress, a.Timestamp})
+	return raw
+}
+
+// Challenge asks a peer to prove that it holds the key of its ID.
+type Challenge struct {
+	Nonce common.Bytes
+}
+
+// Response is the proof of a peer that it holds the key of its ID.
+type Response struct {
+	Nonce     common.Bytes
+	Signature *crypto.Signature
+}
+
+// responseSignBytes returns the bytes signed by the peer answering the challenge. The ID of the
+// challenger is included, so that the response cannot be replayed to another node.
+func responseSignBytes(nonce common.Bytes, challenger common.Address) common.Bytes {
+	raw, _ := rlp.EncodeToBytes([]interface{}{"theta-validator-mesh-response", nonce, challenger})
+	return raw
+}
+
+func encodeMessage(msgType MessageType, payload interface{}) (*Message, error) {
+	raw, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Type: msgType, Payload: raw}, nil
+}
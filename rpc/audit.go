@@ -0,0 +1,282 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+// MaxAuditEntriesPerQuery is the max number of audit log entries returned by GetAuditLog.
+const MaxAuditEntriesPerQuery = 1000
+
+// maxAuditEntrySize is the max size of an audit log entry, which includes the params of the call
+const maxAuditEntrySize = 16 * 1024 * 1024
+
+// auditedMethods are the RPC methods acting on the node, whose invocations are recorded in the
+// audit log along with the admin methods.
+var auditedMethods = map[string]bool{
+	"theta.BackupSnapshot": true,
+	"theta.BackupChain":    true,
+	"theta.CaptureProfile": true,
+}
+
+func isAuditedMethod(method string) bool {
+	return adminMethods[method] || auditedMethods[method]
+}
+
+// AuditCaller identifies the caller of an audited RPC method.
+type AuditCaller struct {
+	Tenant     string `json:"tenant,omitempty"`      // Tenant authenticated by its API key or virtual host
+	ClientCert string `json:"client_cert,omitempty"` // Subject of the TLS client certificate verified by the server
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// AuditEntry is the record of an audited RPC invocation. Each entry carries the hash of the
+// previous one, so that altering, removing or reordering the entries breaks the chain.
+type AuditEntry struct {
+	Seq      uint64          `json:"seq"`
+	Time     int64           `json:"time"` // Unix time in milliseconds
+	Method   string          `json:"method"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Caller   AuditCaller     `json:"caller"`
+	Error    string          `json:"error,omitempty"` // Reason the invocation was rejected, empty if it was admitted
+	PrevHash common.Hash     `json:"prev_hash"`
+	Hash     common.Hash     `json:"hash"`
+}
+
+// computeHash returns the hash of the entry, which covers all its fields but the hash itself.
+func (e *AuditEntry) computeHash() (common.Hash, error) {
+	unhashed := *e
+	unhashed.Hash = common.Hash{}
+	raw, err := json.Marshal(&unhashed)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(raw), nil
+}
+
+// AuditLog is an append-only, hash-chained log of the audited RPC invocations, stored as one
+// JSON entry per line.
+type AuditLog struct {
+	mu *sync.Mutex
+
+	path     string
+	file     *os.File
+	length   uint64
+	lastHash common.Hash
+}
+
+// OpenAuditLog opens the audit log at path, creating it if needed. It fails if the entries
+// already in the log do not form a valid chain.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	l := &AuditLog{
+		mu:   &sync.Mutex{},
+		path: path,
+	}
+	err := l.traverse(func(e *AuditEntry) bool {
+		l.length = e.Seq + 1
+		l.lastHash = e.Hash
+		return true
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the audit log %v: %v", path, err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// NewAuditLogFromConfig opens the audit log at common.CfgRPCAuditLog, or returns nil if not set.
+func NewAuditLogFromConfig() (*AuditLog, error) {
+	path := viper.GetString(common.CfgRPCAuditLog)
+	if len(path) == 0 {
+		return nil, nil
+	}
+	return OpenAuditLog(path)
+}
+
+// Close closes the audit log file.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Append records the entry, setting its sequence number and hashes, and syncs it to disk.
+func (l *AuditLog) Append(entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(entry.Params) > 0 {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, entry.Params); err != nil {
+			return fmt.Errorf("Invalid params: %v", err)
+		}
+		entry.Params = compacted.Bytes()
+	}
+	entry.Seq = l.length
+	entry.PrevHash = l.lastHash
+	hash, err := entry.computeHash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(raw, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.length++
+	l.lastHash = hash
+	return nil
+}
+
+// Head returns the number of entries and the hash of the last one.
+func (l *AuditLog) Head() (uint64, common.Hash) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.length, l.lastHash
+}
+
+// Entries returns up to limit entries starting from the given sequence number, after verifying
+// the chain up to the last of them.
+func (l *AuditLog) Entries(from uint64, limit int) ([]*AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []*AuditEntry{}
+	err := l.traverse(func(e *AuditEntry) bool {
+		if e.Seq >= from {
+			entries = append(entries, e)
+		}
+		return len(entries) < limit
+	})
+	return entries, err
+}
+
+// traverse calls cb on the entries in order, until cb returns false. It fails on the first entry
+// which does not chain to the previous one.
+func (l *AuditLog) traverse(cb func(e *AuditEntry) bool) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxAuditEntrySize)
+	seq := uint64(0)
+	prevHash := common.Hash{}
+	for scanner.Scan() {
+		e := &AuditEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			return fmt.Errorf("Malformed audit log entry %v: %v", seq, err)
+		}
+		hash, err := e.computeHash()
+		if err != nil {
+			return err
+		}
+		if e.Seq != seq || e.PrevHash != prevHash || e.Hash != hash {
+			return fmt.Errorf("Audit log chain broken at entry %v", seq)
+		}
+		if !cb(e) {
+			return nil
+		}
+		seq++
+		prevHash = hash
+	}
+	return scanner.Err()
+}
+
+// record appends the audited calls of a request to the audit log. The calls are rejected if they
+// cannot be recorded.
+func (l *AuditLog) record(t *tenant, req *http.Request, calls []rpcCall, rpcErr *jsonrpc2.Error) *jsonrpc2.Error {
+	if l == nil {
+		return rpcErr
+	}
+	caller := AuditCaller{RemoteAddr: req.RemoteAddr}
+	if t != nil {
+		caller.Tenant = t.config.Name
+	}
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		caller.ClientCert = req.TLS.VerifiedChains[0][0].Subject.String()
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	for _, call := range calls {
+		if !isAuditedMethod(call.Method) {
+			continue
+		}
+		entry := &AuditEntry{
+			Time:   now,
+			Method: call.Method,
+			Params: call.Params,
+			Caller: caller,
+		}
+		if rpcErr != nil {
+			entry.Error = rpcErr.Message
+		}
+		if err := l.Append(entry); err != nil {
+			logger.Errorf("Failed to record %v in the audit log: %v", call.Method, err)
+			if rpcErr == nil {
+				rpcErr = jsonrpc2.NewError(errCodeAuditFailed, "Failed to record the call in the audit log")
+			}
+		}
+	}
+	return rpcErr
+}
+
+// ------------------------------ GetAuditLog -----------------------------------
+
+type GetAuditLogArgs struct {
+	From  common.JSONUint64 `json:"from"`  // Sequence number of the first entry
+	Limit common.JSONUint64 `json:"limit"` // MaxAuditEntriesPerQuery if 0
+}
+
+// GetAuditLogResult lists the entries of the audit log, whose chain is verified up to the last
+// of them. Length and Head are the number of entries and the hash of the last entry of the log.
+type GetAuditLogResult struct {
+	Entries []*AuditEntry     `json:"entries"`
+	Length  common.JSONUint64 `json:"length"`
+	Head    common.Hash       `json:"head"`
+}
+
+func (t *ThetaRPCService) GetAuditLog(args *GetAuditLogArgs, result *GetAuditLogResult) (err error) {
+	if t.auditLog == nil {
+		return errors.New("Audit log is not enabled")
+	}
+	limit := int(args.Limit)
+	if limit == 0 {
+		limit = MaxAuditEntriesPerQuery
+	}
+	if uint64(args.Limit) > MaxAuditEntriesPerQuery {
+		return fmt.Errorf("Limit must not exceed %v", MaxAuditEntriesPerQuery)
+	}
+
+	length, head := t.auditLog.Head()
+	result.Entries, err = t.auditLog.Entries(uint64(args.From), limit)
+	if err != nil {
+		return err
+	}
+	result.Length = common.JSONUint64(length)
+	result.Head = head
+	return nil
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "rpc-audit")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	auditLog, err := OpenAuditLog(path)
+	require.Nil(err)
+	m := newTestTenantManager(t, false)
+	m.SetAuditLog(auditLog)
+	handler := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	// The audited calls are recorded whether admitted or not, the other calls are not recorded
+	sendTestRPCRequest(handler, "localhost", "ops", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":1}`)
+	sendTestRPCRequest(handler, "localhost", "acme-key", `{"jsonrpc":"2.0","method":"theta.GetTenantUsage","params":[{}],"id":2}`)
+	sendTestRPCRequest(handler, "localhost", "ops-key", `[{"jsonrpc":"2.0","method":"theta.BackupChain","params":[{ "start": "1" }],"id":3},
+		{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":4}]`)
+
+	service := &ThetaRPCService{auditLog: auditLog}
+	result := &GetAuditLogResult{}
+	require.Nil(service.GetAuditLog(&GetAuditLogArgs{}, result))
+	require.Equal(2, len(result.Entries))
+	assert.Equal(uint64(2), uint64(result.Length))
+	assert.Equal(result.Entries[1].Hash, result.Head)
+
+	rejected := result.Entries[0]
+	assert.Equal("theta.GetTenantUsage", rejected.Method)
+	assert.Equal("acme", rejected.Caller.Tenant)
+	assert.NotEmpty(rejected.Caller.RemoteAddr)
+	assert.Contains(rejected.Error, "admin API key")
+
+	admitted := result.Entries[1]
+	assert.Equal(uint64(1), admitted.Seq)
+	assert.Equal("theta.BackupChain", admitted.Method)
+	assert.Equal("ops", admitted.Caller.Tenant)
+	assert.Equal(`[{"start":"1"}]`, string(admitted.Params))
+	assert.Empty(admitted.Error)
+	assert.Equal(rejected.Hash, admitted.PrevHash)
+
+	result = &GetAuditLogResult{}
+	require.Nil(service.GetAuditLog(&GetAuditLogArgs{From: 1, Limit: 1}, result))
+	require.Equal(1, len(result.Entries))
+	assert.Equal(admitted.Hash, result.Entries[0].Hash)
+
+	// The chain is restored when the log is reopened
+	require.Nil(auditLog.Close())
+	auditLog, err = OpenAuditLog(path)
+	require.Nil(err)
+	length, head := auditLog.Head()
+	assert.Equal(uint64(2), length)
+	assert.Equal(admitted.Hash, head)
+	require.Nil(auditLog.Append(&AuditEntry{Method: "theta.CaptureProfile"}))
+	entries, err := auditLog.Entries(0, MaxAuditEntriesPerQuery)
+	require.Nil(err)
+	require.Equal(3, len(entries))
+	assert.Equal(admitted.Hash, entries[2].PrevHash)
+	require.Nil(auditLog.Close())
+
+	// Altering an entry breaks the chain
+	raw, err := ioutil.ReadFile(path)
+	require.Nil(err)
+	tampered := strings.Replace(string(raw), `"tenant":"acme"`, `"tenant":"ops"`, 1)
+	require.Nil(ioutil.WriteFile(path, []byte(tampered), 0600))
+	_, err = OpenAuditLog(path)
+	assert.NotNil(err)
+}
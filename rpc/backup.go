@@ -19,7 +19,7 @@ type BackupSnapshotResult struct {
 
 func (t *ThetaRPCService) BackupSnapshot(args *BackupSnapshotArgs, result *BackupSnapshotResult) error {
 	db := t.ledger.State().DB()
-	consensus := t.consensus
+	finality := t.finality
 	chain := t.chain
 
 	snapshotDir := path.Join(args.Config, "backup", "snapshot")
@@ -27,7 +27,7 @@ func (t *ThetaRPCService) BackupSnapshot(args *BackupSnapshotArgs, result *Backu
 		os.MkdirAll(snapshotDir, os.ModePerm)
 	}
 
-	snapshotFile, err := snapshot.ExportSnapshot(db, consensus, chain, snapshotDir)
+	snapshotFile, err := snapshot.ExportSnapshot(db, finality, chain, snapshotDir)
 	result.SnapshotFile = snapshotFile
 
 	return err
@@ -49,7 +49,7 @@ type BackupChainResult struct {
 
 func (t *ThetaRPCService) BackupChain(args *BackupChainArgs, result *BackupChainResult) error {
 	db := t.ledger.State().DB()
-	consensus := t.consensus
+	finality := t.finality
 	chain := t.chain
 	startHeight := args.Start
 	endHeight := args.End
@@ -59,7 +59,7 @@ func (t *ThetaRPCService) BackupChain(args *BackupChainArgs, result *BackupChain
 		os.MkdirAll(backupDir, os.ModePerm)
 	}
 
-	actualStartHeight, actualEndHeight, chainFile, err := snapshot.ExportChainBackup(db, consensus, chain, startHeight, endHeight, backupDir)
+	actualStartHeight, actualEndHeight, chainFile, err := snapshot.ExportChainBackup(db, finality, chain, startHeight, endHeight, backupDir)
 	result.ActualStartHeight = actualStartHeight
 	result.ActualEndHeight = actualEndHeight
 	result.ChainFile = chainFile
@@ -1,13 +1,25 @@
 package rpc
 
 import (
+	"encoding/hex"
+	"fmt"
+
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/ledger/vm"
 )
 
+// MaxEstimateGasLimit is the gas limit up to which EstimateGas searches, if the transaction does
+// not set a lower one
+const MaxEstimateGasLimit = uint64(50000000)
+
 // ------------------------------- CallSmartContract -----------------------------------
 
 type CallSmartContractArgs struct {
-	SctxBytes string `json:"sctx_bytes"`
+	SctxBytes string            `json:"sctx_bytes"`
+	Height    common.JSONUint64 `json:"height"`     // height of the finalized block to call at, the latest state if 0
+	BlockHash string            `json:"block_hash"` // hash of the finalized block to call at, instead of the height
 }
 
 type CallSmartContractResult struct {
@@ -15,38 +27,107 @@ type CallSmartContractResult struct {
 	ContractAddress common.Address    `json:"contract_address"`
 	GasUsed         common.JSONUint64 `json:"gas_used"`
 	VmError         string            `json:"vm_error"`
+	Height          common.JSONUint64 `json:"height"` // the height of the state the call ran on
 }
 
-// ---- Temporarily disable the CallSmartContract RPC API, more testing needed ---- //
-
 // CallSmartContract calls the smart contract. However, calling a smart contract does NOT modify
 // the globally consensus state. It can be used for dry run, or for retrieving info from smart contracts
-// without actually spending gas.
+// without actually spending gas. The call runs on the latest state, or on the state of a finalized
+// block if its height or hash is specified, which is only available on archive nodes once the
+// state has been pruned.
 func (t *ThetaRPCService) CallSmartContract(args *CallSmartContractArgs, result *CallSmartContractResult) (err error) {
-	// sctxBytes, err := hex.DecodeString(args.SctxBytes)
-	// if err != nil {
-	// 	return err
-	// }
-
-	// tx, err := types.TxFromBytes(sctxBytes)
-	// sctx, ok := tx.(*types.SmartContractTx)
-	// if !ok {
-	// 	return fmt.Errorf("Failed to parse SmartContractTx: %v", args.SctxBytes)
-	// }
-
-	// ledgerState, err := t.ledger.GetDeliveredSnapshot()
-	// if err != nil {
-	// 	return err
-	// }
-	// vmRet, contractAddr, gasUsed, vmErr := vm.Execute(sctx, ledgerState)
-	// ledgerState.Save()
-
-	// result.VmReturn = hex.EncodeToString(vmRet)
-	// result.ContractAddress = contractAddr
-	// result.GasUsed = common.JSONUint64(gasUsed)
-	// if vmErr != nil {
-	// 	result.VmError = vmErr.Error()
-	// }
+	sctxBytes, err := hex.DecodeString(args.SctxBytes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := types.TxFromBytes(sctxBytes)
+	if err != nil {
+		return err
+	}
+	sctx, ok := tx.(*types.SmartContractTx)
+	if !ok {
+		return fmt.Errorf("Failed to parse SmartContractTx: %v", args.SctxBytes)
+	}
+
+	var ledgerState *state.StoreView
+	if args.BlockHash != "" {
+		block, findErr := t.chain.FindBlock(common.HexToHash(args.BlockHash))
+		if findErr != nil {
+			return fmt.Errorf("Block %v is not found", args.BlockHash)
+		}
+		if !block.Status.IsFinalized() {
+			return fmt.Errorf("Block %v is not finalized yet", args.BlockHash)
+		}
+		ledgerState, err = t.getBlockState(block)
+	} else if args.Height != 0 {
+		_, ledgerState, err = t.getFinalizedBlockState(args.Height)
+	} else {
+		ledgerState, err = t.ledger.GetDeliveredSnapshot()
+	}
+	if err != nil {
+		return err
+	}
+	vmRet, contractAddr, gasUsed, vmErr := vm.Execute(sctx, ledgerState)
+
+	result.VmReturn = hex.EncodeToString(vmRet)
+	result.ContractAddress = contractAddr
+	result.GasUsed = common.JSONUint64(gasUsed)
+	if vmErr != nil {
+		result.VmError = vmErr.Error()
+	}
+	result.Height = common.JSONUint64(ledgerState.Height())
+
+	return nil
+}
+
+// ------------------------------- EstimateGas -----------------------------------
+
+type EstimateGasArgs struct {
+	SctxBytes string `json:"sctx_bytes"` // the signature of the transaction is not checked
+}
+
+type EstimateGasResult struct {
+	GasLimit     common.JSONUint64 `json:"gas_limit"` // 0 if the execution fails
+	VmReturn     string            `json:"vm_return"`
+	VmError      string            `json:"vm_error"`
+	RevertReason string            `json:"revert_reason"`
+}
+
+// EstimateGas returns the minimal gas limit with which the smart contract transaction executes
+// successfully on the latest state, searching up to the gas limit of the transaction, or
+// MaxEstimateGasLimit if not set. If the execution fails, the VM error and the revert reason, if
+// any, are returned instead. The state is not modified.
+func (t *ThetaRPCService) EstimateGas(args *EstimateGasArgs, result *EstimateGasResult) (err error) {
+	sctxBytes, err := hex.DecodeString(args.SctxBytes)
+	if err != nil {
+		return err
+	}
+	tx, err := types.TxFromBytes(sctxBytes)
+	if err != nil {
+		return err
+	}
+	sctx, ok := tx.(*types.SmartContractTx)
+	if !ok {
+		return fmt.Errorf("Failed to parse SmartContractTx: %v", args.SctxBytes)
+	}
+	if sctx.GasLimit == 0 || sctx.GasLimit > MaxEstimateGasLimit {
+		sctx.GasLimit = MaxEstimateGasLimit
+	}
+
+	view, err := t.ledger.GetDeliveredSnapshot()
+	if err != nil {
+		return err
+	}
+	gasLimit, vmRet, vmErr := vm.EstimateGas(sctx, view)
 
+	result.GasLimit = common.JSONUint64(gasLimit)
+	result.VmReturn = hex.EncodeToString(vmRet)
+	if vmErr != nil {
+		result.VmError = vmErr.Error()
+	}
+	if vmErr == vm.ErrExecutionReverted {
+		result.RevertReason, _ = vm.RevertReason(vmRet)
+	}
 	return nil
 }
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/execution"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ------------------------------ GetChainParameters -----------------------------------
+
+// ChainParameter is a consensus or ledger parameter, and the height from which it is in effect
+type ChainParameter struct {
+	Name             string            `json:"name"`
+	Value            string            `json:"value"`
+	ActivationHeight common.JSONUint64 `json:"activation_height"`
+}
+
+type GetChainParametersArgs struct{}
+
+type GetChainParametersResult struct {
+	ChainID     string           `json:"chain_id"`
+	GenesisHash string           `json:"genesis_hash"`
+	Parameters  []ChainParameter `json:"parameters"`
+}
+
+func (t *ThetaRPCService) GetChainParameters(args *GetChainParametersArgs, result *GetChainParametersResult) (err error) {
+	result.ChainID = t.chain.ChainID
+	result.GenesisHash = getGenesisHash(t.chain)
+	result.Parameters = getChainParameters(t.chain.ChainID)
+	return nil
+}
+
+// getGenesisHash returns the hash of the genesis block. The chain might start from a snapshot,
+// in which case the root block is not the genesis block.
+func getGenesisHash(chain *blockchain.Chain) string {
+	if chain.ChainID == core.MainnetChainID {
+		return core.MainnetGenesisBlockHash
+	}
+	if root := chain.Root(); root != nil && root.Height == core.GenesisBlockHeight {
+		return root.Hash().Hex()
+	}
+	return viper.GetString(common.CfgGenesisHash)
+}
+
+// getChainParameters lists the parameters of the chain. The parameters introduced by a fork are
+// listed with the fork height.
+func getChainParameters(chainID string) []ChainParameter {
+	param := func(name string, value interface{}) ChainParameter {
+		return ChainParameter{
+			Name:             name,
+			Value:            fmt.Sprintf("%v", value),
+			ActivationHeight: common.JSONUint64(core.GenesisBlockHeight),
+		}
+	}
+	forkParam := func(fork core.Fork, name string, value interface{}) ChainParameter {
+		p := param(name, value)
+		if height := core.ForkHeight(fork, chainID); height > uint64(p.ActivationHeight) {
+			p.ActivationHeight = common.JSONUint64(height)
+		}
+		return p
+	}
+	rate := func(numerator, denominator int64) string {
+		return fmt.Sprintf("%d/%d", numerator, denominator)
+	}
+
+	return []ChainParameter{
+		// The block gas limit is enforced by the proposer when reaping the mempool, 0 means unlimited
+		param("block_gas_limit", viper.GetInt64(common.CfgMempoolReapMaxGas)),
+		param("minimum_gas_price", types.MinimumGasPrice),
+		param("minimum_transaction_fee_tfuelwei", types.MinimumTransactionFeeTFuelWei),
+		param("max_accounts_affected_per_tx", types.MaxAccountsAffectedPerTx),
+		param("min_validator_stake_deposit", core.MinValidatorStakeDeposit),
+		param("stake_return_locking_period", core.ReturnLockingPeriod),
+		param("validator_theta_generation_rate", rate(types.ValidatorThetaGenerationRateNumerator, types.ValidatorThetaGenerationRateDenominator)),
+		param("validator_tfuel_generation_rate", rate(types.ValidatorTFuelGenerationRateNumerator, types.ValidatorTFuelGenerationRateDenominator)),
+		param("regular_tfuel_generation_rate", rate(types.RegularTFuelGenerationRateNumerator, types.RegularTFuelGenerationRateDenominator)),
+		param("minimum_fund_reserve_duration", types.MinimumFundReserveDuration),
+		param("maximum_fund_reserve_duration", types.MaximumFundReserveDuration),
+		param("reserved_fund_freeze_period_duration", types.ReservedFundFreezePeriodDuration),
+		forkParam(core.ForkReservedFundLimit, "maximum_active_reserved_funds_per_account",
+			execution.MaxActiveReservedFunds(chainID)),
+		param("service_payment_dispute_window", types.ServicePaymentDisputeWindow),
+	}
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/execution"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func TestGetGenesisHash(t *testing.T) {
+	assert := assert.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	genesis := core.CreateTestBlock("genesis", "")
+	genesis.ChainID = "testchain"
+	genesis.Height = core.GenesisBlockHeight
+	chain := blockchain.NewChain("testchain", store, genesis)
+	assert.Equal(genesis.Hash().Hex(), getGenesisHash(chain))
+
+	// The chain starts from a snapshot
+	viper.Set(common.CfgGenesisHash, "0x1234")
+	defer viper.Set(common.CfgGenesisHash, "")
+	snapshotRoot := core.CreateTestBlock("snapshot", "")
+	snapshotRoot.ChainID = "testchain"
+	snapshotRoot.Height = 100
+	chain = blockchain.NewChain("testchain", kvstore.NewKVStore(backend.NewMemDatabase()), snapshotRoot)
+	assert.Equal("0x1234", getGenesisHash(chain))
+}
+
+func TestGetChainParameters(t *testing.T) {
+	assert := assert.New(t)
+
+	params := getChainParameters("testchain")
+	names := make(map[string]ChainParameter)
+	for _, param := range params {
+		_, exists := names[param.Name]
+		assert.False(exists, "duplicated parameter %v", param.Name)
+		names[param.Name] = param
+	}
+
+	assert.Equal("28800", names["stake_return_locking_period"].Value)
+	assert.Equal("1000000000000", names["minimum_transaction_fee_tfuelwei"].Value)
+	assert.Equal(common.JSONUint64(core.GenesisBlockHeight), names["block_gas_limit"].ActivationHeight)
+
+	execution.SetMaxActiveReservedFunds("testchain", 4)
+	defer execution.SetMaxActiveReservedFunds("testchain", types.MaximumActiveReservedFundsPerAccount)
+	core.SetForkHeight(core.ForkReservedFundLimit, "testchain", 1000)
+	defer core.SetForkHeight(core.ForkReservedFundLimit, "testchain", 0)
+	for _, param := range getChainParameters("testchain") {
+		if param.Name == "maximum_active_reserved_funds_per_account" {
+			assert.Equal("4", param.Value)
+			assert.Equal(common.JSONUint64(1000), param.ActivationHeight)
+		}
+	}
+}
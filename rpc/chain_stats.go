@@ -0,0 +1,291 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const (
+	// DefaultChainStatsWindow is the number of blocks covered by the chain stats by default
+	DefaultChainStatsWindow = 100
+
+	// MaxChainStatsWindow is the max number of blocks covered by the chain stats
+	MaxChainStatsWindow = 10000
+
+	// chainStatsFinalityLookahead is the number of blocks following the window scanned for the
+	// commit certificates finalizing the last blocks of the window
+	chainStatsFinalityLookahead = 16
+)
+
+// chainStatsHistogramBounds are the upper bounds, in seconds, of the histogram buckets of the
+// block intervals and the finalization latencies. The last bucket is unbounded.
+var chainStatsHistogramBounds = []float64{1, 2, 4, 6, 8, 10, 15, 20, 30, 60}
+
+// ------------------------------ GetChainStats -----------------------------------
+
+type GetChainStatsArgs struct {
+	Window    common.JSONUint64 `json:"window"`     // Number of blocks, DefaultChainStatsWindow if 0
+	EndHeight common.JSONUint64 `json:"end_height"` // Height of the last block, the last finalized block if 0
+}
+
+// HistogramBucket counts the samples up to the upper bound, and above the previous bucket. The
+// upper bound of the last bucket is omitted.
+type HistogramBucket struct {
+	UpperBound *float64 `json:"upper_bound,omitempty"`
+	Count      int      `json:"count"`
+}
+
+// DurationStats summarizes durations in seconds
+type DurationStats struct {
+	Count     int               `json:"count"`
+	Average   float64           `json:"average"`
+	Min       float64           `json:"min"`
+	P50       float64           `json:"p50"`
+	P90       float64           `json:"p90"`
+	P99       float64           `json:"p99"`
+	Max       float64           `json:"max"`
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+// FeeStats summarizes the TFuelWei fees paid per block
+type FeeStats struct {
+	Total   *common.JSONBig `json:"total"`
+	Average *common.JSONBig `json:"average"`
+	P50     *common.JSONBig `json:"p50"`
+	P90     *common.JSONBig `json:"p90"`
+	Max     *common.JSONBig `json:"max"`
+}
+
+// TxTypeCount is the number of transactions of a type, see GetTransactionResult.Type
+type TxTypeCount struct {
+	Type  byte `json:"type"`
+	Count int  `json:"count"`
+}
+
+// GetChainStatsResult holds the statistics of the finalized blocks in the window. The votes carry
+// no timestamp, so a block is considered finalized at the timestamp of the first block carrying
+// the commit certificate that finalizes it. The gas used is not stored locally, so the fees of the
+// smart contract transactions are computed from their gas limit, an upper bound.
+type GetChainStatsResult struct {
+	StartHeight     common.JSONUint64 `json:"start_height"`
+	EndHeight       common.JSONUint64 `json:"end_height"`
+	NumBlocks       int               `json:"num_blocks"`
+	NumTxs          int               `json:"num_txs"`
+	BlockInterval   DurationStats     `json:"block_interval"`
+	FinalityLatency DurationStats     `json:"finality_latency"`
+	FeesPerBlock    FeeStats          `json:"fees_per_block"`
+	TxTypes         []TxTypeCount     `json:"tx_types"`
+}
+
+func (t *ThetaRPCService) GetChainStats(args *GetChainStatsArgs, result *GetChainStatsResult) (err error) {
+	window := uint64(args.Window)
+	if window == 0 {
+		window = DefaultChainStatsWindow
+	}
+	if window > MaxChainStatsWindow {
+		return fmt.Errorf("Window too large: %v blocks, at most %v blocks allowed", window, MaxChainStatsWindow)
+	}
+	lastFinalized := t.finality.GetLastFinalizedBlock()
+	if lastFinalized == nil {
+		return errors.New("No finalized block yet")
+	}
+	end := uint64(args.EndHeight)
+	if end == 0 {
+		end = lastFinalized.Height
+	}
+	if end > lastFinalized.Height {
+		return fmt.Errorf("Block %v is not finalized yet, the last finalized block is %v", end, lastFinalized.Height)
+	}
+	start := uint64(0)
+	if end+1 > window {
+		start = end + 1 - window
+	}
+
+	// Load the finalized blocks of the window, the previous block to measure the first interval,
+	// and the following blocks carrying the commit certificates
+	blocks := make(map[uint64]*core.ExtendedBlock)
+	from := start
+	if from > 0 {
+		from--
+	}
+	to := end + chainStatsFinalityLookahead
+	if to > lastFinalized.Height {
+		to = lastFinalized.Height
+	}
+	for height := from; height <= to; height++ {
+		if block := findFinalizedBlock(t.chain, height); block != nil {
+			blocks[height] = block
+		}
+	}
+
+	result.StartHeight = common.JSONUint64(start)
+	result.EndHeight = common.JSONUint64(end)
+	intervals, latencies := []float64{}, []float64{}
+	fees := []*big.Int{}
+	txTypes := make(map[byte]int)
+	finalizedAt := t.finalizationTimes(blocks, from, to)
+	for height := start; height <= end; height++ {
+		block, ok := blocks[height]
+		if !ok {
+			continue
+		}
+		result.NumBlocks++
+		if parent, ok := blocks[height-1]; ok && height > 0 {
+			intervals = append(intervals, timestampDiff(block.Timestamp, parent.Timestamp))
+		}
+		if at, ok := finalizedAt[height]; ok {
+			latencies = append(latencies, timestampDiff(at, block.Timestamp))
+		}
+
+		fee := new(big.Int)
+		for _, rawTx := range block.Txs {
+			tx, err := types.TxFromBytes(rawTx)
+			if err != nil {
+				return err
+			}
+			result.NumTxs++
+			txTypes[getTxType(tx)]++
+			gasUsed := uint64(0)
+			if sctx, ok := tx.(*types.SmartContractTx); ok {
+				gasUsed = sctx.GasLimit
+			}
+			fee.Add(fee, types.TxFee(tx, gasUsed).NoNil().TFuelWei)
+		}
+		fees = append(fees, fee)
+	}
+
+	result.BlockInterval = newDurationStats(intervals)
+	result.FinalityLatency = newDurationStats(latencies)
+	result.FeesPerBlock = newFeeStats(fees)
+	result.TxTypes = []TxTypeCount{}
+	for txType, count := range txTypes {
+		result.TxTypes = append(result.TxTypes, TxTypeCount{Type: txType, Count: count})
+	}
+	sort.Slice(result.TxTypes, func(i, j int) bool { return result.TxTypes[i].Type < result.TxTypes[j].Type })
+	return nil
+}
+
+// findFinalizedBlock returns the finalized block at the given height, nil if there is none.
+func findFinalizedBlock(chain *blockchain.Chain, height uint64) *core.ExtendedBlock {
+	for _, block := range chain.FindBlocksByHeight(height) {
+		if block.Status.IsFinalized() {
+			return block
+		}
+	}
+	return nil
+}
+
+// finalizationTimes returns the time at which each of the given blocks was finalized, if known. A
+// block is finalized with its ancestors once a block b2 is committed, b2.Parent == b2.HCC being
+// the block. The commit certificate of b2 is carried by the HCC of a later block.
+func (t *ThetaRPCService) finalizationTimes(blocks map[uint64]*core.ExtendedBlock, from, to uint64) map[uint64]*big.Int {
+	// The earliest time at which the block at each height was directly finalized
+	direct := make(map[uint64]*big.Int)
+	for height := from; height <= to; height++ {
+		block, ok := blocks[height]
+		if !ok || block.HCC.BlockHash.IsEmpty() {
+			continue
+		}
+		committed, err := t.chain.FindBlock(block.HCC.BlockHash)
+		if err != nil || committed.Height == 0 || committed.Parent != committed.HCC.BlockHash {
+			continue
+		}
+		finalized := committed.Height - 1
+		if at, ok := direct[finalized]; !ok || block.Timestamp.Cmp(at) < 0 {
+			direct[finalized] = block.Timestamp
+		}
+	}
+
+	// Each block is finalized at the earliest direct finalization of itself or a descendant
+	finalizedAt := make(map[uint64]*big.Int)
+	var earliest *big.Int
+	for height := to; ; height-- {
+		if at, ok := direct[height]; ok && (earliest == nil || at.Cmp(earliest) < 0) {
+			earliest = at
+		}
+		if earliest != nil {
+			finalizedAt[height] = earliest
+		}
+		if height == from {
+			break
+		}
+	}
+	return finalizedAt
+}
+
+func timestampDiff(a, b *big.Int) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	diff, _ := new(big.Float).SetInt(new(big.Int).Sub(a, b)).Float64()
+	return diff
+}
+
+func newDurationStats(samples []float64) DurationStats {
+	stats := DurationStats{Count: len(samples), Histogram: []HistogramBucket{}}
+	for i := range chainStatsHistogramBounds {
+		stats.Histogram = append(stats.Histogram, HistogramBucket{UpperBound: &chainStatsHistogramBounds[i]})
+	}
+	stats.Histogram = append(stats.Histogram, HistogramBucket{})
+	if len(samples) == 0 {
+		return stats
+	}
+
+	sorted := append([]float64{}, samples...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, sample := range sorted {
+		sum += sample
+		bucket := sort.SearchFloat64s(chainStatsHistogramBounds, sample)
+		stats.Histogram[bucket].Count++
+	}
+	stats.Average = sum / float64(len(sorted))
+	stats.Min = sorted[0]
+	stats.P50 = sorted[percentileIndex(len(sorted), 50)]
+	stats.P90 = sorted[percentileIndex(len(sorted), 90)]
+	stats.P99 = sorted[percentileIndex(len(sorted), 99)]
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
+
+func newFeeStats(samples []*big.Int) FeeStats {
+	total := new(big.Int)
+	for _, sample := range samples {
+		total.Add(total, sample)
+	}
+	stats := FeeStats{
+		Total:   (*common.JSONBig)(total),
+		Average: (*common.JSONBig)(new(big.Int)),
+		P50:     (*common.JSONBig)(new(big.Int)),
+		P90:     (*common.JSONBig)(new(big.Int)),
+		Max:     (*common.JSONBig)(new(big.Int)),
+	}
+	if len(samples) == 0 {
+		return stats
+	}
+
+	sorted := append([]*big.Int{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	stats.Average = (*common.JSONBig)(new(big.Int).Quo(total, big.NewInt(int64(len(sorted)))))
+	stats.P50 = (*common.JSONBig)(sorted[percentileIndex(len(sorted), 50)])
+	stats.P90 = (*common.JSONBig)(sorted[percentileIndex(len(sorted), 90)])
+	stats.Max = (*common.JSONBig)(sorted[len(sorted)-1])
+	return stats
+}
+
+// percentileIndex returns the index of the nearest-rank percentile in n sorted samples
+func percentileIndex(n int, percentile float64) int {
+	index := int(math.Ceil(percentile/100*float64(n))) - 1
+	if index < 0 {
+		return 0
+	}
+	return index
+}
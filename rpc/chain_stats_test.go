@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+// chainStatsFinality serves the last finalized block of the chain.
+type chainStatsFinality struct {
+	testFinality
+	last *core.ExtendedBlock
+}
+
+func (f *chainStatsFinality) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return f.last
+}
+
+func createChainStatsTestBlock(parent *core.Block, timestamp int64, txs []common.Bytes) *core.Block {
+	block := core.NewBlock()
+	block.ChainID = "testchain"
+	block.Height = parent.Height + 1
+	block.Parent = parent.Hash()
+	block.HCC.BlockHash = parent.Hash()
+	block.Timestamp = big.NewInt(timestamp)
+	block.AddTxs(txs)
+	return block
+}
+
+func TestGetChainStats(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.NewBlock()
+	root.ChainID = "testchain"
+	root.Timestamp = big.NewInt(1000)
+	chain := blockchain.NewChain("testchain", store, root)
+	finality := &chainStatsFinality{testFinality: testFinality{chain: chain}}
+	service := &ThetaRPCService{chain: chain, finality: finality}
+
+	result := &GetChainStatsResult{}
+	assert.NotNil(service.GetChainStats(&GetChainStatsArgs{}, result), "no finalized block")
+
+	// A block every 2 seconds, each certifying its parent, so that each block finalizes its
+	// grandparent 4 seconds after the grandparent
+	sendTx := &types.SendTx{Fee: types.NewCoins(0, 1000000000000)}
+	rawSendTx, err := types.TxToBytes(sendTx)
+	require.Nil(err)
+	parent := root
+	for height := int64(1); height <= 20; height++ {
+		txs := []common.Bytes{}
+		if height%2 == 0 {
+			txs = append(txs, rawSendTx)
+		}
+		block := createChainStatsTestBlock(parent, 1000+2*height, txs)
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+		if height >= 2 {
+			chain.FinalizePreviousBlocks(parent.Parent)
+		}
+		parent = block
+	}
+	last, err := chain.FindBlock(parent.Parent)
+	require.Nil(err)
+	last, err = chain.FindBlock(last.Parent)
+	require.Nil(err)
+	finality.last = last
+	assert.Equal(uint64(18), last.Height)
+
+	require.Nil(service.GetChainStats(&GetChainStatsArgs{Window: 10}, result))
+	assert.Equal(common.JSONUint64(9), result.StartHeight)
+	assert.Equal(common.JSONUint64(18), result.EndHeight)
+	assert.Equal(10, result.NumBlocks)
+	assert.Equal(5, result.NumTxs)
+
+	assert.Equal(10, result.BlockInterval.Count)
+	assert.Equal(2.0, result.BlockInterval.Average)
+	assert.Equal(2.0, result.BlockInterval.P99)
+	assert.Equal(10, result.BlockInterval.Histogram[1].Count)
+
+	// The last blocks are finalized by blocks not finalized yet
+	assert.Equal(8, result.FinalityLatency.Count)
+	assert.Equal(4.0, result.FinalityLatency.Min)
+	assert.Equal(4.0, result.FinalityLatency.Max)
+	assert.Equal(8, result.FinalityLatency.Histogram[2].Count)
+
+	assert.Equal(big.NewInt(5000000000000), (*big.Int)(result.FeesPerBlock.Total))
+	assert.Equal(big.NewInt(500000000000), (*big.Int)(result.FeesPerBlock.Average))
+	assert.Equal(big.NewInt(1000000000000), (*big.Int)(result.FeesPerBlock.Max))
+	assert.Equal([]TxTypeCount{{Type: TxTypeSend, Count: 5}}, result.TxTypes)
+
+	// The window starts at the genesis
+	result = &GetChainStatsResult{}
+	require.Nil(service.GetChainStats(&GetChainStatsArgs{Window: 100, EndHeight: 3}, result))
+	assert.Equal(common.JSONUint64(0), result.StartHeight)
+	assert.Equal(4, result.NumBlocks)
+	assert.Equal(3, result.BlockInterval.Count)
+	assert.Equal(4, result.FinalityLatency.Count)
+
+	assert.NotNil(service.GetChainStats(&GetChainStatsArgs{EndHeight: 19}, result), "not finalized")
+	assert.NotNil(service.GetChainStats(&GetChainStatsArgs{Window: MaxChainStatsWindow + 1}, result))
+}
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// ------------------------------- GetDataCommitment -----------------------------------
+
+type GetDataCommitmentArgs struct {
+	Root common.Hash `json:"root"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
+}
+
+type GetDataCommitmentResult struct {
+	*types.DataCommitment
+}
+
+func (t *ThetaRPCService) GetDataCommitment(args *GetDataCommitmentArgs, result *GetDataCommitmentResult) (err error) {
+	if args.Root.IsEmpty() {
+		return errors.New("Root must be specified")
+	}
+	ledgerState, err := t.dataCommitmentState(args.FinalizedOnly)
+	if err != nil {
+		return err
+	}
+	result.DataCommitment = ledgerState.GetDataCommitment(args.Root)
+	return nil
+}
+
+// ------------------------------- VerifyDataCommitment -----------------------------------
+
+type VerifyDataCommitmentArgs struct {
+	Root  common.Hash                `json:"root"`
+	Leaf  string                     `json:"leaf"` // hex encoded
+	Proof *types.DataCommitmentProof `json:"proof"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
+}
+
+type VerifyDataCommitmentResult struct {
+	Valid      bool                  `json:"valid"`
+	Error      string                `json:"error,omitempty"` // why the leaf is not committed, if not valid
+	Commitment *types.DataCommitment `json:"commitment"`
+}
+
+// VerifyDataCommitment checks that the leaf is part of the data committed on chain with the root,
+// and returns the commitment, i.e. the owner and the height the data is notarized at.
+func (t *ThetaRPCService) VerifyDataCommitment(args *VerifyDataCommitmentArgs, result *VerifyDataCommitmentResult) (err error) {
+	if args.Root.IsEmpty() {
+		return errors.New("Root must be specified")
+	}
+	leaf, err := decodeTxHexBytes(args.Leaf)
+	if err != nil {
+		return fmt.Errorf("Failed to decode the leaf: %v", err)
+	}
+	ledgerState, err := t.dataCommitmentState(args.FinalizedOnly)
+	if err != nil {
+		return err
+	}
+
+	commitment := ledgerState.GetDataCommitment(args.Root)
+	result.Commitment = commitment
+	if commitment == nil {
+		result.Error = fmt.Sprintf("Root %v is not committed", args.Root.Hex())
+		return nil
+	}
+	if args.Proof != nil && uint64(args.Proof.NumLeaves) != commitment.NumLeaves {
+		result.Error = fmt.Sprintf("The proof is for %v leaves, %v leaves are committed", args.Proof.NumLeaves, commitment.NumLeaves)
+		return nil
+	}
+	if err := types.VerifyDataCommitmentProof(args.Root, leaf, args.Proof); err != nil {
+		result.Error = err.Error()
+		return nil
+	}
+	result.Valid = true
+	return nil
+}
+
+func (t *ThetaRPCService) dataCommitmentState(finalizedOnly *bool) (*state.StoreView, error) {
+	if t.isFinalizedOnly(finalizedOnly) {
+		return t.ledger.GetFinalizedSnapshot()
+	}
+	return t.ledger.GetDeliveredSnapshot()
+}
@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+// errCodeServer is the JSON-RPC code of the free-form errors in the legacy error format
+const errCodeServer = -32000
+
+// errCodeStatePruned is the JSON-RPC code of the queries on a state which the node no longer retains
+const errCodeStatePruned = -32004
+
+// legacyErrors is set by common.CfgRPCLegacyErrors
+var legacyErrors bool
+
+// rpcCodeNames are the symbolic names of the JSON-RPC error codes, reported for the errors which
+// occur before or outside of the ledger
+var rpcCodeNames = map[int]string{
+	-32700:               "ParseError",
+	-32600:               "InvalidRequest",
+	-32601:               "MethodNotFound",
+	-32602:               "InvalidParams",
+	-32603:               "InternalError",
+	errCodeServer:        "ServerError",
+	errCodeStatePruned:   "StatePruned",
+	errCodeUnauthorized:  "Unauthorized",
+	errCodeLimitExceeded: "LimitExceeded",
+	errCodeAuditFailed:   "AuditFailed",
+}
+
+// ErrorData is the "data" member of the RPC errors. Name is the symbolic name of the error code,
+// and Info the structured details of the error, if any.
+type ErrorData struct {
+	Name string      `json:"name"`
+	Info result.Info `json:"info,omitempty"`
+}
+
+// newRPCError returns the RPC error reporting the error code, message and info of the result.
+func newRPCError(res result.Result) error {
+	return &jsonrpc2.Error{
+		Code:    int(res.Code),
+		Message: res.Message,
+		Data:    &ErrorData{Name: res.Code.Name(), Info: res.Info},
+	}
+}
+
+// isLedgerErrorCode returns whether the code of an RPC error is a result.ErrorCode
+func isLedgerErrorCode(code int) bool {
+	return code >= int(result.CodeGenericError)
+}
+
+// encodeError converts an error into the error object sent to the clients. The code of the errors
+// is the result.ErrorCode, result.CodeGenericError for the free-form errors, or the JSON-RPC code
+// for the protocol errors, and their data always carries the symbolic name of the code. In the
+// legacy format, the errors only carry the JSON-RPC codes, and no data.
+func encodeError(e *jsonrpc2.Error) *jsonrpc2.Error {
+	if legacyErrors {
+		if isLedgerErrorCode(e.Code) {
+			return jsonrpc2.NewError(errCodeServer, e.Message)
+		}
+		return e
+	}
+	if e.Data != nil {
+		return e
+	}
+
+	code := e.Code
+	if code == errCodeServer {
+		code = int(result.CodeGenericError)
+	}
+	name, ok := rpcCodeNames[code]
+	if isLedgerErrorCode(code) {
+		name = result.ErrorCode(code).Name()
+	} else if !ok {
+		name = rpcCodeNames[errCodeServer]
+	}
+	return &jsonrpc2.Error{
+		Code:    code,
+		Message: e.Message,
+		Data:    &ErrorData{Name: name},
+	}
+}
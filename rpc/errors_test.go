@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+func TestEncodeError(t *testing.T) {
+	assert := assert.New(t)
+
+	res := result.Error("Invalid sequence").WithErrorCode(result.CodeInvalidSequence)
+	res.Info["expected"] = 3
+	ledgerErr := newRPCError(res).(*jsonrpc2.Error)
+
+	e := encodeError(ledgerErr)
+	assert.Equal(int(result.CodeInvalidSequence), e.Code)
+	assert.Equal("Invalid sequence", e.Message)
+	data := e.Data.(*ErrorData)
+	assert.Equal("InvalidSequence", data.Name)
+	assert.Equal(3, data.Info["expected"])
+
+	e = encodeError(jsonrpc2.NewError(errCodeServer, "failure"))
+	assert.Equal(int(result.CodeGenericError), e.Code)
+	assert.Equal("GenericError", e.Data.(*ErrorData).Name)
+
+	e = encodeError(jsonrpc2.NewError(-32601, "rpc: can't find method"))
+	assert.Equal(-32601, e.Code)
+	assert.Equal("MethodNotFound", e.Data.(*ErrorData).Name)
+
+	e = encodeError(jsonrpc2.NewError(errCodeUnauthorized, "unauthorized"))
+	assert.Equal("Unauthorized", e.Data.(*ErrorData).Name)
+
+	e = encodeError(jsonrpc2.NewError(errCodeStatePruned, "The state at height 10 has been pruned"))
+	assert.Equal(errCodeStatePruned, e.Code)
+	assert.Equal("StatePruned", e.Data.(*ErrorData).Name)
+
+	legacyErrors = true
+	defer func() { legacyErrors = false }()
+
+	e = encodeError(ledgerErr)
+	assert.Equal(errCodeServer, e.Code)
+	assert.Equal("Invalid sequence", e.Message)
+	assert.Nil(e.Data)
+
+	e = encodeError(jsonrpc2.NewError(-32601, "rpc: can't find method"))
+	assert.Equal(-32601, e.Code)
+	assert.Nil(e.Data)
+}
+
+func TestErrorCodeName(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("InsufficientFund", result.CodeInsufficientFund.Name())
+	assert.Equal("ErrorCode(42)", result.ErrorCode(42).Name())
+}
@@ -0,0 +1,819 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/ledger/vm"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"github.com/thetatoken/theta/version"
+	"github.com/thetatoken/theta/webhook"
+)
+
+// The adapter of the Ethereum JSON-RPC API (the eth_* namespace) served at /eth, so that the
+// Ethereum wallets and tools can query the node. The balances are in TFuel, which pays for the gas.
+// The transactions sent must be native Theta transactions, since the ledger cannot verify the
+// signatures of the Ethereum transactions.
+
+const (
+	// ethCallGasCap is the gas limit of eth_call and eth_estimateGas, if not specified or higher
+	ethCallGasCap = uint64(50000000)
+
+	// ethErrCodeReverted is the error code of eth_call when the contract reverts, the revert
+	// data being the data of the error
+	ethErrCodeReverted = 3
+)
+
+// ethChainIDs are the EIP-155 chain IDs of the Theta networks
+var ethChainIDs = map[string]uint64{
+	"mainnet":    361,
+	"testnet":    365,
+	"privatenet": 366,
+}
+
+// The block tags of the Ethereum API
+const (
+	ethBlockLatest    = "latest"
+	ethBlockFinalized = "finalized"
+	ethBlockSafe      = "safe"
+	ethBlockPending   = "pending"
+	ethBlockEarliest  = "earliest"
+)
+
+type ethRequest struct {
+	Version string            `json:"jsonrpc"`
+	ID      *json.RawMessage  `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type ethResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc2.Error  `json:"error,omitempty"`
+}
+
+type ethMethod func(s *ethService, params []json.RawMessage) (interface{}, error)
+
+var ethMethods = map[string]ethMethod{
+	"web3_clientVersion":        (*ethService).clientVersion,
+	"net_version":               (*ethService).netVersion,
+	"eth_chainId":               (*ethService).chainID,
+	"eth_blockNumber":           (*ethService).blockNumber,
+	"eth_gasPrice":              (*ethService).gasPrice,
+	"eth_getBalance":            (*ethService).getBalance,
+	"eth_getTransactionCount":   (*ethService).getTransactionCount,
+	"eth_getCode":               (*ethService).getCode,
+	"eth_call":                  (*ethService).call,
+	"eth_estimateGas":           (*ethService).estimateGas,
+	"eth_sendRawTransaction":    (*ethService).sendRawTransaction,
+	"eth_getBlockByNumber":      (*ethService).getBlockByNumber,
+	"eth_getBlockByHash":        (*ethService).getBlockByHash,
+	"eth_getTransactionByHash":  (*ethService).getTransactionByHash,
+	"eth_getTransactionReceipt": (*ethService).getTransactionReceipt,
+	"eth_getLogs":               (*ethService).getLogs,
+}
+
+// ethService serves the eth_* methods on top of the ThetaRPCService.
+type ethService struct {
+	t          *ThetaRPCService
+	ethChainID uint64
+}
+
+// newEthService creates the adapter. The EIP-155 chain ID is common.CfgRPCEthChainID if set, or
+// the one of the Theta network.
+func newEthService(t *ThetaRPCService) *ethService {
+	ethChainID := viper.GetUint64(common.CfgRPCEthChainID)
+	if ethChainID == 0 && t.chain != nil {
+		ethChainID = ethChainIDs[t.chain.ChainID]
+	}
+	return &ethService{t: t, ethChainID: ethChainID}
+}
+
+// ServeHTTP handles the single and batch JSON-RPC requests.
+func (s *ethService) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(s.handle(body))
+}
+
+func (s *ethService) handle(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		reqs := []json.RawMessage{}
+		if err := json.Unmarshal(trimmed, &reqs); err != nil || len(reqs) == 0 {
+			raw, _ := json.Marshal(newEthErrorResponse(nil, jsonrpc2.NewError(-32600, "Invalid request")))
+			return raw
+		}
+		resps := []*ethResponse{}
+		for _, raw := range reqs {
+			resps = append(resps, s.handleRequest(raw))
+		}
+		raw, _ := json.Marshal(resps)
+		return raw
+	}
+	raw, _ := json.Marshal(s.handleRequest(trimmed))
+	return raw
+}
+
+func (s *ethService) handleRequest(raw json.RawMessage) *ethResponse {
+	req := ethRequest{}
+	if err := json.Unmarshal(raw, &req); err != nil || len(req.Method) == 0 {
+		return newEthErrorResponse(req.ID, jsonrpc2.NewError(-32600, "Invalid request"))
+	}
+	method, ok := ethMethods[req.Method]
+	if !ok {
+		return newEthErrorResponse(req.ID, jsonrpc2.NewError(-32601, fmt.Sprintf("Method %v not found", req.Method)))
+	}
+	result, err := method(s, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*jsonrpc2.Error)
+		if !ok {
+			rpcErr = jsonrpc2.NewError(-32000, err.Error())
+		}
+		return newEthErrorResponse(req.ID, rpcErr)
+	}
+	resultRaw, err := json.Marshal(result)
+	if err != nil {
+		return newEthErrorResponse(req.ID, jsonrpc2.NewError(-32603, err.Error()))
+	}
+	msg := json.RawMessage(resultRaw)
+	return &ethResponse{Version: "2.0", ID: ethResponseID(req.ID), Result: &msg}
+}
+
+func newEthErrorResponse(id *json.RawMessage, rpcErr *jsonrpc2.Error) *ethResponse {
+	return &ethResponse{Version: "2.0", ID: ethResponseID(id), Error: rpcErr}
+}
+
+func ethResponseID(id *json.RawMessage) *json.RawMessage {
+	if id == nil {
+		null := json.RawMessage("null")
+		return &null
+	}
+	return id
+}
+
+// ethParam decodes the i-th parameter into v. The missing optional parameters leave v unchanged.
+func ethParam(params []json.RawMessage, i int, v interface{}, required bool) error {
+	if i >= len(params) || string(params[i]) == "null" {
+		if required {
+			return jsonrpc2.NewError(-32602, fmt.Sprintf("Missing parameter %v", i))
+		}
+		return nil
+	}
+	if err := json.Unmarshal(params[i], v); err != nil {
+		return jsonrpc2.NewError(-32602, fmt.Sprintf("Invalid parameter %v: %v", i, err))
+	}
+	return nil
+}
+
+// blockParam returns the finalized block designated by the block number or tag of the i-th
+// parameter, the last finalized block if the parameter is missing. The pending state has no block,
+// so it is reported by the second return value.
+func (s *ethService) blockParam(params []json.RawMessage, i int) (*core.ExtendedBlock, bool, error) {
+	tag := ethBlockLatest
+	if err := ethParam(params, i, &tag, false); err != nil {
+		return nil, false, err
+	}
+	var block *core.ExtendedBlock
+	switch tag {
+	case ethBlockPending:
+		return nil, true, nil
+	case ethBlockLatest, ethBlockFinalized, ethBlockSafe:
+		block = s.t.finality.GetLastFinalizedBlock()
+	case ethBlockEarliest:
+		block = s.t.chain.Root()
+	default:
+		height, err := hexutil.DecodeUint64(tag)
+		if err != nil {
+			return nil, false, jsonrpc2.NewError(-32602, fmt.Sprintf("Invalid block number %v", tag))
+		}
+		if block = findFinalizedBlock(s.t.chain, height); block == nil {
+			return nil, false, fmt.Errorf("There is no finalized block at height %v", height)
+		}
+	}
+	return block, false, nil
+}
+
+// stateParam returns the state of the block designated by the i-th parameter.
+func (s *ethService) stateParam(params []json.RawMessage, i int) (*state.StoreView, error) {
+	block, pending, err := s.blockParam(params, i)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return s.t.ledger.GetScreenedSnapshot()
+	}
+	finalizedView, err := s.t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	view := state.NewStoreView(block.Height, block.StateHash, finalizedView.GetDB())
+	if view == nil {
+		return nil, fmt.Errorf("The state at height %v has been pruned, please query an archive node", block.Height)
+	}
+	return view, nil
+}
+
+// ------------------------------- Node and chain -----------------------------------
+
+func (s *ethService) clientVersion(params []json.RawMessage) (interface{}, error) {
+	return fmt.Sprintf("theta/%v-%v", version.Version, version.GitHash), nil
+}
+
+func (s *ethService) netVersion(params []json.RawMessage) (interface{}, error) {
+	return fmt.Sprintf("%v", s.ethChainID), nil
+}
+
+func (s *ethService) chainID(params []json.RawMessage) (interface{}, error) {
+	if s.ethChainID == 0 {
+		return nil, fmt.Errorf("No EIP-155 chain ID is known for chain %v, please set %v", s.t.chain.ChainID, common.CfgRPCEthChainID)
+	}
+	return hexutil.Uint64(s.ethChainID), nil
+}
+
+func (s *ethService) blockNumber(params []json.RawMessage) (interface{}, error) {
+	return hexutil.Uint64(s.t.finality.GetLastFinalizedBlock().Height), nil
+}
+
+func (s *ethService) gasPrice(params []json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(new(big.Int).SetUint64(types.MinimumGasPrice)), nil
+}
+
+// ------------------------------- Accounts -----------------------------------
+
+func (s *ethService) getAccount(params []json.RawMessage) (*types.Account, error) {
+	var address common.Address
+	if err := ethParam(params, 0, &address, true); err != nil {
+		return nil, err
+	}
+	view, err := s.stateParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	account := view.GetAccount(address)
+	if account != nil {
+		account.UpdateToHeight(view.Height())
+	}
+	return account, nil
+}
+
+func (s *ethService) getBalance(params []json.RawMessage) (interface{}, error) {
+	account, err := s.getAccount(params)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Balance.TFuelWei == nil {
+		return (*hexutil.Big)(big.NewInt(0)), nil
+	}
+	return (*hexutil.Big)(account.Balance.TFuelWei), nil
+}
+
+// getTransactionCount returns the sequence of the account, i.e. the number of transactions it sent,
+// the next transaction using the sequence plus one.
+func (s *ethService) getTransactionCount(params []json.RawMessage) (interface{}, error) {
+	account, err := s.getAccount(params)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return hexutil.Uint64(0), nil
+	}
+	return hexutil.Uint64(account.Sequence), nil
+}
+
+func (s *ethService) getCode(params []json.RawMessage) (interface{}, error) {
+	var address common.Address
+	if err := ethParam(params, 0, &address, true); err != nil {
+		return nil, err
+	}
+	view, err := s.stateParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(view.GetCode(address)), nil
+}
+
+// ------------------------------- Contracts -----------------------------------
+
+type ethCallArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     *hexutil.Bytes  `json:"data"`
+	Input    *hexutil.Bytes  `json:"input"`
+}
+
+// call executes the contract call on a copy of the state, which is discarded afterwards.
+func (s *ethService) call(params []json.RawMessage) (interface{}, error) {
+	args := ethCallArgs{}
+	if err := ethParam(params, 0, &args, true); err != nil {
+		return nil, err
+	}
+	view, err := s.stateParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, _, _, vmErr := vm.Execute(newEthCallTx(&args), view)
+	if vmErr != nil {
+		return nil, newEthVMError(ret, vmErr)
+	}
+	return hexutil.Bytes(ret), nil
+}
+
+// estimateGas returns the minimal gas limit with which the call succeeds, see vm.EstimateGas.
+func (s *ethService) estimateGas(params []json.RawMessage) (interface{}, error) {
+	args := ethCallArgs{}
+	if err := ethParam(params, 0, &args, true); err != nil {
+		return nil, err
+	}
+	view, err := s.stateParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	gasLimit, ret, vmErr := vm.EstimateGas(newEthCallTx(&args), view)
+	if vmErr != nil {
+		return nil, newEthVMError(ret, vmErr)
+	}
+	return hexutil.Uint64(gasLimit), nil
+}
+
+// newEthCallTx creates the smart contract transaction of the call, the gas limit being capped to
+// ethCallGasCap.
+func newEthCallTx(args *ethCallArgs) *types.SmartContractTx {
+	tx := &types.SmartContractTx{
+		From:     types.TxInput{Address: args.From, Coins: types.NewCoins(0, 0)},
+		GasLimit: ethCallGasCap,
+		GasPrice: big.NewInt(0),
+	}
+	if args.To != nil {
+		tx.To = types.TxOutput{Address: *args.To}
+	}
+	if args.Gas != nil && uint64(*args.Gas) < ethCallGasCap {
+		tx.GasLimit = uint64(*args.Gas)
+	}
+	if args.GasPrice != nil {
+		tx.GasPrice = args.GasPrice.ToInt()
+	}
+	if args.Value != nil {
+		tx.From.Coins.TFuelWei = args.Value.ToInt()
+	}
+	if args.Input != nil {
+		tx.Data = common.Bytes(*args.Input)
+	} else if args.Data != nil {
+		tx.Data = common.Bytes(*args.Data)
+	}
+	return tx
+}
+
+// newEthVMError returns the error of a failed execution. The reverts carry the revert data, and
+// the decoded reason in the message if any.
+func newEthVMError(ret common.Bytes, vmErr error) error {
+	if vmErr != vm.ErrExecutionReverted {
+		return vmErr
+	}
+	message := "execution reverted"
+	if reason, ok := vm.RevertReason(ret); ok {
+		message += ": " + reason
+	}
+	return &jsonrpc2.Error{Code: ethErrCodeReverted, Message: message, Data: hexutil.Bytes(ret).String()}
+}
+
+// ------------------------------- Transactions -----------------------------------
+
+// sendRawTransaction submits a native Theta transaction, and returns its hash.
+func (s *ethService) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var raw hexutil.Bytes
+	if err := ethParam(params, 0, &raw, true); err != nil {
+		return nil, err
+	}
+	if _, err := types.TxFromBytes(raw); err != nil {
+		return nil, fmt.Errorf("Not a Theta transaction, the Ethereum transactions are not supported: %v", err)
+	}
+	result := BroadcastRawTransactionAsyncResult{}
+	if err := s.t.BroadcastRawTransactionAsync(&BroadcastRawTransactionAsyncArgs{TxBytes: hex.EncodeToString(raw)}, &result); err != nil {
+		return nil, err
+	}
+	return common.HexToHash(result.TxHash), nil
+}
+
+// ethTransaction is a transaction in the Ethereum format. The native transactions pay a flat fee,
+// reported as 1 gas priced at the fee, so that the fee computed by the Ethereum tools is right.
+type ethTransaction struct {
+	Hash             common.Hash     `json:"hash"`
+	BlockHash        *common.Hash    `json:"blockHash"`
+	BlockNumber      *hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	From             common.Address  `json:"from"`
+	To               *common.Address `json:"to"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	Value            *hexutil.Big    `json:"value"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Input            hexutil.Bytes   `json:"input"`
+	Type             hexutil.Uint64  `json:"type"`
+	ThetaTxType      hexutil.Uint64  `json:"thetaTxType"` // the type of the native transaction, see TxType
+}
+
+// newEthTransaction maps the native transaction onto the fields of an Ethereum transaction. The
+// sender is the first input of the transaction, and the recipient its only output, if any.
+func newEthTransaction(tx types.Tx, hash common.Hash) *ethTransaction {
+	ethTx := &ethTransaction{
+		Hash:        hash,
+		Value:       (*hexutil.Big)(big.NewInt(0)),
+		Gas:         1,
+		GasPrice:    (*hexutil.Big)(types.TxFee(tx, 0).TFuelWei),
+		Input:       hexutil.Bytes{},
+		ThetaTxType: hexutil.Uint64(getTxType(tx)),
+	}
+	if input, ok := webhook.TxSender(tx); ok {
+		ethTx.From = input.Address
+		ethTx.Nonce = hexutil.Uint64(input.Sequence)
+	}
+	switch tx := tx.(type) {
+	case *types.SmartContractTx:
+		if (tx.To.Address != common.Address{}) {
+			ethTx.To = &tx.To.Address
+		}
+		if tx.From.Coins.TFuelWei != nil {
+			ethTx.Value = (*hexutil.Big)(tx.From.Coins.TFuelWei)
+		}
+		ethTx.Gas = hexutil.Uint64(tx.GasLimit)
+		ethTx.GasPrice = (*hexutil.Big)(tx.GasPrice)
+		ethTx.Input = hexutil.Bytes(tx.Data)
+	default:
+		if _, recipients := webhook.TxParticipants(tx); len(recipients) == 1 {
+			ethTx.To = &recipients[0]
+		}
+		if sendTx, ok := tx.(*types.SendTx); ok && len(sendTx.Outputs) == 1 && sendTx.Outputs[0].Coins.TFuelWei != nil {
+			ethTx.Value = (*hexutil.Big)(sendTx.Outputs[0].Coins.TFuelWei)
+		}
+	}
+	if ethTx.GasPrice.ToInt() == nil {
+		ethTx.GasPrice = (*hexutil.Big)(big.NewInt(0))
+	}
+	return ethTx
+}
+
+// findTx returns the transaction with the given hash in a finalized block, and its index.
+func (s *ethService) findTx(hash common.Hash) (types.Tx, *core.ExtendedBlock, int, error) {
+	raw, block, found := s.t.chain.FindTxByHash(hash)
+	if !found || !block.Status.IsFinalized() {
+		return nil, nil, 0, nil
+	}
+	tx, err := types.TxFromBytes(raw)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	index := 0
+	for i, blockTx := range block.Txs {
+		if bytes.Equal(blockTx, raw) {
+			index = i
+			break
+		}
+	}
+	return tx, block, index, nil
+}
+
+func (s *ethService) getTransactionByHash(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := ethParam(params, 0, &hash, true); err != nil {
+		return nil, err
+	}
+	tx, block, index, err := s.findTx(hash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	ethTx := newEthTransaction(tx, hash)
+	blockHash := block.Hash()
+	blockNumber := hexutil.Uint64(block.Height)
+	txIndex := hexutil.Uint64(index)
+	ethTx.BlockHash, ethTx.BlockNumber, ethTx.TransactionIndex = &blockHash, &blockNumber, &txIndex
+	return ethTx, nil
+}
+
+// ethLog is an event log in the Ethereum format.
+type ethLog struct {
+	Address          common.Address `json:"address"`
+	Topics           []common.Hash  `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+// ethReceipt is a transaction receipt in the Ethereum format. The transactions of the finalized
+// blocks have all been applied, hence the status 1. The gas used by the smart contract
+// transactions is not recorded, so the gas limit is reported as an upper bound.
+type ethReceipt struct {
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	Logs              []*ethLog       `json:"logs"`
+	LogsBloom         hexutil.Bytes   `json:"logsBloom"`
+	Status            hexutil.Uint64  `json:"status"`
+	Type              hexutil.Uint64  `json:"type"`
+}
+
+func (s *ethService) getTransactionReceipt(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := ethParam(params, 0, &hash, true); err != nil {
+		return nil, err
+	}
+	tx, block, index, err := s.findTx(hash)
+	if err != nil || tx == nil {
+		return nil, err
+	}
+	ethTx := newEthTransaction(tx, hash)
+	receipt := &ethReceipt{
+		TransactionHash:   hash,
+		TransactionIndex:  hexutil.Uint64(index),
+		BlockHash:         block.Hash(),
+		BlockNumber:       hexutil.Uint64(block.Height),
+		From:              ethTx.From,
+		To:                ethTx.To,
+		GasUsed:           ethTx.Gas,
+		EffectiveGasPrice: ethTx.GasPrice,
+		Logs:              []*ethLog{},
+		LogsBloom:         make(hexutil.Bytes, core.BloomByteLength),
+		Status:            1,
+	}
+	if sctx, ok := tx.(*types.SmartContractTx); ok && ethTx.To == nil {
+		// The contract address is derived from the sequence of the sender before the transaction
+		contractAddress := crypto.CreateAddress(sctx.From.Address, sctx.From.Sequence-1)
+		receipt.ContractAddress = &contractAddress
+	}
+
+	logIndex := 0
+	if receipts, ok := s.t.chain.FindBlockReceipts(block.Hash()); ok {
+		for i, txReceipt := range receipts {
+			if i < index {
+				logIndex += len(txReceipt.Events)
+				continue
+			}
+			if txReceipt.TxHash == hash {
+				receipt.Logs = newEthLogs(txReceipt, block, index, logIndex)
+				receipt.LogsBloom = core.BytesToBloom(core.EventsBloom(txReceipt.Events).Bytes()).Bytes()
+			}
+			break
+		}
+	}
+	for _, blockTx := range block.Txs[:index+1] {
+		if t, err := types.TxFromBytes(blockTx); err == nil {
+			receipt.CumulativeGasUsed += newEthTransaction(t, common.Hash{}).Gas
+		}
+	}
+	return receipt, nil
+}
+
+func newEthLogs(receipt *core.TxReceipt, block *core.ExtendedBlock, txIndex, logIndex int) []*ethLog {
+	logs := []*ethLog{}
+	for i := range receipt.Events {
+		logs = append(logs, newEthLog(&receipt.Events[i], block, receipt.TxHash, txIndex, logIndex+i))
+	}
+	return logs
+}
+
+func newEthLog(event *core.ReceiptEvent, block *core.ExtendedBlock, txHash common.Hash, txIndex, logIndex int) *ethLog {
+	topics := event.Topics
+	if topics == nil {
+		topics = []common.Hash{}
+	}
+	return &ethLog{
+		Address:          event.Address,
+		Topics:           topics,
+		Data:             hexutil.Bytes(event.Data),
+		BlockNumber:      hexutil.Uint64(block.Height),
+		BlockHash:        block.Hash(),
+		TransactionHash:  txHash,
+		TransactionIndex: hexutil.Uint64(txIndex),
+		LogIndex:         hexutil.Uint64(logIndex),
+	}
+}
+
+// ------------------------------- Blocks -----------------------------------
+
+type ethBlock struct {
+	Number           hexutil.Uint64  `json:"number"`
+	Hash             common.Hash     `json:"hash"`
+	ParentHash       common.Hash     `json:"parentHash"`
+	StateRoot        common.Hash     `json:"stateRoot"`
+	TransactionsRoot common.Hash     `json:"transactionsRoot"`
+	Miner            common.Address  `json:"miner"`
+	Timestamp        *hexutil.Big    `json:"timestamp"`
+	GasLimit         hexutil.Uint64  `json:"gasLimit"`
+	GasUsed          hexutil.Uint64  `json:"gasUsed"`
+	Difficulty       hexutil.Uint64  `json:"difficulty"`
+	ExtraData        hexutil.Bytes   `json:"extraData"`
+	LogsBloom        hexutil.Bytes   `json:"logsBloom"`
+	Transactions     []interface{}   `json:"transactions"` // hashes, or the transactions if requested
+	Uncles           []common.Hash   `json:"uncles"`
+	Size             *hexutil.Uint64 `json:"size,omitempty"`
+}
+
+func (s *ethService) getBlockByNumber(params []json.RawMessage) (interface{}, error) {
+	block, pending, err := s.blockParam(params, 0)
+	if err != nil || pending {
+		return nil, err
+	}
+	fullTxs := false
+	if err := ethParam(params, 1, &fullTxs, false); err != nil {
+		return nil, err
+	}
+	return newEthBlock(block, fullTxs)
+}
+
+func (s *ethService) getBlockByHash(params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	if err := ethParam(params, 0, &hash, true); err != nil {
+		return nil, err
+	}
+	fullTxs := false
+	if err := ethParam(params, 1, &fullTxs, false); err != nil {
+		return nil, err
+	}
+	block, err := s.t.chain.FindBlock(hash)
+	if err != nil || !block.Status.IsFinalized() {
+		return nil, nil
+	}
+	return newEthBlock(block, fullTxs)
+}
+
+func newEthBlock(block *core.ExtendedBlock, fullTxs bool) (*ethBlock, error) {
+	timestamp := block.Timestamp
+	if timestamp == nil {
+		timestamp = big.NewInt(0)
+	}
+	ethBlock := &ethBlock{
+		Number:           hexutil.Uint64(block.Height),
+		Hash:             block.Hash(),
+		ParentHash:       block.Parent,
+		StateRoot:        block.StateHash,
+		TransactionsRoot: block.TxHash,
+		Miner:            block.Proposer,
+		Timestamp:        (*hexutil.Big)(timestamp),
+		GasLimit:         hexutil.Uint64(ethCallGasCap),
+		ExtraData:        hexutil.Bytes{},
+		LogsBloom:        block.Bloom.Bytes(),
+		Transactions:     []interface{}{},
+		Uncles:           []common.Hash{},
+	}
+	for i, raw := range block.Txs {
+		hash := crypto.Keccak256Hash(raw)
+		if !fullTxs {
+			ethBlock.Transactions = append(ethBlock.Transactions, hash)
+			continue
+		}
+		tx, err := types.TxFromBytes(raw)
+		if err != nil {
+			return nil, err
+		}
+		ethTx := newEthTransaction(tx, hash)
+		blockHash := block.Hash()
+		blockNumber := hexutil.Uint64(block.Height)
+		txIndex := hexutil.Uint64(i)
+		ethTx.BlockHash, ethTx.BlockNumber, ethTx.TransactionIndex = &blockHash, &blockNumber, &txIndex
+		ethBlock.Transactions = append(ethBlock.Transactions, ethTx)
+	}
+	return ethBlock, nil
+}
+
+// ------------------------------- Logs -----------------------------------
+
+type ethLogFilter struct {
+	FromBlock *string         `json:"fromBlock"`
+	ToBlock   *string         `json:"toBlock"`
+	BlockHash *common.Hash    `json:"blockHash"`
+	Address   json.RawMessage `json:"address"` // an address or a list of addresses
+	Topics    []interface{}   `json:"topics"`  // per position, null, a topic or a list of topics
+}
+
+func newEthLogCriteria(filter *ethLogFilter) (*logCriteria, error) {
+	criteria := &logCriteria{addresses: make(map[common.Address]bool)}
+	if len(filter.Address) > 0 && string(filter.Address) != "null" {
+		addresses := []common.Address{}
+		if err := json.Unmarshal(filter.Address, &addresses); err != nil {
+			var address common.Address
+			if err := json.Unmarshal(filter.Address, &address); err != nil {
+				return nil, jsonrpc2.NewError(-32602, fmt.Sprintf("Invalid address filter: %v", err))
+			}
+			addresses = append(addresses, address)
+		}
+		for _, address := range addresses {
+			criteria.addresses[address] = true
+		}
+	}
+	for _, topic := range filter.Topics {
+		position := make(map[common.Hash]bool)
+		switch topic := topic.(type) {
+		case nil:
+		case string:
+			position[common.HexToHash(topic)] = true
+		case []interface{}:
+			for _, t := range topic {
+				s, ok := t.(string)
+				if !ok {
+					return nil, jsonrpc2.NewError(-32602, "Invalid topic filter")
+				}
+				position[common.HexToHash(s)] = true
+			}
+		default:
+			return nil, jsonrpc2.NewError(-32602, "Invalid topic filter")
+		}
+		criteria.topics = append(criteria.topics, position)
+	}
+	return criteria, nil
+}
+
+// getLogs returns the logs of the finalized blocks matching the filter, see GetLogs.
+func (s *ethService) getLogs(params []json.RawMessage) (interface{}, error) {
+	filter := ethLogFilter{}
+	if err := ethParam(params, 0, &filter, true); err != nil {
+		return nil, err
+	}
+	criteria, err := newEthLogCriteria(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []*blockLog
+	if filter.BlockHash != nil {
+		logs, err = s.t.findBlockLogs(criteria, *filter.BlockHash)
+	} else {
+		var from, to uint64
+		if from, err = s.logFilterHeight(filter.FromBlock); err != nil {
+			return nil, err
+		}
+		if to, err = s.logFilterHeight(filter.ToBlock); err != nil {
+			return nil, err
+		}
+		logs, err = s.t.findLogs(criteria, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ethLogs := []*ethLog{}
+	for _, log := range logs {
+		ethLogs = append(ethLogs, newEthLog(log.event, log.block, log.txHash, log.txIndex, log.logIndex))
+	}
+	return ethLogs, nil
+}
+
+// logFilterHeight returns the height of the block number or tag of a log filter, the last
+// finalized height if not set.
+func (s *ethService) logFilterHeight(param *string) (uint64, error) {
+	lastFinalized := s.t.finality.GetLastFinalizedBlock().Height
+	if param == nil {
+		return lastFinalized, nil
+	}
+	switch *param {
+	case ethBlockLatest, ethBlockFinalized, ethBlockSafe, ethBlockPending:
+		return lastFinalized, nil
+	case ethBlockEarliest:
+		return s.t.chain.Root().Height, nil
+	}
+	height, err := hexutil.DecodeUint64(*param)
+	if err != nil {
+		return 0, jsonrpc2.NewError(-32602, fmt.Sprintf("Invalid block number %v", *param))
+	}
+	if height > lastFinalized {
+		return lastFinalized, nil
+	}
+	return height, nil
+}
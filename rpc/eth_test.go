@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestEthTransaction(t *testing.T) {
+	assert := assert.New(t)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	hash := common.HexToHash("0x01")
+
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: from, Coins: types.NewCoins(0, 1000000000500), Sequence: 7}},
+		Outputs: []types.TxOutput{{Address: to, Coins: types.NewCoins(0, 500)}},
+	}
+	ethTx := newEthTransaction(sendTx, hash)
+	assert.Equal(hash, ethTx.Hash)
+	assert.Equal(from, ethTx.From)
+	assert.Equal(to, *ethTx.To)
+	assert.Equal(uint64(7), uint64(ethTx.Nonce))
+	assert.Equal(big.NewInt(500), ethTx.Value.ToInt())
+	assert.Equal(uint64(1), uint64(ethTx.Gas))
+	assert.Equal(big.NewInt(1000000000000), ethTx.GasPrice.ToInt())
+	assert.Equal(uint64(TxTypeSend), uint64(ethTx.ThetaTxType))
+
+	// A contract deployment has no recipient
+	deployTx := &types.SmartContractTx{
+		From:     types.TxInput{Address: from, Coins: types.NewCoins(0, 0), Sequence: 3},
+		GasLimit: 100000,
+		GasPrice: big.NewInt(4e12),
+		Data:     common.Hex2Bytes("6080"),
+	}
+	ethTx = newEthTransaction(deployTx, hash)
+	assert.Equal(from, ethTx.From)
+	assert.Nil(ethTx.To)
+	assert.Equal(uint64(3), uint64(ethTx.Nonce))
+	assert.Equal(uint64(100000), uint64(ethTx.Gas))
+	assert.Equal(big.NewInt(4e12), ethTx.GasPrice.ToInt())
+	assert.Equal(common.Hex2Bytes("6080"), []byte(ethTx.Input))
+	assert.Equal(uint64(TxTypeSmartContract), uint64(ethTx.ThetaTxType))
+
+	callTx := &types.SmartContractTx{
+		From:     types.TxInput{Address: from, Coins: types.NewCoins(0, 9), Sequence: 4},
+		To:       types.TxOutput{Address: to},
+		GasLimit: 21000,
+		GasPrice: big.NewInt(4e12),
+	}
+	ethTx = newEthTransaction(callTx, hash)
+	assert.Equal(to, *ethTx.To)
+	assert.Equal(big.NewInt(9), ethTx.Value.ToInt())
+}
+
+func TestEthLogCriteria(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	contract := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	transfer := common.HexToHash("0xddf252ad")
+	event := &core.ReceiptEvent{
+		Address: contract,
+		Topics:  []common.Hash{transfer, common.HexToHash("0x01"), common.HexToHash("0x02")},
+	}
+
+	parse := func(raw string) *logCriteria {
+		filter := ethLogFilter{}
+		require.Nil(json.Unmarshal([]byte(raw), &filter))
+		criteria, err := newEthLogCriteria(&filter)
+		require.Nil(err)
+		return criteria
+	}
+
+	assert.True(parse(`{}`).match(event))
+	assert.True(parse(`{"address":"0x3333333333333333333333333333333333333333"}`).match(event))
+	assert.True(parse(`{"address":["0x4444444444444444444444444444444444444444","0x3333333333333333333333333333333333333333"]}`).match(event))
+	assert.False(parse(`{"address":"0x4444444444444444444444444444444444444444"}`).match(event))
+
+	assert.True(parse(`{"topics":["0xddf252ad"]}`).match(event))
+	assert.True(parse(`{"topics":[null,null,["0x03","0x02"]]}`).match(event))
+	assert.False(parse(`{"topics":[null,"0x02"]}`).match(event))
+	assert.False(parse(`{"topics":[null,null,null,null]}`).match(event), "more topics than the event")
+
+	filter := ethLogFilter{Topics: []interface{}{float64(1)}}
+	_, err := newEthLogCriteria(&filter)
+	assert.NotNil(err)
+}
+
+func TestEthHandle(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	service := &ethService{t: &ThetaRPCService{}, ethChainID: 361}
+
+	resp := ethResponse{}
+	require.Nil(json.Unmarshal(service.handle([]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}`)), &resp))
+	require.Nil(resp.Error)
+	assert.Equal(`"0x169"`, string(*resp.Result))
+	assert.Equal(`1`, string(*resp.ID))
+
+	resp = ethResponse{}
+	require.Nil(json.Unmarshal(service.handle([]byte(`{"jsonrpc":"2.0","id":2,"method":"eth_mine"}`)), &resp))
+	require.NotNil(resp.Error)
+	assert.Equal(-32601, resp.Error.Code)
+	assert.Nil(resp.Result)
+
+	resp = ethResponse{}
+	require.Nil(json.Unmarshal(service.handle([]byte(`{"id":3}`)), &resp))
+	require.NotNil(resp.Error)
+	assert.Equal(-32600, resp.Error.Code)
+
+	// The responses of a batch follow the order of the requests
+	resps := []ethResponse{}
+	require.Nil(json.Unmarshal(service.handle([]byte(` [
+		{"jsonrpc":"2.0","id":"a","method":"net_version"},
+		{"jsonrpc":"2.0","id":"b","method":"eth_gasPrice"},
+		{"jsonrpc":"2.0","id":"c","method":"eth_unknown"}
+	]`)), &resps))
+	require.Equal(3, len(resps))
+	assert.Equal(`"a"`, string(*resps[0].ID))
+	assert.Equal(`"361"`, string(*resps[0].Result))
+	assert.Equal(`"0x5f5e100"`, string(*resps[1].Result))
+	require.NotNil(resps[2].Error)
+	assert.Equal(-32601, resps[2].Error.Code)
+
+	resp = ethResponse{}
+	require.Nil(json.Unmarshal(service.handle([]byte(`[]`)), &resp))
+	require.NotNil(resp.Error)
+	assert.Equal(-32600, resp.Error.Code)
+}
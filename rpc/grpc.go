@@ -0,0 +1,368 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"github.com/thetatoken/theta/rpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcService serves the gRPC API defined in rpc/pb/theta.proto. The methods delegate to the
+// JSON-RPC methods of the ThetaRPCService, so that both APIs return the same data.
+type grpcService struct {
+	t *ThetaRPCService
+}
+
+var _ pb.ThetaServer = (*grpcService)(nil)
+
+// newGRPCServer creates the gRPC server. The calls go through the tenant manager like the JSON-RPC
+// calls, the clients passing their API key in the x-api-key metadata.
+func newGRPCServer(service *ThetaRPCService) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(service.tenantUnaryInterceptor),
+		grpc.StreamInterceptor(service.tenantStreamInterceptor),
+	)
+	pb.RegisterThetaServer(server, &grpcService{t: service})
+	return server
+}
+
+func (t *ThetaRPCServer) serveGRPC() {
+	config, err := NewListenerConfigFromViper()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Invalid RPC listener config")
+	}
+	config.Port = viper.GetString(common.CfgRPCGRPCPort)
+	config.UnixSocket = ""
+	listeners, err := OpenListeners(config)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Failed to create gRPC listener")
+	}
+	logger.WithFields(log.Fields{
+		"port": config.Port,
+		"tls":  len(config.TLSCertFile) > 0,
+	}).Info("gRPC server started")
+
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			defer l.Close()
+			logger.Info(t.grpcServer.Serve(l))
+		}(l)
+	}
+}
+
+// grpcTenant returns the tenant of the gRPC call, identified like the HTTP requests.
+func (t *ThetaRPCService) grpcTenant(ctx context.Context) (*tenant, *jsonrpc2.Error) {
+	var key, host string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(APIKeyHeader)); len(values) > 0 {
+			key = values[0]
+		}
+		if values := md.Get(":authority"); len(values) > 0 {
+			host = values[0]
+		}
+	}
+	return t.tenants.identifyByKeyOrHost(key, host)
+}
+
+func (t *ThetaRPCService) tenantUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	tenant, rpcErr := t.grpcTenant(ctx)
+	if rpcErr == nil {
+		rpcErr = t.tenants.checkQuotas(tenant, []rpcCall{{Method: grpcMethodName(info.FullMethod)}})
+	}
+	if rpcErr != nil {
+		return nil, grpcError(rpcErr)
+	}
+	return handler(ctx, req)
+}
+
+func (t *ThetaRPCService) tenantStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	tenant, rpcErr := t.grpcTenant(ss.Context())
+	if rpcErr == nil {
+		rpcErr = t.tenants.openSubscription(tenant)
+	}
+	if rpcErr != nil {
+		return grpcError(rpcErr)
+	}
+	defer t.tenants.closeSubscription(tenant)
+	return handler(srv, ss)
+}
+
+// grpcMethodName converts the full name of a gRPC method, e.g. /theta.Theta/GetStatus, into the
+// name of the JSON-RPC method, e.g. theta.GetStatus, which the tenant quotas refer to.
+func grpcMethodName(fullMethod string) string {
+	return "theta." + fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+// grpcError converts the errors of the JSON-RPC methods into gRPC status errors. The ledger errors
+// carry the name of their result code, as the data of the JSON-RPC errors does.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	rpcErr, ok := err.(*jsonrpc2.Error)
+	if !ok {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	switch rpcErr.Code {
+	case errCodeUnauthorized:
+		return status.Error(codes.Unauthenticated, rpcErr.Message)
+	case errCodeLimitExceeded:
+		return status.Error(codes.ResourceExhausted, rpcErr.Message)
+	}
+	if data, ok := rpcErr.Data.(*ErrorData); ok && isLedgerErrorCode(rpcErr.Code) {
+		return status.Error(codes.FailedPrecondition, fmt.Sprintf("%v: %v", data.Name, rpcErr.Message))
+	}
+	return status.Error(codes.Unknown, rpcErr.Message)
+}
+
+func optionalBool(value *wrappers.BoolValue) *bool {
+	if value == nil {
+		return nil
+	}
+	return &value.Value
+}
+
+func bigToInt64(value *common.JSONBig) int64 {
+	if value == nil {
+		return 0
+	}
+	return (*big.Int)(value).Int64()
+}
+
+func bigToString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+// ------------------------------- Queries -----------------------------------
+
+func (s *grpcService) GetStatus(ctx context.Context, req *pb.GetStatusRequest) (*pb.Status, error) {
+	result := GetStatusResult{}
+	if err := s.t.GetStatus(&GetStatusArgs{}, &result); err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.Status{
+		LatestFinalizedBlockHash:   result.LatestFinalizedBlockHash.Bytes(),
+		LatestFinalizedBlockHeight: uint64(result.LatestFinalizedBlockHeight),
+		LatestFinalizedBlockTime:   bigToInt64(result.LatestFinalizedBlockTime),
+		LatestFinalizedBlockEpoch:  uint64(result.LatestFinalizedBlockEpoch),
+		CurrentEpoch:               uint64(result.CurrentEpoch),
+		CurrentTime:                bigToInt64(result.CurrentTime),
+		Syncing:                    result.Syncing,
+		ChainId:                    result.ChainID,
+		GenesisHash:                result.GenesisHash,
+	}, nil
+}
+
+func (s *grpcService) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.Account, error) {
+	if len(req.Address) != common.AddressLength {
+		return nil, status.Error(codes.InvalidArgument, "Address must be specified")
+	}
+	address := common.BytesToAddress(req.Address)
+	result := GetAccountResult{}
+	err := s.t.GetAccount(&GetAccountArgs{
+		Address:       address.Hex(),
+		Preview:       req.Preview,
+		FinalizedOnly: optionalBool(req.FinalizedOnly),
+	}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.Account{
+		Address:                address.Bytes(),
+		Sequence:               result.Sequence,
+		ThetaWei:               bigToString(result.Balance.ThetaWei),
+		TfuelWei:               bigToString(result.Balance.TFuelWei),
+		LastUpdatedBlockHeight: result.LastUpdatedBlockHeight,
+		Root:                   result.Root.Bytes(),
+		CodeHash:               result.CodeHash.Bytes(),
+	}, nil
+}
+
+func (s *grpcService) GetBlock(ctx context.Context, req *pb.GetBlockRequest) (*pb.Block, error) {
+	if len(req.Hash) != common.HashLength {
+		return nil, status.Error(codes.InvalidArgument, "Block hash must be specified")
+	}
+	result := GetBlockResult{}
+	err := s.t.GetBlock(&GetBlockArgs{
+		Hash:          common.BytesToHash(req.Hash),
+		FinalizedOnly: optionalBool(req.FinalizedOnly),
+	}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return newPBBlock(result.GetBlockResultInner)
+}
+
+func (s *grpcService) GetBlockByHeight(ctx context.Context, req *pb.GetBlockByHeightRequest) (*pb.Block, error) {
+	result := GetBlockResult{}
+	err := s.t.GetBlockByHeight(&GetBlockByHeightArgs{
+		Height:        common.JSONUint64(req.Height),
+		FinalizedOnly: optionalBool(req.FinalizedOnly),
+	}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return newPBBlock(result.GetBlockResultInner)
+}
+
+func newPBBlock(block *GetBlockResultInner) (*pb.Block, error) {
+	if block == nil {
+		return nil, status.Error(codes.NotFound, "Block not found")
+	}
+	pbBlock := &pb.Block{
+		ChainId:          block.ChainID,
+		Epoch:            uint64(block.Epoch),
+		Height:           uint64(block.Height),
+		Parent:           block.Parent.Bytes(),
+		TransactionsHash: block.TxHash.Bytes(),
+		StateHash:        block.StateHash.Bytes(),
+		Timestamp:        bigToInt64(block.Timestamp),
+		Proposer:         block.Proposer.Bytes(),
+		Status:           uint32(block.Status),
+		Hash:             block.Hash.Bytes(),
+	}
+	for _, child := range block.Children {
+		pbBlock.Children = append(pbBlock.Children, child.Bytes())
+	}
+	for _, tx := range block.Txs {
+		pbTx, err := newPBTransaction(tx.Hash, tx.Type, tx.Tx)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		pbBlock.Transactions = append(pbBlock.Transactions, pbTx)
+	}
+	return pbBlock, nil
+}
+
+func newPBTransaction(hash common.Hash, txType byte, tx types.Tx) (*pb.Transaction, error) {
+	raw, err := types.TxToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.Transaction{
+		Hash: hash.Bytes(),
+		Type: uint32(txType),
+		Raw:  raw,
+	}, nil
+}
+
+func (s *grpcService) GetTransaction(ctx context.Context, req *pb.GetTransactionRequest) (*pb.GetTransactionResponse, error) {
+	if len(req.Hash) != common.HashLength {
+		return nil, status.Error(codes.InvalidArgument, "Transanction hash must be specified")
+	}
+	result := GetTransactionResult{}
+	err := s.t.GetTransaction(&GetTransactionArgs{
+		Hash:          common.BytesToHash(req.Hash).Hex(),
+		FinalizedOnly: optionalBool(req.FinalizedOnly),
+	}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &pb.GetTransactionResponse{
+		Status:      string(result.Status),
+		BlockHeight: uint64(result.BlockHeight),
+	}
+	if !result.BlockHash.IsEmpty() {
+		resp.BlockHash = result.BlockHash.Bytes()
+	}
+	if result.Tx != nil {
+		if resp.Transaction, err = newPBTransaction(result.TxHash, result.Type, result.Tx); err != nil {
+			return nil, grpcError(err)
+		}
+	}
+	return resp, nil
+}
+
+// ------------------------------- Broadcast -----------------------------------
+
+func (s *grpcService) BroadcastRawTransaction(ctx context.Context, req *pb.BroadcastRawTransactionRequest) (*pb.BroadcastRawTransactionResponse, error) {
+	result := BroadcastRawTransactionResult{}
+	err := s.t.BroadcastRawTransaction(&BroadcastRawTransactionArgs{TxBytes: hex.EncodeToString(req.TxBytes)}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &pb.BroadcastRawTransactionResponse{
+		TxHash: common.HexToHash(result.TxHash).Bytes(),
+	}
+	if result.Block != nil {
+		resp.BlockHash = result.Block.Hash().Bytes()
+		resp.BlockHeight = result.Block.Height
+	}
+	return resp, nil
+}
+
+func (s *grpcService) BroadcastRawTransactionAsync(ctx context.Context, req *pb.BroadcastRawTransactionRequest) (*pb.BroadcastRawTransactionResponse, error) {
+	result := BroadcastRawTransactionAsyncResult{}
+	err := s.t.BroadcastRawTransactionAsync(&BroadcastRawTransactionAsyncArgs{TxBytes: hex.EncodeToString(req.TxBytes)}, &result)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.BroadcastRawTransactionResponse{
+		TxHash: common.HexToHash(result.TxHash).Bytes(),
+	}, nil
+}
+
+// ------------------------------- Subscriptions -----------------------------------
+
+func (s *grpcService) SubscribeNewBlocks(req *pb.SubscribeBlocksRequest, stream pb.Theta_SubscribeNewBlocksServer) error {
+	return s.streamBlockEvents(stream.Context(), TopicNewBlock, stream.Send)
+}
+
+func (s *grpcService) SubscribeFinalizedBlocks(req *pb.SubscribeBlocksRequest, stream pb.Theta_SubscribeFinalizedBlocksServer) error {
+	return s.streamBlockEvents(stream.Context(), TopicFinalizedBlock, stream.Send)
+}
+
+// streamBlockEvents sends the block events of the topic until the client cancels the stream. The
+// stream ends with an error if the client falls behind, so that it can resubscribe and catch up
+// with the queries instead of missing events.
+func (s *grpcService) streamBlockEvents(ctx context.Context, topic string, send func(*pb.BlockEvent) error) error {
+	listener, unlisten := s.t.subscriptions.listen(topic)
+	defer unlisten()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-listener.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "Event stream closed, the node is stopping or the client fell behind")
+			}
+			if err := send(newPBBlockEvent(event.(*BlockEvent))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newPBBlockEvent(event *BlockEvent) *pb.BlockEvent {
+	pbEvent := &pb.BlockEvent{
+		Hash:      event.Hash.Bytes(),
+		Height:    uint64(event.Height),
+		Parent:    event.Parent.Bytes(),
+		Epoch:     uint64(event.Epoch),
+		Timestamp: bigToInt64(event.Timestamp),
+		Proposer:  event.Proposer.Bytes(),
+	}
+	for _, txHash := range event.TxHashes {
+		pbEvent.TxHashes = append(pbEvent.TxHashes, txHash.Bytes())
+	}
+	return pbEvent
+}
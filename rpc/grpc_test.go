@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"github.com/thetatoken/theta/rpc/pb"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCBlockStream(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.NewBlock()
+	root.ChainID = "testchain"
+	root.Timestamp = big.NewInt(1000)
+	chain := blockchain.NewChain("testchain", store, root)
+	b1 := createChainStatsTestBlock(root, 1002, []common.Bytes{})
+
+	hub := newSubscriptionHub(chain)
+	validated := make(chan *core.Block)
+	finalized := make(chan *core.Block)
+	txEvents := make(chan *mempool.TxEvent)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	go hub.run(hubCtx, 0, validated, finalized, txEvents)
+
+	service := &grpcService{t: &ThetaRPCService{subscriptions: hub}}
+	events := make(chan *pb.BlockEvent, 1)
+	done := make(chan error)
+	go func() {
+		done <- service.streamBlockEvents(context.Background(), TopicNewBlock, func(event *pb.BlockEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	// Wait for the stream to listen before feeding the block
+	for {
+		hub.mu.Lock()
+		numListeners := len(hub.listeners)
+		hub.mu.Unlock()
+		if numListeners > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	validated <- b1
+	event := <-events
+	assert.Equal(b1.Hash().Bytes(), event.Hash)
+	assert.Equal(b1.Height, event.Height)
+	assert.Equal(root.Hash().Bytes(), event.Parent)
+	assert.Equal(int64(1002), event.Timestamp)
+
+	// The stream ends when the hub stops
+	stopHub()
+	err := <-done
+	require.NotNil(err)
+	assert.Equal(codes.Unavailable, status.Code(err))
+}
+
+func TestGRPCListenerOverflow(t *testing.T) {
+	assert := assert.New(t)
+
+	hub := newSubscriptionHub(nil)
+	listener, unlisten := hub.listen(TopicFinalizedBlock)
+	defer unlisten()
+	other, unlistenOther := hub.listen(TopicNewBlock)
+	defer unlistenOther()
+
+	// A listener which falls behind is dropped rather than missing events
+	for i := 0; i <= subscriptionSendQueueSize; i++ {
+		hub.notify(TopicFinalizedBlock, nil, &BlockEvent{Height: common.JSONUint64(i)})
+	}
+	received := 0
+	for range listener.C {
+		received++
+	}
+	assert.Equal(subscriptionSendQueueSize, received)
+	assert.Equal(0, len(other.C))
+
+	hub.mu.Lock()
+	_, listening := hub.listeners[other]
+	assert.True(listening)
+	assert.Equal(1, len(hub.listeners))
+	hub.mu.Unlock()
+}
+
+func TestGRPCErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("theta.GetStatus", grpcMethodName("/theta.Theta/GetStatus"))
+	assert.Equal("theta.SubscribeNewBlocks", grpcMethodName("/theta.Theta/SubscribeNewBlocks"))
+
+	assert.Nil(grpcError(nil))
+	assert.Equal(codes.Unknown, status.Code(grpcError(errors.New("failure"))))
+	assert.Equal(codes.Unauthenticated, status.Code(grpcError(jsonrpc2.NewError(errCodeUnauthorized, "Invalid API key"))))
+	assert.Equal(codes.ResourceExhausted, status.Code(grpcError(jsonrpc2.NewError(errCodeLimitExceeded, "Rate limit exceeded"))))
+
+	err := grpcError(newRPCError(result.Error("Insufficient fund").WithErrorCode(result.CodeInsufficientFund)))
+	assert.Equal(codes.FailedPrecondition, status.Code(err))
+	assert.Equal(result.CodeInsufficientFund.Name()+": Insufficient fund", status.Convert(err).Message())
+}
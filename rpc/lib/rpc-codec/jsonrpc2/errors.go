@@ -24,6 +24,18 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// ServerErrorEncoder, if set, rewrites the errors sent by the server, e.g. to
+// attach application error codes. It is given the error returned by the called
+// RPC method, converted to an Error.
+var ServerErrorEncoder func(e *Error) *Error
+
+func serverError(e *Error) *Error {
+	if ServerErrorEncoder == nil {
+		return e
+	}
+	return ServerErrorEncoder(e)
+}
+
 // NewError returns an Error with given code and message.
 func NewError(code int, message string) *Error {
 	return &Error{Code: code, Message: message}
@@ -150,7 +150,7 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 	var raw json.RawMessage
 	if err := c.dec.Decode(&raw); err != nil {
 		c.encmutex.Lock()
-		_ = c.enc.Encode(serverResponse{Version: protoVer, ID: &null, Error: errParse})
+		_ = c.enc.Encode(serverResponse{Version: protoVer, ID: &null, Error: serverError(errParse)})
 		c.encmutex.Unlock()
 		return err
 	}
@@ -163,7 +163,7 @@ func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (err error) {
 	} else if err := json.Unmarshal(raw, &c.req); err != nil {
 		if err.Error() == "bad request" {
 			c.encmutex.Lock()
-			_ = c.enc.Encode(serverResponse{Version: protoVer, ID: &null, Error: errRequest})
+			_ = c.enc.Encode(serverResponse{Version: protoVer, ID: &null, Error: serverError(errRequest)})
 			c.encmutex.Unlock()
 		}
 		return err
@@ -266,9 +266,15 @@ func (c *serverCodec) WriteResponse(r *rpc.Response, x interface{}) error {
 		// can force sending wrong reply or many replies instead
 		// of one) and normal errors won't be formatted this way.
 		raw := json.RawMessage(r.Error)
+		if ServerErrorEncoder != nil {
+			e := &Error{}
+			if err := json.Unmarshal(raw, e); err == nil {
+				raw = json.RawMessage(serverError(e).Error())
+			}
+		}
 		resp.Error = &raw
 	default:
-		raw := json.RawMessage(newError(r.Error).Error())
+		raw := json.RawMessage(serverError(newError(r.Error)).Error())
 		resp.Error = &raw
 	}
 	c.encmutex.Lock()
@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"golang.org/x/net/netutil"
+)
+
+// ListenerConfig describes where the RPC server accepts connections
+type ListenerConfig struct {
+	Port           string
+	MaxConnections int // per listener
+
+	// The TCP listener serves TLS if a certificate is set, and requires the clients to present a
+	// certificate signed by one of the client CAs if set.
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+
+	// Path of the unix domain socket the RPC server also listens on, if set
+	UnixSocket     string
+	UnixSocketMode os.FileMode
+}
+
+// NewListenerConfigFromViper reads the listener config of the RPC server.
+func NewListenerConfigFromViper() (ListenerConfig, error) {
+	mode, err := strconv.ParseUint(viper.GetString(common.CfgRPCUnixSocketMode), 8, 32)
+	if err != nil {
+		return ListenerConfig{}, fmt.Errorf("Invalid unix socket mode %v: %v", viper.GetString(common.CfgRPCUnixSocketMode), err)
+	}
+	return ListenerConfig{
+		Port:            viper.GetString(common.CfgRPCPort),
+		MaxConnections:  viper.GetInt(common.CfgRPCMaxConnections),
+		TLSCertFile:     viper.GetString(common.CfgRPCTLSCertFile),
+		TLSKeyFile:      viper.GetString(common.CfgRPCTLSKeyFile),
+		TLSClientCAFile: viper.GetString(common.CfgRPCTLSClientCAFile),
+		UnixSocket:      viper.GetString(common.CfgRPCUnixSocket),
+		UnixSocketMode:  os.FileMode(mode),
+	}, nil
+}
+
+// OpenListeners opens the TCP listener, and the unix socket listener if configured.
+func OpenListeners(config ListenerConfig) ([]net.Listener, error) {
+	tcpListener, err := openTCPListener(config)
+	if err != nil {
+		return nil, err
+	}
+	listeners := []net.Listener{tcpListener}
+
+	if len(config.UnixSocket) > 0 {
+		unixListener, err := openUnixListener(config.UnixSocket, config.UnixSocketMode)
+		if err != nil {
+			tcpListener.Close()
+			return nil, err
+		}
+		listeners = append(listeners, unixListener)
+	}
+
+	for i, l := range listeners {
+		listeners[i] = netutil.LimitListener(l, config.MaxConnections)
+	}
+	return listeners, nil
+}
+
+func openTCPListener(config ListenerConfig) (net.Listener, error) {
+	if len(config.TLSCertFile) == 0 {
+		return net.Listen("tcp", ":"+config.Port)
+	}
+	tlsConfig, err := newTLSConfig(config.TLSCertFile, config.TLSKeyFile, config.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return tls.Listen("tcp", ":"+config.Port, tlsConfig)
+}
+
+func newTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the TLS certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if len(clientCAFile) > 0 {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the TLS client CAs: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificate found in %v", clientCAFile)
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// openUnixListener listens on the unix socket. The socket left by a node which did not shut down
+// cleanly is replaced, but not any other kind of file. The socket is created in a private
+// directory, and moved in place once its mode is set, so that it cannot be connected to before.
+func openUnixListener(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("Cannot listen on %v, the file exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// TempDir creates the directory with mode 0700. Its name is kept short, as the length of
+	// the socket paths is limited.
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".sock")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "s")
+
+	l, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	ul := l.(*net.UnixListener)
+	ul.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		ul.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		ul.Close()
+		return nil, err
+	}
+	return &unixListener{UnixListener: ul, path: path}, nil
+}
+
+// unixListener removes the socket once closed.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	os.Remove(l.path)
+	return err
+}
@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocketListener(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "rpc-listener")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rpc.sock")
+
+	listeners, err := OpenListeners(ListenerConfig{Port: "0", MaxConnections: 1, UnixSocket: path, UnixSocketMode: 0600})
+	require.Nil(err)
+	require.Equal(2, len(listeners))
+	info, err := os.Stat(path)
+	require.Nil(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+	assertEcho(t, listeners[1], func() (net.Conn, error) { return net.Dial("unix", path) })
+	closeListeners(listeners)
+
+	// Neither the socket nor the private directory it was created in are left behind
+	files, err := ioutil.ReadDir(dir)
+	require.Nil(err)
+	assert.Equal(0, len(files))
+
+	// The socket left by a previous run is replaced
+	l, err := net.Listen("unix", path)
+	require.Nil(err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	listeners, err = OpenListeners(ListenerConfig{Port: "0", MaxConnections: 1, UnixSocket: path, UnixSocketMode: 0600})
+	require.Nil(err)
+	closeListeners(listeners)
+
+	// Other files are not
+	require.Nil(ioutil.WriteFile(path, []byte("data"), 0600))
+	_, err = OpenListeners(ListenerConfig{Port: "0", MaxConnections: 1, UnixSocket: path, UnixSocketMode: 0600})
+	assert.NotNil(err)
+}
+
+func TestTLSListener(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "rpc-listener")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	serverCert, serverCertFile, serverKeyFile := createTestCert(t, dir, "server")
+	clientCert, clientCertFile, _ := createTestCert(t, dir, "client")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.Leaf)
+
+	listeners, err := OpenListeners(ListenerConfig{Port: "0", MaxConnections: 1,
+		TLSCertFile: serverCertFile, TLSKeyFile: serverKeyFile})
+	require.Nil(err)
+	require.Equal(1, len(listeners))
+	addr := listeners[0].Addr().String()
+	assertEcho(t, listeners[0], func() (net.Conn, error) {
+		return tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	})
+	closeListeners(listeners)
+
+	// Client certificates are required once the client CAs are set
+	listeners, err = OpenListeners(ListenerConfig{Port: "0", MaxConnections: 1,
+		TLSCertFile: serverCertFile, TLSKeyFile: serverKeyFile, TLSClientCAFile: clientCertFile})
+	require.Nil(err)
+	defer closeListeners(listeners)
+	addr = listeners[0].Addr().String()
+	assertEcho(t, listeners[0], func() (net.Conn, error) {
+		return tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "localhost",
+			Certificates: []tls.Certificate{clientCert}})
+	})
+
+	go func() {
+		if conn, err := listeners[0].Accept(); err == nil {
+			conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+	}()
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	if err == nil {
+		// The handshake only fails on the server with TLS 1.3, and the client learns it on read
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	require.NotNil(err)
+}
+
+// assertEcho checks that a connection dialed is accepted by the listener, and can exchange data.
+func assertEcho(t *testing.T, l net.Listener, dial func() (net.Conn, error)) {
+	require := require.New(t)
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4)
+		if _, err := conn.Read(buf); err == nil {
+			conn.Write(buf)
+		}
+	}()
+
+	conn, err := dial()
+	require.Nil(err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Write([]byte("ping"))
+	require.Nil(err)
+	buf := make([]byte, 4)
+	_, err = conn.Read(buf)
+	require.Nil(err)
+	require.Equal("ping", string(buf))
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
+
+// createTestCert creates a self-signed certificate for localhost, and writes it and its key to
+// PEM files.
+func createTestCert(t *testing.T, dir string, name string) (cert tls.Certificate, certFile string, keyFile string) {
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.Nil(ioutil.WriteFile(certFile, certPEM, 0600))
+	require.Nil(ioutil.WriteFile(keyFile, keyPEM, 0600))
+
+	cert, err = tls.X509KeyPair(certPEM, keyPEM)
+	require.Nil(err)
+	cert.Leaf, err = x509.ParseCertificate(der)
+	require.Nil(err)
+	return cert, certFile, keyFile
+}
@@ -0,0 +1,295 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+)
+
+const (
+	// MaxLogsBlockRange is the max number of blocks scanned by GetLogs without an address or
+	// topic filter
+	MaxLogsBlockRange = 1000
+
+	// MaxIndexedLogsBlockRange is the max number of blocks covered by GetLogs with an address or
+	// topic filter, the candidate blocks being looked up in the log index
+	MaxIndexedLogsBlockRange = 100000
+)
+
+// ------------------------------ GetLogs -----------------------------------
+
+type GetLogsArgs struct {
+	FromHeight common.JSONUint64  `json:"from_height"`
+	ToHeight   *common.JSONUint64 `json:"to_height"`  // the last finalized block if not set
+	BlockHash  string             `json:"block_hash"` // the logs of this block only, instead of the heights
+	Addresses  []string           `json:"addresses"`  // the logs emitted by any of the addresses
+	Topics     [][]string         `json:"topics"`     // per position, any of the topics, an empty list matching all
+}
+
+// LogEntry is an event emitted by a transaction of a finalized block.
+type LogEntry struct {
+	Address     common.Address    `json:"address"`
+	Topics      []common.Hash     `json:"topics"`
+	Data        common.Bytes      `json:"data"`
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	TxHash      common.Hash       `json:"tx_hash"`
+	TxIndex     common.JSONUint64 `json:"tx_index"`
+	LogIndex    common.JSONUint64 `json:"log_index"` // index of the log in the block
+}
+
+type GetLogsResult struct {
+	Logs []*LogEntry `json:"logs"`
+}
+
+// GetLogs returns the logs of the finalized blocks matching the filter, in the order they were
+// emitted.
+func (t *ThetaRPCService) GetLogs(args *GetLogsArgs, result *GetLogsResult) (err error) {
+	criteria := &logCriteria{addresses: make(map[common.Address]bool)}
+	for _, address := range args.Addresses {
+		criteria.addresses[common.HexToAddress(address)] = true
+	}
+	for _, topics := range args.Topics {
+		position := make(map[common.Hash]bool)
+		for _, topic := range topics {
+			position[common.HexToHash(topic)] = true
+		}
+		criteria.topics = append(criteria.topics, position)
+	}
+
+	var logs []*blockLog
+	if args.BlockHash != "" {
+		if args.FromHeight != 0 || args.ToHeight != nil {
+			return errors.New("The block hash and the heights cannot be both specified")
+		}
+		logs, err = t.findBlockLogs(criteria, common.HexToHash(args.BlockHash))
+	} else {
+		toHeight := t.finality.GetLastFinalizedBlock().Height
+		if args.ToHeight != nil && uint64(*args.ToHeight) < toHeight {
+			toHeight = uint64(*args.ToHeight)
+		}
+		logs, err = t.findLogs(criteria, uint64(args.FromHeight), toHeight)
+	}
+	if err != nil {
+		return err
+	}
+
+	result.Logs = []*LogEntry{}
+	for _, log := range logs {
+		topics := log.event.Topics
+		if topics == nil {
+			topics = []common.Hash{}
+		}
+		result.Logs = append(result.Logs, &LogEntry{
+			Address:     log.event.Address,
+			Topics:      topics,
+			Data:        log.event.Data,
+			BlockHash:   log.block.Hash(),
+			BlockHeight: common.JSONUint64(log.block.Height),
+			TxHash:      log.txHash,
+			TxIndex:     common.JSONUint64(log.txIndex),
+			LogIndex:    common.JSONUint64(log.logIndex),
+		})
+	}
+	return nil
+}
+
+// ------------------------------ Utils ------------------------------
+
+// logCriteria are the criteria of a log filter: any of the addresses, and per position any of
+// the topics. Empty criteria match everything.
+type logCriteria struct {
+	addresses map[common.Address]bool
+	topics    []map[common.Hash]bool
+}
+
+func (c *logCriteria) match(event *core.ReceiptEvent) bool {
+	if len(c.addresses) > 0 && !c.addresses[event.Address] {
+		return false
+	}
+	if len(c.topics) > len(event.Topics) {
+		return false
+	}
+	for i, position := range c.topics {
+		if len(position) > 0 && !position[event.Topics[i]] {
+			return false
+		}
+	}
+	return true
+}
+
+// indexed indicates whether the criteria can be looked up in the log index.
+func (c *logCriteria) indexed() bool {
+	if len(c.addresses) > 0 {
+		return true
+	}
+	for _, position := range c.topics {
+		if len(position) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// matchBloom indicates whether the block with the bloom may have logs matching the criteria.
+func (c *logCriteria) matchBloom(bloom core.Bloom) bool {
+	if len(c.addresses) > 0 {
+		found := false
+		for address := range c.addresses {
+			if core.BloomLookup(bloom, address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, position := range c.topics {
+		if len(position) == 0 {
+			continue
+		}
+		found := false
+		for topic := range position {
+			if core.BloomLookup(bloom, topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// blockLog is a log matching a filter, with its position in the finalized block.
+type blockLog struct {
+	event    *core.ReceiptEvent
+	block    *core.ExtendedBlock
+	txHash   common.Hash
+	txIndex  int
+	logIndex int
+}
+
+// findLogs returns the logs of the finalized blocks between the heights, inclusive, matching the
+// criteria. With an address or a topic, the candidate blocks are looked up in the log index,
+// otherwise all the blocks are scanned.
+func (t *ThetaRPCService) findLogs(criteria *logCriteria, fromHeight, toHeight uint64) ([]*blockLog, error) {
+	logs := []*blockLog{}
+	if fromHeight > toHeight {
+		return logs, nil
+	}
+	if !criteria.indexed() {
+		if toHeight-fromHeight >= MaxLogsBlockRange {
+			return nil, fmt.Errorf("The block range is limited to %v blocks without an address or topic filter", MaxLogsBlockRange)
+		}
+		for height := fromHeight; height <= toHeight; height++ {
+			if block := findFinalizedBlock(t.chain, height); block != nil {
+				logs = append(logs, t.matchBlockLogs(criteria, block)...)
+			}
+		}
+		return logs, nil
+	}
+
+	if toHeight-fromHeight >= MaxIndexedLogsBlockRange {
+		return nil, fmt.Errorf("The block range is limited to %v blocks", MaxIndexedLogsBlockRange)
+	}
+	for _, height := range sortedHeights(t.findLogCandidates(criteria, fromHeight, toHeight)) {
+		if block := findFinalizedBlock(t.chain, height); block != nil {
+			logs = append(logs, t.matchBlockLogs(criteria, block)...)
+		}
+	}
+	return logs, nil
+}
+
+// findLogCandidates returns the heights of the blocks which may have logs matching the indexed
+// criteria, according to the log index.
+func (t *ThetaRPCService) findLogCandidates(criteria *logCriteria, fromHeight, toHeight uint64) map[uint64]bool {
+	var candidates map[uint64]bool
+	intersect := func(heights map[uint64]bool) {
+		if candidates == nil {
+			candidates = heights
+			return
+		}
+		for height := range candidates {
+			if !heights[height] {
+				delete(candidates, height)
+			}
+		}
+	}
+
+	if len(criteria.addresses) > 0 {
+		heights := make(map[uint64]bool)
+		for address := range criteria.addresses {
+			for _, b := range t.chain.FindLogBlocksByAddress(address, fromHeight, toHeight) {
+				heights[b.Height] = true
+			}
+		}
+		intersect(heights)
+	}
+	for _, position := range criteria.topics {
+		if len(position) == 0 {
+			continue
+		}
+		heights := make(map[uint64]bool)
+		for topic := range position {
+			for _, b := range t.chain.FindLogBlocksByTopic(topic, fromHeight, toHeight) {
+				heights[b.Height] = true
+			}
+		}
+		intersect(heights)
+	}
+	return candidates
+}
+
+// findBlockLogs returns the logs of the finalized block with the hash matching the criteria.
+func (t *ThetaRPCService) findBlockLogs(criteria *logCriteria, blockHash common.Hash) ([]*blockLog, error) {
+	block, err := t.chain.FindBlock(blockHash)
+	if err != nil || !block.Status.IsFinalized() {
+		return nil, fmt.Errorf("Finalized block %v not found", blockHash.Hex())
+	}
+	return t.matchBlockLogs(criteria, block), nil
+}
+
+// matchBlockLogs returns the logs of the block matching the criteria, skipping the blocks whose
+// bloom rules them out.
+func (t *ThetaRPCService) matchBlockLogs(criteria *logCriteria, block *core.ExtendedBlock) []*blockLog {
+	logs := []*blockLog{}
+	if bloom, ok := t.chain.FindBlockBloom(block.Hash()); ok && !criteria.matchBloom(bloom) {
+		return logs
+	}
+	receipts, ok := t.chain.FindBlockReceipts(block.Hash())
+	if !ok {
+		return logs
+	}
+	logIndex := 0
+	for txIndex, receipt := range receipts {
+		for i := range receipt.Events {
+			if criteria.match(&receipt.Events[i]) {
+				logs = append(logs, &blockLog{
+					event:    &receipt.Events[i],
+					block:    block,
+					txHash:   receipt.TxHash,
+					txIndex:  txIndex,
+					logIndex: logIndex + i,
+				})
+			}
+		}
+		logIndex += len(receipt.Events)
+	}
+	return logs
+}
+
+// sortedHeights returns the heights in ascending order.
+func sortedHeights(heights map[uint64]bool) []uint64 {
+	sorted := make([]uint64, 0, len(heights))
+	for height := range heights {
+		sorted = append(sorted, height)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
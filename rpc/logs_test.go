@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func TestGetLogs(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.NewBlock()
+	root.ChainID = "testchain"
+	root.Timestamp = big.NewInt(1000)
+	chain := blockchain.NewChain("testchain", store, root)
+	finality := &testFinality{chain: chain}
+	service := &ThetaRPCService{chain: chain, finality: finality}
+
+	token := common.HexToAddress("0x1234")
+	other := common.HexToAddress("0x5678")
+	transfer := common.HexToHash("0xddf252ad")
+	alice := common.HexToHash("0xa11ce")
+	bob := common.HexToHash("0xb0b")
+
+	// Blocks 1 to 4 with a token transfer from alice at the odd heights, and an event of another
+	// contract at height 2. Block 4 is not finalized.
+	parent := root
+	blocks := []*core.Block{}
+	for height := 1; height <= 4; height++ {
+		block := createChainStatsTestBlock(parent, 1000+int64(height), []common.Bytes{})
+		receipts := []*core.TxReceipt{{TxHash: common.HexToHash("0x01")}}
+		if height%2 == 1 {
+			receipts = append(receipts, &core.TxReceipt{
+				TxHash: common.BigToHash(big.NewInt(int64(height))),
+				Events: []core.ReceiptEvent{
+					{Address: token, Topics: []common.Hash{transfer, alice, bob}, Data: common.Bytes("100")},
+				},
+			})
+		} else {
+			receipts[0].Events = []core.ReceiptEvent{{Address: other, Topics: []common.Hash{bob}}}
+		}
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+		chain.AddBlockReceipts(block.Hash(), receipts)
+		chain.AddLogsToIndex(block, receipts)
+		blocks = append(blocks, block)
+		parent = block
+	}
+	chain.FinalizePreviousBlocks(blocks[2].Hash())
+	finality.lastFinalized, _ = chain.FindBlock(blocks[2].Hash())
+
+	result := &GetLogsResult{}
+	require.Nil(service.GetLogs(&GetLogsArgs{Addresses: []string{token.Hex()}}, result))
+	require.Equal(2, len(result.Logs))
+	assert.Equal(blocks[0].Hash(), result.Logs[0].BlockHash)
+	assert.Equal(common.JSONUint64(1), result.Logs[0].BlockHeight)
+	assert.Equal(common.JSONUint64(1), result.Logs[0].TxIndex)
+	assert.Equal(common.JSONUint64(0), result.Logs[0].LogIndex)
+	assert.Equal(common.BigToHash(big.NewInt(1)), result.Logs[0].TxHash)
+	assert.Equal(common.Bytes("100"), result.Logs[0].Data)
+	assert.Equal(blocks[2].Hash(), result.Logs[1].BlockHash)
+
+	// Topics match by position
+	require.Nil(service.GetLogs(&GetLogsArgs{Topics: [][]string{{}, {}, {bob.Hex()}}}, result))
+	assert.Equal(2, len(result.Logs))
+	require.Nil(service.GetLogs(&GetLogsArgs{Topics: [][]string{{bob.Hex()}}}, result))
+	require.Equal(1, len(result.Logs))
+	assert.Equal(other, result.Logs[0].Address)
+	require.Nil(service.GetLogs(&GetLogsArgs{Topics: [][]string{{transfer.Hex()}, {bob.Hex(), common.HexToHash("0xca401").Hex()}}}, result))
+	assert.Equal(0, len(result.Logs))
+
+	// Height range, and the unfiltered scan
+	toHeight := common.JSONUint64(2)
+	require.Nil(service.GetLogs(&GetLogsArgs{FromHeight: 2, ToHeight: &toHeight}, result))
+	require.Equal(1, len(result.Logs))
+	assert.Equal(common.JSONUint64(2), result.Logs[0].BlockHeight)
+	require.Nil(service.GetLogs(&GetLogsArgs{}, result))
+	assert.Equal(3, len(result.Logs), "block 4 is not finalized")
+
+	// Single block
+	require.Nil(service.GetLogs(&GetLogsArgs{BlockHash: blocks[2].Hash().Hex(), Addresses: []string{token.Hex()}}, result))
+	require.Equal(1, len(result.Logs))
+	assert.NotNil(service.GetLogs(&GetLogsArgs{BlockHash: blocks[3].Hash().Hex()}, result), "not finalized")
+	assert.NotNil(service.GetLogs(&GetLogsArgs{BlockHash: blocks[2].Hash().Hex(), FromHeight: 1}, result))
+
+	// The unfiltered scans are limited
+	finality.lastFinalized = &core.ExtendedBlock{Block: &core.Block{BlockHeader: &core.BlockHeader{Height: MaxLogsBlockRange}}}
+	assert.NotNil(service.GetLogs(&GetLogsArgs{}, result))
+	require.Nil(service.GetLogs(&GetLogsArgs{Addresses: []string{token.Hex()}}, result))
+	assert.Equal(2, len(result.Logs))
+}
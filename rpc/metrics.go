@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"sort"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics/prometheus"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+// nodeStatsFamilies exports the node-wide stats computed on demand to Prometheus, i.e. the number
+// of peers, the gossip traffic per channel summed over the peers, and the mempool size
+func (t *ThetaRPCService) nodeStatsFamilies() []prometheus.Family {
+	families := buildChannelStatsFamilies(t.dispatcher.PeerStats())
+	families = append(families, prometheus.Family{
+		Name:    "mempool_size",
+		Help:    "Transactions in the mempool.",
+		Type:    "gauge",
+		Samples: []prometheus.Sample{{Value: float64(t.mempool.Size())}},
+	})
+	return families
+}
+
+func buildChannelStatsFamilies(allStats []p2ptypes.PeerStats) []prometheus.Family {
+	received := make(map[common.ChannelIDEnum]uint64)
+	sent := make(map[common.ChannelIDEnum]uint64)
+	for _, peerStats := range allStats {
+		for _, cs := range peerStats.Channels {
+			received[cs.ChannelID] += cs.BytesReceived
+			sent[cs.ChannelID] += cs.BytesSent
+		}
+	}
+	channelIDs := []common.ChannelIDEnum{}
+	for channelID := range received {
+		channelIDs = append(channelIDs, channelID)
+	}
+	sort.Slice(channelIDs, func(i, j int) bool { return channelIDs[i] < channelIDs[j] })
+
+	families := []prometheus.Family{
+		{Name: "p2p_peers", Help: "Connected peers.", Type: "gauge",
+			Samples: []prometheus.Sample{{Value: float64(len(allStats))}}},
+		{Name: "p2p_channel_received_bytes_total", Help: "Bytes received from all the peers on the channel.", Type: "counter"},
+		{Name: "p2p_channel_sent_bytes_total", Help: "Bytes sent to all the peers on the channel.", Type: "counter"},
+	}
+	for _, channelID := range channelIDs {
+		labels := map[string]string{"channel": p2ptypes.ChannelName(channelID)}
+		families[1].Samples = append(families[1].Samples, prometheus.Sample{Labels: labels, Value: float64(received[channelID])})
+		families[2].Samples = append(families[2].Samples, prometheus.Sample{Labels: labels, Value: float64(sent[channelID])})
+	}
+	return families
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+func TestBuildChannelStatsFamilies(t *testing.T) {
+	assert := assert.New(t)
+
+	allStats := []p2ptypes.PeerStats{
+		{
+			PeerID: "peer1",
+			Channels: []p2ptypes.ChannelStats{
+				{ChannelID: common.ChannelIDVote, BytesReceived: 100, BytesSent: 10},
+				{ChannelID: common.ChannelIDBlock, BytesReceived: 1000, BytesSent: 20},
+			},
+		},
+		{
+			PeerID: "peer2",
+			Channels: []p2ptypes.ChannelStats{
+				{ChannelID: common.ChannelIDVote, BytesReceived: 50, BytesSent: 5},
+			},
+		},
+	}
+
+	families := buildChannelStatsFamilies(allStats)
+	assert.Equal(3, len(families))
+
+	assert.Equal("p2p_peers", families[0].Name)
+	assert.Equal(float64(2), families[0].Samples[0].Value)
+
+	received := families[1]
+	assert.Equal("p2p_channel_received_bytes_total", received.Name)
+	assert.Equal(2, len(received.Samples))
+	assert.Equal("block", received.Samples[0].Labels["channel"])
+	assert.Equal(float64(1000), received.Samples[0].Value)
+	assert.Equal("vote", received.Samples[1].Labels["channel"])
+	assert.Equal(float64(150), received.Samples[1].Value)
+
+	sent := families[2]
+	assert.Equal(float64(20), sent.Samples[0].Value)
+	assert.Equal(float64(15), sent.Samples[1].Value)
+}
@@ -0,0 +1,254 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+// Max number of payment sessions the node keeps track of
+const maxPaymentSessions = 10000
+
+const (
+	// PaymentSessionReserveNotFound indicates the reserve is released, expired, or never existed
+	PaymentSessionReserveNotFound = "reserve_not_found"
+	// PaymentSessionReserveExpiring indicates the reserve expires soon, the payment should be settled on-chain
+	PaymentSessionReserveExpiring = "reserve_expiring"
+	// PaymentSessionCompetingSettlement indicates payments to other targets were settled on-chain from the reserve
+	PaymentSessionCompetingSettlement = "competing_settlement"
+	// PaymentSessionInsufficientReserve indicates the remaining reserve cannot cover the payment held by the target
+	PaymentSessionInsufficientReserve = "insufficient_reserve"
+	// PaymentSessionSettled indicates the payment held by the target has been settled on-chain
+	PaymentSessionSettled = "settled"
+)
+
+// PaymentSession is the latest off-chain payment state a target holds for a reserve of the source.
+type PaymentSession struct {
+	Source          common.Address    `json:"source"`
+	Target          common.Address    `json:"target"`
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+	PaymentSequence common.JSONUint64 `json:"payment_sequence"`
+	Amount          types.Coins       `json:"amount"`
+	Warnings        []string          `json:"warnings"`
+}
+
+func (s *PaymentSession) key() string {
+	return fmt.Sprintf("%v/%v/%v", s.Target.Hex(), s.Source.Hex(), uint64(s.ReserveSequence))
+}
+
+func (s *PaymentSession) hasWarning(warning string) bool {
+	for _, w := range s.Warnings {
+		if w == warning {
+			return true
+		}
+	}
+	return false
+}
+
+// check evaluates the session against the reserve of the source account at the given height.
+func (s *PaymentSession) check(sourceAccount *types.Account, height uint64, expiryWarningBlocks uint64) []string {
+	if sourceAccount == nil {
+		return []string{PaymentSessionReserveNotFound}
+	}
+
+	var reservedFund *types.ReservedFund
+	for idx := range sourceAccount.ReservedFunds {
+		if sourceAccount.ReservedFunds[idx].ReserveSequence == uint64(s.ReserveSequence) {
+			reservedFund = &sourceAccount.ReservedFunds[idx]
+			break
+		}
+	}
+	if reservedFund == nil || reservedFund.EndBlockHeight < height {
+		return []string{PaymentSessionReserveNotFound}
+	}
+
+	warnings := []string{}
+	competing := false
+	for _, record := range reservedFund.TransferRecords {
+		payment := record.ServicePayment
+		if payment.Target.Address != s.Target {
+			competing = true
+		} else if payment.PaymentSequence >= uint64(s.PaymentSequence) {
+			return []string{PaymentSessionSettled}
+		}
+	}
+	if competing {
+		warnings = append(warnings, PaymentSessionCompetingSettlement)
+	}
+
+	remainingFund := reservedFund.InitialFund.NoNil().Minus(reservedFund.UsedFund.NoNil())
+	if !remainingFund.IsGTE(s.Amount.NoNil()) {
+		warnings = append(warnings, PaymentSessionInsufficientReserve)
+	}
+
+	if reservedFund.EndBlockHeight-height <= expiryWarningBlocks {
+		warnings = append(warnings, PaymentSessionReserveExpiring)
+	}
+
+	return warnings
+}
+
+// PaymentSessionManager keeps track of the payment sessions registered by the targets.
+type PaymentSessionManager struct {
+	mu       *sync.Mutex
+	sessions map[string]*PaymentSession
+}
+
+// NewPaymentSessionManager creates a new instance of PaymentSessionManager
+func NewPaymentSessionManager() *PaymentSessionManager {
+	return &PaymentSessionManager{
+		mu:       &sync.Mutex{},
+		sessions: make(map[string]*PaymentSession),
+	}
+}
+
+// Register adds the session, or updates it to a later payment state.
+func (m *PaymentSessionManager) Register(session *PaymentSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := session.key()
+	existing, ok := m.sessions[key]
+	if ok && existing.PaymentSequence > session.PaymentSequence {
+		return fmt.Errorf("Payment sequence %v is older than the registered payment sequence %v",
+			session.PaymentSequence, existing.PaymentSequence)
+	}
+	if !ok && len(m.sessions) >= maxPaymentSessions {
+		return errors.New("Too many payment sessions registered")
+	}
+	session.Warnings = []string{}
+	m.sessions[key] = session
+	return nil
+}
+
+// Sessions returns copies of the sessions of the target.
+func (m *PaymentSessionManager) Sessions(target common.Address) []PaymentSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ret := []PaymentSession{}
+	for _, session := range m.sessions {
+		if session.Target == target {
+			ret = append(ret, *session)
+		}
+	}
+	return ret
+}
+
+// Check re-evaluates all sessions against the ledger state, and returns the sessions with
+// newly raised warnings. Sessions that are settled or whose reserve is gone are removed
+// after being reported.
+func (m *PaymentSessionManager) Check(view *state.StoreView, expiryWarningBlocks uint64) []PaymentSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	height := view.Height()
+	accounts := make(map[common.Address]*types.Account)
+	updated := []PaymentSession{}
+	for key, session := range m.sessions {
+		account, ok := accounts[session.Source]
+		if !ok {
+			account = view.GetAccount(session.Source)
+			accounts[session.Source] = account
+		}
+
+		warnings := session.check(account, height, expiryWarningBlocks)
+		raised := false
+		for _, warning := range warnings {
+			if !session.hasWarning(warning) {
+				raised = true
+			}
+		}
+		session.Warnings = warnings
+		if raised {
+			updated = append(updated, *session)
+		}
+		if session.hasWarning(PaymentSessionSettled) || session.hasWarning(PaymentSessionReserveNotFound) {
+			delete(m.sessions, key)
+		}
+	}
+	return updated
+}
+
+var paymentSessionManager = NewPaymentSessionManager()
+
+func (t *ThetaRPCService) checkPaymentSessions() {
+	view, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Warn("Failed to get the finalized snapshot to check payment sessions")
+		return
+	}
+	expiryWarningBlocks := uint64(viper.GetInt(common.CfgRPCPaymentSessionExpiryWarningBlocks))
+	for _, session := range paymentSessionManager.Check(view, expiryWarningBlocks) {
+		logger.WithFields(log.Fields{
+			"source":          session.Source.Hex(),
+			"target":          session.Target.Hex(),
+			"reserveSequence": session.ReserveSequence,
+			"paymentSequence": session.PaymentSequence,
+			"warnings":        session.Warnings,
+		}).Warn("Payment session needs attention")
+	}
+}
+
+// ------------------------------- RegisterPaymentSession -----------------------------------
+
+type RegisterPaymentSessionArgs struct {
+	Source          string            `json:"source"`
+	Target          string            `json:"target"`
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+	PaymentSequence common.JSONUint64 `json:"payment_sequence"`
+	Amount          types.Coins       `json:"amount"`
+}
+
+type RegisterPaymentSessionResult struct {
+	Session PaymentSession `json:"session"`
+}
+
+func (t *ThetaRPCService) RegisterPaymentSession(args *RegisterPaymentSessionArgs, result *RegisterPaymentSessionResult) (err error) {
+	if args.Source == "" || args.Target == "" {
+		return errors.New("Source and target must be specified")
+	}
+	session := &PaymentSession{
+		Source:          common.HexToAddress(args.Source),
+		Target:          common.HexToAddress(args.Target),
+		ReserveSequence: args.ReserveSequence,
+		PaymentSequence: args.PaymentSequence,
+		Amount:          args.Amount.NoNil(),
+	}
+	if err := paymentSessionManager.Register(session); err != nil {
+		return err
+	}
+
+	view, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	expiryWarningBlocks := uint64(viper.GetInt(common.CfgRPCPaymentSessionExpiryWarningBlocks))
+	result.Session = *session
+	result.Session.Warnings = session.check(view.GetAccount(session.Source), view.Height(), expiryWarningBlocks)
+	return nil
+}
+
+// ------------------------------- GetPaymentSessions -----------------------------------
+
+type GetPaymentSessionsArgs struct {
+	Target string `json:"target"`
+}
+
+type GetPaymentSessionsResult struct {
+	Sessions []PaymentSession `json:"sessions"`
+}
+
+func (t *ThetaRPCService) GetPaymentSessions(args *GetPaymentSessionsArgs, result *GetPaymentSessionsResult) (err error) {
+	if args.Target == "" {
+		return errors.New("Target must be specified")
+	}
+	result.Sessions = paymentSessionManager.Sessions(common.HexToAddress(args.Target))
+	return nil
+}
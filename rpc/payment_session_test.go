@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func newTestPaymentSessionAccount(source common.Address, endBlockHeight uint64) *types.Account {
+	return &types.Account{
+		Address: source,
+		Balance: types.NewCoins(0, 0),
+		ReservedFunds: []types.ReservedFund{
+			types.ReservedFund{
+				Collateral:      types.NewCoins(0, 1001),
+				InitialFund:     types.NewCoins(0, 1000),
+				UsedFund:        types.NewCoins(0, 0),
+				ResourceIDs:     []string{"rid001"},
+				EndBlockHeight:  endBlockHeight,
+				ReserveSequence: 1,
+			},
+		},
+	}
+}
+
+func recordTestPayment(account *types.Account, target common.Address, paymentSequence uint64, amount int64) {
+	reservedFund := &account.ReservedFunds[0]
+	reservedFund.UsedFund = reservedFund.UsedFund.Plus(types.NewCoins(0, amount))
+	reservedFund.TransferRecords = append(reservedFund.TransferRecords, types.TransferRecord{
+		ServicePayment: types.ServicePaymentTx{
+			Source:          types.TxInput{Address: account.Address, Coins: types.NewCoins(0, amount)},
+			Target:          types.TxInput{Address: target},
+			PaymentSequence: paymentSequence,
+			ReserveSequence: 1,
+		},
+	})
+}
+
+func TestPaymentSessionCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	source := common.HexToAddress("a1")
+	target := common.HexToAddress("b1")
+	other := common.HexToAddress("c1")
+	session := &PaymentSession{
+		Source:          source,
+		Target:          target,
+		ReserveSequence: 1,
+		PaymentSequence: 3,
+		Amount:          types.NewCoins(0, 600),
+	}
+
+	assert.Equal([]string{PaymentSessionReserveNotFound}, session.check(nil, 10, 100))
+
+	account := newTestPaymentSessionAccount(source, 1000)
+	assert.Equal([]string{}, session.check(account, 10, 100))
+	assert.Equal([]string{PaymentSessionReserveExpiring}, session.check(account, 950, 100))
+	assert.Equal([]string{PaymentSessionReserveNotFound}, session.check(account, 1001, 100))
+
+	recordTestPayment(account, other, 1, 500)
+	assert.Equal([]string{PaymentSessionCompetingSettlement, PaymentSessionInsufficientReserve},
+		session.check(account, 10, 100))
+
+	recordTestPayment(account, target, 3, 400)
+	assert.Equal([]string{PaymentSessionSettled}, session.check(account, 10, 100))
+}
+
+func TestPaymentSessionManager(t *testing.T) {
+	assert := assert.New(t)
+
+	source := common.HexToAddress("a1")
+	target := common.HexToAddress("b1")
+	other := common.HexToAddress("c1")
+
+	m := NewPaymentSessionManager()
+	assert.Nil(m.Register(&PaymentSession{Source: source, Target: target, ReserveSequence: 1, PaymentSequence: 2, Amount: types.NewCoins(0, 300)}))
+	assert.Nil(m.Register(&PaymentSession{Source: source, Target: target, ReserveSequence: 1, PaymentSequence: 3, Amount: types.NewCoins(0, 600)}))
+	assert.NotNil(m.Register(&PaymentSession{Source: source, Target: target, ReserveSequence: 1, PaymentSequence: 1}))
+	assert.Nil(m.Register(&PaymentSession{Source: source, Target: other, ReserveSequence: 2, PaymentSequence: 1}))
+
+	sessions := m.Sessions(target)
+	assert.Equal(1, len(sessions))
+	assert.Equal(common.JSONUint64(3), sessions[0].PaymentSequence)
+
+	view := state.NewStoreView(10, common.Hash{}, backend.NewMemDatabase())
+	account := newTestPaymentSessionAccount(source, 1000)
+	recordTestPayment(account, other, 1, 500)
+	view.SetAccount(source, account)
+
+	// The session of the other target uses a reserve that does not exist
+	updated := m.Check(view, 100)
+	assert.Equal(2, len(updated))
+	assert.Equal(0, len(m.Sessions(other)))
+
+	// Warnings are only reported once
+	updated = m.Check(view, 100)
+	assert.Equal(0, len(updated))
+	sessions = m.Sessions(target)
+	assert.Equal(1, len(sessions))
+	assert.Equal([]string{PaymentSessionCompetingSettlement, PaymentSessionInsufficientReserve}, sessions[0].Warnings)
+}
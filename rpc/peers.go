@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics/prometheus"
+	p2ptypes "github.com/thetatoken/theta/p2p/types"
+)
+
+// ------------------------------- GetPeers -----------------------------------
+
+type GetPeersArgs struct {
+}
+
+type GetPeersResult struct {
+	Peers []PeerInfo `json:"peers"`
+}
+
+type PeerInfo struct {
+	ID         string             `json:"id"`
+	NetAddress string             `json:"net_address"`
+	IsOutbound bool               `json:"is_outbound"`
+	Version    string             `json:"version"`
+	GitHash    string             `json:"git_hash"`
+	Channels   []PeerChannelStats `json:"channels"`
+}
+
+type PeerChannelStats struct {
+	Channel            string            `json:"channel"`
+	MsgsReceived       common.JSONUint64 `json:"msgs_received"`
+	BytesReceived      common.JSONUint64 `json:"bytes_received"`
+	MsgsSent           common.JSONUint64 `json:"msgs_sent"`
+	BytesSent          common.JSONUint64 `json:"bytes_sent"`
+	DecodeFailures     common.JSONUint64 `json:"decode_failures"`
+	ReplaysRejected    common.JSONUint64 `json:"replays_rejected"`
+	AvgHandlingLatency common.JSONUint64 `json:"avg_handling_latency_us"`
+}
+
+func (t *ThetaRPCService) GetPeers(args *GetPeersArgs, result *GetPeersResult) (err error) {
+	result.Peers = []PeerInfo{}
+	for _, peerStats := range t.dispatcher.PeerStats() {
+		peer := PeerInfo{
+			ID:         peerStats.PeerID,
+			NetAddress: peerStats.NetAddress,
+			IsOutbound: peerStats.IsOutbound,
+			Version:    peerStats.Version,
+			GitHash:    peerStats.GitHash,
+			Channels:   []PeerChannelStats{},
+		}
+		for _, cs := range peerStats.Channels {
+			peer.Channels = append(peer.Channels, PeerChannelStats{
+				Channel:            p2ptypes.ChannelName(cs.ChannelID),
+				MsgsReceived:       common.JSONUint64(cs.MsgsReceived),
+				BytesReceived:      common.JSONUint64(cs.BytesReceived),
+				MsgsSent:           common.JSONUint64(cs.MsgsSent),
+				BytesSent:          common.JSONUint64(cs.BytesSent),
+				DecodeFailures:     common.JSONUint64(cs.DecodeFailures),
+				ReplaysRejected:    common.JSONUint64(cs.ReplaysRejected),
+				AvgHandlingLatency: common.JSONUint64(cs.AvgHandlingMicros),
+			})
+		}
+		result.Peers = append(result.Peers, peer)
+	}
+	return nil
+}
+
+// peerStatsFamilies exports the per-peer, per-channel traffic stats to Prometheus
+func (t *ThetaRPCService) peerStatsFamilies() []prometheus.Family {
+	return buildPeerStatsFamilies(t.dispatcher.PeerStats())
+}
+
+func buildPeerStatsFamilies(allStats []p2ptypes.PeerStats) []prometheus.Family {
+	families := []prometheus.Family{
+		{Name: "p2p_peer_channel_received_messages_total", Help: "Messages received from the peer on the channel.", Type: "counter"},
+		{Name: "p2p_peer_channel_received_bytes_total", Help: "Bytes received from the peer on the channel.", Type: "counter"},
+		{Name: "p2p_peer_channel_sent_messages_total", Help: "Messages sent to the peer on the channel.", Type: "counter"},
+		{Name: "p2p_peer_channel_sent_bytes_total", Help: "Bytes sent to the peer on the channel.", Type: "counter"},
+		{Name: "p2p_peer_channel_decode_failures_total", Help: "Messages from the peer on the channel that failed to decode.", Type: "counter"},
+		{Name: "p2p_peer_channel_replays_rejected_total", Help: "Stale or replayed messages from the peer on the channel.", Type: "counter"},
+		{Name: "p2p_peer_channel_avg_handling_latency_microseconds", Help: "Average time spent handling a message from the peer on the channel.", Type: "gauge"},
+	}
+	for _, peerStats := range allStats {
+		for _, cs := range peerStats.Channels {
+			labels := map[string]string{
+				"peer":    peerStats.PeerID,
+				"channel": p2ptypes.ChannelName(cs.ChannelID),
+			}
+			values := []uint64{cs.MsgsReceived, cs.BytesReceived, cs.MsgsSent, cs.BytesSent, cs.DecodeFailures, cs.ReplaysRejected, cs.AvgHandlingMicros}
+			for i, value := range values {
+				families[i].Samples = append(families[i].Samples, prometheus.Sample{Labels: labels, Value: float64(value)})
+			}
+		}
+	}
+	return families
+}
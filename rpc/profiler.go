@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/thetatoken/theta/common/profiler"
+)
+
+var errProfilerDisabled = errors.New("Profiler is not enabled")
+
+// ------------------------------ GetProfileCaptures -----------------------------------
+
+type GetProfileCapturesArgs struct{}
+
+type GetProfileCapturesResult struct {
+	Captures []profiler.Capture `json:"captures"`
+}
+
+func (t *ThetaRPCService) GetProfileCaptures(args *GetProfileCapturesArgs, result *GetProfileCapturesResult) (err error) {
+	if t.profiler == nil {
+		return errProfilerDisabled
+	}
+	result.Captures = t.profiler.Captures()
+	return nil
+}
+
+// ------------------------------ CaptureProfile -----------------------------------
+
+type CaptureProfileArgs struct{}
+
+type CaptureProfileResult struct {
+	Capture *profiler.Capture `json:"capture"`
+}
+
+func (t *ThetaRPCService) CaptureProfile(args *CaptureProfileArgs, result *CaptureProfileResult) (err error) {
+	if t.profiler == nil {
+		return errProfilerDisabled
+	}
+	result.Capture, err = t.profiler.Capture(profiler.ReasonManual)
+	return err
+}
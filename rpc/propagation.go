@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/netsync"
+)
+
+// DefaultBlockPropagationCount is the number of recent blocks GetBlockPropagation reports by default
+const DefaultBlockPropagationCount = 20
+
+// SetPropagationTracker sets the tracker reported by GetBlockPropagation, which fails if nil.
+func (t *ThetaRPCServer) SetPropagationTracker(tracker *netsync.PropagationTracker) {
+	t.propagation = tracker
+}
+
+// ------------------------------ GetBlockPropagation -----------------------------------
+
+type GetBlockPropagationArgs struct {
+	Hash  common.Hash       `json:"hash"`  // the most recently seen blocks if empty
+	Count common.JSONUint64 `json:"count"` // DefaultBlockPropagationCount if 0
+}
+
+type VotePropagation struct {
+	Voter     common.Address    `json:"voter"`
+	Epoch     common.JSONUint64 `json:"epoch"`
+	Source    string            `json:"source"`     // ID of the peer the vote was first received from
+	FirstSeen common.JSONUint64 `json:"first_seen"` // Unix time in milliseconds
+	Receipts  common.JSONUint64 `json:"receipts"`
+}
+
+type BlockPropagation struct {
+	Hash                  common.Hash       `json:"hash"`
+	Height                common.JSONUint64 `json:"height"`
+	Source                string            `json:"source"`       // ID of the peer the block was first received from
+	FirstSeen             common.JSONUint64 `json:"first_seen"`   // Unix time in milliseconds, 0 if only the votes were received
+	FinalizedAt           common.JSONUint64 `json:"finalized_at"` // Unix time in milliseconds, 0 if not finalized yet
+	FinalizationLatencyMs common.JSONUint64 `json:"finalization_latency_ms"`
+	Receipts              common.JSONUint64 `json:"receipts"`
+	Duplicates            common.JSONUint64 `json:"duplicates"`
+	Votes                 []VotePropagation `json:"votes"`
+}
+
+// GetBlockPropagationResult reports when and from which peers the node first received the blocks
+// and the votes on them, how many times they were received, and how long the blocks took to be
+// finalized once received. Only the most recently seen blocks are tracked.
+type GetBlockPropagationResult struct {
+	Blocks []BlockPropagation `json:"blocks"`
+}
+
+func (t *ThetaRPCService) GetBlockPropagation(args *GetBlockPropagationArgs, result *GetBlockPropagationResult) (err error) {
+	if t.propagation == nil {
+		return errors.New("Block propagation is not tracked")
+	}
+
+	result.Blocks = []BlockPropagation{}
+	if !args.Hash.IsEmpty() {
+		p, ok := t.propagation.GetBlockPropagation(args.Hash)
+		if !ok {
+			return fmt.Errorf("Propagation of block %v is not tracked", args.Hash.Hex())
+		}
+		result.Blocks = append(result.Blocks, newBlockPropagation(p))
+		return nil
+	}
+
+	count := int(args.Count)
+	if count == 0 {
+		count = DefaultBlockPropagationCount
+	}
+	for _, p := range t.propagation.GetRecentBlockPropagations(count) {
+		result.Blocks = append(result.Blocks, newBlockPropagation(p))
+	}
+	return nil
+}
+
+func newBlockPropagation(p *netsync.BlockPropagation) BlockPropagation {
+	bp := BlockPropagation{
+		Hash:                  p.Hash,
+		Height:                common.JSONUint64(p.Height),
+		Source:                p.Source,
+		FirstSeen:             unixMillis(p.FirstSeen),
+		FinalizedAt:           unixMillis(p.FinalizedAt),
+		FinalizationLatencyMs: common.JSONUint64(p.FinalizationLatency() / time.Millisecond),
+		Receipts:              common.JSONUint64(p.Receipts),
+		Duplicates:            common.JSONUint64(p.Duplicates()),
+		Votes:                 []VotePropagation{},
+	}
+	for _, vp := range p.Votes {
+		bp.Votes = append(bp.Votes, VotePropagation{
+			Voter:     vp.Voter,
+			Epoch:     common.JSONUint64(vp.Epoch),
+			Source:    vp.Source,
+			FirstSeen: unixMillis(vp.FirstSeen),
+			Receipts:  common.JSONUint64(vp.Receipts),
+		})
+	}
+	return bp
+}
+
+func unixMillis(t time.Time) common.JSONUint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return common.JSONUint64(t.UnixNano() / int64(time.Millisecond))
+}
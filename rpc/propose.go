@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+)
+
+// ------------------------------ DryRunProposal -----------------------------------
+
+type DryRunProposalArgs struct{}
+
+type RejectedTx struct {
+	Hash   common.Hash      `json:"hash"`
+	Code   result.ErrorCode `json:"code"`
+	Reason string           `json:"reason"`
+}
+
+type DryRunProposalResult struct {
+	ParentHash  common.Hash       `json:"parent_hash"`
+	Height      common.JSONUint64 `json:"height"`
+	Epoch       common.JSONUint64 `json:"epoch"`
+	Proposer    common.Address    `json:"proposer"`
+	StateHash   common.Hash       `json:"state_hash"`
+	NumTxs      common.JSONUint64 `json:"num_txs"`
+	TxHashes    []common.Hash     `json:"tx_hashes"`
+	GasUsed     common.JSONUint64 `json:"gas_used"`
+	Fees        types.Coins       `json:"fees"`
+	MempoolSize common.JSONUint64 `json:"mempool_size"`
+	RejectedTxs []RejectedTx      `json:"rejected_txs"`
+	NumRejected common.JSONUint64 `json:"num_rejected"`
+	IsReadOnly  bool              `json:"is_read_only"`
+}
+
+// DryRunProposal assembles the block the node would propose on top of its current tip from the
+// mempool, and executes it speculatively. The block is neither added to the chain nor broadcast,
+// and the mempool is left untouched. It reports the transactions that would be rejected along
+// with the reasons, to help diagnose why transactions are not included. As it executes a whole
+// block, it is disabled unless an admin tenant is configured.
+func (t *ThetaRPCService) DryRunProposal(args *DryRunProposalArgs, res *DryRunProposalResult) (err error) {
+	if !t.tenants.hasAdminTenant() {
+		return jsonrpc2.NewError(errCodeUnauthorized, "DryRunProposal is disabled unless an admin tenant is configured")
+	}
+
+	tip := t.consensus.GetTip(false)
+
+	block := core.NewBlock()
+	block.ChainID = t.chain.ChainID
+	block.Epoch = t.consensus.GetEpoch()
+	block.Parent = tip.Hash()
+	block.Height = tip.Height + 1
+	block.Version = core.BlockHeaderVersion(block.ChainID, block.Height)
+	block.Timestamp = big.NewInt(time.Now().Unix())
+	block.HCC.BlockHash = tip.Hash()
+	if privateKey := t.consensus.PrivateKey(); privateKey != nil {
+		block.Proposer = privateKey.PublicKey().Address()
+	}
+
+	res.MempoolSize = common.JSONUint64(t.mempool.Size())
+
+	dryRun, r := t.ledger.DryRunProposal(block)
+	if r.IsError() {
+		r.Message = "Failed to dry run the proposal: " + r.Message
+		return newRPCError(r)
+	}
+
+	res.ParentHash = block.Parent
+	res.Height = common.JSONUint64(block.Height)
+	res.Epoch = common.JSONUint64(block.Epoch)
+	res.Proposer = block.Proposer
+	res.StateHash = dryRun.StateHash
+	res.NumTxs = common.JSONUint64(len(dryRun.Txs))
+	res.TxHashes = []common.Hash{}
+	for _, tx := range dryRun.Txs {
+		res.TxHashes = append(res.TxHashes, crypto.Keccak256Hash(tx))
+	}
+	res.GasUsed = common.JSONUint64(dryRun.GasUsed)
+	res.Fees = dryRun.Fees
+	res.RejectedTxs = []RejectedTx{}
+	for _, rejected := range dryRun.RejectedTxs {
+		res.RejectedTxs = append(res.RejectedTxs, RejectedTx{
+			Hash:   rejected.Hash,
+			Code:   rejected.Code,
+			Reason: rejected.Reason,
+		})
+	}
+	res.NumRejected = common.JSONUint64(len(res.RejectedTxs))
+	res.IsReadOnly = block.Proposer == (common.Address{})
+
+	return nil
+}
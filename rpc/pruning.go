@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger"
+)
+
+// SetStatePruner sets the state pruner reported by GetStatePruningStatus, which fails if nil.
+func (t *ThetaRPCServer) SetStatePruner(pruner *ledger.StatePruner) {
+	t.pruner = pruner
+}
+
+// ------------------------------ GetStatePruningStatus -----------------------------------
+
+type GetStatePruningStatusArgs struct{}
+
+// GetStatePruningStatusResult reports the progress of the state pruning. The states up to
+// PrunedHeight have been pruned, except the states of the stake transaction heights and of the
+// blocks with validator updates, and the states after TargetHeight are retained.
+type GetStatePruningStatusResult struct {
+	RetainedBlocks        common.JSONUint64 `json:"retained_blocks"`
+	BatchSize             common.JSONUint64 `json:"batch_size"`
+	LatestFinalizedHeight common.JSONUint64 `json:"latest_finalized_height"`
+	TargetHeight          common.JSONUint64 `json:"target_height"`
+	PrunedHeight          common.JSONUint64 `json:"pruned_height"`
+	RemainingHeights      common.JSONUint64 `json:"remaining_heights"`
+	PrunedStates          common.JSONUint64 `json:"pruned_states"` // since the node started
+	LastBatchTime         *common.JSONBig   `json:"last_batch_time"`
+	LastBatchDurationMs   common.JSONUint64 `json:"last_batch_duration_ms"`
+	LastError             string            `json:"last_error"`
+}
+
+func (t *ThetaRPCService) GetStatePruningStatus(args *GetStatePruningStatusArgs, result *GetStatePruningStatusResult) (err error) {
+	if t.pruner == nil {
+		return errors.New("State pruning is not enabled")
+	}
+
+	status := t.pruner.Status()
+	result.RetainedBlocks = common.JSONUint64(status.RetainedBlocks)
+	result.BatchSize = common.JSONUint64(status.BatchSize)
+	result.LatestFinalizedHeight = common.JSONUint64(status.LastFinalizedHeight)
+	result.TargetHeight = common.JSONUint64(status.TargetHeight)
+	result.PrunedHeight = common.JSONUint64(status.PrunedHeight)
+	if status.TargetHeight > status.PrunedHeight {
+		result.RemainingHeights = common.JSONUint64(status.TargetHeight - status.PrunedHeight)
+	}
+	result.PrunedStates = common.JSONUint64(status.PrunedStates)
+	if !status.LastBatchTime.IsZero() {
+		result.LastBatchTime = (*common.JSONBig)(big.NewInt(status.LastBatchTime.Unix()))
+	}
+	result.LastBatchDurationMs = common.JSONUint64(status.LastBatchDuration / time.Millisecond)
+	result.LastError = status.LastError
+
+	return nil
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
 	"github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
 	"github.com/thetatoken/theta/version"
 )
 
@@ -21,15 +23,11 @@ type GetVersionArgs struct {
 }
 
 type GetVersionResult struct {
-	Version   string `json:"version"`
-	GitHash   string `json:"git_hash"`
-	Timestamp string `json:"timestamp"`
+	version.Info
 }
 
 func (t *ThetaRPCService) GetVersion(args *GetVersionArgs, result *GetVersionResult) (err error) {
-	result.Version = version.Version
-	result.GitHash = version.GitHash
-	result.Timestamp = version.Timestamp
+	result.Info = version.GetInfo()
 	return nil
 }
 
@@ -39,6 +37,8 @@ type GetAccountArgs struct {
 	Name    string `json:"name"`
 	Address string `json:"address"`
 	Preview bool   `json:"preview"` // preview the account balance from the ScreenedView
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 type GetAccountResult struct {
@@ -55,6 +55,9 @@ func (t *ThetaRPCService) GetAccount(args *GetAccountArgs, result *GetAccountRes
 
 	var ledgerState *state.StoreView
 	if args.Preview {
+		if t.isFinalizedOnly(args.FinalizedOnly) {
+			return errors.New("Preview is not available in the finalized-only mode")
+		}
 		ledgerState, err = t.ledger.GetScreenedSnapshot()
 	} else {
 		ledgerState, err = t.ledger.GetFinalizedSnapshot()
@@ -73,10 +76,81 @@ func (t *ThetaRPCService) GetAccount(args *GetAccountArgs, result *GetAccountRes
 	return nil
 }
 
+// ------------------------------- GetReservedFund -----------------------------------
+
+type GetReservedFundArgs struct {
+	Address         string            `json:"address"`
+	ReserveSequence common.JSONUint64 `json:"reserve_sequence"`
+}
+
+type GetReservedFundResult struct {
+	*types.ReservedFund
+	Active bool              `json:"active"` // false if the reserved fund has been removed from the account
+	Height common.JSONUint64 `json:"height"` // the height of the state the reserved fund is read from
+}
+
+// GetReservedFund returns the reserved fund with the given reserve sequence. Reserved funds are removed
+// from the account once released, expired or fully settled. The removed ones are read from the state
+// of the last height holding them, which is only available on archive nodes (state pruning disabled).
+func (t *ThetaRPCService) GetReservedFund(args *GetReservedFundArgs, result *GetReservedFundResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+	address := common.HexToAddress(args.Address)
+	reserveSequence := uint64(args.ReserveSequence)
+
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+
+	if account := ledgerState.GetAccount(address); account != nil {
+		for idx := range account.ReservedFunds {
+			if account.ReservedFunds[idx].ReserveSequence == reserveSequence {
+				result.ReservedFund = &account.ReservedFunds[idx]
+				result.Active = true
+				result.Height = common.JSONUint64(ledgerState.Height())
+				return nil
+			}
+		}
+	}
+
+	height, ok := ledgerState.GetReservedFundLastHeight(address, reserveSequence)
+	if !ok {
+		return fmt.Errorf("Reserved fund %v of account %v is not found", reserveSequence, address.Hex())
+	}
+	for _, block := range t.chain.FindBlocksByHeight(height) {
+		if !block.Status.IsFinalized() {
+			continue
+		}
+		blockStoreView := state.NewStoreView(height, block.StateHash, ledgerState.GetDB())
+		if blockStoreView == nil { // might have been pruned
+			return fmt.Errorf("Reserved fund %v of account %v was removed at height %v, the state has been pruned, "+
+				"please query an archive node", reserveSequence, address.Hex(), height+1)
+		}
+		account := blockStoreView.GetAccount(address)
+		if account == nil {
+			break
+		}
+		for idx := range account.ReservedFunds {
+			if account.ReservedFunds[idx].ReserveSequence == reserveSequence {
+				result.ReservedFund = &account.ReservedFunds[idx]
+				result.Active = false
+				result.Height = common.JSONUint64(height)
+				return nil
+			}
+		}
+		break
+	}
+	return fmt.Errorf("Reserved fund %v of account %v is not found at height %v", reserveSequence, address.Hex(), height)
+}
+
 // ------------------------------- GetSplitRule -----------------------------------
 
 type GetSplitRuleArgs struct {
 	ResourceID string `json:"resource_id"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 type GetSplitRuleResult struct {
@@ -88,7 +162,12 @@ func (t *ThetaRPCService) GetSplitRule(args *GetSplitRuleArgs, result *GetSplitR
 		return errors.New("ResourceID must be specified")
 	}
 	resourceID := args.ResourceID
-	ledgerState, err := t.ledger.GetDeliveredSnapshot()
+	var ledgerState *state.StoreView
+	if t.isFinalizedOnly(args.FinalizedOnly) {
+		ledgerState, err = t.ledger.GetFinalizedSnapshot()
+	} else {
+		ledgerState, err = t.ledger.GetDeliveredSnapshot()
+	}
 	if err != nil {
 		return err
 	}
@@ -100,6 +179,8 @@ func (t *ThetaRPCService) GetSplitRule(args *GetSplitRuleArgs, result *GetSplitR
 
 type GetTransactionArgs struct {
 	Hash string `json:"hash"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 type GetTransactionResult struct {
@@ -118,6 +199,8 @@ const (
 	TxStatusPending   = "pending"
 	TxStatusFinalized = "finalized"
 	TxStatusAbandoned = "abandoned"
+	TxStatusExpired   = "expired"
+	TxStatusReplaced  = "replaced"
 )
 
 func (t *ThetaRPCService) GetTransaction(args *GetTransactionArgs, result *GetTransactionResult) (err error) {
@@ -133,6 +216,10 @@ func (t *ThetaRPCService) GetTransaction(args *GetTransactionArgs, result *GetTr
 		if exists {
 			if txStatus == mempool.TxStatusAbandoned {
 				result.Status = TxStatusAbandoned
+			} else if txStatus == mempool.TxStatusExpired {
+				result.Status = TxStatusExpired
+			} else if txStatus == mempool.TxStatusReplaced {
+				result.Status = TxStatusReplaced
 			} else {
 				result.Status = TxStatusPending
 			}
@@ -141,14 +228,18 @@ func (t *ThetaRPCService) GetTransaction(args *GetTransactionArgs, result *GetTr
 		}
 		return nil
 	}
-	result.BlockHash = block.Hash()
-	result.BlockHeight = common.JSONUint64(block.Height)
 
 	if block.Status.IsFinalized() {
 		result.Status = TxStatusFinalized
 	} else {
 		result.Status = TxStatusPending
 	}
+	// In the finalized-only mode, the blocks not finalized yet are not disclosed, since they might
+	// still be abandoned
+	if result.Status == TxStatusFinalized || !t.isFinalizedOnly(args.FinalizedOnly) {
+		result.BlockHash = block.Hash()
+		result.BlockHeight = common.JSONUint64(block.Height)
+	}
 
 	tx, err := types.TxFromBytes(raw)
 	if err != nil {
@@ -160,6 +251,333 @@ func (t *ThetaRPCService) GetTransaction(args *GetTransactionArgs, result *GetTr
 	return nil
 }
 
+// ------------------------------ GetTransactionProof -----------------------------------
+
+type GetTransactionProofArgs struct {
+	Hash string `json:"hash"`
+}
+
+// GetTransactionProofResult has everything a light client needs to verify a finalized transaction
+// without the state: the hash of the raw block header is the block hash, the proof links the
+// transaction to the TxHash of the header, and the certificate holds the votes of the validators
+// committing the block.
+type GetTransactionProofResult struct {
+	TxHash         common.Hash             `json:"hash"`
+	Tx             common.Bytes            `json:"transaction"`
+	BlockHash      common.Hash             `json:"block_hash"`
+	BlockHeight    common.JSONUint64       `json:"block_height"`
+	RawBlockHeader common.Bytes            `json:"raw_block_header"`
+	Proof          *core.TxProof           `json:"proof"`
+	Certificate    *core.CommitCertificate `json:"finalization_certificate"`
+}
+
+func (t *ThetaRPCService) GetTransactionProof(args *GetTransactionProofArgs, result *GetTransactionProofResult) (err error) {
+	if args.Hash == "" {
+		return errors.New("Transanction hash must be specified")
+	}
+	hash := common.HexToHash(args.Hash)
+
+	raw, block, found := t.chain.FindTxByHash(hash)
+	if !found {
+		return fmt.Errorf("Transaction %v is not found", hash.Hex())
+	}
+	if !block.Status.IsFinalized() {
+		return fmt.Errorf("Transaction %v is not finalized yet", hash.Hex())
+	}
+
+	index := -1
+	for i, tx := range block.Txs {
+		if crypto.Keccak256Hash(tx) == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("Transaction %v is not found in block %v", hash.Hex(), block.Hash().Hex())
+	}
+	proof, err := block.ProveTx(index)
+	if err != nil {
+		return err
+	}
+	rawHeader, err := rlp.EncodeToBytes(block.BlockHeader)
+	if err != nil {
+		return err
+	}
+
+	result.TxHash = hash
+	result.Tx = raw
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.RawBlockHeader = rawHeader
+	result.Proof = proof
+	result.Certificate, err = t.finality.GetFinalizationCertificate(block.Hash())
+	return err
+}
+
+// ------------------------------ GetTransactionReceipt -----------------------------------
+
+type GetTransactionReceiptArgs struct {
+	Hash string `json:"hash"`
+}
+
+// GetTransactionReceiptResult holds the receipt of a finalized transaction, with the proof linking
+// it to the ReceiptHash of the raw block header, and the certificate committing the block.
+type GetTransactionReceiptResult struct {
+	TxHash         common.Hash             `json:"hash"`
+	Receipt        *core.TxReceipt         `json:"receipt"`
+	BlockHash      common.Hash             `json:"block_hash"`
+	BlockHeight    common.JSONUint64       `json:"block_height"`
+	RawBlockHeader common.Bytes            `json:"raw_block_header"`
+	Proof          *core.TxProof           `json:"proof"`
+	Certificate    *core.CommitCertificate `json:"finalization_certificate"`
+}
+
+func (t *ThetaRPCService) GetTransactionReceipt(args *GetTransactionReceiptArgs, result *GetTransactionReceiptResult) (err error) {
+	if args.Hash == "" {
+		return errors.New("Transanction hash must be specified")
+	}
+	hash := common.HexToHash(args.Hash)
+
+	receipt, block, receipts, index, found := t.chain.FindTxReceiptByHash(hash)
+	if !found {
+		return fmt.Errorf("Receipt of transaction %v is not found", hash.Hex())
+	}
+	if !block.Status.IsFinalized() {
+		return fmt.Errorf("Transaction %v is not finalized yet", hash.Hex())
+	}
+	proof, err := core.ProveReceipt(receipts, index)
+	if err != nil {
+		return err
+	}
+	rawHeader, err := rlp.EncodeToBytes(block.BlockHeader)
+	if err != nil {
+		return err
+	}
+
+	result.TxHash = hash
+	result.Receipt = receipt
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.RawBlockHeader = rawHeader
+	result.Proof = proof
+	result.Certificate, err = t.finality.GetFinalizationCertificate(block.Hash())
+	return err
+}
+
+// ------------------------------ GetAccountProof -----------------------------------
+
+type GetAccountProofArgs struct {
+	Address string            `json:"address"`
+	Height  common.JSONUint64 `json:"height"` // height of the finalized block, the last finalized block if 0
+}
+
+// GetAccountProofResult has everything a light client needs to verify an account without the state:
+// the proof links the account, or its absence, to the StateHash of the raw block header, and the
+// certificate holds the votes of the validators committing the block. Account is nil if the account
+// does not exist.
+type GetAccountProofResult struct {
+	Address        common.Address          `json:"address"`
+	Account        *types.Account          `json:"account"`
+	BlockHash      common.Hash             `json:"block_hash"`
+	BlockHeight    common.JSONUint64       `json:"block_height"`
+	StateRoot      common.Hash             `json:"state_root"`
+	RawBlockHeader common.Bytes            `json:"raw_block_header"`
+	Proof          *types.AccountProof     `json:"proof"`
+	Certificate    *core.CommitCertificate `json:"finalization_certificate"`
+}
+
+func (t *ThetaRPCService) GetAccountProof(args *GetAccountProofArgs, result *GetAccountProofResult) (err error) {
+	if args.Address == "" {
+		return errors.New("Address must be specified")
+	}
+	address := common.HexToAddress(args.Address)
+
+	block, blockStoreView, err := t.getFinalizedBlockState(args.Height)
+	if err != nil {
+		return err
+	}
+	proof, err := blockStoreView.ProveAccount(address)
+	if err != nil {
+		return err
+	}
+	rawHeader, err := rlp.EncodeToBytes(block.BlockHeader)
+	if err != nil {
+		return err
+	}
+
+	result.Address = address
+	result.Account = blockStoreView.GetAccount(address)
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.StateRoot = block.StateHash
+	result.RawBlockHeader = rawHeader
+	result.Proof = proof
+	result.Certificate, err = t.finality.GetFinalizationCertificate(block.Hash())
+	return err
+}
+
+// ------------------------------ GetBatchAccountProof -----------------------------------
+
+// MaxBatchAccountProofAddresses is the maximum number of accounts proven by a GetBatchAccountProof call
+const MaxBatchAccountProofAddresses = 1000
+
+type GetBatchAccountProofArgs struct {
+	Addresses []string          `json:"addresses"`
+	Height    common.JSONUint64 `json:"height"` // height of the finalized block, the last finalized block if 0
+}
+
+// GetBatchAccountProofResult is GetAccountProofResult for many accounts, proven by a single proof
+// bundle sharing the common nodes. Accounts are in the order of the addresses, nil for the accounts
+// which do not exist.
+type GetBatchAccountProofResult struct {
+	Accounts       []*types.Account         `json:"accounts"`
+	BlockHash      common.Hash              `json:"block_hash"`
+	BlockHeight    common.JSONUint64        `json:"block_height"`
+	StateRoot      common.Hash              `json:"state_root"`
+	RawBlockHeader common.Bytes             `json:"raw_block_header"`
+	Proof          *types.BatchAccountProof `json:"proof"`
+	Certificate    *core.CommitCertificate  `json:"finalization_certificate"`
+}
+
+func (t *ThetaRPCService) GetBatchAccountProof(args *GetBatchAccountProofArgs, result *GetBatchAccountProofResult) (err error) {
+	if len(args.Addresses) == 0 {
+		return errors.New("Addresses must be specified")
+	}
+	if len(args.Addresses) > MaxBatchAccountProofAddresses {
+		return fmt.Errorf("Too many addresses: %v, at most %v can be proven at once", len(args.Addresses), MaxBatchAccountProofAddresses)
+	}
+	addresses := make([]common.Address, len(args.Addresses))
+	for i, address := range args.Addresses {
+		addresses[i] = common.HexToAddress(address)
+	}
+
+	block, blockStoreView, err := t.getFinalizedBlockState(args.Height)
+	if err != nil {
+		return err
+	}
+	proof, err := blockStoreView.ProveAccounts(addresses)
+	if err != nil {
+		return err
+	}
+	rawHeader, err := rlp.EncodeToBytes(block.BlockHeader)
+	if err != nil {
+		return err
+	}
+
+	result.Accounts = make([]*types.Account, len(addresses))
+	for i, address := range addresses {
+		result.Accounts[i] = blockStoreView.GetAccount(address)
+	}
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.StateRoot = block.StateHash
+	result.RawBlockHeader = rawHeader
+	result.Proof = proof
+	result.Certificate, err = t.finality.GetFinalizationCertificate(block.Hash())
+	return err
+}
+
+// getFinalizedBlockState returns the finalized block at the height, the last finalized block if
+// the height is 0, and the view of its state.
+func (t *ThetaRPCService) getFinalizedBlockState(height common.JSONUint64) (*core.ExtendedBlock, *state.StoreView, error) {
+	var block *core.ExtendedBlock
+	if height == 0 {
+		block = t.finality.GetLastFinalizedBlock()
+	} else {
+		for _, b := range t.chain.FindBlocksByHeight(uint64(height)) {
+			if b.Status.IsFinalized() {
+				block = b
+				break
+			}
+		}
+	}
+	if block == nil {
+		return nil, nil, fmt.Errorf("There is no finalized block at height %v", height)
+	}
+
+	blockStoreView, err := t.getBlockState(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, blockStoreView, nil
+}
+
+// getBlockState returns the view of the state of the block, or an errCodeStatePruned error if the
+// state is no longer retained.
+func (t *ThetaRPCService) getBlockState(block *core.ExtendedBlock) (*state.StoreView, error) {
+	finalizedView, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	blockStoreView := state.NewStoreView(block.Height, block.StateHash, finalizedView.GetDB())
+	if blockStoreView == nil { // might have been pruned
+		return nil, jsonrpc2.NewError(errCodeStatePruned,
+			fmt.Sprintf("The state at height %v has been pruned, please query an archive node", block.Height))
+	}
+	return blockStoreView, nil
+}
+
+// ------------------------------ GetFinalityStatus -----------------------------------
+
+type GetFinalityStatusArgs struct {
+	BlockHash string `json:"block_hash"`
+	TxHash    string `json:"tx_hash"` // the block including the transaction, if the block hash is not specified
+}
+
+// GetFinalityStatusResult tells whether a block, or the block including a transaction, is final.
+// Unlike on the chains secured by the confirmation count, a block is irreversible once finalized by
+// the validators, however many blocks are built on top of it, so the integrations should act on
+// SafeToAct rather than on the Depth. Once true, SafeToAct never turns false. Depth is the number
+// of blocks the last finalized block is above the block, and 0 if the block is not finalized.
+type GetFinalityStatusResult struct {
+	BlockHash            common.Hash       `json:"block_hash"`
+	BlockHeight          common.JSONUint64 `json:"block_height"`
+	Status               core.BlockStatus  `json:"status"`
+	Finalized            bool              `json:"finalized"`
+	Depth                common.JSONUint64 `json:"depth"`
+	FinalizedBlockHash   common.Hash       `json:"finalized_block_hash"`
+	FinalizedBlockHeight common.JSONUint64 `json:"finalized_block_height"`
+	SafeToAct            bool              `json:"safe_to_act"`
+}
+
+func (t *ThetaRPCService) GetFinalityStatus(args *GetFinalityStatusArgs, result *GetFinalityStatusResult) (err error) {
+	var block *core.ExtendedBlock
+	if args.BlockHash != "" {
+		hash := common.HexToHash(args.BlockHash)
+		block, err = t.chain.FindBlock(hash)
+		if err != nil {
+			return fmt.Errorf("Block %v is not found", hash.Hex())
+		}
+	} else if args.TxHash != "" {
+		hash := common.HexToHash(args.TxHash)
+		var found bool
+		_, block, found = t.chain.FindTxByHash(hash)
+		if !found {
+			return fmt.Errorf("Transaction %v is not included in any block", hash.Hex())
+		}
+	} else {
+		return errors.New("Block hash or transaction hash must be specified")
+	}
+
+	head := t.finality.GetLastFinalizedBlock()
+	if head == nil {
+		return errors.New("There is no finalized block yet")
+	}
+
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.Status = block.Status
+	result.Finalized = block.Status.IsFinalized()
+	result.FinalizedBlockHash = head.Hash()
+	result.FinalizedBlockHeight = common.JSONUint64(head.Height)
+	if result.Finalized && head.Height >= block.Height {
+		result.Depth = common.JSONUint64(head.Height - block.Height)
+	}
+	// A finalized block is on the canonical chain, and can't be reverted
+	result.SafeToAct = result.Finalized
+	return nil
+}
+
 // ------------------------------ GetPendingTransactions -----------------------------------
 
 type GetPendingTransactionsArgs struct {
@@ -179,6 +597,8 @@ func (t *ThetaRPCService) GetPendingTransactions(args *GetPendingTransactionsArg
 
 type GetBlockArgs struct {
 	Hash common.Hash `json:"hash"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 type Tx struct {
@@ -221,6 +641,14 @@ const (
 	TxTypeSmartContract
 	TxTypeDepositStake
 	TxTypeWithdrawStake
+	TxTypeSetAccountOperator
+	TxTypeServicePaymentDispute
+	TxTypeRegisterNodeAddress
+	TxTypeMultiSend
+	TxTypeSlashReview
+	TxTypeDataCommitment
+	TxTypeUpdateAccountSigners
+	TxTypeMultiSigSend
 )
 
 func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (err error) {
@@ -232,6 +660,10 @@ func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (
 	if err != nil {
 		return err
 	}
+	finalizedOnly := t.isFinalizedOnly(args.FinalizedOnly)
+	if finalizedOnly && !block.Status.IsFinalized() {
+		return fmt.Errorf("Block %v is not finalized yet", args.Hash.Hex())
+	}
 
 	result.GetBlockResultInner = &GetBlockResultInner{}
 	result.ChainID = block.ChainID
@@ -243,6 +675,9 @@ func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (
 	result.Timestamp = (*common.JSONBig)(block.Timestamp)
 	result.Proposer = block.Proposer
 	result.Children = block.Children
+	if finalizedOnly {
+		result.Children = t.finalizedChildren(block)
+	}
 	result.Status = block.Status
 
 	result.Hash = block.Hash()
@@ -271,6 +706,8 @@ func (t *ThetaRPCService) GetBlock(args *GetBlockArgs, result *GetBlockResult) (
 
 type GetBlockByHeightArgs struct {
 	Height common.JSONUint64 `json:"height"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 func (t *ThetaRPCService) GetBlockByHeight(args *GetBlockByHeightArgs, result *GetBlockResult) (err error) {
@@ -302,6 +739,9 @@ func (t *ThetaRPCService) GetBlockByHeight(args *GetBlockByHeightArgs, result *G
 	result.Timestamp = (*common.JSONBig)(block.Timestamp)
 	result.Proposer = block.Proposer
 	result.Children = block.Children
+	if t.isFinalizedOnly(args.FinalizedOnly) {
+		result.Children = t.finalizedChildren(block)
+	}
 	result.Status = block.Status
 
 	result.Hash = block.Hash()
@@ -338,24 +778,23 @@ type GetStatusResult struct {
 	CurrentEpoch               common.JSONUint64 `json:"current_epoch"`
 	CurrentTime                *common.JSONBig   `json:"current_time"`
 	Syncing                    bool              `json:"syncing"`
+	ChainID                    string            `json:"chain_id"`
+	GenesisHash                string            `json:"genesis_hash"`
 }
 
 func (t *ThetaRPCService) GetStatus(args *GetStatusArgs, result *GetStatusResult) (err error) {
-	s := t.consensus.GetSummary()
-	latestFinalizedHash := s.LastFinalizedBlock
-	if !latestFinalizedHash.IsEmpty() {
-		result.LatestFinalizedBlockHash = latestFinalizedHash
-		block, err := t.chain.FindBlock(latestFinalizedHash)
-		if err != nil {
-			return err
-		}
+	block := t.finality.GetLastFinalizedBlock()
+	if block != nil && !block.Hash().IsEmpty() {
+		result.LatestFinalizedBlockHash = block.Hash()
 		result.LatestFinalizedBlockEpoch = common.JSONUint64(block.Epoch)
 		result.LatestFinalizedBlockHeight = common.JSONUint64(block.Height)
 		result.LatestFinalizedBlockTime = (*common.JSONBig)(block.Timestamp)
 		result.Syncing = isSyncing(block)
 	}
-	result.CurrentEpoch = common.JSONUint64(s.Epoch)
+	result.CurrentEpoch = common.JSONUint64(t.consensus.GetEpoch())
 	result.CurrentTime = (*common.JSONBig)(big.NewInt(time.Now().Unix()))
+	result.ChainID = t.chain.ChainID
+	result.GenesisHash = getGenesisHash(t.chain)
 
 	return
 }
@@ -364,6 +803,8 @@ func (t *ThetaRPCService) GetStatus(args *GetStatusArgs, result *GetStatusResult
 
 type GetVcpByHeightArgs struct {
 	Height common.JSONUint64 `json:"height"`
+
+	FinalizedOnly *bool `json:"finalized_only,omitempty"` // overrides the server default if set
 }
 
 type GetVcpResult struct {
@@ -387,7 +828,11 @@ func (t *ThetaRPCService) GetVcpByHeight(args *GetVcpByHeightArgs, result *GetVc
 
 	blockHashVcpPairs := []BlockHashVcpPair{}
 	blocks := t.chain.FindBlocksByHeight(height)
+	finalizedOnly := t.isFinalizedOnly(args.FinalizedOnly)
 	for _, b := range blocks {
+		if finalizedOnly && !b.Status.IsFinalized() {
+			continue
+		}
 		blockHash := b.Hash()
 		stateRoot := b.StateHash
 		blockStoreView := state.NewStoreView(height, stateRoot, db)
@@ -408,8 +853,100 @@ func (t *ThetaRPCService) GetVcpByHeight(args *GetVcpByHeightArgs, result *GetVc
 	return nil
 }
 
+// ------------------------------ GetSlashRecords -----------------------------------
+
+type GetSlashRecordsArgs struct{}
+
+// GetSlashRecordsResult lists the slashes pending the review of the slashing council. Params is
+// nil if slashing is not enabled on the network.
+type GetSlashRecordsResult struct {
+	Params        *types.SlashingParams `json:"params"`
+	Records       []*types.SlashRecord  `json:"records"`
+	BurnedSlashes types.Coins           `json:"burned_slashes"`
+}
+
+func (t *ThetaRPCService) GetSlashRecords(args *GetSlashRecordsArgs, result *GetSlashRecordsResult) (err error) {
+	ledgerState, err := t.ledger.GetFinalizedSnapshot()
+	if err != nil {
+		return err
+	}
+	result.Params = ledgerState.GetSlashingParams()
+	result.Records = ledgerState.GetSlashRecords()
+	result.BurnedSlashes = ledgerState.GetBurnedSlashes()
+	return nil
+}
+
+// ------------------------------ GetVoteTimestamps -----------------------------------
+
+type GetVoteTimestampsArgs struct {
+	Hash common.Hash `json:"hash"`
+}
+
+type VoteTimestamp struct {
+	Voter      common.Address    `json:"voter"`
+	Epoch      common.JSONUint64 `json:"epoch"`
+	SignedAt   common.JSONUint64 `json:"signed_at"`   // Unix time in milliseconds signed by the voter
+	ReceivedAt common.JSONUint64 `json:"received_at"` // Unix time in milliseconds at which the node processed the vote
+}
+
+// GetVoteTimestampsResult lists the timestamped votes on the block received by the node, in the
+// order they were received.
+type GetVoteTimestampsResult struct {
+	Hash       common.Hash       `json:"hash"`
+	Height     common.JSONUint64 `json:"height"`
+	Timestamp  *common.JSONBig   `json:"timestamp"`
+	Timestamps []VoteTimestamp   `json:"vote_timestamps"`
+}
+
+func (t *ThetaRPCService) GetVoteTimestamps(args *GetVoteTimestampsArgs, result *GetVoteTimestampsResult) (err error) {
+	if args.Hash.IsEmpty() {
+		return errors.New("Block hash must be specified")
+	}
+
+	block, err := t.chain.FindBlock(args.Hash)
+	if err != nil {
+		return err
+	}
+
+	result.Hash = block.Hash()
+	result.Height = common.JSONUint64(block.Height)
+	result.Timestamp = (*common.JSONBig)(block.Timestamp)
+	result.Timestamps = []VoteTimestamp{}
+	for _, ts := range t.chain.FindVoteTimestamps(args.Hash) {
+		result.Timestamps = append(result.Timestamps, VoteTimestamp{
+			Voter:      ts.Voter,
+			Epoch:      common.JSONUint64(ts.Epoch),
+			SignedAt:   common.JSONUint64(ts.SignedAt),
+			ReceivedAt: common.JSONUint64(ts.ReceivedAt),
+		})
+	}
+	return nil
+}
+
 // ------------------------------ Utils ------------------------------
 
+// isFinalizedOnly tells whether a query only serves finalized data, i.e. the state and the blocks as
+// of the last finalized block, so that nothing reported can be reverted by a block being abandoned.
+// The finalized_only argument of the request overrides the default of the server.
+func (t *ThetaRPCService) isFinalizedOnly(finalizedOnly *bool) bool {
+	if finalizedOnly != nil {
+		return *finalizedOnly
+	}
+	return t.finalizedOnly
+}
+
+// finalizedChildren returns the children of the block which are finalized.
+func (t *ThetaRPCService) finalizedChildren(block *core.ExtendedBlock) []common.Hash {
+	children := []common.Hash{}
+	for _, hash := range block.Children {
+		child, err := t.chain.FindBlock(hash)
+		if err == nil && child.Status.IsFinalized() {
+			children = append(children, hash)
+		}
+	}
+	return children
+}
+
 func getTxType(tx types.Tx) byte {
 	t := byte(0x0)
 	switch tx.(type) {
@@ -433,6 +970,22 @@ func getTxType(tx types.Tx) byte {
 		t = TxTypeDepositStake
 	case *types.WithdrawStakeTx:
 		t = TxTypeWithdrawStake
+	case *types.SetAccountOperatorTx:
+		t = TxTypeSetAccountOperator
+	case *types.ServicePaymentDisputeTx:
+		t = TxTypeServicePaymentDispute
+	case *types.RegisterNodeAddressTx:
+		t = TxTypeRegisterNodeAddress
+	case *types.MultiSendTx:
+		t = TxTypeMultiSend
+	case *types.SlashReviewTx:
+		t = TxTypeSlashReview
+	case *types.DataCommitmentTx:
+		t = TxTypeDataCommitment
+	case *types.UpdateAccountSignersTx:
+		t = TxTypeUpdateAccountSigners
+	case *types.MultiSigSendTx:
+		t = TxTypeMultiSigSend
 	}
 
 	return t
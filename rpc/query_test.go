@@ -0,0 +1,191 @@
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/rlp"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+// testFinality serves the finality of the blocks from the chain, without a consensus engine.
+type testFinality struct {
+	core.FinalityProvider
+	chain         *blockchain.Chain
+	lastFinalized *core.ExtendedBlock
+}
+
+func (f *testFinality) GetLastFinalizedBlock() *core.ExtendedBlock {
+	return f.lastFinalized
+}
+
+func (f *testFinality) GetFinalizationCertificate(hash common.Hash) (*core.CommitCertificate, error) {
+	return f.chain.FindFinalizationCertificate(hash)
+}
+
+func TestGetTransactionProof(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	signer, _, _ := crypto.GenerateKeyPair()
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("proof_root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	service := &ThetaRPCService{chain: chain, finality: &testFinality{chain: chain}}
+
+	txs := []common.Bytes{common.Bytes("tx0"), common.Bytes("tx1"), common.Bytes("tx2")}
+	b1 := core.CreateTestBlock("proof_b1", "proof_root")
+	b1.AddTxs(txs)
+	eb1, err := chain.AddBlock(b1)
+	require.Nil(err)
+	chain.AddTxsToIndex(eb1, true)
+
+	txHash := crypto.Keccak256Hash(txs[1])
+	result := &GetTransactionProofResult{}
+	assert.NotNil(service.GetTransactionProof(&GetTransactionProofArgs{Hash: txHash.Hex()}, result), "not finalized")
+
+	vote := core.Vote{Block: b1.Hash(), Height: b1.Height, ID: signer.PublicKey().Address()}
+	vote.Sign(signer)
+	votes := core.NewVoteSet()
+	votes.AddVote(vote)
+	b2 := core.CreateTestBlock("proof_b2", "proof_b1")
+	b2.HCC = core.CommitCertificate{BlockHash: b1.Hash(), Votes: votes}
+	chain.AddBlock(b2)
+	chain.FinalizePreviousBlocks(b2.Hash())
+
+	require.Nil(service.GetTransactionProof(&GetTransactionProofArgs{Hash: txHash.Hex()}, result))
+	assert.Equal(b1.Hash(), result.BlockHash)
+	assert.Equal(txs[1], result.Tx)
+
+	// Verify the transaction from the result only
+	assert.Equal(result.BlockHash, crypto.Keccak256Hash(result.RawBlockHeader))
+	header := &core.BlockHeader{}
+	require.Nil(rlp.DecodeBytes(result.RawBlockHeader, header))
+	tx, err := core.VerifyTxProof(header.TxHash, result.Proof)
+	require.Nil(err)
+	assert.Equal(txs[1], tx)
+	assert.Equal(b1.Hash(), result.Certificate.BlockHash)
+	assert.Equal(1, result.Certificate.Votes.Size())
+
+	assert.NotNil(service.GetTransactionProof(&GetTransactionProofArgs{Hash: "0x1234"}, result))
+}
+
+func TestFinalizedOnlyQueries(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("finalized_root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	service := &ThetaRPCService{chain: chain, finalizedOnly: true}
+
+	rawTx1, err := types.TxToBytes(&types.SendTx{Fee: types.NewCoins(0, 1000000000000)})
+	require.Nil(err)
+	rawTx2, err := types.TxToBytes(&types.SendTx{Fee: types.NewCoins(0, 2000000000000)})
+	require.Nil(err)
+	b1 := core.CreateTestBlock("finalized_b1", "finalized_root")
+	b1.AddTxs([]common.Bytes{rawTx1})
+	eb1, err := chain.AddBlock(b1)
+	require.Nil(err)
+	chain.AddTxsToIndex(eb1, true)
+	b2 := core.CreateTestBlock("finalized_b2", "finalized_b1")
+	b2.AddTxs([]common.Bytes{rawTx2})
+	eb2, err := chain.AddBlock(b2)
+	require.Nil(err)
+	chain.AddTxsToIndex(eb2, true)
+	chain.FinalizePreviousBlocks(b1.Hash())
+
+	// The blocks not finalized yet are not served
+	blockResult := &GetBlockResult{}
+	assert.NotNil(service.GetBlock(&GetBlockArgs{Hash: b2.Hash()}, blockResult))
+	require.Nil(service.GetBlock(&GetBlockArgs{Hash: b1.Hash()}, blockResult))
+	assert.Equal(b1.Hash(), blockResult.Hash)
+	assert.Empty(blockResult.Children)
+
+	// Unless the request overrides the server default
+	notFinalizedOnly := false
+	blockResult = &GetBlockResult{}
+	require.Nil(service.GetBlock(&GetBlockArgs{Hash: b1.Hash(), FinalizedOnly: &notFinalizedOnly}, blockResult))
+	assert.Equal([]common.Hash{b2.Hash()}, blockResult.Children)
+	require.Nil(service.GetBlock(&GetBlockArgs{Hash: b2.Hash(), FinalizedOnly: &notFinalizedOnly}, blockResult))
+
+	txResult := &GetTransactionResult{}
+	require.Nil(service.GetTransaction(&GetTransactionArgs{Hash: crypto.Keccak256Hash(rawTx1).Hex()}, txResult))
+	assert.Equal(TxStatus(TxStatusFinalized), txResult.Status)
+	assert.Equal(b1.Hash(), txResult.BlockHash)
+
+	txResult = &GetTransactionResult{}
+	require.Nil(service.GetTransaction(&GetTransactionArgs{Hash: crypto.Keccak256Hash(rawTx2).Hex()}, txResult))
+	assert.Equal(TxStatus(TxStatusPending), txResult.Status)
+	assert.True(txResult.BlockHash.IsEmpty())
+	assert.Equal(common.JSONUint64(0), txResult.BlockHeight)
+
+	txResult = &GetTransactionResult{}
+	require.Nil(service.GetTransaction(&GetTransactionArgs{Hash: crypto.Keccak256Hash(rawTx2).Hex(), FinalizedOnly: &notFinalizedOnly}, txResult))
+	assert.Equal(TxStatus(TxStatusPending), txResult.Status)
+	assert.Equal(b2.Hash(), txResult.BlockHash)
+
+	// The account balances can't be previewed from the screened state
+	assert.NotNil(service.GetAccount(&GetAccountArgs{Address: "0x2E833968E5bB786Ae419c4d13189fB081Cc43bab", Preview: true}, &GetAccountResult{}))
+}
+
+func TestGetFinalityStatus(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("finality_root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	finality := &testFinality{chain: chain}
+	service := &ThetaRPCService{chain: chain, finality: finality}
+
+	rawTx := common.Bytes("tx")
+	b1 := core.CreateTestBlock("finality_b1", "finality_root")
+	b1.AddTxs([]common.Bytes{rawTx})
+	eb1, err := chain.AddBlock(b1)
+	require.Nil(err)
+	chain.AddTxsToIndex(eb1, true)
+	b2 := core.CreateTestBlock("finality_b2", "finality_b1")
+	_, err = chain.AddBlock(b2)
+	require.Nil(err)
+	b3 := core.CreateTestBlock("finality_b3", "finality_b2")
+	_, err = chain.AddBlock(b3)
+	require.Nil(err)
+
+	finality.lastFinalized, err = chain.FindBlock(root.Hash())
+	require.Nil(err)
+
+	// Not finalized, however many blocks are built on top of it
+	result := &GetFinalityStatusResult{}
+	require.Nil(service.GetFinalityStatus(&GetFinalityStatusArgs{TxHash: crypto.Keccak256Hash(rawTx).Hex()}, result))
+	assert.Equal(b1.Hash(), result.BlockHash)
+	assert.False(result.Finalized)
+	assert.False(result.SafeToAct)
+	assert.Equal(common.JSONUint64(0), result.Depth)
+
+	chain.FinalizePreviousBlocks(b2.Hash())
+	finality.lastFinalized, err = chain.FindBlock(b2.Hash())
+	require.Nil(err)
+
+	result = &GetFinalityStatusResult{}
+	require.Nil(service.GetFinalityStatus(&GetFinalityStatusArgs{BlockHash: b1.Hash().Hex()}, result))
+	assert.True(result.Finalized)
+	assert.True(result.SafeToAct)
+	assert.Equal(common.JSONUint64(1), result.Depth)
+	assert.Equal(b2.Hash(), result.FinalizedBlockHash)
+
+	result = &GetFinalityStatusResult{}
+	require.Nil(service.GetFinalityStatus(&GetFinalityStatusArgs{BlockHash: b3.Hash().Hex()}, result))
+	assert.False(result.SafeToAct)
+
+	assert.NotNil(service.GetFinalityStatus(&GetFinalityStatusArgs{}, result))
+	assert.NotNil(service.GetFinalityStatus(&GetFinalityStatusArgs{TxHash: "0x1234"}, result))
+	assert.NotNil(service.GetFinalityStatus(&GetFinalityStatusArgs{BlockHash: "0x1234"}, result))
+}
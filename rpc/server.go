@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sync"
 
+	"net/http/pprof"
 	"net/rpc"
 
 	"github.com/gorilla/mux"
@@ -14,22 +15,41 @@ import (
 	"github.com/spf13/viper"
 	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/metrics"
+	"github.com/thetatoken/theta/common/metrics/prometheus"
+	"github.com/thetatoken/theta/common/profiler"
 	"github.com/thetatoken/theta/common/util"
-	"github.com/thetatoken/theta/consensus"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/dispatcher"
 	"github.com/thetatoken/theta/ledger"
 	"github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/netsync"
 	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
-	"golang.org/x/net/netutil"
+	"github.com/thetatoken/theta/txindex"
 	"golang.org/x/net/websocket"
+	"google.golang.org/grpc"
 )
 
-var logger *log.Entry
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "rpc"})
 
 type ThetaRPCService struct {
-	mempool   *mempool.Mempool
-	ledger    *ledger.Ledger
-	chain     *blockchain.Chain
-	consensus *consensus.ConsensusEngine
+	mempool    *mempool.Mempool
+	ledger     *ledger.Ledger
+	chain      *blockchain.Chain
+	consensus  core.ConsensusEngine
+	finality   core.FinalityProvider
+	dispatcher *dispatcher.Dispatcher
+	profiler   *profiler.Profiler
+	tenants    *TenantManager
+	txIndexer  *txindex.Indexer
+	pruner     *ledger.StatePruner
+	auditLog   *AuditLog
+
+	propagation *netsync.PropagationTracker
+
+	subscriptions *subscriptionHub
+
+	finalizedOnly bool // default of the finalized-only mode of the query RPCs
 
 	// Life cycle
 	wg      *sync.WaitGroup
@@ -42,14 +62,17 @@ type ThetaRPCService struct {
 type ThetaRPCServer struct {
 	*ThetaRPCService
 
-	server   *http.Server
-	handler  *rpc.Server
-	router   *mux.Router
-	listener net.Listener
+	server    *http.Server
+	handler   *rpc.Server
+	router    *mux.Router
+	listeners []net.Listener
+
+	grpcServer *grpc.Server // nil unless common.CfgRPCGRPCEnabled is set
 }
 
 // NewThetaRPCServer creates a new instance of ThetaRPCServer.
-func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *blockchain.Chain, consensus *consensus.ConsensusEngine) *ThetaRPCServer {
+func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *blockchain.Chain,
+	consensus core.ConsensusEngine, dispatcher *dispatcher.Dispatcher) *ThetaRPCServer {
 	t := &ThetaRPCServer{
 		ThetaRPCService: &ThetaRPCService{
 			wg: &sync.WaitGroup{},
@@ -60,6 +83,28 @@ func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *b
 	t.ledger = ledger
 	t.chain = chain
 	t.consensus = consensus
+	t.finality = consensus
+	t.dispatcher = dispatcher
+	t.subscriptions = newSubscriptionHub(chain)
+	t.finalizedOnly = viper.GetBool(common.CfgRPCFinalizedOnly)
+
+	logger = util.GetLoggerForModule("rpc")
+
+	legacyErrors = viper.GetBool(common.CfgRPCLegacyErrors)
+	jsonrpc2.ServerErrorEncoder = encodeError
+
+	tenants, err := NewTenantManagerFromConfig()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Failed to load the RPC tenants")
+	}
+	t.tenants = tenants
+
+	auditLog, err := NewAuditLogFromConfig()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Failed to open the RPC audit log")
+	}
+	t.auditLog = auditLog
+	tenants.SetAuditLog(auditLog)
 
 	s := rpc.NewServer()
 	s.RegisterName("theta", t.ThetaRPCService)
@@ -67,20 +112,51 @@ func NewThetaRPCServer(mempool *mempool.Mempool, ledger *ledger.Ledger, chain *b
 	t.handler = s
 
 	t.router = mux.NewRouter()
-	t.router.Handle("/rpc", jsonrpc2.HTTPHandler(s))
+	t.router.Handle("/rpc", tenants.HTTPHandler(jsonrpc2.HTTPHandler(s)))
 	t.router.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
-		s.ServeCodec(jsonrpc2.NewServerCodec(ws, s))
+		conn, err := tenants.WebsocketConn(ws)
+		if err != nil {
+			return
+		}
+		s.ServeCodec(jsonrpc2.NewServerCodec(conn, s))
+	}))
+	if viper.GetBool(common.CfgRPCEthEnabled) {
+		t.router.Handle("/eth", tenants.HTTPHandler(newEthService(t.ThetaRPCService)))
+	}
+	t.router.Handle("/ws/subscribe", websocket.Handler(func(ws *websocket.Conn) {
+		conn, err := tenants.WebsocketConn(ws)
+		if err != nil {
+			return
+		}
+		t.subscriptions.serve(conn)
 	}))
+	if viper.GetBool(common.CfgRPCPrometheusEnabled) {
+		t.router.Handle("/metrics", prometheus.Handler(metrics.DefaultRegistry, t.nodeStatsFamilies, t.peerStatsFamilies))
+	}
+	if viper.GetBool(common.CfgRPCPprofEnabled) {
+		t.router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		t.router.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		t.router.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		t.router.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		t.router.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+	}
 
 	t.server = &http.Server{
 		Handler: t.router,
 	}
 
-	logger = util.GetLoggerForModule("rpc")
+	if viper.GetBool(common.CfgRPCGRPCEnabled) {
+		t.grpcServer = newGRPCServer(t.ThetaRPCService)
+	}
 
 	return t
 }
 
+// SetProfiler sets the profiler whose captures are served through the RPC.
+func (t *ThetaRPCServer) SetProfiler(profiler *profiler.Profiler) {
+	t.profiler = profiler
+}
+
 // Start creates the main goroutine.
 func (t *ThetaRPCServer) Start(ctx context.Context) {
 	c, cancel := context.WithCancel(ctx)
@@ -92,32 +168,52 @@ func (t *ThetaRPCServer) Start(ctx context.Context) {
 
 	t.wg.Add(1)
 	go t.txCallback()
+
+	t.wg.Add(1)
+	go t.subscriptionLoop()
 }
 
 func (t *ThetaRPCServer) mainLoop() {
 	defer t.wg.Done()
 
 	go t.serve()
+	if t.grpcServer != nil {
+		go t.serveGRPC()
+	}
 
 	<-t.ctx.Done()
 	t.stopped = true
 	t.server.Shutdown(t.ctx)
+	if t.grpcServer != nil {
+		t.grpcServer.Stop()
+	}
+	if t.auditLog != nil {
+		t.auditLog.Close()
+	}
 }
 
 func (t *ThetaRPCServer) serve() {
-	port := viper.GetString(common.CfgRPCPort)
-	l, err := net.Listen("tcp", ":"+port)
+	config, err := NewListenerConfigFromViper()
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err}).Fatal("Invalid RPC listener config")
+	}
+	listeners, err := OpenListeners(config)
 	if err != nil {
 		logger.WithFields(log.Fields{"error": err}).Fatal("Failed to create listener")
-	} else {
-		logger.WithFields(log.Fields{"port": port}).Info("RPC server started")
 	}
-	defer l.Close()
-
-	ll := netutil.LimitListener(l, viper.GetInt(common.CfgRPCMaxConnections))
-	t.listener = ll
-
-	logger.Info(t.server.Serve(ll))
+	t.listeners = listeners
+	logger.WithFields(log.Fields{
+		"port":       config.Port,
+		"tls":        len(config.TLSCertFile) > 0,
+		"unixSocket": config.UnixSocket,
+	}).Info("RPC server started")
+
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			defer l.Close()
+			logger.Info(t.server.Serve(l))
+		}(l)
+	}
 }
 
 // Stop notifies all goroutines to stop without blocking.
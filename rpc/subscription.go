@@ -0,0 +1,471 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"github.com/thetatoken/theta/webhook"
+)
+
+//
+// ------------------------------ Subscriptions -----------------------------------
+//
+// The websocket endpoint /ws/subscribe pushes the chain events to the clients, so that explorers
+// and wallets do not need to poll. The clients call the subscribe and unsubscribe methods, and
+// receive the events of their subscriptions as notifications:
+//
+//   --> {"jsonrpc":"2.0","id":1,"method":"subscribe","params":{"topic":"addressActivity","address":"0x..."}}
+//   <-- {"jsonrpc":"2.0","id":1,"result":"0x1"}
+//   <-- {"jsonrpc":"2.0","method":"subscription","params":{"subscription":"0x1","result":{...}}}
+//   --> {"jsonrpc":"2.0","id":2,"method":"unsubscribe","params":{"subscription":"0x1"}}
+//   <-- {"jsonrpc":"2.0","id":2,"result":true}
+//
+// The events are sourced from the consensus engine and the mempool. Like their feeds, the events
+// are dropped for the connections whose send queue is full.
+//
+
+const (
+	// TopicNewBlock notifies the blocks once validated, see BlockEvent
+	TopicNewBlock = "newBlock"
+	// TopicFinalizedBlock notifies the finalized blocks in order of height, see BlockEvent
+	TopicFinalizedBlock = "finalizedBlock"
+	// TopicPendingTx notifies the insertions and removals of the mempool, see PendingTxEvent
+	TopicPendingTx = "pendingTx"
+	// TopicAddressActivity notifies the pending and finalized transactions involving an address,
+	// see AddressActivityEvent
+	TopicAddressActivity = "addressActivity"
+)
+
+const (
+	// Max number of subscriptions of a websocket connection
+	maxSubscriptionsPerConn = 64
+
+	// Max number of messages waiting to be sent to a websocket connection
+	subscriptionSendQueueSize = 256
+
+	// Number of events buffered from the consensus engine and the mempool
+	subscriptionFeedBufferSize = 512
+)
+
+type SubscribeArgs struct {
+	Topic   string `json:"topic"`
+	Address string `json:"address"` // Address of the addressActivity topic
+}
+
+type UnsubscribeArgs struct {
+	Subscription string `json:"subscription"`
+}
+
+// BlockEvent is the notification of the newBlock and finalizedBlock topics
+type BlockEvent struct {
+	Hash      common.Hash       `json:"hash"`
+	Height    common.JSONUint64 `json:"height"`
+	Parent    common.Hash       `json:"parent"`
+	Epoch     common.JSONUint64 `json:"epoch"`
+	Timestamp *common.JSONBig   `json:"timestamp"`
+	Proposer  common.Address    `json:"proposer"`
+	TxHashes  []common.Hash     `json:"tx_hashes"`
+}
+
+// PendingTxEvent is the notification of the pendingTx topic
+type PendingTxEvent struct {
+	Status string         `json:"status"` // inserted, committed, dropped or replaced
+	TxHash common.Hash    `json:"tx_hash"`
+	Sender common.Address `json:"sender"`
+}
+
+// AddressActivityEvent is the notification of the addressActivity topic. A transaction is notified
+// once when it enters the mempool, and once when its block is finalized.
+type AddressActivityEvent struct {
+	Address     common.Address     `json:"address"`
+	Status      string             `json:"status"` // pending or finalized
+	TxHash      common.Hash        `json:"tx_hash"`
+	TxType      byte               `json:"tx_type"`
+	BlockHash   *common.Hash       `json:"block_hash,omitempty"`
+	BlockHeight *common.JSONUint64 `json:"block_height,omitempty"`
+}
+
+type subscriptionRequest struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+}
+
+type subscriptionResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *jsonrpc2.Error  `json:"error,omitempty"`
+}
+
+type subscriptionNotification struct {
+	Version string                         `json:"jsonrpc"`
+	Method  string                         `json:"method"`
+	Params  subscriptionNotificationParams `json:"params"`
+}
+
+type subscriptionNotificationParams struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+type subscription struct {
+	id      string
+	topic   string
+	address common.Address
+}
+
+// subscriptionConn is a websocket connection and its subscriptions
+type subscriptionConn struct {
+	conn io.ReadWriteCloser
+	mu   *sync.Mutex
+	subs map[string]*subscription
+	out  chan []byte
+	done chan struct{}
+}
+
+// subscriptionHub dispatches the events to the subscriptions of the websocket connections.
+type subscriptionHub struct {
+	chain *blockchain.Chain
+
+	mu        *sync.Mutex
+	conns     map[*subscriptionConn]struct{}
+	listeners map[*eventListener]struct{}
+	nextID    uint64
+
+	lastFinalizedHeight uint64
+}
+
+func newSubscriptionHub(chain *blockchain.Chain) *subscriptionHub {
+	return &subscriptionHub{
+		chain:     chain,
+		mu:        &sync.Mutex{},
+		conns:     make(map[*subscriptionConn]struct{}),
+		listeners: make(map[*eventListener]struct{}),
+	}
+}
+
+// eventListener receives the events of a topic within the node, e.g. for the gRPC streams. Its
+// channel is closed when the hub stops, or when the listener falls too far behind, since a listener
+// must not miss events silently.
+type eventListener struct {
+	topic string
+	C     chan interface{}
+}
+
+// listen registers a listener of the topic, and returns the function unregistering it.
+func (h *subscriptionHub) listen(topic string) (*eventListener, func()) {
+	l := &eventListener{
+		topic: topic,
+		C:     make(chan interface{}, subscriptionSendQueueSize),
+	}
+	h.mu.Lock()
+	h.listeners[l] = struct{}{}
+	h.mu.Unlock()
+	return l, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.listeners[l]; ok {
+			delete(h.listeners, l)
+			close(l.C)
+		}
+	}
+}
+
+func (t *ThetaRPCService) subscriptionLoop() {
+	defer t.wg.Done()
+
+	validated := t.consensus.SubscribeValidatedBlocks(subscriptionFeedBufferSize)
+	defer validated.Unsubscribe()
+	finalized := t.finality.SubscribeFinalizedBlocks(subscriptionFeedBufferSize)
+	defer finalized.Unsubscribe()
+	txEvents := t.mempool.SubscribeTxEvents(subscriptionFeedBufferSize)
+	defer txEvents.Unsubscribe()
+
+	lastFinalized := t.finality.GetLastFinalizedBlock()
+	t.subscriptions.run(t.ctx, lastFinalized.Height, validated.C, finalized.C, txEvents.C)
+}
+
+// run dispatches the events until the context is done, then closes the connections. The
+// finalized blocks are published from the height following lastFinalizedHeight.
+func (h *subscriptionHub) run(ctx context.Context, lastFinalizedHeight uint64, validated <-chan *core.Block,
+	finalized <-chan *core.Block, txEvents <-chan *mempool.TxEvent) {
+	h.lastFinalizedHeight = lastFinalizedHeight
+	for {
+		select {
+		case <-ctx.Done():
+			h.mu.Lock()
+			for c := range h.conns {
+				c.conn.Close()
+			}
+			for l := range h.listeners {
+				delete(h.listeners, l)
+				close(l.C)
+			}
+			h.mu.Unlock()
+			return
+		case block := <-validated:
+			h.notify(TopicNewBlock, nil, newBlockEvent(block))
+		case block := <-finalized:
+			h.publishFinalizedBlock(block)
+		case event := <-txEvents:
+			h.publishTxEvent(event)
+		}
+	}
+}
+
+// publishFinalizedBlock publishes the finalized block, preceded by its ancestors finalized since
+// the last finalized block published. Only the directly finalized blocks are fed by the engine.
+func (h *subscriptionHub) publishFinalizedBlock(block *core.Block) {
+	for height := h.lastFinalizedHeight + 1; height < block.Height; height++ {
+		ancestor := findFinalizedBlock(h.chain, height)
+		if ancestor == nil {
+			logger.WithFields(log.Fields{"height": height}).Warn("Finalized block not found")
+			continue
+		}
+		h.publishFinalizedTxs(ancestor.Block)
+	}
+	h.publishFinalizedTxs(block)
+	if block.Height > h.lastFinalizedHeight {
+		h.lastFinalizedHeight = block.Height
+	}
+}
+
+func (h *subscriptionHub) publishFinalizedTxs(block *core.Block) {
+	h.notify(TopicFinalizedBlock, nil, newBlockEvent(block))
+	blockHash := block.Hash()
+	blockHeight := common.JSONUint64(block.Height)
+	for _, rawTx := range block.Txs {
+		tx, err := types.TxFromBytes(rawTx)
+		if err != nil {
+			continue
+		}
+		for _, address := range webhook.TxAddresses(tx) {
+			h.notify(TopicAddressActivity, &address, &AddressActivityEvent{
+				Address:     address,
+				Status:      "finalized",
+				TxHash:      crypto.Keccak256Hash(rawTx),
+				TxType:      getTxType(tx),
+				BlockHash:   &blockHash,
+				BlockHeight: &blockHeight,
+			})
+		}
+	}
+}
+
+func (h *subscriptionHub) publishTxEvent(event *mempool.TxEvent) {
+	h.notify(TopicPendingTx, nil, &PendingTxEvent{
+		Status: event.Type.String(),
+		TxHash: event.TxHash,
+		Sender: event.Address,
+	})
+	if event.Type != mempool.TxEventInserted {
+		return
+	}
+	tx, err := types.TxFromBytes(event.RawTx)
+	if err != nil {
+		return
+	}
+	for _, address := range webhook.TxAddresses(tx) {
+		h.notify(TopicAddressActivity, &address, &AddressActivityEvent{
+			Address: address,
+			Status:  "pending",
+			TxHash:  event.TxHash,
+			TxType:  getTxType(tx),
+		})
+	}
+}
+
+// notify sends the event to the subscriptions of the topic, and of the address if given.
+func (h *subscriptionHub) notify(topic string, address *common.Address, result interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.conns {
+		c.mu.Lock()
+		for _, sub := range c.subs {
+			if sub.topic != topic || (address != nil && sub.address != *address) {
+				continue
+			}
+			raw, err := json.Marshal(subscriptionNotification{
+				Version: "2.0",
+				Method:  "subscription",
+				Params:  subscriptionNotificationParams{Subscription: sub.id, Result: result},
+			})
+			if err != nil {
+				logger.WithFields(log.Fields{"error": err}).Error("Failed to encode notification")
+				continue
+			}
+			select {
+			case c.out <- raw:
+			default:
+			}
+		}
+		c.mu.Unlock()
+	}
+	if address != nil {
+		return
+	}
+	for l := range h.listeners {
+		if l.topic != topic {
+			continue
+		}
+		select {
+		case l.C <- result:
+		default:
+			logger.WithFields(log.Fields{"topic": topic}).Warn("Event listener too slow, dropping it")
+			delete(h.listeners, l)
+			close(l.C)
+		}
+	}
+}
+
+// serve handles the requests of the websocket connection until it is closed.
+func (h *subscriptionHub) serve(conn io.ReadWriteCloser) {
+	c := &subscriptionConn{
+		conn: conn,
+		mu:   &sync.Mutex{},
+		subs: make(map[string]*subscription),
+		out:  make(chan []byte, subscriptionSendQueueSize),
+		done: make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.conns[c] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.conns, c)
+		h.mu.Unlock()
+		close(c.done)
+		conn.Close()
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-c.done:
+				return
+			case raw := <-c.out:
+				if _, err := conn.Write(raw); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return // The stream cannot be resynchronized after a malformed message
+		}
+		select {
+		case c.out <- h.handle(c, raw):
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (h *subscriptionHub) handle(c *subscriptionConn, raw json.RawMessage) []byte {
+	req := subscriptionRequest{}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) || json.Unmarshal(raw, &req) != nil || len(req.Method) == 0 {
+		return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32600, "Invalid request"))
+	}
+
+	switch req.Method {
+	case "subscribe":
+		args := SubscribeArgs{}
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32602, err.Error()))
+		}
+		id, err := h.subscribe(c, args)
+		if err != nil {
+			return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32602, err.Error()))
+		}
+		return encodeSubscriptionResponse(req.ID, id, nil)
+	case "unsubscribe":
+		args := UnsubscribeArgs{}
+		if err := json.Unmarshal(req.Params, &args); err != nil {
+			return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32602, err.Error()))
+		}
+		c.mu.Lock()
+		_, ok := c.subs[args.Subscription]
+		delete(c.subs, args.Subscription)
+		c.mu.Unlock()
+		if !ok {
+			return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32602, fmt.Sprintf("Subscription %v not found", args.Subscription)))
+		}
+		return encodeSubscriptionResponse(req.ID, true, nil)
+	default:
+		return encodeSubscriptionResponse(req.ID, nil, jsonrpc2.NewError(-32601, fmt.Sprintf("Method %v not found", req.Method)))
+	}
+}
+
+func (h *subscriptionHub) subscribe(c *subscriptionConn, args SubscribeArgs) (string, error) {
+	sub := &subscription{topic: args.Topic}
+	switch args.Topic {
+	case TopicNewBlock, TopicFinalizedBlock, TopicPendingTx:
+	case TopicAddressActivity:
+		if !common.IsHexAddress(args.Address) {
+			return "", fmt.Errorf("Invalid address: %v", args.Address)
+		}
+		sub.address = common.HexToAddress(args.Address)
+	default:
+		return "", fmt.Errorf("Unknown topic: %v", args.Topic)
+	}
+
+	h.mu.Lock()
+	h.nextID++
+	sub.id = fmt.Sprintf("0x%x", h.nextID)
+	h.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.subs) >= maxSubscriptionsPerConn {
+		return "", fmt.Errorf("Too many subscriptions, at most %v allowed per connection", maxSubscriptionsPerConn)
+	}
+	c.subs[sub.id] = sub
+	return sub.id, nil
+}
+
+func encodeSubscriptionResponse(id *json.RawMessage, result interface{}, rpcErr *jsonrpc2.Error) []byte {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	if rpcErr != nil {
+		rpcErr = encodeError(rpcErr)
+	}
+	raw, _ := json.Marshal(subscriptionResponse{Version: "2.0", ID: id, Result: result, Error: rpcErr})
+	return raw
+}
+
+func newBlockEvent(block *core.Block) *BlockEvent {
+	txHashes := []common.Hash{}
+	for _, rawTx := range block.Txs {
+		txHashes = append(txHashes, crypto.Keccak256Hash(rawTx))
+	}
+	return &BlockEvent{
+		Hash:      block.Hash(),
+		Height:    common.JSONUint64(block.Height),
+		Parent:    block.Parent,
+		Epoch:     common.JSONUint64(block.Epoch),
+		Timestamp: (*common.JSONBig)(block.Timestamp),
+		Proposer:  block.Proposer,
+		TxHashes:  txHashes,
+	}
+}
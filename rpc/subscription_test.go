@@ -0,0 +1,198 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/mempool"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+	"golang.org/x/net/websocket"
+)
+
+type subscriptionTestMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonrpc2.Error `json:"error"`
+	Method string          `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+type subscriptionTestClient struct {
+	t      *testing.T
+	ws     *websocket.Conn
+	nextID int
+}
+
+func (c *subscriptionTestClient) call(method string, params interface{}) subscriptionTestMessage {
+	c.nextID++
+	raw, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	require.Nil(c.t, websocket.Message.Send(c.ws, string(raw)))
+	msg := c.receive()
+	require.NotNil(c.t, msg.ID)
+	require.Equal(c.t, c.nextID, *msg.ID)
+	return msg
+}
+
+func (c *subscriptionTestClient) subscribe(params map[string]string) string {
+	msg := c.call("subscribe", params)
+	require.Nil(c.t, msg.Error)
+	id := ""
+	require.Nil(c.t, json.Unmarshal(msg.Result, &id))
+	return id
+}
+
+func (c *subscriptionTestClient) receive() subscriptionTestMessage {
+	var raw string
+	require.Nil(c.t, websocket.Message.Receive(c.ws, &raw))
+	msg := subscriptionTestMessage{}
+	require.Nil(c.t, json.Unmarshal([]byte(raw), &msg))
+	return msg
+}
+
+// receiveNotification returns the next notification, decoding its result into result.
+func (c *subscriptionTestClient) receiveNotification(result interface{}) string {
+	msg := c.receive()
+	require.Equal(c.t, "subscription", msg.Method)
+	require.Nil(c.t, json.Unmarshal(msg.Params.Result, result))
+	return msg.Params.Subscription
+}
+
+func TestSubscriptions(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.NewBlock()
+	root.ChainID = "testchain"
+	root.Timestamp = big.NewInt(1000)
+	chain := blockchain.NewChain("testchain", store, root)
+
+	address := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	recipient := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	sendTx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: address, Coins: types.NewCoins(0, 1000000000001)}},
+		Outputs: []types.TxOutput{{Address: recipient, Coins: types.NewCoins(0, 1)}},
+	}
+	rawTx, err := types.TxToBytes(sendTx)
+	require.Nil(err)
+	txHash := crypto.Keccak256Hash(rawTx)
+
+	b1 := createChainStatsTestBlock(root, 1002, []common.Bytes{rawTx})
+	b2 := createChainStatsTestBlock(b1, 1004, []common.Bytes{})
+	_, err = chain.AddBlock(b1)
+	require.Nil(err)
+	_, err = chain.AddBlock(b2)
+	require.Nil(err)
+	chain.FinalizePreviousBlocks(b2.Hash())
+
+	hub := newSubscriptionHub(chain)
+	validated := make(chan *core.Block)
+	finalized := make(chan *core.Block)
+	txEvents := make(chan *mempool.TxEvent)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.run(ctx, 0, validated, finalized, txEvents)
+
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		hub.serve(ws)
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	ws, err := websocket.Dial(url, "", server.URL)
+	require.Nil(err)
+	defer ws.Close()
+	client := &subscriptionTestClient{t: t, ws: ws}
+
+	newBlockSub := client.subscribe(map[string]string{"topic": TopicNewBlock})
+	finalizedSub := client.subscribe(map[string]string{"topic": TopicFinalizedBlock})
+	pendingSub := client.subscribe(map[string]string{"topic": TopicPendingTx})
+	addressSub := client.subscribe(map[string]string{"topic": TopicAddressActivity, "address": recipient.Hex()})
+	assert.Equal(4, len(map[string]bool{newBlockSub: true, finalizedSub: true, pendingSub: true, addressSub: true}))
+
+	// Invalid requests
+	assert.NotNil(client.call("subscribe", map[string]string{"topic": "unknown"}).Error)
+	assert.NotNil(client.call("subscribe", map[string]string{"topic": TopicAddressActivity, "address": "0x12"}).Error)
+	assert.NotNil(client.call("unsubscribe", map[string]string{"subscription": "0x1234"}).Error)
+	assert.Equal(-32601, client.call("eth_subscribe", nil).Error.Code)
+
+	validated <- b1
+	blockEvent := &BlockEvent{}
+	assert.Equal(newBlockSub, client.receiveNotification(blockEvent))
+	assert.Equal(b1.Hash(), blockEvent.Hash)
+	assert.Equal(common.JSONUint64(1), blockEvent.Height)
+	assert.Equal([]common.Hash{txHash}, blockEvent.TxHashes)
+
+	txEvents <- &mempool.TxEvent{Type: mempool.TxEventInserted, Address: address, TxHash: txHash, RawTx: rawTx}
+	pendingEvent := &PendingTxEvent{}
+	assert.Equal(pendingSub, client.receiveNotification(pendingEvent))
+	assert.Equal(PendingTxEvent{Status: "inserted", TxHash: txHash, Sender: address}, *pendingEvent)
+	activityEvent := &AddressActivityEvent{}
+	assert.Equal(addressSub, client.receiveNotification(activityEvent))
+	assert.Equal("pending", activityEvent.Status)
+	assert.Equal(recipient, activityEvent.Address)
+	assert.Equal(txHash, activityEvent.TxHash)
+	assert.Equal(TxTypeSend, activityEvent.TxType)
+	assert.Nil(activityEvent.BlockHash)
+
+	// The ancestors of the directly finalized block are published first
+	finalized <- b2
+	for _, block := range []*core.Block{b1, b2} {
+		blockEvent = &BlockEvent{}
+		assert.Equal(finalizedSub, client.receiveNotification(blockEvent))
+		assert.Equal(block.Hash(), blockEvent.Hash)
+		if block == b1 {
+			activityEvent = &AddressActivityEvent{}
+			assert.Equal(addressSub, client.receiveNotification(activityEvent))
+			assert.Equal("finalized", activityEvent.Status)
+			assert.Equal(b1.Hash(), *activityEvent.BlockHash)
+			assert.Equal(common.JSONUint64(1), *activityEvent.BlockHeight)
+		}
+	}
+
+	// The removals are only published to the pendingTx topic, and the unsubscribed topics are not
+	// published anymore
+	msg := client.call("unsubscribe", map[string]string{"subscription": newBlockSub})
+	require.Nil(msg.Error)
+	assert.Equal("true", string(msg.Result))
+	validated <- b2
+	txEvents <- &mempool.TxEvent{Type: mempool.TxEventCommitted, Address: address, TxHash: txHash, RawTx: rawTx}
+	assert.Equal(pendingSub, client.receiveNotification(pendingEvent))
+	assert.Equal("committed", pendingEvent.Status)
+
+	// The connections are closed when the hub stops
+	cancel()
+	var raw string
+	assert.NotNil(websocket.Message.Receive(ws, &raw))
+}
+
+func TestSubscriptionsLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	hub := newSubscriptionHub(nil)
+	c := &subscriptionConn{mu: &sync.Mutex{}, subs: make(map[string]*subscription)}
+	for i := 0; i < maxSubscriptionsPerConn; i++ {
+		_, err := hub.subscribe(c, SubscribeArgs{Topic: TopicNewBlock})
+		assert.Nil(err, fmt.Sprintf("subscription %v", i))
+	}
+	_, err := hub.subscribe(c, SubscribeArgs{Topic: TopicNewBlock})
+	assert.NotNil(err)
+}
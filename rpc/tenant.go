@@ -0,0 +1,452 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/ratelimit"
+	"github.com/thetatoken/theta/rpc/lib/rpc-codec/jsonrpc2"
+	"golang.org/x/net/websocket"
+)
+
+// APIKeyHeader is the HTTP header carrying the API key of a tenant. Websocket clients which cannot
+// set headers can pass the key in the api_key query parameter instead.
+const APIKeyHeader = "X-API-Key"
+
+const apiKeyQueryParam = "api_key"
+
+// maxRequestBodySize is the max size of the body of an HTTP RPC request
+const maxRequestBodySize = 16 << 20
+
+var (
+	errCodeUnauthorized  = -32003
+	errCodeLimitExceeded = -32005
+	errCodeAuditFailed   = -32006
+)
+
+// heavyMethods are the RPC methods expensive enough to have their own quota
+var heavyMethods = map[string]bool{
+	"theta.BackupSnapshot":               true,
+	"theta.BackupChain":                  true,
+	"theta.BroadcastRawTransactionBatch": true,
+	"theta.CallSmartContract":            true,
+	"theta.CaptureProfile":               true,
+	"theta.DryRunProposal":               true,
+}
+
+// adminMethods can only be called by the admin tenants, so they are disabled unless an admin tenant
+// is configured
+var adminMethods = map[string]bool{
+	"theta.GetTenantUsage": true,
+	"theta.DryRunProposal": true,
+	"theta.GetAuditLog":    true,
+}
+
+// TenantConfig is the configuration of an API tenant, see common.CfgRPCTenants.
+type TenantConfig struct {
+	Name                   string   `mapstructure:"name"`
+	APIKey                 string   `mapstructure:"apiKey"`
+	Hosts                  []string `mapstructure:"hosts"`
+	Admin                  bool     `mapstructure:"admin"`
+	RequestsPerSecond      int      `mapstructure:"requestsPerSecond"`
+	MaxSubscriptions       int      `mapstructure:"maxSubscriptions"`
+	HeavyRequestsPerMinute int      `mapstructure:"heavyRequestsPerMinute"`
+}
+
+// TenantUsage is the usage accounting of an API tenant since the node started.
+type TenantUsage struct {
+	Name                string            `json:"name"`
+	Requests            uint64            `json:"requests"`
+	HeavyRequests       uint64            `json:"heavy_requests"`
+	RejectedRequests    uint64            `json:"rejected_requests"`
+	ActiveSubscriptions int               `json:"active_subscriptions"`
+	Methods             map[string]uint64 `json:"methods"`
+}
+
+type tenant struct {
+	config        TenantConfig
+	requests      *ratelimit.TokenBucket
+	heavyRequests *ratelimit.TokenBucket
+	usage         TenantUsage
+}
+
+// TenantManager identifies the tenant of the RPC requests, enforces the quotas of the tenants, and
+// keeps track of their usage. Requests which do not belong to any tenant are not limited, unless
+// common.CfgRPCRequireAPIKey is set, in which case they are rejected.
+type TenantManager struct {
+	mu *sync.Mutex
+
+	tenants       []*tenant
+	byAPIKey      map[string]*tenant
+	byHost        map[string]*tenant
+	requireAPIKey bool
+	auditLog      *AuditLog
+}
+
+// NewTenantManager creates a new instance of TenantManager.
+func NewTenantManager(configs []TenantConfig, requireAPIKey bool) (*TenantManager, error) {
+	m := &TenantManager{
+		mu:            &sync.Mutex{},
+		tenants:       []*tenant{},
+		byAPIKey:      make(map[string]*tenant),
+		byHost:        make(map[string]*tenant),
+		requireAPIKey: requireAPIKey,
+	}
+	names := make(map[string]bool)
+	for _, config := range configs {
+		if len(config.Name) == 0 {
+			return nil, fmt.Errorf("Tenant name is missing")
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("Duplicated tenant %v", config.Name)
+		}
+		names[config.Name] = true
+		if len(config.APIKey) == 0 {
+			return nil, fmt.Errorf("API key of tenant %v is missing", config.Name)
+		}
+		if _, ok := m.byAPIKey[config.APIKey]; ok {
+			return nil, fmt.Errorf("API key of tenant %v is used by another tenant", config.Name)
+		}
+		if config.RequestsPerSecond < 0 || config.MaxSubscriptions < 0 || config.HeavyRequestsPerMinute < 0 {
+			return nil, fmt.Errorf("Quotas of tenant %v cannot be negative", config.Name)
+		}
+
+		t := &tenant{
+			config:        config,
+			requests:      ratelimit.NewTokenBucket(float64(config.RequestsPerSecond), time.Second, time.Now()),
+			heavyRequests: ratelimit.NewTokenBucket(float64(config.HeavyRequestsPerMinute), time.Minute, time.Now()),
+			usage: TenantUsage{
+				Name:    config.Name,
+				Methods: make(map[string]uint64),
+			},
+		}
+		// The Host header is set by the client, so it cannot grant the admin rights
+		if config.Admin && len(config.Hosts) > 0 {
+			return nil, fmt.Errorf("Admin tenant %v can only be identified by its API key, not by hosts", config.Name)
+		}
+		m.tenants = append(m.tenants, t)
+		m.byAPIKey[config.APIKey] = t
+		for _, host := range config.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := m.byHost[host]; ok {
+				return nil, fmt.Errorf("Host %v is used by both tenant %v and %v", host, other.config.Name, config.Name)
+			}
+			m.byHost[host] = t
+		}
+	}
+	return m, nil
+}
+
+// NewTenantManagerFromConfig creates a TenantManager from common.CfgRPCTenants.
+func NewTenantManagerFromConfig() (*TenantManager, error) {
+	configs := []TenantConfig{}
+	if err := viper.UnmarshalKey(common.CfgRPCTenants, &configs); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", common.CfgRPCTenants, err)
+	}
+	return NewTenantManager(configs, viper.GetBool(common.CfgRPCRequireAPIKey))
+}
+
+// identify returns the tenant of the request, or nil if the request does not belong to any tenant.
+func (m *TenantManager) identify(req *http.Request) (*tenant, *jsonrpc2.Error) {
+	key := req.Header.Get(APIKeyHeader)
+	if len(key) == 0 {
+		key = req.URL.Query().Get(apiKeyQueryParam)
+	}
+	return m.identifyByKeyOrHost(key, req.Host)
+}
+
+// identifyByKeyOrHost returns the tenant of the API key, or of the host if no key is given.
+func (m *TenantManager) identifyByKeyOrHost(key, host string) (*tenant, *jsonrpc2.Error) {
+	if len(key) != 0 {
+		t, ok := m.byAPIKey[key]
+		if !ok {
+			return nil, jsonrpc2.NewError(errCodeUnauthorized, "Invalid API key")
+		}
+		return t, nil
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if t, ok := m.byHost[strings.ToLower(host)]; ok {
+		return t, nil
+	}
+
+	if m.requireAPIKey {
+		return nil, jsonrpc2.NewError(errCodeUnauthorized, "API key is required")
+	}
+	return nil, nil
+}
+
+// hasAdminTenant returns whether an admin tenant is configured, which the admin methods require.
+func (m *TenantManager) hasAdminTenant() bool {
+	if m == nil {
+		return false
+	}
+	for _, t := range m.tenants {
+		if t.config.Admin {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAuditLog sets the audit log recording the invocations of the audited methods.
+func (m *TenantManager) SetAuditLog(auditLog *AuditLog) {
+	m.auditLog = auditLog
+}
+
+// admit checks the calls of a request against the quotas of the tenant and records the usage. The
+// calls of a batch request are admitted or rejected together. The audited calls are recorded in
+// the audit log whether they are admitted or not.
+func (m *TenantManager) admit(t *tenant, req *http.Request, calls []rpcCall) *jsonrpc2.Error {
+	rpcErr := m.checkQuotas(t, calls)
+	return m.auditLog.record(t, req, calls, rpcErr)
+}
+
+func (m *TenantManager) checkQuotas(t *tenant, calls []rpcCall) *jsonrpc2.Error {
+	numHeavy := 0
+	for _, call := range calls {
+		if heavyMethods[call.Method] {
+			numHeavy++
+		}
+		if adminMethods[call.Method] && (t == nil || !t.config.Admin) {
+			return jsonrpc2.NewError(errCodeUnauthorized, fmt.Sprintf("Method %v requires an admin API key", call.Method))
+		}
+	}
+	if t == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if !t.requests.Available(len(calls), now) || !t.heavyRequests.Available(numHeavy, now) {
+		t.usage.RejectedRequests += uint64(len(calls))
+		return jsonrpc2.NewError(errCodeLimitExceeded, fmt.Sprintf("Rate limit exceeded for tenant %v", t.config.Name))
+	}
+	t.requests.Take(len(calls))
+	t.heavyRequests.Take(numHeavy)
+	t.usage.Requests += uint64(len(calls))
+	t.usage.HeavyRequests += uint64(numHeavy)
+	for _, call := range calls {
+		t.usage.Methods[call.Method]++
+	}
+	return nil
+}
+
+func (m *TenantManager) openSubscription(t *tenant) *jsonrpc2.Error {
+	if t == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if t.config.MaxSubscriptions > 0 && t.usage.ActiveSubscriptions >= t.config.MaxSubscriptions {
+		return jsonrpc2.NewError(errCodeLimitExceeded, fmt.Sprintf("Subscription limit exceeded for tenant %v", t.config.Name))
+	}
+	t.usage.ActiveSubscriptions++
+	return nil
+}
+
+func (m *TenantManager) closeSubscription(t *tenant) {
+	if t == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t.usage.ActiveSubscriptions--
+}
+
+// Usage returns the usage of the tenants, sorted by name.
+func (m *TenantManager) Usage() []TenantUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usages := []TenantUsage{}
+	for _, t := range m.tenants {
+		usage := t.usage
+		usage.Methods = make(map[string]uint64)
+		for method, count := range t.usage.Methods {
+			usage.Methods[method] = count
+		}
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Name < usages[j].Name })
+	return usages
+}
+
+// HTTPHandler enforces the quotas of the tenants on the JSON RPC requests before passing them to next.
+func (m *TenantManager) HTTPHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		t, rpcErr := m.identify(req)
+		if rpcErr != nil {
+			writeRPCErrors(w, http.StatusUnauthorized, []rpcCall{{}}, rpcErr)
+			return
+		}
+		if req.Method != "POST" {
+			next.ServeHTTP(w, req)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxRequestBodySize))
+		if err != nil {
+			if len(body) >= maxRequestBodySize {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			return
+		}
+		calls := parseRPCCalls(body)
+		if rpcErr := m.admit(t, req, calls); rpcErr != nil {
+			status := http.StatusTooManyRequests
+			if rpcErr.Code == errCodeUnauthorized {
+				status = http.StatusForbidden
+			}
+			writeRPCErrors(w, status, calls, rpcErr)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, req)
+	})
+}
+
+// WebsocketConn returns a connection which enforces the quotas of the tenant of the websocket on
+// each message. A websocket connection counts as a subscription of the tenant until it is closed.
+func (m *TenantManager) WebsocketConn(ws *websocket.Conn) (*TenantConn, error) {
+	t, rpcErr := m.identify(ws.Request())
+	if rpcErr == nil {
+		rpcErr = m.openSubscription(t)
+	}
+	if rpcErr != nil {
+		websocket.Message.Send(ws, string(encodeRPCErrors([]rpcCall{{}}, rpcErr)))
+		return nil, rpcErr
+	}
+	return &TenantConn{
+		ws:      ws,
+		manager: m,
+		tenant:  t,
+		pending: &bytes.Buffer{},
+	}, nil
+}
+
+// TenantConn is a websocket connection of a tenant, see TenantManager.WebsocketConn.
+type TenantConn struct {
+	ws      *websocket.Conn
+	manager *TenantManager
+	tenant  *tenant
+	pending *bytes.Buffer
+	closed  bool
+}
+
+// Read returns the admitted messages, the rejected ones are answered with an error directly.
+func (c *TenantConn) Read(buf []byte) (int, error) {
+	for c.pending.Len() == 0 {
+		var msg []byte
+		if err := websocket.Message.Receive(c.ws, &msg); err != nil {
+			return 0, err
+		}
+		calls := parseRPCCalls(msg)
+		if rpcErr := c.manager.admit(c.tenant, c.ws.Request(), calls); rpcErr != nil {
+			if err := websocket.Message.Send(c.ws, string(encodeRPCErrors(calls, rpcErr))); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		c.pending.Write(msg)
+	}
+	return c.pending.Read(buf)
+}
+
+func (c *TenantConn) Write(buf []byte) (int, error) {
+	return c.ws.Write(buf)
+}
+
+func (c *TenantConn) Close() error {
+	if !c.closed {
+		c.closed = true
+		c.manager.closeSubscription(c.tenant)
+	}
+	return c.ws.Close()
+}
+
+// rpcCall is the part of a JSON RPC call needed to enforce the quotas and audit the call
+type rpcCall struct {
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+	ID     *json.RawMessage `json:"id"`
+}
+
+// parseRPCCalls returns the calls of a single or batch request. Malformed requests count as one
+// call, the RPC server replies to them with the parse error.
+func parseRPCCalls(body []byte) []rpcCall {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		calls := []rpcCall{}
+		if err := json.Unmarshal(trimmed, &calls); err == nil && len(calls) > 0 {
+			return calls
+		}
+		return []rpcCall{{}}
+	}
+	call := rpcCall{}
+	json.Unmarshal(trimmed, &call)
+	return []rpcCall{call}
+}
+
+type rpcErrorResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *jsonrpc2.Error  `json:"error"`
+}
+
+func encodeRPCErrors(calls []rpcCall, rpcErr *jsonrpc2.Error) []byte {
+	responses := []rpcErrorResponse{}
+	for _, call := range calls {
+		id := call.ID
+		if id == nil {
+			null := json.RawMessage("null")
+			id = &null
+		}
+		responses = append(responses, rpcErrorResponse{Version: "2.0", ID: id, Error: encodeError(rpcErr)})
+	}
+	var raw []byte
+	if len(responses) == 1 {
+		raw, _ = json.Marshal(responses[0])
+	} else {
+		raw, _ = json.Marshal(responses)
+	}
+	return raw
+}
+
+func writeRPCErrors(w http.ResponseWriter, status int, calls []rpcCall, rpcErr *jsonrpc2.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encodeRPCErrors(calls, rpcErr))
+}
+
+// ------------------------------ GetTenantUsage -----------------------------------
+
+type GetTenantUsageArgs struct{}
+
+type GetTenantUsageResult struct {
+	Tenants []TenantUsage `json:"tenants"`
+}
+
+func (t *ThetaRPCService) GetTenantUsage(args *GetTenantUsageArgs, result *GetTenantUsageResult) (err error) {
+	result.Tenants = t.tenants.Usage()
+	return nil
+}
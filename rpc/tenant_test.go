@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+)
+
+func newTestTenantManager(t *testing.T, requireAPIKey bool) *TenantManager {
+	m, err := NewTenantManager([]TenantConfig{
+		{Name: "acme", APIKey: "acme-key", Hosts: []string{"rpc.acme.com"}, RequestsPerSecond: 3, HeavyRequestsPerMinute: 1, MaxSubscriptions: 1},
+		{Name: "ops", APIKey: "ops-key", Admin: true},
+	}, requireAPIKey)
+	require.Nil(t, err)
+	return m
+}
+
+func sendTestRPCRequest(handler http.Handler, host, apiKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "http://"+host+"/rpc", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if len(apiKey) > 0 {
+		req.Header.Set(APIKeyHeader, apiKey)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestTenantManagerConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewTenantManager([]TenantConfig{{Name: "a", APIKey: "k"}, {Name: "b", APIKey: "k"}}, false)
+	assert.NotNil(err)
+	_, err = NewTenantManager([]TenantConfig{{Name: "a"}}, false)
+	assert.NotNil(err)
+	_, err = NewTenantManager([]TenantConfig{{Name: "a", APIKey: "k1", Hosts: []string{"a.com"}}, {Name: "b", APIKey: "k2", Hosts: []string{"A.com"}}}, false)
+	assert.NotNil(err)
+	_, err = NewTenantManager([]TenantConfig{{Name: "a", APIKey: "k", Hosts: []string{"a.com"}, Admin: true}}, false)
+	assert.NotNil(err) // the admin tenants are only identified by their API key
+
+	viper.Set(common.CfgRPCTenants, []interface{}{
+		map[string]interface{}{"name": "acme", "apiKey": "acme-key", "hosts": []interface{}{"rpc.acme.com"}, "requestsPerSecond": 10},
+	})
+	defer viper.Set(common.CfgRPCTenants, []interface{}{})
+	m, err := NewTenantManagerFromConfig()
+	assert.Nil(err)
+	assert.Equal(10, m.byAPIKey["acme-key"].config.RequestsPerSecond)
+	assert.Equal("acme", m.byHost["rpc.acme.com"].config.Name)
+}
+
+func TestTenantQuotas(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestTenantManager(t, false)
+	served := 0
+	handler := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		served++
+	}))
+
+	// Requests without a tenant are not limited
+	for i := 0; i < 5; i++ {
+		assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "localhost:16888", "", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":1}`).Code)
+	}
+	assert.Equal(http.StatusUnauthorized, sendTestRPCRequest(handler, "localhost", "bad-key", `{}`).Code)
+
+	// The tenant is identified by the virtual host or the API key
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "rpc.acme.com", "", `{"jsonrpc":"2.0","method":"theta.CallSmartContract","params":[{}],"id":1}`).Code)
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "localhost", "acme-key", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":2}`).Code)
+
+	// Heavy request quota exhausted
+	res := sendTestRPCRequest(handler, "localhost", "acme-key", `{"jsonrpc":"2.0","method":"theta.BackupChain","params":[{}],"id":3}`)
+	assert.Equal(http.StatusTooManyRequests, res.Code)
+	rpcRes := rpcErrorResponse{}
+	assert.Nil(json.Unmarshal(res.Body.Bytes(), &rpcRes))
+	assert.Equal(errCodeLimitExceeded, rpcRes.Error.Code)
+	assert.Equal("3", string(*rpcRes.ID))
+
+	// The calls of a batch are admitted together
+	res = sendTestRPCRequest(handler, "localhost", "acme-key", `[{"jsonrpc":"2.0","method":"theta.GetStatus","id":4},{"jsonrpc":"2.0","method":"theta.GetStatus","id":5}]`)
+	assert.Equal(http.StatusTooManyRequests, res.Code)
+	rpcResponses := []rpcErrorResponse{}
+	assert.Nil(json.Unmarshal(res.Body.Bytes(), &rpcResponses))
+	assert.Equal(2, len(rpcResponses))
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "localhost", "acme-key", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":6}`).Code)
+	assert.Equal(http.StatusTooManyRequests, sendTestRPCRequest(handler, "localhost", "acme-key", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":7}`).Code)
+	assert.Equal(8, served)
+
+	// Admin methods require an admin tenant
+	assert.Equal(http.StatusForbidden, sendTestRPCRequest(handler, "localhost", "", `{"jsonrpc":"2.0","method":"theta.GetTenantUsage","params":[{}],"id":1}`).Code)
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "localhost", "ops-key", `{"jsonrpc":"2.0","method":"theta.GetTenantUsage","params":[{}],"id":1}`).Code)
+
+	usage := m.Usage()
+	assert.Equal(2, len(usage))
+	assert.Equal("acme", usage[0].Name)
+	assert.Equal(uint64(3), usage[0].Requests)
+	assert.Equal(uint64(1), usage[0].HeavyRequests)
+	assert.Equal(uint64(4), usage[0].RejectedRequests)
+	assert.Equal(uint64(2), usage[0].Methods["theta.GetStatus"])
+	assert.Equal(uint64(1), usage[1].Requests)
+
+	// Subscriptions
+	acme := m.byAPIKey["acme-key"]
+	assert.Nil(m.openSubscription(acme))
+	assert.NotNil(m.openSubscription(acme))
+	m.closeSubscription(acme)
+	assert.Nil(m.openSubscription(acme))
+}
+
+func TestTenantRequireAPIKey(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestTenantManager(t, true)
+	handler := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	assert.Equal(http.StatusUnauthorized, sendTestRPCRequest(handler, "localhost", "", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":1}`).Code)
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "rpc.acme.com:16888", "", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":1}`).Code)
+}
+
+func TestTenantAdminMethodsWithoutTenants(t *testing.T) {
+	assert := assert.New(t)
+
+	m, err := NewTenantManager([]TenantConfig{}, false)
+	require.Nil(t, err)
+	handler := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	assert.Equal(http.StatusOK, sendTestRPCRequest(handler, "localhost", "", `{"jsonrpc":"2.0","method":"theta.GetStatus","params":[{}],"id":1}`).Code)
+	assert.Equal(http.StatusForbidden, sendTestRPCRequest(handler, "localhost", "", `{"jsonrpc":"2.0","method":"theta.DryRunProposal","params":[{}],"id":1}`).Code)
+
+	// Nor can the method be called directly
+	service := &ThetaRPCService{tenants: m}
+	assert.NotNil(service.DryRunProposal(&DryRunProposalArgs{}, &DryRunProposalResult{}))
+	assert.True(newTestTenantManager(t, false).hasAdminTenant())
+}
+
+func TestTenantMaxRequestBodySize(t *testing.T) {
+	assert := assert.New(t)
+
+	m := newTestTenantManager(t, false)
+	handler := m.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	body := `{"jsonrpc":"2.0","method":"theta.GetStatus","params":["` + strings.Repeat("a", maxRequestBodySize) + `"],"id":1}`
+	assert.Equal(http.StatusRequestEntityTooLarge, sendTestRPCRequest(handler, "localhost", "", body).Code)
+}
@@ -0,0 +1,173 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/vm"
+)
+
+const (
+	// DefaultTraceLimit is the max number of steps recorded by TraceTransaction by default
+	DefaultTraceLimit = 10000
+
+	// MaxTraceLimit is the max number of steps TraceTransaction can record
+	MaxTraceLimit = 100000
+)
+
+// ------------------------------ TraceTransaction -----------------------------------
+
+// TraceConfig selects what the trace records at each step.
+type TraceConfig struct {
+	DisableStack   bool              `json:"disable_stack"`
+	DisableMemory  bool              `json:"disable_memory"`
+	DisableStorage bool              `json:"disable_storage"`
+	Limit          common.JSONUint64 `json:"limit"` // max number of steps, DefaultTraceLimit if 0
+}
+
+type TraceTransactionArgs struct {
+	Hash   string      `json:"hash"`
+	Config TraceConfig `json:"config"`
+}
+
+// TraceStep is the state of the VM before a step of the execution.
+type TraceStep struct {
+	Pc      common.JSONUint64           `json:"pc"`
+	Op      string                      `json:"op"`
+	Gas     common.JSONUint64           `json:"gas"`
+	GasCost common.JSONUint64           `json:"gas_cost"`
+	Depth   int                         `json:"depth"`
+	Stack   []*common.JSONBig           `json:"stack,omitempty"`
+	Memory  common.Bytes                `json:"memory,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"` // the slots written so far by the contract
+	Error   string                      `json:"error,omitempty"`
+}
+
+// TraceCall is a message call or a contract creation made by a contract.
+type TraceCall struct {
+	Type   string            `json:"type"`
+	From   common.Address    `json:"from"`
+	To     common.Address    `json:"to"`
+	Value  *common.JSONBig   `json:"value"`
+	Gas    common.JSONUint64 `json:"gas"`
+	Input  common.Bytes      `json:"input"`
+	Depth  int               `json:"depth"`
+	Failed bool              `json:"failed"`
+}
+
+type TraceTransactionResult struct {
+	TxHash          common.Hash       `json:"hash"`
+	BlockHash       common.Hash       `json:"block_hash"`
+	BlockHeight     common.JSONUint64 `json:"block_height"`
+	GasUsed         common.JSONUint64 `json:"gas_used"`
+	Failed          bool              `json:"failed"`
+	VmError         string            `json:"vm_error"`
+	ReturnValue     common.Bytes      `json:"return_value"`
+	ContractAddress common.Address    `json:"contract_address"`
+	Truncated       bool              `json:"truncated"` // the steps beyond the limit are not recorded
+	Steps           []*TraceStep      `json:"steps"`
+	Calls           []*TraceCall      `json:"calls"`
+}
+
+// TraceTransaction re-executes a finalized smart contract transaction on the state preceding it,
+// recording the steps of the execution and the internal calls. It lets contract developers debug
+// the failed calls.
+func (t *ThetaRPCService) TraceTransaction(args *TraceTransactionArgs, result *TraceTransactionResult) (err error) {
+	if args.Hash == "" {
+		return errors.New("Transaction hash must be specified")
+	}
+	hash := common.HexToHash(args.Hash)
+
+	limit := int(args.Config.Limit)
+	if limit == 0 {
+		limit = DefaultTraceLimit
+	}
+	if limit > MaxTraceLimit {
+		return fmt.Errorf("The trace limit cannot exceed %v steps", MaxTraceLimit)
+	}
+
+	_, block, found := t.chain.FindTxByHash(hash)
+	if !found {
+		return fmt.Errorf("Transaction %v is not found", hash.Hex())
+	}
+	if !block.Status.IsFinalized() {
+		return fmt.Errorf("Transaction %v is not finalized yet", hash.Hex())
+	}
+	index := -1
+	for i, tx := range block.Txs {
+		if crypto.Keccak256Hash(tx) == hash {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("Transaction %v is not found in block %v", hash.Hex(), block.Hash().Hex())
+	}
+
+	tracer := vm.NewExecutionTracer(&vm.LogConfig{
+		DisableStack:   args.Config.DisableStack,
+		DisableMemory:  args.Config.DisableMemory,
+		DisableStorage: args.Config.DisableStorage,
+		Limit:          limit + 1, // one more step to tell whether the trace is truncated
+	})
+	traced, res := t.ledger.TraceTx(block.Block, index, tracer)
+	if res.IsError() {
+		return newRPCError(res)
+	}
+
+	result.TxHash = hash
+	result.BlockHash = block.Hash()
+	result.BlockHeight = common.JSONUint64(block.Height)
+	result.GasUsed = common.JSONUint64(traced.GasUsed)
+	result.Failed = traced.VMError != nil
+	if traced.VMError != nil {
+		result.VmError = traced.VMError.Error()
+	}
+	result.ReturnValue = traced.ReturnValue
+	result.ContractAddress = traced.ContractAddress
+
+	logs := tracer.StructLogs()
+	if len(logs) > limit {
+		logs = logs[:limit]
+		result.Truncated = true
+	}
+	result.Steps = []*TraceStep{}
+	for _, log := range logs {
+		result.Steps = append(result.Steps, newTraceStep(&log))
+	}
+	result.Calls = []*TraceCall{}
+	for _, call := range tracer.Calls() {
+		result.Calls = append(result.Calls, &TraceCall{
+			Type:   call.Type.String(),
+			From:   call.From,
+			To:     call.To,
+			Value:  (*common.JSONBig)(call.Value),
+			Gas:    common.JSONUint64(call.Gas),
+			Input:  call.Input,
+			Depth:  call.Depth,
+			Failed: call.Failed,
+		})
+	}
+	return nil
+}
+
+// ------------------------------ Utils ------------------------------
+
+func newTraceStep(log *vm.StructLog) *TraceStep {
+	step := &TraceStep{
+		Pc:      common.JSONUint64(log.Pc),
+		Op:      log.OpName(),
+		Gas:     common.JSONUint64(log.Gas),
+		GasCost: common.JSONUint64(log.GasCost),
+		Depth:   log.Depth,
+		Memory:  log.Memory,
+		Storage: log.Storage,
+		Error:   log.ErrorString(),
+	}
+	for _, item := range log.Stack {
+		step.Stack = append(step.Stack, (*common.JSONBig)(item))
+	}
+	return step
+}
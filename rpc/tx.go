@@ -3,17 +3,26 @@ package rpc
 import (
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/common/hexutil"
+	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/mempool"
 )
 
 const txTimeout = 60 * time.Second
 
+// Interval at which a synchronous broadcast checks whether its transaction expired
+const txExpiryCheckInterval = 1 * time.Second
+
+// Number of transaction replacements buffered for a synchronous broadcast
+const replacedTxsBufferSize = 64
+
 type Callback struct {
 	txHash   string
 	created  time.Time
@@ -80,17 +89,23 @@ func (m *TxCallbackManager) Trim() {
 
 var txCallbackManager = NewTxCallbackManager()
 
+// Number of finalized blocks buffered for the tx callbacks
+const finalizedBlocksBufferSize = 512
+
 func (t *ThetaRPCService) txCallback() {
 	defer t.wg.Done()
 
 	timer := time.NewTicker(1 * time.Second)
 	defer timer.Stop()
 
+	finalized := t.finality.SubscribeFinalizedBlocks(finalizedBlocksBufferSize)
+	defer finalized.Unsubscribe()
+
 	for {
 		select {
 		case <-t.ctx.Done():
 			return
-		case block := <-t.consensus.FinalizedBlocks():
+		case block := <-finalized.C:
 			for _, tx := range block.Txs {
 				txHash := crypto.Keccak256Hash(tx)
 				cb, ok := txCallbackManager.RemoveCallback(txHash)
@@ -98,6 +113,7 @@ func (t *ThetaRPCService) txCallback() {
 					cb.Callback(block)
 				}
 			}
+			t.checkPaymentSessions()
 		case <-timer.C:
 			txCallbackManager.Trim()
 		}
@@ -116,20 +132,23 @@ type BroadcastRawTransactionResult struct {
 }
 
 func (t *ThetaRPCService) BroadcastRawTransaction(
-	args *BroadcastRawTransactionArgs, result *BroadcastRawTransactionResult) (err error) {
+	args *BroadcastRawTransactionArgs, reply *BroadcastRawTransactionResult) (err error) {
 	txBytes, err := decodeTxHexBytes(args.TxBytes)
 	if err != nil {
 		return err
 	}
 
 	hash := crypto.Keccak256Hash(txBytes)
-	result.TxHash = hash.Hex()
+	reply.TxHash = hash.Hex()
 
 	logger.Infof("Broadcast raw transaction (sync): %v, hash: %v", hex.EncodeToString(txBytes), hash.Hex())
 
-	err = t.mempool.InsertTransaction(txBytes)
-	if err != nil {
-		return err
+	// Subscribe before the insertion, so that no replacement of the transaction is missed
+	replacements := t.mempool.SubscribeReplacedTxs(replacedTxsBufferSize)
+	defer replacements.Unsubscribe()
+
+	if res := insertTxResult(t.mempool.InsertTransaction(txBytes)); res.IsError() {
+		return newRPCError(res)
 	}
 
 	finalized := make(chan *core.Block)
@@ -140,12 +159,33 @@ func (t *ThetaRPCService) BroadcastRawTransaction(
 		finalized <- block
 	})
 
-	select {
-	case block := <-finalized:
-		result.Block = block.BlockHeader
-		return nil
-	case <-timeout.C:
-		return errors.New("Timed out waiting for transaction to be included")
+	// Poll the mempool, so that the submitter learns right away that the transaction expired
+	// before being included, and can safely re-sign it
+	expiryCheck := time.NewTicker(txExpiryCheckInterval)
+	defer expiryCheck.Stop()
+
+	for {
+		select {
+		case block := <-finalized:
+			reply.Block = block.BlockHeader
+			return nil
+		case replacement := <-replacements.C:
+			if replacement.ReplacedTxHash == hash {
+				txCallbackManager.RemoveCallback(hash)
+				res := result.Error("Transaction replaced by transaction %v with a higher fee", replacement.ReplacementTxHash.Hex())
+				res.Info["replacement_tx_hash"] = replacement.ReplacementTxHash.Hex()
+				return newRPCError(res.WithErrorCode(result.CodeTxReplaced))
+			}
+		case <-expiryCheck.C:
+			if txStatus, exists := t.mempool.GetTransactionStatus(hex.EncodeToString(hash[:])); exists && txStatus == mempool.TxStatusExpired {
+				txCallbackManager.RemoveCallback(hash)
+				return newRPCError(result.Error("Transaction expired before being included, and was dropped from the mempool").
+					WithErrorCode(result.CodeTxExpired))
+			}
+		case <-timeout.C:
+			return newRPCError(result.Error("Timed out waiting for transaction to be included").
+				WithErrorCode(result.CodeTxTimeout))
+		}
 	}
 }
 
@@ -171,11 +211,114 @@ func (t *ThetaRPCService) BroadcastRawTransactionAsync(
 
 	logger.Infof("Broadcast raw transaction (async): %v, hash: %v", hex.EncodeToString(txBytes), hash.Hex())
 
-	return t.mempool.InsertTransaction(txBytes)
+	if res := insertTxResult(t.mempool.InsertTransaction(txBytes)); res.IsError() {
+		return newRPCError(res)
+	}
+	return nil
+}
+
+// ------------------------------- BroadcastRawTransactionBatch -----------------------------------
+
+// MaxBroadcastBatchSize is the max number of transactions broadcasted by a batch call
+const MaxBroadcastBatchSize = 1000
+
+type BroadcastRawTransactionBatchArgs struct {
+	TxBytes []string `json:"tx_bytes"`
+}
+
+type BroadcastRawTransactionBatchEntry struct {
+	TxHash  string           `json:"hash"`
+	Code    result.ErrorCode `json:"code"`
+	Message string           `json:"message"`
+}
+
+type BroadcastRawTransactionBatchResult struct {
+	Results []BroadcastRawTransactionBatchEntry `json:"results"`
+}
+
+// BroadcastRawTransactionBatch inserts the transactions into the mempool in order, without waiting
+// for them to be included. A rejected transaction does not affect the others, the result of each
+// transaction is reported at its index.
+func (t *ThetaRPCService) BroadcastRawTransactionBatch(
+	args *BroadcastRawTransactionBatchArgs, res *BroadcastRawTransactionBatchResult) (err error) {
+	if len(args.TxBytes) == 0 {
+		return errors.New("No transaction to broadcast")
+	}
+	if len(args.TxBytes) > MaxBroadcastBatchSize {
+		return fmt.Errorf("Too many transactions: %v, at most %v transactions can be broadcasted per batch",
+			len(args.TxBytes), MaxBroadcastBatchSize)
+	}
+
+	logger.Infof("Broadcast raw transaction batch, size: %v", len(args.TxBytes))
+
+	res.Results = make([]BroadcastRawTransactionBatchEntry, len(args.TxBytes))
+	for i, txHex := range args.TxBytes {
+		entry := &res.Results[i]
+		txBytes, err := decodeTxHexBytes(txHex)
+		if err != nil {
+			entry.Code = result.CodeGenericError
+			entry.Message = fmt.Sprintf("Failed to decode transaction: %v", err)
+			continue
+		}
+		entry.TxHash = crypto.Keccak256Hash(txBytes).Hex()
+		res := insertTxResult(t.mempool.InsertTransaction(txBytes))
+		entry.Code, entry.Message = res.Code, res.Message
+	}
+	return nil
+}
+
+// ------------------------------- WouldAccept -----------------------------------
+
+type WouldAcceptArgs struct {
+	TxBytes string `json:"tx_bytes"`
+}
+
+// WouldAcceptResult is the verdict of the mempool admission. Code and Name are the error code
+// and its symbolic name, result.CodeOK if the transaction would be accepted.
+type WouldAcceptResult struct {
+	TxHash   string           `json:"hash"`
+	Accepted bool             `json:"accepted"`
+	Code     result.ErrorCode `json:"code"`
+	Name     string           `json:"name"`
+	Message  string           `json:"message"`
+	Info     result.Info      `json:"info,omitempty"`
+}
+
+// WouldAccept runs the mempool admission checks (size, fee, sequence, balance, pending transaction
+// limits and fee replacement rules) on the signed transaction without broadcasting it, and reports
+// whether BroadcastRawTransaction would accept it, or the error it would return.
+func (t *ThetaRPCService) WouldAccept(args *WouldAcceptArgs, res *WouldAcceptResult) (err error) {
+	txBytes, err := decodeTxHexBytes(args.TxBytes)
+	if err != nil {
+		return err
+	}
+	res.TxHash = crypto.Keccak256Hash(txBytes).Hex()
+
+	verdict := insertTxResult(t.mempool.WouldAccept(txBytes))
+	res.Accepted = verdict.IsOK()
+	res.Code = verdict.Code
+	res.Name = verdict.Code.Name()
+	res.Message = verdict.Message
+	res.Info = verdict.Info
+	return nil
 }
 
 // -------------------------- Utilities -------------------------- //
 
+// insertTxResult converts the error returned by the mempool insertion into a result
+func insertTxResult(err error) result.Result {
+	if err == nil {
+		return result.OK
+	}
+	if err == mempool.DuplicateTxError {
+		return result.Error("%v", err).WithErrorCode(result.CodeDuplicateTx)
+	}
+	if screeningErr, ok := err.(mempool.ScreeningError); ok {
+		return screeningErr.Result
+	}
+	return result.Error("%v", err)
+}
+
 func decodeTxHexBytes(txBytes string) ([]byte, error) {
 	if hexutil.Has0xPrefix(txBytes) {
 		txBytes = txBytes[2:]
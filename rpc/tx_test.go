@@ -1,12 +1,15 @@
 package rpc
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/common/result"
 	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/mempool"
 )
 
 func TestTxCallbackManager(t *testing.T) {
@@ -55,3 +58,37 @@ func TestTxCallbackManager(t *testing.T) {
 	assert.Equal(1, len(m.txHashToCallback))
 	assert.Equal(1, len(m.callbacks))
 }
+
+func TestBroadcastRawTransactionBatchSize(t *testing.T) {
+	assert := assert.New(t)
+
+	service := &ThetaRPCService{}
+	res := &BroadcastRawTransactionBatchResult{}
+	assert.NotNil(service.BroadcastRawTransactionBatch(&BroadcastRawTransactionBatchArgs{}, res))
+
+	args := &BroadcastRawTransactionBatchArgs{TxBytes: make([]string, MaxBroadcastBatchSize+1)}
+	assert.NotNil(service.BroadcastRawTransactionBatch(args, res))
+	assert.Nil(res.Results)
+}
+
+func TestInsertTxResult(t *testing.T) {
+	assert := assert.New(t)
+
+	res := insertTxResult(nil)
+	assert.Equal(result.CodeOK, res.Code)
+	assert.Equal("", res.Message)
+
+	res = insertTxResult(mempool.DuplicateTxError)
+	assert.Equal(result.CodeDuplicateTx, res.Code)
+
+	screeningErr := mempool.ScreeningError{
+		Result: result.Error("Invalid sequence").WithErrorCode(result.CodeInvalidSequence),
+	}
+	res = insertTxResult(screeningErr)
+	assert.Equal(result.CodeInvalidSequence, res.Code)
+	assert.Equal("Invalid sequence", res.Message)
+
+	res = insertTxResult(errors.New("failure"))
+	assert.Equal(result.CodeGenericError, res.Code)
+	assert.Equal("failure", res.Message)
+}
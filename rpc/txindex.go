@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/txindex"
+)
+
+// DefaultTransactionsByAddressLimit is the number of transactions returned per page by default
+const DefaultTransactionsByAddressLimit = 100
+
+// SetTxIndexer sets the transaction index serving GetTransactionsByAddress, which fails if nil.
+func (t *ThetaRPCServer) SetTxIndexer(indexer *txindex.Indexer) {
+	t.txIndexer = indexer
+}
+
+// ------------------------------ GetTransactionsByAddress -----------------------------------
+
+type GetTransactionsByAddressArgs struct {
+	Address   string            `json:"address"`
+	Direction string            `json:"direction"` // "sent", "received", or both if empty
+	Cursor    string            `json:"cursor"`    // NextCursor of the previous page, the first page if empty
+	Limit     common.JSONUint64 `json:"limit"`     // DefaultTransactionsByAddressLimit if 0
+	Ascending bool              `json:"ascending"` // Oldest transactions first, most recent first otherwise
+}
+
+type AddressTransaction struct {
+	TxHash      common.Hash       `json:"hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Index       common.JSONUint64 `json:"index"` // Index of the transaction in the block
+	Sent        bool              `json:"sent"`
+	Received    bool              `json:"received"`
+}
+
+// GetTransactionsByAddressResult lists the transactions of the finalized blocks up to
+// IndexedHeight. NextCursor is empty on the last page.
+type GetTransactionsByAddressResult struct {
+	Transactions  []AddressTransaction `json:"transactions"`
+	NextCursor    string               `json:"next_cursor"`
+	IndexedHeight *common.JSONUint64   `json:"indexed_height"`
+}
+
+func (t *ThetaRPCService) GetTransactionsByAddress(args *GetTransactionsByAddressArgs,
+	result *GetTransactionsByAddressResult) (err error) {
+	if t.txIndexer == nil {
+		return errors.New("Transaction index is not enabled")
+	}
+	if !common.IsHexAddress(args.Address) {
+		return fmt.Errorf("Invalid address: %v", args.Address)
+	}
+	address := common.HexToAddress(args.Address)
+
+	var direction txindex.Direction
+	switch strings.ToLower(args.Direction) {
+	case "":
+		direction = txindex.DirectionAll
+	case "sent":
+		direction = txindex.DirectionSent
+	case "received":
+		direction = txindex.DirectionReceived
+	default:
+		return fmt.Errorf("Invalid direction: %v, must be sent, received or empty", args.Direction)
+	}
+
+	var after *txindex.Position
+	if args.Cursor != "" {
+		if after, err = decodeTxIndexCursor(args.Cursor); err != nil {
+			return err
+		}
+	}
+
+	limit := int(args.Limit)
+	if limit == 0 {
+		limit = DefaultTransactionsByAddressLimit
+	}
+	if uint64(args.Limit) > txindex.MaxLimit {
+		return fmt.Errorf("Limit must not exceed %v", txindex.MaxLimit)
+	}
+
+	// Read the height first, so that the entries cover at least the blocks up to it
+	if height, ok := t.txIndexer.IndexedHeight(); ok {
+		h := common.JSONUint64(height)
+		result.IndexedHeight = &h
+	}
+	entries, err := t.txIndexer.GetTransactions(address, direction, after, limit, args.Ascending)
+	if err != nil {
+		return err
+	}
+
+	result.Transactions = []AddressTransaction{}
+	for _, entry := range entries {
+		result.Transactions = append(result.Transactions, AddressTransaction{
+			TxHash:      entry.TxHash,
+			BlockHeight: common.JSONUint64(entry.Height),
+			Index:       common.JSONUint64(entry.Index),
+			Sent:        entry.Sent,
+			Received:    entry.Received,
+		})
+	}
+	if len(entries) == limit {
+		result.NextCursor = encodeTxIndexCursor(entries[len(entries)-1].Position)
+	}
+	return nil
+}
+
+// encodeTxIndexCursor encodes the position of a transaction as an opaque hex string.
+func encodeTxIndexCursor(pos txindex.Position) string {
+	raw := make([]byte, 12)
+	binary.BigEndian.PutUint64(raw, pos.Height)
+	binary.BigEndian.PutUint32(raw[8:], pos.Index)
+	return "0x" + hex.EncodeToString(raw)
+}
+
+func decodeTxIndexCursor(cursor string) (*txindex.Position, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(cursor, "0x"))
+	if err != nil || len(raw) != 12 {
+		return nil, fmt.Errorf("Invalid cursor: %v", cursor)
+	}
+	return &txindex.Position{
+		Height: binary.BigEndian.Uint64(raw),
+		Index:  binary.BigEndian.Uint32(raw[8:]),
+	}, nil
+}
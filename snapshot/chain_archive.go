@@ -0,0 +1,274 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/rlp"
+)
+
+// ChainArchiveVersion is the version of the chain archive format written by ExportChainArchive
+const ChainArchiveVersion uint64 = 1
+
+// chainArchiveMagic identifies a chain archive. It is followed by the format version, and then
+// the gzip compressed header and block records.
+var chainArchiveMagic = []byte("THETACHN")
+
+// Max size of a record in the archive, guards against allocating huge buffers for corrupted archives
+const maxChainArchiveRecordSize = 256 * 1024 * 1024
+
+// ChainArchiveHeader describes the blocks contained in a chain archive
+type ChainArchiveHeader struct {
+	ChainID     string
+	StartHeight uint64
+	EndHeight   uint64
+}
+
+// chainArchiveBlock is a finalized block along with the votes for it
+type chainArchiveBlock struct {
+	Block *core.Block
+	Votes *core.VoteSet `rlp:"nil"`
+}
+
+// ExportChainArchive writes the finalized blocks from startHeight to endHeight and their votes to a
+// compressed archive at filePath. If endHeight is 0, all the finalized blocks from startHeight on are
+// exported. The blocks are written as they are read from the chain, so that exporting a long
+// chain does not hold it in memory.
+func ExportChainArchive(chain *blockchain.Chain, startHeight, endHeight uint64, filePath string) (*ChainArchiveHeader, error) {
+	if endHeight != 0 && startHeight > endHeight {
+		return nil, fmt.Errorf("Start height %v is larger than end height %v", startHeight, endHeight)
+	}
+	if startHeight == 0 {
+		return nil, fmt.Errorf("The genesis block cannot be exported, start height should be at least 1")
+	}
+
+	if endHeight == 0 {
+		// The header, which comes first, records the height of the last block
+		for endHeight = startHeight; findFinalizedBlock(chain, endHeight+1) != nil; endHeight++ {
+		}
+	}
+	header := &ChainArchiveHeader{
+		ChainID:     chain.ChainID,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err = writeChainArchive(chain, header, file); err != nil {
+		file.Close()
+		os.Remove(filePath)
+		return nil, err
+	}
+	return header, file.Sync()
+}
+
+func writeChainArchive(chain *blockchain.Chain, header *ChainArchiveHeader, file io.Writer) error {
+	if _, err := file.Write(chainArchiveMagic); err != nil {
+		return err
+	}
+	if _, err := file.Write(core.Itobytes(ChainArchiveVersion)); err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(file)
+	if err := writeArchiveRecord(writer, header); err != nil {
+		return err
+	}
+	for height := header.StartHeight; height <= header.EndHeight; height++ {
+		block := findFinalizedBlock(chain, height)
+		if block == nil {
+			return fmt.Errorf("There is no finalized block at height %v", height)
+		}
+		archiveBlock := &chainArchiveBlock{
+			Block: block.Block,
+			Votes: chain.FindVotesByHash(block.Hash()),
+		}
+		if err := writeArchiveRecord(writer, archiveBlock); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// ValidateChainArchive checks the archive is well formed and belongs to the chain without importing
+// any block. It verifies the blocks link to each other, and the signatures of the proposers and the
+// voters. The finality of the blocks is verified by the consensus engine when the node processes
+// the imported blocks.
+func ValidateChainArchive(chain *blockchain.Chain, filePath string) (*ChainArchiveHeader, error) {
+	return readChainArchive(chain, filePath, nil)
+}
+
+// ImportChainArchive validates the archive, and then adds the blocks and their votes to the chain.
+// The imported blocks are pending, and are processed by the consensus engine when the node starts.
+// It returns the number of blocks that were not in the chain yet.
+func ImportChainArchive(chain *blockchain.Chain, filePath string) (*ChainArchiveHeader, int, error) {
+	if _, err := ValidateChainArchive(chain, filePath); err != nil {
+		return nil, 0, err
+	}
+
+	numImported := 0
+	header, err := readChainArchive(chain, filePath, func(archiveBlock *chainArchiveBlock) error {
+		if _, err := chain.FindBlock(archiveBlock.Block.Hash()); err != nil {
+			if _, err := chain.AddBlock(archiveBlock.Block); err != nil {
+				return err
+			}
+			numImported++
+		}
+		if archiveBlock.Votes != nil {
+			for _, vote := range archiveBlock.Votes.Votes() {
+				chain.AddVoteToIndex(vote)
+			}
+		}
+		return nil
+	})
+	return header, numImported, err
+}
+
+func readChainArchive(chain *blockchain.Chain, filePath string, process func(*chainArchiveBlock) error) (*ChainArchiveHeader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	prefix := make([]byte, len(chainArchiveMagic)+8)
+	if _, err = io.ReadFull(file, prefix); err != nil || !bytes.Equal(prefix[:len(chainArchiveMagic)], chainArchiveMagic) {
+		return nil, fmt.Errorf("%v is not a chain archive", filePath)
+	}
+	if version := core.Bytestoi(prefix[len(chainArchiveMagic):]); version != ChainArchiveVersion {
+		return nil, fmt.Errorf("Unsupported chain archive version %v, expected: %v", version, ChainArchiveVersion)
+	}
+	reader, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decompress chain archive, %v", err)
+	}
+	defer reader.Close()
+
+	header := &ChainArchiveHeader{}
+	if err = readArchiveRecord(reader, header); err != nil {
+		return nil, fmt.Errorf("Failed to read chain archive header, %v", err)
+	}
+	if header.ChainID != chain.ChainID {
+		return nil, fmt.Errorf("The archive belongs to chain %v, expected: %v", header.ChainID, chain.ChainID)
+	}
+
+	var parent *core.BlockHeader
+	for height := header.StartHeight; height <= header.EndHeight; height++ {
+		archiveBlock := &chainArchiveBlock{}
+		if err = readArchiveRecord(reader, archiveBlock); err != nil {
+			return nil, fmt.Errorf("Failed to read block at height %v, %v", height, err)
+		}
+		if archiveBlock.Block == nil || archiveBlock.Block.BlockHeader == nil {
+			return nil, fmt.Errorf("Block at height %v is missing", height)
+		}
+		if parent == nil {
+			eb, err := chain.FindBlock(archiveBlock.Block.Parent)
+			if err != nil {
+				return nil, fmt.Errorf("The parent of the first block %v is not in the chain, the archive should "+
+					"start from a height the node has already synced to", archiveBlock.Block.Hash().Hex())
+			}
+			parent = eb.BlockHeader
+		}
+		if err = validateArchiveBlock(archiveBlock, parent, header.ChainID, height); err != nil {
+			return nil, err
+		}
+		if process != nil {
+			if err = process(archiveBlock); err != nil {
+				return nil, err
+			}
+		}
+		parent = archiveBlock.Block.BlockHeader
+	}
+
+	if err = readArchiveRecord(reader, &chainArchiveBlock{}); err != io.EOF {
+		return nil, fmt.Errorf("Unexpected data after block at height %v", header.EndHeight)
+	}
+	return header, nil
+}
+
+func validateArchiveBlock(archiveBlock *chainArchiveBlock, parent *core.BlockHeader, chainID string, height uint64) error {
+	block := archiveBlock.Block
+	hash := block.Hash()
+	if block.Height != height {
+		return fmt.Errorf("Block %v has height %v, expected: %v", hash.Hex(), block.Height, height)
+	}
+	if block.ChainID != chainID {
+		return fmt.Errorf("Block %v belongs to chain %v, expected: %v", hash.Hex(), block.ChainID, chainID)
+	}
+	if block.Parent != parent.Hash() {
+		return fmt.Errorf("Block %v does not extend block %v", hash.Hex(), parent.Hash().Hex())
+	}
+	if res := block.Validate(); res.IsError() {
+		return fmt.Errorf("Block %v is invalid, %v", hash.Hex(), res.Message)
+	}
+	txs := core.NewBlock()
+	txs.AddTxs(block.Txs)
+	if txs.TxHash != block.TxHash {
+		return fmt.Errorf("The transactions of block %v do not match the tx hash", hash.Hex())
+	}
+
+	// Indirectly finalized blocks might not have votes
+	if archiveBlock.Votes == nil {
+		return nil
+	}
+	for _, vote := range archiveBlock.Votes.Votes() {
+		if vote.Block != hash {
+			return fmt.Errorf("Vote from %v is not for block %v", vote.ID.Hex(), hash.Hex())
+		}
+		if res := vote.Validate(); res.IsError() {
+			return fmt.Errorf("Vote from %v for block %v is invalid, %v", vote.ID.Hex(), hash.Hex(), res.Message)
+		}
+	}
+	return nil
+}
+
+func findFinalizedBlock(chain *blockchain.Chain, height uint64) *core.ExtendedBlock {
+	for _, block := range chain.FindBlocksByHeight(height) {
+		if block.Status.IsFinalized() {
+			return block
+		}
+	}
+	return nil
+}
+
+func writeArchiveRecord(writer io.Writer, obj interface{}) error {
+	raw, err := rlp.EncodeToBytes(obj)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(core.Itobytes(uint64(len(raw)))); err != nil {
+		return err
+	}
+	_, err = writer.Write(raw)
+	return err
+}
+
+// readArchiveRecord returns io.EOF if there is no more record
+func readArchiveRecord(reader io.Reader, obj interface{}) error {
+	sizeBytes := make([]byte, 8)
+	if _, err := io.ReadFull(reader, sizeBytes); err != nil {
+		return err
+	}
+	size := core.Bytestoi(sizeBytes)
+	if size > maxChainArchiveRecordSize {
+		return fmt.Errorf("Record size %v exceeds the limit", size)
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(reader, raw); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return rlp.DecodeBytes(raw, obj)
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+func newTestArchiveChain(root *core.Block) *blockchain.Chain {
+	return blockchain.NewChain("testchain", kvstore.NewKVStore(backend.NewMemDatabase()), root)
+}
+
+func newTestArchiveBlock(parent *core.Block, signer *crypto.PrivateKey) *core.Block {
+	block := core.NewBlock()
+	block.ChainID = "testchain"
+	block.Parent = parent.Hash()
+	block.Height = parent.Height + 1
+	block.Version = core.BlockHeaderVersion(block.ChainID, block.Height)
+	block.Epoch = block.Height
+	block.HCC.BlockHash = parent.Hash()
+	block.Proposer = signer.PublicKey().Address()
+	block.Timestamp = big.NewInt(time.Now().Unix())
+	block.AddTxs([]common.Bytes{common.Bytes("tx")})
+	block.Signature, _ = signer.Sign(block.SignBytes())
+	return block
+}
+
+func TestChainArchive(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "theta-chain-archive-test")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	archivePath := path.Join(dir, "archive")
+
+	signer, _, _ := crypto.GenerateKeyPair()
+	root := core.CreateTestBlock("archive_root", "")
+	chain := newTestArchiveChain(root)
+	parent := root
+	blocks := []*core.Block{}
+	for i := 0; i < 3; i++ {
+		block := newTestArchiveBlock(parent, signer)
+		_, err := chain.AddBlock(block)
+		require.Nil(err)
+		vote := core.Vote{Block: block.Hash(), Height: block.Height, Epoch: block.Epoch, ID: signer.PublicKey().Address()}
+		vote.Sign(signer)
+		chain.AddVoteToIndex(vote)
+		blocks = append(blocks, block)
+		parent = block
+	}
+
+	// Only finalized blocks are exported
+	_, err = ExportChainArchive(chain, 1, 3, archivePath)
+	assert.NotNil(err)
+	_, err = os.Stat(archivePath)
+	assert.True(os.IsNotExist(err), "the partial archive should be removed")
+	chain.FinalizePreviousBlocks(blocks[2].Hash())
+
+	header, err := ExportChainArchive(chain, 2, 0, archivePath)
+	require.Nil(err)
+	assert.Equal(uint64(2), header.StartHeight)
+	assert.Equal(uint64(3), header.EndHeight)
+
+	// The node importing the archive needs to have the parent of the first block
+	header, err = ExportChainArchive(chain, 1, 3, archivePath)
+	require.Nil(err)
+	_, err = ValidateChainArchive(newTestArchiveChain(blocks[0]), archivePath)
+	assert.NotNil(err)
+
+	chain2 := newTestArchiveChain(root)
+	header, numImported, err := ImportChainArchive(chain2, archivePath)
+	require.Nil(err)
+	assert.Equal(3, numImported)
+	assert.Equal(uint64(3), header.EndHeight)
+	for _, block := range blocks {
+		eb, err := chain2.FindBlock(block.Hash())
+		require.Nil(err)
+		assert.True(eb.Status.IsPending())
+		assert.Equal(1, chain2.FindVotesByHash(block.Hash()).Size())
+	}
+
+	// Importing again does not add the blocks again
+	_, numImported, err = ImportChainArchive(chain2, archivePath)
+	require.Nil(err)
+	assert.Equal(0, numImported)
+
+	// Corrupted archives are rejected
+	raw, err := ioutil.ReadFile(archivePath)
+	require.Nil(err)
+	require.Nil(ioutil.WriteFile(archivePath, raw[:len(raw)-20], 0600))
+	_, err = ValidateChainArchive(newTestArchiveChain(root), archivePath)
+	assert.NotNil(err)
+	raw[len(chainArchiveMagic)] = 2
+	require.Nil(ioutil.WriteFile(archivePath, raw, 0600))
+	_, err = ValidateChainArchive(newTestArchiveChain(root), archivePath)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "Unsupported chain archive version 2")
+}
@@ -0,0 +1,94 @@
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+// GenesisAccount is an account funded in the genesis state
+type GenesisAccount struct {
+	Address common.Address
+	Balance types.Coins
+	Stake   *big.Int // ThetaWei staked by the account to itself out of its balance, nil if not a validator
+}
+
+// WriteGenesisSnapshot generates the genesis state with the accounts funded and the stakes of the
+// validators deposited, and writes it to the snapshot file. The accounts are partitioned into the
+// given number of shards, unless it is 0. Slashing is enabled with the given parameters, unless
+// nil. It returns the genesis block header.
+func WriteGenesisSnapshot(chainID string, timestamp int64, numShards uint64, slashing *types.SlashingParams,
+	accounts []GenesisAccount, filePath string) (*core.BlockHeader, error) {
+	db := backend.NewMemDatabase()
+	sv := state.NewStoreView(core.GenesisBlockHeight, common.Hash{}, db)
+	if numShards != 0 {
+		if err := sv.InitShards(numShards); err != nil {
+			return nil, err
+		}
+	}
+	if slashing != nil {
+		if err := sv.InitSlashing(slashing); err != nil {
+			return nil, err
+		}
+	}
+	vcp := &core.ValidatorCandidatePool{}
+	for _, ga := range accounts {
+		balance := ga.Balance.NoNil()
+		if ga.Stake != nil {
+			if balance.ThetaWei.Cmp(ga.Stake) < 0 {
+				return nil, fmt.Errorf("The balance of %v is less than its stake %v", ga.Address.Hex(), ga.Stake)
+			}
+			if err := vcp.DepositStake(ga.Address, ga.Address, ga.Stake); err != nil {
+				return nil, fmt.Errorf("Failed to deposit the stake of %v: %v", ga.Address.Hex(), err)
+			}
+			balance = balance.Minus(types.Coins{ThetaWei: ga.Stake, TFuelWei: big.NewInt(0)})
+		}
+		sv.SetAccount(ga.Address, &types.Account{
+			Address:  ga.Address,
+			Root:     common.Hash{},
+			CodeHash: types.EmptyCodeHash,
+			Balance:  balance,
+		})
+	}
+	sv.UpdateValidatorCandidatePool(vcp)
+
+	hl := &types.HeightList{}
+	hl.Append(core.GenesisBlockHeight)
+	sv.UpdateStakeTransactionHeightList(hl)
+
+	genesisBlock := core.NewBlock()
+	genesisBlock.ChainID = chainID
+	genesisBlock.Height = core.GenesisBlockHeight
+	genesisBlock.Epoch = genesisBlock.Height
+	genesisBlock.Parent = common.Hash{}
+	genesisBlock.StateHash = sv.Hash()
+	genesisBlock.Timestamp = big.NewInt(timestamp)
+
+	metadata := &core.SnapshotMetadata{
+		TailTrio: core.SnapshotBlockTrio{
+			First:  core.SnapshotFirstBlock{},
+			Second: core.SnapshotSecondBlock{Header: *genesisBlock.BlockHeader},
+			Third:  core.SnapshotThirdBlock{},
+		},
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if err := core.WriteMetadata(writer, metadata); err != nil {
+		return nil, err
+	}
+	writeStoreView(sv, true, writer, db)
+
+	return genesisBlock.BlockHeader, nil
+}
@@ -0,0 +1,103 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func TestWriteGenesisSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "theta-genesis-test")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	snapshotPath := path.Join(dir, "snapshot")
+
+	validator := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	account := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	balance := func() types.Coins {
+		return types.Coins{
+			ThetaWei: new(big.Int).Mul(big.NewInt(2), core.MinValidatorStakeDeposit),
+			TFuelWei: big.NewInt(1000),
+		}
+	}
+	accounts := []GenesisAccount{
+		{Address: validator, Balance: balance(), Stake: core.MinValidatorStakeDeposit},
+		{Address: account, Balance: balance()},
+	}
+
+	header, err := WriteGenesisSnapshot("genesis_test", 1546300800, 0, nil, accounts, snapshotPath)
+	require.Nil(err)
+	assert.Equal("genesis_test", header.ChainID)
+	assert.Equal(core.GenesisBlockHeight, header.Height)
+
+	// The same accounts always produce the same genesis block
+	again, err := WriteGenesisSnapshot("genesis_test", 1546300800, 0, nil, accounts, path.Join(dir, "again"))
+	require.Nil(err)
+	assert.Equal(header.Hash(), again.Hash())
+
+	viper.Set(common.CfgGenesisHash, header.Hash().Hex())
+	defer viper.Set(common.CfgGenesisHash, "")
+	db := backend.NewMemDatabase()
+	imported, err := ImportSnapshot(snapshotPath, db)
+	require.Nil(err)
+	assert.Equal(header.Hash(), imported.Hash())
+
+	sv := state.NewStoreView(imported.Height, imported.StateHash, db)
+	assert.Equal(core.MinValidatorStakeDeposit, sv.GetAccount(validator).Balance.ThetaWei)
+	assert.Equal(balance().ThetaWei, sv.GetAccount(account).Balance.ThetaWei)
+	vcp := sv.GetValidatorCandidatePool()
+	require.NotNil(vcp)
+	require.Equal(1, len(vcp.SortedCandidates))
+	assert.Equal(validator, vcp.SortedCandidates[0].Holder)
+
+	// The accounts of a sharded genesis state are imported into their shards
+	shardedPath := path.Join(dir, "sharded")
+	sharded, err := WriteGenesisSnapshot("genesis_test", 1546300800, 4, nil, accounts, shardedPath)
+	require.Nil(err)
+	assert.NotEqual(header.Hash(), sharded.Hash())
+	viper.Set(common.CfgGenesisHash, sharded.Hash().Hex())
+	db = backend.NewMemDatabase()
+	imported, err = ImportSnapshot(shardedPath, db)
+	require.Nil(err)
+	assert.Equal(sharded.Hash(), imported.Hash())
+
+	sv = state.NewStoreView(imported.Height, imported.StateHash, db)
+	assert.Equal(uint64(4), sv.NumShards())
+	assert.Equal(core.MinValidatorStakeDeposit, sv.GetAccount(validator).Balance.ThetaWei)
+	assert.Equal(balance().ThetaWei, sv.GetAccount(account).Balance.ThetaWei)
+	assert.Nil(sv.GetSlashingParams())
+
+	// The slashing parameters are part of the genesis state
+	slashing := &types.SlashingParams{Council: []common.Address{validator}, Threshold: 1, ReviewWindow: 100}
+	slashingPath := path.Join(dir, "slashing")
+	withSlashing, err := WriteGenesisSnapshot("genesis_test", 1546300800, 0, slashing, accounts, slashingPath)
+	require.Nil(err)
+	viper.Set(common.CfgGenesisHash, withSlashing.Hash().Hex())
+	db = backend.NewMemDatabase()
+	imported, err = ImportSnapshot(slashingPath, db)
+	require.Nil(err)
+	sv = state.NewStoreView(imported.Height, imported.StateHash, db)
+	assert.Equal(slashing, sv.GetSlashingParams())
+	_, err = WriteGenesisSnapshot("genesis_test", 1546300800, 0, &types.SlashingParams{Council: []common.Address{validator}},
+		accounts, slashingPath)
+	assert.NotNil(err)
+
+	// The stake cannot exceed the balance
+	accounts[1].Stake = new(big.Int).Mul(big.NewInt(3), core.MinValidatorStakeDeposit)
+	_, err = WriteGenesisSnapshot("genesis_test", 1546300800, 0, nil, accounts, snapshotPath)
+	assert.NotNil(err)
+}
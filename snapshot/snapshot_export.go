@@ -11,7 +11,6 @@ import (
 
 	"github.com/thetatoken/theta/blockchain"
 	"github.com/thetatoken/theta/common"
-	cns "github.com/thetatoken/theta/consensus"
 	"github.com/thetatoken/theta/core"
 	"github.com/thetatoken/theta/ledger/state"
 	"github.com/thetatoken/theta/ledger/types"
@@ -20,17 +19,65 @@ import (
 	"github.com/thetatoken/theta/store/treestore"
 )
 
-func ExportSnapshot(db database.Database, consensus *cns.ConsensusEngine, chain *blockchain.Chain, snapshotDir string) (string, error) {
-	metadata := &core.SnapshotMetadata{}
+func ExportSnapshot(db database.Database, finality core.FinalityProvider, chain *blockchain.Chain, snapshotDir string) (string, error) {
+	lastFinalizedHash := finality.GetLastFinalizedBlock().Hash()
+	lastFinalizedBlock, err := chain.FindBlock(lastFinalizedHash)
+	if err != nil {
+		logger.Errorf("Failed to get block %v, %v", lastFinalizedHash, err)
+		return "", err
+	}
 
-	stub := consensus.GetSummary()
-	lastFinalizedBlock, err := chain.FindBlock(stub.LastFinalizedBlock)
+	currentTime := time.Now().UTC()
+	filename := "theta_snapshot-" + strconv.FormatUint(lastFinalizedBlock.Height, 10) + "-" + lastFinalizedBlock.StateHash.String() + "-" + currentTime.Format("2006-01-02")
+	snapshotPath := path.Join(snapshotDir, filename)
+	file, err := os.Create(snapshotPath)
 	if err != nil {
-		logger.Errorf("Failed to get block %v, %v", stub.LastFinalizedBlock, err)
 		return "", err
 	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	if err = writeSnapshot(db, chain, lastFinalizedBlock, writer); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
 
+// writeSnapshot writes the snapshot of the state after the given finalized block, along with the
+// proofs of the validator set changes up to it.
+func writeSnapshot(db database.Database, chain *blockchain.Chain, lastFinalizedBlock *core.ExtendedBlock, writer *bufio.Writer) error {
 	sv := state.NewStoreView(lastFinalizedBlock.Height, lastFinalizedBlock.BlockHeader.StateHash, db)
+	if sv == nil {
+		return fmt.Errorf("The state at height %v has been pruned", lastFinalizedBlock.Height)
+	}
+	metadata, genesisBlockHeader, err := buildSnapshotMetadata(db, chain, lastFinalizedBlock, sv)
+	if err != nil {
+		return err
+	}
+
+	err = core.WriteMetadata(writer, metadata)
+	if err != nil {
+		return err
+	}
+
+	genesisSV := state.NewStoreView(genesisBlockHeader.Height, genesisBlockHeader.StateHash, db)
+	writeStoreView(genesisSV, false, writer, db)
+	parentHeader := &metadata.TailTrio.First.Header
+	parentSV := state.NewStoreView(parentHeader.Height, parentHeader.StateHash, db)
+	if parentSV == nil {
+		return fmt.Errorf("The state at height %v has been pruned", parentHeader.Height)
+	}
+	writeStoreView(parentSV, true, writer, db)
+	writeStoreView(sv, true, writer, db)
+
+	return nil
+}
+
+// buildSnapshotMetadata collects the proofs of the validator set changes up to the finalized block
+// whose state is sv, and the tail trio of the block. It also returns the genesis block header.
+func buildSnapshotMetadata(db database.Database, chain *blockchain.Chain, lastFinalizedBlock *core.ExtendedBlock,
+	sv *state.StoreView) (*core.SnapshotMetadata, *core.BlockHeader, error) {
+	metadata := &core.SnapshotMetadata{}
 
 	var genesisBlockHeader *core.BlockHeader
 	kvStore := kvstore.NewKVStore(db)
@@ -66,42 +113,42 @@ func ExportSnapshot(db database.Database, consensus *cns.ConsensusEngine, chain
 					var child, grandChild core.BlockHeader
 					b, err := getFinalizedChild(block, chain)
 					if err != nil {
-						return "", err
+						return nil, nil, err
 					}
 					if b != nil {
 						child = *b.BlockHeader
 						b, err = getFinalizedChild(b, chain)
 						if err != nil {
-							return "", err
+							return nil, nil, err
 						}
 						if b != nil {
 							grandChild = *b.BlockHeader
 						} else {
-							return "", fmt.Errorf("Can't find finalized grandchild block. " +
+							return nil, nil, fmt.Errorf("Can't find finalized grandchild block. " +
 								"Likely the last finalized block also contains stake change transactions. " +
 								"Please try again in 30 seconds.")
 						}
 					} else {
-						return "", fmt.Errorf("Can't find finalized child block. " +
+						return nil, nil, fmt.Errorf("Can't find finalized child block. " +
 							"Likely the last finalized block also contains stake change transactions. " +
 							"Please try again in 30 seconds.")
 					}
 
 					if child.HCC.BlockHash != block.Hash() || grandChild.HCC.BlockHash != child.Hash() {
-						return "", fmt.Errorf("Invalid block HCC link for validator set changes")
+						return nil, nil, fmt.Errorf("Invalid block HCC link for validator set changes")
 					}
 					if grandChild.HCC.Votes.IsEmpty() {
-						return "", fmt.Errorf("Missing block HCC votes for validator set changes")
+						return nil, nil, fmt.Errorf("Missing block HCC votes for validator set changes")
 					}
 					for _, vote := range grandChild.HCC.Votes.Votes() {
 						if vote.Block != child.Hash() {
-							return "", fmt.Errorf("Invalid block HCC votes for validator set changes")
+							return nil, nil, fmt.Errorf("Invalid block HCC votes for validator set changes")
 						}
 					}
 
 					vcpProof, err := proveVCP(block, db)
 					if err != nil {
-						return "", fmt.Errorf("Failed to get VCP Proof")
+						return nil, nil, fmt.Errorf("Failed to get VCP Proof")
 					}
 					metadata.ProofTrios = append(metadata.ProofTrios,
 						core.SnapshotBlockTrio{
@@ -114,61 +161,47 @@ func ExportSnapshot(db database.Database, consensus *cns.ConsensusEngine, chain
 				}
 			}
 			if !foundDirectlyFinalizedBlock {
-				return "", fmt.Errorf("Finalized block not found for height %v", height)
+				return nil, nil, fmt.Errorf("Finalized block not found for height %v", height)
 			}
 		}
 	}
 
 	parentBlock, err := chain.FindBlock(lastFinalizedBlock.Parent)
 	if err != nil {
-		return "", fmt.Errorf("Failed to find last finalized block's parent, %v", err)
+		return nil, nil, fmt.Errorf("Failed to find last finalized block's parent, %v", err)
 	}
 	childBlock, err := getAtLeastCommittedChild(lastFinalizedBlock, chain)
 	if err != nil {
-		return "", fmt.Errorf("Failed to find last finalized block's committed child, %v", err)
+		return nil, nil, fmt.Errorf("Failed to find last finalized block's committed child, %v", err)
+	}
+	if childBlock == nil {
+		return nil, nil, fmt.Errorf("Block %v has no committed child yet", lastFinalizedBlock.Hash().Hex())
 	}
 
 	if lastFinalizedBlock.HCC.BlockHash != parentBlock.Hash() {
-		return "", fmt.Errorf("Parent block hash mismatch: %v vs %v", lastFinalizedBlock.HCC.BlockHash, parentBlock.Hash())
+		return nil, nil, fmt.Errorf("Parent block hash mismatch: %v vs %v", lastFinalizedBlock.HCC.BlockHash, parentBlock.Hash())
 	}
 
 	if childBlock.HCC.BlockHash != lastFinalizedBlock.Hash() {
-		return "", fmt.Errorf("Finalized block hash mismatch: %v vs %v", childBlock.HCC.BlockHash, lastFinalizedBlock.Hash())
+		return nil, nil, fmt.Errorf("Finalized block hash mismatch: %v vs %v", childBlock.HCC.BlockHash, lastFinalizedBlock.Hash())
 	}
 
 	childVoteSet := chain.FindVotesByHash(childBlock.Hash())
 
 	vcpProof, err := proveVCP(parentBlock, db)
 	if err != nil {
-		return "", fmt.Errorf("Failed to get VCP Proof")
+		return nil, nil, fmt.Errorf("Failed to get VCP Proof")
 	}
 	metadata.TailTrio = core.SnapshotBlockTrio{
 		First:  core.SnapshotFirstBlock{Header: *parentBlock.BlockHeader, Proof: *vcpProof},
 		Second: core.SnapshotSecondBlock{Header: *lastFinalizedBlock.BlockHeader},
 		Third:  core.SnapshotThirdBlock{Header: *childBlock.BlockHeader, VoteSet: childVoteSet},
 	}
-
-	currentTime := time.Now().UTC()
-	filename := "theta_snapshot-" + strconv.FormatUint(sv.Height(), 10) + "-" + sv.Hash().String() + "-" + currentTime.Format("2006-01-02")
-	snapshotPath := path.Join(snapshotDir, filename)
-	file, err := os.Create(snapshotPath)
-	if err != nil {
-		return "", err
+	if genesisBlockHeader == nil {
+		return nil, nil, fmt.Errorf("Missing the genesis block")
 	}
-	defer file.Close()
-	writer := bufio.NewWriter(file)
-	err = core.WriteMetadata(writer, metadata)
-	if err != nil {
-		return "", err
-	}
-
-	genesisSV := state.NewStoreView(genesisBlockHeader.Height, genesisBlockHeader.StateHash, db)
-	writeStoreView(genesisSV, false, writer, db)
-	parentSV := state.NewStoreView(parentBlock.Height, parentBlock.StateHash, db)
-	writeStoreView(parentSV, true, writer, db)
-	writeStoreView(sv, true, writer, db)
 
-	return filename, nil
+	return metadata, genesisBlockHeader, nil
 }
 
 func proveVCP(block *core.ExtendedBlock, db database.Database) (*core.VCPProof, error) {
@@ -213,7 +246,7 @@ func writeStoreView(sv *state.StoreView, needAccountStorage bool, writer *bufio.
 	if err != nil {
 		panic(err)
 	}
-	sv.GetStore().Traverse(nil, func(k, v common.Bytes) bool {
+	writeEntry := func(k, v common.Bytes) bool {
 		err = core.WriteRecord(writer, k, v)
 		if err != nil {
 			panic(err)
@@ -245,6 +278,17 @@ func writeStoreView(sv *state.StoreView, needAccountStorage bool, writer *bufio.
 			}
 		}
 		return true
+	}
+	// The shard count comes first, so that the accounts are imported into their shards
+	if sv.NumShards() != 0 {
+		writeEntry(state.ShardCountKey(), sv.Get(state.ShardCountKey()))
+		sv.TraverseAccounts(writeEntry)
+	}
+	sv.GetStore().Traverse(nil, func(k, v common.Bytes) bool {
+		if sv.NumShards() != 0 && (bytes.Equal(k, state.ShardCountKey()) || state.IsShardKey(k)) {
+			return true // the shard roots are derived from the accounts
+		}
+		return writeEntry(k, v)
 	})
 	err = core.WriteRecord(writer, []byte{core.SVEnd}, height)
 	if err != nil {
@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto/sha3"
+	"github.com/thetatoken/theta/store/database"
+)
+
+// SnapshotFileVersion is the version of the snapshot file format written by ExportSnapshotFile
+const SnapshotFileVersion uint64 = 1
+
+// snapshotFileMagic identifies a snapshot file. It is followed by the format version, the header
+// record, and then the gzip compressed snapshot, in the format loaded by ImportSnapshot.
+var snapshotFileMagic = []byte("THETASNP")
+
+// SnapshotFileHeader identifies the state carried by a snapshot file, so that it can be checked
+// before the snapshot is used to bootstrap a node.
+type SnapshotFileHeader struct {
+	ChainID   string
+	Height    uint64
+	BlockHash common.Hash // hash of the finalized block the state is taken after
+	StateRoot common.Hash // Merkle root of the state
+	Checksum  common.Hash // Keccak256 hash of the uncompressed snapshot
+}
+
+// ExportSnapshotFile writes the snapshot of the state after the finalized block at the given height,
+// or the last finalized block if height is 0, to a snapshot file at filePath. The states of the block
+// and of its parent must not have been pruned.
+func ExportSnapshotFile(db database.Database, chain *blockchain.Chain, height uint64, filePath string) (*SnapshotFileHeader, error) {
+	var block *core.ExtendedBlock
+	if height == 0 {
+		for h := chain.Root().Height; ; h++ {
+			b := findFinalizedBlock(chain, h)
+			if b == nil {
+				break
+			}
+			block = b
+		}
+	} else {
+		block = findFinalizedBlock(chain, height)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("There is no finalized block at height %v", height)
+	}
+
+	header := &SnapshotFileHeader{
+		ChainID:   chain.ChainID,
+		Height:    block.Height,
+		BlockHash: block.Hash(),
+		StateRoot: block.StateHash,
+	}
+	err := writeSnapshotFile(filePath, header, func(writer *bufio.Writer) error {
+		return writeSnapshot(db, chain, block, writer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// ImportSnapshotFile verifies the snapshot file, and then installs the snapshot it carries at
+// snapshotPath, where the node loads it from when it starts. The snapshot is validated the same
+// way as when the node starts, and must match the header of the file.
+func ImportSnapshotFile(filePath, snapshotPath string) (*SnapshotFileHeader, error) {
+	tmpPath := snapshotPath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	header, err := readSnapshotFile(filePath, tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	blockHeader, err := ValidateSnapshot(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if blockHeader.ChainID != header.ChainID {
+		return nil, fmt.Errorf("Chain ID mismatch: %v vs %v", blockHeader.ChainID, header.ChainID)
+	}
+	if blockHeader.Height != header.Height || blockHeader.Hash() != header.BlockHash {
+		return nil, fmt.Errorf("Block mismatch: %v at height %v vs %v at height %v",
+			blockHeader.Hash().Hex(), blockHeader.Height, header.BlockHash.Hex(), header.Height)
+	}
+	if blockHeader.StateHash != header.StateRoot {
+		return nil, fmt.Errorf("State root mismatch: %v vs %v", blockHeader.StateHash.Hex(), header.StateRoot.Hex())
+	}
+
+	if err := os.Rename(tmpPath, snapshotPath); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// writeSnapshotFile writes the snapshot produced by writeBody to filePath, along with the header
+// completed with its checksum.
+func writeSnapshotFile(filePath string, header *SnapshotFileHeader, writeBody func(writer *bufio.Writer) error) error {
+	return writeCompressedFile(filePath, snapshotFileMagic, SnapshotFileVersion, header, &header.Checksum, writeBody)
+}
+
+// readSnapshotFile decompresses the snapshot of the file at filePath to snapshotPath, and verifies
+// its checksum. It returns the header of the file.
+func readSnapshotFile(filePath, snapshotPath string) (*SnapshotFileHeader, error) {
+	header := &SnapshotFileHeader{}
+	if err := readCompressedFile(filePath, snapshotFileMagic, SnapshotFileVersion, header, &header.Checksum, snapshotPath); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// writeCompressedFile writes the magic, the format version and the header record to filePath,
+// followed by the gzip compressed body produced by writeBody. The header is written with the
+// checksum of the uncompressed body set, which is why the compressed body is staged in a temporary
+// file.
+func writeCompressedFile(filePath string, magic []byte, version uint64, header interface{}, checksum *common.Hash,
+	writeBody func(writer *bufio.Writer) error) error {
+	tmpPath := filePath + ".tmp"
+	defer os.Remove(tmpPath)
+
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer tmpFile.Close()
+	hasher := sha3.NewKeccak256()
+	compressor := gzip.NewWriter(tmpFile)
+	writer := bufio.NewWriter(io.MultiWriter(compressor, hasher))
+	if err = writeBody(writer); err != nil {
+		return err
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if err = compressor.Close(); err != nil {
+		return err
+	}
+	*checksum = common.BytesToHash(hasher.Sum(nil))
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err = file.Write(magic); err != nil {
+		return err
+	}
+	if _, err = file.Write(core.Itobytes(version)); err != nil {
+		return err
+	}
+	if err = writeArchiveRecord(file, header); err != nil {
+		return err
+	}
+	if _, err = tmpFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, tmpFile); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// readCompressedFile reads the header of a file written by writeCompressedFile, and decompresses
+// its body to outPath. The checksum of the body must match the one of the header, which checksum
+// points to.
+func readCompressedFile(filePath string, magic []byte, version uint64, header interface{}, checksum *common.Hash, outPath string) error {
+	file, err := openCompressedFile(filePath, magic, version, header)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decompressor, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer decompressor.Close()
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	hasher := sha3.NewKeccak256()
+	if _, err = io.Copy(io.MultiWriter(out, hasher), decompressor); err != nil {
+		return fmt.Errorf("Failed to decompress file, %v", err)
+	}
+	if sum := common.BytesToHash(hasher.Sum(nil)); sum != *checksum {
+		return fmt.Errorf("Checksum mismatch: %v vs %v", sum.Hex(), checksum.Hex())
+	}
+	return out.Sync()
+}
+
+// openCompressedFile opens a file written by writeCompressedFile and reads its header. The
+// returned file is positioned at the start of the compressed body.
+func openCompressedFile(filePath string, magic []byte, version uint64, header interface{}) (*os.File, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := make([]byte, len(magic)+8)
+	if _, err = io.ReadFull(file, prefix); err != nil {
+		err = fmt.Errorf("Failed to read file prefix, %v", err)
+	} else if !bytes.Equal(prefix[:len(magic)], magic) {
+		err = fmt.Errorf("%v is not a %s file", filePath, magic)
+	} else if v := core.Bytestoi(prefix[len(magic):]); v != version {
+		err = fmt.Errorf("Unsupported %s file version %v", magic, v)
+	} else if err = readArchiveRecord(file, header); err != nil {
+		err = fmt.Errorf("Failed to read file header, %v", err)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,91 @@
+package snapshot
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "theta-snapshot-file-test")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	validator := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	accounts := []GenesisAccount{{
+		Address: validator,
+		Balance: types.Coins{ThetaWei: core.MinValidatorStakeDeposit, TFuelWei: big.NewInt(1000)},
+		Stake:   core.MinValidatorStakeDeposit,
+	}}
+	genesisPath := path.Join(dir, "genesis")
+	genesis, err := WriteGenesisSnapshot("snapshot_file_test", 1546300800, 0, nil, accounts, genesisPath)
+	require.Nil(err)
+	viper.Set(common.CfgGenesisHash, genesis.Hash().Hex())
+	defer viper.Set(common.CfgGenesisHash, "")
+
+	body, err := ioutil.ReadFile(genesisPath)
+	require.Nil(err)
+	writeFile := func(filePath string, header *SnapshotFileHeader) {
+		err := writeSnapshotFile(filePath, header, func(writer *bufio.Writer) error {
+			_, err := writer.Write(body)
+			return err
+		})
+		require.Nil(err)
+	}
+
+	filePath := path.Join(dir, "genesis.snapshot")
+	writeFile(filePath, &SnapshotFileHeader{
+		ChainID:   genesis.ChainID,
+		Height:    genesis.Height,
+		BlockHash: genesis.Hash(),
+		StateRoot: genesis.StateHash,
+	})
+
+	snapshotPath := path.Join(dir, "snapshot")
+	header, err := ImportSnapshotFile(filePath, snapshotPath)
+	require.Nil(err)
+	assert.Equal("snapshot_file_test", header.ChainID)
+	assert.Equal(genesis.Hash(), header.BlockHash)
+	imported, err := ioutil.ReadFile(snapshotPath)
+	require.Nil(err)
+	assert.Equal(body, imported)
+
+	// The snapshot must match the header
+	mismatchPath := path.Join(dir, "mismatch.snapshot")
+	writeFile(mismatchPath, &SnapshotFileHeader{
+		ChainID:   genesis.ChainID,
+		Height:    genesis.Height,
+		BlockHash: genesis.Hash(),
+		StateRoot: common.BytesToHash([]byte("other root")),
+	})
+	_, err = ImportSnapshotFile(mismatchPath, path.Join(dir, "mismatch"))
+	assert.NotNil(err)
+	_, err = os.Stat(path.Join(dir, "mismatch"))
+	assert.True(os.IsNotExist(err))
+
+	// A corrupted snapshot is rejected
+	corrupted, err := ioutil.ReadFile(filePath)
+	require.Nil(err)
+	corrupted[len(corrupted)-16] ^= 0xff // in the compressed data, before the gzip trailer
+	corruptedPath := path.Join(dir, "corrupted.snapshot")
+	require.Nil(ioutil.WriteFile(corruptedPath, corrupted, 0600))
+	_, err = ImportSnapshotFile(corruptedPath, path.Join(dir, "corrupted"))
+	assert.NotNil(err)
+
+	// Only snapshot files can be imported
+	_, err = ImportSnapshotFile(genesisPath, path.Join(dir, "plain"))
+	assert.NotNil(err)
+}
@@ -85,6 +85,22 @@ func ValidateSnapshot(filePath string) (*core.BlockHeader, error) {
 	return blockHeader, nil
 }
 
+// ReadSnapshotCheckpoint returns the hash of the last finalized block of the snapshot, without
+// validating the snapshot
+func ReadSnapshotCheckpoint(filePath string) (common.Hash, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer file.Close()
+
+	metadata := core.SnapshotMetadata{}
+	if err := core.ReadRecord(file, &metadata); err != nil {
+		return common.Hash{}, fmt.Errorf("Failed to load snapshot metadata, %v", err)
+	}
+	return metadata.TailTrio.Second.Header.Hash(), nil
+}
+
 func loadSnapshot(filePath string, db database.Database) (*core.BlockHeader, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -116,7 +132,7 @@ func loadSnapshot(filePath string, db database.Database) (*core.BlockHeader, err
 
 	for _, blockTrio := range metadata.ProofTrios {
 		blockTrioKey := []byte(core.BlockTrioStoreKeyPrefix + strconv.FormatUint(blockTrio.First.Header.Height, 10))
-		kvstore.Put(blockTrioKey, blockTrio)
+		kvstore.Put(blockTrioKey, &blockTrio)
 	}
 
 	secondBlockHeader := saveTailBlocks(&metadata, sv, kvstore)
@@ -169,6 +185,19 @@ func loadState(file *os.File, db database.Database) (*state.StoreView, common.Ha
 			if sv == nil {
 				return nil, common.Hash{}, fmt.Errorf("Missing storeview to handle")
 			}
+			if bytes.Equal(record.K, state.ShardCountKey()) {
+				var numShards uint64
+				if err := rlp.DecodeBytes(record.V, &numShards); err != nil {
+					return nil, common.Hash{}, fmt.Errorf("Failed to parse the number of shards, %v", err)
+				}
+				if err := sv.InitShards(numShards); err != nil {
+					return nil, common.Hash{}, err
+				}
+				continue
+			}
+			if state.IsShardKey(record.K) {
+				return nil, common.Hash{}, fmt.Errorf("Unexpected shard root record")
+			}
 			sv.Set(record.K, record.V)
 
 			if account == nil {
@@ -362,7 +391,7 @@ func saveTailBlocks(metadata *core.SnapshotMetadata, sv *state.StoreView, kvstor
 
 		existingFirstExt := core.ExtendedBlock{}
 		if kvstore.Get(firstBlockHash[:], &existingFirstExt) != nil {
-			kvstore.Put(firstBlockHash[:], firstExt)
+			kvstore.Put(firstBlockHash[:], &firstExt)
 		}
 	}
 
@@ -376,7 +405,7 @@ func saveTailBlocks(metadata *core.SnapshotMetadata, sv *state.StoreView, kvstor
 
 	existingSecondExt := core.ExtendedBlock{}
 	if kvstore.Get(secondBlockHash[:], &existingSecondExt) != nil {
-		kvstore.Put(secondBlockHash[:], secondExt)
+		kvstore.Put(secondBlockHash[:], &secondExt)
 	}
 
 	if secondExt.Height != core.GenesisBlockHeight && secondExt.HasValidatorUpdate {
@@ -0,0 +1,408 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/treestore"
+)
+
+//
+// ------------------------- State Diffs -------------------------
+//
+// A state diff file carries the changes of the state between the snapshots of two finalized blocks,
+// so that a node holding the snapshot of the first block derives the snapshot of the second one
+// without downloading the full state. The compressed body consists of the snapshot metadata of the
+// second block, followed by the diff from the state of the first block to the state of the parent of
+// the second block, and then by the diff from the state of the first block to the state of the second
+// block. The diffs are in the record format of the snapshots, where an empty value deletes the key,
+// and the diff of an account storage follows the account record. Both ends are verified: the base
+// snapshot must be the one the diff is taken from, and the resulting states must match the state
+// roots of the blocks of the metadata.
+//
+
+// StateDiffFileVersion is the version of the state diff file format written by ExportStateDiffFile
+const StateDiffFileVersion uint64 = 1
+
+// stateDiffFileMagic identifies a state diff file
+var stateDiffFileMagic = []byte("THETADIF")
+
+// StateDiffFileHeader identifies the snapshots a state diff file is taken between
+type StateDiffFileHeader struct {
+	ChainID       string
+	FromHeight    uint64
+	FromBlockHash common.Hash // hash of the last finalized block of the base snapshot
+	FromStateRoot common.Hash
+	ToHeight      uint64
+	ToBlockHash   common.Hash // hash of the last finalized block of the resulting snapshot
+	ToStateRoot   common.Hash
+	Checksum      common.Hash // Keccak256 hash of the uncompressed diff
+}
+
+// ExportStateDiffFile writes the state diff between the snapshots of the finalized blocks `from`
+// and `to` to a state diff file at filePath. The states of `from`, of `to` and of the parent of
+// `to` must not have been pruned.
+func ExportStateDiffFile(db database.Database, chain *blockchain.Chain, from, to common.Hash, filePath string) (*StateDiffFileHeader, error) {
+	fromBlock, err := chain.FindBlock(from)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find block %v, %v", from.Hex(), err)
+	}
+	toBlock, err := chain.FindBlock(to)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to find block %v, %v", to.Hex(), err)
+	}
+	if !fromBlock.Status.IsFinalized() || !toBlock.Status.IsFinalized() {
+		return nil, fmt.Errorf("The blocks of a state diff must be finalized")
+	}
+	if toBlock.Height <= fromBlock.Height {
+		return nil, fmt.Errorf("Block %v is not after block %v", to.Hex(), from.Hex())
+	}
+
+	fromSV := state.NewStoreView(fromBlock.Height, fromBlock.StateHash, db)
+	if fromSV == nil {
+		return nil, fmt.Errorf("The state at height %v has been pruned", fromBlock.Height)
+	}
+	toSV := state.NewStoreView(toBlock.Height, toBlock.StateHash, db)
+	if toSV == nil {
+		return nil, fmt.Errorf("The state at height %v has been pruned", toBlock.Height)
+	}
+	metadata, _, err := buildSnapshotMetadata(db, chain, toBlock, toSV)
+	if err != nil {
+		return nil, err
+	}
+	parentHeader := &metadata.TailTrio.First.Header
+	parentSV := state.NewStoreView(parentHeader.Height, parentHeader.StateHash, db)
+	if parentSV == nil {
+		return nil, fmt.Errorf("The state at height %v has been pruned", parentHeader.Height)
+	}
+
+	header := &StateDiffFileHeader{
+		ChainID:       chain.ChainID,
+		FromHeight:    fromBlock.Height,
+		FromBlockHash: from,
+		FromStateRoot: fromBlock.StateHash,
+		ToHeight:      toBlock.Height,
+		ToBlockHash:   to,
+		ToStateRoot:   toBlock.StateHash,
+	}
+	err = writeCompressedFile(filePath, stateDiffFileMagic, StateDiffFileVersion, header, &header.Checksum, func(writer *bufio.Writer) error {
+		if err := core.WriteMetadata(writer, metadata); err != nil {
+			return err
+		}
+		if err := writeStateDiff(fromSV, parentSV, writer, db); err != nil {
+			return err
+		}
+		return writeStateDiff(fromSV, toSV, writer, db)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// ReadStateDiffFileHeader returns the header of the state diff file, without verifying the diff
+func ReadStateDiffFileHeader(filePath string) (*StateDiffFileHeader, error) {
+	header := &StateDiffFileHeader{}
+	file, err := openCompressedFile(filePath, stateDiffFileMagic, StateDiffFileVersion, header)
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	return header, nil
+}
+
+// ApplyStateDiffFile applies the state diff file to the snapshot at baseSnapshotPath, which must be
+// the snapshot the diff is taken from, and writes the resulting snapshot to snapshotPath. Both
+// snapshots are validated the same way as when the node starts. Returns the header of the last
+// finalized block of the resulting snapshot.
+func ApplyStateDiffFile(baseSnapshotPath, diffFilePath, snapshotPath string) (*core.BlockHeader, error) {
+	tmpdbRoot, err := ioutil.TempDir("", "tmpdb")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create temporary db for the state diff: %v", err)
+	}
+	defer os.RemoveAll(tmpdbRoot)
+	db, err := backend.NewLDBDatabase(path.Join(tmpdbRoot, "main"), path.Join(tmpdbRoot, "ref"), 256, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	baseHeader, err := loadSnapshot(baseSnapshotPath, db)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid base snapshot: %v", err)
+	}
+
+	rawPath := diffFilePath + ".raw"
+	defer os.Remove(rawPath)
+	header := &StateDiffFileHeader{}
+	err = readCompressedFile(diffFilePath, stateDiffFileMagic, StateDiffFileVersion, header, &header.Checksum, rawPath)
+	if err != nil {
+		return nil, err
+	}
+	if header.ChainID != baseHeader.ChainID {
+		return nil, fmt.Errorf("Chain ID mismatch: %v vs %v", header.ChainID, baseHeader.ChainID)
+	}
+	if header.FromBlockHash != baseHeader.Hash() || header.FromStateRoot != baseHeader.StateHash {
+		return nil, fmt.Errorf("The state diff is taken from block %v, not from block %v of the base snapshot",
+			header.FromBlockHash.Hex(), baseHeader.Hash().Hex())
+	}
+
+	return applyStateDiff(rawPath, header, baseHeader.StateHash, db, snapshotPath)
+}
+
+// applyStateDiff applies the uncompressed state diff at rawPath to the state with the base root,
+// checks the resulting states against the metadata of the diff, and writes the resulting snapshot
+// to snapshotPath.
+func applyStateDiff(rawPath string, header *StateDiffFileHeader, baseRoot common.Hash, db database.Database,
+	snapshotPath string) (*core.BlockHeader, error) {
+	file, err := os.Open(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	metadata := core.SnapshotMetadata{}
+	if err = core.ReadRecord(file, &metadata); err != nil {
+		return nil, fmt.Errorf("Failed to load state diff metadata, %v", err)
+	}
+	if len(metadata.ProofTrios) == 0 {
+		return nil, fmt.Errorf("Missing the genesis block in the state diff metadata")
+	}
+	if blockHash := metadata.TailTrio.Second.Header.Hash(); blockHash != header.ToBlockHash ||
+		metadata.TailTrio.Second.Header.StateHash != header.ToStateRoot {
+		return nil, fmt.Errorf("Block mismatch: %v vs %v", blockHash.Hex(), header.ToBlockHash.Hex())
+	}
+	parentHeader := &metadata.TailTrio.First.Header
+	parentSV, err := readStateDiff(file, baseRoot, parentHeader.Height, db)
+	if err != nil {
+		return nil, err
+	}
+	if parentSV.Hash() != parentHeader.StateHash {
+		return nil, fmt.Errorf("Parent state root mismatch: %v vs %v", parentSV.Hash().Hex(), parentHeader.StateHash.Hex())
+	}
+	sv, err := readStateDiff(file, baseRoot, metadata.TailTrio.Second.Header.Height, db)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSnapshot(sv, &metadata, db); err != nil {
+		return nil, fmt.Errorf("State diff validation failed: %v", err)
+	}
+
+	tmpPath := snapshotPath + ".tmp"
+	defer os.Remove(tmpPath)
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	writer := bufio.NewWriter(out)
+	if err = core.WriteMetadata(writer, &metadata); err != nil {
+		return nil, err
+	}
+	genesisHeader := &metadata.ProofTrios[0].Second.Header
+	genesisSV := state.NewStoreView(genesisHeader.Height, genesisHeader.StateHash, db)
+	if genesisSV == nil {
+		return nil, fmt.Errorf("Missing the genesis state")
+	}
+	writeStoreView(genesisSV, false, writer, db)
+	writeStoreView(parentSV, true, writer, db)
+	writeStoreView(sv, true, writer, db)
+	if err = out.Sync(); err != nil {
+		return nil, err
+	}
+	if err = os.Rename(tmpPath, snapshotPath); err != nil {
+		return nil, err
+	}
+	return &metadata.TailTrio.Second.Header, nil
+}
+
+// writeStateDiff writes the diff from the state `from` to the state `to`, including the diffs of
+// the account storages.
+func writeStateDiff(from, to *state.StoreView, writer *bufio.Writer, db database.Database) error {
+	height := core.Itobytes(to.Height())
+	if err := core.WriteRecord(writer, []byte{core.SVStart}, height); err != nil {
+		return err
+	}
+	var err error
+	diffErr := state.DiffStoreViews(from, to, func(k, v common.Bytes) bool {
+		if err = core.WriteRecord(writer, k, v); err != nil {
+			return false
+		}
+		if len(v) == 0 || !bytes.HasPrefix(k, state.AccountKeyPrefix()) {
+			return true
+		}
+		var oldRoot, newRoot common.Hash
+		if oldRoot, err = accountStorageRoot(from.Get(k)); err != nil {
+			return false
+		}
+		if newRoot, err = accountStorageRoot(v); err != nil {
+			return false
+		}
+		if newRoot == (common.Hash{}) || newRoot == oldRoot {
+			return true
+		}
+		storageFrom := treestore.NewTreeStore(oldRoot, db)
+		storageTo := treestore.NewTreeStore(newRoot, db)
+		if storageFrom == nil || storageTo == nil {
+			err = fmt.Errorf("The storage of account %X is missing", k)
+			return false
+		}
+		if err = core.WriteRecord(writer, []byte{core.SVStart}, height); err != nil {
+			return false
+		}
+		storageErr := treestore.Diff(storageFrom, storageTo, func(sk, sv common.Bytes) bool {
+			err = core.WriteRecord(writer, sk, sv)
+			return err == nil
+		})
+		if err == nil {
+			err = storageErr
+		}
+		if err == nil {
+			err = core.WriteRecord(writer, []byte{core.SVEnd}, height)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	if diffErr != nil {
+		return diffErr
+	}
+	return core.WriteRecord(writer, []byte{core.SVEnd}, height)
+}
+
+// readStateDiff reads a diff written by writeStateDiff, applies it to the state with the base root,
+// and saves the resulting state. The storage root of each updated account is verified.
+func readStateDiff(file *os.File, baseRoot common.Hash, height uint64, db database.Database) (*state.StoreView, error) {
+	readRecord := func() (*core.SnapshotTrieRecord, error) {
+		record := &core.SnapshotTrieRecord{}
+		if err := core.ReadRecord(file, record); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("Unexpected end of the state diff")
+			}
+			return nil, fmt.Errorf("Failed to read state diff record, %v", err)
+		}
+		return record, nil
+	}
+	isMarker := func(record *core.SnapshotTrieRecord, marker byte) bool {
+		return bytes.Equal(record.K, []byte{marker}) && core.Bytestoi(record.V) == height
+	}
+
+	record, err := readRecord()
+	if err != nil {
+		return nil, err
+	}
+	if !isMarker(record, core.SVStart) {
+		return nil, fmt.Errorf("Expected the state diff at height %v", height)
+	}
+	sv := state.NewStoreView(height, baseRoot, db)
+	if sv == nil {
+		return nil, fmt.Errorf("Missing the base state %v", baseRoot.Hex())
+	}
+
+	var pendingRoot, storageRoot common.Hash // storage root of the last account, and its base root
+	for {
+		record, err = readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if isMarker(record, core.SVEnd) {
+			if pendingRoot != (common.Hash{}) {
+				return nil, fmt.Errorf("Missing the account storage diff")
+			}
+			break
+		}
+		if isMarker(record, core.SVStart) {
+			if pendingRoot == (common.Hash{}) {
+				return nil, fmt.Errorf("Unexpected account storage diff")
+			}
+			if err = readStorageDiff(readRecord, isMarker, storageRoot, pendingRoot, height, db); err != nil {
+				return nil, err
+			}
+			pendingRoot = common.Hash{}
+			continue
+		}
+		if pendingRoot != (common.Hash{}) {
+			return nil, fmt.Errorf("Missing the account storage diff")
+		}
+		if state.IsShardKey(record.K) || bytes.Equal(record.K, state.ShardCountKey()) {
+			return nil, fmt.Errorf("Unexpected shard record")
+		}
+
+		if len(record.V) == 0 {
+			sv.Delete(record.K)
+			continue
+		}
+		if bytes.HasPrefix(record.K, state.AccountKeyPrefix()) {
+			oldRoot, err := accountStorageRoot(sv.Get(record.K))
+			if err != nil {
+				return nil, err
+			}
+			newRoot, err := accountStorageRoot(record.V)
+			if err != nil {
+				return nil, err
+			}
+			if newRoot != (common.Hash{}) && newRoot != oldRoot {
+				pendingRoot, storageRoot = newRoot, oldRoot
+			}
+		}
+		sv.Set(record.K, record.V)
+	}
+	sv.Save()
+	return sv, nil
+}
+
+// readStorageDiff applies the diff of an account storage to the storage with the base root, and
+// checks the resulting storage root.
+func readStorageDiff(readRecord func() (*core.SnapshotTrieRecord, error), isMarker func(*core.SnapshotTrieRecord, byte) bool,
+	baseRoot, expectedRoot common.Hash, height uint64, db database.Database) error {
+	storage := state.NewStoreView(height, baseRoot, db)
+	if storage == nil {
+		return fmt.Errorf("Missing the account storage %v", baseRoot.Hex())
+	}
+	for {
+		record, err := readRecord()
+		if err != nil {
+			return err
+		}
+		if isMarker(record, core.SVEnd) {
+			break
+		}
+		if isMarker(record, core.SVStart) {
+			return fmt.Errorf("Unexpected nested storage diff")
+		}
+		if len(record.V) == 0 {
+			storage.Delete(record.K)
+		} else {
+			storage.Set(record.K, record.V)
+		}
+	}
+	if root := storage.Save(); root != expectedRoot {
+		return fmt.Errorf("Account storage root doesn't match: %v vs %v", root.Hex(), expectedRoot.Hex())
+	}
+	return nil
+}
+
+// accountStorageRoot returns the storage root of the encoded account, empty if there is no account
+func accountStorageRoot(raw common.Bytes) (common.Hash, error) {
+	if len(raw) == 0 {
+		return common.Hash{}, nil
+	}
+	account := &types.Account{}
+	if err := types.FromBytes(raw, account); err != nil {
+		return common.Hash{}, fmt.Errorf("Failed to parse account, %v", err)
+	}
+	return account.Root, nil
+}
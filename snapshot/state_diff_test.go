@@ -0,0 +1,130 @@
+package snapshot
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/ledger/state"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func setStateDiffTestAccount(sv *state.StoreView, addr common.Address, balance int64, storage map[string]string, db database.Database) {
+	acc := sv.GetAccount(addr)
+	if acc == nil {
+		acc = types.NewAccount(addr)
+	}
+	acc.Balance = types.NewCoins(0, balance)
+	if storage != nil {
+		storageSV := state.NewStoreView(sv.Height(), acc.Root, db)
+		for k, v := range storage {
+			if len(v) == 0 {
+				storageSV.Delete(common.Bytes(k))
+			} else {
+				storageSV.Set(common.Bytes(k), common.Bytes(v))
+			}
+		}
+		acc.Root = storageSV.Save()
+	}
+	sv.SetAccount(addr, acc)
+}
+
+func TestStateDiff(t *testing.T) {
+	for _, numShards := range []uint64{0, 4} {
+		testStateDiff(t, numShards)
+	}
+}
+
+func testStateDiff(t *testing.T, numShards uint64) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "theta-state-diff-test")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	alice := common.BytesToAddress([]byte("alice"))
+	bob := common.BytesToAddress([]byte("bob"))
+	carol := common.BytesToAddress([]byte("carol"))
+	dave := common.BytesToAddress([]byte("dave"))
+
+	// The base state, exported as a snapshot
+	db := backend.NewMemDatabase()
+	base := state.NewStoreView(10, common.Hash{}, db)
+	if numShards != 0 {
+		require.Nil(base.InitShards(numShards))
+	}
+	setStateDiffTestAccount(base, alice, 100, map[string]string{"k1": "v1", "k2": "v2"}, db)
+	setStateDiffTestAccount(base, bob, 200, nil, db)
+	setStateDiffTestAccount(base, carol, 300, map[string]string{"k1": "v1"}, db)
+	base.Set(common.Bytes("misc"), common.Bytes("old"))
+	baseRoot := base.Save()
+
+	// The updated state
+	updated := state.NewStoreView(20, baseRoot, db)
+	setStateDiffTestAccount(updated, alice, 150, map[string]string{"k1": "", "k2": "v2'", "k3": "v3"}, db)
+	updated.DeleteAccount(bob)
+	setStateDiffTestAccount(updated, dave, 400, map[string]string{"k1": "v1"}, db)
+	updated.Set(common.Bytes("misc"), common.Bytes("new"))
+	updatedRoot := updated.Save()
+
+	// Write the base snapshot and the diff
+	writeFile := func(name string, write func(writer *bufio.Writer) error) *os.File {
+		filePath := path.Join(dir, name)
+		file, err := os.Create(filePath)
+		require.Nil(err)
+		writer := bufio.NewWriter(file)
+		require.Nil(write(writer))
+		require.Nil(writer.Flush())
+		file.Close()
+		file, err = os.Open(filePath)
+		require.Nil(err)
+		return file
+	}
+	baseFile := writeFile("base", func(writer *bufio.Writer) error {
+		writeStoreView(state.NewStoreView(10, baseRoot, db), true, writer, db)
+		return nil
+	})
+	defer baseFile.Close()
+	numRecords := 0
+	err = state.DiffStoreViews(state.NewStoreView(10, baseRoot, db), state.NewStoreView(20, updatedRoot, db),
+		func(k, v common.Bytes) bool {
+			numRecords++
+			return true
+		})
+	require.Nil(err)
+	assert.Equal(4, numRecords) // alice, bob, dave and misc
+	diffFile := writeFile("diff", func(writer *bufio.Writer) error {
+		return writeStateDiff(state.NewStoreView(10, baseRoot, db), state.NewStoreView(20, updatedRoot, db), writer, db)
+	})
+	defer diffFile.Close()
+
+	// Apply the diff to the base snapshot loaded into another database
+	otherDB := backend.NewMemDatabase()
+	loaded, _, err := loadState(baseFile, otherDB)
+	require.Nil(err)
+	require.Equal(baseRoot, loaded.Hash())
+	applied, err := readStateDiff(diffFile, baseRoot, 20, otherDB)
+	require.Nil(err)
+	assert.Equal(updatedRoot, applied.Hash())
+	assert.Nil(applied.GetAccount(bob))
+	assert.Equal(common.Bytes("new"), applied.Get(common.Bytes("misc")))
+	storage := state.NewStoreView(20, applied.GetAccount(alice).Root, otherDB)
+	require.NotNil(storage)
+	assert.Equal(common.Bytes("v3"), storage.Get(common.Bytes("k3")))
+	assert.Equal(0, len(storage.Get(common.Bytes("k1"))))
+
+	// The diff must be for the expected height
+	_, err = diffFile.Seek(0, io.SeekStart)
+	require.Nil(err)
+	_, err = readStateDiff(diffFile, baseRoot, 21, otherDB)
+	assert.NotNil(err)
+}
@@ -0,0 +1,321 @@
+package dirlock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var logger = log.WithFields(log.Fields{"prefix": "dirlock"})
+
+const (
+	// LockFileName is the name of the lock file in the locked directory
+	LockFileName = "theta.lock"
+
+	// osLockFileName is the name of the file locked through the OS in the locked directory. It
+	// is never removed, since two processes could otherwise lock different files.
+	osLockFileName = "theta.oslock"
+
+	// HeartbeatInterval is how often the holder of a lock refreshes its heartbeat
+	HeartbeatInterval = 5 * time.Second
+
+	// StaleAfter is how long after its last heartbeat a lock is considered abandoned, e.g. by a
+	// crashed or zombie process, and can be taken over
+	StaleAfter = 30 * time.Second
+)
+
+// ErrLocked is returned when the directory is locked by another live process
+var ErrLocked = errors.New("Directory is locked by another process")
+
+// Owner describes the process holding a lock. It is the content of the lock file.
+type Owner struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	Token     uint64    `json:"token"` // tells apart the processes reusing a PID
+	StartedAt time.Time `json:"started_at"`
+	Heartbeat time.Time `json:"heartbeat"`
+}
+
+// Lock is an exclusive lock of a directory, held until it is released or the process exits.
+// Processes on the same host are excluded by an OS lock, which the OS releases when the holder
+// exits. Processes on other hosts sharing the directory, e.g. over a network filesystem, are
+// excluded by the heartbeat of the lock file: a lock whose heartbeat is older than StaleAfter is
+// taken over.
+type Lock struct {
+	file   string
+	owner  Owner
+	osLock *os.File
+
+	mu       *sync.Mutex
+	lost     chan struct{}
+	quit     chan struct{}
+	wg       *sync.WaitGroup
+	released bool
+
+	now func() time.Time
+}
+
+// Acquire locks the directory, creating it if needed. It fails with an error wrapping ErrLocked
+// if another live process holds the lock, unless force is set, which lets an admin override a
+// lock known to be abandoned.
+func Acquire(dir string, force bool) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	osLock, err := lockFile(path.Join(dir, osLockFileName))
+	if err == ErrLocked {
+		// Unlike an abandoned lock file, a lock held through the OS cannot be overridden
+		return nil, fmt.Errorf("%v: %v is in use by a running process on this host. Stop the other process first",
+			ErrLocked, dir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	l := &Lock{
+		file:   path.Join(dir, LockFileName),
+		osLock: osLock,
+		owner: Owner{
+			PID:       os.Getpid(),
+			Hostname:  hostname,
+			Token:     rand.New(rand.NewSource(now.UnixNano())).Uint64(),
+			StartedAt: now,
+			Heartbeat: now,
+		},
+		mu:   &sync.Mutex{},
+		lost: make(chan struct{}),
+		quit: make(chan struct{}),
+		wg:   &sync.WaitGroup{},
+		now:  time.Now,
+	}
+	if err := l.acquire(force); err != nil {
+		osLock.Close()
+		return nil, err
+	}
+
+	l.wg.Add(1)
+	go l.heartbeatLoop()
+
+	return l, nil
+}
+
+func (l *Lock) acquire(force bool) error {
+	for attempt := 0; attempt < 2; attempt++ {
+		err := l.create()
+		if err == nil {
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(l.file)
+		if os.IsNotExist(err) {
+			continue // released in the meantime
+		} else if err != nil {
+			return err
+		}
+		holder, err := parseOwner(l.file, data)
+		if err != nil {
+			// A holder crashing while writing leaves a malformed file behind.
+			logger.WithFields(log.Fields{"file": l.file, "error": err}).Warn("Taking over unreadable lock file")
+		} else if err := l.checkTakeover(holder, force); err != nil {
+			return err
+		}
+		if err := l.removeAbandoned(data); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%v: lost the race for %v to another process", ErrLocked, l.file)
+}
+
+// removeAbandoned removes the lock file if it still has the given content. Another process
+// racing for the same abandoned lock might have replaced it in the meantime, in which case
+// its lock is put back.
+func (l *Lock) removeAbandoned(data []byte) error {
+	aside := fmt.Sprintf("%v.%v", l.file, l.owner.Token)
+	if err := os.Rename(l.file, aside); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer os.Remove(aside)
+
+	current, err := ioutil.ReadFile(aside)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, data) {
+		os.Link(aside, l.file) // fails if yet another process holds the lock by now
+		return fmt.Errorf("%v: lost the race for %v to another process", ErrLocked, l.file)
+	}
+	return nil
+}
+
+func (l *Lock) checkTakeover(holder *Owner, force bool) error {
+	age := l.now().Sub(holder.Heartbeat)
+	fields := log.Fields{
+		"file":          l.file,
+		"pid":           holder.PID,
+		"hostname":      holder.Hostname,
+		"lastHeartbeat": holder.Heartbeat,
+	}
+	switch {
+	case force:
+		logger.WithFields(fields).Warn("Forcefully taking over lock")
+	case holder.Hostname == l.owner.Hostname:
+		// The OS lock is held, so the holder on this host has exited, e.g. crashed or ran in a
+		// container restarted since
+		logger.WithFields(fields).Warn("Taking over lock of exited process")
+	case age > StaleAfter:
+		logger.WithFields(fields).Warn("Taking over stale lock")
+	default:
+		return fmt.Errorf("%v: %v is held by process %v on %v, last heartbeat %v ago. "+
+			"Stop the other process first, or if it is known to be gone, override the lock with --force-unlock",
+			ErrLocked, l.file, holder.PID, holder.Hostname, age.Round(time.Second))
+	}
+	return nil
+}
+
+// create creates the lock file, failing if it already exists.
+func (l *Lock) create() error {
+	f, err := os.OpenFile(l.file, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(l.owner)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Lost returns a channel which is closed if the lock is taken over by another process, in which
+// case the holder should stop using the directory right away.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Owner returns the description of the process holding the lock.
+func (l *Lock) Owner() Owner {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.owner
+}
+
+// Release stops the heartbeat, removes the lock file, unless it was taken over, and releases
+// the OS lock.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	if l.released {
+		l.mu.Unlock()
+		return nil
+	}
+	l.released = true
+	close(l.quit)
+	l.mu.Unlock()
+
+	l.wg.Wait()
+	defer l.osLock.Close()
+
+	holder, err := readOwner(l.file)
+	if err != nil || !l.owns(holder) {
+		return nil
+	}
+	return os.Remove(l.file)
+}
+
+func (l *Lock) heartbeatLoop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.quit:
+			return
+		case <-ticker.C:
+			if err := l.heartbeat(); err != nil {
+				if err == ErrLocked {
+					logger.WithFields(log.Fields{"file": l.file}).Error("Lock was taken over by another process")
+					close(l.lost)
+					return
+				}
+				logger.WithFields(log.Fields{"file": l.file, "error": err}).Warn("Failed to update lock heartbeat")
+			}
+		}
+	}
+}
+
+func (l *Lock) heartbeat() error {
+	holder, err := readOwner(l.file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if holder == nil || !l.owns(holder) {
+		return ErrLocked
+	}
+
+	l.mu.Lock()
+	l.owner.Heartbeat = l.now()
+	data, err := json.Marshal(l.owner)
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Replace the file atomically so that readers never see a partial write.
+	tmp := l.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.file)
+}
+
+func (l *Lock) owns(holder *Owner) bool {
+	return holder.PID == l.owner.PID && holder.Token == l.owner.Token && holder.Hostname == l.owner.Hostname
+}
+
+// ReadOwner returns the description of the process holding the lock of the directory, or nil
+// if the directory is not locked.
+func ReadOwner(dir string) (*Owner, error) {
+	owner, err := readOwner(path.Join(dir, LockFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return owner, err
+}
+
+func readOwner(file string) (*Owner, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseOwner(file, data)
+}
+
+func parseOwner(file string, data []byte) (*Owner, error) {
+	owner := &Owner{}
+	if err := json.Unmarshal(data, owner); err != nil {
+		return nil, fmt.Errorf("Malformed lock file %v: %v", file, err)
+	}
+	return owner, nil
+}
@@ -0,0 +1,111 @@
+package dirlock
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeOwner(t *testing.T, dir string, owner Owner) {
+	data, err := json.Marshal(owner)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path.Join(dir, LockFileName), data, 0600))
+}
+
+func TestDirLock(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "dirlock")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	l, err := Acquire(dir, false)
+	require.Nil(err)
+
+	owner, err := ReadOwner(dir)
+	require.Nil(err)
+	require.Equal(os.Getpid(), owner.PID)
+	require.Equal(l.Owner().Token, owner.Token)
+
+	require.Nil(l.heartbeat())
+	require.Nil(l.Release())
+	owner, err = ReadOwner(dir)
+	require.Nil(err)
+	require.Nil(owner)
+}
+
+func TestDirLockHeldByLiveProcess(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "dirlock")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	// A process on this host holds the OS lock, which cannot be overridden
+	osLock, err := lockFile(path.Join(dir, osLockFileName))
+	require.Nil(err)
+	_, err = Acquire(dir, false)
+	require.NotNil(err)
+	require.Contains(err.Error(), ErrLocked.Error())
+	_, err = Acquire(dir, true)
+	require.NotNil(err)
+	require.Contains(err.Error(), ErrLocked.Error())
+	require.Nil(osLock.Close())
+
+	// A process on this host exited without releasing the lock file
+	hostname, _ := os.Hostname()
+	writeOwner(t, dir, Owner{PID: os.Getppid(), Hostname: hostname, Token: 1, Heartbeat: time.Now()})
+	l, err := Acquire(dir, false)
+	require.Nil(err)
+	require.Nil(l.Release())
+
+	// A process on another host holds the lock file
+	other := Owner{PID: os.Getppid(), Hostname: hostname + "-other", Token: 1, Heartbeat: time.Now()}
+	writeOwner(t, dir, other)
+
+	_, err = Acquire(dir, false)
+	require.NotNil(err)
+	require.Contains(err.Error(), ErrLocked.Error())
+
+	// Admin override
+	l, err = Acquire(dir, true)
+	require.Nil(err)
+	defer l.Release()
+	owner, err := ReadOwner(dir)
+	require.Nil(err)
+	require.Equal(os.Getpid(), owner.PID)
+}
+
+func TestDirLockTakeover(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "dirlock")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	hostname, _ := os.Hostname()
+
+	// Stale heartbeat, e.g. a hung or zombie process
+	writeOwner(t, dir, Owner{PID: os.Getppid(), Hostname: hostname, Token: 1, Heartbeat: time.Now().Add(-2 * StaleAfter)})
+	l, err := Acquire(dir, false)
+	require.Nil(err)
+	require.Nil(l.Release())
+
+	// Malformed lock file left by a crash
+	require.Nil(ioutil.WriteFile(path.Join(dir, LockFileName), []byte("{\"pid\": 12"), 0600))
+	l, err = Acquire(dir, false)
+	require.Nil(err)
+
+	// The lock is lost once another process takes it over
+	writeOwner(t, dir, Owner{PID: os.Getppid(), Hostname: hostname, Token: 2, Heartbeat: time.Now()})
+	require.Equal(ErrLocked, l.heartbeat())
+	require.Nil(l.Release())
+	owner, err := ReadOwner(dir)
+	require.Nil(err)
+	require.Equal(uint64(2), owner.Token)
+}
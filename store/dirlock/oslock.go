@@ -0,0 +1,26 @@
+// +build !windows
+
+package dirlock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile opens the file and takes an exclusive flock on it, which the kernel releases once the
+// file is closed, including when the process exits or crashes. It fails with ErrLocked if another
+// open file holds the lock.
+func lockFile(file string) (*os.File, error) {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,26 @@
+package dirlock
+
+import (
+	"os"
+	"syscall"
+)
+
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens the file without sharing it, so that no other handle can open it until the file
+// is closed, including when the process exits or crashes. It fails with ErrLocked if another
+// handle has the file open.
+func lockFile(file string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(file)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == errorSharingViolation {
+		return nil, ErrLocked
+	} else if err != nil {
+		return nil, &os.PathError{Op: "open", Path: file, Err: err}
+	}
+	return os.NewFile(uintptr(h), file), nil
+}
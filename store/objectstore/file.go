@@ -0,0 +1,42 @@
+package objectstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStore stores the objects as files under a directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a new instance of FileStore under dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes the object to a temporary file first, so that a partially written object is never
+// visible under its key.
+func (fs *FileStore) Put(key string, data []byte) error {
+	filePath := filepath.Join(fs.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	tmpPath := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func (fs *FileStore) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(fs.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
@@ -0,0 +1,37 @@
+package objectstore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// ErrObjectNotFound is returned when the object does not exist in the store.
+var ErrObjectNotFound = errors.New("Object not found")
+
+// ObjectStore stores immutable objects by key, e.g. in an S3/GCS bucket.
+type ObjectStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+}
+
+// NewObjectStore creates the object store at the given URL. A file:// URL stores the objects in a
+// local directory, e.g. a mounted bucket. A http:// or https:// URL is the endpoint of an S3
+// compatible service, e.g. https://s3.us-east-1.amazonaws.com or https://storage.googleapis.com.
+func NewObjectStore(storeURL, bucket, region, accessKey, secretKey string) (ObjectStore, error) {
+	u, err := url.Parse(storeURL)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid object store URL %v: %v", storeURL, err)
+	}
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(u.Path)
+	case "http", "https":
+		if len(bucket) == 0 {
+			return nil, errors.New("Bucket of the object store is missing")
+		}
+		return NewS3Store(storeURL, bucket, region, accessKey, secretKey), nil
+	default:
+		return nil, fmt.Errorf("Unsupported object store URL %v, expected file://, http:// or https://", storeURL)
+	}
+}
@@ -0,0 +1,97 @@
+package objectstore
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "objectstore")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewObjectStore("file://"+dir, "", "", "", "")
+	require.Nil(err)
+
+	_, err = store.Get("a/b")
+	assert.Equal(ErrObjectNotFound, err)
+	require.Nil(store.Put("a/b", []byte("hello")))
+	data, err := store.Get("a/b")
+	require.Nil(err)
+	assert.Equal([]byte("hello"), data)
+}
+
+func TestS3Store(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mu := &sync.Mutex{}
+	objects := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=access/20200102/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("x-amz-content-sha256") != sha256Hex(body) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "PUT":
+			objects[r.URL.Path] = body
+		case "GET":
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	store := NewS3Store(server.URL, "bucket", "us-east-1", "access", "secret")
+	store.now = func() time.Time { return time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	_, err := store.Get("chain/blocks/0x01")
+	assert.Equal(ErrObjectNotFound, err)
+	require.Nil(store.Put("chain/blocks/0x01", []byte("hello")))
+	assert.Equal([]byte("hello"), objects["/bucket/chain/blocks/0x01"])
+	data, err := store.Get("chain/blocks/0x01")
+	require.Nil(err)
+	assert.Equal([]byte("hello"), data)
+
+	_, err = NewObjectStore(server.URL, "", "us-east-1", "access", "secret")
+	assert.NotNil(err, "missing bucket")
+	_, err = NewObjectStore("ftp://host", "bucket", "us-east-1", "access", "secret")
+	assert.NotNil(err)
+}
+
+func TestS3Signature(t *testing.T) {
+	assert := assert.New(t)
+
+	// Example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	assert.Equal("c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+
+	assert.Equal("/bucket/a%20b/c~d", uriEncode("/bucket/a b/c~d"))
+	assert.Equal("e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", sha256Hex(nil))
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/thetatoken/theta/store"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+var logger = log.WithFields(log.Fields{"prefix": "schema"})
+
+// versionKey is the key of the schema version record. A database without the record predates
+// the schema versioning, i.e. has version 0.
+var versionKey = []byte("schema/version")
+
+// Migration upgrades the database from Version-1 to Version. A migration is applied at most once,
+// and the version is recorded right after it completes, so it must tolerate being re-run on a
+// partially migrated database if the node is interrupted in the middle. Fresh databases go
+// through all the migrations too. Migrate is nil for a version recording a change the older
+// releases can not run on, without anything to convert in the database.
+type Migration struct {
+	Version     uint64
+	Description string
+	Migrate     func(db database.Database) error
+}
+
+// Migrations lists the migrations in the order of their versions. A release changing the layout
+// of the keys or of the values appends a migration here.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Record the schema version",
+	},
+	{
+		// The reserved fund removal index ("ls/rflh/" keys) is written to the state from the
+		// ForkReservedFundRemoval height on, nothing to convert. Recording the version keeps the
+		// older releases, which compute different state roots past the fork, off the database.
+		Version:     2,
+		Description: "Index the heights at which the reserved funds are removed from the accounts",
+	},
+	{
+		// The number of account shards is fixed at genesis, the chains started before have no
+		// shards, nothing to convert.
+		Version:     3,
+		Description: "Partition the accounts into shard tries",
+	},
+}
+
+// CurrentVersion returns the schema version of the databases written by this release.
+func CurrentVersion() uint64 {
+	return Migrations[len(Migrations)-1].Version
+}
+
+// GetVersion returns the schema version of the database.
+func GetVersion(db database.Database) (uint64, error) {
+	var version uint64
+	err := kvstore.NewKVStore(db).Get(versionKey, &version)
+	if err == store.ErrKeyNotFound {
+		return 0, nil
+	}
+	return version, err
+}
+
+func setVersion(db database.Database, version uint64) error {
+	return kvstore.NewKVStore(db).Put(versionKey, version)
+}
+
+// NeedsMigration returns whether the database has to be migrated before use. It fails if the
+// database has been written by a newer release, which this release cannot run on.
+func NeedsMigration(db database.Database) (bool, error) {
+	version, err := GetVersion(db)
+	if err != nil {
+		return false, fmt.Errorf("Failed to read the schema version: %v", err)
+	}
+	if version > CurrentVersion() {
+		return false, fmt.Errorf("The database has schema version %v, newer than version %v supported by this release. "+
+			"Please upgrade the node", version, CurrentVersion())
+	}
+	return version < CurrentVersion(), nil
+}
+
+// NeedsBackup returns whether the migration of the database to the current schema version
+// converts any data, i.e. whether it is worth backing up the database before.
+func NeedsBackup(db database.Database) (bool, error) {
+	return needsBackup(db, Migrations)
+}
+
+func needsBackup(db database.Database, migrations []Migration) (bool, error) {
+	version, err := GetVersion(db)
+	if err != nil {
+		return false, fmt.Errorf("Failed to read the schema version: %v", err)
+	}
+	for _, m := range migrations {
+		if m.Version > version && m.Migrate != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Migrate upgrades the database to the current schema version.
+func Migrate(db database.Database) error {
+	return migrate(db, Migrations)
+}
+
+func migrate(db database.Database, migrations []Migration) error {
+	version, err := GetVersion(db)
+	if err != nil {
+		return fmt.Errorf("Failed to read the schema version: %v", err)
+	}
+	target := migrations[len(migrations)-1].Version
+	if version > target {
+		return fmt.Errorf("The database has schema version %v, newer than version %v supported by this release. "+
+			"Please upgrade the node", version, target)
+	}
+
+	for _, m := range migrations {
+		if m.Version <= version {
+			continue
+		}
+		if m.Version != version+1 {
+			return fmt.Errorf("Missing migration from schema version %v to %v", version, version+1)
+		}
+
+		logger.WithFields(log.Fields{"version": m.Version, "description": m.Description}).Info("Migrating database")
+		if m.Migrate != nil {
+			if err := m.Migrate(db); err != nil {
+				return fmt.Errorf("Failed to migrate the database to schema version %v: %v", m.Version, err)
+			}
+		}
+		if err := setVersion(db, m.Version); err != nil {
+			return fmt.Errorf("Failed to record schema version %v: %v", m.Version, err)
+		}
+		version = m.Version
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/store/database"
+	"github.com/thetatoken/theta/store/database/backend"
+)
+
+func TestMigrate(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	version, err := GetVersion(db)
+	require.Nil(err)
+	require.Equal(uint64(0), version)
+
+	applied := []uint64{}
+	migrations := []Migration{}
+	for v := uint64(1); v <= 3; v++ {
+		v := v
+		migrations = append(migrations, Migration{
+			Version: v,
+			Migrate: func(db database.Database) error {
+				applied = append(applied, v)
+				return nil
+			},
+		})
+	}
+
+	require.Nil(migrate(db, migrations[:2]))
+	require.Equal([]uint64{1, 2}, applied)
+
+	// Only the new migrations are applied on upgrade.
+	require.Nil(migrate(db, migrations))
+	require.Equal([]uint64{1, 2, 3}, applied)
+	version, err = GetVersion(db)
+	require.Nil(err)
+	require.Equal(uint64(3), version)
+
+	// Refuses to run on a database written by a newer release.
+	require.NotNil(migrate(db, migrations[:2]))
+}
+
+func TestMigrateFailure(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	migrations := []Migration{
+		{Version: 1, Migrate: func(db database.Database) error { return nil }},
+		{Version: 2, Migrate: func(db database.Database) error { return errors.New("failed") }},
+	}
+	require.NotNil(migrate(db, migrations))
+
+	// The version of the last successful migration is recorded.
+	version, err := GetVersion(db)
+	require.Nil(err)
+	require.Equal(uint64(1), version)
+}
+
+func TestNeedsBackup(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	migrations := []Migration{
+		{Version: 1},
+		{Version: 2, Migrate: func(db database.Database) error { return nil }},
+		{Version: 3},
+	}
+	needed, err := needsBackup(db, migrations)
+	require.Nil(err)
+	require.True(needed)
+
+	// Only the versions without data to convert are pending
+	require.Nil(setVersion(db, 2))
+	needed, err = needsBackup(db, migrations)
+	require.Nil(err)
+	require.False(needed)
+	require.Nil(migrate(db, migrations))
+	version, err := GetVersion(db)
+	require.Nil(err)
+	require.Equal(uint64(3), version)
+}
+
+func TestNeedsMigration(t *testing.T) {
+	require := require.New(t)
+
+	db := backend.NewMemDatabase()
+	needed, err := NeedsMigration(db)
+	require.Nil(err)
+	require.True(needed)
+
+	require.Nil(Migrate(db))
+	needed, err = NeedsMigration(db)
+	require.Nil(err)
+	require.False(needed)
+
+	require.Nil(setVersion(db, CurrentVersion()+1))
+	_, err = NeedsMigration(db)
+	require.NotNil(err)
+}
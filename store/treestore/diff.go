@@ -0,0 +1,35 @@
+package treestore
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/store/trie"
+)
+
+// Diff calls cb on the key/value pairs of the trie of `to` which are not in the trie of `from`,
+// i.e. the keys added or updated with their new value, and then on the keys of `from` deleted in
+// `to` with an empty value. The subtries shared by both tries are skipped, so the cost depends on
+// the size of the difference rather than on the size of the tries. It stops when cb returns false.
+func Diff(from, to *TreeStore, cb func(k, v common.Bytes) bool) error {
+	updated, _ := trie.NewDifferenceIterator(from.NodeIterator(nil), to.NodeIterator(nil))
+	it := trie.NewIterator(updated)
+	for it.Next() {
+		if !cb(it.Key, it.Value) {
+			return nil
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+
+	removed, _ := trie.NewDifferenceIterator(to.NodeIterator(nil), from.NodeIterator(nil))
+	it = trie.NewIterator(removed)
+	for it.Next() {
+		if len(to.Get(it.Key)) != 0 {
+			continue // updated
+		}
+		if !cb(it.Key, nil) {
+			return nil
+		}
+	}
+	return it.Err
+}
@@ -51,17 +51,30 @@ func (store *TreeStore) Commit() (common.Hash, error) {
 // passed to the Revert() function needs to be one of the previous roots,
 // otherwise the function will return an error.
 func (store *TreeStore) Revert(root common.Hash) (*TreeStore, error) {
+	return store.Open(root)
+}
+
+// Open creates a TreeStore for the Trie with the given root, sharing the in-memory
+// trie DB of the current Trie, e.g. for a Trie whose root is a value of this one.
+// The nodes committed to the shared trie DB are visible to both TreeStores.
+func (store *TreeStore) Open(root common.Hash) (*TreeStore, error) {
 	trieDB := store.Trie.GetDB()
-	revertedTrie, err := trie.New(root, trieDB)
+	openedTrie, err := trie.New(root, trieDB)
 	if err != nil {
 		return nil, err
 	}
 
-	revertedStore := &TreeStore{
-		Trie: revertedTrie,
+	openedStore := &TreeStore{
+		Trie: openedTrie,
 		db:   store.db,
 	}
-	return revertedStore, nil
+	return openedStore, nil
+}
+
+// CommitTrie persists the Trie with the given root from the in-memory trie DB,
+// e.g. a Trie opened with Open() and committed to the trie DB since.
+func (store *TreeStore) CommitTrie(root common.Hash) error {
+	return store.Trie.GetDB().Commit(root, true)
 }
 
 // Copy returns a copy of the TreeStore
@@ -99,6 +99,37 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb database.Putter)
 	return t.trie.Prove(key, fromLevel, proofDb)
 }
 
+// ProveBatch constructs a single merkle proof for many keys. The nodes shared by the
+// paths to the keys, e.g. the root node, are put once in proofDb if it is keyed by the
+// node hash, like the proof databases are.
+func (t *Trie) ProveBatch(keys [][]byte, proofDb database.Putter) error {
+	for _, key := range keys {
+		if err := t.Prove(key, 0, proofDb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProveBatch constructs a single merkle proof for many keys, see Trie.ProveBatch.
+func (t *SecureTrie) ProveBatch(keys [][]byte, proofDb database.Putter) error {
+	return t.trie.ProveBatch(keys, proofDb)
+}
+
+// VerifyBatchProof checks a merkle proof of many keys, as constructed by ProveBatch.
+// It returns the values of the keys in order, nil for the keys the proof shows are
+// absent.
+func VerifyBatchProof(rootHash common.Hash, keys [][]byte, proofDb DatabaseReader) (values [][]byte, err error) {
+	values = make([][]byte, len(keys))
+	for i, key := range keys {
+		values[i], _, err = VerifyProof(rootHash, key, proofDb)
+		if err != nil {
+			return nil, fmt.Errorf("key %x: %v", key, err)
+		}
+	}
+	return values, nil
+}
+
 // VerifyProof checks merkle proofs. The given proof must contain the value for
 // key in a trie with the given root hash. VerifyProof returns an error if the
 // proof contains invalid trie nodes or the wrong value.
@@ -144,6 +144,53 @@ func TestMissingKeyProof(t *testing.T) {
 	}
 }
 
+func TestBatchProof(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+
+	keys := [][]byte{}
+	for _, kv := range vals {
+		keys = append(keys, kv.k)
+		if len(keys) == 50 {
+			break
+		}
+	}
+	missing := randBytes(32)
+	keys = append(keys, missing)
+
+	proof := dbbackend.NewMemDatabase()
+	if err := trie.ProveBatch(keys, proof); err != nil {
+		t.Fatalf("failed to construct batch proof: %v", err)
+	}
+	separateSize := 0
+	for _, key := range keys {
+		single := dbbackend.NewMemDatabase()
+		trie.Prove(key, 0, single)
+		separateSize += single.Len()
+	}
+	if proof.Len() >= separateSize {
+		t.Errorf("batch proof should share nodes: %d nodes, %d nodes in separate proofs", proof.Len(), separateSize)
+	}
+
+	values, err := VerifyBatchProof(root, keys, proof)
+	if err != nil {
+		t.Fatalf("failed to verify batch proof: %v", err)
+	}
+	for i, key := range keys[:len(keys)-1] {
+		if !bytes.Equal(values[i], vals[string(key)].v) {
+			t.Fatalf("verified value mismatch for key %x: have %x, want %x", key, values[i], vals[string(key)].v)
+		}
+	}
+	if values[len(keys)-1] != nil {
+		t.Fatalf("verified value mismatch for missing key %x: have %x, want nil", missing, values[len(keys)-1])
+	}
+
+	// The proof is only valid for the root
+	if _, err := VerifyBatchProof(common.BytesToHash([]byte("other root")), keys, proof); err == nil {
+		t.Fatalf("expected batch proof to fail for another root")
+	}
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {
@@ -0,0 +1,302 @@
+package txindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/webhook"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "txindex"})
+
+// Interval at which the newly finalized blocks are checked
+const pollInterval = 1 * time.Second
+
+// Max number of blocks indexed per poll, so that catching up does not delay stopping the indexer
+const maxBlocksPerPoll = 1000
+
+// MaxLimit is the max number of transactions returned by a query.
+const MaxLimit = 1000
+
+// Key of the height of the next block to index, written along with the entries of each block so
+// that the index resumes from there after a restart
+var cursorKey = []byte("txindex/cursor")
+
+// Prefix of the entries, keyed by address, block height and index of the transaction in the block
+var entryPrefix = []byte("a/")
+
+const entryKeyLen = 2 + common.AddressLength + 8 + 4
+
+const (
+	flagSent     byte = 1 << 0
+	flagReceived byte = 1 << 1
+)
+
+// Direction selects the transactions of an address by the role of the address.
+type Direction int
+
+const (
+	// DirectionAll selects the transactions sent or received by the address
+	DirectionAll Direction = iota
+	// DirectionSent selects the transactions the address is an input of
+	DirectionSent
+	// DirectionReceived selects the transactions the address receives from or is otherwise affected by
+	DirectionReceived
+)
+
+// Position locates a transaction in the chain.
+type Position struct {
+	Height uint64
+	Index  uint32 // Index of the transaction in the block
+}
+
+// Entry is a transaction involving an address.
+type Entry struct {
+	Position
+	TxHash   common.Hash
+	Sent     bool
+	Received bool
+}
+
+// Indexer maintains the index of the transactions of the finalized blocks by address.
+type Indexer struct {
+	chain    *blockchain.Chain
+	finality core.FinalityProvider
+	db       *leveldb.DB
+
+	mu         *sync.Mutex
+	nextHeight uint64
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// OpenIndexer creates a new instance of Indexer storing the index in the LevelDB at path.
+func OpenIndexer(chain *blockchain.Chain, finality core.FinalityProvider, path string) (*Indexer, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open the transaction index %v: %v", path, err)
+	}
+	return NewIndexer(chain, finality, db)
+}
+
+// NewIndexer creates a new instance of Indexer storing the index in db. The indexing starts from
+// the root of the chain, or resumes from where it stopped if db already has an index.
+func NewIndexer(chain *blockchain.Chain, finality core.FinalityProvider, db *leveldb.DB) (*Indexer, error) {
+	ix := &Indexer{
+		chain:      chain,
+		finality:   finality,
+		db:         db,
+		mu:         &sync.Mutex{},
+		nextHeight: chain.Root().Height,
+		wg:         &sync.WaitGroup{},
+	}
+	cursor, err := db.Get(cursorKey, nil)
+	if err == nil && len(cursor) == 8 {
+		ix.nextHeight = binary.BigEndian.Uint64(cursor)
+	} else if err != nil && err != leveldb.ErrNotFound {
+		return nil, fmt.Errorf("Failed to read the transaction index cursor: %v", err)
+	}
+	return ix, nil
+}
+
+// Start creates the main goroutine.
+func (ix *Indexer) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	ix.ctx = c
+	ix.cancel = cancel
+
+	ix.wg.Add(1)
+	go ix.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (ix *Indexer) Stop() {
+	ix.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (ix *Indexer) Wait() {
+	ix.wg.Wait()
+}
+
+func (ix *Indexer) mainLoop() {
+	defer ix.wg.Done()
+	defer ix.db.Close()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ix.ctx.Done():
+			return
+		case <-ticker.C:
+			ix.indexFinalizedBlocks()
+		}
+	}
+}
+
+// IndexedHeight returns the height up to which the finalized blocks are indexed, and false if no
+// block is indexed yet.
+func (ix *Indexer) IndexedHeight() (uint64, bool) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.nextHeight == 0 {
+		return 0, false
+	}
+	return ix.nextHeight - 1, true
+}
+
+// indexFinalizedBlocks indexes the blocks finalized since the last call.
+func (ix *Indexer) indexFinalizedBlocks() {
+	lastFinalized := ix.finality.GetLastFinalizedBlock()
+	for i := 0; i < maxBlocksPerPoll; i++ {
+		ix.mu.Lock()
+		height := ix.nextHeight
+		ix.mu.Unlock()
+		if height > lastFinalized.Height {
+			return
+		}
+
+		var block *core.Block
+		if eb := ix.findFinalizedBlock(height); eb != nil {
+			block = eb.Block
+		} else {
+			logger.WithFields(log.Fields{"height": height}).Debug("Finalized block not found, skipping it")
+		}
+		if err := ix.indexBlock(height, block); err != nil {
+			logger.WithFields(log.Fields{"height": height, "error": err}).Error("Failed to index block")
+			return
+		}
+
+		ix.mu.Lock()
+		ix.nextHeight = height + 1
+		ix.mu.Unlock()
+	}
+}
+
+func (ix *Indexer) findFinalizedBlock(height uint64) *core.ExtendedBlock {
+	for _, block := range ix.chain.FindBlocksByHeight(height) {
+		if block.Status.IsFinalized() {
+			return block
+		}
+	}
+	return nil
+}
+
+// indexBlock writes the entries of the transactions of the block, if any, and moves the cursor
+// past height atomically.
+func (ix *Indexer) indexBlock(height uint64, block *core.Block) error {
+	batch := new(leveldb.Batch)
+	if block != nil {
+		for i, rawTx := range block.Txs {
+			tx, err := types.TxFromBytes(rawTx)
+			if err != nil {
+				logger.WithFields(log.Fields{"height": height, "index": i, "error": err}).Warn("Failed to decode transaction")
+				continue
+			}
+			flags := make(map[common.Address]byte)
+			senders, recipients := webhook.TxParticipants(tx)
+			for _, address := range senders {
+				flags[address] |= flagSent
+			}
+			for _, address := range recipients {
+				flags[address] |= flagReceived
+			}
+			txHash := crypto.Keccak256Hash(rawTx)
+			for address, f := range flags {
+				value := append(txHash.Bytes(), f)
+				batch.Put(entryKey(address, Position{Height: height, Index: uint32(i)}), value)
+			}
+		}
+	}
+	cursor := make([]byte, 8)
+	binary.BigEndian.PutUint64(cursor, height+1)
+	batch.Put(cursorKey, cursor)
+	return ix.db.Write(batch, nil)
+}
+
+// GetTransactions returns up to limit transactions of the address in the given direction, the
+// oldest first if ascending and the most recent first otherwise. The listing starts right after
+// the given position if not nil, so the position of the last entry of a page gives the next page.
+func (ix *Indexer) GetTransactions(address common.Address, direction Direction, after *Position,
+	limit int, ascending bool) ([]Entry, error) {
+	if limit <= 0 || limit > MaxLimit {
+		return nil, fmt.Errorf("Limit must be between 1 and %v", MaxLimit)
+	}
+
+	r := util.BytesPrefix(append(append([]byte{}, entryPrefix...), address.Bytes()...))
+	if after != nil {
+		if ascending {
+			// The keys have the same length, so the key followed by a zero byte is the
+			// smallest key greater than it
+			r.Start = append(entryKey(address, *after), 0)
+		} else {
+			r.Limit = entryKey(address, *after)
+		}
+	}
+	it := ix.db.NewIterator(r, nil)
+	defer it.Release()
+
+	entries := []Entry{}
+	next := it.Next
+	ok := it.First()
+	if !ascending {
+		next = it.Prev
+		ok = it.Last()
+	}
+	for ; ok && len(entries) < limit; ok = next() {
+		entry, err := decodeEntry(it.Key(), it.Value())
+		if err != nil {
+			return nil, err
+		}
+		if (direction == DirectionSent && !entry.Sent) || (direction == DirectionReceived && !entry.Received) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func entryKey(address common.Address, pos Position) []byte {
+	key := make([]byte, 0, entryKeyLen)
+	key = append(key, entryPrefix...)
+	key = append(key, address.Bytes()...)
+	key = append(key, make([]byte, 12)...)
+	binary.BigEndian.PutUint64(key[len(key)-12:], pos.Height)
+	binary.BigEndian.PutUint32(key[len(key)-4:], pos.Index)
+	return key
+}
+
+func decodeEntry(key, value []byte) (Entry, error) {
+	if len(key) != entryKeyLen || !bytes.HasPrefix(key, entryPrefix) || len(value) != common.HashLength+1 {
+		return Entry{}, fmt.Errorf("Malformed transaction index entry: %x", key)
+	}
+	flags := value[common.HashLength]
+	return Entry{
+		Position: Position{
+			Height: binary.BigEndian.Uint64(key[entryKeyLen-12:]),
+			Index:  binary.BigEndian.Uint32(key[entryKeyLen-4:]),
+		},
+		TxHash:   common.BytesToHash(value[:common.HashLength]),
+		Sent:     flags&flagSent != 0,
+		Received: flags&flagReceived != 0,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package txindex
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+type mockConsensus struct {
+	core.FinalityProvider
+	chain *blockchain.Chain
+	mu    *sync.Mutex
+	last  *core.ExtendedBlock
+}
+
+func (c *mockConsensus) GetLastFinalizedBlock() *core.ExtendedBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+func (c *mockConsensus) finalize(block *core.Block) {
+	c.chain.FinalizePreviousBlocks(block.Hash())
+	eb, _ := c.chain.FindBlock(block.Hash())
+	c.mu.Lock()
+	c.last = eb
+	c.mu.Unlock()
+}
+
+func newTestSendTx(from, to common.Address, tfuel int64) common.Bytes {
+	tx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: from, Coins: types.NewCoins(0, tfuel+1000000000000)}},
+		Outputs: []types.TxOutput{{Address: to, Coins: types.NewCoins(0, tfuel)}},
+	}
+	raw, _ := types.TxToBytes(tx)
+	return raw
+}
+
+func TestIndexer(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0xc15E2D5e8e5B0b2cB4a0BA0E5bB4eAE49a6f2E3E")
+	carol := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("txindex_root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	rootBlock, _ := chain.FindBlock(root.Hash())
+	consensus := &mockConsensus{chain: chain, mu: &sync.Mutex{}, last: rootBlock}
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	require.Nil(err)
+	defer db.Close()
+	ix, err := NewIndexer(chain, consensus, db)
+	require.Nil(err)
+
+	txAliceBob := newTestSendTx(alice, bob, 100)
+	txBobAlice := newTestSendTx(bob, alice, 200)
+	txAliceAlice := newTestSendTx(alice, alice, 300)
+	txBobCarol := newTestSendTx(bob, carol, 400)
+	b1 := core.CreateTestBlock("txindex_b1", "txindex_root")
+	b1.AddTxs([]common.Bytes{txAliceBob})
+	b2 := core.CreateTestBlock("txindex_b2", "txindex_b1")
+	b2.AddTxs([]common.Bytes{txBobAlice, txAliceAlice})
+	b3 := core.CreateTestBlock("txindex_b3", "txindex_b2")
+	b3.AddTxs([]common.Bytes{txBobCarol})
+	for _, b := range []*core.Block{b1, b2, b3} {
+		_, err = chain.AddBlock(b)
+		require.Nil(err)
+	}
+
+	// Only the finalized blocks are indexed
+	consensus.finalize(b2)
+	ix.indexFinalizedBlocks()
+	height, ok := ix.IndexedHeight()
+	assert.True(ok)
+	assert.Equal(b2.Height, height)
+	entries, err := ix.GetTransactions(carol, DirectionAll, nil, 10, true)
+	require.Nil(err)
+	assert.Equal(0, len(entries))
+
+	consensus.finalize(b3)
+	ix.indexFinalizedBlocks()
+	height, _ = ix.IndexedHeight()
+	assert.Equal(b3.Height, height)
+
+	hash := crypto.Keccak256Hash
+	entries, err = ix.GetTransactions(alice, DirectionAll, nil, 10, true)
+	require.Nil(err)
+	assert.Equal([]Entry{
+		{Position{b1.Height, 0}, hash(txAliceBob), true, false},
+		{Position{b2.Height, 0}, hash(txBobAlice), false, true},
+		{Position{b2.Height, 1}, hash(txAliceAlice), true, true},
+	}, entries)
+
+	entries, err = ix.GetTransactions(bob, DirectionAll, nil, 10, false)
+	require.Nil(err)
+	assert.Equal([]Entry{
+		{Position{b3.Height, 0}, hash(txBobCarol), true, false},
+		{Position{b2.Height, 0}, hash(txBobAlice), true, false},
+		{Position{b1.Height, 0}, hash(txAliceBob), false, true},
+	}, entries)
+
+	// Directions
+	entries, err = ix.GetTransactions(alice, DirectionSent, nil, 10, true)
+	require.Nil(err)
+	assert.Equal([]Entry{
+		{Position{b1.Height, 0}, hash(txAliceBob), true, false},
+		{Position{b2.Height, 1}, hash(txAliceAlice), true, true},
+	}, entries)
+	entries, err = ix.GetTransactions(bob, DirectionReceived, nil, 10, true)
+	require.Nil(err)
+	assert.Equal([]Entry{{Position{b1.Height, 0}, hash(txAliceBob), false, true}}, entries)
+
+	// Pagination in both orders
+	for _, ascending := range []bool{true, false} {
+		all, err := ix.GetTransactions(alice, DirectionAll, nil, 10, ascending)
+		require.Nil(err)
+		paged := []Entry{}
+		var after *Position
+		for {
+			page, err := ix.GetTransactions(alice, DirectionAll, after, 2, ascending)
+			require.Nil(err)
+			paged = append(paged, page...)
+			if len(page) < 2 {
+				break
+			}
+			after = &page[len(page)-1].Position
+		}
+		assert.Equal(all, paged)
+	}
+
+	_, err = ix.GetTransactions(alice, DirectionAll, nil, MaxLimit+1, true)
+	assert.NotNil(err)
+
+	// The indexing resumes from the cursor
+	ix, err = NewIndexer(chain, consensus, db)
+	require.Nil(err)
+	height, _ = ix.IndexedHeight()
+	assert.Equal(b3.Height, height)
+}
@@ -0,0 +1,61 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// The build information is set at build time through the linker flags, e.g.
+//
+//	go build -ldflags "-X github.com/thetatoken/theta/version.Version=1.0.0 ..."
+//
+// See the LDFLAGS in the Makefile. Timestamp is the time of the commit rather than the
+// time of the build, so that building the same commit always produces the same binary.
+var (
+	// Version is the semantic version of the binary
+	Version = "0.0.0-dev"
+	// GitHash is the commit the binary is built from
+	GitHash = "unknown"
+	// Timestamp is the time of the commit the binary is built from
+	Timestamp = "unknown"
+	// Features lists the optional features enabled in the build, comma separated
+	Features = ""
+)
+
+// Info is the build information of the binary
+type Info struct {
+	Version   string   `json:"version"`
+	GitHash   string   `json:"git_hash"`
+	Timestamp string   `json:"timestamp"`
+	Features  []string `json:"features"`
+	GoVersion string   `json:"go_version"`
+	Platform  string   `json:"platform"`
+}
+
+// GetInfo returns the build information of the binary
+func GetInfo() Info {
+	features := []string{}
+	for _, feature := range strings.Split(Features, ",") {
+		if feature = strings.TrimSpace(feature); len(feature) > 0 {
+			features = append(features, feature)
+		}
+	}
+	return Info{
+		Version:   Version,
+		GitHash:   GitHash,
+		Timestamp: Timestamp,
+		Features:  features,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+func (info Info) String() string {
+	features := "none"
+	if len(info.Features) > 0 {
+		features = strings.Join(info.Features, ",")
+	}
+	return fmt.Sprintf("Version %v %v\nBuilt at %v\nFeatures: %v\n%v %v",
+		info.Version, info.GitHash, info.Timestamp, features, info.GoVersion, info.Platform)
+}
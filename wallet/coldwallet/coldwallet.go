@@ -136,9 +136,23 @@ func (w *ColdWallet) UpdatePassword(address common.Address, oldPassword, newPass
 	return fmt.Errorf("Not supported for cold wallet")
 }
 
+// Derive derives the address at the path. If pin is set, the account is tracked, so that the
+// transactions can be signed with its key.
 func (w *ColdWallet) Derive(path types.DerivationPath, pin bool) (common.Address, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.device == nil {
+		return common.Address{}, fmt.Errorf("wallet closed")
+	}
 	address, err := w.driver.Derive(path)
-	return address, err
+	if err != nil {
+		return common.Address{}, err
+	}
+	if pin {
+		w.addressPathMap[address] = path
+	}
+	return address, nil
 }
 
 func (w *ColdWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
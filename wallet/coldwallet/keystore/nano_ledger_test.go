@@ -0,0 +1,122 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/wallet/types"
+)
+
+// testLedgerDevice emulates the HID transport of a Ledger device, replying to each APDU with the
+// reply of the handler.
+type testLedgerDevice struct {
+	handle func(apdu []byte) []byte
+
+	request []byte   // APDU being received
+	length  int      // length of the APDU being received
+	chunks  [][]byte // chunks of the replies to read
+}
+
+func (d *testLedgerDevice) Write(chunk []byte) (int, error) {
+	if binary.BigEndian.Uint16(chunk[3:5]) == 0 {
+		d.length = int(binary.BigEndian.Uint16(chunk[5:7]))
+		d.request = append([]byte{}, chunk[7:]...)
+	} else {
+		d.request = append(d.request, chunk[5:]...)
+	}
+	if len(d.request) < d.length {
+		return len(chunk), nil
+	}
+
+	reply := append(d.handle(d.request[:d.length]), 0x90, 0x00) // status word: success
+	payload := make([]byte, 2, len(reply)+2)
+	binary.BigEndian.PutUint16(payload, uint16(len(reply)))
+	payload = append(payload, reply...)
+	for i := 0; len(payload) > 0; i++ {
+		next := make([]byte, 64)
+		copy(next, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(next[3:], uint16(i))
+		n := copy(next[5:], payload)
+		payload = payload[n:]
+		d.chunks = append(d.chunks, next)
+	}
+	return len(chunk), nil
+}
+
+func (d *testLedgerDevice) Read(p []byte) (int, error) {
+	n := copy(p, d.chunks[0])
+	d.chunks = d.chunks[1:]
+	return n, nil
+}
+
+func TestLedgerDriver(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	privKey, _, err := crypto.GenerateKeyPair()
+	require.Nil(err)
+	address := privKey.PublicKey().Address()
+	path, err := types.ParseDerivationPath("m/44'/500'/0'/0/0")
+	require.Nil(err)
+
+	encodedPath := []byte{byte(len(path))}
+	for _, component := range path {
+		encodedPath = append(encodedPath, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(encodedPath[len(encodedPath)-4:], component)
+	}
+
+	var txrlp []byte // the transaction received so far
+	device := &testLedgerDevice{handle: func(apdu []byte) []byte {
+		op, p1, data := ledgerOpcode(apdu[1]), ledgerParam1(apdu[2]), apdu[5:]
+		assert.Equal(byte(0xe0), apdu[0])
+		assert.Equal(int(apdu[4]), len(data))
+		switch op {
+		case ledgerOpGetConfiguration:
+			return []byte{0x01, 1, 2, 3}
+		case ledgerOpRetrieveAddress:
+			pubKey := privKey.PublicKey().ToBytes()
+			addrHex := []byte(hex.EncodeToString(address[:]))
+			if !bytes.Equal(encodedPath, data) {
+				addrHex = []byte(hex.EncodeToString(make([]byte, 20)))
+			}
+			reply := append([]byte{byte(len(pubKey))}, pubKey...)
+			return append(append(reply, byte(len(addrHex))), addrHex...)
+		case ledgerOpSignTransaction:
+			if p1 == ledgerP1InitTransactionData {
+				assert.Equal(encodedPath, data[:len(encodedPath)])
+				txrlp = append([]byte{}, data[len(encodedPath):]...)
+			} else {
+				txrlp = append(txrlp, data...)
+			}
+			sig, err := privKey.Sign(txrlp)
+			require.Nil(err)
+			sigBytes := sig.ToBytes()
+			return append([]byte{sigBytes[64] + 27}, sigBytes[:64]...)
+		}
+		return nil
+	}}
+
+	driver := NewLedgerDriver()
+	require.Nil(driver.Open(device, ""))
+	status, err := driver.Status()
+	require.Nil(err)
+	assert.Equal("Ethereum app v1.2.3 online", status)
+
+	derived, err := driver.Derive(path)
+	require.Nil(err)
+	assert.Equal(address, derived)
+
+	// The transactions longer than 255 bytes are sent in several APDUs
+	tx := common.Bytes(bytes.Repeat([]byte{0xab}, 600))
+	signer, sig, err := driver.SignTx(path, tx)
+	require.Nil(err)
+	assert.Equal(address, signer)
+	assert.Equal(tx, common.Bytes(txrlp))
+	assert.True(sig.Verify(tx, address))
+}
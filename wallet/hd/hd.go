@@ -0,0 +1,110 @@
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/thetatoken/theta/common/math"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/crypto/secp256k1"
+	"github.com/thetatoken/theta/wallet/types"
+)
+
+// HardenedKeyStart is the index of the first hardened child key
+const HardenedKeyStart = uint32(0x80000000)
+
+// masterKeySalt is the HMAC key deriving the master key from the seed, as specified by BIP-32
+var masterKeySalt = []byte("Bitcoin seed")
+
+var (
+	ErrInvalidSeedLength = errors.New("The seed must be between 16 and 64 bytes")
+	ErrInvalidKey        = errors.New("The derived key is invalid, please use the next index")
+)
+
+// ExtendedKey is a BIP-32 extended private key, i.e. a private key with its chain code, from which
+// the child keys are derived.
+type ExtendedKey struct {
+	key       []byte // 32 bytes
+	chainCode []byte // 32 bytes
+}
+
+// NewMasterKey derives the master key of the hierarchy from the seed
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeedLength
+	}
+	mac := hmac.New(sha512.New, masterKeySalt)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(secp256k1.S256().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	return &ExtendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// Child derives the child key at the index, the hardened child keys starting at HardenedKeyStart.
+// In the rare case the derived key is invalid, ErrInvalidKey is returned.
+func (ek *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	var data []byte
+	if index >= HardenedKeyStart {
+		data = append([]byte{0x0}, ek.key...)
+	} else {
+		curve := secp256k1.S256()
+		x, y := curve.ScalarBaseMult(ek.key)
+		data = secp256k1.CompressPubkey(x, y)
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	mac := hmac.New(sha512.New, ek.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := secp256k1.S256().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	k := il.Add(il, new(big.Int).SetBytes(ek.key))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, ErrInvalidKey
+	}
+	return &ExtendedKey{key: math.PaddedBigBytes(k, 32), chainCode: sum[32:]}, nil
+}
+
+// Derive derives the descendant key along the path
+func (ek *ExtendedKey) Derive(path types.DerivationPath) (*ExtendedKey, error) {
+	key := ek
+	for _, index := range path {
+		var err error
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// PrivateKey returns the private key of the extended key
+func (ek *ExtendedKey) PrivateKey() (*crypto.PrivateKey, error) {
+	return crypto.PrivateKeyFromBytes(ek.key)
+}
+
+// DeriveFromSeed derives the private key at the path from the seed
+func DeriveFromSeed(seed []byte, path types.DerivationPath) (*crypto.PrivateKey, error) {
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey()
+}
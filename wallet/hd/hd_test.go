@@ -0,0 +1,73 @@
+package hd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/wallet/types"
+)
+
+// Test vector 1 of BIP-32
+func TestDerive(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	require.Nil(err)
+	assert.Equal("e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(master.key))
+	assert.Equal("873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", hex.EncodeToString(master.chainCode))
+
+	tests := []struct {
+		path      string
+		key       string
+		chainCode string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca", "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f"},
+		{"m/0'/1/2'/2", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4", "cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", "c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e"},
+	}
+	for _, test := range tests {
+		path, err := types.ParseDerivationPath(test.path)
+		require.Nil(err)
+		key, err := master.Derive(path)
+		require.Nil(err, test.path)
+		assert.Equal(test.key, hex.EncodeToString(key.key), test.path)
+		assert.Equal(test.chainCode, hex.EncodeToString(key.chainCode), test.path)
+	}
+
+	_, err = NewMasterKey(seed[:15])
+	assert.Equal(ErrInvalidSeedLength, err)
+}
+
+func TestMnemonic(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	mnemonic, err := NewMnemonic()
+	require.Nil(err)
+	assert.Equal(24, len(strings.Fields(mnemonic)))
+	seed, err := SeedFromMnemonic(mnemonic, "")
+	require.Nil(err)
+	assert.Equal(64, len(seed))
+
+	// The first account of the default path, the same as the other BIP-44 wallets
+	seed, err = SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon  about", "")
+	require.Nil(err)
+	assert.Equal("5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4", hex.EncodeToString(seed))
+	privKey, err := DeriveFromSeed(seed, types.DefaultBaseDerivationPath)
+	require.Nil(err)
+	assert.Equal("1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727", hex.EncodeToString(privKey.ToBytes()))
+	assert.Equal(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), privKey.PublicKey().Address())
+
+	_, err = SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "")
+	assert.NotNil(err, "invalid checksum")
+	_, err = SeedFromMnemonic("abandon abandon theta", "")
+	assert.NotNil(err)
+}
@@ -0,0 +1,25 @@
+package hd
+
+import (
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// MnemonicEntropyBits is the entropy of the generated mnemonics, encoded by 24 words
+const MnemonicEntropyBits = 256
+
+// NewMnemonic generates a new BIP-39 mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MnemonicEntropyBits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic validates the BIP-39 mnemonic and returns its seed. The passphrase is optional.
+func SeedFromMnemonic(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	return bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+}
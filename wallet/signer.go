@@ -0,0 +1,84 @@
+package wallet
+
+import (
+	"fmt"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/wallet/types"
+)
+
+var _ types.Signer = (*walletSigner)(nil)
+
+// walletSigner signs with the key of an unlocked account of a wallet
+type walletSigner struct {
+	wallet   types.Wallet
+	address  common.Address
+	onDevice bool
+}
+
+// OpenSoftSigner unlocks the key of the address in the encrypted soft wallet
+func OpenSoftSigner(cfgPath string, address common.Address, password string) (types.Signer, error) {
+	wallet, err := OpenWallet(cfgPath, types.WalletTypeSoft, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := wallet.Unlock(address, password); err != nil {
+		return nil, err
+	}
+	return &walletSigner{wallet: wallet, address: address}, nil
+}
+
+// OpenLedgerSigner connects to the Ledger device and derives the account at the path, or at the
+// default path if nil. The private key never leaves the device, which displays the transactions
+// for the user to confirm before signing them.
+func OpenLedgerSigner(path types.DerivationPath) (types.Signer, error) {
+	wallet, err := OpenWallet("", types.WalletTypeCold, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := wallet.Unlock(common.Address{}, ""); err != nil {
+		return nil, err
+	}
+
+	var address common.Address
+	if path != nil {
+		address, err = wallet.Derive(path, true)
+		if err != nil {
+			wallet.Lock(common.Address{})
+			return nil, err
+		}
+	} else {
+		addresses, err := wallet.List()
+		if err != nil {
+			wallet.Lock(common.Address{})
+			return nil, err
+		}
+		if len(addresses) == 0 {
+			wallet.Lock(common.Address{})
+			return nil, fmt.Errorf("No address detected in the wallet")
+		}
+		address = addresses[0]
+	}
+	return &walletSigner{wallet: wallet, address: address, onDevice: true}, nil
+}
+
+// Address returns the address of the account
+func (s *walletSigner) Address() common.Address {
+	return s.address
+}
+
+// Sign signs the transaction bytes with the key of the account
+func (s *walletSigner) Sign(txrlp common.Bytes) (*crypto.Signature, error) {
+	return s.wallet.Sign(s.address, txrlp)
+}
+
+// OnDevice indicates whether the transactions are confirmed on a hardware wallet
+func (s *walletSigner) OnDevice() bool {
+	return s.onDevice
+}
+
+// Close locks the key, or closes the connection to the device
+func (s *walletSigner) Close() error {
+	return s.wallet.Lock(s.address)
+}
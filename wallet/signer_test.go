@@ -0,0 +1,43 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/wallet/types"
+)
+
+func TestSoftSigner(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	cfgPath, err := ioutil.TempDir("", "theta-signer-test")
+	require.Nil(err)
+	defer os.RemoveAll(cfgPath)
+
+	wallet, err := OpenWallet(cfgPath, types.WalletTypeSoft, true)
+	require.Nil(err)
+	address, err := wallet.NewKey("abcd")
+	require.Nil(err)
+
+	_, err = OpenSoftSigner(cfgPath, address, "wrong password")
+	assert.NotNil(err)
+
+	signer, err := OpenSoftSigner(cfgPath, address, "abcd")
+	require.Nil(err)
+	assert.Equal(address, signer.Address())
+	assert.False(signer.OnDevice())
+
+	tx := common.Bytes("tx sign bytes")
+	sig, err := signer.Sign(tx)
+	require.Nil(err)
+	assert.True(sig.Verify(tx, address))
+
+	require.Nil(signer.Close())
+	_, err = signer.Sign(tx)
+	assert.NotNil(err, "the key is locked")
+}
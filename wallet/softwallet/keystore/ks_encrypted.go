@@ -194,6 +194,55 @@ func (ks KeystoreEncrypted) getFilePath(address common.Address, addrFormat Addre
 // encryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
+	keyBytes := math.PaddedBigBytes(key.PrivateKey.D(), 32)
+	cryptoStruct, err := encryptData(keyBytes, auth, scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedKeyJSON := encryptedKeyJSON{
+		hex.EncodeToString(key.Address[:]),
+		cryptoStruct,
+		key.Id.String(),
+		version,
+	}
+	return json.Marshal(encryptedKeyJSON)
+}
+
+// decryptKey decrypts a key from a json blob, returning the private key itself.
+func decryptKey(keyjson []byte, auth string) (*Key, error) {
+	encryptedKeyJs := new(encryptedKeyJSON)
+	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
+		return nil, err
+	}
+
+	if encryptedKeyJs.Version != version {
+		return nil, fmt.Errorf("Version %v not supported", encryptedKeyJs.Version)
+	}
+
+	keyId := uuid.Parse(encryptedKeyJs.Id)
+
+	keyBytes, err := decryptData(encryptedKeyJs.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the "unsafe" convertor to support legacy private keys
+	// whose lengths are less than 32 bytes
+	privKey := crypto.PrivateKeyFromBytesUnsafe(keyBytes)
+
+	key := &Key{
+		Id:         keyId,
+		Address:    privKey.PublicKey().Address(),
+		PrivateKey: privKey,
+	}
+
+	return key, nil
+}
+
+// encryptData encrypts the data with AES-128-CTR, using the specified scrypt
+// parameters to derive the encryption key from the password.
+func encryptData(data []byte, auth string, scryptN, scryptP int) (cryptoJSON, error) {
 	authArray := []byte(auth)
 
 	salt := make([]byte, 32)
@@ -202,18 +251,17 @@ func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	}
 	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptR, scryptP, scryptDKLen)
 	if err != nil {
-		return nil, err
+		return cryptoJSON{}, err
 	}
 	encryptKey := derivedKey[:16]
-	keyBytes := math.PaddedBigBytes(key.PrivateKey.D(), 32)
 
 	iv := make([]byte, aes.BlockSize) // 16
 	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
 		panic("reading from crypto/rand failed: " + err.Error())
 	}
-	cipherText, err := aesCTRXOR(encryptKey, keyBytes, iv)
+	cipherText, err := aesCTRXOR(encryptKey, data, iv)
 	if err != nil {
-		return nil, err
+		return cryptoJSON{}, err
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
@@ -236,49 +284,31 @@ func encryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 		KDFParams:    scryptParamsJSON,
 		MAC:          hex.EncodeToString(mac),
 	}
-
-	encryptedKeyJSON := encryptedKeyJSON{
-		hex.EncodeToString(key.Address[:]),
-		cryptoStruct,
-		key.Id.String(),
-		version,
-	}
-	return json.Marshal(encryptedKeyJSON)
+	return cryptoStruct, nil
 }
 
-// decryptKey decrypts a key from a json blob, returning the private key itself.
-func decryptKey(keyjson []byte, auth string) (*Key, error) {
-	encryptedKeyJs := new(encryptedKeyJSON)
-	if err := json.Unmarshal(keyjson, encryptedKeyJs); err != nil {
-		return nil, err
+// decryptData checks the MAC and decrypts the data encrypted by encryptData.
+func decryptData(cryptoJSON cryptoJSON, auth string) ([]byte, error) {
+	if cryptoJSON.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("Cipher not supported: %v", cryptoJSON.Cipher)
 	}
 
-	if encryptedKeyJs.Version != version {
-		return nil, fmt.Errorf("Version %v not supported", encryptedKeyJs.Version)
-	}
-
-	if encryptedKeyJs.Crypto.Cipher != "aes-128-ctr" {
-		return nil, fmt.Errorf("Cipher not supported: %v", encryptedKeyJs.Crypto.Cipher)
-	}
-
-	keyId := uuid.Parse(encryptedKeyJs.Id)
-
-	mac, err := hex.DecodeString(encryptedKeyJs.Crypto.MAC)
+	mac, err := hex.DecodeString(cryptoJSON.MAC)
 	if err != nil {
 		return nil, err
 	}
 
-	iv, err := hex.DecodeString(encryptedKeyJs.Crypto.CipherParams.IV)
+	iv, err := hex.DecodeString(cryptoJSON.CipherParams.IV)
 	if err != nil {
 		return nil, err
 	}
 
-	cipherText, err := hex.DecodeString(encryptedKeyJs.Crypto.CipherText)
+	cipherText, err := hex.DecodeString(cryptoJSON.CipherText)
 	if err != nil {
 		return nil, err
 	}
 
-	derivedKey, err := getKDFKey(encryptedKeyJs.Crypto, auth)
+	derivedKey, err := getKDFKey(cryptoJSON, auth)
 	if err != nil {
 		return nil, err
 	}
@@ -288,22 +318,7 @@ func decryptKey(keyjson []byte, auth string) (*Key, error) {
 		return nil, ErrDecrypt
 	}
 
-	keyBytes, err := aesCTRXOR(derivedKey[:16], cipherText, iv)
-	if err != nil {
-		return nil, err
-	}
-
-	// Use the "unsafe" convertor to support legacy private keys
-	// whose lengths are less than 32 bytes
-	privKey := crypto.PrivateKeyFromBytesUnsafe(keyBytes)
-
-	key := &Key{
-		Id:         keyId,
-		Address:    privKey.PublicKey().Address(),
-		PrivateKey: privKey,
-	}
-
-	return key, nil
+	return aesCTRXOR(derivedKey[:16], cipherText, iv)
 }
 
 func getKDFKey(cryptoJSON cryptoJSON, auth string) ([]byte, error) {
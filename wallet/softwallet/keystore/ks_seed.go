@@ -0,0 +1,67 @@
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const seedFileName = "seed"
+
+// SeedStore stores the seed of the hierarchical deterministic wallet, encrypted
+// with scrypt the same way as the keys of the KeystoreEncrypted.
+type SeedStore struct {
+	filePath string
+	scryptN  int
+	scryptP  int
+}
+
+type encryptedSeedJSON struct {
+	Crypto  cryptoJSON `json:"crypto"`
+	Version int        `json:"version"`
+}
+
+func NewSeedStore(keysDirRoot string, scryptN, scryptP int) *SeedStore {
+	return &SeedStore{
+		filePath: path.Join(keysDirRoot, seedFileName),
+		scryptN:  scryptN,
+		scryptP:  scryptP,
+	}
+}
+
+// HasSeed indicates whether a seed has been stored
+func (ss *SeedStore) HasSeed() bool {
+	_, err := os.Stat(ss.filePath)
+	return err == nil
+}
+
+// GetSeed loads and decrypts the seed
+func (ss *SeedStore) GetSeed(auth string) ([]byte, error) {
+	seedjson, err := ioutil.ReadFile(ss.filePath)
+	if err != nil {
+		return nil, err
+	}
+	encryptedSeedJs := new(encryptedSeedJSON)
+	if err := json.Unmarshal(seedjson, encryptedSeedJs); err != nil {
+		return nil, err
+	}
+	if encryptedSeedJs.Version != version {
+		return nil, fmt.Errorf("Version %v not supported", encryptedSeedJs.Version)
+	}
+	return decryptData(encryptedSeedJs.Crypto, auth)
+}
+
+// StoreSeed encrypts and writes the seed, replacing the stored one if any
+func (ss *SeedStore) StoreSeed(seed []byte, auth string) error {
+	cryptoStruct, err := encryptData(seed, auth, ss.scryptN, ss.scryptP)
+	if err != nil {
+		return err
+	}
+	seedjson, err := json.Marshal(encryptedSeedJSON{cryptoStruct, version})
+	if err != nil {
+		return err
+	}
+	return writeKeyFile(ss.filePath, seedjson)
+}
@@ -0,0 +1,39 @@
+package keystore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSeedStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "theta-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ss := NewSeedStore(dir, veryLightScryptN, veryLightScryptP)
+	if ss.HasSeed() {
+		t.Fatal("seed should not exist yet")
+	}
+	seed := bytes.Repeat([]byte{0x5e}, 64)
+	if err := ss.StoreSeed(seed, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if !ss.HasSeed() {
+		t.Fatal("seed should exist")
+	}
+
+	stored, err := ss.GetSeed("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(seed, stored) {
+		t.Fatalf("seed mismatch: have %x, want %x", stored, seed)
+	}
+	if _, err := ss.GetSeed("bar"); err != ErrDecrypt {
+		t.Fatalf("wrong error for invalid password\ngot %q\nwant %q", err, ErrDecrypt)
+	}
+}
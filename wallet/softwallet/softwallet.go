@@ -6,6 +6,7 @@ import (
 
 	"github.com/thetatoken/theta/common"
 	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/wallet/hd"
 	ks "github.com/thetatoken/theta/wallet/softwallet/keystore"
 	"github.com/thetatoken/theta/wallet/types"
 )
@@ -26,6 +27,7 @@ const (
 type SoftWallet struct {
 	mu             *sync.RWMutex
 	keystore       ks.Keystore
+	seedStore      *ks.SeedStore                   // nil for the plain keystore
 	unlockedKeyMap map[common.Address]*UnlockedKey // Currently unlocked keys (decrypted private keys)
 }
 
@@ -36,9 +38,11 @@ type UnlockedKey struct {
 
 func NewSoftWallet(keysDirPath string, kstype KeystoreType) (*SoftWallet, error) {
 	var keystore ks.Keystore
+	var seedStore *ks.SeedStore
 	var err error
 	if kstype == KeystoreTypeEncrypted {
 		keystore, err = ks.NewKeystoreEncrypted(keysDirPath, ks.StandardScryptN, ks.StandardScryptP)
+		seedStore = ks.NewSeedStore(keysDirPath, ks.StandardScryptN, ks.StandardScryptP)
 	} else {
 		keystore, err = ks.NewKeystorePlain(keysDirPath)
 	}
@@ -49,6 +53,7 @@ func NewSoftWallet(keysDirPath string, kstype KeystoreType) (*SoftWallet, error)
 	wallet := &SoftWallet{
 		mu:             &sync.RWMutex{},
 		keystore:       keystore,
+		seedStore:      seedStore,
 		unlockedKeyMap: make(map[common.Address]*UnlockedKey),
 	}
 
@@ -174,11 +179,82 @@ func (w *SoftWallet) UpdatePassword(address common.Address, oldPassword, newPass
 	return err
 }
 
-// Derive is not supported for SoftWallet
+// Derive is not supported for SoftWallet, since the seed is encrypted. Use DeriveKey instead.
 func (w *SoftWallet) Derive(path types.DerivationPath, pin bool) (common.Address, error) {
 	return common.Address{}, fmt.Errorf("Not supported for software wallet")
 }
 
+// NewMnemonic generates a new BIP-39 mnemonic, stores its seed encrypted with the password, and
+// creates the key of the first account, at the default derivation path. The mnemonic is the
+// backup of all the keys derived from the seed.
+func (w *SoftWallet) NewMnemonic(password string) (mnemonic string, address common.Address, err error) {
+	mnemonic, err = hd.NewMnemonic()
+	if err != nil {
+		return "", common.Address{}, err
+	}
+	address, err = w.RecoverFromMnemonic(mnemonic, password)
+	return mnemonic, address, err
+}
+
+// RecoverFromMnemonic stores the seed of the BIP-39 mnemonic encrypted with the password, and
+// recovers the key of the first account, at the default derivation path. The other accounts are
+// recovered with DeriveKey.
+func (w *SoftWallet) RecoverFromMnemonic(mnemonic string, password string) (common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seedStore == nil {
+		return common.Address{}, fmt.Errorf("Mnemonics are only supported by the encrypted keystore")
+	}
+	if w.seedStore.HasSeed() {
+		return common.Address{}, fmt.Errorf("A seed is stored already, one wallet holds one seed only")
+	}
+	seed, err := hd.SeedFromMnemonic(mnemonic, "")
+	if err != nil {
+		return common.Address{}, err
+	}
+	if err := w.seedStore.StoreSeed(seed, password); err != nil {
+		return common.Address{}, err
+	}
+
+	return w.storeDerivedKey(seed, types.DefaultBaseDerivationPath, password)
+}
+
+// DeriveKey derives the key at the path from the stored seed, and stores it encrypted with the
+// password, which must be the password of the seed.
+func (w *SoftWallet) DeriveKey(path types.DerivationPath, password string) (common.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.seedStore == nil || !w.seedStore.HasSeed() {
+		return common.Address{}, fmt.Errorf("No seed is stored, please create or recover one from a mnemonic first")
+	}
+	seed, err := w.seedStore.GetSeed(password)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return w.storeDerivedKey(seed, path, password)
+}
+
+// storeDerivedKey stores the key at the path derived from the seed, which is considered unlocked
+func (w *SoftWallet) storeDerivedKey(seed []byte, path types.DerivationPath, password string) (common.Address, error) {
+	privKey, err := hd.DeriveFromSeed(seed, path)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	key := ks.NewKey(privKey)
+	if err := w.keystore.StoreKey(key, password); err != nil {
+		return common.Address{}, err
+	}
+	w.unlockedKeyMap[key.Address] = &UnlockedKey{
+		Key: key,
+	}
+
+	return key.Address, nil
+}
+
 // GetPublicKey returns the public key of the address if the address has been unlocked
 func (w *SoftWallet) GetPublicKey(address common.Address) (*crypto.PublicKey, error) {
 	w.mu.Lock()
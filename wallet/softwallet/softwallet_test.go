@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/wallet/types"
 )
 
 func TestPlainSoftWalletBasics(t *testing.T) {
@@ -26,6 +27,58 @@ func TestEncryptedSoftWalletMultipleKeys(t *testing.T) {
 	testSoftWalletMultipleKeys(t, KeystoreTypeEncrypted)
 }
 
+func TestSoftWalletMnemonic(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir := createTempDir()
+	defer os.RemoveAll(tmpdir)
+
+	wallet, err := NewSoftWallet(tmpdir, KeystoreTypeEncrypted)
+	assert.Nil(err)
+	_, err = wallet.DeriveKey(types.DefaultBaseDerivationPath, "abcd")
+	assert.NotNil(err, "no seed stored yet")
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	addr0, err := wallet.RecoverFromMnemonic(mnemonic, "abcd")
+	assert.Nil(err)
+	assert.Equal(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), addr0)
+	assert.True(wallet.IsUnlocked(addr0))
+	_, _, err = wallet.NewMnemonic("abcd")
+	assert.NotNil(err, "one seed per wallet")
+
+	path, err := types.ParseDerivationPath("m/44'/60'/0'/0/1")
+	assert.Nil(err)
+	_, err = wallet.DeriveKey(path, "wrong password")
+	assert.NotNil(err)
+	addr1, err := wallet.DeriveKey(path, "abcd")
+	assert.Nil(err)
+	assert.NotEqual(addr0, addr1)
+
+	// The derived keys are stored as the other keys
+	assert.Nil(wallet.Lock(addr1))
+	assert.Nil(wallet.Unlock(addr1, "abcd"))
+	addrs, err := wallet.List()
+	assert.Nil(err)
+	assert.Equal(sortAddresses([]common.Address{addr0, addr1}), sortAddresses(addrs))
+
+	// The same mnemonic recovers the same keys in another wallet
+	tmpdir2 := createTempDir()
+	defer os.RemoveAll(tmpdir2)
+	wallet2, err := NewSoftWallet(tmpdir2, KeystoreTypeEncrypted)
+	assert.Nil(err)
+	addr, err := wallet2.RecoverFromMnemonic(mnemonic, "xyz")
+	assert.Nil(err)
+	assert.Equal(addr0, addr)
+	addr, err = wallet2.DeriveKey(path, "xyz")
+	assert.Nil(err)
+	assert.Equal(addr1, addr)
+
+	plainWallet, err := NewSoftWallet(tmpdir2, KeystoreTypePlain)
+	assert.Nil(err)
+	_, _, err = plainWallet.NewMnemonic("abcd")
+	assert.NotNil(err, "not supported by the plain keystore")
+}
+
 // ---------------- Test Utilities ---------------- //
 
 func testSoftWalletBasics(t *testing.T, ksType KeystoreType) {
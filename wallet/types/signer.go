@@ -0,0 +1,14 @@
+package types
+
+import (
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/crypto"
+)
+
+// Signer signs the transactions of one account, regardless of the wallet holding its key
+type Signer interface {
+	Address() common.Address
+	Sign(txrlp common.Bytes) (*crypto.Signature, error)
+	OnDevice() bool // whether the transactions are reviewed and confirmed on a hardware wallet
+	Close() error
+}
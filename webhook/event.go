@@ -0,0 +1,340 @@
+package webhook
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+)
+
+const (
+	// EventTransaction is sent for each finalized transaction matching the filters of the webhook
+	EventTransaction = "transaction"
+	// EventBlock is sent for each finalized block
+	EventBlock = "block"
+)
+
+var txTypeNames = map[types.TxType]string{
+	types.TxCoinbase:              "coinbase",
+	types.TxSlash:                 "slash",
+	types.TxSend:                  "send",
+	types.TxReserveFund:           "reserve_fund",
+	types.TxReleaseFund:           "release_fund",
+	types.TxServicePayment:        "service_payment",
+	types.TxSplitRule:             "split_rule",
+	types.TxSmartContract:         "smart_contract",
+	types.TxDepositStake:          "deposit_stake",
+	types.TxWithdrawStake:         "withdraw_stake",
+	types.TxSetAccountOperator:    "set_account_operator",
+	types.TxServicePaymentDispute: "service_payment_dispute",
+	types.TxRegisterNodeAddress:   "register_node_address",
+	types.TxMultiSend:             "multi_send",
+	types.TxSlashReview:           "slash_review",
+	types.TxDataCommitment:        "data_commitment",
+	types.TxUpdateAccountSigners:  "update_account_signers",
+	types.TxMultiSigSend:          "multi_sig_send",
+}
+
+// parseTxType returns the tx type with the given name, see txTypeNames.
+func parseTxType(name string) (types.TxType, error) {
+	for txType, txTypeName := range txTypeNames {
+		if strings.EqualFold(txTypeName, name) {
+			return txType, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown transaction type %v", name)
+}
+
+// Notification is the JSON body posted to the webhooks.
+type Notification struct {
+	ID          string            `json:"id"` // unique ID of the event, for the receivers to detect duplicates
+	Event       string            `json:"event"`
+	ChainID     string            `json:"chain_id"`
+	BlockHash   common.Hash       `json:"block_hash"`
+	BlockHeight common.JSONUint64 `json:"block_height"`
+	Timestamp   *common.JSONBig   `json:"timestamp"`
+
+	// Block events
+	NumTxs int `json:"num_txs,omitempty"`
+
+	// Transaction events
+	TxHash      *common.Hash     `json:"tx_hash,omitempty"`
+	TxType      string           `json:"tx_type,omitempty"`
+	Addresses   []common.Address `json:"addresses,omitempty"` // addresses of the transaction matching the filter
+	Amount      *types.Coins     `json:"amount,omitempty"`    // largest amount sent or received by the addresses
+	Transaction types.Tx         `json:"transaction,omitempty"`
+}
+
+// transfer is an amount sent or received by an address in a transaction
+type transfer struct {
+	address common.Address
+	coins   types.Coins
+	sent    bool          // whether the address is an input of the transaction
+	input   types.TxInput // the input, if sent
+}
+
+// txTransfers returns the addresses involved in the transaction, and the amounts they send or receive.
+func txTransfers(tx types.Tx) []transfer {
+	fromInput := func(input types.TxInput) transfer {
+		return transfer{address: input.Address, coins: input.Coins, sent: true, input: input}
+	}
+	fromMultiSigInput := func(input types.MultiSigInput) transfer {
+		return fromInput(types.TxInput{Address: input.Address, Coins: input.Coins, Sequence: input.Sequence})
+	}
+	fromOutput := func(output types.TxOutput) transfer {
+		return transfer{address: output.Address, coins: output.Coins}
+	}
+
+	transfers := []transfer{}
+	switch tx := tx.(type) {
+	case *types.CoinbaseTx:
+		transfers = append(transfers, fromInput(tx.Proposer))
+		for _, output := range tx.Outputs {
+			transfers = append(transfers, fromOutput(output))
+		}
+	case *types.SlashTx:
+		transfers = append(transfers, fromInput(tx.Proposer), transfer{address: tx.SlashedAddress})
+	case *types.SendTx:
+		for _, input := range tx.Inputs {
+			transfers = append(transfers, fromInput(input))
+		}
+		for _, output := range tx.Outputs {
+			transfers = append(transfers, fromOutput(output))
+		}
+	case *types.MultiSendTx:
+		for _, input := range tx.Inputs {
+			transfers = append(transfers, fromInput(input))
+		}
+		for _, output := range tx.TxOutputs() {
+			transfers = append(transfers, fromOutput(output))
+		}
+	case *types.ReserveFundTx:
+		transfers = append(transfers, fromInput(tx.Source))
+	case *types.ReleaseFundTx:
+		transfers = append(transfers, fromInput(tx.Source))
+	case *types.ServicePaymentTx:
+		// The target receives the amount signed by the source
+		transfers = append(transfers, fromInput(tx.Source), transfer{address: tx.Target.Address, coins: tx.Source.Coins})
+	case *types.SplitRuleTx:
+		transfers = append(transfers, fromInput(tx.Initiator))
+	case *types.SmartContractTx:
+		transfers = append(transfers, fromInput(tx.From), fromOutput(tx.To))
+	case *types.DepositStakeTx:
+		transfers = append(transfers, fromInput(tx.Source), fromOutput(tx.Holder))
+	case *types.WithdrawStakeTx:
+		transfers = append(transfers, fromInput(tx.Source), fromOutput(tx.Holder))
+	case *types.SetAccountOperatorTx:
+		transfers = append(transfers, fromInput(tx.Account), transfer{address: tx.Operator})
+	case *types.ServicePaymentDisputeTx:
+		transfers = append(transfers, fromInput(tx.Source), transfer{address: tx.Proof.Target.Address})
+	case *types.RegisterNodeAddressTx:
+		transfers = append(transfers, fromInput(tx.Validator))
+	case *types.SlashReviewTx:
+		for _, input := range tx.Inputs {
+			transfers = append(transfers, fromInput(input))
+		}
+	case *types.DataCommitmentTx:
+		transfers = append(transfers, fromInput(tx.Owner))
+	case *types.UpdateAccountSignersTx:
+		transfers = append(transfers, fromMultiSigInput(tx.Account))
+		for _, signer := range tx.Signers {
+			transfers = append(transfers, transfer{address: signer})
+		}
+	case *types.MultiSigSendTx:
+		transfers = append(transfers, fromMultiSigInput(tx.Input))
+		for _, output := range tx.Outputs {
+			transfers = append(transfers, fromOutput(output))
+		}
+	}
+	return transfers
+}
+
+// TxSender returns the first input of the transaction, whose address is the sender of the transaction
+// and whose sequence is its nonce. It returns false for the transactions without inputs.
+func TxSender(tx types.Tx) (types.TxInput, bool) {
+	for _, t := range txTransfers(tx) {
+		if t.sent {
+			return t.input, true
+		}
+	}
+	return types.TxInput{}, false
+}
+
+// TxAddresses returns the addresses involved in the transaction, in order of appearance and
+// without duplicates.
+func TxAddresses(tx types.Tx) []common.Address {
+	addresses := []common.Address{}
+	seen := make(map[common.Address]bool)
+	for _, t := range txTransfers(tx) {
+		if !seen[t.address] {
+			seen[t.address] = true
+			addresses = append(addresses, t.address)
+		}
+	}
+	return addresses
+}
+
+// TxParticipants returns the addresses sending, i.e. the inputs of the transaction, and the
+// addresses receiving or otherwise affected by the transaction, each without duplicates.
+func TxParticipants(tx types.Tx) (senders []common.Address, recipients []common.Address) {
+	senders, recipients = []common.Address{}, []common.Address{}
+	seenSenders, seenRecipients := make(map[common.Address]bool), make(map[common.Address]bool)
+	for _, t := range txTransfers(tx) {
+		if t.sent && !seenSenders[t.address] {
+			seenSenders[t.address] = true
+			senders = append(senders, t.address)
+		} else if !t.sent && !seenRecipients[t.address] {
+			seenRecipients[t.address] = true
+			recipients = append(recipients, t.address)
+		}
+	}
+	return senders, recipients
+}
+
+// filter selects the events sent to a webhook. Empty criteria match everything.
+type filter struct {
+	events    map[string]bool
+	addresses map[common.Address]bool
+	txTypes   map[types.TxType]bool
+	minAmount types.Coins
+}
+
+func newFilter(config HookConfig) (*filter, error) {
+	f := &filter{
+		events:    make(map[string]bool),
+		addresses: make(map[common.Address]bool),
+		txTypes:   make(map[types.TxType]bool),
+		minAmount: types.NewCoins(0, 0),
+	}
+	events := config.Events
+	if len(events) == 0 {
+		events = []string{EventTransaction}
+	}
+	for _, event := range events {
+		if event != EventTransaction && event != EventBlock {
+			return nil, fmt.Errorf("Unknown event %v, expected %v or %v", event, EventTransaction, EventBlock)
+		}
+		f.events[event] = true
+	}
+	for _, address := range config.Addresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("Invalid address %v", address)
+		}
+		f.addresses[common.HexToAddress(address)] = true
+	}
+	for _, name := range config.TxTypes {
+		txType, err := parseTxType(name)
+		if err != nil {
+			return nil, err
+		}
+		f.txTypes[txType] = true
+	}
+	if len(config.MinTheta) > 0 {
+		amount, ok := types.ParseCoinAmount(config.MinTheta)
+		if !ok {
+			return nil, fmt.Errorf("Invalid Theta amount %v", config.MinTheta)
+		}
+		f.minAmount.ThetaWei = amount
+	}
+	if len(config.MinTFuel) > 0 {
+		amount, ok := types.ParseCoinAmount(config.MinTFuel)
+		if !ok {
+			return nil, fmt.Errorf("Invalid TFuel amount %v", config.MinTFuel)
+		}
+		f.minAmount.TFuelWei = amount
+	}
+	return f, nil
+}
+
+// matchTx returns the notification of the transaction, or nil if the transaction does not pass the filter.
+func (f *filter) matchTx(block *core.Block, rawTx common.Bytes) *Notification {
+	if !f.events[EventTransaction] {
+		return nil
+	}
+	tx, err := types.TxFromBytes(rawTx)
+	if err != nil {
+		return nil
+	}
+	txType, err := types.GetTxType(tx)
+	if err != nil {
+		return nil
+	}
+	if len(f.txTypes) > 0 && !f.txTypes[txType] {
+		return nil
+	}
+
+	addresses := []common.Address{}
+	seen := make(map[common.Address]bool)
+	amount := types.NewCoins(0, 0)
+	for _, t := range txTransfers(tx) {
+		if len(f.addresses) > 0 && !f.addresses[t.address] {
+			continue
+		}
+		if !seen[t.address] {
+			seen[t.address] = true
+			addresses = append(addresses, t.address)
+		}
+		coins := t.coins.NoNil()
+		if coins.ThetaWei.Cmp(amount.ThetaWei) > 0 {
+			amount.ThetaWei = coins.ThetaWei
+		}
+		if coins.TFuelWei.Cmp(amount.TFuelWei) > 0 {
+			amount.TFuelWei = coins.TFuelWei
+		}
+	}
+	if len(f.addresses) > 0 && len(addresses) == 0 {
+		return nil
+	}
+	if !f.matchAmount(amount) {
+		return nil
+	}
+
+	txHash := crypto.Keccak256Hash(rawTx)
+	notification := newNotification(EventTransaction, txHash.Hex(), block)
+	notification.TxHash = &txHash
+	notification.TxType = txTypeNames[txType]
+	notification.Addresses = addresses
+	notification.Amount = &amount
+	notification.Transaction = tx
+	return notification
+}
+
+// matchAmount returns whether the amount reaches the min Theta or the min TFuel amount of the filter.
+func (f *filter) matchAmount(amount types.Coins) bool {
+	minTheta := f.minAmount.ThetaWei.Sign() > 0
+	minTFuel := f.minAmount.TFuelWei.Sign() > 0
+	if !minTheta && !minTFuel {
+		return true
+	}
+	return (minTheta && amount.ThetaWei.Cmp(f.minAmount.ThetaWei) >= 0) ||
+		(minTFuel && amount.TFuelWei.Cmp(f.minAmount.TFuelWei) >= 0)
+}
+
+// matchBlock returns the notification of the block, or nil if the webhook does not subscribe to blocks.
+func (f *filter) matchBlock(block *core.Block) *Notification {
+	if !f.events[EventBlock] {
+		return nil
+	}
+	notification := newNotification(EventBlock, block.Hash().Hex(), block)
+	notification.NumTxs = len(block.Txs)
+	return notification
+}
+
+func newNotification(event string, id string, block *core.Block) *Notification {
+	timestamp := block.Timestamp
+	if timestamp == nil {
+		timestamp = big.NewInt(0)
+	}
+	return &Notification{
+		ID:          id,
+		Event:       event,
+		ChainID:     block.ChainID,
+		BlockHash:   block.Hash(),
+		BlockHeight: common.JSONUint64(block.Height),
+		Timestamp:   (*common.JSONBig)(timestamp),
+	}
+}
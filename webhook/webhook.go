@@ -0,0 +1,353 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/store"
+)
+
+var logger *log.Entry = log.WithFields(log.Fields{"prefix": "webhook"})
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the body, keyed with the secret of the webhook
+	SignatureHeader = "X-Theta-Signature"
+	// EventHeader carries the event type of the notification
+	EventHeader = "X-Theta-Event"
+)
+
+// Interval at which the newly finalized blocks are checked
+const pollInterval = 1 * time.Second
+
+// Max number of notifications waiting to be delivered to a webhook. Once the queue is full, the
+// finalized blocks are processed as the notifications are delivered.
+const queueSize = 4096
+
+// Max interval between two delivery attempts
+const maxRetryInterval = 10 * time.Minute
+
+// Key of the height of the last finalized block whose notifications are delivered to all the
+// webhooks, so that the notifications resume from there after a restart
+var cursorKey = common.Bytes("webhook/cursor")
+
+// HookConfig is the configuration of a webhook, see common.CfgWebhookHooks.
+type HookConfig struct {
+	URL       string   `mapstructure:"url"`
+	Secret    string   `mapstructure:"secret"`
+	Events    []string `mapstructure:"events"`
+	Addresses []string `mapstructure:"addresses"`
+	TxTypes   []string `mapstructure:"txTypes"`
+	MinTheta  string   `mapstructure:"minTheta"`
+	MinTFuel  string   `mapstructure:"minTFuel"`
+}
+
+type hook struct {
+	config HookConfig
+	filter *filter
+	queue  chan *delivery
+
+	deliveredHeight uint64 // all the notifications up to this height are delivered
+	gaveUp          bool   // a notification was not delivered, the cursor stays before its block
+}
+
+func newHook(config HookConfig, filter *filter) *hook {
+	return &hook{
+		config: config,
+		filter: filter,
+		queue:  make(chan *delivery, queueSize),
+	}
+}
+
+// delivery is an item of the queue of a webhook. The notifications of a block are followed by a
+// delivery without notification, marking the block as delivered.
+type delivery struct {
+	notification *Notification
+	height       uint64
+}
+
+// Manager posts the notifications of the finalized blocks and transactions to the webhooks whose
+// filters they match. Each webhook receives its notifications in order, a failed delivery is
+// retried with exponential backoff before moving on to the next notification.
+type Manager struct {
+	chain    *blockchain.Chain
+	finality core.FinalityProvider
+	store    store.Store
+
+	hooks         []*hook
+	client        *http.Client
+	maxRetries    int
+	retryInterval time.Duration
+
+	lastHeight  uint64 // height of the last finalized block queued
+	cursorMutex *sync.Mutex
+	cursor      uint64 // height of the last finalized block delivered to all the webhooks
+
+	// Life cycle
+	wg     *sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager creates a new instance of Manager with the webhooks of common.CfgWebhookHooks.
+func NewManager(chain *blockchain.Chain, finality core.FinalityProvider, store store.Store) (*Manager, error) {
+	configs := []HookConfig{}
+	if err := viper.UnmarshalKey(common.CfgWebhookHooks, &configs); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", common.CfgWebhookHooks, err)
+	}
+	m := &Manager{
+		chain:         chain,
+		finality:      finality,
+		store:         store,
+		hooks:         []*hook{},
+		client:        &http.Client{Timeout: time.Duration(viper.GetInt(common.CfgWebhookTimeout)) * time.Second},
+		maxRetries:    viper.GetInt(common.CfgWebhookMaxRetries),
+		retryInterval: time.Duration(viper.GetInt(common.CfgWebhookRetryInterval)) * time.Second,
+		cursorMutex:   &sync.Mutex{},
+		wg:            &sync.WaitGroup{},
+	}
+	for _, config := range configs {
+		if len(config.URL) == 0 {
+			return nil, fmt.Errorf("Webhook URL is missing")
+		}
+		filter, err := newFilter(config)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid filter for webhook %v: %v", config.URL, err)
+		}
+		m.hooks = append(m.hooks, newHook(config, filter))
+	}
+	return m, nil
+}
+
+// NumHooks returns the number of webhooks configured.
+func (m *Manager) NumHooks() int {
+	return len(m.hooks)
+}
+
+// Start creates the main goroutine, and one goroutine per webhook delivering its notifications.
+func (m *Manager) Start(ctx context.Context) {
+	c, cancel := context.WithCancel(ctx)
+	m.ctx = c
+	m.cancel = cancel
+
+	if err := m.store.Get(cursorKey, &m.lastHeight); err != nil {
+		m.lastHeight = m.finality.GetLastFinalizedBlock().Height
+	}
+	m.cursor = m.lastHeight
+
+	for _, h := range m.hooks {
+		h.deliveredHeight = m.lastHeight
+		m.wg.Add(1)
+		go m.deliverLoop(h)
+	}
+
+	m.wg.Add(1)
+	go m.mainLoop()
+}
+
+// Stop notifies all goroutines to stop without blocking.
+func (m *Manager) Stop() {
+	m.cancel()
+}
+
+// Wait blocks until all goroutines stop.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+func (m *Manager) mainLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.processFinalizedBlocks()
+		}
+	}
+}
+
+// processFinalizedBlocks queues the notifications of the blocks finalized since the last call. The
+// finalized ancestors of the last finalized block are looked up by height, since the finality
+// provider does not publish the blocks finalized indirectly. It blocks while the queue of a webhook
+// is full, and returns once the manager is stopped.
+func (m *Manager) processFinalizedBlocks() {
+	lastFinalized := m.finality.GetLastFinalizedBlock()
+	for height := m.lastHeight + 1; height <= lastFinalized.Height; height++ {
+		block := m.findFinalizedBlock(height)
+		if block == nil {
+			logger.WithFields(log.Fields{"height": height}).Warn("Finalized block not found")
+			continue
+		}
+		if !m.processBlock(block.Block) {
+			return
+		}
+		m.lastHeight = height
+	}
+}
+
+func (m *Manager) findFinalizedBlock(height uint64) *core.ExtendedBlock {
+	for _, block := range m.chain.FindBlocksByHeight(height) {
+		if block.Status.IsFinalized() {
+			return block
+		}
+	}
+	return nil
+}
+
+// processBlock queues the notifications of the block, and returns false if the manager is stopped
+// before they are all queued.
+func (m *Manager) processBlock(block *core.Block) bool {
+	for _, h := range m.hooks {
+		if notification := h.filter.matchBlock(block); notification != nil {
+			if !m.enqueue(h, &delivery{notification: notification, height: block.Height}) {
+				return false
+			}
+		}
+		for _, rawTx := range block.Txs {
+			if notification := h.filter.matchTx(block, rawTx); notification != nil {
+				if !m.enqueue(h, &delivery{notification: notification, height: block.Height}) {
+					return false
+				}
+			}
+		}
+		if !m.enqueue(h, &delivery{height: block.Height}) {
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue waits for room in the queue of the webhook, rather than dropping the notification.
+func (m *Manager) enqueue(h *hook, d *delivery) bool {
+	select {
+	case h.queue <- d:
+		return true
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+func (m *Manager) deliverLoop(h *hook) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case d := <-h.queue:
+			if d.notification == nil {
+				if !h.gaveUp {
+					m.markDelivered(h, d.height)
+				}
+				continue
+			}
+			if !m.deliver(h, d.notification) {
+				if m.ctx.Err() != nil {
+					return
+				}
+				h.gaveUp = true
+			}
+		}
+	}
+}
+
+// markDelivered records that the notifications of the webhook are delivered up to the height, and
+// saves the cursor once they are delivered to all the webhooks. The notifications queued but not
+// delivered yet are sent again after a restart.
+func (m *Manager) markDelivered(h *hook, height uint64) {
+	m.cursorMutex.Lock()
+	defer m.cursorMutex.Unlock()
+
+	h.deliveredHeight = height
+	cursor := height
+	for _, other := range m.hooks {
+		if other.deliveredHeight < cursor {
+			cursor = other.deliveredHeight
+		}
+	}
+	if cursor <= m.cursor {
+		return
+	}
+	m.cursor = cursor
+	if err := m.store.Put(cursorKey, m.cursor); err != nil {
+		logger.WithFields(log.Fields{"error": err}).Error("Failed to save webhook cursor")
+	}
+}
+
+// deliver posts the notification, retrying with exponential backoff until it succeeds or
+// maxRetries is reached. Returns whether the notification is delivered. The webhook keeps receiving
+// the next notifications after giving up on one, but the cursor no longer moves past it, so that it
+// is sent again after a restart.
+func (m *Manager) deliver(h *hook, notification *Notification) bool {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		logger.WithFields(log.Fields{"error": err, "id": notification.ID}).Error("Failed to encode notification")
+		return false
+	}
+
+	interval := m.retryInterval
+	for attempt := 0; ; attempt++ {
+		err = m.post(h, notification.Event, body)
+		if err == nil {
+			return true
+		}
+		if attempt >= m.maxRetries {
+			logger.WithFields(log.Fields{"error": err, "url": h.config.URL, "id": notification.ID}).Error("Failed to deliver webhook notification, giving up")
+			return false
+		}
+		logger.WithFields(log.Fields{"error": err, "url": h.config.URL, "id": notification.ID, "retryIn": interval}).Warn("Failed to deliver webhook notification")
+		select {
+		case <-m.ctx.Done():
+			return false
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+func (m *Manager) post(h *hook, event string, body []byte) error {
+	req, err := http.NewRequest("POST", h.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if len(h.config.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(h.config.Secret, body))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook returned status %v", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of the body, as set in the SignatureHeader of the notifications. The
+// receivers verify the notifications by computing the signature with the shared secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,261 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thetatoken/theta/blockchain"
+	"github.com/thetatoken/theta/common"
+	"github.com/thetatoken/theta/core"
+	"github.com/thetatoken/theta/crypto"
+	"github.com/thetatoken/theta/ledger/types"
+	"github.com/thetatoken/theta/store"
+	"github.com/thetatoken/theta/store/database/backend"
+	"github.com/thetatoken/theta/store/kvstore"
+)
+
+type mockConsensus struct {
+	core.FinalityProvider
+	chain *blockchain.Chain
+	mu    *sync.Mutex
+	last  *core.ExtendedBlock
+}
+
+func (c *mockConsensus) GetLastFinalizedBlock() *core.ExtendedBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+func (c *mockConsensus) finalize(block *core.Block) {
+	c.chain.FinalizePreviousBlocks(block.Hash())
+	eb, _ := c.chain.FindBlock(block.Hash())
+	c.mu.Lock()
+	c.last = eb
+	c.mu.Unlock()
+}
+
+func newTestSendTx(from, to common.Address, tfuel int64) common.Bytes {
+	tx := &types.SendTx{
+		Fee:     types.NewCoins(0, 1000000000000),
+		Inputs:  []types.TxInput{{Address: from, Coins: types.NewCoins(0, tfuel+1000000000000)}},
+		Outputs: []types.TxOutput{{Address: to, Coins: types.NewCoins(0, tfuel)}},
+	}
+	raw, _ := types.TxToBytes(tx)
+	return raw
+}
+
+func TestFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0xc15E2D5e8e5B0b2cB4a0BA0E5bB4eAE49a6f2E3E")
+	carol := common.HexToAddress("0x9F1233798E905E173560071255140b4A8aBd3Ec6")
+	block := core.CreateTestBlock("filter_b1", "")
+	block.Height = 10
+	tx := newTestSendTx(alice, bob, 5000)
+
+	f, err := newFilter(HookConfig{Addresses: []string{bob.Hex()}, TxTypes: []string{"send"}, MinTFuel: "1000wei"})
+	assert.Nil(err)
+	notification := f.matchTx(block, tx)
+	assert.NotNil(notification)
+	assert.Equal(EventTransaction, notification.Event)
+	assert.Equal("send", notification.TxType)
+	assert.Equal([]common.Address{bob}, notification.Addresses)
+	assert.Equal(int64(5000), notification.Amount.TFuelWei.Int64())
+	assert.Equal(crypto.Keccak256Hash(tx), *notification.TxHash)
+	assert.Nil(f.matchBlock(block))
+
+	// Below the min amount
+	assert.Nil(f.matchTx(block, newTestSendTx(alice, bob, 999)))
+	// Other addresses
+	assert.Nil(f.matchTx(block, newTestSendTx(alice, carol, 5000)))
+
+	f, err = newFilter(HookConfig{Events: []string{EventBlock}})
+	assert.Nil(err)
+	assert.Nil(f.matchTx(block, tx))
+	assert.Equal(uint64(10), uint64(f.matchBlock(block).BlockHeight))
+
+	_, err = newFilter(HookConfig{TxTypes: []string{"unknown"}})
+	assert.NotNil(err)
+	_, err = newFilter(HookConfig{Addresses: []string{"0x1234"}})
+	assert.NotNil(err)
+	_, err = newFilter(HookConfig{Events: []string{"payment"}})
+	assert.NotNil(err)
+}
+
+func TestManager(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0xc15E2D5e8e5B0b2cB4a0BA0E5bB4eAE49a6f2E3E")
+
+	mu := &sync.Mutex{}
+	// The transaction is an interface, only decode the other fields
+	type receivedNotification struct {
+		BlockHash common.Hash  `json:"block_hash"`
+		Amount    *types.Coins `json:"amount"`
+	}
+	received := []receivedNotification{}
+	numRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		numRequests++
+		// The first delivery fails and is retried
+		if numRequests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(Sign("secret", body), req.Header.Get(SignatureHeader))
+		notification := receivedNotification{}
+		assert.Nil(json.Unmarshal(body, &notification))
+		received = append(received, notification)
+	}))
+	defer server.Close()
+
+	store := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("hook_root", "")
+	chain := blockchain.NewChain("testchain", store, root)
+	rootBlock, _ := chain.FindBlock(root.Hash())
+	consensus := &mockConsensus{chain: chain, mu: &sync.Mutex{}, last: rootBlock}
+
+	m, err := NewManager(chain, consensus, store)
+	require.Nil(err)
+	filter, err := newFilter(HookConfig{Addresses: []string{bob.Hex()}})
+	require.Nil(err)
+	m.hooks = append(m.hooks, newHook(HookConfig{URL: server.URL, Secret: "secret"}, filter))
+	m.retryInterval = 10 * time.Millisecond
+	m.Start(context.Background())
+	defer m.Stop()
+
+	// Blocks finalized indirectly are notified too
+	b1 := core.CreateTestBlock("hook_b1", "hook_root")
+	b1.AddTxs([]common.Bytes{newTestSendTx(alice, bob, 100)})
+	b2 := core.CreateTestBlock("hook_b2", "hook_b1")
+	b2.AddTxs([]common.Bytes{newTestSendTx(bob, alice, 200), newTestSendTx(alice, alice, 300)})
+	chain.AddBlock(b1)
+	chain.AddBlock(b2)
+	consensus.finalize(b2)
+
+	for i := 0; i < 200; i++ {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(2, len(received))
+	assert.Equal(3, numRequests)
+	assert.Equal(b1.Hash(), received[0].BlockHash)
+	assert.Equal(int64(100), received[0].Amount.TFuelWei.Int64())
+	assert.Equal(b2.Hash(), received[1].BlockHash)
+	// Bob sends the amount plus the fee
+	assert.Equal(int64(200+1000000000000), received[1].Amount.TFuelWei.Int64())
+
+	// The cursor is persisted once the notifications are delivered
+	assert.Equal(b2.Height, waitForCursor(store, b2.Height))
+}
+
+func waitForCursor(db store.Store, height uint64) uint64 {
+	var cursor uint64
+	for i := 0; i < 100; i++ {
+		if err := db.Get(cursorKey, &cursor); err == nil && cursor >= height {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return cursor
+}
+
+func TestManagerCursorAndBackpressure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	alice := common.HexToAddress("0x2E833968E5bB786Ae419c4d13189fB081Cc43bab")
+	bob := common.HexToAddress("0xc15E2D5e8e5B0b2cB4a0BA0E5bB4eAE49a6f2E3E")
+
+	mu := &sync.Mutex{}
+	numDelivered := 0
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		numDelivered++
+	}))
+	defer server.Close()
+
+	db := kvstore.NewKVStore(backend.NewMemDatabase())
+	root := core.CreateTestBlock("cursor_root", "")
+	chain := blockchain.NewChain("testchain", db, root)
+	rootBlock, _ := chain.FindBlock(root.Hash())
+	consensus := &mockConsensus{chain: chain, mu: &sync.Mutex{}, last: rootBlock}
+
+	m, err := NewManager(chain, consensus, db)
+	require.Nil(err)
+	filter, err := newFilter(HookConfig{Addresses: []string{bob.Hex()}})
+	require.Nil(err)
+	h := newHook(HookConfig{URL: server.URL}, filter)
+	// A single slot, the blocks are processed as the notifications are delivered
+	h.queue = make(chan *delivery, 1)
+	m.hooks = append(m.hooks, h)
+	m.maxRetries = 0
+	m.retryInterval = 10 * time.Millisecond
+	m.Start(context.Background())
+	defer m.Stop()
+
+	// The notification of b1 is given up, the cursor stays before b1
+	b1 := core.CreateTestBlock("cursor_b1", "cursor_root")
+	b1.AddTxs([]common.Bytes{newTestSendTx(alice, bob, 100)})
+	chain.AddBlock(b1)
+	consensus.finalize(b1)
+	time.Sleep(2 * pollInterval)
+	var cursor uint64
+	assert.NotNil(db.Get(cursorKey, &cursor))
+
+	// None of the notifications is dropped while the queue is full
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	parent := "cursor_b1"
+	numTxs := 5
+	for i := 0; i < numTxs; i++ {
+		block := core.CreateTestBlock(fmt.Sprintf("cursor_b%v", i+2), parent)
+		block.AddTxs([]common.Bytes{newTestSendTx(alice, bob, 100), newTestSendTx(alice, bob, 200)})
+		chain.AddBlock(block)
+		consensus.finalize(block)
+		parent = fmt.Sprintf("cursor_b%v", i+2)
+	}
+	for i := 0; i < 200; i++ {
+		mu.Lock()
+		n := numDelivered
+		mu.Unlock()
+		if n >= 2*numTxs {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	mu.Lock()
+	assert.Equal(2*numTxs, numDelivered)
+	mu.Unlock()
+	assert.NotNil(db.Get(cursorKey, &cursor))
+}